@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
+	"time"
 
 	"relay/internal/handler"
 	"relay/internal/middleware"
@@ -54,22 +59,136 @@ func main() {
 		log.Fatal("Failed to initialize file storage:", err)
 	}
 
+	// Workspace keys sealing secret variables and certificates; passphrase
+	// keys are unlocked through the API after every restart
+	keyRing := service.NewKeyRing(db, queries, fileStorage)
+	fileStorage.SetKeyRing(keyRing)
+
 	variableResolver := service.NewVariableResolver(queries)
+	variableResolver.SetKeyRing(keyRing)
 	requestExecutor := service.NewRequestExecutor(queries, variableResolver, fileStorage)
+
+	// Collection signing hooks: executables in SIGNING_HOOK_DIR (disabled when unset)
+	signingHooks := service.NewSigningHooks(os.Getenv("SIGNING_HOOK_DIR"))
+	requestExecutor.SetSigningHooks(signingHooks)
+
 	flowRunner := service.NewFlowRunner(queries, requestExecutor, variableResolver)
 
 	wsRelay := service.NewWebSocketRelay(queries, variableResolver)
+	wsRelay.SetFileStorage(fileStorage)
+	driftChecker := service.NewContractDriftChecker(queries, requestExecutor)
+
+	// Collection setup/teardown flows around drift checks and scheduled monitor checks
+	collectionRunHooks := service.NewCollectionRunHooks(queries, flowRunner)
+	driftChecker.SetRunHooks(collectionRunHooks)
+
+	// Register with other instances sharing the database; background jobs
+	// below run only on the instance holding their lease
+	instance := service.NewInstance(queries)
+	if err := instance.Start(context.Background()); err != nil {
+		log.Fatal("Failed to register instance:", err)
+	}
+
+	// Persistent background jobs (webhook deliveries, file cleanup and the
+	// scheduled work queued below); workers start once every type is registered
+	jobQueue := service.NewJobQueue(queries)
+	jobQueue.SetInstance(instance)
+	jobQueue.Register(service.JobTypeFileCleanup, service.FileCleanupJob(db, queries, fileStorage))
+
+	// Email notifications (SMTP_* env vars); weekly digests run in the background
+	emailNotifier := service.NewEmailNotifier(queries, service.SMTPConfigFromEnv())
+	emailNotifier.SetInstance(instance)
+	emailNotifier.Start(context.Background())
+
+	// Background uptime checks for requests marked as monitors
+	monitorRunner := service.NewMonitorRunner(queries, requestExecutor, emailNotifier)
+	monitorRunner.SetInstance(instance)
+	monitorRunner.SetRunHooks(collectionRunHooks)
+	monitorRunner.SetJobQueue(jobQueue)
+	monitorRunner.Start(context.Background())
+
+	// Drop request history older than 30 days and run timelines older than a
+	// week; flagged entries are kept. With ARCHIVE_DIR or ARCHIVE_S3_* set
+	// they are archived first.
+	historyRetention := service.NewHistoryRetention(queries)
+	historyRetention.SetInstance(instance)
+	archiveStore, err := service.ArchiveStoreFromEnv()
+	if err != nil {
+		log.Fatal("Failed to initialize archive store:", err)
+	}
+	var archiver *service.Archiver
+	if archiveStore != nil {
+		archiver = service.NewArchiver(queries, archiveStore)
+		historyRetention.SetArchiver(archiver)
+	}
+	historyRetention.SetJobQueue(jobQueue)
+	historyRetention.Start(context.Background())
+
+	// Full-text index over history response bodies for GET /api/history/search
+	historySearchIndexer := service.NewHistorySearchIndexer(queries)
+	historySearchIndexer.SetInstance(instance)
+	historySearchIndexer.Start(context.Background())
+
+	// Scheduled flow runs that write their outputs into an environment (key rotation)
+	environmentRotator := service.NewEnvironmentRotator(queries, flowRunner)
+	environmentRotator.SetInstance(instance)
+	environmentRotator.SetJobQueue(jobQueue)
+	environmentRotator.Start(context.Background())
+
+	// Login requests run on an interval to keep tokens fresh in environments
+	tokenRefresher := service.NewTokenRefresher(queries, requestExecutor)
+	tokenRefresher.SetInstance(instance)
+	tokenRefresher.SetJobQueue(jobQueue)
+	tokenRefresher.Start(context.Background())
+
+	// Scheduled contract drift checks of collections, alerting via webhook
+	driftChecker.SetJobQueue(jobQueue)
+	driftMonitorRunner := service.NewDriftMonitorRunner(queries, driftChecker)
+	driftMonitorRunner.SetInstance(instance)
+	driftMonitorRunner.SetJobQueue(jobQueue)
+	driftMonitorRunner.Start(context.Background())
+
+	jobQueue.Start(context.Background(), 4)
 
 	// Initialize handlers
 	workspaceHandler := handler.NewWorkspaceHandler(queries)
+	workspaceHandler.SetKeyRing(keyRing)
 	collectionHandler := handler.NewCollectionHandler(queries, db)
+	collectionHandler.SetKeyRing(keyRing)
 	requestHandler := handler.NewRequestHandler(queries, requestExecutor, flowRunner)
+	requestMergeHandler := handler.NewRequestMergeHandler(db, queries)
 	environmentHandler := handler.NewEnvironmentHandler(queries)
 	proxyHandler := handler.NewProxyHandler(queries)
 	flowHandler := handler.NewFlowHandler(queries, flowRunner, db)
 	historyHandler := handler.NewHistoryHandler(queries)
-	fileHandler := handler.NewFileHandler(db, queries, fileStorage)
+	runArchiveHandler := handler.NewRunArchiveHandler(queries, archiver)
+	fileHandler := handler.NewFileHandler(db, queries, fileStorage, jobQueue)
 	wsHandler := handler.NewWebSocketHandler(wsRelay)
+	exportHandler := handler.NewExportHandler(queries)
+	scriptHandler := handler.NewScriptHandler()
+	variablePreviewHandler := handler.NewVariablePreviewHandler(queries, variableResolver)
+	driftHandler := handler.NewDriftHandler(queries, driftChecker)
+	driftMonitorHandler := handler.NewDriftMonitorHandler(queries, driftMonitorRunner)
+	monitorHandler := handler.NewMonitorHandler(queries, monitorRunner)
+	notificationHandler := handler.NewNotificationHandler(queries, emailNotifier)
+	preferencesHandler := handler.NewPreferencesHandler(queries)
+	sessionHandler := handler.NewSessionHandler(queries)
+	signingHookHandler := handler.NewSigningHookHandler(queries, signingHooks)
+	extensionHandler := handler.NewExtensionHandler(queries)
+	dataFactoryHandler := handler.NewDataFactoryHandler(queries)
+	snippetHandler := handler.NewSnippetHandler(queries)
+	jobHandler := handler.NewJobHandler(queries)
+	adminHandler := handler.NewAdminHandler(db, flowRunner, requestExecutor, fileStorage, instance)
+	graphqlHandler := handler.NewGraphQLHandler(queries)
+	grpcHandler := handler.NewGRPCHandler(variableResolver)
+	oauth2Tokens := service.NewOAuth2Tokens(queries, variableResolver)
+	oauth2Tokens.SetFileStorage(fileStorage)
+	oauth2Handler := handler.NewOAuth2Handler(queries, oauth2Tokens)
+	environmentRotationHandler := handler.NewEnvironmentRotationHandler(queries, environmentRotator)
+	tokenRefresherHandler := handler.NewTokenRefresherHandler(queries, tokenRefresher)
+	cookieHandler := handler.NewCookieHandler(queries)
+	openAPISpecHandler := handler.NewOpenAPISpecHandler(queries)
+	encryptionHandler := handler.NewEncryptionHandler(queries, keyRing)
 
 	// Setup router
 	r := chi.NewRouter()
@@ -87,12 +206,39 @@ func main() {
 	r.Route("/api", func(r chi.Router) {
 		r.Use(middleware.WorkspaceID)
 
+		// Background jobs
+		r.Get("/jobs", jobHandler.List)
+		r.Get("/jobs/{id}", jobHandler.Get)
+		r.Post("/jobs/{id}/retry", jobHandler.Retry)
+		r.Post("/jobs/{id}/cancel", jobHandler.Cancel)
+
+		// Admin (server-wide)
+		r.Get("/admin/stats", adminHandler.Stats)
+		r.Get("/admin/instances", adminHandler.Instances)
+		r.Post("/admin/vacuum", adminHandler.Vacuum)
+		r.Post("/admin/reindex", adminHandler.Reindex)
+		r.Post("/admin/cache/clear", adminHandler.ClearCaches)
+
 		// Workspaces
 		r.Get("/workspaces", workspaceHandler.List)
 		r.Post("/workspaces", workspaceHandler.Create)
 		r.Get("/workspaces/{id}", workspaceHandler.Get)
 		r.Put("/workspaces/{id}", workspaceHandler.Update)
 		r.Delete("/workspaces/{id}", workspaceHandler.Delete)
+		r.Get("/workspaces/{id}/settings", workspaceHandler.GetSettings)
+		r.Put("/workspaces/{id}/settings", workspaceHandler.UpdateSettings)
+		r.Get("/workspaces/{id}/feed", workspaceHandler.Feed)
+		r.Get("/workspaces/{id}/usage", workspaceHandler.Usage)
+		r.Get("/workspaces/{id}/test-summary", workspaceHandler.TestSummary)
+		r.Get("/workspaces/{id}/variables", workspaceHandler.ListVariables)
+		r.Put("/workspaces/{id}/variables", workspaceHandler.ReplaceVariables)
+		r.Put("/workspaces/{id}/variables/{key}", workspaceHandler.SetVariable)
+		r.Delete("/workspaces/{id}/variables/{key}", workspaceHandler.DeleteVariable)
+		r.Get("/workspaces/{id}/encryption", encryptionHandler.Get)
+		r.Put("/workspaces/{id}/encryption", encryptionHandler.Rotate)
+		r.Delete("/workspaces/{id}/encryption", encryptionHandler.Remove)
+		r.Post("/workspaces/{id}/encryption/unlock", encryptionHandler.Unlock)
+		r.Post("/workspaces/{id}/encryption/lock", encryptionHandler.Lock)
 
 		// Collections
 		r.Get("/collections", collectionHandler.List)
@@ -102,27 +248,58 @@ func main() {
 		r.Put("/collections/{id}", collectionHandler.Update)
 		r.Delete("/collections/{id}", collectionHandler.Delete)
 		r.Post("/collections/{id}/duplicate", collectionHandler.Duplicate)
+		r.Post("/collections/{id}/drift-check", driftHandler.CheckCollection)
+		r.Get("/collections/{id}/run-flows", collectionHandler.GetRunFlows)
+		r.Put("/collections/{id}/run-flows", collectionHandler.UpdateRunFlows)
+		r.Get("/collections/{id}/auth", collectionHandler.GetAuth)
+		r.Put("/collections/{id}/auth", collectionHandler.UpdateAuth)
+		r.Get("/collections/{id}/signing-hook", signingHookHandler.Get)
+		r.Put("/collections/{id}/signing-hook", signingHookHandler.Update)
+		r.Get("/collections/{id}/variables", collectionHandler.ListVariables)
+		r.Put("/collections/{id}/variables", collectionHandler.ReplaceVariables)
+		r.Put("/collections/{id}/variables/{key}", collectionHandler.SetVariable)
+		r.Delete("/collections/{id}/variables/{key}", collectionHandler.DeleteVariable)
+		r.Post("/variables/preview", variablePreviewHandler.Preview)
 
 		// Ad-hoc execute (no saved request needed)
 		r.Post("/execute", requestHandler.ExecuteAdhoc)
+		r.Post("/execute/stream", requestHandler.ExecuteAdhocStream)
 
 		// Requests
 		r.Get("/requests", requestHandler.List)
 		r.Post("/requests", requestHandler.Create)
 		r.Put("/requests/reorder", requestHandler.Reorder)
+		r.Post("/requests/merge", requestMergeHandler.Merge)
 		r.Get("/requests/{id}", requestHandler.Get)
 		r.Put("/requests/{id}", requestHandler.Update)
 		r.Delete("/requests/{id}", requestHandler.Delete)
 		r.Post("/requests/{id}/execute", requestHandler.Execute)
+		r.Post("/requests/{id}/execute/stream", requestHandler.ExecuteStream)
 		r.Post("/requests/{id}/duplicate", requestHandler.Duplicate)
+		r.Post("/requests/{id}/archive", requestHandler.Archive)
+		r.Post("/requests/{id}/unarchive", requestHandler.Unarchive)
+		r.Get("/requests/{id}/draft", requestHandler.GetDraft)
+		r.Patch("/requests/{id}/draft", requestHandler.PatchDraft)
+		r.Delete("/requests/{id}/draft", requestHandler.DiscardDraft)
+		r.Get("/requests/{id}/draft/diff", requestHandler.DiffDraft)
+		r.Post("/requests/{id}/draft/apply", requestHandler.ApplyDraft)
+		r.Get("/requests/{id}/annotations", requestHandler.ListAnnotations)
+		r.Put("/requests/{id}/annotations", requestHandler.PutAnnotation)
+		r.Delete("/requests/{id}/annotations/{annotationId}", requestHandler.DeleteAnnotation)
+		r.Put("/requests/{id}/drift-baseline", driftHandler.SaveBaseline)
+		r.Delete("/requests/{id}/drift-baseline", driftHandler.DeleteBaseline)
 
 		// Environments
 		r.Get("/environments", environmentHandler.List)
 		r.Post("/environments", environmentHandler.Create)
+		r.Post("/environments/import-dotenv", environmentHandler.ImportDotenv)
 		r.Get("/environments/{id}", environmentHandler.Get)
 		r.Put("/environments/{id}", environmentHandler.Update)
 		r.Delete("/environments/{id}", environmentHandler.Delete)
 		r.Post("/environments/{id}/activate", environmentHandler.Activate)
+		r.Post("/environments/{id}/deactivate", environmentHandler.Deactivate)
+		r.Get("/environments/{id}/resolved", environmentHandler.Resolved)
+		r.Get("/environments/{id}/export-dotenv", environmentHandler.ExportDotenv)
 
 		// Proxies
 		r.Get("/proxies", proxyHandler.List)
@@ -134,6 +311,9 @@ func main() {
 		r.Post("/proxies/deactivate", proxyHandler.Deactivate)
 		r.Post("/proxies/{id}/test", proxyHandler.Test)
 
+		// Run by name (command palette / CLI)
+		r.Post("/run", requestHandler.RunByName)
+
 		// Flows
 		r.Get("/flows", flowHandler.List)
 		r.Post("/flows", flowHandler.Create)
@@ -143,7 +323,18 @@ func main() {
 		r.Delete("/flows/{id}", flowHandler.Delete)
 		r.Post("/flows/{id}/run", flowHandler.Run)
 		r.Post("/flows/{id}/run/stream", flowHandler.RunStream)
+		r.Get("/flows/{id}/debug", flowHandler.Debug)
 		r.Post("/flows/{id}/duplicate", flowHandler.Duplicate)
+		r.Post("/flows/{id}/archive", flowHandler.Archive)
+		r.Post("/flows/{id}/unarchive", flowHandler.Unarchive)
+		r.Get("/flows/{id}/export", exportHandler.Flow)
+		r.Get("/flows/{id}/graph", flowHandler.GetGraph)
+		r.Put("/flows/{id}/graph", flowHandler.UpdateGraph)
+		r.Get("/flows/runs/approvals", flowHandler.ListApprovals)
+		r.Get("/flows/runs/{id}/timeline", flowHandler.Timeline)
+		r.Post("/flows/runs/{id}/approve", flowHandler.Approve)
+		r.Get("/flows/{id}/runs", flowHandler.ListRuns)
+		r.Get("/flow-runs/{id}", flowHandler.GetRun)
 		r.Get("/flows/{id}/steps", flowHandler.ListSteps)
 		r.Post("/flows/{id}/steps", flowHandler.CreateStep)
 		r.Post("/flows/{id}/import-collection", flowHandler.ImportCollection)
@@ -156,13 +347,138 @@ func main() {
 		r.Get("/files/{id}", fileHandler.Get)
 		r.Delete("/files/{id}", fileHandler.Delete)
 
+		// Monitors (uptime/latency checks)
+		r.Get("/monitors", monitorHandler.List)
+		r.Post("/monitors", monitorHandler.Create)
+		r.Get("/monitors/{id}", monitorHandler.Get)
+		r.Put("/monitors/{id}", monitorHandler.Update)
+		r.Delete("/monitors/{id}", monitorHandler.Delete)
+		r.Get("/monitors/{id}/checks", monitorHandler.Checks)
+		r.Post("/monitors/{id}/run", monitorHandler.Run)
+
+		// Email notifications
+		r.Post("/notifications/email/test", notificationHandler.TestEmail)
+		r.Get("/notifications/digest", notificationHandler.Digest)
+		r.Post("/notifications/digest", notificationHandler.SendDigest)
+
+		// OAuth2 token helper (client_credentials, password grants)
+		r.Get("/oauth2/configs", oauth2Handler.List)
+		r.Post("/oauth2/configs", oauth2Handler.Create)
+		r.Get("/oauth2/configs/{id}", oauth2Handler.Get)
+		r.Put("/oauth2/configs/{id}", oauth2Handler.Update)
+		r.Delete("/oauth2/configs/{id}", oauth2Handler.Delete)
+		r.Post("/oauth2/configs/{id}/fetch-token", oauth2Handler.FetchToken)
+
+		// Environment rotations (scheduled flow outputs -> environment variables)
+		r.Get("/environment-rotations", environmentRotationHandler.List)
+		r.Post("/environment-rotations", environmentRotationHandler.Create)
+		r.Get("/environment-rotations/{id}", environmentRotationHandler.Get)
+		r.Put("/environment-rotations/{id}", environmentRotationHandler.Update)
+		r.Delete("/environment-rotations/{id}", environmentRotationHandler.Delete)
+		r.Get("/environment-rotations/{id}/runs", environmentRotationHandler.Runs)
+		r.Post("/environment-rotations/{id}/run", environmentRotationHandler.Run)
+
+		// Drift monitors (scheduled contract drift checks of a collection)
+		r.Get("/drift-monitors", driftMonitorHandler.List)
+		r.Post("/drift-monitors", driftMonitorHandler.Create)
+		r.Get("/drift-monitors/{id}", driftMonitorHandler.Get)
+		r.Put("/drift-monitors/{id}", driftMonitorHandler.Update)
+		r.Delete("/drift-monitors/{id}", driftMonitorHandler.Delete)
+		r.Post("/drift-monitors/{id}/run", driftMonitorHandler.Run)
+
+		// Token refreshers (login request on an interval -> environment variables)
+		r.Get("/token-refreshers", tokenRefresherHandler.List)
+		r.Post("/token-refreshers", tokenRefresherHandler.Create)
+		r.Get("/token-refreshers/{id}", tokenRefresherHandler.Get)
+		r.Put("/token-refreshers/{id}", tokenRefresherHandler.Update)
+		r.Delete("/token-refreshers/{id}", tokenRefresherHandler.Delete)
+		r.Post("/token-refreshers/{id}/refresh", tokenRefresherHandler.Refresh)
+
+		// Cookie jar
+		r.Get("/cookies", cookieHandler.List)
+		r.Post("/cookies", cookieHandler.Create)
+		r.Delete("/cookies", cookieHandler.Clear)
+		r.Get("/cookies/{id}", cookieHandler.Get)
+		r.Put("/cookies/{id}", cookieHandler.Update)
+		r.Delete("/cookies/{id}", cookieHandler.Delete)
+
+		// OpenAPI specs
+		r.Get("/openapi-specs", openAPISpecHandler.List)
+		r.Post("/openapi-specs", openAPISpecHandler.Create)
+		r.Get("/openapi-specs/{id}", openAPISpecHandler.Get)
+		r.Put("/openapi-specs/{id}", openAPISpecHandler.Update)
+		r.Delete("/openapi-specs/{id}", openAPISpecHandler.Delete)
+
+		// Signing hooks installed on the server
+		r.Get("/signing-hooks", signingHookHandler.List)
+
+		// Wasm extensions (custom DSL assertions and transforms)
+		r.Get("/extensions", extensionHandler.List)
+		r.Put("/extensions/{name}", extensionHandler.Upload)
+		r.Delete("/extensions/{name}", extensionHandler.Delete)
+
+		// Test data factories ({{seq:name}} counters, {{pool:name}} value lists)
+		r.Get("/sequences", dataFactoryHandler.ListSequences)
+		r.Put("/sequences/{name}", dataFactoryHandler.UpdateSequence)
+		r.Delete("/sequences/{name}", dataFactoryHandler.DeleteSequence)
+		r.Get("/pools", dataFactoryHandler.ListPools)
+		r.Put("/pools/{name}", dataFactoryHandler.UpdatePool)
+		r.Delete("/pools/{name}", dataFactoryHandler.DeletePool)
+		r.Get("/snippets", snippetHandler.List)
+		r.Get("/snippets/{name}", snippetHandler.Get)
+		r.Put("/snippets/{name}", snippetHandler.Update)
+		r.Delete("/snippets/{name}", snippetHandler.Delete)
+
+		// User preferences (keyed by X-User-Token)
+		r.Get("/preferences", preferencesHandler.Get)
+		r.Put("/preferences", preferencesHandler.Update)
+		r.Delete("/preferences", preferencesHandler.Delete)
+
+		// Unsaved editor state (open tabs, drafts) per user and workspace
+		r.Get("/session", sessionHandler.Get)
+		r.Put("/session", sessionHandler.Update)
+		r.Delete("/session", sessionHandler.Delete)
+
 		// WebSocket Relay
 		r.Get("/ws/relay", wsHandler.Relay)
 
 		// History
 		r.Get("/history", historyHandler.List)
+		r.Get("/history/search", historyHandler.Search)
 		r.Get("/history/{id}", historyHandler.Get)
 		r.Delete("/history/{id}", historyHandler.Delete)
+		r.Get("/history/{id}/ws-messages", historyHandler.WSMessages)
+		r.Post("/history/{id}/note", historyHandler.Note)
+
+		// Archived runs and history (read back from the archive store)
+		r.Get("/archives", runArchiveHandler.List)
+		r.Get("/archives/{id}", runArchiveHandler.Get)
+		r.Get("/archives/runs/{runId}", runArchiveHandler.GetRun)
+
+		// Export (optional ?mask=email,bearer,uuid|all anonymization)
+		r.Get("/export/mask-rules", exportHandler.MaskRules)
+		r.Get("/export/workspace", exportHandler.Workspace)
+		r.Get("/export/collections/{id}", exportHandler.Collection)
+		r.Get("/export/collections/{id}/openapi", exportHandler.OpenAPI)
+		r.Post("/export/run", exportHandler.Run)
+		r.Post("/import/flow", flowHandler.Import)
+		r.Post("/import/postman", collectionHandler.ImportPostman)
+		r.Post("/import/preview", collectionHandler.ImportPreview)
+		r.Post("/import/commit", collectionHandler.ImportCommit)
+		r.Post("/import/decrypt", exportHandler.Decrypt)
+
+		// Script / condition validation (edit-time diagnostics)
+		r.Post("/scripts/validate", scriptHandler.ValidateScript)
+		r.Post("/conditions/validate", scriptHandler.ValidateCondition)
+		r.Get("/scripting/api-spec", scriptHandler.APISpec)
+
+		// GraphQL (read-only queries over collections, requests, flows, history)
+		r.Get("/graphql", graphqlHandler.Query)
+		r.Post("/graphql", graphqlHandler.Query)
+		r.Get("/graphql/schema", graphqlHandler.Schema)
+
+		// gRPC server reflection (requests use method GRPC and grpc:// URLs)
+		r.Post("/grpc/reflect", grpcHandler.Reflect)
 	})
 
 	// Serve static files
@@ -190,9 +506,26 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on http://localhost:%s", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatal("Server failed:", err)
+	srv := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		log.Printf("Server starting on http://localhost:%s", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server failed:", err)
+		}
+	}()
+
+	// On SIGINT/SIGTERM stop taking requests, then flush queued trace spans
+	stop, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	<-stop.Done()
+	log.Println("Shutting down")
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+	if err := requestExecutor.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Trace export shutdown: %v", err)
 	}
 }
 
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE requests ADD COLUMN response_transform TEXT DEFAULT '';
+ALTER TABLE flow_steps ADD COLUMN response_transform TEXT DEFAULT '';
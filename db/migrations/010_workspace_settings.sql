@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE workspaces ADD COLUMN settings TEXT DEFAULT '{}';
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE workspaces ADD COLUMN secret_variables TEXT DEFAULT '[]';
+ALTER TABLE collections ADD COLUMN secret_variables TEXT DEFAULT '[]';
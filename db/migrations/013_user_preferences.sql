@@ -0,0 +1,7 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS user_preferences (
+    token_hash TEXT PRIMARY KEY,
+    preferences TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
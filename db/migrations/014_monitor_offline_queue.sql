@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE monitors ADD COLUMN max_queue_seconds INTEGER NOT NULL DEFAULT 900;
+ALTER TABLE monitors ADD COLUMN offline_since DATETIME;
+ALTER TABLE monitor_checks ADD COLUMN offline_seconds INTEGER NOT NULL DEFAULT 0;
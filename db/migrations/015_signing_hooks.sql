@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE collections ADD COLUMN signing_hook TEXT DEFAULT '';
@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE requests ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS request_drafts (
+    request_id INTEGER PRIMARY KEY REFERENCES requests(id) ON DELETE CASCADE,
+    data TEXT NOT NULL DEFAULT '{}',
+    base_version INTEGER NOT NULL,
+    revision INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE environments ADD COLUMN parent_id INTEGER REFERENCES environments(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE environments ADD COLUMN collection_id INTEGER REFERENCES collections(id) ON DELETE CASCADE;
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE request_history ADD COLUMN trace_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_history_trace ON request_history(trace_id);
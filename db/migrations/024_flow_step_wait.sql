@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE flow_steps ADD COLUMN wait_until TEXT DEFAULT '';
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE request_history ADD COLUMN note TEXT DEFAULT '';
+ALTER TABLE request_history ADD COLUMN flagged INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE request_history ADD COLUMN execution_group_id TEXT;
+CREATE INDEX IF NOT EXISTS idx_history_execution_group ON request_history(execution_group_id);
@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE request_history ADD COLUMN parent_history_id INTEGER REFERENCES request_history(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_history_parent ON request_history(parent_history_id);
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE requests ADD COLUMN auth TEXT DEFAULT '';
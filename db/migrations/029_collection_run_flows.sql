@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE collections ADD COLUMN run_flows TEXT DEFAULT '';
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE flows ADD COLUMN outputs TEXT DEFAULT '';
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS run_timelines (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id TEXT NOT NULL UNIQUE,
+    workspace_id INTEGER NOT NULL DEFAULT 1,
+    flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    timeline TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_run_timelines_flow ON run_timelines(flow_id);
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE requests ADD COLUMN execution_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE requests ADD COLUMN last_executed_at DATETIME;
+ALTER TABLE flows ADD COLUMN execution_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flows ADD COLUMN last_executed_at DATETIME;
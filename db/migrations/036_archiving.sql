@@ -0,0 +1,3 @@
+-- +migrate Up
+ALTER TABLE requests ADD COLUMN archived_at DATETIME;
+ALTER TABLE flows ADD COLUMN archived_at DATETIME;
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE VIRTUAL TABLE IF NOT EXISTS history_search USING fts5(
+    body,
+    error,
+    workspace_id UNINDEXED
+);
+
+CREATE TABLE IF NOT EXISTS history_search_cursor (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    last_history_id INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE collections ADD COLUMN auth TEXT DEFAULT '';
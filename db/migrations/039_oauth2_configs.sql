@@ -0,0 +1,22 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS oauth2_configs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    grant_type TEXT NOT NULL,
+    token_url TEXT NOT NULL,
+    client_id TEXT NOT NULL DEFAULT '',
+    client_secret TEXT NOT NULL DEFAULT '',
+    client_auth TEXT NOT NULL DEFAULT 'basic',
+    username TEXT NOT NULL DEFAULT '',
+    password TEXT NOT NULL DEFAULT '',
+    scope TEXT NOT NULL DEFAULT '',
+    environment_id INTEGER REFERENCES environments(id) ON DELETE SET NULL,
+    token_variable TEXT NOT NULL DEFAULT 'accessToken',
+    auto_refresh INTEGER NOT NULL DEFAULT 1,
+    refresh_token TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_oauth2_configs_workspace ON oauth2_configs(workspace_id);
@@ -0,0 +1,25 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS environment_rotations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    environment_id INTEGER NOT NULL REFERENCES environments(id) ON DELETE CASCADE,
+    flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    interval_seconds INTEGER NOT NULL DEFAULT 86400,
+    outputs TEXT NOT NULL DEFAULT '',
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_run_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_environment_rotations_workspace ON environment_rotations(workspace_id);
+
+CREATE TABLE IF NOT EXISTS environment_rotation_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rotation_id INTEGER NOT NULL REFERENCES environment_rotations(id) ON DELETE CASCADE,
+    success INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    variables TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    ran_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_environment_rotation_runs_rotation ON environment_rotation_runs(rotation_id, ran_at);
@@ -0,0 +1,19 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS token_refreshers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    login_request_id INTEGER NOT NULL REFERENCES requests(id) ON DELETE CASCADE,
+    environment_id INTEGER REFERENCES environments(id) ON DELETE SET NULL,
+    extract_vars TEXT NOT NULL,
+    expires_in_path TEXT NOT NULL DEFAULT '',
+    interval_seconds INTEGER NOT NULL DEFAULT 900,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_attempt_at DATETIME,
+    last_refreshed_at DATETIME,
+    expires_at DATETIME,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_token_refreshers_workspace ON token_refreshers(workspace_id);
@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS ws_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    history_id INTEGER NOT NULL REFERENCES request_history(id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    direction TEXT NOT NULL,
+    format TEXT NOT NULL DEFAULT 'text',
+    payload TEXT NOT NULL DEFAULT '',
+    size INTEGER NOT NULL DEFAULT 0,
+    sent_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_ws_messages_history ON ws_messages(history_id, seq);
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE requests ADD COLUMN timeout_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE requests ADD COLUMN retry_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE requests ADD COLUMN retry_backoff_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE requests ADD COLUMN retry_on_status TEXT NOT NULL DEFAULT '';
+ALTER TABLE flow_steps ADD COLUMN timeout_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flow_steps ADD COLUMN retry_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flow_steps ADD COLUMN retry_backoff_ms INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flow_steps ADD COLUMN retry_on_status TEXT NOT NULL DEFAULT '';
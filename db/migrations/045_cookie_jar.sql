@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS cookies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    domain TEXT NOT NULL,
+    path TEXT NOT NULL DEFAULT '/',
+    name TEXT NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME,
+    secure INTEGER NOT NULL DEFAULT 0,
+    http_only INTEGER NOT NULL DEFAULT 0,
+    host_only INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(workspace_id, domain, path, name)
+);
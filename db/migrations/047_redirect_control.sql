@@ -0,0 +1,6 @@
+-- +migrate Up
+ALTER TABLE requests ADD COLUMN disable_redirects INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE requests ADD COLUMN max_redirects INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flow_steps ADD COLUMN disable_redirects INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flow_steps ADD COLUMN max_redirects INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE request_history ADD COLUMN redirects TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE request_history ADD COLUMN network TEXT NOT NULL DEFAULT '';
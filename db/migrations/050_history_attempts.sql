@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE request_history ADD COLUMN attempt_group_id TEXT;
+ALTER TABLE request_history ADD COLUMN attempt INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_request_history_attempt_group ON request_history(attempt_group_id);
@@ -0,0 +1,2 @@
+-- +migrate Up
+ALTER TABLE environments ADD COLUMN comments TEXT NOT NULL DEFAULT '';
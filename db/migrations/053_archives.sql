@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS archives (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    run_id TEXT,
+    flow_id INTEGER,
+    storage TEXT NOT NULL,
+    object_key TEXT NOT NULL,
+    entries INTEGER NOT NULL DEFAULT 0,
+    size INTEGER NOT NULL DEFAULT 0,
+    oldest_at DATETIME,
+    newest_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_archives_workspace ON archives(workspace_id, id);
+CREATE INDEX IF NOT EXISTS idx_archives_run ON archives(run_id);
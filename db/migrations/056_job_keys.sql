@@ -0,0 +1,4 @@
+-- +migrate Up
+ALTER TABLE jobs ADD COLUMN dedupe_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_active_key ON jobs(dedupe_key) WHERE dedupe_key IS NOT NULL AND status IN ('queued', 'running');
@@ -0,0 +1,7 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS token_refresh_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    refresher_id INTEGER NOT NULL REFERENCES token_refreshers(id) ON DELETE CASCADE,
+    ran_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_token_refresh_runs_refresher ON token_refresh_runs(refresher_id, ran_at);
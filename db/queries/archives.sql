@@ -0,0 +1,15 @@
+-- name: CreateArchive :one
+INSERT INTO archives (
+    workspace_id, kind, run_id, flow_id, storage, object_key, entries, size, oldest_at, newest_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: GetArchive :one
+SELECT * FROM archives WHERE id = ? LIMIT 1;
+
+-- name: GetRunArchive :one
+SELECT * FROM archives WHERE workspace_id = ? AND kind = 'run' AND run_id = ? LIMIT 1;
+
+-- name: ListArchives :many
+SELECT * FROM archives
+WHERE workspace_id = @workspace_id AND (@kind = '' OR kind = @kind)
+ORDER BY id DESC LIMIT @limit;
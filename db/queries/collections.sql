@@ -25,6 +25,18 @@ SELECT variables FROM collections WHERE id = ?;
 -- name: UpdateCollectionVariables :one
 UPDATE collections SET variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
 
+-- name: UpdateCollectionVariableSet :one
+UPDATE collections SET variables = ?, secret_variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+
+-- name: UpdateCollectionSigningHook :one
+UPDATE collections SET signing_hook = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+
+-- name: UpdateCollectionRunFlows :one
+UPDATE collections SET run_flows = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+
+-- name: UpdateCollectionAuth :one
+UPDATE collections SET auth = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+
 -- name: UpdateCollectionSortOrder :exec
 UPDATE collections SET sort_order = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
 
@@ -0,0 +1,45 @@
+-- name: GetDriftBaseline :one
+SELECT * FROM drift_baselines WHERE request_id = ? LIMIT 1;
+
+-- name: SaveDriftBaseline :one
+INSERT INTO drift_baselines (request_id, history_id, response_body) VALUES (?, ?, ?)
+ON CONFLICT(request_id) DO UPDATE SET
+    history_id = excluded.history_id,
+    response_body = excluded.response_body,
+    created_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteDriftBaseline :exec
+DELETE FROM drift_baselines WHERE request_id = ?;
+
+-- name: GetDriftMonitor :one
+SELECT * FROM drift_monitors WHERE id = ? LIMIT 1;
+
+-- name: GetDriftMonitorByCollection :one
+SELECT * FROM drift_monitors WHERE collection_id = ? LIMIT 1;
+
+-- name: ListDriftMonitors :many
+SELECT * FROM drift_monitors WHERE workspace_id = ? ORDER BY id;
+
+-- name: ListDueDriftMonitors :many
+SELECT * FROM drift_monitors
+WHERE enabled = 1
+  AND (last_run_at IS NULL OR last_run_at <= datetime('now', '-' || interval_seconds || ' seconds'))
+ORDER BY id;
+
+-- name: CreateDriftMonitor :one
+INSERT INTO drift_monitors (workspace_id, collection_id, interval_seconds, webhook_url, enabled)
+VALUES (?, ?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateDriftMonitor :one
+UPDATE drift_monitors SET interval_seconds = ?, webhook_url = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? RETURNING *;
+
+-- name: RecordDriftMonitorRun :exec
+UPDATE drift_monitors SET last_run_at = CURRENT_TIMESTAMP, last_report = ? WHERE id = ?;
+
+-- name: MarkDriftMonitorRun :exec
+UPDATE drift_monitors SET last_run_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: DeleteDriftMonitor :exec
+DELETE FROM drift_monitors WHERE id = ?;
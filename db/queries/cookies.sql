@@ -0,0 +1,34 @@
+-- name: GetCookie :one
+SELECT * FROM cookies WHERE id = ? LIMIT 1;
+
+-- name: ListCookies :many
+SELECT * FROM cookies WHERE workspace_id = ? ORDER BY domain, path, name;
+
+-- name: UpsertCookie :one
+INSERT INTO cookies (workspace_id, domain, path, name, value, expires_at, secure, http_only, host_only)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(workspace_id, domain, path, name) DO UPDATE SET
+    value = excluded.value,
+    expires_at = excluded.expires_at,
+    secure = excluded.secure,
+    http_only = excluded.http_only,
+    host_only = excluded.host_only,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: UpdateCookie :one
+UPDATE cookies SET domain = ?, path = ?, name = ?, value = ?, expires_at = ?, secure = ?, http_only = ?, host_only = ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ? RETURNING *;
+
+-- name: DeleteCookie :exec
+DELETE FROM cookies WHERE id = ?;
+
+-- name: DeleteCookieByName :exec
+DELETE FROM cookies WHERE workspace_id = ? AND domain = ? AND path = ? AND name = ?;
+
+-- name: DeleteDomainCookies :execrows
+DELETE FROM cookies WHERE workspace_id = ? AND domain = ?;
+
+-- name: DeleteWorkspaceCookies :execrows
+DELETE FROM cookies WHERE workspace_id = ?;
@@ -0,0 +1,41 @@
+-- name: ListSequences :many
+SELECT * FROM sequences WHERE workspace_id = ? ORDER BY name;
+
+-- name: GetSequence :one
+SELECT * FROM sequences WHERE workspace_id = ? AND name = ? LIMIT 1;
+
+-- name: UpsertSequence :one
+INSERT INTO sequences (workspace_id, name, value) VALUES (?, ?, ?)
+ON CONFLICT(workspace_id, name) DO UPDATE SET value = excluded.value, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: NextSequenceValue :one
+INSERT INTO sequences (workspace_id, name, value) VALUES (?, ?, 1)
+ON CONFLICT(workspace_id, name) DO UPDATE SET value = sequences.value + 1, updated_at = CURRENT_TIMESTAMP
+RETURNING value;
+
+-- name: DeleteSequence :exec
+DELETE FROM sequences WHERE id = ?;
+
+-- name: ListValuePools :many
+SELECT * FROM value_pools WHERE workspace_id = ? ORDER BY name;
+
+-- name: GetValuePool :one
+SELECT * FROM value_pools WHERE workspace_id = ? AND name = ? LIMIT 1;
+
+-- name: UpsertValuePool :one
+INSERT INTO value_pools (workspace_id, name, mode, items) VALUES (?, ?, ?, ?)
+ON CONFLICT(workspace_id, name) DO UPDATE SET
+    mode = excluded.mode,
+    items = excluded.items,
+    cursor = 0,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: AdvanceValuePool :one
+UPDATE value_pools SET cursor = cursor + 1
+WHERE workspace_id = ? AND name = ?
+RETURNING *;
+
+-- name: DeleteValuePool :exec
+DELETE FROM value_pools WHERE id = ?;
@@ -0,0 +1,50 @@
+-- name: GetEnvironmentRotation :one
+SELECT * FROM environment_rotations WHERE id = ? LIMIT 1;
+
+-- name: ListEnvironmentRotations :many
+SELECT * FROM environment_rotations WHERE workspace_id = ? ORDER BY id;
+
+-- name: ListDueEnvironmentRotations :many
+SELECT * FROM environment_rotations
+WHERE enabled = 1
+  AND (last_run_at IS NULL OR last_run_at <= datetime('now', '-' || interval_seconds || ' seconds'))
+ORDER BY id;
+
+-- name: CreateEnvironmentRotation :one
+INSERT INTO environment_rotations (workspace_id, environment_id, flow_id, interval_seconds, outputs, enabled)
+VALUES (?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateEnvironmentRotation :one
+UPDATE environment_rotations SET environment_id = ?, flow_id = ?, interval_seconds = ?, outputs = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? RETURNING *;
+
+-- name: MarkEnvironmentRotationRun :exec
+UPDATE environment_rotations SET last_run_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: DeleteEnvironmentRotation :exec
+DELETE FROM environment_rotations WHERE id = ?;
+
+-- name: CreateEnvironmentRotationRun :one
+INSERT INTO environment_rotation_runs (rotation_id, success, error, variables, duration_ms)
+VALUES (?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ListEnvironmentRotationRuns :many
+SELECT * FROM environment_rotation_runs WHERE rotation_id = ? ORDER BY id DESC LIMIT ?;
+
+-- name: GetLastEnvironmentRotationRun :one
+SELECT * FROM environment_rotation_runs WHERE rotation_id = ? ORDER BY id DESC LIMIT 1;
+
+-- name: PruneEnvironmentRotationRuns :exec
+DELETE FROM environment_rotation_runs WHERE ran_at < datetime('now', '-30 days');
+
+-- name: ListRecentEnvironmentRotationRuns :many
+SELECT ro.id AS rotation_id, f.name AS flow_name, e.name AS environment_name, ro.enabled, rr.success, rr.ran_at
+FROM environment_rotations ro
+JOIN flows f ON f.id = ro.flow_id
+JOIN environments e ON e.id = ro.environment_id
+LEFT JOIN (
+    SELECT rotation_id, success, ran_at, ROW_NUMBER() OVER (PARTITION BY rotation_id ORDER BY id DESC) AS rn
+    FROM environment_rotation_runs
+) rr ON rr.rotation_id = ro.id AND rr.rn <= @runs
+WHERE ro.workspace_id = @workspace_id
+ORDER BY ro.id, rr.rn DESC;
@@ -5,22 +5,37 @@ SELECT * FROM environments WHERE id = ? LIMIT 1;
 SELECT * FROM environments WHERE workspace_id = ? ORDER BY name;
 
 -- name: GetActiveEnvironment :one
-SELECT * FROM environments WHERE is_active = TRUE AND workspace_id = ? LIMIT 1;
+SELECT * FROM environments WHERE is_active = TRUE AND workspace_id = ? AND collection_id IS NULL LIMIT 1;
+
+-- name: GetActiveCollectionEnvironment :one
+SELECT * FROM environments WHERE is_active = TRUE AND collection_id = ? LIMIT 1;
 
 -- name: CreateEnvironment :one
-INSERT INTO environments (name, variables, workspace_id) VALUES (?, ?, ?) RETURNING *;
+INSERT INTO environments (name, variables, workspace_id, parent_id, collection_id) VALUES (?, ?, ?, ?, ?) RETURNING *;
 
 -- name: UpdateEnvironment :one
-UPDATE environments SET name = ?, variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+UPDATE environments SET name = ?, variables = ?, parent_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
 
 -- name: DeleteEnvironment :exec
 DELETE FROM environments WHERE id = ?;
 
+-- name: ClearEnvironmentParent :exec
+UPDATE environments SET parent_id = NULL, updated_at = CURRENT_TIMESTAMP WHERE parent_id = ?;
+
 -- name: DeactivateAllEnvironments :exec
-UPDATE environments SET is_active = FALSE WHERE workspace_id = ?;
+UPDATE environments SET is_active = FALSE WHERE workspace_id = ? AND collection_id IS NULL;
+
+-- name: DeactivateCollectionEnvironments :exec
+UPDATE environments SET is_active = FALSE WHERE collection_id = ?;
+
+-- name: DeactivateEnvironment :one
+UPDATE environments SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
 
 -- name: ActivateEnvironment :one
 UPDATE environments SET is_active = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
 
+-- name: UpdateEnvironmentComments :exec
+UPDATE environments SET comments = ? WHERE id = ?;
+
 -- name: UpdateEnvironmentVariables :one
 UPDATE environments SET variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
@@ -10,3 +10,6 @@ DELETE FROM uploaded_files WHERE id = ?;
 
 -- name: ListAllUploadedFiles :many
 SELECT id, stored_name FROM uploaded_files;
+
+-- name: UpdateUploadedFileStoredName :exec
+UPDATE uploaded_files SET stored_name = ? WHERE id = ?;
@@ -0,0 +1,20 @@
+-- name: CreateFlowRun :exec
+INSERT INTO flow_runs (
+    run_id, workspace_id, flow_id, flow_name, success, error, total_time_ms, step_count, result, started_at
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+
+-- name: GetFlowRun :one
+SELECT * FROM flow_runs WHERE run_id = ?;
+
+-- name: ListFlowRunsByFlow :many
+SELECT id, run_id, flow_id, flow_name, success, error, total_time_ms, step_count, started_at, created_at
+FROM flow_runs
+WHERE flow_id = @flow_id AND workspace_id = @workspace_id
+ORDER BY id DESC
+LIMIT @limit;
+
+-- name: DeleteFlowRunsByFlow :exec
+DELETE FROM flow_runs WHERE flow_id = ?;
+
+-- name: DeleteFlowRunsBefore :execrows
+DELETE FROM flow_runs WHERE created_at < ?;
@@ -5,10 +5,10 @@ SELECT * FROM flows WHERE id = ? LIMIT 1;
 SELECT * FROM flows WHERE workspace_id = ? ORDER BY sort_order ASC, name ASC;
 
 -- name: CreateFlow :one
-INSERT INTO flows (name, description, workspace_id, sort_order) VALUES (?, ?, ?, ?) RETURNING *;
+INSERT INTO flows (name, description, workspace_id, sort_order, outputs) VALUES (?, ?, ?, ?, ?) RETURNING *;
 
 -- name: UpdateFlow :one
-UPDATE flows SET name = ?, description = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+UPDATE flows SET name = ?, description = ?, outputs = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
 
 -- name: DeleteFlow :exec
 DELETE FROM flows WHERE id = ?;
@@ -19,6 +19,9 @@ UPDATE flows SET sort_order = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
 -- name: GetMaxFlowSortOrder :one
 SELECT COALESCE(MAX(sort_order), 0) AS max_sort_order FROM flows WHERE workspace_id = ?;
 
+-- name: RecordFlowExecution :exec
+UPDATE flows SET execution_count = execution_count + 1, last_executed_at = CURRENT_TIMESTAMP WHERE id = ?;
+
 -- name: GetFlowStep :one
 SELECT * FROM flow_steps WHERE id = ? LIMIT 1;
 
@@ -28,8 +31,9 @@ SELECT * FROM flow_steps WHERE flow_id = ? ORDER BY step_order;
 -- name: CreateFlowStep :one
 INSERT INTO flow_steps (flow_id, request_id, step_order, delay_ms, extract_vars, condition,
                         name, method, url, headers, body, body_type, cookies, proxy_id, loop_count,
-                        pre_script, post_script, continue_on_error)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+                        pre_script, post_script, continue_on_error, response_transform, wait_until, approval,
+                        timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: UpdateFlowStep :one
 UPDATE flow_steps SET
@@ -50,6 +54,15 @@ UPDATE flow_steps SET
     pre_script = ?,
     post_script = ?,
     continue_on_error = ?,
+    response_transform = ?,
+    wait_until = ?,
+    approval = ?,
+    timeout_ms = ?,
+    retry_count = ?,
+    retry_backoff_ms = ?,
+    retry_on_status = ?,
+    disable_redirects = ?,
+    max_redirects = ?,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ? RETURNING *;
 
@@ -58,3 +71,32 @@ DELETE FROM flow_steps WHERE id = ?;
 
 -- name: DeleteFlowStepsByFlow :exec
 DELETE FROM flow_steps WHERE flow_id = ?;
+
+-- name: ListFlowNodes :many
+SELECT * FROM flow_nodes WHERE flow_id = ? ORDER BY id;
+
+-- name: CreateFlowNode :one
+INSERT INTO flow_nodes (flow_id, node_key, type, name, config, position_x, position_y)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: DeleteFlowNodesByFlow :exec
+DELETE FROM flow_nodes WHERE flow_id = ?;
+
+-- name: ListFlowEdges :many
+SELECT * FROM flow_edges WHERE flow_id = ? ORDER BY id;
+
+-- name: CreateFlowEdge :one
+INSERT INTO flow_edges (flow_id, source_key, target_key, label) VALUES (?, ?, ?, ?) RETURNING *;
+
+-- name: DeleteFlowEdgesByFlow :exec
+DELETE FROM flow_edges WHERE flow_id = ?;
+
+-- name: RewireFlowStepsRequest :execrows
+UPDATE flow_steps SET request_id = @survivor_id, updated_at = CURRENT_TIMESTAMP WHERE request_id = @loser_id;
+
+-- name: RewireFlowNodesRequest :execrows
+UPDATE flow_nodes SET config = json_set(config, '$.requestId', @survivor_id)
+WHERE type = 'request' AND json_extract(config, '$.requestId') = @loser_id;
+
+-- name: SetFlowArchived :execrows
+UPDATE flows SET archived_at = ? WHERE id = ? AND workspace_id = ?;
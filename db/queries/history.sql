@@ -4,17 +4,62 @@ SELECT * FROM request_history WHERE id = ? LIMIT 1;
 -- name: ListHistory :many
 SELECT * FROM request_history WHERE workspace_id = ? ORDER BY created_at DESC LIMIT ?;
 
+-- name: ListFlaggedHistory :many
+SELECT * FROM request_history WHERE workspace_id = ? AND flagged = 1 ORDER BY created_at DESC LIMIT ?;
+
+-- name: ListHistoryByTrace :many
+SELECT * FROM request_history WHERE workspace_id = ? AND trace_id = ? ORDER BY created_at, id;
+
+-- name: ListHistoryByExecutionGroup :many
+SELECT * FROM request_history WHERE workspace_id = ? AND execution_group_id = ? ORDER BY id;
+
+-- name: ListHistoryByAttemptGroup :many
+SELECT * FROM request_history WHERE workspace_id = ? AND attempt_group_id = ? ORDER BY attempt, id;
+
+-- name: ListHistoryChildren :many
+SELECT * FROM request_history WHERE parent_history_id = ? ORDER BY id;
+
 -- name: ListHistoryByRequest :many
 SELECT * FROM request_history WHERE request_id = ? ORDER BY created_at DESC LIMIT ?;
 
 -- name: CreateHistory :one
 INSERT INTO request_history (
     request_id, flow_id, method, url, request_headers, request_body,
-    status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, workspace_id
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+    status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, workspace_id, trace_id,
+    execution_group_id, redirects, network, attempt_group_id, attempt
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: DeleteHistory :exec
 DELETE FROM request_history WHERE id = ?;
 
--- name: DeleteOldHistory :exec
-DELETE FROM request_history WHERE created_at < datetime('now', '-30 days');
+-- name: SetHistoryParent :exec
+UPDATE request_history SET parent_history_id = ? WHERE id = ?;
+
+-- name: UpdateHistoryNote :one
+UPDATE request_history SET note = ?, flagged = ? WHERE id = ? AND workspace_id = ? RETURNING *;
+
+-- name: DeleteOldHistory :execrows
+DELETE FROM request_history WHERE created_at < datetime('now', '-30 days') AND flagged = 0;
+
+-- name: GetWeeklyHistoryStats :one
+SELECT CAST(COUNT(*) AS INTEGER) AS executions,
+       CAST(COALESCE(SUM(CASE WHEN (error IS NOT NULL AND error != '') OR status_code >= 400 THEN 1 ELSE 0 END), 0) AS INTEGER) AS failures,
+       CAST(COALESCE(AVG(duration_ms), 0) AS REAL) AS avg_duration_ms,
+       CAST(COALESCE(SUM(CASE WHEN flow_id IS NOT NULL THEN 1 ELSE 0 END), 0) AS INTEGER) AS flow_executions
+FROM request_history
+WHERE workspace_id = ? AND created_at >= datetime('now', '-7 days');
+
+-- name: MoveRequestHistory :execrows
+UPDATE request_history SET request_id = @survivor_id WHERE request_id = @loser_id;
+
+-- name: ListExpiredHistoryWorkspaces :many
+SELECT DISTINCT workspace_id FROM request_history WHERE created_at < @before AND flagged = 0;
+
+-- name: ListExpiredHistory :many
+SELECT * FROM request_history
+WHERE workspace_id = @workspace_id AND created_at < @before AND flagged = 0 AND id > @after_id
+  AND (execution_group_id IS NULL OR execution_group_id NOT IN (SELECT run_id FROM archives WHERE kind = 'run' AND run_id IS NOT NULL))
+ORDER BY id LIMIT @limit;
+
+-- name: DeleteHistoryBefore :execrows
+DELETE FROM request_history WHERE created_at < @before AND flagged = 0;
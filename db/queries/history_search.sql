@@ -0,0 +1,26 @@
+-- name: GetHistorySearchCursor :one
+SELECT last_history_id FROM history_search_cursor WHERE id = 1;
+
+-- name: SetHistorySearchCursor :exec
+INSERT INTO history_search_cursor (id, last_history_id) VALUES (1, ?)
+ON CONFLICT(id) DO UPDATE SET last_history_id = excluded.last_history_id;
+
+-- name: ListHistoryToIndex :many
+SELECT id, workspace_id, response_body, error, is_binary FROM request_history WHERE id > ? ORDER BY id LIMIT ?;
+
+-- name: CreateHistorySearchEntry :exec
+INSERT INTO history_search (rowid, body, error, workspace_id) VALUES (?, ?, ?, ?);
+
+-- name: DeleteHistorySearchByWorkspace :execrows
+DELETE FROM history_search WHERE workspace_id = ?;
+
+-- name: DeleteOrphanHistorySearch :execrows
+DELETE FROM history_search WHERE rowid NOT IN (SELECT id FROM request_history);
+
+-- name: SearchHistory :many
+SELECT sqlc.embed(request_history), snippet(history_search, -1, '<mark>', '</mark>', '…', 16) AS snippet
+FROM history_search
+JOIN request_history ON request_history.id = history_search.rowid
+WHERE history_search MATCH @query AND request_history.workspace_id = @workspace_id
+ORDER BY request_history.id DESC
+LIMIT @limit;
@@ -0,0 +1,23 @@
+-- name: RegisterInstance :exec
+INSERT INTO instances (id, hostname, pid) VALUES (?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET heartbeat_at = CURRENT_TIMESTAMP;
+
+-- name: HeartbeatInstance :exec
+UPDATE instances SET heartbeat_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: ListInstances :many
+SELECT * FROM instances ORDER BY started_at, id;
+
+-- name: DeleteStaleInstances :exec
+DELETE FROM instances WHERE heartbeat_at < datetime('now', '-5 minutes');
+
+-- name: AcquireJobLease :execrows
+INSERT INTO job_leases (name, holder, expires_at) VALUES (@name, @holder, @expires_at)
+ON CONFLICT(name) DO UPDATE SET
+    holder = excluded.holder,
+    expires_at = excluded.expires_at,
+    acquired_at = CASE WHEN job_leases.holder = excluded.holder THEN job_leases.acquired_at ELSE CURRENT_TIMESTAMP END
+WHERE job_leases.holder = excluded.holder OR job_leases.expires_at <= @now;
+
+-- name: ListJobLeases :many
+SELECT * FROM job_leases ORDER BY name;
@@ -0,0 +1,47 @@
+-- name: CreateJob :one
+INSERT INTO jobs (workspace_id, type, payload, max_attempts, run_at, dedupe_key) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs WHERE id = ? LIMIT 1;
+
+-- name: ListJobs :many
+SELECT * FROM jobs WHERE workspace_id = ? OR workspace_id IS NULL ORDER BY id DESC LIMIT ?;
+
+-- name: ClaimJob :one
+UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_by = @locked_by, locked_until = @locked_until, updated_at = CURRENT_TIMESTAMP
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE (status = 'queued' AND run_at <= @now) OR (status = 'running' AND locked_until <= @stale_before)
+    ORDER BY run_at, id
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: CompleteJob :exec
+UPDATE jobs SET status = 'succeeded', result = ?, last_error = NULL, locked_by = NULL, locked_until = NULL,
+    finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND locked_by = ?;
+
+-- name: FailJob :exec
+UPDATE jobs SET status = ?, last_error = ?, run_at = ?, locked_by = NULL, locked_until = NULL,
+    finished_at = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND locked_by = ?;
+
+-- name: RetryJob :one
+UPDATE jobs SET status = 'queued', attempts = 0, run_at = ?, last_error = NULL, finished_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status IN ('failed', 'cancelled')
+    AND NOT EXISTS (
+        SELECT 1 FROM jobs pending
+        WHERE pending.dedupe_key = jobs.dedupe_key AND pending.status IN ('queued', 'running')
+    )
+RETURNING *;
+
+-- name: CancelJob :one
+UPDATE jobs SET status = 'cancelled', finished_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'queued'
+RETURNING *;
+
+-- name: DeleteFinishedJobs :exec
+DELETE FROM jobs WHERE status IN ('succeeded', 'failed', 'cancelled') AND finished_at < datetime('now', '-7 days');
@@ -0,0 +1,95 @@
+-- name: GetMonitor :one
+SELECT * FROM monitors WHERE id = ? LIMIT 1;
+
+-- name: GetMonitorByRequest :one
+SELECT * FROM monitors WHERE request_id = ? LIMIT 1;
+
+-- name: ListMonitors :many
+SELECT * FROM monitors WHERE workspace_id = ? ORDER BY id;
+
+-- name: ListDueMonitors :many
+SELECT * FROM monitors
+WHERE enabled = 1
+  AND (last_checked_at IS NULL OR last_checked_at <= datetime('now', '-' ||
+       CASE WHEN offline_since IS NULL THEN interval_seconds ELSE MIN(interval_seconds, 30) END || ' seconds'))
+ORDER BY id;
+
+-- name: CreateMonitor :one
+INSERT INTO monitors (workspace_id, request_id, interval_seconds, enabled, max_queue_seconds)
+VALUES (?, ?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateMonitor :one
+UPDATE monitors SET interval_seconds = ?, enabled = ?, max_queue_seconds = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? RETURNING *;
+
+-- name: MarkMonitorChecked :exec
+UPDATE monitors SET last_checked_at = CURRENT_TIMESTAMP WHERE id = ?;
+
+-- name: SetMonitorOffline :exec
+UPDATE monitors SET offline_since = CURRENT_TIMESTAMP WHERE id = ? AND offline_since IS NULL;
+
+-- name: ClearMonitorOffline :exec
+UPDATE monitors SET offline_since = NULL WHERE id = ?;
+
+-- name: DeleteMonitor :exec
+DELETE FROM monitors WHERE id = ?;
+
+-- name: CreateMonitorCheck :one
+INSERT INTO monitor_checks (monitor_id, status_code, duration_ms, success, error, offline_seconds)
+VALUES (?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: ListMonitorChecks :many
+SELECT * FROM monitor_checks WHERE monitor_id = ? ORDER BY id DESC LIMIT ?;
+
+-- name: GetLastMonitorResult :one
+SELECT * FROM monitor_checks WHERE monitor_id = ? AND offline_seconds = 0 ORDER BY id DESC LIMIT 1;
+
+-- name: GetMonitorStats :one
+SELECT CAST(COUNT(*) AS INTEGER) AS checks,
+       CAST(COALESCE(SUM(success), 0) AS INTEGER) AS successes,
+       CAST(COALESCE(AVG(duration_ms), 0) AS REAL) AS avg_duration_ms,
+       CAST(COALESCE(MAX(duration_ms), 0) AS INTEGER) AS max_duration_ms
+FROM monitor_checks
+WHERE monitor_id = ? AND offline_seconds = 0 AND checked_at >= datetime('now', '-1 day');
+
+-- name: ListMonitorTransitions :many
+SELECT c.id, c.monitor_id, r.name AS request_name, c.status_code, c.success, c.error, c.checked_at
+FROM monitor_checks c
+JOIN monitors m ON m.id = c.monitor_id
+JOIN requests r ON r.id = m.request_id
+WHERE m.workspace_id = ? AND c.offline_seconds = 0
+  AND c.success IS NOT (
+      SELECT p.success FROM monitor_checks p
+      WHERE p.monitor_id = c.monitor_id AND p.offline_seconds = 0 AND p.id < c.id
+      ORDER BY p.id DESC LIMIT 1)
+ORDER BY c.id DESC
+LIMIT ?;
+
+-- name: ListMonitorWeeklyStats :many
+SELECT m.id, r.name, m.enabled,
+       CAST(COUNT(c.id) AS INTEGER) AS checks,
+       CAST(COALESCE(SUM(c.success), 0) AS INTEGER) AS successes
+FROM monitors m
+JOIN requests r ON r.id = m.request_id
+LEFT JOIN monitor_checks c ON c.monitor_id = m.id AND c.offline_seconds = 0 AND c.checked_at >= datetime('now', '-7 days')
+WHERE m.workspace_id = ?
+GROUP BY m.id, r.name, m.enabled
+ORDER BY r.name;
+
+-- name: PruneMonitorChecks :exec
+DELETE FROM monitor_checks WHERE checked_at < datetime('now', '-7 days');
+
+-- name: MoveRequestMonitor :execrows
+UPDATE monitors SET request_id = @survivor_id, updated_at = CURRENT_TIMESTAMP
+WHERE request_id = @loser_id AND NOT EXISTS (SELECT 1 FROM monitors m WHERE m.request_id = @survivor_id);
+
+-- name: ListRecentMonitorChecks :many
+SELECT m.id AS monitor_id, r.name AS request_name, m.enabled, c.success, c.checked_at
+FROM monitors m
+JOIN requests r ON r.id = m.request_id
+LEFT JOIN (
+    SELECT monitor_id, success, checked_at, ROW_NUMBER() OVER (PARTITION BY monitor_id ORDER BY id DESC) AS rn
+    FROM monitor_checks WHERE offline_seconds = 0
+) c ON c.monitor_id = m.id AND c.rn <= @runs
+WHERE m.workspace_id = @workspace_id
+ORDER BY r.name, m.id, c.rn DESC;
@@ -0,0 +1,21 @@
+-- name: GetOAuth2Config :one
+SELECT * FROM oauth2_configs WHERE id = ? LIMIT 1;
+
+-- name: ListOAuth2Configs :many
+SELECT * FROM oauth2_configs WHERE workspace_id = ? ORDER BY name;
+
+-- name: CreateOAuth2Config :one
+INSERT INTO oauth2_configs (workspace_id, name, grant_type, token_url, client_id, client_secret, client_auth, username, password, scope, environment_id, token_variable, auto_refresh)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateOAuth2Config :one
+UPDATE oauth2_configs SET name = ?, grant_type = ?, token_url = ?, client_id = ?, client_secret = ?, client_auth = ?,
+    username = ?, password = ?, scope = ?, environment_id = ?, token_variable = ?, auto_refresh = ?,
+    refresh_token = '', expires_at = NULL, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? RETURNING *;
+
+-- name: SetOAuth2ConfigToken :exec
+UPDATE oauth2_configs SET refresh_token = ?, expires_at = ? WHERE id = ?;
+
+-- name: DeleteOAuth2Config :exec
+DELETE FROM oauth2_configs WHERE id = ?;
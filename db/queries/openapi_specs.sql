@@ -0,0 +1,14 @@
+-- name: GetOpenAPISpec :one
+SELECT * FROM openapi_specs WHERE id = ? LIMIT 1;
+
+-- name: ListOpenAPISpecs :many
+SELECT * FROM openapi_specs WHERE workspace_id = ? ORDER BY name;
+
+-- name: CreateOpenAPISpec :one
+INSERT INTO openapi_specs (workspace_id, name, spec) VALUES (?, ?, ?) RETURNING *;
+
+-- name: UpdateOpenAPISpec :one
+UPDATE openapi_specs SET name = ?, spec = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+
+-- name: DeleteOpenAPISpec :exec
+DELETE FROM openapi_specs WHERE id = ?;
@@ -0,0 +1,10 @@
+-- name: GetUserPreferences :one
+SELECT * FROM user_preferences WHERE token_hash = ? LIMIT 1;
+
+-- name: UpsertUserPreferences :one
+INSERT INTO user_preferences (token_hash, preferences) VALUES (?, ?)
+ON CONFLICT(token_hash) DO UPDATE SET preferences = excluded.preferences, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteUserPreferences :exec
+DELETE FROM user_preferences WHERE token_hash = ?;
@@ -0,0 +1,13 @@
+-- name: GetRequestDraft :one
+SELECT * FROM request_drafts WHERE request_id = ? LIMIT 1;
+
+-- name: UpsertRequestDraft :one
+INSERT INTO request_drafts (request_id, data, base_version) VALUES (?, ?, ?)
+ON CONFLICT(request_id) DO UPDATE SET
+    data = excluded.data,
+    revision = revision + 1,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteRequestDraft :exec
+DELETE FROM request_drafts WHERE request_id = ?;
@@ -8,8 +8,8 @@ SELECT * FROM requests WHERE workspace_id = ? ORDER BY sort_order ASC, name ASC;
 SELECT * FROM requests WHERE collection_id = ? ORDER BY sort_order ASC, name ASC;
 
 -- name: CreateRequest :one
-INSERT INTO requests (collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, workspace_id, pre_script, post_script, sort_order)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+INSERT INTO requests (collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, workspace_id, pre_script, post_script, sort_order, response_transform, auth, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
 
 -- name: UpdateRequest :one
 UPDATE requests SET
@@ -24,6 +24,15 @@ UPDATE requests SET
     proxy_id = ?,
     pre_script = ?,
     post_script = ?,
+    response_transform = ?,
+    auth = ?,
+    timeout_ms = ?,
+    retry_count = ?,
+    retry_backoff_ms = ?,
+    retry_on_status = ?,
+    disable_redirects = ?,
+    max_redirects = ?,
+    version = version + 1,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = ? RETURNING *;
 
@@ -36,5 +45,16 @@ UPDATE requests SET sort_order = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
 -- name: UpdateRequestCollectionAndSortOrder :exec
 UPDATE requests SET collection_id = ?, sort_order = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
 
+-- name: RecordRequestExecution :exec
+UPDATE requests SET execution_count = execution_count + 1, last_executed_at = CURRENT_TIMESTAMP WHERE id = ?;
+
 -- name: GetMaxRequestSortOrder :one
 SELECT COALESCE(MAX(sort_order), 0) AS max_sort_order FROM requests WHERE collection_id = ?;
+
+-- name: FindDuplicateRequests :many
+SELECT * FROM requests
+WHERE workspace_id = @workspace_id AND UPPER(method) = UPPER(@method) AND RTRIM(url, '/') = RTRIM(@url, '/') AND id != @exclude_id
+ORDER BY id;
+
+-- name: SetRequestArchived :execrows
+UPDATE requests SET archived_at = ? WHERE id = ? AND workspace_id = ?;
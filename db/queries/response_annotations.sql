@@ -0,0 +1,16 @@
+-- name: GetResponseAnnotation :one
+SELECT * FROM response_annotations WHERE id = ? LIMIT 1;
+
+-- name: ListResponseAnnotations :many
+SELECT * FROM response_annotations WHERE request_id = ? ORDER BY path;
+
+-- name: UpsertResponseAnnotation :one
+INSERT INTO response_annotations (request_id, path, description, deprecated) VALUES (?, ?, ?, ?)
+ON CONFLICT(request_id, path) DO UPDATE SET
+    description = excluded.description,
+    deprecated = excluded.deprecated,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteResponseAnnotation :exec
+DELETE FROM response_annotations WHERE id = ?;
@@ -0,0 +1,35 @@
+-- name: GetRunTimeline :one
+SELECT * FROM run_timelines WHERE run_id = ?;
+
+-- name: CreateRunTimeline :exec
+INSERT INTO run_timelines (run_id, workspace_id, flow_id, timeline) VALUES (?, ?, ?, ?);
+
+-- name: DeleteRunTimelinesByFlow :exec
+DELETE FROM run_timelines WHERE flow_id = ?;
+
+-- name: PruneRunTimelines :exec
+DELETE FROM run_timelines WHERE created_at < datetime('now', '-7 days');
+
+-- name: ListWorkspaceRunTimelines :many
+SELECT * FROM run_timelines WHERE workspace_id = ? ORDER BY id DESC LIMIT ?;
+
+-- name: ListLatestFlowRunTimelines :many
+SELECT * FROM run_timelines
+WHERE id IN (SELECT MAX(id) FROM run_timelines WHERE workspace_id = ? GROUP BY flow_id);
+
+-- name: ListRecentFlowRuns :many
+SELECT f.id AS flow_id, f.name AS flow_name,
+       CAST(json_extract(t.timeline, '$.success') AS INTEGER) AS success, t.created_at
+FROM flows f
+LEFT JOIN (
+    SELECT flow_id, timeline, created_at, ROW_NUMBER() OVER (PARTITION BY flow_id ORDER BY id DESC) AS rn
+    FROM run_timelines
+) t ON t.flow_id = f.id AND t.rn <= @runs
+WHERE f.workspace_id = @workspace_id AND f.archived_at IS NULL
+ORDER BY f.name, f.id, t.rn DESC;
+
+-- name: ListExpiredRunTimelines :many
+SELECT * FROM run_timelines WHERE created_at < @before ORDER BY id LIMIT @limit;
+
+-- name: DeleteRunTimeline :exec
+DELETE FROM run_timelines WHERE id = ?;
@@ -0,0 +1,10 @@
+-- name: GetEditorSession :one
+SELECT * FROM editor_sessions WHERE token_hash = ? AND workspace_id = ? LIMIT 1;
+
+-- name: UpsertEditorSession :one
+INSERT INTO editor_sessions (token_hash, workspace_id, state) VALUES (?, ?, ?)
+ON CONFLICT(token_hash, workspace_id) DO UPDATE SET state = excluded.state, updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteEditorSession :exec
+DELETE FROM editor_sessions WHERE token_hash = ? AND workspace_id = ?;
@@ -0,0 +1,16 @@
+-- name: ListSnippets :many
+SELECT * FROM snippets WHERE workspace_id = ? ORDER BY name;
+
+-- name: GetSnippet :one
+SELECT * FROM snippets WHERE workspace_id = ? AND name = ? LIMIT 1;
+
+-- name: UpsertSnippet :one
+INSERT INTO snippets (workspace_id, name, content, description) VALUES (?, ?, ?, ?)
+ON CONFLICT(workspace_id, name) DO UPDATE SET
+    content = excluded.content,
+    description = excluded.description,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteSnippet :exec
+DELETE FROM snippets WHERE id = ?;
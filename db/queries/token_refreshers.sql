@@ -0,0 +1,33 @@
+-- name: GetTokenRefresher :one
+SELECT * FROM token_refreshers WHERE id = ? LIMIT 1;
+
+-- name: ListTokenRefreshers :many
+SELECT * FROM token_refreshers WHERE workspace_id = ? ORDER BY name;
+
+-- name: ListEnabledTokenRefreshers :many
+SELECT * FROM token_refreshers WHERE enabled = 1 ORDER BY id;
+
+-- name: CreateTokenRefresher :one
+INSERT INTO token_refreshers (workspace_id, name, login_request_id, environment_id, extract_vars, expires_in_path, interval_seconds, enabled)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING *;
+
+-- name: UpdateTokenRefresher :one
+UPDATE token_refreshers SET name = ?, login_request_id = ?, environment_id = ?, extract_vars = ?, expires_in_path = ?,
+    interval_seconds = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ? RETURNING *;
+
+-- name: MarkTokenRefreshed :exec
+UPDATE token_refreshers SET last_attempt_at = CURRENT_TIMESTAMP, last_refreshed_at = CURRENT_TIMESTAMP, last_error = '', expires_at = ?
+WHERE id = ?;
+
+-- name: MarkTokenRefreshFailed :exec
+UPDATE token_refreshers SET last_attempt_at = CURRENT_TIMESTAMP, last_error = ? WHERE id = ?;
+
+-- name: RecordTokenRefreshRun :exec
+INSERT INTO token_refresh_runs (refresher_id) VALUES (?);
+
+-- name: PruneTokenRefreshRuns :exec
+DELETE FROM token_refresh_runs WHERE ran_at < datetime('now', '-2 days');
+
+-- name: DeleteTokenRefresher :exec
+DELETE FROM token_refreshers WHERE id = ?;
@@ -0,0 +1,20 @@
+-- name: ListWasmExtensions :many
+SELECT id, workspace_id, name, sha256, size, exports, created_at, updated_at
+FROM wasm_extensions WHERE workspace_id = ? ORDER BY name;
+
+-- name: GetWasmExtension :one
+SELECT * FROM wasm_extensions WHERE workspace_id = ? AND name = ? LIMIT 1;
+
+-- name: UpsertWasmExtension :one
+INSERT INTO wasm_extensions (workspace_id, name, module, sha256, size, exports)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(workspace_id, name) DO UPDATE SET
+    module = excluded.module,
+    sha256 = excluded.sha256,
+    size = excluded.size,
+    exports = excluded.exports,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING id, workspace_id, name, sha256, size, exports, created_at, updated_at;
+
+-- name: DeleteWasmExtension :exec
+DELETE FROM wasm_extensions WHERE id = ?;
@@ -0,0 +1,18 @@
+-- name: GetWorkspaceEncryptionKey :one
+SELECT * FROM workspace_encryption_keys WHERE workspace_id = ? LIMIT 1;
+
+-- name: UpsertWorkspaceEncryptionKey :one
+INSERT INTO workspace_encryption_keys (workspace_id, source, kms_key_env, salt, iterations, key_check)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(workspace_id) DO UPDATE SET
+    source = excluded.source,
+    kms_key_env = excluded.kms_key_env,
+    salt = excluded.salt,
+    iterations = excluded.iterations,
+    key_check = excluded.key_check,
+    version = workspace_encryption_keys.version + 1,
+    rotated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteWorkspaceEncryptionKey :exec
+DELETE FROM workspace_encryption_keys WHERE workspace_id = ?;
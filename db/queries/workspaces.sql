@@ -18,3 +18,36 @@ SELECT variables FROM workspaces WHERE id = ?;
 
 -- name: UpdateWorkspaceVariables :one
 UPDATE workspaces SET variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+
+-- name: UpdateWorkspaceVariableSet :one
+UPDATE workspaces SET variables = ?, secret_variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+
+-- name: GetWorkspaceSettings :one
+SELECT settings FROM workspaces WHERE id = ?;
+
+-- name: UpdateWorkspaceSettings :one
+UPDATE workspaces SET settings = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING *;
+
+-- name: GetWorkspaceUsage :one
+SELECT
+    (SELECT COUNT(*) FROM requests WHERE requests.workspace_id = ?) AS requests,
+    (SELECT COUNT(*) FROM request_history WHERE request_history.workspace_id = ?) AS history_rows,
+    (SELECT COALESCE(SUM(COALESCE(LENGTH(request_body), 0) + COALESCE(LENGTH(response_body), 0)), 0) FROM request_history WHERE request_history.workspace_id = ?)
+        + (SELECT COALESCE(SUM(size), 0) FROM uploaded_files WHERE uploaded_files.workspace_id = ?) AS storage_bytes,
+    (SELECT COUNT(*) FROM monitor_checks c JOIN monitors m ON m.id = c.monitor_id
+        WHERE m.workspace_id = ? AND c.offline_seconds = 0 AND c.checked_at >= date('now'))
+        + (SELECT COUNT(*) FROM environment_rotation_runs rr JOIN environment_rotations er ON er.id = rr.rotation_id
+        WHERE er.workspace_id = ? AND rr.ran_at >= date('now'))
+        + (SELECT COUNT(*) FROM token_refresh_runs tr JOIN token_refreshers t ON t.id = tr.refresher_id
+        WHERE t.workspace_id = ? AND tr.ran_at >= date('now')) AS scheduled_runs_today;
+
+-- name: ListWorkspaceChanges :many
+SELECT CAST('collection' AS TEXT) AS kind, id, name, created_at, updated_at FROM collections WHERE workspace_id = ?
+UNION ALL
+SELECT CAST('request' AS TEXT) AS kind, id, name, created_at, updated_at FROM requests WHERE workspace_id = ?
+UNION ALL
+SELECT CAST('flow' AS TEXT) AS kind, id, name, created_at, updated_at FROM flows WHERE workspace_id = ?
+UNION ALL
+SELECT CAST('environment' AS TEXT) AS kind, id, name, created_at, updated_at FROM environments WHERE workspace_id = ?
+ORDER BY updated_at DESC, id DESC
+LIMIT ?;
@@ -0,0 +1,11 @@
+-- name: CreateWSMessage :exec
+INSERT INTO ws_messages (history_id, seq, direction, format, payload, size, sent_at) VALUES (?, ?, ?, ?, ?, ?, ?);
+
+-- name: ListWSMessages :many
+SELECT * FROM ws_messages WHERE history_id = ? AND seq > ? ORDER BY seq LIMIT ?;
+
+-- name: DeleteWSMessagesByHistory :exec
+DELETE FROM ws_messages WHERE history_id = ?;
+
+-- name: DeleteOrphanWSMessages :execrows
+DELETE FROM ws_messages WHERE history_id NOT IN (SELECT id FROM request_history);
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// AdminHandler serves server-wide stats and maintenance actions for
+// self-hosted instances. It is not scoped to a workspace.
+type AdminHandler struct {
+	db              *sql.DB
+	flowRunner      *service.FlowRunner
+	requestExecutor *service.RequestExecutor
+	fileStorage     *service.FileStorage
+	instance        *service.Instance
+	startedAt       time.Time
+}
+
+func NewAdminHandler(db *sql.DB, fr *service.FlowRunner, re *service.RequestExecutor, fs *service.FileStorage, inst *service.Instance) *AdminHandler {
+	return &AdminHandler{db: db, flowRunner: fr, requestExecutor: re, fileStorage: fs, instance: inst, startedAt: time.Now()}
+}
+
+type TableStats struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+}
+
+type StorageStats struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+type AdminStatsResponse struct {
+	DatabaseBytes    int64               `json:"databaseBytes"`
+	FreeBytes        int64               `json:"freeBytes"` // reclaimable by VACUUM
+	Tables           []TableStats        `json:"tables"`
+	Storage          StorageStats        `json:"storage"` // uploaded files
+	ActiveRuns       []service.ActiveRun `json:"activeRuns"`
+	InFlightRequests int64               `json:"inFlightRequests"`
+	StartedAt        string              `json:"startedAt"`
+	UptimeSeconds    int64               `json:"uptimeSeconds"`
+}
+
+type MaintenanceResponse struct {
+	DurationMs  int64 `json:"durationMs"`
+	BeforeBytes int64 `json:"beforeBytes"`
+	AfterBytes  int64 `json:"afterBytes"`
+}
+
+type CacheClearResponse struct {
+	WasmModules int `json:"wasmModules"` // compiled modules dropped
+	HostSlots   int `json:"hostSlots"`   // idle host limiter entries dropped
+}
+
+type InstanceResponse struct {
+	ID          string `json:"id"`
+	Hostname    string `json:"hostname"`
+	Pid         int64  `json:"pid"`
+	Self        bool   `json:"self"`
+	StartedAt   string `json:"startedAt"`
+	HeartbeatAt string `json:"heartbeatAt"`
+}
+
+type JobLeaseResponse struct {
+	Name       string `json:"name"`
+	Holder     string `json:"holder"`
+	Active     bool   `json:"active"` // false once expired; the next instance to ask takes over
+	ExpiresAt  string `json:"expiresAt"`
+	AcquiredAt string `json:"acquiredAt"`
+}
+
+type InstancesResponse struct {
+	Instances []InstanceResponse `json:"instances"`
+	Leases    []JobLeaseResponse `json:"leases"`
+}
+
+// databaseSize returns the database file size and the part of it on the
+// freelist, both in bytes
+func (h *AdminHandler) databaseSize(r *http.Request) (int64, int64, error) {
+	var pageSize, pages, free int64
+	if err := h.db.QueryRowContext(r.Context(), "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, 0, err
+	}
+	if err := h.db.QueryRowContext(r.Context(), "PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, 0, err
+	}
+	if err := h.db.QueryRowContext(r.Context(), "PRAGMA freelist_count").Scan(&free); err != nil {
+		return 0, 0, err
+	}
+	return pages * pageSize, free * pageSize, nil
+}
+
+func (h *AdminHandler) tableStats(r *http.Request) ([]TableStats, error) {
+	rows, err := h.db.QueryContext(r.Context(), "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tables := make([]TableStats, 0, len(names))
+	for _, name := range names {
+		// Names come from sqlite_master, so quoting them is enough
+		t := TableStats{Name: name}
+		if err := h.db.QueryRowContext(r.Context(), `SELECT COUNT(*) FROM "`+name+`"`).Scan(&t.Rows); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	size, free, err := h.databaseSize(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	tables, err := h.tableStats(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := AdminStatsResponse{
+		DatabaseBytes:    size,
+		FreeBytes:        free,
+		Tables:           tables,
+		ActiveRuns:       h.flowRunner.ActiveRuns(),
+		InFlightRequests: h.requestExecutor.InFlight(),
+		StartedAt:        h.startedAt.UTC().Format(time.RFC3339),
+		UptimeSeconds:    int64(time.Since(h.startedAt).Seconds()),
+	}
+	if h.fileStorage != nil {
+		files, bytes, err := h.fileStorage.Usage()
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp.Storage = StorageStats{Files: files, Bytes: bytes}
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// maintain runs stmt and reports the database size around it
+func (h *AdminHandler) maintain(w http.ResponseWriter, r *http.Request, stmt string) {
+	before, _, err := h.databaseSize(r)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	start := time.Now()
+	if _, err := h.db.ExecContext(r.Context(), stmt); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := MaintenanceResponse{DurationMs: time.Since(start).Milliseconds(), BeforeBytes: before}
+	resp.AfterBytes, _, _ = h.databaseSize(r)
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Vacuum rebuilds the database file, returning free pages to the filesystem
+func (h *AdminHandler) Vacuum(w http.ResponseWriter, r *http.Request) {
+	h.maintain(w, r, "VACUUM")
+}
+
+// Reindex rebuilds every index and refreshes the query planner statistics
+func (h *AdminHandler) Reindex(w http.ResponseWriter, r *http.Request) {
+	h.maintain(w, r, "REINDEX; ANALYZE")
+}
+
+func (h *AdminHandler) ClearCaches(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, CacheClearResponse{
+		WasmModules: h.flowRunner.ClearCaches(r.Context()),
+		HostSlots:   h.requestExecutor.PruneHostLimits(),
+	})
+}
+
+// Instances lists the servers sharing this database and which of them runs
+// each background job
+func (h *AdminHandler) Instances(w http.ResponseWriter, r *http.Request) {
+	queries := repository.New(h.db)
+	instances, err := queries.ListInstances(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	leases, err := queries.ListJobLeases(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := InstancesResponse{
+		Instances: make([]InstanceResponse, 0, len(instances)),
+		Leases:    make([]JobLeaseResponse, 0, len(leases)),
+	}
+	for _, inst := range instances {
+		resp.Instances = append(resp.Instances, InstanceResponse{
+			ID:          inst.ID,
+			Hostname:    inst.Hostname,
+			Pid:         inst.Pid,
+			Self:        h.instance != nil && inst.ID == h.instance.ID,
+			StartedAt:   formatTime(inst.StartedAt),
+			HeartbeatAt: formatTime(inst.HeartbeatAt),
+		})
+	}
+	now := time.Now()
+	for _, l := range leases {
+		expires := time.UnixMilli(l.ExpiresAt)
+		resp.Leases = append(resp.Leases, JobLeaseResponse{
+			Name:       l.Name,
+			Holder:     l.Holder,
+			Active:     expires.After(now),
+			ExpiresAt:  expires.UTC().Format(time.RFC3339),
+			AcquiredAt: formatTime(l.AcquiredAt),
+		})
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,75 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestAdminStatsAndMaintenance(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	fs, err := service.NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.Store(strings.NewReader("hello"))
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, fs)
+	h := handler.NewAdminHandler(db, service.NewFlowRunner(q, re, vr), re, fs, nil)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/admin/stats", h.Stats)
+	r.Post("/api/admin/vacuum", h.Vacuum)
+	r.Post("/api/admin/reindex", h.Reindex)
+	r.Post("/api/admin/cache/clear", h.ClearCaches)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/admin/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats handler.AdminStatsResponse
+	readJSON(t, resp, &stats)
+	if resp.StatusCode != http.StatusOK || stats.DatabaseBytes <= 0 {
+		t.Fatalf("status %d, stats %+v", resp.StatusCode, stats)
+	}
+	rows := make(map[string]int64)
+	for _, tbl := range stats.Tables {
+		rows[tbl.Name] = tbl.Rows
+	}
+	if rows["workspaces"] != 1 || rows["requests"] != 0 {
+		t.Errorf("row counts = %v", rows)
+	}
+	if stats.Storage.Files != 1 || stats.Storage.Bytes != 5 {
+		t.Errorf("storage = %+v", stats.Storage)
+	}
+	if len(stats.ActiveRuns) != 0 || stats.StartedAt == "" {
+		t.Errorf("runtime stats = %+v", stats)
+	}
+
+	for _, path := range []string{"/api/admin/vacuum", "/api/admin/reindex"} {
+		resp, _ := postJSON(ts.URL+path, "")
+		var m handler.MaintenanceResponse
+		readJSON(t, resp, &m)
+		if resp.StatusCode != http.StatusOK || m.AfterBytes <= 0 {
+			t.Errorf("%s: status %d, %+v", path, resp.StatusCode, m)
+		}
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/admin/cache/clear", "")
+	var cleared handler.CacheClearResponse
+	readJSON(t, resp, &cleared)
+	if resp.StatusCode != http.StatusOK || cleared.WasmModules != 0 {
+		t.Errorf("status %d, %+v", resp.StatusCode, cleared)
+	}
+}
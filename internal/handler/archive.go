@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// Archived requests and flows keep their history, flow step links and
+// monitors but are left out of lists, search and batch runs unless
+// ?includeArchived=true.
+
+// includeArchived reports whether the list asks for archived entities too
+func includeArchived(r *http.Request) bool {
+	return r.URL.Query().Get("includeArchived") == "true"
+}
+
+// withoutArchived filters archived entities out of items in place
+func withoutArchived[T any](items []T, archivedAt func(T) sql.NullTime) []T {
+	kept := items[:0]
+	for _, item := range items {
+		if !archivedAt(item).Valid {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// setArchived archives or restores the {id} entity of the workspace through
+// set, responding 404 when there is none. It returns the ID on success.
+func setArchived(w http.ResponseWriter, r *http.Request, archive bool, notFound string, set func(ctx context.Context, id, wsID int64, at sql.NullTime) (int64, error)) (int64, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return 0, false
+	}
+	var at sql.NullTime
+	if archive {
+		at = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	}
+	n, err := set(r.Context(), id, middleware.GetWorkspaceID(r.Context()), at)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return 0, false
+	}
+	if n == 0 {
+		respondError(w, http.StatusNotFound, notFound)
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *RequestHandler) setArchived(w http.ResponseWriter, r *http.Request, archive bool) {
+	id, ok := setArchived(w, r, archive, "Request not found", func(ctx context.Context, id, wsID int64, at sql.NullTime) (int64, error) {
+		return h.queries.SetRequestArchived(ctx, repository.SetRequestArchivedParams{ArchivedAt: at, ID: id, WorkspaceID: wsID})
+	})
+	if !ok {
+		return
+	}
+	req, err := h.queries.GetRequest(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toRequestResponse(req))
+}
+
+// Archive hides a request from lists and batch runs without deleting it
+func (h *RequestHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+// Unarchive restores an archived request
+func (h *RequestHandler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
+
+func (h *FlowHandler) setArchived(w http.ResponseWriter, r *http.Request, archive bool) {
+	id, ok := setArchived(w, r, archive, "Flow not found", func(ctx context.Context, id, wsID int64, at sql.NullTime) (int64, error) {
+		return h.queries.SetFlowArchived(ctx, repository.SetFlowArchivedParams{ArchivedAt: at, ID: id, WorkspaceID: wsID})
+	})
+	if !ok {
+		return
+	}
+	flow, err := h.queries.GetFlow(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toFlowResponse(flow))
+}
+
+// Archive hides a flow from lists and batch runs without deleting it
+func (h *FlowHandler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, true)
+}
+
+// Unarchive restores an archived flow
+func (h *FlowHandler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	h.setArchived(w, r, false)
+}
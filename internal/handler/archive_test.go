@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestArchive_RequestsAndFlows(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	fr := service.NewFlowRunner(q, re, vr)
+	reqH := handler.NewRequestHandler(q, re, fr)
+	flowH := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/requests", reqH.List)
+	r.Post("/api/requests", reqH.Create)
+	r.Post("/api/requests/{id}/archive", reqH.Archive)
+	r.Post("/api/requests/{id}/unarchive", reqH.Unarchive)
+	r.Get("/api/flows", flowH.List)
+	r.Post("/api/flows", flowH.Create)
+	r.Post("/api/flows/{id}/archive", flowH.Archive)
+	r.Post("/api/flows/{id}/unarchive", flowH.Unarchive)
+	r.Post("/api/run", reqH.RunByName)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	var old, current handler.RequestResponse
+	resp, _ := postJSON(ts.URL+"/api/requests", `{"name":"Login","method":"GET","url":"http://localhost/old"}`)
+	readJSON(t, resp, &old)
+	resp, _ = postJSON(ts.URL+"/api/requests", `{"name":"Login v2","method":"GET","url":"http://localhost/new"}`)
+	readJSON(t, resp, &current)
+	var flow handler.FlowResponse
+	resp, _ = postJSON(ts.URL+"/api/flows", `{"name":"Checkout"}`)
+	readJSON(t, resp, &flow)
+
+	resp, _ = postJSON(fmt.Sprintf("%s/api/requests/%d/archive", ts.URL, old.ID), `{}`)
+	var archived handler.RequestResponse
+	readJSON(t, resp, &archived)
+	if !archived.Archived || archived.ArchivedAt == "" {
+		t.Fatalf("archived request = %+v", archived)
+	}
+
+	var requests []handler.RequestResponse
+	resp, _ = http.Get(ts.URL + "/api/requests")
+	readJSON(t, resp, &requests)
+	if len(requests) != 1 || requests[0].ID != current.ID {
+		t.Errorf("default list = %+v", requests)
+	}
+	resp, _ = http.Get(ts.URL + "/api/requests?includeArchived=true")
+	readJSON(t, resp, &requests)
+	if len(requests) != 2 {
+		t.Errorf("includeArchived list = %+v", requests)
+	}
+
+	// Run by name skips the archived exact match
+	resp, _ = postJSON(ts.URL+"/api/run", `{"type":"request","name":"login"}`)
+	var run handler.RunByNameResponse
+	readJSON(t, resp, &run)
+	if run.ID != current.ID {
+		t.Errorf("run by name picked %d, want %d", run.ID, current.ID)
+	}
+
+	resp, _ = postJSON(fmt.Sprintf("%s/api/requests/%d/unarchive", ts.URL, old.ID), `{}`)
+	var restored handler.RequestResponse
+	readJSON(t, resp, &restored)
+	if restored.Archived || restored.ArchivedAt != "" {
+		t.Errorf("unarchived request = %+v", restored)
+	}
+	resp, _ = http.Get(ts.URL + "/api/requests")
+	readJSON(t, resp, &requests)
+	if len(requests) != 2 {
+		t.Errorf("list after unarchive = %+v", requests)
+	}
+
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/archive", ts.URL, flow.ID), `{}`)
+	resp.Body.Close()
+	var flows []handler.FlowResponse
+	resp, _ = http.Get(ts.URL + "/api/flows")
+	readJSON(t, resp, &flows)
+	if len(flows) != 0 {
+		t.Errorf("flows = %+v", flows)
+	}
+	resp, _ = http.Get(ts.URL + "/api/flows?includeArchived=true")
+	readJSON(t, resp, &flows)
+	if len(flows) != 1 || !flows[0].Archived {
+		t.Errorf("includeArchived flows = %+v", flows)
+	}
+
+	// Other workspaces cannot archive the entities
+	for _, path := range []string{
+		fmt.Sprintf("/api/requests/%d/archive", current.ID),
+		fmt.Sprintf("/api/flows/%d/unarchive", flow.ID),
+		"/api/requests/999/archive",
+	} {
+		resp, _ = postJSONWithWorkspace(ts.URL+path, `{}`, 2)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
@@ -7,17 +7,24 @@ import (
 
 	"relay/internal/middleware"
 	"relay/internal/repository"
+	"relay/internal/service"
 )
 
 type CollectionHandler struct {
 	queries *repository.Queries
 	db      *sql.DB
+	keys    *service.KeyRing
 }
 
 func NewCollectionHandler(queries *repository.Queries, db *sql.DB) *CollectionHandler {
 	return &CollectionHandler{queries: queries, db: db}
 }
 
+// SetKeyRing sets the workspace keys secret variables are sealed with
+func (h *CollectionHandler) SetKeyRing(keys *service.KeyRing) {
+	h.keys = keys
+}
+
 type CollectionRequest struct {
 	Name     string `json:"name"`
 	ParentID *int64 `json:"parentId"`
@@ -44,6 +51,9 @@ func (h *CollectionHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	// Get all requests
 	requests, _ := h.queries.ListRequests(r.Context(), wsID)
+	if !includeArchived(r) {
+		requests = withoutArchived(requests, func(req repository.Request) sql.NullTime { return req.ArchivedAt })
+	}
 
 	// Build request map by collection ID
 	requestsByCollection := make(map[int64][]RequestResponse)
@@ -277,6 +287,10 @@ func (h *CollectionHandler) isDescendant(ctx context.Context, candidateID, ances
 }
 
 func (h *CollectionHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, service.QuotaRequests) {
+		return
+	}
+
 	id, err := parseID(r, "id")
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid ID")
@@ -457,3 +471,45 @@ func (h *CollectionHandler) Reorder(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *CollectionHandler) variableScope() variableScope {
+	return variableScope{
+		name: "Collection",
+		load: func(ctx context.Context, id int64) (sql.NullString, sql.NullString, error) {
+			c, err := h.queries.GetCollection(ctx, id)
+			return c.Variables, c.SecretVariables, err
+		},
+		save: func(ctx context.Context, id int64, vars, secrets sql.NullString) error {
+			_, err := h.queries.UpdateCollectionVariableSet(ctx, repository.UpdateCollectionVariableSetParams{
+				Variables:       vars,
+				SecretVariables: secrets,
+				ID:              id,
+			})
+			return err
+		},
+		seal: func(ctx context.Context, id int64, values map[string]string, secrets map[string]bool) error {
+			c, err := h.queries.GetCollection(ctx, id)
+			if err != nil {
+				return err
+			}
+			return h.keys.SealVariables(ctx, c.WorkspaceID, values, secrets)
+		},
+	}
+}
+
+// ListVariables returns the collection variables; secret values are omitted
+func (h *CollectionHandler) ListVariables(w http.ResponseWriter, r *http.Request) {
+	listVariables(w, r, h.variableScope())
+}
+
+func (h *CollectionHandler) ReplaceVariables(w http.ResponseWriter, r *http.Request) {
+	replaceVariables(w, r, h.variableScope())
+}
+
+func (h *CollectionHandler) SetVariable(w http.ResponseWriter, r *http.Request) {
+	setVariable(w, r, h.variableScope())
+}
+
+func (h *CollectionHandler) DeleteVariable(w http.ResponseWriter, r *http.Request) {
+	deleteVariable(w, r, h.variableScope())
+}
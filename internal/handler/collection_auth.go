@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type CollectionAuthResponse struct {
+	CollectionID int64 `json:"collectionId"`
+	service.RequestAuth
+}
+
+func toCollectionAuthResponse(c repository.Collection) CollectionAuthResponse {
+	resp := CollectionAuthResponse{CollectionID: c.ID, RequestAuth: service.RequestAuth{Type: "inherit"}}
+	if c.Auth.String != "" {
+		json.Unmarshal([]byte(c.Auth.String), &resp.RequestAuth)
+	}
+	return resp
+}
+
+// GetAuth returns the collection's own auth block; "inherit" means its
+// requests use the parent collection's (then the workspace's)
+func (h *CollectionHandler) GetAuth(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	c, err := h.queries.GetCollection(r.Context(), id)
+	if err != nil || c.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, toCollectionAuthResponse(c))
+}
+
+// UpdateAuth sets the auth block inherited by the collection's requests;
+// type "" or "inherit" removes it
+func (h *CollectionHandler) UpdateAuth(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req service.RequestAuth
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	c, err := h.queries.GetCollection(r.Context(), id)
+	if err != nil || c.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+
+	raw := sql.NullString{String: "", Valid: true}
+	if req.Type != "" && req.Type != "inherit" {
+		if req.Type != "none" {
+			if err := req.Validate(); err != nil {
+				respondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		data, err := json.Marshal(req)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		raw.String = string(data)
+	}
+
+	c, err = h.queries.UpdateCollectionAuth(r.Context(), repository.UpdateCollectionAuthParams{
+		Auth: raw,
+		ID:   id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "Collection not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toCollectionAuthResponse(c))
+}
@@ -0,0 +1,70 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCollection_Auth(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	h := handler.NewCollectionHandler(q, db)
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/collections/{id}/auth", h.GetAuth)
+	r.Put("/api/collections/{id}/auth", h.UpdateAuth)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	col, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "API", WorkspaceID: 1})
+	url := fmt.Sprintf("%s/api/collections/%d/auth", ts.URL, col.ID)
+
+	var auth handler.CollectionAuthResponse
+	resp, _ := http.Get(url)
+	readJSON(t, resp, &auth)
+	if auth.CollectionID != col.ID || auth.Type != "inherit" {
+		t.Errorf("default = %+v", auth)
+	}
+
+	resp, _ = putJSON(url, `{"type":"bearer","token":"{{token}}"}`)
+	readJSON(t, resp, &auth)
+	if auth.Type != "bearer" || auth.Token != "{{token}}" {
+		t.Errorf("updated = %+v", auth)
+	}
+	var persisted handler.CollectionAuthResponse
+	resp, _ = http.Get(url)
+	readJSON(t, resp, &persisted)
+	if persisted.Type != "bearer" || persisted.Token != "{{token}}" {
+		t.Errorf("persisted = %+v", persisted)
+	}
+
+	resp, _ = putJSON(url, `{"type":"apikey","in":"cookie","key":"k"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid block: status = %d, want 400", resp.StatusCode)
+	}
+
+	var cleared handler.CollectionAuthResponse
+	resp, _ = putJSON(url, `{"type":"inherit"}`)
+	readJSON(t, resp, &cleared)
+	if cleared.Type != "inherit" || cleared.Token != "" {
+		t.Errorf("cleared = %+v", cleared)
+	}
+
+	ws, _ := q.CreateWorkspace(ctx, "Other")
+	resp, _ = getWithWorkspace(url, ws.ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", resp.StatusCode)
+	}
+}
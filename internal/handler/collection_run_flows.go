@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type CollectionRunFlowsResponse struct {
+	CollectionID int64 `json:"collectionId"`
+	service.CollectionRunFlows
+}
+
+func toCollectionRunFlowsResponse(c repository.Collection) CollectionRunFlowsResponse {
+	resp := CollectionRunFlowsResponse{CollectionID: c.ID}
+	if f := service.ParseCollectionRunFlows(c.RunFlows); f != nil {
+		resp.CollectionRunFlows = *f
+	}
+	return resp
+}
+
+// GetRunFlows returns the collection's own setup/teardown flows (not the
+// ones inherited from ancestors)
+func (h *CollectionHandler) GetRunFlows(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	c, err := h.queries.GetCollection(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, toCollectionRunFlowsResponse(c))
+}
+
+// UpdateRunFlows sets the collection's setup/teardown flows; zero IDs remove them
+func (h *CollectionHandler) UpdateRunFlows(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req service.CollectionRunFlows
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	c, err := h.queries.GetCollection(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+	for field, flowID := range map[string]int64{"setupFlowId": req.SetupFlowID, "teardownFlowId": req.TeardownFlowID} {
+		if flowID == 0 {
+			continue
+		}
+		flow, err := h.queries.GetFlow(r.Context(), flowID)
+		if err != nil || flow.WorkspaceID != c.WorkspaceID {
+			respondError(w, http.StatusBadRequest, field+" must be a flow of the collection's workspace")
+			return
+		}
+	}
+
+	raw := sql.NullString{String: "", Valid: true}
+	if req.SetupFlowID != 0 || req.TeardownFlowID != 0 {
+		data, err := json.Marshal(req)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		raw.String = string(data)
+	}
+
+	c, err = h.queries.UpdateCollectionRunFlows(r.Context(), repository.UpdateCollectionRunFlowsParams{
+		RunFlows: raw,
+		ID:       id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "Collection not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toCollectionRunFlowsResponse(c))
+}
@@ -0,0 +1,65 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCollection_RunFlows(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	h := handler.NewCollectionHandler(q, db)
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/collections/{id}/run-flows", h.GetRunFlows)
+	r.Put("/api/collections/{id}/run-flows", h.UpdateRunFlows)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	col, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "API", WorkspaceID: 1})
+	seed, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "seed", WorkspaceID: 1})
+	ws, _ := q.CreateWorkspace(ctx, "Other")
+	foreign, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "foreign", WorkspaceID: ws.ID})
+	url := fmt.Sprintf("%s/api/collections/%d/run-flows", ts.URL, col.ID)
+
+	var flows handler.CollectionRunFlowsResponse
+	resp, _ := putJSON(url, fmt.Sprintf(`{"setupFlowId":%d,"scheduled":true}`, seed.ID))
+	readJSON(t, resp, &flows)
+	if flows.SetupFlowID != seed.ID || flows.TeardownFlowID != 0 || !flows.Scheduled {
+		t.Errorf("updated = %+v", flows)
+	}
+	resp, _ = http.Get(url)
+	readJSON(t, resp, &flows)
+	if flows.CollectionID != col.ID || flows.SetupFlowID != seed.ID {
+		t.Errorf("persisted = %+v", flows)
+	}
+
+	resp, _ = putJSON(url, fmt.Sprintf(`{"teardownFlowId":%d}`, foreign.ID))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("flow of another workspace: status = %d, want 400", resp.StatusCode)
+	}
+
+	var cleared handler.CollectionRunFlowsResponse
+	resp, _ = putJSON(url, `{}`)
+	readJSON(t, resp, &cleared)
+	if cleared.SetupFlowID != 0 || cleared.Scheduled {
+		t.Errorf("cleared = %+v", cleared)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/collections/999/run-flows")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing collection: status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// CookieHandler manages the workspace's cookie jar, which requests and flow
+// steps fill from Set-Cookie headers and send back automatically
+type CookieHandler struct {
+	queries *repository.Queries
+}
+
+func NewCookieHandler(queries *repository.Queries) *CookieHandler {
+	return &CookieHandler{queries: queries}
+}
+
+// CookieRequest creates or replaces a jar cookie
+type CookieRequest struct {
+	Domain    string `json:"domain"`
+	Path      string `json:"path"` // default "/"
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	ExpiresAt string `json:"expiresAt"` // RFC3339; empty for a session cookie, kept until deleted
+	Secure    bool   `json:"secure"`
+	HttpOnly  bool   `json:"httpOnly"`
+	HostOnly  bool   `json:"hostOnly"` // sent to domain only, not its subdomains
+}
+
+type CookieResponse struct {
+	ID        int64  `json:"id"`
+	Domain    string `json:"domain"`
+	Path      string `json:"path"`
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+	Secure    bool   `json:"secure"`
+	HttpOnly  bool   `json:"httpOnly"`
+	HostOnly  bool   `json:"hostOnly"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+func toCookieResponse(c repository.Cookie) CookieResponse {
+	return CookieResponse{
+		ID:        c.ID,
+		Domain:    c.Domain,
+		Path:      c.Path,
+		Name:      c.Name,
+		Value:     c.Value,
+		ExpiresAt: formatTime(c.ExpiresAt),
+		Secure:    c.Secure == 1,
+		HttpOnly:  c.HttpOnly == 1,
+		HostOnly:  c.HostOnly == 1,
+		CreatedAt: formatTime(c.CreatedAt),
+		UpdatedAt: formatTime(c.UpdatedAt),
+	}
+}
+
+// List returns the workspace's unexpired cookies; ?domain= keeps a domain's
+// own cookies and ?url= the cookies a request to that URL would send
+func (h *CookieHandler) List(w http.ResponseWriter, r *http.Request) {
+	cookies, err := h.queries.ListCookies(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	domain := strings.ToLower(r.URL.Query().Get("domain"))
+	target, err := parseCookieURL(r.URL.Query().Get("url"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "url must be an absolute http(s) URL")
+		return
+	}
+
+	now := time.Now()
+	resp := []CookieResponse{}
+	for _, c := range cookies {
+		if c.ExpiresAt.Valid && !c.ExpiresAt.Time.After(now) {
+			continue
+		}
+		if (domain != "" && c.Domain != domain) || (target != nil && !service.CookieMatches(c, target)) {
+			continue
+		}
+		resp = append(resp, toCookieResponse(c))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *CookieHandler) Get(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.cookieByID(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, toCookieResponse(c))
+}
+
+// Create adds a cookie, replacing the one with the same domain, path and name
+func (h *CookieHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CookieRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	p, ok := cookieParams(w, req)
+	if !ok {
+		return
+	}
+
+	c, err := h.queries.UpsertCookie(r.Context(), repository.UpsertCookieParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Domain:      p.Domain,
+		Path:        p.Path,
+		Name:        p.Name,
+		Value:       p.Value,
+		ExpiresAt:   p.ExpiresAt,
+		Secure:      p.Secure,
+		HttpOnly:    p.HttpOnly,
+		HostOnly:    p.HostOnly,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, toCookieResponse(c))
+}
+
+func (h *CookieHandler) Update(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.cookieByID(w, r)
+	if !ok {
+		return
+	}
+	var req CookieRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	p, ok := cookieParams(w, req)
+	if !ok {
+		return
+	}
+	p.ID = existing.ID
+
+	c, err := h.queries.UpdateCookie(r.Context(), p)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "A cookie with this domain, path and name already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toCookieResponse(c))
+}
+
+func (h *CookieHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.cookieByID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.queries.DeleteCookie(r.Context(), c.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Clear empties the jar, or with ?domain= removes a domain's own cookies
+func (h *CookieHandler) Clear(w http.ResponseWriter, r *http.Request) {
+	wsID := middleware.GetWorkspaceID(r.Context())
+	var deleted int64
+	var err error
+	if domain := strings.ToLower(r.URL.Query().Get("domain")); domain != "" {
+		deleted, err = h.queries.DeleteDomainCookies(r.Context(), repository.DeleteDomainCookiesParams{WorkspaceID: wsID, Domain: domain})
+	} else {
+		deleted, err = h.queries.DeleteWorkspaceCookies(r.Context(), wsID)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]int64{"deleted": deleted})
+}
+
+// parseCookieURL parses the ?url= filter; nil when absent
+func parseCookieURL(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, errors.New("invalid url")
+	}
+	return u, nil
+}
+
+// cookieByID loads the {id} cookie of the current workspace, responding 400/404
+func (h *CookieHandler) cookieByID(w http.ResponseWriter, r *http.Request) (repository.Cookie, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.Cookie{}, false
+	}
+	c, err := h.queries.GetCookie(r.Context(), id)
+	if err != nil || c.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Cookie not found")
+		return repository.Cookie{}, false
+	}
+	return c, true
+}
+
+// cookieParams validates req, responding 400
+func cookieParams(w http.ResponseWriter, req CookieRequest) (repository.UpdateCookieParams, bool) {
+	req.Domain = strings.TrimPrefix(strings.ToLower(req.Domain), ".")
+	if req.Path == "" {
+		req.Path = "/"
+	}
+	if err := service.ValidateCookie(req.Domain, req.Path, req.Name, req.Value); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return repository.UpdateCookieParams{}, false
+	}
+	p := repository.UpdateCookieParams{Domain: req.Domain, Path: req.Path, Name: req.Name, Value: req.Value}
+	if req.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "expiresAt must be an RFC3339 time")
+			return repository.UpdateCookieParams{}, false
+		}
+		p.ExpiresAt = sql.NullTime{Time: t.UTC(), Valid: true}
+	}
+	if req.Secure {
+		p.Secure = 1
+	}
+	if req.HttpOnly {
+		p.HttpOnly = 1
+	}
+	if req.HostOnly {
+		p.HostOnly = 1
+	}
+	return p, true
+}
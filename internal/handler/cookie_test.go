@@ -0,0 +1,110 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCookies_CRUD(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	h := handler.NewCookieHandler(q)
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/cookies", h.List)
+	r.Post("/api/cookies", h.Create)
+	r.Delete("/api/cookies", h.Clear)
+	r.Get("/api/cookies/{id}", h.Get)
+	r.Put("/api/cookies/{id}", h.Update)
+	r.Delete("/api/cookies/{id}", h.Delete)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for _, body := range []string{
+		`{"domain":"example.com","name":"bad name","value":"x"}`,
+		`{"domain":"https://example.com","name":"sid","value":"x"}`,
+		`{"domain":"example.com","path":"api","name":"sid","value":"x"}`,
+		`{"domain":"example.com","name":"sid","value":"x","expiresAt":"tomorrow"}`,
+	} {
+		resp, _ := postJSON(ts.URL+"/api/cookies", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	var c handler.CookieResponse
+	resp, _ := postJSON(ts.URL+"/api/cookies", `{"domain":".Example.com","name":"sid","value":"1","secure":true}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status = %d", resp.StatusCode)
+	}
+	readJSON(t, resp, &c)
+	if c.Domain != "example.com" || c.Path != "/" || !c.Secure || c.HostOnly {
+		t.Errorf("created = %+v", c)
+	}
+	// Same domain, path and name replaces the cookie
+	resp, _ = postJSON(ts.URL+"/api/cookies", `{"domain":"example.com","name":"sid","value":"2"}`)
+	var replaced handler.CookieResponse
+	readJSON(t, resp, &replaced)
+	if replaced.ID != c.ID || replaced.Value != "2" || replaced.Secure {
+		t.Errorf("replaced = %+v", replaced)
+	}
+	resp, _ = postJSON(ts.URL+"/api/cookies", `{"domain":"other.com","name":"theme","value":"dark","hostOnly":true,"expiresAt":"2000-01-01T00:00:00Z"}`)
+	readJSON(t, resp, &handler.CookieResponse{})
+	resp, _ = postJSON(ts.URL+"/api/cookies", `{"domain":"other.com","path":"/admin","name":"admin","value":"1"}`)
+	var admin handler.CookieResponse
+	readJSON(t, resp, &admin)
+
+	// Expired cookies are not listed
+	var list []handler.CookieResponse
+	resp, _ = http.Get(ts.URL + "/api/cookies")
+	readJSON(t, resp, &list)
+	if len(list) != 2 {
+		t.Errorf("list = %+v", list)
+	}
+	resp, _ = http.Get(ts.URL + "/api/cookies?url=" + "http://www.example.com/api")
+	readJSON(t, resp, &list)
+	if len(list) != 1 || list[0].Name != "sid" {
+		t.Errorf("cookies for url = %+v", list)
+	}
+
+	resp, _ = putJSON(fmt.Sprintf("%s/api/cookies/%d", ts.URL, admin.ID), `{"domain":"example.com","name":"sid","value":"3"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("update onto existing cookie: status = %d, want 409", resp.StatusCode)
+	}
+	resp, _ = putJSON(fmt.Sprintf("%s/api/cookies/%d", ts.URL, admin.ID), `{"domain":"other.com","path":"/admin","name":"admin","value":"2","httpOnly":true}`)
+	readJSON(t, resp, &admin)
+	if admin.Value != "2" || !admin.HttpOnly {
+		t.Errorf("updated = %+v", admin)
+	}
+
+	// Cookies of other workspaces are not visible
+	resp, _ = getWithWorkspace(fmt.Sprintf("%s/api/cookies/%d", ts.URL, c.ID), 2)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace get: status = %d, want 404", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/cookies/%d", ts.URL, c.ID), nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status = %d", resp.StatusCode)
+	}
+
+	req, _ = http.NewRequest(http.MethodDelete, ts.URL+"/api/cookies?domain=other.com", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	var cleared map[string]int64
+	readJSON(t, resp, &cleared)
+	if cleared["deleted"] != 2 {
+		t.Errorf("cleared = %v", cleared)
+	}
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type DataFactoryHandler struct {
+	queries *repository.Queries
+}
+
+func NewDataFactoryHandler(queries *repository.Queries) *DataFactoryHandler {
+	return &DataFactoryHandler{queries: queries}
+}
+
+type SequenceResponse struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Value     int64  `json:"value"` // last value handed out; the next use gets value+1
+	UpdatedAt string `json:"updatedAt"`
+}
+
+type PoolResponse struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Mode      string   `json:"mode"`
+	Items     []string `json:"items"`
+	Cursor    int64    `json:"cursor"`
+	UpdatedAt string   `json:"updatedAt"`
+}
+
+type UpdateSequenceRequest struct {
+	Value int64 `json:"value"`
+}
+
+type UpdatePoolRequest struct {
+	Mode  string   `json:"mode"`
+	Items []string `json:"items"`
+}
+
+func toSequenceResponse(s repository.Sequence) SequenceResponse {
+	return SequenceResponse{ID: s.ID, Name: s.Name, Value: s.Value, UpdatedAt: formatTime(s.UpdatedAt)}
+}
+
+func toPoolResponse(p repository.ValuePool) PoolResponse {
+	return PoolResponse{
+		ID:        p.ID,
+		Name:      p.Name,
+		Mode:      p.Mode,
+		Items:     service.ParsePoolItems(p.Items),
+		Cursor:    p.Cursor,
+		UpdatedAt: formatTime(p.UpdatedAt),
+	}
+}
+
+func (h *DataFactoryHandler) ListSequences(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.queries.ListSequences(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := make([]SequenceResponse, len(rows))
+	for i, s := range rows {
+		result[i] = toSequenceResponse(s)
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// UpdateSequence creates a sequence or resets its current value
+func (h *DataFactoryHandler) UpdateSequence(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !service.ValidDataFactoryName(name) {
+		respondError(w, http.StatusBadRequest, "Invalid sequence name (letters, digits, '_', '.' and '-', up to 64 characters)")
+		return
+	}
+
+	var req UpdateSequenceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	s, err := h.queries.UpsertSequence(r.Context(), repository.UpsertSequenceParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        name,
+		Value:       req.Value,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toSequenceResponse(s))
+}
+
+func (h *DataFactoryHandler) DeleteSequence(w http.ResponseWriter, r *http.Request) {
+	s, err := h.queries.GetSequence(r.Context(), repository.GetSequenceParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        chi.URLParam(r, "name"),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Sequence not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := h.queries.DeleteSequence(r.Context(), s.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *DataFactoryHandler) ListPools(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.queries.ListValuePools(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := make([]PoolResponse, len(rows))
+	for i, p := range rows {
+		result[i] = toPoolResponse(p)
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// UpdatePool creates or replaces a pool; replacing it restarts round-robin
+// picks from the first item
+func (h *DataFactoryHandler) UpdatePool(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !service.ValidDataFactoryName(name) {
+		respondError(w, http.StatusBadRequest, "Invalid pool name (letters, digits, '_', '.' and '-', up to 64 characters)")
+		return
+	}
+
+	var req UpdatePoolRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = service.PoolModeRoundRobin
+	}
+	if err := service.ValidatePool(req.Mode, req.Items); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	items, err := json.Marshal(req.Items)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	p, err := h.queries.UpsertValuePool(r.Context(), repository.UpsertValuePoolParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        name,
+		Mode:        req.Mode,
+		Items:       string(items),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toPoolResponse(p))
+}
+
+func (h *DataFactoryHandler) DeletePool(w http.ResponseWriter, r *http.Request) {
+	p, err := h.queries.GetValuePool(r.Context(), repository.GetValuePoolParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        chi.URLParam(r, "name"),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Pool not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := h.queries.DeleteValuePool(r.Context(), p.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
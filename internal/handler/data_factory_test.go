@@ -0,0 +1,116 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupDataFactoryTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	h := handler.NewDataFactoryHandler(testutil.SetupTestDB(t))
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/sequences", h.ListSequences)
+	r.Put("/api/sequences/{name}", h.UpdateSequence)
+	r.Delete("/api/sequences/{name}", h.DeleteSequence)
+	r.Get("/api/pools", h.ListPools)
+	r.Put("/api/pools/{name}", h.UpdatePool)
+	r.Delete("/api/pools/{name}", h.DeletePool)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestDataFactory_Sequences(t *testing.T) {
+	ts := setupDataFactoryTestServer(t)
+
+	resp, err := putJSON(ts.URL+"/api/sequences/userIndex", `{"value":41}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seq handler.SequenceResponse
+	readJSON(t, resp, &seq)
+	if seq.Name != "userIndex" || seq.Value != 41 {
+		t.Errorf("created = %+v", seq)
+	}
+
+	resp, _ = putJSON(ts.URL+"/api/sequences/bad%20name", `{"value":1}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid name: status %d", resp.StatusCode)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/sequences")
+	var list []handler.SequenceResponse
+	readJSON(t, resp, &list)
+	if len(list) != 1 || list[0].ID != seq.ID {
+		t.Errorf("list = %+v", list)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/sequences/userIndex", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status %d", resp.StatusCode)
+	}
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("delete again: status %d", resp.StatusCode)
+	}
+}
+
+func TestDataFactory_Pools(t *testing.T) {
+	ts := setupDataFactoryTestServer(t)
+
+	resp, err := putJSON(ts.URL+"/api/pools/users", `{"items":["alice","bob"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pool handler.PoolResponse
+	readJSON(t, resp, &pool)
+	if pool.Mode != "roundRobin" || len(pool.Items) != 2 || pool.Items[1] != "bob" {
+		t.Errorf("created = %+v", pool)
+	}
+
+	for name, body := range map[string]string{
+		"bad mode": `{"mode":"shuffle","items":["a"]}`,
+		"empty":    `{"items":[]}`,
+	} {
+		resp, _ := putJSON(ts.URL+"/api/pools/users", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d", name, resp.StatusCode)
+		}
+	}
+
+	resp, _ = putJSON(ts.URL+"/api/pools/users", `{"mode":"random","items":["carol"]}`)
+	readJSON(t, resp, &pool)
+	if pool.Mode != "random" || len(pool.Items) != 1 {
+		t.Errorf("replaced = %+v", pool)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/pools")
+	var list []handler.PoolResponse
+	readJSON(t, resp, &list)
+	if len(list) != 1 || list[0].ID != pool.ID {
+		t.Errorf("list = %+v", list)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/pools/users", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status %d", resp.StatusCode)
+	}
+}
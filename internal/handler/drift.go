@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type DriftHandler struct {
+	queries *repository.Queries
+	checker *service.ContractDriftChecker
+}
+
+func NewDriftHandler(queries *repository.Queries, checker *service.ContractDriftChecker) *DriftHandler {
+	return &DriftHandler{queries: queries, checker: checker}
+}
+
+type DriftCheckRequest struct {
+	// WebhookURL receives the report (POST, JSON) when drift is detected
+	WebhookURL string `json:"webhookUrl"`
+}
+
+type DriftBaselineRequest struct {
+	// HistoryID is the response to use; 0 takes the latest successful JSON response
+	HistoryID int64 `json:"historyId"`
+}
+
+type DriftBaselineResponse struct {
+	RequestID int64  `json:"requestId"`
+	HistoryID int64  `json:"historyId,omitempty"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// validWebhookURL reports whether s is an absolute http(s) URL
+func validWebhookURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// CheckCollection runs the collection against the live API and reports response
+// fields that appeared, disappeared or changed type against each request's
+// saved baseline, and responses that do not match the workspace's OpenAPI specs
+func (h *DriftHandler) CheckCollection(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req DriftCheckRequest
+	if err := decodeJSON(r, &req); err != nil {
+		req = DriftCheckRequest{} // body is optional
+	}
+	if req.WebhookURL != "" && !validWebhookURL(req.WebhookURL) {
+		respondError(w, http.StatusBadRequest, "webhookUrl must be an http(s) URL")
+		return
+	}
+
+	report, err := h.checker.CheckCollection(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if report.Drifted && req.WebhookURL != "" {
+		h.checker.Alert(r.Context(), middleware.GetWorkspaceID(r.Context()), req.WebhookURL, report)
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+// SaveBaseline makes a response of the request its drift baseline, accepting
+// the shape it has now
+func (h *DriftHandler) SaveBaseline(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.request(w, r)
+	if !ok {
+		return
+	}
+	var body DriftBaselineRequest
+	if err := decodeJSON(r, &body); err != nil {
+		body = DriftBaselineRequest{} // body is optional
+	}
+
+	baseline, err := h.checker.SaveBaseline(r.Context(), req.ID, body.HistoryID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, DriftBaselineResponse{
+		RequestID: baseline.RequestID,
+		HistoryID: baseline.HistoryID.Int64,
+		CreatedAt: formatTime(baseline.CreatedAt),
+	})
+}
+
+// DeleteBaseline forgets the request's baseline; the next check saves a new one
+func (h *DriftHandler) DeleteBaseline(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.request(w, r)
+	if !ok {
+		return
+	}
+	if err := h.queries.DeleteDriftBaseline(r.Context(), req.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// request loads the {id} request of the current workspace, responding 400/404
+func (h *DriftHandler) request(w http.ResponseWriter, r *http.Request) (repository.Request, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.Request{}, false
+	}
+	req, err := h.queries.GetRequest(r.Context(), id)
+	if err != nil || req.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Request not found")
+		return repository.Request{}, false
+	}
+	return req, true
+}
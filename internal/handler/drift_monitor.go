@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type DriftMonitorHandler struct {
+	queries *repository.Queries
+	runner  *service.DriftMonitorRunner
+}
+
+func NewDriftMonitorHandler(queries *repository.Queries, runner *service.DriftMonitorRunner) *DriftMonitorHandler {
+	return &DriftMonitorHandler{queries: queries, runner: runner}
+}
+
+// DriftMonitorRequest creates or updates a drift monitor. On update, omitted
+// fields keep their value; the collection cannot be changed.
+type DriftMonitorRequest struct {
+	CollectionID    int64   `json:"collectionId"`
+	IntervalSeconds *int64  `json:"intervalSeconds"` // default 3600
+	WebhookURL      *string `json:"webhookUrl"`      // receives the report when drift is detected
+	Enabled         *bool   `json:"enabled"`
+}
+
+type DriftMonitorResponse struct {
+	ID              int64                `json:"id"`
+	CollectionID    int64                `json:"collectionId"`
+	CollectionName  string               `json:"collectionName"`
+	IntervalSeconds int64                `json:"intervalSeconds"`
+	WebhookURL      string               `json:"webhookUrl,omitempty"`
+	Enabled         bool                 `json:"enabled"`
+	LastRunAt       string               `json:"lastRunAt,omitempty"`
+	NextRunAt       string               `json:"nextRunAt,omitempty"` // empty while paused or never run (due now)
+	LastReport      *service.DriftReport `json:"lastReport,omitempty"`
+	CreatedAt       string               `json:"createdAt"`
+	UpdatedAt       string               `json:"updatedAt"`
+}
+
+func (h *DriftMonitorHandler) toResponse(r *http.Request, mon repository.DriftMonitor) (DriftMonitorResponse, error) {
+	resp := DriftMonitorResponse{
+		ID:              mon.ID,
+		CollectionID:    mon.CollectionID,
+		IntervalSeconds: mon.IntervalSeconds,
+		WebhookURL:      mon.WebhookUrl,
+		Enabled:         mon.Enabled == 1,
+		LastRunAt:       formatTime(mon.LastRunAt),
+		LastReport:      service.ParseDriftReport(mon.LastReport),
+		CreatedAt:       formatTime(mon.CreatedAt),
+		UpdatedAt:       formatTime(mon.UpdatedAt),
+	}
+	if resp.Enabled && mon.LastRunAt.Valid {
+		next := mon.LastRunAt.Time.Add(time.Duration(mon.IntervalSeconds) * time.Second)
+		resp.NextRunAt = formatTime(sql.NullTime{Time: next, Valid: true})
+	}
+	coll, err := h.queries.GetCollection(r.Context(), mon.CollectionID)
+	if err != nil {
+		return resp, err
+	}
+	resp.CollectionName = coll.Name
+	return resp, nil
+}
+
+func (h *DriftMonitorHandler) List(w http.ResponseWriter, r *http.Request) {
+	monitors, err := h.queries.ListDriftMonitors(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]DriftMonitorResponse, 0, len(monitors))
+	for _, mon := range monitors {
+		mr, err := h.toResponse(r, mon)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp = append(resp, mr)
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *DriftMonitorHandler) Get(w http.ResponseWriter, r *http.Request) {
+	mon, ok := h.monitor(w, r)
+	if !ok {
+		return
+	}
+	h.respond(w, r, http.StatusOK, mon)
+}
+
+// Create schedules drift checks of a collection
+func (h *DriftMonitorHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req DriftMonitorRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+	coll, err := h.queries.GetCollection(ctx, req.CollectionID)
+	if err != nil || coll.WorkspaceID != wsID {
+		respondError(w, http.StatusBadRequest, "collectionId must be a collection of the workspace")
+		return
+	}
+	if _, err := h.queries.GetDriftMonitorByCollection(ctx, coll.ID); err == nil {
+		respondError(w, http.StatusConflict, "Collection already has a drift monitor")
+		return
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	params, ok := h.params(w, req, repository.UpdateDriftMonitorParams{IntervalSeconds: 60 * 60, Enabled: 1})
+	if !ok {
+		return
+	}
+
+	mon, err := h.queries.CreateDriftMonitor(ctx, repository.CreateDriftMonitorParams{
+		WorkspaceID:     wsID,
+		CollectionID:    coll.ID,
+		IntervalSeconds: params.IntervalSeconds,
+		WebhookUrl:      params.WebhookUrl,
+		Enabled:         params.Enabled,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.respond(w, r, http.StatusCreated, mon)
+}
+
+// Update changes the interval or webhook, or pauses/resumes the monitor
+func (h *DriftMonitorHandler) Update(w http.ResponseWriter, r *http.Request) {
+	mon, ok := h.monitor(w, r)
+	if !ok {
+		return
+	}
+	var req DriftMonitorRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	params, ok := h.params(w, req, repository.UpdateDriftMonitorParams{
+		IntervalSeconds: mon.IntervalSeconds,
+		WebhookUrl:      mon.WebhookUrl,
+		Enabled:         mon.Enabled,
+		ID:              mon.ID,
+	})
+	if !ok {
+		return
+	}
+
+	mon, err := h.queries.UpdateDriftMonitor(r.Context(), params)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.respond(w, r, http.StatusOK, mon)
+}
+
+func (h *DriftMonitorHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	mon, ok := h.monitor(w, r)
+	if !ok {
+		return
+	}
+	if err := h.queries.DeleteDriftMonitor(r.Context(), mon.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Run checks the collection immediately, independent of the schedule; the
+// next scheduled check counts from now
+func (h *DriftMonitorHandler) Run(w http.ResponseWriter, r *http.Request) {
+	mon, ok := h.monitor(w, r)
+	if !ok {
+		return
+	}
+	report, err := h.runner.Run(r.Context(), mon)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+func (h *DriftMonitorHandler) respond(w http.ResponseWriter, r *http.Request, status int, mon repository.DriftMonitor) {
+	resp, err := h.toResponse(r, mon)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, status, resp)
+}
+
+// monitor loads the {id} drift monitor of the current workspace, responding 400/404
+func (h *DriftMonitorHandler) monitor(w http.ResponseWriter, r *http.Request) (repository.DriftMonitor, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.DriftMonitor{}, false
+	}
+	mon, err := h.queries.GetDriftMonitor(r.Context(), id)
+	if err != nil || mon.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Drift monitor not found")
+		return repository.DriftMonitor{}, false
+	}
+	return mon, true
+}
+
+// params applies req over p and validates the result
+func (h *DriftMonitorHandler) params(w http.ResponseWriter, req DriftMonitorRequest, p repository.UpdateDriftMonitorParams) (repository.UpdateDriftMonitorParams, bool) {
+	if req.IntervalSeconds != nil {
+		p.IntervalSeconds = *req.IntervalSeconds
+	}
+	if req.WebhookURL != nil {
+		p.WebhookUrl = *req.WebhookURL
+	}
+	if req.Enabled != nil {
+		p.Enabled = 0
+		if *req.Enabled {
+			p.Enabled = 1
+		}
+	}
+
+	if p.IntervalSeconds < service.MinDriftInterval || p.IntervalSeconds > service.MaxDriftInterval {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("intervalSeconds must be between %d and %d", service.MinDriftInterval, service.MaxDriftInterval))
+		return p, false
+	}
+	if p.WebhookUrl != "" && !validWebhookURL(p.WebhookUrl) {
+		respondError(w, http.StatusBadRequest, "webhookUrl must be an http(s) URL")
+		return p, false
+	}
+	return p, true
+}
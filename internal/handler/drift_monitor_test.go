@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDriftMonitor_CRUDAndRun(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer api.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := service.NewRequestExecutor(q, service.NewVariableResolver(q), nil)
+	h := handler.NewDriftMonitorHandler(q, service.NewDriftMonitorRunner(q, service.NewContractDriftChecker(q, re)))
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/drift-monitors", h.List)
+	r.Post("/api/drift-monitors", h.Create)
+	r.Get("/api/drift-monitors/{id}", h.Get)
+	r.Put("/api/drift-monitors/{id}", h.Update)
+	r.Delete("/api/drift-monitors/{id}", h.Delete)
+	r.Post("/api/drift-monitors/{id}/run", h.Run)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	coll, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Users API", WorkspaceID: 1})
+	q.CreateRequest(ctx, repository.CreateRequestParams{
+		CollectionID: sql.NullInt64{Int64: coll.ID, Valid: true}, Name: "Get user", Method: "GET", Url: api.URL + "/users/1", WorkspaceID: 1,
+	})
+	ws, _ := q.CreateWorkspace(ctx, "Other")
+	otherColl, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Other", WorkspaceID: ws.ID})
+
+	for _, body := range []string{
+		`{}`,
+		fmt.Sprintf(`{"collectionId":%d}`, otherColl.ID),
+		fmt.Sprintf(`{"collectionId":%d,"intervalSeconds":10}`, coll.ID),
+		fmt.Sprintf(`{"collectionId":%d,"webhookUrl":"ftp://x"}`, coll.ID),
+	} {
+		resp, _ := postJSON(ts.URL+"/api/drift-monitors", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	resp, _ := postJSON(ts.URL+"/api/drift-monitors", fmt.Sprintf(`{"collectionId":%d,"webhookUrl":"https://hooks.example.com/drift"}`, coll.ID))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status = %d", resp.StatusCode)
+	}
+	var created handler.DriftMonitorResponse
+	readJSON(t, resp, &created)
+	if created.IntervalSeconds != 3600 || !created.Enabled || created.CollectionName != "Users API" || created.LastReport != nil {
+		t.Errorf("created = %+v", created)
+	}
+	resp, _ = postJSON(ts.URL+"/api/drift-monitors", fmt.Sprintf(`{"collectionId":%d}`, coll.ID))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("second monitor for the collection: status = %d, want 409", resp.StatusCode)
+	}
+	monitorURL := fmt.Sprintf("%s/api/drift-monitors/%d", ts.URL, created.ID)
+
+	resp, _ = postJSON(monitorURL+"/run", `{}`)
+	var report service.DriftReport
+	readJSON(t, resp, &report)
+	if resp.StatusCode != http.StatusOK || len(report.Requests) != 1 || report.Requests[0].Status != service.DriftStatusNoBaseline {
+		t.Fatalf("run: status %d, report %+v", resp.StatusCode, report)
+	}
+
+	resp, _ = putJSON(monitorURL, `{"intervalSeconds":600,"enabled":false}`)
+	var updated handler.DriftMonitorResponse
+	readJSON(t, resp, &updated)
+	if updated.IntervalSeconds != 600 || updated.Enabled || updated.WebhookURL != "https://hooks.example.com/drift" {
+		t.Errorf("updated = %+v", updated)
+	}
+	if updated.LastRunAt == "" || updated.LastReport == nil || updated.LastReport.Collection != "Users API" {
+		t.Errorf("last run not recorded: %+v", updated)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/drift-monitors")
+	var list []handler.DriftMonitorResponse
+	readJSON(t, resp, &list)
+	if len(list) != 1 {
+		t.Errorf("list = %+v", list)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, monitorURL, nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status = %d", resp.StatusCode)
+	}
+	resp, _ = http.Get(monitorURL)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("get after delete: status = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,147 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestDrift_CheckCollection(t *testing.T) {
+	var mu sync.Mutex
+	body := `{"id":1,"name":"alice"}`
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer api.Close()
+
+	var alerts []service.DriftReport
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report service.DriftReport
+		json.NewDecoder(r.Body).Decode(&report)
+		alerts = append(alerts, report)
+	}))
+	defer hook.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	collH := handler.NewCollectionHandler(q, nil)
+	reqH := handler.NewRequestHandler(q, re, nil)
+	driftH := handler.NewDriftHandler(q, service.NewContractDriftChecker(q, re))
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/collections", collH.Create)
+	r.Post("/api/requests", reqH.Create)
+	r.Post("/api/collections/{id}/drift-check", driftH.CheckCollection)
+	r.Put("/api/requests/{id}/drift-baseline", driftH.SaveBaseline)
+	r.Delete("/api/requests/{id}/drift-baseline", driftH.DeleteBaseline)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := postJSON(ts.URL+"/api/collections", `{"name":"Users API"}`)
+	var coll handler.CollectionResponse
+	readJSON(t, resp, &coll)
+	resp, _ = postJSON(ts.URL+"/api/requests", fmt.Sprintf(`{"collectionId":%d,"name":"Get user","method":"GET","url":%q}`, coll.ID, api.URL+"/users/1"))
+	var req handler.RequestResponse
+	readJSON(t, resp, &req)
+
+	check := func() service.DriftReport {
+		t.Helper()
+		resp, err := postJSON(fmt.Sprintf("%s/api/collections/%d/drift-check", ts.URL, coll.ID), fmt.Sprintf(`{"webhookUrl":%q}`, hook.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("drift-check: status %d", resp.StatusCode)
+		}
+		var report service.DriftReport
+		readJSON(t, resp, &report)
+		if len(report.Requests) != 1 {
+			t.Fatalf("expected 1 request, got %+v", report.Requests)
+		}
+		return report
+	}
+
+	// First run saves the baseline
+	if report := check(); report.Requests[0].Status != service.DriftStatusNoBaseline || report.Drifted {
+		t.Errorf("first check = %+v", report.Requests[0])
+	}
+	if report := check(); report.Requests[0].Status != service.DriftStatusOK {
+		t.Errorf("unchanged API should be ok, got %+v", report.Requests[0])
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("no alert expected without drift, got %d", len(alerts))
+	}
+
+	mu.Lock()
+	body = `{"id":"1","email":"a@example.com"}`
+	mu.Unlock()
+
+	report := check()
+	rd := report.Requests[0]
+	if !report.Drifted || rd.Status != service.DriftStatusDrift || len(rd.Changes) != 3 {
+		t.Fatalf("expected 3 drift changes, got %+v", rd)
+	}
+	if len(alerts) != 1 || !alerts[0].Drifted || alerts[0].Requests[0].Name != "Get user" {
+		t.Errorf("webhook alerts = %+v", alerts)
+	}
+
+	// The saved baseline does not follow the live API
+	if report := check(); report.Requests[0].Status != service.DriftStatusDrift {
+		t.Errorf("drift should persist until accepted, got %+v", report.Requests[0])
+	}
+
+	// Accepting the latest response makes it the baseline
+	resp, err := putJSON(fmt.Sprintf("%s/api/requests/%d/drift-baseline", ts.URL, req.ID), `{}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var baseline handler.DriftBaselineResponse
+	readJSON(t, resp, &baseline)
+	if resp.StatusCode != http.StatusOK || baseline.HistoryID == 0 {
+		t.Fatalf("save baseline: status %d, %+v", resp.StatusCode, baseline)
+	}
+	if report := check(); report.Requests[0].Status != service.DriftStatusOK || report.Requests[0].BaselineHistoryID != baseline.HistoryID {
+		t.Errorf("after accepting, the new shape is the baseline, got %+v", report.Requests[0])
+	}
+
+	resp, _ = putJSON(fmt.Sprintf("%s/api/requests/%d/drift-baseline", ts.URL, req.ID), `{"historyId":99999}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("foreign history entry: expected 400, got %d", resp.StatusCode)
+	}
+	httpReq, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/requests/%d/drift-baseline", ts.URL, req.ID), nil)
+	resp, _ = http.DefaultClient.Do(httpReq)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete baseline: expected 204, got %d", resp.StatusCode)
+	}
+	if report := check(); report.Requests[0].Status != service.DriftStatusNoBaseline {
+		t.Errorf("after delete the next check saves a baseline, got %+v", report.Requests[0])
+	}
+
+	resp, _ = postJSON(fmt.Sprintf("%s/api/collections/%d/drift-check", ts.URL, coll.ID), `{"webhookUrl":"ftp://x"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid webhook: expected 400, got %d", resp.StatusCode)
+	}
+	resp, _ = postJSON(ts.URL+"/api/collections/999/drift-check", `{}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown collection: expected 404, got %d", resp.StatusCode)
+	}
+}
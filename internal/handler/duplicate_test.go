@@ -566,3 +566,37 @@ func TestDuplicate_Collection_NotFound(t *testing.T) {
 		t.Errorf("expected 404, got %d", resp.StatusCode)
 	}
 }
+
+func TestDuplicate_RequestAuthBlock(t *testing.T) {
+	ts := setupDuplicateTestServer(t)
+
+	resp, _ := postJSON(ts.URL+"/api/requests", `{"name":"Bad","method":"GET","url":"http://x","auth":"{\"type\":\"kerberos\"}"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unsupported auth type: expected 400, got %d", resp.StatusCode)
+	}
+
+	auth := `{"type":"ntlm","domain":"CORP","username":"svc","password":"{{pw}}"}`
+	body, _ := json.Marshal(map[string]string{"name": "Intranet", "method": "GET", "url": "http://intranet", "auth": auth})
+	resp, _ = postJSON(ts.URL+"/api/requests", string(body))
+	var orig struct {
+		ID   int64  `json:"id"`
+		Auth string `json:"auth"`
+	}
+	readJSON(t, resp, &orig)
+	if orig.Auth != auth {
+		t.Fatalf("auth: got %q, want %q", orig.Auth, auth)
+	}
+
+	resp, err := http.Post(ts.URL+fmt.Sprintf("/api/requests/%d/duplicate", orig.ID), "", nil)
+	if err != nil {
+		t.Fatalf("duplicate request: %v", err)
+	}
+	var dup struct {
+		Auth string `json:"auth"`
+	}
+	readJSON(t, resp, &dup)
+	if dup.Auth != auth {
+		t.Errorf("duplicate auth: got %q, want %q", dup.Auth, auth)
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// EncryptionHandler manages the key a workspace's secret variables and TLS
+// certificates are sealed with
+type EncryptionHandler struct {
+	queries *repository.Queries
+	keys    *service.KeyRing
+}
+
+func NewEncryptionHandler(queries *repository.Queries, keys *service.KeyRing) *EncryptionHandler {
+	return &EncryptionHandler{queries: queries, keys: keys}
+}
+
+type EncryptionStatusResponse struct {
+	Enabled   bool   `json:"enabled"`
+	Source    string `json:"source,omitempty"`
+	KMSKeyEnv string `json:"kmsKeyEnv,omitempty"`
+	// Locked is true while the key is unavailable; executions needing a
+	// secret fail until it is unlocked
+	Locked    bool                  `json:"locked"`
+	Version   int64                 `json:"version,omitempty"`
+	CreatedAt string                `json:"createdAt,omitempty"`
+	RotatedAt string                `json:"rotatedAt,omitempty"`
+	Resealed  *service.ResealResult `json:"resealed,omitempty"` // set by rotate and remove
+}
+
+type UnlockEncryptionRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+func (h *EncryptionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.workspaceID(w, r)
+	if !ok {
+		return
+	}
+	h.respondStatus(w, r, id, http.StatusOK, nil)
+}
+
+// Rotate sets the workspace key, or replaces it; secrets and certificates
+// are re-encrypted under the new key
+func (h *EncryptionHandler) Rotate(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.workspaceID(w, r)
+	if !ok {
+		return
+	}
+	var req service.KeySpec
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	_, resealed, err := h.keys.Rotate(r.Context(), id, req)
+	if err != nil {
+		respondError(w, encryptionErrorStatus(err), err.Error())
+		return
+	}
+	h.respondStatus(w, r, id, http.StatusOK, &resealed)
+}
+
+// Remove decrypts the workspace's secrets and certificates and drops its key
+func (h *EncryptionHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.workspaceID(w, r)
+	if !ok {
+		return
+	}
+	resealed, err := h.keys.Remove(r.Context(), id)
+	if err != nil {
+		respondError(w, encryptionErrorStatus(err), err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, EncryptionStatusResponse{Resealed: &resealed})
+}
+
+func (h *EncryptionHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.workspaceID(w, r)
+	if !ok {
+		return
+	}
+	var req UnlockEncryptionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := h.keys.Unlock(r.Context(), id, req.Passphrase); err != nil {
+		respondError(w, encryptionErrorStatus(err), err.Error())
+		return
+	}
+	h.respondStatus(w, r, id, http.StatusOK, nil)
+}
+
+// Lock forgets the workspace's passphrase key until it is unlocked again
+func (h *EncryptionHandler) Lock(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.workspaceID(w, r)
+	if !ok {
+		return
+	}
+	h.keys.Lock(id)
+	h.respondStatus(w, r, id, http.StatusOK, nil)
+}
+
+func (h *EncryptionHandler) respondStatus(w http.ResponseWriter, r *http.Request, id int64, status int, resealed *service.ResealResult) {
+	key, locked, err := h.keys.Status(r.Context(), id)
+	if errors.Is(err, service.ErrNoEncryptionKey) {
+		respondJSON(w, status, EncryptionStatusResponse{Resealed: resealed})
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := EncryptionStatusResponse{
+		Enabled:   true,
+		Source:    key.Source,
+		KMSKeyEnv: key.KmsKeyEnv,
+		Locked:    locked,
+		Version:   key.Version,
+		CreatedAt: formatTime(key.CreatedAt),
+		Resealed:  resealed,
+	}
+	if key.RotatedAt.Valid {
+		resp.RotatedAt = formatTime(key.RotatedAt)
+	}
+	respondJSON(w, status, resp)
+}
+
+// workspaceID reads the {id} workspace, responding 400/404
+func (h *EncryptionHandler) workspaceID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return 0, false
+	}
+	if _, err := h.queries.GetWorkspace(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "Workspace not found")
+		return 0, false
+	}
+	return id, true
+}
+
+// encryptionErrorStatus maps workspace key errors to a response status
+func encryptionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrEncryptionLocked):
+		return http.StatusLocked
+	case errors.Is(err, service.ErrNoEncryptionKey):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrWrongPassphrase):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrInvalidKey):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
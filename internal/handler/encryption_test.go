@@ -0,0 +1,105 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestEncryption_KeyLifecycle(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	keys := service.NewKeyRing(db, q, nil)
+	h := handler.NewEncryptionHandler(q, keys)
+	wsH := handler.NewWorkspaceHandler(q)
+	wsH.SetKeyRing(keys)
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/workspaces/{id}/encryption", h.Get)
+	r.Put("/api/workspaces/{id}/encryption", h.Rotate)
+	r.Delete("/api/workspaces/{id}/encryption", h.Remove)
+	r.Post("/api/workspaces/{id}/encryption/unlock", h.Unlock)
+	r.Post("/api/workspaces/{id}/encryption/lock", h.Lock)
+	r.Put("/api/workspaces/{id}/variables/{key}", wsH.SetVariable)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	url := ts.URL + "/api/workspaces/1/encryption"
+
+	var status handler.EncryptionStatusResponse
+	resp, _ := http.Get(url)
+	readJSON(t, resp, &status)
+	if status.Enabled {
+		t.Fatalf("initial status = %+v", status)
+	}
+
+	for _, body := range []string{
+		`{"source":"passphrase","passphrase":"short"}`,
+		`{"source":"kms","kmsKeyEnv":"not a name"}`,
+		`{"source":"vault"}`,
+	} {
+		resp, _ = putJSON(url, body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	resp, _ = putJSON(ts.URL+"/api/workspaces/1/variables/token", `{"value":"s3cret","secret":true}`)
+	resp.Body.Close()
+	resp, _ = putJSON(url, `{"source":"passphrase","passphrase":"correct horse"}`)
+	readJSON(t, resp, &status)
+	if !status.Enabled || status.Locked || status.Source != "passphrase" || status.Resealed == nil || status.Resealed.Secrets != 1 {
+		t.Fatalf("rotate = %+v", status)
+	}
+	ws, _ := q.GetWorkspace(context.Background(), 1)
+	if strings.Contains(ws.Variables.String, "s3cret") {
+		t.Errorf("stored variables hold the plaintext secret: %s", ws.Variables.String)
+	}
+
+	resp, _ = postJSON(url+"/lock", `{}`)
+	readJSON(t, resp, &status)
+	if !status.Locked {
+		t.Errorf("lock = %+v", status)
+	}
+	resp, _ = putJSON(ts.URL+"/api/workspaces/1/variables/other", `{"value":"x","secret":true}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusLocked {
+		t.Errorf("secret write while locked: status = %d, want 423", resp.StatusCode)
+	}
+
+	resp, _ = postJSON(url+"/unlock", `{"passphrase":"wrong horse"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("wrong passphrase: status = %d, want 403", resp.StatusCode)
+	}
+	resp, _ = postJSON(url+"/unlock", `{"passphrase":"correct horse"}`)
+	readJSON(t, resp, &status)
+	if status.Locked {
+		t.Errorf("unlock = %+v", status)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	resp, _ = http.DefaultClient.Do(req)
+	readJSON(t, resp, &status)
+	if status.Enabled || status.Resealed == nil || status.Resealed.Secrets != 1 {
+		t.Errorf("remove = %+v", status)
+	}
+	ws, _ = q.GetWorkspace(context.Background(), 1)
+	if !strings.Contains(ws.Variables.String, "s3cret") {
+		t.Errorf("variables after remove = %s", ws.Variables.String)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/workspaces/99/encryption")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown workspace: status = %d, want 404", resp.StatusCode)
+	}
+}
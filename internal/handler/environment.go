@@ -2,10 +2,13 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"relay/internal/middleware"
 	"relay/internal/repository"
+	"relay/internal/service"
 )
 
 type EnvironmentHandler struct {
@@ -19,35 +22,109 @@ func NewEnvironmentHandler(queries *repository.Queries) *EnvironmentHandler {
 type EnvironmentRequest struct {
 	Name      string `json:"name"`
 	Variables string `json:"variables"`
+	ParentID  *int64 `json:"parentId"` // nil = no parent
+	// CollectionID scopes the environment to a collection; set on create only
+	CollectionID *int64 `json:"collectionId"`
 }
 
 type EnvironmentResponse struct {
-	ID        int64  `json:"id"`
-	Name      string `json:"name"`
-	Variables string `json:"variables"`
-	IsActive  bool   `json:"isActive"`
-	CreatedAt string `json:"createdAt"`
-	UpdatedAt string `json:"updatedAt"`
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Variables    string `json:"variables"`
+	ParentID     *int64 `json:"parentId"`
+	CollectionID *int64 `json:"collectionId"`
+	IsActive     bool   `json:"isActive"`
+	CreatedAt    string `json:"createdAt"`
+	UpdatedAt    string `json:"updatedAt"`
+}
+
+// ResolvedVariable is an effective variable and the environment that sets it
+type ResolvedVariable struct {
+	Value           string `json:"value"`
+	EnvironmentID   int64  `json:"environmentId"`
+	EnvironmentName string `json:"environmentName"`
+	Inherited       bool   `json:"inherited"`
 }
 
+type ResolvedEnvironmentResponse struct {
+	ID        int64                       `json:"id"`
+	Chain     []int64                     `json:"chain"` // the environment, then its ancestors
+	Variables map[string]ResolvedVariable `json:"variables"`
+}
+
+func toEnvironmentResponse(env repository.Environment) EnvironmentResponse {
+	resp := EnvironmentResponse{
+		ID:        env.ID,
+		Name:      env.Name,
+		Variables: env.Variables.String,
+		IsActive:  env.IsActive.Valid && env.IsActive.Bool,
+		CreatedAt: formatTime(env.CreatedAt),
+		UpdatedAt: formatTime(env.UpdatedAt),
+	}
+	if env.ParentID.Valid {
+		pid := env.ParentID.Int64
+		resp.ParentID = &pid
+	}
+	if env.CollectionID.Valid {
+		cid := env.CollectionID.Int64
+		resp.CollectionID = &cid
+	}
+	return resp
+}
+
+// parentParam validates the requested parent and writes the error response
+// itself when it is rejected
+func (h *EnvironmentHandler) parentParam(w http.ResponseWriter, r *http.Request, envID int64, parentID *int64) (sql.NullInt64, bool) {
+	if parentID == nil {
+		return sql.NullInt64{}, true
+	}
+	wsID := middleware.GetWorkspaceID(r.Context())
+	if err := service.CheckEnvironmentParent(r.Context(), h.queries, wsID, envID, *parentID); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return sql.NullInt64{}, false
+	}
+	return sql.NullInt64{Int64: *parentID, Valid: true}, true
+}
+
+// List returns the workspace environments, or with ?collectionId= the
+// environments scoped to that collection
 func (h *EnvironmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	var collectionID int64
+	if c := r.URL.Query().Get("collectionId"); c != "" {
+		parsed, err := strconv.ParseInt(c, 10, 64)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid collectionId")
+			return
+		}
+		collectionID = parsed
+	}
+
+	lq, ok := parseListQuery(w, r, listSortName, listSortCreatedAt, listSortUpdatedAt)
+	if !ok {
+		return
+	}
+
 	wsID := middleware.GetWorkspaceID(r.Context())
-	envs, err := h.queries.ListEnvironments(r.Context(), wsID)
+	all, err := h.queries.ListEnvironments(r.Context(), wsID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	envs := all[:0]
+	for _, env := range all {
+		if env.CollectionID.Int64 == collectionID && lq.matches(env.Name) {
+			envs = append(envs, env)
+		}
+	}
+	sortByFields(envs, lq.sortBy, lq.desc, func(e repository.Environment) (string, sql.NullTime, sql.NullTime) {
+		return e.Name, e.CreatedAt, e.UpdatedAt
+	})
+	envs = paginate(w, envs, lq)
+
 	resp := make([]EnvironmentResponse, 0, len(envs))
 	for _, env := range envs {
-		resp = append(resp, EnvironmentResponse{
-			ID:        env.ID,
-			Name:      env.Name,
-			Variables: env.Variables.String,
-			IsActive:  env.IsActive.Valid && env.IsActive.Bool,
-			CreatedAt: formatTime(env.CreatedAt),
-			UpdatedAt: formatTime(env.UpdatedAt),
-		})
+		resp = append(resp, toEnvironmentResponse(env))
 	}
 
 	respondJSON(w, http.StatusOK, resp)
@@ -66,14 +143,7 @@ func (h *EnvironmentHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, EnvironmentResponse{
-		ID:        env.ID,
-		Name:      env.Name,
-		Variables: env.Variables.String,
-		IsActive:  env.IsActive.Valid && env.IsActive.Bool,
-		CreatedAt: formatTime(env.CreatedAt),
-		UpdatedAt: formatTime(env.UpdatedAt),
-	})
+	respondJSON(w, http.StatusOK, toEnvironmentResponse(env))
 }
 
 func (h *EnvironmentHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -87,25 +157,35 @@ func (h *EnvironmentHandler) Create(w http.ResponseWriter, r *http.Request) {
 		req.Variables = "{}"
 	}
 
+	parentID, ok := h.parentParam(w, r, 0, req.ParentID)
+	if !ok {
+		return
+	}
+
 	wsID := middleware.GetWorkspaceID(r.Context())
+	var collectionID sql.NullInt64
+	if req.CollectionID != nil {
+		c, err := h.queries.GetCollection(r.Context(), *req.CollectionID)
+		if err != nil || c.WorkspaceID != wsID {
+			respondError(w, http.StatusBadRequest, "Collection not found")
+			return
+		}
+		collectionID = sql.NullInt64{Int64: c.ID, Valid: true}
+	}
+
 	env, err := h.queries.CreateEnvironment(r.Context(), repository.CreateEnvironmentParams{
-		Name:        req.Name,
-		Variables:   sql.NullString{String: req.Variables, Valid: true},
-		WorkspaceID: wsID,
+		Name:         req.Name,
+		Variables:    sql.NullString{String: req.Variables, Valid: true},
+		WorkspaceID:  wsID,
+		ParentID:     parentID,
+		CollectionID: collectionID,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, EnvironmentResponse{
-		ID:        env.ID,
-		Name:      env.Name,
-		Variables: env.Variables.String,
-		IsActive:  env.IsActive.Valid && env.IsActive.Bool,
-		CreatedAt: formatTime(env.CreatedAt),
-		UpdatedAt: formatTime(env.UpdatedAt),
-	})
+	respondJSON(w, http.StatusCreated, toEnvironmentResponse(env))
 }
 
 func (h *EnvironmentHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -121,24 +201,23 @@ func (h *EnvironmentHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	parentID, ok := h.parentParam(w, r, id, req.ParentID)
+	if !ok {
+		return
+	}
+
 	env, err := h.queries.UpdateEnvironment(r.Context(), repository.UpdateEnvironmentParams{
 		ID:        id,
 		Name:      req.Name,
 		Variables: sql.NullString{String: req.Variables, Valid: true},
+		ParentID:  parentID,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, EnvironmentResponse{
-		ID:        env.ID,
-		Name:      env.Name,
-		Variables: env.Variables.String,
-		IsActive:  env.IsActive.Valid && env.IsActive.Bool,
-		CreatedAt: formatTime(env.CreatedAt),
-		UpdatedAt: formatTime(env.UpdatedAt),
-	})
+	respondJSON(w, http.StatusOK, toEnvironmentResponse(env))
 }
 
 func (h *EnvironmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -152,10 +231,46 @@ func (h *EnvironmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	// Children keep their own variables and stop inheriting
+	h.queries.ClearEnvironmentParent(r.Context(), sql.NullInt64{Int64: id, Valid: true})
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// Resolved returns the environment's effective variables after inheritance,
+// with the environment each value comes from
+func (h *EnvironmentHandler) Resolved(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	env, err := h.queries.GetEnvironment(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Environment not found")
+		return
+	}
+
+	chain := service.EnvironmentChain(r.Context(), h.queries, env)
+	resp := ResolvedEnvironmentResponse{ID: env.ID, Variables: make(map[string]ResolvedVariable)}
+	for _, e := range chain {
+		resp.Chain = append(resp.Chain, e.ID)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range service.MergeEnvironmentVars(chain[i : i+1]) {
+			resp.Variables[k] = ResolvedVariable{
+				Value:           v,
+				EnvironmentID:   chain[i].ID,
+				EnvironmentName: chain[i].Name,
+				Inherited:       i > 0,
+			}
+		}
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
 func (h *EnvironmentHandler) Activate(w http.ResponseWriter, r *http.Request) {
 	id, err := parseID(r, "id")
 	if err != nil {
@@ -163,22 +278,47 @@ func (h *EnvironmentHandler) Activate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Deactivate all first
-	wsID := middleware.GetWorkspaceID(r.Context())
-	h.queries.DeactivateAllEnvironments(r.Context(), wsID)
+	env, err := h.queries.GetEnvironment(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Environment not found")
+		return
+	}
+
+	// Deactivate all first; one environment is active per workspace and per collection
+	if env.CollectionID.Valid {
+		h.queries.DeactivateCollectionEnvironments(r.Context(), env.CollectionID)
+	} else {
+		wsID := middleware.GetWorkspaceID(r.Context())
+		h.queries.DeactivateAllEnvironments(r.Context(), wsID)
+	}
 
-	env, err := h.queries.ActivateEnvironment(r.Context(), id)
+	env, err = h.queries.ActivateEnvironment(r.Context(), id)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, EnvironmentResponse{
-		ID:        env.ID,
-		Name:      env.Name,
-		Variables: env.Variables.String,
-		IsActive:  true,
-		CreatedAt: formatTime(env.CreatedAt),
-		UpdatedAt: formatTime(env.UpdatedAt),
-	})
+	respondJSON(w, http.StatusOK, toEnvironmentResponse(env))
+}
+
+// Deactivate turns the environment off, e.g. so a collection's requests fall
+// back to the workspace environment
+func (h *EnvironmentHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	env, err := h.queries.DeactivateEnvironment(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Environment not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toEnvironmentResponse(env))
 }
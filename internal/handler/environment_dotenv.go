@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type DotenvImportRequest struct {
+	Content string `json:"content"` // the .env file
+	// Name creates a new environment; EnvironmentID merges into an existing one
+	Name          string `json:"name"`
+	EnvironmentID *int64 `json:"environmentId"`
+}
+
+type DotenvImportResponse struct {
+	Environment EnvironmentResponse `json:"environment"`
+	Imported    int                 `json:"imported"`
+	Warnings    []string            `json:"warnings,omitempty"`
+}
+
+// ImportDotenv maps a .env file's KEY=value pairs to environment variables,
+// keeping its comments for export. Into an existing environment, imported
+// keys overwrite and new ones are appended.
+func (h *EnvironmentHandler) ImportDotenv(w http.ResponseWriter, r *http.Request) {
+	var req DotenvImportRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		respondError(w, http.StatusBadRequest, "content is required")
+		return
+	}
+	if req.EnvironmentID == nil && strings.TrimSpace(req.Name) == "" {
+		respondError(w, http.StatusBadRequest, "name or environmentId is required")
+		return
+	}
+
+	file, warnings := service.ParseDotenv(req.Content)
+	if len(file.Keys) == 0 {
+		respondError(w, http.StatusBadRequest, "no KEY=value pairs found")
+		return
+	}
+
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+	keys, values, comments := file.Keys, file.Values, file.Comments
+	status := http.StatusCreated
+	var env repository.Environment
+	if req.EnvironmentID != nil {
+		existing, err := h.queries.GetEnvironment(ctx, *req.EnvironmentID)
+		if err != nil || existing.WorkspaceID != wsID {
+			respondError(w, http.StatusNotFound, "Environment not found")
+			return
+		}
+		keys, values, comments = mergeDotenv(existing, file)
+		env, err = h.queries.UpdateEnvironmentVariables(ctx, repository.UpdateEnvironmentVariablesParams{
+			Variables: sql.NullString{String: service.MarshalOrderedVariables(keys, values), Valid: true},
+			ID:        existing.ID,
+		})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		status = http.StatusOK
+	} else {
+		var err error
+		env, err = h.queries.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+			Name:        strings.TrimSpace(req.Name),
+			Variables:   sql.NullString{String: service.MarshalOrderedVariables(keys, values), Valid: true},
+			WorkspaceID: wsID,
+		})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	raw, _ := json.Marshal(comments)
+	if err := h.queries.UpdateEnvironmentComments(ctx, repository.UpdateEnvironmentCommentsParams{
+		Comments: string(raw),
+		ID:       env.ID,
+	}); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, status, DotenvImportResponse{
+		Environment: toEnvironmentResponse(env),
+		Imported:    len(file.Keys),
+		Warnings:    warnings,
+	})
+}
+
+// mergeDotenv applies an imported file to an environment's variables and
+// comments: existing keys keep their place
+func mergeDotenv(env repository.Environment, file service.DotenvFile) ([]string, map[string]string, map[string]string) {
+	keys := service.OrderedJSONKeys(env.Variables.String)
+	values := map[string]string{}
+	json.Unmarshal([]byte(env.Variables.String), &values)
+	comments := map[string]string{}
+	json.Unmarshal([]byte(env.Comments), &comments)
+	for _, key := range file.Keys {
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = file.Values[key]
+		delete(comments, key)
+	}
+	for key, c := range file.Comments {
+		comments[key] = c
+	}
+	return keys, values, comments
+}
+
+var dotenvFilenameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ExportDotenv writes the environment's own variables (not inherited ones)
+// as a .env file, with the comments kept from import. Variables whose names
+// a .env file cannot hold are left out and listed in X-Skipped-Variables.
+func (h *EnvironmentHandler) ExportDotenv(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	env, err := h.queries.GetEnvironment(r.Context(), id)
+	if err != nil || env.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Environment not found")
+		return
+	}
+
+	values := map[string]string{}
+	json.Unmarshal([]byte(env.Variables.String), &values)
+	comments := map[string]string{}
+	json.Unmarshal([]byte(env.Comments), &comments)
+	content, skipped := service.FormatDotenv(service.OrderedJSONKeys(env.Variables.String), values, comments)
+
+	filename := strings.Trim(dotenvFilenameUnsafe.ReplaceAllString(env.Name, "-"), "-")
+	if filename == "" {
+		filename = "environment"
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.env"`, filename))
+	if len(skipped) > 0 {
+		w.Header().Set("X-Skipped-Variables", strings.Join(skipped, ","))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(content))
+}
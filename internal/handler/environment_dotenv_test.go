@@ -0,0 +1,69 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"relay/internal/handler"
+)
+
+func TestEnvironment_DotenvImportExport(t *testing.T) {
+	ts := setupEnvironmentTestServer(t)
+
+	content := "# Staging API\nAPI_URL=https://staging.example.com\nexport API_KEY=\"k3y with space\" # rotate monthly\nnot a pair\n"
+	body, _ := json.Marshal(handler.DotenvImportRequest{Name: "staging", Content: content})
+	resp, err := postJSON(ts.URL+"/api/environments/import-dotenv", string(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("import: status = %d", resp.StatusCode)
+	}
+	var imported handler.DotenvImportResponse
+	readJSON(t, resp, &imported)
+	if imported.Imported != 2 || len(imported.Warnings) != 1 || imported.Environment.Name != "staging" {
+		t.Errorf("import = %+v", imported)
+	}
+	if imported.Environment.Variables != `{"API_URL":"https://staging.example.com","API_KEY":"k3y with space"}` {
+		t.Errorf("variables = %s", imported.Environment.Variables)
+	}
+	envURL := fmt.Sprintf("%s/api/environments/%d", ts.URL, imported.Environment.ID)
+
+	// Merging overwrites imported keys and appends new ones
+	body, _ = json.Marshal(map[string]any{"environmentId": imported.Environment.ID, "content": "DEBUG=true\nAPI_URL=https://staging2.example.com\n"})
+	resp, _ = postJSON(ts.URL+"/api/environments/import-dotenv", string(body))
+	readJSON(t, resp, &imported)
+	if resp.StatusCode != http.StatusOK || imported.Environment.Variables != `{"API_URL":"https://staging2.example.com","API_KEY":"k3y with space","DEBUG":"true"}` {
+		t.Errorf("merge: status %d, variables = %s", resp.StatusCode, imported.Environment.Variables)
+	}
+
+	resp, err = http.Get(envURL + "/export-dotenv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	want := "API_URL=https://staging2.example.com\n# rotate monthly\nAPI_KEY=\"k3y with space\"\nDEBUG=true\n"
+	if string(out) != want {
+		t.Errorf("export =\n%s\nwant\n%s", out, want)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != `attachment; filename="staging.env"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	for _, body := range []string{`{"name":"x","content":""}`, `{"content":"A=1"}`, `{"name":"x","content":"# only a comment"}`} {
+		resp, _ = postJSON(ts.URL+"/api/environments/import-dotenv", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+	resp, _ = getWithWorkspace(envURL+"/export-dotenv", 2)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace export: status = %d, want 404", resp.StatusCode)
+	}
+}
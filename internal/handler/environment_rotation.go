@@ -0,0 +1,327 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type EnvironmentRotationHandler struct {
+	queries *repository.Queries
+	rotator *service.EnvironmentRotator
+}
+
+func NewEnvironmentRotationHandler(queries *repository.Queries, rotator *service.EnvironmentRotator) *EnvironmentRotationHandler {
+	return &EnvironmentRotationHandler{queries: queries, rotator: rotator}
+}
+
+// EnvironmentRotationRequest creates or updates a rotation. Outputs maps
+// environment variables to flow outputs; empty writes every output under its
+// own name. On update, omitted fields keep their value.
+type EnvironmentRotationRequest struct {
+	EnvironmentID   *int64            `json:"environmentId"`
+	FlowID          *int64            `json:"flowId"`
+	IntervalSeconds *int64            `json:"intervalSeconds"` // default 86400
+	Outputs         map[string]string `json:"outputs"`
+	Enabled         *bool             `json:"enabled"`
+}
+
+type RotationRunResponse struct {
+	ID         int64    `json:"id"`
+	Success    bool     `json:"success"`
+	Error      string   `json:"error,omitempty"`
+	Variables  []string `json:"variables,omitempty"` // names written; values are not recorded
+	DurationMs int64    `json:"durationMs"`
+	RanAt      string   `json:"ranAt"`
+}
+
+type EnvironmentRotationResponse struct {
+	ID              int64                `json:"id"`
+	EnvironmentID   int64                `json:"environmentId"`
+	EnvironmentName string               `json:"environmentName"`
+	FlowID          int64                `json:"flowId"`
+	FlowName        string               `json:"flowName"`
+	IntervalSeconds int64                `json:"intervalSeconds"`
+	Outputs         map[string]string    `json:"outputs,omitempty"`
+	Enabled         bool                 `json:"enabled"`
+	LastRunAt       string               `json:"lastRunAt,omitempty"`
+	NextRunAt       string               `json:"nextRunAt,omitempty"` // empty while paused or never run (due now)
+	LastRun         *RotationRunResponse `json:"lastRun,omitempty"`
+	CreatedAt       string               `json:"createdAt"`
+	UpdatedAt       string               `json:"updatedAt"`
+}
+
+func toRotationRunResponse(run repository.EnvironmentRotationRun) RotationRunResponse {
+	resp := RotationRunResponse{
+		ID:         run.ID,
+		Success:    run.Success == 1,
+		Error:      run.Error,
+		DurationMs: run.DurationMs,
+		RanAt:      formatTime(run.RanAt),
+	}
+	if run.Variables != "" {
+		json.Unmarshal([]byte(run.Variables), &resp.Variables)
+	}
+	return resp
+}
+
+func (h *EnvironmentRotationHandler) toResponse(r *http.Request, rot repository.EnvironmentRotation) (EnvironmentRotationResponse, error) {
+	ctx := r.Context()
+	resp := EnvironmentRotationResponse{
+		ID:              rot.ID,
+		EnvironmentID:   rot.EnvironmentID,
+		FlowID:          rot.FlowID,
+		IntervalSeconds: rot.IntervalSeconds,
+		Outputs:         service.ParseRotationOutputs(rot.Outputs),
+		Enabled:         rot.Enabled == 1,
+		LastRunAt:       formatTime(rot.LastRunAt),
+		CreatedAt:       formatTime(rot.CreatedAt),
+		UpdatedAt:       formatTime(rot.UpdatedAt),
+	}
+	if resp.Enabled && rot.LastRunAt.Valid {
+		next := rot.LastRunAt.Time.Add(time.Duration(rot.IntervalSeconds) * time.Second)
+		resp.NextRunAt = formatTime(sql.NullTime{Time: next, Valid: true})
+	}
+
+	env, err := h.queries.GetEnvironment(ctx, rot.EnvironmentID)
+	if err != nil {
+		return resp, err
+	}
+	resp.EnvironmentName = env.Name
+	flow, err := h.queries.GetFlow(ctx, rot.FlowID)
+	if err != nil {
+		return resp, err
+	}
+	resp.FlowName = flow.Name
+
+	last, err := h.queries.GetLastEnvironmentRotationRun(ctx, rot.ID)
+	if err == nil {
+		lr := toRotationRunResponse(last)
+		resp.LastRun = &lr
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return resp, err
+	}
+	return resp, nil
+}
+
+func (h *EnvironmentRotationHandler) List(w http.ResponseWriter, r *http.Request) {
+	rotations, err := h.queries.ListEnvironmentRotations(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]EnvironmentRotationResponse, 0, len(rotations))
+	for _, rot := range rotations {
+		rr, err := h.toResponse(r, rot)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp = append(resp, rr)
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *EnvironmentRotationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	rot, ok := h.rotation(w, r)
+	if !ok {
+		return
+	}
+	h.respond(w, r, http.StatusOK, rot)
+}
+
+// Create schedules a flow whose outputs are written into an environment
+func (h *EnvironmentRotationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req EnvironmentRotationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.EnvironmentID == nil || req.FlowID == nil {
+		respondError(w, http.StatusBadRequest, "environmentId and flowId are required")
+		return
+	}
+	params, ok := h.params(w, r, req, repository.UpdateEnvironmentRotationParams{IntervalSeconds: 24 * 60 * 60, Enabled: 1})
+	if !ok {
+		return
+	}
+
+	rot, err := h.queries.CreateEnvironmentRotation(r.Context(), repository.CreateEnvironmentRotationParams{
+		WorkspaceID:     middleware.GetWorkspaceID(r.Context()),
+		EnvironmentID:   params.EnvironmentID,
+		FlowID:          params.FlowID,
+		IntervalSeconds: params.IntervalSeconds,
+		Outputs:         params.Outputs,
+		Enabled:         params.Enabled,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.respond(w, r, http.StatusCreated, rot)
+}
+
+// Update changes the rotation's target, flow, outputs or interval, or pauses/resumes it
+func (h *EnvironmentRotationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	rot, ok := h.rotation(w, r)
+	if !ok {
+		return
+	}
+	var req EnvironmentRotationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	params, ok := h.params(w, r, req, repository.UpdateEnvironmentRotationParams{
+		EnvironmentID:   rot.EnvironmentID,
+		FlowID:          rot.FlowID,
+		IntervalSeconds: rot.IntervalSeconds,
+		Outputs:         rot.Outputs,
+		Enabled:         rot.Enabled,
+		ID:              rot.ID,
+	})
+	if !ok {
+		return
+	}
+
+	rot, err := h.queries.UpdateEnvironmentRotation(r.Context(), params)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.respond(w, r, http.StatusOK, rot)
+}
+
+func (h *EnvironmentRotationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	rot, ok := h.rotation(w, r)
+	if !ok {
+		return
+	}
+	if err := h.queries.DeleteEnvironmentRotation(r.Context(), rot.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Runs returns the most recent runs, newest first (?limit=, default 50)
+func (h *EnvironmentRotationHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	rot, ok := h.rotation(w, r)
+	if !ok {
+		return
+	}
+	limit := int64(50)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+	}
+
+	runs, err := h.queries.ListEnvironmentRotationRuns(r.Context(), repository.ListEnvironmentRotationRunsParams{RotationID: rot.ID, Limit: limit})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]RotationRunResponse, 0, len(runs))
+	for _, run := range runs {
+		resp = append(resp, toRotationRunResponse(run))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Run rotates immediately, independent of the schedule; the next scheduled
+// run counts from now
+func (h *EnvironmentRotationHandler) Run(w http.ResponseWriter, r *http.Request) {
+	rot, ok := h.rotation(w, r)
+	if !ok {
+		return
+	}
+	run, err := h.rotator.Rotate(r.Context(), rot)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toRotationRunResponse(run))
+}
+
+func (h *EnvironmentRotationHandler) respond(w http.ResponseWriter, r *http.Request, status int, rot repository.EnvironmentRotation) {
+	resp, err := h.toResponse(r, rot)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, status, resp)
+}
+
+// rotation loads the {id} rotation of the current workspace, responding 400/404
+func (h *EnvironmentRotationHandler) rotation(w http.ResponseWriter, r *http.Request) (repository.EnvironmentRotation, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.EnvironmentRotation{}, false
+	}
+	rot, err := h.queries.GetEnvironmentRotation(r.Context(), id)
+	if err != nil || rot.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Rotation not found")
+		return repository.EnvironmentRotation{}, false
+	}
+	return rot, true
+}
+
+// params applies req over p and validates the result against the workspace's
+// environments and flows
+func (h *EnvironmentRotationHandler) params(w http.ResponseWriter, r *http.Request, req EnvironmentRotationRequest, p repository.UpdateEnvironmentRotationParams) (repository.UpdateEnvironmentRotationParams, bool) {
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+
+	if req.EnvironmentID != nil {
+		p.EnvironmentID = *req.EnvironmentID
+	}
+	if req.FlowID != nil {
+		p.FlowID = *req.FlowID
+	}
+	if req.IntervalSeconds != nil {
+		p.IntervalSeconds = *req.IntervalSeconds
+	}
+	if req.Enabled != nil {
+		p.Enabled = 0
+		if *req.Enabled {
+			p.Enabled = 1
+		}
+	}
+	if req.Outputs != nil {
+		p.Outputs = ""
+		if len(req.Outputs) > 0 {
+			data, _ := json.Marshal(req.Outputs)
+			p.Outputs = string(data)
+		}
+	}
+
+	if p.IntervalSeconds < service.MinRotationInterval || p.IntervalSeconds > service.MaxRotationInterval {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("intervalSeconds must be between %d and %d", service.MinRotationInterval, service.MaxRotationInterval))
+		return p, false
+	}
+	env, err := h.queries.GetEnvironment(ctx, p.EnvironmentID)
+	if err != nil || env.WorkspaceID != wsID {
+		respondError(w, http.StatusBadRequest, "environmentId must be an environment of the workspace")
+		return p, false
+	}
+	flow, err := h.queries.GetFlow(ctx, p.FlowID)
+	if err != nil || flow.WorkspaceID != wsID {
+		respondError(w, http.StatusBadRequest, "flowId must be a flow of the workspace")
+		return p, false
+	}
+	if err := service.ValidateRotationOutputs(service.ParseRotationOutputs(p.Outputs), service.ParseFlowOutputs(flow.Outputs)); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return p, false
+	}
+	return p, true
+}
@@ -0,0 +1,130 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestEnvironmentRotation_CRUDAndRun(t *testing.T) {
+	keyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"key":"fresh"}`))
+	}))
+	defer keyServer.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := service.NewVariableResolver(q)
+	rotator := service.NewEnvironmentRotator(q, service.NewFlowRunner(q, service.NewRequestExecutor(q, vr, nil), vr))
+	h := handler.NewEnvironmentRotationHandler(q, rotator)
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/environment-rotations", h.List)
+	r.Post("/api/environment-rotations", h.Create)
+	r.Get("/api/environment-rotations/{id}", h.Get)
+	r.Put("/api/environment-rotations/{id}", h.Update)
+	r.Delete("/api/environment-rotations/{id}", h.Delete)
+	r.Get("/api/environment-rotations/{id}/runs", h.Runs)
+	r.Post("/api/environment-rotations/{id}/run", h.Run)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "prod", WorkspaceID: 1})
+	flow, _ := q.CreateFlow(ctx, repository.CreateFlowParams{
+		Name: "mint-key", WorkspaceID: 1, Outputs: sql.NullString{String: `{"key":"newKey"}`, Valid: true},
+	})
+	q.CreateFlowStep(ctx, repository.CreateFlowStepParams{
+		FlowID: flow.ID, StepOrder: 1, Name: "mint", Method: "POST", Url: keyServer.URL,
+		ExtractVars: sql.NullString{String: `{"newKey":"$.key"}`, Valid: true},
+	})
+	noOutputs, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "plain", WorkspaceID: 1})
+	ws, _ := q.CreateWorkspace(ctx, "Other")
+	otherEnv, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "other", WorkspaceID: ws.ID})
+
+	for _, body := range []string{
+		fmt.Sprintf(`{"flowId":%d}`, flow.ID),
+		fmt.Sprintf(`{"environmentId":%d,"flowId":%d}`, env.ID, noOutputs.ID),
+		fmt.Sprintf(`{"environmentId":%d,"flowId":%d}`, otherEnv.ID, flow.ID),
+		fmt.Sprintf(`{"environmentId":%d,"flowId":%d,"intervalSeconds":5}`, env.ID, flow.ID),
+		fmt.Sprintf(`{"environmentId":%d,"flowId":%d,"outputs":{"API_KEY":"token"}}`, env.ID, flow.ID),
+	} {
+		resp, _ := postJSON(ts.URL+"/api/environment-rotations", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	resp, _ := postJSON(ts.URL+"/api/environment-rotations", fmt.Sprintf(`{"environmentId":%d,"flowId":%d,"outputs":{"API_KEY":"key"}}`, env.ID, flow.ID))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status = %d", resp.StatusCode)
+	}
+	var created handler.EnvironmentRotationResponse
+	readJSON(t, resp, &created)
+	if created.IntervalSeconds != 86400 || !created.Enabled || created.EnvironmentName != "prod" || created.FlowName != "mint-key" || created.Outputs["API_KEY"] != "key" {
+		t.Errorf("created = %+v", created)
+	}
+	rotationURL := fmt.Sprintf("%s/api/environment-rotations/%d", ts.URL, created.ID)
+
+	resp, _ = postJSON(rotationURL+"/run", `{}`)
+	var run handler.RotationRunResponse
+	readJSON(t, resp, &run)
+	if !run.Success || len(run.Variables) != 1 || run.Variables[0] != "API_KEY" {
+		t.Errorf("run = %+v", run)
+	}
+	env, _ = q.GetEnvironment(ctx, env.ID)
+	if env.Variables.String != `{"API_KEY":"fresh"}` {
+		t.Errorf("environment variables = %s", env.Variables.String)
+	}
+
+	var got handler.EnvironmentRotationResponse
+	resp, _ = http.Get(rotationURL)
+	readJSON(t, resp, &got)
+	if got.LastRun == nil || !got.LastRun.Success || got.LastRunAt == "" || got.NextRunAt == "" {
+		t.Errorf("after run = %+v", got)
+	}
+	var runs []handler.RotationRunResponse
+	resp, _ = http.Get(rotationURL + "/runs")
+	readJSON(t, resp, &runs)
+	if len(runs) != 1 {
+		t.Errorf("runs = %d, want 1", len(runs))
+	}
+
+	// Pausing keeps the other fields; an empty mapping writes every output
+	var paused handler.EnvironmentRotationResponse
+	resp, _ = putJSON(rotationURL, `{"enabled":false,"outputs":{}}`)
+	readJSON(t, resp, &paused)
+	if paused.Enabled || paused.NextRunAt != "" || paused.Outputs != nil || paused.EnvironmentID != env.ID || paused.IntervalSeconds != 86400 {
+		t.Errorf("paused = %+v", paused)
+	}
+
+	resp, _ = getWithWorkspace(rotationURL, ws.ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, rotationURL, nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status = %d", resp.StatusCode)
+	}
+	var list []handler.EnvironmentRotationResponse
+	resp, _ = http.Get(ts.URL + "/api/environment-rotations")
+	readJSON(t, resp, &list)
+	if len(list) != 0 {
+		t.Errorf("after delete: %d rotations", len(list))
+	}
+}
@@ -16,8 +16,9 @@ import (
 func setupEnvironmentTestServer(t *testing.T) *httptest.Server {
 	t.Helper()
 
-	_, q := testutil.SetupTestDBWithConn(t)
+	db, q := testutil.SetupTestDBWithConn(t)
 	envH := handler.NewEnvironmentHandler(q)
+	collH := handler.NewCollectionHandler(q, db)
 
 	r := chi.NewRouter()
 	r.Use(middleware.WorkspaceID)
@@ -28,6 +29,11 @@ func setupEnvironmentTestServer(t *testing.T) *httptest.Server {
 	r.Put("/api/environments/{id}", envH.Update)
 	r.Delete("/api/environments/{id}", envH.Delete)
 	r.Post("/api/environments/{id}/activate", envH.Activate)
+	r.Get("/api/environments/{id}/resolved", envH.Resolved)
+	r.Post("/api/environments/{id}/deactivate", envH.Deactivate)
+	r.Post("/api/environments/import-dotenv", envH.ImportDotenv)
+	r.Get("/api/environments/{id}/export-dotenv", envH.ExportDotenv)
+	r.Post("/api/collections", collH.Create)
 
 	ts := httptest.NewServer(r)
 	t.Cleanup(ts.Close)
@@ -214,3 +220,118 @@ func TestEnvironment_InvalidID(t *testing.T) {
 		t.Fatalf("expected status 400, got %d", resp.StatusCode)
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Environment inheritance
+// ---------------------------------------------------------------------------
+
+func TestEnvironment_Inheritance(t *testing.T) {
+	ts := setupEnvironmentTestServer(t)
+
+	var base, staging handler.EnvironmentResponse
+	resp, _ := postJSON(ts.URL+"/api/environments", `{"name":"base","variables":"{\"baseUrl\":\"https://api.example.com\",\"apiKey\":\"k1\"}"}`)
+	readJSON(t, resp, &base)
+	resp, _ = postJSON(ts.URL+"/api/environments", fmt.Sprintf(`{"name":"staging","variables":"{\"baseUrl\":\"https://staging.example.com\"}","parentId":%d}`, base.ID))
+	readJSON(t, resp, &staging)
+	if staging.ParentID == nil || *staging.ParentID != base.ID {
+		t.Fatalf("parentId = %v", staging.ParentID)
+	}
+
+	var resolved handler.ResolvedEnvironmentResponse
+	resp, _ = http.Get(fmt.Sprintf("%s/api/environments/%d/resolved", ts.URL, staging.ID))
+	readJSON(t, resp, &resolved)
+	if len(resolved.Chain) != 2 || resolved.Chain[1] != base.ID {
+		t.Errorf("chain = %v", resolved.Chain)
+	}
+	if v := resolved.Variables["baseUrl"]; v.Value != "https://staging.example.com" || v.Inherited {
+		t.Errorf("baseUrl = %+v", v)
+	}
+	if v := resolved.Variables["apiKey"]; v.Value != "k1" || !v.Inherited || v.EnvironmentName != "base" {
+		t.Errorf("apiKey = %+v", v)
+	}
+
+	// base -> staging -> base would loop
+	resp, _ = putJSON(fmt.Sprintf("%s/api/environments/%d", ts.URL, base.ID), fmt.Sprintf(`{"name":"base","variables":"{}","parentId":%d}`, staging.ID))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("cycle: expected 400, got %d", resp.StatusCode)
+	}
+
+	// Deleting the parent leaves the child standalone
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/environments/%d", ts.URL, base.ID), nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	var got handler.EnvironmentResponse
+	resp, _ = http.Get(fmt.Sprintf("%s/api/environments/%d", ts.URL, staging.ID))
+	readJSON(t, resp, &got)
+	if got.ParentID != nil {
+		t.Errorf("parentId after parent deleted = %v", *got.ParentID)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Collection-scoped environments
+// ---------------------------------------------------------------------------
+
+func TestEnvironment_CollectionScoped(t *testing.T) {
+	ts := setupEnvironmentTestServer(t)
+
+	var col struct {
+		ID int64 `json:"id"`
+	}
+	resp, _ := postJSON(ts.URL+"/api/collections", `{"name":"Payments"}`)
+	readJSON(t, resp, &col)
+
+	var wsEnv, colA, colB handler.EnvironmentResponse
+	resp, _ = postJSON(ts.URL+"/api/environments", `{"name":"staging","variables":"{}"}`)
+	readJSON(t, resp, &wsEnv)
+	resp, _ = postJSON(ts.URL+"/api/environments", fmt.Sprintf(`{"name":"sandbox","variables":"{}","collectionId":%d}`, col.ID))
+	readJSON(t, resp, &colA)
+	resp, _ = postJSON(ts.URL+"/api/environments", fmt.Sprintf(`{"name":"live","variables":"{}","collectionId":%d}`, col.ID))
+	readJSON(t, resp, &colB)
+	if colA.CollectionID == nil || *colA.CollectionID != col.ID {
+		t.Fatalf("collectionId = %v", colA.CollectionID)
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/environments", `{"name":"x","variables":"{}","collectionId":9999}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown collection: expected 400, got %d", resp.StatusCode)
+	}
+
+	// The workspace list leaves out collection environments
+	var envs []handler.EnvironmentResponse
+	resp, _ = http.Get(ts.URL + "/api/environments")
+	readJSON(t, resp, &envs)
+	if len(envs) != 1 || envs[0].ID != wsEnv.ID {
+		t.Errorf("workspace environments = %+v", envs)
+	}
+	resp, _ = http.Get(fmt.Sprintf("%s/api/environments?collectionId=%d", ts.URL, col.ID))
+	readJSON(t, resp, &envs)
+	if len(envs) != 2 {
+		t.Errorf("collection environments = %+v", envs)
+	}
+
+	// Activation is exclusive per scope
+	for _, id := range []int64{wsEnv.ID, colA.ID, colB.ID} {
+		resp, _ = postJSON(fmt.Sprintf("%s/api/environments/%d/activate", ts.URL, id), "")
+		resp.Body.Close()
+	}
+	active := map[int64]bool{}
+	for _, id := range []int64{wsEnv.ID, colA.ID, colB.ID} {
+		var env handler.EnvironmentResponse
+		resp, _ = http.Get(fmt.Sprintf("%s/api/environments/%d", ts.URL, id))
+		readJSON(t, resp, &env)
+		active[id] = env.IsActive
+	}
+	if !active[wsEnv.ID] || active[colA.ID] || !active[colB.ID] {
+		t.Errorf("active = %v", active)
+	}
+
+	var deactivated handler.EnvironmentResponse
+	resp, _ = postJSON(fmt.Sprintf("%s/api/environments/%d/deactivate", ts.URL, colB.ID), "")
+	readJSON(t, resp, &deactivated)
+	if deactivated.IsActive {
+		t.Errorf("deactivate left environment active")
+	}
+}
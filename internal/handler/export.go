@@ -0,0 +1,444 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+const exportVersion = 1
+
+// exportPassphraseHeader carries the passphrase that encrypts an export or
+// decrypts an encrypted import
+const exportPassphraseHeader = "X-Export-Passphrase"
+
+// Standalone flow files carry their own format tag and version so they can be
+// checked into repositories and imported independently of workspace exports.
+const (
+	flowFileFormat  = "relay-flow"
+	flowFileVersion = 1
+)
+
+type ExportHandler struct {
+	queries *repository.Queries
+}
+
+func NewExportHandler(queries *repository.Queries) *ExportHandler {
+	return &ExportHandler{queries: queries}
+}
+
+type FlowExport struct {
+	FlowResponse
+	Steps []FlowStepResponse `json:"steps"`
+}
+
+type WorkspaceExport struct {
+	Version      int                   `json:"version"`
+	ExportedAt   string                `json:"exportedAt"`
+	Masked       bool                  `json:"masked,omitempty"`
+	Workspace    WorkspaceResponse     `json:"workspace"`
+	Collections  []CollectionResponse  `json:"collections"`
+	Environments []EnvironmentResponse `json:"environments"`
+	Flows        []FlowExport          `json:"flows"`
+}
+
+type CollectionExport struct {
+	Version    int                `json:"version"`
+	ExportedAt string             `json:"exportedAt"`
+	Masked     bool               `json:"masked,omitempty"`
+	Collection CollectionResponse `json:"collection"`
+}
+
+// FlowFile is the standalone flow export: the flow with its steps and scripts,
+// plus snapshots of the saved requests the steps were created from.
+type FlowFile struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	ExportedAt string            `json:"exportedAt"`
+	Masked     bool              `json:"masked,omitempty"`
+	Flow       FlowExport        `json:"flow"`
+	Requests   []RequestResponse `json:"requests"`
+}
+
+type RunExportRequest struct {
+	Result interface{}        `json:"result"`
+	Mask   []string           `json:"mask"`
+	Rules  []service.MaskRule `json:"rules"`
+}
+
+type RunExport struct {
+	Version    int         `json:"version"`
+	ExportedAt string      `json:"exportedAt"`
+	Masked     bool        `json:"masked,omitempty"`
+	Result     interface{} `json:"result"`
+}
+
+type MaskRulesResponse struct {
+	Builtin []string `json:"builtin"`
+}
+
+// MaskRules lists the built-in anonymization rules usable with ?mask=
+func (h *ExportHandler) MaskRules(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, MaskRulesResponse{Builtin: service.BuiltinMaskRuleNames()})
+}
+
+// Workspace exports the current workspace (collections, requests, environments, flows)
+func (h *ExportHandler) Workspace(w http.ResponseWriter, r *http.Request) {
+	anon, ok := anonymizerFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+	ws, err := h.queries.GetWorkspace(ctx, wsID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Workspace not found")
+		return
+	}
+
+	collections, err := h.queries.ListRootCollections(ctx, wsID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	export := WorkspaceExport{
+		Version:    exportVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Masked:     anon.Enabled(),
+		Workspace: WorkspaceResponse{
+			ID:        ws.ID,
+			Name:      ws.Name,
+			CreatedAt: formatTime(ws.CreatedAt),
+			UpdatedAt: formatTime(ws.UpdatedAt),
+		},
+		Collections:  []CollectionResponse{},
+		Environments: []EnvironmentResponse{},
+		Flows:        []FlowExport{},
+	}
+	for _, c := range collections {
+		tree, err := buildCollectionExport(ctx, h.queries, c)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		export.Collections = append(export.Collections, tree)
+	}
+
+	envs, err := h.queries.ListEnvironments(ctx, wsID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, env := range envs {
+		export.Environments = append(export.Environments, toEnvironmentResponse(env))
+	}
+
+	flows, err := h.queries.ListFlows(ctx, wsID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, f := range flows {
+		fe, err := buildFlowExport(ctx, h.queries, f)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		export.Flows = append(export.Flows, fe)
+	}
+
+	respondMasked(w, r, anon, export)
+}
+
+// Collection exports a collection subtree with full request definitions
+func (h *ExportHandler) Collection(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	anon, ok := anonymizerFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	coll, err := h.queries.GetCollection(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+	tree, err := buildCollectionExport(r.Context(), h.queries, coll)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondMasked(w, r, anon, CollectionExport{
+		Version:    exportVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Masked:     anon.Enabled(),
+		Collection: tree,
+	})
+}
+
+// OpenAPI documents the collection's HTTP requests as an OpenAPI 3.0
+// document, with response schemas inferred from each request's latest JSON
+// response and described by its annotations
+func (h *ExportHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	coll, err := h.queries.GetCollection(r.Context(), id)
+	if err != nil || coll.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+	doc, err := service.BuildCollectionOpenAPI(r.Context(), h.queries, coll)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, doc)
+}
+
+// Flow exports a single flow as a standalone, versioned file
+func (h *ExportHandler) Flow(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	anon, ok := anonymizerFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	flow, err := h.queries.GetFlow(ctx, id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Flow not found")
+		return
+	}
+	fe, err := buildFlowExport(ctx, h.queries, flow)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	file := FlowFile{
+		Format:     flowFileFormat,
+		Version:    flowFileVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Masked:     anon.Enabled(),
+		Flow:       fe,
+		Requests:   []RequestResponse{},
+	}
+	seen := make(map[int64]bool)
+	for _, s := range fe.Steps {
+		if s.RequestID == nil || seen[*s.RequestID] {
+			continue
+		}
+		seen[*s.RequestID] = true
+		req, err := h.queries.GetRequest(ctx, *s.RequestID)
+		if err != nil {
+			continue // source request was deleted; the step is self-contained
+		}
+		file.Requests = append(file.Requests, toRequestResponse(req))
+	}
+
+	respondMasked(w, r, anon, file)
+}
+
+// Run wraps a client-supplied flow run result for export, applying mask rules
+func (h *ExportHandler) Run(w http.ResponseWriter, r *http.Request) {
+	var req RunExportRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Result == nil {
+		respondError(w, http.StatusBadRequest, "result is required")
+		return
+	}
+	anon, err := newAnonymizer(req.Mask, req.Rules)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondMasked(w, r, anon, RunExport{
+		Version:    exportVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Masked:     anon.Enabled(),
+		Result:     req.Result,
+	})
+}
+
+func newAnonymizer(names []string, custom []service.MaskRule) (*service.Anonymizer, error) {
+	rules, err := service.ResolveMaskRules(names, custom)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewAnonymizer(rules)
+}
+
+// anonymizerFromQuery builds an anonymizer from ?mask=email,bearer,uuid (or "all")
+func anonymizerFromQuery(w http.ResponseWriter, r *http.Request) (*service.Anonymizer, bool) {
+	var names []string
+	if v := r.URL.Query().Get("mask"); v != "" {
+		names = strings.Split(v, ",")
+	}
+	anon, err := newAnonymizer(names, nil)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownMaskRule) {
+			respondError(w, http.StatusBadRequest, err.Error())
+		} else {
+			respondError(w, http.StatusInternalServerError, err.Error())
+		}
+		return nil, false
+	}
+	return anon, true
+}
+
+// respondMasked writes an export after anonymizing it, encrypted into a
+// bundle when the request carries a passphrase
+func respondMasked(w http.ResponseWriter, r *http.Request, anon *service.Anonymizer, data interface{}) {
+	if anon.Enabled() {
+		masked, err := anon.Mask(data)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		data = masked
+	}
+
+	passphrase := r.Header.Get(exportPassphraseHeader)
+	if passphrase == "" {
+		respondJSON(w, http.StatusOK, data)
+		return
+	}
+	if len(passphrase) < service.MinBundlePassphrase {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("passphrase must be at least %d characters", service.MinBundlePassphrase))
+		return
+	}
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	bundle, err := service.EncryptBundle(plaintext, passphrase)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, bundle)
+}
+
+// Decrypt returns the export document inside an encrypted bundle, unlocked
+// with the X-Export-Passphrase header
+func (h *ExportHandler) Decrypt(w http.ResponseWriter, r *http.Request) {
+	data, status, err := readImportBody(r)
+	if err != nil {
+		respondError(w, status, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, json.RawMessage(data))
+}
+
+// readImportBody reads an import document, decrypting it first when it is an
+// encrypted bundle. On error it also returns the status to respond with.
+func readImportBody(r *http.Request) ([]byte, int, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(data) {
+		return nil, http.StatusBadRequest, errors.New("Invalid request body")
+	}
+	if !service.IsEncryptedBundle(data) {
+		return data, http.StatusOK, nil
+	}
+
+	passphrase := r.Header.Get(exportPassphraseHeader)
+	if passphrase == "" {
+		return nil, http.StatusBadRequest, errors.New("bundle is encrypted; send the passphrase in the " + exportPassphraseHeader + " header")
+	}
+	var bundle service.EncryptedBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	plaintext, err := service.DecryptBundle(&bundle, passphrase)
+	if errors.Is(err, service.ErrWrongPassphrase) {
+		return nil, http.StatusUnauthorized, err
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return plaintext, http.StatusOK, nil
+}
+
+func buildCollectionExport(ctx context.Context, q *repository.Queries, c repository.Collection) (CollectionResponse, error) {
+	resp := CollectionResponse{
+		ID:        c.ID,
+		Name:      c.Name,
+		SortOrder: c.SortOrder,
+		Children:  []CollectionResponse{},
+		Requests:  []RequestResponse{},
+		CreatedAt: formatTime(c.CreatedAt),
+		UpdatedAt: formatTime(c.UpdatedAt),
+	}
+	if c.ParentID.Valid {
+		parentID := c.ParentID.Int64
+		resp.ParentID = &parentID
+	}
+
+	requests, err := q.ListRequestsByCollection(ctx, sql.NullInt64{Int64: c.ID, Valid: true})
+	if err != nil {
+		return resp, err
+	}
+	for _, req := range requests {
+		rr := toRequestResponse(req)
+		if rr.Annotations, err = listResponseAnnotations(ctx, q, req.ID); err != nil {
+			return resp, err
+		}
+		resp.Requests = append(resp.Requests, rr)
+	}
+
+	children, err := q.ListChildCollections(ctx, sql.NullInt64{Int64: c.ID, Valid: true})
+	if err != nil {
+		return resp, err
+	}
+	for _, child := range children {
+		childResp, err := buildCollectionExport(ctx, q, child)
+		if err != nil {
+			return resp, err
+		}
+		resp.Children = append(resp.Children, childResp)
+	}
+	return resp, nil
+}
+
+func buildFlowExport(ctx context.Context, q *repository.Queries, f repository.Flow) (FlowExport, error) {
+	fe := FlowExport{
+		FlowResponse: toFlowResponse(f),
+		Steps:        []FlowStepResponse{},
+	}
+	steps, err := q.ListFlowSteps(ctx, f.ID)
+	if err != nil {
+		return fe, err
+	}
+	for _, s := range steps {
+		fe.Steps = append(fe.Steps, toFlowStepResponse(s))
+	}
+	return fe, nil
+}
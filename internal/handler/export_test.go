@@ -0,0 +1,124 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupExportServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	q := testutil.SetupTestDB(t)
+
+	collH := handler.NewCollectionHandler(q, nil)
+	reqH := handler.NewRequestHandler(q, nil, nil)
+	exportH := handler.NewExportHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/collections", collH.Create)
+	r.Post("/api/requests", reqH.Create)
+	r.Get("/api/export/workspace", exportH.Workspace)
+	r.Get("/api/export/collections/{id}", exportH.Collection)
+	r.Post("/api/export/run", exportH.Run)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestExport_WorkspaceMasked(t *testing.T) {
+	ts := setupExportServer(t)
+
+	resp, err := postJSON(ts.URL+"/api/collections", `{"name":"Users"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var coll struct {
+		ID int64 `json:"id"`
+	}
+	readJSON(t, resp, &coll)
+
+	body := fmt.Sprintf(`{"collectionId":%d,"name":"Get user","method":"GET","url":"https://api.test/users/3f2b8c1e-9a7d-4c2e-8b1f-0a1b2c3d4e5f","headers":"{\"Authorization\":\"Bearer abc.def\"}","body":"{\"email\":\"dev@corp.io\"}","bodyType":"json"}`, coll.ID)
+	resp, err = postJSON(ts.URL+"/api/requests", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(ts.URL + "/api/export/workspace?mask=all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status: got %d", resp.StatusCode)
+	}
+	var raw map[string]interface{}
+	readJSON(t, resp, &raw)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(raw)
+	out := buf.String()
+	for _, leaked := range []string{"dev@corp.io", "abc.def", "3f2b8c1e-9a7d"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("export leaked %q: %s", leaked, out)
+		}
+	}
+	if !strings.Contains(out, "<uuid>") || !strings.Contains(out, "Bearer <token>") || !strings.Contains(out, "<email>") {
+		t.Errorf("expected placeholders in export: %s", out)
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/api/export/collections/%d", ts.URL, coll.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collExport handler.CollectionExport
+	readJSON(t, resp, &collExport)
+	if collExport.Masked || len(collExport.Collection.Requests) != 1 {
+		t.Fatalf("unexpected collection export: %+v", collExport)
+	}
+	if !strings.Contains(collExport.Collection.Requests[0].Body, "dev@corp.io") {
+		t.Errorf("unmasked export should keep original body, got %s", collExport.Collection.Requests[0].Body)
+	}
+}
+
+func TestExport_RunAndUnknownRule(t *testing.T) {
+	ts := setupExportServer(t)
+
+	resp, err := postJSON(ts.URL+"/api/export/run", `{"result":{"steps":[{"body":"token for a@b.io"}]},"mask":["email"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var runExport struct {
+		Masked bool `json:"masked"`
+		Result struct {
+			Steps []struct {
+				Body string `json:"body"`
+			} `json:"steps"`
+		} `json:"result"`
+	}
+	readJSON(t, resp, &runExport)
+	if !runExport.Masked || runExport.Result.Steps[0].Body != "token for <email>" {
+		t.Errorf("unexpected run export: %+v", runExport)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/export/workspace?mask=phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown rule: got status %d, want 400", resp.StatusCode)
+	}
+}
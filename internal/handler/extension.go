@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ExtensionHandler struct {
+	queries *repository.Queries
+}
+
+func NewExtensionHandler(queries *repository.Queries) *ExtensionHandler {
+	return &ExtensionHandler{queries: queries}
+}
+
+type ExtensionResponse struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Sha256    string   `json:"sha256"`
+	Size      int64    `json:"size"`
+	Exports   []string `json:"exports"`
+	CreatedAt string   `json:"createdAt"`
+	UpdatedAt string   `json:"updatedAt"`
+}
+
+func toExtensionResponse(id int64, name, sha string, size int64, exports string, createdAt, updatedAt sql.NullTime) ExtensionResponse {
+	return ExtensionResponse{
+		ID:        id,
+		Name:      name,
+		Sha256:    sha,
+		Size:      size,
+		Exports:   strings.Split(exports, ","),
+		CreatedAt: formatTime(createdAt),
+		UpdatedAt: formatTime(updatedAt),
+	}
+}
+
+// List returns the workspace's extensions without their module bytes
+func (h *ExtensionHandler) List(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.queries.ListWasmExtensions(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := make([]ExtensionResponse, len(rows))
+	for i, e := range rows {
+		result[i] = toExtensionResponse(e.ID, e.Name, e.Sha256, e.Size, e.Exports, e.CreatedAt, e.UpdatedAt)
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// Upload registers or replaces an extension; the request body is the raw .wasm module
+func (h *ExtensionHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !service.ValidWasmExtensionName(name) {
+		respondError(w, http.StatusBadRequest, "Invalid extension name (lowercase letters, digits, '_' and '-', up to 64 characters)")
+		return
+	}
+
+	module, err := io.ReadAll(io.LimitReader(r.Body, service.MaxWasmModuleSize+1))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read module")
+		return
+	}
+	if len(module) > service.MaxWasmModuleSize {
+		respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Module exceeds %d bytes", service.MaxWasmModuleSize))
+		return
+	}
+	exports, err := service.InspectWasmModule(r.Context(), module)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	e, err := h.queries.UpsertWasmExtension(r.Context(), repository.UpsertWasmExtensionParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        name,
+		Module:      module,
+		Sha256:      service.WasmModuleHash(module),
+		Size:        int64(len(module)),
+		Exports:     strings.Join(exports, ","),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toExtensionResponse(e.ID, e.Name, e.Sha256, e.Size, e.Exports, e.CreatedAt, e.UpdatedAt))
+}
+
+func (h *ExtensionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	e, err := h.queries.GetWasmExtension(r.Context(), repository.GetWasmExtensionParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        chi.URLParam(r, "name"),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Extension not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := h.queries.DeleteWasmExtension(r.Context(), e.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+package handler_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupExtensionTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	h := handler.NewExtensionHandler(testutil.SetupTestDB(t))
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/extensions", h.List)
+	r.Put("/api/extensions/{name}", h.Upload)
+	r.Delete("/api/extensions/{name}", h.Delete)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func putWasm(t *testing.T, url string, module []byte) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPut, url, bytes.NewReader(module))
+	req.Header.Set("Content-Type", "application/wasm")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestExtensions_UploadListDelete(t *testing.T) {
+	ts := setupExtensionTestServer(t)
+	module, err := os.ReadFile("../service/testdata/pass_marker.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := putWasm(t, ts.URL+"/api/extensions/pass-marker", module)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload: status %d", resp.StatusCode)
+	}
+	var ext handler.ExtensionResponse
+	readJSON(t, resp, &ext)
+	if ext.Name != "pass-marker" || ext.Size != int64(len(module)) || len(ext.Exports) != 2 || len(ext.Sha256) != 64 {
+		t.Errorf("uploaded = %+v", ext)
+	}
+
+	// Re-uploading replaces the module under the same name
+	resp = putWasm(t, ts.URL+"/api/extensions/pass-marker", module)
+	resp.Body.Close()
+
+	resp, _ = http.Get(ts.URL + "/api/extensions")
+	var list []handler.ExtensionResponse
+	readJSON(t, resp, &list)
+	if len(list) != 1 || list[0].ID != ext.ID {
+		t.Errorf("list = %+v", list)
+	}
+
+	for name, tc := range map[string]struct {
+		name   string
+		module []byte
+	}{
+		"invalid name":   {"Bad.Name", module},
+		"invalid module": {"junk", []byte("not wasm")},
+	} {
+		resp := putWasm(t, ts.URL+"/api/extensions/"+tc.name, tc.module)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, resp.StatusCode)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/extensions/pass-marker", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status %d", resp.StatusCode)
+	}
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("second delete: status %d", resp.StatusCode)
+	}
+}
@@ -14,10 +14,11 @@ type FileHandler struct {
 	db          *sql.DB
 	queries     *repository.Queries
 	fileStorage *service.FileStorage
+	jobs        *service.JobQueue // optional; needed for async cleanup
 }
 
-func NewFileHandler(db *sql.DB, queries *repository.Queries, fileStorage *service.FileStorage) *FileHandler {
-	return &FileHandler{db: db, queries: queries, fileStorage: fileStorage}
+func NewFileHandler(db *sql.DB, queries *repository.Queries, fileStorage *service.FileStorage, jobs *service.JobQueue) *FileHandler {
+	return &FileHandler{db: db, queries: queries, fileStorage: fileStorage, jobs: jobs}
 }
 
 type UploadedFileResponse struct {
@@ -123,11 +124,26 @@ func (h *FileHandler) Delete(w http.ResponseWriter, r *http.Request) {
 func (h *FileHandler) Cleanup(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		DryRun bool `json:"dryRun"`
+		Async  bool `json:"async"` // queue as a job and return it (202)
 	}
 	if r.Body != nil {
 		json.NewDecoder(r.Body).Decode(&req)
 	}
 
+	if req.Async {
+		if h.jobs == nil {
+			respondError(w, http.StatusServiceUnavailable, "Job queue is not available")
+			return
+		}
+		job, err := h.jobs.Enqueue(r.Context(), service.JobTypeFileCleanup, map[string]bool{"dryRun": req.DryRun}, service.EnqueueOptions{MaxAttempts: 1})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		respondJSON(w, http.StatusAccepted, toJobResponse(job))
+		return
+	}
+
 	result, err := service.CleanupOrphanFiles(r.Context(), h.db, h.queries, h.fileStorage, req.DryRun)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Cleanup failed: "+err.Error())
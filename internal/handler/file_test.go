@@ -27,7 +27,7 @@ func setupFileTestServer(t *testing.T) *httptest.Server {
 	if err != nil {
 		t.Fatalf("NewFileStorage: %v", err)
 	}
-	fh := handler.NewFileHandler(db, q, fs)
+	fh := handler.NewFileHandler(db, q, fs, nil)
 
 	r := chi.NewRouter()
 	r.Use(middleware.WorkspaceID)
@@ -203,7 +203,10 @@ func TestFileDelete_Success(t *testing.T) {
 	}
 
 	// Verify it's gone
-	getResp, _ := http.Get(ts.URL + fmt.Sprintf("/api/files/%d", uploaded.ID))
+	getResp, err := http.Get(ts.URL + fmt.Sprintf("/api/files/%d", uploaded.ID))
+	if err != nil {
+		t.Fatalf("get after delete: %v", err)
+	}
 	defer getResp.Body.Close()
 	if getResp.StatusCode != http.StatusNotFound {
 		t.Errorf("expected 404 after delete, got %d", getResp.StatusCode)
@@ -277,7 +280,10 @@ func TestFileUploadAndGet_RoundTrip(t *testing.T) {
 	req, _ := http.NewRequest("DELETE", ts.URL+fmt.Sprintf("/api/files/%d", f1.ID), nil)
 	http.DefaultClient.Do(req)
 
-	getResp, _ := http.Get(ts.URL + fmt.Sprintf("/api/files/%d", f2.ID))
+	getResp, err := http.Get(ts.URL + fmt.Sprintf("/api/files/%d", f2.ID))
+	if err != nil {
+		t.Fatalf("get file2: %v", err)
+	}
 	defer getResp.Body.Close()
 	if getResp.StatusCode != http.StatusOK {
 		t.Errorf("file2 should still exist after deleting file1, got %d", getResp.StatusCode)
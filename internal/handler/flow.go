@@ -1,20 +1,30 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"relay/internal/middleware"
 	"relay/internal/repository"
 	"relay/internal/service"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
 )
 
 type FlowHandler struct {
 	queries *repository.Queries
 	runner  *service.FlowRunner
 	db      *sql.DB
+	// Runs of POST /flows/{id}/run that went on in the background at an
+	// approval gate: run ID → the gate they first waited at
+	backgroundRuns sync.Map
 }
 
 func NewFlowHandler(queries *repository.Queries, runner *service.FlowRunner, db *sql.DB) *FlowHandler {
@@ -24,39 +34,153 @@ func NewFlowHandler(queries *repository.Queries, runner *service.FlowRunner, db
 type FlowRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// Outputs maps output names to variables; omitted on update = unchanged
+	Outputs map[string]string `json:"outputs"`
 }
 
 type FlowResponse struct {
-	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	SortOrder   int64  `json:"sortOrder"`
-	CreatedAt   string `json:"createdAt"`
-	UpdatedAt   string `json:"updatedAt"`
+	ID          int64             `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Outputs     map[string]string `json:"outputs"`
+	SortOrder   int64             `json:"sortOrder"`
+	CreatedAt   string            `json:"createdAt"`
+	UpdatedAt   string            `json:"updatedAt"`
+
+	ExecutionCount int64  `json:"executionCount"`
+	LastExecutedAt string `json:"lastExecutedAt,omitempty"`
+	Archived       bool   `json:"archived"`
+	ArchivedAt     string `json:"archivedAt,omitempty"`
+
+	// LastRun is set on the list while the latest run's timeline is kept
+	LastRun *FlowRunSummary `json:"lastRun,omitempty"`
+}
+
+// FlowRunSummary is the outcome of a flow's latest run
+type FlowRunSummary struct {
+	RunID      string `json:"runId"`
+	Success    bool   `json:"success"`
+	StartedAt  string `json:"startedAt"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+func toFlowResponse(f repository.Flow) FlowResponse {
+	outputs := service.ParseFlowOutputs(f.Outputs)
+	if outputs == nil {
+		outputs = map[string]string{}
+	}
+	return FlowResponse{
+		ID:          f.ID,
+		Name:        f.Name,
+		Description: f.Description.String,
+		Outputs:     outputs,
+		SortOrder:   f.SortOrder,
+		CreatedAt:   formatTime(f.CreatedAt),
+		UpdatedAt:   formatTime(f.UpdatedAt),
+
+		ExecutionCount: f.ExecutionCount,
+		LastExecutedAt: formatTime(f.LastExecutedAt),
+		Archived:       f.ArchivedAt.Valid,
+		ArchivedAt:     formatTime(f.ArchivedAt),
+	}
+}
+
+// flowOutputsColumn encodes outputs for the flows.outputs column
+func flowOutputsColumn(outputs map[string]string) (sql.NullString, error) {
+	if len(outputs) == 0 {
+		return sql.NullString{String: "", Valid: true}, nil
+	}
+	if err := service.ValidateFlowOutputs(outputs); err != nil {
+		return sql.NullString{}, err
+	}
+	data, err := json.Marshal(outputs)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
 }
 
 type FlowStepRequest struct {
-	RequestID       *int64 `json:"requestId"`
-	StepOrder       int64  `json:"stepOrder"`
-	DelayMs         int64  `json:"delayMs"`
-	ExtractVars     string `json:"extractVars"`
-	Condition       string `json:"condition"`
-	Name            string `json:"name"`
-	Method          string `json:"method"`
-	URL             string `json:"url"`
-	Headers         string `json:"headers"`
-	Body            string `json:"body"`
-	BodyType        string `json:"bodyType"`
-	Cookies         string `json:"cookies"`
-	ProxyID         *int64 `json:"proxyId"`
-	LoopCount       int64  `json:"loopCount"`
-	PreScript       string `json:"preScript"`
-	PostScript      string `json:"postScript"`
-	ContinueOnError bool   `json:"continueOnError"`
+	RequestID         *int64 `json:"requestId"`
+	StepOrder         int64  `json:"stepOrder"`
+	DelayMs           int64  `json:"delayMs"`
+	ExtractVars       string `json:"extractVars"`
+	Condition         string `json:"condition"`
+	Name              string `json:"name"`
+	Method            string `json:"method"`
+	URL               string `json:"url"`
+	Headers           string `json:"headers"`
+	Body              string `json:"body"`
+	BodyType          string `json:"bodyType"`
+	Cookies           string `json:"cookies"`
+	ProxyID           *int64 `json:"proxyId"`
+	LoopCount         int64  `json:"loopCount"`
+	PreScript         string `json:"preScript"`
+	PostScript        string `json:"postScript"`
+	ContinueOnError   bool   `json:"continueOnError"`
+	ResponseTransform string `json:"responseTransform"`
+	WaitUntil         string `json:"waitUntil"`
+	Approval          string `json:"approval"`
+	// Timeout, retry and redirect policy, as on saved requests
+	TimeoutMs       int64  `json:"timeoutMs"`
+	RetryCount      int64  `json:"retryCount"`
+	RetryBackoffMs  int64  `json:"retryBackoffMs"`
+	RetryOnStatus   string `json:"retryOnStatus"`
+	FollowRedirects *bool  `json:"followRedirects"`
+	MaxRedirects    int64  `json:"maxRedirects"`
+}
+
+func (r FlowStepRequest) policy() service.RequestPolicy {
+	return service.RequestPolicy{TimeoutMs: r.TimeoutMs, RetryCount: r.RetryCount, RetryBackoffMs: r.RetryBackoffMs, RetryOnStatus: r.RetryOnStatus, MaxRedirects: r.MaxRedirects}
 }
 
 type RunFlowRequest struct {
-	StepIDs []int64 `json:"stepIds"`
+	StepIDs     []int64           `json:"stepIds"`
+	StartStepID int64             `json:"startStepId"`
+	EndStepID   int64             `json:"endStepId"`
+	RuntimeVars map[string]string `json:"runtimeVars"`
+	// VariableMode is "live" (default) or "snapshot"
+	VariableMode string `json:"variableMode"`
+	// DryVariables keeps pm.environment/collectionVariables/globals writes out of the DB
+	DryVariables bool `json:"dryVariables"`
+	// FrozenTime freezes {{$timestamp}}, {{$date}} and Date.now() for the run
+	// (RFC3339 or unix milliseconds)
+	FrozenTime string `json:"frozenTime"`
+	// Seed makes {{$randomInt}}, {{$guid}} and Math.random() repeat across runs
+	Seed *int64 `json:"seed"`
+	// TraceVariables adds each step's variable sources to its executeResult
+	TraceVariables bool `json:"traceVariables"`
+	// CacheSendRequests memoizes pm.sendRequest by method+URL+body for the run
+	CacheSendRequests bool `json:"cacheSendRequests"`
+	// RestoreVariables is "never" (default), "always" or "onFailure": roll back
+	// the run's environment/collection/global writes when it ends or fails
+	RestoreVariables string `json:"restoreVariables"`
+	// SafeMode only sends GET, HEAD and OPTIONS requests; blocked steps are skipped
+	SafeMode bool `json:"safeMode"`
+	// Chaos injects faults into the run's requests to test error handling
+	Chaos *service.ChaosOptions `json:"chaos"`
+	// ValidateSpec fails steps whose response does not match the workspace's
+	// OpenAPI specs
+	ValidateSpec bool `json:"validateSpec"`
+}
+
+func (req RunFlowRequest) toRunOptions() *service.RunOptions {
+	return &service.RunOptions{
+		StepIDs:           req.StepIDs,
+		StartStepID:       req.StartStepID,
+		EndStepID:         req.EndStepID,
+		InitialVars:       req.RuntimeVars,
+		VariableMode:      service.VariableMode(req.VariableMode),
+		DryVariables:      req.DryVariables,
+		FrozenTime:        req.FrozenTime,
+		Seed:              req.Seed,
+		TraceVariables:    req.TraceVariables,
+		CacheSendRequests: req.CacheSendRequests,
+		RestoreVariables:  service.VariableRestore(req.RestoreVariables),
+		SafeMode:          req.SafeMode,
+		Chaos:             req.Chaos,
+		ValidateSpec:      req.ValidateSpec,
+	}
 }
 
 type ImportCollectionRequest struct {
@@ -64,27 +188,36 @@ type ImportCollectionRequest struct {
 }
 
 type FlowStepResponse struct {
-	ID              int64  `json:"id"`
-	FlowID          int64  `json:"flowId"`
-	RequestID       *int64 `json:"requestId"`
-	StepOrder       int64  `json:"stepOrder"`
-	DelayMs         int64  `json:"delayMs"`
-	ExtractVars     string `json:"extractVars"`
-	Condition       string `json:"condition"`
-	Name            string `json:"name"`
-	Method          string `json:"method"`
-	URL             string `json:"url"`
-	Headers         string `json:"headers"`
-	Body            string `json:"body"`
-	BodyType        string `json:"bodyType"`
-	Cookies         string `json:"cookies"`
-	ProxyID         *int64 `json:"proxyId"`
-	LoopCount       int64  `json:"loopCount"`
-	PreScript       string `json:"preScript"`
-	PostScript      string `json:"postScript"`
-	ContinueOnError bool   `json:"continueOnError"`
-	CreatedAt       string `json:"createdAt"`
-	UpdatedAt       string `json:"updatedAt"`
+	ID                int64  `json:"id"`
+	FlowID            int64  `json:"flowId"`
+	RequestID         *int64 `json:"requestId"`
+	StepOrder         int64  `json:"stepOrder"`
+	DelayMs           int64  `json:"delayMs"`
+	ExtractVars       string `json:"extractVars"`
+	Condition         string `json:"condition"`
+	Name              string `json:"name"`
+	Method            string `json:"method"`
+	URL               string `json:"url"`
+	Headers           string `json:"headers"`
+	Body              string `json:"body"`
+	BodyType          string `json:"bodyType"`
+	Cookies           string `json:"cookies"`
+	ProxyID           *int64 `json:"proxyId"`
+	LoopCount         int64  `json:"loopCount"`
+	PreScript         string `json:"preScript"`
+	PostScript        string `json:"postScript"`
+	ContinueOnError   bool   `json:"continueOnError"`
+	ResponseTransform string `json:"responseTransform"`
+	WaitUntil         string `json:"waitUntil"`
+	Approval          string `json:"approval"`
+	TimeoutMs         int64  `json:"timeoutMs"`
+	RetryCount        int64  `json:"retryCount"`
+	RetryBackoffMs    int64  `json:"retryBackoffMs"`
+	RetryOnStatus     string `json:"retryOnStatus"`
+	FollowRedirects   *bool  `json:"followRedirects"`
+	MaxRedirects      int64  `json:"maxRedirects"`
+	CreatedAt         string `json:"createdAt"`
+	UpdatedAt         string `json:"updatedAt"`
 }
 
 func toFlowStepResponse(s repository.FlowStep) FlowStepResponse {
@@ -102,48 +235,85 @@ func toFlowStepResponse(s repository.FlowStep) FlowStepResponse {
 		loopCount = 1
 	}
 	return FlowStepResponse{
-		ID:              s.ID,
-		FlowID:          s.FlowID,
-		RequestID:       reqID,
-		StepOrder:       s.StepOrder,
-		DelayMs:         s.DelayMs.Int64,
-		ExtractVars:     s.ExtractVars.String,
-		Condition:       s.Condition.String,
-		Name:            s.Name,
-		Method:          s.Method,
-		URL:             s.Url,
-		Headers:         s.Headers.String,
-		Body:            s.Body.String,
-		BodyType:        s.BodyType.String,
-		Cookies:         s.Cookies.String,
-		ProxyID:         proxyID,
-		LoopCount:       loopCount,
-		PreScript:       s.PreScript.String,
-		PostScript:      s.PostScript.String,
-		ContinueOnError: s.ContinueOnError.Int64 == 1,
-		CreatedAt:       formatTime(s.CreatedAt),
-		UpdatedAt:       formatTime(s.UpdatedAt),
+		ID:                s.ID,
+		FlowID:            s.FlowID,
+		RequestID:         reqID,
+		StepOrder:         s.StepOrder,
+		DelayMs:           s.DelayMs.Int64,
+		ExtractVars:       s.ExtractVars.String,
+		Condition:         s.Condition.String,
+		Name:              s.Name,
+		Method:            s.Method,
+		URL:               s.Url,
+		Headers:           s.Headers.String,
+		Body:              s.Body.String,
+		BodyType:          s.BodyType.String,
+		Cookies:           s.Cookies.String,
+		ProxyID:           proxyID,
+		LoopCount:         loopCount,
+		PreScript:         s.PreScript.String,
+		PostScript:        s.PostScript.String,
+		ContinueOnError:   s.ContinueOnError.Int64 == 1,
+		ResponseTransform: s.ResponseTransform.String,
+		WaitUntil:         s.WaitUntil.String,
+		Approval:          s.Approval.String,
+		TimeoutMs:         s.TimeoutMs,
+		RetryCount:        s.RetryCount,
+		RetryBackoffMs:    s.RetryBackoffMs,
+		RetryOnStatus:     s.RetryOnStatus,
+		FollowRedirects:   followsRedirects(s.DisableRedirects),
+		MaxRedirects:      s.MaxRedirects,
+		CreatedAt:         formatTime(s.CreatedAt),
+		UpdatedAt:         formatTime(s.UpdatedAt),
 	}
 }
 
 func (h *FlowHandler) List(w http.ResponseWriter, r *http.Request) {
+	lq, ok := parseListQuery(w, r, usageSortExecutions, usageSortLastExecuted, listSortName, listSortCreatedAt, listSortUpdatedAt)
+	if !ok {
+		return
+	}
 	wsID := middleware.GetWorkspaceID(r.Context())
-	flows, err := h.queries.ListFlows(r.Context(), wsID)
+	all, err := h.queries.ListFlows(r.Context(), wsID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	archived := includeArchived(r)
+	flows := all[:0]
+	for _, f := range all {
+		if (archived || !f.ArchivedAt.Valid) && lq.matches(f.Name, f.Description.String) {
+			flows = append(flows, f)
+		}
+	}
+	sortByUsage(flows, lq.sortBy, lq.desc, func(f repository.Flow) (int64, sql.NullTime) {
+		return f.ExecutionCount, f.LastExecutedAt
+	})
+	sortByFields(flows, lq.sortBy, lq.desc, func(f repository.Flow) (string, sql.NullTime, sql.NullTime) {
+		return f.Name, f.CreatedAt, f.UpdatedAt
+	})
+	flows = paginate(w, flows, lq)
+
+	lastRuns := map[int64]*FlowRunSummary{}
+	if runs, err := h.queries.ListLatestFlowRunTimelines(r.Context(), wsID); err == nil {
+		for _, run := range runs {
+			var tl service.RunTimeline
+			if json.Unmarshal([]byte(run.Timeline), &tl) == nil {
+				lastRuns[run.FlowID] = &FlowRunSummary{
+					RunID:      run.RunID,
+					Success:    tl.Success,
+					StartedAt:  tl.StartedAt.UTC().Format(time.RFC3339),
+					DurationMs: tl.TotalMs,
+				}
+			}
+		}
+	}
 
 	resp := make([]FlowResponse, 0, len(flows))
 	for _, f := range flows {
-		resp = append(resp, FlowResponse{
-			ID:          f.ID,
-			Name:        f.Name,
-			Description: f.Description.String,
-			SortOrder:   f.SortOrder,
-			CreatedAt:   formatTime(f.CreatedAt),
-			UpdatedAt:   formatTime(f.UpdatedAt),
-		})
+		fr := toFlowResponse(f)
+		fr.LastRun = lastRuns[f.ID]
+		resp = append(resp, fr)
 	}
 
 	respondJSON(w, http.StatusOK, resp)
@@ -162,14 +332,7 @@ func (h *FlowHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, FlowResponse{
-		ID:          flow.ID,
-		Name:        flow.Name,
-		Description: flow.Description.String,
-		SortOrder:   flow.SortOrder,
-		CreatedAt:   formatTime(flow.CreatedAt),
-		UpdatedAt:   formatTime(flow.UpdatedAt),
-	})
+	respondJSON(w, http.StatusOK, toFlowResponse(flow))
 }
 
 func (h *FlowHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -179,6 +342,12 @@ func (h *FlowHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	outputs, err := flowOutputsColumn(req.Outputs)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	wsID := middleware.GetWorkspaceID(r.Context())
 
 	// Calculate next sort_order
@@ -193,20 +362,14 @@ func (h *FlowHandler) Create(w http.ResponseWriter, r *http.Request) {
 		Description: sql.NullString{String: req.Description, Valid: req.Description != ""},
 		WorkspaceID: wsID,
 		SortOrder:   maxSortOrder + 1,
+		Outputs:     outputs,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, FlowResponse{
-		ID:          flow.ID,
-		Name:        flow.Name,
-		Description: flow.Description.String,
-		SortOrder:   flow.SortOrder,
-		CreatedAt:   formatTime(flow.CreatedAt),
-		UpdatedAt:   formatTime(flow.UpdatedAt),
-	})
+	respondJSON(w, http.StatusCreated, toFlowResponse(flow))
 }
 
 func (h *FlowHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -222,24 +385,31 @@ func (h *FlowHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var outputs sql.NullString
+	if req.Outputs == nil {
+		existing, err := h.queries.GetFlow(r.Context(), id)
+		if err != nil {
+			respondError(w, http.StatusNotFound, "Flow not found")
+			return
+		}
+		outputs = existing.Outputs
+	} else if outputs, err = flowOutputsColumn(req.Outputs); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	flow, err := h.queries.UpdateFlow(r.Context(), repository.UpdateFlowParams{
 		ID:          id,
 		Name:        req.Name,
 		Description: sql.NullString{String: req.Description, Valid: req.Description != ""},
+		Outputs:     outputs,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, FlowResponse{
-		ID:          flow.ID,
-		Name:        flow.Name,
-		Description: flow.Description.String,
-		SortOrder:   flow.SortOrder,
-		CreatedAt:   formatTime(flow.CreatedAt),
-		UpdatedAt:   formatTime(flow.UpdatedAt),
-	})
+	respondJSON(w, http.StatusOK, toFlowResponse(flow))
 }
 
 func (h *FlowHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -253,11 +423,20 @@ func (h *FlowHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	// Foreign keys aren't enforced, so remove the graph and runs explicitly
+	h.queries.DeleteFlowEdgesByFlow(r.Context(), id)
+	h.queries.DeleteFlowNodesByFlow(r.Context(), id)
+	h.queries.DeleteRunTimelinesByFlow(r.Context(), id)
+	h.queries.DeleteFlowRunsByFlow(r.Context(), id)
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *FlowHandler) Run(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, executionQuotas...) {
+		return
+	}
+
 	id, err := parseID(r, "id")
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid ID")
@@ -267,12 +446,52 @@ func (h *FlowHandler) Run(w http.ResponseWriter, r *http.Request) {
 	var req RunFlowRequest
 	if err := decodeJSON(r, &req); err != nil {
 		// Ignore decode error for backwards compatibility (empty body)
-		req.StepIDs = nil
-	}
+		req = RunFlowRequest{}
+	}
+
+	// A run that reaches an approval gate doesn't hold the request open while
+	// it waits: the response says so and the run goes on in the background,
+	// ending up in GET /api/flow-runs/{runId}
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	waiting := make(chan service.PendingApproval, 1)
+	done := make(chan struct{})
+	var result *service.FlowResult
+	var runErr error
+	go func() {
+		defer close(done)
+		defer cancel()
+		var runID string
+		result, runErr = h.runner.RunWithOptions(runCtx, id, req.toRunOptions(), &service.StreamCallbacks{
+			OnApproval: func(e service.PendingApproval) {
+				if runID == "" {
+					runID = e.RunID
+					h.backgroundRuns.Store(runID, e)
+					waiting <- e
+				}
+			},
+		})
+		if runID != "" {
+			h.backgroundRuns.Delete(runID)
+		}
+	}()
 
-	result, err := h.runner.Run(r.Context(), id, req.StepIDs)
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+	select {
+	case e := <-waiting:
+		respondJSON(w, http.StatusAccepted, RunWaitingResponse{RunID: e.RunID, Status: RunStatusWaitingApproval, Approval: e})
+		return
+	case <-r.Context().Done():
+		// The client left before any gate; stop the run as before
+		cancel()
+		<-done
+		return
+	case <-done:
+	}
+	if runErr != nil {
+		if errors.Is(runErr, service.ErrInvalidRunOptions) {
+			respondError(w, http.StatusBadRequest, runErr.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, runErr.Error())
 		return
 	}
 
@@ -280,6 +499,10 @@ func (h *FlowHandler) Run(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *FlowHandler) RunStream(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, executionQuotas...) {
+		return
+	}
+
 	id, err := parseID(r, "id")
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid ID")
@@ -288,7 +511,7 @@ func (h *FlowHandler) RunStream(w http.ResponseWriter, r *http.Request) {
 
 	var req RunFlowRequest
 	if err := decodeJSON(r, &req); err != nil {
-		req.StepIDs = nil
+		req = RunFlowRequest{}
 	}
 
 	flusher, ok := w.(http.Flusher)
@@ -317,12 +540,139 @@ func (h *FlowHandler) RunStream(w http.ResponseWriter, r *http.Request) {
 		OnStepComplete: func(sr service.StepResult) {
 			writeSSE("step:complete", sr)
 		},
+		OnStepWait: func(e service.WaitEvent) {
+			writeSSE("step:wait", e)
+		},
+		OnApproval: func(e service.PendingApproval) {
+			writeSSE("step:approval", e)
+		},
 		OnFlowComplete: func(e service.FlowCompleteEvent) {
 			writeSSE("flow:complete", e)
 		},
 	}
 
-	h.runner.RunStream(r.Context(), id, req.StepIDs, callbacks)
+	if _, err := h.runner.RunWithOptions(r.Context(), id, req.toRunOptions(), callbacks); err != nil {
+		writeSSE("flow:complete", service.FlowCompleteEvent{Success: false, Error: err.Error()})
+	}
+}
+
+// debugMessage is the JSON envelope used on the flow debug WebSocket.
+// Client → server: start, continue, step, abort
+// Server → client: step:start, step:wait, step:approval, step:complete, paused, flow:complete, error
+type debugMessage struct {
+	Type  string `json:"type"`
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// debugStartMessage is the first client message; it carries the run options
+type debugStartMessage struct {
+	Type string `json:"type"`
+	RunFlowRequest
+	Breakpoints []int64 `json:"breakpoints"`
+}
+
+// Debug runs a flow interactively over WebSocket, pausing at breakpoints
+// and waiting for continue/step/abort commands from the client.
+func (h *FlowHandler) Debug(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var start debugStartMessage
+	if err := wsjson.Read(ctx, conn, &start); err != nil {
+		return
+	}
+	if start.Type != "start" {
+		wsjson.Write(ctx, conn, debugMessage{Type: "error", Error: "Expected 'start' message as first message"})
+		return
+	}
+
+	// Reader goroutine: forwards commands; abort also cancels in-flight requests
+	commands := make(chan service.DebugCommand, 8)
+	go func() {
+		defer close(commands)
+		for {
+			var msg debugMessage
+			if err := wsjson.Read(ctx, conn, &msg); err != nil {
+				cancel()
+				return
+			}
+			cmd := service.DebugCommand(msg.Type)
+			// Commands sent while the run isn't paused must not block the
+			// reader once the handler has returned
+			switch cmd {
+			case service.DebugContinue, service.DebugStep:
+				select {
+				case commands <- cmd:
+				case <-ctx.Done():
+					return
+				}
+			case service.DebugAbort:
+				select {
+				case commands <- cmd:
+				case <-ctx.Done():
+					return
+				}
+				cancel()
+				return
+			}
+		}
+	}()
+
+	send := func(msgType string, data any) {
+		wsjson.Write(ctx, conn, debugMessage{Type: msgType, Data: data})
+	}
+
+	callbacks := &service.StreamCallbacks{
+		OnStepStart: func(e service.StepStartEvent) {
+			send("step:start", e)
+		},
+		OnStepComplete: func(sr service.StepResult) {
+			send("step:complete", sr)
+		},
+		OnStepWait: func(e service.WaitEvent) {
+			send("step:wait", e)
+		},
+		OnApproval: func(e service.PendingApproval) {
+			send("step:approval", e)
+		},
+		OnFlowComplete: func(e service.FlowCompleteEvent) {
+			// Use the parent context so the final event is delivered after an abort
+			wsjson.Write(r.Context(), conn, debugMessage{Type: "flow:complete", Data: e})
+		},
+		OnPause: func(e service.PauseEvent) service.DebugCommand {
+			send("paused", e)
+			select {
+			case cmd, ok := <-commands:
+				if !ok {
+					return service.DebugAbort
+				}
+				return cmd
+			case <-ctx.Done():
+				return service.DebugAbort
+			}
+		},
+	}
+
+	opts := start.RunFlowRequest.toRunOptions()
+	opts.Breakpoints = start.Breakpoints
+	if _, err := h.runner.RunWithOptions(ctx, id, opts, callbacks); err != nil {
+		wsjson.Write(ctx, conn, debugMessage{Type: "error", Error: err.Error()})
+	}
 }
 
 func (h *FlowHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
@@ -343,6 +693,11 @@ func (h *FlowHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	graph, err := service.LoadFlowGraph(r.Context(), h.queries, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	tx, err := h.db.BeginTx(r.Context(), nil)
 	if err != nil {
@@ -357,6 +712,7 @@ func (h *FlowHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
 		Name:        source.Name + " (Copy)",
 		Description: source.Description,
 		WorkspaceID: source.WorkspaceID,
+		Outputs:     source.Outputs,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
@@ -365,24 +721,33 @@ func (h *FlowHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
 
 	for _, s := range steps {
 		_, err := txQueries.CreateFlowStep(r.Context(), repository.CreateFlowStepParams{
-			FlowID:          newFlow.ID,
-			RequestID:       s.RequestID,
-			StepOrder:       s.StepOrder,
-			DelayMs:         s.DelayMs,
-			ExtractVars:     s.ExtractVars,
-			Condition:       s.Condition,
-			Name:            s.Name,
-			Method:          s.Method,
-			Url:             s.Url,
-			Headers:         s.Headers,
-			Body:            s.Body,
-			BodyType:        s.BodyType,
-			Cookies:         s.Cookies,
-			ProxyID:         s.ProxyID,
-			LoopCount:       s.LoopCount,
-			PreScript:       s.PreScript,
-			PostScript:      s.PostScript,
-			ContinueOnError: s.ContinueOnError,
+			FlowID:            newFlow.ID,
+			RequestID:         s.RequestID,
+			StepOrder:         s.StepOrder,
+			DelayMs:           s.DelayMs,
+			ExtractVars:       s.ExtractVars,
+			Condition:         s.Condition,
+			Name:              s.Name,
+			Method:            s.Method,
+			Url:               s.Url,
+			Headers:           s.Headers,
+			Body:              s.Body,
+			BodyType:          s.BodyType,
+			Cookies:           s.Cookies,
+			ProxyID:           s.ProxyID,
+			LoopCount:         s.LoopCount,
+			PreScript:         s.PreScript,
+			PostScript:        s.PostScript,
+			ContinueOnError:   s.ContinueOnError,
+			ResponseTransform: s.ResponseTransform,
+			WaitUntil:         s.WaitUntil,
+			Approval:          s.Approval,
+			TimeoutMs:         s.TimeoutMs,
+			RetryCount:        s.RetryCount,
+			RetryBackoffMs:    s.RetryBackoffMs,
+			RetryOnStatus:     s.RetryOnStatus,
+			DisableRedirects:  s.DisableRedirects,
+			MaxRedirects:      s.MaxRedirects,
 		})
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, err.Error())
@@ -390,19 +755,17 @@ func (h *FlowHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if err := saveFlowGraph(r.Context(), txQueries, newFlow.ID, graph); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, FlowResponse{
-		ID:          newFlow.ID,
-		Name:        newFlow.Name,
-		Description: newFlow.Description.String,
-		SortOrder:   newFlow.SortOrder,
-		CreatedAt:   formatTime(newFlow.CreatedAt),
-		UpdatedAt:   formatTime(newFlow.UpdatedAt),
-	})
+	respondJSON(w, http.StatusCreated, toFlowResponse(newFlow))
 }
 
 func (h *FlowHandler) ImportCollection(w http.ResponseWriter, r *http.Request) {
@@ -458,30 +821,37 @@ func (h *FlowHandler) ImportCollection(w http.ResponseWriter, r *http.Request) {
 	createdSteps := make([]FlowStepResponse, 0)
 	stepIndex := int64(0)
 	for _, req := range requests {
-		// Skip WebSocket requests
-		if req.Method == "WS" {
+		// Skip WebSocket and archived requests
+		if req.Method == "WS" || req.ArchivedAt.Valid {
 			continue
 		}
 
 		step, err := txQueries.CreateFlowStep(r.Context(), repository.CreateFlowStepParams{
-			FlowID:          id,
-			RequestID:       sql.NullInt64{Int64: req.ID, Valid: true},
-			StepOrder:       maxStepOrder + stepIndex + 1,
-			Name:            req.Name,
-			Method:          req.Method,
-			Url:             req.Url,
-			Headers:         req.Headers,
-			Body:            req.Body,
-			BodyType:        req.BodyType,
-			Cookies:         req.Cookies,
-			ProxyID:         req.ProxyID,
-			PreScript:       req.PreScript,
-			PostScript:      req.PostScript,
-			DelayMs:         sql.NullInt64{Int64: 0, Valid: true},
-			ExtractVars:     sql.NullString{String: "{}", Valid: true},
-			Condition:       sql.NullString{},
-			LoopCount:       sql.NullInt64{Int64: 1, Valid: true},
-			ContinueOnError: sql.NullInt64{Int64: 0, Valid: true},
+			FlowID:            id,
+			RequestID:         sql.NullInt64{Int64: req.ID, Valid: true},
+			StepOrder:         maxStepOrder + stepIndex + 1,
+			Name:              req.Name,
+			Method:            req.Method,
+			Url:               req.Url,
+			Headers:           req.Headers,
+			Body:              req.Body,
+			BodyType:          req.BodyType,
+			Cookies:           req.Cookies,
+			ProxyID:           req.ProxyID,
+			PreScript:         req.PreScript,
+			PostScript:        req.PostScript,
+			DelayMs:           sql.NullInt64{Int64: 0, Valid: true},
+			ExtractVars:       sql.NullString{String: "{}", Valid: true},
+			Condition:         sql.NullString{},
+			LoopCount:         sql.NullInt64{Int64: 1, Valid: true},
+			ContinueOnError:   sql.NullInt64{Int64: 0, Valid: true},
+			ResponseTransform: req.ResponseTransform,
+			TimeoutMs:         req.TimeoutMs,
+			RetryCount:        req.RetryCount,
+			RetryBackoffMs:    req.RetryBackoffMs,
+			RetryOnStatus:     req.RetryOnStatus,
+			DisableRedirects:  req.DisableRedirects,
+			MaxRedirects:      req.MaxRedirects,
 		})
 		if err != nil {
 			respondError(w, http.StatusInternalServerError, err.Error())
@@ -533,6 +903,18 @@ func (h *FlowHandler) CreateStep(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if _, err := service.ParseWaitUntil(req.WaitUntil); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := service.ParseApprovalGate(req.Approval); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := req.policy().Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	if req.ExtractVars == "" {
 		req.ExtractVars = "{}"
@@ -577,24 +959,33 @@ func (h *FlowHandler) CreateStep(w http.ResponseWriter, r *http.Request) {
 	}
 
 	step, err := h.queries.CreateFlowStep(r.Context(), repository.CreateFlowStepParams{
-		FlowID:          flowID,
-		RequestID:       reqID,
-		StepOrder:       req.StepOrder,
-		DelayMs:         sql.NullInt64{Int64: req.DelayMs, Valid: true},
-		ExtractVars:     sql.NullString{String: req.ExtractVars, Valid: true},
-		Condition:       sql.NullString{String: req.Condition, Valid: req.Condition != ""},
-		Name:            req.Name,
-		Method:          req.Method,
-		Url:             req.URL,
-		Headers:         sql.NullString{String: req.Headers, Valid: true},
-		Body:            sql.NullString{String: req.Body, Valid: true},
-		BodyType:        sql.NullString{String: req.BodyType, Valid: true},
-		Cookies:         sql.NullString{String: req.Cookies, Valid: true},
-		ProxyID:         proxyID,
-		LoopCount:       sql.NullInt64{Int64: loopCount, Valid: true},
-		PreScript:       sql.NullString{String: req.PreScript, Valid: req.PreScript != ""},
-		PostScript:      sql.NullString{String: req.PostScript, Valid: req.PostScript != ""},
-		ContinueOnError: sql.NullInt64{Int64: continueOnError, Valid: true},
+		FlowID:            flowID,
+		RequestID:         reqID,
+		StepOrder:         req.StepOrder,
+		DelayMs:           sql.NullInt64{Int64: req.DelayMs, Valid: true},
+		ExtractVars:       sql.NullString{String: req.ExtractVars, Valid: true},
+		Condition:         sql.NullString{String: req.Condition, Valid: req.Condition != ""},
+		Name:              req.Name,
+		Method:            req.Method,
+		Url:               req.URL,
+		Headers:           sql.NullString{String: req.Headers, Valid: true},
+		Body:              sql.NullString{String: req.Body, Valid: true},
+		BodyType:          sql.NullString{String: req.BodyType, Valid: true},
+		Cookies:           sql.NullString{String: req.Cookies, Valid: true},
+		ProxyID:           proxyID,
+		LoopCount:         sql.NullInt64{Int64: loopCount, Valid: true},
+		PreScript:         sql.NullString{String: req.PreScript, Valid: req.PreScript != ""},
+		PostScript:        sql.NullString{String: req.PostScript, Valid: req.PostScript != ""},
+		ContinueOnError:   sql.NullInt64{Int64: continueOnError, Valid: true},
+		ResponseTransform: sql.NullString{String: req.ResponseTransform, Valid: req.ResponseTransform != ""},
+		WaitUntil:         sql.NullString{String: req.WaitUntil, Valid: req.WaitUntil != ""},
+		Approval:          sql.NullString{String: req.Approval, Valid: req.Approval != ""},
+		TimeoutMs:         req.TimeoutMs,
+		RetryCount:        req.RetryCount,
+		RetryBackoffMs:    req.RetryBackoffMs,
+		RetryOnStatus:     req.RetryOnStatus,
+		DisableRedirects:  redirectsDisabled(req.FollowRedirects),
+		MaxRedirects:      req.MaxRedirects,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
@@ -616,6 +1007,18 @@ func (h *FlowHandler) UpdateStep(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if _, err := service.ParseWaitUntil(req.WaitUntil); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := service.ParseApprovalGate(req.Approval); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := req.policy().Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	var reqID sql.NullInt64
 	if req.RequestID != nil {
@@ -643,24 +1046,33 @@ func (h *FlowHandler) UpdateStep(w http.ResponseWriter, r *http.Request) {
 	}
 
 	step, err := h.queries.UpdateFlowStep(r.Context(), repository.UpdateFlowStepParams{
-		ID:              stepID,
-		RequestID:       reqID,
-		StepOrder:       req.StepOrder,
-		DelayMs:         sql.NullInt64{Int64: req.DelayMs, Valid: true},
-		ExtractVars:     sql.NullString{String: req.ExtractVars, Valid: true},
-		Condition:       sql.NullString{String: req.Condition, Valid: req.Condition != ""},
-		Name:            req.Name,
-		Method:          req.Method,
-		Url:             req.URL,
-		Headers:         sql.NullString{String: req.Headers, Valid: true},
-		Body:            sql.NullString{String: req.Body, Valid: true},
-		BodyType:        sql.NullString{String: req.BodyType, Valid: true},
-		Cookies:         sql.NullString{String: req.Cookies, Valid: true},
-		ProxyID:         proxyID,
-		LoopCount:       sql.NullInt64{Int64: loopCount, Valid: true},
-		PreScript:       sql.NullString{String: req.PreScript, Valid: req.PreScript != ""},
-		PostScript:      sql.NullString{String: req.PostScript, Valid: req.PostScript != ""},
-		ContinueOnError: sql.NullInt64{Int64: continueOnError, Valid: true},
+		ID:                stepID,
+		RequestID:         reqID,
+		StepOrder:         req.StepOrder,
+		DelayMs:           sql.NullInt64{Int64: req.DelayMs, Valid: true},
+		ExtractVars:       sql.NullString{String: req.ExtractVars, Valid: true},
+		Condition:         sql.NullString{String: req.Condition, Valid: req.Condition != ""},
+		Name:              req.Name,
+		Method:            req.Method,
+		Url:               req.URL,
+		Headers:           sql.NullString{String: req.Headers, Valid: true},
+		Body:              sql.NullString{String: req.Body, Valid: true},
+		BodyType:          sql.NullString{String: req.BodyType, Valid: true},
+		Cookies:           sql.NullString{String: req.Cookies, Valid: true},
+		ProxyID:           proxyID,
+		LoopCount:         sql.NullInt64{Int64: loopCount, Valid: true},
+		PreScript:         sql.NullString{String: req.PreScript, Valid: req.PreScript != ""},
+		PostScript:        sql.NullString{String: req.PostScript, Valid: req.PostScript != ""},
+		ContinueOnError:   sql.NullInt64{Int64: continueOnError, Valid: true},
+		ResponseTransform: sql.NullString{String: req.ResponseTransform, Valid: req.ResponseTransform != ""},
+		WaitUntil:         sql.NullString{String: req.WaitUntil, Valid: req.WaitUntil != ""},
+		Approval:          sql.NullString{String: req.Approval, Valid: req.Approval != ""},
+		TimeoutMs:         req.TimeoutMs,
+		RetryCount:        req.RetryCount,
+		RetryBackoffMs:    req.RetryBackoffMs,
+		RetryOnStatus:     req.RetryOnStatus,
+		DisableRedirects:  redirectsDisabled(req.FollowRedirects),
+		MaxRedirects:      req.MaxRedirects,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
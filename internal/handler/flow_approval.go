@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ApproveRunRequest struct {
+	// Approved defaults to true; false rejects the step and stops the run
+	Approved *bool  `json:"approved"`
+	Comment  string `json:"comment"`
+}
+
+// RunWaitingResponse answers POST /flows/{id}/run when the run stopped at an
+// approval gate; it goes on once the gate is decided or times out
+type RunWaitingResponse struct {
+	RunID    string                  `json:"runId"`
+	Status   string                  `json:"status"` // waiting_approval
+	Approval service.PendingApproval `json:"approval"`
+}
+
+type ApproveRunResponse struct {
+	RunID    string `json:"runId"`
+	StepID   int64  `json:"stepId"`
+	StepName string `json:"stepName"`
+	Decision string `json:"decision"`
+}
+
+// ListApprovals returns the workspace's runs waiting at an approval gate
+func (h *FlowHandler) ListApprovals(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.runner.PendingApprovals(middleware.GetWorkspaceID(r.Context())))
+}
+
+// Approve lets a run waiting at an approval gate go on, or rejects it with
+// {"approved": false}. Runs wait on the instance that started them.
+func (h *FlowHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	var req ApproveRunRequest
+	if err := decodeJSON(r, &req); err != nil {
+		// An empty body approves
+		req = ApproveRunRequest{}
+	}
+	approved := req.Approved == nil || *req.Approved
+
+	pending, err := h.runner.DecideApproval(middleware.GetWorkspaceID(r.Context()), chi.URLParam(r, "id"), approved, req.Comment)
+	if errors.Is(err, service.ErrNoPendingApproval) {
+		respondError(w, http.StatusNotFound, "Run is not waiting for approval")
+		return
+	}
+
+	decision := service.ApprovalApproved
+	if !approved {
+		decision = service.ApprovalRejected
+	}
+	respondJSON(w, http.StatusOK, ApproveRunResponse{
+		RunID:    pending.RunID,
+		StepID:   pending.StepID,
+		StepName: pending.StepName,
+		Decision: decision,
+	})
+}
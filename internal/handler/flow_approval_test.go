@@ -0,0 +1,107 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestFlow_Approve(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	fr := service.NewFlowRunner(q, service.NewRequestExecutor(q, vr, nil), vr)
+	h := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/flows", h.Create)
+	r.Post("/api/flows/{id}/run", h.Run)
+	r.Post("/api/flows/{id}/steps", h.CreateStep)
+	r.Get("/api/flows/runs/approvals", h.ListApprovals)
+	r.Post("/api/flows/runs/{id}/approve", h.Approve)
+	r.Get("/api/flow-runs/{id}", h.GetRun)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Release"}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+	stepsURL := fmt.Sprintf("%s/api/flows/%d/steps", ts.URL, flow.ID)
+
+	resp, _ = postJSON(stepsURL, `{"name":"sign-off","approval":"{\"onTimeout\":\"later\"}"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid approval: status = %d, want 400", resp.StatusCode)
+	}
+	resp, _ = postJSON(stepsURL, `{"name":"sign-off","approval":"{\"message\":\"ship it?\"}"}`)
+	var step handler.FlowStepResponse
+	readJSON(t, resp, &step)
+	if step.Approval != `{"message":"ship it?"}` {
+		t.Fatalf("step approval = %q", step.Approval)
+	}
+
+	// The run answers as soon as it waits at the gate
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/run", ts.URL, flow.ID), `{}`)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("run: status = %d, want 202", resp.StatusCode)
+	}
+	var waiting handler.RunWaitingResponse
+	readJSON(t, resp, &waiting)
+	if waiting.Status != handler.RunStatusWaitingApproval || waiting.RunID == "" || waiting.Approval.StepName != "sign-off" {
+		t.Fatalf("run = %+v", waiting)
+	}
+
+	var pending []service.PendingApproval
+	resp, _ = http.Get(ts.URL + "/api/flows/runs/approvals")
+	readJSON(t, resp, &pending)
+	if len(pending) != 1 || pending[0].RunID != waiting.RunID || pending[0].Message != "ship it?" {
+		t.Fatalf("pending = %+v", pending)
+	}
+	var run handler.FlowRunDetailResponse
+	resp, _ = http.Get(ts.URL + "/api/flow-runs/" + waiting.RunID)
+	readJSON(t, resp, &run)
+	if run.Status != handler.RunStatusWaitingApproval || run.FlowID != flow.ID {
+		t.Errorf("waiting run = %+v", run)
+	}
+
+	resp, _ = postJSONWithWorkspace(ts.URL+"/api/flows/runs/"+pending[0].RunID+"/approve", `{}`, 2)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", resp.StatusCode)
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/flows/runs/"+pending[0].RunID+"/approve", `{"comment":"lgtm"}`)
+	var approved handler.ApproveRunResponse
+	readJSON(t, resp, &approved)
+	if approved.Decision != service.ApprovalApproved || approved.RunID != pending[0].RunID {
+		t.Errorf("approve = %+v", approved)
+	}
+
+	// The run finishes in the background
+	for i := 0; i < 100 && run.Status != handler.RunStatusSucceeded; i++ {
+		time.Sleep(10 * time.Millisecond)
+		resp, _ := http.Get(ts.URL + "/api/flow-runs/" + waiting.RunID)
+		readJSON(t, resp, &run)
+	}
+	var result service.FlowResult
+	json.Unmarshal(run.Result, &result)
+	if run.Status != handler.RunStatusSucceeded || result.Steps[0].Approval == nil || result.Steps[0].Approval.Comment != "lgtm" {
+		t.Fatalf("run = %+v", run)
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/flows/runs/"+result.RunID+"/approve", `{}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("finished run: status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,62 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestFlow_RunWithChaos(t *testing.T) {
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	fr := service.NewFlowRunner(q, service.NewRequestExecutor(q, vr, nil), vr)
+	h := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/flows", h.Create)
+	r.Post("/api/flows/{id}/run", h.Run)
+	r.Post("/api/flows/{id}/steps", h.CreateStep)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Checkout"}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/steps", ts.URL, flow.ID),
+		fmt.Sprintf(`{"name":"cart","method":"GET","url":%q,"stepOrder":1,"retryCount":1,"retryOnStatus":"503"}`, api.URL))
+	resp.Body.Close()
+	runURL := fmt.Sprintf("%s/api/flows/%d/run", ts.URL, flow.ID)
+
+	var result service.FlowResult
+	resp, _ = postJSON(runURL, `{"chaos":{"errorPercent":100,"errorStatus":503}}`)
+	readJSON(t, resp, &result)
+	if len(result.Steps) != 1 {
+		t.Fatalf("steps = %+v", result.Steps)
+	}
+	if er := result.Steps[0].ExecuteResult; er == nil || er.StatusCode != 503 || er.Fault != service.FaultError || er.Attempts != 2 || calls.Load() != 0 {
+		t.Errorf("step result = %+v, %d calls", er, calls.Load())
+	}
+
+	resp, _ = postJSON(runURL, `{"chaos":{"dropPercent":150}}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid chaos: status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,103 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/go-chi/chi/v5"
+)
+
+type debugEnvelope struct {
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+	Error string          `json:"error"`
+}
+
+func TestFlowDebug_PauseAndContinue(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	fr := service.NewFlowRunner(q, re, vr)
+	flowH := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/flows", flowH.Create)
+	r.Post("/api/flows/{id}/steps", flowH.CreateStep)
+	r.Get("/api/flows/{id}/debug", flowH.Debug)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Debug Flow"}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+
+	var stepIDs []int64
+	for i := 1; i <= 2; i++ {
+		resp, _ := postJSON(ts.URL+fmt.Sprintf("/api/flows/%d/steps", flow.ID),
+			fmt.Sprintf(`{"name":"s%d","method":"GET","url":"%s","stepOrder":%d}`, i, target.URL, i))
+		var step handler.FlowStepResponse
+		readJSON(t, resp, &step)
+		stepIDs = append(stepIDs, step.ID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http") + fmt.Sprintf("/api/flows/%d/debug", flow.ID)
+	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	wsjson.Write(ctx, conn, map[string]any{"type": "start", "breakpoints": []int64{stepIDs[1]}})
+
+	var events []string
+	for {
+		var msg debugEnvelope
+		if err := wsjson.Read(ctx, conn, &msg); err != nil {
+			t.Fatalf("read: %v (events so far: %v)", err, events)
+		}
+		events = append(events, msg.Type)
+		if msg.Type == "paused" {
+			var pause service.PauseEvent
+			json.Unmarshal(msg.Data, &pause)
+			if pause.StepID != stepIDs[1] {
+				t.Errorf("paused at step %d, want %d", pause.StepID, stepIDs[1])
+			}
+			wsjson.Write(ctx, conn, map[string]string{"type": "continue"})
+		}
+		if msg.Type == "flow:complete" {
+			var done service.FlowCompleteEvent
+			json.Unmarshal(msg.Data, &done)
+			if !done.Success {
+				t.Errorf("expected success, got error %q", done.Error)
+			}
+			break
+		}
+	}
+
+	want := []string{"step:start", "step:complete", "paused", "step:start", "step:complete", "flow:complete"}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("events: got %v, want %v", events, want)
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// GetGraph returns the flow's nodes and edges (empty for linear flows)
+func (h *FlowHandler) GetGraph(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	if _, err := h.queries.GetFlow(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "Flow not found")
+		return
+	}
+
+	graph, err := service.LoadFlowGraph(r.Context(), h.queries, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, graph)
+}
+
+// UpdateGraph replaces the flow's graph. Once a flow has nodes it runs as a
+// graph; saving an empty graph turns it back into a linear flow.
+func (h *FlowHandler) UpdateGraph(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var graph service.FlowGraph
+	if err := decodeJSON(r, &graph); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+	flow, err := h.queries.GetFlow(ctx, id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Flow not found")
+		return
+	}
+	if err := service.ValidateFlowGraph(&graph); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.checkGraphReferences(ctx, flow, &graph); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if err := saveFlowGraph(ctx, h.queries.WithTx(tx), id, &graph); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	saved, err := service.LoadFlowGraph(ctx, h.queries, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, saved)
+}
+
+// checkGraphReferences makes sure saved requests and sub-flows used by the
+// graph belong to the flow's workspace
+func (h *FlowHandler) checkGraphReferences(ctx context.Context, flow repository.Flow, graph *service.FlowGraph) error {
+	for _, n := range graph.Nodes {
+		switch n.Type {
+		case service.FlowNodeRequest:
+			var c service.RequestNodeConfig
+			if err := json.Unmarshal(n.Config, &c); err != nil || c.RequestID == 0 {
+				continue
+			}
+			req, err := h.queries.GetRequest(ctx, c.RequestID)
+			if err != nil || req.WorkspaceID != flow.WorkspaceID {
+				return fmt.Errorf("node %q: request %d not found in workspace", n.Key, c.RequestID)
+			}
+		case service.FlowNodeSubflow:
+			var c service.SubflowNodeConfig
+			if err := json.Unmarshal(n.Config, &c); err != nil {
+				continue
+			}
+			if c.FlowID == flow.ID {
+				return fmt.Errorf("node %q: a flow cannot run itself", n.Key)
+			}
+			sub, err := h.queries.GetFlow(ctx, c.FlowID)
+			if err != nil || sub.WorkspaceID != flow.WorkspaceID {
+				return fmt.Errorf("node %q: flow %d not found in workspace", n.Key, c.FlowID)
+			}
+		}
+	}
+	return nil
+}
+
+// saveFlowGraph replaces the flow's nodes and edges with graph
+func saveFlowGraph(ctx context.Context, q *repository.Queries, flowID int64, graph *service.FlowGraph) error {
+	if err := q.DeleteFlowEdgesByFlow(ctx, flowID); err != nil {
+		return err
+	}
+	if err := q.DeleteFlowNodesByFlow(ctx, flowID); err != nil {
+		return err
+	}
+	for _, n := range graph.Nodes {
+		config := string(n.Config)
+		if config == "" || config == "null" {
+			config = "{}"
+		}
+		if _, err := q.CreateFlowNode(ctx, repository.CreateFlowNodeParams{
+			FlowID:    flowID,
+			NodeKey:   n.Key,
+			Type:      string(n.Type),
+			Name:      n.Name,
+			Config:    config,
+			PositionX: n.Position.X,
+			PositionY: n.Position.Y,
+		}); err != nil {
+			return err
+		}
+	}
+	for _, e := range graph.Edges {
+		if _, err := q.CreateFlowEdge(ctx, repository.CreateFlowEdgeParams{
+			FlowID:    flowID,
+			SourceKey: e.Source,
+			TargetKey: e.Target,
+			Label:     e.Label,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
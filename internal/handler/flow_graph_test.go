@@ -0,0 +1,103 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestFlow_Graph(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	fr := service.NewFlowRunner(q, service.NewRequestExecutor(q, vr, nil), vr)
+	h := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/flows/{id}/graph", h.GetGraph)
+	r.Put("/api/flows/{id}/graph", h.UpdateGraph)
+	r.Post("/api/flows/{id}/duplicate", h.Duplicate)
+	r.Delete("/api/flows/{id}", h.Delete)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	flow, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "graph", WorkspaceID: 1})
+	login, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "login", WorkspaceID: 1})
+	ws, _ := q.CreateWorkspace(ctx, "Other")
+	foreign, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "foreign", WorkspaceID: ws.ID})
+	url := fmt.Sprintf("%s/api/flows/%d/graph", ts.URL, flow.ID)
+
+	body := fmt.Sprintf(`{
+		"nodes": [
+			{"key":"auth","type":"subflow","name":"Log in","config":{"flowId":%d},"position":{"x":10,"y":20}},
+			{"key":"ok","type":"condition","config":{"expression":"{{authToken}}"}},
+			{"key":"wait","type":"delay","config":{"delayMs":100}}
+		],
+		"edges": [
+			{"source":"auth","target":"ok"},
+			{"source":"ok","target":"wait","label":"true"}
+		]
+	}`, login.ID)
+	resp, _ := putJSON(url, body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("save graph: status %d", resp.StatusCode)
+	}
+	var graph service.FlowGraph
+	readJSON(t, resp, &graph)
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 || graph.Nodes[0].Position.Y != 20 || graph.Edges[1].Label != "true" {
+		t.Errorf("saved graph = %+v", graph)
+	}
+
+	var fetched service.FlowGraph
+	resp, _ = http.Get(url)
+	readJSON(t, resp, &fetched)
+	if len(fetched.Nodes) != 3 || fetched.Nodes[0].Name != "Log in" {
+		t.Errorf("fetched graph = %+v", fetched)
+	}
+
+	for name, bad := range map[string]string{
+		"cycle":             `{"nodes":[{"key":"a","type":"parallel"},{"key":"b","type":"parallel"}],"edges":[{"source":"a","target":"b"},{"source":"b","target":"a"}]}`,
+		"foreign sub-flow":  fmt.Sprintf(`{"nodes":[{"key":"a","type":"subflow","config":{"flowId":%d}}]}`, foreign.ID),
+		"runs itself":       fmt.Sprintf(`{"nodes":[{"key":"a","type":"subflow","config":{"flowId":%d}}]}`, flow.ID),
+		"unknown node type": `{"nodes":[{"key":"a","type":"loop"}]}`,
+	} {
+		resp, _ := putJSON(url, bad)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, resp.StatusCode)
+		}
+	}
+
+	// Duplicates copy the graph; deleting a flow removes it
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/duplicate", ts.URL, flow.ID), `{}`)
+	var dup handler.FlowResponse
+	readJSON(t, resp, &dup)
+	if nodes, _ := q.ListFlowNodes(ctx, dup.ID); len(nodes) != 3 {
+		t.Errorf("duplicate has %d nodes", len(nodes))
+	}
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/flows/%d", ts.URL, dup.ID), nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if edges, _ := q.ListFlowEdges(ctx, dup.ID); len(edges) != 0 {
+		t.Errorf("deleted flow kept %d edges", len(edges))
+	}
+
+	// An empty graph turns the flow back into a linear one
+	var cleared service.FlowGraph
+	resp, _ = putJSON(url, `{"nodes":[],"edges":[]}`)
+	readJSON(t, resp, &cleared)
+	if len(cleared.Nodes) != 0 {
+		t.Errorf("cleared graph = %+v", cleared)
+	}
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type FlowImportResponse struct {
+	Flow     FlowExport `json:"flow"`
+	Warnings []string   `json:"warnings,omitempty"`
+}
+
+// requestKey identifies a saved request across instances, where IDs differ
+func requestKey(name, method, url string) string {
+	return name + "\x00" + method + "\x00" + url
+}
+
+// Import creates a new flow in the current workspace from a standalone flow file.
+// Steps are self-contained; links to saved requests are restored only when a
+// request with the same name, method and URL exists in the workspace.
+// Encrypted flow files are decrypted with the X-Export-Passphrase header.
+func (h *FlowHandler) Import(w http.ResponseWriter, r *http.Request) {
+	data, status, err := readImportBody(r)
+	if err != nil {
+		respondError(w, status, err.Error())
+		return
+	}
+	var file FlowFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if file.Format != flowFileFormat {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("unsupported format %q (expected %q)", file.Format, flowFileFormat))
+		return
+	}
+	if file.Version < 1 || file.Version > flowFileVersion {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("unsupported flow file version %d", file.Version))
+		return
+	}
+	if strings.TrimSpace(file.Flow.Name) == "" {
+		respondError(w, http.StatusBadRequest, "flow name is required")
+		return
+	}
+
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+	var warnings []string
+	if file.Masked {
+		warnings = append(warnings, "file was exported with masking; masked values must be re-entered")
+	}
+
+	// Saved requests in this workspace, for relinking steps
+	existing, err := h.queries.ListRequests(ctx, wsID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	localRequests := make(map[string]int64, len(existing))
+	for _, req := range existing {
+		key := requestKey(req.Name, req.Method, req.Url)
+		if _, ok := localRequests[key]; !ok {
+			localRequests[key] = req.ID
+		}
+	}
+	snapshots := make(map[int64]RequestResponse, len(file.Requests))
+	for _, req := range file.Requests {
+		snapshots[req.ID] = req
+	}
+
+	steps := append([]FlowStepResponse(nil), file.Flow.Steps...)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].StepOrder < steps[j].StepOrder })
+
+	var maxSortOrder int64
+	if val, err := h.queries.GetMaxFlowSortOrder(ctx, wsID); err == nil {
+		maxSortOrder, _ = val.(int64)
+	}
+
+	outputs, err := flowOutputsColumn(file.Flow.Outputs)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	txQueries := h.queries.WithTx(tx)
+
+	flow, err := txQueries.CreateFlow(ctx, repository.CreateFlowParams{
+		Name:        file.Flow.Name,
+		Description: sql.NullString{String: file.Flow.Description, Valid: file.Flow.Description != ""},
+		WorkspaceID: wsID,
+		SortOrder:   maxSortOrder + 1,
+		Outputs:     outputs,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for i, s := range steps {
+		label := s.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+
+		var requestID sql.NullInt64
+		if s.RequestID != nil {
+			if snap, ok := snapshots[*s.RequestID]; ok {
+				if localID, ok := localRequests[requestKey(snap.Name, snap.Method, snap.URL)]; ok {
+					requestID = sql.NullInt64{Int64: localID, Valid: true}
+				}
+			}
+			if !requestID.Valid {
+				warnings = append(warnings, fmt.Sprintf("step %s: source request not found in workspace, imported unlinked", label))
+			}
+		}
+
+		var proxyID sql.NullInt64
+		if s.ProxyID != nil {
+			if p, err := h.queries.GetProxy(ctx, *s.ProxyID); err == nil && p.WorkspaceID == wsID {
+				proxyID = sql.NullInt64{Int64: p.ID, Valid: true}
+			} else {
+				warnings = append(warnings, fmt.Sprintf("step %s: proxy %d not found in workspace, using the default", label, *s.ProxyID))
+			}
+		}
+
+		policy := service.RequestPolicy{TimeoutMs: s.TimeoutMs, RetryCount: s.RetryCount, RetryBackoffMs: s.RetryBackoffMs, RetryOnStatus: s.RetryOnStatus, MaxRedirects: s.MaxRedirects}
+		if err := policy.Validate(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("step %s: %v, timeout, retry and redirect policy dropped", label, err))
+			policy = service.RequestPolicy{}
+		}
+
+		// Same defaults as CreateStep
+		if s.ExtractVars == "" {
+			s.ExtractVars = "{}"
+		}
+		if s.Method == "" {
+			s.Method = "GET"
+		}
+		if s.Headers == "" {
+			s.Headers = "{}"
+		}
+		if s.BodyType == "" {
+			s.BodyType = "none"
+		}
+		if s.Cookies == "" {
+			s.Cookies = "{}"
+		}
+
+		loopCount := s.LoopCount
+		if loopCount < 1 {
+			loopCount = 1
+		}
+		continueOnError := int64(0)
+		if s.ContinueOnError {
+			continueOnError = 1
+		}
+
+		_, err := txQueries.CreateFlowStep(ctx, repository.CreateFlowStepParams{
+			FlowID:            flow.ID,
+			RequestID:         requestID,
+			StepOrder:         int64(i + 1),
+			DelayMs:           sql.NullInt64{Int64: s.DelayMs, Valid: true},
+			ExtractVars:       sql.NullString{String: s.ExtractVars, Valid: true},
+			Condition:         sql.NullString{String: s.Condition, Valid: s.Condition != ""},
+			Name:              s.Name,
+			Method:            s.Method,
+			Url:               s.URL,
+			Headers:           sql.NullString{String: s.Headers, Valid: true},
+			Body:              sql.NullString{String: s.Body, Valid: true},
+			BodyType:          sql.NullString{String: s.BodyType, Valid: true},
+			Cookies:           sql.NullString{String: s.Cookies, Valid: true},
+			ProxyID:           proxyID,
+			LoopCount:         sql.NullInt64{Int64: loopCount, Valid: true},
+			PreScript:         sql.NullString{String: s.PreScript, Valid: s.PreScript != ""},
+			PostScript:        sql.NullString{String: s.PostScript, Valid: s.PostScript != ""},
+			ContinueOnError:   sql.NullInt64{Int64: continueOnError, Valid: true},
+			ResponseTransform: sql.NullString{String: s.ResponseTransform, Valid: s.ResponseTransform != ""},
+			WaitUntil:         sql.NullString{String: s.WaitUntil, Valid: s.WaitUntil != ""},
+			Approval:          sql.NullString{String: s.Approval, Valid: s.Approval != ""},
+			TimeoutMs:         policy.TimeoutMs,
+			RetryCount:        policy.RetryCount,
+			RetryBackoffMs:    policy.RetryBackoffMs,
+			RetryOnStatus:     policy.RetryOnStatus,
+			DisableRedirects:  redirectsDisabled(s.FollowRedirects),
+			MaxRedirects:      policy.MaxRedirects,
+		})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	fe, err := buildFlowExport(ctx, h.queries, flow)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, FlowImportResponse{Flow: fe, Warnings: warnings})
+}
@@ -0,0 +1,232 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupFlowImportTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	fr := service.NewFlowRunner(q, re, vr)
+
+	flowH := handler.NewFlowHandler(q, fr, db)
+	reqH := handler.NewRequestHandler(q, nil, nil)
+	wsH := handler.NewWorkspaceHandler(q)
+	exportH := handler.NewExportHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+
+	r.Post("/api/workspaces", wsH.Create)
+	r.Post("/api/requests", reqH.Create)
+	r.Post("/api/flows", flowH.Create)
+	r.Post("/api/flows/{id}/steps", flowH.CreateStep)
+	r.Get("/api/flows/{id}/export", exportH.Flow)
+	r.Post("/api/import/flow", flowH.Import)
+	r.Post("/api/import/decrypt", exportH.Decrypt)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// exportTestFlow creates a request and a two-step flow (one step linked to the
+// request) and returns the raw flow file.
+func exportTestFlow(t *testing.T, ts *httptest.Server) (string, int64) {
+	t.Helper()
+
+	resp, err := postJSON(ts.URL+"/api/requests", `{"name":"Login","method":"POST","url":"https://api.test/login"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req handler.RequestResponse
+	readJSON(t, resp, &req)
+
+	resp, _ = postJSON(ts.URL+"/api/flows", `{"name":"Checkout","description":"happy path"}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+
+	steps := []string{
+		fmt.Sprintf(`{"requestId":%d,"stepOrder":1,"name":"Login","method":"POST","url":"https://api.test/login","postScript":"pm.environment.set(\"token\", pm.response.json().token);"}`, req.ID),
+		`{"stepOrder":2,"name":"Pay","method":"POST","url":"https://api.test/pay","condition":"{{token}} != \"\"","loopCount":2,"continueOnError":true}`,
+	}
+	for _, s := range steps {
+		resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/steps", ts.URL, flow.ID), s)
+		resp.Body.Close()
+	}
+
+	resp, err = http.Get(fmt.Sprintf("%s/api/flows/%d/export", ts.URL, flow.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("export: status %d: %s", resp.StatusCode, data)
+	}
+	return string(data), req.ID
+}
+
+func TestFlowFile_ExportFormat(t *testing.T) {
+	ts := setupFlowImportTestServer(t)
+	raw, reqID := exportTestFlow(t, ts)
+
+	var file handler.FlowFile
+	if err := json.Unmarshal([]byte(raw), &file); err != nil {
+		t.Fatal(err)
+	}
+
+	if file.Format != "relay-flow" || file.Version != 1 {
+		t.Errorf("format/version = %q/%d", file.Format, file.Version)
+	}
+	if file.Flow.Name != "Checkout" || len(file.Flow.Steps) != 2 {
+		t.Fatalf("flow = %+v", file.Flow)
+	}
+	if !strings.Contains(file.Flow.Steps[0].PostScript, "pm.environment.set") {
+		t.Errorf("post script not exported: %q", file.Flow.Steps[0].PostScript)
+	}
+	if len(file.Requests) != 1 || file.Requests[0].ID != reqID || file.Requests[0].Name != "Login" {
+		t.Errorf("request snapshots = %+v", file.Requests)
+	}
+}
+
+func TestFlowFile_ImportRoundTrip(t *testing.T) {
+	ts := setupFlowImportTestServer(t)
+	raw, reqID := exportTestFlow(t, ts)
+
+	// Same workspace: the step is relinked to the existing request
+	resp, err := postJSON(ts.URL+"/api/import/flow", raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("import: status %d", resp.StatusCode)
+	}
+	var imported handler.FlowImportResponse
+	readJSON(t, resp, &imported)
+
+	if len(imported.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", imported.Warnings)
+	}
+	steps := imported.Flow.Steps
+	if imported.Flow.Name != "Checkout" || imported.Flow.Description != "happy path" || len(steps) != 2 {
+		t.Fatalf("imported flow = %+v", imported.Flow)
+	}
+	if steps[0].RequestID == nil || *steps[0].RequestID != reqID {
+		t.Errorf("step 1 should link to request %d, got %v", reqID, steps[0].RequestID)
+	}
+	if steps[1].LoopCount != 2 || !steps[1].ContinueOnError || steps[1].Condition != `{{token}} != ""` {
+		t.Errorf("step 2 not preserved: %+v", steps[1])
+	}
+
+	// Another workspace: the request does not exist there, step is kept unlinked
+	resp, _ = postJSON(ts.URL+"/api/workspaces", `{"name":"Other"}`)
+	var ws handler.WorkspaceResponse
+	readJSON(t, resp, &ws)
+
+	resp, err = postJSONWithWorkspace(ts.URL+"/api/import/flow", raw, ws.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readJSON(t, resp, &imported)
+	if imported.Flow.Steps[0].RequestID != nil {
+		t.Errorf("step should be unlinked in another workspace, got %v", *imported.Flow.Steps[0].RequestID)
+	}
+	if len(imported.Warnings) != 1 || !strings.Contains(imported.Warnings[0], "step Login") {
+		t.Errorf("warnings = %v", imported.Warnings)
+	}
+}
+
+func TestFlowFile_EncryptedRoundTrip(t *testing.T) {
+	ts := setupFlowImportTestServer(t)
+	exportTestFlow(t, ts)
+
+	send := func(method, url, body, passphrase string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, ts.URL+url, strings.NewReader(body))
+		if passphrase != "" {
+			req.Header.Set("X-Export-Passphrase", passphrase)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := send(http.MethodGet, "/api/flows/1/export", "", "open sesame")
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	var bundle service.EncryptedBundle
+	if err := json.Unmarshal(data, &bundle); err != nil || bundle.Format != "relay-encrypted" || strings.Contains(string(data), "api.test") {
+		t.Fatalf("bundle = %s", data)
+	}
+	raw := string(data)
+
+	for passphrase, want := range map[string]int{"": http.StatusBadRequest, "wrong guess": http.StatusUnauthorized} {
+		resp := send(http.MethodPost, "/api/import/flow", raw, passphrase)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("passphrase %q: status %d, want %d", passphrase, resp.StatusCode, want)
+		}
+	}
+
+	resp = send(http.MethodPost, "/api/import/flow", raw, "open sesame")
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("import: status %d", resp.StatusCode)
+	}
+	var imported handler.FlowImportResponse
+	readJSON(t, resp, &imported)
+	if imported.Flow.Name != "Checkout" || len(imported.Flow.Steps) != 2 {
+		t.Errorf("imported flow = %+v", imported.Flow)
+	}
+
+	var file handler.FlowFile
+	readJSON(t, send(http.MethodPost, "/api/import/decrypt", raw, "open sesame"), &file)
+	if file.Format != "relay-flow" || file.Flow.Name != "Checkout" {
+		t.Errorf("decrypted = %+v", file)
+	}
+
+	resp = send(http.MethodGet, "/api/flows/1/export", "", "short")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("short passphrase: status %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestFlowFile_ImportRejectsUnknownFormat(t *testing.T) {
+	ts := setupFlowImportTestServer(t)
+
+	cases := map[string]string{
+		"format":  `{"format":"postman","version":1,"flow":{"name":"x"}}`,
+		"version": `{"format":"relay-flow","version":99,"flow":{"name":"x"}}`,
+		"name":    `{"format":"relay-flow","version":1,"flow":{"name":""}}`,
+	}
+	for name, body := range cases {
+		resp, err := postJSON(ts.URL+"/api/import/flow", body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, resp.StatusCode)
+		}
+	}
+}
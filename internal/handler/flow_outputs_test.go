@@ -0,0 +1,83 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestFlow_Outputs(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token":"t-123","user":{"id":42}}`))
+	}))
+	defer api.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	fr := service.NewFlowRunner(q, service.NewRequestExecutor(q, vr, nil), vr)
+	h := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/flows", h.Create)
+	r.Put("/api/flows/{id}", h.Update)
+	r.Post("/api/flows/{id}/run", h.Run)
+	r.Post("/api/flows/{id}/steps", h.CreateStep)
+	r.Post("/api/flows/{id}/duplicate", h.Duplicate)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Login","outputs":{"token":"authToken","userId":"{{uid}}"}}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+	if flow.Outputs["token"] != "authToken" || flow.Outputs["userId"] != "{{uid}}" {
+		t.Fatalf("created outputs = %v", flow.Outputs)
+	}
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/steps", ts.URL, flow.ID),
+		fmt.Sprintf(`{"name":"login","method":"POST","url":%q,"extractVars":"{\"authToken\":\"$.token\",\"uid\":\"$.user.id\"}"}`, api.URL))
+	resp.Body.Close()
+
+	// Renaming without outputs keeps them
+	var renamed handler.FlowResponse
+	resp, _ = putJSON(fmt.Sprintf("%s/api/flows/%d", ts.URL, flow.ID), `{"name":"Sign in"}`)
+	readJSON(t, resp, &renamed)
+	if renamed.Name != "Sign in" || len(renamed.Outputs) != 2 {
+		t.Errorf("renamed = %+v", renamed)
+	}
+
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/run", ts.URL, flow.ID), `{}`)
+	var result service.FlowResult
+	readJSON(t, resp, &result)
+	if !result.Success || result.Outputs["token"] != "t-123" || result.Outputs["userId"] != "42" {
+		t.Errorf("run outputs = %v (success %v, error %q)", result.Outputs, result.Success, result.Error)
+	}
+
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/duplicate", ts.URL, flow.ID), `{}`)
+	var dup handler.FlowResponse
+	readJSON(t, resp, &dup)
+	if len(dup.Outputs) != 2 {
+		t.Errorf("duplicate outputs = %v", dup.Outputs)
+	}
+
+	resp, _ = putJSON(fmt.Sprintf("%s/api/flows/%d", ts.URL, flow.ID), `{"name":"Sign in","outputs":{"token":""}}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("output without variable: status = %d, want 400", resp.StatusCode)
+	}
+
+	var cleared handler.FlowResponse
+	resp, _ = putJSON(fmt.Sprintf("%s/api/flows/%d", ts.URL, flow.ID), `{"name":"Sign in","outputs":{}}`)
+	readJSON(t, resp, &cleared)
+	if len(cleared.Outputs) != 0 {
+		t.Errorf("cleared outputs = %v", cleared.Outputs)
+	}
+}
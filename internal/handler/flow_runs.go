@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Flow run statuses; runs still going are only reported for runs of
+// POST /flows/{id}/run that went on in the background at an approval gate
+const (
+	RunStatusWaitingApproval = "waiting_approval"
+	RunStatusRunning         = "running"
+	RunStatusSucceeded       = "succeeded"
+	RunStatusFailed          = "failed"
+)
+
+// FlowRunResponse summarizes one stored flow run
+type FlowRunResponse struct {
+	ID          int64  `json:"id"`
+	RunID       string `json:"runId"`
+	Status      string `json:"status"`
+	FlowID      int64  `json:"flowId"`
+	FlowName    string `json:"flowName"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+	TotalTimeMs int64  `json:"totalTimeMs"`
+	StepCount   int64  `json:"stepCount"` // step executions, or graph nodes
+	StartedAt   string `json:"startedAt"`
+	CreatedAt   string `json:"createdAt"` // when the run finished
+}
+
+// FlowRunDetailResponse is a stored run with its full result
+type FlowRunDetailResponse struct {
+	FlowRunResponse
+	Result json.RawMessage `json:"result"` // the run's FlowResult
+}
+
+func toFlowRunResponse(r repository.ListFlowRunsByFlowRow) FlowRunResponse {
+	status := RunStatusFailed
+	if r.Success != 0 {
+		status = RunStatusSucceeded
+	}
+	return FlowRunResponse{
+		ID:          r.ID,
+		RunID:       r.RunID,
+		Status:      status,
+		FlowID:      r.FlowID,
+		FlowName:    r.FlowName,
+		Success:     r.Success != 0,
+		Error:       r.Error,
+		TotalTimeMs: r.TotalTimeMs,
+		StepCount:   r.StepCount,
+		StartedAt:   formatTime(r.StartedAt),
+		CreatedAt:   formatTime(r.CreatedAt),
+	}
+}
+
+// ListRuns returns the flow's stored runs, newest first (?limit=, default 50).
+// Results are kept for 30 days.
+func (h *FlowHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	wsID := middleware.GetWorkspaceID(r.Context())
+	flow, err := h.queries.GetFlow(r.Context(), id)
+	if err != nil || flow.WorkspaceID != wsID {
+		respondError(w, http.StatusNotFound, "Flow not found")
+		return
+	}
+	limit := int64(50)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 && parsed <= 500 {
+			limit = parsed
+		}
+	}
+	rows, err := h.queries.ListFlowRunsByFlow(r.Context(), repository.ListFlowRunsByFlowParams{
+		FlowID:      id,
+		WorkspaceID: wsID,
+		Limit:       limit,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]FlowRunResponse, len(rows))
+	for i, row := range rows {
+		resp[i] = toFlowRunResponse(row)
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// GetRun returns a stored run with its steps, assertions, extracted
+// variables and timings; {id} is the run ID. A run still going on in the
+// background after an approval gate comes back without a result.
+func (h *FlowHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	wsID := middleware.GetWorkspaceID(r.Context())
+	runID := chi.URLParam(r, "id")
+	run, err := h.queries.GetFlowRun(r.Context(), runID)
+	if err != nil {
+		if bg, ok := h.backgroundRun(wsID, runID); ok {
+			respondJSON(w, http.StatusOK, FlowRunDetailResponse{FlowRunResponse: bg})
+			return
+		}
+	}
+	if err != nil || run.WorkspaceID != wsID {
+		respondError(w, http.StatusNotFound, "Run not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, FlowRunDetailResponse{
+		FlowRunResponse: toFlowRunResponse(repository.ListFlowRunsByFlowRow{
+			ID:          run.ID,
+			RunID:       run.RunID,
+			FlowID:      run.FlowID,
+			FlowName:    run.FlowName,
+			Success:     run.Success,
+			Error:       run.Error,
+			TotalTimeMs: run.TotalTimeMs,
+			StepCount:   run.StepCount,
+			StartedAt:   run.StartedAt,
+			CreatedAt:   run.CreatedAt,
+		}),
+		Result: json.RawMessage(run.Result),
+	})
+}
+
+// backgroundRun reports a run of the workspace still going on in the
+// background after an approval gate
+func (h *FlowHandler) backgroundRun(wsID int64, runID string) (FlowRunResponse, bool) {
+	v, ok := h.backgroundRuns.Load(runID)
+	if !ok || v.(service.PendingApproval).WorkspaceID != wsID {
+		return FlowRunResponse{}, false
+	}
+	gate := v.(service.PendingApproval)
+	status := RunStatusRunning
+	if _, waiting := h.runner.PendingApproval(wsID, runID); waiting {
+		status = RunStatusWaitingApproval
+	}
+	return FlowRunResponse{RunID: runID, Status: status, FlowID: gate.FlowID, FlowName: gate.FlowName}, true
+}
@@ -0,0 +1,114 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestFlow_Runs(t *testing.T) {
+	fail := false
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte(`{"token":"abc"}`))
+	}))
+	defer api.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	fr := service.NewFlowRunner(q, service.NewRequestExecutor(q, vr, nil), vr)
+	h := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/flows", h.Create)
+	r.Delete("/api/flows/{id}", h.Delete)
+	r.Post("/api/flows/{id}/run", h.Run)
+	r.Post("/api/flows/{id}/steps", h.CreateStep)
+	r.Get("/api/flows/{id}/runs", h.ListRuns)
+	r.Get("/api/flow-runs/{id}", h.GetRun)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Login"}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/steps", ts.URL, flow.ID),
+		fmt.Sprintf(`{"name":"token","method":"GET","url":%q,"extractVars":"{\"token\":\"$.token\"}"}`, api.URL))
+	resp.Body.Close()
+
+	var first, second service.FlowResult
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/run", ts.URL, flow.ID), `{}`)
+	readJSON(t, resp, &first)
+	fail = true
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/run", ts.URL, flow.ID), `{}`)
+	readJSON(t, resp, &second)
+
+	var runs []handler.FlowRunResponse
+	resp, _ = http.Get(fmt.Sprintf("%s/api/flows/%d/runs", ts.URL, flow.ID))
+	readJSON(t, resp, &runs)
+	if len(runs) != 2 || runs[0].RunID != second.RunID || runs[1].RunID != first.RunID {
+		t.Fatalf("runs = %+v", runs)
+	}
+	if !runs[1].Success || runs[0].Success || runs[1].FlowName != "Login" || runs[1].StepCount != 1 || runs[1].StartedAt == "" {
+		t.Errorf("runs = %+v", runs)
+	}
+	resp, _ = http.Get(fmt.Sprintf("%s/api/flows/%d/runs?limit=1", ts.URL, flow.ID))
+	readJSON(t, resp, &runs)
+	if len(runs) != 1 || runs[0].RunID != second.RunID {
+		t.Errorf("limit=1: runs = %+v", runs)
+	}
+
+	// The stored result is the one the run returned
+	var detail struct {
+		handler.FlowRunResponse
+		Result service.FlowResult `json:"result"`
+	}
+	resp, _ = http.Get(ts.URL + "/api/flow-runs/" + first.RunID)
+	readJSON(t, resp, &detail)
+	if detail.RunID != first.RunID || !detail.Success || len(detail.Result.Steps) != 1 {
+		t.Fatalf("detail = %+v", detail)
+	}
+	if step := detail.Result.Steps[0]; step.ExtractedVars["token"] != "abc" || step.ExecuteResult == nil || step.ExecuteResult.StatusCode != http.StatusOK {
+		t.Errorf("step = %+v", step)
+	}
+	if detail.Result.TotalTimeMs != detail.TotalTimeMs || detail.Result.Profile == nil {
+		t.Errorf("result = %+v", detail.Result)
+	}
+
+	// Runs of another workspace are not visible
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/flow-runs/"+first.RunID, nil)
+	req.Header.Set("X-Workspace-ID", "2")
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", resp.StatusCode)
+	}
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/flows/%d/runs", ts.URL, flow.ID), nil)
+	req.Header.Set("X-Workspace-ID", "2")
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace list: status = %d, want 404", resp.StatusCode)
+	}
+
+	// Deleting the flow deletes its runs
+	req, _ = http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/flows/%d", ts.URL, flow.ID), nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	resp, _ = http.Get(ts.URL + "/api/flow-runs/" + first.RunID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("deleted flow: status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -308,3 +308,64 @@ func TestFlowStep_ContinueOnError(t *testing.T) {
 		t.Errorf("expected continueOnError toggled to true")
 	}
 }
+
+func TestFlowStep_RetryPolicy(t *testing.T) {
+	ts := setupFlowStepTestServer(t)
+
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Flaky Flow"}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+	stepsURL := fmt.Sprintf("%s/api/flows/%d/steps", ts.URL, flow.ID)
+
+	resp, _ = postJSON(stepsURL, `{"name":"s","url":"https://api.example.com","stepOrder":1,
+		"timeoutMs":5000,"retryCount":3,"retryBackoffMs":200,"retryOnStatus":"502, 503"}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status %d", resp.StatusCode)
+	}
+	var step handler.FlowStepResponse
+	readJSON(t, resp, &step)
+	if step.TimeoutMs != 5000 || step.RetryCount != 3 || step.RetryBackoffMs != 200 || step.RetryOnStatus != "502, 503" {
+		t.Errorf("policy = %+v", step)
+	}
+
+	for _, body := range []string{
+		`{"name":"s","url":"https://x","retryOnStatus":"50x"}`,
+		`{"name":"s","url":"https://x","retryCount":11}`,
+		`{"name":"s","url":"https://x","timeoutMs":-1}`,
+	} {
+		resp, _ = putJSON(fmt.Sprintf("%s/%d", stepsURL, step.ID), body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestFlowStep_RedirectPolicy(t *testing.T) {
+	ts := setupFlowStepTestServer(t)
+
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Login Flow"}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+	stepsURL := fmt.Sprintf("%s/api/flows/%d/steps", ts.URL, flow.ID)
+
+	// Redirects are followed unless turned off
+	resp, _ = postJSON(stepsURL, `{"name":"s","url":"https://api.example.com","stepOrder":1}`)
+	var step handler.FlowStepResponse
+	readJSON(t, resp, &step)
+	if step.FollowRedirects == nil || !*step.FollowRedirects || step.MaxRedirects != 0 {
+		t.Errorf("default policy = %v, %d", step.FollowRedirects, step.MaxRedirects)
+	}
+
+	resp, _ = putJSON(fmt.Sprintf("%s/%d", stepsURL, step.ID), `{"name":"s","url":"https://api.example.com","followRedirects":false,"maxRedirects":3}`)
+	readJSON(t, resp, &step)
+	if step.FollowRedirects == nil || *step.FollowRedirects || step.MaxRedirects != 3 {
+		t.Errorf("updated policy = %v, %d", step.FollowRedirects, step.MaxRedirects)
+	}
+
+	resp, _ = putJSON(fmt.Sprintf("%s/%d", stepsURL, step.ID), `{"name":"s","url":"https://x","maxRedirects":51}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("maxRedirects 51: status %d", resp.StatusCode)
+	}
+}
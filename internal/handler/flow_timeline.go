@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"relay/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Timeline returns where a flow run's time went: each step's start and end
+// with its script, delay, queue, HTTP and extraction phases. Timelines are
+// kept for a week.
+func (h *FlowHandler) Timeline(w http.ResponseWriter, r *http.Request) {
+	row, err := h.queries.GetRunTimeline(r.Context(), chi.URLParam(r, "id"))
+	if err != nil || row.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Run not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, json.RawMessage(row.Timeline))
+}
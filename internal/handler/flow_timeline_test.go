@@ -0,0 +1,71 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestFlow_Timeline(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	fr := service.NewFlowRunner(q, service.NewRequestExecutor(q, vr, nil), vr)
+	h := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/flows", h.Create)
+	r.Post("/api/flows/{id}/run", h.Run)
+	r.Post("/api/flows/{id}/steps", h.CreateStep)
+	r.Get("/api/flows/runs/{id}/timeline", h.Timeline)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Checkout"}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/steps", ts.URL, flow.ID),
+		fmt.Sprintf(`{"name":"cart","method":"GET","url":%q,"delayMs":10}`, api.URL))
+	resp.Body.Close()
+
+	var result service.FlowResult
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/run", ts.URL, flow.ID), `{}`)
+	readJSON(t, resp, &result)
+
+	var tl service.RunTimeline
+	resp, _ = http.Get(fmt.Sprintf("%s/api/flows/runs/%s/timeline", ts.URL, result.RunID))
+	readJSON(t, resp, &tl)
+	if tl.RunID != result.RunID || tl.FlowName != "Checkout" || len(tl.Entries) != 1 {
+		t.Fatalf("timeline = %+v", tl)
+	}
+	if e := tl.Entries[0]; e.Name != "cart" || e.Status != service.NodeStatusSuccess || len(e.Phases) != 2 || e.Phases[0].Kind != service.TimelinePhaseDelay {
+		t.Errorf("entry = %+v", e)
+	}
+
+	// Runs of another workspace are not visible
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/flows/runs/%s/timeline", ts.URL, result.RunID), nil)
+	req.Header.Set("X-Workspace-ID", "2")
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", resp.StatusCode)
+	}
+	resp, _ = http.Get(ts.URL + "/api/flows/runs/unknown/timeline")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown run: status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type GraphQLHandler struct {
+	schema *service.GraphQLSchema
+}
+
+func NewGraphQLHandler(queries *repository.Queries) *GraphQLHandler {
+	return &GraphQLHandler{schema: service.NewGraphQLSchema(queries)}
+}
+
+// Query executes a read-only GraphQL query scoped to the workspace.
+// POST takes a JSON body; GET takes query, variables (JSON) and
+// operationName as query parameters.
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	var req service.GraphQLRequest
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				respondError(w, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	} else if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Query == "" {
+		respondError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	resp := h.schema.Execute(r.Context(), req)
+	status := http.StatusOK
+	if resp.Data == nil {
+		// The document couldn't be run at all (syntax, unknown operation, ...)
+		status = http.StatusBadRequest
+	}
+	respondJSON(w, status, resp)
+}
+
+// Schema returns the schema in SDL
+func (h *GraphQLHandler) Schema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(h.schema.SDL()))
+}
@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestGraphQL_Query(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer target.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	collH := handler.NewCollectionHandler(q, nil)
+	reqH := handler.NewRequestHandler(q, re, service.NewFlowRunner(q, re, vr))
+	gqlH := handler.NewGraphQLHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/collections", collH.Create)
+	r.Post("/api/requests", reqH.Create)
+	r.Post("/api/requests/{id}/execute", reqH.Execute)
+	r.Get("/api/graphql", gqlH.Query)
+	r.Post("/api/graphql", gqlH.Query)
+	r.Get("/api/graphql/schema", gqlH.Schema)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	var coll handler.CollectionResponse
+	resp, _ := postJSON(ts.URL+"/api/collections", `{"name":"API"}`)
+	readJSON(t, resp, &coll)
+	var req handler.RequestResponse
+	resp, _ = postJSON(ts.URL+"/api/requests", fmt.Sprintf(`{"collectionId":%d,"name":"Tea","method":"GET","url":"%s"}`, coll.ID, target.URL))
+	readJSON(t, resp, &req)
+	resp, _ = postJSON(fmt.Sprintf("%s/api/requests/%d/execute", ts.URL, req.ID), `{}`)
+	resp.Body.Close()
+
+	query := `{"query":"{ collections { name requests { name executionCount lastRun { statusCode success } } } }"}`
+	resp, _ = postJSON(ts.URL+"/api/graphql", query)
+	var result struct {
+		Data struct {
+			Collections []struct {
+				Name     string `json:"name"`
+				Requests []struct {
+					Name           string `json:"name"`
+					ExecutionCount int    `json:"executionCount"`
+					LastRun        *struct {
+						StatusCode int  `json:"statusCode"`
+						Success    bool `json:"success"`
+					} `json:"lastRun"`
+				} `json:"requests"`
+			} `json:"collections"`
+		} `json:"data"`
+		Errors []service.GraphQLError `json:"errors"`
+	}
+	readJSON(t, resp, &result)
+	if resp.StatusCode != http.StatusOK || len(result.Errors) > 0 {
+		t.Fatalf("status %d, errors %+v", resp.StatusCode, result.Errors)
+	}
+	if len(result.Data.Collections) != 1 || len(result.Data.Collections[0].Requests) != 1 {
+		t.Fatalf("data = %+v", result.Data)
+	}
+	got := result.Data.Collections[0].Requests[0]
+	if got.Name != "Tea" || got.ExecutionCount != 1 || got.LastRun == nil || got.LastRun.StatusCode != http.StatusTeapot || got.LastRun.Success {
+		t.Errorf("request = %+v", got)
+	}
+
+	// GET with variables; other workspaces see nothing of workspace 1
+	params := url.Values{
+		"query":     {`query($id: Int!) { request(id: $id) { name } collections { id } }`},
+		"variables": {fmt.Sprintf(`{"id":%d}`, req.ID)},
+	}
+	httpReq, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/graphql?"+params.Encode(), nil)
+	httpReq.Header.Set("X-Workspace-ID", "2")
+	resp, _ = http.DefaultClient.Do(httpReq)
+	var isolated map[string]json.RawMessage
+	readJSON(t, resp, &isolated)
+	if string(isolated["data"]) != `{"request":null,"collections":[]}` {
+		t.Errorf("other workspace data = %s", isolated["data"])
+	}
+
+	// Documents that can't run are 400s
+	for _, body := range []string{`{"query":"mutation { collections { id } }"}`, `{"query":""}`, `not json`} {
+		resp, _ = postJSON(ts.URL+"/api/graphql", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/graphql/schema")
+	sdl, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(sdl), "type Collection {") {
+		t.Errorf("schema = %s", sdl)
+	}
+}
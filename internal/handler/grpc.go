@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+
+	"relay/internal/service"
+)
+
+type GRPCHandler struct {
+	variableResolver *service.VariableResolver
+}
+
+func NewGRPCHandler(vr *service.VariableResolver) *GRPCHandler {
+	return &GRPCHandler{variableResolver: vr}
+}
+
+// GRPCReflectRequest names the server to describe. URL and headers may use
+// variables, resolved against the collection when collectionId is set.
+type GRPCReflectRequest struct {
+	URL          string            `json:"url"`     // grpc://host:port or grpcs://host:port; a method path is ignored
+	Headers      string            `json:"headers"` // JSON object, sent as metadata
+	CollectionID int64             `json:"collectionId"`
+	Variables    map[string]string `json:"variables"`
+}
+
+type GRPCReflectResponse struct {
+	Services []service.GRPCServiceInfo `json:"services"`
+}
+
+// Reflect lists the server's services and methods through server reflection,
+// so a gRPC request can be built from them
+func (h *GRPCHandler) Reflect(w http.ResponseWriter, r *http.Request) {
+	var req GRPCReflectRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	ctx := r.Context()
+	rawURL, err := h.variableResolver.Resolve(ctx, req.URL, req.Variables, req.CollectionID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	target, err := service.ParseGRPCURL(rawURL)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	headers := req.Headers
+	if headers == "" {
+		headers = "{}"
+	}
+	resolvedHeaders, err := h.variableResolver.ResolveHeaders(ctx, headers, req.Variables, req.CollectionID)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	services, err := service.DescribeGRPC(ctx, target, service.GRPCMetadata(resolvedHeaders))
+	if err != nil {
+		respondError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, GRPCReflectResponse{Services: services})
+}
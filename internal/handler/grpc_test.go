@@ -0,0 +1,72 @@
+package handler_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestGRPC_Reflect(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	q := testutil.SetupTestDB(t)
+	h := handler.NewGRPCHandler(service.NewVariableResolver(q))
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/grpc/reflect", h.Reflect)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, err := postJSON(ts.URL+"/api/grpc/reflect", fmt.Sprintf(`{"url":"grpc://{{host}}","variables":{"host":%q}}`, lis.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("reflect: status %d", resp.StatusCode)
+	}
+	var out handler.GRPCReflectResponse
+	readJSON(t, resp, &out)
+	if len(out.Services) != 1 || out.Services[0].Name != "grpc.health.v1.Health" || len(out.Services[0].Methods) == 0 {
+		t.Errorf("services = %+v", out.Services)
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/grpc/reflect", `{"url":"http://localhost:1"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("http URL: status %d", resp.StatusCode)
+	}
+
+	// A server without reflection can't be described
+	plain := grpc.NewServer()
+	plainLis, _ := net.Listen("tcp", "127.0.0.1:0")
+	go plain.Serve(plainLis)
+	defer plain.Stop()
+	resp, _ = postJSON(ts.URL+"/api/grpc/reflect", fmt.Sprintf(`{"url":"grpc://%s"}`, plainLis.Addr().String()))
+	var errResp map[string]string
+	readJSON(t, resp, &errResp)
+	if resp.StatusCode != http.StatusBadGateway || errResp["error"] != "server reflection is not enabled on the server" {
+		t.Errorf("without reflection: status %d, %v", resp.StatusCode, errResp)
+	}
+}
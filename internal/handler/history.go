@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"database/sql"
+	"encoding/json"
 	"net/http"
+	"sort"
 
 	"relay/internal/middleware"
 	"relay/internal/repository"
@@ -30,15 +33,110 @@ type HistoryResponse struct {
 	Error           string `json:"error,omitempty"`
 	BodySize        int64  `json:"bodySize"`
 	IsBinary        bool   `json:"isBinary,omitempty"`
+	TraceID         string `json:"traceId,omitempty"`
+	RunID           string `json:"runId,omitempty"`
+	Note            string `json:"note,omitempty"`
+	Flagged         bool   `json:"flagged"`
 	CreatedAt       string `json:"createdAt"`
+	// Redirects are the redirect responses followed before the final one
+	Redirects json.RawMessage `json:"redirects,omitempty"`
+	// Network is the proxy, TLS mode and protocol the execution used
+	Network json.RawMessage `json:"network,omitempty"`
+	// AttemptGroupID links the attempts of a request with a retry policy;
+	// Attempt is this entry's number in the group, from 1
+	AttemptGroupID string `json:"attemptGroupId,omitempty"`
+	Attempt        int64  `json:"attempt,omitempty"`
+	// Attempts are all entries of the attempt group in order (single entry only)
+	Attempts []HistoryResponse `json:"attempts,omitempty"`
+	// ParentID is the entry of the step or request whose script sent this one
+	ParentID *int64 `json:"parentId,omitempty"`
+	// Children are the pm.sendRequest calls made by this entry's scripts (single entry only)
+	Children []HistoryResponse `json:"children,omitempty"`
 }
 
+func toHistoryResponse(hist repository.RequestHistory) HistoryResponse {
+	item := HistoryResponse{
+		ID:              hist.ID,
+		Method:          hist.Method,
+		URL:             hist.Url,
+		RequestHeaders:  hist.RequestHeaders.String,
+		RequestBody:     hist.RequestBody.String,
+		ResponseHeaders: hist.ResponseHeaders.String,
+		ResponseBody:    hist.ResponseBody.String,
+		Error:           hist.Error.String,
+		BodySize:        hist.BodySize.Int64,
+		IsBinary:        hist.IsBinary.Int64 != 0,
+		TraceID:         hist.TraceID.String,
+		RunID:           hist.ExecutionGroupID.String,
+		AttemptGroupID:  hist.AttemptGroupID.String,
+		Attempt:         hist.Attempt,
+		Note:            hist.Note.String,
+		Flagged:         hist.Flagged != 0,
+		CreatedAt:       formatTime(hist.CreatedAt),
+	}
+	if hist.RequestID.Valid {
+		reqID := hist.RequestID.Int64
+		item.RequestID = &reqID
+	}
+	if hist.FlowID.Valid {
+		flowID := hist.FlowID.Int64
+		item.FlowID = &flowID
+	}
+	if hist.StatusCode.Valid {
+		code := hist.StatusCode.Int64
+		item.StatusCode = &code
+	}
+	if hist.DurationMs.Valid {
+		duration := hist.DurationMs.Int64
+		item.DurationMs = &duration
+	}
+	if hist.ParentHistoryID.Valid {
+		parentID := hist.ParentHistoryID.Int64
+		item.ParentID = &parentID
+	}
+	if hist.Redirects != "" {
+		item.Redirects = json.RawMessage(hist.Redirects)
+	}
+	if hist.Network != "" {
+		item.Network = json.RawMessage(hist.Network)
+	}
+	return item
+}
+
+// HistoryGroup is one flow run's history entries in execution order, or a
+// single execution outside any run
+type HistoryGroup struct {
+	RunID     string            `json:"runId,omitempty"`
+	FlowID    *int64            `json:"flowId,omitempty"`
+	CreatedAt string            `json:"createdAt"` // of the first entry
+	Entries   []HistoryResponse `json:"entries"`
+}
+
+// List returns the latest history entries, or with ?traceId= every request of
+// one trace (e.g. a flow run) in execution order. ?flagged=true keeps only
+// flagged entries. ?groupBy=run returns HistoryGroups instead, latest first.
 func (h *HistoryHandler) List(w http.ResponseWriter, r *http.Request) {
 	wsID := middleware.GetWorkspaceID(r.Context())
-	history, err := h.queries.ListHistory(r.Context(), repository.ListHistoryParams{
-		WorkspaceID: wsID,
-		Limit:       100,
-	})
+	flaggedOnly := r.URL.Query().Get("flagged") == "true"
+	traceID := r.URL.Query().Get("traceId")
+	var history []repository.RequestHistory
+	var err error
+	if traceID != "" {
+		history, err = h.queries.ListHistoryByTrace(r.Context(), repository.ListHistoryByTraceParams{
+			WorkspaceID: wsID,
+			TraceID:     sql.NullString{String: traceID, Valid: true},
+		})
+	} else if flaggedOnly {
+		history, err = h.queries.ListFlaggedHistory(r.Context(), repository.ListFlaggedHistoryParams{
+			WorkspaceID: wsID,
+			Limit:       100,
+		})
+	} else {
+		history, err = h.queries.ListHistory(r.Context(), repository.ListHistoryParams{
+			WorkspaceID: wsID,
+			Limit:       100,
+		})
+	}
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -46,39 +144,69 @@ func (h *HistoryHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	resp := make([]HistoryResponse, 0, len(history))
 	for _, hist := range history {
-		item := HistoryResponse{
-			ID:              hist.ID,
-			Method:          hist.Method,
-			URL:             hist.Url,
-			RequestHeaders:  hist.RequestHeaders.String,
-			RequestBody:     hist.RequestBody.String,
-			ResponseHeaders: hist.ResponseHeaders.String,
-			ResponseBody:    hist.ResponseBody.String,
-			Error:           hist.Error.String,
-			BodySize:        hist.BodySize.Int64,
-			IsBinary:        hist.IsBinary.Int64 != 0,
-			CreatedAt:       formatTime(hist.CreatedAt),
-		}
-		if hist.RequestID.Valid {
-			reqID := hist.RequestID.Int64
-			item.RequestID = &reqID
+		if flaggedOnly && hist.Flagged == 0 {
+			continue
 		}
-		if hist.FlowID.Valid {
-			flowID := hist.FlowID.Int64
-			item.FlowID = &flowID
+		resp = append(resp, toHistoryResponse(hist))
+	}
+
+	if r.URL.Query().Get("groupBy") == "run" {
+		// The latest entries may cut a run short; fill in the rest of it
+		// unless the list was filtered
+		groups, err := h.groupByRun(r, wsID, resp, traceID == "" && !flaggedOnly)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
 		}
-		if hist.StatusCode.Valid {
-			code := hist.StatusCode.Int64
-			item.StatusCode = &code
+		respondJSON(w, http.StatusOK, groups)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// groupByRun groups entries by flow run in the order their runs first appear.
+// Each run's entries are in execution order; with complete, every entry of
+// the run is included, not only those in entries.
+func (h *HistoryHandler) groupByRun(r *http.Request, wsID int64, entries []HistoryResponse, complete bool) ([]HistoryGroup, error) {
+	groups := []HistoryGroup{}
+	runIndex := make(map[string]int)
+	for _, item := range entries {
+		if item.RunID == "" {
+			groups = append(groups, HistoryGroup{FlowID: item.FlowID, CreatedAt: item.CreatedAt, Entries: []HistoryResponse{item}})
+			continue
 		}
-		if hist.DurationMs.Valid {
-			duration := hist.DurationMs.Int64
-			item.DurationMs = &duration
+		if i, ok := runIndex[item.RunID]; ok {
+			groups[i].Entries = append(groups[i].Entries, item)
+			continue
 		}
-		resp = append(resp, item)
+		runIndex[item.RunID] = len(groups)
+		groups = append(groups, HistoryGroup{RunID: item.RunID, FlowID: item.FlowID, Entries: []HistoryResponse{item}})
 	}
 
-	respondJSON(w, http.StatusOK, resp)
+	for i := range groups {
+		g := &groups[i]
+		if g.RunID == "" {
+			continue
+		}
+		if complete {
+			rows, err := h.queries.ListHistoryByExecutionGroup(r.Context(), repository.ListHistoryByExecutionGroupParams{
+				WorkspaceID:      wsID,
+				ExecutionGroupID: sql.NullString{String: g.RunID, Valid: true},
+			})
+			if err != nil {
+				return nil, err
+			}
+			g.Entries = make([]HistoryResponse, 0, len(rows))
+			for _, hist := range rows {
+				g.Entries = append(g.Entries, toHistoryResponse(hist))
+			}
+		} else {
+			sort.Slice(g.Entries, func(a, b int) bool { return g.Entries[a].ID < g.Entries[b].ID })
+		}
+		g.CreatedAt = g.Entries[0].CreatedAt
+	}
+	return groups, nil
 }
 
 func (h *HistoryHandler) Get(w http.ResponseWriter, r *http.Request) {
@@ -94,34 +222,27 @@ func (h *HistoryHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	item := HistoryResponse{
-		ID:              hist.ID,
-		Method:          hist.Method,
-		URL:             hist.Url,
-		RequestHeaders:  hist.RequestHeaders.String,
-		RequestBody:     hist.RequestBody.String,
-		ResponseHeaders: hist.ResponseHeaders.String,
-		ResponseBody:    hist.ResponseBody.String,
-		Error:           hist.Error.String,
-		BodySize:        hist.BodySize.Int64,
-		IsBinary:        hist.IsBinary.Int64 != 0,
-		CreatedAt:       formatTime(hist.CreatedAt),
-	}
-	if hist.RequestID.Valid {
-		reqID := hist.RequestID.Int64
-		item.RequestID = &reqID
-	}
-	if hist.FlowID.Valid {
-		flowID := hist.FlowID.Int64
-		item.FlowID = &flowID
+	item := toHistoryResponse(hist)
+	children, err := h.queries.ListHistoryChildren(r.Context(), sql.NullInt64{Int64: hist.ID, Valid: true})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
-	if hist.StatusCode.Valid {
-		code := hist.StatusCode.Int64
-		item.StatusCode = &code
+	for _, child := range children {
+		item.Children = append(item.Children, toHistoryResponse(child))
 	}
-	if hist.DurationMs.Valid {
-		duration := hist.DurationMs.Int64
-		item.DurationMs = &duration
+	if hist.AttemptGroupID.Valid {
+		attempts, err := h.queries.ListHistoryByAttemptGroup(r.Context(), repository.ListHistoryByAttemptGroupParams{
+			WorkspaceID:    hist.WorkspaceID,
+			AttemptGroupID: hist.AttemptGroupID,
+		})
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, a := range attempts {
+			item.Attempts = append(item.Attempts, toHistoryResponse(a))
+		}
 	}
 
 	respondJSON(w, http.StatusOK, item)
@@ -138,6 +259,58 @@ func (h *HistoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if err := h.queries.DeleteWSMessagesByHistory(r.Context(), id); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+type HistoryNoteRequest struct {
+	Note    string `json:"note"`
+	Flagged *bool  `json:"flagged"` // unchanged when omitted
+}
+
+// Note sets an entry's note and flag. Flagged entries are exempt from history
+// retention.
+func (h *HistoryHandler) Note(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req HistoryNoteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	wsID := middleware.GetWorkspaceID(r.Context())
+	hist, err := h.queries.GetHistory(r.Context(), id)
+	if err != nil || hist.WorkspaceID != wsID {
+		respondError(w, http.StatusNotFound, "History not found")
+		return
+	}
+
+	flagged := hist.Flagged
+	if req.Flagged != nil {
+		flagged = 0
+		if *req.Flagged {
+			flagged = 1
+		}
+	}
+	hist, err = h.queries.UpdateHistoryNote(r.Context(), repository.UpdateHistoryNoteParams{
+		Note:        sql.NullString{String: req.Note, Valid: req.Note != ""},
+		Flagged:     flagged,
+		ID:          id,
+		WorkspaceID: wsID,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toHistoryResponse(hist))
+}
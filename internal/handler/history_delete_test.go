@@ -28,6 +28,7 @@ func setupHistoryDeleteTestServer(t *testing.T) (*httptest.Server, *repository.Q
 	r.Get("/api/history", histH.List)
 	r.Get("/api/history/{id}", histH.Get)
 	r.Delete("/api/history/{id}", histH.Delete)
+	r.Post("/api/history/{id}/note", histH.Note)
 
 	ts := httptest.NewServer(r)
 	t.Cleanup(ts.Close)
@@ -0,0 +1,131 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/repository"
+)
+
+func TestHistory_GroupByRun(t *testing.T) {
+	ts, q := setupHistoryDeleteTestServer(t)
+
+	// Two runs interleaved with an ad-hoc execution
+	var ids []int64
+	for _, runID := range []string{"run-a", "", "run-a", "run-b"} {
+		hist, err := q.CreateHistory(context.Background(), repository.CreateHistoryParams{
+			Method:           "GET",
+			Url:              "https://api.example.com/" + runID,
+			WorkspaceID:      1,
+			ExecutionGroupID: sql.NullString{String: runID, Valid: runID != ""},
+		})
+		if err != nil {
+			t.Fatalf("create history: %v", err)
+		}
+		ids = append(ids, hist.ID)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/history?groupBy=run")
+	if err != nil {
+		t.Fatalf("list grouped history: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	var groups []handler.HistoryGroup
+	readJSON(t, resp, &groups)
+
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %+v", groups)
+	}
+	byRun := make(map[string]handler.HistoryGroup)
+	for _, g := range groups {
+		byRun[g.RunID] = g
+	}
+	runA := byRun["run-a"].Entries
+	if len(runA) != 2 || runA[0].ID != ids[0] || runA[1].ID != ids[2] {
+		t.Errorf("run-a entries = %+v", runA)
+	}
+	if len(byRun["run-b"].Entries) != 1 || len(byRun[""].Entries) != 1 || byRun[""].Entries[0].ID != ids[1] {
+		t.Errorf("groups = %+v", groups)
+	}
+}
+
+func TestHistory_GetChildren(t *testing.T) {
+	ts, q := setupHistoryDeleteTestServer(t)
+	ctx := context.Background()
+
+	parent, err := q.CreateHistory(ctx, repository.CreateHistoryParams{Method: "GET", Url: "https://api.example.com/me", WorkspaceID: 1})
+	if err != nil {
+		t.Fatalf("create history: %v", err)
+	}
+	child, err := q.CreateHistory(ctx, repository.CreateHistoryParams{Method: "POST", Url: "https://auth.example.com/token", WorkspaceID: 1})
+	if err != nil {
+		t.Fatalf("create history: %v", err)
+	}
+	if err := q.SetHistoryParent(ctx, repository.SetHistoryParentParams{
+		ParentHistoryID: sql.NullInt64{Int64: parent.ID, Valid: true},
+		ID:              child.ID,
+	}); err != nil {
+		t.Fatalf("set parent: %v", err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/history/" + strconv.FormatInt(parent.ID, 10))
+	if err != nil {
+		t.Fatalf("get history: %v", err)
+	}
+	var got handler.HistoryResponse
+	readJSON(t, resp, &got)
+	if len(got.Children) != 1 || got.Children[0].ID != child.ID || *got.Children[0].ParentID != parent.ID {
+		t.Errorf("children = %+v", got.Children)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/history/" + strconv.FormatInt(child.ID, 10))
+	if err != nil {
+		t.Fatalf("get history: %v", err)
+	}
+	got = handler.HistoryResponse{}
+	readJSON(t, resp, &got)
+	if got.ParentID == nil || *got.ParentID != parent.ID || len(got.Children) != 0 {
+		t.Errorf("child = %+v", got)
+	}
+}
+
+func TestHistory_GetAttempts(t *testing.T) {
+	ts, q := setupHistoryDeleteTestServer(t)
+	ctx := context.Background()
+
+	group := sql.NullString{String: "attempts-1", Valid: true}
+	var ids []int64
+	for n, status := range []int64{503, 200} {
+		hist, err := q.CreateHistory(ctx, repository.CreateHistoryParams{
+			Method:         "GET",
+			Url:            "https://api.example.com/flaky",
+			StatusCode:     sql.NullInt64{Int64: status, Valid: true},
+			WorkspaceID:    1,
+			AttemptGroupID: group,
+			Attempt:        int64(n + 1),
+		})
+		if err != nil {
+			t.Fatalf("create history: %v", err)
+		}
+		ids = append(ids, hist.ID)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/history/" + strconv.FormatInt(ids[1], 10))
+	if err != nil {
+		t.Fatalf("get history: %v", err)
+	}
+	var got handler.HistoryResponse
+	readJSON(t, resp, &got)
+	if got.AttemptGroupID != group.String || got.Attempt != 2 {
+		t.Errorf("attempt = %q #%d", got.AttemptGroupID, got.Attempt)
+	}
+	if len(got.Attempts) != 2 || got.Attempts[0].ID != ids[0] || *got.Attempts[0].StatusCode != 503 || got.Attempts[1].Attempt != 2 {
+		t.Errorf("attempts = %+v", got.Attempts)
+	}
+}
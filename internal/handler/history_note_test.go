@@ -0,0 +1,68 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/repository"
+)
+
+func TestHistory_Note(t *testing.T) {
+	ts, q := setupHistoryDeleteTestServer(t)
+	ctx := context.Background()
+
+	var ids []int64
+	for _, url := range []string{"https://api.example.com/a", "https://api.example.com/b"} {
+		hist, err := q.CreateHistory(ctx, repository.CreateHistoryParams{Method: "GET", Url: url, WorkspaceID: 1})
+		if err != nil {
+			t.Fatalf("create history: %v", err)
+		}
+		ids = append(ids, hist.ID)
+	}
+	noteURL := ts.URL + fmt.Sprintf("/api/history/%d/note", ids[0])
+
+	resp, err := postJSON(noteURL, `{"note":"flaky upstream","flagged":true}`)
+	if err != nil {
+		t.Fatalf("set note: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	var hist handler.HistoryResponse
+	readJSON(t, resp, &hist)
+	if hist.Note != "flaky upstream" || !hist.Flagged {
+		t.Errorf("note = %q, flagged = %v", hist.Note, hist.Flagged)
+	}
+
+	// Omitting flagged keeps the flag
+	resp, err = postJSON(noteURL, `{"note":"still flaky"}`)
+	if err != nil {
+		t.Fatalf("update note: %v", err)
+	}
+	readJSON(t, resp, &hist)
+	if hist.Note != "still flaky" || !hist.Flagged {
+		t.Errorf("note = %q, flagged = %v", hist.Note, hist.Flagged)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/history?flagged=true")
+	if err != nil {
+		t.Fatalf("list flagged: %v", err)
+	}
+	var flagged []handler.HistoryResponse
+	readJSON(t, resp, &flagged)
+	if len(flagged) != 1 || flagged[0].ID != ids[0] {
+		t.Errorf("flagged = %+v", flagged)
+	}
+
+	resp, err = postJSONWithWorkspace(noteURL, `{"note":"x"}`, 2)
+	if err != nil {
+		t.Fatalf("set note in other workspace: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: expected status 404, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+const (
+	defaultHistorySearchLimit = 50
+	maxHistorySearchLimit     = 200
+)
+
+// HistorySearchResult is a history entry whose response body or error
+// matched, with the matching text marked up in <mark> tags
+type HistorySearchResult struct {
+	HistoryResponse
+	Snippet string `json:"snippet"`
+}
+
+// Search finds history entries whose response body or error contains every
+// word of ?q=, latest first. Entries appear once the background indexer has
+// picked them up (within a minute).
+func (h *HistoryHandler) Search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+
+	query := service.HistorySearchQuery(r.URL.Query().Get("q"))
+	if query == "" {
+		respondError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	limit := int64(defaultHistorySearchLimit)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || n > maxHistorySearchLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 200")
+			return
+		}
+		limit = n
+	}
+
+	raw, err := h.queries.GetWorkspaceSettings(ctx, wsID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if service.ParseWorkspaceSettings(raw).HistorySearch.Disabled {
+		respondError(w, http.StatusConflict, "History search is disabled for this workspace")
+		return
+	}
+
+	rows, err := h.queries.SearchHistory(ctx, repository.SearchHistoryParams{
+		Query:       query,
+		WorkspaceID: wsID,
+		Limit:       limit,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]HistorySearchResult, 0, len(rows))
+	for _, row := range rows {
+		resp = append(resp, HistorySearchResult{
+			HistoryResponse: toHistoryResponse(row.RequestHistory),
+			Snippet:         row.Snippet,
+		})
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
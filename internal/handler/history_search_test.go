@@ -0,0 +1,81 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHistorySearch(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	h := handler.NewHistoryHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/history/search", h.Search)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	ws2, _ := q.CreateWorkspace(ctx, "Opted out")
+	q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"historySearch":{"disabled":true}}`, Valid: true}, ID: ws2.ID,
+	})
+	var ids []int64
+	for _, body := range []string{`{"message":"quota exceeded for key abc-123"}`, `{"ok":true}`, `{"message":"Quota Exceeded"}`} {
+		hist, _ := q.CreateHistory(ctx, repository.CreateHistoryParams{
+			Method:       "POST",
+			Url:          "https://api.example.com/charge",
+			ResponseBody: sql.NullString{String: body, Valid: true},
+			WorkspaceID:  1,
+		})
+		ids = append(ids, hist.ID)
+	}
+	if _, err := service.NewHistorySearchIndexer(q).Index(ctx); err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	var results []handler.HistorySearchResult
+	resp, _ := http.Get(ts.URL + "/api/history/search?q=" + url.QueryEscape("quota - exceeded"))
+	readJSON(t, resp, &results)
+	if len(results) != 2 || results[0].ID != ids[2] || results[1].ID != ids[0] {
+		t.Fatalf("results = %+v", results)
+	}
+	if results[0].Snippet != `{"message":"<mark>Quota</mark> <mark>Exceeded</mark>"}` || results[0].URL != "https://api.example.com/charge" {
+		t.Errorf("first result = %+v", results[0])
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/history/search?q=abc-123&limit=1")
+	readJSON(t, resp, &results)
+	if len(results) != 1 || results[0].ID != ids[0] {
+		t.Errorf("abc-123 = %+v", results)
+	}
+
+	for query, status := range map[string]int{
+		"":                 http.StatusBadRequest,
+		"?q=quota&limit=0": http.StatusBadRequest,
+		"?q=quota&limit=x": http.StatusBadRequest,
+	} {
+		resp, _ = http.Get(ts.URL + "/api/history/search" + query)
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("%q: status = %d, want %d", query, resp.StatusCode, status)
+		}
+	}
+	resp, _ = getWithWorkspace(ts.URL+"/api/history/search?q=quota", ws2.ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("opted-out workspace: status = %d, want 409", resp.StatusCode)
+	}
+}
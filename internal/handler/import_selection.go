@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// ImportPreviewRequest carries an export to parse; format defaults to postman
+type ImportPreviewRequest struct {
+	Format string          `json:"format"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type ImportPreviewResponse struct {
+	Tree        service.ImportPreviewNode `json:"tree"`
+	Collections int                       `json:"collections"`
+	Requests    int                       `json:"requests"`
+	Warnings    []string                  `json:"warnings,omitempty"`
+}
+
+// ImportCommitRequest re-sends the previewed export with the items to create.
+// Without collectionId the selection becomes a new root collection; with it,
+// the selected items are added to that existing collection.
+type ImportCommitRequest struct {
+	Format       string          `json:"format"`
+	Data         json.RawMessage `json:"data"`
+	Items        []string        `json:"items"` // preview node IDs
+	CollectionID *int64          `json:"collectionId"`
+}
+
+// parseImport decodes and parses the export, responding 400 on failure
+func parseImport(w http.ResponseWriter, format string, data json.RawMessage) (*service.ImportedCollection, []string, bool) {
+	if len(data) == 0 {
+		respondError(w, http.StatusBadRequest, "data is required")
+		return nil, nil, false
+	}
+	tree, warnings, err := service.ParseImport(format, data)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
+	return tree, warnings, true
+}
+
+// ImportPreview parses an export without creating anything and returns its
+// tree, so the client can choose what to import
+func (h *CollectionHandler) ImportPreview(w http.ResponseWriter, r *http.Request) {
+	var req ImportPreviewRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	tree, warnings, ok := parseImport(w, req.Format, req.Data)
+	if !ok {
+		return
+	}
+	collections, requests := tree.Count()
+	respondJSON(w, http.StatusOK, ImportPreviewResponse{
+		Tree:        tree.Preview(),
+		Collections: collections,
+		Requests:    requests,
+		Warnings:    warnings,
+	})
+}
+
+// ImportCommit creates the selected items of a previewed export
+func (h *CollectionHandler) ImportCommit(w http.ResponseWriter, r *http.Request) {
+	var req ImportCommitRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	tree, warnings, ok := parseImport(w, req.Format, req.Data)
+	if !ok {
+		return
+	}
+	selection, err := tree.Select(req.Items)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collections, requests := selection.Count()
+	if !enforceRequestQuota(w, r, h.queries, requests) {
+		return
+	}
+
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+
+	var target repository.Collection
+	if req.CollectionID != nil {
+		target, err = h.queries.GetCollection(ctx, *req.CollectionID)
+		if err != nil || target.WorkspaceID != wsID {
+			respondError(w, http.StatusNotFound, "Collection not found")
+			return
+		}
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.queries.WithTx(tx)
+
+	if req.CollectionID == nil {
+		var maxSortOrder int64
+		if val, err := qtx.GetMaxRootCollectionSortOrder(ctx, wsID); err == nil {
+			maxSortOrder, _ = val.(int64)
+		}
+		target, err = createImportedCollection(ctx, qtx, wsID, sql.NullInt64{}, maxSortOrder+1, selection)
+	} else {
+		// The root's own variables belong to the collection it would have
+		// created; the existing collection keeps its own
+		collections--
+		if len(selection.Variables) > 0 {
+			warnings = append(warnings, selection.Name+": collection variables were not imported into an existing collection")
+		}
+		parentID := sql.NullInt64{Int64: target.ID, Valid: true}
+		var maxRequest, maxChild int64
+		if val, err := qtx.GetMaxRequestSortOrder(ctx, parentID); err == nil {
+			maxRequest, _ = val.(int64)
+		}
+		if val, err := qtx.GetMaxChildCollectionSortOrder(ctx, parentID); err == nil {
+			maxChild, _ = val.(int64)
+		}
+		err = createImportedContents(ctx, qtx, wsID, target.ID, maxRequest+1, maxChild+1, selection)
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := CollectionResponse{
+		ID:        target.ID,
+		Name:      target.Name,
+		SortOrder: target.SortOrder,
+		CreatedAt: formatTime(target.CreatedAt),
+		UpdatedAt: formatTime(target.UpdatedAt),
+	}
+	if target.ParentID.Valid {
+		parentID := target.ParentID.Int64
+		resp.ParentID = &parentID
+	}
+	respondJSON(w, http.StatusCreated, PostmanImportResponse{
+		Collection:  resp,
+		Collections: collections,
+		Requests:    requests,
+		Warnings:    warnings,
+	})
+}
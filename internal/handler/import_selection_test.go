@@ -0,0 +1,112 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCollection_ImportPreviewAndCommit(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	h := handler.NewCollectionHandler(q, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/import/preview", h.ImportPreview)
+	r.Post("/api/import/commit", h.ImportCommit)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	postman := `{
+		"info": {"name": "Petstore", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"variable": [{"key": "host", "value": "https://pets.test"}],
+		"item": [
+			{"name": "Pets", "item": [
+				{"name": "List pets", "request": {"method": "GET", "url": "{{host}}/pets"}},
+				{"name": "Delete pet", "request": {"method": "DELETE", "url": "{{host}}/pets/1"}}
+			]},
+			{"name": "Ping", "request": {"method": "GET", "url": "{{host}}/ping"}}
+		]
+	}`
+
+	resp, _ := postJSON(ts.URL+"/api/import/preview", `{"format":"postman","data":`+postman+`}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("preview: status = %d", resp.StatusCode)
+	}
+	var preview handler.ImportPreviewResponse
+	readJSON(t, resp, &preview)
+	if preview.Collections != 2 || preview.Requests != 3 || preview.Tree.Name != "Petstore" || len(preview.Tree.Children) != 2 {
+		t.Fatalf("preview = %+v", preview)
+	}
+	if pets := preview.Tree.Children[1]; pets.ID != "0.0" || pets.Children[0].ID != "0.0:0" || pets.Children[0].Method != "GET" {
+		t.Errorf("pets = %+v", pets)
+	}
+	// Previewing creates nothing
+	ctx := context.Background()
+	if roots, _ := q.ListRootCollections(ctx, 1); len(roots) != 0 {
+		t.Errorf("preview created %d collections", len(roots))
+	}
+
+	// Only the chosen request, inside its folder, in a new root collection
+	resp, _ = postJSON(ts.URL+"/api/import/commit", `{"data":`+postman+`,"items":["0.0:0"]}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("commit: status = %d", resp.StatusCode)
+	}
+	var created handler.PostmanImportResponse
+	readJSON(t, resp, &created)
+	if created.Collection.Name != "Petstore" || created.Collections != 2 || created.Requests != 1 {
+		t.Errorf("created = %+v", created)
+	}
+	folders, _ := q.ListChildCollections(ctx, sql.NullInt64{Int64: created.Collection.ID, Valid: true})
+	if len(folders) != 1 {
+		t.Fatalf("folders = %+v", folders)
+	}
+	requests, _ := q.ListRequestsByCollection(ctx, sql.NullInt64{Int64: folders[0].ID, Valid: true})
+	if len(requests) != 1 || requests[0].Name != "List pets" {
+		t.Errorf("requests = %+v", requests)
+	}
+
+	// Into an existing collection, after what is already there
+	existing, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Mine", WorkspaceID: 1})
+	existingID := sql.NullInt64{Int64: existing.ID, Valid: true}
+	q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Old", Method: "GET", CollectionID: existingID, WorkspaceID: 1, SortOrder: 4})
+	resp, _ = postJSON(ts.URL+"/api/import/commit", fmt.Sprintf(`{"data":%s,"items":["0:0","0.0"],"collectionId":%d}`, postman, existing.ID))
+	var merged handler.PostmanImportResponse
+	readJSON(t, resp, &merged)
+	if merged.Collection.ID != existing.ID || merged.Collections != 1 || merged.Requests != 3 || len(merged.Warnings) != 1 {
+		t.Errorf("merged = %+v", merged)
+	}
+	requests, _ = q.ListRequestsByCollection(ctx, existingID)
+	if len(requests) != 2 || requests[1].Name != "Ping" || requests[1].SortOrder != 5 {
+		t.Errorf("existing collection requests = %+v", requests)
+	}
+	if vars, _ := q.GetCollectionVariables(ctx, existing.ID); vars.Valid && vars.String != "" && vars.String != "{}" {
+		t.Errorf("existing collection variables = %s", vars.String)
+	}
+
+	ws, _ := q.CreateWorkspace(ctx, "Other")
+	other, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Theirs", WorkspaceID: ws.ID})
+	for body, want := range map[string]int{
+		`{"format":"har","data":{}}`:               http.StatusBadRequest,
+		`{"data":` + postman + `}`:                 http.StatusBadRequest,
+		`{"data":` + postman + `,"items":["0.5"]}`: http.StatusBadRequest,
+		`{"items":["0"]}`:                          http.StatusBadRequest,
+		fmt.Sprintf(`{"data":%s,"items":["0"],"collectionId":%d}`, postman, other.ID): http.StatusNotFound,
+	} {
+		resp, _ := postJSON(ts.URL+"/api/import/commit", body)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%.60s: status = %d, want %d", body, resp.StatusCode, want)
+		}
+	}
+}
@@ -244,6 +244,35 @@ func TestIntegration_AdhocExecution(t *testing.T) {
 	}
 }
 
+func TestIntegration_AdhocPreScript(t *testing.T) {
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Signature") + "|" + r.Header.Get("X-Tenant")))
+	}))
+	defer mock.Close()
+
+	ts := setupTestServer(t, mock)
+
+	resp, err := postJSON(ts.URL+"/api/execute", fmt.Sprintf(`{
+		"method":"GET",
+		"url":"%s/signed",
+		"headers":"{\"X-Signature\":\"{{signature}}\",\"X-Tenant\":\"{{tenant}}\"}",
+		"variables":{"tenant":"acme"},
+		"preScript":"pm.variables.set('signature', 'sig-' + (40 + 2)); pm.variables.set('tenant', 'ignored');"
+	}`, mock.URL))
+	if err != nil {
+		t.Fatalf("adhoc execute: %v", err)
+	}
+	var result handler.RequestExecuteResponse
+	readJSON(t, resp, &result)
+
+	if result.ExecuteResult == nil || result.Body != "sig-42|acme" {
+		t.Fatalf("body = %+v", result.ExecuteResult)
+	}
+	if result.PreScriptResult == nil || !result.PreScriptResult.Success {
+		t.Errorf("pre-script result = %+v", result.PreScriptResult)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Test 3: Flow execution with variable extraction across steps
 // ---------------------------------------------------------------------------
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+type JobHandler struct {
+	queries *repository.Queries
+}
+
+func NewJobHandler(queries *repository.Queries) *JobHandler {
+	return &JobHandler{queries: queries}
+}
+
+type JobResponse struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int64           `json:"attempts"`
+	MaxAttempts int64           `json:"maxAttempts"`
+	RunAt       string          `json:"runAt"` // next attempt while queued
+	Result      json.RawMessage `json:"result,omitempty"`
+	LastError   string          `json:"lastError,omitempty"`
+	WorkspaceID *int64          `json:"workspaceId"` // nil for server-wide jobs
+	CreatedAt   string          `json:"createdAt"`
+	UpdatedAt   string          `json:"updatedAt"`
+	FinishedAt  string          `json:"finishedAt,omitempty"`
+	Key         string          `json:"key,omitempty"` // at most one queued or running job per key
+}
+
+func toJobResponse(job repository.Job) JobResponse {
+	resp := JobResponse{
+		ID:          job.ID,
+		Type:        job.Type,
+		Status:      job.Status,
+		Payload:     json.RawMessage(job.Payload),
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		RunAt:       time.UnixMilli(job.RunAt).UTC().Format(time.RFC3339),
+		LastError:   job.LastError.String,
+		CreatedAt:   formatTime(job.CreatedAt),
+		UpdatedAt:   formatTime(job.UpdatedAt),
+		FinishedAt:  formatTime(job.FinishedAt),
+		Key:         job.DedupeKey.String,
+	}
+	if job.Result.Valid {
+		resp.Result = json.RawMessage(job.Result.String)
+	}
+	if job.WorkspaceID.Valid {
+		wsID := job.WorkspaceID.Int64
+		resp.WorkspaceID = &wsID
+	}
+	return resp
+}
+
+// visibleJob loads the job if it belongs to the request's workspace or is
+// server-wide, writing the error response itself otherwise
+func (h *JobHandler) visibleJob(w http.ResponseWriter, r *http.Request) (repository.Job, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.Job{}, false
+	}
+	job, err := h.queries.GetJob(r.Context(), id)
+	if err != nil || (job.WorkspaceID.Valid && job.WorkspaceID.Int64 != middleware.GetWorkspaceID(r.Context())) {
+		respondError(w, http.StatusNotFound, "Job not found")
+		return repository.Job{}, false
+	}
+	return job, true
+}
+
+// List returns the latest 100 jobs, optionally filtered by ?status= and ?type=
+func (h *JobHandler) List(w http.ResponseWriter, r *http.Request) {
+	wsID := middleware.GetWorkspaceID(r.Context())
+	jobs, err := h.queries.ListJobs(r.Context(), repository.ListJobsParams{
+		WorkspaceID: sql.NullInt64{Int64: wsID, Valid: true},
+		Limit:       100,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	status, jobType := r.URL.Query().Get("status"), r.URL.Query().Get("type")
+	resp := make([]JobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		if (status != "" && job.Status != status) || (jobType != "" && job.Type != jobType) {
+			continue
+		}
+		resp = append(resp, toJobResponse(job))
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *JobHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.visibleJob(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, toJobResponse(job))
+}
+
+// Retry queues a failed or cancelled job again with its attempts reset
+func (h *JobHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.visibleJob(w, r)
+	if !ok {
+		return
+	}
+	job, err := h.queries.RetryJob(r.Context(), repository.RetryJobParams{RunAt: time.Now().UnixMilli(), ID: job.ID})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusConflict, "Only failed or cancelled jobs can be retried, and not while the same work is queued again")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toJobResponse(job))
+}
+
+// Cancel stops a job that has not started yet
+func (h *JobHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.visibleJob(w, r)
+	if !ok {
+		return
+	}
+	job, err := h.queries.CancelJob(r.Context(), job.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusConflict, "Only queued jobs can be cancelled")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toJobResponse(job))
+}
@@ -0,0 +1,89 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestJobsAPI(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	fs, err := service.NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := service.NewJobQueue(q)
+	jobs.Register(service.JobTypeFileCleanup, service.FileCleanupJob(db, q, fs))
+	fh := handler.NewFileHandler(db, q, fs, jobs)
+	jh := handler.NewJobHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/files/cleanup", fh.Cleanup)
+	r.Get("/api/jobs", jh.List)
+	r.Get("/api/jobs/{id}", jh.Get)
+	r.Post("/api/jobs/{id}/retry", jh.Retry)
+	r.Post("/api/jobs/{id}/cancel", jh.Cancel)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	// Async cleanup is queued and runs on a worker
+	resp, _ := postJSON(ts.URL+"/api/files/cleanup", `{"async":true,"dryRun":true}`)
+	var queued handler.JobResponse
+	readJSON(t, resp, &queued)
+	if resp.StatusCode != http.StatusAccepted || queued.Type != service.JobTypeFileCleanup || queued.Status != service.JobQueued || queued.WorkspaceID != nil {
+		t.Fatalf("status %d, job %+v", resp.StatusCode, queued)
+	}
+	jobs.RunNext(context.Background())
+
+	resp, _ = http.Get(fmt.Sprintf("%s/api/jobs/%d", ts.URL, queued.ID))
+	var done handler.JobResponse
+	readJSON(t, resp, &done)
+	if done.Status != service.JobSucceeded || len(done.Result) == 0 || done.FinishedAt == "" {
+		t.Errorf("finished job = %+v", done)
+	}
+
+	// A workspace job is hidden from other workspaces
+	wsJob, _ := jobs.Enqueue(context.Background(), service.JobTypeWebhook, service.WebhookJob{}, service.EnqueueOptions{WorkspaceID: 1})
+	ws2, _ := q.CreateWorkspace(context.Background(), "other")
+	resp, _ = getWithWorkspace(fmt.Sprintf("%s/api/jobs/%d", ts.URL, wsJob.ID), ws2.ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace got status %d", resp.StatusCode)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/jobs?status=queued")
+	var list []handler.JobResponse
+	readJSON(t, resp, &list)
+	if len(list) != 1 || list[0].ID != wsJob.ID {
+		t.Errorf("queued jobs = %+v", list)
+	}
+
+	// Cancel while queued, then retry
+	resp, _ = postJSON(fmt.Sprintf("%s/api/jobs/%d/cancel", ts.URL, wsJob.ID), "")
+	var cancelled handler.JobResponse
+	readJSON(t, resp, &cancelled)
+	if resp.StatusCode != http.StatusOK || cancelled.Status != service.JobCancelled {
+		t.Errorf("cancel: status %d, %+v", resp.StatusCode, cancelled)
+	}
+	resp, _ = postJSON(fmt.Sprintf("%s/api/jobs/%d/cancel", ts.URL, wsJob.ID), "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("second cancel: status %d", resp.StatusCode)
+	}
+	resp, _ = postJSON(fmt.Sprintf("%s/api/jobs/%d/retry", ts.URL, wsJob.ID), "")
+	var retried handler.JobResponse
+	readJSON(t, resp, &retried)
+	if resp.StatusCode != http.StatusOK || retried.Status != service.JobQueued || retried.Attempts != 0 {
+		t.Errorf("retry: status %d, %+v", resp.StatusCode, retried)
+	}
+}
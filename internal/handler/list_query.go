@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sorts by entity fields for ?sort= on the flow and environment lists,
+// alongside the usage sorts
+const (
+	listSortName      = "name"
+	listSortCreatedAt = "createdAt"
+	listSortUpdatedAt = "updatedAt"
+)
+
+const maxListLimit = 1000
+
+// listQuery is the ?q=, ?sort=, ?order=, ?limit= and ?offset= of a list.
+// A zero limit returns every item.
+type listQuery struct {
+	q      string
+	sortBy string
+	desc   bool
+	limit  int
+	offset int
+}
+
+// parseListQuery reads a list's filter, sort and page parameters, responding
+// 400 for a sort not in sorts or a bad page
+func parseListQuery(w http.ResponseWriter, r *http.Request, sorts ...string) (listQuery, bool) {
+	q := r.URL.Query()
+	lq := listQuery{q: strings.ToLower(strings.TrimSpace(q.Get("q")))}
+
+	var ok bool
+	if lq.sortBy, lq.desc, ok = listSortOrder(w, r, sorts...); !ok {
+		return lq, false
+	}
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > maxListLimit {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxListLimit))
+			return lq, false
+		}
+		lq.limit = n
+	}
+	if o := q.Get("offset"); o != "" {
+		n, err := strconv.Atoi(o)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "offset must be a non-negative number")
+			return lq, false
+		}
+		lq.offset = n
+	}
+	return lq, true
+}
+
+// listSortOrder reads ?sort= (one of sorts, or empty for the list's own
+// order) and ?order=asc|desc
+func listSortOrder(w http.ResponseWriter, r *http.Request, sorts ...string) (sortBy string, desc bool, ok bool) {
+	q := r.URL.Query()
+	sortBy = q.Get("sort")
+	if sortBy != "" && !slices.Contains(sorts, sortBy) {
+		respondError(w, http.StatusBadRequest, "sort must be one of "+strings.Join(sorts, ", "))
+		return "", false, false
+	}
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		desc = true
+	default:
+		respondError(w, http.StatusBadRequest, "order must be asc or desc")
+		return "", false, false
+	}
+	return sortBy, desc, true
+}
+
+// matches reports whether one of fields contains the ?q= text, ignoring case
+func (lq listQuery) matches(fields ...string) bool {
+	if lq.q == "" {
+		return true
+	}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), lq.q) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortByFields orders items by name (case-insensitive) or timestamps for the
+// entity field sorts; other sorts leave items as they are
+func sortByFields[T any](items []T, sortBy string, desc bool, fields func(T) (name string, createdAt, updatedAt sql.NullTime)) {
+	var less func(a, b T) bool
+	switch sortBy {
+	case listSortName:
+		less = func(a, b T) bool {
+			nameA, _, _ := fields(a)
+			nameB, _, _ := fields(b)
+			return strings.ToLower(nameA) < strings.ToLower(nameB)
+		}
+	case listSortCreatedAt, listSortUpdatedAt:
+		less = func(a, b T) bool {
+			_, createdA, updatedA := fields(a)
+			_, createdB, updatedB := fields(b)
+			if sortBy == listSortUpdatedAt {
+				return updatedA.Time.Before(updatedB.Time)
+			}
+			return createdA.Time.Before(createdB.Time)
+		}
+	default:
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if desc {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+}
+
+// paginate returns the requested page of items and reports the number of
+// matching items in the X-Total-Count header
+func paginate[T any](w http.ResponseWriter, items []T, lq listQuery) []T {
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(items)))
+	if lq.offset >= len(items) {
+		return items[:0]
+	}
+	items = items[lq.offset:]
+	if lq.limit > 0 && lq.limit < len(items) {
+		items = items[:lq.limit]
+	}
+	return items
+}
@@ -0,0 +1,83 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestListQuery_FlowsAndEnvironments(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	flowH := handler.NewFlowHandler(q, nil, db)
+	envH := handler.NewEnvironmentHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/flows", flowH.List)
+	r.Get("/api/environments", envH.List)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	flows := map[string]int64{}
+	for i, name := range []string{"Checkout", "login smoke", "Login full", "Signup"} {
+		f, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: name, WorkspaceID: 1, SortOrder: int64(i + 1)})
+		flows[name] = f.ID
+	}
+	q.CreateRunTimeline(ctx, repository.CreateRunTimelineParams{RunID: "run-1", WorkspaceID: 1, FlowID: flows["Login full"], Timeline: `{"success":true,"totalMs":10}`})
+	q.CreateRunTimeline(ctx, repository.CreateRunTimelineParams{RunID: "run-2", WorkspaceID: 1, FlowID: flows["Login full"], Timeline: `{"success":false,"totalMs":25,"startedAt":"2026-01-02T03:04:05Z"}`})
+	for _, name := range []string{"staging", "prod", "Preview"} {
+		q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: name, WorkspaceID: 1, Variables: sql.NullString{String: "{}", Valid: true}})
+	}
+
+	var page []handler.FlowResponse
+	resp, _ := http.Get(ts.URL + "/api/flows?q=LOGIN&sort=name&order=desc")
+	readJSON(t, resp, &page)
+	if resp.Header.Get("X-Total-Count") != "2" || len(page) != 2 || page[0].Name != "login smoke" || page[1].Name != "Login full" {
+		t.Fatalf("filtered flows = %+v", page)
+	}
+	if page[0].LastRun != nil || page[1].LastRun == nil || page[1].LastRun.RunID != "run-2" || page[1].LastRun.Success || page[1].LastRun.DurationMs != 25 || page[1].LastRun.StartedAt != "2026-01-02T03:04:05Z" {
+		t.Errorf("lastRun = %+v / %+v", page[0].LastRun, page[1].LastRun)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/flows?limit=2&offset=1")
+	readJSON(t, resp, &page)
+	if resp.Header.Get("X-Total-Count") != "4" || len(page) != 2 || page[0].Name != "login smoke" || page[1].Name != "Login full" {
+		t.Errorf("page = %+v", page)
+	}
+	resp, _ = http.Get(ts.URL + "/api/flows?offset=10")
+	readJSON(t, resp, &page)
+	if len(page) != 0 {
+		t.Errorf("past the end = %+v", page)
+	}
+
+	var envs []handler.EnvironmentResponse
+	resp, _ = http.Get(ts.URL + "/api/environments?q=p&sort=name")
+	readJSON(t, resp, &envs)
+	if resp.Header.Get("X-Total-Count") != "2" || len(envs) != 2 || envs[0].Name != "Preview" || envs[1].Name != "prod" {
+		t.Errorf("environments = %+v", envs)
+	}
+
+	for _, query := range []string{
+		"/api/flows?limit=0",
+		"/api/flows?limit=1001",
+		"/api/flows?offset=-1",
+		"/api/flows?sort=size",
+		"/api/environments?sort=executions",
+	} {
+		resp, _ = http.Get(ts.URL + query)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, resp.StatusCode)
+		}
+	}
+}
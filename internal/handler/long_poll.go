@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"relay/internal/service"
+)
+
+// LongPollExecuteRequest is an ExecuteRequest run in long-polling mode
+type LongPollExecuteRequest struct {
+	ExecuteRequest
+	LongPoll service.LongPollOptions `json:"longPoll"`
+}
+
+// LongPollAdhocRequest is an AdhocExecuteRequest run in long-polling mode
+type LongPollAdhocRequest struct {
+	AdhocExecuteRequest
+	LongPoll service.LongPollOptions `json:"longPoll"`
+}
+
+// ExecuteStream runs a saved request in long-polling mode: the timeout is
+// extended (5 minutes by default, up to 30) and the result is streamed as
+// server-sent events, "progress" every heartbeat while the response is
+// pending, then "complete" with the usual execution response.
+func (h *RequestHandler) ExecuteStream(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, executionQuotas...) {
+		return
+	}
+
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var execReq LongPollExecuteRequest
+	decodeJSON(r, &execReq) // OK if empty
+	if err := execReq.LongPoll.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx, overrides, ok := h.executeOptions(w, r, id, execReq.ExecuteRequest)
+	if !ok {
+		return
+	}
+
+	streamLongPoll(ctx, w, execReq.LongPoll, func(ctx context.Context) (any, error) {
+		return h.executeSaved(ctx, id, execReq.Variables, overrides)
+	})
+}
+
+// ExecuteAdhocStream is ExecuteStream for an unsaved request
+func (h *RequestHandler) ExecuteAdhocStream(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, executionQuotas...) {
+		return
+	}
+
+	var reqBody LongPollAdhocRequest
+	if err := decodeJSON(r, &reqBody); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if reqBody.URL == "" {
+		respondError(w, http.StatusBadRequest, "URL is required")
+		return
+	}
+	if reqBody.Method == "" {
+		reqBody.Method = "GET"
+	}
+	if err := reqBody.LongPoll.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	streamLongPoll(r.Context(), w, reqBody.LongPoll, func(ctx context.Context) (any, error) {
+		return h.executeAdhoc(ctx, reqBody.AdhocExecuteRequest)
+	})
+}
+
+// streamLongPoll runs execute in long-polling mode, writing its progress and
+// result (or {error}) as server-sent events
+func streamLongPoll(ctx context.Context, w http.ResponseWriter, opts service.LongPollOptions, execute func(context.Context) (any, error)) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondError(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var mu sync.Mutex
+	writeSSE := func(event string, data any) {
+		jsonData, _ := json.Marshal(data)
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, jsonData)
+		flusher.Flush()
+	}
+
+	ctx = service.WithLongPoll(ctx, opts, func(p service.LongPollProgress) {
+		writeSSE("progress", p)
+	})
+	result, err := execute(ctx)
+	if err != nil {
+		writeSSE("error", map[string]string{"error": err.Error()})
+		return
+	}
+	writeSSE("complete", result)
+}
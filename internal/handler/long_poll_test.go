@@ -0,0 +1,98 @@
+package handler_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// readSSE returns the events of a server-sent event stream in order
+func readSSE(t *testing.T, resp *http.Response) (names []string, data []string) {
+	t.Helper()
+	defer resp.Body.Close()
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			names = append(names, name)
+		} else if d, ok := strings.CutPrefix(line, "data: "); ok {
+			data = append(data, d)
+		}
+	}
+	return names, data
+}
+
+func TestExecuteStream_LongPoll(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	defer api.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	fr := service.NewFlowRunner(q, re, vr)
+	reqH := handler.NewRequestHandler(q, re, fr)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/requests", reqH.Create)
+	r.Post("/api/requests/{id}/execute/stream", reqH.ExecuteStream)
+	r.Post("/api/execute/stream", reqH.ExecuteAdhocStream)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	var saved handler.RequestResponse
+	resp, _ := postJSON(ts.URL+"/api/requests", fmt.Sprintf(`{"name":"Poll","method":"GET","url":%q}`, api.URL+"/{{topic}}"))
+	readJSON(t, resp, &saved)
+
+	resp, err := postJSON(fmt.Sprintf("%s/api/requests/%d/execute/stream", ts.URL, saved.ID), `{"variables":{"topic":"events"},"longPoll":{"timeoutSeconds":600}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type = %q", ct)
+	}
+	names, data := readSSE(t, resp)
+	if len(names) != 1 || names[0] != "complete" {
+		t.Fatalf("events = %v", names)
+	}
+	var result handler.RequestExecuteResponse
+	json.Unmarshal([]byte(data[0]), &result)
+	if result.ExecuteResult == nil || result.ExecuteResult.Body != `{"path":"/events"}` {
+		t.Errorf("saved result = %s", data[0])
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/execute/stream", fmt.Sprintf(`{"url":%q}`, api.URL+"/adhoc"))
+	names, data = readSSE(t, resp)
+	var adhoc service.ExecuteResult
+	if len(names) == 1 && names[0] == "complete" {
+		json.Unmarshal([]byte(data[0]), &adhoc)
+	}
+	if adhoc.Body != `{"path":"/adhoc"}` || adhoc.StatusCode != 200 {
+		t.Errorf("ad-hoc events = %v %v", names, data)
+	}
+
+	for path, body := range map[string]string{
+		fmt.Sprintf("/api/requests/%d/execute/stream", saved.ID): `{"longPoll":{"timeoutSeconds":3600}}`,
+		"/api/execute/stream": fmt.Sprintf(`{"url":%q,"longPoll":{"heartbeatSeconds":-1}}`, api.URL),
+	} {
+		resp, _ = postJSON(ts.URL+path, body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s %s: status = %d, want 400", path, body, resp.StatusCode)
+		}
+	}
+}
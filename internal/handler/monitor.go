@@ -0,0 +1,386 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// Monitor dashboard statuses
+const (
+	monitorStatusUp      = "up"
+	monitorStatusDown    = "down"
+	monitorStatusPending = "pending" // no checks yet
+	monitorStatusPaused  = "paused"
+	monitorStatusOffline = "offline" // network unreachable, checks held back
+)
+
+type MonitorHandler struct {
+	queries *repository.Queries
+	runner  *service.MonitorRunner
+}
+
+func NewMonitorHandler(queries *repository.Queries, runner *service.MonitorRunner) *MonitorHandler {
+	return &MonitorHandler{queries: queries, runner: runner}
+}
+
+type CreateMonitorRequest struct {
+	RequestID       int64  `json:"requestId"`
+	IntervalSeconds int64  `json:"intervalSeconds"`
+	Enabled         *bool  `json:"enabled"`
+	MaxQueueSeconds *int64 `json:"maxQueueSeconds"`
+}
+
+type UpdateMonitorRequest struct {
+	IntervalSeconds *int64 `json:"intervalSeconds"`
+	Enabled         *bool  `json:"enabled"`
+	MaxQueueSeconds *int64 `json:"maxQueueSeconds"`
+}
+
+type MonitorCheckResponse struct {
+	ID             int64  `json:"id"`
+	StatusCode     int64  `json:"statusCode"`
+	DurationMs     int64  `json:"durationMs"`
+	Success        bool   `json:"success"`
+	Error          string `json:"error,omitempty"`
+	CheckedAt      string `json:"checkedAt"`
+	OfflineSeconds int64  `json:"offlineSeconds,omitempty"` // > 0: gap while the network was unreachable
+}
+
+// MonitorResponse is a monitor with its dashboard summary over the last 24 hours
+type MonitorResponse struct {
+	ID              int64                 `json:"id"`
+	RequestID       int64                 `json:"requestId"`
+	Name            string                `json:"name"`
+	Method          string                `json:"method"`
+	URL             string                `json:"url"`
+	IntervalSeconds int64                 `json:"intervalSeconds"`
+	Enabled         bool                  `json:"enabled"`
+	MaxQueueSeconds int64                 `json:"maxQueueSeconds"`
+	OfflineSince    string                `json:"offlineSince,omitempty"`
+	Status          string                `json:"status"`
+	LastCheck       *MonitorCheckResponse `json:"lastCheck,omitempty"`
+	Checks24h       int64                 `json:"checks24h"`
+	Uptime24h       *float64              `json:"uptime24h"` // percent, null without checks
+	AvgLatencyMs24h int64                 `json:"avgLatencyMs24h"`
+	MaxLatencyMs24h int64                 `json:"maxLatencyMs24h"`
+	CreatedAt       string                `json:"createdAt"`
+}
+
+func toMonitorCheckResponse(c repository.MonitorCheck) MonitorCheckResponse {
+	return MonitorCheckResponse{
+		ID:             c.ID,
+		StatusCode:     c.StatusCode,
+		DurationMs:     c.DurationMs,
+		Success:        c.Success == 1,
+		Error:          c.Error,
+		CheckedAt:      formatTime(c.CheckedAt),
+		OfflineSeconds: c.OfflineSeconds,
+	}
+}
+
+func (h *MonitorHandler) toMonitorResponse(r *http.Request, m repository.Monitor) (MonitorResponse, error) {
+	ctx := r.Context()
+	resp := MonitorResponse{
+		ID:              m.ID,
+		RequestID:       m.RequestID,
+		IntervalSeconds: m.IntervalSeconds,
+		Enabled:         m.Enabled == 1,
+		MaxQueueSeconds: m.MaxQueueSeconds,
+		OfflineSince:    formatTime(m.OfflineSince),
+		Status:          monitorStatusPending,
+		CreatedAt:       formatTime(m.CreatedAt),
+	}
+
+	req, err := h.queries.GetRequest(ctx, m.RequestID)
+	if err != nil {
+		return resp, err
+	}
+	resp.Name, resp.Method, resp.URL = req.Name, req.Method, req.Url
+
+	last, err := h.queries.GetLastMonitorResult(ctx, m.ID)
+	if err == nil {
+		lc := toMonitorCheckResponse(last)
+		resp.LastCheck = &lc
+		resp.Status = monitorStatusDown
+		if lc.Success {
+			resp.Status = monitorStatusUp
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return resp, err
+	}
+	if m.OfflineSince.Valid {
+		resp.Status = monitorStatusOffline
+	}
+	if !resp.Enabled {
+		resp.Status = monitorStatusPaused
+	}
+
+	stats, err := h.queries.GetMonitorStats(ctx, m.ID)
+	if err != nil {
+		return resp, err
+	}
+	resp.Checks24h = stats.Checks
+	resp.AvgLatencyMs24h = int64(math.Round(stats.AvgDurationMs))
+	resp.MaxLatencyMs24h = stats.MaxDurationMs
+	if stats.Checks > 0 {
+		uptime := math.Round(float64(stats.Successes)/float64(stats.Checks)*10000) / 100
+		resp.Uptime24h = &uptime
+	}
+	return resp, nil
+}
+
+func validMonitorInterval(seconds int64) error {
+	if seconds < service.MinMonitorInterval || seconds > service.MaxMonitorInterval {
+		return fmt.Errorf("intervalSeconds must be between %d and %d", service.MinMonitorInterval, service.MaxMonitorInterval)
+	}
+	return nil
+}
+
+func validMonitorMaxQueue(seconds int64) error {
+	if seconds < 0 || seconds > service.MaxMonitorMaxQueue {
+		return fmt.Errorf("maxQueueSeconds must be between 0 and %d", service.MaxMonitorMaxQueue)
+	}
+	return nil
+}
+
+// List returns status summaries of the workspace's monitors
+func (h *MonitorHandler) List(w http.ResponseWriter, r *http.Request) {
+	wsID := middleware.GetWorkspaceID(r.Context())
+	monitors, err := h.queries.ListMonitors(r.Context(), wsID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]MonitorResponse, 0, len(monitors))
+	for _, m := range monitors {
+		mr, err := h.toMonitorResponse(r, m)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp = append(resp, mr)
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *MonitorHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	m, err := h.queries.GetMonitor(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Monitor not found")
+		return
+	}
+
+	resp, err := h.toMonitorResponse(r, m)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Create marks a saved request as a monitor
+func (h *MonitorHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateMonitorRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.IntervalSeconds == 0 {
+		req.IntervalSeconds = 60
+	}
+	if err := validMonitorInterval(req.IntervalSeconds); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	maxQueue := int64(service.DefaultMonitorMaxQueue)
+	if req.MaxQueueSeconds != nil {
+		if err := validMonitorMaxQueue(*req.MaxQueueSeconds); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		maxQueue = *req.MaxQueueSeconds
+	}
+
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+	target, err := h.queries.GetRequest(ctx, req.RequestID)
+	if err != nil || target.WorkspaceID != wsID {
+		respondError(w, http.StatusNotFound, "Request not found")
+		return
+	}
+	if target.Method == "WS" {
+		respondError(w, http.StatusBadRequest, "WebSocket requests cannot be monitored")
+		return
+	}
+	if _, err := h.queries.GetMonitorByRequest(ctx, target.ID); err == nil {
+		respondError(w, http.StatusConflict, "Request is already monitored")
+		return
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	enabled := int64(1)
+	if req.Enabled != nil && !*req.Enabled {
+		enabled = 0
+	}
+	m, err := h.queries.CreateMonitor(ctx, repository.CreateMonitorParams{
+		WorkspaceID:     wsID,
+		RequestID:       target.ID,
+		IntervalSeconds: req.IntervalSeconds,
+		Enabled:         enabled,
+		MaxQueueSeconds: maxQueue,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp, err := h.toMonitorResponse(r, m)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, resp)
+}
+
+// Update changes the interval or offline queue age, or pauses/resumes a monitor
+func (h *MonitorHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req UpdateMonitorRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	m, err := h.queries.GetMonitor(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Monitor not found")
+		return
+	}
+
+	params := repository.UpdateMonitorParams{ID: m.ID, IntervalSeconds: m.IntervalSeconds, Enabled: m.Enabled, MaxQueueSeconds: m.MaxQueueSeconds}
+	if req.IntervalSeconds != nil {
+		if err := validMonitorInterval(*req.IntervalSeconds); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		params.IntervalSeconds = *req.IntervalSeconds
+	}
+	if req.MaxQueueSeconds != nil {
+		if err := validMonitorMaxQueue(*req.MaxQueueSeconds); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		params.MaxQueueSeconds = *req.MaxQueueSeconds
+	}
+	if req.Enabled != nil {
+		params.Enabled = 0
+		if *req.Enabled {
+			params.Enabled = 1
+		}
+	}
+
+	m, err = h.queries.UpdateMonitor(r.Context(), params)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp, err := h.toMonitorResponse(r, m)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *MonitorHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.queries.DeleteMonitor(r.Context(), id); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Checks returns the most recent status/latency points, newest first (?limit=, default 100)
+func (h *MonitorHandler) Checks(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	limit := int64(100)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	if _, err := h.queries.GetMonitor(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "Monitor not found")
+		return
+	}
+
+	checks, err := h.queries.ListMonitorChecks(r.Context(), repository.ListMonitorChecksParams{MonitorID: id, Limit: limit})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := make([]MonitorCheckResponse, 0, len(checks))
+	for _, c := range checks {
+		resp = append(resp, toMonitorCheckResponse(c))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Run checks the monitor immediately, independent of its schedule
+func (h *MonitorHandler) Run(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	m, err := h.queries.GetMonitor(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Monitor not found")
+		return
+	}
+
+	check, err := h.runner.Check(r.Context(), m)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toMonitorCheckResponse(check))
+}
@@ -0,0 +1,141 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupMonitorTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	q := testutil.SetupTestDB(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+
+	reqH := handler.NewRequestHandler(q, re, nil)
+	monH := handler.NewMonitorHandler(q, service.NewMonitorRunner(q, re, nil))
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/requests", reqH.Create)
+	r.Get("/api/monitors", monH.List)
+	r.Post("/api/monitors", monH.Create)
+	r.Get("/api/monitors/{id}", monH.Get)
+	r.Put("/api/monitors/{id}", monH.Update)
+	r.Delete("/api/monitors/{id}", monH.Delete)
+	r.Get("/api/monitors/{id}/checks", monH.Checks)
+	r.Post("/api/monitors/{id}/run", monH.Run)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestMonitors_Dashboard(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer api.Close()
+	ts := setupMonitorTestServer(t)
+
+	resp, _ := postJSON(ts.URL+"/api/requests", fmt.Sprintf(`{"name":"Health","method":"GET","url":%q}`, api.URL))
+	var req handler.RequestResponse
+	readJSON(t, resp, &req)
+
+	resp, err := postJSON(ts.URL+"/api/monitors", fmt.Sprintf(`{"requestId":%d,"intervalSeconds":30}`, req.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status %d", resp.StatusCode)
+	}
+	var mon handler.MonitorResponse
+	readJSON(t, resp, &mon)
+	if mon.Status != "pending" || mon.Name != "Health" || mon.IntervalSeconds != 30 || mon.MaxQueueSeconds != 900 || mon.Uptime24h != nil {
+		t.Errorf("new monitor = %+v", mon)
+	}
+
+	resp, _ = postJSON(fmt.Sprintf("%s/api/monitors/%d/run", ts.URL, mon.ID), `{}`)
+	var check handler.MonitorCheckResponse
+	readJSON(t, resp, &check)
+	if !check.Success || check.StatusCode != 200 {
+		t.Errorf("check = %+v", check)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/monitors")
+	var list []handler.MonitorResponse
+	readJSON(t, resp, &list)
+	if len(list) != 1 || list[0].Status != "up" || list[0].Checks24h != 1 || list[0].Uptime24h == nil || *list[0].Uptime24h != 100 {
+		t.Fatalf("dashboard = %+v", list)
+	}
+
+	resp, _ = http.Get(fmt.Sprintf("%s/api/monitors/%d/checks", ts.URL, mon.ID))
+	var checks []handler.MonitorCheckResponse
+	readJSON(t, resp, &checks)
+	if len(checks) != 1 || checks[0].ID != check.ID {
+		t.Errorf("checks = %+v", checks)
+	}
+
+	resp, _ = putJSON(fmt.Sprintf("%s/api/monitors/%d", ts.URL, mon.ID), `{"enabled":false,"maxQueueSeconds":0}`)
+	readJSON(t, resp, &mon)
+	if mon.Enabled || mon.Status != "paused" || mon.IntervalSeconds != 30 || mon.MaxQueueSeconds != 0 {
+		t.Errorf("paused monitor = %+v", mon)
+	}
+
+	req2, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/monitors/%d", ts.URL, mon.ID), nil)
+	resp, _ = http.DefaultClient.Do(req2)
+	resp.Body.Close()
+	resp, _ = http.Get(fmt.Sprintf("%s/api/monitors/%d", ts.URL, mon.ID))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("deleted monitor: expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestMonitors_CreateValidation(t *testing.T) {
+	ts := setupMonitorTestServer(t)
+
+	resp, _ := postJSON(ts.URL+"/api/requests", `{"name":"API","method":"GET","url":"http://localhost"}`)
+	var req handler.RequestResponse
+	readJSON(t, resp, &req)
+	resp, _ = postJSON(ts.URL+"/api/requests", `{"name":"Socket","method":"WS","url":"ws://localhost"}`)
+	var ws handler.RequestResponse
+	readJSON(t, resp, &ws)
+
+	resp, _ = postJSON(ts.URL+"/api/monitors", fmt.Sprintf(`{"requestId":%d}`, req.ID))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status %d", resp.StatusCode)
+	}
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"duplicate", fmt.Sprintf(`{"requestId":%d}`, req.ID), http.StatusConflict},
+		{"interval too short", fmt.Sprintf(`{"requestId":%d,"intervalSeconds":5}`, ws.ID), http.StatusBadRequest},
+		{"negative queue age", fmt.Sprintf(`{"requestId":%d,"maxQueueSeconds":-1}`, ws.ID), http.StatusBadRequest},
+		{"websocket", fmt.Sprintf(`{"requestId":%d}`, ws.ID), http.StatusBadRequest},
+		{"unknown request", `{"requestId":999}`, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		resp, err := postJSON(ts.URL+"/api/monitors", tc.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, resp.StatusCode)
+		}
+	}
+}
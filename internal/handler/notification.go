@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type NotificationHandler struct {
+	queries  *repository.Queries
+	notifier *service.EmailNotifier
+}
+
+func NewNotificationHandler(queries *repository.Queries, notifier *service.EmailNotifier) *NotificationHandler {
+	return &NotificationHandler{queries: queries, notifier: notifier}
+}
+
+type TestEmailRequest struct {
+	To string `json:"to"` // defaults to the workspace's notification emails
+}
+
+type DigestResponse struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Sent    bool   `json:"sent"`
+}
+
+func (h *NotificationHandler) respondSendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrEmailNotConfigured):
+		respondError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	case errors.Is(err, service.ErrNoRecipients):
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	respondError(w, http.StatusBadGateway, err.Error())
+}
+
+// TestEmail sends a test message to check the SMTP configuration
+func (h *NotificationHandler) TestEmail(w http.ResponseWriter, r *http.Request) {
+	var req TestEmailRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if !h.notifier.Enabled() {
+		respondError(w, http.StatusServiceUnavailable, service.ErrEmailNotConfigured.Error())
+		return
+	}
+
+	to := []string{req.To}
+	if req.To == "" {
+		raw, err := h.queries.GetWorkspaceSettings(r.Context(), middleware.GetWorkspaceID(r.Context()))
+		if err != nil {
+			respondError(w, http.StatusNotFound, "Workspace not found")
+			return
+		}
+		to = service.ParseWorkspaceSettings(raw).Notifications.Emails
+		if len(to) == 0 {
+			respondError(w, http.StatusBadRequest, service.ErrNoRecipients.Error())
+			return
+		}
+	} else if _, err := mail.ParseAddress(req.To); err != nil {
+		respondError(w, http.StatusBadRequest, "invalid email address")
+		return
+	}
+
+	if err := h.notifier.Send(to, "[Relay] Test email", "Email notifications are configured correctly.\n"); err != nil {
+		h.respondSendError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"sent": true, "to": to})
+}
+
+// Digest previews the current workspace's weekly digest
+func (h *NotificationHandler) Digest(w http.ResponseWriter, r *http.Request) {
+	subject, body, err := h.notifier.WeeklyDigest(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, DigestResponse{Subject: subject, Body: body})
+}
+
+// SendDigest emails the weekly digest now, outside the Monday schedule
+func (h *NotificationHandler) SendDigest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+	subject, body, err := h.notifier.WeeklyDigest(ctx, wsID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := h.notifier.SendWeeklyDigest(ctx, wsID); err != nil {
+		h.respondSendError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, DigestResponse{Subject: subject, Body: body, Sent: true})
+}
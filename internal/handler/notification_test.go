@@ -0,0 +1,50 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNotifications_WithoutSMTP(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	h := handler.NewNotificationHandler(q, service.NewEmailNotifier(q, service.SMTPConfig{}))
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/notifications/email/test", h.TestEmail)
+	r.Get("/api/notifications/digest", h.Digest)
+	r.Post("/api/notifications/digest", h.SendDigest)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	// Preview works without SMTP
+	resp, err := http.Get(ts.URL + "/api/notifications/digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var digest handler.DigestResponse
+	readJSON(t, resp, &digest)
+	if digest.Sent || !strings.Contains(digest.Body, "Executions: 0") {
+		t.Errorf("digest preview = %+v", digest)
+	}
+
+	for _, path := range []string{"/api/notifications/email/test", "/api/notifications/digest"} {
+		resp, err := postJSON(ts.URL+path, `{}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected 503, got %d", path, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type OAuth2Handler struct {
+	queries *repository.Queries
+	tokens  *service.OAuth2Tokens
+}
+
+func NewOAuth2Handler(queries *repository.Queries, tokens *service.OAuth2Tokens) *OAuth2Handler {
+	return &OAuth2Handler{queries: queries, tokens: tokens}
+}
+
+// OAuth2ConfigRequest creates or replaces a config. Credentials may use
+// {{variables}}; a null clientSecret or password keeps the stored one.
+type OAuth2ConfigRequest struct {
+	Name          string  `json:"name"`
+	GrantType     string  `json:"grantType"` // client_credentials | password
+	TokenURL      string  `json:"tokenUrl"`
+	ClientID      string  `json:"clientId"`
+	ClientSecret  *string `json:"clientSecret"`
+	ClientAuth    string  `json:"clientAuth"` // basic (default) | body
+	Username      string  `json:"username"`
+	Password      *string `json:"password"`
+	Scope         string  `json:"scope"`
+	EnvironmentID *int64  `json:"environmentId"` // null: the active environment when fetching
+	TokenVariable string  `json:"tokenVariable"` // default accessToken
+	AutoRefresh   *bool   `json:"autoRefresh"`   // default true
+}
+
+// OAuth2ConfigResponse omits the client secret, password and refresh token
+type OAuth2ConfigResponse struct {
+	ID              int64  `json:"id"`
+	Name            string `json:"name"`
+	GrantType       string `json:"grantType"`
+	TokenURL        string `json:"tokenUrl"`
+	ClientID        string `json:"clientId"`
+	HasClientSecret bool   `json:"hasClientSecret"`
+	ClientAuth      string `json:"clientAuth"`
+	Username        string `json:"username,omitempty"`
+	HasPassword     bool   `json:"hasPassword"`
+	Scope           string `json:"scope,omitempty"`
+	EnvironmentID   *int64 `json:"environmentId"`
+	TokenVariable   string `json:"tokenVariable"`
+	AutoRefresh     bool   `json:"autoRefresh"`
+	HasRefreshToken bool   `json:"hasRefreshToken"`
+	ExpiresAt       string `json:"expiresAt,omitempty"`
+	CreatedAt       string `json:"createdAt"`
+	UpdatedAt       string `json:"updatedAt"`
+}
+
+func toOAuth2ConfigResponse(c repository.Oauth2Config) OAuth2ConfigResponse {
+	resp := OAuth2ConfigResponse{
+		ID:              c.ID,
+		Name:            c.Name,
+		GrantType:       c.GrantType,
+		TokenURL:        c.TokenUrl,
+		ClientID:        c.ClientID,
+		HasClientSecret: c.ClientSecret != "",
+		ClientAuth:      c.ClientAuth,
+		Username:        c.Username,
+		HasPassword:     c.Password != "",
+		Scope:           c.Scope,
+		TokenVariable:   c.TokenVariable,
+		AutoRefresh:     c.AutoRefresh != 0,
+		HasRefreshToken: c.RefreshToken != "",
+		ExpiresAt:       formatTime(c.ExpiresAt),
+		CreatedAt:       formatTime(c.CreatedAt),
+		UpdatedAt:       formatTime(c.UpdatedAt),
+	}
+	if c.EnvironmentID.Valid {
+		id := c.EnvironmentID.Int64
+		resp.EnvironmentID = &id
+	}
+	return resp
+}
+
+func (h *OAuth2Handler) List(w http.ResponseWriter, r *http.Request) {
+	configs, err := h.queries.ListOAuth2Configs(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]OAuth2ConfigResponse, 0, len(configs))
+	for _, c := range configs {
+		resp = append(resp, toOAuth2ConfigResponse(c))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *OAuth2Handler) Get(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.config(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, toOAuth2ConfigResponse(c))
+}
+
+func (h *OAuth2Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req OAuth2ConfigRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	params, ok := h.params(w, r, req, repository.Oauth2Config{})
+	if !ok {
+		return
+	}
+
+	c, err := h.queries.CreateOAuth2Config(r.Context(), repository.CreateOAuth2ConfigParams{
+		WorkspaceID:   middleware.GetWorkspaceID(r.Context()),
+		Name:          params.Name,
+		GrantType:     params.GrantType,
+		TokenUrl:      params.TokenUrl,
+		ClientID:      params.ClientID,
+		ClientSecret:  params.ClientSecret,
+		ClientAuth:    params.ClientAuth,
+		Username:      params.Username,
+		Password:      params.Password,
+		Scope:         params.Scope,
+		EnvironmentID: params.EnvironmentID,
+		TokenVariable: params.TokenVariable,
+		AutoRefresh:   params.AutoRefresh,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, toOAuth2ConfigResponse(c))
+}
+
+// Update replaces a config; the stored token's expiry and refresh token are
+// dropped since they belong to the old settings
+func (h *OAuth2Handler) Update(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.config(w, r)
+	if !ok {
+		return
+	}
+	var req OAuth2ConfigRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	params, ok := h.params(w, r, req, existing)
+	if !ok {
+		return
+	}
+	params.ID = existing.ID
+
+	c, err := h.queries.UpdateOAuth2Config(r.Context(), params)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toOAuth2ConfigResponse(c))
+}
+
+func (h *OAuth2Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.config(w, r)
+	if !ok {
+		return
+	}
+	if err := h.queries.DeleteOAuth2Config(r.Context(), c.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FetchToken runs the config's grant now and writes the access token to its
+// environment variable
+func (h *OAuth2Handler) FetchToken(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.config(w, r)
+	if !ok {
+		return
+	}
+	token, err := h.tokens.Fetch(r.Context(), c)
+	if err != nil {
+		if errors.Is(err, service.ErrNoTokenEnvironment) {
+			respondError(w, http.StatusConflict, err.Error())
+			return
+		}
+		respondError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, token)
+}
+
+// config loads the {id} config of the current workspace, responding 400/404
+func (h *OAuth2Handler) config(w http.ResponseWriter, r *http.Request) (repository.Oauth2Config, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.Oauth2Config{}, false
+	}
+	c, err := h.queries.GetOAuth2Config(r.Context(), id)
+	if err != nil || c.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "OAuth2 config not found")
+		return repository.Oauth2Config{}, false
+	}
+	return c, true
+}
+
+// params validates req, filling omitted secrets from existing
+func (h *OAuth2Handler) params(w http.ResponseWriter, r *http.Request, req OAuth2ConfigRequest, existing repository.Oauth2Config) (repository.UpdateOAuth2ConfigParams, bool) {
+	if req.Name == "" || req.TokenURL == "" {
+		respondError(w, http.StatusBadRequest, "name and tokenUrl are required")
+		return repository.UpdateOAuth2ConfigParams{}, false
+	}
+	if err := service.ValidateOAuth2Grant(req.GrantType, req.ClientAuth); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return repository.UpdateOAuth2ConfigParams{}, false
+	}
+	if req.GrantType == "password" && req.Username == "" {
+		respondError(w, http.StatusBadRequest, "username is required for the password grant")
+		return repository.UpdateOAuth2ConfigParams{}, false
+	}
+
+	p := repository.UpdateOAuth2ConfigParams{
+		Name:          req.Name,
+		GrantType:     req.GrantType,
+		TokenUrl:      req.TokenURL,
+		ClientID:      req.ClientID,
+		ClientSecret:  existing.ClientSecret,
+		ClientAuth:    req.ClientAuth,
+		Username:      req.Username,
+		Password:      existing.Password,
+		Scope:         req.Scope,
+		TokenVariable: req.TokenVariable,
+		AutoRefresh:   1,
+	}
+	if req.ClientSecret != nil {
+		p.ClientSecret = *req.ClientSecret
+	}
+	if req.Password != nil {
+		p.Password = *req.Password
+	}
+	if p.ClientAuth == "" {
+		p.ClientAuth = "basic"
+	}
+	if p.TokenVariable == "" {
+		p.TokenVariable = "accessToken"
+	}
+	if req.AutoRefresh != nil && !*req.AutoRefresh {
+		p.AutoRefresh = 0
+	}
+	if req.EnvironmentID != nil {
+		env, err := h.queries.GetEnvironment(r.Context(), *req.EnvironmentID)
+		if err != nil || env.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+			respondError(w, http.StatusBadRequest, "environmentId must be an environment of the workspace")
+			return repository.UpdateOAuth2ConfigParams{}, false
+		}
+		p.EnvironmentID = sql.NullInt64{Int64: env.ID, Valid: true}
+	}
+	return p, true
+}
@@ -0,0 +1,148 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOAuth2_ConfigsAndFetchToken(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "cli" || pass != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"at-1","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	q := testutil.SetupTestDB(t)
+	h := handler.NewOAuth2Handler(q, service.NewOAuth2Tokens(q, service.NewVariableResolver(q)))
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/oauth2/configs", h.List)
+	r.Post("/api/oauth2/configs", h.Create)
+	r.Get("/api/oauth2/configs/{id}", h.Get)
+	r.Put("/api/oauth2/configs/{id}", h.Update)
+	r.Delete("/api/oauth2/configs/{id}", h.Delete)
+	r.Post("/api/oauth2/configs/{id}/fetch-token", h.FetchToken)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	ctx := context.Background()
+
+	for _, body := range []string{
+		`{"name":"api","grantType":"implicit","tokenUrl":"http://x"}`,
+		`{"name":"api","grantType":"password","tokenUrl":"http://x"}`,
+		`{"name":"api","grantType":"client_credentials","tokenUrl":"http://x","clientAuth":"jwt"}`,
+		`{"grantType":"client_credentials","tokenUrl":"http://x"}`,
+	} {
+		resp, _ := postJSON(ts.URL+"/api/oauth2/configs", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	resp, _ := postJSON(ts.URL+"/api/oauth2/configs", fmt.Sprintf(
+		`{"name":"api","grantType":"client_credentials","tokenUrl":%q,"clientId":"cli","clientSecret":"s3cret"}`, tokenServer.URL))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status = %d", resp.StatusCode)
+	}
+	var created handler.OAuth2ConfigResponse
+	readJSON(t, resp, &created)
+	if !created.HasClientSecret || created.ClientAuth != "basic" || created.TokenVariable != "accessToken" || !created.AutoRefresh {
+		t.Errorf("created = %+v", created)
+	}
+	configURL := fmt.Sprintf("%s/api/oauth2/configs/%d", ts.URL, created.ID)
+
+	resp, _ = http.Get(configURL)
+	var raw map[string]any
+	json.NewDecoder(resp.Body).Decode(&raw)
+	resp.Body.Close()
+	if _, ok := raw["clientSecret"]; ok {
+		t.Error("response exposes the client secret")
+	}
+
+	// No active environment to write the token to
+	resp, _ = postJSON(configURL+"/fetch-token", `{}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("fetch without environment: status = %d, want 409", resp.StatusCode)
+	}
+
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "dev", WorkspaceID: 1})
+	q.ActivateEnvironment(ctx, env.ID)
+	resp, _ = postJSON(configURL+"/fetch-token", `{}`)
+	var tok service.OAuth2Token
+	readJSON(t, resp, &tok)
+	if tok.EnvironmentID != env.ID || tok.Variable != "accessToken" || tok.ExpiresAt == nil {
+		t.Errorf("token = %+v", tok)
+	}
+	env, _ = q.GetEnvironment(ctx, env.ID)
+	if env.Variables.String != `{"accessToken":"at-1"}` {
+		t.Errorf("environment variables = %s", env.Variables.String)
+	}
+
+	// A null clientSecret keeps the stored one
+	resp, _ = putJSON(configURL, fmt.Sprintf(
+		`{"name":"renamed","grantType":"client_credentials","tokenUrl":%q,"clientId":"cli","clientSecret":null,"environmentId":%d}`, tokenServer.URL, env.ID))
+	var updated handler.OAuth2ConfigResponse
+	readJSON(t, resp, &updated)
+	if updated.Name != "renamed" || !updated.HasClientSecret || updated.EnvironmentID == nil || *updated.EnvironmentID != env.ID {
+		t.Errorf("updated = %+v", updated)
+	}
+	resp, _ = postJSON(configURL+"/fetch-token", `{}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("fetch after update: status = %d", resp.StatusCode)
+	}
+
+	// A rejected client surfaces as a bad gateway
+	resp, _ = putJSON(configURL, fmt.Sprintf(
+		`{"name":"renamed","grantType":"client_credentials","tokenUrl":%q,"clientId":"cli","clientSecret":"wrong"}`, tokenServer.URL))
+	resp.Body.Close()
+	resp, _ = postJSON(configURL+"/fetch-token", `{}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("rejected client: status = %d, want 502", resp.StatusCode)
+	}
+
+	ws, _ := q.CreateWorkspace(ctx, "Other")
+	resp, _ = getWithWorkspace(configURL, ws.ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", resp.StatusCode)
+	}
+	otherEnv, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "prod", WorkspaceID: ws.ID})
+	resp, _ = putJSON(configURL, fmt.Sprintf(
+		`{"name":"renamed","grantType":"client_credentials","tokenUrl":"http://x","environmentId":%d}`, otherEnv.ID))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("environment of another workspace: status = %d, want 400", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, configURL, nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status = %d", resp.StatusCode)
+	}
+	var list []handler.OAuth2ConfigResponse
+	resp, _ = http.Get(ts.URL + "/api/oauth2/configs")
+	readJSON(t, resp, &list)
+	if len(list) != 0 {
+		t.Errorf("after delete: %d configs", len(list))
+	}
+}
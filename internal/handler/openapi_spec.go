@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// OpenAPISpecHandler manages the workspace's OpenAPI specs, which responses
+// are validated against with validateSpec
+type OpenAPISpecHandler struct {
+	queries *repository.Queries
+}
+
+func NewOpenAPISpecHandler(queries *repository.Queries) *OpenAPISpecHandler {
+	return &OpenAPISpecHandler{queries: queries}
+}
+
+// OpenAPISpecRequest imports an OpenAPI 3 JSON document
+type OpenAPISpecRequest struct {
+	Name string          `json:"name"`
+	Spec json.RawMessage `json:"spec"`
+}
+
+type OpenAPISpecResponse struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`
+	Spec      json.RawMessage `json:"spec,omitempty"` // omitted from lists
+	CreatedAt string          `json:"createdAt"`
+	UpdatedAt string          `json:"updatedAt"`
+}
+
+func toOpenAPISpecResponse(s repository.OpenapiSpec, withSpec bool) OpenAPISpecResponse {
+	resp := OpenAPISpecResponse{
+		ID:        s.ID,
+		Name:      s.Name,
+		CreatedAt: formatTime(s.CreatedAt),
+		UpdatedAt: formatTime(s.UpdatedAt),
+	}
+	if withSpec {
+		resp.Spec = json.RawMessage(s.Spec)
+	}
+	return resp
+}
+
+func (h *OpenAPISpecHandler) List(w http.ResponseWriter, r *http.Request) {
+	specs, err := h.queries.ListOpenAPISpecs(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]OpenAPISpecResponse, len(specs))
+	for i, s := range specs {
+		resp[i] = toOpenAPISpecResponse(s, false)
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *OpenAPISpecHandler) Get(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.specByID(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, toOpenAPISpecResponse(s, true))
+}
+
+func (h *OpenAPISpecHandler) Create(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeOpenAPISpecRequest(w, r)
+	if !ok {
+		return
+	}
+	s, err := h.queries.CreateOpenAPISpec(r.Context(), repository.CreateOpenAPISpecParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        req.Name,
+		Spec:        string(req.Spec),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, toOpenAPISpecResponse(s, true))
+}
+
+func (h *OpenAPISpecHandler) Update(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.specByID(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeOpenAPISpecRequest(w, r)
+	if !ok {
+		return
+	}
+	s, err := h.queries.UpdateOpenAPISpec(r.Context(), repository.UpdateOpenAPISpecParams{
+		Name: req.Name,
+		Spec: string(req.Spec),
+		ID:   existing.ID,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toOpenAPISpecResponse(s, true))
+}
+
+func (h *OpenAPISpecHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.specByID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.queries.DeleteOpenAPISpec(r.Context(), s.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// specByID loads the {id} spec of the current workspace, responding 400/404
+func (h *OpenAPISpecHandler) specByID(w http.ResponseWriter, r *http.Request) (repository.OpenapiSpec, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.OpenapiSpec{}, false
+	}
+	s, err := h.queries.GetOpenAPISpec(r.Context(), id)
+	if err != nil || s.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "OpenAPI spec not found")
+		return repository.OpenapiSpec{}, false
+	}
+	return s, true
+}
+
+// decodeOpenAPISpecRequest reads and validates the body, responding 400
+func decodeOpenAPISpecRequest(w http.ResponseWriter, r *http.Request) (OpenAPISpecRequest, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxOpenAPISpecSize)
+	var req OpenAPISpecRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return req, false
+	}
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return req, false
+	}
+	if err := service.ValidateOpenAPISpec(req.Spec); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+	return req, true
+}
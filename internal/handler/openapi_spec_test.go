@@ -0,0 +1,76 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpenAPISpecs_CRUD(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	h := handler.NewOpenAPISpecHandler(q)
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/openapi-specs", h.List)
+	r.Post("/api/openapi-specs", h.Create)
+	r.Get("/api/openapi-specs/{id}", h.Get)
+	r.Put("/api/openapi-specs/{id}", h.Update)
+	r.Delete("/api/openapi-specs/{id}", h.Delete)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for _, body := range []string{
+		`{"name":"","spec":{"openapi":"3.0.0","paths":{}}}`,
+		`{"name":"Old","spec":{"swagger":"2.0","paths":{}}}`,
+		`{"name":"No paths","spec":{"openapi":"3.0.0"}}`,
+	} {
+		resp, _ := postJSON(ts.URL+"/api/openapi-specs", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	var spec handler.OpenAPISpecResponse
+	resp, _ := postJSON(ts.URL+"/api/openapi-specs", `{"name":"Pets","spec":{"openapi":"3.0.0","paths":{"/pets":{}}}}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status = %d", resp.StatusCode)
+	}
+	readJSON(t, resp, &spec)
+	if spec.Name != "Pets" || len(spec.Spec) == 0 {
+		t.Errorf("created = %+v", spec)
+	}
+
+	var list []handler.OpenAPISpecResponse
+	resp, _ = http.Get(ts.URL + "/api/openapi-specs")
+	readJSON(t, resp, &list)
+	if len(list) != 1 || list[0].ID != spec.ID || list[0].Spec != nil {
+		t.Errorf("list = %+v", list)
+	}
+
+	resp, _ = putJSON(fmt.Sprintf("%s/api/openapi-specs/%d", ts.URL, spec.ID), `{"name":"Pet Store","spec":{"openapi":"3.1.0","paths":{}}}`)
+	readJSON(t, resp, &spec)
+	if spec.Name != "Pet Store" {
+		t.Errorf("updated = %+v", spec)
+	}
+
+	resp, _ = getWithWorkspace(fmt.Sprintf("%s/api/openapi-specs/%d", ts.URL, spec.ID), 2)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace get: status = %d, want 404", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/openapi-specs/%d", ts.URL, spec.ID), nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status = %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type PostmanImportResponse struct {
+	Collection  CollectionResponse `json:"collection"`
+	Collections int                `json:"collections"`
+	Requests    int                `json:"requests"`
+	Warnings    []string           `json:"warnings,omitempty"`
+}
+
+// ImportPostman creates a collection tree from a Postman Collection v2.1
+// export: folders become child collections, collection/folder variables
+// become collection variables.
+func (h *CollectionHandler) ImportPostman(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(data) {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	tree, warnings, err := service.ParsePostmanCollection(data)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collections, requests := tree.Count()
+	if !enforceRequestQuota(w, r, h.queries, requests) {
+		return
+	}
+
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+
+	var maxSortOrder int64
+	if val, err := h.queries.GetMaxRootCollectionSortOrder(ctx, wsID); err == nil {
+		maxSortOrder, _ = val.(int64)
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	root, err := createImportedCollection(ctx, h.queries.WithTx(tx), wsID, sql.NullInt64{}, maxSortOrder+1, tree)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, PostmanImportResponse{
+		Collection: CollectionResponse{
+			ID:        root.ID,
+			Name:      root.Name,
+			SortOrder: root.SortOrder,
+			CreatedAt: formatTime(root.CreatedAt),
+			UpdatedAt: formatTime(root.UpdatedAt),
+		},
+		Collections: collections,
+		Requests:    requests,
+		Warnings:    warnings,
+	})
+}
+
+// createImportedCollection creates coll with its variables, requests and
+// children under parentID
+func createImportedCollection(ctx context.Context, q *repository.Queries, wsID int64, parentID sql.NullInt64, sortOrder int64, coll *service.ImportedCollection) (repository.Collection, error) {
+	created, err := q.CreateCollection(ctx, repository.CreateCollectionParams{
+		Name:        coll.Name,
+		ParentID:    parentID,
+		WorkspaceID: wsID,
+		SortOrder:   sortOrder,
+	})
+	if err != nil {
+		return created, err
+	}
+
+	if len(coll.Variables) > 0 {
+		vars, _ := json.Marshal(coll.Variables)
+		secrets := sql.NullString{}
+		if len(coll.Secrets) > 0 {
+			data, _ := json.Marshal(coll.Secrets)
+			secrets = sql.NullString{String: string(data), Valid: true}
+		}
+		if _, err := q.UpdateCollectionVariableSet(ctx, repository.UpdateCollectionVariableSetParams{
+			ID:              created.ID,
+			Variables:       sql.NullString{String: string(vars), Valid: true},
+			SecretVariables: secrets,
+		}); err != nil {
+			return created, err
+		}
+	}
+
+	return created, createImportedContents(ctx, q, wsID, created.ID, 1, 1, coll)
+}
+
+// createImportedContents creates coll's requests and children inside an
+// existing collection, numbering them from the given sort orders
+func createImportedContents(ctx context.Context, q *repository.Queries, wsID, id, requestOrder, childOrder int64, coll *service.ImportedCollection) error {
+	collectionID := sql.NullInt64{Int64: id, Valid: true}
+	for i, req := range coll.Requests {
+		_, err := q.CreateRequest(ctx, repository.CreateRequestParams{
+			CollectionID: collectionID,
+			Name:         req.Name,
+			Method:       req.Method,
+			Url:          req.URL,
+			Headers:      sql.NullString{String: req.Headers, Valid: true},
+			Body:         sql.NullString{String: req.Body, Valid: true},
+			BodyType:     sql.NullString{String: req.BodyType, Valid: true},
+			Cookies:      sql.NullString{String: "{}", Valid: true},
+			WorkspaceID:  wsID,
+			PreScript:    sql.NullString{String: req.PreScript, Valid: req.PreScript != ""},
+			PostScript:   sql.NullString{String: req.PostScript, Valid: req.PostScript != ""},
+			SortOrder:    requestOrder + int64(i),
+			Auth:         sql.NullString{String: req.Auth, Valid: req.Auth != ""},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, child := range coll.Children {
+		if _, err := createImportedCollection(ctx, q, wsID, collectionID, childOrder+int64(i), child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestCollection_ImportPostman(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	h := handler.NewCollectionHandler(q, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/import/postman", h.ImportPostman)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	body := `{
+		"info": {"name": "Petstore", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"variable": [{"key": "host", "value": "https://pets.test"}],
+		"item": [
+			{"name": "Pets", "item": [
+				{"name": "List pets", "request": {"method": "GET", "url": "{{host}}/pets", "header": [{"key": "Accept", "value": "application/json"}]}},
+				{"name": "Admin", "item": []}
+			]},
+			{"name": "Ping", "request": {"method": "GET", "url": "{{host}}/ping", "auth": {"type": "digest"}}}
+		]
+	}`
+	resp, _ := postJSONWithWorkspace(ts.URL+"/api/import/postman", body, 2)
+	var result handler.PostmanImportResponse
+	readJSON(t, resp, &result)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if result.Collection.Name != "Petstore" || result.Collections != 3 || result.Requests != 2 || len(result.Warnings) != 1 {
+		t.Errorf("result = %+v", result)
+	}
+
+	ctx := context.Background()
+	vars, _ := q.GetCollectionVariables(ctx, result.Collection.ID)
+	if vars.String != `{"host":"https://pets.test"}` {
+		t.Errorf("variables = %s", vars.String)
+	}
+	folders, _ := q.ListChildCollections(ctx, sql.NullInt64{Int64: result.Collection.ID, Valid: true})
+	if len(folders) != 1 || folders[0].Name != "Pets" || folders[0].WorkspaceID != 2 {
+		t.Fatalf("folders = %+v", folders)
+	}
+	requests, _ := q.ListRequestsByCollection(ctx, sql.NullInt64{Int64: folders[0].ID, Valid: true})
+	if len(requests) != 1 || requests[0].Url != "{{host}}/pets" || requests[0].Headers.String != `{"Accept":{"value":"application/json","enabled":true}}` {
+		t.Errorf("requests = %+v", requests)
+	}
+
+	for _, bad := range []string{`{"format": "relay-flow"}`, `not json`} {
+		resp, _ = postJSON(ts.URL+"/api/import/postman", bad)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", bad, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// userTokenHeader identifies the user's preference set until accounts exist
+const userTokenHeader = "X-User-Token"
+
+type PreferencesHandler struct {
+	queries *repository.Queries
+}
+
+func NewPreferencesHandler(queries *repository.Queries) *PreferencesHandler {
+	return &PreferencesHandler{queries: queries}
+}
+
+type UserPreferencesResponse struct {
+	service.UserPreferences
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// userTokenHash returns the storage key for the request's token, or responds 400
+func userTokenHash(w http.ResponseWriter, r *http.Request) (string, bool) {
+	token := r.Header.Get(userTokenHeader)
+	if !service.ValidUserToken(token) {
+		respondError(w, http.StatusBadRequest, userTokenHeader+" header is required (16-128 characters: letters, digits, '.', '_', '-')")
+		return "", false
+	}
+	return service.HashUserToken(token), true
+}
+
+// Get returns the caller's preferences, or the defaults if none were saved
+func (h *PreferencesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	key, ok := userTokenHash(w, r)
+	if !ok {
+		return
+	}
+
+	row, err := h.queries.GetUserPreferences(r.Context(), key)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondJSON(w, http.StatusOK, UserPreferencesResponse{UserPreferences: service.DefaultUserPreferences()})
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, UserPreferencesResponse{
+		UserPreferences: service.ParseUserPreferences(row.Preferences),
+		UpdatedAt:       formatTime(row.UpdatedAt),
+	})
+}
+
+// Update replaces the caller's preferences; omitted fields take their defaults
+func (h *PreferencesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	key, ok := userTokenHash(w, r)
+	if !ok {
+		return
+	}
+
+	prefs := service.DefaultUserPreferences()
+	if err := decodeJSON(r, &prefs); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if prefs.HiddenColumns == nil {
+		prefs.HiddenColumns = map[string][]string{}
+	}
+	if err := prefs.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if prefs.DefaultWorkspaceID != nil {
+		if _, err := h.queries.GetWorkspace(r.Context(), *prefs.DefaultWorkspaceID); err != nil {
+			respondError(w, http.StatusBadRequest, "defaultWorkspaceId: workspace not found")
+			return
+		}
+	}
+
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	row, err := h.queries.UpsertUserPreferences(r.Context(), repository.UpsertUserPreferencesParams{
+		TokenHash:   key,
+		Preferences: string(data),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, UserPreferencesResponse{
+		UserPreferences: service.ParseUserPreferences(row.Preferences),
+		UpdatedAt:       formatTime(row.UpdatedAt),
+	})
+}
+
+// Delete resets the caller's preferences to the defaults
+func (h *PreferencesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	key, ok := userTokenHash(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.queries.DeleteUserPreferences(r.Context(), key); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,123 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	prefsTokenA = "0f8b6c1e-user-a-token"
+	prefsTokenB = "7d2e9a44-user-b-token"
+)
+
+func setupPreferencesTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	q := testutil.SetupTestDB(t)
+	h := handler.NewPreferencesHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/preferences", h.Get)
+	r.Put("/api/preferences", h.Update)
+	r.Delete("/api/preferences", h.Delete)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func preferencesRequest(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-User-Token", token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestPreferences_SaveAndLoad(t *testing.T) {
+	ts := setupPreferencesTestServer(t)
+	url := ts.URL + "/api/preferences"
+
+	var prefs handler.UserPreferencesResponse
+	readJSON(t, preferencesRequest(t, http.MethodGet, url, prefsTokenA, ""), &prefs)
+	if prefs.Theme != "system" || prefs.Editor.FontSize != 13 || !prefs.Editor.LineNumbers || prefs.UpdatedAt != "" {
+		t.Errorf("defaults = %+v", prefs)
+	}
+
+	resp := preferencesRequest(t, http.MethodPut, url, prefsTokenA,
+		`{"theme":"dark","defaultWorkspaceId":1,"editor":{"fontSize":15,"wordWrap":true},"hiddenColumns":{"history":["duration"]}}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update: status %d", resp.StatusCode)
+	}
+	readJSON(t, resp, &prefs)
+
+	// Another machine with the same token sees the same settings
+	readJSON(t, preferencesRequest(t, http.MethodGet, url, prefsTokenA, ""), &prefs)
+	if prefs.Theme != "dark" || prefs.DefaultWorkspaceID == nil || *prefs.DefaultWorkspaceID != 1 {
+		t.Errorf("saved prefs = %+v", prefs)
+	}
+	if prefs.Editor.FontSize != 15 || !prefs.Editor.WordWrap || prefs.Editor.TabSize != 2 || !prefs.Editor.LineNumbers {
+		t.Errorf("editor prefs (omitted fields keep defaults) = %+v", prefs.Editor)
+	}
+	if len(prefs.HiddenColumns["history"]) != 1 || prefs.UpdatedAt == "" {
+		t.Errorf("hiddenColumns/updatedAt = %v / %q", prefs.HiddenColumns, prefs.UpdatedAt)
+	}
+
+	// Other users are unaffected
+	readJSON(t, preferencesRequest(t, http.MethodGet, url, prefsTokenB, ""), &prefs)
+	if prefs.Theme != "system" {
+		t.Errorf("token B theme = %q", prefs.Theme)
+	}
+
+	resp = preferencesRequest(t, http.MethodDelete, url, prefsTokenA, "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status %d", resp.StatusCode)
+	}
+	readJSON(t, preferencesRequest(t, http.MethodGet, url, prefsTokenA, ""), &prefs)
+	if prefs.Theme != "system" {
+		t.Errorf("after reset theme = %q", prefs.Theme)
+	}
+}
+
+func TestPreferences_Validation(t *testing.T) {
+	ts := setupPreferencesTestServer(t)
+	url := ts.URL + "/api/preferences"
+
+	cases := []struct {
+		name  string
+		token string
+		body  string
+	}{
+		{"missing token", "", `{}`},
+		{"short token", "abc", `{}`},
+		{"theme", prefsTokenA, `{"theme":"neon"}`},
+		{"font size", prefsTokenA, `{"editor":{"fontSize":100}}`},
+		{"workspace", prefsTokenA, `{"defaultWorkspaceId":999}`},
+	}
+	for _, tc := range cases {
+		resp := preferencesRequest(t, http.MethodPut, url, tc.token, tc.body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tc.name, resp.StatusCode)
+		}
+	}
+}
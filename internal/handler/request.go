@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -24,35 +25,81 @@ func NewRequestHandler(queries *repository.Queries, executor *service.RequestExe
 }
 
 type RequestRequest struct {
-	CollectionID *int64 `json:"collectionId"`
-	Name         string `json:"name"`
-	Method       string `json:"method"`
-	URL          string `json:"url"`
-	Headers      string `json:"headers"`
-	Body         string `json:"body"`
-	BodyType     string `json:"bodyType"`
-	Cookies      string `json:"cookies"`
-	ProxyID      *int64 `json:"proxyId"`
-	PreScript    string `json:"preScript"`
-	PostScript   string `json:"postScript"`
+	CollectionID      *int64 `json:"collectionId"`
+	Name              string `json:"name"`
+	Method            string `json:"method"`
+	URL               string `json:"url"`
+	Headers           string `json:"headers"`
+	Body              string `json:"body"`
+	BodyType          string `json:"bodyType"`
+	Cookies           string `json:"cookies"`
+	ProxyID           *int64 `json:"proxyId"`
+	PreScript         string `json:"preScript"`
+	PostScript        string `json:"postScript"`
+	ResponseTransform string `json:"responseTransform"`
+	Auth              string `json:"auth"` // RequestAuth JSON (ntlm, negotiate)
+	// Timeout and retry policy; 0 keeps the defaults (60s, no retries)
+	TimeoutMs      int64  `json:"timeoutMs"`
+	RetryCount     int64  `json:"retryCount"`
+	RetryBackoffMs int64  `json:"retryBackoffMs"`
+	RetryOnStatus  string `json:"retryOnStatus"` // e.g. "502,503,504"
+	// Redirect policy: followRedirects defaults to true, maxRedirects 0 to 10
+	FollowRedirects *bool `json:"followRedirects"`
+	MaxRedirects    int64 `json:"maxRedirects"`
+}
+
+func (r RequestRequest) policy() service.RequestPolicy {
+	return service.RequestPolicy{TimeoutMs: r.TimeoutMs, RetryCount: r.RetryCount, RetryBackoffMs: r.RetryBackoffMs, RetryOnStatus: r.RetryOnStatus, MaxRedirects: r.MaxRedirects}
+}
+
+// redirectsDisabled stores followRedirects (default true) as disable_redirects
+func redirectsDisabled(follow *bool) int64 {
+	if follow != nil && !*follow {
+		return 1
+	}
+	return 0
+}
+
+// followsRedirects is the followRedirects of a disable_redirects column;
+// always set, so exported files re-import with the same policy
+func followsRedirects(disabled int64) *bool {
+	follow := disabled == 0
+	return &follow
 }
 
 type RequestResponse struct {
-	ID           int64  `json:"id"`
-	CollectionID *int64 `json:"collectionId,omitempty"`
-	Name         string `json:"name"`
-	Method       string `json:"method"`
-	URL          string `json:"url"`
-	Headers      string `json:"headers,omitempty"`
-	Body         string `json:"body,omitempty"`
-	BodyType     string `json:"bodyType,omitempty"`
-	Cookies      string `json:"cookies,omitempty"`
-	ProxyID      *int64 `json:"proxyId"`
-	SortOrder    int64  `json:"sortOrder"`
-	PreScript    string `json:"preScript,omitempty"`
-	PostScript   string `json:"postScript,omitempty"`
-	CreatedAt    string `json:"createdAt,omitempty"`
-	UpdatedAt    string `json:"updatedAt,omitempty"`
+	ID                int64  `json:"id"`
+	CollectionID      *int64 `json:"collectionId,omitempty"`
+	Name              string `json:"name"`
+	Method            string `json:"method"`
+	URL               string `json:"url"`
+	Headers           string `json:"headers,omitempty"`
+	Body              string `json:"body,omitempty"`
+	BodyType          string `json:"bodyType,omitempty"`
+	Cookies           string `json:"cookies,omitempty"`
+	ProxyID           *int64 `json:"proxyId"`
+	SortOrder         int64  `json:"sortOrder"`
+	PreScript         string `json:"preScript,omitempty"`
+	PostScript        string `json:"postScript,omitempty"`
+	ResponseTransform string `json:"responseTransform,omitempty"`
+	Auth              string `json:"auth,omitempty"`
+	TimeoutMs         int64  `json:"timeoutMs"`
+	RetryCount        int64  `json:"retryCount"`
+	RetryBackoffMs    int64  `json:"retryBackoffMs"`
+	RetryOnStatus     string `json:"retryOnStatus,omitempty"`
+	FollowRedirects   *bool  `json:"followRedirects"`
+	MaxRedirects      int64  `json:"maxRedirects"`
+	Version           int64  `json:"version"`
+	CreatedAt         string `json:"createdAt,omitempty"`
+	UpdatedAt         string `json:"updatedAt,omitempty"`
+	ExecutionCount    int64  `json:"executionCount"`
+	LastExecutedAt    string `json:"lastExecutedAt,omitempty"`
+	Archived          bool   `json:"archived"`
+	ArchivedAt        string `json:"archivedAt,omitempty"`
+	// Duplicates lists requests with the same method and URL (?duplicates=warn)
+	Duplicates []DuplicateRequest `json:"duplicates,omitempty"`
+	// Annotations document response fields (collection exports only)
+	Annotations []ResponseAnnotationResponse `json:"annotations,omitempty"`
 }
 
 type RequestExecuteResponse struct {
@@ -70,6 +117,27 @@ type ExecuteRequest struct {
 	Body     string `json:"body,omitempty"`
 	BodyType string `json:"bodyType,omitempty"`
 	ProxyID  *int64 `json:"proxyId"`
+	// ResponseTransform overrides the saved transform; "" disables it for this run
+	ResponseTransform *string `json:"responseTransform,omitempty"`
+	// UseDraft runs the autosaved draft instead of the saved definition when
+	// one exists (default true)
+	UseDraft *bool `json:"useDraft,omitempty"`
+	// FrozenTime freezes {{$timestamp}}, {{$date}} and Date.now() in the
+	// request and its scripts (RFC3339 or unix milliseconds)
+	FrozenTime string `json:"frozenTime,omitempty"`
+	// Seed makes {{$randomInt}}, {{$guid}} and Math.random() repeat across runs
+	Seed *int64 `json:"seed,omitempty"`
+	// TraceVariables reports which scope every variable was resolved from
+	// (executeResult.variableTrace; secrets masked)
+	TraceVariables bool `json:"traceVariables,omitempty"`
+	// SafeMode only sends GET, HEAD and OPTIONS requests (executeResult.safeModeBlocked)
+	SafeMode bool `json:"safeMode,omitempty"`
+	// Chaos injects latency, a dropped request or a synthetic error response
+	// (executeResult.fault)
+	Chaos *service.ChaosOptions `json:"chaos,omitempty"`
+	// ValidateSpec checks the response against the workspace's OpenAPI specs
+	// (executeResult.specValidation)
+	ValidateSpec bool `json:"validateSpec,omitempty"`
 }
 
 type AdhocExecuteRequest struct {
@@ -79,23 +147,38 @@ type AdhocExecuteRequest struct {
 	Body      string            `json:"body"`
 	Variables map[string]string `json:"variables"`
 	ProxyID   *int64            `json:"proxyId"`
+	// PreScript runs before sending, as a saved request's pre-script does
+	PreScript string `json:"preScript,omitempty"`
 }
 
 func toRequestResponse(req repository.Request) RequestResponse {
 	resp := RequestResponse{
-		ID:         req.ID,
-		Name:       req.Name,
-		Method:     req.Method,
-		URL:        req.Url,
-		Headers:    req.Headers.String,
-		Body:       req.Body.String,
-		BodyType:   req.BodyType.String,
-		Cookies:    req.Cookies.String,
-		SortOrder:  req.SortOrder,
-		PreScript:  req.PreScript.String,
-		PostScript: req.PostScript.String,
-		CreatedAt:  formatTime(req.CreatedAt),
-		UpdatedAt:  formatTime(req.UpdatedAt),
+		ID:                req.ID,
+		Name:              req.Name,
+		Method:            req.Method,
+		URL:               req.Url,
+		Headers:           req.Headers.String,
+		Body:              req.Body.String,
+		BodyType:          req.BodyType.String,
+		Cookies:           req.Cookies.String,
+		SortOrder:         req.SortOrder,
+		PreScript:         req.PreScript.String,
+		PostScript:        req.PostScript.String,
+		ResponseTransform: req.ResponseTransform.String,
+		Auth:              req.Auth.String,
+		TimeoutMs:         req.TimeoutMs,
+		RetryCount:        req.RetryCount,
+		RetryBackoffMs:    req.RetryBackoffMs,
+		RetryOnStatus:     req.RetryOnStatus,
+		FollowRedirects:   followsRedirects(req.DisableRedirects),
+		MaxRedirects:      req.MaxRedirects,
+		Version:           req.Version,
+		CreatedAt:         formatTime(req.CreatedAt),
+		UpdatedAt:         formatTime(req.UpdatedAt),
+		ExecutionCount:    req.ExecutionCount,
+		LastExecutedAt:    formatTime(req.LastExecutedAt),
+		Archived:          req.ArchivedAt.Valid,
+		ArchivedAt:        formatTime(req.ArchivedAt),
 	}
 	if req.CollectionID.Valid {
 		collID := req.CollectionID.Int64
@@ -109,12 +192,22 @@ func toRequestResponse(req repository.Request) RequestResponse {
 }
 
 func (h *RequestHandler) List(w http.ResponseWriter, r *http.Request) {
+	sortBy, desc, ok := listUsageSort(w, r)
+	if !ok {
+		return
+	}
 	wsID := middleware.GetWorkspaceID(r.Context())
 	requests, err := h.queries.ListRequests(r.Context(), wsID)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	if !includeArchived(r) {
+		requests = withoutArchived(requests, func(req repository.Request) sql.NullTime { return req.ArchivedAt })
+	}
+	sortByUsage(requests, sortBy, desc, func(req repository.Request) (int64, sql.NullTime) {
+		return req.ExecutionCount, req.LastExecutedAt
+	})
 
 	resp := make([]RequestResponse, 0, len(requests))
 	for _, req := range requests {
@@ -141,6 +234,15 @@ func (h *RequestHandler) Get(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RequestHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, service.QuotaRequests) {
+		return
+	}
+
+	dupMode, ok := duplicatesMode(w, r)
+	if !ok {
+		return
+	}
+
 	var reqBody RequestRequest
 	if err := decodeJSON(r, &reqBody); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
@@ -161,6 +263,14 @@ func (h *RequestHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if reqBody.Cookies == "" {
 		reqBody.Cookies = "{}"
 	}
+	if _, err := service.ParseRequestAuth(reqBody.Auth); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := reqBody.policy().Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	var proxyID sql.NullInt64
 	if reqBody.ProxyID != nil {
@@ -173,6 +283,10 @@ func (h *RequestHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	wsID := middleware.GetWorkspaceID(r.Context())
+	dups, ok := h.checkDuplicates(r.Context(), w, dupMode, wsID, reqBody.Method, reqBody.URL, 0)
+	if !ok {
+		return
+	}
 
 	// Calculate next sort_order
 	var maxSortOrder int64
@@ -182,26 +296,36 @@ func (h *RequestHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req, err := h.queries.CreateRequest(r.Context(), repository.CreateRequestParams{
-		CollectionID: collectionID,
-		Name:         reqBody.Name,
-		Method:       reqBody.Method,
-		Url:          reqBody.URL,
-		Headers:      sql.NullString{String: reqBody.Headers, Valid: true},
-		Body:         sql.NullString{String: reqBody.Body, Valid: reqBody.Body != ""},
-		BodyType:     sql.NullString{String: reqBody.BodyType, Valid: true},
-		Cookies:      sql.NullString{String: reqBody.Cookies, Valid: true},
-		ProxyID:      proxyID,
-		WorkspaceID:  wsID,
-		PreScript:    sql.NullString{String: reqBody.PreScript, Valid: reqBody.PreScript != ""},
-		PostScript:   sql.NullString{String: reqBody.PostScript, Valid: reqBody.PostScript != ""},
-		SortOrder:    maxSortOrder + 1,
+		CollectionID:      collectionID,
+		Name:              reqBody.Name,
+		Method:            reqBody.Method,
+		Url:               reqBody.URL,
+		Headers:           sql.NullString{String: reqBody.Headers, Valid: true},
+		Body:              sql.NullString{String: reqBody.Body, Valid: reqBody.Body != ""},
+		BodyType:          sql.NullString{String: reqBody.BodyType, Valid: true},
+		Cookies:           sql.NullString{String: reqBody.Cookies, Valid: true},
+		ProxyID:           proxyID,
+		WorkspaceID:       wsID,
+		PreScript:         sql.NullString{String: reqBody.PreScript, Valid: reqBody.PreScript != ""},
+		PostScript:        sql.NullString{String: reqBody.PostScript, Valid: reqBody.PostScript != ""},
+		SortOrder:         maxSortOrder + 1,
+		ResponseTransform: sql.NullString{String: reqBody.ResponseTransform, Valid: reqBody.ResponseTransform != ""},
+		Auth:              sql.NullString{String: reqBody.Auth, Valid: reqBody.Auth != ""},
+		TimeoutMs:         reqBody.TimeoutMs,
+		RetryCount:        reqBody.RetryCount,
+		RetryBackoffMs:    reqBody.RetryBackoffMs,
+		RetryOnStatus:     reqBody.RetryOnStatus,
+		DisableRedirects:  redirectsDisabled(reqBody.FollowRedirects),
+		MaxRedirects:      reqBody.MaxRedirects,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, toRequestResponse(req))
+	resp := toRequestResponse(req)
+	resp.Duplicates = dups
+	respondJSON(w, http.StatusCreated, resp)
 }
 
 func (h *RequestHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -210,12 +334,24 @@ func (h *RequestHandler) Update(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "Invalid ID")
 		return
 	}
+	dupMode, ok := duplicatesMode(w, r)
+	if !ok {
+		return
+	}
 
 	var reqBody RequestRequest
 	if err := decodeJSON(r, &reqBody); err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if _, err := service.ParseRequestAuth(reqBody.Auth); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := reqBody.policy().Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	var collectionID sql.NullInt64
 	if reqBody.CollectionID != nil {
@@ -232,26 +368,41 @@ func (h *RequestHandler) Update(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	dups, ok := h.checkDuplicates(r.Context(), w, dupMode, middleware.GetWorkspaceID(r.Context()), reqBody.Method, reqBody.URL, id)
+	if !ok {
+		return
+	}
+
 	req, err := h.queries.UpdateRequest(r.Context(), repository.UpdateRequestParams{
-		ID:           id,
-		CollectionID: collectionID,
-		Name:         reqBody.Name,
-		Method:       reqBody.Method,
-		Url:          reqBody.URL,
-		Headers:      sql.NullString{String: reqBody.Headers, Valid: true},
-		Body:         sql.NullString{String: reqBody.Body, Valid: reqBody.Body != ""},
-		BodyType:     sql.NullString{String: reqBody.BodyType, Valid: true},
-		Cookies:      sql.NullString{String: reqBody.Cookies, Valid: true},
-		ProxyID:      proxyID,
-		PreScript:    sql.NullString{String: reqBody.PreScript, Valid: reqBody.PreScript != ""},
-		PostScript:   sql.NullString{String: reqBody.PostScript, Valid: reqBody.PostScript != ""},
+		ID:                id,
+		CollectionID:      collectionID,
+		Name:              reqBody.Name,
+		Method:            reqBody.Method,
+		Url:               reqBody.URL,
+		Headers:           sql.NullString{String: reqBody.Headers, Valid: true},
+		Body:              sql.NullString{String: reqBody.Body, Valid: reqBody.Body != ""},
+		BodyType:          sql.NullString{String: reqBody.BodyType, Valid: true},
+		Cookies:           sql.NullString{String: reqBody.Cookies, Valid: true},
+		ProxyID:           proxyID,
+		PreScript:         sql.NullString{String: reqBody.PreScript, Valid: reqBody.PreScript != ""},
+		PostScript:        sql.NullString{String: reqBody.PostScript, Valid: reqBody.PostScript != ""},
+		ResponseTransform: sql.NullString{String: reqBody.ResponseTransform, Valid: reqBody.ResponseTransform != ""},
+		Auth:              sql.NullString{String: reqBody.Auth, Valid: reqBody.Auth != ""},
+		TimeoutMs:         reqBody.TimeoutMs,
+		RetryCount:        reqBody.RetryCount,
+		RetryBackoffMs:    reqBody.RetryBackoffMs,
+		RetryOnStatus:     reqBody.RetryOnStatus,
+		DisableRedirects:  redirectsDisabled(reqBody.FollowRedirects),
+		MaxRedirects:      reqBody.MaxRedirects,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, toRequestResponse(req))
+	resp := toRequestResponse(req)
+	resp.Duplicates = dups
+	respondJSON(w, http.StatusOK, resp)
 }
 
 func (h *RequestHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -270,6 +421,10 @@ func (h *RequestHandler) Delete(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RequestHandler) Execute(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, executionQuotas...) {
+		return
+	}
+
 	id, err := parseID(r, "id")
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid ID")
@@ -285,22 +440,85 @@ func (h *RequestHandler) Execute(w http.ResponseWriter, r *http.Request) {
 	var execReq ExecuteRequest
 	decodeJSON(r, &execReq) // OK if empty
 
+	ctx, overrides, ok := h.executeOptions(w, r, id, execReq)
+	if !ok {
+		return
+	}
+	resp, err := h.executeSaved(ctx, id, execReq.Variables, overrides)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// executeOptions builds the overrides and run context of a JSON execution
+// request, responding 400 on invalid options
+func (h *RequestHandler) executeOptions(w http.ResponseWriter, r *http.Request, id int64, execReq ExecuteRequest) (context.Context, *service.RequestOverrides, bool) {
 	// Build inline overrides if provided
 	var overrides *service.RequestOverrides
-	if execReq.URL != "" || execReq.ProxyID != nil {
+	if execReq.URL != "" || execReq.ProxyID != nil || execReq.ResponseTransform != nil {
 		overrides = &service.RequestOverrides{
-			Method:   execReq.Method,
-			URL:      execReq.URL,
-			Headers:  execReq.Headers,
-			Body:     execReq.Body,
-			BodyType: execReq.BodyType,
-			ProxyID:  execReq.ProxyID,
+			Method:            execReq.Method,
+			URL:               execReq.URL,
+			Headers:           execReq.Headers,
+			Body:              execReq.Body,
+			BodyType:          execReq.BodyType,
+			ProxyID:           execReq.ProxyID,
+			ResponseTransform: execReq.ResponseTransform,
 		}
 	}
+	if execReq.UseDraft == nil || *execReq.UseDraft {
+		if draft, ok := h.loadDraft(r.Context(), id); ok {
+			if overrides == nil {
+				overrides = &service.RequestOverrides{}
+			}
+			overrides.Draft = &draft
+		}
+	}
+
+	ctx := r.Context()
+	if execReq.FrozenTime != "" {
+		frozen, err := service.ParseFrozenTime(execReq.FrozenTime)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return nil, nil, false
+		}
+		ctx = service.WithFrozenClock(ctx, frozen)
+	}
+	if execReq.Seed != nil {
+		ctx = service.WithRandomSeed(ctx, *execReq.Seed)
+	}
+	if execReq.TraceVariables {
+		ctx = service.WithVariableTrace(ctx)
+	}
+	if execReq.SafeMode {
+		ctx = service.WithSafeMode(ctx)
+	}
+	if execReq.Chaos != nil {
+		if err := execReq.Chaos.Validate(); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return nil, nil, false
+		}
+		ctx = service.WithChaos(ctx, *execReq.Chaos)
+	}
+	if execReq.ValidateSpec {
+		ctx = service.WithSpecValidation(ctx)
+	}
+	return ctx, overrides, true
+}
 
+// executeSaved runs a saved request between its pre- and post-scripts
+func (h *RequestHandler) executeSaved(ctx context.Context, id int64, vars map[string]string, overrides *service.RequestOverrides) (RequestExecuteResponse, error) {
 	// Load request for scripts and collection context
-	savedReq, _ := h.queries.GetRequest(r.Context(), id)
+	savedReq, _ := h.queries.GetRequest(ctx, id)
+	if overrides != nil && overrides.Draft != nil {
+		overrides.Draft.ApplyTo(&savedReq)
+	}
 	resp := RequestExecuteResponse{}
+	// pm.sendRequest calls use the request's proxy and become children of its history entry
+	ctx, scriptReqs := service.WithScriptRequests(ctx, overrides.Proxy(savedReq.ProxyID))
 
 	// Run pre-script
 	runtimeVars := make(map[string]string)
@@ -309,7 +527,7 @@ func (h *RequestHandler) Execute(w http.ResponseWriter, r *http.Request) {
 		if savedReq.CollectionID.Valid {
 			collectionID = savedReq.CollectionID.Int64
 		}
-		preResult := h.flowRunner.ExecuteScriptForRequest(r.Context(), savedReq.PreScript.String, runtimeVars, collectionID)
+		preResult := h.flowRunner.ExecuteScriptForRequest(ctx, savedReq.PreScript.String, runtimeVars, collectionID)
 		resp.PreScriptResult = preResult
 		for k, v := range preResult.UpdatedVars {
 			runtimeVars[k] = v
@@ -317,25 +535,24 @@ func (h *RequestHandler) Execute(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Merge runtime vars into execute variables
-	if execReq.Variables == nil {
-		execReq.Variables = runtimeVars
+	if vars == nil {
+		vars = runtimeVars
 	} else {
 		for k, v := range runtimeVars {
-			if _, exists := execReq.Variables[k]; !exists {
-				execReq.Variables[k] = v
+			if _, exists := vars[k]; !exists {
+				vars[k] = v
 			}
 		}
 	}
 
-	result, err := h.executor.Execute(r.Context(), id, execReq.Variables, overrides)
+	result, err := h.executor.Execute(ctx, id, vars, overrides)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
-		return
+		return resp, err
 	}
 	resp.ExecuteResult = result
 
-	// Run post-script
-	if savedReq.PostScript.Valid && savedReq.PostScript.String != "" {
+	// Run post-script (not for a request safe mode kept from being sent)
+	if savedReq.PostScript.Valid && savedReq.PostScript.String != "" && !result.SafeModeBlocked {
 		reqHeaders := make(map[string]string)
 		if savedReq.Headers.Valid {
 			json.Unmarshal([]byte(savedReq.Headers.String), &reqHeaders)
@@ -344,11 +561,12 @@ func (h *RequestHandler) Execute(w http.ResponseWriter, r *http.Request) {
 		if savedReq.CollectionID.Valid {
 			collectionID = savedReq.CollectionID.Int64
 		}
-		postResult := h.flowRunner.ExecuteScriptForRequestWithResponse(r.Context(), savedReq.PostScript.String, runtimeVars, result, savedReq.Url, savedReq.Method, reqHeaders, savedReq.Body.String, collectionID)
+		postResult := h.flowRunner.ExecuteScriptForRequestWithResponse(ctx, savedReq.PostScript.String, runtimeVars, result, savedReq.Url, savedReq.Method, reqHeaders, savedReq.Body.String, collectionID)
 		resp.PostScriptResult = postResult
 	}
+	scriptReqs.Attach(ctx, h.queries, result)
 
-	respondJSON(w, http.StatusOK, resp)
+	return resp, nil
 }
 
 type formDataItemDTO struct {
@@ -407,18 +625,20 @@ func (h *RequestHandler) executeMultipart(w http.ResponseWriter, r *http.Request
 
 	// Build overrides
 	overrides := &service.RequestOverrides{
-		Method:        execReq.Method,
-		URL:           execReq.URL,
-		Headers:       execReq.Headers,
-		Body:          r.FormValue("_items"), // Store items JSON as body
-		BodyType:      "formdata",
-		ProxyID:       execReq.ProxyID,
-		FormDataFiles: formDataFiles,
+		Method:            execReq.Method,
+		URL:               execReq.URL,
+		Headers:           execReq.Headers,
+		Body:              r.FormValue("_items"), // Store items JSON as body
+		BodyType:          "formdata",
+		ProxyID:           execReq.ProxyID,
+		FormDataFiles:     formDataFiles,
+		ResponseTransform: execReq.ResponseTransform,
 	}
 
 	// Load request for scripts
 	savedReq, _ := h.queries.GetRequest(r.Context(), id)
 	resp := RequestExecuteResponse{}
+	ctx, scriptReqs := service.WithScriptRequests(r.Context(), overrides.Proxy(savedReq.ProxyID))
 
 	// Run pre-script
 	runtimeVars := make(map[string]string)
@@ -427,7 +647,7 @@ func (h *RequestHandler) executeMultipart(w http.ResponseWriter, r *http.Request
 		if savedReq.CollectionID.Valid {
 			collectionID = savedReq.CollectionID.Int64
 		}
-		preResult := h.flowRunner.ExecuteScriptForRequest(r.Context(), savedReq.PreScript.String, runtimeVars, collectionID)
+		preResult := h.flowRunner.ExecuteScriptForRequest(ctx, savedReq.PreScript.String, runtimeVars, collectionID)
 		resp.PreScriptResult = preResult
 		for k, v := range preResult.UpdatedVars {
 			runtimeVars[k] = v
@@ -444,7 +664,7 @@ func (h *RequestHandler) executeMultipart(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	result, err := h.executor.Execute(r.Context(), id, execReq.Variables, overrides)
+	result, err := h.executor.Execute(ctx, id, execReq.Variables, overrides)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -461,14 +681,19 @@ func (h *RequestHandler) executeMultipart(w http.ResponseWriter, r *http.Request
 		if savedReq.CollectionID.Valid {
 			collectionID = savedReq.CollectionID.Int64
 		}
-		postResult := h.flowRunner.ExecuteScriptForRequestWithResponse(r.Context(), savedReq.PostScript.String, runtimeVars, result, savedReq.Url, savedReq.Method, reqHeaders, savedReq.Body.String, collectionID)
+		postResult := h.flowRunner.ExecuteScriptForRequestWithResponse(ctx, savedReq.PostScript.String, runtimeVars, result, savedReq.Url, savedReq.Method, reqHeaders, savedReq.Body.String, collectionID)
 		resp.PostScriptResult = postResult
 	}
+	scriptReqs.Attach(ctx, h.queries, result)
 
 	respondJSON(w, http.StatusOK, resp)
 }
 
 func (h *RequestHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, service.QuotaRequests) {
+		return
+	}
+
 	id, err := parseID(r, "id")
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid ID")
@@ -482,18 +707,26 @@ func (h *RequestHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	req, err := h.queries.CreateRequest(r.Context(), repository.CreateRequestParams{
-		CollectionID: source.CollectionID,
-		Name:         source.Name + " (Copy)",
-		Method:       source.Method,
-		Url:          source.Url,
-		Headers:      source.Headers,
-		Body:         source.Body,
-		BodyType:     source.BodyType,
-		Cookies:      source.Cookies,
-		ProxyID:      source.ProxyID,
-		WorkspaceID:  source.WorkspaceID,
-		PreScript:    source.PreScript,
-		PostScript:   source.PostScript,
+		CollectionID:      source.CollectionID,
+		Name:              source.Name + " (Copy)",
+		Method:            source.Method,
+		Url:               source.Url,
+		Headers:           source.Headers,
+		Body:              source.Body,
+		BodyType:          source.BodyType,
+		Cookies:           source.Cookies,
+		ProxyID:           source.ProxyID,
+		WorkspaceID:       source.WorkspaceID,
+		PreScript:         source.PreScript,
+		PostScript:        source.PostScript,
+		ResponseTransform: source.ResponseTransform,
+		Auth:              source.Auth,
+		TimeoutMs:         source.TimeoutMs,
+		RetryCount:        source.RetryCount,
+		RetryBackoffMs:    source.RetryBackoffMs,
+		RetryOnStatus:     source.RetryOnStatus,
+		DisableRedirects:  source.DisableRedirects,
+		MaxRedirects:      source.MaxRedirects,
 	})
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
@@ -504,6 +737,10 @@ func (h *RequestHandler) Duplicate(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *RequestHandler) ExecuteAdhoc(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, executionQuotas...) {
+		return
+	}
+
 	ct := r.Header.Get("Content-Type")
 	if strings.HasPrefix(ct, "multipart/form-data") {
 		h.executeAdhocMultipart(w, r)
@@ -524,13 +761,53 @@ func (h *RequestHandler) ExecuteAdhoc(w http.ResponseWriter, r *http.Request) {
 		reqBody.Method = "GET"
 	}
 
-	result, err := h.executor.ExecuteAdhoc(r.Context(), reqBody.Method, reqBody.URL, reqBody.Headers, reqBody.Body, reqBody.Variables, reqBody.ProxyID)
+	resp, err := h.executeAdhoc(r.Context(), reqBody)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondJSON(w, http.StatusOK, result)
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// executeAdhoc runs an unsaved request after its pre-script, if any
+func (h *RequestHandler) executeAdhoc(ctx context.Context, req AdhocExecuteRequest) (RequestExecuteResponse, error) {
+	resp := RequestExecuteResponse{}
+	var scriptReqs *service.ScriptRequests
+	if req.PreScript != "" {
+		ctx, scriptReqs = service.WithScriptRequests(ctx, adhocProxy(req.ProxyID))
+		resp.PreScriptResult, req.Variables = h.runPreScript(ctx, req.PreScript, req.Variables)
+	}
+
+	result, err := h.executor.ExecuteAdhoc(ctx, req.Method, req.URL, req.Headers, req.Body, req.Variables, req.ProxyID)
+	if err != nil {
+		return resp, err
+	}
+	resp.ExecuteResult = result
+	scriptReqs.Attach(ctx, h.queries, result)
+	return resp, nil
+}
+
+// runPreScript runs an unsaved request's pre-script and adds the variables
+// it sets to vars. Variables sent with the execution take precedence, as for
+// saved requests.
+func (h *RequestHandler) runPreScript(ctx context.Context, script string, vars map[string]string) (*service.ScriptResult, map[string]string) {
+	result := h.flowRunner.ExecuteScriptForRequest(ctx, script, make(map[string]string), 0)
+	if vars == nil {
+		vars = make(map[string]string, len(result.UpdatedVars))
+	}
+	for k, v := range result.UpdatedVars {
+		if _, exists := vars[k]; !exists {
+			vars[k] = v
+		}
+	}
+	return result, vars
+}
+
+// adhocProxy is the proxy setting of an unsaved request's proxyId, which
+// pm.sendRequest calls of its scripts share
+func adhocProxy(proxyID *int64) sql.NullInt64 {
+	return (&service.RequestOverrides{ProxyID: proxyID}).Proxy(sql.NullInt64{})
 }
 
 func (h *RequestHandler) executeAdhocMultipart(w http.ResponseWriter, r *http.Request) {
@@ -546,6 +823,7 @@ func (h *RequestHandler) executeAdhocMultipart(w http.ResponseWriter, r *http.Re
 		Headers   string            `json:"headers"`
 		Variables map[string]string `json:"variables"`
 		ProxyID   *int64            `json:"proxyId"`
+		PreScript string            `json:"preScript"`
 	}
 	if metaStr := r.FormValue("_metadata"); metaStr != "" {
 		if err := json.Unmarshal([]byte(metaStr), &meta); err != nil {
@@ -593,14 +871,24 @@ func (h *RequestHandler) executeAdhocMultipart(w http.ResponseWriter, r *http.Re
 		}
 	}
 
+	ctx := r.Context()
+	resp := RequestExecuteResponse{}
+	var scriptReqs *service.ScriptRequests
+	if meta.PreScript != "" {
+		ctx, scriptReqs = service.WithScriptRequests(ctx, adhocProxy(meta.ProxyID))
+		resp.PreScriptResult, meta.Variables = h.runPreScript(ctx, meta.PreScript, meta.Variables)
+	}
+
 	itemsJSON := r.FormValue("_items")
-	result, err := h.executor.ExecuteAdhocFormData(r.Context(), meta.Method, meta.URL, meta.Headers, itemsJSON, meta.Variables, meta.ProxyID, formDataFiles)
+	result, err := h.executor.ExecuteAdhocFormData(ctx, meta.Method, meta.URL, meta.Headers, itemsJSON, meta.Variables, meta.ProxyID, formDataFiles)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	resp.ExecuteResult = result
+	scriptReqs.Attach(ctx, h.queries, result)
 
-	respondJSON(w, http.StatusOK, result)
+	respondJSON(w, http.StatusOK, resp)
 }
 
 type RequestReorderItem struct {
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type RequestDraftResponse struct {
+	RequestID   int64                `json:"requestId"`
+	Draft       service.RequestDraft `json:"draft"`
+	BaseVersion int64                `json:"baseVersion"`
+	Version     int64                `json:"version"`
+	Revision    int64                `json:"revision"`
+	// Stale is set when the request was saved after the draft was started
+	Stale     bool   `json:"stale"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+type RequestDraftDiffResponse struct {
+	RequestID int64                    `json:"requestId"`
+	Stale     bool                     `json:"stale"`
+	Changes   []service.DraftFieldDiff `json:"changes"`
+}
+
+func toRequestDraftResponse(req repository.Request, row repository.RequestDraft) RequestDraftResponse {
+	return RequestDraftResponse{
+		RequestID:   req.ID,
+		Draft:       service.ParseRequestDraft(row.Data, req),
+		BaseVersion: row.BaseVersion,
+		Version:     req.Version,
+		Revision:    row.Revision,
+		Stale:       row.BaseVersion != req.Version,
+		UpdatedAt:   formatTime(row.UpdatedAt),
+	}
+}
+
+// loadDraft returns the request's autosaved draft, if any
+func (h *RequestHandler) loadDraft(ctx context.Context, id int64) (service.RequestDraft, bool) {
+	row, err := h.queries.GetRequestDraft(ctx, id)
+	if err != nil {
+		return service.RequestDraft{}, false
+	}
+	req, err := h.queries.GetRequest(ctx, id)
+	if err != nil {
+		return service.RequestDraft{}, false
+	}
+	return service.ParseRequestDraft(row.Data, req), true
+}
+
+// getRequestAndDraft writes the error response itself when either is missing
+func (h *RequestHandler) getRequestAndDraft(w http.ResponseWriter, r *http.Request) (repository.Request, repository.RequestDraft, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.Request{}, repository.RequestDraft{}, false
+	}
+	req, err := h.queries.GetRequest(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Request not found")
+		return repository.Request{}, repository.RequestDraft{}, false
+	}
+	row, err := h.queries.GetRequestDraft(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "No draft for this request")
+		return repository.Request{}, repository.RequestDraft{}, false
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return repository.Request{}, repository.RequestDraft{}, false
+	}
+	return req, row, true
+}
+
+func (h *RequestHandler) GetDraft(w http.ResponseWriter, r *http.Request) {
+	req, row, ok := h.getRequestAndDraft(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, toRequestDraftResponse(req, row))
+}
+
+// PatchDraft autosaves the given fields into the request's draft, starting
+// one from the saved definition if none exists. The saved request is not
+// touched.
+func (h *RequestHandler) PatchDraft(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var patch service.RequestDraftPatch
+	if err := decodeJSON(r, &patch); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req, err := h.queries.GetRequest(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Request not found")
+		return
+	}
+
+	draft := service.DraftFromRequest(req)
+	baseVersion := req.Version
+	if row, err := h.queries.GetRequestDraft(r.Context(), id); err == nil {
+		draft = service.ParseRequestDraft(row.Data, req)
+		baseVersion = row.BaseVersion
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	draft.Apply(patch)
+
+	data, err := json.Marshal(draft)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	row, err := h.queries.UpsertRequestDraft(r.Context(), repository.UpsertRequestDraftParams{
+		RequestID:   id,
+		Data:        string(data),
+		BaseVersion: baseVersion,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toRequestDraftResponse(req, row))
+}
+
+func (h *RequestHandler) DiffDraft(w http.ResponseWriter, r *http.Request) {
+	req, row, ok := h.getRequestAndDraft(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, RequestDraftDiffResponse{
+		RequestID: req.ID,
+		Stale:     row.BaseVersion != req.Version,
+		Changes:   service.DiffRequestDraft(req, service.ParseRequestDraft(row.Data, req)),
+	})
+}
+
+// ApplyDraft saves the draft as the request definition and removes it. A
+// draft started before the last save is rejected with 409 unless
+// ?force=true, so a save from another tab isn't silently overwritten.
+func (h *RequestHandler) ApplyDraft(w http.ResponseWriter, r *http.Request) {
+	req, row, ok := h.getRequestAndDraft(w, r)
+	if !ok {
+		return
+	}
+	if row.BaseVersion != req.Version && r.URL.Query().Get("force") != "true" {
+		respondError(w, http.StatusConflict, "Request was saved after this draft was started; review the diff or apply with ?force=true")
+		return
+	}
+
+	draft := service.ParseRequestDraft(row.Data, req)
+	draft.ApplyTo(&req)
+	updated, err := h.queries.UpdateRequest(r.Context(), repository.UpdateRequestParams{
+		ID:                req.ID,
+		CollectionID:      req.CollectionID,
+		Name:              req.Name,
+		Method:            req.Method,
+		Url:               req.Url,
+		Headers:           req.Headers,
+		Body:              req.Body,
+		BodyType:          req.BodyType,
+		Cookies:           req.Cookies,
+		ProxyID:           req.ProxyID,
+		PreScript:         req.PreScript,
+		PostScript:        req.PostScript,
+		ResponseTransform: req.ResponseTransform,
+		Auth:              req.Auth,
+		TimeoutMs:         req.TimeoutMs,
+		RetryCount:        req.RetryCount,
+		RetryBackoffMs:    req.RetryBackoffMs,
+		RetryOnStatus:     req.RetryOnStatus,
+		DisableRedirects:  req.DisableRedirects,
+		MaxRedirects:      req.MaxRedirects,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := h.queries.DeleteRequestDraft(r.Context(), req.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, toRequestResponse(updated))
+}
+
+func (h *RequestHandler) DiscardDraft(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	if err := h.queries.DeleteRequestDraft(r.Context(), id); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
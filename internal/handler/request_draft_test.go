@@ -0,0 +1,158 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupRequestDraftTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	q := testutil.SetupTestDB(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	fr := service.NewFlowRunner(q, re, vr)
+	reqH := handler.NewRequestHandler(q, re, fr)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/requests", reqH.Create)
+	r.Get("/api/requests/{id}", reqH.Get)
+	r.Put("/api/requests/{id}", reqH.Update)
+	r.Post("/api/requests/{id}/execute", reqH.Execute)
+	r.Get("/api/requests/{id}/draft", reqH.GetDraft)
+	r.Patch("/api/requests/{id}/draft", reqH.PatchDraft)
+	r.Delete("/api/requests/{id}/draft", reqH.DiscardDraft)
+	r.Get("/api/requests/{id}/draft/diff", reqH.DiffDraft)
+	r.Post("/api/requests/{id}/draft/apply", reqH.ApplyDraft)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func patchJSON(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPatch, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestRequestDraft_AutosaveExecuteAndApply(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer target.Close()
+	ts := setupRequestDraftTestServer(t)
+
+	var saved handler.RequestResponse
+	resp, _ := postJSON(ts.URL+"/api/requests", fmt.Sprintf(`{"name":"Users","method":"GET","url":"%s/saved"}`, target.URL))
+	readJSON(t, resp, &saved)
+	base := fmt.Sprintf("%s/api/requests/%d", ts.URL, saved.ID)
+
+	resp, _ = http.Get(base + "/draft")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("draft before autosave: status %d", resp.StatusCode)
+	}
+
+	// Two autosaves of different fields both survive
+	var draft handler.RequestDraftResponse
+	readJSON(t, patchJSON(t, base+"/draft", fmt.Sprintf(`{"url":"%s/draft"}`, target.URL)), &draft)
+	readJSON(t, patchJSON(t, base+"/draft", `{"name":"Users v2"}`), &draft)
+	if draft.Draft.URL != target.URL+"/draft" || draft.Draft.Name != "Users v2" || draft.Draft.Method != "GET" {
+		t.Errorf("draft = %+v", draft.Draft)
+	}
+	if draft.Revision != 2 || draft.Stale {
+		t.Errorf("revision %d stale %v", draft.Revision, draft.Stale)
+	}
+
+	var diff handler.RequestDraftDiffResponse
+	resp, _ = http.Get(base + "/draft/diff")
+	readJSON(t, resp, &diff)
+	if len(diff.Changes) != 2 || diff.Changes[0].Field != "name" || diff.Changes[1].Field != "url" {
+		t.Errorf("diff = %+v", diff.Changes)
+	}
+
+	// Execute runs the draft unless told otherwise; the saved request is unchanged
+	var result handler.RequestExecuteResponse
+	resp, _ = postJSON(base+"/execute", `{}`)
+	readJSON(t, resp, &result)
+	if result.Body != "/draft" {
+		t.Errorf("execute with draft hit %q", result.Body)
+	}
+	resp, _ = postJSON(base+"/execute", `{"useDraft":false}`)
+	readJSON(t, resp, &result)
+	if result.Body != "/saved" {
+		t.Errorf("execute without draft hit %q", result.Body)
+	}
+
+	var applied handler.RequestResponse
+	resp, _ = postJSON(base+"/draft/apply", ``)
+	readJSON(t, resp, &applied)
+	if applied.Name != "Users v2" || applied.URL != target.URL+"/draft" || applied.Version != saved.Version+1 {
+		t.Errorf("applied = %+v", applied)
+	}
+	resp, _ = http.Get(base + "/draft")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("draft after apply: status %d", resp.StatusCode)
+	}
+}
+
+func TestRequestDraft_StaleApplyAndDiscard(t *testing.T) {
+	ts := setupRequestDraftTestServer(t)
+
+	var saved handler.RequestResponse
+	resp, _ := postJSON(ts.URL+"/api/requests", `{"name":"Orders","method":"GET","url":"https://example.com/orders"}`)
+	readJSON(t, resp, &saved)
+	base := fmt.Sprintf("%s/api/requests/%d", ts.URL, saved.ID)
+
+	resp = patchJSON(t, base+"/draft", `{"method":"POST"}`)
+	resp.Body.Close()
+
+	// Saved from elsewhere after the draft was started
+	resp, _ = putJSON(base, `{"name":"Orders","method":"GET","url":"https://example.com/v2/orders"}`)
+	resp.Body.Close()
+
+	var draft handler.RequestDraftResponse
+	resp, _ = http.Get(base + "/draft")
+	readJSON(t, resp, &draft)
+	if !draft.Stale || draft.BaseVersion != saved.Version || draft.Version != saved.Version+1 {
+		t.Errorf("draft = %+v", draft)
+	}
+
+	resp, _ = postJSON(base+"/draft/apply", ``)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("stale apply: status %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, base+"/draft", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("discard: status %d", resp.StatusCode)
+	}
+
+	var current handler.RequestResponse
+	resp, _ = http.Get(base)
+	readJSON(t, resp, &current)
+	if current.Method != "GET" || current.URL != "https://example.com/v2/orders" {
+		t.Errorf("discard changed the saved request: %+v", current)
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"relay/internal/repository"
+)
+
+// Duplicate detection modes for ?duplicates= on request create and update.
+// Requests with the same method and URL (ignoring a trailing slash) in the
+// workspace count as duplicates.
+const (
+	duplicatesWarn   = "warn"   // save, listing the duplicates in the response
+	duplicatesReject = "reject" // don't save; 409 pointing at the existing request
+)
+
+type DuplicateRequest struct {
+	ID           int64  `json:"id"`
+	CollectionID *int64 `json:"collectionId,omitempty"`
+	Name         string `json:"name"`
+	Method       string `json:"method"`
+	URL          string `json:"url"`
+}
+
+// DuplicateConflictResponse is the 409 body of a rejected duplicate
+type DuplicateConflictResponse struct {
+	Error      string             `json:"error"`
+	Existing   DuplicateRequest   `json:"existing"` // the oldest duplicate
+	Duplicates []DuplicateRequest `json:"duplicates"`
+}
+
+// duplicatesMode reads ?duplicates=, responding 400 for unknown modes
+func duplicatesMode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	mode := r.URL.Query().Get("duplicates")
+	switch mode {
+	case "", duplicatesWarn, duplicatesReject:
+		return mode, true
+	}
+	respondError(w, http.StatusBadRequest, "duplicates must be warn or reject")
+	return "", false
+}
+
+// checkDuplicates looks up the workspace's requests matching method and URL
+// (other than excludeID). In reject mode it responds 409 when there are any
+// and returns false.
+func (h *RequestHandler) checkDuplicates(ctx context.Context, w http.ResponseWriter, mode string, wsID int64, method, url string, excludeID int64) ([]DuplicateRequest, bool) {
+	if mode == "" {
+		return nil, true
+	}
+	rows, err := h.queries.FindDuplicateRequests(ctx, repository.FindDuplicateRequestsParams{
+		WorkspaceID: wsID,
+		Method:      method,
+		Url:         url,
+		ExcludeID:   excludeID,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	dups := make([]DuplicateRequest, 0, len(rows))
+	for _, req := range rows {
+		d := DuplicateRequest{ID: req.ID, Name: req.Name, Method: req.Method, URL: req.Url}
+		if req.CollectionID.Valid {
+			collID := req.CollectionID.Int64
+			d.CollectionID = &collID
+		}
+		dups = append(dups, d)
+	}
+	if mode == duplicatesReject && len(dups) > 0 {
+		respondJSON(w, http.StatusConflict, DuplicateConflictResponse{
+			Error:      "A request with the same method and URL already exists",
+			Existing:   dups[0],
+			Duplicates: dups,
+		})
+		return nil, false
+	}
+	return dups, true
+}
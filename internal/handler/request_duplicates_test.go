@@ -0,0 +1,77 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequest_DuplicateDetection(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	reqH := handler.NewRequestHandler(q, nil, nil)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/requests", reqH.Create)
+	r.Put("/api/requests/{id}", reqH.Update)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	var first handler.RequestResponse
+	resp, _ := postJSON(ts.URL+"/api/requests?duplicates=reject", `{"name":"List users","method":"GET","url":"https://api.test/users"}`)
+	readJSON(t, resp, &first)
+	if first.ID == 0 || len(first.Duplicates) != 0 {
+		t.Fatalf("first = %+v", first)
+	}
+
+	// Method case and a trailing slash don't make a request different
+	resp, _ = postJSON(ts.URL+"/api/requests?duplicates=reject", `{"name":"Users again","method":"get","url":"https://api.test/users/"}`)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("reject: status %d, want 409", resp.StatusCode)
+	}
+	var conflict handler.DuplicateConflictResponse
+	readJSON(t, resp, &conflict)
+	if conflict.Existing.ID != first.ID || len(conflict.Duplicates) != 1 {
+		t.Errorf("conflict = %+v", conflict)
+	}
+
+	var second handler.RequestResponse
+	resp, _ = postJSON(ts.URL+"/api/requests?duplicates=warn", `{"name":"Users again","method":"GET","url":"https://api.test/users"}`)
+	readJSON(t, resp, &second)
+	if resp.StatusCode != http.StatusCreated || len(second.Duplicates) != 1 || second.Duplicates[0].ID != first.ID {
+		t.Errorf("warn: status %d, %+v", resp.StatusCode, second)
+	}
+
+	// Without the option duplicates are saved silently
+	resp, _ = postJSON(ts.URL+"/api/requests", `{"name":"Third","method":"GET","url":"https://api.test/users"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("no check: status %d", resp.StatusCode)
+	}
+
+	// Saving a request doesn't match itself; other workspaces don't count
+	var updated handler.RequestResponse
+	resp, _ = putJSON(fmt.Sprintf("%s/api/requests/%d?duplicates=warn", ts.URL, first.ID), `{"name":"List users","method":"POST","url":"https://api.test/users"}`)
+	readJSON(t, resp, &updated)
+	if len(updated.Duplicates) != 0 {
+		t.Errorf("update: %+v", updated)
+	}
+	resp, _ = postJSONWithWorkspace(ts.URL+"/api/requests?duplicates=reject", `{"name":"x","method":"GET","url":"https://api.test/users"}`, 2)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("other workspace: status %d", resp.StatusCode)
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/requests?duplicates=merge", `{"name":"x","method":"GET","url":"https://api.test/x"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad mode: status %d", resp.StatusCode)
+	}
+}
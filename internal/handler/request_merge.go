@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// Sides of a merge a field can be taken from
+const (
+	mergeSurvivor = "survivor"
+	mergeLoser    = "loser"
+)
+
+type RequestMergeHandler struct {
+	db      *sql.DB
+	queries *repository.Queries
+}
+
+func NewRequestMergeHandler(db *sql.DB, queries *repository.Queries) *RequestMergeHandler {
+	return &RequestMergeHandler{db: db, queries: queries}
+}
+
+type MergeRequestsRequest struct {
+	SurvivorID int64 `json:"survivorId"`
+	LoserID    int64 `json:"loserId"`
+	// Fields picks the side each field comes from ("survivor" by default or
+	// "loser"), e.g. {"headers": "loser", "postScript": "loser"}
+	Fields map[string]string `json:"fields"`
+}
+
+type MergeRequestsResponse struct {
+	Request      RequestResponse `json:"request"`
+	RewiredSteps int64           `json:"rewiredSteps"` // flow steps now linked to the survivor
+	RewiredNodes int64           `json:"rewiredNodes"` // graph request nodes now sending the survivor
+	MovedHistory int64           `json:"movedHistory"`
+	MovedMonitor bool            `json:"movedMonitor"` // the loser's monitor now checks the survivor
+}
+
+// mergeFields copy a request field from the loser into the merged request
+var mergeFields = map[string]func(merged *repository.UpdateRequestParams, loser repository.Request){
+	"collectionId": func(m *repository.UpdateRequestParams, l repository.Request) { m.CollectionID = l.CollectionID },
+	"name":         func(m *repository.UpdateRequestParams, l repository.Request) { m.Name = l.Name },
+	"method":       func(m *repository.UpdateRequestParams, l repository.Request) { m.Method = l.Method },
+	"url":          func(m *repository.UpdateRequestParams, l repository.Request) { m.Url = l.Url },
+	"headers":      func(m *repository.UpdateRequestParams, l repository.Request) { m.Headers = l.Headers },
+	"body":         func(m *repository.UpdateRequestParams, l repository.Request) { m.Body = l.Body },
+	"bodyType":     func(m *repository.UpdateRequestParams, l repository.Request) { m.BodyType = l.BodyType },
+	"cookies":      func(m *repository.UpdateRequestParams, l repository.Request) { m.Cookies = l.Cookies },
+	"proxyId":      func(m *repository.UpdateRequestParams, l repository.Request) { m.ProxyID = l.ProxyID },
+	"preScript":    func(m *repository.UpdateRequestParams, l repository.Request) { m.PreScript = l.PreScript },
+	"postScript":   func(m *repository.UpdateRequestParams, l repository.Request) { m.PostScript = l.PostScript },
+	"responseTransform": func(m *repository.UpdateRequestParams, l repository.Request) {
+		m.ResponseTransform = l.ResponseTransform
+	},
+	"auth": func(m *repository.UpdateRequestParams, l repository.Request) { m.Auth = l.Auth },
+}
+
+// Merge combines two requests of the workspace into the survivor, taking
+// each field from the side the caller picked, then points the loser's flow
+// steps, graph nodes, history and monitor at the survivor and deletes the
+// loser. Everything happens in one transaction.
+func (h *RequestMergeHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	var req MergeRequestsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.SurvivorID == 0 || req.LoserID == 0 || req.SurvivorID == req.LoserID {
+		respondError(w, http.StatusBadRequest, "survivorId and loserId must be two different requests")
+		return
+	}
+	for field, side := range req.Fields {
+		if _, ok := mergeFields[field]; !ok {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("unknown field %q", field))
+			return
+		}
+		if side != mergeSurvivor && side != mergeLoser {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("field %q: side must be survivor or loser", field))
+			return
+		}
+	}
+
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+	survivor, err := h.queries.GetRequest(ctx, req.SurvivorID)
+	if err != nil || survivor.WorkspaceID != wsID {
+		respondError(w, http.StatusNotFound, "Survivor request not found")
+		return
+	}
+	loser, err := h.queries.GetRequest(ctx, req.LoserID)
+	if err != nil || loser.WorkspaceID != wsID {
+		respondError(w, http.StatusNotFound, "Loser request not found")
+		return
+	}
+
+	merged := repository.UpdateRequestParams{
+		ID:                survivor.ID,
+		CollectionID:      survivor.CollectionID,
+		Name:              survivor.Name,
+		Method:            survivor.Method,
+		Url:               survivor.Url,
+		Headers:           survivor.Headers,
+		Body:              survivor.Body,
+		BodyType:          survivor.BodyType,
+		Cookies:           survivor.Cookies,
+		ProxyID:           survivor.ProxyID,
+		PreScript:         survivor.PreScript,
+		PostScript:        survivor.PostScript,
+		ResponseTransform: survivor.ResponseTransform,
+		Auth:              survivor.Auth,
+		TimeoutMs:         survivor.TimeoutMs,
+		RetryCount:        survivor.RetryCount,
+		RetryBackoffMs:    survivor.RetryBackoffMs,
+		RetryOnStatus:     survivor.RetryOnStatus,
+		DisableRedirects:  survivor.DisableRedirects,
+		MaxRedirects:      survivor.MaxRedirects,
+	}
+	for field, side := range req.Fields {
+		if side == mergeLoser {
+			mergeFields[field](&merged, loser)
+		}
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	resp, err := mergeRequests(ctx, h.queries.WithTx(tx), merged, loser.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func mergeRequests(ctx context.Context, q *repository.Queries, merged repository.UpdateRequestParams, loserID int64) (MergeRequestsResponse, error) {
+	var resp MergeRequestsResponse
+	saved, err := q.UpdateRequest(ctx, merged)
+	if err != nil {
+		return resp, err
+	}
+	resp.Request = toRequestResponse(saved)
+
+	survivorID := saved.ID
+	if resp.RewiredSteps, err = q.RewireFlowStepsRequest(ctx, repository.RewireFlowStepsRequestParams{SurvivorID: survivorID, LoserID: loserID}); err != nil {
+		return resp, err
+	}
+	if resp.RewiredNodes, err = q.RewireFlowNodesRequest(ctx, repository.RewireFlowNodesRequestParams{SurvivorID: survivorID, LoserID: loserID}); err != nil {
+		return resp, err
+	}
+	if resp.MovedHistory, err = q.MoveRequestHistory(ctx, repository.MoveRequestHistoryParams{SurvivorID: survivorID, LoserID: loserID}); err != nil {
+		return resp, err
+	}
+	// The survivor keeps its own monitor; the loser's goes with the loser
+	moved, err := q.MoveRequestMonitor(ctx, repository.MoveRequestMonitorParams{SurvivorID: survivorID, LoserID: loserID})
+	if err != nil {
+		return resp, err
+	}
+	resp.MovedMonitor = moved > 0
+	return resp, q.DeleteRequest(ctx, loserID)
+}
@@ -0,0 +1,89 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequest_Merge(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	h := handler.NewRequestMergeHandler(db, q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/requests/merge", h.Merge)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	survivor, _ := q.CreateRequest(ctx, repository.CreateRequestParams{
+		Name: "Users", Method: "GET", Url: "https://api.test/users", WorkspaceID: 1,
+		Headers: sql.NullString{String: `{"Accept":"text/plain"}`, Valid: true},
+	})
+	loser, _ := q.CreateRequest(ctx, repository.CreateRequestParams{
+		Name: "Users (copy)", Method: "GET", Url: "https://api.test/users/", WorkspaceID: 1,
+		Headers: sql.NullString{String: `{"Accept":"application/json"}`, Valid: true},
+	})
+	other, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "x", Method: "GET", Url: "https://x", WorkspaceID: 2})
+
+	flow, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "Sync", WorkspaceID: 1})
+	q.CreateFlowStep(ctx, repository.CreateFlowStepParams{FlowID: flow.ID, RequestID: sql.NullInt64{Int64: loser.ID, Valid: true}, StepOrder: 1, Name: "list", Method: "GET"})
+	q.CreateHistory(ctx, repository.CreateHistoryParams{RequestID: sql.NullInt64{Int64: loser.ID, Valid: true}, Method: "GET", Url: loser.Url, WorkspaceID: 1})
+	q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: loser.ID, IntervalSeconds: 60, Enabled: 1})
+
+	for name, body := range map[string]string{
+		"same request":  fmt.Sprintf(`{"survivorId":%d,"loserId":%d}`, survivor.ID, survivor.ID),
+		"unknown field": fmt.Sprintf(`{"survivorId":%d,"loserId":%d,"fields":{"color":"loser"}}`, survivor.ID, loser.ID),
+		"bad side":      fmt.Sprintf(`{"survivorId":%d,"loserId":%d,"fields":{"url":"both"}}`, survivor.ID, loser.ID),
+	} {
+		resp, _ := postJSON(ts.URL+"/api/requests/merge", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, resp.StatusCode)
+		}
+	}
+	resp, _ := postJSON(ts.URL+"/api/requests/merge", fmt.Sprintf(`{"survivorId":%d,"loserId":%d}`, survivor.ID, other.ID))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", resp.StatusCode)
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/requests/merge", fmt.Sprintf(`{"survivorId":%d,"loserId":%d,"fields":{"headers":"loser"}}`, survivor.ID, loser.ID))
+	var merged handler.MergeRequestsResponse
+	readJSON(t, resp, &merged)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("merge: status = %d", resp.StatusCode)
+	}
+	if merged.Request.ID != survivor.ID || merged.Request.Name != "Users" || merged.Request.Headers != `{"Accept":"application/json"}` {
+		t.Errorf("merged request = %+v", merged.Request)
+	}
+	if merged.RewiredSteps != 1 || merged.MovedHistory != 1 || !merged.MovedMonitor {
+		t.Errorf("merge = %+v", merged)
+	}
+
+	if _, err := q.GetRequest(ctx, loser.ID); err != sql.ErrNoRows {
+		t.Errorf("loser still exists: %v", err)
+	}
+	steps, _ := q.ListFlowSteps(ctx, flow.ID)
+	if len(steps) != 1 || steps[0].RequestID.Int64 != survivor.ID {
+		t.Errorf("steps = %+v", steps)
+	}
+	history, _ := q.ListHistoryByRequest(ctx, repository.ListHistoryByRequestParams{RequestID: sql.NullInt64{Int64: survivor.ID, Valid: true}, Limit: 10})
+	if len(history) != 1 {
+		t.Errorf("survivor history = %d entries, want 1", len(history))
+	}
+	if m, err := q.GetMonitorByRequest(ctx, survivor.ID); err != nil || m.IntervalSeconds != 60 {
+		t.Errorf("survivor monitor = %+v, %v", m, err)
+	}
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// ResponseAnnotationRequest documents one JSON path of the request's response
+type ResponseAnnotationRequest struct {
+	Path        string `json:"path"` // $.field, $.items[].id
+	Description string `json:"description"`
+	Deprecated  bool   `json:"deprecated"`
+}
+
+type ResponseAnnotationResponse struct {
+	ID          int64  `json:"id"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+	Deprecated  bool   `json:"deprecated"`
+	// InExample is false when the latest recorded response has no value at
+	// the path (omitted while there is no JSON response to compare with)
+	InExample *bool  `json:"inExample,omitempty"`
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+func toResponseAnnotationResponse(a repository.ResponseAnnotation, examplePaths map[string]bool) ResponseAnnotationResponse {
+	resp := ResponseAnnotationResponse{
+		ID:          a.ID,
+		Path:        a.Path,
+		Description: a.Description,
+		Deprecated:  a.Deprecated == 1,
+		UpdatedAt:   formatTime(a.UpdatedAt),
+	}
+	if examplePaths != nil {
+		in := examplePaths[a.Path]
+		resp.InExample = &in
+	}
+	return resp
+}
+
+// listResponseAnnotations returns the request's annotations, nil when it has none
+func listResponseAnnotations(ctx context.Context, q *repository.Queries, requestID int64) ([]ResponseAnnotationResponse, error) {
+	annotations, err := q.ListResponseAnnotations(ctx, requestID)
+	if err != nil || len(annotations) == 0 {
+		return nil, err
+	}
+	resp := make([]ResponseAnnotationResponse, 0, len(annotations))
+	for _, a := range annotations {
+		resp = append(resp, toResponseAnnotationResponse(a, nil))
+	}
+	return resp, nil
+}
+
+// ListAnnotations returns the request's response annotations, each flagged
+// with whether the latest JSON response still has the path
+func (h *RequestHandler) ListAnnotations(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.requestByID(w, r)
+	if !ok {
+		return
+	}
+	annotations, err := h.queries.ListResponseAnnotations(r.Context(), req.ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	examplePaths := h.examplePaths(r.Context(), req.ID)
+	resp := make([]ResponseAnnotationResponse, 0, len(annotations))
+	for _, a := range annotations {
+		resp = append(resp, toResponseAnnotationResponse(a, examplePaths))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// PutAnnotation creates or replaces the annotation at a path
+func (h *RequestHandler) PutAnnotation(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.requestByID(w, r)
+	if !ok {
+		return
+	}
+	var body ResponseAnnotationRequest
+	if err := decodeJSON(r, &body); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := service.ValidateResponseAnnotation(body.Path, body.Description); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := repository.UpsertResponseAnnotationParams{
+		RequestID:   req.ID,
+		Path:        body.Path,
+		Description: body.Description,
+	}
+	if body.Deprecated {
+		params.Deprecated = 1
+	}
+	a, err := h.queries.UpsertResponseAnnotation(r.Context(), params)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toResponseAnnotationResponse(a, h.examplePaths(r.Context(), req.ID)))
+}
+
+func (h *RequestHandler) DeleteAnnotation(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.requestByID(w, r)
+	if !ok {
+		return
+	}
+	annotationID, err := parseID(r, "annotationId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid annotation ID")
+		return
+	}
+	a, err := h.queries.GetResponseAnnotation(r.Context(), annotationID)
+	if err != nil || a.RequestID != req.ID {
+		respondError(w, http.StatusNotFound, "Annotation not found")
+		return
+	}
+	if err := h.queries.DeleteResponseAnnotation(r.Context(), a.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// examplePaths returns the paths of the request's latest JSON response, nil
+// when there is none
+func (h *RequestHandler) examplePaths(ctx context.Context, requestID int64) map[string]bool {
+	example, ok := service.LatestJSONResponse(ctx, h.queries, requestID)
+	if !ok {
+		return nil
+	}
+	return service.ResponseExamplePaths(example.ResponseBody.String)
+}
+
+// requestByID loads the {id} request of the current workspace, responding 400/404
+func (h *RequestHandler) requestByID(w http.ResponseWriter, r *http.Request) (repository.Request, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.Request{}, false
+	}
+	req, err := h.queries.GetRequest(r.Context(), id)
+	if err != nil || req.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Request not found")
+		return repository.Request{}, false
+	}
+	return req, true
+}
@@ -0,0 +1,139 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestResponseAnnotations_OpenAPIExport(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"alice","legacyId":"u1","roles":[{"name":"admin"}]}`))
+	}))
+	defer api.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	collH := handler.NewCollectionHandler(q, nil)
+	reqH := handler.NewRequestHandler(q, re, nil)
+	exportH := handler.NewExportHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/collections", collH.Create)
+	r.Post("/api/requests", reqH.Create)
+	r.Post("/api/requests/{id}/execute", reqH.Execute)
+	r.Get("/api/requests/{id}/annotations", reqH.ListAnnotations)
+	r.Put("/api/requests/{id}/annotations", reqH.PutAnnotation)
+	r.Delete("/api/requests/{id}/annotations/{annotationId}", reqH.DeleteAnnotation)
+	r.Get("/api/export/collections/{id}", exportH.Collection)
+	r.Get("/api/export/collections/{id}/openapi", exportH.OpenAPI)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := postJSON(ts.URL+"/api/collections", `{"name":"Users API"}`)
+	var coll handler.CollectionResponse
+	readJSON(t, resp, &coll)
+	resp, _ = postJSON(ts.URL+"/api/requests", fmt.Sprintf(`{"collectionId":%d,"name":"Get user","method":"GET","url":%q}`, coll.ID, api.URL+"/users/{{userId}}?expand=roles"))
+	var req handler.RequestResponse
+	readJSON(t, resp, &req)
+	annotationsURL := fmt.Sprintf("%s/api/requests/%d/annotations", ts.URL, req.ID)
+
+	put := func(body string) (*http.Response, handler.ResponseAnnotationResponse) {
+		t.Helper()
+		resp, err := putJSON(annotationsURL, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var a handler.ResponseAnnotationResponse
+		if resp.StatusCode == http.StatusOK {
+			readJSON(t, resp, &a)
+		} else {
+			resp.Body.Close()
+		}
+		return resp, a
+	}
+
+	// Annotations can be written before any response is recorded
+	resp, legacy := put(`{"path":"$.legacyId","description":"Use id","deprecated":true}`)
+	if resp.StatusCode != http.StatusOK || legacy.InExample != nil {
+		t.Fatalf("put without example: status %d, %+v", resp.StatusCode, legacy)
+	}
+	if resp, _ := put(`{"path":"legacyId"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("path without $: status %d", resp.StatusCode)
+	}
+
+	resp, _ = postJSON(fmt.Sprintf("%s/api/requests/%d/execute", ts.URL, req.ID), `{}`)
+	resp.Body.Close()
+
+	put(`{"path":"$.roles[].name","description":"Role name"}`)
+	put(`{"path":"$.email","description":"Primary address"}`)
+	// Writing the same path again replaces the annotation
+	resp, legacy2 := put(`{"path":"$.legacyId","description":"Use id instead","deprecated":true}`)
+	if legacy2.ID != legacy.ID || legacy2.Description != "Use id instead" || legacy2.InExample == nil || !*legacy2.InExample {
+		t.Errorf("upsert: %+v", legacy2)
+	}
+
+	resp, _ = http.Get(annotationsURL)
+	var list []handler.ResponseAnnotationResponse
+	readJSON(t, resp, &list)
+	if len(list) != 3 || list[0].Path != "$.email" || *list[0].InExample {
+		t.Fatalf("list = %+v", list)
+	}
+
+	resp, _ = http.Get(fmt.Sprintf("%s/api/export/collections/%d", ts.URL, coll.ID))
+	var export handler.CollectionExport
+	readJSON(t, resp, &export)
+	if got := export.Collection.Requests[0].Annotations; len(got) != 3 {
+		t.Errorf("exported annotations = %+v", got)
+	}
+
+	resp, _ = http.Get(fmt.Sprintf("%s/api/export/collections/%d/openapi", ts.URL, coll.ID))
+	var doc service.OpenAPIDocument
+	readJSON(t, resp, &doc)
+	op := doc.Paths["/users/{userId}"]["get"]
+	if op == nil {
+		t.Fatalf("paths = %+v", doc.Paths)
+	}
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "userId" {
+		t.Errorf("parameters = %+v", op.Parameters)
+	}
+	schema := op.Responses["200"].Content["application/json"].Schema
+	props := schema["properties"].(map[string]interface{})
+	if got := props["legacyId"].(map[string]interface{}); got["deprecated"] != true || got["description"] != "Use id instead" {
+		t.Errorf("legacyId schema = %v", got)
+	}
+	role := props["roles"].(map[string]interface{})["items"].(map[string]interface{})["properties"].(map[string]interface{})["name"].(map[string]interface{})
+	if role["description"] != "Role name" {
+		t.Errorf("roles[].name schema = %v", role)
+	}
+	if props["id"].(map[string]interface{})["type"] != "integer" {
+		t.Errorf("id schema = %v", props["id"])
+	}
+
+	deleteReq, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/%d", annotationsURL, list[0].ID), nil)
+	resp, _ = http.DefaultClient.Do(deleteReq)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status %d", resp.StatusCode)
+	}
+	resp, _ = http.DefaultClient.Do(deleteReq)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("second delete: status %d", resp.StatusCode)
+	}
+
+	// Requests of other workspaces are not visible
+	resp, _ = getWithWorkspace(annotationsURL, 2)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status %d", resp.StatusCode)
+	}
+}
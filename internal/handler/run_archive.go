@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type RunArchiveHandler struct {
+	queries  *repository.Queries
+	archiver *service.Archiver // nil when no archive store is configured
+}
+
+func NewRunArchiveHandler(queries *repository.Queries, archiver *service.Archiver) *RunArchiveHandler {
+	return &RunArchiveHandler{queries: queries, archiver: archiver}
+}
+
+type ArchiveResponse struct {
+	ID        int64  `json:"id"`
+	Kind      string `json:"kind"` // run or history
+	RunID     string `json:"runId,omitempty"`
+	FlowID    *int64 `json:"flowId,omitempty"`
+	Storage   string `json:"storage"`
+	ObjectKey string `json:"objectKey"`
+	Entries   int64  `json:"entries"` // history entries in the archive
+	Size      int64  `json:"size"`    // compressed bytes
+	OldestAt  string `json:"oldestAt,omitempty"`
+	NewestAt  string `json:"newestAt,omitempty"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// ArchiveContentResponse is an archive read back from the store
+type ArchiveContentResponse struct {
+	ArchiveResponse
+	Timeline json.RawMessage   `json:"timeline,omitempty"`
+	Result   json.RawMessage   `json:"result,omitempty"` // the run's FlowResult
+	History  []HistoryResponse `json:"history"`
+}
+
+func toArchiveResponse(a repository.Archive) ArchiveResponse {
+	resp := ArchiveResponse{
+		ID:        a.ID,
+		Kind:      a.Kind,
+		RunID:     a.RunID.String,
+		Storage:   a.Storage,
+		ObjectKey: a.ObjectKey,
+		Entries:   a.Entries,
+		Size:      a.Size,
+		OldestAt:  formatTime(a.OldestAt),
+		NewestAt:  formatTime(a.NewestAt),
+		CreatedAt: formatTime(a.CreatedAt),
+	}
+	if a.FlowID.Valid {
+		resp.FlowID = &a.FlowID.Int64
+	}
+	return resp
+}
+
+// List returns the workspace's archives, newest first (?kind=run|history,
+// ?limit=, default 100)
+func (h *RunArchiveHandler) List(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	if kind != "" && kind != service.ArchiveKindRun && kind != service.ArchiveKindHistory {
+		respondError(w, http.StatusBadRequest, "kind must be run or history")
+		return
+	}
+	limit := int64(100)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	rows, err := h.queries.ListArchives(r.Context(), repository.ListArchivesParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Kind:        kind,
+		Limit:       limit,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]ArchiveResponse, len(rows))
+	for i, a := range rows {
+		resp[i] = toArchiveResponse(a)
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// Get re-hydrates an archive: it reads the object back from the store and
+// returns the run's timeline, result and history entries
+func (h *RunArchiveHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	a, err := h.queries.GetArchive(r.Context(), id)
+	if err != nil || a.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Archive not found")
+		return
+	}
+	h.respondContent(w, r, a)
+}
+
+// GetRun re-hydrates the archive of a flow run by its run ID, once its
+// timeline has left the database
+func (h *RunArchiveHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	a, err := h.queries.GetRunArchive(r.Context(), repository.GetRunArchiveParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		RunID:       sql.NullString{String: chi.URLParam(r, "runId"), Valid: true},
+	})
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Archived run not found")
+		return
+	}
+	h.respondContent(w, r, a)
+}
+
+func (h *RunArchiveHandler) respondContent(w http.ResponseWriter, r *http.Request, a repository.Archive) {
+	if h.archiver == nil {
+		respondError(w, http.StatusServiceUnavailable, "No archive store is configured (ARCHIVE_DIR or ARCHIVE_S3_BUCKET)")
+		return
+	}
+	doc, err := h.archiver.Load(r.Context(), a)
+	if errors.Is(err, service.ErrArchiveNotFound) {
+		respondError(w, http.StatusGone, "Archive object is missing from the store")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	resp := ArchiveContentResponse{
+		ArchiveResponse: toArchiveResponse(a),
+		Timeline:        doc.Timeline,
+		Result:          doc.Result,
+		History:         make([]HistoryResponse, len(doc.History)),
+	}
+	for i, hist := range doc.History {
+		resp.History[i] = toHistoryResponse(hist)
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
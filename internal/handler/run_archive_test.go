@@ -0,0 +1,90 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRunArchive_ListAndRehydrate(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	store, err := service.NewDirArchiveStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	archiver := service.NewArchiver(q, store)
+
+	hist, _ := q.CreateHistory(ctx, repository.CreateHistoryParams{
+		Method: "POST", Url: "https://example.com/orders", WorkspaceID: 1,
+		ExecutionGroupID: sql.NullString{String: "run-1", Valid: true},
+	})
+	q.CreateRunTimeline(ctx, repository.CreateRunTimelineParams{RunID: "run-1", WorkspaceID: 1, FlowID: 3, Timeline: `{"runId":"run-1"}`})
+	db.Exec(`UPDATE run_timelines SET created_at = datetime('now', '-8 days')`)
+	retention := service.NewHistoryRetention(q)
+	retention.SetArchiver(archiver)
+	if _, err := retention.Prune(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	newServer := func(archiver *service.Archiver) *httptest.Server {
+		h := handler.NewRunArchiveHandler(q, archiver)
+		r := chi.NewRouter()
+		r.Use(middleware.WorkspaceID)
+		r.Get("/api/archives", h.List)
+		r.Get("/api/archives/{id}", h.Get)
+		r.Get("/api/archives/runs/{runId}", h.GetRun)
+		ts := httptest.NewServer(r)
+		t.Cleanup(ts.Close)
+		return ts
+	}
+	ts := newServer(archiver)
+
+	var list []handler.ArchiveResponse
+	resp, _ := http.Get(ts.URL + "/api/archives?kind=run")
+	readJSON(t, resp, &list)
+	if len(list) != 1 || list[0].RunID != "run-1" || list[0].FlowID == nil || *list[0].FlowID != 3 || list[0].Entries != 1 {
+		t.Fatalf("list = %+v", list)
+	}
+
+	var content handler.ArchiveContentResponse
+	resp, _ = http.Get(ts.URL + "/api/archives/runs/run-1")
+	readJSON(t, resp, &content)
+	if string(content.Timeline) != `{"runId":"run-1"}` || len(content.History) != 1 ||
+		content.History[0].ID != hist.ID || content.History[0].URL != "https://example.com/orders" || content.History[0].RunID != "run-1" {
+		t.Errorf("rehydrated run = %+v", content)
+	}
+	resp, _ = http.Get(fmt.Sprintf("%s/api/archives/%d", ts.URL, list[0].ID))
+	readJSON(t, resp, &content)
+	if content.ID != list[0].ID || len(content.History) != 1 {
+		t.Errorf("get = %+v", content)
+	}
+
+	for url, want := range map[string]int{
+		ts.URL + "/api/archives?kind=flows":             http.StatusBadRequest,
+		ts.URL + "/api/archives/runs/run-9":             http.StatusNotFound,
+		newServer(nil).URL + "/api/archives/runs/run-1": http.StatusServiceUnavailable,
+	} {
+		resp, _ = http.Get(url)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: status %d, want %d", url, resp.StatusCode, want)
+		}
+	}
+	resp, _ = getWithWorkspace(fmt.Sprintf("%s/api/archives/%d", ts.URL, list[0].ID), 2)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"relay/internal/middleware"
+	"relay/internal/service"
+)
+
+type RunByNameRequest struct {
+	Type      string            `json:"type"` // request | flow
+	Name      string            `json:"name"`
+	Variables map[string]string `json:"variables"`
+	SafeMode  bool              `json:"safeMode"` // only send GET, HEAD and OPTIONS requests
+}
+
+type RunByNameResponse struct {
+	Type    string                  `json:"type"`
+	ID      int64                   `json:"id"`
+	Name    string                  `json:"name"`
+	Match   string                  `json:"match"` // exact | prefix | substring | fuzzy
+	Request *RequestExecuteResponse `json:"request,omitempty"`
+	Flow    *service.FlowResult     `json:"flow,omitempty"`
+}
+
+// RunByNameConflict lists the candidates when a name matches several items equally well
+type RunByNameConflict struct {
+	Error      string              `json:"error"`
+	Candidates []service.NameMatch `json:"candidates"`
+}
+
+// RunByName executes the request or flow in the current workspace whose name
+// best matches the given name, so command palettes and CLIs need no IDs.
+func (h *RequestHandler) RunByName(w http.ResponseWriter, r *http.Request) {
+	if !enforceQuotas(w, r, h.queries, executionQuotas...) {
+		return
+	}
+
+	var req RunByNameRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+
+	var items []service.NamedItem
+	switch req.Type {
+	case "request":
+		requests, err := h.queries.ListRequests(ctx, wsID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, sr := range requests {
+			// WebSocket requests need an interactive session
+			if sr.Method != "WS" && !sr.ArchivedAt.Valid {
+				items = append(items, service.NamedItem{ID: sr.ID, Name: sr.Name})
+			}
+		}
+	case "flow":
+		flows, err := h.queries.ListFlows(ctx, wsID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, f := range flows {
+			if !f.ArchivedAt.Valid {
+				items = append(items, service.NamedItem{ID: f.ID, Name: f.Name})
+			}
+		}
+	default:
+		respondError(w, http.StatusBadRequest, `type must be "request" or "flow"`)
+		return
+	}
+
+	match, candidates := service.ResolveName(req.Name, items)
+	if len(candidates) > 0 {
+		respondJSON(w, http.StatusConflict, RunByNameConflict{
+			Error:      fmt.Sprintf("%q matches %d %ss, be more specific", req.Name, len(candidates), req.Type),
+			Candidates: candidates,
+		})
+		return
+	}
+	if match == nil {
+		respondError(w, http.StatusNotFound, fmt.Sprintf("no %s matches %q", req.Type, req.Name))
+		return
+	}
+
+	resp := RunByNameResponse{Type: req.Type, ID: match.ID, Name: match.Name, Match: match.Kind}
+	if req.SafeMode {
+		ctx = service.WithSafeMode(ctx)
+	}
+	if req.Type == "flow" {
+		result, err := h.flowRunner.RunWithOptions(ctx, match.ID, &service.RunOptions{InitialVars: req.Variables}, nil)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp.Flow = result
+	} else {
+		result, err := h.executeSaved(ctx, match.ID, req.Variables, nil)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		resp.Request = &result
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,92 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRunByName(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	defer api.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	fr := service.NewFlowRunner(q, re, vr)
+	reqH := handler.NewRequestHandler(q, re, fr)
+	flowH := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/requests", reqH.Create)
+	r.Post("/api/flows", flowH.Create)
+	r.Post("/api/flows/{id}/steps", flowH.CreateStep)
+	r.Post("/api/run", reqH.RunByName)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	for _, name := range []string{"List Orders", "List Users"} {
+		resp, _ := postJSON(ts.URL+"/api/requests", fmt.Sprintf(`{"name":%q,"method":"GET","url":%q}`, name, api.URL+"/{{resource}}"))
+		resp.Body.Close()
+	}
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Checkout"}`)
+	var flow handler.FlowResponse
+	readJSON(t, resp, &flow)
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/steps", ts.URL, flow.ID), fmt.Sprintf(`{"name":"Pay","method":"GET","url":%q}`, api.URL+"/pay"))
+	resp.Body.Close()
+
+	// Fuzzy request match with runtime variables
+	resp, err := postJSON(ts.URL+"/api/run", `{"type":"request","name":"lst ordr","variables":{"resource":"orders"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("run request: status %d", resp.StatusCode)
+	}
+	var run handler.RunByNameResponse
+	readJSON(t, resp, &run)
+	if run.Name != "List Orders" || run.Match != "fuzzy" || run.Request == nil || run.Request.Body != `{"path":"/orders"}` {
+		t.Errorf("request run = %+v", run)
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/run", `{"type":"flow","name":"checkout"}`)
+	readJSON(t, resp, &run)
+	if run.ID != flow.ID || run.Match != "exact" || run.Flow == nil || !run.Flow.Success || len(run.Flow.Steps) != 1 {
+		t.Errorf("flow run = %+v", run)
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/run", `{"type":"request","name":"list"}`)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("ambiguous: expected 409, got %d", resp.StatusCode)
+	}
+	var conflict handler.RunByNameConflict
+	readJSON(t, resp, &conflict)
+	if len(conflict.Candidates) != 2 {
+		t.Errorf("candidates = %+v", conflict.Candidates)
+	}
+
+	cases := map[string]int{
+		`{"type":"flow","name":"nothing like it"}`: http.StatusNotFound,
+		`{"type":"folder","name":"x"}`:             http.StatusBadRequest,
+		`{"type":"request","name":"  "}`:           http.StatusBadRequest,
+	}
+	for body, want := range cases {
+		resp, _ := postJSON(ts.URL+"/api/run", body)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: expected %d, got %d", body, want, resp.StatusCode)
+		}
+	}
+}
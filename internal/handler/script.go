@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"relay/internal/service"
+)
+
+type ScriptHandler struct {
+	apiSpec service.ScriptAPISpec
+}
+
+func NewScriptHandler() *ScriptHandler {
+	return &ScriptHandler{
+		apiSpec: service.NewJSScriptExecutor(nil).ScriptAPI(),
+	}
+}
+
+type ValidateScriptRequest struct {
+	Script string `json:"script"`
+}
+
+type ValidateScriptResponse struct {
+	Valid    bool                      `json:"valid"`
+	Language string                    `json:"language"`
+	Errors   []service.ValidationIssue `json:"errors"`
+}
+
+type ValidateConditionRequest struct {
+	Condition string `json:"condition"`
+}
+
+type ValidateConditionResponse struct {
+	Valid  bool                      `json:"valid"`
+	Errors []*service.ConditionError `json:"errors"`
+}
+
+// ValidateScript checks a pre/post script (JS or DSL) without executing it
+func (h *ScriptHandler) ValidateScript(w http.ResponseWriter, r *http.Request) {
+	var req ValidateScriptRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	language, issues := service.ValidateScript(req.Script)
+	if issues == nil {
+		issues = []service.ValidationIssue{}
+	}
+	respondJSON(w, http.StatusOK, ValidateScriptResponse{
+		Valid:    len(issues) == 0,
+		Language: language,
+		Errors:   issues,
+	})
+}
+
+// ValidateCondition checks the syntax of a step or DSL condition expression
+func (h *ScriptHandler) ValidateCondition(w http.ResponseWriter, r *http.Request) {
+	var req ValidateConditionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	resp := ValidateConditionResponse{Valid: true, Errors: []*service.ConditionError{}}
+	if err := service.ValidateCondition(req.Condition); err != nil {
+		resp.Valid = false
+		var condErr *service.ConditionError
+		if !errors.As(err, &condErr) {
+			condErr = &service.ConditionError{Msg: err.Error()}
+		}
+		resp.Errors = append(resp.Errors, condErr)
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// APISpec describes the pm.* scripting surface for editor autocomplete
+func (h *ScriptHandler) APISpec(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.apiSpec)
+}
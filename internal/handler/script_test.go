@@ -0,0 +1,139 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupScriptServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	h := handler.NewScriptHandler()
+
+	r := chi.NewRouter()
+	r.Post("/api/scripts/validate", h.ValidateScript)
+	r.Post("/api/conditions/validate", h.ValidateCondition)
+	r.Get("/api/scripting/api-spec", h.APISpec)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestScriptValidate(t *testing.T) {
+	ts := setupScriptServer(t)
+
+	tests := []struct {
+		name      string
+		body      string
+		wantValid bool
+		wantLang  string
+		wantPath  string
+	}{
+		{"valid js", `{"script":"pm.environment.set('a', 1);"}`, true, "javascript", ""},
+		{"invalid js", `{"script":"pm.environment.set('a', ;"}`, false, "javascript", ""},
+		{"valid dsl", `{"script":"{\"assertions\":[{\"type\":\"status\",\"value\":200}]}"}`, true, "dsl", ""},
+		{"invalid dsl", `{"script":"{\"setVariables\":[{\"name\":\"x\",\"operation\":\"explode\"}]}"}`, false, "dsl", "setVariables[0].operation"},
+		{"empty", `{"script":""}`, true, "javascript", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := postJSON(ts.URL+"/api/scripts/validate", tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			var result handler.ValidateScriptResponse
+			readJSON(t, resp, &result)
+
+			if result.Valid != tt.wantValid {
+				t.Errorf("valid = %v, want %v (errors: %+v)", result.Valid, tt.wantValid, result.Errors)
+			}
+			if result.Language != tt.wantLang {
+				t.Errorf("language = %q, want %q", result.Language, tt.wantLang)
+			}
+			if !tt.wantValid && len(result.Errors) == 0 {
+				t.Error("expected errors")
+			}
+			if tt.wantPath != "" && (len(result.Errors) == 0 || result.Errors[0].Path != tt.wantPath) {
+				t.Errorf("errors = %+v, want path %s", result.Errors, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestConditionValidate(t *testing.T) {
+	ts := setupScriptServer(t)
+
+	resp, err := postJSON(ts.URL+"/api/conditions/validate", `{"condition":"{{status}} == 200 && {{env}} in ['dev', 'qa']"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ok handler.ValidateConditionResponse
+	readJSON(t, resp, &ok)
+	if !ok.Valid || len(ok.Errors) != 0 {
+		t.Errorf("expected valid condition, got %+v", ok)
+	}
+
+	resp, err = postJSON(ts.URL+"/api/conditions/validate", `{"condition":"(a == 1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bad struct {
+		Valid  bool `json:"valid"`
+		Errors []struct {
+			Pos     int    `json:"pos"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	readJSON(t, resp, &bad)
+	if bad.Valid || len(bad.Errors) != 1 || bad.Errors[0].Message == "" {
+		t.Errorf("expected one error, got %+v", bad)
+	}
+
+	resp, err = postJSON(ts.URL+"/api/conditions/validate", `not json`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestScriptingAPISpec(t *testing.T) {
+	ts := setupScriptServer(t)
+
+	resp, err := http.Get(ts.URL + "/api/scripting/api-spec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Entries []struct {
+			Path      string `json:"path"`
+			Kind      string `json:"kind"`
+			Signature string `json:"signature"`
+		} `json:"entries"`
+	}
+	readJSON(t, resp, &spec)
+
+	found := false
+	for _, e := range spec.Entries {
+		if e.Path == "pm.environment.get" {
+			found = true
+			if e.Kind != "function" || e.Signature == "" {
+				t.Errorf("pm.environment.get = %+v", e)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("pm.environment.get missing from %d entries", len(spec.Entries))
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type SessionHandler struct {
+	queries *repository.Queries
+}
+
+func NewSessionHandler(queries *repository.Queries) *SessionHandler {
+	return &SessionHandler{queries: queries}
+}
+
+type EditorSessionResponse struct {
+	service.EditorSessionState
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// Get returns the caller's open tabs and drafts in the current workspace
+func (h *SessionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	key, ok := userTokenHash(w, r)
+	if !ok {
+		return
+	}
+
+	row, err := h.queries.GetEditorSession(r.Context(), repository.GetEditorSessionParams{
+		TokenHash:   key,
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondJSON(w, http.StatusOK, EditorSessionResponse{EditorSessionState: service.ParseEditorSessionState("")})
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, EditorSessionResponse{
+		EditorSessionState: service.ParseEditorSessionState(row.State),
+		UpdatedAt:          formatTime(row.UpdatedAt),
+	})
+}
+
+// Update replaces the caller's session state in the current workspace
+func (h *SessionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	key, ok := userTokenHash(w, r)
+	if !ok {
+		return
+	}
+
+	var state service.EditorSessionState
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxEditorSessionSize)
+	if err := decodeJSON(r, &state); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Session exceeds %d bytes", service.MaxEditorSessionSize))
+			return
+		}
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if state.Tabs == nil {
+		state.Tabs = []service.SessionTab{}
+	}
+	if err := state.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(data) > service.MaxEditorSessionSize {
+		respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Session exceeds %d bytes", service.MaxEditorSessionSize))
+		return
+	}
+
+	row, err := h.queries.UpsertEditorSession(r.Context(), repository.UpsertEditorSessionParams{
+		TokenHash:   key,
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		State:       string(data),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, EditorSessionResponse{
+		EditorSessionState: service.ParseEditorSessionState(row.State),
+		UpdatedAt:          formatTime(row.UpdatedAt),
+	})
+}
+
+// Delete clears the caller's session in the current workspace
+func (h *SessionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	key, ok := userTokenHash(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.queries.DeleteEditorSession(r.Context(), repository.DeleteEditorSessionParams{
+		TokenHash:   key,
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,126 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupSessionTestServer(t *testing.T) (*httptest.Server, *repository.Queries) {
+	t.Helper()
+
+	q := testutil.SetupTestDB(t)
+	h := handler.NewSessionHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/session", h.Get)
+	r.Put("/api/session", h.Update)
+	r.Delete("/api/session", h.Delete)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts, q
+}
+
+func sessionRequest(t *testing.T, method, url, token string, wsID int64, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-Token", token)
+	req.Header.Set("X-Workspace-ID", fmt.Sprintf("%d", wsID))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestSession_SaveRestoreAndIsolation(t *testing.T) {
+	ts, q := setupSessionTestServer(t)
+	url := ts.URL + "/api/session"
+	ws2, _ := q.CreateWorkspace(context.Background(), "Team B")
+
+	var session handler.EditorSessionResponse
+	readJSON(t, sessionRequest(t, http.MethodGet, url, prefsTokenA, 1, ""), &session)
+	if len(session.Tabs) != 0 || session.UpdatedAt != "" {
+		t.Errorf("new session = %+v", session)
+	}
+
+	body := `{
+		"tabs": [
+			{"id": "t1", "kind": "request", "requestId": 7, "title": "Get user", "draft": {"url": "{{base}}/users/2"}},
+			{"id": "t2", "kind": "draft", "title": "Untitled", "draft": {"method": "POST", "url": "https://example.com", "body": "{}"}}
+		],
+		"activeTabId": "t2"
+	}`
+	resp := sessionRequest(t, http.MethodPut, url, prefsTokenA, 1, body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("save: status %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	readJSON(t, sessionRequest(t, http.MethodGet, url, prefsTokenA, 1, ""), &session)
+	if len(session.Tabs) != 2 || session.ActiveTabID != "t2" || session.UpdatedAt == "" {
+		t.Fatalf("restored session = %+v", session)
+	}
+	if *session.Tabs[0].RequestID != 7 || !strings.Contains(string(session.Tabs[1].Draft), `"method":"POST"`) {
+		t.Errorf("restored tabs = %+v", session.Tabs)
+	}
+
+	// Other users and other workspaces have their own sessions
+	for _, tc := range []struct {
+		token string
+		wsID  int64
+	}{{prefsTokenB, 1}, {prefsTokenA, ws2.ID}} {
+		readJSON(t, sessionRequest(t, http.MethodGet, url, tc.token, tc.wsID, ""), &session)
+		if len(session.Tabs) != 0 {
+			t.Errorf("token %s workspace %d sees %+v", tc.token, tc.wsID, session.Tabs)
+		}
+	}
+
+	resp = sessionRequest(t, http.MethodDelete, url, prefsTokenA, 1, "")
+	resp.Body.Close()
+	readJSON(t, sessionRequest(t, http.MethodGet, url, prefsTokenA, 1, ""), &session)
+	if len(session.Tabs) != 0 {
+		t.Errorf("session after delete = %+v", session)
+	}
+}
+
+func TestSession_Validation(t *testing.T) {
+	ts, _ := setupSessionTestServer(t)
+	url := ts.URL + "/api/session"
+
+	cases := []struct {
+		name  string
+		token string
+		body  string
+		want  int
+	}{
+		{"missing token", "", `{"tabs":[]}`, http.StatusBadRequest},
+		{"duplicate id", prefsTokenA, `{"tabs":[{"id":"a","kind":"flow","flowId":1},{"id":"a","kind":"flow","flowId":2}]}`, http.StatusBadRequest},
+		{"unknown kind", prefsTokenA, `{"tabs":[{"id":"a","kind":"terminal"}]}`, http.StatusBadRequest},
+		{"request without id", prefsTokenA, `{"tabs":[{"id":"a","kind":"request"}]}`, http.StatusBadRequest},
+		{"draft without content", prefsTokenA, `{"tabs":[{"id":"a","kind":"draft","draft":null}]}`, http.StatusBadRequest},
+		{"draft not an object", prefsTokenA, `{"tabs":[{"id":"a","kind":"draft","draft":"GET /"}]}`, http.StatusBadRequest},
+		{"active tab not open", prefsTokenA, `{"tabs":[],"activeTabId":"a"}`, http.StatusBadRequest},
+		{"too large", prefsTokenA, `{"tabs":[{"id":"a","kind":"draft","draft":{"body":"` + strings.Repeat("x", 3<<20) + `"}}]}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		resp := sessionRequest(t, http.MethodPut, url, tc.token, 1, tc.body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, resp.StatusCode)
+		}
+	}
+}
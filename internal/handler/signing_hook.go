@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type SigningHookHandler struct {
+	queries *repository.Queries
+	hooks   *service.SigningHooks
+}
+
+func NewSigningHookHandler(queries *repository.Queries, hooks *service.SigningHooks) *SigningHookHandler {
+	return &SigningHookHandler{queries: queries, hooks: hooks}
+}
+
+type SigningHooksResponse struct {
+	Enabled bool     `json:"enabled"`
+	Hooks   []string `json:"hooks"`
+}
+
+type CollectionSigningHookResponse struct {
+	CollectionID int64             `json:"collectionId"`
+	Name         string            `json:"name"` // "" when the collection has no hook
+	Config       map[string]string `json:"config"`
+}
+
+func toCollectionSigningHookResponse(c repository.Collection) CollectionSigningHookResponse {
+	resp := CollectionSigningHookResponse{CollectionID: c.ID, Config: map[string]string{}}
+	if cfg := service.ParseSigningHookConfig(c.SigningHook); cfg != nil {
+		resp.Name, resp.Config = cfg.Name, cfg.Config
+	}
+	return resp
+}
+
+// List returns the hook executables installed in SIGNING_HOOK_DIR
+func (h *SigningHookHandler) List(w http.ResponseWriter, r *http.Request) {
+	names, err := h.hooks.List()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, SigningHooksResponse{Enabled: h.hooks.Enabled(), Hooks: names})
+}
+
+func (h *SigningHookHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	c, err := h.queries.GetCollection(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Collection not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, toCollectionSigningHookResponse(c))
+}
+
+// Update sets the collection's signing hook; an empty name removes it
+func (h *SigningHookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req service.SigningHookConfig
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	raw := sql.NullString{String: "", Valid: true}
+	if req.Name != "" {
+		if err := h.hooks.Validate(req); err != nil {
+			if errors.Is(err, service.ErrSigningHooksDisabled) {
+				respondError(w, http.StatusServiceUnavailable, err.Error())
+				return
+			}
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Config == nil {
+			req.Config = map[string]string{}
+		}
+		data, err := json.Marshal(req)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		raw.String = string(data)
+	}
+
+	c, err := h.queries.UpdateCollectionSigningHook(r.Context(), repository.UpdateCollectionSigningHookParams{
+		SigningHook: raw,
+		ID:          id,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(w, http.StatusNotFound, "Collection not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toCollectionSigningHookResponse(c))
+}
@@ -0,0 +1,115 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupSigningHookTestServer(t *testing.T, dir string) (*httptest.Server, *repository.Queries) {
+	t.Helper()
+
+	q := testutil.SetupTestDB(t)
+	h := handler.NewSigningHookHandler(q, service.NewSigningHooks(dir))
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/signing-hooks", h.List)
+	r.Get("/api/collections/{id}/signing-hook", h.Get)
+	r.Put("/api/collections/{id}/signing-hook", h.Update)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts, q
+}
+
+func TestSigningHooks_CollectionConfig(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "hmac"), []byte("#!/bin/sh\necho '{}'\n"), 0o755)
+	ts, q := setupSigningHookTestServer(t, dir)
+
+	resp, _ := http.Get(ts.URL + "/api/signing-hooks")
+	var list handler.SigningHooksResponse
+	readJSON(t, resp, &list)
+	if !list.Enabled || len(list.Hooks) != 1 || list.Hooks[0] != "hmac" {
+		t.Errorf("hooks = %+v", list)
+	}
+
+	col, _ := q.CreateCollection(context.Background(), repository.CreateCollectionParams{Name: "Partner", WorkspaceID: 1})
+	url := fmt.Sprintf("%s/api/collections/%d/signing-hook", ts.URL, col.ID)
+
+	var hook handler.CollectionSigningHookResponse
+	resp, _ = http.Get(url)
+	readJSON(t, resp, &hook)
+	if hook.Name != "" {
+		t.Errorf("new collection hook = %+v", hook)
+	}
+
+	resp, _ = putJSON(url, `{"name":"hmac","config":{"keyId":"k1","secret":"{{partnerSecret}}"}}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("update: status %d", resp.StatusCode)
+	}
+	resp, _ = http.Get(url)
+	readJSON(t, resp, &hook)
+	if hook.Name != "hmac" || hook.Config["secret"] != "{{partnerSecret}}" {
+		t.Errorf("saved hook = %+v", hook)
+	}
+
+	cases := []struct {
+		name string
+		url  string
+		body string
+		want int
+	}{
+		{"unknown hook", url, `{"name":"rsa"}`, http.StatusBadRequest},
+		{"path traversal", url, `{"name":"../hmac"}`, http.StatusBadRequest},
+		{"unknown collection", ts.URL + "/api/collections/999/signing-hook", `{"name":""}`, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		resp, err := putJSON(tc.url, tc.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, resp.StatusCode)
+		}
+	}
+
+	// Empty name removes the hook
+	resp, _ = putJSON(url, `{"name":""}`)
+	readJSON(t, resp, &hook)
+	if hook.Name != "" {
+		t.Errorf("cleared hook = %+v", hook)
+	}
+}
+
+func TestSigningHooks_Disabled(t *testing.T) {
+	ts, q := setupSigningHookTestServer(t, "")
+
+	resp, _ := http.Get(ts.URL + "/api/signing-hooks")
+	var list handler.SigningHooksResponse
+	readJSON(t, resp, &list)
+	if list.Enabled || len(list.Hooks) != 0 {
+		t.Errorf("hooks = %+v", list)
+	}
+
+	col, _ := q.CreateCollection(context.Background(), repository.CreateCollectionParams{Name: "Partner", WorkspaceID: 1})
+	resp, _ = putJSON(fmt.Sprintf("%s/api/collections/%d/signing-hook", ts.URL, col.ID), `{"name":"hmac"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without SIGNING_HOOK_DIR, got %d", resp.StatusCode)
+	}
+}
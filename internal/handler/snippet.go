@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SnippetHandler struct {
+	queries *repository.Queries
+}
+
+func NewSnippetHandler(queries *repository.Queries) *SnippetHandler {
+	return &SnippetHandler{queries: queries}
+}
+
+type SnippetResponse struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Content     string `json:"content"`
+	Description string `json:"description,omitempty"`
+	UpdatedAt   string `json:"updatedAt"`
+}
+
+type UpdateSnippetRequest struct {
+	Content     string `json:"content"`
+	Description string `json:"description"`
+}
+
+func toSnippetResponse(s repository.Snippet) SnippetResponse {
+	return SnippetResponse{
+		ID:          s.ID,
+		Name:        s.Name,
+		Content:     s.Content,
+		Description: s.Description,
+		UpdatedAt:   formatTime(s.UpdatedAt),
+	}
+}
+
+func (h *SnippetHandler) List(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.queries.ListSnippets(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := make([]SnippetResponse, len(rows))
+	for i, s := range rows {
+		result[i] = toSnippetResponse(s)
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+func (h *SnippetHandler) Get(w http.ResponseWriter, r *http.Request) {
+	s, err := h.queries.GetSnippet(r.Context(), repository.GetSnippetParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        chi.URLParam(r, "name"),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Snippet not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toSnippetResponse(s))
+}
+
+// Update creates or replaces a snippet; requests pick up the new content on
+// their next execution
+func (h *SnippetHandler) Update(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if !service.ValidSnippetName(name) {
+		respondError(w, http.StatusBadRequest, "Invalid snippet name (letters, digits, '_', '.' and '-', up to 64 characters)")
+		return
+	}
+
+	var req UpdateSnippetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := service.ValidateSnippet(req.Content); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s, err := h.queries.UpsertSnippet(r.Context(), repository.UpsertSnippetParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        name,
+		Content:     req.Content,
+		Description: req.Description,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, toSnippetResponse(s))
+}
+
+func (h *SnippetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	s, err := h.queries.GetSnippet(r.Context(), repository.GetSnippetParams{
+		WorkspaceID: middleware.GetWorkspaceID(r.Context()),
+		Name:        chi.URLParam(r, "name"),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(w, http.StatusNotFound, "Snippet not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := h.queries.DeleteSnippet(r.Context(), s.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
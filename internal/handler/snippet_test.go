@@ -0,0 +1,75 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestSnippet_CRUD(t *testing.T) {
+	h := handler.NewSnippetHandler(testutil.SetupTestDB(t))
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/snippets", h.List)
+	r.Get("/api/snippets/{name}", h.Get)
+	r.Put("/api/snippets/{name}", h.Update)
+	r.Delete("/api/snippets/{name}", h.Delete)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, err := putJSON(ts.URL+"/api/snippets/address", `{"content":"{\"city\":\"{{city}}\"}","description":"Shipping address"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s handler.SnippetResponse
+	readJSON(t, resp, &s)
+	if s.Name != "address" || s.Content != `{"city":"{{city}}"}` || s.Description != "Shipping address" {
+		t.Errorf("created = %+v", s)
+	}
+
+	resp, _ = putJSON(ts.URL+"/api/snippets/address", `{"content":"{}"}`)
+	readJSON(t, resp, &s)
+	var got handler.SnippetResponse
+	resp, _ = http.Get(ts.URL + "/api/snippets/address")
+	readJSON(t, resp, &got)
+	if got.Content != "{}" || got.Description != "" {
+		t.Errorf("replaced = %+v", got)
+	}
+
+	for _, tc := range []struct{ name, body string }{
+		{"bad%20name", `{"content":"{}"}`},
+		{"big", `{"content":"` + strings.Repeat("x", 256*1024+1) + `"}`},
+	} {
+		resp, _ = putJSON(ts.URL+"/api/snippets/"+tc.name, tc.body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", tc.name, resp.StatusCode)
+		}
+	}
+
+	resp, _ = getWithWorkspace(ts.URL+"/api/snippets", 2)
+	var list []handler.SnippetResponse
+	readJSON(t, resp, &list)
+	if len(list) != 0 {
+		t.Errorf("other workspace list = %+v", list)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/api/snippets/address", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status %d", resp.StatusCode)
+	}
+	resp, _ = http.Get(ts.URL + "/api/snippets/address")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("after delete: status %d, want 404", resp.StatusCode)
+	}
+}
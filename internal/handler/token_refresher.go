@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type TokenRefresherHandler struct {
+	queries   *repository.Queries
+	refresher *service.TokenRefresher
+}
+
+func NewTokenRefresherHandler(queries *repository.Queries, refresher *service.TokenRefresher) *TokenRefresherHandler {
+	return &TokenRefresherHandler{queries: queries, refresher: refresher}
+}
+
+// TokenRefresherRequest creates or replaces a refresher. ExtractVars maps
+// environment variables to JSONPaths in the login response.
+type TokenRefresherRequest struct {
+	Name            string            `json:"name"`
+	LoginRequestID  int64             `json:"loginRequestId"`
+	EnvironmentID   *int64            `json:"environmentId"` // null: the active environment at refresh time
+	ExtractVars     map[string]string `json:"extractVars"`
+	ExpiresInPath   string            `json:"expiresInPath"`   // token lifetime in seconds; refreshes a minute before expiry
+	IntervalSeconds int64             `json:"intervalSeconds"` // default 900
+	Enabled         *bool             `json:"enabled"`
+}
+
+// TokenRefresherResponse is a refresher with its token's freshness
+type TokenRefresherResponse struct {
+	ID               int64             `json:"id"`
+	Name             string            `json:"name"`
+	LoginRequestID   int64             `json:"loginRequestId"`
+	LoginRequestName string            `json:"loginRequestName"`
+	EnvironmentID    *int64            `json:"environmentId"`
+	ExtractVars      map[string]string `json:"extractVars"`
+	ExpiresInPath    string            `json:"expiresInPath,omitempty"`
+	IntervalSeconds  int64             `json:"intervalSeconds"`
+	Enabled          bool              `json:"enabled"`
+	Status           string            `json:"status"` // fresh | expired | failing | pending | paused
+	LastRefreshedAt  string            `json:"lastRefreshedAt,omitempty"`
+	LastAttemptAt    string            `json:"lastAttemptAt,omitempty"`
+	LastError        string            `json:"lastError,omitempty"`
+	ExpiresAt        string            `json:"expiresAt,omitempty"`
+	NextRefreshAt    string            `json:"nextRefreshAt,omitempty"` // empty while paused or due now
+	CreatedAt        string            `json:"createdAt"`
+	UpdatedAt        string            `json:"updatedAt"`
+}
+
+func (h *TokenRefresherHandler) toResponse(r *http.Request, tr repository.TokenRefresher) TokenRefresherResponse {
+	resp := TokenRefresherResponse{
+		ID:              tr.ID,
+		Name:            tr.Name,
+		LoginRequestID:  tr.LoginRequestID,
+		ExtractVars:     service.ParseTokenExtractVars(tr.ExtractVars),
+		ExpiresInPath:   tr.ExpiresInPath,
+		IntervalSeconds: tr.IntervalSeconds,
+		Enabled:         tr.Enabled == 1,
+		Status:          service.TokenFreshness(tr, time.Now()),
+		LastRefreshedAt: formatTime(tr.LastRefreshedAt),
+		LastAttemptAt:   formatTime(tr.LastAttemptAt),
+		LastError:       tr.LastError,
+		ExpiresAt:       formatTime(tr.ExpiresAt),
+		CreatedAt:       formatTime(tr.CreatedAt),
+		UpdatedAt:       formatTime(tr.UpdatedAt),
+	}
+	if tr.EnvironmentID.Valid {
+		id := tr.EnvironmentID.Int64
+		resp.EnvironmentID = &id
+	}
+	if next := service.NextTokenRefresh(tr); resp.Enabled && !next.IsZero() {
+		resp.NextRefreshAt = formatTime(sql.NullTime{Time: next, Valid: true})
+	}
+	if req, err := h.queries.GetRequest(r.Context(), tr.LoginRequestID); err == nil {
+		resp.LoginRequestName = req.Name
+	}
+	return resp
+}
+
+// List returns the workspace's refreshers with their freshness
+func (h *TokenRefresherHandler) List(w http.ResponseWriter, r *http.Request) {
+	refreshers, err := h.queries.ListTokenRefreshers(r.Context(), middleware.GetWorkspaceID(r.Context()))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := make([]TokenRefresherResponse, 0, len(refreshers))
+	for _, tr := range refreshers {
+		resp = append(resp, h.toResponse(r, tr))
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+func (h *TokenRefresherHandler) Get(w http.ResponseWriter, r *http.Request) {
+	tr, ok := h.refresherByID(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, h.toResponse(r, tr))
+}
+
+func (h *TokenRefresherHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req TokenRefresherRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	params, ok := h.params(w, r, req)
+	if !ok {
+		return
+	}
+
+	tr, err := h.queries.CreateTokenRefresher(r.Context(), repository.CreateTokenRefresherParams{
+		WorkspaceID:     middleware.GetWorkspaceID(r.Context()),
+		Name:            params.Name,
+		LoginRequestID:  params.LoginRequestID,
+		EnvironmentID:   params.EnvironmentID,
+		ExtractVars:     params.ExtractVars,
+		ExpiresInPath:   params.ExpiresInPath,
+		IntervalSeconds: params.IntervalSeconds,
+		Enabled:         params.Enabled,
+	})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, h.toResponse(r, tr))
+}
+
+// Update replaces a refresher; the next refresh keeps its schedule
+func (h *TokenRefresherHandler) Update(w http.ResponseWriter, r *http.Request) {
+	existing, ok := h.refresherByID(w, r)
+	if !ok {
+		return
+	}
+	var req TokenRefresherRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	params, ok := h.params(w, r, req)
+	if !ok {
+		return
+	}
+	params.ID = existing.ID
+
+	tr, err := h.queries.UpdateTokenRefresher(r.Context(), params)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, h.toResponse(r, tr))
+}
+
+func (h *TokenRefresherHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	tr, ok := h.refresherByID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.queries.DeleteTokenRefresher(r.Context(), tr.ID); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Refresh runs the login now, e.g. after the server revoked the token. A
+// failed login is reported in status and lastError, not as an HTTP error.
+func (h *TokenRefresherHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	tr, ok := h.refresherByID(w, r)
+	if !ok {
+		return
+	}
+	tr, err := h.refresher.Refresh(r.Context(), tr)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, h.toResponse(r, tr))
+}
+
+// refresherByID loads the {id} refresher of the current workspace, responding 400/404
+func (h *TokenRefresherHandler) refresherByID(w http.ResponseWriter, r *http.Request) (repository.TokenRefresher, bool) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return repository.TokenRefresher{}, false
+	}
+	tr, err := h.queries.GetTokenRefresher(r.Context(), id)
+	if err != nil || tr.WorkspaceID != middleware.GetWorkspaceID(r.Context()) {
+		respondError(w, http.StatusNotFound, "Token refresher not found")
+		return repository.TokenRefresher{}, false
+	}
+	return tr, true
+}
+
+// params validates req against the workspace's requests and environments
+func (h *TokenRefresherHandler) params(w http.ResponseWriter, r *http.Request, req TokenRefresherRequest) (repository.UpdateTokenRefresherParams, bool) {
+	ctx := r.Context()
+	wsID := middleware.GetWorkspaceID(ctx)
+
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return repository.UpdateTokenRefresherParams{}, false
+	}
+	if err := service.ValidateTokenRefresher(req.ExtractVars, req.ExpiresInPath); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return repository.UpdateTokenRefresherParams{}, false
+	}
+	if req.IntervalSeconds == 0 {
+		req.IntervalSeconds = 900
+	}
+	if req.IntervalSeconds < service.MinTokenRefreshInterval || req.IntervalSeconds > service.MaxTokenRefreshInterval {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("intervalSeconds must be between %d and %d", service.MinTokenRefreshInterval, service.MaxTokenRefreshInterval))
+		return repository.UpdateTokenRefresherParams{}, false
+	}
+	login, err := h.queries.GetRequest(ctx, req.LoginRequestID)
+	if err != nil || login.WorkspaceID != wsID {
+		respondError(w, http.StatusBadRequest, "loginRequestId must be a request of the workspace")
+		return repository.UpdateTokenRefresherParams{}, false
+	}
+
+	extractVars, _ := json.Marshal(req.ExtractVars)
+	p := repository.UpdateTokenRefresherParams{
+		Name:            req.Name,
+		LoginRequestID:  login.ID,
+		ExtractVars:     string(extractVars),
+		ExpiresInPath:   req.ExpiresInPath,
+		IntervalSeconds: req.IntervalSeconds,
+		Enabled:         1,
+	}
+	if req.Enabled != nil && !*req.Enabled {
+		p.Enabled = 0
+	}
+	if req.EnvironmentID != nil {
+		env, err := h.queries.GetEnvironment(ctx, *req.EnvironmentID)
+		if err != nil || env.WorkspaceID != wsID {
+			respondError(w, http.StatusBadRequest, "environmentId must be an environment of the workspace")
+			return repository.UpdateTokenRefresherParams{}, false
+		}
+		p.EnvironmentID = sql.NullInt64{Int64: env.ID, Valid: true}
+	}
+	return p, true
+}
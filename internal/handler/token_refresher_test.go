@@ -0,0 +1,110 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestTokenRefresher_CRUDAndRefresh(t *testing.T) {
+	loginServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+	}))
+	defer loginServer.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := service.NewVariableResolver(q)
+	refresher := service.NewTokenRefresher(q, service.NewRequestExecutor(q, vr, nil))
+	h := handler.NewTokenRefresherHandler(q, refresher)
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/token-refreshers", h.List)
+	r.Post("/api/token-refreshers", h.Create)
+	r.Get("/api/token-refreshers/{id}", h.Get)
+	r.Put("/api/token-refreshers/{id}", h.Update)
+	r.Delete("/api/token-refreshers/{id}", h.Delete)
+	r.Post("/api/token-refreshers/{id}/refresh", h.Refresh)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "dev", WorkspaceID: 1})
+	login, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Login", Method: "POST", Url: loginServer.URL, WorkspaceID: 1})
+	ws, _ := q.CreateWorkspace(ctx, "Other")
+	otherLogin, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Login", Method: "POST", Url: loginServer.URL, WorkspaceID: ws.ID})
+
+	for _, body := range []string{
+		fmt.Sprintf(`{"name":"api","loginRequestId":%d}`, login.ID),
+		fmt.Sprintf(`{"name":"api","loginRequestId":%d,"extractVars":{"token":"access_token"}}`, login.ID),
+		fmt.Sprintf(`{"name":"api","loginRequestId":%d,"extractVars":{"token":"$.access_token"},"intervalSeconds":5}`, login.ID),
+		fmt.Sprintf(`{"name":"api","loginRequestId":%d,"extractVars":{"token":"$.access_token"}}`, otherLogin.ID),
+		fmt.Sprintf(`{"loginRequestId":%d,"extractVars":{"token":"$.access_token"}}`, login.ID),
+	} {
+		resp, _ := postJSON(ts.URL+"/api/token-refreshers", body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+
+	resp, _ := postJSON(ts.URL+"/api/token-refreshers", fmt.Sprintf(
+		`{"name":"api","loginRequestId":%d,"environmentId":%d,"extractVars":{"token":"$.access_token"},"expiresInPath":"$.expires_in"}`,
+		login.ID, env.ID))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: status = %d", resp.StatusCode)
+	}
+	var created handler.TokenRefresherResponse
+	readJSON(t, resp, &created)
+	if created.Status != service.TokenStatusPending || created.IntervalSeconds != 900 || !created.Enabled || created.LoginRequestName != "Login" {
+		t.Errorf("created = %+v", created)
+	}
+	refresherURL := fmt.Sprintf("%s/api/token-refreshers/%d", ts.URL, created.ID)
+
+	var refreshed handler.TokenRefresherResponse
+	resp, _ = postJSON(refresherURL+"/refresh", `{}`)
+	readJSON(t, resp, &refreshed)
+	if refreshed.Status != service.TokenStatusFresh || refreshed.ExpiresAt == "" || refreshed.LastRefreshedAt == "" || refreshed.NextRefreshAt == "" {
+		t.Errorf("refreshed = %+v", refreshed)
+	}
+	env, _ = q.GetEnvironment(ctx, env.ID)
+	if env.Variables.String != `{"token":"tok"}` {
+		t.Errorf("environment variables = %s", env.Variables.String)
+	}
+
+	var paused handler.TokenRefresherResponse
+	resp, _ = putJSON(refresherURL, fmt.Sprintf(
+		`{"name":"api","loginRequestId":%d,"extractVars":{"token":"$.access_token"},"enabled":false}`, login.ID))
+	readJSON(t, resp, &paused)
+	if paused.Status != service.TokenStatusPaused || paused.NextRefreshAt != "" || paused.EnvironmentID != nil {
+		t.Errorf("paused = %+v", paused)
+	}
+
+	resp, _ = getWithWorkspace(refresherURL, ws.ID)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, refresherURL, nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("delete: status = %d", resp.StatusCode)
+	}
+	var list []handler.TokenRefresherResponse
+	resp, _ = http.Get(ts.URL + "/api/token-refreshers")
+	readJSON(t, resp, &list)
+	if len(list) != 0 {
+		t.Errorf("after delete: %d refreshers", len(list))
+	}
+}
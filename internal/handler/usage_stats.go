@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+)
+
+// Usage sorts for ?sort= on the request and flow lists. Ascending order (the
+// default) puts unused entries first: the fewest executions, or never run
+// and then the longest idle. ?order=desc reverses it.
+const (
+	usageSortExecutions   = "executions"
+	usageSortLastExecuted = "lastExecuted"
+)
+
+// listUsageSort reads ?sort= and ?order=, responding 400 for unknown values.
+// sortBy is empty when the list keeps its own order.
+func listUsageSort(w http.ResponseWriter, r *http.Request) (sortBy string, desc bool, ok bool) {
+	return listSortOrder(w, r, usageSortExecutions, usageSortLastExecuted)
+}
+
+// sortByUsage orders items by their usage statistics, keeping the list
+// order between equal entries
+func sortByUsage[T any](items []T, sortBy string, desc bool, usage func(T) (int64, sql.NullTime)) {
+	if sortBy == "" {
+		return
+	}
+	less := func(a, b T) bool {
+		countA, lastA := usage(a)
+		countB, lastB := usage(b)
+		if sortBy == usageSortExecutions {
+			return countA < countB
+		}
+		if lastA.Valid != lastB.Valid {
+			return !lastA.Valid
+		}
+		return lastA.Time.Before(lastB.Time)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if desc {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+}
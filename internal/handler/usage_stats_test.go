@@ -0,0 +1,90 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestUsageStats_ListSort(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	vr := service.NewVariableResolver(q)
+	re := service.NewRequestExecutor(q, vr, nil)
+	fr := service.NewFlowRunner(q, re, vr)
+	reqH := handler.NewRequestHandler(q, re, fr)
+	flowH := handler.NewFlowHandler(q, fr, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/requests", reqH.List)
+	r.Post("/api/requests", reqH.Create)
+	r.Post("/api/requests/{id}/execute", reqH.Execute)
+	r.Get("/api/flows", flowH.List)
+	r.Post("/api/flows", flowH.Create)
+	r.Post("/api/flows/{id}/run", flowH.Run)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ids := map[string]int64{}
+	for _, name := range []string{"unused", "busy", "once"} {
+		var created handler.RequestResponse
+		resp, _ := postJSON(ts.URL+"/api/requests", fmt.Sprintf(`{"name":%q,"method":"GET","url":%q}`, name, upstream.URL))
+		readJSON(t, resp, &created)
+		ids[name] = created.ID
+	}
+	for _, name := range []string{"busy", "busy", "once"} {
+		resp, _ := postJSON(fmt.Sprintf("%s/api/requests/%d/execute", ts.URL, ids[name]), `{}`)
+		resp.Body.Close()
+	}
+
+	list := func(query string) []handler.RequestResponse {
+		var items []handler.RequestResponse
+		resp, _ := http.Get(ts.URL + "/api/requests" + query)
+		readJSON(t, resp, &items)
+		return items
+	}
+	items := list("?sort=executions")
+	if len(items) != 3 || items[0].Name != "unused" || items[1].Name != "once" || items[2].Name != "busy" {
+		t.Fatalf("sort=executions: %+v", items)
+	}
+	if items[0].ExecutionCount != 0 || items[0].LastExecutedAt != "" || items[2].ExecutionCount != 2 || items[2].LastExecutedAt == "" {
+		t.Errorf("usage stats: %+v", items)
+	}
+	if items = list("?sort=executions&order=desc"); items[0].Name != "busy" {
+		t.Errorf("order=desc: first = %q", items[0].Name)
+	}
+	if items = list("?sort=lastExecuted"); items[0].Name != "unused" {
+		t.Errorf("sort=lastExecuted: first = %q", items[0].Name)
+	}
+
+	for _, query := range []string{"?sort=name", "?sort=executions&order=up"} {
+		resp, _ := http.Get(ts.URL + "/api/requests" + query)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, resp.StatusCode)
+		}
+	}
+
+	var flow handler.FlowResponse
+	resp, _ := postJSON(ts.URL+"/api/flows", `{"name":"Nightly"}`)
+	readJSON(t, resp, &flow)
+	resp, _ = postJSON(fmt.Sprintf("%s/api/flows/%d/run", ts.URL, flow.ID), `{}`)
+	resp.Body.Close()
+	var flows []handler.FlowResponse
+	resp, _ = http.Get(ts.URL + "/api/flows?sort=executions")
+	readJSON(t, resp, &flows)
+	if len(flows) != 1 || flows[0].ExecutionCount != 1 || flows[0].LastExecutedAt == "" {
+		t.Errorf("flows = %+v", flows)
+	}
+}
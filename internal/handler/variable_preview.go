@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+type VariablePreviewHandler struct {
+	queries  *repository.Queries
+	resolver *service.VariableResolver
+}
+
+func NewVariablePreviewHandler(queries *repository.Queries, resolver *service.VariableResolver) *VariablePreviewHandler {
+	return &VariablePreviewHandler{queries: queries, resolver: resolver}
+}
+
+// PreviewVariablesRequest resolves Text as it would be for a request
+// (its collection's variables and environment apply) or a flow step. With
+// neither, only workspace-level scopes apply.
+type PreviewVariablesRequest struct {
+	Text       string `json:"text"`
+	RequestID  *int64 `json:"requestId,omitempty"`
+	FlowStepID *int64 `json:"flowStepId,omitempty"`
+}
+
+type PreviewVariablesResponse struct {
+	Resolved  string                   `json:"resolved"`
+	Variables []service.VariableSource `json:"variables"`
+}
+
+// Preview resolves {{variables}} in arbitrary text and reports which scope
+// each value came from
+func (h *VariablePreviewHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	var req PreviewVariablesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.RequestID != nil && req.FlowStepID != nil {
+		respondError(w, http.StatusBadRequest, "requestId and flowStepId are mutually exclusive")
+		return
+	}
+
+	wsID := middleware.GetWorkspaceID(r.Context())
+	var collectionID int64
+	if req.RequestID != nil {
+		request, err := h.queries.GetRequest(r.Context(), *req.RequestID)
+		if err != nil || request.WorkspaceID != wsID {
+			respondError(w, http.StatusNotFound, "Request not found")
+			return
+		}
+		collectionID = request.CollectionID.Int64
+	}
+	if req.FlowStepID != nil {
+		// Flow steps resolve without a collection, like in a run
+		step, err := h.queries.GetFlowStep(r.Context(), *req.FlowStepID)
+		if err != nil || step.WorkspaceID != wsID {
+			respondError(w, http.StatusNotFound, "Flow step not found")
+			return
+		}
+	}
+
+	resolved, sources := h.resolver.Explain(r.Context(), req.Text, nil, collectionID)
+	respondJSON(w, http.StatusOK, PreviewVariablesResponse{Resolved: resolved, Variables: sources})
+}
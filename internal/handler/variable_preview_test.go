@@ -0,0 +1,95 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupVariablePreviewTestServer(t *testing.T) (*httptest.Server, *repository.Queries) {
+	t.Helper()
+
+	_, q := testutil.SetupTestDBWithConn(t)
+	h := handler.NewVariablePreviewHandler(q, service.NewVariableResolver(q))
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/variables/preview", h.Preview)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts, q
+}
+
+func TestVariablePreview(t *testing.T) {
+	ts, q := setupVariablePreviewTestServer(t)
+	ctx := context.Background()
+
+	col, err := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "col", WorkspaceID: 1})
+	if err != nil {
+		t.Fatalf("create collection: %v", err)
+	}
+	if _, err := q.UpdateCollectionVariables(ctx, repository.UpdateCollectionVariablesParams{
+		Variables: sql.NullString{String: `{"host":"col-host"}`, Valid: true},
+		ID:        col.ID,
+	}); err != nil {
+		t.Fatalf("update collection vars: %v", err)
+	}
+	req, err := q.CreateRequest(ctx, repository.CreateRequestParams{
+		CollectionID: sql.NullInt64{Int64: col.ID, Valid: true},
+		Name:         "r",
+		Method:       "GET",
+		Url:          "https://{{host}}",
+		WorkspaceID:  1,
+	})
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+
+	resp, err := postJSON(ts.URL+"/api/variables/preview", fmt.Sprintf(`{"text":"https://{{host}}/x","requestId":%d}`, req.ID))
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	var out handler.PreviewVariablesResponse
+	readJSON(t, resp, &out)
+	if out.Resolved != "https://col-host/x" {
+		t.Errorf("resolved = %q", out.Resolved)
+	}
+	if len(out.Variables) != 1 || out.Variables[0].Scope != service.VarScopeCollection || out.Variables[0].ScopeID != col.ID {
+		t.Errorf("variables = %+v", out.Variables)
+	}
+
+	// Without a request the collection's variables don't apply
+	resp, err = postJSON(ts.URL+"/api/variables/preview", `{"text":"{{host}}"}`)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	var unscoped handler.PreviewVariablesResponse
+	readJSON(t, resp, &unscoped)
+	if unscoped.Resolved != "{{host}}" || unscoped.Variables[0].Scope != "" {
+		t.Errorf("workspace preview = %+v", unscoped)
+	}
+
+	resp, err = postJSONWithWorkspace(ts.URL+"/api/variables/preview", fmt.Sprintf(`{"text":"x","requestId":%d}`, req.ID), 2)
+	if err != nil {
+		t.Fatalf("preview in other workspace: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: expected status 404, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// VariableEntry is one workspace or collection variable. Secret values are
+// never returned; on write, a null value for an existing secret keeps it.
+type VariableEntry struct {
+	Key    string  `json:"key"`
+	Value  *string `json:"value"`
+	Secret bool    `json:"secret"`
+}
+
+type VariablesResponse struct {
+	Variables []VariableEntry `json:"variables"`
+}
+
+type VariablesRequest struct {
+	Variables []VariableEntry `json:"variables"`
+}
+
+type SetVariableRequest struct {
+	Value  *string `json:"value"`
+	Secret bool    `json:"secret"`
+}
+
+// variableScope loads and stores the variables/secret_variables columns of
+// one row; seal encrypts secret values with the workspace key before a save
+type variableScope struct {
+	name string
+	load func(ctx context.Context, id int64) (vars, secrets sql.NullString, err error)
+	save func(ctx context.Context, id int64, vars, secrets sql.NullString) error
+	seal func(ctx context.Context, id int64, values map[string]string, secrets map[string]bool) error
+}
+
+type variableSet struct {
+	values  map[string]string
+	secrets map[string]bool
+}
+
+func parseVariableSet(vars, secrets sql.NullString) variableSet {
+	vs := variableSet{values: map[string]string{}, secrets: map[string]bool{}}
+	if vars.Valid && vars.String != "" {
+		json.Unmarshal([]byte(vars.String), &vs.values)
+	}
+	if secrets.Valid && secrets.String != "" {
+		var names []string
+		json.Unmarshal([]byte(secrets.String), &names)
+		for _, n := range names {
+			if _, ok := vs.values[n]; ok {
+				vs.secrets[n] = true
+			}
+		}
+	}
+	return vs
+}
+
+func (vs variableSet) entries() []VariableEntry {
+	keys := make([]string, 0, len(vs.values))
+	for k := range vs.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	entries := make([]VariableEntry, 0, len(keys))
+	for _, k := range keys {
+		e := VariableEntry{Key: k, Secret: vs.secrets[k]}
+		if !e.Secret {
+			v := vs.values[k]
+			e.Value = &v
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func (vs variableSet) encode() (sql.NullString, sql.NullString, error) {
+	varsJSON, err := json.Marshal(vs.values)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, err
+	}
+	names := make([]string, 0, len(vs.secrets))
+	for k := range vs.secrets {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	secretsJSON, err := json.Marshal(names)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, err
+	}
+	return sql.NullString{String: string(varsJSON), Valid: true},
+		sql.NullString{String: string(secretsJSON), Valid: true}, nil
+}
+
+// set validates and stores one entry; a nil value keeps the current value
+func (vs variableSet) set(prev variableSet, key string, value *string, secret bool) error {
+	if err := validateVariableKey(key); err != nil {
+		return err
+	}
+	if value == nil {
+		old, ok := prev.values[key]
+		if !ok {
+			return fmt.Errorf("variable %q: value is required", key)
+		}
+		value = &old
+	}
+	vs.values[key] = *value
+	if secret {
+		vs.secrets[key] = true
+	} else {
+		delete(vs.secrets, key)
+	}
+	return nil
+}
+
+func validateVariableKey(key string) error {
+	if strings.TrimSpace(key) == "" {
+		return fmt.Errorf("variable key is required")
+	}
+	if key != strings.TrimSpace(key) {
+		return fmt.Errorf("variable %q: key must not start or end with whitespace", key)
+	}
+	if strings.ContainsAny(key, "{}") {
+		return fmt.Errorf("variable %q: key must not contain braces", key)
+	}
+	return nil
+}
+
+// variableKeyParam returns the {key} URL parameter, unescaped
+func variableKeyParam(r *http.Request) string {
+	key := chi.URLParam(r, "key")
+	if unescaped, err := url.PathUnescape(key); err == nil {
+		return unescaped
+	}
+	return key
+}
+
+func listVariables(w http.ResponseWriter, r *http.Request, scope variableScope) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	vars, secrets, err := scope.load(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, scope.name+" not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, VariablesResponse{Variables: parseVariableSet(vars, secrets).entries()})
+}
+
+// replaceVariables replaces the full variable set
+func replaceVariables(w http.ResponseWriter, r *http.Request, scope variableScope) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	var req VariablesRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	vars, secrets, err := scope.load(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, scope.name+" not found")
+		return
+	}
+	prev := parseVariableSet(vars, secrets)
+	next := variableSet{values: map[string]string{}, secrets: map[string]bool{}}
+	for _, e := range req.Variables {
+		if _, dup := next.values[e.Key]; dup {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("duplicate variable %q", e.Key))
+			return
+		}
+		if err := next.set(prev, e.Key, e.Value, e.Secret); err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	saveVariables(w, r, scope, id, next)
+}
+
+// setVariable creates or updates a single variable
+func setVariable(w http.ResponseWriter, r *http.Request, scope variableScope) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	var req SetVariableRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	vars, secrets, err := scope.load(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, scope.name+" not found")
+		return
+	}
+	vs := parseVariableSet(vars, secrets)
+	if err := vs.set(vs, variableKeyParam(r), req.Value, req.Secret); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	saveVariables(w, r, scope, id, vs)
+}
+
+func deleteVariable(w http.ResponseWriter, r *http.Request, scope variableScope) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	vars, secrets, err := scope.load(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, scope.name+" not found")
+		return
+	}
+	vs := parseVariableSet(vars, secrets)
+	key := variableKeyParam(r)
+	if _, ok := vs.values[key]; !ok {
+		respondError(w, http.StatusNotFound, "Variable not found")
+		return
+	}
+	delete(vs.values, key)
+	delete(vs.secrets, key)
+
+	varsCol, secretsCol, err := vs.encode()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := scope.save(r.Context(), id, varsCol, secretsCol); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func saveVariables(w http.ResponseWriter, r *http.Request, scope variableScope, id int64, vs variableSet) {
+	if err := scope.seal(r.Context(), id, vs.values, vs.secrets); err != nil {
+		respondError(w, encryptionErrorStatus(err), err.Error())
+		return
+	}
+	varsCol, secretsCol, err := vs.encode()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := scope.save(r.Context(), id, varsCol, secretsCol); err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, VariablesResponse{Variables: vs.entries()})
+}
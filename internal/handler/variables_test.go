@@ -0,0 +1,187 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupVariablesTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	wsH := handler.NewWorkspaceHandler(q)
+	collH := handler.NewCollectionHandler(q, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+
+	r.Post("/api/collections", collH.Create)
+	r.Get("/api/workspaces/{id}/variables", wsH.ListVariables)
+	r.Put("/api/workspaces/{id}/variables", wsH.ReplaceVariables)
+	r.Put("/api/workspaces/{id}/variables/{key}", wsH.SetVariable)
+	r.Delete("/api/workspaces/{id}/variables/{key}", wsH.DeleteVariable)
+	r.Get("/api/collections/{id}/variables", collH.ListVariables)
+	r.Put("/api/collections/{id}/variables", collH.ReplaceVariables)
+	r.Put("/api/collections/{id}/variables/{key}", collH.SetVariable)
+	r.Delete("/api/collections/{id}/variables/{key}", collH.DeleteVariable)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func getVariables(t *testing.T, url string) []handler.VariableEntry {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: status %d", url, resp.StatusCode)
+	}
+	var out handler.VariablesResponse
+	readJSON(t, resp, &out)
+	return out.Variables
+}
+
+func TestVariables_WorkspaceReplaceAndMaskSecrets(t *testing.T) {
+	ts := setupVariablesTestServer(t)
+	url := ts.URL + "/api/workspaces/1/variables"
+
+	if vars := getVariables(t, url); len(vars) != 0 {
+		t.Fatalf("expected no variables, got %v", vars)
+	}
+
+	resp, err := putJSON(url, `{"variables":[
+		{"key":"token","value":"s3cr3t","secret":true},
+		{"key":"baseUrl","value":"http://localhost"}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("replace: status %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	vars := getVariables(t, url)
+	if len(vars) != 2 || vars[0].Key != "baseUrl" || vars[1].Key != "token" {
+		t.Fatalf("unexpected variables: %+v", vars)
+	}
+	if vars[0].Value == nil || *vars[0].Value != "http://localhost" || vars[0].Secret {
+		t.Errorf("baseUrl = %+v", vars[0])
+	}
+	if vars[1].Value != nil || !vars[1].Secret {
+		t.Errorf("secret value should be masked, got %+v", vars[1])
+	}
+
+	// A null value keeps the stored secret
+	resp, err = putJSON(url, `{"variables":[{"key":"token","value":null,"secret":true}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("keep secret: status %d", resp.StatusCode)
+	}
+
+	// Un-flagging the secret reveals the kept value
+	resp, err = putJSON(url+"/token", `{"secret":false}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out handler.VariablesResponse
+	readJSON(t, resp, &out)
+	if len(out.Variables) != 1 || out.Variables[0].Value == nil || *out.Variables[0].Value != "s3cr3t" {
+		t.Errorf("expected kept secret value, got %+v", out.Variables)
+	}
+}
+
+func TestVariables_WorkspaceValidation(t *testing.T) {
+	ts := setupVariablesTestServer(t)
+	url := ts.URL + "/api/workspaces/1/variables"
+
+	cases := map[string]string{
+		"duplicate":     `{"variables":[{"key":"a","value":"1"},{"key":"a","value":"2"}]}`,
+		"empty key":     `{"variables":[{"key":"","value":"1"}]}`,
+		"braces":        `{"variables":[{"key":"{{a}}","value":"1"}]}`,
+		"missing value": `{"variables":[{"key":"new"}]}`,
+		"invalid json":  `{"variables":`,
+	}
+	for name, body := range cases {
+		resp, err := putJSON(url, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(ts.URL + "/api/workspaces/999/variables")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown workspace: expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestVariables_CollectionSetAndDelete(t *testing.T) {
+	ts := setupVariablesTestServer(t)
+
+	resp, err := postJSON(ts.URL+"/api/collections", `{"name":"API"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var coll handler.CollectionResponse
+	readJSON(t, resp, &coll)
+	url := fmt.Sprintf("%s/api/collections/%d/variables", ts.URL, coll.ID)
+
+	resp, err = putJSON(url+"/api%20key", `{"value":"abc","secret":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("set: status %d", resp.StatusCode)
+	}
+
+	vars := getVariables(t, url)
+	if len(vars) != 1 || vars[0].Key != "api key" || !vars[0].Secret || vars[0].Value != nil {
+		t.Fatalf("unexpected variables: %+v", vars)
+	}
+
+	req, _ := http.NewRequest("DELETE", url+"/api%20key", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", resp.StatusCode)
+	}
+	if vars := getVariables(t, url); len(vars) != 0 {
+		t.Errorf("expected no variables after delete, got %+v", vars)
+	}
+
+	req, _ = http.NewRequest("DELETE", url+"/missing", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("delete missing: expected 404, got %d", resp.StatusCode)
+	}
+}
@@ -1,19 +1,30 @@
 package handler
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"relay/internal/repository"
+	"relay/internal/service"
 )
 
 type WorkspaceHandler struct {
 	queries *repository.Queries
+	keys    *service.KeyRing
 }
 
 func NewWorkspaceHandler(queries *repository.Queries) *WorkspaceHandler {
 	return &WorkspaceHandler{queries: queries}
 }
 
+// SetKeyRing sets the workspace keys secrets and certificates are sealed with
+func (h *WorkspaceHandler) SetKeyRing(keys *service.KeyRing) {
+	h.keys = keys
+}
+
 type WorkspaceRequest struct {
 	Name string `json:"name"`
 }
@@ -25,6 +36,11 @@ type WorkspaceResponse struct {
 	UpdatedAt string `json:"updatedAt"`
 }
 
+type WorkspaceSettingsResponse struct {
+	service.WorkspaceSettings
+	AvailableScriptLibraries []string `json:"availableScriptLibraries"`
+}
+
 func (h *WorkspaceHandler) List(w http.ResponseWriter, r *http.Request) {
 	workspaces, err := h.queries.ListWorkspaces(r.Context())
 	if err != nil {
@@ -137,3 +153,145 @@ func (h *WorkspaceHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *WorkspaceHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	raw, err := h.queries.GetWorkspaceSettings(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Workspace not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, WorkspaceSettingsResponse{
+		WorkspaceSettings:        service.ParseWorkspaceSettings(raw),
+		AvailableScriptLibraries: service.ScriptLibraryNames(),
+	})
+}
+
+func (h *WorkspaceHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	var req service.WorkspaceSettings
+	if err := decodeJSON(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.ScriptLibraries == nil {
+		req.ScriptLibraries = []string{}
+	}
+	if req.Notifications.Emails == nil {
+		req.Notifications.Emails = []string{}
+	}
+	if req.HostLimits.Hosts == nil {
+		req.HostLimits.Hosts = map[string]service.HostLimit{}
+	}
+	if req.RewriteRules == nil {
+		req.RewriteRules = []service.RewriteRule{}
+	}
+	if req.AuthSessions == nil {
+		req.AuthSessions = []service.AuthSession{}
+	}
+	if req.TLS.ClientCerts == nil {
+		req.TLS.ClientCerts = []service.ClientCertificate{}
+	}
+	if err := req.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if proxyID := req.ProxyChain.UpstreamProxyID; proxyID > 0 {
+		proxy, err := h.queries.GetProxy(r.Context(), proxyID)
+		if err != nil || proxy.WorkspaceID != id {
+			respondError(w, http.StatusBadRequest, "proxyChain.upstreamProxyId must be a proxy of this workspace")
+			return
+		}
+	}
+	for _, fileID := range req.TLS.FileIDs() {
+		f, err := h.queries.GetUploadedFile(r.Context(), fileID)
+		if err != nil || f.WorkspaceID != id {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("tls: file %d must be a file of this workspace", fileID))
+			return
+		}
+	}
+	// Certificates get sealed with the workspace key, if it has one
+	if err := h.keys.SealFiles(r.Context(), id, req.TLS.FileIDs()); err != nil {
+		respondError(w, encryptionErrorStatus(err), "tls: "+err.Error())
+		return
+	}
+	for _, s := range req.AuthSessions {
+		login, err := h.queries.GetRequest(r.Context(), s.LoginRequestID)
+		if err != nil || login.WorkspaceID != id {
+			respondError(w, http.StatusBadRequest, fmt.Sprintf("auth session %q: loginRequestId must be a request of this workspace", s.Name))
+			return
+		}
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ws, err := h.queries.UpdateWorkspaceSettings(r.Context(), repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: string(data), Valid: true},
+		ID:       id,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusNotFound, "Workspace not found")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, WorkspaceSettingsResponse{
+		WorkspaceSettings:        service.ParseWorkspaceSettings(ws.Settings),
+		AvailableScriptLibraries: service.ScriptLibraryNames(),
+	})
+}
+
+func (h *WorkspaceHandler) variableScope() variableScope {
+	return variableScope{
+		name: "Workspace",
+		load: func(ctx context.Context, id int64) (sql.NullString, sql.NullString, error) {
+			ws, err := h.queries.GetWorkspace(ctx, id)
+			return ws.Variables, ws.SecretVariables, err
+		},
+		save: func(ctx context.Context, id int64, vars, secrets sql.NullString) error {
+			_, err := h.queries.UpdateWorkspaceVariableSet(ctx, repository.UpdateWorkspaceVariableSetParams{
+				Variables:       vars,
+				SecretVariables: secrets,
+				ID:              id,
+			})
+			return err
+		},
+		seal: func(ctx context.Context, id int64, values map[string]string, secrets map[string]bool) error {
+			return h.keys.SealVariables(ctx, id, values, secrets)
+		},
+	}
+}
+
+// ListVariables returns the workspace (global) variables; secret values are omitted
+func (h *WorkspaceHandler) ListVariables(w http.ResponseWriter, r *http.Request) {
+	listVariables(w, r, h.variableScope())
+}
+
+func (h *WorkspaceHandler) ReplaceVariables(w http.ResponseWriter, r *http.Request) {
+	replaceVariables(w, r, h.variableScope())
+}
+
+func (h *WorkspaceHandler) SetVariable(w http.ResponseWriter, r *http.Request) {
+	setVariable(w, r, h.variableScope())
+}
+
+func (h *WorkspaceHandler) DeleteVariable(w http.ResponseWriter, r *http.Request) {
+	deleteVariable(w, r, h.variableScope())
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+
+	"relay/internal/service"
+)
+
+// Feed returns the workspace's recent changes, flow runs and monitor state
+// changes as a JSON Feed, or as Atom with ?format=atom. The workspace comes
+// from the path so feed readers need no X-Workspace-ID header.
+func (h *WorkspaceHandler) Feed(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "atom" {
+		respondError(w, http.StatusBadRequest, "format must be json or atom")
+		return
+	}
+	limit := service.DefaultFeedLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= service.MaxFeedLimit {
+			limit = parsed
+		}
+	}
+
+	ws, err := h.queries.GetWorkspace(r.Context(), id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Workspace not found")
+		return
+	}
+	items, err := service.WorkspaceFeed(r.Context(), h.queries, ws.ID, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	title := "Relay: " + ws.Name
+	feedURL := feedSelfURL(r)
+	if format == "atom" {
+		data, err := xml.MarshalIndent(service.NewAtomFeed(ws.ID, title, feedURL, items), "", "  ")
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(xml.Header))
+		w.Write(data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/feed+json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(service.NewJSONFeed(title, feedURL, items))
+}
+
+// feedSelfURL is the absolute URL the feed was requested at
+func feedSelfURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
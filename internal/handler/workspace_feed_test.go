@@ -0,0 +1,141 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func setupFeedTestServer(t *testing.T) (*httptest.Server, *repository.Queries) {
+	t.Helper()
+
+	q := testutil.SetupTestDB(t)
+	h := handler.NewWorkspaceHandler(q)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/workspaces/{id}/feed", h.Feed)
+
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+	return ts, q
+}
+
+func seedFeed(t *testing.T, q *repository.Queries) {
+	t.Helper()
+	ctx := context.Background()
+
+	req, err := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Get user", Method: "GET", Url: "http://x", WorkspaceID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flow, err := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "Checkout", WorkspaceID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.CreateRunTimeline(ctx, repository.CreateRunTimelineParams{
+		RunID:       "run-1",
+		WorkspaceID: 1,
+		FlowID:      flow.ID,
+		Timeline:    `{"runId":"run-1","flowName":"Checkout","success":false,"totalMs":12,"entries":[{"name":"a","status":"success"},{"name":"b","status":"failed"}]}`,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	mon, err := q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: req.ID, IntervalSeconds: 60, Enabled: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// up, up, down: two state changes
+	for _, ok := range []int64{1, 1, 0} {
+		if _, err := q.CreateMonitorCheck(ctx, repository.CreateMonitorCheckParams{MonitorID: mon.ID, StatusCode: 200 + 300*(1-ok), Success: ok}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWorkspaceFeed_JSON(t *testing.T) {
+	ts, q := setupFeedTestServer(t)
+	seedFeed(t, q)
+
+	resp, err := http.Get(ts.URL + "/api/workspaces/1/feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/feed+json" {
+		t.Errorf("content type = %q", ct)
+	}
+	var feed service.JSONFeed
+	readJSON(t, resp, &feed)
+	if feed.Version != "https://jsonfeed.org/version/1.1" || !strings.HasSuffix(feed.FeedURL, "/api/workspaces/1/feed") {
+		t.Errorf("feed = %+v", feed)
+	}
+
+	byTag := map[string][]string{}
+	for _, it := range feed.Items {
+		byTag[it.Tags[0]] = append(byTag[it.Tags[0]], it.Title)
+	}
+	if len(byTag[service.FeedKindChange]) != 2 {
+		t.Errorf("changes = %v", byTag[service.FeedKindChange])
+	}
+	if runs := byTag[service.FeedKindRun]; len(runs) != 1 || runs[0] != "Flow Checkout failed" {
+		t.Errorf("runs = %v", runs)
+	}
+	if mons := byTag[service.FeedKindMonitor]; len(mons) != 2 {
+		t.Errorf("monitor transitions = %v", mons)
+	}
+
+	resp, _ = http.Get(ts.URL + "/api/workspaces/1/feed?limit=2")
+	readJSON(t, resp, &feed)
+	if len(feed.Items) != 2 {
+		t.Errorf("limited to %d items", len(feed.Items))
+	}
+}
+
+func TestWorkspaceFeed_Atom(t *testing.T) {
+	ts, q := setupFeedTestServer(t)
+	seedFeed(t, q)
+
+	resp, err := http.Get(ts.URL + "/api/workspaces/1/feed?format=atom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/atom+xml") {
+		t.Errorf("content type = %q", ct)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	var feed service.AtomFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		t.Fatalf("parse atom: %v\n%s", err, data)
+	}
+	if feed.ID != "urn:relay:workspace:1:feed" || len(feed.Entries) != 5 || feed.Link == nil {
+		t.Errorf("feed = %+v", feed)
+	}
+	if !strings.HasPrefix(feed.Entries[0].ID, "urn:relay:workspace:1:") {
+		t.Errorf("entry id = %q", feed.Entries[0].ID)
+	}
+
+	for url, want := range map[string]int{
+		"/api/workspaces/1/feed?format=rss": http.StatusBadRequest,
+		"/api/workspaces/999/feed":          http.StatusNotFound,
+	} {
+		resp, _ := http.Get(ts.URL + url)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: status %d, want %d", url, resp.StatusCode, want)
+		}
+	}
+}
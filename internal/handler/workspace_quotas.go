@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+)
+
+// Quotas guarded by endpoints that send requests
+var executionQuotas = []string{service.QuotaHistoryRows, service.QuotaStorageBytes}
+
+// QuotaExceededResponse is the 429 body of an action refused by a quota
+type QuotaExceededResponse struct {
+	Error string `json:"error"`
+	Quota string `json:"quota"`
+	Limit int64  `json:"limit"`
+	Used  int64  `json:"used"`
+}
+
+// enforceQuotas responds 429 when the request's workspace has used up one of
+// the named quotas and returns false
+func enforceQuotas(w http.ResponseWriter, r *http.Request, queries *repository.Queries, names ...string) bool {
+	return quotaAllows(w, service.CheckQuotas(r.Context(), queries, middleware.GetWorkspaceID(r.Context()), names...))
+}
+
+// enforceRequestQuota responds 429 when adding that many saved requests
+// would take the workspace past its limit and returns false
+func enforceRequestQuota(w http.ResponseWriter, r *http.Request, queries *repository.Queries, adding int) bool {
+	return quotaAllows(w, service.CheckRequestQuota(r.Context(), queries, middleware.GetWorkspaceID(r.Context()), int64(adding)))
+}
+
+// quotaAllows responds 429 for a *QuotaExceededError and returns false
+func quotaAllows(w http.ResponseWriter, err error) bool {
+	var exceeded *service.QuotaExceededError
+	if errors.As(err, &exceeded) {
+		respondJSON(w, http.StatusTooManyRequests, QuotaExceededResponse{
+			Error: exceeded.Error(),
+			Quota: exceeded.Quota,
+			Limit: exceeded.Limit,
+			Used:  exceeded.Used,
+		})
+		return false
+	}
+	// Failing to measure usage doesn't block work
+	return true
+}
+
+// Usage reports the workspace's usage against its quotas (settings.quotas)
+func (h *WorkspaceHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	if _, err := h.queries.GetWorkspace(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "Workspace not found")
+		return
+	}
+
+	usage, err := service.GetWorkspaceUsage(r.Context(), h.queries, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, usage)
+}
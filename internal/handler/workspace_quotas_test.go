@@ -0,0 +1,131 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestWorkspace_Quotas(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	wsH := handler.NewWorkspaceHandler(q)
+	reqH := handler.NewRequestHandler(q, nil, nil)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Put("/api/workspaces/{id}/settings", wsH.UpdateSettings)
+	r.Get("/api/workspaces/{id}/usage", wsH.Usage)
+	r.Post("/api/requests", reqH.Create)
+	r.Post("/api/requests/{id}/duplicate", reqH.Duplicate)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	resp, _ := putJSON(ts.URL+"/api/workspaces/1/settings", `{"quotas":{"maxRequests":-1}}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("negative quota: status = %d, want 400", resp.StatusCode)
+	}
+	resp, _ = putJSON(ts.URL+"/api/workspaces/1/settings", `{"quotas":{"maxRequests":2}}`)
+	resp.Body.Close()
+
+	var first handler.RequestResponse
+	for i := 0; i < 2; i++ {
+		resp, _ = postJSON(ts.URL+"/api/requests", `{"name":"r","method":"GET","url":"https://api.test"}`)
+		readJSON(t, resp, &first)
+	}
+
+	resp, _ = postJSON(ts.URL+"/api/requests", `{"name":"r","method":"GET","url":"https://api.test"}`)
+	var exceeded handler.QuotaExceededResponse
+	readJSON(t, resp, &exceeded)
+	if resp.StatusCode != http.StatusTooManyRequests || exceeded.Quota != service.QuotaRequests || exceeded.Limit != 2 || exceeded.Used != 2 || exceeded.Error == "" {
+		t.Errorf("create over quota: status %d, %+v", resp.StatusCode, exceeded)
+	}
+	resp, _ = postJSON(fmt.Sprintf("%s/api/requests/%d/duplicate", ts.URL, first.ID), `{}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("duplicate over quota: status = %d, want 429", resp.StatusCode)
+	}
+
+	// Other workspaces have their own quotas
+	resp, _ = postJSONWithWorkspace(ts.URL+"/api/requests", `{"name":"r","method":"GET","url":"https://api.test"}`, 2)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("other workspace: status = %d", resp.StatusCode)
+	}
+
+	var usage service.WorkspaceUsage
+	resp, _ = http.Get(ts.URL + "/api/workspaces/1/usage")
+	readJSON(t, resp, &usage)
+	if usage.WorkspaceID != 1 || usage.Requests != (service.QuotaUsage{Used: 2, Limit: 2, Exceeded: true}) || usage.HistoryRows.Exceeded {
+		t.Errorf("usage = %+v", usage)
+	}
+	resp, _ = http.Get(ts.URL + "/api/workspaces/99/usage")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown workspace: status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestWorkspace_ImportQuota(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	h := handler.NewCollectionHandler(q, db)
+
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Post("/api/collections/import/postman", h.ImportPostman)
+	r.Post("/api/import/commit", h.ImportCommit)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"quotas":{"maxRequests":3}}`, Valid: true},
+		ID:       1,
+	})
+	q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Old", Method: "GET", WorkspaceID: 1})
+
+	postman := `{
+		"info": {"name": "Petstore", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"item": [
+			{"name": "Pets", "item": [
+				{"name": "List pets", "request": {"method": "GET", "url": "https://pets.test/pets"}},
+				{"name": "Delete pet", "request": {"method": "DELETE", "url": "https://pets.test/pets/1"}}
+			]},
+			{"name": "Ping", "request": {"method": "GET", "url": "https://pets.test/ping"}}
+		]
+	}`
+
+	// 1 saved + 3 imported is over the limit of 3, though the workspace is under it
+	resp, _ := postJSON(ts.URL+"/api/collections/import/postman", postman)
+	var exceeded handler.QuotaExceededResponse
+	readJSON(t, resp, &exceeded)
+	if resp.StatusCode != http.StatusTooManyRequests || exceeded.Quota != service.QuotaRequests || exceeded.Used != 1 || exceeded.Limit != 3 {
+		t.Fatalf("postman import over quota: status %d, %+v", resp.StatusCode, exceeded)
+	}
+	resp, _ = postJSON(ts.URL+"/api/import/commit", `{"data":`+postman+`,"items":["0.0","0:0"]}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("commit over quota: status = %d, want 429", resp.StatusCode)
+	}
+	if roots, _ := q.ListRootCollections(ctx, 1); len(roots) != 0 {
+		t.Errorf("refused imports created %d collections", len(roots))
+	}
+
+	// A selection that fits is imported
+	resp, _ = postJSON(ts.URL+"/api/import/commit", `{"data":`+postman+`,"items":["0.0"]}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("commit within quota: status = %d, want 201", resp.StatusCode)
+	}
+}
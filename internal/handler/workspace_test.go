@@ -28,6 +28,8 @@ func setupWorkspaceTestServer(t *testing.T) *httptest.Server {
 	r.Get("/api/workspaces/{id}", wsH.Get)
 	r.Put("/api/workspaces/{id}", wsH.Update)
 	r.Delete("/api/workspaces/{id}", wsH.Delete)
+	r.Get("/api/workspaces/{id}/settings", wsH.GetSettings)
+	r.Put("/api/workspaces/{id}/settings", wsH.UpdateSettings)
 
 	ts := httptest.NewServer(r)
 	t.Cleanup(ts.Close)
@@ -243,3 +245,159 @@ func TestWorkspace_ListAfterMultipleCreates(t *testing.T) {
 		}
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Workspace Settings Tests
+// ---------------------------------------------------------------------------
+
+func TestWorkspace_Settings(t *testing.T) {
+	ts := setupWorkspaceTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/api/workspaces/1/settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings handler.WorkspaceSettingsResponse
+	readJSON(t, resp, &settings)
+	if len(settings.ScriptLibraries) != 0 {
+		t.Errorf("expected no libraries by default, got %v", settings.ScriptLibraries)
+	}
+	if len(settings.AvailableScriptLibraries) != 4 {
+		t.Errorf("expected 4 available libraries, got %v", settings.AvailableScriptLibraries)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"scriptLibraries":["lodash","uuid"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readJSON(t, resp, &settings)
+	if fmt.Sprint(settings.ScriptLibraries) != "[lodash uuid]" {
+		t.Errorf("scriptLibraries = %v", settings.ScriptLibraries)
+	}
+
+	resp, err = http.Get(ts.URL + "/api/workspaces/1/settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readJSON(t, resp, &settings)
+	if fmt.Sprint(settings.ScriptLibraries) != "[lodash uuid]" {
+		t.Errorf("persisted scriptLibraries = %v", settings.ScriptLibraries)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"scriptLibraries":["moment"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown library: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"notifications":{"emails":["ops@example.com"],"monitorFailures":true}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readJSON(t, resp, &settings)
+	if fmt.Sprint(settings.Notifications.Emails) != "[ops@example.com]" || !settings.Notifications.MonitorFailures {
+		t.Errorf("notifications = %+v", settings.Notifications)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"notifications":{"emails":["not-an-email"]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid email: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"hostLimits":{"default":{"maxConcurrent":8},"hosts":{"api.example.com":{"maxConcurrent":2,"minDelayMs":100}}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readJSON(t, resp, &settings)
+	if settings.HostLimits.Default.MaxConcurrent != 8 || settings.HostLimits.Hosts["api.example.com"].MinDelayMs != 100 {
+		t.Errorf("hostLimits = %+v", settings.HostLimits)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"hostLimits":{"hosts":{"api.example.com":{"maxConcurrent":-1}}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid host limit: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"proxyChain":{"intercept":true,"upstreamProxyId":42}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown upstream proxy: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"rewriteRules":[{"name":"staging","match":{"host":"api.example.com"},"mapHost":"staging.example.com"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readJSON(t, resp, &settings)
+	if len(settings.RewriteRules) != 1 || settings.RewriteRules[0].MapHost != "staging.example.com" {
+		t.Errorf("rewriteRules = %+v", settings.RewriteRules)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"rewriteRules":[{"name":"no action"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("rule without action: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"scriptRequests":{"maxPerScript":1000}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("script request cap too high: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"authSessions":[{"name":"api","loginRequestId":42,"tokenPath":"$.token"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown login request: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"tls":{"verify":true,"clientCerts":[{"host":"api.example.com","certFileId":42,"keyFileId":43}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown client certificate file: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/1/settings", `{"tls":{"clientCerts":[{"host":"https://api.example.com","certFileId":1,"keyFileId":2}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("client certificate host with scheme: status = %d, want 400", resp.StatusCode)
+	}
+
+	resp, err = putJSON(ts.URL+"/api/workspaces/999/settings", `{"scriptLibraries":[]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing workspace: status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"relay/internal/service"
+)
+
+// TestSummary rolls up the latest runs of the workspace's flows, monitors and
+// environment rotations: status by latest run, pass/fail counts and trend
+// over the last ?runs=N runs (default 10, max 100)
+func (h *WorkspaceHandler) TestSummary(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	runs := service.DefaultTestSummaryRuns
+	if v := r.URL.Query().Get("runs"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > service.MaxTestSummaryRuns {
+			respondError(w, http.StatusBadRequest, "runs must be between 1 and 100")
+			return
+		}
+		runs = parsed
+	}
+	if _, err := h.queries.GetWorkspace(r.Context(), id); err != nil {
+		respondError(w, http.StatusNotFound, "Workspace not found")
+		return
+	}
+
+	summary, err := service.BuildTestSummary(r.Context(), h.queries, id, runs)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/service"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestWorkspaceTestSummary(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	h := handler.NewWorkspaceHandler(q)
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/api/workspaces/{id}/test-summary", h.TestSummary)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	checkout, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "Checkout", WorkspaceID: 1})
+	q.CreateFlow(ctx, repository.CreateFlowParams{Name: "Never run", WorkspaceID: 1})
+	for i, success := range []bool{false, true, true, false} {
+		q.CreateRunTimeline(ctx, repository.CreateRunTimelineParams{
+			RunID:       fmt.Sprintf("run-%d", i),
+			WorkspaceID: 1,
+			FlowID:      checkout.ID,
+			Timeline:    fmt.Sprintf(`{"success":%t}`, success),
+		})
+	}
+
+	req, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Health", Method: "GET", Url: "http://x", WorkspaceID: 1})
+	mon, _ := q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: req.ID, IntervalSeconds: 60, Enabled: 1})
+	for _, ok := range []int64{0, 1} {
+		q.CreateMonitorCheck(ctx, repository.CreateMonitorCheckParams{MonitorID: mon.ID, StatusCode: 200, Success: ok})
+	}
+	// Offline gaps are not runs
+	q.CreateMonitorCheck(ctx, repository.CreateMonitorCheckParams{MonitorID: mon.ID, OfflineSeconds: 30})
+
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "Prod", WorkspaceID: 1})
+	rot, _ := q.CreateEnvironmentRotation(ctx, repository.CreateEnvironmentRotationParams{WorkspaceID: 1, EnvironmentID: env.ID, FlowID: checkout.ID, IntervalSeconds: 3600})
+	q.CreateEnvironmentRotationRun(ctx, repository.CreateEnvironmentRotationRunParams{RotationID: rot.ID, Success: 1})
+
+	// Other workspaces are left out
+	other, _ := q.CreateWorkspace(ctx, "Other")
+	q.CreateFlow(ctx, repository.CreateFlowParams{Name: "Elsewhere", WorkspaceID: other.ID})
+
+	resp, err := http.Get(ts.URL + "/api/workspaces/1/test-summary?runs=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d", resp.StatusCode)
+	}
+	var summary service.TestSummary
+	readJSON(t, resp, &summary)
+
+	if len(summary.Flows) != 2 {
+		t.Fatalf("flows = %+v", summary.Flows)
+	}
+	flow := summary.Flows[0]
+	if flow.Name != "Checkout" || flow.Status != service.TestStatusFailing || flow.Passed != 2 || flow.Failed != 1 ||
+		fmt.Sprint(flow.Trend) != "[true true false]" || flow.LastRunAt == "" {
+		t.Errorf("Checkout = %+v", flow)
+	}
+	if never := summary.Flows[1]; never.Status != service.TestStatusNoRuns || len(never.Trend) != 0 {
+		t.Errorf("Never run = %+v", never)
+	}
+	if len(summary.Monitors) != 1 || summary.Monitors[0].Status != service.TestStatusPassing || fmt.Sprint(summary.Monitors[0].Trend) != "[false true]" {
+		t.Errorf("monitors = %+v", summary.Monitors)
+	}
+	if len(summary.Rotations) != 1 || summary.Rotations[0].Name != "Checkout → Prod" || !summary.Rotations[0].Disabled || summary.Rotations[0].Status != service.TestStatusPassing {
+		t.Errorf("rotations = %+v", summary.Rotations)
+	}
+	want := service.TestSummaryTotals{Items: 4, Passing: 2, Failing: 1, NoRuns: 1, PassedRuns: 4, FailedRuns: 2}
+	if summary.Totals != want {
+		t.Errorf("totals = %+v, want %+v", summary.Totals, want)
+	}
+
+	for _, path := range []string{"/api/workspaces/1/test-summary?runs=0", "/api/workspaces/1/test-summary?runs=101"} {
+		resp, _ := http.Get(ts.URL + path)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status %d", path, resp.StatusCode)
+		}
+	}
+	resp, _ = http.Get(ts.URL + "/api/workspaces/999/test-summary")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown workspace: status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// WSMessageResponse is one frame of a WebSocket session. Payload is base64
+// when Format is "binary".
+type WSMessageResponse struct {
+	Seq       int64  `json:"seq"`
+	Direction string `json:"direction"` // sent | received
+	Format    string `json:"format"`
+	Payload   string `json:"payload"`
+	Size      int64  `json:"size"`
+	SentAt    string `json:"sentAt"`
+}
+
+type WSMessagesResponse struct {
+	Messages  []WSMessageResponse `json:"messages"`
+	NextAfter int64               `json:"nextAfter,omitempty"` // pass as ?after= for the next page
+}
+
+// WSMessages pages through the frames of a WebSocket history entry in order
+// (?after= seq, ?limit=, default 200)
+func (h *HistoryHandler) WSMessages(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	}
+	hist, err := h.queries.GetHistory(r.Context(), id)
+	if err != nil || hist.WorkspaceID != middleware.GetWorkspaceID(r.Context()) || hist.Method != "WS" {
+		respondError(w, http.StatusNotFound, "WebSocket session not found")
+		return
+	}
+
+	var after int64
+	if a := r.URL.Query().Get("after"); a != "" {
+		if after, err = strconv.ParseInt(a, 10, 64); err != nil || after < 0 {
+			respondError(w, http.StatusBadRequest, "after must be a message seq")
+			return
+		}
+	}
+	limit := int64(200)
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.ParseInt(l, 10, 64); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	messages, err := h.queries.ListWSMessages(r.Context(), repository.ListWSMessagesParams{HistoryID: hist.ID, Seq: after, Limit: limit + 1})
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := WSMessagesResponse{Messages: make([]WSMessageResponse, 0, len(messages))}
+	if int64(len(messages)) > limit {
+		messages = messages[:limit]
+		resp.NextAfter = messages[limit-1].Seq
+	}
+	for _, m := range messages {
+		resp.Messages = append(resp.Messages, WSMessageResponse{
+			Seq:       m.Seq,
+			Direction: m.Direction,
+			Format:    m.Format,
+			Payload:   m.Payload,
+			Size:      m.Size,
+			SentAt:    formatTime(sql.NullTime{Time: m.SentAt, Valid: true}),
+		})
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,80 @@
+package handler_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"relay/internal/handler"
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestHistory_WSMessages(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	h := handler.NewHistoryHandler(q)
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Delete("/api/history/{id}", h.Delete)
+	r.Get("/api/history/{id}/ws-messages", h.WSMessages)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	session, _ := q.CreateHistory(ctx, repository.CreateHistoryParams{Method: "WS", Url: "ws://example.com", WorkspaceID: 1})
+	for i := 1; i <= 5; i++ {
+		direction := "sent"
+		if i%2 == 0 {
+			direction = "received"
+		}
+		q.CreateWSMessage(ctx, repository.CreateWSMessageParams{
+			HistoryID: session.ID, Seq: int64(i), Direction: direction, Format: "text",
+			Payload: fmt.Sprintf("m%d", i), Size: 2, SentAt: time.Now(),
+		})
+	}
+	plain, _ := q.CreateHistory(ctx, repository.CreateHistoryParams{Method: "GET", Url: "https://example.com", WorkspaceID: 1})
+	messagesURL := fmt.Sprintf("%s/api/history/%d/ws-messages", ts.URL, session.ID)
+
+	var page handler.WSMessagesResponse
+	resp, _ := http.Get(messagesURL + "?limit=3")
+	readJSON(t, resp, &page)
+	if len(page.Messages) != 3 || page.Messages[0].Payload != "m1" || page.Messages[1].Direction != "received" || page.NextAfter != 3 || page.Messages[0].SentAt == "" {
+		t.Fatalf("first page = %+v", page)
+	}
+	var rest handler.WSMessagesResponse
+	resp, _ = http.Get(fmt.Sprintf("%s?after=%d&limit=3", messagesURL, page.NextAfter))
+	readJSON(t, resp, &rest)
+	if len(rest.Messages) != 2 || rest.Messages[0].Seq != 4 || rest.NextAfter != 0 {
+		t.Errorf("second page = %+v", rest)
+	}
+
+	for url, want := range map[string]int{
+		fmt.Sprintf("%s/api/history/%d/ws-messages", ts.URL, plain.ID): http.StatusNotFound,
+		messagesURL + "?after=x": http.StatusBadRequest,
+	} {
+		resp, _ := http.Get(url)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: status = %d, want %d", url, resp.StatusCode, want)
+		}
+	}
+	resp, _ = getWithWorkspace(messagesURL, 2)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other workspace: status = %d, want 404", resp.StatusCode)
+	}
+
+	// Deleting the entry deletes its frames
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/history/%d", ts.URL, session.ID), nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if msgs, _ := q.ListWSMessages(ctx, repository.ListWSMessagesParams{HistoryID: session.ID, Limit: 10}); len(msgs) != 0 {
+		t.Errorf("%d messages left after delete", len(msgs))
+	}
+}
@@ -34,7 +34,7 @@ func TestCORS_Headers(t *testing.T) {
 	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, PUT, DELETE, OPTIONS" {
 		t.Errorf("expected Access-Control-Allow-Methods, got %q", got)
 	}
-	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type, Authorization, X-Workspace-ID" {
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type, Authorization, X-Workspace-ID, X-User-Token" {
 		t.Errorf("expected Access-Control-Allow-Headers, got %q", got)
 	}
 }
@@ -70,7 +70,7 @@ func TestCORS_Preflight(t *testing.T) {
 	if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, PUT, DELETE, OPTIONS" {
 		t.Errorf("expected Access-Control-Allow-Methods, got %q", got)
 	}
-	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type, Authorization, X-Workspace-ID" {
+	if got := rec.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type, Authorization, X-Workspace-ID, X-User-Token" {
 		t.Errorf("expected Access-Control-Allow-Headers, got %q", got)
 	}
 }
@@ -29,3 +29,9 @@ func GetWorkspaceID(ctx context.Context) int64 {
 	}
 	return 1
 }
+
+// WithWorkspaceID scopes ctx to a workspace outside of an HTTP request
+// (e.g. background jobs)
+func WithWorkspaceID(ctx context.Context, wsID int64) context.Context {
+	return context.WithValue(ctx, workspaceKey, wsID)
+}
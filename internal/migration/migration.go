@@ -24,6 +24,55 @@ func Run(db *sql.DB) error {
 	migrateWorkspaceCollectionVariables(db)
 	migrateRequestScripts(db)
 	migrateSortOrder(db)
+	migrateResponseTransform(db)
+	migrateWorkspaceSettings(db)
+	migrateSecretVariables(db)
+	migrateMonitors(db)
+	migrateUserPreferences(db)
+	migrateMonitorOfflineQueue(db)
+	migrateSigningHooks(db)
+	migrateWasmExtensions(db)
+	migrateEditorSessions(db)
+	migrateRequestDrafts(db)
+	migrateEnvironmentParents(db)
+	migrateCollectionEnvironments(db)
+	migrateHistoryTraceID(db)
+	migrateInstances(db)
+	migrateJobs(db)
+	migrateFlowStepWait(db)
+	migrateHistoryNotes(db)
+	migrateHistoryExecutionGroup(db)
+	migrateHistoryParent(db)
+	migrateRequestAuth(db)
+	migrateCollectionRunFlows(db)
+	migrateFlowOutputs(db)
+	migrateFlowGraph(db)
+	migrateRunTimelines(db)
+	migrateDataFactories(db)
+	migrateFlowStepApproval(db)
+	migrateUsageStats(db)
+	migrateArchiving(db)
+	migrateHistorySearch(db)
+	migrateCollectionAuth(db)
+	migrateOAuth2Configs(db)
+	migrateEnvironmentRotations(db)
+	migrateTokenRefreshers(db)
+	migrateWSMessages(db)
+	migrateResponseAnnotations(db)
+	migrateRetryPolicy(db)
+	migrateCookieJar(db)
+	migrateOpenAPISpecs(db)
+	migrateRedirectControl(db)
+	migrateEncryptionKeys(db)
+	migrateHistoryNetwork(db)
+	migrateHistoryAttempts(db)
+	migrateEnvironmentComments(db)
+	migrateSnippets(db)
+	migrateArchives(db)
+	migrateFlowRuns(db)
+	migrateContractDrift(db)
+	migrateJobKeys(db)
+	migrateTokenRefreshRuns(db)
 
 	return nil
 }
@@ -306,9 +355,611 @@ func migrateSortOrder(db *sql.DB) {
 	}
 }
 
+func migrateResponseTransform(db *sql.DB) {
+	stmts := []string{
+		"ALTER TABLE requests ADD COLUMN response_transform TEXT DEFAULT ''",
+		"ALTER TABLE flow_steps ADD COLUMN response_transform TEXT DEFAULT ''",
+	}
+	for _, s := range stmts {
+		db.Exec(s) // Ignore "duplicate column" errors
+	}
+}
+
+func migrateWorkspaceSettings(db *sql.DB) {
+	// JSON object of per-workspace options (e.g. enabled script libraries)
+	db.Exec("ALTER TABLE workspaces ADD COLUMN settings TEXT DEFAULT '{}'")
+}
+
+func migrateSecretVariables(db *sql.DB) {
+	// JSON array of variable names whose values are masked in API responses
+	stmts := []string{
+		"ALTER TABLE workspaces ADD COLUMN secret_variables TEXT DEFAULT '[]'",
+		"ALTER TABLE collections ADD COLUMN secret_variables TEXT DEFAULT '[]'",
+	}
+	for _, s := range stmts {
+		db.Exec(s) // Ignore "duplicate column" errors
+	}
+}
+
+func migrateMonitors(db *sql.DB) {
+	// Requests checked periodically by the background monitor runner
+	db.Exec(`CREATE TABLE IF NOT EXISTS monitors (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		request_id INTEGER NOT NULL UNIQUE REFERENCES requests(id) ON DELETE CASCADE,
+		interval_seconds INTEGER NOT NULL DEFAULT 60,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		last_checked_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec(`CREATE TABLE IF NOT EXISTS monitor_checks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		monitor_id INTEGER NOT NULL REFERENCES monitors(id) ON DELETE CASCADE,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		success INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		checked_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_monitor_checks_monitor ON monitor_checks(monitor_id, checked_at)")
+}
+
+func migrateUserPreferences(db *sql.DB) {
+	// UI preferences keyed by a hash of the client's X-User-Token
+	db.Exec(`CREATE TABLE IF NOT EXISTS user_preferences (
+		token_hash TEXT PRIMARY KEY,
+		preferences TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+}
+
+func migrateMonitorOfflineQueue(db *sql.DB) {
+	// Checks that can't reach the network are held back (up to max_queue_seconds)
+	// and recorded as one offline_seconds gap instead of a run of errors
+	stmts := []string{
+		"ALTER TABLE monitors ADD COLUMN max_queue_seconds INTEGER NOT NULL DEFAULT 900",
+		"ALTER TABLE monitors ADD COLUMN offline_since DATETIME",
+		"ALTER TABLE monitor_checks ADD COLUMN offline_seconds INTEGER NOT NULL DEFAULT 0",
+	}
+	for _, s := range stmts {
+		db.Exec(s) // Ignore "duplicate column" errors
+	}
+}
+
+func migrateSigningHooks(db *sql.DB) {
+	// JSON {name, config} of the SIGNING_HOOK_DIR executable that rewrites
+	// outgoing requests of the collection and its sub-collections
+	db.Exec("ALTER TABLE collections ADD COLUMN signing_hook TEXT DEFAULT ''")
+}
+
+func migrateWasmExtensions(db *sql.DB) {
+	// User-provided wasm modules callable from DSL scripts (custom assertions/transforms)
+	db.Exec(`CREATE TABLE IF NOT EXISTS wasm_extensions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		module BLOB NOT NULL,
+		sha256 TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		exports TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(workspace_id, name)
+	)`)
+}
+
+func migrateEditorSessions(db *sql.DB) {
+	// Unsaved editor state (open tabs, ad-hoc drafts) per X-User-Token and workspace
+	db.Exec(`CREATE TABLE IF NOT EXISTS editor_sessions (
+		token_hash TEXT NOT NULL,
+		workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+		state TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (token_hash, workspace_id)
+	)`)
+}
+
+func migrateRequestDrafts(db *sql.DB) {
+	// version counts saved edits; a draft remembers the version it started from
+	// so applying it over a newer save can be refused
+	db.Exec("ALTER TABLE requests ADD COLUMN version INTEGER NOT NULL DEFAULT 1")
+	db.Exec(`CREATE TABLE IF NOT EXISTS request_drafts (
+		request_id INTEGER PRIMARY KEY REFERENCES requests(id) ON DELETE CASCADE,
+		data TEXT NOT NULL DEFAULT '{}',
+		base_version INTEGER NOT NULL,
+		revision INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+}
+
+func migrateEnvironmentParents(db *sql.DB) {
+	// An environment inherits its parent's variables and overrides them by key
+	db.Exec("ALTER TABLE environments ADD COLUMN parent_id INTEGER REFERENCES environments(id) ON DELETE SET NULL")
+}
+
+func migrateCollectionEnvironments(db *sql.DB) {
+	// NULL = workspace environment; otherwise it applies to that collection's requests
+	db.Exec("ALTER TABLE environments ADD COLUMN collection_id INTEGER REFERENCES collections(id) ON DELETE CASCADE")
+}
+
+func migrateHistoryTraceID(db *sql.DB) {
+	// Requests of one flow run share a trace ID (also sent as traceparent when tracing is on)
+	db.Exec("ALTER TABLE request_history ADD COLUMN trace_id TEXT")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_history_trace ON request_history(trace_id)")
+}
+
+func migrateInstances(db *sql.DB) {
+	// Servers sharing the database register here; background jobs run only on
+	// the instance holding their lease (expires_at in unix milliseconds)
+	db.Exec(`CREATE TABLE IF NOT EXISTS instances (
+		id TEXT PRIMARY KEY,
+		hostname TEXT NOT NULL DEFAULT '',
+		pid INTEGER NOT NULL DEFAULT 0,
+		started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		heartbeat_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec(`CREATE TABLE IF NOT EXISTS job_leases (
+		name TEXT PRIMARY KEY,
+		holder TEXT NOT NULL,
+		expires_at INTEGER NOT NULL,
+		acquired_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+}
+
+func migrateJobs(db *sql.DB) {
+	// Persistent background work; run_at and locked_until are unix milliseconds.
+	// workspace_id is NULL for server-wide jobs such as file cleanup.
+	db.Exec(`CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL DEFAULT '{}',
+		status TEXT NOT NULL DEFAULT 'queued',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL DEFAULT 3,
+		run_at INTEGER NOT NULL,
+		locked_by TEXT,
+		locked_until INTEGER,
+		result TEXT,
+		last_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		finished_at DATETIME
+	)`)
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(status, run_at)")
+}
+
+func migrateFlowStepWait(db *sql.DB) {
+	// JSON wait config: repeat the step's request until a condition holds
+	db.Exec("ALTER TABLE flow_steps ADD COLUMN wait_until TEXT DEFAULT ''")
+}
+
+func migrateHistoryNotes(db *sql.DB) {
+	// Flagged entries are kept by history retention
+	db.Exec("ALTER TABLE request_history ADD COLUMN note TEXT DEFAULT ''")
+	db.Exec("ALTER TABLE request_history ADD COLUMN flagged INTEGER NOT NULL DEFAULT 0")
+}
+
+func migrateHistoryExecutionGroup(db *sql.DB) {
+	// Requests of one flow run share its run ID, so history can list them together
+	db.Exec("ALTER TABLE request_history ADD COLUMN execution_group_id TEXT")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_history_execution_group ON request_history(execution_group_id)")
+}
+
+func migrateHistoryParent(db *sql.DB) {
+	// pm.sendRequest calls are recorded as children of the step or request that made them
+	db.Exec("ALTER TABLE request_history ADD COLUMN parent_history_id INTEGER REFERENCES request_history(id) ON DELETE SET NULL")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_history_parent ON request_history(parent_history_id)")
+}
+
+func migrateRequestAuth(db *sql.DB) {
+	// Auth block (NTLM/Negotiate credentials) handled by the executor
+	db.Exec("ALTER TABLE requests ADD COLUMN auth TEXT DEFAULT ''")
+}
+
+func migrateCollectionRunFlows(db *sql.DB) {
+	// Setup/teardown flows run around collection runs
+	db.Exec("ALTER TABLE collections ADD COLUMN run_flows TEXT DEFAULT ''")
+}
+
+func migrateFlowOutputs(db *sql.DB) {
+	// Named outputs (name -> variable) reported by flow runs
+	db.Exec("ALTER TABLE flows ADD COLUMN outputs TEXT DEFAULT ''")
+}
+
+func migrateFlowGraph(db *sql.DB) {
+	// Graph flows: nodes keyed per flow, edges between node keys
+	db.Exec(`CREATE TABLE IF NOT EXISTS flow_nodes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+		node_key TEXT NOT NULL,
+		type TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		config TEXT NOT NULL DEFAULT '{}',
+		position_x REAL NOT NULL DEFAULT 0,
+		position_y REAL NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (flow_id, node_key)
+	)`)
+	db.Exec(`CREATE TABLE IF NOT EXISTS flow_edges (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+		source_key TEXT NOT NULL,
+		target_key TEXT NOT NULL,
+		label TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_flow_edges_flow ON flow_edges(flow_id)")
+}
+
+func migrateRunTimelines(db *sql.DB) {
+	// Per-run step/phase timings for the timeline view
+	db.Exec(`CREATE TABLE IF NOT EXISTS run_timelines (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id TEXT NOT NULL UNIQUE,
+		workspace_id INTEGER NOT NULL DEFAULT 1,
+		flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+		timeline TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_run_timelines_flow ON run_timelines(flow_id)")
+}
+
+func migrateDataFactories(db *sql.DB) {
+	// Server-managed test data: {{seq:name}} counters and {{pool:name}} value lists
+	db.Exec(`CREATE TABLE IF NOT EXISTS sequences (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		value INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(workspace_id, name)
+	)`)
+	db.Exec(`CREATE TABLE IF NOT EXISTS value_pools (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		mode TEXT NOT NULL DEFAULT 'roundRobin',
+		items TEXT NOT NULL DEFAULT '[]',
+		cursor INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(workspace_id, name)
+	)`)
+}
+
+func migrateFlowStepApproval(db *sql.DB) {
+	// JSON approval gate config: the step waits for a manual approve/reject
+	db.Exec("ALTER TABLE flow_steps ADD COLUMN approval TEXT DEFAULT ''")
+}
+
 func migrateWorkspaceCollectionVariables(db *sql.DB) {
 	// Add variables column to workspaces for pm.globals
 	db.Exec("ALTER TABLE workspaces ADD COLUMN variables TEXT DEFAULT '{}'")
 	// Add variables column to collections for pm.collectionVariables
 	db.Exec("ALTER TABLE collections ADD COLUMN variables TEXT DEFAULT '{}'")
 }
+
+func migrateUsageStats(db *sql.DB) {
+	// Denormalized usage counters, bumped by the executor and flow runner
+	for _, table := range []string{"requests", "flows"} {
+		db.Exec("ALTER TABLE " + table + " ADD COLUMN execution_count INTEGER NOT NULL DEFAULT 0")
+		db.Exec("ALTER TABLE " + table + " ADD COLUMN last_executed_at DATETIME")
+	}
+}
+
+func migrateArchiving(db *sql.DB) {
+	// Archived requests and flows are hidden from lists and batch runs
+	for _, table := range []string{"requests", "flows"} {
+		db.Exec("ALTER TABLE " + table + " ADD COLUMN archived_at DATETIME")
+	}
+}
+
+func migrateHistorySearch(db *sql.DB) {
+	// Full-text index over history response bodies, filled by the background
+	// indexer; rowid is the request_history ID
+	db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS history_search USING fts5(
+		body,
+		error,
+		workspace_id UNINDEXED
+	)`)
+	db.Exec(`CREATE TABLE IF NOT EXISTS history_search_cursor (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		last_history_id INTEGER NOT NULL DEFAULT 0
+	)`)
+}
+
+func migrateCollectionAuth(db *sql.DB) {
+	// Auth block inherited by the collection's requests
+	db.Exec("ALTER TABLE collections ADD COLUMN auth TEXT DEFAULT ''")
+}
+
+func migrateOAuth2Configs(db *sql.DB) {
+	// OAuth2 token endpoints whose tokens are written to an environment variable
+	db.Exec(`CREATE TABLE IF NOT EXISTS oauth2_configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		grant_type TEXT NOT NULL,
+		token_url TEXT NOT NULL,
+		client_id TEXT NOT NULL DEFAULT '',
+		client_secret TEXT NOT NULL DEFAULT '',
+		client_auth TEXT NOT NULL DEFAULT 'basic',
+		username TEXT NOT NULL DEFAULT '',
+		password TEXT NOT NULL DEFAULT '',
+		scope TEXT NOT NULL DEFAULT '',
+		environment_id INTEGER REFERENCES environments(id) ON DELETE SET NULL,
+		token_variable TEXT NOT NULL DEFAULT 'accessToken',
+		auto_refresh INTEGER NOT NULL DEFAULT 1,
+		refresh_token TEXT NOT NULL DEFAULT '',
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_oauth2_configs_workspace ON oauth2_configs(workspace_id)`)
+}
+
+func migrateEnvironmentRotations(db *sql.DB) {
+	// Scheduled flow runs whose outputs are written into an environment, e.g.
+	// minting a fresh API key nightly
+	db.Exec(`CREATE TABLE IF NOT EXISTS environment_rotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		environment_id INTEGER NOT NULL REFERENCES environments(id) ON DELETE CASCADE,
+		flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+		interval_seconds INTEGER NOT NULL DEFAULT 86400,
+		outputs TEXT NOT NULL DEFAULT '',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		last_run_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_environment_rotations_workspace ON environment_rotations(workspace_id)`)
+	db.Exec(`CREATE TABLE IF NOT EXISTS environment_rotation_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rotation_id INTEGER NOT NULL REFERENCES environment_rotations(id) ON DELETE CASCADE,
+		success INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		variables TEXT NOT NULL DEFAULT '',
+		duration_ms INTEGER NOT NULL DEFAULT 0,
+		ran_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_environment_rotation_runs_rotation ON environment_rotation_runs(rotation_id, ran_at)`)
+}
+
+func migrateTokenRefreshers(db *sql.DB) {
+	// Login requests run on an interval to keep a token fresh in an environment
+	db.Exec(`CREATE TABLE IF NOT EXISTS token_refreshers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		login_request_id INTEGER NOT NULL REFERENCES requests(id) ON DELETE CASCADE,
+		environment_id INTEGER REFERENCES environments(id) ON DELETE SET NULL,
+		extract_vars TEXT NOT NULL,
+		expires_in_path TEXT NOT NULL DEFAULT '',
+		interval_seconds INTEGER NOT NULL DEFAULT 900,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		last_attempt_at DATETIME,
+		last_refreshed_at DATETIME,
+		expires_at DATETIME,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_token_refreshers_workspace ON token_refreshers(workspace_id)`)
+}
+
+func migrateWSMessages(db *sql.DB) {
+	// Frames of a WebSocket session, one row each, kept with its history entry
+	db.Exec(`CREATE TABLE IF NOT EXISTS ws_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		history_id INTEGER NOT NULL REFERENCES request_history(id) ON DELETE CASCADE,
+		seq INTEGER NOT NULL,
+		direction TEXT NOT NULL,
+		format TEXT NOT NULL DEFAULT 'text',
+		payload TEXT NOT NULL DEFAULT '',
+		size INTEGER NOT NULL DEFAULT 0,
+		sent_at DATETIME NOT NULL
+	)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_ws_messages_history ON ws_messages(history_id, seq)`)
+}
+
+func migrateResponseAnnotations(db *sql.DB) {
+	// Field descriptions and deprecation notes on JSON paths of a request's response
+	db.Exec(`CREATE TABLE IF NOT EXISTS response_annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		request_id INTEGER NOT NULL REFERENCES requests(id) ON DELETE CASCADE,
+		path TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		deprecated INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(request_id, path)
+	)`)
+}
+
+func migrateRetryPolicy(db *sql.DB) {
+	// Per-request timeout and retry policy; 0 keeps the executor defaults
+	for _, table := range []string{"requests", "flow_steps"} {
+		db.Exec("ALTER TABLE " + table + " ADD COLUMN timeout_ms INTEGER NOT NULL DEFAULT 0")
+		db.Exec("ALTER TABLE " + table + " ADD COLUMN retry_count INTEGER NOT NULL DEFAULT 0")
+		db.Exec("ALTER TABLE " + table + " ADD COLUMN retry_backoff_ms INTEGER NOT NULL DEFAULT 0")
+		db.Exec("ALTER TABLE " + table + " ADD COLUMN retry_on_status TEXT NOT NULL DEFAULT ''")
+	}
+}
+
+func migrateCookieJar(db *sql.DB) {
+	// Cookies captured from Set-Cookie responses, sent with later requests of the workspace
+	db.Exec(`CREATE TABLE IF NOT EXISTS cookies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		domain TEXT NOT NULL,
+		path TEXT NOT NULL DEFAULT '/',
+		name TEXT NOT NULL,
+		value TEXT NOT NULL DEFAULT '',
+		expires_at DATETIME,
+		secure INTEGER NOT NULL DEFAULT 0,
+		http_only INTEGER NOT NULL DEFAULT 0,
+		host_only INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(workspace_id, domain, path, name)
+	)`)
+}
+
+func migrateOpenAPISpecs(db *sql.DB) {
+	// Imported OpenAPI documents that executions can validate responses against
+	db.Exec(`CREATE TABLE IF NOT EXISTS openapi_specs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		spec TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_openapi_specs_workspace ON openapi_specs(workspace_id)`)
+}
+
+func migrateRedirectControl(db *sql.DB) {
+	// Per-request redirect policy; 0 follows up to the default 10 redirects
+	for _, table := range []string{"requests", "flow_steps"} {
+		db.Exec("ALTER TABLE " + table + " ADD COLUMN disable_redirects INTEGER NOT NULL DEFAULT 0")
+		db.Exec("ALTER TABLE " + table + " ADD COLUMN max_redirects INTEGER NOT NULL DEFAULT 0")
+	}
+	// Redirect chain of each execution (JSON array of hops)
+	db.Exec("ALTER TABLE request_history ADD COLUMN redirects TEXT NOT NULL DEFAULT ''")
+}
+
+func migrateEncryptionKeys(db *sql.DB) {
+	// Key that seals the workspace's secret variables and client certificates;
+	// key_check is a known value sealed with it, to verify passphrases
+	db.Exec(`CREATE TABLE IF NOT EXISTS workspace_encryption_keys (
+		workspace_id INTEGER PRIMARY KEY REFERENCES workspaces(id) ON DELETE CASCADE,
+		source TEXT NOT NULL,
+		kms_key_env TEXT NOT NULL DEFAULT '',
+		salt TEXT NOT NULL DEFAULT '',
+		iterations INTEGER NOT NULL DEFAULT 0,
+		key_check TEXT NOT NULL,
+		version INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		rotated_at DATETIME
+	)`)
+}
+
+func migrateHistoryNetwork(db *sql.DB) {
+	// Effective proxy/TLS settings of each execution (JSON object)
+	db.Exec("ALTER TABLE request_history ADD COLUMN network TEXT NOT NULL DEFAULT ''")
+}
+
+func migrateHistoryAttempts(db *sql.DB) {
+	// Attempts of one execution with a retry policy share attempt_group_id
+	db.Exec("ALTER TABLE request_history ADD COLUMN attempt_group_id TEXT")
+	db.Exec("ALTER TABLE request_history ADD COLUMN attempt INTEGER NOT NULL DEFAULT 0")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_request_history_attempt_group ON request_history(attempt_group_id)")
+}
+
+func migrateEnvironmentComments(db *sql.DB) {
+	// .env comments kept for export (JSON object: variable name → comment)
+	db.Exec("ALTER TABLE environments ADD COLUMN comments TEXT NOT NULL DEFAULT ''")
+}
+
+func migrateSnippets(db *sql.DB) {
+	// Named body fragments inserted with {{snippet:name}}
+	db.Exec(`CREATE TABLE IF NOT EXISTS snippets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		content TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(workspace_id, name)
+	)`)
+}
+
+func migrateArchives(db *sql.DB) {
+	// Flow runs and history moved to the archive store (ARCHIVE_DIR/ARCHIVE_S3_*)
+	// before retention deleted them; kind is run or history
+	db.Exec(`CREATE TABLE IF NOT EXISTS archives (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		kind TEXT NOT NULL,
+		run_id TEXT,
+		flow_id INTEGER,
+		storage TEXT NOT NULL,
+		object_key TEXT NOT NULL,
+		entries INTEGER NOT NULL DEFAULT 0,
+		size INTEGER NOT NULL DEFAULT 0,
+		oldest_at DATETIME,
+		newest_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_archives_workspace ON archives(workspace_id, id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_archives_run ON archives(run_id)")
+}
+
+func migrateFlowRuns(db *sql.DB) {
+	// Each flow run's full result (steps, assertions, extracted variables,
+	// timings) so past runs can be reviewed after the response is gone
+	db.Exec(`CREATE TABLE IF NOT EXISTS flow_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		run_id TEXT NOT NULL UNIQUE,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		flow_id INTEGER NOT NULL,
+		flow_name TEXT NOT NULL DEFAULT '',
+		success INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		total_time_ms INTEGER NOT NULL DEFAULT 0,
+		step_count INTEGER NOT NULL DEFAULT 0,
+		result TEXT NOT NULL DEFAULT '{}',
+		started_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_flow_runs_flow ON flow_runs(flow_id, id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_flow_runs_created ON flow_runs(created_at)")
+}
+
+func migrateContractDrift(db *sql.DB) {
+	// Saved response examples drift checks compare against, and collections
+	// checked for drift on a schedule
+	db.Exec(`CREATE TABLE IF NOT EXISTS drift_baselines (
+		request_id INTEGER PRIMARY KEY REFERENCES requests(id) ON DELETE CASCADE,
+		history_id INTEGER,
+		response_body TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec(`CREATE TABLE IF NOT EXISTS drift_monitors (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+		collection_id INTEGER NOT NULL UNIQUE REFERENCES collections(id) ON DELETE CASCADE,
+		interval_seconds INTEGER NOT NULL DEFAULT 3600,
+		webhook_url TEXT NOT NULL DEFAULT '',
+		enabled INTEGER NOT NULL DEFAULT 1,
+		last_run_at DATETIME,
+		last_report TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec(`CREATE INDEX IF NOT EXISTS idx_drift_monitors_workspace ON drift_monitors(workspace_id)`)
+}
+
+func migrateJobKeys(db *sql.DB) {
+	// Scheduled work is queued at most once while a job for it is pending
+	db.Exec("ALTER TABLE jobs ADD COLUMN dedupe_key TEXT")
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_active_key ON jobs(dedupe_key) WHERE dedupe_key IS NOT NULL AND status IN ('queued', 'running')")
+}
+
+func migrateTokenRefreshRuns(db *sql.DB) {
+	// Scheduled token refreshes, counted against scheduledRunsPerDay
+	db.Exec(`CREATE TABLE IF NOT EXISTS token_refresh_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		refresher_id INTEGER NOT NULL REFERENCES token_refreshers(id) ON DELETE CASCADE,
+		ran_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_token_refresh_runs_refresher ON token_refresh_runs(refresher_id, ran_at)")
+}
@@ -11,7 +11,7 @@ import (
 )
 
 const createCollection = `-- name: CreateCollection :one
-INSERT INTO collections (name, parent_id, workspace_id, sort_order) VALUES (?, ?, ?, ?) RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order
+INSERT INTO collections (name, parent_id, workspace_id, sort_order) VALUES (?, ?, ?, ?) RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth
 `
 
 type CreateCollectionParams struct {
@@ -38,6 +38,10 @@ func (q *Queries) CreateCollection(ctx context.Context, arg CreateCollectionPara
 		&i.WorkspaceID,
 		&i.Variables,
 		&i.SortOrder,
+		&i.SecretVariables,
+		&i.SigningHook,
+		&i.RunFlows,
+		&i.Auth,
 	)
 	return i, err
 }
@@ -52,7 +56,7 @@ func (q *Queries) DeleteCollection(ctx context.Context, id int64) error {
 }
 
 const getCollection = `-- name: GetCollection :one
-SELECT id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order FROM collections WHERE id = ? LIMIT 1
+SELECT id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth FROM collections WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetCollection(ctx context.Context, id int64) (Collection, error) {
@@ -67,6 +71,10 @@ func (q *Queries) GetCollection(ctx context.Context, id int64) (Collection, erro
 		&i.WorkspaceID,
 		&i.Variables,
 		&i.SortOrder,
+		&i.SecretVariables,
+		&i.SigningHook,
+		&i.RunFlows,
+		&i.Auth,
 	)
 	return i, err
 }
@@ -105,7 +113,7 @@ func (q *Queries) GetMaxRootCollectionSortOrder(ctx context.Context, workspaceID
 }
 
 const listChildCollections = `-- name: ListChildCollections :many
-SELECT id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order FROM collections WHERE parent_id = ? ORDER BY sort_order ASC, name ASC
+SELECT id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth FROM collections WHERE parent_id = ? ORDER BY sort_order ASC, name ASC
 `
 
 func (q *Queries) ListChildCollections(ctx context.Context, parentID sql.NullInt64) ([]Collection, error) {
@@ -126,6 +134,10 @@ func (q *Queries) ListChildCollections(ctx context.Context, parentID sql.NullInt
 			&i.WorkspaceID,
 			&i.Variables,
 			&i.SortOrder,
+			&i.SecretVariables,
+			&i.SigningHook,
+			&i.RunFlows,
+			&i.Auth,
 		); err != nil {
 			return nil, err
 		}
@@ -141,7 +153,7 @@ func (q *Queries) ListChildCollections(ctx context.Context, parentID sql.NullInt
 }
 
 const listCollections = `-- name: ListCollections :many
-SELECT id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order FROM collections WHERE workspace_id = ? ORDER BY sort_order ASC, name ASC
+SELECT id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth FROM collections WHERE workspace_id = ? ORDER BY sort_order ASC, name ASC
 `
 
 func (q *Queries) ListCollections(ctx context.Context, workspaceID int64) ([]Collection, error) {
@@ -162,6 +174,10 @@ func (q *Queries) ListCollections(ctx context.Context, workspaceID int64) ([]Col
 			&i.WorkspaceID,
 			&i.Variables,
 			&i.SortOrder,
+			&i.SecretVariables,
+			&i.SigningHook,
+			&i.RunFlows,
+			&i.Auth,
 		); err != nil {
 			return nil, err
 		}
@@ -177,7 +193,7 @@ func (q *Queries) ListCollections(ctx context.Context, workspaceID int64) ([]Col
 }
 
 const listRootCollections = `-- name: ListRootCollections :many
-SELECT id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order FROM collections WHERE parent_id IS NULL AND workspace_id = ? ORDER BY sort_order ASC, name ASC
+SELECT id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth FROM collections WHERE parent_id IS NULL AND workspace_id = ? ORDER BY sort_order ASC, name ASC
 `
 
 func (q *Queries) ListRootCollections(ctx context.Context, workspaceID int64) ([]Collection, error) {
@@ -198,6 +214,10 @@ func (q *Queries) ListRootCollections(ctx context.Context, workspaceID int64) ([
 			&i.WorkspaceID,
 			&i.Variables,
 			&i.SortOrder,
+			&i.SecretVariables,
+			&i.SigningHook,
+			&i.RunFlows,
+			&i.Auth,
 		); err != nil {
 			return nil, err
 		}
@@ -213,7 +233,7 @@ func (q *Queries) ListRootCollections(ctx context.Context, workspaceID int64) ([
 }
 
 const updateCollection = `-- name: UpdateCollection :one
-UPDATE collections SET name = ?, parent_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order
+UPDATE collections SET name = ?, parent_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth
 `
 
 type UpdateCollectionParams struct {
@@ -234,6 +254,39 @@ func (q *Queries) UpdateCollection(ctx context.Context, arg UpdateCollectionPara
 		&i.WorkspaceID,
 		&i.Variables,
 		&i.SortOrder,
+		&i.SecretVariables,
+		&i.SigningHook,
+		&i.RunFlows,
+		&i.Auth,
+	)
+	return i, err
+}
+
+const updateCollectionAuth = `-- name: UpdateCollectionAuth :one
+UPDATE collections SET auth = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth
+`
+
+type UpdateCollectionAuthParams struct {
+	Auth sql.NullString `json:"auth"`
+	ID   int64          `json:"id"`
+}
+
+func (q *Queries) UpdateCollectionAuth(ctx context.Context, arg UpdateCollectionAuthParams) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, updateCollectionAuth, arg.Auth, arg.ID)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ParentID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.Variables,
+		&i.SortOrder,
+		&i.SecretVariables,
+		&i.SigningHook,
+		&i.RunFlows,
+		&i.Auth,
 	)
 	return i, err
 }
@@ -253,6 +306,64 @@ func (q *Queries) UpdateCollectionParentAndSortOrder(ctx context.Context, arg Up
 	return err
 }
 
+const updateCollectionRunFlows = `-- name: UpdateCollectionRunFlows :one
+UPDATE collections SET run_flows = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth
+`
+
+type UpdateCollectionRunFlowsParams struct {
+	RunFlows sql.NullString `json:"run_flows"`
+	ID       int64          `json:"id"`
+}
+
+func (q *Queries) UpdateCollectionRunFlows(ctx context.Context, arg UpdateCollectionRunFlowsParams) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, updateCollectionRunFlows, arg.RunFlows, arg.ID)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ParentID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.Variables,
+		&i.SortOrder,
+		&i.SecretVariables,
+		&i.SigningHook,
+		&i.RunFlows,
+		&i.Auth,
+	)
+	return i, err
+}
+
+const updateCollectionSigningHook = `-- name: UpdateCollectionSigningHook :one
+UPDATE collections SET signing_hook = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth
+`
+
+type UpdateCollectionSigningHookParams struct {
+	SigningHook sql.NullString `json:"signing_hook"`
+	ID          int64          `json:"id"`
+}
+
+func (q *Queries) UpdateCollectionSigningHook(ctx context.Context, arg UpdateCollectionSigningHookParams) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, updateCollectionSigningHook, arg.SigningHook, arg.ID)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ParentID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.Variables,
+		&i.SortOrder,
+		&i.SecretVariables,
+		&i.SigningHook,
+		&i.RunFlows,
+		&i.Auth,
+	)
+	return i, err
+}
+
 const updateCollectionSortOrder = `-- name: UpdateCollectionSortOrder :exec
 UPDATE collections SET sort_order = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
 `
@@ -267,8 +378,38 @@ func (q *Queries) UpdateCollectionSortOrder(ctx context.Context, arg UpdateColle
 	return err
 }
 
+const updateCollectionVariableSet = `-- name: UpdateCollectionVariableSet :one
+UPDATE collections SET variables = ?, secret_variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth
+`
+
+type UpdateCollectionVariableSetParams struct {
+	Variables       sql.NullString `json:"variables"`
+	SecretVariables sql.NullString `json:"secret_variables"`
+	ID              int64          `json:"id"`
+}
+
+func (q *Queries) UpdateCollectionVariableSet(ctx context.Context, arg UpdateCollectionVariableSetParams) (Collection, error) {
+	row := q.db.QueryRowContext(ctx, updateCollectionVariableSet, arg.Variables, arg.SecretVariables, arg.ID)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.ParentID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.Variables,
+		&i.SortOrder,
+		&i.SecretVariables,
+		&i.SigningHook,
+		&i.RunFlows,
+		&i.Auth,
+	)
+	return i, err
+}
+
 const updateCollectionVariables = `-- name: UpdateCollectionVariables :one
-UPDATE collections SET variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order
+UPDATE collections SET variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, parent_id, created_at, updated_at, workspace_id, variables, sort_order, secret_variables, signing_hook, run_flows, auth
 `
 
 type UpdateCollectionVariablesParams struct {
@@ -288,6 +429,10 @@ func (q *Queries) UpdateCollectionVariables(ctx context.Context, arg UpdateColle
 		&i.WorkspaceID,
 		&i.Variables,
 		&i.SortOrder,
+		&i.SecretVariables,
+		&i.SigningHook,
+		&i.RunFlows,
+		&i.Auth,
 	)
 	return i, err
 }
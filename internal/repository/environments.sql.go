@@ -11,7 +11,7 @@ import (
 )
 
 const activateEnvironment = `-- name: ActivateEnvironment :one
-UPDATE environments SET is_active = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, variables, is_active, created_at, updated_at, workspace_id
+UPDATE environments SET is_active = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, variables, is_active, created_at, updated_at, workspace_id, parent_id, collection_id, comments
 `
 
 func (q *Queries) ActivateEnvironment(ctx context.Context, id int64) (Environment, error) {
@@ -25,22 +25,42 @@ func (q *Queries) ActivateEnvironment(ctx context.Context, id int64) (Environmen
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.WorkspaceID,
+		&i.ParentID,
+		&i.CollectionID,
+		&i.Comments,
 	)
 	return i, err
 }
 
+const clearEnvironmentParent = `-- name: ClearEnvironmentParent :exec
+UPDATE environments SET parent_id = NULL, updated_at = CURRENT_TIMESTAMP WHERE parent_id = ?
+`
+
+func (q *Queries) ClearEnvironmentParent(ctx context.Context, parentID sql.NullInt64) error {
+	_, err := q.db.ExecContext(ctx, clearEnvironmentParent, parentID)
+	return err
+}
+
 const createEnvironment = `-- name: CreateEnvironment :one
-INSERT INTO environments (name, variables, workspace_id) VALUES (?, ?, ?) RETURNING id, name, variables, is_active, created_at, updated_at, workspace_id
+INSERT INTO environments (name, variables, workspace_id, parent_id, collection_id) VALUES (?, ?, ?, ?, ?) RETURNING id, name, variables, is_active, created_at, updated_at, workspace_id, parent_id, collection_id, comments
 `
 
 type CreateEnvironmentParams struct {
-	Name        string         `json:"name"`
-	Variables   sql.NullString `json:"variables"`
-	WorkspaceID int64          `json:"workspace_id"`
+	Name         string         `json:"name"`
+	Variables    sql.NullString `json:"variables"`
+	WorkspaceID  int64          `json:"workspace_id"`
+	ParentID     sql.NullInt64  `json:"parent_id"`
+	CollectionID sql.NullInt64  `json:"collection_id"`
 }
 
 func (q *Queries) CreateEnvironment(ctx context.Context, arg CreateEnvironmentParams) (Environment, error) {
-	row := q.db.QueryRowContext(ctx, createEnvironment, arg.Name, arg.Variables, arg.WorkspaceID)
+	row := q.db.QueryRowContext(ctx, createEnvironment,
+		arg.Name,
+		arg.Variables,
+		arg.WorkspaceID,
+		arg.ParentID,
+		arg.CollectionID,
+	)
 	var i Environment
 	err := row.Scan(
 		&i.ID,
@@ -50,12 +70,15 @@ func (q *Queries) CreateEnvironment(ctx context.Context, arg CreateEnvironmentPa
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.WorkspaceID,
+		&i.ParentID,
+		&i.CollectionID,
+		&i.Comments,
 	)
 	return i, err
 }
 
 const deactivateAllEnvironments = `-- name: DeactivateAllEnvironments :exec
-UPDATE environments SET is_active = FALSE WHERE workspace_id = ?
+UPDATE environments SET is_active = FALSE WHERE workspace_id = ? AND collection_id IS NULL
 `
 
 func (q *Queries) DeactivateAllEnvironments(ctx context.Context, workspaceID int64) error {
@@ -63,6 +86,37 @@ func (q *Queries) DeactivateAllEnvironments(ctx context.Context, workspaceID int
 	return err
 }
 
+const deactivateCollectionEnvironments = `-- name: DeactivateCollectionEnvironments :exec
+UPDATE environments SET is_active = FALSE WHERE collection_id = ?
+`
+
+func (q *Queries) DeactivateCollectionEnvironments(ctx context.Context, collectionID sql.NullInt64) error {
+	_, err := q.db.ExecContext(ctx, deactivateCollectionEnvironments, collectionID)
+	return err
+}
+
+const deactivateEnvironment = `-- name: DeactivateEnvironment :one
+UPDATE environments SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, variables, is_active, created_at, updated_at, workspace_id, parent_id, collection_id, comments
+`
+
+func (q *Queries) DeactivateEnvironment(ctx context.Context, id int64) (Environment, error) {
+	row := q.db.QueryRowContext(ctx, deactivateEnvironment, id)
+	var i Environment
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Variables,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.ParentID,
+		&i.CollectionID,
+		&i.Comments,
+	)
+	return i, err
+}
+
 const deleteEnvironment = `-- name: DeleteEnvironment :exec
 DELETE FROM environments WHERE id = ?
 `
@@ -72,8 +126,30 @@ func (q *Queries) DeleteEnvironment(ctx context.Context, id int64) error {
 	return err
 }
 
+const getActiveCollectionEnvironment = `-- name: GetActiveCollectionEnvironment :one
+SELECT id, name, variables, is_active, created_at, updated_at, workspace_id, parent_id, collection_id, comments FROM environments WHERE is_active = TRUE AND collection_id = ? LIMIT 1
+`
+
+func (q *Queries) GetActiveCollectionEnvironment(ctx context.Context, collectionID sql.NullInt64) (Environment, error) {
+	row := q.db.QueryRowContext(ctx, getActiveCollectionEnvironment, collectionID)
+	var i Environment
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Variables,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.WorkspaceID,
+		&i.ParentID,
+		&i.CollectionID,
+		&i.Comments,
+	)
+	return i, err
+}
+
 const getActiveEnvironment = `-- name: GetActiveEnvironment :one
-SELECT id, name, variables, is_active, created_at, updated_at, workspace_id FROM environments WHERE is_active = TRUE AND workspace_id = ? LIMIT 1
+SELECT id, name, variables, is_active, created_at, updated_at, workspace_id, parent_id, collection_id, comments FROM environments WHERE is_active = TRUE AND workspace_id = ? AND collection_id IS NULL LIMIT 1
 `
 
 func (q *Queries) GetActiveEnvironment(ctx context.Context, workspaceID int64) (Environment, error) {
@@ -87,12 +163,15 @@ func (q *Queries) GetActiveEnvironment(ctx context.Context, workspaceID int64) (
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.WorkspaceID,
+		&i.ParentID,
+		&i.CollectionID,
+		&i.Comments,
 	)
 	return i, err
 }
 
 const getEnvironment = `-- name: GetEnvironment :one
-SELECT id, name, variables, is_active, created_at, updated_at, workspace_id FROM environments WHERE id = ? LIMIT 1
+SELECT id, name, variables, is_active, created_at, updated_at, workspace_id, parent_id, collection_id, comments FROM environments WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetEnvironment(ctx context.Context, id int64) (Environment, error) {
@@ -106,12 +185,15 @@ func (q *Queries) GetEnvironment(ctx context.Context, id int64) (Environment, er
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.WorkspaceID,
+		&i.ParentID,
+		&i.CollectionID,
+		&i.Comments,
 	)
 	return i, err
 }
 
 const listEnvironments = `-- name: ListEnvironments :many
-SELECT id, name, variables, is_active, created_at, updated_at, workspace_id FROM environments WHERE workspace_id = ? ORDER BY name
+SELECT id, name, variables, is_active, created_at, updated_at, workspace_id, parent_id, collection_id, comments FROM environments WHERE workspace_id = ? ORDER BY name
 `
 
 func (q *Queries) ListEnvironments(ctx context.Context, workspaceID int64) ([]Environment, error) {
@@ -131,6 +213,9 @@ func (q *Queries) ListEnvironments(ctx context.Context, workspaceID int64) ([]En
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.WorkspaceID,
+			&i.ParentID,
+			&i.CollectionID,
+			&i.Comments,
 		); err != nil {
 			return nil, err
 		}
@@ -146,17 +231,23 @@ func (q *Queries) ListEnvironments(ctx context.Context, workspaceID int64) ([]En
 }
 
 const updateEnvironment = `-- name: UpdateEnvironment :one
-UPDATE environments SET name = ?, variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, variables, is_active, created_at, updated_at, workspace_id
+UPDATE environments SET name = ?, variables = ?, parent_id = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, variables, is_active, created_at, updated_at, workspace_id, parent_id, collection_id, comments
 `
 
 type UpdateEnvironmentParams struct {
 	Name      string         `json:"name"`
 	Variables sql.NullString `json:"variables"`
+	ParentID  sql.NullInt64  `json:"parent_id"`
 	ID        int64          `json:"id"`
 }
 
 func (q *Queries) UpdateEnvironment(ctx context.Context, arg UpdateEnvironmentParams) (Environment, error) {
-	row := q.db.QueryRowContext(ctx, updateEnvironment, arg.Name, arg.Variables, arg.ID)
+	row := q.db.QueryRowContext(ctx, updateEnvironment,
+		arg.Name,
+		arg.Variables,
+		arg.ParentID,
+		arg.ID,
+	)
 	var i Environment
 	err := row.Scan(
 		&i.ID,
@@ -166,12 +257,29 @@ func (q *Queries) UpdateEnvironment(ctx context.Context, arg UpdateEnvironmentPa
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.WorkspaceID,
+		&i.ParentID,
+		&i.CollectionID,
+		&i.Comments,
 	)
 	return i, err
 }
 
+const updateEnvironmentComments = `-- name: UpdateEnvironmentComments :exec
+UPDATE environments SET comments = ? WHERE id = ?
+`
+
+type UpdateEnvironmentCommentsParams struct {
+	Comments string `json:"comments"`
+	ID       int64  `json:"id"`
+}
+
+func (q *Queries) UpdateEnvironmentComments(ctx context.Context, arg UpdateEnvironmentCommentsParams) error {
+	_, err := q.db.ExecContext(ctx, updateEnvironmentComments, arg.Comments, arg.ID)
+	return err
+}
+
 const updateEnvironmentVariables = `-- name: UpdateEnvironmentVariables :one
-UPDATE environments SET variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, variables, is_active, created_at, updated_at, workspace_id
+UPDATE environments SET variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, variables, is_active, created_at, updated_at, workspace_id, parent_id, collection_id, comments
 `
 
 type UpdateEnvironmentVariablesParams struct {
@@ -190,6 +298,9 @@ func (q *Queries) UpdateEnvironmentVariables(ctx context.Context, arg UpdateEnvi
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.WorkspaceID,
+		&i.ParentID,
+		&i.CollectionID,
+		&i.Comments,
 	)
 	return i, err
 }
@@ -102,3 +102,17 @@ func (q *Queries) ListAllUploadedFiles(ctx context.Context) ([]ListAllUploadedFi
 	}
 	return items, nil
 }
+
+const updateUploadedFileStoredName = `-- name: UpdateUploadedFileStoredName :exec
+UPDATE uploaded_files SET stored_name = ? WHERE id = ?
+`
+
+type UpdateUploadedFileStoredNameParams struct {
+	StoredName string `json:"stored_name"`
+	ID         int64  `json:"id"`
+}
+
+func (q *Queries) UpdateUploadedFileStoredName(ctx context.Context, arg UpdateUploadedFileStoredNameParams) error {
+	_, err := q.db.ExecContext(ctx, updateUploadedFileStoredName, arg.StoredName, arg.ID)
+	return err
+}
@@ -11,7 +11,7 @@ import (
 )
 
 const createFlow = `-- name: CreateFlow :one
-INSERT INTO flows (name, description, workspace_id, sort_order) VALUES (?, ?, ?, ?) RETURNING id, name, description, created_at, updated_at, workspace_id, sort_order
+INSERT INTO flows (name, description, workspace_id, sort_order, outputs) VALUES (?, ?, ?, ?, ?) RETURNING id, name, description, created_at, updated_at, workspace_id, sort_order, outputs, execution_count, last_executed_at, archived_at
 `
 
 type CreateFlowParams struct {
@@ -19,6 +19,7 @@ type CreateFlowParams struct {
 	Description sql.NullString `json:"description"`
 	WorkspaceID int64          `json:"workspace_id"`
 	SortOrder   int64          `json:"sort_order"`
+	Outputs     sql.NullString `json:"outputs"`
 }
 
 func (q *Queries) CreateFlow(ctx context.Context, arg CreateFlowParams) (Flow, error) {
@@ -27,6 +28,7 @@ func (q *Queries) CreateFlow(ctx context.Context, arg CreateFlowParams) (Flow, e
 		arg.Description,
 		arg.WorkspaceID,
 		arg.SortOrder,
+		arg.Outputs,
 	)
 	var i Flow
 	err := row.Scan(
@@ -37,6 +39,80 @@ func (q *Queries) CreateFlow(ctx context.Context, arg CreateFlowParams) (Flow, e
 		&i.UpdatedAt,
 		&i.WorkspaceID,
 		&i.SortOrder,
+		&i.Outputs,
+		&i.ExecutionCount,
+		&i.LastExecutedAt,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
+const createFlowEdge = `-- name: CreateFlowEdge :one
+INSERT INTO flow_edges (flow_id, source_key, target_key, label) VALUES (?, ?, ?, ?) RETURNING id, flow_id, source_key, target_key, label, created_at
+`
+
+type CreateFlowEdgeParams struct {
+	FlowID    int64  `json:"flow_id"`
+	SourceKey string `json:"source_key"`
+	TargetKey string `json:"target_key"`
+	Label     string `json:"label"`
+}
+
+func (q *Queries) CreateFlowEdge(ctx context.Context, arg CreateFlowEdgeParams) (FlowEdge, error) {
+	row := q.db.QueryRowContext(ctx, createFlowEdge,
+		arg.FlowID,
+		arg.SourceKey,
+		arg.TargetKey,
+		arg.Label,
+	)
+	var i FlowEdge
+	err := row.Scan(
+		&i.ID,
+		&i.FlowID,
+		&i.SourceKey,
+		&i.TargetKey,
+		&i.Label,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createFlowNode = `-- name: CreateFlowNode :one
+INSERT INTO flow_nodes (flow_id, node_key, type, name, config, position_x, position_y)
+VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id, flow_id, node_key, type, name, config, position_x, position_y, created_at
+`
+
+type CreateFlowNodeParams struct {
+	FlowID    int64   `json:"flow_id"`
+	NodeKey   string  `json:"node_key"`
+	Type      string  `json:"type"`
+	Name      string  `json:"name"`
+	Config    string  `json:"config"`
+	PositionX float64 `json:"position_x"`
+	PositionY float64 `json:"position_y"`
+}
+
+func (q *Queries) CreateFlowNode(ctx context.Context, arg CreateFlowNodeParams) (FlowNode, error) {
+	row := q.db.QueryRowContext(ctx, createFlowNode,
+		arg.FlowID,
+		arg.NodeKey,
+		arg.Type,
+		arg.Name,
+		arg.Config,
+		arg.PositionX,
+		arg.PositionY,
+	)
+	var i FlowNode
+	err := row.Scan(
+		&i.ID,
+		&i.FlowID,
+		&i.NodeKey,
+		&i.Type,
+		&i.Name,
+		&i.Config,
+		&i.PositionX,
+		&i.PositionY,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -44,29 +120,39 @@ func (q *Queries) CreateFlow(ctx context.Context, arg CreateFlowParams) (Flow, e
 const createFlowStep = `-- name: CreateFlowStep :one
 INSERT INTO flow_steps (flow_id, request_id, step_order, delay_ms, extract_vars, condition,
                         name, method, url, headers, body, body_type, cookies, proxy_id, loop_count,
-                        pre_script, post_script, continue_on_error)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, flow_id, request_id, step_order, delay_ms, extract_vars, condition, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, loop_count, pre_script, post_script, continue_on_error
+                        pre_script, post_script, continue_on_error, response_transform, wait_until, approval,
+                        timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, flow_id, request_id, step_order, delay_ms, extract_vars, condition, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, loop_count, pre_script, post_script, continue_on_error, response_transform, wait_until, approval, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects
 `
 
 type CreateFlowStepParams struct {
-	FlowID          int64          `json:"flow_id"`
-	RequestID       sql.NullInt64  `json:"request_id"`
-	StepOrder       int64          `json:"step_order"`
-	DelayMs         sql.NullInt64  `json:"delay_ms"`
-	ExtractVars     sql.NullString `json:"extract_vars"`
-	Condition       sql.NullString `json:"condition"`
-	Name            string         `json:"name"`
-	Method          string         `json:"method"`
-	Url             string         `json:"url"`
-	Headers         sql.NullString `json:"headers"`
-	Body            sql.NullString `json:"body"`
-	BodyType        sql.NullString `json:"body_type"`
-	Cookies         sql.NullString `json:"cookies"`
-	ProxyID         sql.NullInt64  `json:"proxy_id"`
-	LoopCount       sql.NullInt64  `json:"loop_count"`
-	PreScript       sql.NullString `json:"pre_script"`
-	PostScript      sql.NullString `json:"post_script"`
-	ContinueOnError sql.NullInt64  `json:"continue_on_error"`
+	FlowID            int64          `json:"flow_id"`
+	RequestID         sql.NullInt64  `json:"request_id"`
+	StepOrder         int64          `json:"step_order"`
+	DelayMs           sql.NullInt64  `json:"delay_ms"`
+	ExtractVars       sql.NullString `json:"extract_vars"`
+	Condition         sql.NullString `json:"condition"`
+	Name              string         `json:"name"`
+	Method            string         `json:"method"`
+	Url               string         `json:"url"`
+	Headers           sql.NullString `json:"headers"`
+	Body              sql.NullString `json:"body"`
+	BodyType          sql.NullString `json:"body_type"`
+	Cookies           sql.NullString `json:"cookies"`
+	ProxyID           sql.NullInt64  `json:"proxy_id"`
+	LoopCount         sql.NullInt64  `json:"loop_count"`
+	PreScript         sql.NullString `json:"pre_script"`
+	PostScript        sql.NullString `json:"post_script"`
+	ContinueOnError   sql.NullInt64  `json:"continue_on_error"`
+	ResponseTransform sql.NullString `json:"response_transform"`
+	WaitUntil         sql.NullString `json:"wait_until"`
+	Approval          sql.NullString `json:"approval"`
+	TimeoutMs         int64          `json:"timeout_ms"`
+	RetryCount        int64          `json:"retry_count"`
+	RetryBackoffMs    int64          `json:"retry_backoff_ms"`
+	RetryOnStatus     string         `json:"retry_on_status"`
+	DisableRedirects  int64          `json:"disable_redirects"`
+	MaxRedirects      int64          `json:"max_redirects"`
 }
 
 func (q *Queries) CreateFlowStep(ctx context.Context, arg CreateFlowStepParams) (FlowStep, error) {
@@ -89,6 +175,15 @@ func (q *Queries) CreateFlowStep(ctx context.Context, arg CreateFlowStepParams)
 		arg.PreScript,
 		arg.PostScript,
 		arg.ContinueOnError,
+		arg.ResponseTransform,
+		arg.WaitUntil,
+		arg.Approval,
+		arg.TimeoutMs,
+		arg.RetryCount,
+		arg.RetryBackoffMs,
+		arg.RetryOnStatus,
+		arg.DisableRedirects,
+		arg.MaxRedirects,
 	)
 	var i FlowStep
 	err := row.Scan(
@@ -114,6 +209,15 @@ func (q *Queries) CreateFlowStep(ctx context.Context, arg CreateFlowStepParams)
 		&i.PreScript,
 		&i.PostScript,
 		&i.ContinueOnError,
+		&i.ResponseTransform,
+		&i.WaitUntil,
+		&i.Approval,
+		&i.TimeoutMs,
+		&i.RetryCount,
+		&i.RetryBackoffMs,
+		&i.RetryOnStatus,
+		&i.DisableRedirects,
+		&i.MaxRedirects,
 	)
 	return i, err
 }
@@ -127,6 +231,24 @@ func (q *Queries) DeleteFlow(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteFlowEdgesByFlow = `-- name: DeleteFlowEdgesByFlow :exec
+DELETE FROM flow_edges WHERE flow_id = ?
+`
+
+func (q *Queries) DeleteFlowEdgesByFlow(ctx context.Context, flowID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFlowEdgesByFlow, flowID)
+	return err
+}
+
+const deleteFlowNodesByFlow = `-- name: DeleteFlowNodesByFlow :exec
+DELETE FROM flow_nodes WHERE flow_id = ?
+`
+
+func (q *Queries) DeleteFlowNodesByFlow(ctx context.Context, flowID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFlowNodesByFlow, flowID)
+	return err
+}
+
 const deleteFlowStep = `-- name: DeleteFlowStep :exec
 DELETE FROM flow_steps WHERE id = ?
 `
@@ -146,7 +268,7 @@ func (q *Queries) DeleteFlowStepsByFlow(ctx context.Context, flowID int64) error
 }
 
 const getFlow = `-- name: GetFlow :one
-SELECT id, name, description, created_at, updated_at, workspace_id, sort_order FROM flows WHERE id = ? LIMIT 1
+SELECT id, name, description, created_at, updated_at, workspace_id, sort_order, outputs, execution_count, last_executed_at, archived_at FROM flows WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetFlow(ctx context.Context, id int64) (Flow, error) {
@@ -160,12 +282,16 @@ func (q *Queries) GetFlow(ctx context.Context, id int64) (Flow, error) {
 		&i.UpdatedAt,
 		&i.WorkspaceID,
 		&i.SortOrder,
+		&i.Outputs,
+		&i.ExecutionCount,
+		&i.LastExecutedAt,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
 
 const getFlowStep = `-- name: GetFlowStep :one
-SELECT id, flow_id, request_id, step_order, delay_ms, extract_vars, condition, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, loop_count, pre_script, post_script, continue_on_error FROM flow_steps WHERE id = ? LIMIT 1
+SELECT id, flow_id, request_id, step_order, delay_ms, extract_vars, condition, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, loop_count, pre_script, post_script, continue_on_error, response_transform, wait_until, approval, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects FROM flow_steps WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetFlowStep(ctx context.Context, id int64) (FlowStep, error) {
@@ -194,6 +320,15 @@ func (q *Queries) GetFlowStep(ctx context.Context, id int64) (FlowStep, error) {
 		&i.PreScript,
 		&i.PostScript,
 		&i.ContinueOnError,
+		&i.ResponseTransform,
+		&i.WaitUntil,
+		&i.Approval,
+		&i.TimeoutMs,
+		&i.RetryCount,
+		&i.RetryBackoffMs,
+		&i.RetryOnStatus,
+		&i.DisableRedirects,
+		&i.MaxRedirects,
 	)
 	return i, err
 }
@@ -209,8 +344,79 @@ func (q *Queries) GetMaxFlowSortOrder(ctx context.Context, workspaceID int64) (i
 	return max_sort_order, err
 }
 
+const listFlowEdges = `-- name: ListFlowEdges :many
+SELECT id, flow_id, source_key, target_key, label, created_at FROM flow_edges WHERE flow_id = ? ORDER BY id
+`
+
+func (q *Queries) ListFlowEdges(ctx context.Context, flowID int64) ([]FlowEdge, error) {
+	rows, err := q.db.QueryContext(ctx, listFlowEdges, flowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FlowEdge
+	for rows.Next() {
+		var i FlowEdge
+		if err := rows.Scan(
+			&i.ID,
+			&i.FlowID,
+			&i.SourceKey,
+			&i.TargetKey,
+			&i.Label,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFlowNodes = `-- name: ListFlowNodes :many
+SELECT id, flow_id, node_key, type, name, config, position_x, position_y, created_at FROM flow_nodes WHERE flow_id = ? ORDER BY id
+`
+
+func (q *Queries) ListFlowNodes(ctx context.Context, flowID int64) ([]FlowNode, error) {
+	rows, err := q.db.QueryContext(ctx, listFlowNodes, flowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FlowNode
+	for rows.Next() {
+		var i FlowNode
+		if err := rows.Scan(
+			&i.ID,
+			&i.FlowID,
+			&i.NodeKey,
+			&i.Type,
+			&i.Name,
+			&i.Config,
+			&i.PositionX,
+			&i.PositionY,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listFlowSteps = `-- name: ListFlowSteps :many
-SELECT id, flow_id, request_id, step_order, delay_ms, extract_vars, condition, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, loop_count, pre_script, post_script, continue_on_error FROM flow_steps WHERE flow_id = ? ORDER BY step_order
+SELECT id, flow_id, request_id, step_order, delay_ms, extract_vars, condition, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, loop_count, pre_script, post_script, continue_on_error, response_transform, wait_until, approval, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects FROM flow_steps WHERE flow_id = ? ORDER BY step_order
 `
 
 func (q *Queries) ListFlowSteps(ctx context.Context, flowID int64) ([]FlowStep, error) {
@@ -245,6 +451,15 @@ func (q *Queries) ListFlowSteps(ctx context.Context, flowID int64) ([]FlowStep,
 			&i.PreScript,
 			&i.PostScript,
 			&i.ContinueOnError,
+			&i.ResponseTransform,
+			&i.WaitUntil,
+			&i.Approval,
+			&i.TimeoutMs,
+			&i.RetryCount,
+			&i.RetryBackoffMs,
+			&i.RetryOnStatus,
+			&i.DisableRedirects,
+			&i.MaxRedirects,
 		); err != nil {
 			return nil, err
 		}
@@ -260,7 +475,7 @@ func (q *Queries) ListFlowSteps(ctx context.Context, flowID int64) ([]FlowStep,
 }
 
 const listFlows = `-- name: ListFlows :many
-SELECT id, name, description, created_at, updated_at, workspace_id, sort_order FROM flows WHERE workspace_id = ? ORDER BY sort_order ASC, name ASC
+SELECT id, name, description, created_at, updated_at, workspace_id, sort_order, outputs, execution_count, last_executed_at, archived_at FROM flows WHERE workspace_id = ? ORDER BY sort_order ASC, name ASC
 `
 
 func (q *Queries) ListFlows(ctx context.Context, workspaceID int64) ([]Flow, error) {
@@ -280,6 +495,10 @@ func (q *Queries) ListFlows(ctx context.Context, workspaceID int64) ([]Flow, err
 			&i.UpdatedAt,
 			&i.WorkspaceID,
 			&i.SortOrder,
+			&i.Outputs,
+			&i.ExecutionCount,
+			&i.LastExecutedAt,
+			&i.ArchivedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -294,18 +513,96 @@ func (q *Queries) ListFlows(ctx context.Context, workspaceID int64) ([]Flow, err
 	return items, nil
 }
 
+const recordFlowExecution = `-- name: RecordFlowExecution :exec
+UPDATE flows SET execution_count = execution_count + 1, last_executed_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+func (q *Queries) RecordFlowExecution(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, recordFlowExecution, id)
+	return err
+}
+
+const rewireFlowNodesRequest = `-- name: RewireFlowNodesRequest :execrows
+UPDATE flow_nodes SET config = json_set(config, '$.requestId', ?)
+WHERE type = 'request' AND json_extract(config, '$.requestId') = ?
+`
+
+type RewireFlowNodesRequestParams struct {
+	SurvivorID int64 `json:"survivor_id"`
+	LoserID    int64 `json:"loser_id"`
+}
+
+func (q *Queries) RewireFlowNodesRequest(ctx context.Context, arg RewireFlowNodesRequestParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rewireFlowNodesRequest,
+		arg.SurvivorID,
+		arg.LoserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rewireFlowStepsRequest = `-- name: RewireFlowStepsRequest :execrows
+UPDATE flow_steps SET request_id = ?, updated_at = CURRENT_TIMESTAMP WHERE request_id = ?
+`
+
+type RewireFlowStepsRequestParams struct {
+	SurvivorID int64 `json:"survivor_id"`
+	LoserID    int64 `json:"loser_id"`
+}
+
+func (q *Queries) RewireFlowStepsRequest(ctx context.Context, arg RewireFlowStepsRequestParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rewireFlowStepsRequest,
+		arg.SurvivorID,
+		arg.LoserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setFlowArchived = `-- name: SetFlowArchived :execrows
+UPDATE flows SET archived_at = ? WHERE id = ? AND workspace_id = ?
+`
+
+type SetFlowArchivedParams struct {
+	ArchivedAt  sql.NullTime `json:"archived_at"`
+	ID          int64        `json:"id"`
+	WorkspaceID int64        `json:"workspace_id"`
+}
+
+func (q *Queries) SetFlowArchived(ctx context.Context, arg SetFlowArchivedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFlowArchived,
+		arg.ArchivedAt,
+		arg.ID,
+		arg.WorkspaceID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateFlow = `-- name: UpdateFlow :one
-UPDATE flows SET name = ?, description = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, description, created_at, updated_at, workspace_id, sort_order
+UPDATE flows SET name = ?, description = ?, outputs = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, description, created_at, updated_at, workspace_id, sort_order, outputs, execution_count, last_executed_at, archived_at
 `
 
 type UpdateFlowParams struct {
 	Name        string         `json:"name"`
 	Description sql.NullString `json:"description"`
+	Outputs     sql.NullString `json:"outputs"`
 	ID          int64          `json:"id"`
 }
 
 func (q *Queries) UpdateFlow(ctx context.Context, arg UpdateFlowParams) (Flow, error) {
-	row := q.db.QueryRowContext(ctx, updateFlow, arg.Name, arg.Description, arg.ID)
+	row := q.db.QueryRowContext(ctx, updateFlow,
+		arg.Name,
+		arg.Description,
+		arg.Outputs,
+		arg.ID,
+	)
 	var i Flow
 	err := row.Scan(
 		&i.ID,
@@ -315,6 +612,10 @@ func (q *Queries) UpdateFlow(ctx context.Context, arg UpdateFlowParams) (Flow, e
 		&i.UpdatedAt,
 		&i.WorkspaceID,
 		&i.SortOrder,
+		&i.Outputs,
+		&i.ExecutionCount,
+		&i.LastExecutedAt,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
@@ -352,29 +653,47 @@ UPDATE flow_steps SET
     pre_script = ?,
     post_script = ?,
     continue_on_error = ?,
+    response_transform = ?,
+    wait_until = ?,
+    approval = ?,
+    timeout_ms = ?,
+    retry_count = ?,
+    retry_backoff_ms = ?,
+    retry_on_status = ?,
+    disable_redirects = ?,
+    max_redirects = ?,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = ? RETURNING id, flow_id, request_id, step_order, delay_ms, extract_vars, condition, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, loop_count, pre_script, post_script, continue_on_error
+WHERE id = ? RETURNING id, flow_id, request_id, step_order, delay_ms, extract_vars, condition, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, loop_count, pre_script, post_script, continue_on_error, response_transform, wait_until, approval, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects
 `
 
 type UpdateFlowStepParams struct {
-	RequestID       sql.NullInt64  `json:"request_id"`
-	StepOrder       int64          `json:"step_order"`
-	DelayMs         sql.NullInt64  `json:"delay_ms"`
-	ExtractVars     sql.NullString `json:"extract_vars"`
-	Condition       sql.NullString `json:"condition"`
-	Name            string         `json:"name"`
-	Method          string         `json:"method"`
-	Url             string         `json:"url"`
-	Headers         sql.NullString `json:"headers"`
-	Body            sql.NullString `json:"body"`
-	BodyType        sql.NullString `json:"body_type"`
-	Cookies         sql.NullString `json:"cookies"`
-	ProxyID         sql.NullInt64  `json:"proxy_id"`
-	LoopCount       sql.NullInt64  `json:"loop_count"`
-	PreScript       sql.NullString `json:"pre_script"`
-	PostScript      sql.NullString `json:"post_script"`
-	ContinueOnError sql.NullInt64  `json:"continue_on_error"`
-	ID              int64          `json:"id"`
+	RequestID         sql.NullInt64  `json:"request_id"`
+	StepOrder         int64          `json:"step_order"`
+	DelayMs           sql.NullInt64  `json:"delay_ms"`
+	ExtractVars       sql.NullString `json:"extract_vars"`
+	Condition         sql.NullString `json:"condition"`
+	Name              string         `json:"name"`
+	Method            string         `json:"method"`
+	Url               string         `json:"url"`
+	Headers           sql.NullString `json:"headers"`
+	Body              sql.NullString `json:"body"`
+	BodyType          sql.NullString `json:"body_type"`
+	Cookies           sql.NullString `json:"cookies"`
+	ProxyID           sql.NullInt64  `json:"proxy_id"`
+	LoopCount         sql.NullInt64  `json:"loop_count"`
+	PreScript         sql.NullString `json:"pre_script"`
+	PostScript        sql.NullString `json:"post_script"`
+	ContinueOnError   sql.NullInt64  `json:"continue_on_error"`
+	ResponseTransform sql.NullString `json:"response_transform"`
+	WaitUntil         sql.NullString `json:"wait_until"`
+	Approval          sql.NullString `json:"approval"`
+	TimeoutMs         int64          `json:"timeout_ms"`
+	RetryCount        int64          `json:"retry_count"`
+	RetryBackoffMs    int64          `json:"retry_backoff_ms"`
+	RetryOnStatus     string         `json:"retry_on_status"`
+	DisableRedirects  int64          `json:"disable_redirects"`
+	MaxRedirects      int64          `json:"max_redirects"`
+	ID                int64          `json:"id"`
 }
 
 func (q *Queries) UpdateFlowStep(ctx context.Context, arg UpdateFlowStepParams) (FlowStep, error) {
@@ -396,6 +715,15 @@ func (q *Queries) UpdateFlowStep(ctx context.Context, arg UpdateFlowStepParams)
 		arg.PreScript,
 		arg.PostScript,
 		arg.ContinueOnError,
+		arg.ResponseTransform,
+		arg.WaitUntil,
+		arg.Approval,
+		arg.TimeoutMs,
+		arg.RetryCount,
+		arg.RetryBackoffMs,
+		arg.RetryOnStatus,
+		arg.DisableRedirects,
+		arg.MaxRedirects,
 		arg.ID,
 	)
 	var i FlowStep
@@ -422,6 +750,15 @@ func (q *Queries) UpdateFlowStep(ctx context.Context, arg UpdateFlowStepParams)
 		&i.PreScript,
 		&i.PostScript,
 		&i.ContinueOnError,
+		&i.ResponseTransform,
+		&i.WaitUntil,
+		&i.Approval,
+		&i.TimeoutMs,
+		&i.RetryCount,
+		&i.RetryBackoffMs,
+		&i.RetryOnStatus,
+		&i.DisableRedirects,
+		&i.MaxRedirects,
 	)
 	return i, err
 }
@@ -13,25 +13,32 @@ import (
 const createHistory = `-- name: CreateHistory :one
 INSERT INTO request_history (
     request_id, flow_id, method, url, request_headers, request_body,
-    status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, workspace_id
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id
+    status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, workspace_id, trace_id,
+    execution_group_id, redirects, network, attempt_group_id, attempt
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt
 `
 
 type CreateHistoryParams struct {
-	RequestID       sql.NullInt64  `json:"request_id"`
-	FlowID          sql.NullInt64  `json:"flow_id"`
-	Method          string         `json:"method"`
-	Url             string         `json:"url"`
-	RequestHeaders  sql.NullString `json:"request_headers"`
-	RequestBody     sql.NullString `json:"request_body"`
-	StatusCode      sql.NullInt64  `json:"status_code"`
-	ResponseHeaders sql.NullString `json:"response_headers"`
-	ResponseBody    sql.NullString `json:"response_body"`
-	DurationMs      sql.NullInt64  `json:"duration_ms"`
-	Error           sql.NullString `json:"error"`
-	BodySize        sql.NullInt64  `json:"body_size"`
-	IsBinary        sql.NullInt64  `json:"is_binary"`
-	WorkspaceID     int64          `json:"workspace_id"`
+	RequestID        sql.NullInt64  `json:"request_id"`
+	FlowID           sql.NullInt64  `json:"flow_id"`
+	Method           string         `json:"method"`
+	Url              string         `json:"url"`
+	RequestHeaders   sql.NullString `json:"request_headers"`
+	RequestBody      sql.NullString `json:"request_body"`
+	StatusCode       sql.NullInt64  `json:"status_code"`
+	ResponseHeaders  sql.NullString `json:"response_headers"`
+	ResponseBody     sql.NullString `json:"response_body"`
+	DurationMs       sql.NullInt64  `json:"duration_ms"`
+	Error            sql.NullString `json:"error"`
+	BodySize         sql.NullInt64  `json:"body_size"`
+	IsBinary         sql.NullInt64  `json:"is_binary"`
+	WorkspaceID      int64          `json:"workspace_id"`
+	TraceID          sql.NullString `json:"trace_id"`
+	ExecutionGroupID sql.NullString `json:"execution_group_id"`
+	Redirects        string         `json:"redirects"`
+	Network          string         `json:"network"`
+	AttemptGroupID   sql.NullString `json:"attempt_group_id"`
+	Attempt          int64          `json:"attempt"`
 }
 
 func (q *Queries) CreateHistory(ctx context.Context, arg CreateHistoryParams) (RequestHistory, error) {
@@ -50,6 +57,12 @@ func (q *Queries) CreateHistory(ctx context.Context, arg CreateHistoryParams) (R
 		arg.BodySize,
 		arg.IsBinary,
 		arg.WorkspaceID,
+		arg.TraceID,
+		arg.ExecutionGroupID,
+		arg.Redirects,
+		arg.Network,
+		arg.AttemptGroupID,
+		arg.Attempt,
 	)
 	var i RequestHistory
 	err := row.Scan(
@@ -69,6 +82,15 @@ func (q *Queries) CreateHistory(ctx context.Context, arg CreateHistoryParams) (R
 		&i.IsBinary,
 		&i.CreatedAt,
 		&i.WorkspaceID,
+		&i.TraceID,
+		&i.Note,
+		&i.Flagged,
+		&i.ExecutionGroupID,
+		&i.ParentHistoryID,
+		&i.Redirects,
+		&i.Network,
+		&i.AttemptGroupID,
+		&i.Attempt,
 	)
 	return i, err
 }
@@ -82,17 +104,60 @@ func (q *Queries) DeleteHistory(ctx context.Context, id int64) error {
 	return err
 }
 
-const deleteOldHistory = `-- name: DeleteOldHistory :exec
-DELETE FROM request_history WHERE created_at < datetime('now', '-30 days')
+const deleteHistoryBefore = `-- name: DeleteHistoryBefore :execrows
+DELETE FROM request_history WHERE created_at < ? AND flagged = 0
 `
 
-func (q *Queries) DeleteOldHistory(ctx context.Context) error {
-	_, err := q.db.ExecContext(ctx, deleteOldHistory)
-	return err
+func (q *Queries) DeleteHistoryBefore(ctx context.Context, before string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteHistoryBefore, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteOldHistory = `-- name: DeleteOldHistory :execrows
+DELETE FROM request_history WHERE created_at < datetime('now', '-30 days') AND flagged = 0
+`
+
+func (q *Queries) DeleteOldHistory(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteOldHistory)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getWeeklyHistoryStats = `-- name: GetWeeklyHistoryStats :one
+SELECT CAST(COUNT(*) AS INTEGER) AS executions,
+       CAST(COALESCE(SUM(CASE WHEN (error IS NOT NULL AND error != '') OR status_code >= 400 THEN 1 ELSE 0 END), 0) AS INTEGER) AS failures,
+       CAST(COALESCE(AVG(duration_ms), 0) AS REAL) AS avg_duration_ms,
+       CAST(COALESCE(SUM(CASE WHEN flow_id IS NOT NULL THEN 1 ELSE 0 END), 0) AS INTEGER) AS flow_executions
+FROM request_history
+WHERE workspace_id = ? AND created_at >= datetime('now', '-7 days')
+`
+
+type GetWeeklyHistoryStatsRow struct {
+	Executions     int64   `json:"executions"`
+	Failures       int64   `json:"failures"`
+	AvgDurationMs  float64 `json:"avg_duration_ms"`
+	FlowExecutions int64   `json:"flow_executions"`
+}
+
+func (q *Queries) GetWeeklyHistoryStats(ctx context.Context, workspaceID int64) (GetWeeklyHistoryStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getWeeklyHistoryStats, workspaceID)
+	var i GetWeeklyHistoryStatsRow
+	err := row.Scan(
+		&i.Executions,
+		&i.Failures,
+		&i.AvgDurationMs,
+		&i.FlowExecutions,
+	)
+	return i, err
 }
 
 const getHistory = `-- name: GetHistory :one
-SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id FROM request_history WHERE id = ? LIMIT 1
+SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt FROM request_history WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetHistory(ctx context.Context, id int64) (RequestHistory, error) {
@@ -115,12 +180,174 @@ func (q *Queries) GetHistory(ctx context.Context, id int64) (RequestHistory, err
 		&i.IsBinary,
 		&i.CreatedAt,
 		&i.WorkspaceID,
+		&i.TraceID,
+		&i.Note,
+		&i.Flagged,
+		&i.ExecutionGroupID,
+		&i.ParentHistoryID,
+		&i.Redirects,
+		&i.Network,
+		&i.AttemptGroupID,
+		&i.Attempt,
 	)
 	return i, err
 }
 
+const listExpiredHistory = `-- name: ListExpiredHistory :many
+SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt FROM request_history
+WHERE workspace_id = ? AND created_at < ? AND flagged = 0 AND id > ?
+  AND (execution_group_id IS NULL OR execution_group_id NOT IN (SELECT run_id FROM archives WHERE kind = 'run' AND run_id IS NOT NULL))
+ORDER BY id LIMIT ?
+`
+
+type ListExpiredHistoryParams struct {
+	WorkspaceID int64  `json:"workspace_id"`
+	Before      string `json:"before"`
+	AfterID     int64  `json:"after_id"`
+	Limit       int64  `json:"limit"`
+}
+
+func (q *Queries) ListExpiredHistory(ctx context.Context, arg ListExpiredHistoryParams) ([]RequestHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredHistory,
+		arg.WorkspaceID,
+		arg.Before,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RequestHistory{}
+	for rows.Next() {
+		var i RequestHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestID,
+			&i.FlowID,
+			&i.Method,
+			&i.Url,
+			&i.RequestHeaders,
+			&i.RequestBody,
+			&i.StatusCode,
+			&i.ResponseHeaders,
+			&i.ResponseBody,
+			&i.DurationMs,
+			&i.Error,
+			&i.BodySize,
+			&i.IsBinary,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+			&i.TraceID,
+			&i.Note,
+			&i.Flagged,
+			&i.ExecutionGroupID,
+			&i.ParentHistoryID,
+			&i.Redirects,
+			&i.Network,
+			&i.AttemptGroupID,
+			&i.Attempt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredHistoryWorkspaces = `-- name: ListExpiredHistoryWorkspaces :many
+SELECT DISTINCT workspace_id FROM request_history WHERE created_at < ? AND flagged = 0
+`
+
+func (q *Queries) ListExpiredHistoryWorkspaces(ctx context.Context, before string) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredHistoryWorkspaces, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var workspace_id int64
+		if err := rows.Scan(&workspace_id); err != nil {
+			return nil, err
+		}
+		items = append(items, workspace_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFlaggedHistory = `-- name: ListFlaggedHistory :many
+SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt FROM request_history WHERE workspace_id = ? AND flagged = 1 ORDER BY created_at DESC LIMIT ?
+`
+
+type ListFlaggedHistoryParams struct {
+	WorkspaceID int64 `json:"workspace_id"`
+	Limit       int64 `json:"limit"`
+}
+
+func (q *Queries) ListFlaggedHistory(ctx context.Context, arg ListFlaggedHistoryParams) ([]RequestHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listFlaggedHistory, arg.WorkspaceID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RequestHistory{}
+	for rows.Next() {
+		var i RequestHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestID,
+			&i.FlowID,
+			&i.Method,
+			&i.Url,
+			&i.RequestHeaders,
+			&i.RequestBody,
+			&i.StatusCode,
+			&i.ResponseHeaders,
+			&i.ResponseBody,
+			&i.DurationMs,
+			&i.Error,
+			&i.BodySize,
+			&i.IsBinary,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+			&i.TraceID,
+			&i.Note,
+			&i.Flagged,
+			&i.ExecutionGroupID,
+			&i.ParentHistoryID,
+			&i.Redirects,
+			&i.Network,
+			&i.AttemptGroupID,
+			&i.Attempt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listHistory = `-- name: ListHistory :many
-SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id FROM request_history WHERE workspace_id = ? ORDER BY created_at DESC LIMIT ?
+SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt FROM request_history WHERE workspace_id = ? ORDER BY created_at DESC LIMIT ?
 `
 
 type ListHistoryParams struct {
@@ -154,6 +381,131 @@ func (q *Queries) ListHistory(ctx context.Context, arg ListHistoryParams) ([]Req
 			&i.IsBinary,
 			&i.CreatedAt,
 			&i.WorkspaceID,
+			&i.TraceID,
+			&i.Note,
+			&i.Flagged,
+			&i.ExecutionGroupID,
+			&i.ParentHistoryID,
+			&i.Redirects,
+			&i.Network,
+			&i.AttemptGroupID,
+			&i.Attempt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHistoryByAttemptGroup = `-- name: ListHistoryByAttemptGroup :many
+SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt FROM request_history WHERE workspace_id = ? AND attempt_group_id = ? ORDER BY attempt, id
+`
+
+type ListHistoryByAttemptGroupParams struct {
+	WorkspaceID    int64          `json:"workspace_id"`
+	AttemptGroupID sql.NullString `json:"attempt_group_id"`
+}
+
+func (q *Queries) ListHistoryByAttemptGroup(ctx context.Context, arg ListHistoryByAttemptGroupParams) ([]RequestHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listHistoryByAttemptGroup, arg.WorkspaceID, arg.AttemptGroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RequestHistory{}
+	for rows.Next() {
+		var i RequestHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestID,
+			&i.FlowID,
+			&i.Method,
+			&i.Url,
+			&i.RequestHeaders,
+			&i.RequestBody,
+			&i.StatusCode,
+			&i.ResponseHeaders,
+			&i.ResponseBody,
+			&i.DurationMs,
+			&i.Error,
+			&i.BodySize,
+			&i.IsBinary,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+			&i.TraceID,
+			&i.Note,
+			&i.Flagged,
+			&i.ExecutionGroupID,
+			&i.ParentHistoryID,
+			&i.Redirects,
+			&i.Network,
+			&i.AttemptGroupID,
+			&i.Attempt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHistoryByExecutionGroup = `-- name: ListHistoryByExecutionGroup :many
+SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt FROM request_history WHERE workspace_id = ? AND execution_group_id = ? ORDER BY id
+`
+
+type ListHistoryByExecutionGroupParams struct {
+	WorkspaceID      int64          `json:"workspace_id"`
+	ExecutionGroupID sql.NullString `json:"execution_group_id"`
+}
+
+func (q *Queries) ListHistoryByExecutionGroup(ctx context.Context, arg ListHistoryByExecutionGroupParams) ([]RequestHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listHistoryByExecutionGroup, arg.WorkspaceID, arg.ExecutionGroupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RequestHistory{}
+	for rows.Next() {
+		var i RequestHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestID,
+			&i.FlowID,
+			&i.Method,
+			&i.Url,
+			&i.RequestHeaders,
+			&i.RequestBody,
+			&i.StatusCode,
+			&i.ResponseHeaders,
+			&i.ResponseBody,
+			&i.DurationMs,
+			&i.Error,
+			&i.BodySize,
+			&i.IsBinary,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+			&i.TraceID,
+			&i.Note,
+			&i.Flagged,
+			&i.ExecutionGroupID,
+			&i.ParentHistoryID,
+			&i.Redirects,
+			&i.Network,
+			&i.AttemptGroupID,
+			&i.Attempt,
 		); err != nil {
 			return nil, err
 		}
@@ -169,7 +521,7 @@ func (q *Queries) ListHistory(ctx context.Context, arg ListHistoryParams) ([]Req
 }
 
 const listHistoryByRequest = `-- name: ListHistoryByRequest :many
-SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id FROM request_history WHERE request_id = ? ORDER BY created_at DESC LIMIT ?
+SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt FROM request_history WHERE request_id = ? ORDER BY created_at DESC LIMIT ?
 `
 
 type ListHistoryByRequestParams struct {
@@ -203,6 +555,73 @@ func (q *Queries) ListHistoryByRequest(ctx context.Context, arg ListHistoryByReq
 			&i.IsBinary,
 			&i.CreatedAt,
 			&i.WorkspaceID,
+			&i.TraceID,
+			&i.Note,
+			&i.Flagged,
+			&i.ExecutionGroupID,
+			&i.ParentHistoryID,
+			&i.Redirects,
+			&i.Network,
+			&i.AttemptGroupID,
+			&i.Attempt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHistoryByTrace = `-- name: ListHistoryByTrace :many
+SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt FROM request_history WHERE workspace_id = ? AND trace_id = ? ORDER BY created_at, id
+`
+
+type ListHistoryByTraceParams struct {
+	WorkspaceID int64          `json:"workspace_id"`
+	TraceID     sql.NullString `json:"trace_id"`
+}
+
+func (q *Queries) ListHistoryByTrace(ctx context.Context, arg ListHistoryByTraceParams) ([]RequestHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listHistoryByTrace, arg.WorkspaceID, arg.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RequestHistory{}
+	for rows.Next() {
+		var i RequestHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestID,
+			&i.FlowID,
+			&i.Method,
+			&i.Url,
+			&i.RequestHeaders,
+			&i.RequestBody,
+			&i.StatusCode,
+			&i.ResponseHeaders,
+			&i.ResponseBody,
+			&i.DurationMs,
+			&i.Error,
+			&i.BodySize,
+			&i.IsBinary,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+			&i.TraceID,
+			&i.Note,
+			&i.Flagged,
+			&i.ExecutionGroupID,
+			&i.ParentHistoryID,
+			&i.Redirects,
+			&i.Network,
+			&i.AttemptGroupID,
+			&i.Attempt,
 		); err != nil {
 			return nil, err
 		}
@@ -216,3 +635,139 @@ func (q *Queries) ListHistoryByRequest(ctx context.Context, arg ListHistoryByReq
 	}
 	return items, nil
 }
+
+const listHistoryChildren = `-- name: ListHistoryChildren :many
+SELECT id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt FROM request_history WHERE parent_history_id = ? ORDER BY id
+`
+
+func (q *Queries) ListHistoryChildren(ctx context.Context, parentHistoryID sql.NullInt64) ([]RequestHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listHistoryChildren, parentHistoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []RequestHistory{}
+	for rows.Next() {
+		var i RequestHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestID,
+			&i.FlowID,
+			&i.Method,
+			&i.Url,
+			&i.RequestHeaders,
+			&i.RequestBody,
+			&i.StatusCode,
+			&i.ResponseHeaders,
+			&i.ResponseBody,
+			&i.DurationMs,
+			&i.Error,
+			&i.BodySize,
+			&i.IsBinary,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+			&i.TraceID,
+			&i.Note,
+			&i.Flagged,
+			&i.ExecutionGroupID,
+			&i.ParentHistoryID,
+			&i.Redirects,
+			&i.Network,
+			&i.AttemptGroupID,
+			&i.Attempt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveRequestHistory = `-- name: MoveRequestHistory :execrows
+UPDATE request_history SET request_id = ? WHERE request_id = ?
+`
+
+type MoveRequestHistoryParams struct {
+	SurvivorID int64 `json:"survivor_id"`
+	LoserID    int64 `json:"loser_id"`
+}
+
+func (q *Queries) MoveRequestHistory(ctx context.Context, arg MoveRequestHistoryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, moveRequestHistory,
+		arg.SurvivorID,
+		arg.LoserID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setHistoryParent = `-- name: SetHistoryParent :exec
+UPDATE request_history SET parent_history_id = ? WHERE id = ?
+`
+
+type SetHistoryParentParams struct {
+	ParentHistoryID sql.NullInt64 `json:"parent_history_id"`
+	ID              int64         `json:"id"`
+}
+
+func (q *Queries) SetHistoryParent(ctx context.Context, arg SetHistoryParentParams) error {
+	_, err := q.db.ExecContext(ctx, setHistoryParent, arg.ParentHistoryID, arg.ID)
+	return err
+}
+
+const updateHistoryNote = `-- name: UpdateHistoryNote :one
+UPDATE request_history SET note = ?, flagged = ? WHERE id = ? AND workspace_id = ? RETURNING id, request_id, flow_id, method, url, request_headers, request_body, status_code, response_headers, response_body, duration_ms, error, body_size, is_binary, created_at, workspace_id, trace_id, note, flagged, execution_group_id, parent_history_id, redirects, network, attempt_group_id, attempt
+`
+
+type UpdateHistoryNoteParams struct {
+	Note        sql.NullString `json:"note"`
+	Flagged     int64          `json:"flagged"`
+	ID          int64          `json:"id"`
+	WorkspaceID int64          `json:"workspace_id"`
+}
+
+func (q *Queries) UpdateHistoryNote(ctx context.Context, arg UpdateHistoryNoteParams) (RequestHistory, error) {
+	row := q.db.QueryRowContext(ctx, updateHistoryNote,
+		arg.Note,
+		arg.Flagged,
+		arg.ID,
+		arg.WorkspaceID,
+	)
+	var i RequestHistory
+	err := row.Scan(
+		&i.ID,
+		&i.RequestID,
+		&i.FlowID,
+		&i.Method,
+		&i.Url,
+		&i.RequestHeaders,
+		&i.RequestBody,
+		&i.StatusCode,
+		&i.ResponseHeaders,
+		&i.ResponseBody,
+		&i.DurationMs,
+		&i.Error,
+		&i.BodySize,
+		&i.IsBinary,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+		&i.TraceID,
+		&i.Note,
+		&i.Flagged,
+		&i.ExecutionGroupID,
+		&i.ParentHistoryID,
+		&i.Redirects,
+		&i.Network,
+		&i.AttemptGroupID,
+		&i.Attempt,
+	)
+	return i, err
+}
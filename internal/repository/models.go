@@ -6,62 +6,288 @@ package repository
 
 import (
 	"database/sql"
+	"time"
 )
 
-type Collection struct {
+type Archive struct {
 	ID          int64          `json:"id"`
-	Name        string         `json:"name"`
-	ParentID    sql.NullInt64  `json:"parent_id"`
-	CreatedAt   sql.NullTime   `json:"created_at"`
-	UpdatedAt   sql.NullTime   `json:"updated_at"`
 	WorkspaceID int64          `json:"workspace_id"`
-	Variables   sql.NullString `json:"variables"`
-	SortOrder   int64          `json:"sort_order"`
+	Kind        string         `json:"kind"`
+	RunID       sql.NullString `json:"run_id"`
+	FlowID      sql.NullInt64  `json:"flow_id"`
+	Storage     string         `json:"storage"`
+	ObjectKey   string         `json:"object_key"`
+	Entries     int64          `json:"entries"`
+	Size        int64          `json:"size"`
+	OldestAt    sql.NullTime   `json:"oldest_at"`
+	NewestAt    sql.NullTime   `json:"newest_at"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+}
+
+type Collection struct {
+	ID              int64          `json:"id"`
+	Name            string         `json:"name"`
+	ParentID        sql.NullInt64  `json:"parent_id"`
+	CreatedAt       sql.NullTime   `json:"created_at"`
+	UpdatedAt       sql.NullTime   `json:"updated_at"`
+	WorkspaceID     int64          `json:"workspace_id"`
+	Variables       sql.NullString `json:"variables"`
+	SortOrder       int64          `json:"sort_order"`
+	SecretVariables sql.NullString `json:"secret_variables"`
+	SigningHook     sql.NullString `json:"signing_hook"`
+	RunFlows        sql.NullString `json:"run_flows"`
+	Auth            sql.NullString `json:"auth"`
+}
+
+type Cookie struct {
+	ID          int64        `json:"id"`
+	WorkspaceID int64        `json:"workspace_id"`
+	Domain      string       `json:"domain"`
+	Path        string       `json:"path"`
+	Name        string       `json:"name"`
+	Value       string       `json:"value"`
+	ExpiresAt   sql.NullTime `json:"expires_at"`
+	Secure      int64        `json:"secure"`
+	HttpOnly    int64        `json:"http_only"`
+	HostOnly    int64        `json:"host_only"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
+}
+
+type DriftBaseline struct {
+	RequestID    int64         `json:"request_id"`
+	HistoryID    sql.NullInt64 `json:"history_id"`
+	ResponseBody string        `json:"response_body"`
+	CreatedAt    sql.NullTime  `json:"created_at"`
+}
+
+type DriftMonitor struct {
+	ID              int64        `json:"id"`
+	WorkspaceID     int64        `json:"workspace_id"`
+	CollectionID    int64        `json:"collection_id"`
+	IntervalSeconds int64        `json:"interval_seconds"`
+	WebhookUrl      string       `json:"webhook_url"`
+	Enabled         int64        `json:"enabled"`
+	LastRunAt       sql.NullTime `json:"last_run_at"`
+	LastReport      string       `json:"last_report"`
+	CreatedAt       sql.NullTime `json:"created_at"`
+	UpdatedAt       sql.NullTime `json:"updated_at"`
+}
+
+type EditorSession struct {
+	TokenHash   string       `json:"token_hash"`
+	WorkspaceID int64        `json:"workspace_id"`
+	State       string       `json:"state"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
 }
 
 type Environment struct {
-	ID          int64          `json:"id"`
-	Name        string         `json:"name"`
-	Variables   sql.NullString `json:"variables"`
-	IsActive    sql.NullBool   `json:"is_active"`
-	CreatedAt   sql.NullTime   `json:"created_at"`
-	UpdatedAt   sql.NullTime   `json:"updated_at"`
-	WorkspaceID int64          `json:"workspace_id"`
+	ID           int64          `json:"id"`
+	Name         string         `json:"name"`
+	Variables    sql.NullString `json:"variables"`
+	IsActive     sql.NullBool   `json:"is_active"`
+	CreatedAt    sql.NullTime   `json:"created_at"`
+	UpdatedAt    sql.NullTime   `json:"updated_at"`
+	WorkspaceID  int64          `json:"workspace_id"`
+	ParentID     sql.NullInt64  `json:"parent_id"`
+	CollectionID sql.NullInt64  `json:"collection_id"`
+	Comments     string         `json:"comments"`
+}
+
+type EnvironmentRotation struct {
+	ID              int64        `json:"id"`
+	WorkspaceID     int64        `json:"workspace_id"`
+	EnvironmentID   int64        `json:"environment_id"`
+	FlowID          int64        `json:"flow_id"`
+	IntervalSeconds int64        `json:"interval_seconds"`
+	Outputs         string       `json:"outputs"`
+	Enabled         int64        `json:"enabled"`
+	LastRunAt       sql.NullTime `json:"last_run_at"`
+	CreatedAt       sql.NullTime `json:"created_at"`
+	UpdatedAt       sql.NullTime `json:"updated_at"`
+}
+
+type EnvironmentRotationRun struct {
+	ID         int64        `json:"id"`
+	RotationID int64        `json:"rotation_id"`
+	Success    int64        `json:"success"`
+	Error      string       `json:"error"`
+	Variables  string       `json:"variables"`
+	DurationMs int64        `json:"duration_ms"`
+	RanAt      sql.NullTime `json:"ran_at"`
 }
 
 type Flow struct {
+	ID             int64          `json:"id"`
+	Name           string         `json:"name"`
+	Description    sql.NullString `json:"description"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	UpdatedAt      sql.NullTime   `json:"updated_at"`
+	WorkspaceID    int64          `json:"workspace_id"`
+	SortOrder      int64          `json:"sort_order"`
+	Outputs        sql.NullString `json:"outputs"`
+	ExecutionCount int64          `json:"execution_count"`
+	LastExecutedAt sql.NullTime   `json:"last_executed_at"`
+	ArchivedAt     sql.NullTime   `json:"archived_at"`
+}
+
+type FlowEdge struct {
+	ID        int64        `json:"id"`
+	FlowID    int64        `json:"flow_id"`
+	SourceKey string       `json:"source_key"`
+	TargetKey string       `json:"target_key"`
+	Label     string       `json:"label"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type FlowNode struct {
+	ID        int64        `json:"id"`
+	FlowID    int64        `json:"flow_id"`
+	NodeKey   string       `json:"node_key"`
+	Type      string       `json:"type"`
+	Name      string       `json:"name"`
+	Config    string       `json:"config"`
+	PositionX float64      `json:"position_x"`
+	PositionY float64      `json:"position_y"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type FlowRun struct {
+	ID          int64        `json:"id"`
+	RunID       string       `json:"run_id"`
+	WorkspaceID int64        `json:"workspace_id"`
+	FlowID      int64        `json:"flow_id"`
+	FlowName    string       `json:"flow_name"`
+	Success     int64        `json:"success"`
+	Error       string       `json:"error"`
+	TotalTimeMs int64        `json:"total_time_ms"`
+	StepCount   int64        `json:"step_count"`
+	Result      string       `json:"result"`
+	StartedAt   sql.NullTime `json:"started_at"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+}
+
+type FlowStep struct {
+	ID                int64          `json:"id"`
+	FlowID            int64          `json:"flow_id"`
+	RequestID         sql.NullInt64  `json:"request_id"`
+	StepOrder         int64          `json:"step_order"`
+	DelayMs           sql.NullInt64  `json:"delay_ms"`
+	ExtractVars       sql.NullString `json:"extract_vars"`
+	Condition         sql.NullString `json:"condition"`
+	Name              string         `json:"name"`
+	Method            string         `json:"method"`
+	Url               string         `json:"url"`
+	Headers           sql.NullString `json:"headers"`
+	Body              sql.NullString `json:"body"`
+	BodyType          sql.NullString `json:"body_type"`
+	Cookies           sql.NullString `json:"cookies"`
+	ProxyID           sql.NullInt64  `json:"proxy_id"`
+	CreatedAt         sql.NullTime   `json:"created_at"`
+	UpdatedAt         sql.NullTime   `json:"updated_at"`
+	WorkspaceID       int64          `json:"workspace_id"`
+	LoopCount         sql.NullInt64  `json:"loop_count"`
+	PreScript         sql.NullString `json:"pre_script"`
+	PostScript        sql.NullString `json:"post_script"`
+	ContinueOnError   sql.NullInt64  `json:"continue_on_error"`
+	ResponseTransform sql.NullString `json:"response_transform"`
+	WaitUntil         sql.NullString `json:"wait_until"`
+	Approval          sql.NullString `json:"approval"`
+	TimeoutMs         int64          `json:"timeout_ms"`
+	RetryCount        int64          `json:"retry_count"`
+	RetryBackoffMs    int64          `json:"retry_backoff_ms"`
+	RetryOnStatus     string         `json:"retry_on_status"`
+	DisableRedirects  int64          `json:"disable_redirects"`
+	MaxRedirects      int64          `json:"max_redirects"`
+}
+
+type Instance struct {
+	ID          string       `json:"id"`
+	Hostname    string       `json:"hostname"`
+	Pid         int64        `json:"pid"`
+	StartedAt   sql.NullTime `json:"started_at"`
+	HeartbeatAt sql.NullTime `json:"heartbeat_at"`
+}
+
+type Job struct {
 	ID          int64          `json:"id"`
-	Name        string         `json:"name"`
-	Description sql.NullString `json:"description"`
+	WorkspaceID sql.NullInt64  `json:"workspace_id"`
+	Type        string         `json:"type"`
+	Payload     string         `json:"payload"`
+	Status      string         `json:"status"`
+	Attempts    int64          `json:"attempts"`
+	MaxAttempts int64          `json:"max_attempts"`
+	RunAt       int64          `json:"run_at"`
+	LockedBy    sql.NullString `json:"locked_by"`
+	LockedUntil sql.NullInt64  `json:"locked_until"`
+	Result      sql.NullString `json:"result"`
+	LastError   sql.NullString `json:"last_error"`
 	CreatedAt   sql.NullTime   `json:"created_at"`
 	UpdatedAt   sql.NullTime   `json:"updated_at"`
-	WorkspaceID int64          `json:"workspace_id"`
-	SortOrder   int64          `json:"sort_order"`
+	FinishedAt  sql.NullTime   `json:"finished_at"`
+	DedupeKey   sql.NullString `json:"dedupe_key"`
 }
 
-type FlowStep struct {
-	ID              int64          `json:"id"`
-	FlowID          int64          `json:"flow_id"`
-	RequestID       sql.NullInt64  `json:"request_id"`
-	StepOrder       int64          `json:"step_order"`
-	DelayMs         sql.NullInt64  `json:"delay_ms"`
-	ExtractVars     sql.NullString `json:"extract_vars"`
-	Condition       sql.NullString `json:"condition"`
-	Name            string         `json:"name"`
-	Method          string         `json:"method"`
-	Url             string         `json:"url"`
-	Headers         sql.NullString `json:"headers"`
-	Body            sql.NullString `json:"body"`
-	BodyType        sql.NullString `json:"body_type"`
-	Cookies         sql.NullString `json:"cookies"`
-	ProxyID         sql.NullInt64  `json:"proxy_id"`
-	CreatedAt       sql.NullTime   `json:"created_at"`
-	UpdatedAt       sql.NullTime   `json:"updated_at"`
-	WorkspaceID     int64          `json:"workspace_id"`
-	LoopCount       sql.NullInt64  `json:"loop_count"`
-	PreScript       sql.NullString `json:"pre_script"`
-	PostScript      sql.NullString `json:"post_script"`
-	ContinueOnError sql.NullInt64  `json:"continue_on_error"`
+type JobLease struct {
+	Name       string       `json:"name"`
+	Holder     string       `json:"holder"`
+	ExpiresAt  int64        `json:"expires_at"`
+	AcquiredAt sql.NullTime `json:"acquired_at"`
+}
+
+type Monitor struct {
+	ID              int64        `json:"id"`
+	WorkspaceID     int64        `json:"workspace_id"`
+	RequestID       int64        `json:"request_id"`
+	IntervalSeconds int64        `json:"interval_seconds"`
+	Enabled         int64        `json:"enabled"`
+	LastCheckedAt   sql.NullTime `json:"last_checked_at"`
+	CreatedAt       sql.NullTime `json:"created_at"`
+	UpdatedAt       sql.NullTime `json:"updated_at"`
+	MaxQueueSeconds int64        `json:"max_queue_seconds"`
+	OfflineSince    sql.NullTime `json:"offline_since"`
+}
+
+type MonitorCheck struct {
+	ID             int64        `json:"id"`
+	MonitorID      int64        `json:"monitor_id"`
+	StatusCode     int64        `json:"status_code"`
+	DurationMs     int64        `json:"duration_ms"`
+	Success        int64        `json:"success"`
+	Error          string       `json:"error"`
+	CheckedAt      sql.NullTime `json:"checked_at"`
+	OfflineSeconds int64        `json:"offline_seconds"`
+}
+
+type Oauth2Config struct {
+	ID            int64         `json:"id"`
+	WorkspaceID   int64         `json:"workspace_id"`
+	Name          string        `json:"name"`
+	GrantType     string        `json:"grant_type"`
+	TokenUrl      string        `json:"token_url"`
+	ClientID      string        `json:"client_id"`
+	ClientSecret  string        `json:"client_secret"`
+	ClientAuth    string        `json:"client_auth"`
+	Username      string        `json:"username"`
+	Password      string        `json:"password"`
+	Scope         string        `json:"scope"`
+	EnvironmentID sql.NullInt64 `json:"environment_id"`
+	TokenVariable string        `json:"token_variable"`
+	AutoRefresh   int64         `json:"auto_refresh"`
+	RefreshToken  string        `json:"refresh_token"`
+	ExpiresAt     sql.NullTime  `json:"expires_at"`
+	CreatedAt     sql.NullTime  `json:"created_at"`
+	UpdatedAt     sql.NullTime  `json:"updated_at"`
+}
+
+type OpenapiSpec struct {
+	ID          int64        `json:"id"`
+	WorkspaceID int64        `json:"workspace_id"`
+	Name        string       `json:"name"`
+	Spec        string       `json:"spec"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
 }
 
 type Proxy struct {
@@ -75,41 +301,127 @@ type Proxy struct {
 }
 
 type Request struct {
-	ID           int64          `json:"id"`
-	CollectionID sql.NullInt64  `json:"collection_id"`
-	Name         string         `json:"name"`
-	Method       string         `json:"method"`
-	Url          string         `json:"url"`
-	Headers      sql.NullString `json:"headers"`
-	Body         sql.NullString `json:"body"`
-	BodyType     sql.NullString `json:"body_type"`
-	Cookies      sql.NullString `json:"cookies"`
-	ProxyID      sql.NullInt64  `json:"proxy_id"`
-	CreatedAt    sql.NullTime   `json:"created_at"`
-	UpdatedAt    sql.NullTime   `json:"updated_at"`
-	WorkspaceID  int64          `json:"workspace_id"`
-	PreScript    sql.NullString `json:"pre_script"`
-	PostScript   sql.NullString `json:"post_script"`
-	SortOrder    int64          `json:"sort_order"`
+	ID                int64          `json:"id"`
+	CollectionID      sql.NullInt64  `json:"collection_id"`
+	Name              string         `json:"name"`
+	Method            string         `json:"method"`
+	Url               string         `json:"url"`
+	Headers           sql.NullString `json:"headers"`
+	Body              sql.NullString `json:"body"`
+	BodyType          sql.NullString `json:"body_type"`
+	Cookies           sql.NullString `json:"cookies"`
+	ProxyID           sql.NullInt64  `json:"proxy_id"`
+	CreatedAt         sql.NullTime   `json:"created_at"`
+	UpdatedAt         sql.NullTime   `json:"updated_at"`
+	WorkspaceID       int64          `json:"workspace_id"`
+	PreScript         sql.NullString `json:"pre_script"`
+	PostScript        sql.NullString `json:"post_script"`
+	SortOrder         int64          `json:"sort_order"`
+	ResponseTransform sql.NullString `json:"response_transform"`
+	Version           int64          `json:"version"`
+	Auth              sql.NullString `json:"auth"`
+	ExecutionCount    int64          `json:"execution_count"`
+	LastExecutedAt    sql.NullTime   `json:"last_executed_at"`
+	ArchivedAt        sql.NullTime   `json:"archived_at"`
+	TimeoutMs         int64          `json:"timeout_ms"`
+	RetryCount        int64          `json:"retry_count"`
+	RetryBackoffMs    int64          `json:"retry_backoff_ms"`
+	RetryOnStatus     string         `json:"retry_on_status"`
+	DisableRedirects  int64          `json:"disable_redirects"`
+	MaxRedirects      int64          `json:"max_redirects"`
+}
+
+type RequestDraft struct {
+	RequestID   int64        `json:"request_id"`
+	Data        string       `json:"data"`
+	BaseVersion int64        `json:"base_version"`
+	Revision    int64        `json:"revision"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
 }
 
 type RequestHistory struct {
-	ID              int64          `json:"id"`
-	RequestID       sql.NullInt64  `json:"request_id"`
-	FlowID          sql.NullInt64  `json:"flow_id"`
-	Method          string         `json:"method"`
-	Url             string         `json:"url"`
-	RequestHeaders  sql.NullString `json:"request_headers"`
-	RequestBody     sql.NullString `json:"request_body"`
-	StatusCode      sql.NullInt64  `json:"status_code"`
-	ResponseHeaders sql.NullString `json:"response_headers"`
-	ResponseBody    sql.NullString `json:"response_body"`
-	DurationMs      sql.NullInt64  `json:"duration_ms"`
-	Error           sql.NullString `json:"error"`
-	BodySize        sql.NullInt64  `json:"body_size"`
-	IsBinary        sql.NullInt64  `json:"is_binary"`
-	CreatedAt       sql.NullTime   `json:"created_at"`
-	WorkspaceID     int64          `json:"workspace_id"`
+	ID               int64          `json:"id"`
+	RequestID        sql.NullInt64  `json:"request_id"`
+	FlowID           sql.NullInt64  `json:"flow_id"`
+	Method           string         `json:"method"`
+	Url              string         `json:"url"`
+	RequestHeaders   sql.NullString `json:"request_headers"`
+	RequestBody      sql.NullString `json:"request_body"`
+	StatusCode       sql.NullInt64  `json:"status_code"`
+	ResponseHeaders  sql.NullString `json:"response_headers"`
+	ResponseBody     sql.NullString `json:"response_body"`
+	DurationMs       sql.NullInt64  `json:"duration_ms"`
+	Error            sql.NullString `json:"error"`
+	BodySize         sql.NullInt64  `json:"body_size"`
+	IsBinary         sql.NullInt64  `json:"is_binary"`
+	CreatedAt        sql.NullTime   `json:"created_at"`
+	WorkspaceID      int64          `json:"workspace_id"`
+	TraceID          sql.NullString `json:"trace_id"`
+	Note             sql.NullString `json:"note"`
+	Flagged          int64          `json:"flagged"`
+	ExecutionGroupID sql.NullString `json:"execution_group_id"`
+	ParentHistoryID  sql.NullInt64  `json:"parent_history_id"`
+	Redirects        string         `json:"redirects"`
+	Network          string         `json:"network"`
+	AttemptGroupID   sql.NullString `json:"attempt_group_id"`
+	Attempt          int64          `json:"attempt"`
+}
+
+type ResponseAnnotation struct {
+	ID          int64        `json:"id"`
+	RequestID   int64        `json:"request_id"`
+	Path        string       `json:"path"`
+	Description string       `json:"description"`
+	Deprecated  int64        `json:"deprecated"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
+}
+
+type RunTimeline struct {
+	ID          int64        `json:"id"`
+	RunID       string       `json:"run_id"`
+	WorkspaceID int64        `json:"workspace_id"`
+	FlowID      int64        `json:"flow_id"`
+	Timeline    string       `json:"timeline"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+}
+
+type Sequence struct {
+	ID          int64        `json:"id"`
+	WorkspaceID int64        `json:"workspace_id"`
+	Name        string       `json:"name"`
+	Value       int64        `json:"value"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
+}
+
+type Snippet struct {
+	ID          int64        `json:"id"`
+	WorkspaceID int64        `json:"workspace_id"`
+	Name        string       `json:"name"`
+	Content     string       `json:"content"`
+	Description string       `json:"description"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
+}
+
+type TokenRefresher struct {
+	ID              int64         `json:"id"`
+	WorkspaceID     int64         `json:"workspace_id"`
+	Name            string        `json:"name"`
+	LoginRequestID  int64         `json:"login_request_id"`
+	EnvironmentID   sql.NullInt64 `json:"environment_id"`
+	ExtractVars     string        `json:"extract_vars"`
+	ExpiresInPath   string        `json:"expires_in_path"`
+	IntervalSeconds int64         `json:"interval_seconds"`
+	Enabled         int64         `json:"enabled"`
+	LastAttemptAt   sql.NullTime  `json:"last_attempt_at"`
+	LastRefreshedAt sql.NullTime  `json:"last_refreshed_at"`
+	ExpiresAt       sql.NullTime  `json:"expires_at"`
+	LastError       string        `json:"last_error"`
+	CreatedAt       sql.NullTime  `json:"created_at"`
+	UpdatedAt       sql.NullTime  `json:"updated_at"`
 }
 
 type UploadedFile struct {
@@ -122,10 +434,65 @@ type UploadedFile struct {
 	CreatedAt    sql.NullTime `json:"created_at"`
 }
 
+type UserPreference struct {
+	TokenHash   string       `json:"token_hash"`
+	Preferences string       `json:"preferences"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
+}
+
+type ValuePool struct {
+	ID          int64        `json:"id"`
+	WorkspaceID int64        `json:"workspace_id"`
+	Name        string       `json:"name"`
+	Mode        string       `json:"mode"`
+	Items       string       `json:"items"`
+	Cursor      int64        `json:"cursor"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
+}
+
+type WasmExtension struct {
+	ID          int64        `json:"id"`
+	WorkspaceID int64        `json:"workspace_id"`
+	Name        string       `json:"name"`
+	Module      []byte       `json:"module"`
+	Sha256      string       `json:"sha256"`
+	Size        int64        `json:"size"`
+	Exports     string       `json:"exports"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
+}
+
 type Workspace struct {
-	ID        int64          `json:"id"`
-	Name      string         `json:"name"`
-	CreatedAt sql.NullTime   `json:"created_at"`
-	UpdatedAt sql.NullTime   `json:"updated_at"`
-	Variables sql.NullString `json:"variables"`
+	ID              int64          `json:"id"`
+	Name            string         `json:"name"`
+	CreatedAt       sql.NullTime   `json:"created_at"`
+	UpdatedAt       sql.NullTime   `json:"updated_at"`
+	Variables       sql.NullString `json:"variables"`
+	Settings        sql.NullString `json:"settings"`
+	SecretVariables sql.NullString `json:"secret_variables"`
+}
+
+type WorkspaceEncryptionKey struct {
+	WorkspaceID int64        `json:"workspace_id"`
+	Source      string       `json:"source"`
+	KmsKeyEnv   string       `json:"kms_key_env"`
+	Salt        string       `json:"salt"`
+	Iterations  int64        `json:"iterations"`
+	KeyCheck    string       `json:"key_check"`
+	Version     int64        `json:"version"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	RotatedAt   sql.NullTime `json:"rotated_at"`
+}
+
+type WsMessage struct {
+	ID        int64     `json:"id"`
+	HistoryID int64     `json:"history_id"`
+	Seq       int64     `json:"seq"`
+	Direction string    `json:"direction"`
+	Format    string    `json:"format"`
+	Payload   string    `json:"payload"`
+	Size      int64     `json:"size"`
+	SentAt    time.Time `json:"sent_at"`
 }
@@ -11,24 +11,32 @@ import (
 )
 
 const createRequest = `-- name: CreateRequest :one
-INSERT INTO requests (collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, workspace_id, pre_script, post_script, sort_order)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order
+INSERT INTO requests (collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, workspace_id, pre_script, post_script, sort_order, response_transform, auth, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order, response_transform, version, auth, execution_count, last_executed_at, archived_at, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects
 `
 
 type CreateRequestParams struct {
-	CollectionID sql.NullInt64  `json:"collection_id"`
-	Name         string         `json:"name"`
-	Method       string         `json:"method"`
-	Url          string         `json:"url"`
-	Headers      sql.NullString `json:"headers"`
-	Body         sql.NullString `json:"body"`
-	BodyType     sql.NullString `json:"body_type"`
-	Cookies      sql.NullString `json:"cookies"`
-	ProxyID      sql.NullInt64  `json:"proxy_id"`
-	WorkspaceID  int64          `json:"workspace_id"`
-	PreScript    sql.NullString `json:"pre_script"`
-	PostScript   sql.NullString `json:"post_script"`
-	SortOrder    int64          `json:"sort_order"`
+	CollectionID      sql.NullInt64  `json:"collection_id"`
+	Name              string         `json:"name"`
+	Method            string         `json:"method"`
+	Url               string         `json:"url"`
+	Headers           sql.NullString `json:"headers"`
+	Body              sql.NullString `json:"body"`
+	BodyType          sql.NullString `json:"body_type"`
+	Cookies           sql.NullString `json:"cookies"`
+	ProxyID           sql.NullInt64  `json:"proxy_id"`
+	WorkspaceID       int64          `json:"workspace_id"`
+	PreScript         sql.NullString `json:"pre_script"`
+	PostScript        sql.NullString `json:"post_script"`
+	SortOrder         int64          `json:"sort_order"`
+	ResponseTransform sql.NullString `json:"response_transform"`
+	Auth              sql.NullString `json:"auth"`
+	TimeoutMs         int64          `json:"timeout_ms"`
+	RetryCount        int64          `json:"retry_count"`
+	RetryBackoffMs    int64          `json:"retry_backoff_ms"`
+	RetryOnStatus     string         `json:"retry_on_status"`
+	DisableRedirects  int64          `json:"disable_redirects"`
+	MaxRedirects      int64          `json:"max_redirects"`
 }
 
 func (q *Queries) CreateRequest(ctx context.Context, arg CreateRequestParams) (Request, error) {
@@ -46,6 +54,14 @@ func (q *Queries) CreateRequest(ctx context.Context, arg CreateRequestParams) (R
 		arg.PreScript,
 		arg.PostScript,
 		arg.SortOrder,
+		arg.ResponseTransform,
+		arg.Auth,
+		arg.TimeoutMs,
+		arg.RetryCount,
+		arg.RetryBackoffMs,
+		arg.RetryOnStatus,
+		arg.DisableRedirects,
+		arg.MaxRedirects,
 	)
 	var i Request
 	err := row.Scan(
@@ -65,6 +81,18 @@ func (q *Queries) CreateRequest(ctx context.Context, arg CreateRequestParams) (R
 		&i.PreScript,
 		&i.PostScript,
 		&i.SortOrder,
+		&i.ResponseTransform,
+		&i.Version,
+		&i.Auth,
+		&i.ExecutionCount,
+		&i.LastExecutedAt,
+		&i.ArchivedAt,
+		&i.TimeoutMs,
+		&i.RetryCount,
+		&i.RetryBackoffMs,
+		&i.RetryOnStatus,
+		&i.DisableRedirects,
+		&i.MaxRedirects,
 	)
 	return i, err
 }
@@ -78,6 +106,76 @@ func (q *Queries) DeleteRequest(ctx context.Context, id int64) error {
 	return err
 }
 
+const findDuplicateRequests = `-- name: FindDuplicateRequests :many
+SELECT id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order, response_transform, version, auth, execution_count, last_executed_at, archived_at, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects FROM requests
+WHERE workspace_id = ? AND UPPER(method) = UPPER(?) AND RTRIM(url, '/') = RTRIM(?, '/') AND id != ?
+ORDER BY id
+`
+
+type FindDuplicateRequestsParams struct {
+	WorkspaceID int64  `json:"workspace_id"`
+	Method      string `json:"method"`
+	Url         string `json:"url"`
+	ExcludeID   int64  `json:"exclude_id"`
+}
+
+func (q *Queries) FindDuplicateRequests(ctx context.Context, arg FindDuplicateRequestsParams) ([]Request, error) {
+	rows, err := q.db.QueryContext(ctx, findDuplicateRequests,
+		arg.WorkspaceID,
+		arg.Method,
+		arg.Url,
+		arg.ExcludeID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Request{}
+	for rows.Next() {
+		var i Request
+		if err := rows.Scan(
+			&i.ID,
+			&i.CollectionID,
+			&i.Name,
+			&i.Method,
+			&i.Url,
+			&i.Headers,
+			&i.Body,
+			&i.BodyType,
+			&i.Cookies,
+			&i.ProxyID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WorkspaceID,
+			&i.PreScript,
+			&i.PostScript,
+			&i.SortOrder,
+			&i.ResponseTransform,
+			&i.Version,
+			&i.Auth,
+			&i.ExecutionCount,
+			&i.LastExecutedAt,
+			&i.ArchivedAt,
+			&i.TimeoutMs,
+			&i.RetryCount,
+			&i.RetryBackoffMs,
+			&i.RetryOnStatus,
+			&i.DisableRedirects,
+			&i.MaxRedirects,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getMaxRequestSortOrder = `-- name: GetMaxRequestSortOrder :one
 SELECT COALESCE(MAX(sort_order), 0) AS max_sort_order FROM requests WHERE collection_id = ?
 `
@@ -90,7 +188,7 @@ func (q *Queries) GetMaxRequestSortOrder(ctx context.Context, collectionID sql.N
 }
 
 const getRequest = `-- name: GetRequest :one
-SELECT id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order FROM requests WHERE id = ? LIMIT 1
+SELECT id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order, response_transform, version, auth, execution_count, last_executed_at, archived_at, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects FROM requests WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetRequest(ctx context.Context, id int64) (Request, error) {
@@ -113,12 +211,24 @@ func (q *Queries) GetRequest(ctx context.Context, id int64) (Request, error) {
 		&i.PreScript,
 		&i.PostScript,
 		&i.SortOrder,
+		&i.ResponseTransform,
+		&i.Version,
+		&i.Auth,
+		&i.ExecutionCount,
+		&i.LastExecutedAt,
+		&i.ArchivedAt,
+		&i.TimeoutMs,
+		&i.RetryCount,
+		&i.RetryBackoffMs,
+		&i.RetryOnStatus,
+		&i.DisableRedirects,
+		&i.MaxRedirects,
 	)
 	return i, err
 }
 
 const listRequests = `-- name: ListRequests :many
-SELECT id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order FROM requests WHERE workspace_id = ? ORDER BY sort_order ASC, name ASC
+SELECT id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order, response_transform, version, auth, execution_count, last_executed_at, archived_at, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects FROM requests WHERE workspace_id = ? ORDER BY sort_order ASC, name ASC
 `
 
 func (q *Queries) ListRequests(ctx context.Context, workspaceID int64) ([]Request, error) {
@@ -147,6 +257,18 @@ func (q *Queries) ListRequests(ctx context.Context, workspaceID int64) ([]Reques
 			&i.PreScript,
 			&i.PostScript,
 			&i.SortOrder,
+			&i.ResponseTransform,
+			&i.Version,
+			&i.Auth,
+			&i.ExecutionCount,
+			&i.LastExecutedAt,
+			&i.ArchivedAt,
+			&i.TimeoutMs,
+			&i.RetryCount,
+			&i.RetryBackoffMs,
+			&i.RetryOnStatus,
+			&i.DisableRedirects,
+			&i.MaxRedirects,
 		); err != nil {
 			return nil, err
 		}
@@ -162,7 +284,7 @@ func (q *Queries) ListRequests(ctx context.Context, workspaceID int64) ([]Reques
 }
 
 const listRequestsByCollection = `-- name: ListRequestsByCollection :many
-SELECT id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order FROM requests WHERE collection_id = ? ORDER BY sort_order ASC, name ASC
+SELECT id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order, response_transform, version, auth, execution_count, last_executed_at, archived_at, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects FROM requests WHERE collection_id = ? ORDER BY sort_order ASC, name ASC
 `
 
 func (q *Queries) ListRequestsByCollection(ctx context.Context, collectionID sql.NullInt64) ([]Request, error) {
@@ -191,6 +313,18 @@ func (q *Queries) ListRequestsByCollection(ctx context.Context, collectionID sql
 			&i.PreScript,
 			&i.PostScript,
 			&i.SortOrder,
+			&i.ResponseTransform,
+			&i.Version,
+			&i.Auth,
+			&i.ExecutionCount,
+			&i.LastExecutedAt,
+			&i.ArchivedAt,
+			&i.TimeoutMs,
+			&i.RetryCount,
+			&i.RetryBackoffMs,
+			&i.RetryOnStatus,
+			&i.DisableRedirects,
+			&i.MaxRedirects,
 		); err != nil {
 			return nil, err
 		}
@@ -205,6 +339,37 @@ func (q *Queries) ListRequestsByCollection(ctx context.Context, collectionID sql
 	return items, nil
 }
 
+const recordRequestExecution = `-- name: RecordRequestExecution :exec
+UPDATE requests SET execution_count = execution_count + 1, last_executed_at = CURRENT_TIMESTAMP WHERE id = ?
+`
+
+func (q *Queries) RecordRequestExecution(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, recordRequestExecution, id)
+	return err
+}
+
+const setRequestArchived = `-- name: SetRequestArchived :execrows
+UPDATE requests SET archived_at = ? WHERE id = ? AND workspace_id = ?
+`
+
+type SetRequestArchivedParams struct {
+	ArchivedAt  sql.NullTime `json:"archived_at"`
+	ID          int64        `json:"id"`
+	WorkspaceID int64        `json:"workspace_id"`
+}
+
+func (q *Queries) SetRequestArchived(ctx context.Context, arg SetRequestArchivedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setRequestArchived,
+		arg.ArchivedAt,
+		arg.ID,
+		arg.WorkspaceID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateRequest = `-- name: UpdateRequest :one
 UPDATE requests SET
     collection_id = ?,
@@ -218,23 +383,40 @@ UPDATE requests SET
     proxy_id = ?,
     pre_script = ?,
     post_script = ?,
+    response_transform = ?,
+    auth = ?,
+    timeout_ms = ?,
+    retry_count = ?,
+    retry_backoff_ms = ?,
+    retry_on_status = ?,
+    disable_redirects = ?,
+    max_redirects = ?,
+    version = version + 1,
     updated_at = CURRENT_TIMESTAMP
-WHERE id = ? RETURNING id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order
+WHERE id = ? RETURNING id, collection_id, name, method, url, headers, body, body_type, cookies, proxy_id, created_at, updated_at, workspace_id, pre_script, post_script, sort_order, response_transform, version, auth, execution_count, last_executed_at, archived_at, timeout_ms, retry_count, retry_backoff_ms, retry_on_status, disable_redirects, max_redirects
 `
 
 type UpdateRequestParams struct {
-	CollectionID sql.NullInt64  `json:"collection_id"`
-	Name         string         `json:"name"`
-	Method       string         `json:"method"`
-	Url          string         `json:"url"`
-	Headers      sql.NullString `json:"headers"`
-	Body         sql.NullString `json:"body"`
-	BodyType     sql.NullString `json:"body_type"`
-	Cookies      sql.NullString `json:"cookies"`
-	ProxyID      sql.NullInt64  `json:"proxy_id"`
-	PreScript    sql.NullString `json:"pre_script"`
-	PostScript   sql.NullString `json:"post_script"`
-	ID           int64          `json:"id"`
+	CollectionID      sql.NullInt64  `json:"collection_id"`
+	Name              string         `json:"name"`
+	Method            string         `json:"method"`
+	Url               string         `json:"url"`
+	Headers           sql.NullString `json:"headers"`
+	Body              sql.NullString `json:"body"`
+	BodyType          sql.NullString `json:"body_type"`
+	Cookies           sql.NullString `json:"cookies"`
+	ProxyID           sql.NullInt64  `json:"proxy_id"`
+	PreScript         sql.NullString `json:"pre_script"`
+	PostScript        sql.NullString `json:"post_script"`
+	ResponseTransform sql.NullString `json:"response_transform"`
+	Auth              sql.NullString `json:"auth"`
+	TimeoutMs         int64          `json:"timeout_ms"`
+	RetryCount        int64          `json:"retry_count"`
+	RetryBackoffMs    int64          `json:"retry_backoff_ms"`
+	RetryOnStatus     string         `json:"retry_on_status"`
+	DisableRedirects  int64          `json:"disable_redirects"`
+	MaxRedirects      int64          `json:"max_redirects"`
+	ID                int64          `json:"id"`
 }
 
 func (q *Queries) UpdateRequest(ctx context.Context, arg UpdateRequestParams) (Request, error) {
@@ -250,6 +432,14 @@ func (q *Queries) UpdateRequest(ctx context.Context, arg UpdateRequestParams) (R
 		arg.ProxyID,
 		arg.PreScript,
 		arg.PostScript,
+		arg.ResponseTransform,
+		arg.Auth,
+		arg.TimeoutMs,
+		arg.RetryCount,
+		arg.RetryBackoffMs,
+		arg.RetryOnStatus,
+		arg.DisableRedirects,
+		arg.MaxRedirects,
 		arg.ID,
 	)
 	var i Request
@@ -270,6 +460,18 @@ func (q *Queries) UpdateRequest(ctx context.Context, arg UpdateRequestParams) (R
 		&i.PreScript,
 		&i.PostScript,
 		&i.SortOrder,
+		&i.ResponseTransform,
+		&i.Version,
+		&i.Auth,
+		&i.ExecutionCount,
+		&i.LastExecutedAt,
+		&i.ArchivedAt,
+		&i.TimeoutMs,
+		&i.RetryCount,
+		&i.RetryBackoffMs,
+		&i.RetryOnStatus,
+		&i.DisableRedirects,
+		&i.MaxRedirects,
 	)
 	return i, err
 }
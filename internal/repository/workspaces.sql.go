@@ -11,7 +11,7 @@ import (
 )
 
 const createWorkspace = `-- name: CreateWorkspace :one
-INSERT INTO workspaces (name) VALUES (?) RETURNING id, name, created_at, updated_at, variables
+INSERT INTO workspaces (name) VALUES (?) RETURNING id, name, created_at, updated_at, variables, settings, secret_variables
 `
 
 func (q *Queries) CreateWorkspace(ctx context.Context, name string) (Workspace, error) {
@@ -23,6 +23,8 @@ func (q *Queries) CreateWorkspace(ctx context.Context, name string) (Workspace,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Variables,
+		&i.Settings,
+		&i.SecretVariables,
 	)
 	return i, err
 }
@@ -37,7 +39,7 @@ func (q *Queries) DeleteWorkspace(ctx context.Context, id int64) error {
 }
 
 const getWorkspace = `-- name: GetWorkspace :one
-SELECT id, name, created_at, updated_at, variables FROM workspaces WHERE id = ? LIMIT 1
+SELECT id, name, created_at, updated_at, variables, settings, secret_variables FROM workspaces WHERE id = ? LIMIT 1
 `
 
 func (q *Queries) GetWorkspace(ctx context.Context, id int64) (Workspace, error) {
@@ -49,6 +51,70 @@ func (q *Queries) GetWorkspace(ctx context.Context, id int64) (Workspace, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Variables,
+		&i.Settings,
+		&i.SecretVariables,
+	)
+	return i, err
+}
+
+const getWorkspaceSettings = `-- name: GetWorkspaceSettings :one
+SELECT settings FROM workspaces WHERE id = ?
+`
+
+func (q *Queries) GetWorkspaceSettings(ctx context.Context, id int64) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceSettings, id)
+	var settings sql.NullString
+	err := row.Scan(&settings)
+	return settings, err
+}
+
+const getWorkspaceUsage = `-- name: GetWorkspaceUsage :one
+SELECT
+    (SELECT COUNT(*) FROM requests WHERE requests.workspace_id = ?) AS requests,
+    (SELECT COUNT(*) FROM request_history WHERE request_history.workspace_id = ?) AS history_rows,
+    (SELECT COALESCE(SUM(COALESCE(LENGTH(request_body), 0) + COALESCE(LENGTH(response_body), 0)), 0) FROM request_history WHERE request_history.workspace_id = ?)
+        + (SELECT COALESCE(SUM(size), 0) FROM uploaded_files WHERE uploaded_files.workspace_id = ?) AS storage_bytes,
+    (SELECT COUNT(*) FROM monitor_checks c JOIN monitors m ON m.id = c.monitor_id
+        WHERE m.workspace_id = ? AND c.offline_seconds = 0 AND c.checked_at >= date('now'))
+        + (SELECT COUNT(*) FROM environment_rotation_runs rr JOIN environment_rotations er ON er.id = rr.rotation_id
+        WHERE er.workspace_id = ? AND rr.ran_at >= date('now'))
+        + (SELECT COUNT(*) FROM token_refresh_runs tr JOIN token_refreshers t ON t.id = tr.refresher_id
+        WHERE t.workspace_id = ? AND tr.ran_at >= date('now')) AS scheduled_runs_today
+`
+
+type GetWorkspaceUsageParams struct {
+	WorkspaceID   int64 `json:"workspace_id"`
+	WorkspaceID_2 int64 `json:"workspace_id_2"`
+	WorkspaceID_3 int64 `json:"workspace_id_3"`
+	WorkspaceID_4 int64 `json:"workspace_id_4"`
+	WorkspaceID_5 int64 `json:"workspace_id_5"`
+	WorkspaceID_6 int64 `json:"workspace_id_6"`
+	WorkspaceID_7 int64 `json:"workspace_id_7"`
+}
+
+type GetWorkspaceUsageRow struct {
+	Requests           int64 `json:"requests"`
+	HistoryRows        int64 `json:"history_rows"`
+	StorageBytes       int64 `json:"storage_bytes"`
+	ScheduledRunsToday int64 `json:"scheduled_runs_today"`
+}
+
+func (q *Queries) GetWorkspaceUsage(ctx context.Context, arg GetWorkspaceUsageParams) (GetWorkspaceUsageRow, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceUsage,
+		arg.WorkspaceID,
+		arg.WorkspaceID_2,
+		arg.WorkspaceID_3,
+		arg.WorkspaceID_4,
+		arg.WorkspaceID_5,
+		arg.WorkspaceID_6,
+		arg.WorkspaceID_7,
+	)
+	var i GetWorkspaceUsageRow
+	err := row.Scan(
+		&i.Requests,
+		&i.HistoryRows,
+		&i.StorageBytes,
+		&i.ScheduledRunsToday,
 	)
 	return i, err
 }
@@ -64,8 +130,71 @@ func (q *Queries) GetWorkspaceVariables(ctx context.Context, id int64) (sql.Null
 	return variables, err
 }
 
+const listWorkspaceChanges = `-- name: ListWorkspaceChanges :many
+SELECT CAST('collection' AS TEXT) AS kind, id, name, created_at, updated_at FROM collections WHERE workspace_id = ?
+UNION ALL
+SELECT CAST('request' AS TEXT) AS kind, id, name, created_at, updated_at FROM requests WHERE workspace_id = ?
+UNION ALL
+SELECT CAST('flow' AS TEXT) AS kind, id, name, created_at, updated_at FROM flows WHERE workspace_id = ?
+UNION ALL
+SELECT CAST('environment' AS TEXT) AS kind, id, name, created_at, updated_at FROM environments WHERE workspace_id = ?
+ORDER BY updated_at DESC, id DESC
+LIMIT ?
+`
+
+type ListWorkspaceChangesParams struct {
+	WorkspaceID   int64 `json:"workspace_id"`
+	WorkspaceID_2 int64 `json:"workspace_id_2"`
+	WorkspaceID_3 int64 `json:"workspace_id_3"`
+	WorkspaceID_4 int64 `json:"workspace_id_4"`
+	Limit         int64 `json:"limit"`
+}
+
+type ListWorkspaceChangesRow struct {
+	Kind      string       `json:"kind"`
+	ID        int64        `json:"id"`
+	Name      string       `json:"name"`
+	CreatedAt sql.NullTime `json:"created_at"`
+	UpdatedAt sql.NullTime `json:"updated_at"`
+}
+
+func (q *Queries) ListWorkspaceChanges(ctx context.Context, arg ListWorkspaceChangesParams) ([]ListWorkspaceChangesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listWorkspaceChanges,
+		arg.WorkspaceID,
+		arg.WorkspaceID_2,
+		arg.WorkspaceID_3,
+		arg.WorkspaceID_4,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListWorkspaceChangesRow{}
+	for rows.Next() {
+		var i ListWorkspaceChangesRow
+		if err := rows.Scan(
+			&i.Kind,
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listWorkspaces = `-- name: ListWorkspaces :many
-SELECT id, name, created_at, updated_at, variables FROM workspaces ORDER BY name
+SELECT id, name, created_at, updated_at, variables, settings, secret_variables FROM workspaces ORDER BY name
 `
 
 func (q *Queries) ListWorkspaces(ctx context.Context) ([]Workspace, error) {
@@ -83,6 +212,8 @@ func (q *Queries) ListWorkspaces(ctx context.Context) ([]Workspace, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Variables,
+			&i.Settings,
+			&i.SecretVariables,
 		); err != nil {
 			return nil, err
 		}
@@ -98,7 +229,7 @@ func (q *Queries) ListWorkspaces(ctx context.Context) ([]Workspace, error) {
 }
 
 const updateWorkspace = `-- name: UpdateWorkspace :one
-UPDATE workspaces SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, created_at, updated_at, variables
+UPDATE workspaces SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, created_at, updated_at, variables, settings, secret_variables
 `
 
 type UpdateWorkspaceParams struct {
@@ -115,12 +246,63 @@ func (q *Queries) UpdateWorkspace(ctx context.Context, arg UpdateWorkspaceParams
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Variables,
+		&i.Settings,
+		&i.SecretVariables,
+	)
+	return i, err
+}
+
+const updateWorkspaceSettings = `-- name: UpdateWorkspaceSettings :one
+UPDATE workspaces SET settings = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, created_at, updated_at, variables, settings, secret_variables
+`
+
+type UpdateWorkspaceSettingsParams struct {
+	Settings sql.NullString `json:"settings"`
+	ID       int64          `json:"id"`
+}
+
+func (q *Queries) UpdateWorkspaceSettings(ctx context.Context, arg UpdateWorkspaceSettingsParams) (Workspace, error) {
+	row := q.db.QueryRowContext(ctx, updateWorkspaceSettings, arg.Settings, arg.ID)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Variables,
+		&i.Settings,
+		&i.SecretVariables,
+	)
+	return i, err
+}
+
+const updateWorkspaceVariableSet = `-- name: UpdateWorkspaceVariableSet :one
+UPDATE workspaces SET variables = ?, secret_variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, created_at, updated_at, variables, settings, secret_variables
+`
+
+type UpdateWorkspaceVariableSetParams struct {
+	Variables       sql.NullString `json:"variables"`
+	SecretVariables sql.NullString `json:"secret_variables"`
+	ID              int64          `json:"id"`
+}
+
+func (q *Queries) UpdateWorkspaceVariableSet(ctx context.Context, arg UpdateWorkspaceVariableSetParams) (Workspace, error) {
+	row := q.db.QueryRowContext(ctx, updateWorkspaceVariableSet, arg.Variables, arg.SecretVariables, arg.ID)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Variables,
+		&i.Settings,
+		&i.SecretVariables,
 	)
 	return i, err
 }
 
 const updateWorkspaceVariables = `-- name: UpdateWorkspaceVariables :one
-UPDATE workspaces SET variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, created_at, updated_at, variables
+UPDATE workspaces SET variables = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? RETURNING id, name, created_at, updated_at, variables, settings, secret_variables
 `
 
 type UpdateWorkspaceVariablesParams struct {
@@ -137,6 +319,8 @@ func (q *Queries) UpdateWorkspaceVariables(ctx context.Context, arg UpdateWorksp
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Variables,
+		&i.Settings,
+		&i.SecretVariables,
 	)
 	return i, err
 }
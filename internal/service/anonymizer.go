@@ -0,0 +1,144 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var ErrUnknownMaskRule = errors.New("unknown mask rule")
+
+// MaskRule replaces every match of Pattern with Replacement.
+// Replacement may reference capture groups ($1, ${name}).
+type MaskRule struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// Built-in rules, applied in this order when requested together
+var builtinMaskRules = []MaskRule{
+	{Name: "bearer", Pattern: `(?i)\b(Bearer)\s+[A-Za-z0-9\-._~+/]+=*`, Replacement: "$1 <token>"},
+	{Name: "email", Pattern: `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`, Replacement: "<email>"},
+	{Name: "uuid", Pattern: `(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`, Replacement: "<uuid>"},
+}
+
+// BuiltinMaskRuleNames returns the names of all built-in mask rules
+func BuiltinMaskRuleNames() []string {
+	names := make([]string, 0, len(builtinMaskRules))
+	for _, r := range builtinMaskRules {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// ResolveMaskRules expands built-in rule names ("all" selects every built-in)
+// and appends custom rules after them.
+func ResolveMaskRules(names []string, custom []MaskRule) ([]MaskRule, error) {
+	selected := make(map[string]bool)
+	for _, n := range names {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if n == "" {
+			continue
+		}
+		if n == "all" {
+			for _, r := range builtinMaskRules {
+				selected[r.Name] = true
+			}
+			continue
+		}
+		found := false
+		for _, r := range builtinMaskRules {
+			if r.Name == n {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownMaskRule, n)
+		}
+		selected[n] = true
+	}
+
+	var rules []MaskRule
+	for _, r := range builtinMaskRules {
+		if selected[r.Name] {
+			rules = append(rules, r)
+		}
+	}
+	return append(rules, custom...), nil
+}
+
+type compiledMaskRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// Anonymizer applies mask rules to exported data
+type Anonymizer struct {
+	rules []compiledMaskRule
+}
+
+func NewAnonymizer(rules []MaskRule) (*Anonymizer, error) {
+	a := &Anonymizer{}
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("mask rule %q: %w", r.Name, err)
+		}
+		a.rules = append(a.rules, compiledMaskRule{re: re, replacement: r.Replacement})
+	}
+	return a, nil
+}
+
+// Enabled reports whether any rule is configured
+func (a *Anonymizer) Enabled() bool {
+	return a != nil && len(a.rules) > 0
+}
+
+func (a *Anonymizer) MaskString(s string) string {
+	if !a.Enabled() {
+		return s
+	}
+	for _, r := range a.rules {
+		s = r.re.ReplaceAllString(s, r.replacement)
+	}
+	return s
+}
+
+// Mask returns a masked copy of v as a generic JSON value. Every string in
+// the JSON representation (keys and values) is passed through the rules,
+// including strings that themselves hold JSON such as headers.
+func (a *Anonymizer) Mask(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return a.maskValue(generic), nil
+}
+
+func (a *Anonymizer) maskValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return a.MaskString(t)
+	case []interface{}:
+		for i := range t {
+			t[i] = a.maskValue(t[i])
+		}
+		return t
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			out[a.MaskString(k)] = a.maskValue(val)
+		}
+		return out
+	default:
+		return v
+	}
+}
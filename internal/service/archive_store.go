@@ -0,0 +1,230 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrArchiveNotFound is returned for an object missing from the archive store
+var ErrArchiveNotFound = errors.New("archive object not found")
+
+// ArchiveStore holds archived runs and history outside the database
+type ArchiveStore interface {
+	Name() string // "dir" or "s3", recorded with each archive
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// ArchiveStoreFromEnv reads the archive location: ARCHIVE_DIR for a local
+// directory, or ARCHIVE_S3_BUCKET with ARCHIVE_S3_ENDPOINT (default AWS),
+// ARCHIVE_S3_REGION (default us-east-1), ARCHIVE_S3_ACCESS_KEY,
+// ARCHIVE_S3_SECRET_KEY and ARCHIVE_S3_PREFIX for an S3-compatible bucket.
+// It returns nil when neither is set, leaving archival off.
+func ArchiveStoreFromEnv() (ArchiveStore, error) {
+	if dir := os.Getenv("ARCHIVE_DIR"); dir != "" {
+		return NewDirArchiveStore(dir)
+	}
+	if bucket := os.Getenv("ARCHIVE_S3_BUCKET"); bucket != "" {
+		return NewS3ArchiveStore(S3Config{
+			Endpoint:  os.Getenv("ARCHIVE_S3_ENDPOINT"),
+			Region:    os.Getenv("ARCHIVE_S3_REGION"),
+			Bucket:    bucket,
+			Prefix:    os.Getenv("ARCHIVE_S3_PREFIX"),
+			AccessKey: os.Getenv("ARCHIVE_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("ARCHIVE_S3_SECRET_KEY"),
+		})
+	}
+	return nil, nil
+}
+
+// DirArchiveStore keeps archives as files under a local directory
+type DirArchiveStore struct {
+	baseDir string
+}
+
+func NewDirArchiveStore(baseDir string) (*DirArchiveStore, error) {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	return &DirArchiveStore{baseDir: baseDir}, nil
+}
+
+func (s *DirArchiveStore) Name() string { return "dir" }
+
+// Put writes to a temporary file first so a crash never leaves half an archive
+func (s *DirArchiveStore) Put(ctx context.Context, key string, data []byte) error {
+	path := filepath.Join(s.baseDir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *DirArchiveStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.baseDir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrArchiveNotFound
+	}
+	return data, err
+}
+
+// S3Config locates an S3-compatible bucket (AWS, MinIO, R2, ...)
+type S3Config struct {
+	Endpoint  string // e.g. https://minio.internal:9000; empty = AWS for Region
+	Region    string
+	Bucket    string
+	Prefix    string // prepended to every object key
+	AccessKey string
+	SecretKey string
+}
+
+// S3ArchiveStore keeps archives in a bucket, addressed path-style and signed
+// with AWS Signature Version 4
+type S3ArchiveStore struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+func NewS3ArchiveStore(cfg S3Config) (*S3ArchiveStore, error) {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("ARCHIVE_S3_ACCESS_KEY and ARCHIVE_S3_SECRET_KEY are required")
+	}
+	u, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	return &S3ArchiveStore{cfg: cfg, endpoint: u, client: &http.Client{Timeout: time.Minute}, now: time.Now}, nil
+}
+
+func (s *S3ArchiveStore) Name() string { return "s3" }
+
+func (s *S3ArchiveStore) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+func (s *S3ArchiveStore) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrArchiveNotFound
+	default:
+		return nil, s3Error(resp)
+	}
+}
+
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+func (s *S3ArchiveStore) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	if s.cfg.Prefix != "" {
+		key = s.cfg.Prefix + "/" + key
+	}
+	u := *s.endpoint
+	u.Path = u.Path + "/" + s.cfg.Bucket + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s.sign(req, body)
+	return s.client.Do(req)
+}
+
+// sign adds SigV4 headers for a request without a query string
+func (s *S3ArchiveStore) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		"",
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), day)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.cfg.AccessKey+"/"+scope+
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="+signature)
+}
+
+// s3EscapePath URI-encodes each path segment the way SigV4 expects
+func s3EscapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		var b strings.Builder
+		for _, c := range []byte(seg) {
+			if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+				b.WriteByte(c)
+			} else {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		}
+		segments[i] = b.String()
+	}
+	return strings.Join(segments, "/")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
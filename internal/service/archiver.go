@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"relay/internal/repository"
+)
+
+// Archive kinds
+const (
+	ArchiveKindRun     = "run"     // a flow run's timeline with its history entries
+	ArchiveKindHistory = "history" // a batch of history entries outside archived runs
+)
+
+const (
+	archiveBatchSize = 500
+	sqliteTimeLayout = "2006-01-02 15:04:05" // CURRENT_TIMESTAMP format
+)
+
+// ArchiveDocument is the content of one archive object, stored as
+// gzip-compressed JSON
+type ArchiveDocument struct {
+	Kind     string                      `json:"kind"`
+	RunID    string                      `json:"runId,omitempty"`
+	Timeline json.RawMessage             `json:"timeline,omitempty"` // RunTimeline
+	Result   json.RawMessage             `json:"result,omitempty"`   // FlowResult
+	History  []repository.RequestHistory `json:"history"`
+}
+
+// Archiver copies flow runs and history that retention is about to delete to
+// an ArchiveStore, recording each object in the archives table so it can be
+// listed and read back later.
+type Archiver struct {
+	queries *repository.Queries
+	store   ArchiveStore
+}
+
+func NewArchiver(queries *repository.Queries, store ArchiveStore) *Archiver {
+	return &Archiver{queries: queries, store: store}
+}
+
+// sqliteTime formats t for comparison with CURRENT_TIMESTAMP columns
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// ArchiveRuns archives the run timelines created before `before`, each with
+// the run's result and history entries, and deletes the timelines. It returns how
+// many runs were archived.
+func (a *Archiver) ArchiveRuns(ctx context.Context, before time.Time) (int, error) {
+	n := 0
+	for {
+		rows, err := a.queries.ListExpiredRunTimelines(ctx, repository.ListExpiredRunTimelinesParams{
+			Before: sqliteTime(before),
+			Limit:  archiveBatchSize,
+		})
+		if err != nil {
+			return n, err
+		}
+		for _, row := range rows {
+			if err := a.archiveRun(ctx, row); err != nil {
+				return n, fmt.Errorf("run %s: %w", row.RunID, err)
+			}
+			n++
+		}
+		if len(rows) < archiveBatchSize {
+			return n, nil
+		}
+	}
+}
+
+func (a *Archiver) archiveRun(ctx context.Context, row repository.RunTimeline) error {
+	runID := sql.NullString{String: row.RunID, Valid: true}
+	history, err := a.queries.ListHistoryByExecutionGroup(ctx, repository.ListHistoryByExecutionGroupParams{
+		WorkspaceID:      row.WorkspaceID,
+		ExecutionGroupID: runID,
+	})
+	if err != nil {
+		return err
+	}
+	doc := ArchiveDocument{
+		Kind:     ArchiveKindRun,
+		RunID:    row.RunID,
+		Timeline: json.RawMessage(row.Timeline),
+		History:  history,
+	}
+	if run, err := a.queries.GetFlowRun(ctx, row.RunID); err == nil {
+		doc.Result = json.RawMessage(run.Result)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	key := fmt.Sprintf("runs/%d/%s.json.gz", row.WorkspaceID, row.RunID)
+	size, err := a.put(ctx, key, doc)
+	if err != nil {
+		return err
+	}
+	if _, err := a.queries.CreateArchive(ctx, repository.CreateArchiveParams{
+		WorkspaceID: row.WorkspaceID,
+		Kind:        ArchiveKindRun,
+		RunID:       runID,
+		FlowID:      sql.NullInt64{Int64: row.FlowID, Valid: true},
+		Storage:     a.store.Name(),
+		ObjectKey:   key,
+		Entries:     int64(len(history)),
+		Size:        size,
+		OldestAt:    row.CreatedAt,
+		NewestAt:    row.CreatedAt,
+	}); err != nil {
+		return err
+	}
+	return a.queries.DeleteRunTimeline(ctx, row.ID)
+}
+
+// ArchiveHistory archives unflagged history entries created before `before`
+// in batches per workspace, leaving out entries of runs archived with their
+// run. It does not delete them; it returns how many were archived.
+func (a *Archiver) ArchiveHistory(ctx context.Context, before time.Time) (int64, error) {
+	cutoff := sqliteTime(before)
+	workspaces, err := a.queries.ListExpiredHistoryWorkspaces(ctx, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, wsID := range workspaces {
+		var afterID int64
+		for {
+			rows, err := a.queries.ListExpiredHistory(ctx, repository.ListExpiredHistoryParams{
+				WorkspaceID: wsID,
+				Before:      cutoff,
+				AfterID:     afterID,
+				Limit:       archiveBatchSize,
+			})
+			if err != nil {
+				return n, err
+			}
+			if len(rows) == 0 {
+				break
+			}
+			if err := a.archiveHistoryBatch(ctx, wsID, rows); err != nil {
+				return n, err
+			}
+			n += int64(len(rows))
+			afterID = rows[len(rows)-1].ID
+			if len(rows) < archiveBatchSize {
+				break
+			}
+		}
+	}
+	return n, nil
+}
+
+func (a *Archiver) archiveHistoryBatch(ctx context.Context, wsID int64, rows []repository.RequestHistory) error {
+	key := fmt.Sprintf("history/%d/%d-%d.json.gz", wsID, rows[0].ID, rows[len(rows)-1].ID)
+	size, err := a.put(ctx, key, ArchiveDocument{Kind: ArchiveKindHistory, History: rows})
+	if err != nil {
+		return err
+	}
+	oldest, newest := rows[0].CreatedAt, rows[0].CreatedAt
+	for _, h := range rows[1:] {
+		if h.CreatedAt.Time.Before(oldest.Time) {
+			oldest = h.CreatedAt
+		}
+		if h.CreatedAt.Time.After(newest.Time) {
+			newest = h.CreatedAt
+		}
+	}
+	_, err = a.queries.CreateArchive(ctx, repository.CreateArchiveParams{
+		WorkspaceID: wsID,
+		Kind:        ArchiveKindHistory,
+		Storage:     a.store.Name(),
+		ObjectKey:   key,
+		Entries:     int64(len(rows)),
+		Size:        size,
+		OldestAt:    oldest,
+		NewestAt:    newest,
+	})
+	return err
+}
+
+// put writes doc to the store and returns its compressed size
+func (a *Archiver) put(ctx context.Context, key string, doc ArchiveDocument) (int64, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(doc); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+	if err := a.store.Put(ctx, key, buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return int64(buf.Len()), nil
+}
+
+// Load reads an archive back from the store
+func (a *Archiver) Load(ctx context.Context, arch repository.Archive) (*ArchiveDocument, error) {
+	if arch.Storage != a.store.Name() {
+		return nil, fmt.Errorf("archive is in %s storage, but %s storage is configured", arch.Storage, a.store.Name())
+	}
+	data, err := a.store.Get(ctx, arch.ObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("corrupt archive: %w", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt archive: %w", err)
+	}
+	var doc ArchiveDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("corrupt archive: %w", err)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestHistoryRetention_Archives(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	store, err := NewDirArchiveStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	archiver := NewArchiver(q, store)
+	retention := NewHistoryRetention(q)
+	retention.SetArchiver(archiver)
+
+	newHistory := func(runID string) int64 {
+		t.Helper()
+		h, err := q.CreateHistory(ctx, repository.CreateHistoryParams{
+			Method: "GET", Url: "https://example.com", WorkspaceID: 1,
+			ExecutionGroupID: sql.NullString{String: runID, Valid: runID != ""},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h.ID
+	}
+	runStep := newHistory("run-1")
+	single := newHistory("")
+	flagged := newHistory("")
+	recent := newHistory("")
+	q.UpdateHistoryNote(ctx, repository.UpdateHistoryNoteParams{Flagged: 1, ID: flagged, WorkspaceID: 1})
+	q.CreateRunTimeline(ctx, repository.CreateRunTimelineParams{RunID: "run-1", WorkspaceID: 1, FlowID: 7, Timeline: `{"runId":"run-1","success":true}`})
+	q.CreateRunTimeline(ctx, repository.CreateRunTimelineParams{RunID: "run-2", WorkspaceID: 1, FlowID: 7, Timeline: `{"runId":"run-2"}`})
+	q.CreateFlowRun(ctx, repository.CreateFlowRunParams{RunID: "run-1", WorkspaceID: 1, FlowID: 7, Success: 1, Result: `{"runId":"run-1"}`})
+	db.Exec(`UPDATE run_timelines SET created_at = datetime('now', '-8 days') WHERE run_id = 'run-1'`)
+	db.Exec(`UPDATE flow_runs SET created_at = datetime('now', '-8 days') WHERE run_id = 'run-1'`)
+	db.Exec(`UPDATE request_history SET created_at = datetime('now', '-8 days') WHERE id = ?`, runStep)
+	db.Exec(`UPDATE request_history SET created_at = datetime('now', '-31 days') WHERE id IN (?, ?)`, single, flagged)
+
+	// First round: the week-old run is archived with its step, which has not
+	// expired yet; the expired single entry is archived and deleted
+	if n, err := retention.Prune(ctx); err != nil || n != 1 {
+		t.Fatalf("first prune: n=%d err=%v", n, err)
+	}
+	if _, err := q.GetRunTimeline(ctx, "run-1"); err == nil {
+		t.Error("archived timeline is still in the database")
+	}
+	if _, err := q.GetRunTimeline(ctx, "run-2"); err != nil {
+		t.Errorf("recent timeline: %v", err)
+	}
+	if _, err := q.GetFlowRun(ctx, "run-1"); err != nil {
+		t.Errorf("run result is kept as long as history: %v", err)
+	}
+
+	// Second round, once the step and result have expired too: they are not
+	// archived again
+	db.Exec(`UPDATE request_history SET created_at = datetime('now', '-31 days') WHERE id = ?`, runStep)
+	db.Exec(`UPDATE flow_runs SET created_at = datetime('now', '-31 days') WHERE run_id = 'run-1'`)
+	if n, err := retention.Prune(ctx); err != nil || n != 1 {
+		t.Fatalf("second prune: n=%d err=%v", n, err)
+	}
+	for id, kept := range map[int64]bool{runStep: false, single: false, flagged: true, recent: true} {
+		if _, err := q.GetHistory(ctx, id); (err == nil) != kept {
+			t.Errorf("history %d kept = %v, want %v", id, err == nil, kept)
+		}
+	}
+	if _, err := q.GetFlowRun(ctx, "run-1"); err == nil {
+		t.Error("expired run result is still in the database")
+	}
+
+	archives, err := q.ListArchives(ctx, repository.ListArchivesParams{WorkspaceID: 1, Limit: 10})
+	if err != nil || len(archives) != 2 {
+		t.Fatalf("archives = %+v, %v", archives, err)
+	}
+	hist, run := archives[0], archives[1]
+	if run.Kind != ArchiveKindRun || run.RunID.String != "run-1" || run.FlowID.Int64 != 7 || run.Entries != 1 || run.Storage != "dir" {
+		t.Errorf("run archive = %+v", run)
+	}
+	if hist.Kind != ArchiveKindHistory || hist.Entries != 1 || !hist.OldestAt.Valid {
+		t.Errorf("history archive = %+v", hist)
+	}
+
+	doc, err := archiver.Load(ctx, run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(doc.Timeline) != `{"runId":"run-1","success":true}` || string(doc.Result) != `{"runId":"run-1"}` || len(doc.History) != 1 || doc.History[0].ID != runStep {
+		t.Errorf("run document = %+v", doc)
+	}
+	if doc, err = archiver.Load(ctx, hist); err != nil || len(doc.History) != 1 || doc.History[0].ID != single {
+		t.Errorf("history document = %+v, %v", doc, err)
+	}
+}
+
+func TestS3ArchiveStore(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path] = body
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	defer srv.Close()
+
+	store, err := NewS3ArchiveStore(S3Config{Endpoint: srv.URL, Region: "eu-west-1", Bucket: "relay", Prefix: "/prod/", AccessKey: "AKID", SecretKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := store.Put(ctx, "runs/1/abc.json.gz", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := objects["/relay/prod/runs/1/abc.json.gz"]; !ok {
+		t.Errorf("stored keys = %v", objects)
+	}
+	if data, err := store.Get(ctx, "runs/1/abc.json.gz"); err != nil || string(data) != "data" {
+		t.Errorf("get = %q, %v", data, err)
+	}
+	if _, err := store.Get(ctx, "missing"); err != ErrArchiveNotFound {
+		t.Errorf("missing object = %v", err)
+	}
+
+	if _, err := NewS3ArchiveStore(S3Config{Bucket: "relay"}); err == nil {
+		t.Error("store without credentials was created")
+	}
+	bad, _ := NewS3ArchiveStore(S3Config{Endpoint: srv.URL, Bucket: "relay", AccessKey: "other", SecretKey: "x"})
+	if err := bad.Put(ctx, "k", nil); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("rejected put = %v", err)
+	}
+}
+
+func TestS3EscapePath(t *testing.T) {
+	if got := s3EscapePath("/bucket/a b/ü~x.json"); got != "/bucket/a%20b/%C3%BC~x.json" {
+		t.Errorf("escaped = %s", got)
+	}
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PaesslerAG/jsonpath"
+
+	"relay/internal/middleware"
+)
+
+const (
+	maxAuthSessions = 50
+	// authSessionTTL is the token lifetime when neither the session nor the
+	// login response sets one
+	authSessionTTL = time.Hour
+	// authSessionSkew renews tokens slightly before they expire so a request
+	// doesn't go out with a token that lapses in flight
+	authSessionSkew = 10 * time.Second
+)
+
+// AuthSession logs in with a saved request and injects the token it returns
+// into dependent requests, running the login again once the token expires.
+// Requests opt in with an auth block of type "session"; requests to Hosts get
+// the token without one.
+type AuthSession struct {
+	Name           string `json:"name"`
+	LoginRequestID int64  `json:"loginRequestId"`
+	// TokenPath is the JSONPath of the token in the login response
+	TokenPath string `json:"tokenPath"`
+	// ExpiresInPath is the JSONPath of the token lifetime in seconds; when it
+	// is unset or absent from the response, ExpiresIn applies (default 3600)
+	ExpiresInPath string `json:"expiresInPath,omitempty"`
+	ExpiresIn     int    `json:"expiresIn,omitempty"`
+	// Header carries the token (default Authorization, sent as
+	// "<Scheme> <token>"; other headers get the bare token)
+	Header string `json:"header,omitempty"`
+	Scheme string `json:"scheme,omitempty"` // default Bearer
+	// Hosts are hostnames or host:port; "*.example.com" matches subdomains
+	Hosts []string `json:"hosts,omitempty"`
+}
+
+// ValidateAuthSessions checks a workspace's auth session definitions
+func ValidateAuthSessions(sessions []AuthSession) error {
+	if len(sessions) > maxAuthSessions {
+		return fmt.Errorf("at most %d auth sessions are allowed", maxAuthSessions)
+	}
+	names := make(map[string]bool, len(sessions))
+	for i, s := range sessions {
+		name := fmt.Sprintf("authSessions[%d]", i)
+		if s.Name == "" {
+			return fmt.Errorf("%s: name is required", name)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("%s: duplicate name %q", name, s.Name)
+		}
+		names[s.Name] = true
+		if s.LoginRequestID <= 0 {
+			return fmt.Errorf("%s: loginRequestId is required", name)
+		}
+		if !strings.HasPrefix(s.TokenPath, "$") {
+			return fmt.Errorf("%s: tokenPath must be a JSONPath starting with $", name)
+		}
+		if s.ExpiresInPath != "" && !strings.HasPrefix(s.ExpiresInPath, "$") {
+			return fmt.Errorf("%s: expiresInPath must be a JSONPath starting with $", name)
+		}
+		if s.ExpiresIn < 0 {
+			return fmt.Errorf("%s: expiresIn must not be negative", name)
+		}
+		if s.Header != "" && !httpTokenPattern.MatchString(s.Header) {
+			return fmt.Errorf("%s: header %q is not a valid header name", name, s.Header)
+		}
+	}
+	return nil
+}
+
+func (s AuthSession) header() string {
+	if s.Header == "" {
+		return "Authorization"
+	}
+	return s.Header
+}
+
+func (s AuthSession) headerValue(token string) string {
+	if !strings.EqualFold(s.header(), "Authorization") {
+		return token
+	}
+	scheme := s.Scheme
+	if scheme == "" {
+		scheme = "Bearer"
+	}
+	return scheme + " " + token
+}
+
+// matches reports whether requests to host get the session's token
+func (s AuthSession) matches(host string) bool {
+	for _, pattern := range s.Hosts {
+		if matchHost(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// authSessionTokens caches the tokens of logged-in sessions. Entries are
+// keyed by workspace and definition, so editing a session logs in afresh.
+type authSessionTokens struct {
+	mu     sync.Mutex
+	tokens map[string]*authSessionToken
+}
+
+type authSessionToken struct {
+	mu      sync.Mutex // held while logging in, so concurrent requests share one login
+	value   string
+	expires time.Time
+}
+
+func newAuthSessionTokens() *authSessionTokens {
+	return &authSessionTokens{tokens: map[string]*authSessionToken{}}
+}
+
+func authSessionKey(ctx context.Context, s AuthSession) string {
+	def, _ := json.Marshal(s)
+	return fmt.Sprintf("%d:%s", middleware.GetWorkspaceID(ctx), def)
+}
+
+func (c *authSessionTokens) entry(key string) *authSessionToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tokens[key]
+	if !ok {
+		t = &authSessionToken{}
+		c.tokens[key] = t
+	}
+	return t
+}
+
+// forget drops the session's token, e.g. after the target rejected it
+func (c *authSessionTokens) forget(ctx context.Context, s AuthSession) {
+	c.mu.Lock()
+	delete(c.tokens, authSessionKey(ctx, s))
+	c.mu.Unlock()
+}
+
+type authSessionLoginKey struct{}
+
+// withAuthSessionLogin marks ctx as running a session's login request, which
+// must not wait on a session itself
+func withAuthSessionLogin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, authSessionLoginKey{}, true)
+}
+
+// authSession picks the session whose token req gets: the one its auth block
+// names, else the first whose hosts match unless the request already sets
+// the session's header
+func (re *RequestExecutor) authSession(ctx context.Context, auth *RequestAuth, rawURL string, headers map[string]string) (*AuthSession, error) {
+	if ctx.Value(authSessionLoginKey{}) != nil {
+		return nil, nil
+	}
+	if auth != nil && auth.Type != "session" {
+		return nil, nil
+	}
+	sessions := re.authSessions(ctx)
+	if auth != nil {
+		for i := range sessions {
+			if sessions[i].Name == auth.Session {
+				return &sessions[i], nil
+			}
+		}
+		return nil, fmt.Errorf("auth session %q is not defined in the workspace settings", auth.Session)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil
+	}
+	for i, s := range sessions {
+		if !s.matches(u.Host) {
+			continue
+		}
+		for k := range headers {
+			if strings.EqualFold(k, s.header()) {
+				return nil, nil
+			}
+		}
+		return &sessions[i], nil
+	}
+	return nil, nil
+}
+
+// authSessionToken returns the session's token, running its login request
+// when there is none yet or it is about to expire
+func (re *RequestExecutor) authSessionToken(ctx context.Context, s AuthSession, runtimeVars map[string]string) (string, error) {
+	t := re.authTokens.entry(authSessionKey(ctx, s))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.value != "" && time.Now().Add(authSessionSkew).Before(t.expires) {
+		return t.value, nil
+	}
+
+	login, err := re.queries.GetRequest(ctx, s.LoginRequestID)
+	if err != nil || login.WorkspaceID != middleware.GetWorkspaceID(ctx) {
+		return "", fmt.Errorf("login request %d not found", s.LoginRequestID)
+	}
+	result, err := re.ExecuteRequest(withAuthSessionLogin(ctx), login, runtimeVars)
+	if err != nil {
+		return "", err
+	}
+	if result.Error != "" {
+		return "", errors.New("login failed: " + result.Error)
+	}
+	if result.StatusCode < 200 || result.StatusCode >= 300 {
+		return "", fmt.Errorf("login failed with status %d", result.StatusCode)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(result.Body), &data); err != nil {
+		return "", errors.New("login response is not JSON")
+	}
+	token, err := jsonpath.Get(s.TokenPath, data)
+	if err != nil || token == nil || fmt.Sprint(token) == "" {
+		return "", fmt.Errorf("login response has no token at %s", s.TokenPath)
+	}
+	ttl := authSessionTTL
+	if s.ExpiresIn > 0 {
+		ttl = time.Duration(s.ExpiresIn) * time.Second
+	}
+	if s.ExpiresInPath != "" {
+		if v, err := jsonpath.Get(s.ExpiresInPath, data); err == nil {
+			if secs, ok := v.(float64); ok && secs > 0 {
+				ttl = time.Duration(secs * float64(time.Second))
+			}
+		}
+	}
+
+	t.value, t.expires = fmt.Sprint(token), time.Now().Add(ttl)
+	return t.value, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestAuthSession_LoginOnDemand(t *testing.T) {
+	var mu sync.Mutex
+	logins := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/login" {
+			logins++
+			fmt.Fprintf(w, `{"data":{"access_token":"tok-%d"},"expires_in":3600}`, logins)
+			return
+		}
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer tok-%d", logins) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+	ctx := context.Background()
+
+	login, err := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "login", Method: "POST", Url: ts.URL + "/login", WorkspaceID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setSessions := func(sessions string) {
+		if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+			Settings: sql.NullString{String: `{"authSessions":` + sessions + `}`, Valid: true},
+			ID:       1,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setSessions(fmt.Sprintf(`[{"name":"api","loginRequestId":%d,"tokenPath":"$.data.access_token","expiresInPath":"$.expires_in","hosts":["%s"]}]`, login.ID, host))
+
+	// Steps to the session's host get the token without a login step
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "one", Method: "GET", Url: ts.URL + "/a"},
+		{Name: "two", Method: "GET", Url: ts.URL + "/b"},
+	})
+	result, err := fr.Run(ctx, flowID, nil)
+	if err != nil || !result.Success {
+		t.Fatalf("run: %v, %+v", err, result)
+	}
+	if logins != 1 || result.Steps[1].ExecuteResult.AuthSession != "api" {
+		t.Errorf("logins = %d, authSession = %q", logins, result.Steps[1].ExecuteResult.AuthSession)
+	}
+
+	// A rejected token is dropped; the next request logs in again
+	mu.Lock()
+	logins++
+	mu.Unlock()
+	res, _ := re.ExecuteAdhoc(ctx, "GET", ts.URL+"/a", "", "", nil, nil)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("revoked token status = %d", res.StatusCode)
+	}
+	res, _ = re.ExecuteAdhoc(ctx, "GET", ts.URL+"/a", "", "", nil, nil)
+	if res.StatusCode != 200 || logins != 3 {
+		t.Errorf("after revocation: status %d, logins %d", res.StatusCode, logins)
+	}
+
+	// Editing the session logs in afresh; tokens about to expire are renewed
+	// before use
+	setSessions(fmt.Sprintf(`[{"name":"api","loginRequestId":%d,"tokenPath":"$.data.access_token","expiresIn":1,"hosts":["%s"]}]`, login.ID, host))
+	re.ExecuteAdhoc(ctx, "GET", ts.URL+"/a", "", "", nil, nil)
+	re.ExecuteAdhoc(ctx, "GET", ts.URL+"/a", "", "", nil, nil)
+	if logins != 5 {
+		t.Errorf("logins with short-lived tokens = %d, want 5", logins)
+	}
+
+	// Requests setting the header themselves are left alone
+	res, _ = re.ExecuteAdhoc(ctx, "GET", ts.URL+"/a", `{"Authorization":"Bearer mine"}`, "", nil, nil)
+	if res.AuthSession != "" || res.ResolvedHeaders["Authorization"] != "Bearer mine" {
+		t.Errorf("explicit header replaced: %+v", res.ResolvedHeaders)
+	}
+}
+
+func TestAuthSession_AuthBlock(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			w.Write([]byte(`{"token":"abc"}`))
+			return
+		}
+		w.Write([]byte(r.Header.Get("X-Api-Token")))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+
+	login, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "login", Method: "POST", Url: ts.URL + "/login", WorkspaceID: 1})
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: fmt.Sprintf(`{"authSessions":[{"name":"svc","loginRequestId":%d,"tokenPath":"$.token","header":"X-Api-Token"}]}`, login.ID), Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	req := repository.Request{Method: "GET", Url: ts.URL + "/data", Auth: sql.NullString{String: `{"type":"session","session":"svc"}`, Valid: true}}
+	res, err := re.ExecuteRequest(ctx, req, nil)
+	if err != nil || res.Body != "abc" || res.AuthSession != "svc" {
+		t.Errorf("session auth block: %v, body %q, error %q", err, res.Body, res.Error)
+	}
+
+	req.Auth.String = `{"type":"session","session":"missing"}`
+	if res, _ := re.ExecuteRequest(ctx, req, nil); !strings.Contains(res.Error, "not defined") {
+		t.Errorf("unknown session error = %q", res.Error)
+	}
+}
+
+func TestValidateAuthSessions(t *testing.T) {
+	valid := AuthSession{Name: "a", LoginRequestID: 1, TokenPath: "$.token"}
+	if err := ValidateAuthSessions([]AuthSession{valid}); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		sessions []AuthSession
+		want     string
+	}{
+		{[]AuthSession{valid, valid}, "duplicate"},
+		{[]AuthSession{{Name: "a", TokenPath: "$.t"}}, "loginRequestId"},
+		{[]AuthSession{{Name: "a", LoginRequestID: 1, TokenPath: "token"}}, "tokenPath"},
+		{[]AuthSession{{Name: "a", LoginRequestID: 1, TokenPath: "$.t", Header: "Bad Header"}}, "header"},
+	} {
+		if err := ValidateAuthSessions(tc.sessions); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: error %v, want %q", tc.sessions, err, tc.want)
+		}
+	}
+	if u, _ := url.Parse("http://api.example.com:8080/x"); !(AuthSession{Hosts: []string{"*.example.com"}}).matches(u.Host) {
+		t.Error("wildcard host should match")
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// BinaryPreview describes a binary response so the UI can label and preview
+// it without decoding the base64 body itself
+type BinaryPreview struct {
+	ContentType string `json:"contentType"` // sniffed from the bytes, not the header
+	SHA256      string `json:"sha256"`
+	Format      string `json:"format,omitempty"` // image format: png, jpeg, gif, webp
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	PageCount   int    `json:"pageCount,omitempty"` // PDF; best effort, 0 when not found
+}
+
+var (
+	pdfPageRe       = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfPagesCountRe = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+)
+
+// BuildBinaryPreview sniffs the content type of body and extracts image
+// dimensions or a PDF page count where it can
+func BuildBinaryPreview(body []byte) *BinaryPreview {
+	sum := sha256.Sum256(body)
+	p := &BinaryPreview{
+		ContentType: http.DetectContentType(body),
+		SHA256:      hex.EncodeToString(sum[:]),
+	}
+
+	switch {
+	case strings.HasPrefix(p.ContentType, "image/webp"):
+		p.Format = "webp"
+		p.Width, p.Height = webpSize(body)
+	case strings.HasPrefix(p.ContentType, "image/"):
+		if cfg, format, err := image.DecodeConfig(bytes.NewReader(body)); err == nil {
+			p.Format, p.Width, p.Height = format, cfg.Width, cfg.Height
+		}
+	case p.ContentType == "application/pdf":
+		p.PageCount = pdfPageCount(body)
+	}
+	return p
+}
+
+// webpSize reads the canvas size from a lossy (VP8), lossless (VP8L) or
+// extended (VP8X) WebP header
+func webpSize(b []byte) (int, int) {
+	if len(b) < 30 {
+		return 0, 0
+	}
+	switch string(b[12:16]) {
+	case "VP8X":
+		w := int(b[24]) | int(b[25])<<8 | int(b[26])<<16
+		h := int(b[27]) | int(b[28])<<8 | int(b[29])<<16
+		return w + 1, h + 1
+	case "VP8L":
+		if b[20] != 0x2f {
+			return 0, 0
+		}
+		bits := binary.LittleEndian.Uint32(b[21:25])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1
+	case "VP8 ":
+		if b[23] != 0x9d || b[24] != 0x01 || b[25] != 0x2a {
+			return 0, 0
+		}
+		return int(binary.LittleEndian.Uint16(b[26:28]) & 0x3fff), int(binary.LittleEndian.Uint16(b[28:30]) & 0x3fff)
+	}
+	return 0, 0
+}
+
+// pdfPageCount uses the largest /Count of a /Pages node (the page tree root),
+// falling back to counting /Page objects. PDFs that keep their page tree in
+// compressed object streams report 0.
+func pdfPageCount(b []byte) int {
+	count := 0
+	for _, m := range pdfPagesCountRe.FindAllSubmatch(b, -1) {
+		digits := m[1]
+		if digits == nil {
+			digits = m[2]
+		}
+		if n, err := strconv.Atoi(string(digits)); err == nil && n > count {
+			count = n
+		}
+	}
+	if count > 0 {
+		return count
+	}
+	return len(pdfPageRe.FindAllIndex(b, -1))
+}
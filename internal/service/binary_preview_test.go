@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/testutil"
+)
+
+func TestBuildBinaryPreview(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	var pngBuf, jpegBuf, gifBuf bytes.Buffer
+	png.Encode(&pngBuf, img)
+	jpeg.Encode(&jpegBuf, img, nil)
+	gif.Encode(&gifBuf, img, nil)
+
+	// RIFF header with an extended (VP8X) chunk for a 640x480 canvas
+	webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00\x00\x00\x00\x00\x7f\x02\x00\xdf\x01\x00")
+
+	pdf := []byte("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+		"2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 >> endobj\n" +
+		"3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n")
+	pdfNoTree := []byte("%PDF-1.4\n1 0 obj << /Type /Page >> endobj\n2 0 obj << /Type /Page >> endobj\n")
+
+	cases := []struct {
+		name        string
+		body        []byte
+		contentType string
+		format      string
+		width       int
+		height      int
+		pages       int
+	}{
+		{"png", pngBuf.Bytes(), "image/png", "png", 40, 30, 0},
+		{"jpeg", jpegBuf.Bytes(), "image/jpeg", "jpeg", 40, 30, 0},
+		{"gif", gifBuf.Bytes(), "image/gif", "gif", 40, 30, 0},
+		{"webp", webp, "image/webp", "webp", 640, 480, 0},
+		{"pdf page tree", pdf, "application/pdf", "", 0, 0, 3},
+		{"pdf page objects", pdfNoTree, "application/pdf", "", 0, 0, 2},
+		{"zip", []byte("PK\x03\x04rest"), "application/zip", "", 0, 0, 0},
+	}
+	for _, tc := range cases {
+		p := BuildBinaryPreview(tc.body)
+		if p.ContentType != tc.contentType || p.Format != tc.format || p.Width != tc.width || p.Height != tc.height || p.PageCount != tc.pages {
+			t.Errorf("%s: got %+v", tc.name, p)
+		}
+		if len(p.SHA256) != 64 {
+			t.Errorf("%s: sha256 = %q", tc.name, p.SHA256)
+		}
+	}
+}
+
+func TestExecuteRequest_BinaryPreview(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 3, 2)))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Mislabelled: the preview reports what the bytes are
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(buf.Bytes())
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	result, err := re.ExecuteAdhoc(context.Background(), "GET", ts.URL, "", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsBinary || result.Preview == nil {
+		t.Fatalf("expected binary preview: %+v", result)
+	}
+	if result.Preview.ContentType != "image/png" || result.Preview.Width != 3 || result.Preview.Height != 2 {
+		t.Errorf("preview = %+v", result.Preview)
+	}
+}
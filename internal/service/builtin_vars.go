@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // IANA zones for $date in minimal container images
+)
+
+// Built-in clock and random variables, resolved when no user variable has
+// the same name:
+//
+//	{{$timestamp}}          unix seconds
+//	{{$timestampMs}}        unix milliseconds
+//	{{$isoTimestamp}}       RFC3339 in the server's local timezone
+//	{{$isoTimestampUTC}}    RFC3339 in UTC
+//	{{$date 'FORMAT' 'TZ' 'OFFSET'}}
+//	{{$guid}}, {{$randomUUID}}  version 4 UUID
+//	{{$randomInt 'MIN' 'MAX'}}  integer in [MIN, MAX], default [0, 1000]
+//
+// FORMAT uses tokens YYYY YY MMMM MMM MM M DD D dddd ddd HH H hh h mm m ss s
+// SSS A a ZZ Z X x; text inside [brackets] is emitted literally. TZ is an IANA
+// name (default UTC) and OFFSET shifts the time, e.g. '-1d', '+2h30m', '+1M'.
+
+const (
+	defaultDateFormat = "YYYY-MM-DDTHH:mm:ssZZ"
+	defaultRandomMax  = 1000
+)
+
+// builtinEnv is what built-in variables read: the run's clock (possibly
+// frozen), random source (possibly seeded) and workspace data factories
+type builtinEnv struct {
+	now    func() time.Time
+	random *runRandom   // nil = true randomness
+	data   *dataFactory // nil = no seq:/pool: variables
+}
+
+// builtinEnv returns the clock, random source and data factories of the run in ctx
+func (vr *VariableResolver) builtinEnv(ctx context.Context) builtinEnv {
+	return builtinEnv{now: vr.clock(ctx), random: runRandomFrom(ctx), data: vr.dataFactory(ctx)}
+}
+
+// resolveBuiltin evaluates a $-prefixed variable expression
+func (vr *VariableResolver) resolveBuiltin(expr string, env builtinEnv) (string, bool) {
+	args, err := splitBuiltinArgs(expr)
+	if err != nil || len(args) == 0 {
+		return "", false
+	}
+
+	switch args[0] {
+	case "$guid", "$randomUUID":
+		return env.random.UUID(), true
+	case "$randomInt":
+		lo, hi := 0, defaultRandomMax
+		if len(args) > 1 {
+			if lo, err = strconv.Atoi(args[1]); err != nil {
+				return "", false
+			}
+		}
+		if len(args) > 2 {
+			if hi, err = strconv.Atoi(args[2]); err != nil {
+				return "", false
+			}
+		}
+		if hi < lo {
+			return "", false
+		}
+		return strconv.Itoa(lo + env.random.IntN(hi-lo+1)), true
+	}
+
+	now := env.now()
+
+	switch args[0] {
+	case "$timestamp":
+		return strconv.FormatInt(now.Unix(), 10), true
+	case "$timestampMs":
+		return strconv.FormatInt(now.UnixMilli(), 10), true
+	case "$isoTimestamp":
+		return now.Local().Format(time.RFC3339), true
+	case "$isoTimestampUTC":
+		return now.UTC().Format(time.RFC3339), true
+	case "$date":
+		layout := defaultDateFormat
+		if len(args) > 1 && args[1] != "" {
+			layout = args[1]
+		}
+		loc := time.UTC
+		if len(args) > 2 && args[2] != "" {
+			l, err := time.LoadLocation(args[2])
+			if err != nil {
+				return "", false
+			}
+			loc = l
+		}
+		t := now.In(loc)
+		if len(args) > 3 && args[3] != "" {
+			shifted, err := applyDateOffset(t, args[3])
+			if err != nil {
+				return "", false
+			}
+			t = shifted
+		}
+		return FormatDate(t, layout), true
+	}
+	return "", false
+}
+
+// splitBuiltinArgs splits `$date 'YYYY-MM-DD' "Asia/Seoul"` into its parts
+func splitBuiltinArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+var dateOffsetPattern = regexp.MustCompile(`([+-]?)(\d+)([yMwdhms])`)
+
+// applyDateOffset shifts t by an offset like "-1d", "+2h30m" or "1y-6M"
+func applyDateOffset(t time.Time, offset string) (time.Time, error) {
+	matches := dateOffsetPattern.FindAllStringSubmatchIndex(offset, -1)
+	consumed := 0
+	for _, m := range matches {
+		if m[0] != consumed {
+			return t, fmt.Errorf("invalid date offset %q", offset)
+		}
+		consumed = m[1]
+		n, _ := strconv.Atoi(offset[m[4]:m[5]])
+		if offset[m[2]:m[3]] == "-" {
+			n = -n
+		}
+		switch offset[m[6]:m[7]] {
+		case "y":
+			t = t.AddDate(n, 0, 0)
+		case "M":
+			t = t.AddDate(0, n, 0)
+		case "w":
+			t = t.AddDate(0, 0, 7*n)
+		case "d":
+			t = t.AddDate(0, 0, n)
+		case "h":
+			t = t.Add(time.Duration(n) * time.Hour)
+		case "m":
+			t = t.Add(time.Duration(n) * time.Minute)
+		case "s":
+			t = t.Add(time.Duration(n) * time.Second)
+		}
+	}
+	if consumed != len(offset) {
+		return t, fmt.Errorf("invalid date offset %q", offset)
+	}
+	return t, nil
+}
+
+// dateTokens are matched longest-first at each position
+var dateTokens = []string{
+	"YYYY", "MMMM", "dddd", "MMM", "ddd", "SSS",
+	"YY", "MM", "DD", "HH", "hh", "mm", "ss", "ZZ",
+	"M", "D", "H", "h", "m", "s", "A", "a", "Z", "X", "x",
+}
+
+// FormatDate renders t using the moment.js-style tokens documented above
+func FormatDate(t time.Time, layout string) string {
+	var b strings.Builder
+	for i := 0; i < len(layout); {
+		if layout[i] == '[' {
+			if end := strings.IndexByte(layout[i:], ']'); end > 0 {
+				b.WriteString(layout[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+		matched := false
+		for _, tok := range dateTokens {
+			if strings.HasPrefix(layout[i:], tok) {
+				b.WriteString(formatDateToken(t, tok))
+				i += len(tok)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(layout[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+func formatDateToken(t time.Time, tok string) string {
+	switch tok {
+	case "YYYY":
+		return fmt.Sprintf("%04d", t.Year())
+	case "YY":
+		return fmt.Sprintf("%02d", t.Year()%100)
+	case "MMMM":
+		return t.Month().String()
+	case "MMM":
+		return t.Month().String()[:3]
+	case "MM":
+		return fmt.Sprintf("%02d", int(t.Month()))
+	case "M":
+		return strconv.Itoa(int(t.Month()))
+	case "DD":
+		return fmt.Sprintf("%02d", t.Day())
+	case "D":
+		return strconv.Itoa(t.Day())
+	case "dddd":
+		return t.Weekday().String()
+	case "ddd":
+		return t.Weekday().String()[:3]
+	case "HH":
+		return fmt.Sprintf("%02d", t.Hour())
+	case "H":
+		return strconv.Itoa(t.Hour())
+	case "hh":
+		return fmt.Sprintf("%02d", hour12(t))
+	case "h":
+		return strconv.Itoa(hour12(t))
+	case "mm":
+		return fmt.Sprintf("%02d", t.Minute())
+	case "m":
+		return strconv.Itoa(t.Minute())
+	case "ss":
+		return fmt.Sprintf("%02d", t.Second())
+	case "s":
+		return strconv.Itoa(t.Second())
+	case "SSS":
+		return fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond))
+	case "A":
+		return t.Format("PM")
+	case "a":
+		return t.Format("pm")
+	case "ZZ":
+		return t.Format("-07:00")
+	case "Z":
+		return t.Format("-0700")
+	case "X":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "x":
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	return tok
+}
+
+func hour12(t time.Time) int {
+	h := t.Hour() % 12
+	if h == 0 {
+		return 12
+	}
+	return h
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveWithVars_BuiltinClockVars(t *testing.T) {
+	vr := NewVariableResolver(nil)
+	fixed := time.Date(2024, 12, 31, 16, 5, 9, 123*int(time.Millisecond), time.UTC)
+	vr.now = func() time.Time { return fixed }
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"{{$timestamp}}", "1735661109"},
+		{"{{$timestampMs}}", "1735661109123"},
+		{"{{$isoTimestampUTC}}", "2024-12-31T16:05:09Z"},
+		{"{{$date}}", "2024-12-31T16:05:09+00:00"},
+		{"{{$date 'YYYY-MM-DD' 'Asia/Seoul'}}", "2025-01-01"},
+		{`{{$date "ddd, D MMM YYYY hh:mm A" "America/New_York"}}`, "Tue, 31 Dec 2024 11:05 AM"},
+		{"{{$date 'YYYY-MM-DD[T]HH:mm:ss.SSSZ' 'UTC' '-1d+2h'}}", "2024-12-30T18:05:09.123+0000"},
+		{"{{$date 'YYYY-MM' 'UTC' '+1M'}}", "2025-01"},
+		{"{{$date 'X' 'Nowhere/City'}}", "{{$date 'X' 'Nowhere/City'}}"},
+		{"{{$date 'X' 'UTC' 'soon'}}", "{{$date 'X' 'UTC' 'soon'}}"},
+		{"{{$unknown}}", "{{$unknown}}"},
+	}
+	for _, tt := range tests {
+		if got := vr.ResolveWithVars(tt.input, nil); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	// User variables take precedence over built-ins
+	if got := vr.ResolveWithVars("{{$timestamp}}", map[string]string{"$timestamp": "custom"}); got != "custom" {
+		t.Errorf("override: got %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Chaos faults injected into a request (ExecuteResult.Fault)
+const (
+	FaultLatency = "latency" // delayed, then sent
+	FaultDrop    = "drop"    // failed with a network error, not sent
+	FaultError   = "error"   // answered with a synthetic error status, not sent
+)
+
+const maxChaosLatencyMs = 60 * 1000
+
+// ChaosOptions injects faults into every request of a run, to check that a
+// flow's error handling and retry policy work. Rolls come from Seed, so a
+// seeded run fails the same requests every time.
+type ChaosOptions struct {
+	LatencyMs    int64  `json:"latencyMs"`    // added before each request is sent
+	DropPercent  int    `json:"dropPercent"`  // share of requests failed with a network error
+	ErrorPercent int    `json:"errorPercent"` // share of requests answered with ErrorStatus
+	ErrorStatus  int    `json:"errorStatus"`  // default 500
+	Seed         *int64 `json:"seed"`         // nil = random rolls
+}
+
+func (c ChaosOptions) Validate() error {
+	switch {
+	case c.LatencyMs < 0 || c.LatencyMs > maxChaosLatencyMs:
+		return fmt.Errorf("chaos.latencyMs must be between 0 and %d", maxChaosLatencyMs)
+	case c.DropPercent < 0 || c.DropPercent > 100:
+		return errors.New("chaos.dropPercent must be between 0 and 100")
+	case c.ErrorPercent < 0 || c.ErrorPercent > 100:
+		return errors.New("chaos.errorPercent must be between 0 and 100")
+	case c.ErrorStatus != 0 && (c.ErrorStatus < 400 || c.ErrorStatus > 599):
+		return errors.New("chaos.errorStatus must be a 4xx or 5xx status")
+	}
+	return nil
+}
+
+type chaosKey struct{}
+
+// chaosLayer is a run's fault injector; its rolls are shared by all the
+// run's requests, so their order decides which ones fail
+type chaosLayer struct {
+	opts ChaosOptions
+	mu   sync.Mutex
+	r    *rand.Rand
+}
+
+// WithChaos injects faults per opts into everything executed with ctx
+func WithChaos(ctx context.Context, opts ChaosOptions) context.Context {
+	seed := rand.Uint64()
+	if opts.Seed != nil {
+		seed = uint64(*opts.Seed)
+	}
+	if opts.ErrorStatus == 0 {
+		opts.ErrorStatus = http.StatusInternalServerError
+	}
+	return context.WithValue(ctx, chaosKey{}, &chaosLayer{opts: opts, r: rand.New(rand.NewPCG(seed, 0))})
+}
+
+func chaosFrom(ctx context.Context) *chaosLayer {
+	c, _ := ctx.Value(chaosKey{}).(*chaosLayer)
+	return c
+}
+
+// roll decides the next request's fault. Both rolls are always drawn so a
+// seeded run keeps its sequence when the percentages change.
+func (c *chaosLayer) roll() string {
+	c.mu.Lock()
+	drop, fail := c.r.IntN(100), c.r.IntN(100)
+	c.mu.Unlock()
+	switch {
+	case drop < c.opts.DropPercent:
+		return FaultDrop
+	case fail < c.opts.ErrorPercent:
+		return FaultError
+	case c.opts.LatencyMs > 0:
+		return FaultLatency
+	}
+	return ""
+}
+
+// injectFault applies the run's chaos options to a request about to be sent.
+// It returns true when the request must not be sent: result then holds the
+// dropped request's error or the synthetic error response.
+func injectFault(ctx context.Context, result *ExecuteResult) bool {
+	c := chaosFrom(ctx)
+	if c == nil {
+		return false
+	}
+	fault := c.roll()
+	if fault == "" {
+		return false
+	}
+	result.Fault = fault
+	if c.opts.LatencyMs > 0 {
+		select {
+		case <-time.After(time.Duration(c.opts.LatencyMs) * time.Millisecond):
+		case <-ctx.Done():
+			result.Error = ctx.Err().Error()
+			return true
+		}
+	}
+	switch fault {
+	case FaultDrop:
+		result.Error = "chaos: request dropped"
+		result.sendFailed = true
+		return true
+	case FaultError:
+		result.StatusCode = c.opts.ErrorStatus
+		result.Headers = map[string]string{"Content-Type": "application/json", "X-Relay-Chaos": FaultError}
+		result.Body = fmt.Sprintf(`{"error":"chaos: injected %d response"}`, c.opts.ErrorStatus)
+		result.BodySize = int64(len(result.Body))
+		return true
+	}
+	return false
+}
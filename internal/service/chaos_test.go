@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestChaosOptions_Validate(t *testing.T) {
+	if err := (ChaosOptions{LatencyMs: 100, DropPercent: 100, ErrorPercent: 50, ErrorStatus: 503}).Validate(); err != nil {
+		t.Errorf("valid options rejected: %v", err)
+	}
+	for _, c := range []ChaosOptions{{LatencyMs: -1}, {LatencyMs: maxChaosLatencyMs + 1}, {DropPercent: 101}, {ErrorPercent: -1}, {ErrorStatus: 200}} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("%+v accepted", c)
+		}
+	}
+}
+
+func TestChaos_SeededRollsRepeat(t *testing.T) {
+	seed := int64(7)
+	rolls := func() []string {
+		c := chaosFrom(WithChaos(context.Background(), ChaosOptions{DropPercent: 30, ErrorPercent: 30, Seed: &seed}))
+		out := make([]string, 20)
+		for i := range out {
+			out[i] = c.roll()
+		}
+		return out
+	}
+	first, second := rolls(), rolls()
+	faults := map[string]int{}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("roll %d: %q then %q", i, first[i], second[i])
+		}
+		faults[first[i]]++
+	}
+	if faults[FaultDrop] == 0 || faults[FaultError] == 0 || faults[""] == 0 {
+		t.Errorf("faults = %v", faults)
+	}
+}
+
+func TestRequestExecutor_Chaos(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	req := repository.Request{Method: "GET", Url: srv.URL}
+
+	ctx := WithChaos(context.Background(), ChaosOptions{ErrorPercent: 100, ErrorStatus: 503})
+	result, _ := re.ExecuteRequest(ctx, req, nil)
+	if result.StatusCode != 503 || result.Fault != FaultError || result.Headers["X-Relay-Chaos"] != FaultError || calls.Load() != 0 {
+		t.Errorf("synthetic error = %+v, %d calls", result, calls.Load())
+	}
+
+	// A dropped request is a network failure, so the retry policy resends it
+	ctx = WithChaos(context.Background(), ChaosOptions{DropPercent: 100})
+	retried := req
+	retried.RetryCount = 2
+	result, _ = re.ExecuteRequest(ctx, retried, nil)
+	if result.Fault != FaultDrop || result.Error != "chaos: request dropped" || result.Attempts != 3 || calls.Load() != 0 {
+		t.Errorf("dropped = %+v, %d calls", result, calls.Load())
+	}
+
+	ctx = WithChaos(context.Background(), ChaosOptions{LatencyMs: 50})
+	result, _ = re.ExecuteRequest(ctx, req, nil)
+	if result.StatusCode != 200 || result.Fault != FaultLatency || result.DurationMs < 50 || calls.Load() != 1 {
+		t.Errorf("latency = %+v, %d calls", result, calls.Load())
+	}
+
+	// Latency is cut short when the run is cancelled
+	ctx, cancel := context.WithTimeout(WithChaos(context.Background(), ChaosOptions{LatencyMs: 5000}), 20*time.Millisecond)
+	defer cancel()
+	if result, _ = re.ExecuteRequest(ctx, req, nil); result.Error == "" || calls.Load() != 1 {
+		t.Errorf("cancelled = %+v", result)
+	}
+}
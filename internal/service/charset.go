@@ -0,0 +1,90 @@
+package service
+
+import (
+	"bytes"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// charsetSniffLen is how far into an HTML/XML body a <meta> or <?xml?>
+// charset declaration is looked for, as browsers do
+const charsetSniffLen = 1024
+
+var (
+	metaCharsetRe = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-zA-Z0-9_:.+-]+)`)
+	xmlEncodingRe = regexp.MustCompile(`^<\?xml[^>]+encoding\s*=\s*["']([a-zA-Z0-9_:.+-]+)["']`)
+)
+
+// DetectCharset returns the charset declared for a text response: the
+// Content-Type charset parameter, a byte order mark, or for HTML/XML a
+// <meta> / <?xml?> declaration near the start of the body. "" when none.
+func DetectCharset(contentType string, body []byte) string {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if cs := params["charset"]; cs != "" {
+		return strings.ToLower(cs)
+	}
+	switch {
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8"
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	}
+	if mediaType != "" && !strings.Contains(mediaType, "html") && !strings.Contains(mediaType, "xml") {
+		return ""
+	}
+	head := body
+	if len(head) > charsetSniffLen {
+		head = head[:charsetSniffLen]
+	}
+	if m := xmlEncodingRe.FindSubmatch(head); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	if m := metaCharsetRe.FindSubmatch(head); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	return ""
+}
+
+// DecodeResponseText converts a text response body to UTF-8. It returns the
+// detected charset (canonical name, "" if undeclared) and whether the body
+// was transcoded. Undeclared or unknown charsets leave the body unchanged.
+func DecodeResponseText(contentType string, body []byte) (text, charset string, converted bool) {
+	declared := DetectCharset(contentType, body)
+	if declared == "" {
+		return string(body), "", false
+	}
+	enc, err := htmlindex.Get(declared)
+	if err != nil {
+		// Unknown label: report it but don't guess
+		return string(body), declared, false
+	}
+	name, _ := htmlindex.Name(enc)
+	if enc == encoding.Nop || name == "utf-8" {
+		return string(bytes.TrimPrefix(body, []byte{0xEF, 0xBB, 0xBF})), name, false
+	}
+	// Pure ASCII is identical in every ASCII-compatible charset
+	if isASCII(body) && !strings.HasPrefix(name, "utf-16") {
+		return string(body), name, false
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil || !utf8.Valid(decoded) {
+		return string(body), name, false
+	}
+	return string(decoded), name, true
+}
+
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
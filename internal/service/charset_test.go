@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/testutil"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	b, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDecodeResponseText(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+		charset     string
+		converted   bool
+	}{
+		{"euc-kr header", "text/plain; charset=EUC-KR", encode(t, korean.EUCKR, "안녕하세요"), "안녕하세요", "euc-kr", true},
+		{"shift_jis header", "application/json; charset=Shift_JIS", encode(t, japanese.ShiftJIS, `{"msg":"こんにちは"}`), `{"msg":"こんにちは"}`, "shift_jis", true},
+		{"latin-1 header", "text/html; charset=ISO-8859-1", encode(t, charmap.ISO8859_1, "café"), "café", "windows-1252", true},
+		{"html meta", "text/html", append([]byte(`<html><head><meta charset="euc-kr"></head>`), encode(t, korean.EUCKR, "한글")...), `<html><head><meta charset="euc-kr"></head>한글`, "euc-kr", true},
+		{"http-equiv meta", "", append([]byte(`<meta http-equiv="Content-Type" content="text/html; charset=shift_jis">`), encode(t, japanese.ShiftJIS, "日本")...), `<meta http-equiv="Content-Type" content="text/html; charset=shift_jis">日本`, "shift_jis", true},
+		{"xml declaration", "application/xml", append([]byte(`<?xml version="1.0" encoding="EUC-KR"?><a>`), encode(t, korean.EUCKR, "값")...), `<?xml version="1.0" encoding="EUC-KR"?><a>값`, "euc-kr", true},
+		{"utf-8 bom", "text/plain", []byte("\xEF\xBB\xBFhello"), "hello", "utf-8", false},
+		{"utf-8 declared", "application/json; charset=utf-8", []byte(`{"a":"é"}`), `{"a":"é"}`, "utf-8", false},
+		{"ascii in euc-kr", "text/plain; charset=euc-kr", []byte("plain"), "plain", "euc-kr", false},
+		{"undeclared", "application/json", []byte(`{"a":1}`), `{"a":1}`, "", false},
+		{"meta ignored for json", "application/json", []byte(`{"html":"<meta charset=euc-kr>"}`), `{"html":"<meta charset=euc-kr>"}`, "", false},
+		{"unknown charset", "text/plain; charset=x-klingon", []byte("qapla\xff"), "qapla\xff", "x-klingon", false},
+	}
+	for _, tc := range cases {
+		text, charset, converted := DecodeResponseText(tc.contentType, tc.body)
+		if text != tc.want || charset != tc.charset || converted != tc.converted {
+			t.Errorf("%s: got (%q, %q, %v), want (%q, %q, %v)", tc.name, text, charset, converted, tc.want, tc.charset, tc.converted)
+		}
+	}
+}
+
+func TestExecuteRequest_TranscodesCharset(t *testing.T) {
+	raw := encode(t, korean.EUCKR, `{"name":"홍길동"}`)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=euc-kr")
+		w.Write(raw)
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	result, err := re.ExecuteAdhoc(context.Background(), "GET", ts.URL, "", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Body != `{"name":"홍길동"}` || result.Charset != "euc-kr" || result.IsBinary {
+		t.Errorf("body %q, charset %q, binary %v", result.Body, result.Charset, result.IsBinary)
+	}
+	if result.BodyBase64 != base64.StdEncoding.EncodeToString(raw) || result.BodySize != int64(len(raw)) {
+		t.Errorf("raw bytes should be kept: base64 %q, size %d", result.BodyBase64, result.BodySize)
+	}
+	if string(result.RawBody()) != string(raw) {
+		t.Errorf("RawBody = %q", result.RawBody())
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"relay/internal/repository"
+)
+
+// CollectionRunFlows attaches setup and teardown flows to a collection: the
+// setup flow runs before the collection is run and the teardown flow after
+// it, whether or not setup or the run succeeded. Sub-collections inherit the
+// flows of their nearest ancestor that has any.
+type CollectionRunFlows struct {
+	SetupFlowID    int64 `json:"setupFlowId,omitempty"`
+	TeardownFlowID int64 `json:"teardownFlowId,omitempty"`
+	// Scheduled also wraps scheduled monitor checks of the collection's requests
+	Scheduled bool `json:"scheduled,omitempty"`
+}
+
+// ParseCollectionRunFlows decodes the run_flows column; nil means the
+// collection has none
+func ParseCollectionRunFlows(raw sql.NullString) *CollectionRunFlows {
+	if !raw.Valid || raw.String == "" {
+		return nil
+	}
+	var f CollectionRunFlows
+	if err := json.Unmarshal([]byte(raw.String), &f); err != nil || (f.SetupFlowID == 0 && f.TeardownFlowID == 0) {
+		return nil
+	}
+	return &f
+}
+
+// CollectionFlowRun summarizes a setup or teardown flow run
+type CollectionFlowRun struct {
+	FlowID  int64  `json:"flowId"`
+	RunID   string `json:"runId,omitempty"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	// Outputs are the flow's declared outputs, e.g. IDs seeded by a setup flow
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+// CollectionRunHooks runs the setup and teardown flows around collection runs
+type CollectionRunHooks struct {
+	queries *repository.Queries
+	flows   *FlowRunner
+}
+
+func NewCollectionRunHooks(queries *repository.Queries, flows *FlowRunner) *CollectionRunHooks {
+	return &CollectionRunHooks{queries: queries, flows: flows}
+}
+
+// Lookup returns the run flows of the collection or its nearest ancestor
+func (h *CollectionRunHooks) Lookup(ctx context.Context, collectionID int64) *CollectionRunFlows {
+	if h == nil {
+		return nil
+	}
+	for depth := 0; collectionID > 0 && depth < 64; depth++ {
+		c, err := h.queries.GetCollection(ctx, collectionID)
+		if err != nil {
+			return nil
+		}
+		if f := ParseCollectionRunFlows(c.RunFlows); f != nil {
+			return f
+		}
+		if !c.ParentID.Valid {
+			return nil
+		}
+		collectionID = c.ParentID.Int64
+	}
+	return nil
+}
+
+// Setup runs the setup flow, if any. A failed setup means the collection run
+// should be skipped; Teardown still applies.
+func (h *CollectionRunHooks) Setup(ctx context.Context, f *CollectionRunFlows) *CollectionFlowRun {
+	if f == nil || f.SetupFlowID == 0 {
+		return nil
+	}
+	return h.run(ctx, f.SetupFlowID)
+}
+
+// Teardown runs the teardown flow, if any
+func (h *CollectionRunHooks) Teardown(ctx context.Context, f *CollectionRunFlows) *CollectionFlowRun {
+	if f == nil || f.TeardownFlowID == 0 {
+		return nil
+	}
+	return h.run(ctx, f.TeardownFlowID)
+}
+
+func (h *CollectionRunHooks) run(ctx context.Context, flowID int64) *CollectionFlowRun {
+	run := &CollectionFlowRun{FlowID: flowID}
+	result, err := h.flows.Run(ctx, flowID, nil)
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+	run.RunID, run.Success, run.Error, run.Outputs = result.RunID, result.Success, result.Error, result.Outputs
+	if !run.Success && run.Error == "" {
+		run.Error = "flow " + result.FlowName + " failed"
+	}
+	return run
+}
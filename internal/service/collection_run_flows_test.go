@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestCollectionRunFlows(t *testing.T) {
+	var mu sync.Mutex
+	var hits []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, r.URL.Path)
+		mu.Unlock()
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer ts.Close()
+	takeHits := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		s := strings.Join(hits, ",")
+		hits = nil
+		return s
+	}
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	hooks := NewCollectionRunHooks(q, NewFlowRunner(q, re, vr))
+	checker := NewContractDriftChecker(q, re)
+	checker.SetRunHooks(hooks)
+	monitors := NewMonitorRunner(q, re, nil)
+	monitors.SetRunHooks(hooks)
+	ctx := context.Background()
+
+	parent, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "API", WorkspaceID: 1})
+	child, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Items", ParentID: sql.NullInt64{Int64: parent.ID, Valid: true}, WorkspaceID: 1})
+	req, _ := q.CreateRequest(ctx, repository.CreateRequestParams{
+		CollectionID: sql.NullInt64{Int64: child.ID, Valid: true},
+		Name:         "items",
+		Method:       "GET",
+		Url:          ts.URL + "/items",
+		WorkspaceID:  1,
+	})
+	setup := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{{Name: "seed", Method: "POST", Url: ts.URL + "/seed"}})
+	teardown := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{{Name: "cleanup", Method: "DELETE", Url: ts.URL + "/cleanup"}})
+	setRunFlows := func(f CollectionRunFlows) {
+		raw, _ := json.Marshal(f)
+		if _, err := q.UpdateCollectionRunFlows(ctx, repository.UpdateCollectionRunFlowsParams{
+			RunFlows: sql.NullString{String: string(raw), Valid: true},
+			ID:       parent.ID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setRunFlows(CollectionRunFlows{SetupFlowID: setup, TeardownFlowID: teardown})
+
+	// The sub-collection inherits its parent's flows
+	report, err := checker.CheckCollection(ctx, child.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := takeHits(); got != "/seed,/items,/cleanup" {
+		t.Errorf("drift check order = %s", got)
+	}
+	if report.Setup == nil || !report.Setup.Success || report.Setup.RunID == "" || report.Teardown == nil || !report.Teardown.Success {
+		t.Errorf("setup = %+v, teardown = %+v", report.Setup, report.Teardown)
+	}
+
+	// Scheduled checks only run the flows when the collection opts in
+	mon, err := q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: req.ID, IntervalSeconds: 60, Enabled: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := monitors.check(ctx, mon, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := takeHits(); got != "/items" {
+		t.Errorf("monitor without scheduled = %s", got)
+	}
+	setRunFlows(CollectionRunFlows{SetupFlowID: setup, TeardownFlowID: teardown, Scheduled: true})
+	if _, err := monitors.check(ctx, mon, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := takeHits(); got != "/seed,/items,/cleanup" {
+		t.Errorf("scheduled monitor order = %s", got)
+	}
+	// Manual checks are left alone
+	if _, err := monitors.Check(ctx, mon); err != nil {
+		t.Fatal(err)
+	}
+	if got := takeHits(); got != "/items" {
+		t.Errorf("manual monitor check = %s", got)
+	}
+
+	// A failed setup skips the run; teardown still cleans up
+	broken := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{{Name: "no url", Method: "GET"}})
+	setRunFlows(CollectionRunFlows{SetupFlowID: broken, TeardownFlowID: teardown, Scheduled: true})
+	report, _ = checker.CheckCollection(ctx, parent.ID)
+	if got := takeHits(); got != "/cleanup" || report.Setup.Success || len(report.Requests) != 0 {
+		t.Errorf("failed setup: hits %s, report %+v", got, report)
+	}
+	check, err := monitors.check(ctx, mon, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Success != 0 || !strings.HasPrefix(check.Error, "Setup flow failed") {
+		t.Errorf("monitor check with failed setup = %+v", check)
+	}
+}
@@ -0,0 +1,478 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Condition expression grammar (variables are substituted before parsing):
+//
+//	expr    := or
+//	or      := and ("||" and)*
+//	and     := unary ("&&" unary)*
+//	unary   := "!" unary | compare
+//	compare := operand [cmpOp operand | ("in" | "notIn") list]
+//	operand := "(" expr ")" | value+
+//	list    := "[" [value ("," value)*] "]"
+//	cmpOp   := == != > >= < <= contains startsWith endsWith
+//
+// Values are quoted strings ('..' or "..", with backslash escapes) or bare
+// words; consecutive bare words form one value ("hello world"). Ordering
+// operators compare numerically when both sides are numbers, chronologically
+// when both sides are dates, and lexically otherwise. An unresolved {{var}}
+// evaluates to the empty string; null equals "" and "null".
+
+type condTokenKind int
+
+const (
+	condTokEOF condTokenKind = iota
+	condTokWord
+	condTokString
+	condTokOp
+	condTokLParen
+	condTokRParen
+	condTokLBracket
+	condTokRBracket
+	condTokComma
+)
+
+type condToken struct {
+	kind condTokenKind
+	text string
+	pos  int
+	end  int
+}
+
+var condKeywordOps = map[string]bool{
+	"contains": true, "startsWith": true, "endsWith": true, "in": true, "notIn": true,
+}
+
+// ConditionError describes a parse error at a byte offset in the expression
+type ConditionError struct {
+	Pos int    `json:"pos"`
+	Msg string `json:"message"`
+}
+
+func (e *ConditionError) Error() string {
+	return fmt.Sprintf("condition: %s at position %d", e.Msg, e.Pos)
+}
+
+func tokenizeCondition(expr string) ([]condToken, error) {
+	var tokens []condToken
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			i++
+			closed := false
+			for i < len(expr) {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					switch expr[i+1] {
+					case 'n':
+						b.WriteByte('\n')
+					case 't':
+						b.WriteByte('\t')
+					default:
+						b.WriteByte(expr[i+1])
+					}
+					i += 2
+					continue
+				}
+				if expr[i] == c {
+					closed = true
+					i++
+					break
+				}
+				b.WriteByte(expr[i])
+				i++
+			}
+			if !closed {
+				return nil, &ConditionError{Pos: start, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, condToken{kind: condTokString, text: b.String(), pos: start, end: i})
+		case c == '(':
+			tokens = append(tokens, condToken{kind: condTokLParen, text: "(", pos: i, end: i + 1})
+			i++
+		case c == ')':
+			tokens = append(tokens, condToken{kind: condTokRParen, text: ")", pos: i, end: i + 1})
+			i++
+		case c == '[':
+			tokens = append(tokens, condToken{kind: condTokLBracket, text: "[", pos: i, end: i + 1})
+			i++
+		case c == ']':
+			tokens = append(tokens, condToken{kind: condTokRBracket, text: "]", pos: i, end: i + 1})
+			i++
+		case c == ',':
+			tokens = append(tokens, condToken{kind: condTokComma, text: ",", pos: i, end: i + 1})
+			i++
+		default:
+			if op := matchCondOp(expr[i:]); op != "" {
+				tokens = append(tokens, condToken{kind: condTokOp, text: op, pos: i, end: i + len(op)})
+				i += len(op)
+				break
+			}
+			start := i
+			for i < len(expr) && !isCondDelimiter(expr, i) {
+				if strings.HasPrefix(expr[i:], "{{") {
+					// Keep unresolved {{var}} placeholders as a single word
+					if end := strings.Index(expr[i:], "}}"); end >= 0 {
+						i += end + 2
+						continue
+					}
+				}
+				i++
+			}
+			word := expr[start:i]
+			kind := condTokWord
+			if condKeywordOps[word] {
+				kind = condTokOp
+			}
+			tokens = append(tokens, condToken{kind: kind, text: word, pos: start, end: i})
+		}
+	}
+	tokens = append(tokens, condToken{kind: condTokEOF, pos: len(expr), end: len(expr)})
+	return tokens, nil
+}
+
+func matchCondOp(s string) string {
+	for _, op := range []string{"==", "!=", ">=", "<=", "&&", "||", ">", "<", "!"} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func isCondDelimiter(expr string, i int) bool {
+	switch expr[i] {
+	case ' ', '\t', '\n', '\r', '(', ')', '[', ']', ',', '"', '\'':
+		return true
+	case '=', '<', '>', '&', '|':
+		return matchCondOp(expr[i:]) != ""
+	case '!':
+		return strings.HasPrefix(expr[i:], "!=")
+	}
+	return false
+}
+
+// condValue is an operand value; null marks the bare null keyword
+type condValue struct {
+	s    string
+	null bool
+}
+
+type condParser struct {
+	expr   string
+	tokens []condToken
+	pos    int
+}
+
+func (p *condParser) peek() condToken { return p.tokens[p.pos] }
+
+func (p *condParser) next() condToken {
+	t := p.tokens[p.pos]
+	if t.kind != condTokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *condParser) errorf(t condToken, format string, args ...interface{}) error {
+	return &ConditionError{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// EvaluateCondition parses and evaluates a condition expression.
+// An empty expression is true.
+func EvaluateCondition(expr string) (bool, error) {
+	if strings.TrimSpace(expr) == "" {
+		return true, nil
+	}
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return false, err
+	}
+	p := &condParser{expr: expr, tokens: tokens}
+	v, err := p.parseOr()
+	if err != nil {
+		return false, err
+	}
+	if t := p.peek(); t.kind != condTokEOF {
+		return false, p.errorf(t, "unexpected %q", t.text)
+	}
+	return v, nil
+}
+
+// ValidateCondition reports syntax errors without caring about the result
+func ValidateCondition(expr string) error {
+	_, err := EvaluateCondition(expr)
+	return err
+}
+
+func (p *condParser) parseOr() (bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return false, err
+	}
+	for p.peek().kind == condTokOp && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return false, err
+		}
+		left = left || right
+	}
+	return left, nil
+}
+
+func (p *condParser) parseAnd() (bool, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return false, err
+	}
+	for p.peek().kind == condTokOp && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return false, err
+		}
+		left = left && right
+	}
+	return left, nil
+}
+
+func (p *condParser) parseUnary() (bool, error) {
+	if t := p.peek(); t.kind == condTokOp && t.text == "!" {
+		p.next()
+		v, err := p.parseUnary()
+		return !v, err
+	}
+	return p.parseCompare()
+}
+
+func (p *condParser) parseCompare() (bool, error) {
+	// Parenthesized sub-expression
+	if p.peek().kind == condTokLParen {
+		p.next()
+		v, err := p.parseOr()
+		if err != nil {
+			return false, err
+		}
+		if t := p.next(); t.kind != condTokRParen {
+			return false, p.errorf(t, "expected \")\"")
+		}
+		return v, nil
+	}
+
+	left, err := p.parseValue()
+	if err != nil {
+		return false, err
+	}
+
+	t := p.peek()
+	if t.kind != condTokOp {
+		return condTruthy(left), nil
+	}
+	switch t.text {
+	case "==", "!=", ">", ">=", "<", "<=", "contains", "startsWith", "endsWith":
+		p.next()
+		right, err := p.parseValue()
+		if err != nil {
+			return false, err
+		}
+		return compareCondValues(t.text, left, right), nil
+	case "in", "notIn":
+		p.next()
+		list, err := p.parseList()
+		if err != nil {
+			return false, err
+		}
+		found := false
+		for _, item := range list {
+			if condEqual(left, item) {
+				found = true
+				break
+			}
+		}
+		if t.text == "notIn" {
+			return !found, nil
+		}
+		return found, nil
+	}
+	return condTruthy(left), nil
+}
+
+func (p *condParser) parseValue() (condValue, error) {
+	t := p.peek()
+	switch t.kind {
+	case condTokString:
+		p.next()
+		return condValue{s: t.text}, nil
+	case condTokWord:
+		// Join consecutive bare words, keeping the original spacing
+		start := t.pos
+		end := t.end
+		p.next()
+		for p.peek().kind == condTokWord {
+			end = p.next().end
+		}
+		raw := p.expr[start:end]
+		if raw == "null" {
+			return condValue{null: true}, nil
+		}
+		if strings.HasPrefix(raw, "{{") && strings.HasSuffix(raw, "}}") && !strings.Contains(raw[2:], "{{") {
+			return condValue{}, nil // unresolved variable
+		}
+		return condValue{s: raw}, nil
+	case condTokEOF:
+		return condValue{}, p.errorf(t, "unexpected end of expression")
+	}
+	return condValue{}, p.errorf(t, "unexpected %q", t.text)
+}
+
+func (p *condParser) parseList() ([]condValue, error) {
+	open := p.next()
+	if open.kind != condTokLBracket && open.kind != condTokLParen {
+		return nil, p.errorf(open, "expected list")
+	}
+	closeKind := condTokRBracket
+	if open.kind == condTokLParen {
+		closeKind = condTokRParen
+	}
+	var items []condValue
+	if p.peek().kind == closeKind {
+		p.next()
+		return items, nil
+	}
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+		t := p.next()
+		if t.kind == closeKind {
+			return items, nil
+		}
+		if t.kind != condTokComma {
+			return nil, p.errorf(t, "expected \",\" or end of list")
+		}
+	}
+}
+
+func condTruthy(v condValue) bool {
+	if v.null {
+		return false
+	}
+	s := strings.TrimSpace(v.s)
+	return s != "" && s != "0" && s != "false" && s != "null"
+}
+
+func condEqual(a, b condValue) bool {
+	if a.null || b.null {
+		other := a
+		if a.null {
+			other = b
+		}
+		return other.null || other.s == "" || other.s == "null"
+	}
+	if af, bf, ok := condNumbers(a.s, b.s); ok {
+		return af == bf
+	}
+	if at, bt, ok := condDates(a.s, b.s); ok {
+		return at.Equal(bt)
+	}
+	return a.s == b.s
+}
+
+func compareCondValues(op string, a, b condValue) bool {
+	switch op {
+	case "==":
+		return condEqual(a, b)
+	case "!=":
+		return !condEqual(a, b)
+	case "contains":
+		return strings.Contains(a.s, b.s)
+	case "startsWith":
+		return strings.HasPrefix(a.s, b.s)
+	case "endsWith":
+		return strings.HasSuffix(a.s, b.s)
+	}
+
+	var cmp int
+	if af, bf, ok := condNumbers(a.s, b.s); ok {
+		cmp = compareOrdered(af, bf)
+	} else if at, bt, ok := condDates(a.s, b.s); ok {
+		cmp = at.Compare(bt)
+	} else {
+		cmp = strings.Compare(a.s, b.s)
+	}
+	switch op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func condNumbers(a, b string) (float64, float64, bool) {
+	af, err := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	bf, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return af, bf, true
+}
+
+var condDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseCondDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range condDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func condDates(a, b string) (time.Time, time.Time, bool) {
+	at, ok := parseCondDate(a)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	bt, ok := parseCondDate(b)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	return at, bt, true
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`status == 'a == b'`, false},
+		{`'a == b' == "a == b"`, true},
+		{`'it\'s' == "it's"`, true},
+		{`msg contains '&&'`, false},
+		{`'x && y' contains '&&'`, true},
+		{`(1 > 2 || 3 > 2) && !(0)`, true},
+		{`!(1 > 2) && (2 >= 2)`, true},
+		{`10 > 9`, true},
+		{`10 > 9.5`, true},
+		{`"10" > "9"`, true},
+		{`1.0 == 1`, true},
+		{`abc < abd`, true},
+		{`2024-03-01 > 2024-02-29`, true},
+		{`2024-03-01T10:00:00+09:00 == 2024-03-01T01:00:00Z`, true},
+		{`'2024-01-01 12:00:00' < 2024-01-02`, true},
+		{`dev in ['dev', 'qa']`, true},
+		{`prod in ['dev', 'qa']`, false},
+		{`prod notIn [dev, qa]`, true},
+		{`200 in [200, 201, 204]`, true},
+		{`hello world contains world`, true},
+		{`hello world startsWith hello`, true},
+		{`file.json endsWith .json`, true},
+		{`{{missing}}`, false},
+		{`!{{missing}}`, true},
+		{`{{missing}} == null`, true},
+		{`value != null`, true},
+		{`null == 'null'`, true},
+	}
+	for _, tt := range tests {
+		got, err := EvaluateCondition(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateCondition_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+	}{
+		{`(1 > 2`, 6},
+		{`status == 'ok`, 10},
+		{`a ==`, 4},
+		{`a in [1, 2`, 10},
+		{`a > 1 )`, 6},
+	}
+	for _, tt := range tests {
+		_, err := EvaluateCondition(tt.expr)
+		var condErr *ConditionError
+		if !errors.As(err, &condErr) {
+			t.Errorf("%s: expected ConditionError, got %v", tt.expr, err)
+			continue
+		}
+		if condErr.Pos != tt.pos {
+			t.Errorf("%s: error position got %d, want %d (%v)", tt.expr, condErr.Pos, tt.pos, err)
+		}
+	}
+}
+
+func TestEvaluateCondition_StepConditionBareVariable(t *testing.T) {
+	fr := NewFlowRunner(nil, nil, NewVariableResolver(nil))
+
+	met, err := fr.evaluateCondition(context.Background(), "{{token}}", map[string]string{"token": "abc=="})
+	if err != nil || !met {
+		t.Errorf("bare variable with operator chars: met=%v err=%v", met, err)
+	}
+	met, _ = fr.evaluateCondition(context.Background(), "{{code}} == 200 && {{env}} in ['dev', 'qa']", map[string]string{"code": "200", "env": "qa"})
+	if !met {
+		t.Error("expected expression condition to be met")
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// The connection factory: HTTP requests and WebSocket relays reach their
+// targets through clients built here, so both pick the same proxy and TLS
+// settings.
+
+// CreateHTTPClient creates an HTTP client with optional proxy configuration
+// and the workspace's TLS settings, whose files are read from fs.
+// Shared by RequestExecutor and WebSocketRelay.
+func CreateHTTPClient(ctx context.Context, queries *repository.Queries, fs *FileStorage, proxyID sql.NullInt64) (*http.Client, error) {
+	transport := newTransport()
+	if proxyURL, ok := selectProxy(ctx, queries, proxyID); ok {
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	rt, err := applyTLSSettings(ctx, queries, fs, transport)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: rt,
+		Timeout:   60 * time.Second,
+	}, nil
+}
+
+// CreateWebSocketClient creates the client a WebSocket handshake goes out
+// on, with the proxy and TLS settings of CreateHTTPClient. HTTP proxies are
+// always asked for a CONNECT tunnel, as most drop the Upgrade header of a
+// plain ws:// request forwarded to them; an HTTPS proxy's certificate is
+// checked like a target's. The timeout covers the handshake.
+func CreateWebSocketClient(ctx context.Context, queries *repository.Queries, fs *FileStorage, proxyID sql.NullInt64) (*http.Client, error) {
+	transport := newTransport()
+	if proxyURL, ok := selectProxy(ctx, queries, proxyID); ok {
+		switch proxyURL.Scheme {
+		case "http", "https":
+			transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialTunnel(ctx, proxyURL, transport.TLSClientConfig, addr)
+			}
+		default:
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	rt, err := applyTLSSettings(ctx, queries, fs, transport)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: rt,
+		Timeout:   60 * time.Second,
+	}, nil
+}
+
+// NetworkInfo is the network path an execution actually took, recorded on
+// its result and history entry for auditing
+type NetworkInfo struct {
+	ProxyURL   string `json:"proxyUrl,omitempty"`   // credentials redacted; empty for a direct connection
+	TLSVerify  bool   `json:"tlsVerify"`            // server certificate checked
+	ClientCert string `json:"clientCert,omitempty"` // host pattern of the client certificate presented
+	Protocol   string `json:"protocol,omitempty"`   // negotiated, e.g. HTTP/1.1 or HTTP/2.0
+	TLSVersion string `json:"tlsVersion,omitempty"` // empty for plain HTTP
+}
+
+// networkInfo describes how req went out through rt, a client transport from
+// CreateHTTPClient. resp is the final response, nil when none arrived.
+func networkInfo(req *http.Request, rt http.RoundTripper, resp *http.Response) *NetworkInfo {
+	transport := httpTransport(rt)
+	if transport == nil {
+		return nil
+	}
+	if resp != nil && resp.Request != nil {
+		// After redirects the final hop is what was negotiated
+		req = resp.Request
+	}
+	info := &NetworkInfo{TLSVerify: !transport.TLSClientConfig.InsecureSkipVerify}
+	if transport.Proxy != nil {
+		if proxyURL, err := transport.Proxy(req); err == nil && proxyURL != nil {
+			info.ProxyURL = proxyURL.Redacted()
+		}
+	}
+	if t, ok := rt.(*clientCertTransport); ok {
+		if i := t.settings.clientCertFor(req.URL.Host); i >= 0 {
+			info.ClientCert = t.settings.ClientCerts[i].Host
+		}
+	}
+	if resp != nil {
+		info.Protocol = resp.Proto
+		if resp.TLS != nil {
+			info.TLSVersion = tls.VersionName(resp.TLS.Version)
+		}
+	}
+	return info
+}
+
+// newTransport returns a transport that accepts any server certificate
+// until applyTLSSettings applies the workspace's settings
+func newTransport() *http.Transport {
+	return &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+}
+
+// selectProxy returns the proxy a request with the given setting goes through
+func selectProxy(ctx context.Context, queries *repository.Queries, proxyID sql.NullInt64) (*url.URL, bool) {
+	var proxy repository.Proxy
+	var err error
+	switch {
+	case !proxyID.Valid:
+		// NULL → inherit the workspace's proxy chain upstream, else the global active proxy
+		if upstream, ok := upstreamProxy(ctx, queries); ok {
+			return upstream, true
+		}
+		proxy, err = queries.GetActiveProxy(ctx, middleware.GetWorkspaceID(ctx))
+	case proxyID.Int64 > 0:
+		// > 0 → use specific proxy
+		proxy, err = queries.GetProxy(ctx, proxyID.Int64)
+	default:
+		// 0 → no proxy (direct connection)
+		return nil, false
+	}
+	if err != nil || proxy.Url == "" {
+		return nil, false
+	}
+	proxyURL, err := url.Parse(proxy.Url)
+	if err != nil {
+		return nil, false
+	}
+	return proxyURL, true
+}
+
+// dialTunnel opens a connection to addr through an HTTP proxy's CONNECT method.
+// An HTTPS proxy is reached with tlsConfig, the workspace's TLS settings.
+func dialTunnel(ctx context.Context, proxyURL *url.URL, tlsConfig *tls.Config, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		cfg := tlsConfig.Clone()
+		cfg.ServerName = proxyURL.Hostname()
+		conn = tls.Client(conn, cfg)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(user.Username()+":"+password)))
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused tunnel to %s: %s", addr, resp.Status)
+	}
+	return conn, nil
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"relay/internal/repository"
+)
+
+// Drift change kinds
+const (
+	DriftAdded       = "added"
+	DriftRemoved     = "removed"
+	DriftTypeChanged = "typeChanged"
+)
+
+// Per-request drift check outcomes
+const (
+	DriftStatusOK         = "ok"
+	DriftStatusDrift      = "drift"
+	DriftStatusNoBaseline = "no-baseline" // the response was saved as the baseline
+	DriftStatusError      = "error"
+	DriftStatusSkipped    = "skipped"
+)
+
+// DriftChange is one difference between two JSON response shapes
+type DriftChange struct {
+	Path   string `json:"path"` // e.g. $.items[].id
+	Kind   string `json:"kind"`
+	Before string `json:"before,omitempty"` // JSON type(s) in the baseline
+	After  string `json:"after,omitempty"`  // JSON type(s) in the live response
+}
+
+type jsonShapeNode struct {
+	parent string
+	types  map[string]bool
+}
+
+// jsonShape maps JSONPath-like paths to the types observed there. Array
+// elements share the path "<array>[]", so all elements are merged.
+type jsonShape map[string]*jsonShapeNode
+
+func inferJSONShape(v interface{}) jsonShape {
+	shape := make(jsonShape)
+	shape.walk("$", "", v)
+	return shape
+}
+
+func (s jsonShape) walk(path, parent string, v interface{}) {
+	node, ok := s[path]
+	if !ok {
+		node = &jsonShapeNode{parent: parent, types: make(map[string]bool)}
+		s[path] = node
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		node.types["object"] = true
+		for k, child := range val {
+			s.walk(path+"."+k, path, child)
+		}
+	case []interface{}:
+		node.types["array"] = true
+		for _, child := range val {
+			s.walk(path+"[]", path, child)
+		}
+	case string:
+		node.types["string"] = true
+	case float64:
+		node.types["number"] = true
+	case bool:
+		node.types["boolean"] = true
+	case nil:
+		node.types["null"] = true
+	}
+}
+
+// elementsUnknown reports whether path is an array whose elements were never
+// observed (always empty), so nothing can be said about their fields
+func (s jsonShape) elementsUnknown(path string) bool {
+	n, ok := s[path]
+	if !ok || !n.types["array"] {
+		return false
+	}
+	_, seen := s[path+"[]"]
+	return !seen
+}
+
+func (n *jsonShapeNode) typeNames() string {
+	names := make([]string, 0, len(n.types))
+	for t := range n.types {
+		if t != "null" {
+			names = append(names, t)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// CompareJSONShapes reports fields that appeared, disappeared or changed type
+// between two JSON documents. Null is treated as compatible with any type, and
+// only the top-most added/removed path of a subtree is reported.
+func CompareJSONShapes(baseline, current string) ([]DriftChange, error) {
+	var before, after interface{}
+	if err := json.Unmarshal([]byte(baseline), &before); err != nil {
+		return nil, fmt.Errorf("baseline is not JSON: %w", err)
+	}
+	if err := json.Unmarshal([]byte(current), &after); err != nil {
+		return nil, fmt.Errorf("response is not JSON: %w", err)
+	}
+	old, cur := inferJSONShape(before), inferJSONShape(after)
+
+	var changes []DriftChange
+	for path, n := range old {
+		if _, ok := cur[path]; ok {
+			continue
+		}
+		if _, ok := cur[n.parent]; n.parent != "" && !ok {
+			continue // reported at the parent
+		}
+		if cur.elementsUnknown(n.parent) {
+			continue
+		}
+		changes = append(changes, DriftChange{Path: path, Kind: DriftRemoved, Before: n.typeNames()})
+	}
+	for path, n := range cur {
+		o, ok := old[path]
+		if !ok {
+			if _, ok := old[n.parent]; n.parent != "" && !ok {
+				continue // reported at the parent
+			}
+			if old.elementsUnknown(n.parent) {
+				continue
+			}
+			changes = append(changes, DriftChange{Path: path, Kind: DriftAdded, After: n.typeNames()})
+			continue
+		}
+		b, a := o.typeNames(), n.typeNames()
+		if b != "" && a != "" && b != a {
+			changes = append(changes, DriftChange{Path: path, Kind: DriftTypeChanged, Before: b, After: a})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// RequestDrift is the drift check outcome for one saved request
+type RequestDrift struct {
+	RequestID         int64           `json:"requestId"`
+	Name              string          `json:"name"`
+	Method            string          `json:"method"`
+	URL               string          `json:"url"`
+	Status            string          `json:"status"`
+	StatusCode        int             `json:"statusCode,omitempty"`
+	BaselineHistoryID int64           `json:"baselineHistoryId,omitempty"` // history entry the saved baseline came from
+	Changes           []DriftChange   `json:"changes,omitempty"`
+	Spec              *SpecValidation `json:"spec,omitempty"` // when an OpenAPI spec of the workspace covers the request
+	Error             string          `json:"error,omitempty"`
+}
+
+// DriftReport summarises a contract drift check of a collection
+type DriftReport struct {
+	CollectionID int64          `json:"collectionId"`
+	Collection   string         `json:"collection"`
+	CheckedAt    string         `json:"checkedAt"`
+	Drifted      bool           `json:"drifted"`
+	Requests     []RequestDrift `json:"requests"`
+	// Setup and Teardown are the collection's run flows; requests are
+	// skipped when setup fails
+	Setup        *CollectionFlowRun `json:"setup,omitempty"`
+	Teardown     *CollectionFlowRun `json:"teardown,omitempty"`
+	WebhookError string             `json:"webhookError,omitempty"`
+	WebhookJobID int64              `json:"webhookJobId,omitempty"` // queued delivery, see /api/jobs
+}
+
+var ErrNoDriftBaseline = errors.New("no successful JSON response to use as the baseline")
+
+// ContractDriftChecker runs a collection against the live API and compares
+// each JSON response with the request's saved baseline example and with the
+// workspace's OpenAPI specs. A request's first successful JSON response is
+// saved as its baseline; later responses never move it, only SaveBaseline
+// (accepting a change) does.
+type ContractDriftChecker struct {
+	queries  *repository.Queries
+	executor *RequestExecutor
+	client   *http.Client        // webhook delivery
+	runHooks *CollectionRunHooks // optional; setup/teardown flows
+	jobs     *JobQueue           // optional; webhooks are delivered inline without it
+}
+
+func NewContractDriftChecker(queries *repository.Queries, executor *RequestExecutor) *ContractDriftChecker {
+	return &ContractDriftChecker{
+		queries:  queries,
+		executor: executor,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetRunHooks runs the collection's setup and teardown flows around checks
+func (c *ContractDriftChecker) SetRunHooks(hooks *CollectionRunHooks) {
+	c.runHooks = hooks
+}
+
+// SetJobQueue queues drift alerts so they are retried if the receiver is down
+func (c *ContractDriftChecker) SetJobQueue(jobs *JobQueue) {
+	c.jobs = jobs
+}
+
+// driftBaselineDepth is how many history entries are searched for a JSON response
+const driftBaselineDepth = 20
+
+// CheckCollection checks every HTTP request in the collection and its
+// sub-collections, running the collection's setup and teardown flows
+func (c *ContractDriftChecker) CheckCollection(ctx context.Context, collectionID int64) (*DriftReport, error) {
+	return c.checkCollection(ctx, collectionID, false)
+}
+
+// checkCollection runs the check; scheduled checks only run the setup and
+// teardown flows of collections that opt in (CollectionRunFlows.Scheduled)
+func (c *ContractDriftChecker) checkCollection(ctx context.Context, collectionID int64, scheduled bool) (*DriftReport, error) {
+	coll, err := c.queries.GetCollection(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	requests, err := c.collectRequests(ctx, collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &DriftReport{
+		CollectionID: coll.ID,
+		Collection:   coll.Name,
+		CheckedAt:    time.Now().UTC().Format(time.RFC3339),
+		Requests:     []RequestDrift{},
+	}
+	flows := c.runHooks.Lookup(ctx, collectionID)
+	if scheduled && flows != nil && !flows.Scheduled {
+		flows = nil
+	}
+	report.Setup = c.runHooks.Setup(ctx, flows)
+	if report.Setup == nil || report.Setup.Success {
+		for _, req := range requests {
+			rd := c.checkRequest(ctx, req)
+			if rd.Status == DriftStatusDrift {
+				report.Drifted = true
+			}
+			report.Requests = append(report.Requests, rd)
+		}
+	}
+	report.Teardown = c.runHooks.Teardown(ctx, flows)
+	return report, nil
+}
+
+func (c *ContractDriftChecker) collectRequests(ctx context.Context, collectionID int64) ([]repository.Request, error) {
+	parent := sql.NullInt64{Int64: collectionID, Valid: true}
+	all, err := c.queries.ListRequestsByCollection(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	// Archived requests are left out of collection runs
+	var requests []repository.Request
+	for _, req := range all {
+		if !req.ArchivedAt.Valid {
+			requests = append(requests, req)
+		}
+	}
+	children, err := c.queries.ListChildCollections(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		sub, err := c.collectRequests(ctx, child.ID)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, sub...)
+	}
+	return requests, nil
+}
+
+func (c *ContractDriftChecker) checkRequest(ctx context.Context, req repository.Request) RequestDrift {
+	rd := RequestDrift{RequestID: req.ID, Name: req.Name, Method: req.Method, URL: req.Url}
+	if req.Method == "WS" {
+		rd.Status = DriftStatusSkipped
+		return rd
+	}
+
+	baseline, err := c.queries.GetDriftBaseline(ctx, req.ID)
+	hasBaseline := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		rd.Status, rd.Error = DriftStatusError, err.Error()
+		return rd
+	}
+
+	result, err := c.executor.ExecuteRequest(ctx, req, nil)
+	if err != nil {
+		rd.Status, rd.Error = DriftStatusError, err.Error()
+		return rd
+	}
+	rd.StatusCode = result.StatusCode
+	if result.Error != "" {
+		rd.Status, rd.Error = DriftStatusError, result.Error
+		return rd
+	}
+	if result.StatusCode < 200 || result.StatusCode >= 300 {
+		rd.Status, rd.Error = DriftStatusError, fmt.Sprintf("unexpected status %d", result.StatusCode)
+		return rd
+	}
+	if spec := ValidateAgainstSpecs(ctx, c.queries, req.Method, result); spec.Skipped == "" {
+		rd.Spec = spec
+	}
+
+	body := result.Body
+	if result.OriginalBody != "" {
+		body = result.OriginalBody // compare the raw body, as stored in history
+	}
+	if hasBaseline {
+		rd.BaselineHistoryID = baseline.HistoryID.Int64
+		changes, err := CompareJSONShapes(baseline.ResponseBody, body)
+		if err != nil {
+			rd.Status, rd.Error = DriftStatusError, err.Error()
+			return rd
+		}
+		rd.Changes = changes
+		rd.Status = DriftStatusOK
+	} else {
+		if !json.Valid([]byte(body)) {
+			rd.Status, rd.Error = DriftStatusError, "response is not JSON"
+			return rd
+		}
+		if _, err := c.queries.SaveDriftBaseline(ctx, repository.SaveDriftBaselineParams{
+			RequestID:    req.ID,
+			HistoryID:    sql.NullInt64{Int64: result.HistoryID, Valid: result.HistoryID != 0},
+			ResponseBody: body,
+		}); err != nil {
+			rd.Status, rd.Error = DriftStatusError, err.Error()
+			return rd
+		}
+		rd.Status = DriftStatusNoBaseline
+	}
+	if len(rd.Changes) > 0 || (rd.Spec != nil && !rd.Spec.Passed) {
+		rd.Status = DriftStatusDrift
+	}
+	return rd
+}
+
+// SaveBaseline makes a history entry of the request its drift baseline, e.g.
+// to accept an intended API change. With historyID 0 the most recent
+// successful JSON response is used.
+func (c *ContractDriftChecker) SaveBaseline(ctx context.Context, requestID, historyID int64) (repository.DriftBaseline, error) {
+	var entry repository.RequestHistory
+	if historyID == 0 {
+		latest, ok := LatestJSONResponse(ctx, c.queries, requestID)
+		if !ok {
+			return repository.DriftBaseline{}, ErrNoDriftBaseline
+		}
+		entry = latest
+	} else {
+		h, err := c.queries.GetHistory(ctx, historyID)
+		if err != nil || h.RequestID.Int64 != requestID {
+			return repository.DriftBaseline{}, fmt.Errorf("history entry %d is not a response of the request", historyID)
+		}
+		if h.StatusCode.Int64 < 200 || h.StatusCode.Int64 >= 300 || h.IsBinary.Int64 == 1 || !json.Valid([]byte(h.ResponseBody.String)) {
+			return repository.DriftBaseline{}, fmt.Errorf("history entry %d is not a successful JSON response", historyID)
+		}
+		entry = h
+	}
+	return c.queries.SaveDriftBaseline(ctx, repository.SaveDriftBaselineParams{
+		RequestID:    requestID,
+		HistoryID:    sql.NullInt64{Int64: entry.ID, Valid: true},
+		ResponseBody: entry.ResponseBody.String,
+	})
+}
+
+// LatestJSONResponse returns the most recent successful JSON response recorded
+// for the request
+func LatestJSONResponse(ctx context.Context, queries *repository.Queries, requestID int64) (repository.RequestHistory, bool) {
+	entries, err := queries.ListHistoryByRequest(ctx, repository.ListHistoryByRequestParams{
+		RequestID: sql.NullInt64{Int64: requestID, Valid: true},
+		Limit:     driftBaselineDepth,
+	})
+	if err != nil {
+		return repository.RequestHistory{}, false
+	}
+	// created_at has second precision; the ID breaks ties between quick runs
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].ID > entries[j].ID })
+	for _, h := range entries {
+		if h.StatusCode.Int64 < 200 || h.StatusCode.Int64 >= 300 || h.IsBinary.Int64 == 1 {
+			continue
+		}
+		if json.Valid([]byte(h.ResponseBody.String)) {
+			return h, true
+		}
+	}
+	return repository.RequestHistory{}, false
+}
+
+// Alert sends the report to the webhook, queued when a job queue is set and
+// inline otherwise. The outcome is recorded on the report.
+func (c *ContractDriftChecker) Alert(ctx context.Context, workspaceID int64, url string, report *DriftReport) {
+	if c.jobs == nil {
+		if err := c.NotifyWebhook(ctx, url, report); err != nil {
+			report.WebhookError = err.Error()
+		}
+		return
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		report.WebhookError = err.Error()
+		return
+	}
+	job, err := c.jobs.Enqueue(ctx, JobTypeWebhook, WebhookJob{URL: url, Body: body}, EnqueueOptions{
+		WorkspaceID: workspaceID,
+		MaxAttempts: 5,
+	})
+	if err != nil {
+		report.WebhookError = err.Error()
+		return
+	}
+	report.WebhookJobID = job.ID
+}
+
+// NotifyWebhook posts the report as JSON to url
+func (c *ContractDriftChecker) NotifyWebhook(ctx context.Context, url string, report *DriftReport) error {
+	payload, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
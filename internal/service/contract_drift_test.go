@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCompareJSONShapes(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline string
+		current  string
+		want     []DriftChange
+	}{
+		{
+			name:     "same shape, different values",
+			baseline: `{"id":1,"name":"a","tags":["x"]}`,
+			current:  `{"id":2,"name":"b","tags":["y","z"]}`,
+		},
+		{
+			name:     "field added and removed",
+			baseline: `{"id":1,"legacy":true}`,
+			current:  `{"id":1,"email":"a@b.c"}`,
+			want: []DriftChange{
+				{Path: "$.email", Kind: DriftAdded, After: "string"},
+				{Path: "$.legacy", Kind: DriftRemoved, Before: "boolean"},
+			},
+		},
+		{
+			name:     "type changed",
+			baseline: `{"id":1}`,
+			current:  `{"id":"1"}`,
+			want:     []DriftChange{{Path: "$.id", Kind: DriftTypeChanged, Before: "number", After: "string"}},
+		},
+		{
+			name:     "removed object reported once",
+			baseline: `{"user":{"id":1,"profile":{"age":3}}}`,
+			current:  `{}`,
+			want:     []DriftChange{{Path: "$.user", Kind: DriftRemoved, Before: "object"}},
+		},
+		{
+			name:     "array element fields",
+			baseline: `{"items":[{"id":1,"price":2}]}`,
+			current:  `{"items":[{"id":1}]}`,
+			want:     []DriftChange{{Path: "$.items[].price", Kind: DriftRemoved, Before: "number"}},
+		},
+		{
+			name:     "empty array says nothing about elements",
+			baseline: `{"items":[{"id":1}]}`,
+			current:  `{"items":[]}`,
+		},
+		{
+			name:     "null is compatible",
+			baseline: `{"deletedAt":null}`,
+			current:  `{"deletedAt":"2024-01-01"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CompareJSONShapes(tt.baseline, tt.current)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareJSONShapes_NotJSON(t *testing.T) {
+	if _, err := CompareJSONShapes(`{}`, `<html>`); err == nil {
+		t.Error("expected error for non-JSON response")
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// cookieJar is a workspace's cookie jar (the cookies table) as an
+// http.CookieJar: the client stores the Set-Cookie headers of every response,
+// redirects included, and adds the matching cookies to every request, so
+// login flows carry their session without extracting tokens by hand.
+type cookieJar struct {
+	ctx     context.Context
+	queries *repository.Queries
+	wsID    int64
+}
+
+func newCookieJar(ctx context.Context, queries *repository.Queries) *cookieJar {
+	return &cookieJar{ctx: ctx, queries: queries, wsID: middleware.GetWorkspaceID(ctx)}
+}
+
+// SetCookies stores cookies set by a response from u; expired ones are
+// removed from the jar
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	now := time.Now()
+	for _, c := range cookies {
+		p, ok := jarCookie(u, c, now)
+		if !ok {
+			continue
+		}
+		p.WorkspaceID = j.wsID
+		if p.ExpiresAt.Valid && !p.ExpiresAt.Time.After(now) {
+			j.queries.DeleteCookieByName(j.ctx, repository.DeleteCookieByNameParams{
+				WorkspaceID: j.wsID, Domain: p.Domain, Path: p.Path, Name: p.Name,
+			})
+			continue
+		}
+		j.queries.UpsertCookie(j.ctx, p)
+	}
+}
+
+// Cookies returns the jar's cookies to send to u, longest path first.
+// Expired cookies found along the way are removed.
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	rows, err := j.queries.ListCookies(j.ctx, j.wsID)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	var matched []repository.Cookie
+	for _, c := range rows {
+		if c.ExpiresAt.Valid && !c.ExpiresAt.Time.After(now) {
+			j.queries.DeleteCookie(j.ctx, c.ID)
+			continue
+		}
+		if CookieMatches(c, u) {
+			matched = append(matched, c)
+		}
+	}
+	sort.SliceStable(matched, func(a, b int) bool { return len(matched[a].Path) > len(matched[b].Path) })
+	cookies := make([]*http.Cookie, len(matched))
+	for i, c := range matched {
+		cookies[i] = &http.Cookie{Name: c.Name, Value: c.Value}
+	}
+	return cookies
+}
+
+// CookieMatches reports whether the jar sends c with a request to u
+func CookieMatches(c repository.Cookie, u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if (c.HostOnly == 1 && host != c.Domain) || (c.HostOnly == 0 && !domainMatch(host, c.Domain)) {
+		return false
+	}
+	if c.Secure == 1 && u.Scheme != "https" && u.Scheme != "wss" {
+		return false
+	}
+	return pathMatch(u.EscapedPath(), c.Path)
+}
+
+// jarCookie turns a Set-Cookie from u into a jar row (without workspace).
+// Cookies for a domain u's host is not part of are rejected. An ExpiresAt
+// in the past means the server deleted the cookie.
+func jarCookie(u *url.URL, c *http.Cookie, now time.Time) (repository.UpsertCookieParams, bool) {
+	host := strings.ToLower(u.Hostname())
+	if c.Name == "" || host == "" {
+		return repository.UpsertCookieParams{}, false
+	}
+	p := repository.UpsertCookieParams{Name: c.Name, Value: c.Value, HostOnly: 1}
+
+	p.Domain = host
+	if d := strings.TrimPrefix(strings.ToLower(c.Domain), "."); d != "" && d != host {
+		// Domain cookies go to subdomains; IP hosts and bare TLDs take none
+		if net.ParseIP(host) != nil || !strings.Contains(d, ".") || !domainMatch(host, d) {
+			return repository.UpsertCookieParams{}, false
+		}
+		p.Domain, p.HostOnly = d, 0
+	} else if d == host {
+		p.HostOnly = 0
+	}
+
+	p.Path = c.Path
+	if !strings.HasPrefix(p.Path, "/") {
+		p.Path = defaultCookiePath(u.EscapedPath())
+	}
+
+	switch {
+	case c.MaxAge < 0:
+		p.ExpiresAt = sql.NullTime{Time: now, Valid: true}
+	case c.MaxAge > 0:
+		p.ExpiresAt = sql.NullTime{Time: now.Add(time.Duration(c.MaxAge) * time.Second).UTC(), Valid: true}
+	case !c.Expires.IsZero():
+		p.ExpiresAt = sql.NullTime{Time: c.Expires.UTC(), Valid: true}
+	}
+	if c.Secure {
+		p.Secure = 1
+	}
+	if c.HttpOnly {
+		p.HttpOnly = 1
+	}
+	return p, true
+}
+
+// ValidateCookie checks a cookie entered through the API
+func ValidateCookie(domain, path, name, value string) error {
+	switch {
+	case domain == "" || strings.ContainsAny(domain, "/:?#@ "):
+		return errors.New("domain must be a hostname")
+	case !strings.HasPrefix(path, "/"):
+		return errors.New("path must start with /")
+	}
+	return (&http.Cookie{Name: name, Value: value}).Valid()
+}
+
+// domainMatch reports whether host is domain or one of its subdomains
+func domainMatch(host, domain string) bool {
+	return host == domain || (strings.HasSuffix(host, "."+domain) && net.ParseIP(host) == nil)
+}
+
+// pathMatch is RFC 6265 path-match: the cookie path is a prefix of the
+// request path ending at a "/" boundary
+func pathMatch(reqPath, cookiePath string) bool {
+	if reqPath == "" {
+		reqPath = "/"
+	}
+	if !strings.HasPrefix(reqPath, cookiePath) {
+		return false
+	}
+	return len(reqPath) == len(cookiePath) || strings.HasSuffix(cookiePath, "/") || reqPath[len(cookiePath)] == '/'
+}
+
+// defaultCookiePath is the directory of the request path (RFC 6265 5.1.4)
+func defaultCookiePath(reqPath string) string {
+	i := strings.LastIndex(reqPath, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return reqPath[:i]
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestJarCookie(t *testing.T) {
+	u, _ := url.Parse("https://api.example.com/v1/login")
+	now := time.Now()
+
+	p, ok := jarCookie(u, &http.Cookie{Name: "sid", Value: "1"}, now)
+	if !ok || p.Domain != "api.example.com" || p.HostOnly != 1 || p.Path != "/v1" || p.ExpiresAt.Valid {
+		t.Errorf("host cookie = %+v", p)
+	}
+	p, ok = jarCookie(u, &http.Cookie{Name: "sid", Value: "1", Domain: ".Example.com", Path: "/", MaxAge: 60}, now)
+	if !ok || p.Domain != "example.com" || p.HostOnly != 0 || p.Path != "/" || !p.ExpiresAt.Time.After(now) {
+		t.Errorf("domain cookie = %+v", p)
+	}
+	for _, domain := range []string{"other.com", "com", "i.api.example.com"} {
+		if _, ok := jarCookie(u, &http.Cookie{Name: "sid", Domain: domain}, now); ok {
+			t.Errorf("cookie for %s accepted from %s", domain, u.Host)
+		}
+	}
+	if p, _ = jarCookie(u, &http.Cookie{Name: "sid", MaxAge: -1}, now); !p.ExpiresAt.Valid || p.ExpiresAt.Time.After(now) {
+		t.Errorf("deleted cookie = %+v", p)
+	}
+}
+
+func TestCookieMatches(t *testing.T) {
+	c := repository.Cookie{Domain: "example.com", Path: "/api", Secure: 1}
+	for raw, want := range map[string]bool{
+		"https://example.com/api":        true,
+		"https://www.example.com/api/v1": true,
+		"https://example.com/apis":       false,
+		"http://example.com/api":         false,
+		"https://badexample.com/api":     false,
+	} {
+		u, _ := url.Parse(raw)
+		if got := CookieMatches(c, u); got != want {
+			t.Errorf("%s: match = %v, want %v", raw, got, want)
+		}
+	}
+	c.HostOnly, c.Secure = 1, 0
+	if u, _ := url.Parse("http://www.example.com/api"); CookieMatches(c, u) {
+		t.Error("host-only cookie sent to a subdomain")
+	}
+}
+
+func TestRequestExecutor_CookieJar(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		http.Redirect(w, r, "/home", http.StatusFound)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("session")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(c.Value))
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Path: "/", MaxAge: -1})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+	get := func(ctx context.Context, path string) *ExecuteResult {
+		t.Helper()
+		result, err := re.ExecuteRequest(ctx, repository.Request{Method: "GET", Url: srv.URL + path}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	// The cookie set on the redirect is stored and sent to its target
+	if result := get(ctx, "/login"); result.StatusCode != 200 || result.Body != "abc" {
+		t.Fatalf("login = %d %q", result.StatusCode, result.Body)
+	}
+	if result := get(ctx, "/home"); result.Body != "abc" {
+		t.Errorf("later request = %d %q", result.StatusCode, result.Body)
+	}
+	// Other workspaces have their own jar
+	if result := get(middleware.WithWorkspaceID(ctx, 2), "/home"); result.StatusCode != http.StatusUnauthorized {
+		t.Errorf("cookie leaked to another workspace: %d", result.StatusCode)
+	}
+
+	get(ctx, "/logout")
+	if cookies, _ := q.ListCookies(ctx, 1); len(cookies) != 0 {
+		t.Errorf("cookies after logout = %+v", cookies)
+	}
+}
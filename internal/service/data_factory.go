@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// Test data factories are server-managed values for generating unique data
+// across repeated runs, persisted per workspace:
+//
+//	{{seq:NAME}}   next value of sequence NAME; every use increments it
+//	               atomically, and an unknown sequence starts at 1
+//	{{pool:NAME}}  an item of pool NAME, picked round-robin or at random
+//	               (from the run's seeded source) depending on its mode
+//
+// A user variable of the same name takes precedence.
+
+const (
+	PoolModeRoundRobin = "roundRobin"
+	PoolModeRandom     = "random"
+
+	MaxPoolItems = 10000
+
+	seqVarPrefix  = "seq:"
+	poolVarPrefix = "pool:"
+)
+
+var dataFactoryNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// ValidDataFactoryName reports whether name can be used for a sequence or pool
+func ValidDataFactoryName(name string) bool {
+	return dataFactoryNameRe.MatchString(name)
+}
+
+// ValidatePool checks a pool's mode and items before it is stored
+func ValidatePool(mode string, items []string) error {
+	if mode != PoolModeRoundRobin && mode != PoolModeRandom {
+		return fmt.Errorf("mode must be %q or %q", PoolModeRoundRobin, PoolModeRandom)
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("pool needs at least one value")
+	}
+	if len(items) > MaxPoolItems {
+		return fmt.Errorf("pool exceeds %d values", MaxPoolItems)
+	}
+	return nil
+}
+
+// ParsePoolItems decodes a stored pool's items column
+func ParsePoolItems(raw string) []string {
+	items := []string{}
+	json.Unmarshal([]byte(raw), &items)
+	return items
+}
+
+// dataFactory resolves seq: and pool: variables for one workspace. In peek
+// mode (variable previews) it reports the next value without consuming it.
+type dataFactory struct {
+	ctx     context.Context
+	queries *repository.Queries
+	wsID    int64
+	random  *runRandom
+	peek    bool
+}
+
+func (vr *VariableResolver) dataFactory(ctx context.Context) *dataFactory {
+	if vr.queries == nil {
+		return nil
+	}
+	return &dataFactory{
+		ctx:     ctx,
+		queries: vr.queries,
+		wsID:    middleware.GetWorkspaceID(ctx),
+		random:  runRandomFrom(ctx),
+	}
+}
+
+// previewing returns a copy of f that does not advance sequences or pools
+func (f *dataFactory) previewing() *dataFactory {
+	if f == nil {
+		return nil
+	}
+	p := *f
+	p.peek = true
+	return &p
+}
+
+// resolve evaluates a seq: or pool: variable name
+func (f *dataFactory) resolve(name string) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+	if ref, ok := strings.CutPrefix(name, seqVarPrefix); ok {
+		return f.sequence(strings.TrimSpace(ref))
+	}
+	if ref, ok := strings.CutPrefix(name, poolVarPrefix); ok {
+		return f.pool(strings.TrimSpace(ref))
+	}
+	return "", false
+}
+
+func (f *dataFactory) sequence(name string) (string, bool) {
+	if !ValidDataFactoryName(name) {
+		return "", false
+	}
+	if f.peek {
+		var next int64 = 1
+		if s, err := f.queries.GetSequence(f.ctx, repository.GetSequenceParams{WorkspaceID: f.wsID, Name: name}); err == nil {
+			next = s.Value + 1
+		}
+		return strconv.FormatInt(next, 10), true
+	}
+	v, err := f.queries.NextSequenceValue(f.ctx, repository.NextSequenceValueParams{WorkspaceID: f.wsID, Name: name})
+	if err != nil {
+		return "", false
+	}
+	return strconv.FormatInt(v, 10), true
+}
+
+func (f *dataFactory) pool(name string) (string, bool) {
+	key := repository.GetValuePoolParams{WorkspaceID: f.wsID, Name: name}
+	p, err := f.queries.GetValuePool(f.ctx, key)
+	if err != nil {
+		return "", false
+	}
+	if p.Mode == PoolModeRoundRobin && !f.peek {
+		// The returned cursor counts this use, so it is one past our pick
+		p, err = f.queries.AdvanceValuePool(f.ctx, repository.AdvanceValuePoolParams(key))
+		if err != nil {
+			return "", false
+		}
+		p.Cursor--
+	}
+	items := ParsePoolItems(p.Items)
+	if len(items) == 0 {
+		return "", false
+	}
+	if p.Mode == PoolModeRandom {
+		return items[f.random.IntN(len(items))], true
+	}
+	return items[p.Cursor%int64(len(items))], true
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func mustResolve(t *testing.T, vr *VariableResolver, ctx context.Context, input string, vars map[string]string, collectionID int64) string {
+	t.Helper()
+	got, err := vr.Resolve(ctx, input, vars, collectionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestDataFactory_Sequence(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	vr := NewVariableResolver(q)
+
+	if got := vr.ResolveWithVars("user{{seq:userIndex}}@x.io", nil); got != "user{{seq:userIndex}}@x.io" {
+		t.Errorf("ResolveWithVars without ctx = %q", got)
+	}
+
+	if got := mustResolve(t, vr, ctx, "{{seq:userIndex}},{{ seq:userIndex }}", nil, 0); got != "1,2" {
+		t.Errorf("first uses = %q", got)
+	}
+	if _, err := q.UpsertSequence(ctx, repository.UpsertSequenceParams{WorkspaceID: 1, Name: "userIndex", Value: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustResolve(t, vr, ctx, "{{seq:userIndex}}", nil, 0); got != "101" {
+		t.Errorf("after reset = %q", got)
+	}
+
+	// Previews show the next value without consuming it
+	if text, _ := vr.Explain(ctx, "{{seq:userIndex}}", nil, 0); text != "102" {
+		t.Errorf("explain = %q", text)
+	}
+	if got := mustResolve(t, vr, ctx, "{{seq:userIndex}}", nil, 0); got != "102" {
+		t.Errorf("after explain = %q", got)
+	}
+
+	// A user variable of the same name wins; invalid names stay unresolved
+	if got := mustResolve(t, vr, ctx, "{{seq:userIndex}}", map[string]string{"seq:userIndex": "mine"}, 0); got != "mine" {
+		t.Errorf("shadowed = %q", got)
+	}
+	if got := mustResolve(t, vr, ctx, "{{seq:bad name}}", nil, 0); got != "{{seq:bad name}}" {
+		t.Errorf("invalid name = %q", got)
+	}
+
+	// Sequences are per workspace
+	ws, err := q.CreateWorkspace(ctx, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mustResolve(t, vr, middleware.WithWorkspaceID(ctx, ws.ID), "{{seq:userIndex}}", nil, 0); got != "1" {
+		t.Errorf("other workspace = %q", got)
+	}
+}
+
+func TestDataFactory_Pool(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	vr := NewVariableResolver(q)
+
+	items, _ := json.Marshal([]string{"a", "b", "c"})
+	for _, mode := range []string{PoolModeRoundRobin, PoolModeRandom} {
+		if _, err := q.UpsertValuePool(ctx, repository.UpsertValuePoolParams{WorkspaceID: 1, Name: mode, Mode: mode, Items: string(items)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if text, _ := vr.Explain(ctx, "{{pool:roundRobin}}", nil, 0); text != "a" {
+		t.Errorf("explain = %q", text)
+	}
+	if got := mustResolve(t, vr, ctx, "{{pool:roundRobin}}{{pool:roundRobin}}{{pool:roundRobin}}{{pool:roundRobin}}", nil, 0); got != "abca" {
+		t.Errorf("round robin = %q", got)
+	}
+
+	// Random picks follow the run's seed
+	pick := func() string {
+		return mustResolve(t, vr, WithRandomSeed(ctx, 7), "{{pool:random}}{{pool:random}}{{pool:random}}{{pool:random}}", nil, 0)
+	}
+	if a, b := pick(), pick(); a != b || len(a) != 4 {
+		t.Errorf("seeded picks = %q, %q", a, b)
+	}
+
+	if got := mustResolve(t, vr, ctx, "{{pool:missing}}", nil, 0); got != "{{pool:missing}}" {
+		t.Errorf("missing pool = %q", got)
+	}
+}
+
+func TestValidatePool(t *testing.T) {
+	for name, tc := range map[string]struct {
+		mode  string
+		items []string
+		ok    bool
+	}{
+		"round robin": {PoolModeRoundRobin, []string{"a"}, true},
+		"random":      {PoolModeRandom, []string{"a", "b"}, true},
+		"bad mode":    {"shuffle", []string{"a"}, false},
+		"empty":       {PoolModeRoundRobin, nil, false},
+		"too many":    {PoolModeRoundRobin, make([]string, MaxPoolItems+1), false},
+	} {
+		if err := ValidatePool(tc.mode, tc.items); (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v", name, err)
+		}
+	}
+}
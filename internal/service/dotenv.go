@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DotenvFile is a parsed .env file: KEY=value pairs in file order and the
+// comments that went with them
+type DotenvFile struct {
+	Keys   []string
+	Values map[string]string
+	// Comments holds the comment lines above each key and its inline
+	// comment, without "#"; "" holds comments after the last variable
+	Comments map[string]string
+}
+
+// ParseDotenv reads .env content. Lines may start with "export "; values may
+// be unquoted (an inline comment needs a space before "#"), 'literal' or
+// "escaped" (\n, \r, \t, \", \\). Lines that are not variables are skipped
+// and reported in the warnings; a repeated key keeps its last value.
+func ParseDotenv(content string) (DotenvFile, []string) {
+	f := DotenvFile{Values: map[string]string{}, Comments: map[string]string{}}
+	var warnings, pending []string
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			pending = append(pending, strings.TrimSpace(line[1:]))
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, rest, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !validDotenvKey(key) {
+			warnings = append(warnings, fmt.Sprintf("line %d: not a KEY=value pair", i+1))
+			continue
+		}
+		value, comment, err := parseDotenvValue(rest)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("line %d: %s: %v", i+1, key, err))
+			continue
+		}
+		if _, seen := f.Values[key]; seen {
+			warnings = append(warnings, fmt.Sprintf("line %d: %s repeated, keeping the last value", i+1, key))
+		} else {
+			f.Keys = append(f.Keys, key)
+		}
+		f.Values[key] = value
+		if comment != "" {
+			pending = append(pending, comment)
+		}
+		if len(pending) > 0 {
+			f.Comments[key] = strings.Join(pending, "\n")
+			pending = nil
+		}
+	}
+	if len(pending) > 0 {
+		f.Comments[""] = strings.Join(pending, "\n")
+	}
+	return f, warnings
+}
+
+// validDotenvKey reports whether name can stand on the left of a .env line
+func validDotenvKey(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " \t\n\r=#\"'")
+}
+
+// parseDotenvValue splits the text after "=" into the value and its inline
+// comment. A "#" right after "=" starts a value (KEY=#fff), one after a
+// space a comment.
+func parseDotenvValue(raw string) (value, comment string, err error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return "", "", nil
+	}
+	if s[0] == '#' && s != raw {
+		return "", strings.TrimSpace(s[1:]), nil
+	}
+	var rest string
+	switch s[0] {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", errors.New("unterminated quoted value")
+		}
+		value, rest = s[1:end+1], s[end+2:]
+	case '"':
+		var b strings.Builder
+		end := -1
+	scan:
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '"':
+				end = i
+				break scan
+			case c == '\\' && i+1 < len(s):
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				case 't':
+					b.WriteByte('\t')
+				case '"', '\\':
+					b.WriteByte(s[i])
+				default:
+					b.WriteByte('\\')
+					b.WriteByte(s[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		if end < 0 {
+			return "", "", errors.New("unterminated quoted value")
+		}
+		value, rest = b.String(), s[end+1:]
+	default:
+		value = s
+		if i := strings.Index(s, " #"); i >= 0 {
+			value, rest = s[:i], s[i+1:]
+		} else if i := strings.Index(s, "\t#"); i >= 0 {
+			value, rest = s[:i], s[i+1:]
+		}
+		value = strings.TrimSpace(value)
+	}
+	rest = strings.TrimSpace(rest)
+	switch {
+	case rest == "":
+	case strings.HasPrefix(rest, "#"):
+		comment = strings.TrimSpace(rest[1:])
+	default:
+		return "", "", fmt.Errorf("unexpected %q after the quoted value", rest)
+	}
+	return value, comment, nil
+}
+
+// FormatDotenv writes variables as .env lines in the order of keys, each
+// below its comments. Keys a .env file cannot hold are left out and
+// returned.
+func FormatDotenv(keys []string, values, comments map[string]string) (string, []string) {
+	var b strings.Builder
+	var skipped []string
+	for _, key := range keys {
+		if !validDotenvKey(key) {
+			skipped = append(skipped, key)
+			continue
+		}
+		writeDotenvComment(&b, comments[key])
+		b.WriteString(key + "=" + quoteDotenvValue(values[key]) + "\n")
+	}
+	writeDotenvComment(&b, comments[""])
+	return b.String(), skipped
+}
+
+func writeDotenvComment(b *strings.Builder, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		if line == "" {
+			b.WriteString("#\n")
+		} else {
+			b.WriteString("# " + line + "\n")
+		}
+	}
+}
+
+// quoteDotenvValue double-quotes values that would not read back unquoted
+func quoteDotenvValue(v string) string {
+	if !strings.ContainsAny(v, " \t\n\r\"'#\\") {
+		return v
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(v) + `"`
+}
+
+// OrderedJSONKeys returns the keys of a JSON object in the order they are
+// written, so exports follow the order variables were stored in
+func OrderedJSONKeys(obj string) []string {
+	dec := json.NewDecoder(strings.NewReader(obj))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil
+	}
+	var keys []string
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return keys
+		}
+		key, _ := t.(string)
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return keys
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// MarshalOrderedVariables encodes variables as a JSON object with its keys
+// in the order given
+func MarshalOrderedVariables(keys []string, values map[string]string) string {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, _ := json.Marshal(values[key])
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	f, warnings := ParseDotenv("# Service\r\n" +
+		"HOST=api.example.com\r\n" +
+		"\n" +
+		"export PORT = 8080 # default\n" +
+		"COLOR=#fff\n" +
+		"EMPTY=\n" +
+		"SINGLE='a \\n b # not a comment'\n" +
+		"DOUBLE=\"line1\\nsaid \\\"hi\\\"\"\n" +
+		"BROKEN=\"open\n" +
+		"= nothing\n" +
+		"HOST=other\n" +
+		"# trailing\n")
+
+	wantKeys := []string{"HOST", "PORT", "COLOR", "EMPTY", "SINGLE", "DOUBLE"}
+	if !reflect.DeepEqual(f.Keys, wantKeys) {
+		t.Errorf("keys = %v, want %v", f.Keys, wantKeys)
+	}
+	wantValues := map[string]string{
+		"HOST":   "other",
+		"PORT":   "8080",
+		"COLOR":  "#fff",
+		"EMPTY":  "",
+		"SINGLE": `a \n b # not a comment`,
+		"DOUBLE": "line1\nsaid \"hi\"",
+	}
+	if !reflect.DeepEqual(f.Values, wantValues) {
+		t.Errorf("values = %q, want %q", f.Values, wantValues)
+	}
+	wantComments := map[string]string{"HOST": "Service", "PORT": "default", "": "trailing"}
+	if !reflect.DeepEqual(f.Comments, wantComments) {
+		t.Errorf("comments = %q, want %q", f.Comments, wantComments)
+	}
+	if len(warnings) != 3 {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestFormatDotenv_RoundTrip(t *testing.T) {
+	values := map[string]string{"A": "plain", "B": "two words", "C": "quote\" and \\ and\nnewline", "D": "", "bad key": "x"}
+	comments := map[string]string{"A": "first\n\nsecond", "": "end"}
+	out, skipped := FormatDotenv([]string{"A", "B", "C", "D", "bad key"}, values, comments)
+	if !reflect.DeepEqual(skipped, []string{"bad key"}) {
+		t.Errorf("skipped = %v", skipped)
+	}
+
+	f, warnings := ParseDotenv(out)
+	delete(values, "bad key")
+	if len(warnings) != 0 || !reflect.DeepEqual(f.Values, values) || !reflect.DeepEqual(f.Comments, comments) {
+		t.Errorf("round trip of\n%s\n= %q, %q, %v", out, f.Values, f.Comments, warnings)
+	}
+}
+
+func TestOrderedJSONKeys(t *testing.T) {
+	if keys := OrderedJSONKeys(`{"z":"1","a":{"n":[1]},"m":"2"}`); !reflect.DeepEqual(keys, []string{"z", "a", "m"}) {
+		t.Errorf("keys = %v", keys)
+	}
+	if keys := OrderedJSONKeys(`[]`); keys != nil {
+		t.Errorf("non-object keys = %v", keys)
+	}
+	if s := MarshalOrderedVariables([]string{"z", "a"}, map[string]string{"a": "1", "z": "\"2\""}); s != `{"z":"\"2\"","a":"1"}` {
+		t.Errorf("marshal = %s", s)
+	}
+}
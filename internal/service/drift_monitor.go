@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// Drift monitor interval bounds (seconds)
+const (
+	MinDriftInterval = 5 * 60
+	MaxDriftInterval = 7 * 24 * 60 * 60
+)
+
+const (
+	driftTick     = 30 * time.Second // how often due drift monitors are looked up
+	driftTimeout  = 10 * time.Minute // per collection check
+	driftLeaseTTL = 15 * time.Minute // covers a round of collection checks
+)
+
+// DriftMonitorRunner checks the collections of drift monitors against the
+// live API on their interval and posts the report to the monitor's webhook
+// when a response no longer matches its saved baseline or OpenAPI schema.
+// Scheduled checks are not written to request history.
+type DriftMonitorRunner struct {
+	queries  *repository.Queries
+	checker  *ContractDriftChecker
+	instance *Instance // optional; checks run only while holding the lease
+	jobs     *JobQueue // optional; scheduled checks run as queued jobs
+}
+
+func NewDriftMonitorRunner(queries *repository.Queries, checker *ContractDriftChecker) *DriftMonitorRunner {
+	return &DriftMonitorRunner{queries: queries, checker: checker}
+}
+
+// SetInstance makes each due check run once when several instances share
+// the database
+func (d *DriftMonitorRunner) SetInstance(inst *Instance) {
+	d.instance = inst
+}
+
+// SetJobQueue runs scheduled checks on the job queue: each tick queues a job
+// per due drift monitor instead of checking them itself
+func (d *DriftMonitorRunner) SetJobQueue(jq *JobQueue) {
+	d.jobs = jq
+	jq.Register(JobTypeDriftCheck, func(ctx context.Context, job repository.Job) (interface{}, error) {
+		mon, ok, err := loadScheduled(ctx, job, d.queries.GetDriftMonitor)
+		if !ok || mon.Enabled == 0 {
+			return nil, err
+		}
+		report, err := d.run(ctx, mon, true)
+		if err != nil || report == nil {
+			return nil, err
+		}
+		return map[string]bool{"drifted": report.Drifted}, nil
+	})
+}
+
+// Start runs due drift monitors in the background until ctx is cancelled
+func (d *DriftMonitorRunner) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(driftTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !d.instance.Acquire(ctx, LeaseDriftMonitors, driftLeaseTTL) {
+					continue
+				}
+				if d.jobs != nil {
+					d.EnqueueDue(ctx)
+				} else {
+					d.RunDue(ctx)
+				}
+			}
+		}
+	}()
+}
+
+// RunDue checks every enabled drift monitor whose interval has elapsed, one
+// at a time. It returns the number of monitors checked.
+func (d *DriftMonitorRunner) RunDue(ctx context.Context) int {
+	due, err := d.queries.ListDueDriftMonitors(ctx)
+	if err != nil {
+		log.Printf("drift: failed to list due monitors: %v", err)
+		return 0
+	}
+	for _, mon := range due {
+		if _, err := d.run(ctx, mon, true); err != nil {
+			log.Printf("drift monitor %d: %v", mon.ID, err)
+		}
+	}
+	return len(due)
+}
+
+// EnqueueDue queues a check of every enabled drift monitor whose interval
+// has elapsed. It returns the number of due monitors.
+func (d *DriftMonitorRunner) EnqueueDue(ctx context.Context) int {
+	due, err := d.queries.ListDueDriftMonitors(ctx)
+	if err != nil {
+		log.Printf("drift: failed to list due monitors: %v", err)
+		return 0
+	}
+	for _, mon := range due {
+		d.jobs.enqueueScheduled(ctx, JobTypeDriftCheck, mon.ID, mon.WorkspaceID)
+	}
+	return len(due)
+}
+
+// Run checks the monitor's collection now, independent of its schedule and
+// quota; the next scheduled check counts from now
+func (d *DriftMonitorRunner) Run(ctx context.Context, mon repository.DriftMonitor) (*DriftReport, error) {
+	return d.run(ctx, mon, false)
+}
+
+// run checks the collection, alerts on drift and records the report as the
+// monitor's last one
+func (d *DriftMonitorRunner) run(ctx context.Context, mon repository.DriftMonitor, scheduled bool) (*DriftReport, error) {
+	if scheduled {
+		var exceeded *QuotaExceededError
+		if err := CheckQuotas(ctx, d.queries, mon.WorkspaceID, QuotaScheduledRuns); errors.As(err, &exceeded) {
+			return nil, d.queries.MarkDriftMonitorRun(ctx, mon.ID)
+		}
+	}
+
+	checkCtx, cancel := context.WithTimeout(withoutHistory(middleware.WithWorkspaceID(ctx, mon.WorkspaceID)), driftTimeout)
+	defer cancel()
+
+	report, err := d.checker.checkCollection(checkCtx, mon.CollectionID, scheduled)
+	if err != nil {
+		if markErr := d.queries.MarkDriftMonitorRun(ctx, mon.ID); markErr != nil {
+			return nil, markErr
+		}
+		return nil, err
+	}
+	if report.Drifted && mon.WebhookUrl != "" {
+		d.checker.Alert(ctx, mon.WorkspaceID, mon.WebhookUrl, report)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	return report, d.queries.RecordDriftMonitorRun(ctx, repository.RecordDriftMonitorRunParams{LastReport: string(data), ID: mon.ID})
+}
+
+// ParseDriftReport decodes a monitor's stored last report; nil before the first check
+func ParseDriftReport(raw string) *DriftReport {
+	if raw == "" {
+		return nil
+	}
+	var report DriftReport
+	if err := json.Unmarshal([]byte(raw), &report); err != nil {
+		return nil
+	}
+	return &report
+}
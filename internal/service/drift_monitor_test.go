@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestDriftMonitorRunner_RunDue(t *testing.T) {
+	var mu sync.Mutex
+	body := `{"id":1,"name":"alice"}`
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	defer api.Close()
+
+	var alerts []DriftReport
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report DriftReport
+		json.NewDecoder(r.Body).Decode(&report)
+		mu.Lock()
+		alerts = append(alerts, report)
+		mu.Unlock()
+	}))
+	defer hook.Close()
+
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	runner := NewDriftMonitorRunner(q, NewContractDriftChecker(q, re))
+
+	coll, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Users API", WorkspaceID: 1})
+	req, _ := q.CreateRequest(ctx, repository.CreateRequestParams{
+		CollectionID: sql.NullInt64{Int64: coll.ID, Valid: true},
+		Name:         "Get user",
+		Method:       "GET",
+		Url:          api.URL + "/users/1",
+		WorkspaceID:  1,
+	})
+	if _, err := q.CreateOpenAPISpec(ctx, repository.CreateOpenAPISpecParams{WorkspaceID: 1, Name: "users", Spec: `{
+		"openapi": "3.0.0",
+		"paths": {"/users/{id}": {"get": {"responses": {"200": {"content": {"application/json": {"schema": {
+			"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}
+		}}}}}}}}
+	}`}); err != nil {
+		t.Fatal(err)
+	}
+	mon, err := q.CreateDriftMonitor(ctx, repository.CreateDriftMonitorParams{
+		WorkspaceID: 1, CollectionID: coll.ID, IntervalSeconds: 3600, WebhookUrl: hook.URL, Enabled: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// First run saves the baseline; the response matches the spec
+	if n := runner.RunDue(ctx); n != 1 {
+		t.Fatalf("RunDue = %d, want 1", n)
+	}
+	mon, _ = q.GetDriftMonitor(ctx, mon.ID)
+	report := ParseDriftReport(mon.LastReport)
+	if !mon.LastRunAt.Valid || report == nil || report.Drifted || report.Requests[0].Status != DriftStatusNoBaseline {
+		t.Fatalf("first run: last run %v, report %+v", mon.LastRunAt, report)
+	}
+	if spec := report.Requests[0].Spec; spec == nil || !spec.Passed {
+		t.Errorf("spec validation = %+v", spec)
+	}
+	if n := runner.RunDue(ctx); n != 0 {
+		t.Errorf("monitor is not due again within its interval, ran %d", n)
+	}
+
+	// Scheduled checks leave request history alone
+	baseline, err := q.GetDriftBaseline(ctx, req.ID)
+	if err != nil || baseline.HistoryID.Valid || baseline.ResponseBody != `{"id":1,"name":"alice"}` {
+		t.Errorf("baseline = %+v, %v", baseline, err)
+	}
+	history, _ := q.ListHistoryByRequest(ctx, repository.ListHistoryByRequestParams{RequestID: sql.NullInt64{Int64: req.ID, Valid: true}, Limit: 10})
+	if len(history) != 0 {
+		t.Errorf("scheduled check wrote %d history entries", len(history))
+	}
+
+	// A type change breaks both the saved example and the schema
+	mu.Lock()
+	body = `{"id":"1","name":"alice"}`
+	mu.Unlock()
+	report, err = runner.Run(ctx, mon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd := report.Requests[0]
+	if !report.Drifted || rd.Status != DriftStatusDrift || len(rd.Changes) != 1 || rd.Changes[0].Kind != DriftTypeChanged {
+		t.Fatalf("drift = %+v", rd)
+	}
+	if rd.Spec == nil || rd.Spec.Passed || rd.Spec.Failures[0].Path != "$.id" {
+		t.Errorf("spec validation = %+v", rd.Spec)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(alerts) != 1 || alerts[0].Requests[0].Name != "Get user" {
+		t.Errorf("webhook alerts = %+v", alerts)
+	}
+}
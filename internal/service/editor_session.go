@@ -0,0 +1,86 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	MaxSessionTabs       = 50
+	MaxEditorSessionSize = 2 * 1024 * 1024 // bytes of stored JSON
+)
+
+// EditorSessionState is the unsaved editor state of one user in one
+// workspace, so closing the browser doesn't lose work in progress
+type EditorSessionState struct {
+	Tabs        []SessionTab `json:"tabs"`
+	ActiveTabID string       `json:"activeTabId"`
+}
+
+// SessionTab is an open editor tab. Saved requests and flows are referenced
+// by ID; "draft" tabs are ad-hoc requests that were never saved.
+type SessionTab struct {
+	ID        string          `json:"id"`
+	Kind      string          `json:"kind"` // request | flow | draft
+	RequestID *int64          `json:"requestId,omitempty"`
+	FlowID    *int64          `json:"flowId,omitempty"`
+	Title     string          `json:"title"`
+	Draft     json.RawMessage `json:"draft,omitempty"` // unsaved editor content (JSON object, shape owned by the UI)
+}
+
+// ParseEditorSessionState decodes a stored session; invalid data yields an
+// empty session
+func ParseEditorSessionState(raw string) EditorSessionState {
+	var s EditorSessionState
+	if raw != "" {
+		json.Unmarshal([]byte(raw), &s)
+	}
+	if s.Tabs == nil {
+		s.Tabs = []SessionTab{}
+	}
+	return s
+}
+
+func (s EditorSessionState) Validate() error {
+	if len(s.Tabs) > MaxSessionTabs {
+		return fmt.Errorf("too many tabs (max %d)", MaxSessionTabs)
+	}
+	ids := make(map[string]bool, len(s.Tabs))
+	for i, tab := range s.Tabs {
+		if tab.ID == "" {
+			return fmt.Errorf("tabs[%d].id is required", i)
+		}
+		if ids[tab.ID] {
+			return fmt.Errorf("tabs[%d].id %q is duplicated", i, tab.ID)
+		}
+		ids[tab.ID] = true
+
+		draft := bytes.TrimSpace(tab.Draft)
+		hasDraft := len(draft) > 0 && string(draft) != "null"
+		if hasDraft && draft[0] != '{' {
+			return fmt.Errorf("tabs[%d].draft must be an object", i)
+		}
+
+		switch tab.Kind {
+		case "request":
+			if tab.RequestID == nil {
+				return fmt.Errorf("tabs[%d].requestId is required for request tabs", i)
+			}
+		case "flow":
+			if tab.FlowID == nil {
+				return fmt.Errorf("tabs[%d].flowId is required for flow tabs", i)
+			}
+		case "draft":
+			if !hasDraft {
+				return fmt.Errorf("tabs[%d].draft is required for draft tabs", i)
+			}
+		default:
+			return fmt.Errorf("tabs[%d].kind must be request, flow or draft", i)
+		}
+	}
+	if s.ActiveTabID != "" && !ids[s.ActiveTabID] {
+		return fmt.Errorf("activeTabId %q is not an open tab", s.ActiveTabID)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"relay/internal/repository"
+)
+
+var (
+	ErrEmailNotConfigured = errors.New("SMTP is not configured (set SMTP_HOST and SMTP_FROM)")
+	ErrNoRecipients       = errors.New("no notification emails configured for this workspace")
+)
+
+// SMTPConfig is the server-wide outgoing mail configuration. Connections use
+// STARTTLS when the server offers it; implicit TLS (port 465) is not supported.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPConfigFromEnv reads SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME,
+// SMTP_PASSWORD and SMTP_FROM
+func SMTPConfigFromEnv() SMTPConfig {
+	cfg := SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     587,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+	if p, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && p > 0 {
+		cfg.Port = p
+	}
+	return cfg
+}
+
+func (c SMTPConfig) Enabled() bool {
+	return c.Host != "" && c.From != ""
+}
+
+// EmailNotifier sends monitor alerts and weekly digests to the recipients
+// configured in each workspace's notification settings.
+type EmailNotifier struct {
+	queries *repository.Queries
+	config  SMTPConfig
+	send    func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+	instance *Instance // optional; digests go out only while holding the digest lease
+
+	mu         sync.Mutex
+	digestWeek map[int64]string // workspace → ISO week of the last digest sent
+}
+
+func NewEmailNotifier(queries *repository.Queries, config SMTPConfig) *EmailNotifier {
+	return &EmailNotifier{
+		queries:    queries,
+		config:     config,
+		send:       smtp.SendMail,
+		digestWeek: make(map[int64]string),
+	}
+}
+
+func (n *EmailNotifier) Enabled() bool {
+	return n != nil && n.config.Enabled()
+}
+
+// Send delivers a plain-text email
+func (n *EmailNotifier) Send(to []string, subject, body string) error {
+	if !n.Enabled() {
+		return ErrEmailNotConfigured
+	}
+	if len(to) == 0 {
+		return ErrNoRecipients
+	}
+	envelope := make([]string, 0, len(to))
+	for _, addr := range to {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q", addr)
+		}
+		envelope = append(envelope, parsed.Address)
+	}
+	from, err := mail.ParseAddress(n.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP_FROM %q", n.config.From)
+	}
+
+	var auth smtp.Auth
+	if n.config.Username != "" {
+		auth = smtp.PlainAuth("", n.config.Username, n.config.Password, n.config.Host)
+	}
+	addr := net.JoinHostPort(n.config.Host, strconv.Itoa(n.config.Port))
+	msg := buildEmailMessage(n.config.From, to, subject, body, time.Now())
+	return n.send(addr, auth, from.Address, envelope, msg)
+}
+
+// headerSafe strips line breaks so user-controlled text cannot inject headers
+func headerSafe(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func buildEmailMessage(from string, to []string, subject, body string, date time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", headerSafe(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerSafe(strings.Join(to, ", ")))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerSafe(subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&b)
+	qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	qp.Close()
+	return b.Bytes()
+}
+
+// recipients returns the workspace's notification settings and addresses, or
+// nil when email is unavailable for it
+func (n *EmailNotifier) recipients(ctx context.Context, wsID int64) (NotificationSettings, []string) {
+	if !n.Enabled() {
+		return NotificationSettings{}, nil
+	}
+	raw, err := n.queries.GetWorkspaceSettings(ctx, wsID)
+	if err != nil {
+		return NotificationSettings{}, nil
+	}
+	ns := ParseWorkspaceSettings(raw).Notifications
+	return ns, ns.Emails
+}
+
+// NotifyMonitorTransition emails the workspace when a monitor goes down or recovers
+func (n *EmailNotifier) NotifyMonitorTransition(ctx context.Context, mon repository.Monitor, req repository.Request, check repository.MonitorCheck) error {
+	ns, to := n.recipients(ctx, mon.WorkspaceID)
+	if !ns.MonitorFailures || len(to) == 0 {
+		return nil
+	}
+
+	state := "DOWN"
+	if check.Success == 1 {
+		state = "RECOVERED"
+	}
+	subject := fmt.Sprintf("[Relay] %s: %s", state, req.Name)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Monitor: %s\n", req.Name)
+	fmt.Fprintf(&body, "Request: %s %s\n", req.Method, req.Url)
+	fmt.Fprintf(&body, "Status:  %s\n", state)
+	if check.StatusCode > 0 {
+		fmt.Fprintf(&body, "HTTP:    %d\n", check.StatusCode)
+	}
+	fmt.Fprintf(&body, "Latency: %d ms\n", check.DurationMs)
+	if check.Error != "" {
+		fmt.Fprintf(&body, "Error:   %s\n", check.Error)
+	}
+	if check.CheckedAt.Valid {
+		fmt.Fprintf(&body, "Checked: %s\n", check.CheckedAt.Time.UTC().Format(time.RFC3339))
+	}
+	return n.Send(to, subject, body.String())
+}
+
+// WeeklyDigest renders the last 7 days of request executions and monitor uptime
+func (n *EmailNotifier) WeeklyDigest(ctx context.Context, wsID int64) (subject, body string, err error) {
+	ws, err := n.queries.GetWorkspace(ctx, wsID)
+	if err != nil {
+		return "", "", err
+	}
+	stats, err := n.queries.GetWeeklyHistoryStats(ctx, wsID)
+	if err != nil {
+		return "", "", err
+	}
+	monitors, err := n.queries.ListMonitorWeeklyStats(ctx, wsID)
+	if err != nil {
+		return "", "", err
+	}
+
+	subject = fmt.Sprintf("[Relay] Weekly digest: %s", ws.Name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Workspace: %s\n", ws.Name)
+	fmt.Fprintf(&b, "Period:    last 7 days (until %s)\n\n", time.Now().UTC().Format("2006-01-02"))
+	fmt.Fprintf(&b, "Executions: %d (%d from flows)\n", stats.Executions, stats.FlowExecutions)
+	failRate := 0.0
+	if stats.Executions > 0 {
+		failRate = float64(stats.Failures) / float64(stats.Executions) * 100
+	}
+	fmt.Fprintf(&b, "Failures:   %d (%.1f%%)\n", stats.Failures, failRate)
+	fmt.Fprintf(&b, "Avg latency: %d ms\n", int64(math.Round(stats.AvgDurationMs)))
+
+	if len(monitors) > 0 {
+		b.WriteString("\nMonitors:\n")
+		for _, m := range monitors {
+			uptime := "no checks"
+			if m.Checks > 0 {
+				uptime = fmt.Sprintf("%.2f%% uptime (%d checks)", float64(m.Successes)/float64(m.Checks)*100, m.Checks)
+			}
+			if m.Enabled != 1 {
+				uptime += ", paused"
+			}
+			fmt.Fprintf(&b, "  - %s: %s\n", m.Name, uptime)
+		}
+	}
+	return subject, b.String(), nil
+}
+
+// SendWeeklyDigest emails the digest to the workspace's recipients
+func (n *EmailNotifier) SendWeeklyDigest(ctx context.Context, wsID int64) error {
+	if !n.Enabled() {
+		return ErrEmailNotConfigured
+	}
+	_, to := n.recipients(ctx, wsID)
+	if len(to) == 0 {
+		return ErrNoRecipients
+	}
+	subject, body, err := n.WeeklyDigest(ctx, wsID)
+	if err != nil {
+		return err
+	}
+	return n.Send(to, subject, body)
+}
+
+// digestWeekKey returns the ISO week whose digest is due at t: digests go out
+// on Mondays from 09:00 server time.
+func digestWeekKey(t time.Time) (string, bool) {
+	if t.Weekday() != time.Monday || t.Hour() < 9 {
+		return "", false
+	}
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week), true
+}
+
+// sendDueDigests sends this week's digest to every workspace that enabled it
+func (n *EmailNotifier) sendDueDigests(ctx context.Context, now time.Time) {
+	week, due := digestWeekKey(now)
+	if !due {
+		return
+	}
+	workspaces, err := n.queries.ListWorkspaces(ctx)
+	if err != nil {
+		log.Printf("digest: failed to list workspaces: %v", err)
+		return
+	}
+	for _, ws := range workspaces {
+		n.mu.Lock()
+		sent := n.digestWeek[ws.ID] == week
+		n.mu.Unlock()
+		if sent {
+			continue
+		}
+		ns, to := n.recipients(ctx, ws.ID)
+		if !ns.WeeklyDigest || len(to) == 0 {
+			continue
+		}
+		if err := n.SendWeeklyDigest(ctx, ws.ID); err != nil {
+			log.Printf("digest: workspace %d: %v", ws.ID, err)
+			continue
+		}
+		n.mu.Lock()
+		n.digestWeek[ws.ID] = week
+		n.mu.Unlock()
+	}
+}
+
+// SetInstance makes digests go out from one instance when several share the
+// database
+func (n *EmailNotifier) SetInstance(inst *Instance) {
+	n.instance = inst
+}
+
+// Start sends weekly digests in the background until ctx is cancelled
+func (n *EmailNotifier) Start(ctx context.Context) {
+	if !n.Enabled() {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(15 * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if n.instance.Acquire(ctx, LeaseWeeklyDigest, time.Hour) {
+					n.sendDueDigests(ctx, now)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+type sentEmail struct {
+	addr string
+	from string
+	to   []string
+	msg  string
+}
+
+func newTestNotifier(q *repository.Queries) (*EmailNotifier, *[]sentEmail) {
+	n := NewEmailNotifier(q, SMTPConfig{Host: "smtp.test", Port: 2525, From: "Relay <relay@example.com>"})
+	var sent []sentEmail
+	n.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, sentEmail{addr: addr, from: from, to: to, msg: string(msg)})
+		return nil
+	}
+	return n, &sent
+}
+
+func setNotificationSettings(t *testing.T, q *repository.Queries, settings string) {
+	t.Helper()
+	_, err := q.UpdateWorkspaceSettings(context.Background(), repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: settings, Valid: true},
+		ID:       1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildEmailMessage(t *testing.T) {
+	msg := string(buildEmailMessage("relay@example.com", []string{"a@example.com"}, "DOWN: api\r\nBcc: evil@example.com", "line 1\nline 2", time.Now()))
+
+	if strings.Contains(msg, "\r\nBcc:") {
+		t.Errorf("header injection not prevented:\n%s", msg)
+	}
+	if !strings.Contains(msg, "Subject: DOWN: api  Bcc: evil@example.com\r\n") {
+		t.Errorf("unexpected subject:\n%s", msg)
+	}
+	if !strings.Contains(msg, "\r\n\r\nline 1\r\nline 2") {
+		t.Errorf("body not CRLF-normalized:\n%q", msg)
+	}
+
+	msg = string(buildEmailMessage("relay@example.com", []string{"a@example.com"}, "모니터 장애", "", time.Now()))
+	if !strings.Contains(msg, "Subject: =?utf-8?q?") {
+		t.Errorf("non-ASCII subject not encoded:\n%s", msg)
+	}
+}
+
+func TestEmailNotifier_NotConfigured(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	n := NewEmailNotifier(q, SMTPConfig{})
+	if err := n.Send([]string{"a@example.com"}, "s", "b"); err != ErrEmailNotConfigured {
+		t.Errorf("expected ErrEmailNotConfigured, got %v", err)
+	}
+	if err := n.SendWeeklyDigest(context.Background(), 1); err != ErrEmailNotConfigured {
+		t.Errorf("expected ErrEmailNotConfigured, got %v", err)
+	}
+}
+
+func TestEmailNotifier_MonitorTransitions(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	notifier, sent := newTestNotifier(q)
+	runner := NewMonitorRunner(q, NewRequestExecutor(q, NewVariableResolver(q), nil), notifier)
+	setNotificationSettings(t, q, `{"notifications":{"emails":["ops@example.com"],"monitorFailures":true}}`)
+
+	req, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Orders API", Method: "GET", Url: server.URL, WorkspaceID: 1})
+	mon, _ := q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: req.ID, IntervalSeconds: 60, Enabled: 1})
+
+	for _, fail := range []bool{false, true, true, false, false} {
+		failing.Store(fail)
+		if _, err := runner.Check(ctx, mon); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only state changes are emailed: down once, recovered once
+	if len(*sent) != 2 {
+		t.Fatalf("expected 2 emails, got %d", len(*sent))
+	}
+	down, up := (*sent)[0], (*sent)[1]
+	if !strings.Contains(down.msg, "Subject: [Relay] DOWN: Orders API") || !strings.Contains(down.msg, "HTTP:    500") {
+		t.Errorf("down email:\n%s", down.msg)
+	}
+	if !strings.Contains(up.msg, "Subject: [Relay] RECOVERED: Orders API") {
+		t.Errorf("recovery email:\n%s", up.msg)
+	}
+	if down.addr != "smtp.test:2525" || down.from != "relay@example.com" || len(down.to) != 1 || down.to[0] != "ops@example.com" {
+		t.Errorf("envelope = %s %s %v", down.addr, down.from, down.to)
+	}
+
+	// Alerts disabled: no email
+	setNotificationSettings(t, q, `{"notifications":{"emails":["ops@example.com"]}}`)
+	failing.Store(true)
+	runner.Check(ctx, mon)
+	if len(*sent) != 2 {
+		t.Errorf("expected no email with monitorFailures off, got %d total", len(*sent))
+	}
+}
+
+func TestEmailNotifier_WeeklyDigest(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	notifier, sent := newTestNotifier(q)
+
+	for _, code := range []int64{200, 200, 500} {
+		q.CreateHistory(ctx, repository.CreateHistoryParams{
+			Method:      "GET",
+			Url:         "http://api.test",
+			StatusCode:  sql.NullInt64{Int64: code, Valid: true},
+			DurationMs:  sql.NullInt64{Int64: 30, Valid: true},
+			WorkspaceID: 1,
+		})
+	}
+	req, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Health", Method: "GET", Url: "http://api.test", WorkspaceID: 1})
+	mon, _ := q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: req.ID, IntervalSeconds: 60, Enabled: 1})
+	q.CreateMonitorCheck(ctx, repository.CreateMonitorCheckParams{MonitorID: mon.ID, StatusCode: 200, Success: 1})
+	q.CreateMonitorCheck(ctx, repository.CreateMonitorCheckParams{MonitorID: mon.ID, StatusCode: 503})
+
+	subject, body, err := notifier.WeeklyDigest(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "[Relay] Weekly digest: Default" {
+		t.Errorf("subject = %q", subject)
+	}
+	for _, want := range []string{"Executions: 3", "Failures:   1 (33.3%)", "Avg latency: 30 ms", "Health: 50.00% uptime (2 checks)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("digest missing %q:\n%s", want, body)
+		}
+	}
+
+	// Scheduled: Mondays from 09:00, once per week, only when enabled
+	monday := time.Date(2024, 6, 3, 9, 30, 0, 0, time.Local)
+	notifier.sendDueDigests(ctx, monday)
+	if len(*sent) != 0 {
+		t.Fatalf("digest sent without weeklyDigest enabled")
+	}
+	setNotificationSettings(t, q, `{"notifications":{"emails":["team@example.com"],"weeklyDigest":true}}`)
+	notifier.sendDueDigests(ctx, monday.Add(-time.Hour)) // 08:30
+	notifier.sendDueDigests(ctx, monday)
+	notifier.sendDueDigests(ctx, monday.Add(2*time.Hour))
+	if len(*sent) != 1 {
+		t.Fatalf("expected exactly 1 digest, got %d", len(*sent))
+	}
+	notifier.sendDueDigests(ctx, monday.AddDate(0, 0, 7))
+	if len(*sent) != 2 {
+		t.Errorf("expected a digest the following Monday, got %d total", len(*sent))
+	}
+}
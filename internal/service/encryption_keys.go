@@ -0,0 +1,658 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"relay/internal/repository"
+)
+
+// Workspace encryption keys seal secret variable values and TLS certificate
+// files at rest with AES-256-GCM. A key is derived from a master passphrase
+// (PBKDF2-SHA256, like encrypted export bundles) or read from an environment
+// variable provisioned by a KMS. Passphrase keys are only held in memory,
+// after an unlock; while a workspace is locked, executions that need one of
+// its secrets fail with ErrEncryptionLocked instead of sending ciphertext.
+const (
+	KeySourcePassphrase = "passphrase"
+	KeySourceKMS        = "kms"
+
+	sealedValuePrefix  = "enc:v1:"
+	keyCheckPlaintext  = "relay-key-check"
+	lockedSecretMarker = "\x00locked-secret:"
+)
+
+// sealedFileHeader starts every sealed file, ahead of nonce and ciphertext
+var sealedFileHeader = []byte("RELAYENC1\n")
+
+var kmsKeyEnvPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var (
+	// ErrEncryptionLocked is returned when a workspace's key is not available:
+	// its passphrase has not been unlocked or its KMS variable is missing
+	ErrEncryptionLocked = errors.New("workspace encryption key is locked")
+	// ErrNoEncryptionKey is returned for workspaces without a key
+	ErrNoEncryptionKey = errors.New("workspace has no encryption key")
+	// ErrInvalidKey is returned when a new KMS key cannot be read
+	ErrInvalidKey = errors.New("invalid encryption key")
+)
+
+// KeySpec selects a workspace key: a passphrase, or the name of the
+// environment variable holding a base64 encoded 32-byte KMS data key
+type KeySpec struct {
+	Source     string `json:"source"`
+	Passphrase string `json:"passphrase,omitempty"`
+	KMSKeyEnv  string `json:"kmsKeyEnv,omitempty"`
+}
+
+func (s KeySpec) Validate() error {
+	switch s.Source {
+	case KeySourcePassphrase:
+		if len(s.Passphrase) < MinBundlePassphrase {
+			return fmt.Errorf("passphrase must be at least %d characters", MinBundlePassphrase)
+		}
+	case KeySourceKMS:
+		if !kmsKeyEnvPattern.MatchString(s.KMSKeyEnv) {
+			return errors.New("kmsKeyEnv must be an environment variable name")
+		}
+	default:
+		return fmt.Errorf("source must be %q or %q", KeySourcePassphrase, KeySourceKMS)
+	}
+	return nil
+}
+
+// ResealResult counts the values and files re-encrypted by a key change
+type ResealResult struct {
+	Secrets int `json:"secrets"`
+	Files   int `json:"files"`
+}
+
+// KeyRing holds the unlocked workspace keys. All methods are safe on a nil
+// KeyRing, which behaves as if no workspace had a key.
+type KeyRing struct {
+	db         *sql.DB
+	queries    *repository.Queries
+	fs         *FileStorage
+	getenv     func(string) string
+	iterations int
+
+	mu       sync.RWMutex
+	unlocked map[int64]cipher.AEAD // passphrase keys by workspace
+}
+
+func NewKeyRing(db *sql.DB, queries *repository.Queries, fs *FileStorage) *KeyRing {
+	return &KeyRing{
+		db:         db,
+		queries:    queries,
+		fs:         fs,
+		getenv:     os.Getenv,
+		iterations: bundleIterations,
+		unlocked:   map[int64]cipher.AEAD{},
+	}
+}
+
+// Status returns the workspace's key and whether it is locked
+func (k *KeyRing) Status(ctx context.Context, wsID int64) (repository.WorkspaceEncryptionKey, bool, error) {
+	if k == nil {
+		return repository.WorkspaceEncryptionKey{}, false, ErrNoEncryptionKey
+	}
+	_, row, err := k.key(ctx, wsID)
+	if errors.Is(err, ErrEncryptionLocked) {
+		return row, true, nil
+	}
+	return row, false, err
+}
+
+// Unlock derives the workspace's passphrase key and keeps it in memory; for
+// KMS keys it only checks that the key is available
+func (k *KeyRing) Unlock(ctx context.Context, wsID int64, passphrase string) error {
+	row, err := k.row(ctx, wsID)
+	if err != nil {
+		return err
+	}
+	if row.Source != KeySourcePassphrase {
+		_, _, err := k.key(ctx, wsID) // KMS keys unlock once their variable is set
+		return err
+	}
+	salt, err := base64.StdEncoding.DecodeString(row.Salt)
+	if err != nil {
+		return fmt.Errorf("stored key salt: %w", err)
+	}
+	gcm, err := bundleGCM(passphrase, salt, int(row.Iterations))
+	if err != nil {
+		return err
+	}
+	if check, err := openValue(gcm, row.KeyCheck); err != nil || check != keyCheckPlaintext {
+		return ErrWrongPassphrase
+	}
+	k.mu.Lock()
+	k.unlocked[wsID] = gcm
+	k.mu.Unlock()
+	return nil
+}
+
+// Lock forgets the workspace's passphrase key until the next unlock
+func (k *KeyRing) Lock(wsID int64) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	delete(k.unlocked, wsID)
+	k.mu.Unlock()
+}
+
+// Rotate sets the workspace's key, re-encrypting its secrets and TLS files
+// under it. Replacing an existing key needs that key to be available.
+func (k *KeyRing) Rotate(ctx context.Context, wsID int64, spec KeySpec) (repository.WorkspaceEncryptionKey, ResealResult, error) {
+	var row repository.WorkspaceEncryptionKey
+	if k == nil {
+		return row, ResealResult{}, errors.New("encryption keys are not available")
+	}
+	if err := spec.Validate(); err != nil {
+		return row, ResealResult{}, err
+	}
+	old, _, err := k.key(ctx, wsID)
+	if err != nil && !errors.Is(err, ErrNoEncryptionKey) {
+		return row, ResealResult{}, err
+	}
+
+	params := repository.UpsertWorkspaceEncryptionKeyParams{WorkspaceID: wsID, Source: spec.Source}
+	var next cipher.AEAD
+	if spec.Source == KeySourceKMS {
+		params.KmsKeyEnv = spec.KMSKeyEnv
+		next, err = kmsGCM(k.getenv(spec.KMSKeyEnv))
+		if err != nil {
+			return row, ResealResult{}, fmt.Errorf("%w: $%s: %v", ErrInvalidKey, spec.KMSKeyEnv, err)
+		}
+	} else {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return row, ResealResult{}, err
+		}
+		params.Salt = base64.StdEncoding.EncodeToString(salt)
+		params.Iterations = int64(k.iterations)
+		if next, err = bundleGCM(spec.Passphrase, salt, k.iterations); err != nil {
+			return row, ResealResult{}, err
+		}
+	}
+	params.KeyCheck = sealValue(next, keyCheckPlaintext)
+
+	result, err := k.reseal(ctx, wsID, old, next, func(q *repository.Queries) error {
+		row, err = q.UpsertWorkspaceEncryptionKey(ctx, params)
+		return err
+	})
+	if err != nil {
+		return row, result, err
+	}
+	k.mu.Lock()
+	if spec.Source == KeySourcePassphrase {
+		k.unlocked[wsID] = next
+	} else {
+		delete(k.unlocked, wsID)
+	}
+	k.mu.Unlock()
+	return row, result, nil
+}
+
+// Remove decrypts the workspace's secrets and TLS files and drops its key
+func (k *KeyRing) Remove(ctx context.Context, wsID int64) (ResealResult, error) {
+	if k == nil {
+		return ResealResult{}, ErrNoEncryptionKey
+	}
+	old, _, err := k.key(ctx, wsID)
+	if err != nil {
+		return ResealResult{}, err
+	}
+	result, err := k.reseal(ctx, wsID, old, nil, func(q *repository.Queries) error {
+		return q.DeleteWorkspaceEncryptionKey(ctx, wsID)
+	})
+	if err == nil {
+		k.Lock(wsID)
+	}
+	return result, err
+}
+
+// SealVariables brings a variable set in line with the workspace's key
+// before it is stored: plaintext secrets are sealed and values that are no
+// longer secret are opened. Without a key the set is left as is.
+func (k *KeyRing) SealVariables(ctx context.Context, wsID int64, values map[string]string, secrets map[string]bool) error {
+	if k == nil {
+		return nil
+	}
+	pending := false
+	for name, v := range values {
+		if secrets[name] != isSealedValue(v) {
+			pending = true
+			break
+		}
+	}
+	if !pending {
+		return nil
+	}
+	gcm, _, err := k.key(ctx, wsID)
+	if errors.Is(err, ErrNoEncryptionKey) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for name, v := range values {
+		if secrets[name] == isSealedValue(v) {
+			continue
+		}
+		if values[name], err = resealValue(v, gcm, gcm, secrets[name]); err != nil {
+			return fmt.Errorf("variable %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SealFiles seals the given workspace files that are still plaintext; it is
+// a no-op for workspaces without a key
+func (k *KeyRing) SealFiles(ctx context.Context, wsID int64, ids []int64) error {
+	if k == nil || k.fs == nil || len(ids) == 0 {
+		return nil
+	}
+	gcm, _, err := k.key(ctx, wsID)
+	if errors.Is(err, ErrNoEncryptionKey) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		f, err := k.queries.GetUploadedFile(ctx, id)
+		if err != nil || f.WorkspaceID != wsID {
+			return fmt.Errorf("file %d not found", id)
+		}
+		data, err := k.fs.Load(f.StoredName)
+		if err != nil {
+			return err
+		}
+		if isSealedFile(data) {
+			continue
+		}
+		if err := k.replaceFile(ctx, k.queries, f, sealFile(gcm, data)); err != nil {
+			return err
+		}
+		k.fs.Delete(f.StoredName)
+	}
+	return nil
+}
+
+// openVars returns vars with sealed values decrypted. Values that cannot be
+// decrypted are replaced by a marker, which fails any request they end up in.
+func (k *KeyRing) openVars(ctx context.Context, wsID int64, vars map[string]string) map[string]string {
+	sealed := false
+	for _, v := range vars {
+		if isSealedValue(v) {
+			sealed = true
+			break
+		}
+	}
+	if !sealed {
+		return vars
+	}
+	var gcm cipher.AEAD
+	if k != nil {
+		gcm, _, _ = k.key(ctx, wsID)
+	}
+	out := make(map[string]string, len(vars))
+	for name, v := range vars {
+		if isSealedValue(v) {
+			plain, err := openValue(gcm, v)
+			if err != nil {
+				plain = lockedSecretMarker + name + "\x00"
+			}
+			v = plain
+		}
+		out[name] = v
+	}
+	return out
+}
+
+// openFile decrypts a sealed workspace file
+func (k *KeyRing) openFile(ctx context.Context, wsID int64, data []byte) ([]byte, error) {
+	if k == nil {
+		return nil, ErrEncryptionLocked
+	}
+	gcm, _, err := k.key(ctx, wsID)
+	if err != nil {
+		return nil, err
+	}
+	return openSealedFile(gcm, data)
+}
+
+func (k *KeyRing) row(ctx context.Context, wsID int64) (repository.WorkspaceEncryptionKey, error) {
+	if k == nil {
+		return repository.WorkspaceEncryptionKey{}, ErrNoEncryptionKey
+	}
+	row, err := k.queries.GetWorkspaceEncryptionKey(ctx, wsID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return row, ErrNoEncryptionKey
+	}
+	return row, err
+}
+
+// key returns the workspace's key; KMS keys are read from the environment
+// on every use so a revoked variable locks the workspace right away
+func (k *KeyRing) key(ctx context.Context, wsID int64) (cipher.AEAD, repository.WorkspaceEncryptionKey, error) {
+	row, err := k.row(ctx, wsID)
+	if err != nil {
+		return nil, row, err
+	}
+	if row.Source == KeySourceKMS {
+		gcm, err := kmsGCM(k.getenv(row.KmsKeyEnv))
+		if err != nil {
+			return nil, row, fmt.Errorf("%w: $%s: %v", ErrEncryptionLocked, row.KmsKeyEnv, err)
+		}
+		if check, err := openValue(gcm, row.KeyCheck); err != nil || check != keyCheckPlaintext {
+			return nil, row, fmt.Errorf("%w: $%s does not hold the workspace key", ErrEncryptionLocked, row.KmsKeyEnv)
+		}
+		return gcm, row, nil
+	}
+	k.mu.RLock()
+	gcm, ok := k.unlocked[wsID]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, row, ErrEncryptionLocked
+	}
+	return gcm, row, nil
+}
+
+// reseal moves every secret and TLS file of the workspace from one key to
+// another (nil: plaintext) and stores the key change with saveKey, all in
+// one transaction. Files are rewritten under new names, so a failed rotation
+// leaves the old ones in place.
+func (k *KeyRing) reseal(ctx context.Context, wsID int64, from, to cipher.AEAD, saveKey func(*repository.Queries) error) (ResealResult, error) {
+	var result ResealResult
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+	q := k.queries.WithTx(tx)
+
+	ws, err := q.GetWorkspace(ctx, wsID)
+	if err != nil {
+		return result, err
+	}
+	vars, n, err := resealVariables(ws.Variables, ws.SecretVariables, from, to)
+	if err != nil {
+		return result, fmt.Errorf("workspace variables: %w", err)
+	}
+	if n > 0 {
+		if _, err := q.UpdateWorkspaceVariableSet(ctx, repository.UpdateWorkspaceVariableSetParams{Variables: vars, SecretVariables: ws.SecretVariables, ID: wsID}); err != nil {
+			return result, err
+		}
+	}
+	result.Secrets += n
+
+	collections, err := q.ListCollections(ctx, wsID)
+	if err != nil {
+		return result, err
+	}
+	for _, c := range collections {
+		vars, n, err := resealVariables(c.Variables, c.SecretVariables, from, to)
+		if err != nil {
+			return result, fmt.Errorf("collection %q variables: %w", c.Name, err)
+		}
+		if n == 0 {
+			continue
+		}
+		if _, err := q.UpdateCollectionVariableSet(ctx, repository.UpdateCollectionVariableSetParams{Variables: vars, SecretVariables: c.SecretVariables, ID: c.ID}); err != nil {
+			return result, err
+		}
+		result.Secrets += n
+	}
+
+	var written, replaced []string
+	fail := func(err error) (ResealResult, error) {
+		for _, name := range written {
+			k.fs.Delete(name)
+		}
+		return result, err
+	}
+	if k.fs != nil {
+		for _, id := range ParseWorkspaceSettings(ws.Settings).TLS.FileIDs() {
+			f, err := q.GetUploadedFile(ctx, id)
+			if err != nil || f.WorkspaceID != wsID {
+				continue // settings were validated on save; the file is gone since
+			}
+			data, err := k.fs.Load(f.StoredName)
+			if err != nil {
+				return fail(fmt.Errorf("file %q: %w", f.OriginalName, err))
+			}
+			if isSealedFile(data) {
+				if data, err = openSealedFile(from, data); err != nil {
+					return fail(fmt.Errorf("file %q: %w", f.OriginalName, err))
+				}
+			}
+			if to != nil {
+				data = sealFile(to, data)
+			}
+			name, _, err := k.fs.Store(bytes.NewReader(data))
+			if err != nil {
+				return fail(err)
+			}
+			written = append(written, name)
+			if err := q.UpdateUploadedFileStoredName(ctx, repository.UpdateUploadedFileStoredNameParams{StoredName: name, ID: f.ID}); err != nil {
+				return fail(err)
+			}
+			replaced = append(replaced, f.StoredName)
+			result.Files++
+		}
+	}
+
+	if err := saveKey(q); err != nil {
+		return fail(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+	for _, name := range replaced {
+		k.fs.Delete(name)
+	}
+	return result, nil
+}
+
+// replaceFile stores data as the new content of f
+func (k *KeyRing) replaceFile(ctx context.Context, q *repository.Queries, f repository.UploadedFile, data []byte) error {
+	name, _, err := k.fs.Store(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if err := q.UpdateUploadedFileStoredName(ctx, repository.UpdateUploadedFileStoredNameParams{StoredName: name, ID: f.ID}); err != nil {
+		k.fs.Delete(name)
+		return err
+	}
+	return nil
+}
+
+// resealVariables moves the secret values of a variables column from one
+// key to another, returning the new column and how many values it changed
+func resealVariables(vars, secrets sql.NullString, from, to cipher.AEAD) (sql.NullString, int, error) {
+	if !vars.Valid || vars.String == "" || !secrets.Valid || secrets.String == "" {
+		return vars, 0, nil
+	}
+	var values map[string]string
+	var names []string
+	if json.Unmarshal([]byte(vars.String), &values) != nil || json.Unmarshal([]byte(secrets.String), &names) != nil {
+		return vars, 0, nil
+	}
+	n := 0
+	for _, name := range names {
+		v, ok := values[name]
+		if !ok {
+			continue
+		}
+		var err error
+		if isSealedValue(v) {
+			if v, err = openValue(from, v); err != nil {
+				return vars, 0, fmt.Errorf("secret %q: %w", name, err)
+			}
+		}
+		if to != nil {
+			v = sealValue(to, v)
+		}
+		values[name] = v
+		n++
+	}
+	if n == 0 {
+		return vars, 0, nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return vars, 0, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, n, nil
+}
+
+// resealValue seals v with to when secret, else returns it opened with from
+func resealValue(v string, from, to cipher.AEAD, secret bool) (string, error) {
+	if isSealedValue(v) {
+		plain, err := openValue(from, v)
+		if err != nil {
+			return "", err
+		}
+		v = plain
+	}
+	if !secret {
+		return v, nil
+	}
+	return sealValue(to, v), nil
+}
+
+func isSealedValue(v string) bool {
+	return strings.HasPrefix(v, sealedValuePrefix)
+}
+
+func isSealedFile(data []byte) bool {
+	return bytes.HasPrefix(data, sealedFileHeader)
+}
+
+func sealValue(gcm cipher.AEAD, plaintext string) string {
+	return sealedValuePrefix + base64.StdEncoding.EncodeToString(sealBytes(gcm, []byte(plaintext)))
+}
+
+func openValue(gcm cipher.AEAD, v string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(v, sealedValuePrefix))
+	if err != nil {
+		return "", fmt.Errorf("sealed value: %w", err)
+	}
+	plain, err := openBytes(gcm, data)
+	return string(plain), err
+}
+
+func sealFile(gcm cipher.AEAD, data []byte) []byte {
+	return append(bytes.Clone(sealedFileHeader), sealBytes(gcm, data)...)
+}
+
+func openSealedFile(gcm cipher.AEAD, data []byte) ([]byte, error) {
+	return openBytes(gcm, data[len(sealedFileHeader):])
+}
+
+// sealBytes returns nonce || ciphertext
+func sealBytes(gcm cipher.AEAD, plaintext []byte) []byte {
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	rand.Read(nonce)
+	return gcm.Seal(nonce, nonce, plaintext, nil)
+}
+
+func openBytes(gcm cipher.AEAD, data []byte) ([]byte, error) {
+	if gcm == nil {
+		return nil, ErrEncryptionLocked
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("sealed data is truncated")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("sealed data does not decrypt with the workspace key")
+	}
+	return plain, nil
+}
+
+// kmsGCM builds the cipher for a base64 encoded 32-byte KMS data key
+func kmsGCM(encoded string) (cipher.AEAD, error) {
+	if encoded == "" {
+		return nil, errors.New("not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("must be a base64 encoded 32-byte key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// lockedSecretError reports the first secret in text that could not be
+// decrypted
+func lockedSecretError(text string) error {
+	i := strings.Index(text, lockedSecretMarker)
+	if i < 0 {
+		return nil
+	}
+	name := text[i+len(lockedSecretMarker):]
+	if end := strings.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	return fmt.Errorf("%w (needed for secret variable %q)", ErrEncryptionLocked, name)
+}
+
+type lockedSecretsKey struct{}
+
+// lockedSecrets collects the secrets an execution needed but could not
+// decrypt, so it fails before anything is sent
+type lockedSecrets struct {
+	mu  sync.Mutex
+	err error
+}
+
+func watchLockedSecrets(ctx context.Context) (context.Context, *lockedSecrets) {
+	l := &lockedSecrets{}
+	return context.WithValue(ctx, lockedSecretsKey{}, l), l
+}
+
+func lockedSecretsFrom(ctx context.Context) *lockedSecrets {
+	l, _ := ctx.Value(lockedSecretsKey{}).(*lockedSecrets)
+	return l
+}
+
+// note records the first locked secret found in resolved text
+func (l *lockedSecrets) note(text string) {
+	if l == nil {
+		return
+	}
+	if err := lockedSecretError(text); err != nil {
+		l.mu.Lock()
+		if l.err == nil {
+			l.err = err
+		}
+		l.mu.Unlock()
+	}
+}
+
+func (l *lockedSecrets) check() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestKeyRing_RotateLockAndRemove(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := NewKeyRing(db, q, fs)
+	keys.iterations = 1000
+	env := map[string]string{}
+	keys.getenv = func(name string) string { return env[name] }
+	fs.SetKeyRing(keys)
+	vr := NewVariableResolver(q)
+	vr.SetKeyRing(keys)
+	re := NewRequestExecutor(q, vr, fs)
+	ctx := middleware.WithWorkspaceID(context.Background(), 1)
+
+	q.UpdateWorkspaceVariableSet(ctx, repository.UpdateWorkspaceVariableSetParams{
+		Variables:       sql.NullString{String: `{"token":"s3cret","host":"api"}`, Valid: true},
+		SecretVariables: sql.NullString{String: `["token"]`, Valid: true},
+		ID:              1,
+	})
+	c, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Col", WorkspaceID: 1})
+	q.UpdateCollectionVariableSet(ctx, repository.UpdateCollectionVariableSetParams{
+		Variables:       sql.NullString{String: `{"apiKey":"k3y"}`, Valid: true},
+		SecretVariables: sql.NullString{String: `["apiKey"]`, Valid: true},
+		ID:              c.ID,
+	})
+	caPEM, _ := selfSignedPEM(t, "ca")
+	f, _ := q.GetUploadedFile(ctx, uploadTestFile(t, q, fs, caPEM))
+	q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: fmt.Sprintf(`{"tls":{"verify":true,"caFileId":%d}}`, f.ID), Valid: true},
+		ID:       1,
+	})
+
+	row, resealed, err := keys.Rotate(ctx, 1, KeySpec{Source: KeySourcePassphrase, Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resealed != (ResealResult{Secrets: 2, Files: 1}) || row.Version != 1 {
+		t.Errorf("rotate: resealed=%+v version=%d", resealed, row.Version)
+	}
+	raw := vr.loadWorkspaceVars(ctx, 1)
+	if !isSealedValue(raw["token"]) || raw["host"] != "api" {
+		t.Errorf("stored vars = %v", raw)
+	}
+	f, _ = q.GetUploadedFile(ctx, f.ID)
+	if data, _ := fs.Load(f.StoredName); !isSealedFile(data) {
+		t.Errorf("file on disk is not sealed: %q", data)
+	}
+	if out, err := vr.Resolve(ctx, "{{token}}/{{apiKey}}", nil, c.ID); err != nil || out != "s3cret/k3y" {
+		t.Errorf("unlocked resolve = %q, %v", out, err)
+	}
+	if data, err := loadWorkspaceFile(ctx, q, fs, f.ID); err != nil || !bytes.Equal(data, caPEM) {
+		t.Errorf("unlocked file = %q, %v", data, err)
+	}
+
+	// Locked: secret-dependent executions fail before sending
+	keys.Lock(1)
+	if _, err := vr.Resolve(ctx, "{{token}}", nil); !errors.Is(err, ErrEncryptionLocked) || !strings.Contains(err.Error(), `"token"`) {
+		t.Errorf("locked resolve error = %v", err)
+	}
+	if out, err := vr.Resolve(ctx, "{{host}}", nil); err != nil || out != "api" {
+		t.Errorf("non-secret while locked = %q, %v", out, err)
+	}
+	result, _ := re.ExecuteAdhoc(ctx, "POST", srv.URL, "{}", `{"token":"{{token}}"}`, nil, nil)
+	if !strings.Contains(result.Error, "workspace encryption key is locked") || hits != 0 {
+		t.Errorf("locked execution: error=%q hits=%d", result.Error, hits)
+	}
+	if _, err := loadWorkspaceFile(ctx, q, fs, f.ID); !errors.Is(err, ErrEncryptionLocked) {
+		t.Errorf("locked file error = %v", err)
+	}
+	if _, _, err := keys.Rotate(ctx, 1, KeySpec{Source: KeySourcePassphrase, Passphrase: "another one"}); !errors.Is(err, ErrEncryptionLocked) {
+		t.Errorf("rotate while locked = %v", err)
+	}
+
+	if err := keys.Unlock(ctx, 1, "wrong horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("wrong passphrase = %v", err)
+	}
+	if err := keys.Unlock(ctx, 1, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ = re.ExecuteAdhoc(ctx, "POST", srv.URL, "{}", `{{token}}`, nil, nil); result.Error != "" || hits != 1 {
+		t.Errorf("unlocked execution: error=%q hits=%d", result.Error, hits)
+	}
+
+	// Rotating to a KMS key re-encrypts under it; the variable going away locks
+	if _, _, err := keys.Rotate(ctx, 1, KeySpec{Source: KeySourceKMS, KMSKeyEnv: "RELAY_KEY"}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("unset KMS variable = %v", err)
+	}
+	env["RELAY_KEY"] = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	if row, _, err = keys.Rotate(ctx, 1, KeySpec{Source: KeySourceKMS, KMSKeyEnv: "RELAY_KEY"}); err != nil || row.Version != 2 {
+		t.Fatalf("rotate to KMS: version=%d err=%v", row.Version, err)
+	}
+	if out, _ := vr.Resolve(ctx, "{{token}}", nil); out != "s3cret" {
+		t.Errorf("KMS resolve = %q", out)
+	}
+	delete(env, "RELAY_KEY")
+	if _, locked, _ := keys.Status(ctx, 1); !locked {
+		t.Error("KMS key without its variable is not locked")
+	}
+	env["RELAY_KEY"] = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+
+	if resealed, err = keys.Remove(ctx, 1); err != nil || resealed.Secrets != 2 {
+		t.Fatalf("remove: %+v, %v", resealed, err)
+	}
+	if raw := vr.loadCollectionVars(ctx, c.ID); raw["apiKey"] != "k3y" {
+		t.Errorf("collection vars after remove = %v", raw)
+	}
+	f, _ = q.GetUploadedFile(ctx, f.ID)
+	if data, _ := fs.Load(f.StoredName); !bytes.Equal(data, caPEM) {
+		t.Errorf("file after remove = %q", data)
+	}
+	if _, _, err := keys.Status(ctx, 1); !errors.Is(err, ErrNoEncryptionKey) {
+		t.Errorf("status after remove = %v", err)
+	}
+}
+
+func TestKeyRing_SealVariables(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	keys := NewKeyRing(db, q, nil)
+	keys.iterations = 1000
+	ctx := context.Background()
+
+	// Without a key the values stay as they are
+	values := map[string]string{"token": "s3cret"}
+	if err := keys.SealVariables(ctx, 1, values, map[string]bool{"token": true}); err != nil || values["token"] != "s3cret" {
+		t.Errorf("no key: %v, %v", values, err)
+	}
+
+	if _, _, err := keys.Rotate(ctx, 1, KeySpec{Source: KeySourcePassphrase, Passphrase: "correct horse"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.SealVariables(ctx, 1, values, map[string]bool{"token": true}); err != nil || !isSealedValue(values["token"]) {
+		t.Errorf("sealed: %v, %v", values, err)
+	}
+	// A value that stops being secret is stored in plaintext again
+	if err := keys.SealVariables(ctx, 1, values, map[string]bool{}); err != nil || values["token"] != "s3cret" {
+		t.Errorf("unsealed: %v, %v", values, err)
+	}
+	keys.Lock(1)
+	if err := keys.SealVariables(ctx, 1, values, map[string]bool{"token": true}); !errors.Is(err, ErrEncryptionLocked) {
+		t.Errorf("locked: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"relay/internal/repository"
+)
+
+// MaxEnvironmentDepth bounds an inheritance chain, the environment itself included
+const MaxEnvironmentDepth = 10
+
+// EnvironmentChain returns env followed by its ancestors, nearest first. A
+// missing parent ends the chain, and a cycle is cut at the first repeat.
+func EnvironmentChain(ctx context.Context, queries *repository.Queries, env repository.Environment) []repository.Environment {
+	chain := []repository.Environment{env}
+	seen := map[int64]bool{env.ID: true}
+	for env.ParentID.Valid && len(chain) < MaxEnvironmentDepth {
+		parent, err := queries.GetEnvironment(ctx, env.ParentID.Int64)
+		if err != nil || seen[parent.ID] || parent.WorkspaceID != env.WorkspaceID {
+			break
+		}
+		seen[parent.ID] = true
+		chain = append(chain, parent)
+		env = parent
+	}
+	return chain
+}
+
+// MergeEnvironmentVars layers the chain's variables from the root down, so
+// nearer environments override their ancestors key by key
+func MergeEnvironmentVars(chain []repository.Environment) map[string]string {
+	vars := make(map[string]string)
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !chain[i].Variables.Valid {
+			continue
+		}
+		var own map[string]string
+		json.Unmarshal([]byte(chain[i].Variables.String), &own)
+		for k, v := range own {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// CheckEnvironmentParent validates making parentID the parent of envID (0
+// for an environment not created yet)
+func CheckEnvironmentParent(ctx context.Context, queries *repository.Queries, workspaceID, envID, parentID int64) error {
+	if parentID == envID {
+		return fmt.Errorf("an environment cannot inherit from itself")
+	}
+	parent, err := queries.GetEnvironment(ctx, parentID)
+	if err != nil || parent.WorkspaceID != workspaceID {
+		return fmt.Errorf("parent environment %d not found", parentID)
+	}
+	chain := EnvironmentChain(ctx, queries, parent)
+	for _, ancestor := range chain {
+		if ancestor.ID == envID {
+			return fmt.Errorf("environment %q already inherits from this environment", parent.Name)
+		}
+	}
+	if len(chain)+1 > MaxEnvironmentDepth {
+		return fmt.Errorf("inheritance is limited to %d levels", MaxEnvironmentDepth)
+	}
+	return nil
+}
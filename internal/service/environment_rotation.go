@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// Rotation interval bounds (seconds)
+const (
+	MinRotationInterval = 60
+	MaxRotationInterval = 30 * 24 * 60 * 60
+)
+
+const (
+	rotationTick     = 30 * time.Second // how often due rotations are looked up
+	rotationTimeout  = 5 * time.Minute  // per flow run
+	rotationLeaseTTL = 15 * time.Minute // covers a round of flow runs
+)
+
+// EnvironmentRotator runs the flows of environment rotations on their
+// interval and writes the flows' outputs into the rotation's environment,
+// e.g. minting a fresh API key nightly. The flow resolves variables against
+// the active environment like any run; only its outputs go to the target
+// environment, which need not be the active one. Runs are not written to
+// request history.
+type EnvironmentRotator struct {
+	queries  *repository.Queries
+	runner   *FlowRunner
+	instance *Instance // optional; rotations run only while holding the lease
+	jobs     *JobQueue // optional; scheduled rotations run as queued jobs
+}
+
+func NewEnvironmentRotator(queries *repository.Queries, runner *FlowRunner) *EnvironmentRotator {
+	return &EnvironmentRotator{queries: queries, runner: runner}
+}
+
+// SetInstance makes each due rotation run once when several instances share
+// the database
+func (r *EnvironmentRotator) SetInstance(inst *Instance) {
+	r.instance = inst
+}
+
+// SetJobQueue runs scheduled rotations on the job queue: each tick queues a
+// job per due rotation instead of running them itself
+func (r *EnvironmentRotator) SetJobQueue(jq *JobQueue) {
+	r.jobs = jq
+	jq.Register(JobTypeEnvironmentRotation, func(ctx context.Context, job repository.Job) (interface{}, error) {
+		rot, ok, err := loadScheduled(ctx, job, r.queries.GetEnvironmentRotation)
+		if !ok || rot.Enabled == 0 {
+			return nil, err
+		}
+		run, err := r.rotate(ctx, rot, true)
+		if err != nil || run.ID == 0 {
+			return nil, err
+		}
+		return map[string]interface{}{"runId": run.ID, "success": run.Success == 1}, nil
+	})
+}
+
+// Start runs due rotations in the background until ctx is cancelled
+func (r *EnvironmentRotator) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(rotationTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !r.instance.Acquire(ctx, LeaseEnvironmentRotations, rotationLeaseTTL) {
+					continue
+				}
+				if r.jobs != nil {
+					r.EnqueueDue(ctx)
+				} else {
+					r.RunDue(ctx)
+				}
+			}
+		}
+	}()
+}
+
+// RunDue runs every enabled rotation whose interval has elapsed, one at a
+// time, and prunes old runs. It returns the number of rotations run.
+func (r *EnvironmentRotator) RunDue(ctx context.Context) int {
+	due, err := r.queries.ListDueEnvironmentRotations(ctx)
+	if err != nil {
+		log.Printf("rotation: failed to list due rotations: %v", err)
+		return 0
+	}
+	for _, rot := range due {
+		if _, err := r.rotate(ctx, rot, true); err != nil {
+			log.Printf("rotation %d: %v", rot.ID, err)
+		}
+	}
+	if err := r.queries.PruneEnvironmentRotationRuns(ctx); err != nil {
+		log.Printf("rotation: failed to prune runs: %v", err)
+	}
+	return len(due)
+}
+
+// EnqueueDue queues a run of every enabled rotation whose interval has
+// elapsed and prunes old runs. It returns the number of due rotations.
+func (r *EnvironmentRotator) EnqueueDue(ctx context.Context) int {
+	due, err := r.queries.ListDueEnvironmentRotations(ctx)
+	if err != nil {
+		log.Printf("rotation: failed to list due rotations: %v", err)
+		return 0
+	}
+	for _, rot := range due {
+		r.jobs.enqueueScheduled(ctx, JobTypeEnvironmentRotation, rot.ID, rot.WorkspaceID)
+	}
+	if err := r.queries.PruneEnvironmentRotationRuns(ctx); err != nil {
+		log.Printf("rotation: failed to prune runs: %v", err)
+	}
+	return len(due)
+}
+
+// Rotate runs the rotation now, independent of its schedule and quota
+func (r *EnvironmentRotator) Rotate(ctx context.Context, rot repository.EnvironmentRotation) (repository.EnvironmentRotationRun, error) {
+	return r.rotate(ctx, rot, false)
+}
+
+// rotate runs the flow and records the run; a failed flow leaves the
+// environment untouched
+func (r *EnvironmentRotator) rotate(ctx context.Context, rot repository.EnvironmentRotation, scheduled bool) (repository.EnvironmentRotationRun, error) {
+	if scheduled {
+		var exceeded *QuotaExceededError
+		if err := CheckQuotas(ctx, r.queries, rot.WorkspaceID, QuotaScheduledRuns); errors.As(err, &exceeded) {
+			return repository.EnvironmentRotationRun{}, r.queries.MarkEnvironmentRotationRun(ctx, rot.ID)
+		}
+	}
+
+	runCtx, cancel := context.WithTimeout(withoutHistory(middleware.WithWorkspaceID(ctx, rot.WorkspaceID)), rotationTimeout)
+	defer cancel()
+
+	start := time.Now()
+	params := repository.CreateEnvironmentRotationRunParams{RotationID: rot.ID}
+	if names, err := r.mint(runCtx, rot); err != nil {
+		params.Error = err.Error()
+	} else {
+		params.Success = 1
+		data, _ := json.Marshal(names)
+		params.Variables = string(data)
+	}
+	params.DurationMs = time.Since(start).Milliseconds()
+
+	run, err := r.queries.CreateEnvironmentRotationRun(ctx, params)
+	if err != nil {
+		return repository.EnvironmentRotationRun{}, err
+	}
+	return run, r.queries.MarkEnvironmentRotationRun(ctx, rot.ID)
+}
+
+// mint runs the flow and writes its outputs, returning the variable names
+func (r *EnvironmentRotator) mint(ctx context.Context, rot repository.EnvironmentRotation) ([]string, error) {
+	flow, err := r.queries.GetFlow(ctx, rot.FlowID)
+	if err != nil {
+		return nil, fmt.Errorf("flow %d not found", rot.FlowID)
+	}
+	if flow.ArchivedAt.Valid {
+		return nil, fmt.Errorf("flow %q is archived", flow.Name)
+	}
+
+	result, err := r.runner.Run(ctx, rot.FlowID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		if result.Error == "" {
+			return nil, errors.New("flow failed")
+		}
+		return nil, fmt.Errorf("flow failed: %s", result.Error)
+	}
+
+	vars, err := RotationVariables(ParseRotationOutputs(rot.Outputs), result.Outputs)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeEnvironmentVariables(ctx, r.queries, r.runner.variableResolver, rot.EnvironmentID, vars); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ParseRotationOutputs decodes the outputs column: environment variable ->
+// flow output name. nil means every output under its own name.
+func ParseRotationOutputs(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+	var outputs map[string]string
+	if err := json.Unmarshal([]byte(raw), &outputs); err != nil || len(outputs) == 0 {
+		return nil
+	}
+	return outputs
+}
+
+// ValidateRotationOutputs checks the mapping against the outputs the flow
+// declares
+func ValidateRotationOutputs(mapping map[string]string, declared map[string]string) error {
+	if len(declared) == 0 {
+		return errors.New("flow declares no outputs to write into the environment")
+	}
+	for variable, output := range mapping {
+		if strings.TrimSpace(variable) == "" {
+			return errors.New("variable name is required")
+		}
+		if _, ok := declared[output]; !ok {
+			return fmt.Errorf("variable %q: flow has no output %q", variable, output)
+		}
+	}
+	return nil
+}
+
+// RotationVariables picks the values to write from a run's outputs. Every
+// mapped output must be set, so a half-finished run never writes a partial
+// rotation.
+func RotationVariables(mapping, outputs map[string]string) (map[string]string, error) {
+	if len(mapping) == 0 {
+		if len(outputs) == 0 {
+			return nil, errors.New("flow run produced no outputs")
+		}
+		return outputs, nil
+	}
+	vars := make(map[string]string, len(mapping))
+	for variable, output := range mapping {
+		v, ok := outputs[output]
+		if !ok {
+			return nil, fmt.Errorf("flow output %q was not set", output)
+		}
+		vars[variable] = v
+	}
+	return vars, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestEnvironmentRotator_RunDue(t *testing.T) {
+	var minted atomic.Int32
+	var broken atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Admin") != "root" || broken.Load() {
+			w.Write([]byte(`{}`))
+			return
+		}
+		fmt.Fprintf(w, `{"key":"k-%d"}`, minted.Add(1))
+	}))
+	defer server.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	vr := NewVariableResolver(q)
+	rotator := NewEnvironmentRotator(q, NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr))
+
+	// The flow resolves against the active environment; its output goes to prod
+	dev, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name: "dev", Variables: sql.NullString{String: `{"admin":"root"}`, Valid: true}, WorkspaceID: 1,
+	})
+	q.ActivateEnvironment(ctx, dev.ID)
+	prod, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name: "prod", Variables: sql.NullString{String: `{"host":"api.example.com"}`, Valid: true}, WorkspaceID: 1,
+	})
+
+	flow, _ := q.CreateFlow(ctx, repository.CreateFlowParams{
+		Name: "mint-key", WorkspaceID: 1, Outputs: sql.NullString{String: `{"key":"newKey"}`, Valid: true},
+	})
+	q.CreateFlowStep(ctx, repository.CreateFlowStepParams{
+		FlowID: flow.ID, StepOrder: 1, Name: "mint", Method: "POST", Url: server.URL + "/keys",
+		Headers:     sql.NullString{String: `{"X-Admin":"{{admin}}"}`, Valid: true},
+		ExtractVars: sql.NullString{String: `{"newKey":"$.key"}`, Valid: true},
+	})
+	rot, err := q.CreateEnvironmentRotation(ctx, repository.CreateEnvironmentRotationParams{
+		WorkspaceID: 1, EnvironmentID: prod.ID, FlowID: flow.ID, IntervalSeconds: 3600,
+		Outputs: `{"API_KEY":"key"}`, Enabled: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Paused rotations are never due
+	q.CreateEnvironmentRotation(ctx, repository.CreateEnvironmentRotationParams{
+		WorkspaceID: 1, EnvironmentID: prod.ID, FlowID: flow.ID, IntervalSeconds: 3600, Enabled: 0,
+	})
+
+	prodVars := func() map[string]string {
+		t.Helper()
+		env, _ := q.GetEnvironment(ctx, prod.ID)
+		var vars map[string]string
+		json.Unmarshal([]byte(env.Variables.String), &vars)
+		return vars
+	}
+
+	if n := rotator.RunDue(ctx); n != 1 {
+		t.Fatalf("first RunDue ran %d rotations, want 1", n)
+	}
+	if vars := prodVars(); vars["API_KEY"] != "k-1" || vars["host"] != "api.example.com" {
+		t.Errorf("prod variables = %v", vars)
+	}
+	last, err := q.GetLastEnvironmentRotationRun(ctx, rot.ID)
+	if err != nil || last.Success != 1 || last.Variables != `["API_KEY"]` {
+		t.Errorf("run = %+v, %v", last, err)
+	}
+	// Interval has not elapsed yet
+	if n := rotator.RunDue(ctx); n != 0 {
+		t.Errorf("second RunDue ran %d rotations, want 0", n)
+	}
+
+	// A run that doesn't produce the output leaves the environment alone
+	broken.Store(true)
+	db.Exec("UPDATE environment_rotations SET last_run_at = datetime('now', '-3601 seconds') WHERE id = ?", rot.ID)
+	if n := rotator.RunDue(ctx); n != 1 {
+		t.Fatalf("RunDue after interval ran %d rotations, want 1", n)
+	}
+	if vars := prodVars(); vars["API_KEY"] != "k-1" {
+		t.Errorf("failed run changed API_KEY to %q", vars["API_KEY"])
+	}
+	last, _ = q.GetLastEnvironmentRotationRun(ctx, rot.ID)
+	if last.Success != 0 || last.Error == "" {
+		t.Errorf("failed run = %+v", last)
+	}
+
+	// Scheduled runs count against the workspace quota; manual runs don't
+	broken.Store(false)
+	q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"quotas":{"maxScheduledRunsPerDay":2}}`, Valid: true}, ID: 1,
+	})
+	db.Exec("UPDATE environment_rotations SET last_run_at = datetime('now', '-3601 seconds') WHERE id = ?", rot.ID)
+	rotator.RunDue(ctx)
+	runs, _ := q.ListEnvironmentRotationRuns(ctx, repository.ListEnvironmentRotationRunsParams{RotationID: rot.ID, Limit: 10})
+	if len(runs) != 2 {
+		t.Errorf("runs over quota = %d, want 2", len(runs))
+	}
+	rot, _ = q.GetEnvironmentRotation(ctx, rot.ID)
+	run, err := rotator.Rotate(ctx, rot)
+	if err != nil || run.Success != 1 {
+		t.Fatalf("manual rotate = %+v, %v", run, err)
+	}
+	if vars := prodVars(); vars["API_KEY"] != "k-2" {
+		t.Errorf("after manual rotate API_KEY = %q, want k-2", vars["API_KEY"])
+	}
+}
+
+func TestRotationVariables(t *testing.T) {
+	outputs := map[string]string{"key": "k-1", "expires": "2026-01-01"}
+
+	vars, err := RotationVariables(nil, outputs)
+	if err != nil || len(vars) != 2 || vars["key"] != "k-1" {
+		t.Errorf("all outputs = %v, %v", vars, err)
+	}
+	vars, err = RotationVariables(map[string]string{"API_KEY": "key"}, outputs)
+	if err != nil || len(vars) != 1 || vars["API_KEY"] != "k-1" {
+		t.Errorf("mapped = %v, %v", vars, err)
+	}
+	if _, err := RotationVariables(map[string]string{"API_KEY": "secret"}, outputs); err == nil {
+		t.Error("missing output accepted")
+	}
+	if _, err := RotationVariables(nil, nil); err == nil {
+		t.Error("run without outputs accepted")
+	}
+
+	if err := ValidateRotationOutputs(nil, nil); err == nil {
+		t.Error("flow without outputs accepted")
+	}
+	if err := ValidateRotationOutputs(map[string]string{"API_KEY": "token"}, map[string]string{"key": "k"}); err == nil {
+		t.Error("undeclared output accepted")
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Encrypted export bundles wrap an export document in AES-256-GCM with a key
+// derived from a passphrase (PBKDF2-SHA256), so exports holding tokens can be
+// mailed or stored without leaking them. Everything needed to decrypt except
+// the passphrase travels in the bundle.
+const (
+	EncryptedBundleFormat  = "relay-encrypted"
+	encryptedBundleVersion = 1
+	bundleCipher           = "AES-256-GCM"
+	bundleKDF              = "PBKDF2-SHA256"
+
+	bundleIterations    = 600_000
+	minBundleIterations = 100_000
+	maxBundleIterations = 10_000_000
+
+	// MinBundlePassphrase is the shortest passphrase accepted for encryption
+	MinBundlePassphrase = 8
+)
+
+var (
+	// ErrWrongPassphrase is returned when a bundle does not decrypt, either
+	// because the passphrase is wrong or the bundle was altered
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted bundle")
+	// ErrInvalidBundle is returned for documents that are not usable bundles
+	ErrInvalidBundle = errors.New("invalid encrypted bundle")
+)
+
+// EncryptedBundle is an encrypted export document
+type EncryptedBundle struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Cipher     string `json:"cipher"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`  // base64
+	Nonce      string `json:"nonce"` // base64
+	Ciphertext string `json:"ciphertext"`
+}
+
+// IsEncryptedBundle reports whether a JSON document is an encrypted bundle
+func IsEncryptedBundle(data []byte) bool {
+	var probe struct {
+		Format string `json:"format"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Format == EncryptedBundleFormat
+}
+
+// EncryptBundle encrypts plaintext with a key derived from passphrase
+func EncryptBundle(plaintext []byte, passphrase string) (*EncryptedBundle, error) {
+	if len(passphrase) < MinBundlePassphrase {
+		return nil, fmt.Errorf("passphrase must be at least %d characters", MinBundlePassphrase)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	gcm, err := bundleGCM(passphrase, salt, bundleIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	b := &EncryptedBundle{
+		Format:     EncryptedBundleFormat,
+		Version:    encryptedBundleVersion,
+		Cipher:     bundleCipher,
+		KDF:        bundleKDF,
+		Iterations: bundleIterations,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+	}
+	// The header is authenticated so its parameters cannot be swapped
+	b.Ciphertext = base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, b.additionalData()))
+	return b, nil
+}
+
+// DecryptBundle returns the document inside a bundle
+func DecryptBundle(b *EncryptedBundle, passphrase string) ([]byte, error) {
+	if b.Format != EncryptedBundleFormat || b.Version < 1 || b.Version > encryptedBundleVersion {
+		return nil, fmt.Errorf("%w: unsupported format %q version %d", ErrInvalidBundle, b.Format, b.Version)
+	}
+	if b.Cipher != bundleCipher || b.KDF != bundleKDF {
+		return nil, fmt.Errorf("%w: unsupported cipher %q with kdf %q", ErrInvalidBundle, b.Cipher, b.KDF)
+	}
+	if b.Iterations < minBundleIterations || b.Iterations > maxBundleIterations {
+		return nil, fmt.Errorf("%w: iterations must be between %d and %d", ErrInvalidBundle, minBundleIterations, maxBundleIterations)
+	}
+	salt, err1 := base64.StdEncoding.DecodeString(b.Salt)
+	nonce, err2 := base64.StdEncoding.DecodeString(b.Nonce)
+	ciphertext, err3 := base64.StdEncoding.DecodeString(b.Ciphertext)
+	if err := errors.Join(err1, err2, err3); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	gcm, err := bundleGCM(passphrase, salt, b.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: bad nonce length", ErrInvalidBundle)
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, b.additionalData())
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+func (b *EncryptedBundle) additionalData() []byte {
+	return fmt.Appendf(nil, "%s/%d/%s/%s/%d/%s", b.Format, b.Version, b.Cipher, b.KDF, b.Iterations, b.Salt)
+}
+
+func bundleGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
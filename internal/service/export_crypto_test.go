@@ -0,0 +1,44 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEncryptBundle_RoundTrip(t *testing.T) {
+	doc := []byte(`{"token":"Bearer s3cret"}`)
+	b, err := EncryptBundle(doc, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := json.Marshal(b)
+	if strings.Contains(string(raw), "s3cret") || !IsEncryptedBundle(raw) || IsEncryptedBundle(doc) {
+		t.Fatalf("bundle = %s", raw)
+	}
+
+	got, err := DecryptBundle(b, "correct horse")
+	if err != nil || string(got) != string(doc) {
+		t.Fatalf("decrypt = %q, %v", got, err)
+	}
+	if _, err := DecryptBundle(b, "wrong horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("wrong passphrase: %v", err)
+	}
+
+	// The header is authenticated along with the ciphertext
+	tampered := *b
+	tampered.Iterations++
+	if _, err := DecryptBundle(&tampered, "correct horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("tampered header: %v", err)
+	}
+	tampered = *b
+	tampered.Iterations = 1
+	if _, err := DecryptBundle(&tampered, "correct horse"); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("weak iterations: %v", err)
+	}
+
+	if _, err := EncryptBundle(doc, "short"); err == nil {
+		t.Error("short passphrase accepted")
+	}
+}
@@ -37,7 +37,8 @@ func CleanupOrphanFiles(ctx context.Context, db *sql.DB, queries *repository.Que
 		dbByName[f.StoredName] = f.ID
 	}
 
-	// 2. Collect all fileId references from request/flow_step bodies
+	// 2. Collect all fileId references from request/flow_step bodies and
+	// workspace TLS settings
 	referencedIDs, err := collectReferencedFileIDs(ctx, db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to collect file references: %w", err)
@@ -128,5 +129,41 @@ func collectReferencedFileIDs(ctx context.Context, db *sql.DB) (map[int64]bool,
 		}
 	}
 
-	return refs, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// TLS certificates, keys and CA bundles of workspace settings
+	settings, err := db.QueryContext(ctx, `SELECT settings FROM workspaces WHERE settings IS NOT NULL AND settings != ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer settings.Close()
+	for settings.Next() {
+		var raw sql.NullString
+		if err := settings.Scan(&raw); err != nil {
+			return nil, err
+		}
+		for _, id := range ParseWorkspaceSettings(raw).TLS.FileIDs() {
+			refs[id] = true
+		}
+	}
+
+	return refs, settings.Err()
+}
+
+// FileCleanupJob runs CleanupOrphanFiles from the job queue. The payload is
+// {"dryRun": bool} and the job result is the CleanupResult.
+func FileCleanupJob(db *sql.DB, queries *repository.Queries, fs *FileStorage) JobFunc {
+	return func(ctx context.Context, job repository.Job) (interface{}, error) {
+		var p struct {
+			DryRun bool `json:"dryRun"`
+		}
+		json.Unmarshal([]byte(job.Payload), &p)
+		result, err := CleanupOrphanFiles(ctx, db, queries, fs, p.DryRun)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
 }
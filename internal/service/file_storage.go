@@ -11,6 +11,7 @@ import (
 
 type FileStorage struct {
 	baseDir string
+	keys    *KeyRing // opens files sealed with a workspace key
 }
 
 func NewFileStorage(baseDir string) (*FileStorage, error) {
@@ -20,6 +21,11 @@ func NewFileStorage(baseDir string) (*FileStorage, error) {
 	return &FileStorage{baseDir: baseDir}, nil
 }
 
+// SetKeyRing sets the workspace keys sealed files are opened with
+func (fs *FileStorage) SetKeyRing(keys *KeyRing) {
+	fs.keys = keys
+}
+
 func (fs *FileStorage) Store(data io.Reader) (storedName string, size int64, err error) {
 	storedName = uuid.New().String() + ".bin"
 	filePath := filepath.Join(fs.baseDir, storedName)
@@ -62,3 +68,21 @@ func (fs *FileStorage) ListDir() ([]string, error) {
 	}
 	return names, nil
 }
+
+// Usage returns the number of stored files and their total size
+func (fs *FileStorage) Usage() (files int, bytes int64, err error) {
+	entries, err := os.ReadDir(fs.baseDir)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read upload directory: %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			files++
+			bytes += info.Size()
+		}
+	}
+	return files, bytes, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	defaultApprovalTimeout = time.Hour
+	maxApprovalTimeout     = 24 * time.Hour
+)
+
+// What an approval gate does when nobody decides in time
+const (
+	ApprovalOnTimeoutFail    = "fail" // stop the run (default)
+	ApprovalOnTimeoutSkip    = "skip" // skip the step's request and go on
+	ApprovalOnTimeoutApprove = "approve"
+)
+
+// Approval decisions
+const (
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalTimedOut = "timedOut"
+)
+
+// ApprovalGate makes a step wait for a person: the run pauses before the
+// step's request until someone approves or rejects it with
+// POST /api/flows/runs/{runId}/approve, or TimeoutMs passes. A gate step
+// without a URL only waits.
+type ApprovalGate struct {
+	Message   string `json:"message"`
+	TimeoutMs int64  `json:"timeoutMs"` // default 1 hour, at most 24 hours
+	OnTimeout string `json:"onTimeout"` // fail (default), skip or approve
+}
+
+// ParseApprovalGate decodes a step's approval config. An empty config returns nil.
+func ParseApprovalGate(raw string) (*ApprovalGate, error) {
+	if raw == "" || raw == "{}" {
+		return nil, nil
+	}
+	var g ApprovalGate
+	if err := json.Unmarshal([]byte(raw), &g); err != nil {
+		return nil, fmt.Errorf("invalid approval: %w", err)
+	}
+	if g.TimeoutMs < 0 {
+		return nil, errors.New("approval timeoutMs must not be negative")
+	}
+	if time.Duration(g.TimeoutMs)*time.Millisecond > maxApprovalTimeout {
+		return nil, fmt.Errorf("approval timeoutMs must be at most %d", maxApprovalTimeout.Milliseconds())
+	}
+	switch g.OnTimeout {
+	case "", ApprovalOnTimeoutFail, ApprovalOnTimeoutSkip, ApprovalOnTimeoutApprove:
+	default:
+		return nil, fmt.Errorf("approval onTimeout must be %s, %s or %s", ApprovalOnTimeoutFail, ApprovalOnTimeoutSkip, ApprovalOnTimeoutApprove)
+	}
+	return &g, nil
+}
+
+func (g *ApprovalGate) timeout() time.Duration {
+	if g.TimeoutMs == 0 {
+		return defaultApprovalTimeout
+	}
+	return time.Duration(g.TimeoutMs) * time.Millisecond
+}
+
+// ApprovalResult reports how a step's approval gate was decided
+type ApprovalResult struct {
+	Decision string `json:"decision"` // approved, rejected or timedOut
+	Comment  string `json:"comment,omitempty"`
+	WaitedMs int64  `json:"waitedMs"`
+}
+
+// PendingApproval is a run waiting at an approval gate. It is also sent as
+// the step:approval stream event.
+type PendingApproval struct {
+	RunID       string    `json:"runId"`
+	WorkspaceID int64     `json:"workspaceId"`
+	FlowID      int64     `json:"flowId"`
+	FlowName    string    `json:"flowName"`
+	StepID      int64     `json:"stepId"`
+	StepName    string    `json:"stepName"`
+	Message     string    `json:"message,omitempty"`
+	RequestedAt time.Time `json:"requestedAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	OnTimeout   string    `json:"onTimeout"`
+}
+
+// ErrNoPendingApproval is returned when a run is not waiting for approval
+var ErrNoPendingApproval = errors.New("run is not waiting for approval")
+
+type approvalDecision struct {
+	approved bool
+	comment  string
+}
+
+type pendingGate struct {
+	info   PendingApproval
+	decide chan approvalDecision
+}
+
+// PendingApprovals lists the workspace's runs waiting for approval, oldest first
+func (fr *FlowRunner) PendingApprovals(wsID int64) []PendingApproval {
+	fr.approvalMu.Lock()
+	defer fr.approvalMu.Unlock()
+	pending := make([]PendingApproval, 0, len(fr.approvals))
+	for _, g := range fr.approvals {
+		if g.info.WorkspaceID == wsID {
+			pending = append(pending, g.info)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].RequestedAt.Before(pending[j].RequestedAt) })
+	return pending
+}
+
+// PendingApproval returns the gate a run of the workspace is waiting at
+func (fr *FlowRunner) PendingApproval(wsID int64, runID string) (PendingApproval, bool) {
+	fr.approvalMu.Lock()
+	defer fr.approvalMu.Unlock()
+	g, ok := fr.approvals[runID]
+	if !ok || g.info.WorkspaceID != wsID {
+		return PendingApproval{}, false
+	}
+	return g.info, true
+}
+
+// DecideApproval approves or rejects the gate a run of the workspace is
+// waiting at
+func (fr *FlowRunner) DecideApproval(wsID int64, runID string, approved bool, comment string) (PendingApproval, error) {
+	fr.approvalMu.Lock()
+	g, ok := fr.approvals[runID]
+	ok = ok && g.info.WorkspaceID == wsID
+	if ok {
+		delete(fr.approvals, runID)
+	}
+	fr.approvalMu.Unlock()
+	if !ok {
+		return PendingApproval{}, ErrNoPendingApproval
+	}
+	g.decide <- approvalDecision{approved: approved, comment: comment}
+	return g.info, nil
+}
+
+// awaitApproval blocks until the gate is decided, times out or ctx ends
+func (fr *FlowRunner) awaitApproval(ctx context.Context, info PendingApproval, gate *ApprovalGate, onApproval func(PendingApproval)) (*ApprovalResult, error) {
+	start := time.Now()
+	timeout := gate.timeout()
+	info.RequestedAt = start.UTC()
+	info.ExpiresAt = info.RequestedAt.Add(timeout)
+	info.Message = gate.Message
+	info.OnTimeout = gate.OnTimeout
+	if info.OnTimeout == "" {
+		info.OnTimeout = ApprovalOnTimeoutFail
+	}
+
+	g := &pendingGate{info: info, decide: make(chan approvalDecision, 1)}
+	fr.approvalMu.Lock()
+	fr.approvals[info.RunID] = g
+	fr.approvalMu.Unlock()
+	defer func() {
+		fr.approvalMu.Lock()
+		if fr.approvals[info.RunID] == g {
+			delete(fr.approvals, info.RunID)
+		}
+		fr.approvalMu.Unlock()
+	}()
+	if onApproval != nil {
+		onApproval(info)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case d := <-g.decide:
+		decision := ApprovalRejected
+		if d.approved {
+			decision = ApprovalApproved
+		}
+		return &ApprovalResult{Decision: decision, Comment: d.comment, WaitedMs: time.Since(start).Milliseconds()}, nil
+	case <-timer.C:
+		return &ApprovalResult{Decision: ApprovalTimedOut, WaitedMs: time.Since(start).Milliseconds()}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
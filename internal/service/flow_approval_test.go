@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestFlowRunner_ApprovalGate(t *testing.T) {
+	sent := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+
+	gate := sql.NullString{String: `{"message":"deploy to prod?"}`, Valid: true}
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "gate", Approval: gate},
+		{Name: "deploy", Method: "POST", Url: ts.URL, Approval: gate},
+	})
+
+	decide := func(approved bool, comment string) *StreamCallbacks {
+		return &StreamCallbacks{OnApproval: func(p PendingApproval) {
+			if p.Message != "deploy to prod?" || len(fr.PendingApprovals(1)) != 1 {
+				t.Errorf("pending = %+v", fr.PendingApprovals(1))
+			}
+			if _, err := fr.DecideApproval(2, p.RunID, true, ""); !errors.Is(err, ErrNoPendingApproval) {
+				t.Errorf("other workspace decided the gate: %v", err)
+			}
+			if _, err := fr.DecideApproval(1, p.RunID, approved, comment); err != nil {
+				t.Error(err)
+			}
+		}}
+	}
+
+	result, err := fr.RunWithOptions(context.Background(), flowID, nil, decide(true, ""))
+	if err != nil || !result.Success || sent != 1 {
+		t.Fatalf("approved run: sent %d, %v, %+v", sent, err, result)
+	}
+	if a := result.Steps[0].Approval; a == nil || a.Decision != ApprovalApproved || result.Steps[0].ExecuteResult != nil {
+		t.Errorf("gate-only step = %+v", result.Steps[0])
+	}
+	if len(fr.PendingApprovals(1)) != 0 {
+		t.Errorf("gate still pending after the run")
+	}
+
+	result, _ = fr.RunWithOptions(context.Background(), flowID, nil, decide(false, "change freeze"))
+	if result.Success || result.Error != `step "gate": approval rejected: change freeze` || len(result.Steps) != 1 || sent != 1 {
+		t.Errorf("rejected run: sent %d, %+v", sent, result)
+	}
+}
+
+func TestFlowRunner_ApprovalTimeout(t *testing.T) {
+	sent := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent++
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+
+	for _, tc := range []struct {
+		onTimeout string
+		success   bool
+		sent      int
+	}{
+		{"", false, 0},
+		{ApprovalOnTimeoutSkip, true, 1},
+		{ApprovalOnTimeoutApprove, true, 2},
+	} {
+		sent = 0
+		flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+			{Name: "guarded", Method: "GET", Url: ts.URL, Approval: sql.NullString{String: `{"timeoutMs":1,"onTimeout":"` + tc.onTimeout + `"}`, Valid: true}},
+			{Name: "after", Method: "GET", Url: ts.URL},
+		})
+		result, err := fr.Run(context.Background(), flowID, nil)
+		if err != nil || result.Success != tc.success || sent != tc.sent {
+			t.Errorf("onTimeout %q: sent %d, %v, %+v", tc.onTimeout, sent, err, result)
+			continue
+		}
+		if a := result.Steps[0].Approval; a == nil || a.Decision != ApprovalTimedOut {
+			t.Errorf("onTimeout %q: approval = %+v", tc.onTimeout, a)
+		}
+	}
+}
+
+func TestParseApprovalGate(t *testing.T) {
+	if g, err := ParseApprovalGate(""); g != nil || err != nil {
+		t.Errorf("empty config = %v, %v", g, err)
+	}
+	for _, raw := range []string{`{"timeoutMs":-1}`, `{"timeoutMs":86400001}`, `{"onTimeout":"retry"}`, `nope`} {
+		if _, err := ParseApprovalGate(raw); err == nil {
+			t.Errorf("%s: expected error", raw)
+		}
+	}
+}
@@ -0,0 +1,718 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"relay/internal/repository"
+)
+
+// FlowNodeType is the kind of a graph flow node
+type FlowNodeType string
+
+const (
+	FlowNodeRequest   FlowNodeType = "request"   // sends a saved or inline request
+	FlowNodeCondition FlowNodeType = "condition" // follows its "true" or "false" edge
+	FlowNodeDelay     FlowNodeType = "delay"     // waits before continuing
+	FlowNodeScript    FlowNodeType = "script"    // runs a script against the run's variables
+	FlowNodeParallel  FlowNodeType = "parallel"  // runs its outgoing branches concurrently
+	FlowNodeSubflow   FlowNodeType = "subflow"   // runs another flow and takes its outputs
+)
+
+// Node run statuses
+const (
+	NodeStatusSuccess = "success"
+	NodeStatusFailed  = "failed"
+	NodeStatusSkipped = "skipped"
+)
+
+// Node outcomes. Request, script and sub-flow nodes record one; edges leaving
+// them may be labelled with an outcome so error paths can be modelled as
+// branches. Request nodes report the specific outcomes, which also match
+// "success" (2xx) or "failure" (anything else).
+const (
+	OutcomeSuccess         = "success"
+	OutcomeFailure         = "failure"
+	Outcome2xx             = "2xx"
+	Outcome3xx             = "3xx"
+	Outcome4xx             = "4xx"
+	Outcome5xx             = "5xx"
+	OutcomeNetworkError    = "networkError"
+	OutcomeAssertionFailed = "assertionFailed"
+)
+
+// edgeLabels lists the labels each node type's outgoing edges may carry
+var edgeLabels = map[FlowNodeType][]string{
+	FlowNodeCondition: {"true", "false"},
+	FlowNodeRequest: {OutcomeSuccess, OutcomeFailure, Outcome2xx, Outcome3xx, Outcome4xx, Outcome5xx,
+		OutcomeNetworkError, OutcomeAssertionFailed},
+	FlowNodeScript:  {OutcomeSuccess, OutcomeFailure},
+	FlowNodeSubflow: {OutcomeSuccess, OutcomeFailure},
+}
+
+const (
+	maxFlowGraphNodes = 500
+	maxFlowNodeKeyLen = 64
+	maxFlowNodeDelay  = 10 * time.Minute
+	// maxSubflowDepth bounds sub-flow nesting (and so sub-flow cycles)
+	maxSubflowDepth = 5
+)
+
+// FlowGraph is the node/edge form of a flow. A flow with nodes runs as a
+// graph; its linear steps are ignored.
+type FlowGraph struct {
+	Nodes []FlowGraphNode `json:"nodes"`
+	Edges []FlowGraphEdge `json:"edges"`
+}
+
+// FlowGraphNode is one node; Config depends on Type
+type FlowGraphNode struct {
+	Key      string           `json:"key"`
+	Type     FlowNodeType     `json:"type"`
+	Name     string           `json:"name"`
+	Config   json.RawMessage  `json:"config,omitempty"`
+	Position FlowNodePosition `json:"position"`
+}
+
+// FlowNodePosition is where the editor draws a node
+type FlowNodePosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// FlowGraphEdge connects two nodes by key. Edges leaving a condition node are
+// labelled "true" or "false"; edges leaving request, script and sub-flow nodes
+// may be labelled with an outcome. All others are unlabelled.
+type FlowGraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Label  string `json:"label,omitempty"`
+}
+
+// RequestNodeConfig sends the saved request RequestID or, without one, the
+// inline request
+type RequestNodeConfig struct {
+	RequestID       int64             `json:"requestId,omitempty"`
+	Method          string            `json:"method,omitempty"`
+	URL             string            `json:"url,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Body            string            `json:"body,omitempty"`
+	BodyType        string            `json:"bodyType,omitempty"`
+	ExtractVars     map[string]string `json:"extractVars,omitempty"`
+	PreScript       string            `json:"preScript,omitempty"`
+	PostScript      string            `json:"postScript,omitempty"`
+	ContinueOnError bool              `json:"continueOnError,omitempty"`
+}
+
+// ConditionNodeConfig holds a step-condition expression, e.g. "{{status}} == 200"
+type ConditionNodeConfig struct {
+	Expression string `json:"expression"`
+}
+
+type DelayNodeConfig struct {
+	DelayMs int64 `json:"delayMs"`
+}
+
+type ScriptNodeConfig struct {
+	Script          string `json:"script"`
+	ContinueOnError bool   `json:"continueOnError,omitempty"`
+}
+
+// SubflowNodeConfig runs FlowID with the run's variables; the sub-flow's
+// declared outputs become variables of this run
+type SubflowNodeConfig struct {
+	FlowID          int64 `json:"flowId"`
+	ContinueOnError bool  `json:"continueOnError,omitempty"`
+}
+
+// NodeResult is the outcome of one node of a graph run
+type NodeResult struct {
+	NodeKey    string        `json:"nodeKey"`
+	Type       FlowNodeType  `json:"type"`
+	Name       string        `json:"name,omitempty"`
+	Status     string        `json:"status"`            // success, failed, skipped
+	Outcome    string        `json:"outcome,omitempty"` // request, script and sub-flow nodes
+	Branch     string        `json:"branch,omitempty"`  // label of the edge followed
+	Error      string        `json:"error,omitempty"`
+	SkipReason string        `json:"skipReason,omitempty"`
+	DurationMs int64         `json:"durationMs"`
+	Step       *StepResult   `json:"step,omitempty"`    // request nodes
+	Script     *ScriptResult `json:"script,omitempty"`  // script nodes
+	SubFlow    *FlowResult   `json:"subFlow,omitempty"` // sub-flow nodes
+
+	timing *stepTiming // for the run timeline
+}
+
+// LoadFlowGraph returns the flow's graph; it has no nodes for linear flows
+func LoadFlowGraph(ctx context.Context, q *repository.Queries, flowID int64) (*FlowGraph, error) {
+	nodes, err := q.ListFlowNodes(ctx, flowID)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := q.ListFlowEdges(ctx, flowID)
+	if err != nil {
+		return nil, err
+	}
+	g := &FlowGraph{Nodes: make([]FlowGraphNode, 0, len(nodes)), Edges: make([]FlowGraphEdge, 0, len(edges))}
+	for _, n := range nodes {
+		g.Nodes = append(g.Nodes, FlowGraphNode{
+			Key:      n.NodeKey,
+			Type:     FlowNodeType(n.Type),
+			Name:     n.Name,
+			Config:   json.RawMessage(n.Config),
+			Position: FlowNodePosition{X: n.PositionX, Y: n.PositionY},
+		})
+	}
+	for _, e := range edges {
+		g.Edges = append(g.Edges, FlowGraphEdge{Source: e.SourceKey, Target: e.TargetKey, Label: e.Label})
+	}
+	return g, nil
+}
+
+// decodeNodeConfig decodes a node's config into v; an empty config is allowed
+func decodeNodeConfig(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// ValidateFlowGraph checks the graph's shape: unique keys, known node types
+// with valid configs, edges between existing nodes, a single entry node and
+// no cycles. Only parallel nodes and nodes with labelled edges may have several
+// outgoing edges; labelled edges are exclusive, so branches run concurrently
+// only below a parallel node.
+func ValidateFlowGraph(g *FlowGraph) error {
+	if len(g.Nodes) > maxFlowGraphNodes {
+		return fmt.Errorf("at most %d nodes are allowed", maxFlowGraphNodes)
+	}
+	nodes := make(map[string]*FlowGraphNode, len(g.Nodes))
+	for i := range g.Nodes {
+		n := &g.Nodes[i]
+		if strings.TrimSpace(n.Key) == "" || len(n.Key) > maxFlowNodeKeyLen {
+			return fmt.Errorf("node %d: key must be 1-%d characters", i+1, maxFlowNodeKeyLen)
+		}
+		if _, dup := nodes[n.Key]; dup {
+			return fmt.Errorf("duplicate node key %q", n.Key)
+		}
+		nodes[n.Key] = n
+		if err := validateNodeConfig(n); err != nil {
+			return fmt.Errorf("node %q: %w", n.Key, err)
+		}
+	}
+
+	incoming := make(map[string]int, len(nodes))
+	outgoing := make(map[string][]FlowGraphEdge, len(nodes))
+	seen := make(map[FlowGraphEdge]bool, len(g.Edges))
+	for _, e := range g.Edges {
+		src, ok := nodes[e.Source]
+		if !ok {
+			return fmt.Errorf("edge source %q is not a node", e.Source)
+		}
+		if _, ok := nodes[e.Target]; !ok {
+			return fmt.Errorf("edge target %q is not a node", e.Target)
+		}
+		if e.Source == e.Target {
+			return fmt.Errorf("node %q: edge to itself", e.Source)
+		}
+		if seen[FlowGraphEdge{Source: e.Source, Target: e.Target}] {
+			return fmt.Errorf("duplicate edge %q -> %q", e.Source, e.Target)
+		}
+		seen[FlowGraphEdge{Source: e.Source, Target: e.Target}] = true
+		if src.Type == FlowNodeCondition && e.Label == "" {
+			return fmt.Errorf("node %q: condition edges must be labelled \"true\" or \"false\"", e.Source)
+		}
+		if e.Label != "" {
+			if !slices.Contains(edgeLabels[src.Type], e.Label) {
+				return fmt.Errorf("node %q: invalid edge label %q for a %s node", e.Source, e.Label, src.Type)
+			}
+			for _, other := range outgoing[e.Source] {
+				if other.Label == e.Label {
+					return fmt.Errorf("node %q: more than one %q edge", e.Source, e.Label)
+				}
+			}
+		}
+		if prev := outgoing[e.Source]; len(prev) > 0 {
+			if (prev[0].Label == "") != (e.Label == "") {
+				return fmt.Errorf("node %q: outgoing edges must be all labelled or all unlabelled", e.Source)
+			}
+			if e.Label == "" && src.Type != FlowNodeParallel {
+				return fmt.Errorf("node %q: only parallel nodes can have several outgoing edges without labels", e.Source)
+			}
+		}
+		outgoing[e.Source] = append(outgoing[e.Source], e)
+		incoming[e.Target]++
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+	var entries []string
+	for _, n := range g.Nodes {
+		if incoming[n.Key] == 0 {
+			entries = append(entries, n.Key)
+		}
+	}
+	if len(entries) != 1 {
+		return fmt.Errorf("graph must have exactly one entry node (found %d)", len(entries))
+	}
+
+	// Kahn's algorithm: every node is visited only if there is no cycle
+	visited := 0
+	queue := entries
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, e := range outgoing[key] {
+			if incoming[e.Target]--; incoming[e.Target] == 0 {
+				queue = append(queue, e.Target)
+			}
+		}
+	}
+	if visited != len(nodes) {
+		return errors.New("graph contains a cycle")
+	}
+	return nil
+}
+
+func validateNodeConfig(n *FlowGraphNode) error {
+	switch n.Type {
+	case FlowNodeRequest:
+		var c RequestNodeConfig
+		if err := decodeNodeConfig(n.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		if c.RequestID == 0 && strings.TrimSpace(c.URL) == "" {
+			return errors.New("requestId or url is required")
+		}
+	case FlowNodeCondition:
+		var c ConditionNodeConfig
+		if err := decodeNodeConfig(n.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		if strings.TrimSpace(c.Expression) == "" {
+			return errors.New("expression is required")
+		}
+	case FlowNodeDelay:
+		var c DelayNodeConfig
+		if err := decodeNodeConfig(n.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		if c.DelayMs < 0 || time.Duration(c.DelayMs)*time.Millisecond > maxFlowNodeDelay {
+			return fmt.Errorf("delayMs must be between 0 and %d", maxFlowNodeDelay.Milliseconds())
+		}
+	case FlowNodeScript:
+		var c ScriptNodeConfig
+		if err := decodeNodeConfig(n.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		if strings.TrimSpace(c.Script) == "" {
+			return errors.New("script is required")
+		}
+	case FlowNodeSubflow:
+		var c SubflowNodeConfig
+		if err := decodeNodeConfig(n.Config, &c); err != nil {
+			return fmt.Errorf("invalid config: %v", err)
+		}
+		if c.FlowID <= 0 {
+			return errors.New("flowId is required")
+		}
+	case FlowNodeParallel:
+	default:
+		return fmt.Errorf("unknown node type %q", n.Type)
+	}
+	return nil
+}
+
+type subflowDepthKey struct{}
+
+// graphRun is the state of one graph walk. Nodes start once every incoming
+// edge is settled; a node none of whose incoming edges was taken is skipped,
+// and the skip travels down its edges, so branches merge cleanly.
+type graphRun struct {
+	fr       *FlowRunner
+	flow     repository.Flow
+	ctx      context.Context
+	stop     context.CancelFunc
+	nodes    map[string]*FlowGraphNode
+	outgoing map[string][]FlowGraphEdge
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	vars    map[string]string
+	result  *FlowResult
+	pending map[string]int  // incoming edges not settled yet
+	reached map[string]bool // an incoming edge was taken
+}
+
+// runGraph walks the flow's graph, filling result. runtimeVars holds the run's
+// variables throughout; each node works on a copy and its changes are merged
+// back when it finishes.
+func (fr *FlowRunner) runGraph(ctx context.Context, flow repository.Flow, graph *FlowGraph, runtimeVars map[string]string, result *FlowResult, callbacks *StreamCallbacks) {
+	startTime := time.Now()
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	g := &graphRun{
+		fr:       fr,
+		flow:     flow,
+		ctx:      runCtx,
+		stop:     stop,
+		nodes:    make(map[string]*FlowGraphNode, len(graph.Nodes)),
+		outgoing: make(map[string][]FlowGraphEdge),
+		vars:     runtimeVars,
+		result:   result,
+		pending:  make(map[string]int),
+		reached:  make(map[string]bool),
+	}
+	result.Nodes = make([]NodeResult, 0, len(graph.Nodes))
+	for i := range graph.Nodes {
+		g.nodes[graph.Nodes[i].Key] = &graph.Nodes[i]
+	}
+	for _, e := range graph.Edges {
+		g.outgoing[e.Source] = append(g.outgoing[e.Source], e)
+		g.pending[e.Target]++
+	}
+
+	g.mu.Lock()
+	for _, n := range graph.Nodes {
+		if g.pending[n.Key] == 0 {
+			g.reached[n.Key] = true
+			g.start(g.nodes[n.Key])
+		}
+	}
+	g.mu.Unlock()
+	g.wg.Wait()
+
+	if ctx.Err() != nil {
+		result.Success = false
+		result.Error = "cancelled"
+	}
+	result.TotalTimeMs = time.Since(startTime).Milliseconds()
+	if callbacks != nil && callbacks.OnFlowComplete != nil {
+		callbacks.OnFlowComplete(FlowCompleteEvent{Success: result.Success, TotalTimeMs: result.TotalTimeMs, Error: result.Error})
+	}
+}
+
+// settle records that one incoming edge of key was followed (taken) or not,
+// and starts or skips the node once all of them are settled. Called with mu held.
+func (g *graphRun) settle(key string, taken bool) {
+	if taken {
+		g.reached[key] = true
+	}
+	if g.pending[key]--; g.pending[key] > 0 {
+		return
+	}
+	node := g.nodes[key]
+	if g.reached[key] && g.result.Success {
+		g.start(node)
+		return
+	}
+	reason := "Branch not taken"
+	if g.reached[key] {
+		reason = "Run stopped"
+	}
+	g.result.Nodes = append(g.result.Nodes, NodeResult{NodeKey: node.Key, Type: node.Type, Name: node.Name, Status: NodeStatusSkipped, SkipReason: reason})
+	for _, e := range g.outgoing[key] {
+		g.settle(e.Target, false)
+	}
+}
+
+// start runs the node in its own goroutine. Called with mu held.
+func (g *graphRun) start(node *FlowGraphNode) {
+	vars := cloneVars(g.vars)
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		before := cloneVars(vars)
+		started := time.Now()
+		nr, proceed := g.fr.runGraphNode(g.ctx, g.flow, node, vars)
+		ended := time.Now()
+		nr.DurationMs = ended.Sub(started).Milliseconds()
+		nr.timing = &stepTiming{start: started, end: ended}
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		for _, c := range diffVars(VarScopeRuntime, before, vars) {
+			if c.Deleted {
+				delete(g.vars, c.Name)
+			} else {
+				g.vars[c.Name] = c.NewValue
+			}
+		}
+		if labelled := g.outgoing[node.Key]; node.Type != FlowNodeCondition && len(labelled) > 0 && labelled[0].Label != "" {
+			// A failure with a matching outcome edge is handled by that branch
+			nr.Branch = routeOutcome(nr.Outcome, labelled)
+			proceed = proceed || nr.Branch != ""
+		}
+		g.result.Nodes = append(g.result.Nodes, nr)
+		if !proceed && g.result.Success {
+			g.result.Success = false
+			label := node.Name
+			if label == "" {
+				label = node.Key
+			}
+			g.result.Error = fmt.Sprintf("node %q: %s", label, nr.Error)
+			g.stop()
+		}
+		for _, e := range g.outgoing[node.Key] {
+			g.settle(e.Target, proceed && e.Label == nr.Branch)
+		}
+	}()
+}
+
+// routeOutcome picks the edge label to follow for outcome: the outcome's own
+// edge, else its "success" or "failure" edge, else none
+func routeOutcome(outcome string, edges []FlowGraphEdge) string {
+	if outcome == "" {
+		return ""
+	}
+	fallback := OutcomeFailure
+	if outcome == OutcomeSuccess || outcome == Outcome2xx {
+		fallback = OutcomeSuccess
+	}
+	for _, label := range []string{outcome, fallback} {
+		for _, e := range edges {
+			if e.Label == label {
+				return label
+			}
+		}
+	}
+	return ""
+}
+
+// runGraphNode executes one node against vars (the node's own copy). proceed
+// reports whether the node's outgoing edges are followed; a failed node stops
+// the run unless its config continues on error.
+func (fr *FlowRunner) runGraphNode(ctx context.Context, flow repository.Flow, node *FlowGraphNode, vars map[string]string) (nr NodeResult, proceed bool) {
+	nr = NodeResult{NodeKey: node.Key, Type: node.Type, Name: node.Name, Status: NodeStatusSuccess}
+	fail := func(err string, continueOnError bool) (NodeResult, bool) {
+		nr.Status, nr.Error = NodeStatusFailed, err
+		return nr, continueOnError
+	}
+	if ctx.Err() != nil {
+		return fail("cancelled", false)
+	}
+
+	switch node.Type {
+	case FlowNodeParallel:
+		return nr, true
+
+	case FlowNodeDelay:
+		var c DelayNodeConfig
+		decodeNodeConfig(node.Config, &c)
+		select {
+		case <-ctx.Done():
+			return fail("cancelled", false)
+		case <-time.After(time.Duration(c.DelayMs) * time.Millisecond):
+		}
+		return nr, true
+
+	case FlowNodeCondition:
+		var c ConditionNodeConfig
+		decodeNodeConfig(node.Config, &c)
+		met, err := fr.evaluateCondition(ctx, c.Expression, vars)
+		if err != nil {
+			return fail(err.Error(), false)
+		}
+		nr.Branch = "false"
+		if met {
+			nr.Branch = "true"
+		}
+		return nr, true
+
+	case FlowNodeScript:
+		var c ScriptNodeConfig
+		decodeNodeConfig(node.Config, &c)
+		scriptCtx := &ScriptContext{RuntimeVars: vars, StepName: node.Name, FlowName: flow.Name}
+		sr := fr.executeScript(ctx, c.Script, scriptCtx, vars)
+		for k, v := range sr.UpdatedVars {
+			vars[k] = v
+		}
+		nr.Script = sr
+		nr.Outcome = OutcomeSuccess
+		if !sr.Success {
+			nr.Outcome = OutcomeFailure
+			msg := "script failed"
+			if len(sr.Errors) > 0 {
+				msg = sr.Errors[0]
+			}
+			return fail(msg, c.ContinueOnError)
+		}
+		return nr, true
+
+	case FlowNodeSubflow:
+		var c SubflowNodeConfig
+		decodeNodeConfig(node.Config, &c)
+		depth, _ := ctx.Value(subflowDepthKey{}).(int)
+		if depth >= maxSubflowDepth {
+			return fail(fmt.Sprintf("sub-flows can be nested at most %d levels deep", maxSubflowDepth), false)
+		}
+		nr.Outcome = OutcomeFailure
+		sub, err := fr.runInternal(context.WithValue(ctx, subflowDepthKey{}, depth+1), c.FlowID, &RunOptions{InitialVars: cloneVars(vars)}, nil)
+		if err != nil {
+			return fail(err.Error(), c.ContinueOnError)
+		}
+		nr.SubFlow = sub
+		for k, v := range sub.Outputs {
+			vars[k] = v
+		}
+		if !sub.Success {
+			return fail(fmt.Sprintf("sub-flow %q failed: %s", sub.FlowName, sub.Error), c.ContinueOnError)
+		}
+		nr.Outcome = OutcomeSuccess
+		return nr, true
+
+	case FlowNodeRequest:
+		var c RequestNodeConfig
+		decodeNodeConfig(node.Config, &c)
+		step, err := fr.runRequestNode(ctx, flow, node, c, vars)
+		nr.Step = step
+		if step != nil && step.Skipped {
+			// Blocked by safe mode: the run goes on as if the request succeeded
+			nr.Status, nr.SkipReason, nr.Outcome = NodeStatusSkipped, step.SkipReason, OutcomeSuccess
+			return nr, true
+		}
+		nr.Outcome = requestOutcome(step)
+		if err != nil {
+			return fail(err.Error(), c.ContinueOnError)
+		}
+		return nr, true
+	}
+	return fail(fmt.Sprintf("unknown node type %q", node.Type), false)
+}
+
+// requestOutcome classifies a request node's result
+func requestOutcome(step *StepResult) string {
+	if step == nil || step.ExecuteResult == nil {
+		return OutcomeFailure // the request could not be prepared
+	}
+	res := step.ExecuteResult
+	if res.Error != "" || res.StatusCode == 0 {
+		return OutcomeNetworkError
+	}
+	if step.PostScriptResult != nil && !step.PostScriptResult.Success {
+		return OutcomeAssertionFailed
+	}
+	if res.SpecValidation != nil && !res.SpecValidation.Passed {
+		return OutcomeAssertionFailed
+	}
+	switch {
+	case res.StatusCode < 300:
+		return Outcome2xx
+	case res.StatusCode < 400:
+		return Outcome3xx
+	case res.StatusCode < 500:
+		return Outcome4xx
+	}
+	return Outcome5xx
+}
+
+// runRequestNode sends a request node's request with its scripts and
+// extractions, like a linear step without loops, waits or flow control
+func (fr *FlowRunner) runRequestNode(ctx context.Context, flow repository.Flow, node *FlowGraphNode, c RequestNodeConfig, vars map[string]string) (*StepResult, error) {
+	var req repository.Request
+	if c.RequestID > 0 {
+		saved, err := fr.queries.GetRequest(ctx, c.RequestID)
+		if err != nil {
+			return nil, fmt.Errorf("request %d not found", c.RequestID)
+		}
+		req = saved
+	} else {
+		headers, _ := json.Marshal(c.Headers)
+		req = repository.Request{
+			Name:     node.Name,
+			Method:   c.Method,
+			Url:      c.URL,
+			Headers:  sql.NullString{String: string(headers), Valid: true},
+			Body:     sql.NullString{String: c.Body, Valid: c.Body != ""},
+			BodyType: sql.NullString{String: c.BodyType, Valid: c.BodyType != ""},
+		}
+		if req.Method == "" {
+			req.Method = "GET"
+		}
+	}
+
+	step := &StepResult{RequestName: node.Name, ExtractedVars: make(map[string]string)}
+	if c.RequestID > 0 {
+		id := c.RequestID
+		step.RequestID = &id
+	}
+	stepCtx, scriptReqs := WithScriptRequests(ctx, req.ProxyID)
+	defer func() {
+		step.ScriptRequests = scriptReqs.Calls()
+		scriptReqs.Attach(ctx, fr.queries, step.ExecuteResult)
+	}()
+	scriptCtx := &ScriptContext{RuntimeVars: vars, StepName: node.Name, FlowName: flow.Name}
+
+	if c.PreScript != "" {
+		pre := fr.executeScript(stepCtx, c.PreScript, scriptCtx, vars)
+		step.PreScriptResult = pre
+		for k, v := range pre.UpdatedVars {
+			vars[k] = v
+		}
+	}
+
+	fr.oauth2.RefreshExpired(ctx)
+	execResult, err := fr.requestExecutor.ExecuteRequest(ctx, req, vars)
+	if err != nil {
+		step.ExecuteResult = &ExecuteResult{Error: err.Error()}
+		return step, err
+	}
+	step.ExecuteResult = execResult
+	if execResult.SafeModeBlocked {
+		step.Skipped, step.SkipReason = true, execResult.Error
+		return step, nil
+	}
+	if execResult.Error != "" {
+		return step, errors.New(execResult.Error)
+	}
+	if execResult.StatusCode < 200 || execResult.StatusCode >= 300 {
+		return step, fmt.Errorf("returned HTTP %d", execResult.StatusCode)
+	}
+
+	if len(c.ExtractVars) > 0 {
+		extractJSON, _ := json.Marshal(c.ExtractVars)
+		if extracted, err := fr.extractVariables(execResult.Body, string(extractJSON)); err == nil {
+			for k, v := range extracted {
+				vars[k] = v
+				step.ExtractedVars[k] = v
+			}
+		}
+	}
+
+	if c.PostScript != "" {
+		scriptCtx.StatusCode = execResult.StatusCode
+		scriptCtx.ResponseBody = execResult.Body
+		scriptCtx.ResponseBytes = execResult.RawBody()
+		scriptCtx.Headers = execResult.Headers
+		scriptCtx.DurationMs = execResult.DurationMs
+		reqHeaders := make(map[string]string)
+		if req.Headers.Valid && req.Headers.String != "" {
+			json.Unmarshal([]byte(req.Headers.String), &reqHeaders)
+		}
+		reqInfo := &RequestInfo{URL: req.Url, Method: req.Method, Headers: reqHeaders, Body: req.Body.String}
+		post := fr.executeScriptWithRequest(stepCtx, c.PostScript, scriptCtx, vars, reqInfo, 0)
+		step.PostScriptResult = post
+		for k, v := range post.UpdatedVars {
+			vars[k] = v
+			step.ExtractedVars[k] = v
+		}
+		if !post.Success {
+			msg := "post-script failed"
+			if len(post.Errors) > 0 {
+				msg = post.Errors[0]
+			}
+			return step, errors.New(msg)
+		}
+	}
+	return step, nil
+}
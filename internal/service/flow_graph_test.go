@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+// graphNode builds a node whose config is marshalled from cfg
+func graphNode(key string, typ FlowNodeType, cfg any) FlowGraphNode {
+	raw, _ := json.Marshal(cfg)
+	return FlowGraphNode{Key: key, Type: typ, Name: key, Config: raw}
+}
+
+// createGraphFlow stores a graph flow after validating it
+func createGraphFlow(t *testing.T, q *repository.Queries, g FlowGraph) int64 {
+	t.Helper()
+	if err := ValidateFlowGraph(&g); err != nil {
+		t.Fatalf("invalid graph: %v", err)
+	}
+	ctx := context.Background()
+	flowID := createFlowWithSteps(t, q, nil)
+	for _, n := range g.Nodes {
+		if _, err := q.CreateFlowNode(ctx, repository.CreateFlowNodeParams{FlowID: flowID, NodeKey: n.Key, Type: string(n.Type), Name: n.Name, Config: string(n.Config)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range g.Edges {
+		if _, err := q.CreateFlowEdge(ctx, repository.CreateFlowEdgeParams{FlowID: flowID, SourceKey: e.Source, TargetKey: e.Target, Label: e.Label}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return flowID
+}
+
+func nodeStatuses(result *FlowResult) map[string]string {
+	statuses := make(map[string]string)
+	for _, n := range result.Nodes {
+		statuses[n.NodeKey] = n.Status
+	}
+	return statuses
+}
+
+func TestFlowGraph_BranchAndMerge(t *testing.T) {
+	var mu sync.Mutex
+	var hits []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits = append(hits, r.URL.RequestURI())
+		mu.Unlock()
+		w.Write([]byte(`{"role":"user"}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	flowID := createGraphFlow(t, q, FlowGraph{
+		Nodes: []FlowGraphNode{
+			graphNode("login", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/login", ExtractVars: map[string]string{"role": "$.role"}}),
+			graphNode("isAdmin", FlowNodeCondition, ConditionNodeConfig{Expression: `{{role}} == "admin"`}),
+			graphNode("admin", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/admin"}),
+			graphNode("user", FlowNodeScript, ScriptNodeConfig{Script: `pm.variables.set("area", "user");`}),
+			graphNode("done", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/done?area={{area}}"}),
+		},
+		Edges: []FlowGraphEdge{
+			{Source: "login", Target: "isAdmin"},
+			{Source: "isAdmin", Target: "admin", Label: "true"},
+			{Source: "isAdmin", Target: "user", Label: "false"},
+			{Source: "admin", Target: "done"},
+			{Source: "user", Target: "done"},
+		},
+	})
+
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("run failed: %s", result.Error)
+	}
+	if got := strings.Join(hits, ","); got != "/login,/done?area=user" {
+		t.Errorf("hits = %s", got)
+	}
+	statuses := nodeStatuses(result)
+	if statuses["admin"] != NodeStatusSkipped || statuses["user"] != NodeStatusSuccess || statuses["done"] != NodeStatusSuccess {
+		t.Errorf("statuses = %v", statuses)
+	}
+	if len(result.Steps) != 0 || len(result.Nodes) != 5 {
+		t.Errorf("steps = %d, nodes = %d", len(result.Steps), len(result.Nodes))
+	}
+}
+
+func TestFlowGraph_ParallelBranchesJoin(t *testing.T) {
+	// Both branch requests wait for each other, so they only finish when
+	// they run concurrently
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	var joined string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a", "/b":
+			arrived.Done()
+			done := make(chan struct{})
+			go func() { arrived.Wait(); close(done) }()
+			select {
+			case <-done:
+			case <-time.After(2 * time.Second):
+				w.WriteHeader(http.StatusGatewayTimeout)
+				return
+			}
+			w.Write([]byte(`{"v":"` + r.URL.Path[1:] + `"}`))
+		default:
+			joined = r.URL.RawQuery
+			w.Write([]byte(`{}`))
+		}
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	flowID := createGraphFlow(t, q, FlowGraph{
+		Nodes: []FlowGraphNode{
+			graphNode("fork", FlowNodeParallel, nil),
+			graphNode("a", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/a", ExtractVars: map[string]string{"fromA": "$.v"}}),
+			graphNode("b", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/b", ExtractVars: map[string]string{"fromB": "$.v"}}),
+			graphNode("join", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/join?a={{fromA}}&b={{fromB}}"}),
+		},
+		Edges: []FlowGraphEdge{
+			{Source: "fork", Target: "a"},
+			{Source: "fork", Target: "b"},
+			{Source: "a", Target: "join"},
+			{Source: "b", Target: "join"},
+		},
+	})
+
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("run failed: %s", result.Error)
+	}
+	if joined != "a=a&b=b" {
+		t.Errorf("join saw %q", joined)
+	}
+	if last := result.Nodes[len(result.Nodes)-1]; last.NodeKey != "join" {
+		t.Errorf("join should finish last, got %s", last.NodeKey)
+	}
+}
+
+func TestFlowGraph_SubflowOutputs(t *testing.T) {
+	var used string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/use" {
+			used = r.URL.Query().Get("t")
+		}
+		w.Write([]byte(`{"token":"sub-token"}`))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	child, err := q.CreateFlow(ctx, repository.CreateFlowParams{
+		Name:        "login",
+		WorkspaceID: 1,
+		Outputs:     sql.NullString{String: `{"authToken":"token"}`, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.CreateFlowStep(ctx, repository.CreateFlowStepParams{
+		FlowID: child.ID, StepOrder: 1, Name: "login", Method: "POST", Url: ts.URL + "/login",
+		ExtractVars: sql.NullString{String: `{"token":"$.token"}`, Valid: true},
+	})
+	flowID := createGraphFlow(t, q, FlowGraph{
+		Nodes: []FlowGraphNode{
+			graphNode("auth", FlowNodeSubflow, SubflowNodeConfig{FlowID: child.ID}),
+			graphNode("use", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/use?t={{authToken}}"}),
+		},
+		Edges: []FlowGraphEdge{{Source: "auth", Target: "use"}},
+	})
+
+	result, err := fr.Run(ctx, flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || used != "sub-token" {
+		t.Errorf("success %v (%s), used token %q", result.Success, result.Error, used)
+	}
+	if sub := result.Nodes[0].SubFlow; sub == nil || sub.FlowID != child.ID || sub.Outputs["authToken"] != "sub-token" {
+		t.Errorf("sub-flow result = %+v", sub)
+	}
+}
+
+func TestFlowGraph_FailureStopsRun(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	flowID := createGraphFlow(t, q, FlowGraph{
+		Nodes: []FlowGraphNode{
+			graphNode("tolerated", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/broken", ContinueOnError: true}),
+			graphNode("broken", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/broken"}),
+			graphNode("after", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/after"}),
+		},
+		Edges: []FlowGraphEdge{{Source: "tolerated", Target: "broken"}, {Source: "broken", Target: "after"}},
+	})
+
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || !strings.Contains(result.Error, `node "broken"`) {
+		t.Errorf("result = %v %q", result.Success, result.Error)
+	}
+	statuses := nodeStatuses(result)
+	if statuses["tolerated"] != NodeStatusFailed || statuses["after"] != NodeStatusSkipped {
+		t.Errorf("statuses = %v", statuses)
+	}
+
+	if _, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{StartStepID: 1}, nil); err == nil {
+		t.Error("step options should be rejected for graph flows")
+	}
+}
+
+func TestFlowGraph_OutcomeEdges(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{"ok":false}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	flowID := createGraphFlow(t, q, FlowGraph{
+		Nodes: []FlowGraphNode{
+			graphNode("fetch", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/missing"}),
+			graphNode("create", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/create", Method: "POST"}),
+			graphNode("use", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/use"}),
+			graphNode("alert", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/alert"}),
+			graphNode("check", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/check", PostScript: `pm.test("ok", () => pm.expect(pm.response.json().ok).to.equal(true));`}),
+			graphNode("invalid", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/invalid"}),
+			graphNode("ping", FlowNodeRequest, RequestNodeConfig{URL: "http://127.0.0.1:1/down"}),
+			graphNode("offline", FlowNodeScript, ScriptNodeConfig{Script: `pm.variables.set("mode", "offline");`}),
+		},
+		Edges: []FlowGraphEdge{
+			{Source: "fetch", Target: "create", Label: Outcome4xx},
+			{Source: "fetch", Target: "use", Label: OutcomeSuccess},
+			{Source: "fetch", Target: "alert", Label: OutcomeFailure},
+			{Source: "create", Target: "check"},
+			{Source: "use", Target: "check"},
+			{Source: "alert", Target: "check"},
+			{Source: "check", Target: "invalid", Label: OutcomeAssertionFailed},
+			{Source: "invalid", Target: "ping"},
+			{Source: "ping", Target: "offline", Label: OutcomeFailure},
+		},
+	})
+
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("routed failures should not fail the run: %q", result.Error)
+	}
+	want := map[string][2]string{ // status, branch
+		"fetch":   {NodeStatusFailed, Outcome4xx},
+		"create":  {NodeStatusSuccess, ""},
+		"use":     {NodeStatusSkipped, ""},
+		"alert":   {NodeStatusSkipped, ""},
+		"check":   {NodeStatusFailed, OutcomeAssertionFailed},
+		"invalid": {NodeStatusSuccess, ""},
+		"ping":    {NodeStatusFailed, OutcomeFailure},
+		"offline": {NodeStatusSuccess, ""},
+	}
+	for _, n := range result.Nodes {
+		if got := [2]string{n.Status, n.Branch}; got != want[n.NodeKey] {
+			t.Errorf("%s: status, branch = %v, want %v", n.NodeKey, got, want[n.NodeKey])
+		}
+		if n.NodeKey == "ping" && n.Outcome != OutcomeNetworkError {
+			t.Errorf("ping outcome = %q", n.Outcome)
+		}
+	}
+
+	// A failure without a matching edge still stops the run
+	flowID = createGraphFlow(t, q, FlowGraph{
+		Nodes: []FlowGraphNode{
+			graphNode("fetch", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/missing"}),
+			graphNode("server", FlowNodeRequest, RequestNodeConfig{URL: ts.URL + "/retry"}),
+		},
+		Edges: []FlowGraphEdge{{Source: "fetch", Target: "server", Label: Outcome5xx}},
+	})
+	result, err = fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || nodeStatuses(result)["server"] != NodeStatusSkipped {
+		t.Errorf("unrouted 404: success = %v, nodes = %v", result.Success, nodeStatuses(result))
+	}
+}
+
+func TestValidateFlowGraph(t *testing.T) {
+	req := func(key string) FlowGraphNode {
+		return graphNode(key, FlowNodeRequest, RequestNodeConfig{URL: "http://x"})
+	}
+	cond := graphNode("c", FlowNodeCondition, ConditionNodeConfig{Expression: "{{ok}}"})
+	tests := []struct {
+		name  string
+		graph FlowGraph
+		want  string
+	}{
+		{"cycle", FlowGraph{Nodes: []FlowGraphNode{req("a"), req("b"), req("c")}, Edges: []FlowGraphEdge{{Source: "a", Target: "b"}, {Source: "b", Target: "c"}, {Source: "c", Target: "b"}}}, "cycle"},
+		{"two entries", FlowGraph{Nodes: []FlowGraphNode{req("a"), req("b")}}, "exactly one entry"},
+		{"fan-out from request", FlowGraph{Nodes: []FlowGraphNode{req("a"), req("b"), req("c")}, Edges: []FlowGraphEdge{{Source: "a", Target: "b"}, {Source: "a", Target: "c"}}}, "several outgoing"},
+		{"unlabelled condition edge", FlowGraph{Nodes: []FlowGraphNode{cond, req("b")}, Edges: []FlowGraphEdge{{Source: "c", Target: "b"}}}, `"true" or "false"`},
+		{"outcome label on delay", FlowGraph{Nodes: []FlowGraphNode{graphNode("d", FlowNodeDelay, DelayNodeConfig{}), req("b")}, Edges: []FlowGraphEdge{{Source: "d", Target: "b", Label: OutcomeFailure}}}, "invalid edge label"},
+		{"status class on script", FlowGraph{Nodes: []FlowGraphNode{graphNode("s", FlowNodeScript, ScriptNodeConfig{Script: "1"}), req("b")}, Edges: []FlowGraphEdge{{Source: "s", Target: "b", Label: Outcome4xx}}}, "invalid edge label"},
+		{"mixed labels", FlowGraph{Nodes: []FlowGraphNode{req("a"), req("b"), req("c")}, Edges: []FlowGraphEdge{{Source: "a", Target: "b", Label: OutcomeFailure}, {Source: "a", Target: "c"}}}, "all labelled or all unlabelled"},
+		{"unknown type", FlowGraph{Nodes: []FlowGraphNode{{Key: "x", Type: "loop"}}}, "unknown node type"},
+		{"missing url", FlowGraph{Nodes: []FlowGraphNode{{Key: "x", Type: FlowNodeRequest}}}, "requestId or url"},
+		{"dangling edge", FlowGraph{Nodes: []FlowGraphNode{req("a")}, Edges: []FlowGraphEdge{{Source: "a", Target: "z"}}}, "not a node"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFlowGraph(&tt.graph)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxFlowOutputs caps the outputs a flow can declare
+const maxFlowOutputs = 100
+
+// ParseFlowOutputs decodes the outputs column: output name -> variable name.
+// nil means the flow declares none.
+func ParseFlowOutputs(raw sql.NullString) map[string]string {
+	if !raw.Valid || raw.String == "" {
+		return nil
+	}
+	var outputs map[string]string
+	if err := json.Unmarshal([]byte(raw.String), &outputs); err != nil || len(outputs) == 0 {
+		return nil
+	}
+	return outputs
+}
+
+// ValidateFlowOutputs checks that every output has a name and a variable
+func ValidateFlowOutputs(outputs map[string]string) error {
+	if len(outputs) > maxFlowOutputs {
+		return fmt.Errorf("at most %d outputs are allowed", maxFlowOutputs)
+	}
+	for name, variable := range outputs {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("output name is required")
+		}
+		if strings.TrimSpace(variable) == "" {
+			return fmt.Errorf("output %q: variable is required", name)
+		}
+	}
+	return nil
+}
+
+// collectOutputs reads the flow's outputs once the run ends. Each variable is
+// looked up the way {{name}} would resolve (runtime, then environment, then
+// globals); unset variables are left out.
+func (fr *FlowRunner) collectOutputs(ctx context.Context, outputs, runtimeVars map[string]string) map[string]string {
+	if len(outputs) == 0 {
+		return nil
+	}
+	vars := fr.variableResolver.buildAllVars(ctx, runtimeVars)
+	collected := make(map[string]string, len(outputs))
+	for name, variable := range outputs {
+		variable = strings.TrimSpace(variable)
+		variable = strings.TrimSuffix(strings.TrimPrefix(variable, "{{"), "}}")
+		if v, ok := vars[variable]; ok {
+			collected[name] = v
+		}
+	}
+	return collected
+}
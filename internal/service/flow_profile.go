@@ -0,0 +1,121 @@
+package service
+
+import (
+	"runtime/metrics"
+	"time"
+)
+
+// StepProfile breaks a step's wall time down by phase
+type StepProfile struct {
+	TotalMs      int64 `json:"totalMs"`
+	ScriptMs     int64 `json:"scriptMs"`     // pre- and post-scripts
+	HTTPMs       int64 `json:"httpMs"`       // request round trip
+	QueueMs      int64 `json:"queueMs"`      // waiting for the host limit
+	DelayMs      int64 `json:"delayMs"`      // the step's configured delay
+	ExtractionMs int64 `json:"extractionMs"` // extractVars
+	OtherMs      int64 `json:"otherMs"`      // variable resolution, conditions, history writes
+	// AllocBytes is the heap allocated while the step ran. It is measured
+	// process-wide, so concurrent runs inflate it.
+	AllocBytes uint64 `json:"allocBytes"`
+}
+
+// RunProfile sums the step profiles of a run
+type RunProfile struct {
+	StepProfile
+	Steps         int   `json:"steps"` // step results, each loop iteration and skipped step included
+	SlowestStepID int64 `json:"slowestStepId,omitempty"`
+	SlowestMs     int64 `json:"slowestMs,omitempty"`
+}
+
+// stepProfiler accumulates one step's phase timings
+type stepProfiler struct {
+	start  time.Time
+	allocs uint64
+	p      StepProfile
+	timing stepTiming
+}
+
+func newStepProfiler() *stepProfiler {
+	return &stepProfiler{start: time.Now(), allocs: heapAllocs()}
+}
+
+// since adds the time elapsed from start to the phase counter
+func (sp *stepProfiler) since(phase *int64, start time.Time) {
+	now := time.Now()
+	*phase += now.Sub(start).Milliseconds()
+	kind := "other"
+	switch phase {
+	case &sp.p.ScriptMs:
+		kind = TimelinePhaseScript
+	case &sp.p.DelayMs:
+		kind = TimelinePhaseDelay
+	case &sp.p.ExtractionMs:
+		kind = TimelinePhaseExtraction
+	}
+	sp.timing.phases = append(sp.timing.phases, timedPhase{kind: kind, start: start, end: now})
+}
+
+// request records an execution's HTTP and queue time. The execution has just
+// finished, so its phases are laid out back from now.
+func (sp *stepProfiler) request(r *ExecuteResult) {
+	if r != nil {
+		sp.p.HTTPMs += r.DurationMs
+		sp.p.QueueMs += r.QueuedMs
+		end := time.Now()
+		httpStart := end.Add(-time.Duration(r.DurationMs) * time.Millisecond)
+		if r.QueuedMs > 0 {
+			sp.timing.phases = append(sp.timing.phases, timedPhase{kind: TimelinePhaseQueue, start: httpStart.Add(-time.Duration(r.QueuedMs) * time.Millisecond), end: httpStart})
+		}
+		sp.timing.phases = append(sp.timing.phases, timedPhase{kind: TimelinePhaseHTTP, start: httpStart, end: end})
+	}
+}
+
+func (sp *stepProfiler) finish() *StepProfile {
+	sp.timing.start, sp.timing.end = sp.start, time.Now()
+	p := sp.p
+	p.TotalMs = time.Since(sp.start).Milliseconds()
+	p.OtherMs = p.TotalMs - p.ScriptMs - p.HTTPMs - p.QueueMs - p.DelayMs - p.ExtractionMs
+	if p.OtherMs < 0 {
+		p.OtherMs = 0 // millisecond rounding
+	}
+	p.AllocBytes = heapAllocs() - sp.allocs
+	return &p
+}
+
+// summarizeProfile totals the profiles of the run's executed steps
+func summarizeProfile(steps []StepResult) *RunProfile {
+	rp := &RunProfile{}
+	for _, s := range steps {
+		p := s.Profile
+		if p == nil {
+			continue
+		}
+		rp.Steps++
+		rp.TotalMs += p.TotalMs
+		rp.ScriptMs += p.ScriptMs
+		rp.HTTPMs += p.HTTPMs
+		rp.QueueMs += p.QueueMs
+		rp.DelayMs += p.DelayMs
+		rp.ExtractionMs += p.ExtractionMs
+		rp.OtherMs += p.OtherMs
+		rp.AllocBytes += p.AllocBytes
+		if p.TotalMs > rp.SlowestMs || rp.SlowestStepID == 0 {
+			rp.SlowestStepID = s.StepID
+			rp.SlowestMs = p.TotalMs
+		}
+	}
+	return rp
+}
+
+var heapAllocSample = "/gc/heap/allocs:bytes"
+
+// heapAllocs returns the cumulative bytes allocated on the heap. Unlike
+// runtime.ReadMemStats it does not stop the world.
+func heapAllocs() uint64 {
+	s := []metrics.Sample{{Name: heapAllocSample}}
+	metrics.Read(s)
+	if s[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return s[0].Value.Uint64()
+}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"relay/internal/middleware"
 	"relay/internal/repository"
 
 	"github.com/PaesslerAG/jsonpath"
+	"github.com/google/uuid"
 )
 
 type FlowRunner struct {
@@ -21,15 +25,67 @@ type FlowRunner struct {
 	variableResolver   *VariableResolver
 	scriptExecutor     *ScriptExecutor
 	jsScriptExecutor   *JSScriptExecutor
+	wasmExtensions     *WasmExtensions
+	oauth2             *OAuth2Tokens
+
+	activeMu   sync.Mutex
+	activeRuns map[string]ActiveRun // by trace ID
+
+	approvalMu sync.Mutex
+	approvals  map[string]*pendingGate // by run ID
+}
+
+// ActiveRun is a flow run in progress
+type ActiveRun struct {
+	FlowID    int64     `json:"flowId"`
+	FlowName  string    `json:"flowName"`
+	TraceID   string    `json:"traceId"`
+	StartedAt time.Time `json:"startedAt"`
 }
 
 func NewFlowRunner(queries *repository.Queries, re *RequestExecutor, vr *VariableResolver) *FlowRunner {
+	oauth2 := NewOAuth2Tokens(queries, vr)
+	if re != nil {
+		oauth2.SetFileStorage(re.fileStorage)
+	}
 	return &FlowRunner{
 		queries:            queries,
 		requestExecutor:    re,
 		variableResolver:   vr,
 		scriptExecutor:     NewScriptExecutor(vr),
 		jsScriptExecutor:   NewJSScriptExecutor(vr),
+		wasmExtensions:     NewWasmExtensions(queries),
+		oauth2:             oauth2,
+		activeRuns:         make(map[string]ActiveRun),
+		approvals:          make(map[string]*pendingGate),
+	}
+}
+
+// ActiveRuns lists the flow runs currently executing, oldest first
+func (fr *FlowRunner) ActiveRuns() []ActiveRun {
+	fr.activeMu.Lock()
+	defer fr.activeMu.Unlock()
+	runs := make([]ActiveRun, 0, len(fr.activeRuns))
+	for _, run := range fr.activeRuns {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.Before(runs[j].StartedAt) })
+	return runs
+}
+
+// ClearCaches drops compiled wasm modules and returns how many there were
+func (fr *FlowRunner) ClearCaches(ctx context.Context) int {
+	return fr.wasmExtensions.ClearCache(ctx)
+}
+
+func (fr *FlowRunner) trackRun(run ActiveRun) func() {
+	fr.activeMu.Lock()
+	fr.activeRuns[run.TraceID] = run
+	fr.activeMu.Unlock()
+	return func() {
+		fr.activeMu.Lock()
+		delete(fr.activeRuns, run.TraceID)
+		fr.activeMu.Unlock()
 	}
 }
 
@@ -46,16 +102,35 @@ type StepResult struct {
 	PreScriptResult  *ScriptResult     `json:"preScriptResult,omitempty"`
 	PostScriptResult *ScriptResult     `json:"postScriptResult,omitempty"`
 	Warnings         []string          `json:"warnings,omitempty"`
+	Profile          *StepProfile      `json:"profile,omitempty"` // where the step's time went
+	Wait             *WaitResult       `json:"wait,omitempty"`
+	Approval         *ApprovalResult   `json:"approval,omitempty"`
+	ScriptRequests   []ScriptRequest   `json:"scriptRequests,omitempty"` // pm.sendRequest calls of the step's scripts
+
+	timing *stepTiming // for the run timeline
 }
 
 type FlowResult struct {
-	FlowID      int64        `json:"flowId"`
-	FlowName    string       `json:"flowName"`
-	Steps       []StepResult `json:"steps"`
-	TotalTimeMs int64        `json:"totalTimeMs"`
-	Success     bool         `json:"success"`
-	Error       string       `json:"error,omitempty"`
-	Warnings    []string     `json:"warnings,omitempty"`
+	FlowID          int64            `json:"flowId"`
+	FlowName        string           `json:"flowName"`
+	Steps           []StepResult     `json:"steps"`
+	TotalTimeMs     int64            `json:"totalTimeMs"`
+	Success         bool             `json:"success"`
+	Error           string           `json:"error,omitempty"`
+	Warnings        []string         `json:"warnings,omitempty"`
+	VariableChanges []VariableChange `json:"variableChanges,omitempty"`
+	RunID           string           `json:"runId"`   // execution group of the run's history entries
+	TraceID         string           `json:"traceId"` // trace ID of every request in the run
+	FrozenTime      string           `json:"frozenTime,omitempty"` // RFC3339, when the run's clock was frozen
+	Seed            *int64           `json:"seed,omitempty"`       // random seed of a seeded run
+	SafeMode        bool             `json:"safeMode,omitempty"`   // only read-only requests were sent
+	Profile         *RunProfile      `json:"profile,omitempty"`
+	// RestoredVariables lists the persisted writes rolled back after the run
+	RestoredVariables []VariableChange `json:"restoredVariables,omitempty"`
+	// Outputs holds the flow's declared outputs (name -> value) at the end of the run
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// Nodes holds the node results of a graph flow, in completion order
+	Nodes []NodeResult `json:"nodes,omitempty"`
 }
 
 // StepStartEvent is sent when a step begins execution
@@ -73,23 +148,113 @@ type FlowCompleteEvent struct {
 	Error       string `json:"error,omitempty"`
 }
 
+// DebugCommand tells a paused debug run how to proceed
+type DebugCommand string
+
+const (
+	DebugContinue DebugCommand = "continue" // run until the next breakpoint
+	DebugStep     DebugCommand = "step"     // pause again before the next step
+	DebugAbort    DebugCommand = "abort"    // stop the run
+)
+
+// PauseEvent is sent when a debug run pauses before executing a step
+type PauseEvent struct {
+	StepID      int64             `json:"stepId"`
+	StepName    string            `json:"stepName"`
+	Iteration   int64             `json:"iteration"`
+	LoopCount   int64             `json:"loopCount"`
+	Reason      string            `json:"reason"` // "breakpoint" or "step"
+	RuntimeVars map[string]string `json:"runtimeVars"`
+}
+
 // StreamCallbacks holds callback functions for streaming flow execution
 type StreamCallbacks struct {
 	OnStepStart    func(StepStartEvent)
 	OnStepComplete func(StepResult)
 	OnFlowComplete func(FlowCompleteEvent)
+	// OnStepWait is called after each poll of a step with waitUntil
+	OnStepWait func(WaitEvent)
+	// OnPause blocks until the debugger decides how to proceed (debug runs only)
+	OnPause func(PauseEvent) DebugCommand
+	// OnApproval is called when the run starts waiting at an approval gate
+	OnApproval func(PendingApproval)
+}
+
+// ErrInvalidRunOptions is returned when run options reference steps outside the flow
+var ErrInvalidRunOptions = errors.New("invalid run options")
+
+// RunOptions controls which part of a flow is executed and with what initial state
+type RunOptions struct {
+	// StepIDs restricts execution to the given steps (empty = all steps)
+	StepIDs []int64
+	// StartStepID begins execution at this step, skipping earlier ones (0 = first step)
+	StartStepID int64
+	// EndStepID stops execution after this step (0 = last step). A goto outside
+	// the start..end window fails the run
+	EndStepID int64
+	// InitialVars seeds the runtime variables before the first step runs
+	InitialVars map[string]string
+	// Breakpoints pauses the run before these steps (requires StreamCallbacks.OnPause)
+	Breakpoints []int64
+	// VariableMode selects live (default) or snapshot reads of persisted variables
+	VariableMode VariableMode
+	// DryVariables keeps environment/collection/global writes in memory for this
+	// run instead of saving them
+	DryVariables bool
+	// FrozenTime fixes the clock seen by built-in time variables and scripts
+	// (RFC3339 or unix milliseconds; "" = real time)
+	FrozenTime string
+	// Seed makes random built-in values and Math.random() reproducible
+	// (nil = true randomness)
+	Seed *int64
+	// TraceVariables reports in each step's ExecuteResult which scope every
+	// variable was resolved from
+	TraceVariables bool
+	// CacheSendRequests answers repeated pm.sendRequest calls with the same
+	// method, URL and body from the run's first response
+	CacheSendRequests bool
+	// RestoreVariables rolls back the run's environment/collection/global
+	// writes when it ends ("always") or fails ("onFailure"); "" keeps them
+	RestoreVariables VariableRestore
+	// SafeMode only sends GET, HEAD and OPTIONS requests; steps whose
+	// request is blocked are skipped
+	SafeMode bool
+	// Chaos injects latency, dropped requests and synthetic error responses
+	// into the run's requests (nil = none)
+	Chaos *ChaosOptions
+	// ValidateSpec checks each response against the workspace's OpenAPI
+	// specs; a mismatch fails the step like a failed assertion
+	ValidateSpec bool
 }
 
 func (fr *FlowRunner) Run(ctx context.Context, flowID int64, selectedStepIDs []int64) (*FlowResult, error) {
-	return fr.runInternal(ctx, flowID, selectedStepIDs, nil)
+	return fr.runInternal(ctx, flowID, &RunOptions{StepIDs: selectedStepIDs}, nil)
 }
 
 // RunStream executes a flow with streaming callbacks for real-time progress
 func (fr *FlowRunner) RunStream(ctx context.Context, flowID int64, selectedStepIDs []int64, callbacks *StreamCallbacks) (*FlowResult, error) {
-	return fr.runInternal(ctx, flowID, selectedStepIDs, callbacks)
+	return fr.runInternal(ctx, flowID, &RunOptions{StepIDs: selectedStepIDs}, callbacks)
 }
 
-func (fr *FlowRunner) runInternal(ctx context.Context, flowID int64, selectedStepIDs []int64, callbacks *StreamCallbacks) (*FlowResult, error) {
+// RunWithOptions executes a flow using the given options; callbacks may be nil
+func (fr *FlowRunner) RunWithOptions(ctx context.Context, flowID int64, opts *RunOptions, callbacks *StreamCallbacks) (*FlowResult, error) {
+	if opts == nil {
+		opts = &RunOptions{}
+	}
+	return fr.runInternal(ctx, flowID, opts, callbacks)
+}
+
+// findStepIndex returns the index of the step with the given ID, or -1
+func findStepIndex(steps []repository.FlowStep, stepID int64) int {
+	for i, s := range steps {
+		if s.ID == stepID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (fr *FlowRunner) runInternal(ctx context.Context, flowID int64, opts *RunOptions, callbacks *StreamCallbacks) (*FlowResult, error) {
 	flow, err := fr.queries.GetFlow(ctx, flowID)
 	if err != nil {
 		return nil, err
@@ -99,13 +264,128 @@ func (fr *FlowRunner) runInternal(ctx context.Context, flowID int64, selectedSte
 	if err != nil {
 		return nil, err
 	}
+	graph, err := LoadFlowGraph(ctx, fr.queries, flowID)
+	if err != nil {
+		return nil, err
+	}
+	if len(graph.Nodes) > 0 && (len(opts.StepIDs) > 0 || opts.StartStepID > 0 || opts.EndStepID > 0 || len(opts.Breakpoints) > 0) {
+		return nil, fmt.Errorf("%w: step selection and breakpoints do not apply to graph flows", ErrInvalidRunOptions)
+	}
+
+	// Resolve the execution window (run-from-step / run-single-step)
+	startIndex := 0
+	endIndex := len(steps) - 1
+	if opts.StartStepID > 0 {
+		if startIndex = findStepIndex(steps, opts.StartStepID); startIndex < 0 {
+			return nil, fmt.Errorf("%w: start step %d not found in flow", ErrInvalidRunOptions, opts.StartStepID)
+		}
+	}
+	if opts.EndStepID > 0 {
+		if endIndex = findStepIndex(steps, opts.EndStepID); endIndex < 0 {
+			return nil, fmt.Errorf("%w: end step %d not found in flow", ErrInvalidRunOptions, opts.EndStepID)
+		}
+	}
+	if endIndex < startIndex && len(steps) > 0 {
+		return nil, fmt.Errorf("%w: end step comes before start step", ErrInvalidRunOptions)
+	}
+	if !opts.VariableMode.valid() {
+		return nil, fmt.Errorf("%w: unknown variable mode %q", ErrInvalidRunOptions, opts.VariableMode)
+	}
+	if opts.VariableMode == VariableModeSnapshot {
+		ctx = withVariableSnapshot(ctx)
+	}
+	if opts.DryVariables {
+		ctx = withDryVariables(ctx)
+	}
+	if !opts.RestoreVariables.valid() {
+		return nil, fmt.Errorf("%w: unknown variable restore %q", ErrInvalidRunOptions, opts.RestoreVariables)
+	}
+	var journal *variableJournal
+	if opts.RestoreVariables == VariableRestoreAlways || opts.RestoreVariables == VariableRestoreOnFailure {
+		journal = newVariableJournal()
+		ctx = withVariableJournal(ctx, journal)
+	}
+	if opts.FrozenTime != "" {
+		frozen, err := ParseFrozenTime(opts.FrozenTime)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRunOptions, err)
+		}
+		ctx = WithFrozenClock(ctx, frozen)
+	}
+	if opts.Seed != nil {
+		ctx = WithRandomSeed(ctx, *opts.Seed)
+	}
+	if opts.TraceVariables {
+		ctx = WithVariableTrace(ctx)
+	}
+	if opts.CacheSendRequests {
+		ctx = withSendRequestCache(ctx)
+	}
+	if opts.SafeMode {
+		ctx = WithSafeMode(ctx)
+	}
+	if opts.Chaos != nil {
+		if err := opts.Chaos.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRunOptions, err)
+		}
+		ctx = WithChaos(ctx, *opts.Chaos)
+	}
+	if opts.ValidateSpec {
+		ctx = WithSpecValidation(ctx)
+	}
+	if raw, err := fr.queries.GetWorkspaceSettings(ctx, middleware.GetWorkspaceID(ctx)); err == nil {
+		settings := ParseWorkspaceSettings(raw)
+		ctx = withScriptRequestBudget(ctx, settings.ScriptRequests.MaxPerRun)
+		if settings.SafeMode.Enabled {
+			ctx = WithSafeMode(ctx)
+		}
+	}
+	// One trace per run groups its requests in history; the run is the
+	// parent span of its step executions
+	runTrace := requestTrace{TraceID: NewTraceID(), SpanID: newSpanID()}
+	ctx = withTraceSpan(ctx, runTrace.TraceID, runTrace.SpanID)
+	runID := uuid.NewString()
+	ctx = withExecutionGroup(ctx, runID, flowID)
+
+	selectedStepIDs := opts.StepIDs
 
 	result := &FlowResult{
 		FlowID:   flowID,
 		FlowName: flow.Name,
+		RunID:    runID,
+		TraceID:  runTrace.TraceID,
 		Steps:    make([]StepResult, 0, len(steps)),
 		Success:  true,
 	}
+	if frozen, ok := frozenClockFrom(ctx); ok {
+		result.FrozenTime = frozen.UTC().Format(time.RFC3339Nano)
+	}
+	result.Seed = opts.Seed
+	result.SafeMode = safeModeFrom(ctx)
+	// Deferred first so the saved result includes everything filled in below
+	defer fr.saveFlowRun(context.WithoutCancel(ctx), result, time.Now())
+	defer func() { result.Profile = summarizeProfile(result.Steps) }()
+	if journal != nil {
+		defer func() {
+			if opts.RestoreVariables == VariableRestoreOnFailure && result.Success {
+				return
+			}
+			// Cancelled runs are rolled back too
+			restored, err := fr.restoreVariables(context.WithoutCancel(ctx), journal)
+			result.RestoredVariables = restored
+			if err != nil {
+				result.Warnings = append(result.Warnings, "Failed to restore variables: "+err.Error())
+			}
+		}()
+	}
+	fr.queries.RecordFlowExecution(ctx, flowID)
+	defer fr.trackRun(ActiveRun{FlowID: flowID, FlowName: flow.Name, TraceID: runTrace.TraceID, StartedAt: time.Now()})()
+	if otlp := fr.requestExecutor.tracingSettings(ctx).OTLP; otlp != nil {
+		runStart := time.Now()
+		defer func() {
+			fr.requestExecutor.exporter.Export(*otlp, flowRunSpan(runTrace, flow, result, runStart))
+		}()
+	}
 
 	// Build set of selected step IDs for quick lookup
 	selectedSet := make(map[int64]bool)
@@ -113,6 +393,12 @@ func (fr *FlowRunner) runInternal(ctx context.Context, flowID int64, selectedSte
 		selectedSet[id] = true
 	}
 
+	breakpointSet := make(map[int64]bool)
+	for _, id := range opts.Breakpoints {
+		breakpointSet[id] = true
+	}
+	stepping := false
+
 	// Build step name -> index map for goto resolution (first occurrence wins)
 	stepNameToIndex := make(map[string]int)
 	stepOrderToIndex := make(map[int]int)
@@ -137,7 +423,21 @@ func (fr *FlowRunner) runInternal(ctx context.Context, flowID int64, selectedSte
 
 	// Runtime variables accumulated during flow execution
 	runtimeVars := make(map[string]string)
+	for k, v := range opts.InitialVars {
+		runtimeVars[k] = v
+	}
+	if outputs := ParseFlowOutputs(flow.Outputs); outputs != nil {
+		defer func() { result.Outputs = fr.collectOutputs(context.WithoutCancel(ctx), outputs, runtimeVars) }()
+	}
+
 	startTime := time.Now()
+	defer fr.saveTimeline(context.WithoutCancel(ctx), result, startTime)
+
+	// Graph flows walk their nodes and edges instead of the step list
+	if len(graph.Nodes) > 0 {
+		fr.runGraph(ctx, flow, graph, runtimeVars, result, callbacks)
+		return result, nil
+	}
 
 	// Track execution limits
 	gotoJumps := 0
@@ -146,9 +446,9 @@ func (fr *FlowRunner) runInternal(ctx context.Context, flowID int64, selectedSte
 	maxIterations := 1000
 
 	// Use index-based iteration for goto support
-	stepIndex := 0
+	stepIndex := startIndex
 outer:
-	for stepIndex < len(steps) {
+	for stepIndex < len(steps) && stepIndex <= endIndex {
 		// Check for cancellation before each step
 		select {
 		case <-ctx.Done():
@@ -194,6 +494,39 @@ outer:
 				return result, nil
 			}
 
+			// Pause at breakpoints (or every step while stepping) in debug runs
+			if callbacks != nil && callbacks.OnPause != nil && (stepping || breakpointSet[step.ID]) {
+				reason := "breakpoint"
+				if stepping {
+					reason = "step"
+				}
+				varsSnapshot := make(map[string]string, len(runtimeVars))
+				for k, v := range runtimeVars {
+					varsSnapshot[k] = v
+				}
+				switch callbacks.OnPause(PauseEvent{
+					StepID:      step.ID,
+					StepName:    step.Name,
+					Iteration:   iteration,
+					LoopCount:   loopCount,
+					Reason:      reason,
+					RuntimeVars: varsSnapshot,
+				}) {
+				case DebugStep:
+					stepping = true
+				case DebugAbort:
+					result.Success = false
+					result.Error = "aborted"
+					result.TotalTimeMs = time.Since(startTime).Milliseconds()
+					if callbacks.OnFlowComplete != nil {
+						callbacks.OnFlowComplete(FlowCompleteEvent{Success: false, TotalTimeMs: result.TotalTimeMs, Error: result.Error})
+					}
+					return result, nil
+				default:
+					stepping = false
+				}
+			}
+
 			// Notify step start
 			if callbacks != nil && callbacks.OnStepStart != nil {
 				callbacks.OnStepStart(StepStartEvent{
@@ -208,6 +541,7 @@ outer:
 			runtimeVars["__iteration__"] = strconv.FormatInt(iteration, 10)
 			runtimeVars["__loopCount__"] = strconv.FormatInt(loopCount, 10)
 
+			prof := newStepProfiler()
 			stepResult := StepResult{
 				StepID:        step.ID,
 				RequestID:     reqID,
@@ -216,6 +550,17 @@ outer:
 				Iteration:     iteration,
 				LoopCount:     loopCount,
 			}
+			// pm.sendRequest calls of the step's scripts use its proxy and
+			// are attached to its history entry
+			stepCtx, scriptReqs := WithScriptRequests(ctx, step.ProxyID)
+			// Helper to record the step, with its profile, in the run result
+			addStep := func() {
+				stepResult.Profile = prof.finish()
+				stepResult.timing = &prof.timing
+				stepResult.ScriptRequests = scriptReqs.Calls()
+				scriptReqs.Attach(ctx, fr.queries, stepResult.ExecuteResult)
+				result.Steps = append(result.Steps, stepResult)
+			}
 
 			// Build script context
 			scriptCtx := &ScriptContext{
@@ -241,19 +586,34 @@ outer:
 				}
 			}
 
+			// Helper to append variable mutations to the run's change log
+			recordChanges := func(source string, changes []VariableChange) {
+				for _, c := range changes {
+					c.StepID = step.ID
+					c.StepName = step.Name
+					c.Iteration = iteration
+					c.Source = source
+					result.VariableChanges = append(result.VariableChanges, c)
+				}
+			}
+
 			// Execute pre-script
 			if step.PreScript.Valid && step.PreScript.String != "" {
-				preResult := fr.executeScript(ctx, step.PreScript.String, scriptCtx, runtimeVars)
+				before := cloneVars(runtimeVars)
+				scriptStart := time.Now()
+				preResult := fr.executeScript(stepCtx, step.PreScript.String, scriptCtx, runtimeVars)
+				prof.since(&prof.p.ScriptMs, scriptStart)
 				stepResult.PreScriptResult = preResult
 
 				// Apply updated variables
 				for k, v := range preResult.UpdatedVars {
 					runtimeVars[k] = v
 				}
+				recordChanges("preScript", append(diffVars(VarScopeRuntime, before, runtimeVars), preResult.VariableChanges...))
 
 				// Handle pre-script flow control
 				if preResult.FlowAction == FlowActionStop {
-					result.Steps = append(result.Steps, stepResult)
+					addStep()
 					emitStepComplete(stepResult)
 					finalizeFlow()
 					return result, nil
@@ -262,19 +622,27 @@ outer:
 
 			// Build request from step's inline fields
 			req := repository.Request{
-				Name:     step.Name,
-				Method:   step.Method,
-				Url:      step.Url,
-				Headers:  step.Headers,
-				Body:     step.Body,
-				BodyType: step.BodyType,
-				Cookies:  step.Cookies,
-				ProxyID:  step.ProxyID,
+				Name:              step.Name,
+				Method:            step.Method,
+				Url:               step.Url,
+				Headers:           step.Headers,
+				Body:              step.Body,
+				BodyType:          step.BodyType,
+				Cookies:           step.Cookies,
+				ProxyID:           step.ProxyID,
+				ResponseTransform: step.ResponseTransform,
+				TimeoutMs:         step.TimeoutMs,
+				RetryCount:        step.RetryCount,
+				RetryBackoffMs:    step.RetryBackoffMs,
+				RetryOnStatus:     step.RetryOnStatus,
+				DisableRedirects:  step.DisableRedirects,
+				MaxRedirects:      step.MaxRedirects,
 			}
 
-			if step.Url == "" {
+			gate, _ := ParseApprovalGate(step.Approval.String)
+			if step.Url == "" && gate == nil {
 				stepResult.ExecuteResult = &ExecuteResult{Error: "step has no URL configured"}
-				result.Steps = append(result.Steps, stepResult)
+				addStep()
 				emitStepComplete(stepResult)
 				result.Success = false
 				result.Error = "step has no URL configured"
@@ -284,11 +652,87 @@ outer:
 
 			// Check condition
 			if step.Condition.Valid && step.Condition.String != "" {
-				conditionMet, err := fr.evaluateCondition(step.Condition.String, runtimeVars)
-				if err != nil || !conditionMet {
+				conditionMet, err := fr.evaluateCondition(ctx, step.Condition.String, runtimeVars)
+				if err != nil {
+					// A malformed condition fails the step rather than skipping it
+					msg := "invalid condition: " + err.Error()
+					stepResult.ExecuteResult = &ExecuteResult{Error: msg}
+					addStep()
+					emitStepComplete(stepResult)
+					result.Success = false
+					result.Error = msg
+					finalizeFlow()
+					return result, nil
+				}
+				if !conditionMet {
 					stepResult.Skipped = true
 					stepResult.SkipReason = "Condition not met"
-					result.Steps = append(result.Steps, stepResult)
+					addStep()
+					emitStepComplete(stepResult)
+					iteration++
+					continue
+				}
+			}
+
+			// Wait for someone to approve the step
+			if gate != nil {
+				var onApproval func(PendingApproval)
+				if callbacks != nil {
+					onApproval = callbacks.OnApproval
+				}
+				approvalStart := time.Now()
+				approval, err := fr.awaitApproval(ctx, PendingApproval{
+					RunID:       runID,
+					WorkspaceID: middleware.GetWorkspaceID(ctx),
+					FlowID:      flowID,
+					FlowName:    flow.Name,
+					StepID:      step.ID,
+					StepName:    step.Name,
+				}, gate, onApproval)
+				prof.since(&prof.p.DelayMs, approvalStart)
+				if err != nil {
+					result.Success = false
+					result.Error = "cancelled"
+					result.TotalTimeMs = time.Since(startTime).Milliseconds()
+					if callbacks != nil && callbacks.OnFlowComplete != nil {
+						callbacks.OnFlowComplete(FlowCompleteEvent{Success: false, TotalTimeMs: result.TotalTimeMs, Error: "cancelled"})
+					}
+					return result, nil
+				}
+				stepResult.Approval = approval
+
+				onTimeout := gate.OnTimeout
+				switch {
+				case approval.Decision == ApprovalRejected:
+					// A rejection always stops the run, whatever continueOnError says
+					addStep()
+					emitStepComplete(stepResult)
+					result.Success = false
+					result.Error = fmt.Sprintf("step %q: approval rejected", step.Name)
+					if approval.Comment != "" {
+						result.Error += ": " + approval.Comment
+					}
+					finalizeFlow()
+					return result, nil
+				case approval.Decision == ApprovalTimedOut && onTimeout == ApprovalOnTimeoutSkip:
+					stepResult.Skipped = true
+					stepResult.SkipReason = "Approval timed out"
+					addStep()
+					emitStepComplete(stepResult)
+					iteration++
+					continue
+				case approval.Decision == ApprovalTimedOut && onTimeout != ApprovalOnTimeoutApprove:
+					addStep()
+					emitStepComplete(stepResult)
+					result.Success = false
+					result.Error = fmt.Sprintf("step %q: approval timed out", step.Name)
+					finalizeFlow()
+					return result, nil
+				}
+
+				// A gate-only step has nothing to send
+				if step.Url == "" {
+					addStep()
 					emitStepComplete(stepResult)
 					iteration++
 					continue
@@ -297,6 +741,7 @@ outer:
 
 			// Apply delay (context-aware)
 			if step.DelayMs.Valid && step.DelayMs.Int64 > 0 {
+				delayStart := time.Now()
 				select {
 				case <-ctx.Done():
 					result.Success = false
@@ -308,13 +753,29 @@ outer:
 					return result, nil
 				case <-time.After(time.Duration(step.DelayMs.Int64) * time.Millisecond):
 				}
+				prof.since(&prof.p.DelayMs, delayStart)
 			}
 
-			// Execute request using inline fields
-			execResult, err := fr.requestExecutor.ExecuteRequest(ctx, req, runtimeVars)
+			// Execute request using inline fields, polling it if the step waits
+			// for a condition
+			fr.oauth2.RefreshExpired(ctx)
+			wait, err := ParseWaitUntil(step.WaitUntil.String)
+			var execResult *ExecuteResult
+			if err == nil && wait != nil {
+				var onPoll func(WaitEvent)
+				if callbacks != nil {
+					onPoll = callbacks.OnStepWait
+				}
+				execResult, stepResult.Wait, err = fr.waitFor(ctx, step, req, runtimeVars, wait, prof, onPoll)
+			} else if err == nil {
+				execResult, err = fr.requestExecutor.ExecuteRequest(ctx, req, runtimeVars)
+				if err == nil {
+					prof.request(execResult)
+				}
+			}
 			if err != nil {
 				stepResult.ExecuteResult = &ExecuteResult{Error: err.Error()}
-				result.Steps = append(result.Steps, stepResult)
+				addStep()
 				emitStepComplete(stepResult)
 				if !step.ContinueOnError.Valid || step.ContinueOnError.Int64 == 0 {
 					result.Success = false
@@ -327,9 +788,34 @@ outer:
 			}
 			stepResult.ExecuteResult = execResult
 
-			// Stop on non-2xx HTTP status (unless continueOnError is set)
-			if execResult.StatusCode < 200 || execResult.StatusCode >= 300 {
-				result.Steps = append(result.Steps, stepResult)
+			// Safe mode blocked the request: nothing was sent, move on
+			if execResult.SafeModeBlocked {
+				stepResult.Skipped = true
+				stepResult.SkipReason = execResult.Error
+				addStep()
+				emitStepComplete(stepResult)
+				iteration++
+				continue
+			}
+
+			// Stop when the wait timed out (unless continueOnError is set)
+			if stepResult.Wait != nil && !stepResult.Wait.Met {
+				addStep()
+				emitStepComplete(stepResult)
+				if !step.ContinueOnError.Valid || step.ContinueOnError.Int64 == 0 {
+					result.Success = false
+					result.Error = fmt.Sprintf("step %q: wait condition not met after %d attempts", step.Name, stepResult.Wait.Attempts)
+					finalizeFlow()
+					return result, nil
+				}
+				iteration++
+				continue
+			}
+
+			// Stop on non-2xx HTTP status (unless continueOnError is set). A
+			// met wait condition has already accepted the response.
+			if stepResult.Wait == nil && (execResult.StatusCode < 200 || execResult.StatusCode >= 300) {
+				addStep()
 				emitStepComplete(stepResult)
 				if !step.ContinueOnError.Valid || step.ContinueOnError.Int64 == 0 {
 					result.Success = false
@@ -348,17 +834,22 @@ outer:
 			// Update script context with response
 			scriptCtx.StatusCode = execResult.StatusCode
 			scriptCtx.ResponseBody = execResult.Body
+			scriptCtx.ResponseBytes = execResult.RawBody()
 			scriptCtx.Headers = execResult.Headers
 			scriptCtx.DurationMs = execResult.DurationMs
 
 			// Extract variables from response (legacy extractVars)
 			if step.ExtractVars.Valid && step.ExtractVars.String != "" && step.ExtractVars.String != "{}" {
+				extractStart := time.Now()
 				extracted, err := fr.extractVariables(execResult.Body, step.ExtractVars.String)
+				prof.since(&prof.p.ExtractionMs, extractStart)
 				if err == nil {
+					before := cloneVars(runtimeVars)
 					stepResult.ExtractedVars = extracted
 					for k, v := range extracted {
 						runtimeVars[k] = v
 					}
+					recordChanges("extractVars", diffVars(VarScopeRuntime, before, runtimeVars))
 				}
 			}
 
@@ -379,7 +870,10 @@ outer:
 					Headers: reqHeaders,
 					Body:    step.Body.String,
 				}
-				postResult := fr.executeScriptWithRequest(ctx, step.PostScript.String, scriptCtx, runtimeVars, reqInfo, 0)
+				before := cloneVars(runtimeVars)
+				scriptStart := time.Now()
+				postResult := fr.executeScriptWithRequest(stepCtx, step.PostScript.String, scriptCtx, runtimeVars, reqInfo, 0)
+				prof.since(&prof.p.ScriptMs, scriptStart)
 				stepResult.PostScriptResult = postResult
 
 				// Apply updated variables
@@ -387,6 +881,7 @@ outer:
 					runtimeVars[k] = v
 					scriptCtx.RuntimeVars[k] = v
 				}
+				recordChanges("postScript", append(diffVars(VarScopeRuntime, before, runtimeVars), postResult.VariableChanges...))
 
 				// Merge script extracted vars into result
 				for k, v := range postResult.UpdatedVars {
@@ -399,7 +894,7 @@ outer:
 
 				// Check assertions
 				if !postResult.Success && (!step.ContinueOnError.Valid || step.ContinueOnError.Int64 == 0) {
-					result.Steps = append(result.Steps, stepResult)
+					addStep()
 					emitStepComplete(stepResult)
 					result.Success = false
 					if len(postResult.Errors) > 0 {
@@ -410,7 +905,16 @@ outer:
 				}
 			}
 
-			result.Steps = append(result.Steps, stepResult)
+			if v := execResult.SpecValidation; v != nil && !v.Passed && (!step.ContinueOnError.Valid || step.ContinueOnError.Int64 == 0) {
+				addStep()
+				emitStepComplete(stepResult)
+				result.Success = false
+				result.Error = fmt.Sprintf("step %q: %s", step.Name, v.Summary())
+				finalizeFlow()
+				return result, nil
+			}
+
+			addStep()
 			emitStepComplete(stepResult)
 
 			// Check if request failed
@@ -452,6 +956,13 @@ outer:
 					}
 				}
 
+				// A partial run never leaves the window the caller selected
+				if targetIndex >= 0 && (targetIndex < startIndex || targetIndex > endIndex) {
+					result.Success = false
+					result.Error = fmt.Sprintf("step %q: goto target %q is outside the selected step range", step.Name, steps[targetIndex].Name)
+					finalizeFlow()
+					return result, nil
+				}
 				if targetIndex >= 0 {
 					stepIndex = targetIndex
 					continue outer
@@ -515,18 +1026,17 @@ func (fr *FlowRunner) extractVariables(responseBody string, extractVarsJSON stri
 	return extracted, nil
 }
 
-func (fr *FlowRunner) evaluateCondition(condition string, vars map[string]string) (bool, error) {
-	// Simple condition evaluation: check if variable exists and is not empty
-	// Format: "{{varName}}" or "{{varName}} == value"
-	resolved := fr.variableResolver.ResolveWithVars(condition, vars)
-
-	// If condition still contains unresolved variables, return false
-	if resolved == condition && condition != "" {
-		return false, nil
+func (fr *FlowRunner) evaluateCondition(ctx context.Context, condition string, vars map[string]string) (bool, error) {
+	// "{{varName}}" checks the variable is set and truthy; full expressions
+	// such as "{{code}} == 200 && {{env}} in ['dev', 'qa']" are also supported
+	trimmed := strings.TrimSpace(condition)
+	if m := variablePattern.FindStringSubmatchIndex(trimmed); m != nil && m[0] == 0 && m[1] == len(trimmed) {
+		// Bare variable: test the raw value so tokens like "abc==" aren't parsed
+		val, ok := vars[strings.TrimSpace(trimmed[m[2]:m[3]])]
+		return ok && condTruthy(condValue{s: val}), nil
 	}
-
-	// If resolved to non-empty string, condition is met
-	return resolved != "", nil
+	resolved := fr.variableResolver.resolveWithVars(condition, vars, fr.variableResolver.builtinEnv(ctx))
+	return EvaluateCondition(resolved)
 }
 
 // isJavaScript detects if script content is JavaScript (not JSON DSL)
@@ -568,50 +1078,43 @@ func (fr *FlowRunner) executeScriptWithRequest(ctx context.Context, scriptConten
 		if reqInfo != nil {
 			return fr.executeJavaScriptWithRequest(ctx, scriptContent, dslCtx, runtimeVars, reqInfo.URL, reqInfo.Method, reqInfo.Headers, reqInfo.Body, collectionID)
 		}
-		return fr.executeJavaScript(ctx, scriptContent, dslCtx, runtimeVars)
+		return fr.executeJavaScriptWithRequest(ctx, scriptContent, dslCtx, runtimeVars, "", "", nil, "", collectionID)
 	}
 
 	// JSON DSL mode - use existing executor
+	dslCtx.Extensions = fr.wasmExtensions.ForWorkspace(ctx)
+	if _, frozen := frozenClockFrom(ctx); frozen {
+		dslCtx.Clock = fr.variableResolver.clock(ctx)
+	}
+	dslCtx.random = runRandomFrom(ctx)
 	return fr.scriptExecutor.Execute(scriptContent, dslCtx)
 }
 
-// executeJavaScript runs JavaScript using goja
-func (fr *FlowRunner) executeJavaScript(ctx context.Context, script string, dslCtx *ScriptContext, runtimeVars map[string]string) *ScriptResult {
-	return fr.executeJavaScriptWithRequest(ctx, script, dslCtx, runtimeVars, "", "", nil, "", 0)
-}
-
 // executeJavaScriptWithRequest runs JavaScript with full request context
 func (fr *FlowRunner) executeJavaScriptWithRequest(ctx context.Context, script string, dslCtx *ScriptContext, runtimeVars map[string]string, reqURL, reqMethod string, reqHeaders map[string]string, reqBody string, collectionID int64) *ScriptResult {
 	wsID := middleware.GetWorkspaceID(ctx)
 
-	// Get environment vars
-	envVars := make(map[string]string)
-	var activeEnvID int64
-	env, err := fr.queries.GetActiveEnvironment(ctx, wsID)
-	if err == nil {
-		activeEnvID = env.ID
-		if env.Variables.Valid {
-			json.Unmarshal([]byte(env.Variables.String), &envVars)
-		}
+	// Persisted scopes (latest values, or the run's snapshot in snapshot mode)
+	activeEnvID, envVars := fr.variableResolver.getEnvironmentFor(ctx, collectionID)
+	globalVars := fr.variableResolver.getWorkspaceVars(ctx)
+
+	// Bundled libraries the workspace allows scripts to require(), and its
+	// cap on pm.sendRequest calls
+	var scriptLibs []string
+	var maxSendRequests int
+	if raw, err := fr.queries.GetWorkspaceSettings(ctx, wsID); err == nil {
+		settings := ParseWorkspaceSettings(raw)
+		scriptLibs = settings.ScriptLibraries
+		maxSendRequests = settings.ScriptRequests.MaxPerScript
 	}
 
-	// Get workspace (global) variables
-	globalVars := make(map[string]string)
-	wsVars, err := fr.queries.GetWorkspaceVariables(ctx, wsID)
-	if err == nil && wsVars.Valid && wsVars.String != "" {
-		json.Unmarshal([]byte(wsVars.String), &globalVars)
-	}
-
-	// Get collection variables
 	collectionVars := make(map[string]string)
 	if collectionID > 0 {
-		colVars, err := fr.queries.GetCollectionVariables(ctx, collectionID)
-		if err == nil && colVars.Valid && colVars.String != "" {
-			json.Unmarshal([]byte(colVars.String), &collectionVars)
-		}
+		collectionVars = fr.variableResolver.getCollectionVars(ctx, collectionID)
 	}
 
 	// Build JS context
+	var cacheHits int
 	jsCtx := &JSScriptContext{
 		RuntimeVars:             runtimeVars,
 		EnvVars:                 envVars,
@@ -627,47 +1130,68 @@ func (fr *FlowRunner) executeJavaScriptWithRequest(ctx context.Context, script s
 		WorkspaceID:             wsID,
 		ActiveEnvID:             activeEnvID,
 		PendingEnvWrites:        make(map[string]string),
-		GlobalVars:              globalVars,
+		GlobalVars:              cloneVars(globalVars), // pm.globals.set writes through; keep the original for the change log
 		PendingGlobalWrites:     make(map[string]string),
 		CollectionID:            collectionID,
 		CollectionVars:          collectionVars,
 		PendingCollectionWrites: make(map[string]string),
+		ScriptLibraries:         scriptLibs,
 		RequestURL:              reqURL,
 		RequestMethod:           reqMethod,
 		RequestHeaders:          reqHeaders,
 		RequestBody:             reqBody,
-		HTTPClientFunc:          fr.createHTTPClientFunc(ctx),
+		HTTPClientFunc:          fr.createHTTPClientFunc(ctx, collectionID, &cacheHits),
+		MaxSendRequests:         maxSendRequests,
 	}
+	if _, frozen := frozenClockFrom(ctx); frozen {
+		jsCtx.Clock = fr.variableResolver.clock(ctx)
+	}
+	jsCtx.random = runRandomFrom(ctx)
 
 	// Execute JavaScript
 	jsResult := fr.jsScriptExecutor.Execute(script, jsCtx)
 
 	// Persist environment variable changes to DB
 	if len(jsResult.UpdatedEnvVars) > 0 && activeEnvID > 0 {
-		fr.persistEnvironmentVariables(ctx, activeEnvID, envVars, jsResult.UpdatedEnvVars)
+		fr.persistEnvironmentVariables(ctx, activeEnvID, jsResult.UpdatedEnvVars)
 	}
 
 	// Persist global (workspace) variable changes to DB
 	if len(jsResult.UpdatedGlobalVars) > 0 {
-		fr.persistWorkspaceVariables(ctx, wsID, globalVars, jsResult.UpdatedGlobalVars)
+		fr.persistWorkspaceVariables(ctx, wsID, jsResult.UpdatedGlobalVars)
 	}
 
 	// Persist collection variable changes to DB
 	if len(jsResult.UpdatedCollectionVars) > 0 && collectionID > 0 {
-		fr.persistCollectionVariables(ctx, collectionID, collectionVars, jsResult.UpdatedCollectionVars)
+		fr.persistCollectionVariables(ctx, collectionID, jsResult.UpdatedCollectionVars)
+	}
+
+	// Record persisted-scope mutations for the run's change log
+	var changes []VariableChange
+	changes = append(changes, persistedChanges(VarScopeEnvironment, envVars, jsResult.UpdatedEnvVars)...)
+	changes = append(changes, persistedChanges(VarScopeCollection, collectionVars, jsResult.UpdatedCollectionVars)...)
+	changes = append(changes, persistedChanges(VarScopeGlobal, globalVars, jsResult.UpdatedGlobalVars)...)
+	if dryVariablesFrom(ctx) != nil {
+		for i := range changes {
+			changes[i].Dry = true
+		}
 	}
 
 	// Convert to ScriptResult for compatibility
 	return &ScriptResult{
-		Success:          jsResult.Success,
-		Errors:           jsResult.Errors,
-		ErrorDetails:     jsResult.ErrorDetails,
-		AssertionsPassed: jsResult.AssertionsPassed,
-		AssertionsFailed: jsResult.AssertionsFailed,
-		UpdatedVars:      jsResult.UpdatedVars,
-		FlowAction:       jsResult.FlowAction,
-		GotoStepName:     jsResult.GotoStepName,
-		GotoStepOrder:    jsResult.GotoStepOrder,
+		VariableChanges:      changes,
+		Success:              jsResult.Success,
+		Errors:               jsResult.Errors,
+		ErrorDetails:         jsResult.ErrorDetails,
+		AssertionsPassed:     jsResult.AssertionsPassed,
+		AssertionsFailed:     jsResult.AssertionsFailed,
+		Tests:                jsResult.Tests,
+		UpdatedVars:          jsResult.UpdatedVars,
+		FlowAction:           jsResult.FlowAction,
+		GotoStepName:         jsResult.GotoStepName,
+		GotoStepOrder:        jsResult.GotoStepOrder,
+		SendRequestCacheHits: cacheHits,
+		Visualization:        jsResult.Visualization,
 	}
 }
 
@@ -684,13 +1208,14 @@ func (fr *FlowRunner) ExecuteScriptForRequest(ctx context.Context, script string
 // ExecuteScriptForRequestWithResponse runs a post-script for a standalone request with response context
 func (fr *FlowRunner) ExecuteScriptForRequestWithResponse(ctx context.Context, script string, runtimeVars map[string]string, execResult *ExecuteResult, reqURL, reqMethod string, reqHeaders map[string]string, reqBody string, collectionID int64) *ScriptResult {
 	scriptCtx := &ScriptContext{
-		RuntimeVars:  runtimeVars,
-		StatusCode:   execResult.StatusCode,
-		ResponseBody: execResult.Body,
-		Headers:      execResult.Headers,
-		DurationMs:   execResult.DurationMs,
-		Iteration:    1,
-		LoopCount:    1,
+		RuntimeVars:   runtimeVars,
+		StatusCode:    execResult.StatusCode,
+		ResponseBody:  execResult.Body,
+		ResponseBytes: execResult.RawBody(),
+		Headers:       execResult.Headers,
+		DurationMs:    execResult.DurationMs,
+		Iteration:     1,
+		LoopCount:     1,
 	}
 	reqInfo := &RequestInfo{
 		URL:     reqURL,
@@ -701,13 +1226,34 @@ func (fr *FlowRunner) ExecuteScriptForRequestWithResponse(ctx context.Context, s
 	return fr.executeScriptWithRequest(ctx, script, scriptCtx, runtimeVars, reqInfo, collectionID)
 }
 
-// createHTTPClientFunc creates a function for pm.sendRequest
-func (fr *FlowRunner) createHTTPClientFunc(ctx context.Context) func(method, url string, headers map[string]string, body string) (int, string, map[string]string, error) {
+// createHTTPClientFunc creates a function for pm.sendRequest. Calls run
+// through the full executor with the collection's context and, within a
+// ScriptRequests scope, the calling step's proxy; the scope lists every call.
+// With a run's sendRequest cache, repeated calls are answered from it and
+// counted in hits. Sent requests count against the run's budget.
+func (fr *FlowRunner) createHTTPClientFunc(ctx context.Context, collectionID int64, hits *int) func(method, url string, headers map[string]string, body string) (int, string, map[string]string, error) {
+	cache := sendRequestCacheFrom(ctx)
+	scope := scriptRequestsFrom(ctx)
 	return func(method, url string, headers map[string]string, body string) (int, string, map[string]string, error) {
+		if cache != nil {
+			if cached, ok := cache.get(method, url, body); ok {
+				*hits++
+				scope.record(ScriptRequest{Method: method, URL: url, StatusCode: cached.status, Cached: true}, 0)
+				return cached.status, cached.body, cached.headers, nil
+			}
+		}
+		if err := takeScriptRequest(ctx); err != nil {
+			return 0, "", nil, err
+		}
+
 		// Create a temporary request for execution
 		req := repository.Request{
-			Method: method,
-			Url:    url,
+			Method:       method,
+			Url:          url,
+			CollectionID: sql.NullInt64{Int64: collectionID, Valid: collectionID > 0},
+		}
+		if scope != nil {
+			req.ProxyID = scope.proxyID
 		}
 
 		// Set headers as JSON
@@ -727,31 +1273,48 @@ func (fr *FlowRunner) createHTTPClientFunc(ctx context.Context) func(method, url
 		// Execute the request
 		result, err := fr.requestExecutor.ExecuteRequest(ctx, req, nil)
 		if err != nil {
+			scope.record(ScriptRequest{Method: method, URL: url, Error: err.Error()}, 0)
 			return 0, "", nil, err
 		}
+		call := ScriptRequest{Method: method, URL: result.ResolvedURL, StatusCode: result.StatusCode, DurationMs: result.DurationMs, Error: result.Error}
+		if call.URL == "" {
+			call.URL = url
+		}
+		scope.record(call, result.HistoryID)
 
 		if result.Error != "" {
 			return result.StatusCode, result.Body, result.Headers, fmt.Errorf("%s", result.Error)
 		}
 
+		if cache != nil {
+			cache.put(method, url, body, cachedSendResponse{status: result.StatusCode, body: result.Body, headers: result.Headers})
+		}
 		return result.StatusCode, result.Body, result.Headers, nil
 	}
 }
 
-// persistEnvironmentVariables saves updated variables to the database
-func (fr *FlowRunner) persistEnvironmentVariables(ctx context.Context, envID int64, existingVars, newVars map[string]string) error {
-	// Merge existing and new vars (empty string means delete)
-	merged := make(map[string]string)
-	for k, v := range existingVars {
-		merged[k] = v
+// persistEnvironmentVariables merges script writes into the latest stored
+// environment variables, so concurrent runs don't drop each other's keys
+func (fr *FlowRunner) persistEnvironmentVariables(ctx context.Context, envID int64, newVars map[string]string) error {
+	return writeEnvironmentVariables(ctx, fr.queries, fr.variableResolver, envID, newVars)
+}
+
+// writeEnvironmentVariables is persistEnvironmentVariables for writers
+// outside a FlowRunner
+func writeEnvironmentVariables(ctx context.Context, queries *repository.Queries, vr *VariableResolver, envID int64, newVars map[string]string) error {
+	if dry := dryVariablesFrom(ctx); dry != nil {
+		dry.record(varScopeKey{VarScopeEnvironment, envID}, newVars)
+		return nil
 	}
-	for k, v := range newVars {
-		if v == "" {
-			delete(merged, k) // Delete if empty
-		} else {
-			merged[k] = v
-		}
+
+	variableWriteMu.Lock()
+	defer variableWriteMu.Unlock()
+
+	merged := vr.loadEnvironmentVars(ctx, envID)
+	if journal := variableJournalFrom(ctx); journal != nil {
+		journal.record(varScopeKey{VarScopeEnvironment, envID}, merged, newVars)
 	}
+	mergeVarUpdates(merged, newVars)
 
 	// Serialize to JSON
 	varsJSON, err := json.Marshal(merged)
@@ -760,26 +1323,35 @@ func (fr *FlowRunner) persistEnvironmentVariables(ctx context.Context, envID int
 	}
 
 	// Update in database
-	_, err = fr.queries.UpdateEnvironmentVariables(ctx, repository.UpdateEnvironmentVariablesParams{
+	_, err = queries.UpdateEnvironmentVariables(ctx, repository.UpdateEnvironmentVariablesParams{
 		ID:        envID,
 		Variables: sql.NullString{String: string(varsJSON), Valid: true},
 	})
+	if err == nil {
+		if snap := variableSnapshotFrom(ctx); snap != nil {
+			snap.apply(varScopeKey{VarScopeEnvironment, envID}, newVars)
+		}
+	}
 	return err
 }
 
-// persistWorkspaceVariables saves workspace (global) variables to the database
-func (fr *FlowRunner) persistWorkspaceVariables(ctx context.Context, wsID int64, existingVars, newVars map[string]string) error {
-	// Merge existing and new vars (empty string means delete)
-	merged := make(map[string]string)
-	for k, v := range existingVars {
-		merged[k] = v
+// persistWorkspaceVariables merges script writes into the latest stored workspace (global) variables
+func (fr *FlowRunner) persistWorkspaceVariables(ctx context.Context, wsID int64, newVars map[string]string) error {
+	if dry := dryVariablesFrom(ctx); dry != nil {
+		dry.record(varScopeKey{VarScopeGlobal, wsID}, newVars)
+		return nil
 	}
-	for k, v := range newVars {
-		if v == "" {
-			delete(merged, k) // Delete if empty
-		} else {
-			merged[k] = v
-		}
+
+	variableWriteMu.Lock()
+	defer variableWriteMu.Unlock()
+
+	merged := fr.variableResolver.loadWorkspaceVars(ctx, wsID)
+	if journal := variableJournalFrom(ctx); journal != nil {
+		journal.record(varScopeKey{VarScopeGlobal, wsID}, merged, newVars)
+	}
+	mergeVarUpdates(merged, newVars)
+	if err := fr.variableResolver.sealSecrets(ctx, varScopeKey{VarScopeGlobal, wsID}, merged); err != nil {
+		return err
 	}
 
 	// Serialize to JSON
@@ -793,22 +1365,31 @@ func (fr *FlowRunner) persistWorkspaceVariables(ctx context.Context, wsID int64,
 		ID:        wsID,
 		Variables: sql.NullString{String: string(varsJSON), Valid: true},
 	})
+	if err == nil {
+		if snap := variableSnapshotFrom(ctx); snap != nil {
+			snap.apply(varScopeKey{VarScopeGlobal, wsID}, newVars)
+		}
+	}
 	return err
 }
 
-// persistCollectionVariables saves collection variables to the database
-func (fr *FlowRunner) persistCollectionVariables(ctx context.Context, collectionID int64, existingVars, newVars map[string]string) error {
-	// Merge existing and new vars (empty string means delete)
-	merged := make(map[string]string)
-	for k, v := range existingVars {
-		merged[k] = v
+// persistCollectionVariables merges script writes into the latest stored collection variables
+func (fr *FlowRunner) persistCollectionVariables(ctx context.Context, collectionID int64, newVars map[string]string) error {
+	if dry := dryVariablesFrom(ctx); dry != nil {
+		dry.record(varScopeKey{VarScopeCollection, collectionID}, newVars)
+		return nil
 	}
-	for k, v := range newVars {
-		if v == "" {
-			delete(merged, k) // Delete if empty
-		} else {
-			merged[k] = v
-		}
+
+	variableWriteMu.Lock()
+	defer variableWriteMu.Unlock()
+
+	merged := fr.variableResolver.loadCollectionVars(ctx, collectionID)
+	if journal := variableJournalFrom(ctx); journal != nil {
+		journal.record(varScopeKey{VarScopeCollection, collectionID}, merged, newVars)
+	}
+	mergeVarUpdates(merged, newVars)
+	if err := fr.variableResolver.sealSecrets(ctx, varScopeKey{VarScopeCollection, collectionID}, merged); err != nil {
+		return err
 	}
 
 	// Serialize to JSON
@@ -822,5 +1403,59 @@ func (fr *FlowRunner) persistCollectionVariables(ctx context.Context, collection
 		ID:        collectionID,
 		Variables: sql.NullString{String: string(varsJSON), Valid: true},
 	})
+	if err == nil {
+		if snap := variableSnapshotFrom(ctx); snap != nil {
+			snap.apply(varScopeKey{VarScopeCollection, collectionID}, newVars)
+		}
+	}
 	return err
 }
+
+// restoreVariables rolls back the persisted writes recorded in the run's
+// journal. Only the keys the run wrote are reset, so other runs' keys survive.
+func (fr *FlowRunner) restoreVariables(ctx context.Context, journal *variableJournal) ([]VariableChange, error) {
+	variableWriteMu.Lock()
+	defer variableWriteMu.Unlock()
+
+	var restored []VariableChange
+	var firstErr error
+	for _, key := range journal.scopes() {
+		var vars map[string]string
+		switch key.scope {
+		case VarScopeEnvironment:
+			vars = fr.variableResolver.loadEnvironmentVars(ctx, key.id)
+		case VarScopeGlobal:
+			vars = fr.variableResolver.loadWorkspaceVars(ctx, key.id)
+		case VarScopeCollection:
+			vars = fr.variableResolver.loadCollectionVars(ctx, key.id)
+		}
+		before := cloneVars(vars)
+		journal.rollback(key, vars)
+		changes := diffVars(key.scope, before, vars)
+		if len(changes) == 0 {
+			continue
+		}
+
+		varsJSON, err := json.Marshal(vars)
+		if err != nil {
+			return restored, err
+		}
+		raw := sql.NullString{String: string(varsJSON), Valid: true}
+		switch key.scope {
+		case VarScopeEnvironment:
+			_, err = fr.queries.UpdateEnvironmentVariables(ctx, repository.UpdateEnvironmentVariablesParams{ID: key.id, Variables: raw})
+		case VarScopeGlobal:
+			_, err = fr.queries.UpdateWorkspaceVariables(ctx, repository.UpdateWorkspaceVariablesParams{ID: key.id, Variables: raw})
+		case VarScopeCollection:
+			_, err = fr.queries.UpdateCollectionVariables(ctx, repository.UpdateCollectionVariablesParams{ID: key.id, Variables: raw})
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		restored = append(restored, changes...)
+	}
+	return restored, firstErr
+}
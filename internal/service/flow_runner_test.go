@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"relay/internal/repository"
 	"relay/internal/testutil"
@@ -159,7 +163,7 @@ func TestEvaluateCondition_VarExists(t *testing.T) {
 	re := NewRequestExecutor(q, vr, nil)
 	fr := NewFlowRunner(q, re, vr)
 
-	met, err := fr.evaluateCondition("{{token}}", map[string]string{"token": "abc"})
+	met, err := fr.evaluateCondition(context.Background(), "{{token}}", map[string]string{"token": "abc"})
 	if err != nil {
 		t.Fatalf("evaluate: %v", err)
 	}
@@ -206,3 +210,350 @@ func TestFlowRunner_StepWithNoURL(t *testing.T) {
 	}
 	_ = fmt.Sprintf("%v", result) // ensure result is usable
 }
+
+func TestFlowRunner_RunFromStep(t *testing.T) {
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "login", Method: "GET", Url: ts.URL + "/login"},
+		{Name: "fetch", Method: "GET", Url: ts.URL + "/items/{{token}}"},
+		{Name: "cleanup", Method: "GET", Url: ts.URL + "/cleanup"},
+	})
+	steps, _ := q.ListFlowSteps(context.Background(), flowID)
+
+	result, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{
+		StartStepID: steps[1].ID,
+		InitialVars: map[string]string{"token": "abc"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("run flow: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("expected success, got error: %s", result.Error)
+	}
+	if len(calls) != 2 || calls[0] != "/items/abc" || calls[1] != "/cleanup" {
+		t.Errorf("calls: got %v, want [/items/abc /cleanup]", calls)
+	}
+}
+
+func TestFlowRunner_RunSingleStep(t *testing.T) {
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "a", Method: "GET", Url: ts.URL + "/a"},
+		{Name: "b", Method: "GET", Url: ts.URL + "/b"},
+		{Name: "c", Method: "GET", Url: ts.URL + "/c"},
+	})
+	steps, _ := q.ListFlowSteps(context.Background(), flowID)
+
+	result, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{
+		StartStepID: steps[1].ID,
+		EndStepID:   steps[1].ID,
+	}, nil)
+	if err != nil {
+		t.Fatalf("run flow: %v", err)
+	}
+	if len(result.Steps) != 1 || len(calls) != 1 || calls[0] != "/b" {
+		t.Errorf("expected only step b to run, got calls %v", calls)
+	}
+}
+
+func TestFlowRunner_RunFromStepGotoOutsideRange(t *testing.T) {
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "a", Method: "GET", Url: ts.URL + "/a"},
+		{Name: "b", Method: "GET", Url: ts.URL + "/b", PostScript: sql.NullString{String: `pm.execution.setNextRequest("a");`, Valid: true}},
+		{Name: "c", Method: "GET", Url: ts.URL + "/c"},
+	})
+	steps, _ := q.ListFlowSteps(context.Background(), flowID)
+
+	result, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{StartStepID: steps[1].ID}, nil)
+	if err != nil {
+		t.Fatalf("run flow: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "outside the selected step range") {
+		t.Errorf("expected out-of-range goto to fail the run, got success=%v error=%q", result.Success, result.Error)
+	}
+	if len(calls) != 1 || calls[0] != "/b" {
+		t.Errorf("calls: got %v, want [/b]", calls)
+	}
+}
+
+func TestFlowRunner_RunOptionsInvalidStep(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "a", Method: "GET", Url: "http://localhost"},
+	})
+
+	_, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{StartStepID: 9999}, nil)
+	if !errors.Is(err, ErrInvalidRunOptions) {
+		t.Errorf("expected ErrInvalidRunOptions, got %v", err)
+	}
+}
+
+func TestFlowRunner_BreakpointsAndStepping(t *testing.T) {
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "a", Method: "GET", Url: ts.URL + "/a"},
+		{Name: "b", Method: "GET", Url: ts.URL + "/b"},
+		{Name: "c", Method: "GET", Url: ts.URL + "/c"},
+	})
+	steps, _ := q.ListFlowSteps(context.Background(), flowID)
+
+	// Break at b, step once (pauses at c), then abort
+	var pauses []PauseEvent
+	commands := []DebugCommand{DebugStep, DebugAbort}
+	callbacks := &StreamCallbacks{
+		OnPause: func(e PauseEvent) DebugCommand {
+			pauses = append(pauses, e)
+			cmd := commands[0]
+			commands = commands[1:]
+			return cmd
+		},
+	}
+
+	result, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{
+		Breakpoints: []int64{steps[1].ID},
+		InitialVars: map[string]string{"token": "abc"},
+	}, callbacks)
+	if err != nil {
+		t.Fatalf("run flow: %v", err)
+	}
+
+	if len(pauses) != 2 {
+		t.Fatalf("pauses: got %d, want 2", len(pauses))
+	}
+	if pauses[0].StepName != "b" || pauses[0].Reason != "breakpoint" {
+		t.Errorf("first pause: got %s/%s, want b/breakpoint", pauses[0].StepName, pauses[0].Reason)
+	}
+	if pauses[0].RuntimeVars["token"] != "abc" {
+		t.Errorf("pause vars: got %v, want token=abc", pauses[0].RuntimeVars)
+	}
+	if pauses[1].StepName != "c" || pauses[1].Reason != "step" {
+		t.Errorf("second pause: got %s/%s, want c/step", pauses[1].StepName, pauses[1].Reason)
+	}
+	if result.Success || result.Error != "aborted" {
+		t.Errorf("expected aborted run, got success=%v error=%q", result.Success, result.Error)
+	}
+	if len(calls) != 2 || calls[0] != "/a" || calls[1] != "/b" {
+		t.Errorf("calls: got %v, want [/a /b]", calls)
+	}
+}
+
+func TestFlowRunner_ActiveRuns(t *testing.T) {
+	arrived := make(chan struct{})
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-release
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+	ctx := context.Background()
+
+	flow, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "slow", WorkspaceID: 1})
+	q.CreateFlowStep(ctx, repository.CreateFlowStepParams{FlowID: flow.ID, StepOrder: 1, Name: "wait", Method: "GET", Url: ts.URL})
+
+	done := make(chan *FlowResult)
+	go func() {
+		result, _ := fr.Run(ctx, flow.ID, nil)
+		done <- result
+	}()
+	<-arrived
+
+	runs := fr.ActiveRuns()
+	if len(runs) != 1 || runs[0].FlowID != flow.ID || runs[0].FlowName != "slow" || re.InFlight() != 1 {
+		t.Errorf("active runs = %+v, in flight = %d", runs, re.InFlight())
+	}
+	close(release)
+	result := <-done
+	if len(runs) == 1 && runs[0].TraceID != result.TraceID {
+		t.Errorf("active run trace %s, result trace %s", runs[0].TraceID, result.TraceID)
+	}
+	if len(fr.ActiveRuns()) != 0 || re.InFlight() != 0 {
+		t.Errorf("run still tracked after completion: %+v", fr.ActiveRuns())
+	}
+}
+
+func TestFlowRunner_Profile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"token":"abc"}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "login", Method: "GET", Url: ts.URL, ExtractVars: sql.NullString{String: `{"token":"$.token"}`, Valid: true}},
+		{Name: "wait", Method: "GET", Url: ts.URL, DelayMs: sql.NullInt64{Int64: 50, Valid: true}},
+	})
+
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatalf("run flow: %v", err)
+	}
+	if len(result.Steps) != 2 || result.Profile == nil {
+		t.Fatalf("steps = %d, profile = %+v", len(result.Steps), result.Profile)
+	}
+	first, second := result.Steps[0].Profile, result.Steps[1].Profile
+	if first == nil || second == nil {
+		t.Fatal("missing step profile")
+	}
+	if first.HTTPMs < 20 || first.DelayMs != 0 {
+		t.Errorf("first step profile = %+v", first)
+	}
+	if second.DelayMs < 50 || second.TotalMs < second.DelayMs+second.HTTPMs {
+		t.Errorf("second step profile = %+v", second)
+	}
+
+	p := result.Profile
+	if p.Steps != 2 || p.TotalMs != first.TotalMs+second.TotalMs || p.DelayMs != second.DelayMs {
+		t.Errorf("run profile = %+v", p)
+	}
+	if p.SlowestStepID != result.Steps[1].StepID || p.SlowestMs != second.TotalMs {
+		t.Errorf("slowest = %d (%dms), want step %d", p.SlowestStepID, p.SlowestMs, result.Steps[1].StepID)
+	}
+}
+
+func TestFlowRunner_HistoryRunID(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "step1", Method: "GET", Url: ts.URL + "/first"},
+		{Name: "step2", Method: "GET", Url: ts.URL + "/second"},
+	})
+
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil || !result.Success {
+		t.Fatalf("run flow: %v, %+v", err, result)
+	}
+	if result.RunID == "" {
+		t.Fatal("result has no run ID")
+	}
+	if _, err := re.ExecuteAdhoc(context.Background(), "GET", ts.URL+"/adhoc", "", "", nil, nil); err != nil {
+		t.Fatalf("adhoc: %v", err)
+	}
+
+	entries, err := q.ListHistoryByExecutionGroup(context.Background(), repository.ListHistoryByExecutionGroupParams{
+		WorkspaceID:      1,
+		ExecutionGroupID: sql.NullString{String: result.RunID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("list run history: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Url != ts.URL+"/first" || entries[1].Url != ts.URL+"/second" {
+		t.Fatalf("run history = %+v", entries)
+	}
+	for _, e := range entries {
+		if e.FlowID.Int64 != flowID {
+			t.Errorf("entry %d flowId = %v, want %d", e.ID, e.FlowID, flowID)
+		}
+	}
+}
+
+func TestFlowRunner_StepCondition(t *testing.T) {
+	var hits int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+
+	// A bare {{var}} is falsy for "0", "false" and "null", not only when empty
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "guarded", Method: "GET", Url: ts.URL, Condition: sql.NullString{String: "{{flag}}", Valid: true}},
+	})
+	for value, met := range map[string]bool{"yes": true, "1": true, "0": false, "false": false, "null": false, "": false} {
+		result, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{InitialVars: map[string]string{"flag": value}}, nil)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if skipped := result.Steps[0].Skipped; skipped == met {
+			t.Errorf("flag=%q: skipped = %v, want %v", value, skipped, !met)
+		}
+	}
+
+	// A malformed condition fails the step and stops the flow instead of skipping it
+	hits = 0
+	flowID = createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "typo", Method: "GET", Url: ts.URL, Condition: sql.NullString{String: "{{flag}} ==", Valid: true}},
+		{Name: "after", Method: "GET", Url: ts.URL},
+	})
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if result.Success || len(result.Steps) != 1 || result.Steps[0].Skipped || hits != 0 {
+		t.Fatalf("result = %+v, hits = %d", result, hits)
+	}
+	if er := result.Steps[0].ExecuteResult; er == nil || !strings.Contains(er.Error, "invalid condition") || result.Error != er.Error {
+		t.Errorf("step error = %+v, flow error = %q", er, result.Error)
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// saveFlowRun stores the finished run's result for GET /api/flow-runs/{id}
+// so it can be reviewed after the response is gone; HistoryRetention drops
+// it with the run's history
+func (fr *FlowRunner) saveFlowRun(ctx context.Context, result *FlowResult, start time.Time) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("flow: failed to encode run result: %v", err)
+		return
+	}
+	steps := len(result.Steps)
+	if len(result.Nodes) > 0 {
+		steps = len(result.Nodes)
+	}
+	var success int64
+	if result.Success {
+		success = 1
+	}
+	if err := fr.queries.CreateFlowRun(ctx, repository.CreateFlowRunParams{
+		RunID:       result.RunID,
+		WorkspaceID: middleware.GetWorkspaceID(ctx),
+		FlowID:      result.FlowID,
+		FlowName:    result.FlowName,
+		Success:     success,
+		Error:       result.Error,
+		TotalTimeMs: result.TotalTimeMs,
+		StepCount:   int64(steps),
+		Result:      string(data),
+		StartedAt:   sql.NullTime{Time: start.UTC(), Valid: true},
+	}); err != nil {
+		log.Printf("flow: failed to save run result: %v", err)
+	}
+}
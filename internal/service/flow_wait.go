@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"relay/internal/repository"
+)
+
+const (
+	defaultWaitInterval = time.Second
+	minWaitInterval     = 100 * time.Millisecond
+	defaultWaitTimeout  = 30 * time.Second
+	maxWaitTimeout      = 10 * time.Minute
+
+	// waitStatusVar holds the status code of the latest poll while the wait
+	// condition is evaluated
+	waitStatusVar = "__status__"
+)
+
+// WaitUntil turns a step into a poll: its request is sent again every
+// IntervalMs until Condition holds or TimeoutMs passes. The condition sees
+// the runtime variables plus the poll response's extractVars and
+// {{__status__}}, e.g. "{{__status__}} == 200 && {{state}} == done".
+type WaitUntil struct {
+	Condition  string `json:"condition"`
+	IntervalMs int64  `json:"intervalMs"` // default 1000, at least 100
+	TimeoutMs  int64  `json:"timeoutMs"`  // default 30000, at most 10 minutes
+}
+
+// ParseWaitUntil decodes a step's wait config. An empty config returns nil.
+func ParseWaitUntil(raw string) (*WaitUntil, error) {
+	if raw == "" || raw == "{}" {
+		return nil, nil
+	}
+	var w WaitUntil
+	if err := json.Unmarshal([]byte(raw), &w); err != nil {
+		return nil, fmt.Errorf("invalid waitUntil: %w", err)
+	}
+	if w.Condition == "" {
+		return nil, errors.New("waitUntil requires a condition")
+	}
+	if w.IntervalMs < 0 || w.TimeoutMs < 0 {
+		return nil, errors.New("waitUntil intervalMs and timeoutMs must not be negative")
+	}
+	if time.Duration(w.TimeoutMs)*time.Millisecond > maxWaitTimeout {
+		return nil, fmt.Errorf("waitUntil timeoutMs must be at most %d", maxWaitTimeout.Milliseconds())
+	}
+	return &w, nil
+}
+
+func (w *WaitUntil) interval() time.Duration {
+	d := time.Duration(w.IntervalMs) * time.Millisecond
+	if d == 0 {
+		return defaultWaitInterval
+	}
+	if d < minWaitInterval {
+		return minWaitInterval
+	}
+	return d
+}
+
+func (w *WaitUntil) timeout() time.Duration {
+	if w.TimeoutMs == 0 {
+		return defaultWaitTimeout
+	}
+	return time.Duration(w.TimeoutMs) * time.Millisecond
+}
+
+// WaitResult reports how a waiting step's polling went
+type WaitResult struct {
+	Attempts int   `json:"attempts"`
+	Met      bool  `json:"met"`
+	WaitedMs int64 `json:"waitedMs"`
+}
+
+// WaitEvent is sent after every poll of a waiting step
+type WaitEvent struct {
+	StepID     int64  `json:"stepId"`
+	StepName   string `json:"stepName"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"statusCode"`
+	Met        bool   `json:"met"`
+	ElapsedMs  int64  `json:"elapsedMs"`
+}
+
+// waitFor sends req until the wait condition holds or the wait times out and
+// returns the last response. Poll variables are evaluated on a copy, so only
+// the final response's extractVars reach the run, as for any other step.
+func (fr *FlowRunner) waitFor(ctx context.Context, step repository.FlowStep, req repository.Request, vars map[string]string, wait *WaitUntil, prof *stepProfiler, onPoll func(WaitEvent)) (*ExecuteResult, *WaitResult, error) {
+	start := time.Now()
+	deadline := start.Add(wait.timeout())
+	wr := &WaitResult{}
+	for {
+		execResult, err := fr.requestExecutor.ExecuteRequest(ctx, req, vars)
+		if err != nil {
+			return nil, wr, err
+		}
+		if execResult.SafeModeBlocked {
+			return execResult, nil, nil
+		}
+		wr.Attempts++
+		prof.request(execResult)
+
+		probe := cloneVars(vars)
+		probe[waitStatusVar] = strconv.Itoa(execResult.StatusCode)
+		if step.ExtractVars.Valid && step.ExtractVars.String != "" && step.ExtractVars.String != "{}" {
+			extractStart := time.Now()
+			extracted, _ := fr.extractVariables(execResult.Body, step.ExtractVars.String)
+			prof.since(&prof.p.ExtractionMs, extractStart)
+			for k, v := range extracted {
+				probe[k] = v
+			}
+		}
+		met, err := fr.evaluateCondition(ctx, wait.Condition, probe)
+		if err != nil {
+			return nil, wr, fmt.Errorf("wait condition: %w", err)
+		}
+		wr.Met = met
+		wr.WaitedMs = time.Since(start).Milliseconds()
+		if onPoll != nil {
+			onPoll(WaitEvent{
+				StepID:     step.ID,
+				StepName:   step.Name,
+				Attempt:    wr.Attempts,
+				StatusCode: execResult.StatusCode,
+				Met:        met,
+				ElapsedMs:  wr.WaitedMs,
+			})
+		}
+		if met || time.Now().Add(wait.interval()).After(deadline) {
+			return execResult, wr, nil
+		}
+
+		sleepStart := time.Now()
+		select {
+		case <-ctx.Done():
+			return nil, wr, ctx.Err()
+		case <-time.After(wait.interval()):
+		}
+		prof.since(&prof.p.DelayMs, sleepStart)
+	}
+}
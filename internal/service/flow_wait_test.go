@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestParseWaitUntil(t *testing.T) {
+	tests := []struct {
+		raw     string
+		wantNil bool
+		wantErr bool
+	}{
+		{raw: "", wantNil: true},
+		{raw: "{}", wantNil: true},
+		{raw: `{"condition":"{{state}} == done","intervalMs":500,"timeoutMs":5000}`},
+		{raw: `{"intervalMs":500}`, wantErr: true},
+		{raw: `{"condition":"x","timeoutMs":-1}`, wantErr: true},
+		{raw: `{"condition":"x","timeoutMs":3600000}`, wantErr: true},
+		{raw: `not json`, wantErr: true},
+	}
+	for _, tt := range tests {
+		w, err := ParseWaitUntil(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (w == nil) != tt.wantNil {
+			t.Errorf("%q: got %+v, wantNil %v", tt.raw, w, tt.wantNil)
+		}
+	}
+
+	w := &WaitUntil{Condition: "x", IntervalMs: 10}
+	if w.interval() != minWaitInterval || w.timeout() != defaultWaitTimeout {
+		t.Errorf("interval %v, timeout %v", w.interval(), w.timeout())
+	}
+}
+
+func TestFlowRunner_WaitUntil(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Write([]byte(`{"state":"pending"}`))
+			return
+		}
+		w.Write([]byte(`{"state":"done"}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{
+			Name:        "poll",
+			Method:      "GET",
+			Url:         ts.URL,
+			ExtractVars: sql.NullString{String: `{"state":"$.state"}`, Valid: true},
+			WaitUntil:   sql.NullString{String: `{"condition":"{{__status__}} == 200 && {{state}} == done","intervalMs":100}`, Valid: true},
+		},
+	})
+
+	var events []WaitEvent
+	result, err := fr.RunWithOptions(context.Background(), flowID, nil, &StreamCallbacks{
+		OnStepWait: func(e WaitEvent) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("run flow: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	step := result.Steps[0]
+	if step.Wait == nil || !step.Wait.Met || step.Wait.Attempts != 3 {
+		t.Fatalf("wait = %+v", step.Wait)
+	}
+	if step.ExtractedVars["state"] != "done" {
+		t.Errorf("extracted = %v", step.ExtractedVars)
+	}
+	if len(events) != 3 || events[0].Met || !events[2].Met || events[2].Attempt != 3 {
+		t.Errorf("events = %+v", events)
+	}
+	if step.Profile.DelayMs < 200 {
+		t.Errorf("poll intervals not counted as delay: %+v", step.Profile)
+	}
+}
+
+func TestFlowRunner_WaitUntilTimeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{
+			Name:      "poll",
+			Method:    "GET",
+			Url:       ts.URL,
+			WaitUntil: sql.NullString{String: `{"condition":"{{__status__}} == 200","intervalMs":100,"timeoutMs":250}`, Valid: true},
+		},
+		{Name: "after", Method: "GET", Url: ts.URL},
+	})
+
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatalf("run flow: %v", err)
+	}
+	if result.Success || len(result.Steps) != 1 {
+		t.Fatalf("success = %v, steps = %d", result.Success, len(result.Steps))
+	}
+	wait := result.Steps[0].Wait
+	if wait == nil || wait.Met || wait.Attempts < 2 || wait.Attempts > 3 {
+		t.Errorf("wait = %+v", wait)
+	}
+	if !strings.Contains(result.Error, "wait condition not met") {
+		t.Errorf("error = %q", result.Error)
+	}
+}
@@ -0,0 +1,841 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A small GraphQL query engine: it parses query documents (operations,
+// variables, aliases, arguments, fragments, inline fragments, @skip and
+// @include) and executes them against a schema of resolver functions.
+// Mutations and subscriptions are not supported; introspection is limited
+// to __typename, with the schema published as SDL instead.
+
+const (
+	maxGraphQLDocument = 100 * 1024
+	maxGraphQLDepth    = 12
+)
+
+// GraphQLRequest is a GraphQL-over-HTTP request
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// GraphQLError is an entry of a response's errors
+type GraphQLError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// GraphQLResponse is the result of a GraphQL request. Data is absent when
+// the request could not be executed at all.
+type GraphQLResponse struct {
+	Data   any            `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLSchema is a set of object types with resolvers, rooted at Query
+type GraphQLSchema struct {
+	types map[string]*gqlObject
+	order []string // for SDL
+}
+
+type gqlObject struct {
+	name   string
+	desc   string
+	fields []*gqlFieldDef
+}
+
+type gqlFieldDef struct {
+	name    string
+	typ     string // SDL type, e.g. "[Request!]!"
+	desc    string
+	args    []gqlArgDef
+	resolve func(ctx context.Context, source any, args map[string]any) (any, error)
+}
+
+type gqlArgDef struct {
+	name string
+	typ  string
+	def  string // default value (SDL literal), "" for none
+}
+
+func (o *gqlObject) field(name string) *gqlFieldDef {
+	for _, f := range o.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// namedType strips list and non-null wrappers: "[Request!]!" -> "Request"
+func namedType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+// SDL renders the schema in GraphQL schema definition language
+func (s *GraphQLSchema) SDL() string {
+	var b strings.Builder
+	for i, name := range s.order {
+		t := s.types[name]
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if t.desc != "" {
+			fmt.Fprintf(&b, "\"\"\"%s\"\"\"\n", t.desc)
+		}
+		fmt.Fprintf(&b, "type %s {\n", t.name)
+		for _, f := range t.fields {
+			if f.desc != "" {
+				fmt.Fprintf(&b, "  \"%s\"\n", f.desc)
+			}
+			b.WriteString("  " + f.name)
+			if len(f.args) > 0 {
+				args := make([]string, len(f.args))
+				for j, a := range f.args {
+					args[j] = a.name + ": " + a.typ
+					if a.def != "" {
+						args[j] += " = " + a.def
+					}
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + f.typ + "\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// Execute runs a query document against the schema
+func (s *GraphQLSchema) Execute(ctx context.Context, req GraphQLRequest) *GraphQLResponse {
+	if len(req.Query) > maxGraphQLDocument {
+		return gqlRequestError("query document is too large")
+	}
+	doc, err := parseGraphQL(req.Query)
+	if err != nil {
+		return gqlRequestError(err.Error())
+	}
+
+	var op *gqlOperation
+	for _, o := range doc.operations {
+		if req.OperationName == "" || o.name == req.OperationName {
+			if op != nil {
+				return gqlRequestError("operationName is required when the document has several operations")
+			}
+			op = o
+		}
+	}
+	if op == nil {
+		if req.OperationName != "" {
+			return gqlRequestError(fmt.Sprintf("unknown operation %q", req.OperationName))
+		}
+		return gqlRequestError("the document has no operation")
+	}
+	if op.kind != "query" {
+		return gqlRequestError(op.kind + " operations are not supported; the API is read-only")
+	}
+
+	vars := map[string]any{}
+	for _, def := range op.variables {
+		if v, ok := req.Variables[def.name]; ok {
+			vars[def.name] = normalizeGraphQLValue(v)
+		} else if def.def != nil {
+			vars[def.name], _ = def.def.eval(nil)
+		} else if strings.HasSuffix(def.typ, "!") {
+			return gqlRequestError(fmt.Sprintf("variable $%s of type %s is required", def.name, def.typ))
+		}
+	}
+
+	e := &gqlExecutor{schema: s, doc: doc, vars: vars}
+	data := e.selectionSet(ctx, s.types["Query"], nil, op.selections, nil, 1)
+	return &GraphQLResponse{Data: data, Errors: e.errors}
+}
+
+func gqlRequestError(msg string) *GraphQLResponse {
+	return &GraphQLResponse{Errors: []GraphQLError{{Message: msg}}}
+}
+
+// normalizeGraphQLValue turns whole JSON numbers into int64 so variables and
+// literals look alike to resolvers
+func normalizeGraphQLValue(v any) any {
+	switch v := v.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	case []any:
+		for i := range v {
+			v[i] = normalizeGraphQLValue(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = normalizeGraphQLValue(v[k])
+		}
+	}
+	return v
+}
+
+// --- execution ---
+
+type gqlExecutor struct {
+	schema *GraphQLSchema
+	doc    *gqlDocument
+	vars   map[string]any
+	errors []GraphQLError
+}
+
+func (e *gqlExecutor) fail(path []any, format string, args ...any) {
+	e.errors = append(e.errors, GraphQLError{Message: fmt.Sprintf(format, args...), Path: append([]any(nil), path...)})
+}
+
+// gqlResult is a response object that keeps its fields in selection order
+type gqlResult struct {
+	keys   []string
+	values map[string]any
+}
+
+func (r *gqlResult) set(key string, v any) {
+	if _, ok := r.values[key]; !ok {
+		r.keys = append(r.keys, key)
+	}
+	r.values[key] = v
+}
+
+func (r *gqlResult) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range r.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		val, err := json.Marshal(r.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(val)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func (e *gqlExecutor) selectionSet(ctx context.Context, typ *gqlObject, source any, sels []gqlSelection, path []any, depth int) *gqlResult {
+	result := &gqlResult{values: map[string]any{}}
+	if depth > maxGraphQLDepth {
+		e.fail(path, "query is nested deeper than %d levels", maxGraphQLDepth)
+		return result
+	}
+	for _, f := range e.collectFields(typ, sels, map[string]bool{}) {
+		key := f.responseKey()
+		fieldPath := append(append([]any(nil), path...), key)
+		if f.name == "__typename" {
+			result.set(key, typ.name)
+			continue
+		}
+		def := typ.field(f.name)
+		if def == nil {
+			e.fail(fieldPath, "Cannot query field %q on type %q", f.name, typ.name)
+			result.set(key, nil)
+			continue
+		}
+		_, isObject := e.schema.types[namedType(def.typ)]
+		if isObject && len(f.selections) == 0 {
+			e.fail(fieldPath, "field %q of type %s needs a selection of sub-fields", f.name, def.typ)
+			result.set(key, nil)
+			continue
+		}
+		if !isObject && len(f.selections) > 0 {
+			e.fail(fieldPath, "field %q of type %s has no sub-fields", f.name, def.typ)
+			result.set(key, nil)
+			continue
+		}
+		args, err := e.arguments(def, f)
+		if err != nil {
+			e.fail(fieldPath, "%v", err)
+			result.set(key, nil)
+			continue
+		}
+		value, err := def.resolve(ctx, source, args)
+		if err != nil {
+			e.fail(fieldPath, "%v", err)
+			result.set(key, nil)
+			continue
+		}
+		result.set(key, e.complete(ctx, def.typ, value, f, fieldPath, depth))
+	}
+	return result
+}
+
+// collectFields flattens fragments into the fields selected on typ,
+// dropping @skip/@include-excluded selections
+func (e *gqlExecutor) collectFields(typ *gqlObject, sels []gqlSelection, visited map[string]bool) []*gqlField {
+	var fields []*gqlField
+	byKey := map[string]*gqlField{}
+	add := func(f *gqlField) {
+		if prev, ok := byKey[f.responseKey()]; ok {
+			// Same response key: merge the sub-selections
+			merged := *prev
+			merged.selections = append(append([]gqlSelection(nil), prev.selections...), f.selections...)
+			*prev = merged
+			return
+		}
+		copied := *f
+		byKey[f.responseKey()] = &copied
+		fields = append(fields, &copied)
+	}
+	for _, sel := range sels {
+		if !e.included(sel.directives) {
+			continue
+		}
+		switch {
+		case sel.field != nil:
+			add(sel.field)
+		case sel.spread != "":
+			frag, ok := e.doc.fragments[sel.spread]
+			if !ok || visited[sel.spread] || frag.on != typ.name {
+				continue
+			}
+			visited[sel.spread] = true
+			for _, f := range e.collectFields(typ, frag.selections, visited) {
+				add(f)
+			}
+			delete(visited, sel.spread)
+		default:
+			if sel.on != "" && sel.on != typ.name {
+				continue
+			}
+			for _, f := range e.collectFields(typ, sel.inline, visited) {
+				add(f)
+			}
+		}
+	}
+	return fields
+}
+
+func (e *gqlExecutor) included(directives []gqlDirective) bool {
+	for _, d := range directives {
+		v, _ := d.args["if"].eval(e.vars)
+		on, _ := v.(bool)
+		if (d.name == "skip" && on) || (d.name == "include" && !on) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *gqlExecutor) arguments(def *gqlFieldDef, f *gqlField) (map[string]any, error) {
+	args := map[string]any{}
+	for name, val := range f.args {
+		known := false
+		for _, a := range def.args {
+			known = known || a.name == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown argument %q on field %q", name, def.name)
+		}
+		v, err := val.eval(e.vars)
+		if err != nil {
+			return nil, err
+		}
+		args[name] = v
+	}
+	for _, a := range def.args {
+		if _, ok := args[a.name]; ok {
+			continue
+		}
+		if a.def != "" {
+			v, err := parseGraphQLValue(a.def)
+			if err != nil {
+				return nil, err
+			}
+			args[a.name], _ = v.eval(nil)
+		} else if strings.HasSuffix(a.typ, "!") {
+			return nil, fmt.Errorf("argument %q of type %s is required", a.name, a.typ)
+		}
+	}
+	return args, nil
+}
+
+// complete shapes a resolved value by its field type: lists element by
+// element, objects through their selection set, scalars as they are
+func (e *gqlExecutor) complete(ctx context.Context, typ string, value any, f *gqlField, path []any, depth int) any {
+	if value == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil
+	}
+	typ = strings.TrimSuffix(typ, "!")
+	if strings.HasPrefix(typ, "[") {
+		if rv.Kind() != reflect.Slice {
+			e.fail(path, "expected a list")
+			return nil
+		}
+		items := make([]any, rv.Len())
+		for i := range items {
+			items[i] = e.complete(ctx, typ[1:len(typ)-1], rv.Index(i).Interface(), f, append(path, i), depth)
+		}
+		return items
+	}
+	obj, ok := e.schema.types[typ]
+	if !ok {
+		return value
+	}
+	return e.selectionSet(ctx, obj, value, f.selections, path, depth+1)
+}
+
+// --- documents ---
+
+type gqlDocument struct {
+	operations []*gqlOperation
+	fragments  map[string]*gqlFragment
+}
+
+type gqlOperation struct {
+	kind       string // query, mutation, subscription
+	name       string
+	variables  []gqlVariableDef
+	selections []gqlSelection
+}
+
+type gqlVariableDef struct {
+	name string
+	typ  string
+	def  *gqlValue
+}
+
+type gqlFragment struct {
+	on         string
+	selections []gqlSelection
+}
+
+// gqlSelection is a field, a fragment spread or an inline fragment
+type gqlSelection struct {
+	field      *gqlField
+	spread     string
+	on         string // inline fragment type condition
+	inline     []gqlSelection
+	directives []gqlDirective
+}
+
+type gqlField struct {
+	alias      string
+	name       string
+	args       map[string]*gqlValue
+	selections []gqlSelection
+}
+
+func (f *gqlField) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type gqlDirective struct {
+	name string
+	args map[string]*gqlValue
+}
+
+// gqlValue is an argument literal: a scalar, an enum, a variable, a list or
+// an object
+type gqlValue struct {
+	variable string
+	scalar   any
+	list     []*gqlValue
+	object   map[string]*gqlValue
+	kind     byte // 's' scalar, 'v' variable, 'l' list, 'o' object
+}
+
+func (v *gqlValue) eval(vars map[string]any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch v.kind {
+	case 'v':
+		val, ok := vars[v.variable]
+		if !ok {
+			return nil, nil
+		}
+		return val, nil
+	case 'l':
+		items := make([]any, len(v.list))
+		for i, item := range v.list {
+			val, err := item.eval(vars)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = val
+		}
+		return items, nil
+	case 'o':
+		obj := map[string]any{}
+		for k, item := range v.object {
+			val, err := item.eval(vars)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = val
+		}
+		return obj, nil
+	}
+	return v.scalar, nil
+}
+
+// --- parsing ---
+
+type gqlParser struct {
+	src string
+	pos int
+	tok string // current token; "" at the end
+	str bool   // current token is a string literal (tok holds its value)
+}
+
+func parseGraphQL(src string) (*gqlDocument, error) {
+	p := &gqlParser{src: src}
+	doc := &gqlDocument{fragments: map[string]*gqlFragment{}}
+	err := p.run(func() {
+		p.next()
+		for p.tok != "" || p.str {
+			switch {
+			case p.tok == "{":
+				doc.operations = append(doc.operations, &gqlOperation{kind: "query", selections: p.selectionSet()})
+			case p.tok == "query" || p.tok == "mutation" || p.tok == "subscription":
+				doc.operations = append(doc.operations, p.operation())
+			case p.tok == "fragment":
+				p.next()
+				name := p.name()
+				if p.name() != "on" {
+					p.errorf("expected \"on\" after fragment %s", name)
+				}
+				frag := &gqlFragment{on: p.name()}
+				p.directives()
+				frag.selections = p.selectionSet()
+				doc.fragments[name] = frag
+			default:
+				p.errorf("unexpected %q", p.tok)
+			}
+		}
+	})
+	return doc, err
+}
+
+func parseGraphQLValue(src string) (*gqlValue, error) {
+	p := &gqlParser{src: src}
+	var v *gqlValue
+	err := p.run(func() {
+		p.next()
+		v = p.value()
+	})
+	return v, err
+}
+
+type gqlSyntaxError struct{ msg string }
+
+func (p *gqlParser) run(parse func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			se, ok := r.(gqlSyntaxError)
+			if !ok {
+				panic(r)
+			}
+			err = fmt.Errorf("syntax error: %s", se.msg)
+		}
+	}()
+	parse()
+	return nil
+}
+
+func (p *gqlParser) errorf(format string, args ...any) {
+	line := 1 + strings.Count(p.src[:min(p.pos, len(p.src))], "\n")
+	panic(gqlSyntaxError{fmt.Sprintf(format, args...) + fmt.Sprintf(" (line %d)", line)})
+}
+
+func (p *gqlParser) expect(tok string) {
+	if p.str || p.tok != tok {
+		p.errorf("expected %q, found %q", tok, p.tok)
+	}
+	p.next()
+}
+
+func (p *gqlParser) name() string {
+	if p.str || !isGraphQLName(p.tok) {
+		p.errorf("expected a name, found %q", p.tok)
+	}
+	name := p.tok
+	p.next()
+	return name
+}
+
+func isGraphQLName(tok string) bool {
+	if tok == "" || tok[0] >= '0' && tok[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(tok); i++ {
+		if !isGraphQLNameChar(tok[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isGraphQLNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// next reads the following token, skipping whitespace, commas and comments
+func (p *gqlParser) next() {
+	p.str = false
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else {
+			break
+		}
+	}
+	if p.pos >= len(p.src) {
+		p.tok = ""
+		return
+	}
+	start := p.pos
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+	case strings.ContainsRune("!$():=@[]{}|", rune(c)):
+		p.pos++
+	case c == '"':
+		p.tok = p.stringLiteral()
+		p.str = true
+		return
+	case c == '-' || c >= '0' && c <= '9':
+		p.pos++
+		for p.pos < len(p.src) && strings.ContainsRune("0123456789.eE+-", rune(p.src[p.pos])) {
+			p.pos++
+		}
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.pos < len(p.src) && isGraphQLNameChar(p.src[p.pos]) {
+			p.pos++
+		}
+	default:
+		p.errorf("unexpected character %q", c)
+	}
+	p.tok = p.src[start:p.pos]
+}
+
+func (p *gqlParser) stringLiteral() string {
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		end := strings.Index(p.src[p.pos+3:], `"""`)
+		if end < 0 {
+			p.errorf("unterminated block string")
+		}
+		s := p.src[p.pos+3 : p.pos+3+end]
+		p.pos += end + 6
+		return strings.TrimSpace(s)
+	}
+	end := p.pos + 1
+	for ; end < len(p.src) && p.src[end] != '"'; end++ {
+		if p.src[end] == '\\' {
+			end++
+		} else if p.src[end] == '\n' {
+			break
+		}
+	}
+	if end >= len(p.src) || p.src[end] != '"' {
+		p.errorf("unterminated string")
+	}
+	s, err := strconv.Unquote(p.src[p.pos : end+1])
+	if err != nil {
+		p.errorf("invalid string %s", p.src[p.pos:end+1])
+	}
+	p.pos = end + 1
+	return s
+}
+
+func (p *gqlParser) operation() *gqlOperation {
+	op := &gqlOperation{kind: p.tok}
+	p.next()
+	if p.tok != "(" && p.tok != "{" && p.tok != "@" {
+		op.name = p.name()
+	}
+	if p.tok == "(" {
+		p.next()
+		for p.tok != ")" {
+			p.expect("$")
+			def := gqlVariableDef{name: p.name()}
+			p.expect(":")
+			def.typ = p.typeRef()
+			if p.tok == "=" {
+				p.next()
+				def.def = p.value()
+			}
+			op.variables = append(op.variables, def)
+		}
+		p.next()
+	}
+	p.directives()
+	op.selections = p.selectionSet()
+	return op
+}
+
+func (p *gqlParser) typeRef() string {
+	var typ string
+	if p.tok == "[" {
+		p.next()
+		typ = "[" + p.typeRef() + "]"
+		p.expect("]")
+	} else {
+		typ = p.name()
+	}
+	if p.tok == "!" {
+		p.next()
+		typ += "!"
+	}
+	return typ
+}
+
+func (p *gqlParser) selectionSet() []gqlSelection {
+	p.expect("{")
+	var sels []gqlSelection
+	for p.tok != "}" {
+		if p.tok == "" {
+			p.errorf("unterminated selection set")
+		}
+		if p.tok == "..." {
+			p.next()
+			var sel gqlSelection
+			if p.tok == "on" {
+				p.next()
+				sel.on = p.name()
+			} else if p.tok != "{" && p.tok != "@" {
+				sel.spread = p.name()
+			}
+			sel.directives = p.directives()
+			if sel.spread == "" {
+				sel.inline = p.selectionSet()
+			}
+			sels = append(sels, sel)
+			continue
+		}
+		f := &gqlField{name: p.name()}
+		if p.tok == ":" {
+			p.next()
+			f.alias, f.name = f.name, p.name()
+		}
+		if p.tok == "(" {
+			f.args = p.arguments()
+		}
+		sel := gqlSelection{field: f, directives: p.directives()}
+		if p.tok == "{" {
+			f.selections = p.selectionSet()
+		}
+		sels = append(sels, sel)
+	}
+	p.next()
+	if len(sels) == 0 {
+		p.errorf("empty selection set")
+	}
+	return sels
+}
+
+func (p *gqlParser) arguments() map[string]*gqlValue {
+	p.expect("(")
+	args := map[string]*gqlValue{}
+	for p.tok != ")" {
+		name := p.name()
+		p.expect(":")
+		args[name] = p.value()
+	}
+	p.next()
+	return args
+}
+
+func (p *gqlParser) directives() []gqlDirective {
+	var ds []gqlDirective
+	for p.tok == "@" && !p.str {
+		p.next()
+		d := gqlDirective{name: p.name()}
+		if p.tok == "(" {
+			d.args = p.arguments()
+		}
+		if d.name != "skip" && d.name != "include" {
+			p.errorf("unknown directive @%s", d.name)
+		}
+		ds = append(ds, d)
+	}
+	return ds
+}
+
+func (p *gqlParser) value() *gqlValue {
+	if p.str {
+		v := &gqlValue{kind: 's', scalar: p.tok}
+		p.next()
+		return v
+	}
+	tok := p.tok
+	switch {
+	case tok == "$":
+		p.next()
+		return &gqlValue{kind: 'v', variable: p.name()}
+	case tok == "[":
+		p.next()
+		v := &gqlValue{kind: 'l'}
+		for p.tok != "]" {
+			if p.tok == "" {
+				p.errorf("unterminated list")
+			}
+			v.list = append(v.list, p.value())
+		}
+		p.next()
+		return v
+	case tok == "{":
+		p.next()
+		v := &gqlValue{kind: 'o', object: map[string]*gqlValue{}}
+		for p.tok != "}" {
+			name := p.name()
+			p.expect(":")
+			v.object[name] = p.value()
+		}
+		p.next()
+		return v
+	case tok == "true" || tok == "false":
+		p.next()
+		return &gqlValue{kind: 's', scalar: tok == "true"}
+	case tok == "null":
+		p.next()
+		return &gqlValue{kind: 's'}
+	case tok != "" && (tok[0] == '-' || tok[0] >= '0' && tok[0] <= '9'):
+		p.next()
+		if n, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			return &gqlValue{kind: 's', scalar: n}
+		}
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			p.errorf("invalid number %q", tok)
+		}
+		return &gqlValue{kind: 's', scalar: f}
+	case isGraphQLName(tok):
+		// Enum values resolve to their name
+		p.next()
+		return &gqlValue{kind: 's', scalar: tok}
+	}
+	p.errorf("unexpected %q", tok)
+	return nil
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// The GraphQL schema of the Relay API: read-only views of the workspace's
+// collections, requests, flows, environments and history over the
+// repository layer. Every lookup is scoped to the request's workspace;
+// entities of other workspaces resolve to null.
+
+const (
+	defaultGraphQLHistory = 20
+	maxGraphQLHistory     = 500
+)
+
+// NewGraphQLSchema builds the API schema
+func NewGraphQLSchema(queries *repository.Queries) *GraphQLSchema {
+	r := &gqlResolvers{queries: queries}
+	s := &GraphQLSchema{types: map[string]*gqlObject{}}
+	for _, t := range []*gqlObject{
+		r.queryType(),
+		r.workspaceType(),
+		r.collectionType(),
+		r.requestType(),
+		r.flowType(),
+		r.flowStepType(),
+		r.environmentType(),
+		r.historyType(),
+	} {
+		s.types[t.name] = t
+		s.order = append(s.order, t.name)
+	}
+	for _, t := range s.types {
+		for _, f := range t.fields {
+			if n := namedType(f.typ); s.types[n] == nil && !gqlScalars[n] {
+				panic(fmt.Sprintf("graphql: %s.%s has unknown type %s", t.name, f.name, n))
+			}
+		}
+	}
+	return s
+}
+
+var gqlScalars = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+
+type gqlResolvers struct {
+	queries *repository.Queries
+}
+
+// gqlProp defines a field read straight from the source object
+func gqlProp[T any](name, typ string, get func(T) any) *gqlFieldDef {
+	return &gqlFieldDef{name: name, typ: typ, resolve: func(_ context.Context, source any, _ map[string]any) (any, error) {
+		return get(source.(T)), nil
+	}}
+}
+
+func gqlNullInt(v sql.NullInt64) any {
+	if !v.Valid {
+		return nil
+	}
+	return v.Int64
+}
+
+func gqlNullTime(v sql.NullTime) any {
+	if !v.Valid {
+		return nil
+	}
+	return v.Time.UTC().Format(time.RFC3339)
+}
+
+// gqlIntArg reads an integer argument
+func gqlIntArg(args map[string]any, name string) (int64, bool) {
+	switch v := args[name].(type) {
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// gqlIncludeArchivedArg lets request and flow lists include archived entities
+var gqlIncludeArchivedArg = gqlArgDef{name: "includeArchived", typ: "Boolean", def: "false"}
+
+// gqlArchivedFilter drops archived items from a list query's result unless
+// includeArchived is set
+func gqlArchivedFilter[T any](args map[string]any, archived func(T) bool) func([]T, error) ([]T, error) {
+	return func(items []T, err error) ([]T, error) {
+		if err != nil || args["includeArchived"] == true {
+			return items, err
+		}
+		kept := items[:0]
+		for _, item := range items {
+			if !archived(item) {
+				kept = append(kept, item)
+			}
+		}
+		return kept, nil
+	}
+}
+
+func gqlLimitArg(args map[string]any) (int64, error) {
+	limit, ok := gqlIntArg(args, "limit")
+	if !ok {
+		return defaultGraphQLHistory, nil
+	}
+	if limit < 1 || limit > maxGraphQLHistory {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxGraphQLHistory)
+	}
+	return limit, nil
+}
+
+func (r *gqlResolvers) queryType() *gqlObject {
+	return &gqlObject{name: "Query", fields: []*gqlFieldDef{
+		{name: "workspace", typ: "Workspace", desc: "The workspace of the request (X-Workspace-ID)", resolve: func(ctx context.Context, _ any, _ map[string]any) (any, error) {
+			ws, err := r.queries.GetWorkspace(ctx, middleware.GetWorkspaceID(ctx))
+			if err != nil {
+				return nil, nil
+			}
+			return ws, nil
+		}},
+		{name: "collections", typ: "[Collection!]!", desc: "Top-level collections; nested ones are under children", resolve: func(ctx context.Context, _ any, _ map[string]any) (any, error) {
+			return r.queries.ListRootCollections(ctx, middleware.GetWorkspaceID(ctx))
+		}},
+		{name: "collection", typ: "Collection", args: []gqlArgDef{{name: "id", typ: "Int!"}}, resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			id, _ := gqlIntArg(args, "id")
+			return r.collection(ctx, id), nil
+		}},
+		{name: "requests", typ: "[Request!]!", desc: "Requests of the workspace, or of one collection", args: []gqlArgDef{{name: "collectionId", typ: "Int"}, gqlIncludeArchivedArg}, resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			if id, ok := gqlIntArg(args, "collectionId"); ok {
+				if r.collection(ctx, id) == nil {
+					return []repository.Request{}, nil
+				}
+				return gqlArchivedFilter(args, func(q repository.Request) bool { return q.ArchivedAt.Valid })(
+					r.queries.ListRequestsByCollection(ctx, sql.NullInt64{Int64: id, Valid: true}))
+			}
+			return gqlArchivedFilter(args, func(q repository.Request) bool { return q.ArchivedAt.Valid })(
+				r.queries.ListRequests(ctx, middleware.GetWorkspaceID(ctx)))
+		}},
+		{name: "request", typ: "Request", args: []gqlArgDef{{name: "id", typ: "Int!"}}, resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			id, _ := gqlIntArg(args, "id")
+			return r.request(ctx, id), nil
+		}},
+		{name: "flows", typ: "[Flow!]!", args: []gqlArgDef{gqlIncludeArchivedArg}, resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			return gqlArchivedFilter(args, func(f repository.Flow) bool { return f.ArchivedAt.Valid })(
+				r.queries.ListFlows(ctx, middleware.GetWorkspaceID(ctx)))
+		}},
+		{name: "flow", typ: "Flow", args: []gqlArgDef{{name: "id", typ: "Int!"}}, resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			id, _ := gqlIntArg(args, "id")
+			flow, err := r.queries.GetFlow(ctx, id)
+			if err != nil || flow.WorkspaceID != middleware.GetWorkspaceID(ctx) {
+				return nil, nil
+			}
+			return flow, nil
+		}},
+		{name: "environments", typ: "[Environment!]!", resolve: func(ctx context.Context, _ any, _ map[string]any) (any, error) {
+			return r.queries.ListEnvironments(ctx, middleware.GetWorkspaceID(ctx))
+		}},
+		{name: "history", typ: "[HistoryEntry!]!", desc: "Latest executions first", args: []gqlArgDef{{name: "limit", typ: "Int", def: fmt.Sprint(defaultGraphQLHistory)}}, resolve: func(ctx context.Context, _ any, args map[string]any) (any, error) {
+			limit, err := gqlLimitArg(args)
+			if err != nil {
+				return nil, err
+			}
+			return r.queries.ListHistory(ctx, repository.ListHistoryParams{WorkspaceID: middleware.GetWorkspaceID(ctx), Limit: limit})
+		}},
+	}}
+}
+
+// collection returns the workspace's collection, or nil
+func (r *gqlResolvers) collection(ctx context.Context, id int64) *repository.Collection {
+	c, err := r.queries.GetCollection(ctx, id)
+	if err != nil || c.WorkspaceID != middleware.GetWorkspaceID(ctx) {
+		return nil
+	}
+	return &c
+}
+
+// request returns the workspace's request, or nil
+func (r *gqlResolvers) request(ctx context.Context, id int64) *repository.Request {
+	req, err := r.queries.GetRequest(ctx, id)
+	if err != nil || req.WorkspaceID != middleware.GetWorkspaceID(ctx) {
+		return nil
+	}
+	return &req
+}
+
+func (r *gqlResolvers) workspaceType() *gqlObject {
+	type W = repository.Workspace
+	return &gqlObject{name: "Workspace", fields: []*gqlFieldDef{
+		gqlProp("id", "Int!", func(w W) any { return w.ID }),
+		gqlProp("name", "String!", func(w W) any { return w.Name }),
+		gqlProp("createdAt", "String", func(w W) any { return gqlNullTime(w.CreatedAt) }),
+		gqlProp("updatedAt", "String", func(w W) any { return gqlNullTime(w.UpdatedAt) }),
+	}}
+}
+
+func (r *gqlResolvers) collectionType() *gqlObject {
+	type C = repository.Collection
+	// Resolvers may get a collection by value (lists) or by pointer (lookups)
+	coll := func(source any) C {
+		if p, ok := source.(*C); ok {
+			return *p
+		}
+		return source.(C)
+	}
+	prop := func(name, typ string, get func(C) any) *gqlFieldDef {
+		return gqlProp(name, typ, func(source any) any { return get(coll(source)) })
+	}
+	return &gqlObject{name: "Collection", fields: []*gqlFieldDef{
+		prop("id", "Int!", func(c C) any { return c.ID }),
+		prop("name", "String!", func(c C) any { return c.Name }),
+		prop("parentId", "Int", func(c C) any { return gqlNullInt(c.ParentID) }),
+		prop("sortOrder", "Int!", func(c C) any { return c.SortOrder }),
+		prop("createdAt", "String", func(c C) any { return gqlNullTime(c.CreatedAt) }),
+		prop("updatedAt", "String", func(c C) any { return gqlNullTime(c.UpdatedAt) }),
+		{name: "parent", typ: "Collection", resolve: func(ctx context.Context, source any, _ map[string]any) (any, error) {
+			c := coll(source)
+			if !c.ParentID.Valid {
+				return nil, nil
+			}
+			return r.collection(ctx, c.ParentID.Int64), nil
+		}},
+		{name: "children", typ: "[Collection!]!", resolve: func(ctx context.Context, source any, _ map[string]any) (any, error) {
+			return r.queries.ListChildCollections(ctx, sql.NullInt64{Int64: coll(source).ID, Valid: true})
+		}},
+		{name: "requests", typ: "[Request!]!", args: []gqlArgDef{gqlIncludeArchivedArg}, resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			return gqlArchivedFilter(args, func(q repository.Request) bool { return q.ArchivedAt.Valid })(
+				r.queries.ListRequestsByCollection(ctx, sql.NullInt64{Int64: coll(source).ID, Valid: true}))
+		}},
+	}}
+}
+
+func (r *gqlResolvers) requestType() *gqlObject {
+	type R = repository.Request
+	req := func(source any) R {
+		if p, ok := source.(*R); ok {
+			return *p
+		}
+		return source.(R)
+	}
+	prop := func(name, typ string, get func(R) any) *gqlFieldDef {
+		return gqlProp(name, typ, func(source any) any { return get(req(source)) })
+	}
+	history := func(ctx context.Context, id, limit int64) ([]repository.RequestHistory, error) {
+		return r.queries.ListHistoryByRequest(ctx, repository.ListHistoryByRequestParams{
+			RequestID: sql.NullInt64{Int64: id, Valid: true},
+			Limit:     limit,
+		})
+	}
+	return &gqlObject{name: "Request", fields: []*gqlFieldDef{
+		prop("id", "Int!", func(q R) any { return q.ID }),
+		prop("collectionId", "Int", func(q R) any { return gqlNullInt(q.CollectionID) }),
+		prop("name", "String!", func(q R) any { return q.Name }),
+		prop("method", "String!", func(q R) any { return q.Method }),
+		prop("url", "String!", func(q R) any { return q.Url }),
+		prop("headers", "String", func(q R) any { return q.Headers.String }),
+		prop("body", "String", func(q R) any { return q.Body.String }),
+		prop("bodyType", "String", func(q R) any { return q.BodyType.String }),
+		prop("sortOrder", "Int!", func(q R) any { return q.SortOrder }),
+		prop("version", "Int!", func(q R) any { return q.Version }),
+		prop("executionCount", "Int!", func(q R) any { return q.ExecutionCount }),
+		prop("lastExecutedAt", "String", func(q R) any { return gqlNullTime(q.LastExecutedAt) }),
+		prop("archivedAt", "String", func(q R) any { return gqlNullTime(q.ArchivedAt) }),
+		prop("createdAt", "String", func(q R) any { return gqlNullTime(q.CreatedAt) }),
+		prop("updatedAt", "String", func(q R) any { return gqlNullTime(q.UpdatedAt) }),
+		{name: "collection", typ: "Collection", resolve: func(ctx context.Context, source any, _ map[string]any) (any, error) {
+			q := req(source)
+			if !q.CollectionID.Valid {
+				return nil, nil
+			}
+			return r.collection(ctx, q.CollectionID.Int64), nil
+		}},
+		{name: "lastRun", typ: "HistoryEntry", desc: "The latest recorded execution", resolve: func(ctx context.Context, source any, _ map[string]any) (any, error) {
+			entries, err := history(ctx, req(source).ID, 1)
+			if err != nil || len(entries) == 0 {
+				return nil, err
+			}
+			return entries[0], nil
+		}},
+		{name: "history", typ: "[HistoryEntry!]!", args: []gqlArgDef{{name: "limit", typ: "Int", def: fmt.Sprint(defaultGraphQLHistory)}}, resolve: func(ctx context.Context, source any, args map[string]any) (any, error) {
+			limit, err := gqlLimitArg(args)
+			if err != nil {
+				return nil, err
+			}
+			return history(ctx, req(source).ID, limit)
+		}},
+	}}
+}
+
+func (r *gqlResolvers) flowType() *gqlObject {
+	type F = repository.Flow
+	return &gqlObject{name: "Flow", fields: []*gqlFieldDef{
+		gqlProp("id", "Int!", func(f F) any { return f.ID }),
+		gqlProp("name", "String!", func(f F) any { return f.Name }),
+		gqlProp("description", "String", func(f F) any { return f.Description.String }),
+		gqlProp("sortOrder", "Int!", func(f F) any { return f.SortOrder }),
+		gqlProp("executionCount", "Int!", func(f F) any { return f.ExecutionCount }),
+		gqlProp("lastExecutedAt", "String", func(f F) any { return gqlNullTime(f.LastExecutedAt) }),
+		gqlProp("archivedAt", "String", func(f F) any { return gqlNullTime(f.ArchivedAt) }),
+		gqlProp("createdAt", "String", func(f F) any { return gqlNullTime(f.CreatedAt) }),
+		gqlProp("updatedAt", "String", func(f F) any { return gqlNullTime(f.UpdatedAt) }),
+		{name: "steps", typ: "[FlowStep!]!", resolve: func(ctx context.Context, source any, _ map[string]any) (any, error) {
+			return r.queries.ListFlowSteps(ctx, source.(F).ID)
+		}},
+	}}
+}
+
+func (r *gqlResolvers) flowStepType() *gqlObject {
+	type S = repository.FlowStep
+	return &gqlObject{name: "FlowStep", fields: []*gqlFieldDef{
+		gqlProp("id", "Int!", func(s S) any { return s.ID }),
+		gqlProp("name", "String!", func(s S) any { return s.Name }),
+		gqlProp("stepOrder", "Int!", func(s S) any { return s.StepOrder }),
+		gqlProp("method", "String!", func(s S) any { return s.Method }),
+		gqlProp("url", "String!", func(s S) any { return s.Url }),
+		gqlProp("requestId", "Int", func(s S) any { return gqlNullInt(s.RequestID) }),
+		{name: "request", typ: "Request", desc: "The saved request the step was linked to", resolve: func(ctx context.Context, source any, _ map[string]any) (any, error) {
+			s := source.(S)
+			if !s.RequestID.Valid {
+				return nil, nil
+			}
+			return r.request(ctx, s.RequestID.Int64), nil
+		}},
+	}}
+}
+
+func (r *gqlResolvers) environmentType() *gqlObject {
+	type E = repository.Environment
+	return &gqlObject{name: "Environment", fields: []*gqlFieldDef{
+		gqlProp("id", "Int!", func(e E) any { return e.ID }),
+		gqlProp("name", "String!", func(e E) any { return e.Name }),
+		gqlProp("isActive", "Boolean!", func(e E) any { return e.IsActive.Valid && e.IsActive.Bool }),
+		gqlProp("parentId", "Int", func(e E) any { return gqlNullInt(e.ParentID) }),
+		gqlProp("collectionId", "Int", func(e E) any { return gqlNullInt(e.CollectionID) }),
+		gqlProp("createdAt", "String", func(e E) any { return gqlNullTime(e.CreatedAt) }),
+		gqlProp("updatedAt", "String", func(e E) any { return gqlNullTime(e.UpdatedAt) }),
+	}}
+}
+
+func (r *gqlResolvers) historyType() *gqlObject {
+	type H = repository.RequestHistory
+	return &gqlObject{name: "HistoryEntry", fields: []*gqlFieldDef{
+		gqlProp("id", "Int!", func(h H) any { return h.ID }),
+		gqlProp("requestId", "Int", func(h H) any { return gqlNullInt(h.RequestID) }),
+		gqlProp("flowId", "Int", func(h H) any { return gqlNullInt(h.FlowID) }),
+		gqlProp("method", "String!", func(h H) any { return h.Method }),
+		gqlProp("url", "String!", func(h H) any { return h.Url }),
+		gqlProp("statusCode", "Int", func(h H) any { return gqlNullInt(h.StatusCode) }),
+		gqlProp("durationMs", "Int", func(h H) any { return gqlNullInt(h.DurationMs) }),
+		gqlProp("bodySize", "Int", func(h H) any { return gqlNullInt(h.BodySize) }),
+		gqlProp("error", "String", func(h H) any { return h.Error.String }),
+		gqlProp("success", "Boolean!", func(h H) any {
+			return h.Error.String == "" && h.StatusCode.Int64 >= 200 && h.StatusCode.Int64 < 400
+		}),
+		gqlProp("traceId", "String", func(h H) any { return h.TraceID.String }),
+		gqlProp("createdAt", "String", func(h H) any { return gqlNullTime(h.CreatedAt) }),
+	}}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestGraphQL_Execute(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := middleware.WithWorkspaceID(context.Background(), 1)
+	schema := NewGraphQLSchema(q)
+
+	coll, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "API", WorkspaceID: 1})
+	q.CreateRequest(ctx, repository.CreateRequestParams{
+		Name: "Users", Method: "GET", Url: "https://api.test/users", WorkspaceID: 1,
+		CollectionID: sql.NullInt64{Int64: coll.ID, Valid: true},
+	})
+
+	run := func(query string, vars map[string]any) (string, []GraphQLError) {
+		resp := schema.Execute(ctx, GraphQLRequest{Query: query, Variables: vars})
+		data, _ := json.Marshal(resp.Data)
+		return string(data), resp.Errors
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		vars    map[string]any
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "aliases and nesting",
+			query: `{ cols: collections { name requests { method, url } } }`,
+			want:  `{"cols":[{"name":"API","requests":[{"method":"GET","url":"https://api.test/users"}]}]}`,
+		},
+		{
+			name: "fragments and variables",
+			query: `query Get($id: Int!) { collection(id: $id) { ...Fields ... on Collection { __typename } } }
+				fragment Fields on Collection { id name }`,
+			vars: map[string]any{"id": float64(coll.ID)},
+			want: `{"collection":{"id":1,"name":"API","__typename":"Collection"}}`,
+		},
+		{
+			name:  "directives",
+			query: `query($all: Boolean = false) { collections { name parentId @include(if: $all) } }`,
+			want:  `{"collections":[{"name":"API"}]}`,
+		},
+		{
+			name:  "unknown ids resolve to null",
+			query: `{ request(id: 999) { id } }`,
+			want:  `{"request":null}`,
+		},
+		{
+			name:    "unknown field",
+			query:   `{ collections { nope } }`,
+			want:    `{"collections":[{"nope":null}]}`,
+			wantErr: `Cannot query field "nope"`,
+		},
+		{
+			name:    "missing selection",
+			query:   `{ collections }`,
+			want:    `{"collections":null}`,
+			wantErr: "needs a selection",
+		},
+		{
+			name:    "required argument",
+			query:   `{ flow { id } }`,
+			want:    `{"flow":null}`,
+			wantErr: "argument",
+		},
+		{
+			name:    "mutations are refused",
+			query:   `mutation { collections { id } }`,
+			want:    `null`,
+			wantErr: "read-only",
+		},
+		{
+			name:    "syntax error",
+			query:   "{ collections {\n id ",
+			want:    `null`,
+			wantErr: "line 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, errs := run(tt.query, tt.vars)
+			if data != tt.want {
+				t.Errorf("data = %s, want %s", data, tt.want)
+			}
+			if tt.wantErr == "" && len(errs) > 0 {
+				t.Errorf("unexpected errors: %+v", errs)
+			}
+			if tt.wantErr != "" && (len(errs) == 0 || !strings.Contains(errs[0].Message, tt.wantErr)) {
+				t.Errorf("errors = %+v, want %q", errs, tt.wantErr)
+			}
+		})
+	}
+
+	if sdl := schema.SDL(); !strings.Contains(sdl, "type Query {") || !strings.Contains(sdl, "lastRun: HistoryEntry") {
+		t.Errorf("SDL = %s", sdl)
+	}
+}
@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"relay/internal/repository"
+)
+
+// GRPCMethod is the request method of gRPC requests. Their URL names the
+// server and the method: grpc://host:port/package.Service/Method, or
+// grpcs:// for TLS. The body is the request message as JSON.
+const GRPCMethod = "GRPC"
+
+// ErrGRPCStreaming is returned for methods that stream in either direction
+var ErrGRPCStreaming = errors.New("only unary gRPC methods can be called")
+
+// GRPCTarget is a parsed gRPC request URL
+type GRPCTarget struct {
+	Address string // host:port
+	TLS     bool
+	Service string // package.Service; empty when the URL only names the server
+	Method  string
+}
+
+// ParseGRPCURL parses grpc://host:port/package.Service/Method. The service
+// and method may be left out to name just the server (for reflection).
+func ParseGRPCURL(raw string) (GRPCTarget, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return GRPCTarget{}, fmt.Errorf("invalid gRPC URL: %w", err)
+	}
+	t := GRPCTarget{Address: u.Host}
+	switch u.Scheme {
+	case "grpc":
+	case "grpcs":
+		t.TLS = true
+	default:
+		return GRPCTarget{}, errors.New("gRPC URL must start with grpc:// or grpcs://")
+	}
+	if u.Host == "" {
+		return GRPCTarget{}, errors.New("gRPC URL has no host")
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		svc, method, ok := strings.Cut(path, "/")
+		if !ok || svc == "" || method == "" || strings.Contains(method, "/") {
+			return GRPCTarget{}, errors.New("gRPC URL path must be /package.Service/Method")
+		}
+		t.Service, t.Method = svc, method
+	}
+	return t, nil
+}
+
+// GRPCServiceInfo is a service discovered through server reflection
+type GRPCServiceInfo struct {
+	Name    string           `json:"name"`
+	Methods []GRPCMethodInfo `json:"methods"`
+}
+
+type GRPCMethodInfo struct {
+	Name            string `json:"name"`
+	Path            string `json:"path"` // /package.Service/Method, to append to the server URL
+	InputType       string `json:"inputType"`
+	OutputType      string `json:"outputType"`
+	ClientStreaming bool   `json:"clientStreaming,omitempty"`
+	ServerStreaming bool   `json:"serverStreaming,omitempty"`
+	// RequestTemplate is the input message with every top-level field set to
+	// its zero value, as a starting point for the JSON body
+	RequestTemplate json.RawMessage `json:"requestTemplate,omitempty"`
+}
+
+// GRPCResponse is the outcome of a unary call. Status is set for calls that
+// reached the server, including failed ones.
+type GRPCResponse struct {
+	Status  *status.Status
+	Body    string // response message as JSON; empty unless the call succeeded
+	Header  metadata.MD
+	Trailer metadata.MD
+}
+
+func dialGRPC(t GRPCTarget) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if t.TLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	// passthrough dials the address as given, like the HTTP client does
+	return grpc.NewClient("passthrough:///"+t.Address, grpc.WithTransportCredentials(creds))
+}
+
+// DescribeGRPC lists the services of the server and their methods through
+// server reflection (grpc.reflection.v1)
+func DescribeGRPC(ctx context.Context, t GRPCTarget, md metadata.MD) ([]GRPCServiceInfo, error) {
+	conn, err := dialGRPC(t)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(ctx, md))
+	defer cancel()
+
+	r, err := newGRPCReflector(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	names, err := r.listServices()
+	if err != nil {
+		return nil, err
+	}
+	var services []string
+	for _, name := range names {
+		if strings.HasPrefix(name, "grpc.reflection.") {
+			continue
+		}
+		if err := r.fileContainingSymbol(name); err != nil {
+			return nil, err
+		}
+		services = append(services, name)
+	}
+	files, err := r.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]GRPCServiceInfo, 0, len(services))
+	for _, name := range services {
+		d, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, err
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		info := GRPCServiceInfo{Name: name, Methods: []GRPCMethodInfo{}}
+		for i := 0; i < sd.Methods().Len(); i++ {
+			m := sd.Methods().Get(i)
+			mi := GRPCMethodInfo{
+				Name:            string(m.Name()),
+				Path:            "/" + name + "/" + string(m.Name()),
+				InputType:       string(m.Input().FullName()),
+				OutputType:      string(m.Output().FullName()),
+				ClientStreaming: m.IsStreamingClient(),
+				ServerStreaming: m.IsStreamingServer(),
+			}
+			if tmpl, err := (protojson.MarshalOptions{EmitUnpopulated: true}).Marshal(dynamicpb.NewMessage(m.Input())); err == nil {
+				mi.RequestTemplate = tmpl
+			}
+			info.Methods = append(info.Methods, mi)
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// InvokeGRPC calls a unary method with a JSON request message, using server
+// reflection to learn the message types. Errors are returned for failures
+// before the call (connection, reflection, invalid JSON); a call the server
+// answered with an error status is reported in the response.
+func InvokeGRPC(ctx context.Context, t GRPCTarget, md metadata.MD, body string) (*GRPCResponse, error) {
+	if t.Service == "" {
+		return nil, errors.New("gRPC URL must name the method: grpc://host:port/package.Service/Method")
+	}
+	conn, err := dialGRPC(t)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	method, files, err := reflectGRPCMethod(ctx, conn, t)
+	if err != nil {
+		return nil, err
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, ErrGRPCStreaming
+	}
+	types := dynamicpb.NewTypes(files)
+
+	req := dynamicpb.NewMessage(method.Input())
+	if strings.TrimSpace(body) != "" {
+		if err := (protojson.UnmarshalOptions{Resolver: types}).Unmarshal([]byte(body), req); err != nil {
+			return nil, fmt.Errorf("invalid %s message: %w", method.Input().FullName(), err)
+		}
+	}
+	resp := dynamicpb.NewMessage(method.Output())
+	out := &GRPCResponse{}
+	err = conn.Invoke(ctx, "/"+t.Service+"/"+t.Method, req, resp, grpc.Header(&out.Header), grpc.Trailer(&out.Trailer))
+	out.Status = status.Convert(err)
+	if err == nil {
+		b, err := (protojson.MarshalOptions{Resolver: types}).Marshal(resp)
+		if err != nil {
+			return nil, err
+		}
+		out.Body = string(b)
+	}
+	return out, nil
+}
+
+// reflectGRPCMethod looks up the target's method through server reflection
+func reflectGRPCMethod(ctx context.Context, conn *grpc.ClientConn, t GRPCTarget) (protoreflect.MethodDescriptor, *protoregistry.Files, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // ends the reflection stream
+	r, err := newGRPCReflector(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := r.fileContainingSymbol(t.Service); err != nil {
+		return nil, nil, err
+	}
+	files, err := r.resolve()
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := files.FindDescriptorByName(protoreflect.FullName(t.Service))
+	if err != nil {
+		return nil, nil, fmt.Errorf("service %s not found", t.Service)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a service", t.Service)
+	}
+	method := sd.Methods().ByName(protoreflect.Name(t.Method))
+	if method == nil {
+		return nil, nil, fmt.Errorf("service %s has no method %s", t.Service, t.Method)
+	}
+	return method, files, nil
+}
+
+// grpcReflector collects file descriptors over one reflection stream
+type grpcReflector struct {
+	stream rpb.ServerReflection_ServerReflectionInfoClient
+	files  map[string]*descriptorpb.FileDescriptorProto
+}
+
+func newGRPCReflector(ctx context.Context, conn *grpc.ClientConn) (*grpcReflector, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, grpcReflectionError(err)
+	}
+	return &grpcReflector{stream: stream, files: make(map[string]*descriptorpb.FileDescriptorProto)}, nil
+}
+
+func grpcReflectionError(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return errors.New("server reflection is not enabled on the server")
+	}
+	return err
+}
+
+func (r *grpcReflector) roundTrip(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := r.stream.Send(req); err != nil {
+		return nil, grpcReflectionError(err)
+	}
+	resp, err := r.stream.Recv()
+	if err != nil {
+		return nil, grpcReflectionError(err)
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+func (r *grpcReflector) listServices() ([]string, error) {
+	resp, err := r.roundTrip(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		names = append(names, s.GetName())
+	}
+	return names, nil
+}
+
+func (r *grpcReflector) fileContainingSymbol(symbol string) error {
+	resp, err := r.roundTrip(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return fmt.Errorf("service %s not found", symbol)
+		}
+		return err
+	}
+	return r.addFiles(resp)
+}
+
+func (r *grpcReflector) addFiles(resp *rpb.ServerReflectionResponse) error {
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fd := new(descriptorpb.FileDescriptorProto)
+		if err := proto.Unmarshal(raw, fd); err != nil {
+			return fmt.Errorf("invalid file descriptor from server: %w", err)
+		}
+		r.files[fd.GetName()] = fd
+	}
+	return nil
+}
+
+// resolve fetches the dependencies the server did not send along and builds
+// the file registry. Well-known types linked into Relay are used when the
+// server doesn't know them by name.
+func (r *grpcReflector) resolve() (*protoregistry.Files, error) {
+	for {
+		missing := r.missingDependency()
+		if missing == "" {
+			break
+		}
+		resp, err := r.roundTrip(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		})
+		if err == nil {
+			err = r.addFiles(resp)
+		}
+		if _, ok := r.files[missing]; !ok {
+			fd, gErr := protoregistry.GlobalFiles.FindFileByPath(missing)
+			if gErr != nil {
+				if err == nil {
+					err = errors.New("not sent by the server")
+				}
+				return nil, fmt.Errorf("proto file %s: %v", missing, err)
+			}
+			r.files[missing] = protodesc.ToFileDescriptorProto(fd)
+		}
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range r.files {
+		set.File = append(set.File, fd)
+	}
+	return protodesc.NewFiles(set)
+}
+
+func (r *grpcReflector) missingDependency() string {
+	for _, fd := range r.files {
+		for _, dep := range fd.GetDependency() {
+			if _, ok := r.files[dep]; !ok {
+				return dep
+			}
+		}
+	}
+	return ""
+}
+
+// grpcCallTimeout matches the HTTP client timeout; a request's timeout_ms replaces it
+const grpcCallTimeout = 60 * time.Second
+
+// executeGRPC calls a saved or ad-hoc gRPC request. The resolved headers are
+// sent as metadata and the response message becomes the JSON body; the gRPC
+// status is mapped to an HTTP status code so assertions and monitors work as
+// for HTTP requests.
+func (re *RequestExecutor) executeGRPC(ctx context.Context, req repository.Request, runtimeVars map[string]string, colID int64, result *ExecuteResult) (*ExecuteResult, error) {
+	target, err := ParseGRPCURL(result.ResolvedURL)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if reason := re.safeModeBlock(ctx, &http.Request{Method: GRPCMethod, URL: &url.URL{Host: target.Address}}); reason != "" {
+		result.Error = reason
+		result.SafeModeBlocked = true
+		return result, nil
+	}
+	body := ""
+	if req.Body.Valid {
+		body, _ = re.variableResolver.Resolve(ctx, req.Body.String, runtimeVars, colID)
+	}
+	if err := lockedSecretsFrom(ctx).check(); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	release, ok := re.acquireHost(ctx, target.Address, result)
+	if !ok {
+		return result, nil
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout(req, grpcCallTimeout))
+	defer cancel()
+	start := time.Now()
+	if injectFault(ctx, result) {
+		result.DurationMs = time.Since(start).Milliseconds()
+		return result, nil
+	}
+	re.inFlight.Add(1)
+	resp, err := InvokeGRPC(ctx, target, GRPCMetadata(result.ResolvedHeaders), body)
+	re.inFlight.Add(-1)
+	re.recordExecution(ctx, req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		result.Unreachable = status.Code(err) == codes.Unavailable
+		result.sendFailed = result.Unreachable || status.Code(err) == codes.DeadlineExceeded
+		result.HistoryID = re.saveHistory(ctx, req, result, nil)
+		return result, nil
+	}
+
+	code := resp.Status.Code()
+	result.GRPCStatus = code.String()
+	result.StatusCode = grpcHTTPStatus(code)
+	result.Headers = make(map[string]string)
+	grpcMetadataHeaders(result.Headers, resp.Header)
+	grpcMetadataHeaders(result.Headers, resp.Trailer)
+	result.Headers["grpc-status"] = strconv.Itoa(int(code))
+	result.Body = resp.Body
+	if code != codes.OK {
+		result.Headers["grpc-message"] = resp.Status.Message()
+		b, _ := json.Marshal(map[string]string{"code": code.String(), "message": resp.Status.Message()})
+		result.Body = string(b)
+	}
+	result.BodySize = int64(len(result.Body))
+	result.HistoryID = re.saveHistory(ctx, req, result, nil)
+
+	if req.ResponseTransform.Valid && req.ResponseTransform.String != "" {
+		applyResponseTransform(req.ResponseTransform.String, result)
+	}
+	return result, nil
+}
+
+// GRPCMetadata converts request headers to call metadata
+func GRPCMetadata(headers map[string]string) metadata.MD {
+	md := metadata.MD{}
+	for k, v := range headers {
+		md.Set(k, v)
+	}
+	return md
+}
+
+// grpcHTTPStatus maps a gRPC status code to the HTTP status recorded in
+// history and checked by assertions and monitors
+func grpcHTTPStatus(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// grpcMetadataHeaders flattens response metadata into result headers
+func grpcMetadataHeaders(dst map[string]string, md metadata.MD) {
+	for k, v := range md {
+		dst[k] = strings.Join(v, ", ")
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+// startGRPCServer serves the health service with reflection and returns its address
+func startGRPCServer(t *testing.T, opts ...grpc.ServerOption) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(opts...)
+	hs := health.NewServer()
+	hs.SetServingStatus("orders", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestParseGRPCURL(t *testing.T) {
+	target, err := ParseGRPCURL("grpcs://api.example.com:443/orders.v1.Orders/Get")
+	if err != nil || !target.TLS || target.Address != "api.example.com:443" || target.Service != "orders.v1.Orders" || target.Method != "Get" {
+		t.Errorf("ParseGRPCURL = %+v, %v", target, err)
+	}
+	if target, err := ParseGRPCURL("grpc://localhost:50051"); err != nil || target.Service != "" {
+		t.Errorf("server only: %+v, %v", target, err)
+	}
+	for _, bad := range []string{"http://localhost/a.B/C", "grpc:///a.B/C", "grpc://localhost/a.B", "grpc://localhost/a.B/C/D"} {
+		if _, err := ParseGRPCURL(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestDescribeGRPC(t *testing.T) {
+	addr := startGRPCServer(t)
+	services, err := DescribeGRPC(context.Background(), GRPCTarget{Address: addr}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || services[0].Name != "grpc.health.v1.Health" {
+		t.Fatalf("services = %+v", services)
+	}
+	methods := map[string]GRPCMethodInfo{}
+	for _, m := range services[0].Methods {
+		methods[m.Name] = m
+	}
+	check := methods["Check"]
+	if check.Path != "/grpc.health.v1.Health/Check" || check.InputType != "grpc.health.v1.HealthCheckRequest" || check.ServerStreaming {
+		t.Errorf("Check = %+v", check)
+	}
+	if string(check.RequestTemplate) != `{"service":""}` {
+		t.Errorf("request template = %s", check.RequestTemplate)
+	}
+	if !methods["Watch"].ServerStreaming {
+		t.Errorf("Watch = %+v", methods["Watch"])
+	}
+}
+
+func TestRequestExecutor_GRPC(t *testing.T) {
+	var gotToken string
+	addr := startGRPCServer(t, grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["authorization"]) > 0 {
+			gotToken = md["authorization"][0]
+		}
+		return handler(ctx, req)
+	}))
+
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	call := func(method, body string) *ExecuteResult {
+		t.Helper()
+		result, err := re.ExecuteRequest(ctx, repository.Request{
+			Method:  GRPCMethod,
+			Url:     "grpc://{{host}}/grpc.health.v1.Health/" + method,
+			Headers: sql.NullString{String: `{"Authorization":"Bearer {{token}}"}`, Valid: true},
+			Body:    sql.NullString{String: body, Valid: true},
+		}, map[string]string{"host": addr, "token": "t1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := call("Check", `{"service":""}`)
+	if result.Error != "" || result.StatusCode != 200 || result.GRPCStatus != "OK" || result.Body != `{"status":"SERVING"}` {
+		t.Fatalf("Check = %+v", result)
+	}
+	if gotToken != "Bearer t1" {
+		t.Errorf("authorization metadata = %q", gotToken)
+	}
+	if result.HistoryID == 0 {
+		t.Error("call not saved to history")
+	}
+
+	result = call("Check", `{"service":"payments"}`)
+	if result.StatusCode != 404 || result.GRPCStatus != "NotFound" || !strings.Contains(result.Body, `"code":"NotFound"`) {
+		t.Errorf("unknown service = %+v", result)
+	}
+	if result = call("Check", `{"service":1}`); !strings.Contains(result.Error, "invalid grpc.health.v1.HealthCheckRequest message") {
+		t.Errorf("invalid JSON: %q", result.Error)
+	}
+	if result = call("Watch", `{}`); result.Error != ErrGRPCStreaming.Error() {
+		t.Errorf("streaming method: %q", result.Error)
+	}
+	if result = call("Probe", `{}`); result.Error != "service grpc.health.v1.Health has no method Probe" {
+		t.Errorf("unknown method: %q", result.Error)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"relay/internal/repository"
+)
+
+const (
+	historyRetentionTick   = time.Hour
+	historyRetentionPeriod = 30 * 24 * time.Hour
+	runTimelineRetention   = 7 * 24 * time.Hour
+)
+
+// HistoryRetention deletes request history and flow run results older than
+// 30 days and flow run timelines older than a week. Flagged entries are kept
+// until they are unflagged.
+type HistoryRetention struct {
+	queries  *repository.Queries
+	instance *Instance // optional; pruning runs only while holding the retention lease
+	archiver *Archiver // optional; runs and history are archived before they are deleted
+	jobs     *JobQueue // optional; pruning runs as a queued job
+}
+
+func NewHistoryRetention(queries *repository.Queries) *HistoryRetention {
+	return &HistoryRetention{queries: queries}
+}
+
+// SetInstance makes pruning run on one instance when several share the database
+func (h *HistoryRetention) SetInstance(inst *Instance) {
+	h.instance = inst
+}
+
+// SetArchiver keeps expired runs and history in an archive store
+func (h *HistoryRetention) SetArchiver(a *Archiver) {
+	h.archiver = a
+}
+
+// SetJobQueue runs pruning on the job queue: each hourly tick queues a
+// history-retention job instead of pruning inline
+func (h *HistoryRetention) SetJobQueue(jq *JobQueue) {
+	h.jobs = jq
+	jq.Register(JobTypeHistoryRetention, func(ctx context.Context, job repository.Job) (interface{}, error) {
+		n, err := h.Prune(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]int64{"deleted": n}, nil
+	})
+}
+
+// Start prunes history now and then hourly until ctx is cancelled
+func (h *HistoryRetention) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(historyRetentionTick)
+		defer ticker.Stop()
+		for {
+			if h.instance.Acquire(ctx, LeaseHistoryRetention, historyRetentionTick) {
+				h.pruneOrEnqueue(ctx)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// pruneOrEnqueue queues a pruning job, or prunes right away without a queue
+func (h *HistoryRetention) pruneOrEnqueue(ctx context.Context) {
+	if h.jobs == nil {
+		if _, err := h.Prune(ctx); err != nil {
+			log.Printf("history: failed to prune old entries: %v", err)
+		}
+		return
+	}
+	_, err := h.jobs.Enqueue(ctx, JobTypeHistoryRetention, nil, EnqueueOptions{Key: JobTypeHistoryRetention})
+	if err != nil && !errors.Is(err, ErrJobPending) {
+		log.Printf("history: failed to queue pruning: %v", err)
+	}
+}
+
+// Prune deletes expired run timelines, run results and unflagged history
+// entries and returns how many history entries were removed. WebSocket frames of entries
+// deleted any other way go with them.
+func (h *HistoryRetention) Prune(ctx context.Context) (int64, error) {
+	var n int64
+	var err error
+	if h.archiver != nil {
+		n, err = h.archiveAndPrune(ctx)
+	} else if err = h.queries.PruneRunTimelines(ctx); err == nil {
+		n, err = h.queries.DeleteOldHistory(ctx)
+	}
+	if err != nil {
+		return n, err
+	}
+	if _, err := h.queries.DeleteFlowRunsBefore(ctx, sqliteTime(time.Now().Add(-historyRetentionPeriod))); err != nil {
+		return n, err
+	}
+	_, err = h.queries.DeleteOrphanWSMessages(ctx)
+	return n, err
+}
+
+// archiveAndPrune archives expired runs (deleting their timelines) and
+// history, then deletes the history. Nothing more is deleted if archiving
+// fails, so the next round retries it.
+func (h *HistoryRetention) archiveAndPrune(ctx context.Context) (int64, error) {
+	now := time.Now()
+	if _, err := h.archiver.ArchiveRuns(ctx, now.Add(-runTimelineRetention)); err != nil {
+		return 0, fmt.Errorf("archive runs: %w", err)
+	}
+	before := now.Add(-historyRetentionPeriod)
+	if _, err := h.archiver.ArchiveHistory(ctx, before); err != nil {
+		return 0, fmt.Errorf("archive history: %w", err)
+	}
+	return h.queries.DeleteHistoryBefore(ctx, sqliteTime(before))
+}
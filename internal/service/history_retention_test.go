@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestHistoryRetention_KeepsFlagged(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+
+	var ids []int64
+	for range 3 {
+		hist, err := q.CreateHistory(ctx, repository.CreateHistoryParams{Method: "GET", Url: "https://example.com", WorkspaceID: 1})
+		if err != nil {
+			t.Fatalf("create history: %v", err)
+		}
+		ids = append(ids, hist.ID)
+	}
+	// ids[0] and ids[1] are expired; ids[1] is flagged
+	if _, err := db.Exec(`UPDATE request_history SET created_at = datetime('now', '-31 days') WHERE id IN (?, ?)`, ids[0], ids[1]); err != nil {
+		t.Fatalf("backdate history: %v", err)
+	}
+	if _, err := q.UpdateHistoryNote(ctx, repository.UpdateHistoryNoteParams{
+		Note: sql.NullString{String: "keep", Valid: true}, Flagged: 1, ID: ids[1], WorkspaceID: 1,
+	}); err != nil {
+		t.Fatalf("flag history: %v", err)
+	}
+
+	n, err := NewHistoryRetention(q).Prune(ctx)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("pruned %d entries, want 1", n)
+	}
+	if _, err := q.GetHistory(ctx, ids[0]); err == nil {
+		t.Error("expired entry was kept")
+	}
+	for _, id := range ids[1:] {
+		if _, err := q.GetHistory(ctx, id); err != nil {
+			t.Errorf("entry %d was deleted: %v", id, err)
+		}
+	}
+}
+
+func TestHistoryRetention_DropsWSMessages(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+
+	expired, _ := q.CreateHistory(ctx, repository.CreateHistoryParams{Method: "WS", Url: "ws://example.com", WorkspaceID: 1})
+	kept, _ := q.CreateHistory(ctx, repository.CreateHistoryParams{Method: "WS", Url: "ws://example.com", WorkspaceID: 1})
+	for _, id := range []int64{expired.ID, kept.ID} {
+		q.CreateWSMessage(ctx, repository.CreateWSMessageParams{HistoryID: id, Seq: 1, Direction: "sent", Format: "text", Payload: "hi", Size: 2, SentAt: time.Now()})
+	}
+	db.Exec(`UPDATE request_history SET created_at = datetime('now', '-31 days') WHERE id = ?`, expired.ID)
+
+	if _, err := NewHistoryRetention(q).Prune(ctx); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if msgs, _ := q.ListWSMessages(ctx, repository.ListWSMessagesParams{HistoryID: expired.ID, Limit: 10}); len(msgs) != 0 {
+		t.Errorf("expired session kept %d messages", len(msgs))
+	}
+	if msgs, _ := q.ListWSMessages(ctx, repository.ListWSMessagesParams{HistoryID: kept.ID, Limit: 10}); len(msgs) != 1 {
+		t.Errorf("kept session has %d messages, want 1", len(msgs))
+	}
+}
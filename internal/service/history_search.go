@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"relay/internal/repository"
+)
+
+const (
+	historySearchTick  = time.Minute
+	historySearchBatch = 500
+
+	// DefaultSearchBodyBytes is how much of each response body is indexed
+	// unless the workspace sets maxBodyBytes
+	DefaultSearchBodyBytes = 64 << 10
+	maxSearchBodyBytes     = 1 << 20
+)
+
+// HistorySearchSettings controls full-text indexing of the workspace's
+// history response bodies
+type HistorySearchSettings struct {
+	Disabled     bool `json:"disabled,omitempty"`     // opt out; indexed bodies are dropped
+	MaxBodyBytes int  `json:"maxBodyBytes,omitempty"` // longer bodies are indexed up to this size; 0 = default
+}
+
+func (s HistorySearchSettings) Validate() error {
+	if s.MaxBodyBytes < 0 || s.MaxBodyBytes > maxSearchBodyBytes {
+		return fmt.Errorf("historySearch.maxBodyBytes must be between 0 and %d", maxSearchBodyBytes)
+	}
+	return nil
+}
+
+func (s HistorySearchSettings) bodyLimit() int {
+	if s.MaxBodyBytes == 0 {
+		return DefaultSearchBodyBytes
+	}
+	return s.MaxBodyBytes
+}
+
+// HistorySearchIndexer copies new history response bodies and errors into
+// the history_search FTS5 index in the background. Binary bodies are
+// skipped, long ones are cut at the workspace's size limit, and entries
+// removed by retention or deletion are dropped from the index.
+type HistorySearchIndexer struct {
+	queries  *repository.Queries
+	instance *Instance // optional; indexing runs only while holding the lease
+}
+
+func NewHistorySearchIndexer(queries *repository.Queries) *HistorySearchIndexer {
+	return &HistorySearchIndexer{queries: queries}
+}
+
+// SetInstance makes indexing run on one instance when several share the database
+func (x *HistorySearchIndexer) SetInstance(inst *Instance) {
+	x.instance = inst
+}
+
+// Start indexes pending history now and then every minute until ctx is cancelled
+func (x *HistorySearchIndexer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(historySearchTick)
+		defer ticker.Stop()
+		for {
+			if x.instance.Acquire(ctx, LeaseHistorySearch, historySearchTick) {
+				if _, err := x.Index(ctx); err != nil {
+					log.Printf("history search: indexing failed: %v", err)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Index adds the history entries created since the last pass to the index
+// and returns how many were indexed
+func (x *HistorySearchIndexer) Index(ctx context.Context) (int, error) {
+	if err := x.prune(ctx); err != nil {
+		return 0, err
+	}
+	cursor, err := x.queries.GetHistorySearchCursor(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	settings := map[int64]HistorySearchSettings{}
+	indexed := 0
+	for {
+		rows, err := x.queries.ListHistoryToIndex(ctx, repository.ListHistoryToIndexParams{ID: cursor, Limit: historySearchBatch})
+		if err != nil {
+			return indexed, err
+		}
+		if len(rows) == 0 {
+			return indexed, nil
+		}
+		for _, row := range rows {
+			s, ok := settings[row.WorkspaceID]
+			if !ok {
+				raw, _ := x.queries.GetWorkspaceSettings(ctx, row.WorkspaceID)
+				s = ParseWorkspaceSettings(raw).HistorySearch
+				settings[row.WorkspaceID] = s
+			}
+			if s.Disabled {
+				continue
+			}
+			body := ""
+			if row.IsBinary.Int64 == 0 {
+				body = truncateUTF8(row.ResponseBody.String, s.bodyLimit())
+			}
+			if body == "" && row.Error.String == "" {
+				continue
+			}
+			if err := x.queries.CreateHistorySearchEntry(ctx, repository.CreateHistorySearchEntryParams{
+				Rowid:       row.ID,
+				Body:        body,
+				Error:       row.Error.String,
+				WorkspaceID: row.WorkspaceID,
+			}); err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+		cursor = rows[len(rows)-1].ID
+		if err := x.queries.SetHistorySearchCursor(ctx, cursor); err != nil {
+			return indexed, err
+		}
+	}
+}
+
+// prune drops index entries of deleted history and of opted-out workspaces
+func (x *HistorySearchIndexer) prune(ctx context.Context) error {
+	if _, err := x.queries.DeleteOrphanHistorySearch(ctx); err != nil {
+		return err
+	}
+	workspaces, err := x.queries.ListWorkspaces(ctx)
+	if err != nil {
+		return err
+	}
+	for _, ws := range workspaces {
+		if ParseWorkspaceSettings(ws.Settings).HistorySearch.Disabled {
+			if _, err := x.queries.DeleteHistorySearchByWorkspace(ctx, ws.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// HistorySearchQuery turns free text into an FTS5 query matching entries
+// that contain every word, so punctuation in pasted error messages is not
+// read as query syntax. It returns "" when q has no words.
+func HistorySearchQuery(q string) string {
+	terms := strings.Fields(q)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(terms, " ")
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
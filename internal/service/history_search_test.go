@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestHistorySearchIndexer(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	ws2, _ := q.CreateWorkspace(ctx, "Private")
+	q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"historySearch":{"maxBodyBytes":32}}`, Valid: true}, ID: 1,
+	})
+
+	create := func(wsID int64, body, errMsg string, binary bool) int64 {
+		t.Helper()
+		p := repository.CreateHistoryParams{
+			Method:       "GET",
+			Url:          "https://example.com",
+			ResponseBody: sql.NullString{String: body, Valid: true},
+			Error:        sql.NullString{String: errMsg, Valid: true},
+			WorkspaceID:  wsID,
+		}
+		if binary {
+			p.IsBinary = sql.NullInt64{Int64: 1, Valid: true}
+		}
+		hist, err := q.CreateHistory(ctx, p)
+		if err != nil {
+			t.Fatalf("create history: %v", err)
+		}
+		return hist.ID
+	}
+	expired := create(1, `{"error":"Token expired for user 42"}`, "", false)
+	long := create(1, `{"padding":"`+strings.Repeat("x", 40)+`","tail":"unreachable"}`, "", false)
+	create(1, "PNG garbage token", "", true)
+	refused := create(1, "", "dial tcp: connection refused", false)
+	create(ws2.ID, `{"error":"Token expired"}`, "", false)
+
+	x := NewHistorySearchIndexer(q)
+	n, err := x.Index(ctx)
+	if err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("indexed %d entries, want 4 (binary body skipped)", n)
+	}
+	if n, _ := x.Index(ctx); n != 0 {
+		t.Errorf("second pass indexed %d entries, want 0", n)
+	}
+
+	search := func(wsID int64, text string) []int64 {
+		t.Helper()
+		rows, err := q.SearchHistory(ctx, repository.SearchHistoryParams{Query: HistorySearchQuery(text), WorkspaceID: wsID, Limit: 10})
+		if err != nil {
+			t.Fatalf("search %q: %v", text, err)
+		}
+		var ids []int64
+		for _, row := range rows {
+			ids = append(ids, row.RequestHistory.ID)
+		}
+		return ids
+	}
+	if ids := search(1, `"token expired"`); len(ids) != 1 || ids[0] != expired {
+		t.Errorf("token expired = %v, want [%d]", ids, expired)
+	}
+	if ids := search(1, "connection refused"); len(ids) != 1 || ids[0] != refused {
+		t.Errorf("connection refused = %v, want [%d]", ids, refused)
+	}
+	if ids := search(1, "unreachable"); len(ids) != 0 {
+		t.Errorf("text past maxBodyBytes matched %v", ids)
+	}
+	if ids := search(1, "padding"); len(ids) != 1 || ids[0] != long {
+		t.Errorf("padding = %v, want [%d]", ids, long)
+	}
+	if ids := search(ws2.ID, "token"); len(ids) != 1 {
+		t.Errorf("workspace 2 token = %v", ids)
+	}
+
+	// Opting out drops the workspace's index; deleted history leaves it too
+	q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"historySearch":{"disabled":true}}`, Valid: true}, ID: ws2.ID,
+	})
+	create(ws2.ID, "token again", "", false)
+	q.DeleteHistory(ctx, expired)
+	if n, err := x.Index(ctx); err != nil || n != 0 {
+		t.Fatalf("index after opt-out = %d, %v", n, err)
+	}
+	if ids := search(ws2.ID, "token"); len(ids) != 0 {
+		t.Errorf("opted-out workspace still searchable: %v", ids)
+	}
+	if ids := search(1, "token"); len(ids) != 0 {
+		t.Errorf("deleted entry still searchable: %v", ids)
+	}
+}
+
+func TestHistorySearchQuery(t *testing.T) {
+	cases := map[string]string{
+		"":                       "",
+		"  ":                     "",
+		"connection refused":     `"connection" "refused"`,
+		`say "hi" (now) OR NEAR`: `"say" """hi""" "(now)" "OR" "NEAR"`,
+	}
+	for in, want := range cases {
+		if got := HistorySearchQuery(in); got != want {
+			t.Errorf("HistorySearchQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
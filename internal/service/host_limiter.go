@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxHostConcurrent = 1000
+	maxHostMinDelayMs = 60000
+)
+
+// HostLimit throttles requests to one target host; zero values mean unlimited
+type HostLimit struct {
+	MaxConcurrent int `json:"maxConcurrent"` // requests in flight at once
+	MinDelayMs    int `json:"minDelayMs"`    // gap between request starts
+}
+
+func (l HostLimit) unlimited() bool {
+	return l.MaxConcurrent <= 0 && l.MinDelayMs <= 0
+}
+
+func (l HostLimit) validate(name string) error {
+	if l.MaxConcurrent < 0 || l.MaxConcurrent > maxHostConcurrent {
+		return fmt.Errorf("%s: maxConcurrent must be between 0 and %d", name, maxHostConcurrent)
+	}
+	if l.MinDelayMs < 0 || l.MinDelayMs > maxHostMinDelayMs {
+		return fmt.Errorf("%s: minDelayMs must be between 0 and %d", name, maxHostMinDelayMs)
+	}
+	return nil
+}
+
+// HostLimitSettings configures per-host throttling for a workspace. Hosts are
+// keyed by hostname or host:port; the default applies to every other host.
+type HostLimitSettings struct {
+	Default HostLimit            `json:"default"`
+	Hosts   map[string]HostLimit `json:"hosts"`
+}
+
+// For returns the limit for host (as in URL.Host), preferring an exact
+// host:port entry over a bare hostname
+func (s HostLimitSettings) For(host string) HostLimit {
+	host = strings.ToLower(host)
+	if l, ok := s.Hosts[host]; ok {
+		return l
+	}
+	if i := strings.LastIndex(host, ":"); i > 0 && !strings.HasSuffix(host, "]") {
+		if l, ok := s.Hosts[host[:i]]; ok {
+			return l
+		}
+	}
+	return s.Default
+}
+
+func (s HostLimitSettings) Validate() error {
+	if err := s.Default.validate("hostLimits.default"); err != nil {
+		return err
+	}
+	for host, l := range s.Hosts {
+		if host == "" || strings.ContainsAny(host, "/?#@ ") {
+			return fmt.Errorf("hostLimits: invalid host %q (use hostname or host:port)", host)
+		}
+		if host != strings.ToLower(host) {
+			return fmt.Errorf("hostLimits: host %q must be lowercase", host)
+		}
+		if err := l.validate("hostLimits." + host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HostLimiter enforces HostLimits across every request the server sends, so
+// concurrent flows, loops and script sendRequests share one budget per host.
+type HostLimiter struct {
+	mu    sync.Mutex
+	hosts map[string]*hostSlot
+}
+
+type hostSlot struct {
+	active    int
+	nextStart time.Time     // earliest start for the next request
+	released  chan struct{} // closed (and replaced) whenever a request finishes
+}
+
+func NewHostLimiter() *HostLimiter {
+	return &HostLimiter{hosts: make(map[string]*hostSlot)}
+}
+
+// Acquire blocks until a request to host may start under limit. The returned
+// release func must be called once the request completes.
+func (l *HostLimiter) Acquire(ctx context.Context, host string, limit HostLimit) (func(), error) {
+	if limit.unlimited() {
+		return func() {}, nil
+	}
+	host = strings.ToLower(host)
+	delay := time.Duration(limit.MinDelayMs) * time.Millisecond
+
+	for {
+		l.mu.Lock()
+		slot := l.hosts[host]
+		if slot == nil {
+			slot = &hostSlot{released: make(chan struct{})}
+			l.hosts[host] = slot
+		}
+		if limit.MaxConcurrent > 0 && slot.active >= limit.MaxConcurrent {
+			wait := slot.released
+			l.mu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		// Reserve a start time so queued requests stay spaced by the delay
+		now := time.Now()
+		start := now
+		if slot.nextStart.After(now) {
+			start = slot.nextStart
+		}
+		slot.nextStart = start.Add(delay)
+		slot.active++
+		l.mu.Unlock()
+
+		release := func() { l.release(host, slot) }
+		if wait := start.Sub(now); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				release()
+				return nil, ctx.Err()
+			}
+		}
+
+		var once sync.Once
+		return func() { once.Do(release) }, nil
+	}
+}
+
+func (l *HostLimiter) release(host string, slot *hostSlot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot.active--
+	close(slot.released)
+	slot.released = make(chan struct{})
+	if slot.active == 0 && !slot.nextStart.After(time.Now()) {
+		delete(l.hosts, host)
+	}
+}
+
+// PruneIdle forgets hosts with no request in flight and returns how many were
+// dropped. A pending minimum delay is forgotten with them.
+func (l *HostLimiter) PruneIdle() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := 0
+	for host, slot := range l.hosts {
+		if slot.active == 0 {
+			delete(l.hosts, host)
+			n++
+		}
+	}
+	return n
+}
+
+// limitHost returns the key requests to rawURL are throttled under
+func limitHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
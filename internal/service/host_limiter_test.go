@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestHostLimitSettings_For(t *testing.T) {
+	s := HostLimitSettings{
+		Default: HostLimit{MaxConcurrent: 10},
+		Hosts: map[string]HostLimit{
+			"api.example.com":      {MaxConcurrent: 2},
+			"api.example.com:8443": {MaxConcurrent: 1},
+		},
+	}
+	cases := map[string]int{
+		"api.example.com":      2,
+		"API.example.com:443":  2,
+		"api.example.com:8443": 1,
+		"other.example.com":    10,
+	}
+	for host, want := range cases {
+		if got := s.For(host).MaxConcurrent; got != want {
+			t.Errorf("For(%q).MaxConcurrent = %d, want %d", host, got, want)
+		}
+	}
+}
+
+func TestHostLimitSettings_Validate(t *testing.T) {
+	bad := []HostLimitSettings{
+		{Default: HostLimit{MaxConcurrent: -1}},
+		{Default: HostLimit{MinDelayMs: maxHostMinDelayMs + 1}},
+		{Hosts: map[string]HostLimit{"https://api.example.com": {MaxConcurrent: 1}}},
+		{Hosts: map[string]HostLimit{"API.example.com": {MaxConcurrent: 1}}},
+	}
+	for i, s := range bad {
+		if err := s.Validate(); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+	ok := HostLimitSettings{Hosts: map[string]HostLimit{"localhost:8080": {MaxConcurrent: 4, MinDelayMs: 100}}}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("valid settings: %v", err)
+	}
+}
+
+func TestHostLimiter_MaxConcurrent(t *testing.T) {
+	l := NewHostLimiter()
+	limit := HostLimit{MaxConcurrent: 3}
+
+	var active, peak int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := l.Acquire(context.Background(), "example.com", limit)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			n := atomic.AddInt32(&active, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+			release()
+		}()
+	}
+	wg.Wait()
+
+	if peak > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", peak)
+	}
+	if len(l.hosts) != 0 {
+		t.Errorf("idle hosts should be dropped, have %d", len(l.hosts))
+	}
+}
+
+func TestHostLimiter_MinDelay(t *testing.T) {
+	l := NewHostLimiter()
+	limit := HostLimit{MinDelayMs: 30}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		release, err := l.Acquire(context.Background(), "example.com", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	// Starts at 0, 30, 60 and 90ms
+	if elapsed := time.Since(start); elapsed < 85*time.Millisecond {
+		t.Errorf("4 requests with 30ms spacing took %v", elapsed)
+	}
+
+	// Other hosts are not held back
+	otherStart := time.Now()
+	release, _ := l.Acquire(context.Background(), "other.example.com", limit)
+	release()
+	if time.Since(otherStart) > 20*time.Millisecond {
+		t.Error("delay leaked to another host")
+	}
+}
+
+func TestHostLimiter_Cancel(t *testing.T) {
+	l := NewHostLimiter()
+	limit := HostLimit{MaxConcurrent: 1}
+
+	hold, _ := l.Acquire(context.Background(), "example.com", limit)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, "example.com", limit); err == nil {
+		t.Fatal("expected context error while host is saturated")
+	}
+	hold()
+
+	release, err := l.Acquire(context.Background(), "example.com", limit)
+	if err != nil {
+		t.Fatalf("slot should be free after release: %v", err)
+	}
+	release()
+}
+
+func TestExecuteRequest_HostLimit(t *testing.T) {
+	var active, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+
+	u, _ := url.Parse(ts.URL)
+	settings := `{"hostLimits":{"hosts":{"` + u.Host + `":{"maxConcurrent":2}}}}`
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: settings, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var queued int64
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := re.ExecuteAdhoc(ctx, "GET", ts.URL, "", "", nil, nil)
+			if err != nil || result.Error != "" || result.StatusCode != 200 {
+				t.Errorf("execute: %v %+v", err, result)
+				return
+			}
+			atomic.AddInt64(&queued, result.QueuedMs)
+		}()
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("server saw %d concurrent requests, want <= 2", peak)
+	}
+	if queued == 0 {
+		t.Error("expected some requests to report queue time")
+	}
+}
+
+func TestExecuteRequest_HostLimitGRPC(t *testing.T) {
+	var active, peak int32
+	addr := startGRPCServer(t, grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		return handler(ctx, req)
+	}))
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"hostLimits":{"hosts":{"` + addr + `":{"maxConcurrent":1}}}}`, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var queued int64
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := re.ExecuteRequest(ctx, repository.Request{
+				Method: GRPCMethod,
+				Url:    "grpc://" + addr + "/grpc.health.v1.Health/Check",
+				Body:   sql.NullString{String: `{}`, Valid: true},
+			}, nil)
+			if err != nil || result.Error != "" || result.GRPCStatus != "OK" {
+				t.Errorf("execute: %v %+v", err, result)
+				return
+			}
+			atomic.AddInt64(&queued, result.QueuedMs)
+		}()
+	}
+	wg.Wait()
+
+	if peak > 1 {
+		t.Errorf("server saw %d concurrent calls, want 1", peak)
+	}
+	if queued == 0 {
+		t.Error("expected some calls to report queue time")
+	}
+}
+
+func TestRequestExecutor_HostLimitLookupError(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"hostLimits":{"default":{"maxConcurrent":2}}}`, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	seen := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	if limit, err := seen.hostLimit(ctx, "api.example.com"); err != nil || limit.MaxConcurrent != 2 {
+		t.Fatalf("hostLimit = %+v, %v", limit, err)
+	}
+
+	// Make the settings lookup fail
+	if _, err := db.Exec(`ALTER TABLE workspaces RENAME TO workspaces_gone`); err != nil {
+		t.Fatal(err)
+	}
+	if limit, err := seen.hostLimit(ctx, "api.example.com"); err != nil || limit.MaxConcurrent != 2 {
+		t.Errorf("lookup error should keep the last limit, got %+v, %v", limit, err)
+	}
+
+	fresh := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	result, err := fresh.ExecuteAdhoc(ctx, "GET", "http://api.example.com", "", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(result.Error, "Host limit unavailable") || result.StatusCode != 0 {
+		t.Errorf("lookup error without a known limit should fail the request, got %+v", result)
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedImportFormat is returned for formats without an importer
+var ErrUnsupportedImportFormat = errors.New("unsupported import format (expected postman)")
+
+// ParseImport converts an export of the given format into a collection tree
+func ParseImport(format string, data []byte) (*ImportedCollection, []string, error) {
+	switch format {
+	case "", "postman":
+		return ParsePostmanCollection(data)
+	}
+	return nil, nil, ErrUnsupportedImportFormat
+}
+
+// ImportPreviewNode is a collection or request of a parsed import. IDs are
+// positions in the tree ("0" is the root, "0.1" its second folder, "0.1:2"
+// that folder's third request), so they stay stable as long as the same
+// document is sent again.
+type ImportPreviewNode struct {
+	ID        string              `json:"id"`
+	Type      string              `json:"type"` // collection | request
+	Name      string              `json:"name"`
+	Method    string              `json:"method,omitempty"`
+	URL       string              `json:"url,omitempty"`
+	Variables int                 `json:"variables,omitempty"`
+	Children  []ImportPreviewNode `json:"children,omitempty"` // requests first, then folders
+}
+
+// Preview returns the tree with the IDs Select accepts
+func (c *ImportedCollection) Preview() ImportPreviewNode {
+	return c.preview("0")
+}
+
+func (c *ImportedCollection) preview(id string) ImportPreviewNode {
+	node := ImportPreviewNode{ID: id, Type: "collection", Name: c.Name, Variables: len(c.Variables)}
+	for i, req := range c.Requests {
+		node.Children = append(node.Children, ImportPreviewNode{
+			ID:     id + ":" + strconv.Itoa(i),
+			Type:   "request",
+			Name:   req.Name,
+			Method: req.Method,
+			URL:    req.URL,
+		})
+	}
+	for i, child := range c.Children {
+		node.Children = append(node.Children, child.preview(id+"."+strconv.Itoa(i)))
+	}
+	return node
+}
+
+// Select returns the part of the tree the preview IDs pick. A selected
+// collection brings its whole subtree; the folders above a selected item are
+// kept (with their variables) so it lands in the same place.
+func (c *ImportedCollection) Select(ids []string) (*ImportedCollection, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("select at least one item to import")
+	}
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !c.has("0", id) {
+			return nil, fmt.Errorf("unknown import item %q", id)
+		}
+		selected[id] = true
+	}
+	picked, _ := c.pick("0", selected)
+	return picked, nil
+}
+
+// has reports whether id names an item of the tree rooted at c
+func (c *ImportedCollection) has(prefix, id string) bool {
+	if id == prefix {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(id, prefix+":"); ok {
+		i, err := strconv.Atoi(rest)
+		return err == nil && strconv.Itoa(i) == rest && i >= 0 && i < len(c.Requests)
+	}
+	for i, child := range c.Children {
+		if child.has(prefix+"."+strconv.Itoa(i), id) {
+			return true
+		}
+	}
+	return false
+}
+
+// pick copies the selected parts of c, reporting whether anything was picked
+func (c *ImportedCollection) pick(id string, selected map[string]bool) (*ImportedCollection, bool) {
+	if selected[id] {
+		return c, true
+	}
+	out := &ImportedCollection{Name: c.Name, Variables: c.Variables, Secrets: c.Secrets}
+	for i, req := range c.Requests {
+		if selected[id+":"+strconv.Itoa(i)] {
+			out.Requests = append(out.Requests, req)
+		}
+	}
+	for i, child := range c.Children {
+		if picked, ok := child.pick(id+"."+strconv.Itoa(i), selected); ok {
+			out.Children = append(out.Children, picked)
+		}
+	}
+	return out, len(out.Requests) > 0 || len(out.Children) > 0
+}
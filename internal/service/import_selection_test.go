@@ -0,0 +1,51 @@
+package service
+
+import "testing"
+
+func TestImportedCollection_Select(t *testing.T) {
+	tree := &ImportedCollection{
+		Name:      "Petstore",
+		Variables: map[string]string{"host": "https://pets.test"},
+		Requests:  []ImportedRequest{{Name: "Ping", Method: "GET"}},
+		Children: []*ImportedCollection{
+			{Name: "Pets", Requests: []ImportedRequest{{Name: "List"}, {Name: "Create"}}},
+			{Name: "Admin", Children: []*ImportedCollection{{Name: "Users", Requests: []ImportedRequest{{Name: "Ban"}}}}},
+		},
+	}
+
+	preview := tree.Preview()
+	if preview.ID != "0" || len(preview.Children) != 3 || preview.Children[0].ID != "0:0" || preview.Children[1].ID != "0.0" {
+		t.Fatalf("preview = %+v", preview)
+	}
+	if users := preview.Children[2].Children[0]; users.ID != "0.1.0" || users.Children[0].ID != "0.1.0:0" {
+		t.Errorf("nested preview = %+v", users)
+	}
+
+	// A request deep in the tree keeps its folders and the root's variables
+	picked, err := tree.Select([]string{"0.1.0:0", "0.0:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if collections, requests := picked.Count(); collections != 4 || requests != 2 || len(picked.Requests) != 0 || picked.Variables["host"] == "" {
+		t.Errorf("picked %d collections, %d requests: %+v", collections, requests, picked)
+	}
+	if picked.Children[0].Requests[0].Name != "Create" || picked.Children[1].Children[0].Requests[0].Name != "Ban" {
+		t.Errorf("picked = %+v", picked)
+	}
+
+	// A selected folder brings everything below it
+	picked, _ = tree.Select([]string{"0.1"})
+	if collections, requests := picked.Count(); collections != 3 || requests != 1 {
+		t.Errorf("folder selection: %d collections, %d requests", collections, requests)
+	}
+	picked, _ = tree.Select([]string{"0"})
+	if picked != tree {
+		t.Error("root selection is not the whole tree")
+	}
+
+	for _, ids := range [][]string{nil, {"1"}, {"0:1"}, {"0.0:01"}, {"0.2"}} {
+		if _, err := tree.Select(ids); err == nil {
+			t.Errorf("%v accepted", ids)
+		}
+	}
+}
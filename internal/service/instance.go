@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"relay/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const instanceHeartbeat = 30 * time.Second
+
+// Lease names of the background jobs
+const (
+	LeaseMonitors             = "monitors"
+	LeaseWeeklyDigest         = "weekly-digest"
+	LeaseHistoryRetention     = "history-retention"
+	LeaseHistorySearch        = "history-search"
+	LeaseEnvironmentRotations = "environment-rotations"
+	LeaseTokenRefreshers      = "token-refreshers"
+	LeaseDriftMonitors        = "drift-monitors"
+)
+
+// Instance is this server process as seen by other Relay instances sharing
+// the database, e.g. while an old container is still shutting down and the
+// new one has started. Background jobs take a lease before each run so only
+// one instance fires them; a lease not renewed within its TTL (the holder
+// crashed or stopped) is taken over by the next instance that asks.
+type Instance struct {
+	queries *repository.Queries
+	ID      string
+}
+
+func NewInstance(queries *repository.Queries) *Instance {
+	return &Instance{queries: queries, ID: uuid.NewString()}
+}
+
+// Start registers the instance and keeps its heartbeat fresh until ctx is
+// cancelled. Instances without a heartbeat for five minutes are forgotten.
+func (i *Instance) Start(ctx context.Context) error {
+	host, _ := os.Hostname()
+	if err := i.queries.RegisterInstance(ctx, repository.RegisterInstanceParams{
+		ID:       i.ID,
+		Hostname: host,
+		Pid:      int64(os.Getpid()),
+	}); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(instanceHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := i.queries.HeartbeatInstance(ctx, i.ID); err != nil {
+					log.Printf("instance: heartbeat failed: %v", err)
+				}
+				i.queries.DeleteStaleInstances(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+// Acquire takes or renews the lease on job for ttl and reports whether this
+// instance holds it. Expiry is judged by each instance's own clock, so ttl
+// should comfortably exceed both the job's run time and any clock skew. A
+// nil Instance holds every lease, for single-instance use and tests.
+func (i *Instance) Acquire(ctx context.Context, job string, ttl time.Duration) bool {
+	if i == nil {
+		return true
+	}
+	now := time.Now()
+	n, err := i.queries.AcquireJobLease(ctx, repository.AcquireJobLeaseParams{
+		Name:      job,
+		Holder:    i.ID,
+		ExpiresAt: now.Add(ttl).UnixMilli(),
+		Now:       now.UnixMilli(),
+	})
+	if err != nil {
+		log.Printf("instance: lease %s: %v", job, err)
+		return false
+	}
+	return n > 0
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"relay/internal/testutil"
+)
+
+func TestInstance_LeaseHandOff(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a, b := NewInstance(q), NewInstance(q)
+	for _, inst := range []*Instance{a, b} {
+		if err := inst.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if instances, _ := q.ListInstances(ctx); len(instances) != 2 {
+		t.Fatalf("registered instances = %+v", instances)
+	}
+
+	if !a.Acquire(ctx, LeaseMonitors, time.Minute) {
+		t.Fatal("first instance should get the free lease")
+	}
+	if b.Acquire(ctx, LeaseMonitors, time.Minute) {
+		t.Error("second instance took a lease that is still held")
+	}
+	if !b.Acquire(ctx, LeaseWeeklyDigest, time.Minute) {
+		t.Error("leases are per job")
+	}
+	if !a.Acquire(ctx, LeaseMonitors, 10*time.Millisecond) {
+		t.Error("holder should renew its own lease")
+	}
+
+	// The holder stops renewing; the lease expires and moves
+	time.Sleep(20 * time.Millisecond)
+	if !b.Acquire(ctx, LeaseMonitors, time.Minute) {
+		t.Error("expired lease was not taken over")
+	}
+	if a.Acquire(ctx, LeaseMonitors, time.Minute) {
+		t.Error("former holder got the lease back while it is held")
+	}
+	leases, _ := q.ListJobLeases(ctx)
+	if len(leases) != 2 || leases[0].Name != LeaseMonitors || leases[0].Holder != b.ID {
+		t.Errorf("leases = %+v", leases)
+	}
+
+	var none *Instance
+	if !none.Acquire(ctx, LeaseMonitors, time.Minute) {
+		t.Error("a nil instance runs every job")
+	}
+}
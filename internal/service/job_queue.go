@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Built-in job types
+const (
+	JobTypeWebhook             = "webhook"
+	JobTypeFileCleanup         = "file-cleanup"
+	JobTypeMonitorCheck        = "monitor-check"
+	JobTypeEnvironmentRotation = "environment-rotation"
+	JobTypeTokenRefresh        = "token-refresh"
+	JobTypeDriftCheck          = "drift-check"
+	JobTypeHistoryRetention    = "history-retention"
+)
+
+// ErrJobPending is returned by Enqueue when a job with the same key is
+// already queued or running
+var ErrJobPending = errors.New("a job with this key is already queued or running")
+
+const (
+	jobPoll            = time.Second
+	jobLockTimeout     = 10 * time.Minute // a job still running after this is retried elsewhere
+	jobPruneInterval   = time.Hour
+	defaultJobAttempts = 3
+	maxJobBackoff      = 10 * time.Minute
+)
+
+// JobFunc runs one job. The returned value is stored as the job's JSON
+// result; an error schedules a retry until the job runs out of attempts.
+type JobFunc func(ctx context.Context, job repository.Job) (interface{}, error)
+
+// JobQueue is a persistent queue of background work in the jobs table.
+// Claiming a job is a single UPDATE, so any number of workers and instances
+// can share the table. A job whose worker died (crash, restart) is picked up
+// again once its lock expires.
+//
+// Schedulers (monitors, environment rotations, token refreshers, drift
+// monitors, history retention) only decide what is due under their lease and
+// queue it; the work itself runs here. Email digests, the history search
+// indexer and imports are not queued.
+type JobQueue struct {
+	queries  *repository.Queries
+	owner    string
+	handlers map[string]JobFunc
+	wake     chan struct{}
+}
+
+func NewJobQueue(queries *repository.Queries) *JobQueue {
+	jq := &JobQueue{
+		queries:  queries,
+		owner:    uuid.NewString(),
+		handlers: make(map[string]JobFunc),
+		wake:     make(chan struct{}, 1),
+	}
+	jq.Register(JobTypeWebhook, webhookJob(&http.Client{Timeout: 10 * time.Second}))
+	return jq
+}
+
+// SetInstance records the instance ID as the owner of claimed jobs
+func (jq *JobQueue) SetInstance(inst *Instance) {
+	jq.owner = inst.ID
+}
+
+// Register sets the function that runs jobs of jobType. Register everything
+// before Start.
+func (jq *JobQueue) Register(jobType string, fn JobFunc) {
+	jq.handlers[jobType] = fn
+}
+
+// EnqueueOptions are optional settings for a new job
+type EnqueueOptions struct {
+	WorkspaceID int64         // 0 for a server-wide job
+	MaxAttempts int           // default 3
+	Delay       time.Duration // run no earlier than this from now
+	Key         string        // at most one queued or running job per key
+}
+
+// Enqueue stores a job with payload encoded as JSON and wakes a worker
+func (jq *JobQueue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts EnqueueOptions) (repository.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return repository.Job{}, err
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultJobAttempts
+	}
+	job, err := jq.queries.CreateJob(ctx, repository.CreateJobParams{
+		WorkspaceID: sql.NullInt64{Int64: opts.WorkspaceID, Valid: opts.WorkspaceID != 0},
+		Type:        jobType,
+		Payload:     string(raw),
+		MaxAttempts: int64(opts.MaxAttempts),
+		RunAt:       time.Now().Add(opts.Delay).UnixMilli(),
+		DedupeKey:   sql.NullString{String: opts.Key, Valid: opts.Key != ""},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.Job{}, ErrJobPending
+	}
+	if err != nil {
+		return repository.Job{}, err
+	}
+	select {
+	case jq.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Start runs workers until ctx is cancelled and prunes jobs finished more
+// than a week ago
+func (jq *JobQueue) Start(ctx context.Context, workers int) {
+	for w := 0; w < workers; w++ {
+		go func() {
+			ticker := time.NewTicker(jobPoll)
+			defer ticker.Stop()
+			for {
+				for jq.RunNext(ctx) {
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				case <-jq.wake:
+				}
+			}
+		}()
+	}
+	go func() {
+		ticker := time.NewTicker(jobPruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := jq.queries.DeleteFinishedJobs(ctx); err != nil {
+					log.Printf("jobs: failed to prune: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// RunNext claims the next due job and runs it. It reports whether there was one.
+func (jq *JobQueue) RunNext(ctx context.Context) bool {
+	now := time.Now()
+	job, err := jq.queries.ClaimJob(ctx, repository.ClaimJobParams{
+		LockedBy:    sql.NullString{String: jq.owner, Valid: true},
+		LockedUntil: sql.NullInt64{Int64: now.Add(jobLockTimeout).UnixMilli(), Valid: true},
+		Now:         now.UnixMilli(),
+		StaleBefore: sql.NullInt64{Int64: now.UnixMilli(), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("jobs: failed to claim: %v", err)
+		}
+		return false
+	}
+	jq.run(ctx, job)
+	return true
+}
+
+func (jq *JobQueue) run(ctx context.Context, job repository.Job) {
+	owner := sql.NullString{String: jq.owner, Valid: true}
+	fn, ok := jq.handlers[job.Type]
+	var result interface{}
+	var err error
+	if ok {
+		result, err = jq.call(ctx, fn, job)
+	} else {
+		err = fmt.Errorf("no handler for job type %q", job.Type)
+	}
+
+	if err == nil {
+		raw, _ := json.Marshal(result)
+		if err := jq.queries.CompleteJob(ctx, repository.CompleteJobParams{
+			Result:   sql.NullString{String: string(raw), Valid: result != nil},
+			ID:       job.ID,
+			LockedBy: owner,
+		}); err != nil {
+			log.Printf("jobs: job %d: %v", job.ID, err)
+		}
+		return
+	}
+
+	params := repository.FailJobParams{
+		Status:     JobFailed,
+		LastError:  sql.NullString{String: err.Error(), Valid: true},
+		RunAt:      job.RunAt,
+		FinishedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		ID:         job.ID,
+		LockedBy:   owner,
+	}
+	if ok && job.Attempts < job.MaxAttempts {
+		params.Status = JobQueued
+		params.RunAt = time.Now().Add(jobBackoff(job.Attempts)).UnixMilli()
+		params.FinishedAt = sql.NullTime{}
+	}
+	if err := jq.queries.FailJob(ctx, params); err != nil {
+		log.Printf("jobs: job %d: %v", job.ID, err)
+	}
+}
+
+// call runs fn with the job's workspace in the context, turning a panic into
+// an error so one bad job can't take a worker down
+func (jq *JobQueue) call(ctx context.Context, fn JobFunc, job repository.Job) (result interface{}, err error) {
+	ctx, cancel := context.WithTimeout(ctx, jobLockTimeout)
+	defer cancel()
+	if job.WorkspaceID.Valid {
+		ctx = middleware.WithWorkspaceID(ctx, job.WorkspaceID.Int64)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(ctx, job)
+}
+
+// ScheduledJob is the payload of the jobs schedulers queue for due items:
+// the monitor, rotation, refresher or drift monitor to run
+type ScheduledJob struct {
+	ID int64 `json:"id"`
+}
+
+// enqueueScheduled queues a single-attempt run of a due item, keyed so the
+// next scheduler tick doesn't queue it again while it is pending. A failed
+// run isn't retried by the queue; the item comes due again on its schedule.
+func (jq *JobQueue) enqueueScheduled(ctx context.Context, jobType string, id, workspaceID int64) {
+	_, err := jq.Enqueue(ctx, jobType, ScheduledJob{ID: id}, EnqueueOptions{
+		WorkspaceID: workspaceID,
+		MaxAttempts: 1,
+		Key:         fmt.Sprintf("%s:%d", jobType, id),
+	})
+	if err != nil && !errors.Is(err, ErrJobPending) {
+		log.Printf("jobs: failed to queue %s %d: %v", jobType, id, err)
+	}
+}
+
+// loadScheduled fetches the item a ScheduledJob names; ok is false when the
+// item was deleted after the job was queued
+func loadScheduled[T any](ctx context.Context, job repository.Job, get func(context.Context, int64) (T, error)) (item T, ok bool, err error) {
+	var p ScheduledJob
+	if err := json.Unmarshal([]byte(job.Payload), &p); err != nil {
+		return item, false, err
+	}
+	item, err = get(ctx, p.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return item, false, nil
+	}
+	return item, err == nil, err
+}
+
+// jobBackoff is the wait before retry n+1: 10s, 20s, 40s... up to 10 minutes
+func jobBackoff(attempts int64) time.Duration {
+	d := 5 * time.Second
+	for i := int64(0); i < attempts && d < maxJobBackoff; i++ {
+		d *= 2
+	}
+	if d > maxJobBackoff {
+		d = maxJobBackoff
+	}
+	return d
+}
+
+// WebhookJob is the payload of a webhook job: Body is POSTed to URL as JSON
+type WebhookJob struct {
+	URL  string          `json:"url"`
+	Body json.RawMessage `json:"body"`
+}
+
+func webhookJob(client *http.Client) JobFunc {
+	return func(ctx context.Context, job repository.Job) (interface{}, error) {
+		var p WebhookJob
+		if err := json.Unmarshal([]byte(job.Payload), &p); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(p.Body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		}
+		return map[string]int{"statusCode": resp.StatusCode}, nil
+	}
+}
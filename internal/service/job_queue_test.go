@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestJobQueue_RetriesThenSucceeds(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	jq := NewJobQueue(q)
+	ctx := context.Background()
+
+	calls := 0
+	jq.Register("flaky", func(ctx context.Context, job repository.Job) (interface{}, error) {
+		calls++
+		if middleware.GetWorkspaceID(ctx) != 1 {
+			t.Errorf("job ran in workspace %d", middleware.GetWorkspaceID(ctx))
+		}
+		if calls == 1 {
+			return nil, errors.New("temporarily down")
+		}
+		return map[string]int{"calls": calls}, nil
+	})
+
+	job, err := jq.Enqueue(ctx, "flaky", map[string]string{"k": "v"}, EnqueueOptions{WorkspaceID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !jq.RunNext(ctx) {
+		t.Fatal("due job was not claimed")
+	}
+	job, _ = q.GetJob(ctx, job.ID)
+	if job.Status != JobQueued || job.Attempts != 1 || job.LastError.String != "temporarily down" || job.RunAt <= time.Now().UnixMilli() {
+		t.Fatalf("after failure: %+v", job)
+	}
+	if jq.RunNext(ctx) {
+		t.Fatal("job ran again before its backoff")
+	}
+
+	// Skip the backoff
+	db.Exec("UPDATE jobs SET run_at = 0 WHERE id = ?", job.ID)
+	if !jq.RunNext(ctx) {
+		t.Fatal("retry was not claimed")
+	}
+	job, _ = q.GetJob(ctx, job.ID)
+	if job.Status != JobSucceeded || job.Result.String != `{"calls":2}` || job.LastError.Valid || !job.FinishedAt.Valid {
+		t.Errorf("after success: %+v", job)
+	}
+}
+
+func TestJobQueue_FailsAfterLastAttempt(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	jq := NewJobQueue(q)
+	ctx := context.Background()
+	jq.Register("boom", func(ctx context.Context, job repository.Job) (interface{}, error) {
+		panic("bad payload")
+	})
+
+	job, _ := jq.Enqueue(ctx, "boom", nil, EnqueueOptions{MaxAttempts: 1})
+	unknown, _ := jq.Enqueue(ctx, "unknown", nil, EnqueueOptions{})
+	for jq.RunNext(ctx) {
+	}
+
+	job, _ = q.GetJob(ctx, job.ID)
+	if job.Status != JobFailed || job.LastError.String != "job panicked: bad payload" {
+		t.Errorf("panicking job: %+v", job)
+	}
+	unknown, _ = q.GetJob(ctx, unknown.ID)
+	if unknown.Status != JobFailed || unknown.Attempts != 1 {
+		t.Errorf("job without handler should fail at once: %+v", unknown)
+	}
+}
+
+func TestJobQueue_ReclaimsStaleJobs(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	crashed, survivor := NewJobQueue(q), NewJobQueue(q)
+
+	job, _ := crashed.Enqueue(ctx, JobTypeWebhook, WebhookJob{}, EnqueueOptions{})
+	// A worker claims the job and dies; its lock has run out
+	now := time.Now().UnixMilli()
+	q.ClaimJob(ctx, repository.ClaimJobParams{
+		LockedBy:    sql.NullString{String: crashed.owner, Valid: true},
+		LockedUntil: sql.NullInt64{Int64: now - 1, Valid: true},
+		Now:         now,
+		StaleBefore: sql.NullInt64{Int64: now, Valid: true},
+	})
+
+	var got WebhookJob
+	survivor.Register(JobTypeWebhook, func(ctx context.Context, job repository.Job) (interface{}, error) {
+		return nil, json.Unmarshal([]byte(job.Payload), &got)
+	})
+	if !survivor.RunNext(ctx) {
+		t.Fatal("stale job was not reclaimed")
+	}
+	job, _ = q.GetJob(ctx, job.ID)
+	if job.Status != JobSucceeded || job.Attempts != 2 {
+		t.Errorf("reclaimed job: %+v", job)
+	}
+}
+
+func TestJobQueue_KeyedJobs(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	jq := NewJobQueue(q)
+	ctx := context.Background()
+	jq.Register("keyed", func(ctx context.Context, job repository.Job) (interface{}, error) {
+		return nil, errors.New("down")
+	})
+
+	first, err := jq.Enqueue(ctx, "keyed", nil, EnqueueOptions{Key: "k", MaxAttempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := jq.Enqueue(ctx, "keyed", nil, EnqueueOptions{Key: "k"}); !errors.Is(err, ErrJobPending) {
+		t.Fatalf("duplicate enqueue: err = %v, want ErrJobPending", err)
+	}
+	if _, err := jq.Enqueue(ctx, "keyed", nil, EnqueueOptions{Key: "other", MaxAttempts: 1}); err != nil {
+		t.Fatalf("other key: %v", err)
+	}
+	for jq.RunNext(ctx) {
+	}
+
+	// Once the job finished the key is free again
+	second, err := jq.Enqueue(ctx, "keyed", nil, EnqueueOptions{Key: "k"})
+	if err != nil {
+		t.Fatalf("enqueue after failure: %v", err)
+	}
+	// ...so the failed job can't be retried next to the pending one
+	if _, err := q.RetryJob(ctx, repository.RetryJobParams{RunAt: time.Now().UnixMilli(), ID: first.ID}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("retry beside a pending job: err = %v", err)
+	}
+	q.CancelJob(ctx, second.ID)
+	if _, err := q.RetryJob(ctx, repository.RetryJobParams{RunAt: time.Now().UnixMilli(), ID: first.ID}); err != nil {
+		t.Errorf("retry once the key is free: %v", err)
+	}
+}
+
+func TestJobQueue_Webhook(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("content type %q", r.Header.Get("Content-Type"))
+		}
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	jq := NewJobQueue(q)
+	ctx := context.Background()
+	job, _ := jq.Enqueue(ctx, JobTypeWebhook, WebhookJob{URL: ts.URL, Body: json.RawMessage(`{"drifted":true}`)}, EnqueueOptions{})
+	jq.RunNext(ctx)
+
+	job, _ = q.GetJob(ctx, job.ID)
+	if job.Status != JobSucceeded || body != `{"drifted":true}` {
+		t.Errorf("job %+v, delivered %q", job, body)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -46,9 +47,20 @@ type JSScriptContext struct {
 	RequestHeaders map[string]string
 	RequestBody    string
 
+	// Bundled libraries enabled for require() (workspace settings)
+	ScriptLibraries []string
+
+	// Clock is the time seen by Date and {{$timestamp}}-style variables
+	// (frozen-clock runs); nil uses the real clock
+	Clock func() time.Time
+	// random backs Math.random() and {{$randomInt}}-style variables in
+	// seeded runs; nil is true randomness
+	random *runRandom
+
 	// HTTP client for pm.sendRequest
 	HTTPClientFunc func(method, url string, headers map[string]string, body string) (int, string, map[string]string, error)
 	SendRequestCount int // Track number of sendRequest calls
+	MaxSendRequests  int // Per-script cap on sendRequest calls (0 = MaxSendRequests)
 }
 
 // JSScriptResult holds the result of JavaScript script execution
@@ -58,6 +70,7 @@ type JSScriptResult struct {
 	ErrorDetails     []ErrorDetail     `json:"errorDetails,omitempty"`
 	AssertionsPassed int               `json:"assertionsPassed"`
 	AssertionsFailed int               `json:"assertionsFailed"`
+	Tests            []TestResult      `json:"tests,omitempty"`          // pm.test results in call order
 	UpdatedEnvVars   map[string]string `json:"updatedEnvVars,omitempty"` // For DB persistence
 	UpdatedVars      map[string]string `json:"updatedVars,omitempty"`    // Runtime variables
 	FlowAction       FlowAction        `json:"flowAction"`
@@ -69,8 +82,22 @@ type JSScriptResult struct {
 
 	// Collection variable updates
 	UpdatedCollectionVars map[string]string `json:"updatedCollectionVars,omitempty"`
+
+	// Visualization set by pm.visualizer.set
+	Visualization *ScriptVisualization `json:"visualization,omitempty"`
 }
 
+// ScriptVisualization is what pm.visualizer.set captured: a Handlebars
+// template and the data to render it with, for the client to display
+type ScriptVisualization struct {
+	Template string          `json:"template"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Options  json.RawMessage `json:"options,omitempty"` // Handlebars compile options
+}
+
+// maxVisualizationSize caps the template plus its JSON data
+const maxVisualizationSize = 1 << 20
+
 // TestResult represents a single test result from pm.test()
 type TestResult struct {
 	Name   string `json:"name"`
@@ -122,6 +149,13 @@ func (jse *JSScriptExecutor) Execute(script string, jsCtx *JSScriptContext) *JSS
 	})
 	defer timer.Stop()
 
+	if jsCtx.Clock != nil {
+		vm.SetTimeSource(jsCtx.Clock)
+	}
+	if jsCtx.random != nil {
+		vm.SetRandSource(jsCtx.random.Float64)
+	}
+
 	// Disable dangerous functions
 	jse.setupSandbox(vm)
 
@@ -131,6 +165,9 @@ func (jse *JSScriptExecutor) Execute(script string, jsCtx *JSScriptContext) *JSS
 	// Set up console.log
 	jse.setupConsole(vm)
 
+	// Set up require() for allow-listed libraries
+	jse.setupRequire(vm, jsCtx.ScriptLibraries)
+
 	// Compile the script first to catch syntax errors with location info
 	prog, compileErr := goja.Compile("script", resolvedScript, true)
 	if compileErr != nil {
@@ -237,6 +274,17 @@ func (jse *JSScriptExecutor) resolveVariables(script string, jsCtx *JSScriptCont
 			return val
 		}
 
+		// Built-in clock variables ($timestamp, $date ...)
+		if strings.HasPrefix(varName, "$") && jse.variableResolver != nil {
+			env := builtinEnv{now: jse.variableResolver.now, random: jsCtx.random}
+			if jsCtx.Clock != nil {
+				env.now = jsCtx.Clock
+			}
+			if val, ok := jse.variableResolver.resolveBuiltin(varName, env); ok {
+				return val
+			}
+		}
+
 		return match // Keep original if not found
 	})
 }
@@ -532,6 +580,27 @@ func (jse *JSScriptExecutor) setupPmAPI(vm *goja.Runtime, jsCtx *JSScriptContext
 		return vm.ToValue(jsCtx.ResponseBody)
 	})
 
+	// pm.response.parts() - parts of a multipart response ([] otherwise)
+	response.Set("parts", func(call goja.FunctionCall) goja.Value {
+		contentType := ""
+		for k, v := range jsCtx.Headers {
+			if strings.EqualFold(k, "Content-Type") {
+				contentType = v
+			}
+		}
+		parts := []interface{}{}
+		if isMultipartResponse(contentType) {
+			parsed, err := ParseMultipartResponse(contentType, []byte(jsCtx.ResponseBody))
+			if err != nil {
+				panic(vm.ToValue("Failed to parse multipart response: " + err.Error()))
+			}
+			for _, p := range parsed {
+				parts = append(parts, jse.responsePartObject(vm, p))
+			}
+		}
+		return vm.ToValue(parts)
+	})
+
 	// pm.response.code
 	response.Set("code", vm.ToValue(jsCtx.StatusCode))
 	response.Set("status", vm.ToValue(jsCtx.StatusCode))
@@ -647,6 +716,7 @@ func (jse *JSScriptExecutor) setupPmAPI(vm *goja.Runtime, jsCtx *JSScriptContext
 		}()
 
 		testMutex.Lock()
+		result.Tests = append(result.Tests, testResult)
 		if testResult.Passed {
 			result.AssertionsPassed++
 		} else {
@@ -708,6 +778,36 @@ func (jse *JSScriptExecutor) setupPmAPI(vm *goja.Runtime, jsCtx *JSScriptContext
 	})
 	pm.Set("execution", execution)
 
+	// pm.visualizer - capture a template and data for the client to render
+	visualizer := vm.NewObject()
+	visualizer.Set("set", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 1 {
+			panic(vm.ToValue("pm.visualizer.set requires a template"))
+		}
+		v := &ScriptVisualization{Template: call.Arguments[0].String()}
+		for i, dst := range []*json.RawMessage{&v.Data, &v.Options} {
+			arg := call.Argument(i + 1)
+			if goja.IsUndefined(arg) || goja.IsNull(arg) {
+				continue
+			}
+			b, err := json.Marshal(arg.Export())
+			if err != nil {
+				panic(vm.ToValue("pm.visualizer.set: data must be JSON-serializable"))
+			}
+			*dst = b
+		}
+		if len(v.Template)+len(v.Data)+len(v.Options) > maxVisualizationSize {
+			panic(vm.ToValue("pm.visualizer.set: template and data exceed 1MB"))
+		}
+		result.Visualization = v
+		return goja.Undefined()
+	})
+	visualizer.Set("clear", func(call goja.FunctionCall) goja.Value {
+		result.Visualization = nil
+		return goja.Undefined()
+	})
+	pm.Set("visualizer", visualizer)
+
 	// pm.request - access to the current request (read-only)
 	request := vm.NewObject()
 	request.Set("url", jsCtx.RequestURL)
@@ -752,8 +852,12 @@ func (jse *JSScriptExecutor) setupPmAPI(vm *goja.Runtime, jsCtx *JSScriptContext
 
 		// Check rate limit
 		jsCtx.SendRequestCount++
-		if jsCtx.SendRequestCount > MaxSendRequests {
-			panic(vm.ToValue(fmt.Sprintf("Maximum sendRequest limit (%d) exceeded", MaxSendRequests)))
+		limit := jsCtx.MaxSendRequests
+		if limit <= 0 {
+			limit = MaxSendRequests
+		}
+		if jsCtx.SendRequestCount > limit {
+			panic(vm.ToValue(fmt.Sprintf("Maximum sendRequest limit (%d) exceeded", limit)))
 		}
 
 		// Check if HTTPClientFunc is available
@@ -814,6 +918,9 @@ func (jse *JSScriptExecutor) setupPmAPI(vm *goja.Runtime, jsCtx *JSScriptContext
 
 		// Execute the request
 		statusCode, respBody, respHeaders, err := jsCtx.HTTPClientFunc(method, url, headers, body)
+		if errors.Is(err, ErrScriptRequestLimit) {
+			panic(vm.ToValue(err.Error()))
+		}
 
 		// Get callback if provided (second argument)
 		if len(call.Arguments) >= 2 {
@@ -1128,6 +1235,45 @@ func (jse *JSScriptExecutor) deepEqual(a, b interface{}) bool {
 	return string(aJSON) == string(bJSON)
 }
 
+// responsePartObject exposes a multipart response part to scripts with the
+// same text()/json()/headers.get() accessors as pm.response
+func (jse *JSScriptExecutor) responsePartObject(vm *goja.Runtime, p ResponsePart) *goja.Object {
+	obj := vm.NewObject()
+	obj.Set("name", vm.ToValue(p.Name))
+	obj.Set("filename", vm.ToValue(p.Filename))
+	obj.Set("contentType", vm.ToValue(p.ContentType))
+	obj.Set("size", vm.ToValue(p.Size))
+	obj.Set("bodyBase64", vm.ToValue(p.BodyBase64))
+	obj.Set("text", func(call goja.FunctionCall) goja.Value {
+		return vm.ToValue(p.Body)
+	})
+	obj.Set("json", func(call goja.FunctionCall) goja.Value {
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(p.Body), &parsed); err != nil {
+			return goja.Undefined()
+		}
+		return vm.ToValue(parsed)
+	})
+	headers := vm.NewObject()
+	for k, v := range p.Headers {
+		headers.Set(strings.ToLower(k), vm.ToValue(v))
+	}
+	headers.Set("get", func(call goja.FunctionCall) goja.Value {
+		if len(call.Arguments) < 1 {
+			return goja.Undefined()
+		}
+		name := call.Arguments[0].String()
+		for k, v := range p.Headers {
+			if strings.EqualFold(k, name) {
+				return vm.ToValue(v)
+			}
+		}
+		return goja.Undefined()
+	})
+	obj.Set("headers", headers)
+	return obj
+}
+
 // setupConsole sets up console.log
 func (jse *JSScriptExecutor) setupConsole(vm *goja.Runtime) {
 	console := vm.NewObject()
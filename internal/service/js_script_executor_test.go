@@ -210,6 +210,9 @@ func TestJSExecutor_PmTestFailure(t *testing.T) {
 	if result.AssertionsFailed != 1 {
 		t.Errorf("Expected 1 assertion failed, got %d", result.AssertionsFailed)
 	}
+	if len(result.Tests) != 1 || result.Tests[0].Name != "Status is 200" || result.Tests[0].Passed || result.Tests[0].Error == "" {
+		t.Errorf("Tests = %+v", result.Tests)
+	}
 }
 
 func TestJSExecutor_ForEachLoop(t *testing.T) {
@@ -1386,3 +1389,42 @@ func TestJSExecutor_VarWritePriorityOrder(t *testing.T) {
 		t.Errorf("Expected UpdatedEnvVars sharedKey=env_value, got %v", result.UpdatedEnvVars["sharedKey"])
 	}
 }
+
+func TestJSExecutor_Visualizer(t *testing.T) {
+	executor := NewJSScriptExecutor(nil)
+	newCtx := func() *JSScriptContext {
+		return &JSScriptContext{
+			RuntimeVars:      make(map[string]string),
+			EnvVars:          make(map[string]string),
+			ResponseBody:     `{"users":[{"name":"alice","age":30},{"name":"bob","age":25}]}`,
+			StatusCode:       200,
+			PendingEnvWrites: make(map[string]string),
+		}
+	}
+
+	script := `
+		var template = '<table>{{#each users}}<tr><td>{{name}}</td><td>{{age}}</td></tr>{{/each}}</table>';
+		pm.visualizer.set(template, { users: pm.response.json().users }, { noEscape: true });
+	`
+	result := executor.Execute(script, newCtx())
+	if !result.Success {
+		t.Fatalf("errors: %v", result.Errors)
+	}
+	v := result.Visualization
+	if v == nil || !strings.HasPrefix(v.Template, "<table>{{#each users}}") {
+		t.Fatalf("visualization = %+v", v)
+	}
+	if string(v.Data) != `{"users":[{"age":30,"name":"alice"},{"age":25,"name":"bob"}]}` || string(v.Options) != `{"noEscape":true}` {
+		t.Errorf("data = %s, options = %s", v.Data, v.Options)
+	}
+
+	result = executor.Execute(`pm.visualizer.set("<p>x</p>"); pm.visualizer.clear();`, newCtx())
+	if !result.Success || result.Visualization != nil {
+		t.Errorf("after clear: %+v, %v", result.Visualization, result.Errors)
+	}
+
+	result = executor.Execute(`pm.visualizer.set("x", { big: "a".repeat(1100000) });`, newCtx())
+	if result.Success || result.Visualization != nil || !strings.Contains(result.Errors[0], "exceed 1MB") {
+		t.Errorf("oversized: %v", result.Errors)
+	}
+}
@@ -0,0 +1,657 @@
+/*
+ * relay-utils: collection and object helpers for Relay scripts.
+ *
+ * A small hand-written utility module, not a lodash build. Function names and
+ * argument order follow the familiar underscore/lodash conventions, and
+ * iteratees accept a function, a property path string, a [path, value] pair,
+ * or a partial-match object. Functions not listed here are not available.
+ * Scripts may also load it as require('lodash'), which covers the lodash
+ * functions Postman scripts commonly use.
+ */
+module.exports = (function () {
+  'use strict';
+
+  var _ = {};
+
+  var hasOwn = Object.prototype.hasOwnProperty;
+  var toStr = Object.prototype.toString;
+
+  // ---- type checks -------------------------------------------------------
+
+  _.isArray = Array.isArray;
+  _.isFunction = function (v) { return typeof v === 'function'; };
+  _.isString = function (v) { return typeof v === 'string'; };
+  _.isNumber = function (v) { return typeof v === 'number'; };
+  _.isBoolean = function (v) { return v === true || v === false; };
+  _.isNil = function (v) { return v === null || v === undefined; };
+  _.isNull = function (v) { return v === null; };
+  _.isUndefined = function (v) { return v === undefined; };
+  _.isObject = function (v) { return v !== null && (typeof v === 'object' || typeof v === 'function'); };
+  _.isObjectLike = function (v) { return v !== null && typeof v === 'object'; };
+  _.isPlainObject = function (v) {
+    if (!_.isObjectLike(v) || toStr.call(v) !== '[object Object]') return false;
+    var proto = Object.getPrototypeOf(v);
+    return proto === null || proto === Object.prototype;
+  };
+  _.isDate = function (v) { return toStr.call(v) === '[object Date]'; };
+  _.isRegExp = function (v) { return toStr.call(v) === '[object RegExp]'; };
+  _.isInteger = Number.isInteger;
+  _.isNaN = function (v) { return typeof v === 'number' && v !== v; };
+  _.isFinite = Number.isFinite;
+
+  _.isEmpty = function (v) {
+    if (_.isNil(v)) return true;
+    if (_.isArray(v) || typeof v === 'string') return v.length === 0;
+    if (_.isObjectLike(v)) return Object.keys(v).length === 0;
+    return true;
+  };
+
+  _.isEqual = function isEqual(a, b) {
+    if (a === b) return a !== 0 || 1 / a === 1 / b;
+    if (a !== a && b !== b) return true;
+    if (!_.isObjectLike(a) || !_.isObjectLike(b)) return false;
+    if (toStr.call(a) !== toStr.call(b)) return false;
+    if (_.isDate(a)) return a.getTime() === b.getTime();
+    if (_.isRegExp(a)) return String(a) === String(b);
+    if (_.isArray(a)) {
+      if (a.length !== b.length) return false;
+      for (var i = 0; i < a.length; i++) if (!isEqual(a[i], b[i])) return false;
+      return true;
+    }
+    var ka = Object.keys(a), kb = Object.keys(b);
+    if (ka.length !== kb.length) return false;
+    for (var j = 0; j < ka.length; j++) {
+      if (!hasOwn.call(b, ka[j]) || !isEqual(a[ka[j]], b[ka[j]])) return false;
+    }
+    return true;
+  };
+
+  // ---- paths -------------------------------------------------------------
+
+  function toPath(path) {
+    if (_.isArray(path)) return path.slice();
+    if (typeof path === 'number') return [path];
+    var result = [];
+    String(path).replace(/[^.[\]]+|\[(?:(-?\d+)|(["'])((?:(?!\2)[^\\]|\\.)*?)\2)\]/g, function (m, num, quote, str) {
+      result.push(quote ? str : (num !== undefined ? num : m));
+    });
+    return result;
+  }
+  _.toPath = toPath;
+
+  _.get = function (obj, path, defaultValue) {
+    var keys = toPath(path), cur = obj;
+    for (var i = 0; i < keys.length; i++) {
+      if (cur === null || cur === undefined) return defaultValue;
+      cur = cur[keys[i]];
+    }
+    return cur === undefined ? defaultValue : cur;
+  };
+
+  _.has = function (obj, path) {
+    var keys = toPath(path), cur = obj;
+    for (var i = 0; i < keys.length; i++) {
+      if (cur === null || cur === undefined || !hasOwn.call(Object(cur), keys[i])) return false;
+      cur = cur[keys[i]];
+    }
+    return keys.length > 0;
+  };
+
+  _.set = function (obj, path, value) {
+    if (!_.isObject(obj)) return obj;
+    var keys = toPath(path), cur = obj;
+    for (var i = 0; i < keys.length - 1; i++) {
+      var k = keys[i];
+      if (!_.isObject(cur[k])) cur[k] = /^\d+$/.test(keys[i + 1]) ? [] : {};
+      cur = cur[k];
+    }
+    cur[keys[keys.length - 1]] = value;
+    return obj;
+  };
+
+  _.unset = function (obj, path) {
+    var keys = toPath(path), parent = _.get(obj, keys.slice(0, -1));
+    if (keys.length === 1) parent = obj;
+    if (_.isObject(parent)) return delete parent[keys[keys.length - 1]];
+    return true;
+  };
+
+  // ---- iteratees ---------------------------------------------------------
+
+  _.identity = function (v) { return v; };
+  _.property = function (path) { return function (o) { return _.get(o, path); }; };
+  _.matches = function (src) {
+    return function (o) {
+      for (var k in src) {
+        if (hasOwn.call(src, k)) {
+          var sv = src[k], ov = o === null || o === undefined ? undefined : o[k];
+          if (_.isPlainObject(sv) ? !_.matches(sv)(ov) : !_.isEqual(sv, ov)) return false;
+        }
+      }
+      return true;
+    };
+  };
+  _.matchesProperty = function (path, value) {
+    return function (o) { return _.isEqual(_.get(o, path), value); };
+  };
+
+  function iteratee(fn) {
+    if (fn === null || fn === undefined) return _.identity;
+    if (typeof fn === 'function') return fn;
+    if (_.isArray(fn)) return _.matchesProperty(fn[0], fn[1]);
+    if (typeof fn === 'object') return _.matches(fn);
+    return _.property(fn);
+  }
+  _.iteratee = iteratee;
+
+  function entries(coll) {
+    if (_.isArray(coll) || typeof coll === 'string') {
+      var out = [];
+      for (var i = 0; i < coll.length; i++) out.push([i, coll[i]]);
+      return out;
+    }
+    if (!_.isObjectLike(coll)) return [];
+    return Object.keys(coll).map(function (k) { return [k, coll[k]]; });
+  }
+
+  // ---- collections -------------------------------------------------------
+
+  _.forEach = _.each = function (coll, fn) {
+    var e = entries(coll);
+    for (var i = 0; i < e.length; i++) if (fn(e[i][1], e[i][0], coll) === false) break;
+    return coll;
+  };
+
+  _.map = function (coll, fn) {
+    var f = iteratee(fn);
+    return entries(coll).map(function (e) { return f(e[1], e[0], coll); });
+  };
+
+  _.filter = function (coll, fn) {
+    var f = iteratee(fn);
+    return entries(coll).filter(function (e) { return f(e[1], e[0], coll); }).map(function (e) { return e[1]; });
+  };
+
+  _.reject = function (coll, fn) {
+    var f = iteratee(fn);
+    return _.filter(coll, function (v, k, c) { return !f(v, k, c); });
+  };
+
+  _.find = function (coll, fn) {
+    var f = iteratee(fn), e = entries(coll);
+    for (var i = 0; i < e.length; i++) if (f(e[i][1], e[i][0], coll)) return e[i][1];
+    return undefined;
+  };
+
+  _.findIndex = function (arr, fn) {
+    var f = iteratee(fn);
+    for (var i = 0; i < (arr || []).length; i++) if (f(arr[i], i, arr)) return i;
+    return -1;
+  };
+
+  _.findLast = function (coll, fn) {
+    var f = iteratee(fn), e = entries(coll);
+    for (var i = e.length - 1; i >= 0; i--) if (f(e[i][1], e[i][0], coll)) return e[i][1];
+    return undefined;
+  };
+
+  _.some = function (coll, fn) {
+    var f = iteratee(fn);
+    return entries(coll).some(function (e) { return f(e[1], e[0], coll); });
+  };
+
+  _.every = function (coll, fn) {
+    var f = iteratee(fn);
+    return entries(coll).every(function (e) { return f(e[1], e[0], coll); });
+  };
+
+  _.reduce = function (coll, fn, acc) {
+    var e = entries(coll), i = 0;
+    if (arguments.length < 3) { acc = e.length ? e[0][1] : undefined; i = 1; }
+    for (; i < e.length; i++) acc = fn(acc, e[i][1], e[i][0], coll);
+    return acc;
+  };
+
+  _.includes = function (coll, value) {
+    if (typeof coll === 'string') return coll.indexOf(value) !== -1;
+    return _.some(coll, function (v) { return _.isEqual(v, value); });
+  };
+
+  _.size = function (coll) {
+    if (_.isNil(coll)) return 0;
+    if (_.isArray(coll) || typeof coll === 'string') return coll.length;
+    return Object.keys(coll).length;
+  };
+
+  function grouper(setter) {
+    return function (coll, fn) {
+      var f = iteratee(fn), out = {};
+      _.forEach(coll, function (v) { setter(out, String(f(v)), v); });
+      return out;
+    };
+  }
+  _.groupBy = grouper(function (o, k, v) { (hasOwn.call(o, k) ? o[k] : (o[k] = [])).push(v); });
+  _.keyBy = grouper(function (o, k, v) { o[k] = v; });
+  _.countBy = grouper(function (o, k) { o[k] = (o[k] || 0) + 1; });
+
+  _.partition = function (coll, fn) {
+    var f = iteratee(fn), pass = [], fail = [];
+    _.forEach(coll, function (v) { (f(v) ? pass : fail).push(v); });
+    return [pass, fail];
+  };
+
+  function compareAsc(a, b) {
+    if (a === b) return 0;
+    if (a === undefined) return 1;
+    if (b === undefined) return -1;
+    return a > b ? 1 : a < b ? -1 : 0;
+  }
+
+  _.orderBy = function (coll, fns, orders) {
+    fns = _.isArray(fns) ? fns : [fns === undefined ? _.identity : fns];
+    orders = _.isArray(orders) ? orders : (orders === undefined ? [] : [orders]);
+    var fs = fns.map(iteratee);
+    return entries(coll).map(function (e, i) { return { v: e[1], i: i }; }).sort(function (x, y) {
+      for (var j = 0; j < fs.length; j++) {
+        var c = compareAsc(fs[j](x.v), fs[j](y.v));
+        if (c !== 0) return orders[j] === 'desc' ? -c : c;
+      }
+      return x.i - y.i;
+    }).map(function (w) { return w.v; });
+  };
+
+  _.sortBy = function (coll) {
+    var fns = Array.prototype.slice.call(arguments, 1);
+    if (fns.length === 1 && _.isArray(fns[0])) fns = fns[0];
+    return _.orderBy(coll, fns.length ? fns : [_.identity]);
+  };
+
+  _.sample = function (coll) {
+    var v = _.values(coll);
+    return v.length ? v[Math.floor(Math.random() * v.length)] : undefined;
+  };
+
+  _.shuffle = function (coll) {
+    var v = _.values(coll).slice();
+    for (var i = v.length - 1; i > 0; i--) {
+      var j = Math.floor(Math.random() * (i + 1)), t = v[i];
+      v[i] = v[j]; v[j] = t;
+    }
+    return v;
+  };
+
+  // ---- arrays ------------------------------------------------------------
+
+  _.head = _.first = function (arr) { return arr && arr.length ? arr[0] : undefined; };
+  _.last = function (arr) { return arr && arr.length ? arr[arr.length - 1] : undefined; };
+  _.tail = function (arr) { return (arr || []).slice(1); };
+  _.initial = function (arr) { return (arr || []).slice(0, -1); };
+  _.take = function (arr, n) { return (arr || []).slice(0, n === undefined ? 1 : Math.max(n, 0)); };
+  _.drop = function (arr, n) { return (arr || []).slice(n === undefined ? 1 : Math.max(n, 0)); };
+  _.compact = function (arr) { return (arr || []).filter(Boolean); };
+  _.concat = function () { return Array.prototype.concat.apply([], arguments); };
+
+  _.chunk = function (arr, size) {
+    size = Math.max(size === undefined ? 1 : Math.floor(size), 0);
+    var out = [];
+    if (!size) return out;
+    for (var i = 0; i < (arr || []).length; i += size) out.push(arr.slice(i, i + size));
+    return out;
+  };
+
+  _.flatten = function (arr) { return Array.prototype.concat.apply([], arr || []); };
+  _.flattenDeep = function flattenDeep(arr) {
+    return (arr || []).reduce(function (acc, v) { return acc.concat(_.isArray(v) ? flattenDeep(v) : v); }, []);
+  };
+
+  _.uniqBy = function (arr, fn) {
+    var f = iteratee(fn), seen = [], out = [];
+    (arr || []).forEach(function (v) {
+      var k = f(v);
+      if (!seen.some(function (s) { return s === k || (s !== s && k !== k); })) {
+        seen.push(k);
+        out.push(v);
+      }
+    });
+    return out;
+  };
+  _.uniq = function (arr) { return _.uniqBy(arr, _.identity); };
+
+  _.difference = function (arr) {
+    var excl = _.flatten(Array.prototype.slice.call(arguments, 1));
+    return (arr || []).filter(function (v) { return excl.indexOf(v) === -1; });
+  };
+  _.without = function (arr) {
+    return _.difference(arr, Array.prototype.slice.call(arguments, 1));
+  };
+  _.intersection = function () {
+    var arrays = Array.prototype.slice.call(arguments);
+    return _.uniq(arrays[0] || []).filter(function (v) {
+      return arrays.every(function (a) { return a.indexOf(v) !== -1; });
+    });
+  };
+  _.union = function () { return _.uniq(_.flatten(Array.prototype.slice.call(arguments))); };
+
+  _.zip = function () {
+    var arrays = Array.prototype.slice.call(arguments);
+    var len = Math.max.apply(null, arrays.map(function (a) { return a.length; }).concat(0));
+    return _.range(len).map(function (i) { return arrays.map(function (a) { return a[i]; }); });
+  };
+  _.fromPairs = function (pairs) {
+    var out = {};
+    (pairs || []).forEach(function (p) { out[p[0]] = p[1]; });
+    return out;
+  };
+  _.toPairs = function (obj) { return entries(obj); };
+
+  _.range = function (start, end, step) {
+    if (end === undefined) { end = start; start = 0; }
+    step = step === undefined ? (start < end ? 1 : -1) : step;
+    var out = [];
+    if (step === 0) return out;
+    for (var i = start; step > 0 ? i < end : i > end; i += step) out.push(i);
+    return out;
+  };
+
+  _.times = function (n, fn) {
+    var f = fn || _.identity, out = [];
+    for (var i = 0; i < n; i++) out.push(f(i));
+    return out;
+  };
+
+  // ---- math --------------------------------------------------------------
+
+  _.sumBy = function (arr, fn) {
+    var f = iteratee(fn);
+    return (arr || []).reduce(function (acc, v) { var x = f(v); return x === undefined ? acc : acc + x; }, 0);
+  };
+  _.sum = function (arr) { return _.sumBy(arr, _.identity); };
+  _.meanBy = function (arr, fn) { return arr && arr.length ? _.sumBy(arr, fn) / arr.length : NaN; };
+  _.mean = function (arr) { return _.meanBy(arr, _.identity); };
+
+  function extremeBy(arr, fn, better) {
+    var f = iteratee(fn), best, bestKey;
+    (arr || []).forEach(function (v) {
+      var k = f(v);
+      if (k !== undefined && k === k && (bestKey === undefined || better(k, bestKey))) { best = v; bestKey = k; }
+    });
+    return best;
+  }
+  _.minBy = function (arr, fn) { return extremeBy(arr, fn, function (a, b) { return a < b; }); };
+  _.maxBy = function (arr, fn) { return extremeBy(arr, fn, function (a, b) { return a > b; }); };
+  _.min = function (arr) { return _.minBy(arr, _.identity); };
+  _.max = function (arr) { return _.maxBy(arr, _.identity); };
+
+  _.clamp = function (n, lower, upper) {
+    if (upper === undefined) { upper = lower; lower = undefined; }
+    if (upper !== undefined) n = n <= upper ? n : upper;
+    if (lower !== undefined) n = n >= lower ? n : lower;
+    return n;
+  };
+  _.inRange = function (n, start, end) {
+    if (end === undefined) { end = start; start = 0; }
+    return n >= Math.min(start, end) && n < Math.max(start, end);
+  };
+  _.random = function (lower, upper, floating) {
+    if (upper === undefined || typeof upper === 'boolean') { floating = upper; upper = lower === undefined ? 1 : lower; lower = 0; }
+    if (floating || lower % 1 || upper % 1) return lower + Math.random() * (upper - lower);
+    return lower + Math.floor(Math.random() * (upper - lower + 1));
+  };
+
+  // ---- objects -----------------------------------------------------------
+
+  _.keys = function (obj) { return _.isObject(obj) ? Object.keys(obj) : []; };
+  _.values = function (obj) { return entries(obj).map(function (e) { return e[1]; }); };
+
+  _.assign = function (target) {
+    target = Object(target);
+    for (var i = 1; i < arguments.length; i++) {
+      var src = arguments[i];
+      if (src) for (var k in src) if (hasOwn.call(src, k)) target[k] = src[k];
+    }
+    return target;
+  };
+  _.extend = _.assign;
+
+  _.defaults = function (target) {
+    target = Object(target);
+    for (var i = 1; i < arguments.length; i++) {
+      var src = arguments[i];
+      if (src) for (var k in src) if (hasOwn.call(src, k) && target[k] === undefined) target[k] = src[k];
+    }
+    return target;
+  };
+
+  _.merge = function merge(target) {
+    target = Object(target);
+    for (var i = 1; i < arguments.length; i++) {
+      var src = arguments[i];
+      if (!src) continue;
+      for (var k in src) {
+        if (!hasOwn.call(src, k)) continue;
+        var sv = src[k], tv = target[k];
+        if ((_.isPlainObject(sv) || _.isArray(sv)) && _.isObjectLike(tv)) {
+          merge(tv, sv);
+        } else if (_.isPlainObject(sv)) {
+          target[k] = merge({}, sv);
+        } else if (_.isArray(sv)) {
+          target[k] = merge([], sv);
+        } else if (sv !== undefined || !hasOwn.call(target, k)) {
+          target[k] = sv;
+        }
+      }
+    }
+    return target;
+  };
+
+  _.pick = function (obj) {
+    var paths = _.flatten(Array.prototype.slice.call(arguments, 1)), out = {};
+    paths.forEach(function (p) { if (_.has(obj, p)) _.set(out, p, _.get(obj, p)); });
+    return out;
+  };
+  _.pickBy = function (obj, fn) {
+    var f = iteratee(fn), out = {};
+    entries(obj).forEach(function (e) { if (f(e[1], e[0])) out[e[0]] = e[1]; });
+    return out;
+  };
+  _.omit = function (obj) {
+    var paths = _.flatten(Array.prototype.slice.call(arguments, 1)), out = _.cloneDeep(obj) || {};
+    paths.forEach(function (p) { _.unset(out, p); });
+    return out;
+  };
+  _.omitBy = function (obj, fn) {
+    var f = iteratee(fn);
+    return _.pickBy(obj, function (v, k) { return !f(v, k); });
+  };
+
+  _.mapValues = function (obj, fn) {
+    var f = iteratee(fn), out = {};
+    entries(obj).forEach(function (e) { out[e[0]] = f(e[1], e[0], obj); });
+    return out;
+  };
+  _.mapKeys = function (obj, fn) {
+    var f = iteratee(fn), out = {};
+    entries(obj).forEach(function (e) { out[f(e[1], e[0], obj)] = e[1]; });
+    return out;
+  };
+  _.invert = function (obj) {
+    var out = {};
+    entries(obj).forEach(function (e) { out[e[1]] = e[0]; });
+    return out;
+  };
+
+  _.clone = function (v) {
+    if (_.isArray(v)) return v.slice();
+    if (_.isDate(v)) return new Date(v.getTime());
+    if (_.isObjectLike(v)) return _.assign({}, v);
+    return v;
+  };
+  _.cloneDeep = function cloneDeep(v) {
+    if (_.isArray(v)) return v.map(cloneDeep);
+    if (_.isDate(v)) return new Date(v.getTime());
+    if (_.isPlainObject(v)) return _.mapValues(v, cloneDeep);
+    return v;
+  };
+
+  // ---- strings -----------------------------------------------------------
+
+  function words(s) {
+    return String(s === null || s === undefined ? '' : s)
+      .replace(/([a-z\d])([A-Z])/g, '$1 $2')
+      .replace(/([A-Z]+)([A-Z][a-z])/g, '$1 $2')
+      .match(/[A-Za-z\d]+/g) || [];
+  }
+  _.words = words;
+  _.capitalize = function (s) { s = String(s || ''); return s.charAt(0).toUpperCase() + s.slice(1).toLowerCase(); };
+  _.upperFirst = function (s) { s = String(s || ''); return s.charAt(0).toUpperCase() + s.slice(1); };
+  _.lowerFirst = function (s) { s = String(s || ''); return s.charAt(0).toLowerCase() + s.slice(1); };
+  _.camelCase = function (s) {
+    return words(s).map(function (w, i) { return i ? _.capitalize(w) : w.toLowerCase(); }).join('');
+  };
+  _.kebabCase = function (s) { return words(s).map(function (w) { return w.toLowerCase(); }).join('-'); };
+  _.snakeCase = function (s) { return words(s).map(function (w) { return w.toLowerCase(); }).join('_'); };
+  _.startCase = function (s) { return words(s).map(_.upperFirst).join(' '); };
+  _.toUpper = function (s) { return String(s || '').toUpperCase(); };
+  _.toLower = function (s) { return String(s || '').toLowerCase(); };
+  _.trim = function (s, chars) {
+    s = String(s === null || s === undefined ? '' : s);
+    if (chars === undefined) return s.trim();
+    var set = chars.split(''), a = 0, b = s.length;
+    while (a < b && set.indexOf(s.charAt(a)) !== -1) a++;
+    while (b > a && set.indexOf(s.charAt(b - 1)) !== -1) b--;
+    return s.slice(a, b);
+  };
+  _.pad = function (s, len, chars) {
+    s = String(s || '');
+    var total = Math.max((len || 0) - s.length, 0), left = Math.floor(total / 2);
+    return _.padStart('', left, chars) + s + _.padEnd('', total - left, chars);
+  };
+  _.padStart = function (s, len, chars) {
+    s = String(s === null || s === undefined ? '' : s);
+    chars = chars === undefined ? ' ' : String(chars);
+    while (chars && s.length < len) s = chars.slice(0, len - s.length) + s;
+    return s;
+  };
+  _.padEnd = function (s, len, chars) {
+    s = String(s === null || s === undefined ? '' : s);
+    chars = chars === undefined ? ' ' : String(chars);
+    while (chars && s.length < len) s = s + chars.slice(0, len - s.length);
+    return s;
+  };
+  _.repeat = function (s, n) { return new Array(Math.max(n === undefined ? 1 : n, 0) + 1).join(String(s || '')); };
+  _.startsWith = function (s, target, pos) { return String(s || '').indexOf(target, pos || 0) === (pos || 0); };
+  _.endsWith = function (s, target) {
+    s = String(s || '');
+    return s.length >= target.length && s.slice(s.length - target.length) === target;
+  };
+  _.split = function (s, sep, limit) { return String(s || '').split(sep, limit); };
+  _.escapeRegExp = function (s) { return String(s || '').replace(/[\\^$.*+?()[\]{}|]/g, '\\$&'); };
+
+  var escapeMap = { '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' };
+  _.escape = function (s) { return String(s || '').replace(/[&<>"']/g, function (c) { return escapeMap[c]; }); };
+
+  // ---- conversions / misc ------------------------------------------------
+
+  _.toNumber = function (v) { return typeof v === 'number' ? v : Number(v); };
+  _.toString = function (v) { return v === null || v === undefined ? '' : String(v); };
+  _.toArray = function (v) { return _.values(v); };
+  _.castArray = function (v) { return arguments.length === 0 ? [] : (_.isArray(v) ? v : [v]); };
+  _.noop = function () {};
+  _.constant = function (v) { return function () { return v; }; };
+
+  var idCounter = 0;
+  _.uniqueId = function (prefix) { idCounter++; return String(prefix === undefined ? '' : prefix) + idCounter; };
+
+  _.once = function (fn) {
+    var called = false, result;
+    return function () {
+      if (!called) { called = true; result = fn.apply(this, arguments); }
+      return result;
+    };
+  };
+
+  // ---- further lodash names used by Postman scripts ----------------------
+
+  _.forOwn = _.forIn = function (obj, fn) {
+    var e = entries(obj);
+    for (var i = 0; i < e.length; i++) if (fn(e[i][1], e[i][0], obj) === false) break;
+    return obj;
+  };
+  _.flatMap = function (coll, fn) { return _.flatten(_.map(coll, fn)); };
+  _.findKey = function (obj, fn) {
+    var f = iteratee(fn), e = entries(obj);
+    for (var i = 0; i < e.length; i++) if (f(e[i][1], e[i][0], obj)) return e[i][0];
+    return undefined;
+  };
+  _.findLastIndex = function (arr, fn) {
+    var f = iteratee(fn);
+    for (var i = (arr || []).length - 1; i >= 0; i--) if (f(arr[i], i, arr)) return i;
+    return -1;
+  };
+  _.indexOf = function (arr, value, from) { return (arr || []).indexOf(value, from); };
+  _.lastIndexOf = function (arr, value) { return (arr || []).lastIndexOf(value); };
+  _.reverse = function (arr) { return arr ? arr.reverse() : arr; };
+  _.join = function (arr, sep) { return (arr || []).join(sep === undefined ? ',' : sep); };
+  _.nth = function (arr, n) { arr = arr || []; n = n || 0; return arr[n < 0 ? arr.length + n : n]; };
+  _.slice = function (arr, start, end) { return (arr || []).slice(start, end); };
+  _.takeRight = function (arr, n) {
+    n = n === undefined ? 1 : Math.max(n, 0);
+    return n ? (arr || []).slice(-n) : [];
+  };
+  _.dropRight = function (arr, n) {
+    n = n === undefined ? 1 : Math.max(n, 0);
+    return (arr || []).slice(0, Math.max((arr || []).length - n, 0));
+  };
+  _.xor = function () {
+    var all = _.flatten(Array.prototype.slice.call(arguments).map(_.uniq));
+    return _.uniq(all).filter(function (v) { return all.indexOf(v) === all.lastIndexOf(v); });
+  };
+  _.zipObject = function (keys, values) {
+    var out = {};
+    (keys || []).forEach(function (k, i) { out[k] = (values || [])[i]; });
+    return out;
+  };
+  _.unzip = function (arrays) { return _.zip.apply(null, arrays || []); };
+  _.pullAll = function (arr, values) {
+    for (var i = (arr || []).length - 1; i >= 0; i--) if ((values || []).indexOf(arr[i]) !== -1) arr.splice(i, 1);
+    return arr;
+  };
+  _.pull = function (arr) { return _.pullAll(arr, Array.prototype.slice.call(arguments, 1)); };
+  _.remove = function (arr, fn) {
+    var f = iteratee(fn), removed = [];
+    for (var i = 0; i < (arr || []).length; i++) {
+      if (f(arr[i], i, arr)) { removed.push(arr[i]); arr.splice(i--, 1); }
+    }
+    return removed;
+  };
+  _.isMatch = function (obj, source) { return _.matches(source)(obj); };
+  _.isError = function (v) { return v instanceof Error; };
+  _.assignIn = _.assign;
+
+  function rounder(method) {
+    return function (n, precision) {
+      var p = Math.pow(10, precision || 0);
+      return Math[method](n * p) / p;
+    };
+  }
+  _.round = rounder('round');
+  _.floor = rounder('floor');
+  _.ceil = rounder('ceil');
+  _.toInteger = function (v) { var n = _.toNumber(v); return n === n && isFinite(n) ? Math.trunc(n) : (n === n ? n : 0); };
+  _.parseInt = function (s, radix) { return parseInt(String(s).trim(), radix || 10); };
+
+  _.lowerCase = function (s) { return words(s).map(function (w) { return w.toLowerCase(); }).join(' '); };
+  _.upperCase = function (s) { return words(s).map(function (w) { return w.toUpperCase(); }).join(' '); };
+  _.replace = function (s, pattern, replacement) { return String(s || '').replace(pattern, replacement); };
+  _.truncate = function (s, options) {
+    s = String(s || '');
+    var length = options && options.length !== undefined ? options.length : 30;
+    var omission = options && options.omission !== undefined ? options.omission : '...';
+    if (s.length <= length) return s;
+    return s.slice(0, Math.max(length - omission.length, 0)) + omission;
+  };
+  var unescapeMap = { '&amp;': '&', '&lt;': '<', '&gt;': '>', '&quot;': '"', '&#39;': "'" };
+  _.unescape = function (s) { return String(s || '').replace(/&(?:amp|lt|gt|quot|#39);/g, function (c) { return unescapeMap[c]; }); };
+
+  return _;
+})();
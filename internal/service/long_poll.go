@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Long polling keeps an execution open past the usual 60s client timeout
+// for APIs that hold the connection until they have something to say. While
+// it waits, the caller gets a progress event every heartbeat with the time
+// spent so far and, once the body starts arriving, the text received since
+// the previous event.
+
+const (
+	defaultLongPollTimeout   = 5 * time.Minute
+	maxLongPollTimeout       = 30 * time.Minute
+	defaultLongPollHeartbeat = 5 * time.Second
+	maxLongPollHeartbeat     = time.Minute
+	// maxLongPollChunk caps the text echoed per progress event; the rest is
+	// only in the final result
+	maxLongPollChunk = 64 << 10
+)
+
+// LongPollOptions configure a long-polling execution
+type LongPollOptions struct {
+	TimeoutSeconds   int `json:"timeoutSeconds,omitempty"`   // whole exchange; 0 = 5 minutes, at most 30
+	HeartbeatSeconds int `json:"heartbeatSeconds,omitempty"` // progress interval; 0 = 5 seconds, at most 60
+}
+
+func (o LongPollOptions) Validate() error {
+	if o.TimeoutSeconds < 0 || time.Duration(o.TimeoutSeconds)*time.Second > maxLongPollTimeout {
+		return fmt.Errorf("longPoll.timeoutSeconds must be between 0 and %d", int(maxLongPollTimeout/time.Second))
+	}
+	if o.HeartbeatSeconds < 0 || time.Duration(o.HeartbeatSeconds)*time.Second > maxLongPollHeartbeat {
+		return fmt.Errorf("longPoll.heartbeatSeconds must be between 0 and %d", int(maxLongPollHeartbeat/time.Second))
+	}
+	return nil
+}
+
+func (o LongPollOptions) timeout() time.Duration {
+	if o.TimeoutSeconds == 0 {
+		return defaultLongPollTimeout
+	}
+	return time.Duration(o.TimeoutSeconds) * time.Second
+}
+
+func (o LongPollOptions) heartbeat() time.Duration {
+	if o.HeartbeatSeconds == 0 {
+		return defaultLongPollHeartbeat
+	}
+	return time.Duration(o.HeartbeatSeconds) * time.Second
+}
+
+// LongPollProgress is a keep-alive event of a long-polling execution
+type LongPollProgress struct {
+	ElapsedMs     int64  `json:"elapsedMs"`
+	Phase         string `json:"phase"`                // waiting (no response yet) | receiving (headers arrived)
+	StatusCode    int    `json:"statusCode,omitempty"` // once receiving
+	BytesReceived int64  `json:"bytesReceived"`
+	// Chunk is the text body received since the previous event (binary
+	// bodies are not echoed)
+	Chunk string `json:"chunk,omitempty"`
+	// Truncated means more text arrived than the 64KB echoed per event; the
+	// chunks no longer add up to the body, which the final result carries
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+type longPollKey struct{}
+
+type longPoll struct {
+	opts       LongPollOptions
+	onProgress func(LongPollProgress)
+}
+
+// WithLongPoll runs the request executed with ctx in long-polling mode,
+// reporting progress to onProgress from another goroutine until the
+// execution returns
+func WithLongPoll(ctx context.Context, opts LongPollOptions, onProgress func(LongPollProgress)) context.Context {
+	return context.WithValue(ctx, longPollKey{}, &longPoll{opts: opts, onProgress: onProgress})
+}
+
+func longPollFrom(ctx context.Context) *longPoll {
+	lp, _ := ctx.Value(longPollKey{}).(*longPoll)
+	return lp
+}
+
+// longPollWatch tracks one long-polling exchange and sends its heartbeats
+type longPollWatch struct {
+	lp    *longPoll
+	start time.Time
+
+	mu       sync.Mutex
+	status   int
+	text     bool
+	received int64
+	pending  []byte
+	dropped  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// watch starts the heartbeats of an exchange that began at start
+func (lp *longPoll) watch(start time.Time) *longPollWatch {
+	w := &longPollWatch{lp: lp, start: start, done: make(chan struct{})}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(lp.opts.heartbeat())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+				w.lp.onProgress(w.progress())
+			}
+		}
+	}()
+	return w
+}
+
+// receiving records that the response headers arrived
+func (w *longPollWatch) receiving(status int, text bool) {
+	w.mu.Lock()
+	w.status, w.text = status, text
+	w.mu.Unlock()
+}
+
+// body returns r counting (and, for text, buffering) what is read from it
+func (w *longPollWatch) body(r io.Reader) io.Reader {
+	return io.TeeReader(r, w)
+}
+
+func (w *longPollWatch) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.received += int64(len(p))
+	if w.text {
+		text := p
+		if room := maxLongPollChunk - len(w.pending); len(text) > room {
+			text, w.dropped = text[:room], true
+		}
+		w.pending = append(w.pending, text...)
+	}
+	w.mu.Unlock()
+	return len(p), nil
+}
+
+// progress returns the current state and takes the buffered text, holding
+// back a character split across reads
+func (w *longPollWatch) progress() LongPollProgress {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p := LongPollProgress{
+		ElapsedMs:     time.Since(w.start).Milliseconds(),
+		Phase:         "waiting",
+		StatusCode:    w.status,
+		BytesReceived: w.received,
+	}
+	if w.status != 0 {
+		p.Phase = "receiving"
+	}
+	n := completeRunes(w.pending)
+	if w.dropped {
+		// The held-back bytes belong to text that was not echoed
+		n, w.dropped, p.Truncated = len(w.pending), false, true
+	}
+	p.Chunk = string(w.pending[:n])
+	w.pending = append(w.pending[:0], w.pending[n:]...)
+	return p
+}
+
+// completeRunes returns the length of b up to its last whole character
+func completeRunes(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return len(b)
+			}
+			return i
+		}
+	}
+	return len(b)
+}
+
+// stop ends the heartbeats; no progress is reported after it returns
+func (w *longPollWatch) stop() {
+	close(w.done)
+	w.wg.Wait()
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"relay/internal/testutil"
+)
+
+func TestLongPoll_HeartbeatAndPartialBody(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("first event\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("second event\n"))
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+
+	var mu sync.Mutex
+	var events []LongPollProgress
+	ctx := WithLongPoll(context.Background(), LongPollOptions{HeartbeatSeconds: 1}, func(p LongPollProgress) {
+		mu.Lock()
+		events = append(events, p)
+		if len(events) == 1 {
+			release <- struct{}{}
+		}
+		mu.Unlock()
+	})
+	result, err := re.ExecuteAdhoc(ctx, "GET", ts.URL, "{}", "", nil, nil)
+	if err != nil || result.Error != "" {
+		t.Fatalf("execute: %v, %+v", err, result)
+	}
+	if result.Body != "first event\nsecond event\n" {
+		t.Errorf("body = %q", result.Body)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) == 0 {
+		t.Fatal("no progress events")
+	}
+	e := events[0]
+	if e.Phase != "receiving" || e.StatusCode != 200 || e.BytesReceived != 12 || e.Chunk != "first event\n" || e.ElapsedMs < 1000 {
+		t.Errorf("progress = %+v", e)
+	}
+}
+
+func TestLongPollWatch_Chunks(t *testing.T) {
+	w := (&longPoll{opts: LongPollOptions{HeartbeatSeconds: 60}, onProgress: func(LongPollProgress) {}}).watch(time.Now())
+	defer w.stop()
+
+	if p := w.progress(); p.Phase != "waiting" || p.StatusCode != 0 || p.Chunk != "" {
+		t.Errorf("before headers = %+v", p)
+	}
+	w.receiving(200, true)
+
+	// A character split across reads is held back until it is complete
+	euro := []byte("€")
+	w.Write(append([]byte("price: "), euro[:2]...))
+	if p := w.progress(); p.Chunk != "price: " || p.BytesReceived != 9 {
+		t.Errorf("split = %+v", p)
+	}
+	w.Write(euro[2:])
+	if p := w.progress(); p.Chunk != "€" {
+		t.Errorf("completed = %+v", p)
+	}
+
+	// Text past the per-event cap is not echoed
+	w.Write([]byte(strings.Repeat("a", maxLongPollChunk+10)))
+	if p := w.progress(); !p.Truncated || len(p.Chunk) != maxLongPollChunk {
+		t.Errorf("over the cap: truncated=%v, %d bytes", p.Truncated, len(p.Chunk))
+	}
+	w.Write([]byte("z"))
+	if p := w.progress(); p.Truncated || p.Chunk != "z" {
+		t.Errorf("after the cap = %+v", p)
+	}
+
+	// Binary bodies are only counted
+	b := (&longPoll{opts: LongPollOptions{HeartbeatSeconds: 60}, onProgress: func(LongPollProgress) {}}).watch(time.Now())
+	defer b.stop()
+	b.receiving(200, false)
+	b.Write([]byte{0x89, 'P', 'N', 'G'})
+	if p := b.progress(); p.Chunk != "" || p.BytesReceived != 4 {
+		t.Errorf("binary = %+v", p)
+	}
+}
+
+func TestLongPollOptions_Validate(t *testing.T) {
+	for _, o := range []LongPollOptions{{TimeoutSeconds: -1}, {TimeoutSeconds: 1801}, {HeartbeatSeconds: 61}} {
+		if o.Validate() == nil {
+			t.Errorf("%+v: no error", o)
+		}
+	}
+	if err := (LongPollOptions{TimeoutSeconds: 1800, HeartbeatSeconds: 60}).Validate(); err != nil {
+		t.Errorf("max options: %v", err)
+	}
+	if (LongPollOptions{}).timeout() != 5*time.Minute || (LongPollOptions{}).heartbeat() != 5*time.Second {
+		t.Error("unexpected defaults")
+	}
+}
@@ -0,0 +1,332 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Math expression grammar used by the DSL "math" operation:
+//
+//	expr   := term (("+" | "-") term)*
+//	term   := unary (("*" | "/" | "%") unary)*
+//	unary  := ("-" | "+") unary | power
+//	power  := atom ["^" unary]            (right-associative)
+//	atom   := number | ident | ident "(" [expr ("," expr)*] ")" | "(" expr ")"
+//
+// Identifiers are looked up in the supplied variables; constants pi and e are
+// built in. Functions: min, max, abs, round(x[, digits]), floor, ceil, sqrt, pow.
+
+type mathParser struct {
+	src  string
+	pos  int
+	vars map[string]string
+	// syntaxOnly skips variable lookups and runtime checks (division by zero)
+	syntaxOnly bool
+}
+
+// EvaluateMath evaluates an arithmetic expression
+func EvaluateMath(expr string, vars map[string]string) (float64, error) {
+	p := &mathParser{src: expr, vars: vars}
+	v, err := p.parseExpr()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return 0, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("math: result is not a finite number")
+	}
+	return v, nil
+}
+
+// ValidateMath reports syntax errors in expr. Unknown identifiers are allowed
+// since variables are only known at run time; {{var}} placeholders count as numbers.
+func ValidateMath(expr string) error {
+	expr = variablePattern.ReplaceAllString(expr, "1")
+	p := &mathParser{src: expr, syntaxOnly: true}
+	if _, err := p.parseExpr(); err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	return nil
+}
+
+func (p *mathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("math: %s at position %d", fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *mathParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// accept consumes c if it is the next non-space character
+func (p *mathParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.src) && p.src[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *mathParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.accept('+'):
+			right, err := p.parseTerm()
+			if err != nil {
+				return 0, err
+			}
+			left += right
+		case p.accept('-'):
+			right, err := p.parseTerm()
+			if err != nil {
+				return 0, err
+			}
+			left -= right
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *mathParser) parseTerm() (float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.accept('*'):
+			right, err := p.parseUnary()
+			if err != nil {
+				return 0, err
+			}
+			left *= right
+		case p.accept('/'):
+			right, err := p.parseUnary()
+			if err != nil {
+				return 0, err
+			}
+			if right == 0 && !p.syntaxOnly {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		case p.accept('%'):
+			right, err := p.parseUnary()
+			if err != nil {
+				return 0, err
+			}
+			if right == 0 && !p.syntaxOnly {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left = math.Mod(left, right)
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *mathParser) parseUnary() (float64, error) {
+	if p.accept('-') {
+		v, err := p.parseUnary()
+		return -v, err
+	}
+	if p.accept('+') {
+		return p.parseUnary()
+	}
+	return p.parsePower()
+}
+
+func (p *mathParser) parsePower() (float64, error) {
+	base, err := p.parseAtom()
+	if err != nil {
+		return 0, err
+	}
+	if p.accept('^') {
+		exp, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		return math.Pow(base, exp), nil
+	}
+	return base, nil
+}
+
+func (p *mathParser) parseAtom() (float64, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0, p.errorf("unexpected end of expression")
+	}
+
+	if p.accept('(') {
+		v, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if !p.accept(')') {
+			return 0, p.errorf("expected \")\"")
+		}
+		return v, nil
+	}
+
+	c := p.src[p.pos]
+	if isMathDigit(c) || c == '.' {
+		start := p.pos
+		for p.pos < len(p.src) && (isMathDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		// Exponent notation (1e3, 2.5E-4)
+		if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+			save := p.pos
+			p.pos++
+			if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+				p.pos++
+			}
+			if p.pos < len(p.src) && isMathDigit(p.src[p.pos]) {
+				for p.pos < len(p.src) && isMathDigit(p.src[p.pos]) {
+					p.pos++
+				}
+			} else {
+				p.pos = save
+			}
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			p.pos = start
+			return 0, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return v, nil
+	}
+
+	if isMathIdentStart(c) {
+		start := p.pos
+		for p.pos < len(p.src) && isMathIdentPart(p.src[p.pos]) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if p.accept('(') {
+			var args []float64
+			if !p.accept(')') {
+				for {
+					v, err := p.parseExpr()
+					if err != nil {
+						return 0, err
+					}
+					args = append(args, v)
+					if p.accept(')') {
+						break
+					}
+					if !p.accept(',') {
+						return 0, p.errorf("expected \",\" or \")\"")
+					}
+				}
+			}
+			return callMathFunc(name, args)
+		}
+		return p.lookupVar(start, name)
+	}
+
+	return 0, p.errorf("unexpected %q", string(c))
+}
+
+func (p *mathParser) lookupVar(pos int, name string) (float64, error) {
+	if p.syntaxOnly {
+		return 1, nil
+	}
+	if raw, ok := p.vars[name]; ok {
+		v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return 0, fmt.Errorf("math: variable %q is not a number: %q", name, raw)
+		}
+		return v, nil
+	}
+	switch name {
+	case "pi", "PI":
+		return math.Pi, nil
+	case "e", "E":
+		return math.E, nil
+	}
+	p.pos = pos
+	return 0, p.errorf("unknown variable %q", name)
+}
+
+func callMathFunc(name string, args []float64) (float64, error) {
+	want := func(n int) error {
+		if len(args) != n {
+			return fmt.Errorf("math: %s expects %d argument(s), got %d", name, n, len(args))
+		}
+		return nil
+	}
+	switch name {
+	case "min", "max":
+		if len(args) == 0 {
+			return 0, fmt.Errorf("math: %s expects at least 1 argument", name)
+		}
+		v := args[0]
+		for _, a := range args[1:] {
+			if name == "min" {
+				v = math.Min(v, a)
+			} else {
+				v = math.Max(v, a)
+			}
+		}
+		return v, nil
+	case "abs":
+		if err := want(1); err != nil {
+			return 0, err
+		}
+		return math.Abs(args[0]), nil
+	case "round":
+		if len(args) == 2 {
+			scale := math.Pow(10, math.Trunc(args[1]))
+			return math.Round(args[0]*scale) / scale, nil
+		}
+		if err := want(1); err != nil {
+			return 0, err
+		}
+		return math.Round(args[0]), nil
+	case "floor":
+		if err := want(1); err != nil {
+			return 0, err
+		}
+		return math.Floor(args[0]), nil
+	case "ceil":
+		if err := want(1); err != nil {
+			return 0, err
+		}
+		return math.Ceil(args[0]), nil
+	case "sqrt":
+		if err := want(1); err != nil {
+			return 0, err
+		}
+		return math.Sqrt(args[0]), nil
+	case "pow":
+		if err := want(2); err != nil {
+			return 0, err
+		}
+		return math.Pow(args[0], args[1]), nil
+	}
+	return 0, fmt.Errorf("math: unknown function %q", name)
+}
+
+func isMathDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isMathIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isMathIdentPart(c byte) bool { return isMathIdentStart(c) || isMathDigit(c) }
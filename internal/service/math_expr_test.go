@@ -0,0 +1,78 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluateMath(t *testing.T) {
+	vars := map[string]string{"price": "12.5", "qty": "4", "neg": "-3"}
+
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"42", 42},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"100 / 10 / 5", 2},
+		{"7 % 3", 1},
+		{"-5 + 2", -3},
+		{"-(2 + 3) * 2", -10},
+		{"--4", 4},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"2 ^ -1", 0.5},
+		{"1.5e2 + .5", 150.5},
+		{"price * qty", 50},
+		{"abs(neg) + qty", 7},
+		{"min(3, 1, 2)", 1},
+		{"max(price, qty * 4)", 16},
+		{"round(2.5)", 3},
+		{"round(3.14159, 2)", 3.14},
+		{"floor(-1.5) + ceil(1.2)", 0},
+		{"sqrt(16) + pow(2, 10)", 1028},
+		{"round(pi, 4)", 3.1416},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := EvaluateMath(tt.expr, vars)
+			if err != nil {
+				t.Fatalf("EvaluateMath(%q) error: %v", tt.expr, err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("EvaluateMath(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateMath_Errors(t *testing.T) {
+	vars := map[string]string{"name": "alice"}
+
+	tests := []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"1 2",
+		"4 / 0",
+		"4 % 0",
+		"unknown + 1",
+		"name * 2",
+		"nope(1)",
+		"abs(1, 2)",
+		"min()",
+		"sqrt(-1)",
+		"1 $ 2",
+	}
+
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := EvaluateMath(expr, vars); err == nil {
+				t.Errorf("EvaluateMath(%q) expected error", expr)
+			}
+		})
+	}
+}
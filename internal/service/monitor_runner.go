@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// Monitor interval bounds (seconds)
+const (
+	MinMonitorInterval = 10
+	MaxMonitorInterval = 24 * 60 * 60
+)
+
+// How long checks are held back while the network is unreachable (seconds);
+// 0 records every failed check immediately
+const (
+	DefaultMonitorMaxQueue = 15 * 60
+	MaxMonitorMaxQueue     = 24 * 60 * 60
+)
+
+const (
+	monitorTick         = 5 * time.Second  // how often due monitors are looked up
+	monitorCheckTimeout = 30 * time.Second // per check, regardless of the request's client timeout
+	monitorConcurrency  = 4
+	monitorLeaseTTL     = 2 * time.Minute // covers a full round of checks
+)
+
+// MonitorRunner periodically executes requests marked as monitors and records
+// status/latency points. Checks are not written to request history.
+//
+// When a scheduled check can't reach the network at all the monitor goes
+// offline: nothing is recorded, the check is retried every 30 seconds (see
+// ListDueMonitors) and once the network is back, or the monitor's max queue
+// age passes, the outage is recorded as a single gap point.
+type MonitorRunner struct {
+	queries  *repository.Queries
+	notifier *EmailNotifier // optional; alerts when a monitor goes down or recovers
+	execute  func(ctx context.Context, req repository.Request) (*ExecuteResult, error)
+	instance *Instance           // optional; checks run only while holding the monitors lease
+	runHooks *CollectionRunHooks // optional; collection setup/teardown flows
+	jobs     *JobQueue           // optional; scheduled checks run as queued jobs
+}
+
+func NewMonitorRunner(queries *repository.Queries, executor *RequestExecutor, notifier *EmailNotifier) *MonitorRunner {
+	return &MonitorRunner{
+		queries:  queries,
+		notifier: notifier,
+		execute: func(ctx context.Context, req repository.Request) (*ExecuteResult, error) {
+			return executor.ExecuteRequest(ctx, req, nil)
+		},
+	}
+}
+
+// Start runs due monitors in the background until ctx is cancelled
+// SetInstance makes the runner coordinate with other instances sharing the
+// database, so each due check runs once
+func (m *MonitorRunner) SetInstance(inst *Instance) {
+	m.instance = inst
+}
+
+// SetRunHooks wraps scheduled checks with the setup and teardown flows of
+// collections that opt in (CollectionRunFlows.Scheduled)
+func (m *MonitorRunner) SetRunHooks(hooks *CollectionRunHooks) {
+	m.runHooks = hooks
+}
+
+// SetJobQueue runs scheduled checks on the job queue: each tick queues a
+// job per due monitor instead of checking them itself
+func (m *MonitorRunner) SetJobQueue(jq *JobQueue) {
+	m.jobs = jq
+	jq.Register(JobTypeMonitorCheck, func(ctx context.Context, job repository.Job) (interface{}, error) {
+		mon, ok, err := loadScheduled(ctx, job, m.queries.GetMonitor)
+		if !ok || mon.Enabled == 0 {
+			return nil, err
+		}
+		check, err := m.check(ctx, mon, true)
+		if err != nil || check.ID == 0 {
+			return nil, err
+		}
+		return map[string]interface{}{"checkId": check.ID, "success": check.Success == 1}, nil
+	})
+}
+
+func (m *MonitorRunner) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(monitorTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !m.instance.Acquire(ctx, LeaseMonitors, monitorLeaseTTL) {
+					continue
+				}
+				if m.jobs != nil {
+					m.EnqueueDue(ctx)
+				} else {
+					m.RunDue(ctx)
+				}
+			}
+		}
+	}()
+}
+
+// RunDue checks every enabled monitor whose interval has elapsed, waits for the
+// checks to finish and prunes old points. It returns the number of checks run.
+func (m *MonitorRunner) RunDue(ctx context.Context) int {
+	due, err := m.queries.ListDueMonitors(ctx)
+	if err != nil {
+		log.Printf("monitor: failed to list due monitors: %v", err)
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, monitorConcurrency)
+	for _, mon := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(mon repository.Monitor) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if _, err := m.check(ctx, mon, true); err != nil {
+				log.Printf("monitor %d: %v", mon.ID, err)
+			}
+		}(mon)
+	}
+	wg.Wait()
+
+	if err := m.queries.PruneMonitorChecks(ctx); err != nil {
+		log.Printf("monitor: failed to prune checks: %v", err)
+	}
+	return len(due)
+}
+
+// EnqueueDue queues a check of every enabled monitor whose interval has
+// elapsed and prunes old points. It returns the number of due monitors.
+func (m *MonitorRunner) EnqueueDue(ctx context.Context) int {
+	due, err := m.queries.ListDueMonitors(ctx)
+	if err != nil {
+		log.Printf("monitor: failed to list due monitors: %v", err)
+		return 0
+	}
+	for _, mon := range due {
+		m.jobs.enqueueScheduled(ctx, JobTypeMonitorCheck, mon.ID, mon.WorkspaceID)
+	}
+	if err := m.queries.PruneMonitorChecks(ctx); err != nil {
+		log.Printf("monitor: failed to prune checks: %v", err)
+	}
+	return len(due)
+}
+
+// Check executes the monitored request once and records the outcome. A check
+// succeeds when the request completes without error with a status below 400.
+// Unlike scheduled checks it is never held back while offline.
+func (m *MonitorRunner) Check(ctx context.Context, mon repository.Monitor) (repository.MonitorCheck, error) {
+	return m.check(ctx, mon, false)
+}
+
+// check runs one check; with queue set, a network outage holds it back
+// (returning a zero check) instead of recording a failure
+func (m *MonitorRunner) check(ctx context.Context, mon repository.Monitor, queue bool) (repository.MonitorCheck, error) {
+	req, err := m.queries.GetRequest(ctx, mon.RequestID)
+	if err != nil {
+		return repository.MonitorCheck{}, err
+	}
+
+	if queue {
+		// Scheduled checks pause while the request is archived or once the
+		// workspace used its daily runs
+		if req.ArchivedAt.Valid {
+			return repository.MonitorCheck{}, m.queries.MarkMonitorChecked(ctx, mon.ID)
+		}
+		var exceeded *QuotaExceededError
+		if err := CheckQuotas(ctx, m.queries, mon.WorkspaceID, QuotaScheduledRuns); errors.As(err, &exceeded) {
+			return repository.MonitorCheck{}, m.queries.MarkMonitorChecked(ctx, mon.ID)
+		}
+	}
+
+	checkCtx, cancel := context.WithTimeout(withoutHistory(middleware.WithWorkspaceID(ctx, mon.WorkspaceID)), monitorCheckTimeout)
+	defer cancel()
+
+	// Previous real result (offline gaps don't count as up or down)
+	wasUp := true
+	if previous, err := m.queries.GetLastMonitorResult(ctx, mon.ID); err == nil {
+		wasUp = previous.Success == 1
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return repository.MonitorCheck{}, err
+	}
+
+	var flows *CollectionRunFlows
+	if queue && req.CollectionID.Valid {
+		if f := m.runHooks.Lookup(ctx, req.CollectionID.Int64); f != nil && f.Scheduled {
+			flows = f
+		}
+	}
+	flowCtx := withoutHistory(middleware.WithWorkspaceID(ctx, mon.WorkspaceID))
+
+	params := repository.CreateMonitorCheckParams{MonitorID: mon.ID}
+	unreachable := false
+	if setup := m.runHooks.Setup(flowCtx, flows); setup != nil && !setup.Success {
+		params.Error = "Setup flow failed: " + setup.Error
+	} else if result, err := m.execute(checkCtx, req); err != nil {
+		params.Error = err.Error()
+	} else {
+		params.StatusCode = int64(result.StatusCode)
+		params.DurationMs = result.DurationMs
+		params.Error = result.Error
+		unreachable = result.Unreachable
+		if result.Error == "" && result.StatusCode >= 200 && result.StatusCode < 400 {
+			params.Success = 1
+		}
+	}
+	if teardown := m.runHooks.Teardown(flowCtx, flows); teardown != nil && !teardown.Success {
+		log.Printf("monitor %d: teardown flow failed: %s", mon.ID, teardown.Error)
+	}
+
+	if unreachable && queue && mon.MaxQueueSeconds > 0 {
+		maxAge := time.Duration(mon.MaxQueueSeconds) * time.Second
+		if !mon.OfflineSince.Valid || time.Since(mon.OfflineSince.Time) < maxAge {
+			if err := m.queries.SetMonitorOffline(ctx, mon.ID); err != nil {
+				return repository.MonitorCheck{}, err
+			}
+			return repository.MonitorCheck{}, m.queries.MarkMonitorChecked(ctx, mon.ID)
+		}
+	}
+
+	// The network is back or the queue expired: record the outage as one gap
+	if mon.OfflineSince.Valid {
+		if err := m.recordOfflineGap(ctx, mon); err != nil {
+			return repository.MonitorCheck{}, err
+		}
+	}
+
+	check, err := m.queries.CreateMonitorCheck(ctx, params)
+	if err != nil {
+		return repository.MonitorCheck{}, err
+	}
+
+	// Alert on state changes only: first failure, or success after a failure
+	if m.notifier != nil && wasUp != (check.Success == 1) {
+		if err := m.notifier.NotifyMonitorTransition(ctx, mon, req, check); err != nil {
+			log.Printf("monitor %d: email alert failed: %v", mon.ID, err)
+		}
+	}
+	return check, m.queries.MarkMonitorChecked(ctx, mon.ID)
+}
+
+// recordOfflineGap writes the point covering the time mon spent offline and
+// clears its offline state
+func (m *MonitorRunner) recordOfflineGap(ctx context.Context, mon repository.Monitor) error {
+	offline := time.Since(mon.OfflineSince.Time).Round(time.Second)
+	if offline < time.Second {
+		offline = time.Second
+	}
+	skipped := int64(offline.Seconds()) / mon.IntervalSeconds
+	_, err := m.queries.CreateMonitorCheck(ctx, repository.CreateMonitorCheckParams{
+		MonitorID:      mon.ID,
+		Error:          fmt.Sprintf("Network unreachable for %s since %s; %d scheduled check(s) held back", offline, mon.OfflineSince.Time.UTC().Format(time.RFC3339), skipped),
+		OfflineSeconds: int64(offline.Seconds()),
+	})
+	if err != nil {
+		return err
+	}
+	return m.queries.ClearMonitorOffline(ctx, mon.ID)
+}
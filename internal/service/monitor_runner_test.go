@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestMonitorRunner_RunDue(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	vr := NewVariableResolver(q)
+	runner := NewMonitorRunner(q, NewRequestExecutor(q, vr, nil), nil)
+
+	req, err := q.CreateRequest(ctx, repository.CreateRequestParams{
+		Name:        "Health",
+		Method:      "GET",
+		Url:         server.URL + "/health",
+		Headers:     sql.NullString{String: "{}", Valid: true},
+		WorkspaceID: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mon, err := q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: req.ID, IntervalSeconds: 60, Enabled: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Paused monitors are never due
+	paused, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Paused", Method: "GET", Url: server.URL, WorkspaceID: 1})
+	q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: paused.ID, IntervalSeconds: 60, Enabled: 0})
+
+	if n := runner.RunDue(ctx); n != 1 {
+		t.Fatalf("first RunDue checked %d monitors, want 1", n)
+	}
+	// Interval has not elapsed yet
+	if n := runner.RunDue(ctx); n != 0 {
+		t.Errorf("second RunDue checked %d monitors, want 0", n)
+	}
+
+	checks, err := q.ListMonitorChecks(ctx, repository.ListMonitorChecksParams{MonitorID: mon.ID, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checks) != 1 || checks[0].Success != 1 || checks[0].StatusCode != 200 {
+		t.Fatalf("checks = %+v", checks)
+	}
+
+	// A manual check records failures too
+	check, err := runner.Check(ctx, mon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Success != 0 || check.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("failed check = %+v", check)
+	}
+
+	stats, err := q.GetMonitorStats(ctx, mon.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Checks != 2 || stats.Successes != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// Due again once the interval has elapsed
+	db.Exec("UPDATE monitors SET last_checked_at = datetime('now', '-61 seconds') WHERE id = ?", mon.ID)
+	if n := runner.RunDue(ctx); n != 1 {
+		t.Errorf("RunDue after interval checked %d monitors, want 1", n)
+	}
+
+	// Monitor checks are kept out of request history
+	history, err := q.ListHistoryByRequest(ctx, repository.ListHistoryByRequestParams{
+		RequestID: sql.NullInt64{Int64: req.ID, Valid: true},
+		Limit:     10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 0 {
+		t.Errorf("expected no history entries, got %d", len(history))
+	}
+}
+
+func TestMonitorRunner_EnqueueDue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	runner := NewMonitorRunner(q, NewRequestExecutor(q, NewVariableResolver(q), nil), nil)
+	jq := NewJobQueue(q)
+	runner.SetJobQueue(jq)
+
+	req, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Health", Method: "GET", Url: server.URL, WorkspaceID: 1})
+	mon, err := q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: req.ID, IntervalSeconds: 60, Enabled: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The check is queued once while it is pending
+	if n := runner.EnqueueDue(ctx); n != 1 {
+		t.Fatalf("EnqueueDue found %d due monitors, want 1", n)
+	}
+	runner.EnqueueDue(ctx)
+	jobs, _ := q.ListJobs(ctx, repository.ListJobsParams{WorkspaceID: sql.NullInt64{Int64: 1, Valid: true}, Limit: 10})
+	if len(jobs) != 1 || jobs[0].Type != JobTypeMonitorCheck || jobs[0].Payload != fmt.Sprintf(`{"id":%d}`, mon.ID) {
+		t.Fatalf("jobs = %+v", jobs)
+	}
+	if checks, _ := q.ListMonitorChecks(ctx, repository.ListMonitorChecksParams{MonitorID: mon.ID, Limit: 10}); len(checks) != 0 {
+		t.Fatalf("EnqueueDue ran the check itself: %+v", checks)
+	}
+
+	if !jq.RunNext(ctx) {
+		t.Fatal("queued check was not claimed")
+	}
+	job, _ := q.GetJob(ctx, jobs[0].ID)
+	if job.Status != JobSucceeded || !strings.Contains(job.Result.String, `"success":true`) {
+		t.Errorf("job = %+v", job)
+	}
+	checks, _ := q.ListMonitorChecks(ctx, repository.ListMonitorChecksParams{MonitorID: mon.ID, Limit: 10})
+	if len(checks) != 1 || checks[0].StatusCode != 200 {
+		t.Errorf("checks = %+v", checks)
+	}
+	// Checked now, so nothing is due
+	if n := runner.EnqueueDue(ctx); n != 0 {
+		t.Errorf("EnqueueDue after the check found %d due monitors", n)
+	}
+}
+
+func TestMonitorRunner_OfflineQueue(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	runner := NewMonitorRunner(q, nil, nil)
+
+	var offline atomic.Bool
+	runner.execute = func(ctx context.Context, req repository.Request) (*ExecuteResult, error) {
+		if offline.Load() {
+			return &ExecuteResult{Error: "dial tcp: connect: network is unreachable", Unreachable: true}, nil
+		}
+		return &ExecuteResult{StatusCode: 200}, nil
+	}
+
+	req, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Health", Method: "GET", Url: "http://api.internal/health", WorkspaceID: 1})
+	mon, err := q.CreateMonitor(ctx, repository.CreateMonitorParams{
+		WorkspaceID: 1, RequestID: req.ID, IntervalSeconds: 60, Enabled: 1, MaxQueueSeconds: DefaultMonitorMaxQueue,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := func() []repository.MonitorCheck {
+		t.Helper()
+		cs, err := q.ListMonitorChecks(ctx, repository.ListMonitorChecksParams{MonitorID: mon.ID, Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cs
+	}
+	makeDue := func(seconds int) {
+		db.Exec("UPDATE monitors SET last_checked_at = datetime('now', ?) WHERE id = ?", fmt.Sprintf("-%d seconds", seconds), mon.ID)
+	}
+
+	// Network down: the check is held back, not recorded
+	offline.Store(true)
+	if n := runner.RunDue(ctx); n != 1 {
+		t.Fatalf("RunDue checked %d monitors, want 1", n)
+	}
+	if cs := checks(); len(cs) != 0 {
+		t.Fatalf("offline check was recorded: %+v", cs)
+	}
+	if m, _ := q.GetMonitor(ctx, mon.ID); !m.OfflineSince.Valid {
+		t.Fatal("monitor should be marked offline")
+	}
+
+	// Offline monitors retry every 30s instead of waiting the full interval
+	makeDue(31)
+	if n := runner.RunDue(ctx); n != 1 {
+		t.Errorf("offline retry checked %d monitors, want 1", n)
+	}
+	if cs := checks(); len(cs) != 0 {
+		t.Fatalf("retry while offline was recorded: %+v", cs)
+	}
+
+	// Connectivity resumes: one gap point, then the real result
+	db.Exec("UPDATE monitors SET offline_since = datetime('now', '-300 seconds') WHERE id = ?", mon.ID)
+	offline.Store(false)
+	makeDue(31)
+	runner.RunDue(ctx)
+	cs := checks()
+	if len(cs) != 2 {
+		t.Fatalf("expected gap + success, got %+v", cs)
+	}
+	gap, ok := cs[1], cs[0]
+	if gap.OfflineSeconds < 299 || gap.Success != 0 || !strings.Contains(gap.Error, "5 scheduled check(s) held back") {
+		t.Errorf("gap = %+v", gap)
+	}
+	if ok.Success != 1 || ok.OfflineSeconds != 0 {
+		t.Errorf("check after reconnect = %+v", ok)
+	}
+	if m, _ := q.GetMonitor(ctx, mon.ID); m.OfflineSince.Valid {
+		t.Error("offline state should be cleared")
+	}
+	// Gaps don't count against uptime
+	if stats, _ := q.GetMonitorStats(ctx, mon.ID); stats.Checks != 1 || stats.Successes != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// Still offline after the max queue age: the outage is reported as a failure
+	offline.Store(true)
+	makeDue(61)
+	runner.RunDue(ctx)
+	db.Exec("UPDATE monitors SET offline_since = datetime('now', '-901 seconds') WHERE id = ?", mon.ID)
+	makeDue(31)
+	runner.RunDue(ctx)
+	cs = checks()
+	if len(cs) != 4 || cs[1].OfflineSeconds == 0 || cs[0].Success != 0 || cs[0].OfflineSeconds != 0 {
+		t.Fatalf("expected gap + failure after queue expiry, got %+v", cs)
+	}
+
+	// Manual checks are never queued
+	mon, _ = q.GetMonitor(ctx, mon.ID)
+	check, err := runner.Check(ctx, mon)
+	if err != nil || check.ID == 0 || check.Success != 0 {
+		t.Errorf("manual check while offline = %+v, %v", check, err)
+	}
+}
+
+func TestIsNetworkUnreachable(t *testing.T) {
+	dial := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://x", Err: &net.OpError{Op: "dial", Net: "tcp", Err: err}}
+	}
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network unreachable", dial(os.NewSyscallError("connect", syscall.ENETUNREACH)), true},
+		{"host unreachable", dial(os.NewSyscallError("connect", syscall.EHOSTUNREACH)), true},
+		{"dns timeout", dial(&net.DNSError{Err: "i/o timeout", Name: "x", IsTimeout: true}), true},
+		{"dns server failure", dial(&net.DNSError{Err: "server misbehaving", Name: "x", IsTemporary: true}), true},
+		{"unknown host", dial(&net.DNSError{Err: "no such host", Name: "x", IsNotFound: true}), false},
+		{"connection refused", dial(os.NewSyscallError("connect", syscall.ECONNREFUSED)), false},
+		{"other", fmt.Errorf("tls: handshake failure"), false},
+	}
+	for _, tc := range cases {
+		if got := isNetworkUnreachable(tc.err); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+)
+
+const maxResponseParts = 1000
+
+// ResponsePart is one part of a multipart/mixed or multipart/form-data
+// response. Text parts carry Body; other parts carry BodyBase64.
+type ResponsePart struct {
+	Headers     map[string]string `json:"headers"`
+	ContentType string            `json:"contentType,omitempty"`
+	Name        string            `json:"name,omitempty"`     // form-data field name
+	Filename    string            `json:"filename,omitempty"` // form-data file name
+	Body        string            `json:"body,omitempty"`
+	BodyBase64  string            `json:"bodyBase64,omitempty"`
+	Size        int64             `json:"size"`
+}
+
+// isMultipartResponse reports whether contentType is a multipart type with a
+// boundary
+func isMultipartResponse(contentType string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	return err == nil && strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != ""
+}
+
+// ParseMultipartResponse splits a multipart response body into its parts.
+// Parts are read in order up to maxResponseParts.
+func ParseMultipartResponse(contentType string, body []byte) ([]ResponsePart, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, errors.New("not a multipart response")
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	parts := []ResponsePart{}
+	for len(parts) < maxResponseParts {
+		p, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return parts, err
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			return parts, err
+		}
+		part := ResponsePart{
+			Headers:     make(map[string]string, len(p.Header)),
+			ContentType: p.Header.Get("Content-Type"),
+			Name:        p.FormName(),
+			Filename:    p.FileName(),
+			Size:        int64(len(data)),
+		}
+		for k := range p.Header {
+			part.Headers[k] = p.Header.Get(k)
+		}
+		if part.ContentType == "" || isTextContentType(part.ContentType) {
+			part.Body, _, _ = DecodeResponseText(part.ContentType, data)
+		} else {
+			part.BodyBase64 = base64.StdEncoding.EncodeToString(data)
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
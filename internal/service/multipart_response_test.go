@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"relay/internal/testutil"
+)
+
+// multipartBody builds a batch-style response with a JSON part, a form field
+// and a binary file
+func multipartBody(t *testing.T) (string, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", "application/json")
+	h.Set("Content-ID", "<item1>")
+	p, _ := w.CreatePart(h)
+	p.Write([]byte(`{"id":1}`))
+	w.WriteField("note", "hello")
+	f, _ := w.CreateFormFile("file", "blob.bin")
+	f.Write([]byte{0x00, 0xFF, 0x10})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return w.FormDataContentType(), buf.Bytes()
+}
+
+func TestParseMultipartResponse(t *testing.T) {
+	ct, body := multipartBody(t)
+	parts, err := ParseMultipartResponse(ct, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 3 {
+		t.Fatalf("parts = %+v", parts)
+	}
+	if parts[0].Body != `{"id":1}` || parts[0].Headers["Content-Id"] != "<item1>" || parts[0].ContentType != "application/json" {
+		t.Errorf("json part = %+v", parts[0])
+	}
+	if parts[1].Name != "note" || parts[1].Body != "hello" {
+		t.Errorf("field part = %+v", parts[1])
+	}
+	if parts[2].Filename != "blob.bin" || parts[2].BodyBase64 != "AP8Q" || parts[2].Body != "" || parts[2].Size != 3 {
+		t.Errorf("file part = %+v", parts[2])
+	}
+
+	if _, err := ParseMultipartResponse("application/json", body); err == nil {
+		t.Error("expected error for non-multipart content type")
+	}
+}
+
+func TestExecuteRequest_MultipartParts(t *testing.T) {
+	ct, body := multipartBody(t)
+	ct = "multipart/mixed" + ct[len("multipart/form-data"):]
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ct)
+		w.Write(body)
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	result, err := re.ExecuteAdhoc(context.Background(), "GET", ts.URL, "", "", nil, nil)
+	if err != nil || result.Error != "" {
+		t.Fatalf("execute: %v, %s", err, result.Error)
+	}
+	if len(result.Parts) != 3 || result.Parts[0].Body != `{"id":1}` || result.PartsError != "" {
+		t.Errorf("parts = %+v, error = %q", result.Parts, result.PartsError)
+	}
+
+	jse := NewJSScriptExecutor(nil)
+	jsResult := jse.Execute(`
+		var parts = pm.response.parts();
+		pm.test("parts", function() {
+			pm.expect(parts.length).to.equal(3);
+			pm.expect(parts[0].json().id).to.equal(1);
+			pm.expect(parts[0].headers.get("content-id")).to.equal("<item1>");
+			pm.expect(parts[1].name).to.equal("note");
+			pm.expect(parts[2].bodyBase64).to.equal("AP8Q");
+		});
+	`, &JSScriptContext{ResponseBody: result.Body, Headers: result.Headers})
+	if !jsResult.Success || jsResult.AssertionsPassed != 1 {
+		t.Errorf("script: %+v", jsResult)
+	}
+
+	jsResult = jse.Execute(`pm.test("none", function() { pm.expect(pm.response.parts().length).to.equal(0); });`,
+		&JSScriptContext{ResponseBody: "{}", Headers: map[string]string{"Content-Type": "application/json"}})
+	if !jsResult.Success {
+		t.Errorf("non-multipart script: %+v", jsResult)
+	}
+}
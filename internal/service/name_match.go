@@ -0,0 +1,113 @@
+package service
+
+import (
+	"sort"
+	"strings"
+)
+
+// Name match kinds, best first
+const (
+	NameMatchExact     = "exact"
+	NameMatchPrefix    = "prefix"
+	NameMatchSubstring = "substring"
+	NameMatchFuzzy     = "fuzzy" // query characters appear in order
+)
+
+var nameMatchRank = map[string]int{
+	NameMatchExact:     4,
+	NameMatchPrefix:    3,
+	NameMatchSubstring: 2,
+	NameMatchFuzzy:     1,
+}
+
+type NamedItem struct {
+	ID   int64
+	Name string
+}
+
+type NameMatch struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Kind  string `json:"match"`
+	score int    // within a kind; higher is closer
+}
+
+func normalizeName(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// scoreName classifies how name matches the normalized query
+func scoreName(query, name string) (string, int, bool) {
+	n := normalizeName(name)
+	switch {
+	case n == query:
+		return NameMatchExact, 0, true
+	case strings.HasPrefix(n, query):
+		return NameMatchPrefix, -(len(n) - len(query)), true
+	}
+	if i := strings.Index(n, query); i >= 0 {
+		return NameMatchSubstring, -i, true
+	}
+
+	// Subsequence: penalize characters skipped between matched ones
+	qr, nr := []rune(query), []rune(n)
+	qi, gaps, last := 0, 0, -1
+	for ni := 0; ni < len(nr) && qi < len(qr); ni++ {
+		if nr[ni] != qr[qi] {
+			continue
+		}
+		if last >= 0 {
+			gaps += ni - last - 1
+		}
+		last = ni
+		qi++
+	}
+	if qi < len(qr) {
+		return "", 0, false
+	}
+	return NameMatchFuzzy, -gaps, true
+}
+
+// MatchNames returns the items whose name matches query, best first. Matching
+// is case-insensitive and ignores repeated whitespace.
+func MatchNames(query string, items []NamedItem) []NameMatch {
+	q := normalizeName(query)
+	if q == "" {
+		return nil
+	}
+	var matches []NameMatch
+	for _, it := range items {
+		if kind, score, ok := scoreName(q, it.Name); ok {
+			matches = append(matches, NameMatch{ID: it.ID, Name: it.Name, Kind: kind, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if nameMatchRank[a.Kind] != nameMatchRank[b.Kind] {
+			return nameMatchRank[a.Kind] > nameMatchRank[b.Kind]
+		}
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		return a.Name < b.Name
+	})
+	return matches
+}
+
+// ResolveName picks the single best match for query. When several items share
+// the best match kind the choice would be a guess, so all of them are returned
+// as candidates instead.
+func ResolveName(query string, items []NamedItem) (best *NameMatch, candidates []NameMatch) {
+	matches := MatchNames(query, items)
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	n := 1
+	for n < len(matches) && matches[n].Kind == matches[0].Kind {
+		n++
+	}
+	if n > 1 {
+		return nil, matches[:n]
+	}
+	return &matches[0], nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import "testing"
+
+func TestResolveName(t *testing.T) {
+	items := []NamedItem{
+		{ID: 1, Name: "Login"},
+		{ID: 2, Name: "Logout"},
+		{ID: 3, Name: "Get  User Profile"},
+		{ID: 4, Name: "Create Order"},
+		{ID: 5, Name: "Cancel Order"},
+	}
+
+	tests := []struct {
+		query      string
+		wantID     int64
+		wantKind   string
+		candidates int
+	}{
+		{"login", 1, NameMatchExact, 0},
+		{"LOGOUT", 2, NameMatchExact, 0},
+		{"get user", 3, NameMatchPrefix, 0},
+		{"profile", 3, NameMatchSubstring, 0},
+		{"crord", 4, NameMatchFuzzy, 0},
+		{"log", 0, "", 2},   // login and logout are equally good
+		{"order", 0, "", 2}, // both orders contain it
+		{"xyz", 0, "", 0},
+	}
+	for _, tt := range tests {
+		best, candidates := ResolveName(tt.query, items)
+		if len(candidates) != tt.candidates {
+			t.Errorf("%q: %d candidates, want %d", tt.query, len(candidates), tt.candidates)
+		}
+		if tt.wantID == 0 {
+			if best != nil {
+				t.Errorf("%q: expected no single match, got %+v", tt.query, best)
+			}
+			continue
+		}
+		if best == nil || best.ID != tt.wantID || best.Kind != tt.wantKind {
+			t.Errorf("%q: got %+v, want id %d (%s)", tt.query, best, tt.wantID, tt.wantKind)
+		}
+	}
+}
+
+func TestMatchNames_Order(t *testing.T) {
+	items := []NamedItem{{1, "Order list"}, {2, "Orders"}, {3, "My order"}, {4, "Other"}}
+	matches := MatchNames("order", items)
+	if len(matches) != 3 {
+		t.Fatalf("matches = %+v", matches)
+	}
+	// Shorter prefix matches first, then substrings
+	if matches[0].ID != 2 || matches[1].ID != 1 || matches[2].ID != 3 {
+		t.Errorf("order = %+v", matches)
+	}
+}
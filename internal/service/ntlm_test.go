@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestNTLMv2Vectors(t *testing.T) {
+	for in, want := range map[string]string{"": "31d6cfe0d16ae931b73c59d7e0c089c0", "abc": "a448017aaf21d8525fc10ae87aa6729d"} {
+		if got := hex.EncodeToString(md4Sum([]byte(in))); got != want {
+			t.Errorf("md4(%q) = %s, want %s", in, got, want)
+		}
+	}
+	// MS-NLMP 4.2.4 NTLMv2 authentication
+	if got := hex.EncodeToString(md4Sum(ntlmString("Password"))); got != "a4f49c406510bdcab6824ee7c30fd852" {
+		t.Errorf("NT hash = %s", got)
+	}
+	hash := ntowfv2("Domain", "User", "Password")
+	if got := hex.EncodeToString(hash); got != "0c868a403bfd7a93a3001ef22ef02e3f" {
+		t.Errorf("NTOWFv2 = %s", got)
+	}
+	targetInfo := unhex(t, "02000c0044006f006d00610069006e00 01000c005300650072007600650072000000 0000")
+	resp := ntlmV2Response(hash, unhex(t, "0123456789abcdef"), bytes.Repeat([]byte{0xaa}, 8), 0, targetInfo)
+	if got := hex.EncodeToString(resp[:16]); got != "68cd0ab851e51c96aabc927bebef6a1c" {
+		t.Errorf("NTProofStr = %s", got)
+	}
+}
+
+// ntlmTestServer accepts NTLM handshakes for DOMAIN\user:secret and
+// requires both legs on one connection
+func ntlmTestServer(t *testing.T, scheme string) (*httptest.Server, *[]string) {
+	challenge := unhex(t, "0123456789abcdef")
+	var mu sync.Mutex
+	var log []string
+	legs := map[string]bool{} // remote addr -> challenge sent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		name, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		msg, _ := base64.StdEncoding.DecodeString(token)
+		if name != scheme || len(msg) < 12 {
+			log = append(log, "anonymous")
+			w.Header().Set("WWW-Authenticate", scheme)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch binary.LittleEndian.Uint32(msg[8:]) {
+		case 1:
+			log = append(log, "negotiate")
+			legs[r.RemoteAddr] = true
+			c := make([]byte, 48)
+			copy(c, ntlmSignature)
+			binary.LittleEndian.PutUint32(c[8:], 2)
+			binary.LittleEndian.PutUint32(c[20:], ntlmNegotiateFlags)
+			copy(c[24:], challenge)
+			binary.LittleEndian.PutUint32(c[44:], 48)
+			w.Header().Set("WWW-Authenticate", scheme+" "+base64.StdEncoding.EncodeToString(c))
+			w.WriteHeader(http.StatusUnauthorized)
+		case 3:
+			field := func(i int) []byte {
+				pos := 12 + i*8
+				n := binary.LittleEndian.Uint16(msg[pos:])
+				off := binary.LittleEndian.Uint32(msg[pos+4:])
+				return msg[off : off+uint32(n)]
+			}
+			nt, domain, user := field(1), field(2), field(3)
+			want := ntlmV2Response(ntowfv2("DOMAIN", "user", "secret"), challenge, nt[32:40], binary.LittleEndian.Uint64(nt[24:]), nil)
+			ok := legs[r.RemoteAddr] && bytes.Equal(nt, want) &&
+				bytes.Equal(domain, ntlmString("DOMAIN")) && bytes.Equal(user, ntlmString("user"))
+			if !ok {
+				log = append(log, "rejected")
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body := new(bytes.Buffer)
+			body.ReadFrom(r.Body)
+			log = append(log, "authenticated:"+body.String())
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	return ts, &log
+}
+
+func TestExecuteRequest_NTLMAuth(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+
+	for _, scheme := range []string{"NTLM", "Negotiate"} {
+		ts, log := ntlmTestServer(t, scheme)
+		req := repository.Request{
+			Method: "POST",
+			Url:    ts.URL,
+			Body:   sql.NullString{String: `{"x":1}`, Valid: true},
+			Auth:   sql.NullString{String: `{"type":"` + strings.ToLower(scheme) + `","domain":"DOMAIN","username":"user","password":"{{pw}}"}`, Valid: true},
+		}
+		result, err := re.ExecuteRequest(ctx, req, map[string]string{"pw": "secret"})
+		ts.Close()
+		if err != nil || result.StatusCode != 200 {
+			t.Fatalf("%s: status %d, error %v %s, log %v", scheme, result.StatusCode, err, result.Error, *log)
+		}
+		if got := strings.Join(*log, ","); got != `negotiate,authenticated:{"x":1}` {
+			t.Errorf("%s handshake = %s", scheme, got)
+		}
+	}
+
+	ts, log := ntlmTestServer(t, "NTLM")
+	defer ts.Close()
+	req := repository.Request{
+		Method: "GET",
+		Url:    ts.URL,
+		Auth:   sql.NullString{String: `{"type":"ntlm","domain":"DOMAIN","username":"user","password":"wrong"}`, Valid: true},
+	}
+	result, _ := re.ExecuteRequest(ctx, req, nil)
+	if result.StatusCode != http.StatusUnauthorized || strings.Join(*log, ",") != "negotiate,rejected" {
+		t.Errorf("wrong password: status %d, log %v", result.StatusCode, *log)
+	}
+
+	req.Auth = sql.NullString{String: `{"type":"digest"}`, Valid: true}
+	if result, _ := re.ExecuteRequest(ctx, req, nil); !strings.Contains(result.Error, "not supported") {
+		t.Errorf("unsupported type error = %q", result.Error)
+	}
+}
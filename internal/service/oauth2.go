@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// OAuth2 configs fetch access tokens from a token endpoint and write them to
+// an environment variable that requests reference, e.g. an Authorization
+// header of "Bearer {{accessToken}}" or a bearer auth block. Flow runs refresh
+// expired tokens of configs with auto-refresh before each step.
+
+const (
+	// oauth2RefreshSkew renews a token this long before it expires
+	oauth2RefreshSkew = 30 * time.Second
+	maxOAuth2Response = 1 << 20
+)
+
+var (
+	// ErrNoTokenEnvironment is returned when a fetched token has no
+	// environment to go to
+	ErrNoTokenEnvironment = errors.New("no environment to store the token in: set environmentId or activate an environment")
+
+	// oauth2FetchMu serializes token fetches so steps running in parallel
+	// refresh an expired token once
+	oauth2FetchMu sync.Mutex
+)
+
+// ValidateOAuth2Grant checks the grant type and how the client authenticates
+func ValidateOAuth2Grant(grantType, clientAuth string) error {
+	if grantType != "client_credentials" && grantType != "password" {
+		return fmt.Errorf("grantType must be client_credentials or password, got %q", grantType)
+	}
+	if clientAuth != "" && clientAuth != "basic" && clientAuth != "body" {
+		return fmt.Errorf("clientAuth must be basic or body, got %q", clientAuth)
+	}
+	return nil
+}
+
+// OAuth2Token is the outcome of a token fetch; the access token itself is in
+// the environment variable
+type OAuth2Token struct {
+	EnvironmentID int64      `json:"environmentId"`
+	Variable      string     `json:"variable"`
+	TokenType     string     `json:"tokenType,omitempty"`
+	Scope         string     `json:"scope,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"` // nil when the server gave no expires_in
+	Refreshed     bool       `json:"refreshed,omitempty"` // obtained with the refresh token
+}
+
+type oauth2TokenResponse struct {
+	AccessToken      string          `json:"access_token"`
+	TokenType        string          `json:"token_type"`
+	ExpiresIn        json.RawMessage `json:"expires_in"` // some servers send a string
+	RefreshToken     string          `json:"refresh_token"`
+	Scope            string          `json:"scope"`
+	Error            string          `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+}
+
+// OAuth2Tokens fetches and refreshes the tokens of a workspace's OAuth2 configs
+type OAuth2Tokens struct {
+	queries          *repository.Queries
+	variableResolver *VariableResolver
+	fileStorage      *FileStorage
+}
+
+func NewOAuth2Tokens(queries *repository.Queries, vr *VariableResolver) *OAuth2Tokens {
+	return &OAuth2Tokens{queries: queries, variableResolver: vr}
+}
+
+// SetFileStorage lets token requests use the workspace's CA bundle and
+// client certificates; without it workspaces referring to them fail to fetch
+func (o *OAuth2Tokens) SetFileStorage(fs *FileStorage) {
+	o.fileStorage = fs
+}
+
+// Fetch runs the config's grant and stores the access token
+func (o *OAuth2Tokens) Fetch(ctx context.Context, cfg repository.Oauth2Config) (*OAuth2Token, error) {
+	oauth2FetchMu.Lock()
+	defer oauth2FetchMu.Unlock()
+	return o.fetch(ctx, cfg, false)
+}
+
+// RefreshExpired renews the tokens of the workspace's auto-refresh configs
+// that expire within oauth2RefreshSkew, using the refresh token when there
+// is one. Failures are logged; the request that needed the token reports
+// the rejection.
+func (o *OAuth2Tokens) RefreshExpired(ctx context.Context) {
+	configs, err := o.queries.ListOAuth2Configs(ctx, middleware.GetWorkspaceID(ctx))
+	if err != nil || len(configs) == 0 {
+		return
+	}
+	oauth2FetchMu.Lock()
+	defer oauth2FetchMu.Unlock()
+	for _, cfg := range configs {
+		if cfg.AutoRefresh == 0 || !cfg.ExpiresAt.Valid || time.Now().Add(oauth2RefreshSkew).Before(cfg.ExpiresAt.Time) {
+			continue
+		}
+		// Another run may have refreshed it while we waited for the lock
+		if latest, err := o.queries.GetOAuth2Config(ctx, cfg.ID); err == nil {
+			cfg = latest
+			if !cfg.ExpiresAt.Valid || time.Now().Add(oauth2RefreshSkew).Before(cfg.ExpiresAt.Time) {
+				continue
+			}
+		}
+		if _, err := o.fetch(ctx, cfg, true); err != nil {
+			log.Printf("oauth2: refreshing %q failed: %v", cfg.Name, err)
+		}
+	}
+}
+
+// fetch requests a token, trying the refresh token first when refresh is set
+func (o *OAuth2Tokens) fetch(ctx context.Context, cfg repository.Oauth2Config, refresh bool) (*OAuth2Token, error) {
+	envID, err := tokenEnvironment(ctx, o.queries, cfg.EnvironmentID, cfg.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	resolve := func(s string) string {
+		v, _ := o.variableResolver.Resolve(ctx, s, nil)
+		return v
+	}
+
+	form := url.Values{}
+	refreshed := refresh && cfg.RefreshToken != ""
+	if refreshed {
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", cfg.RefreshToken)
+	} else {
+		form.Set("grant_type", cfg.GrantType)
+		if cfg.GrantType == "password" {
+			form.Set("username", resolve(cfg.Username))
+			form.Set("password", resolve(cfg.Password))
+		}
+	}
+	if scope := resolve(cfg.Scope); scope != "" {
+		form.Set("scope", scope)
+	}
+	clientID, clientSecret := resolve(cfg.ClientID), resolve(cfg.ClientSecret)
+	if cfg.ClientAuth == "body" {
+		form.Set("client_id", clientID)
+		if clientSecret != "" {
+			form.Set("client_secret", clientSecret)
+		}
+	}
+
+	tok, err := o.request(ctx, resolve(cfg.TokenUrl), form, cfg.ClientAuth != "body", clientID, clientSecret)
+	if err != nil && refreshed {
+		// The refresh token was revoked or expired; run the grant again
+		cfg.RefreshToken = ""
+		return o.fetch(ctx, cfg, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeEnvironmentVariables(ctx, o.queries, o.variableResolver, envID, map[string]string{cfg.TokenVariable: tok.AccessToken}); err != nil {
+		return nil, err
+	}
+	result := &OAuth2Token{EnvironmentID: envID, Variable: cfg.TokenVariable, TokenType: tok.TokenType, Scope: tok.Scope, Refreshed: refreshed}
+	expires := sql.NullTime{}
+	if secs := parseExpiresIn(tok.ExpiresIn); secs > 0 {
+		t := time.Now().Add(time.Duration(secs) * time.Second).UTC()
+		expires = sql.NullTime{Time: t, Valid: true}
+		result.ExpiresAt = &t
+	}
+	refreshToken := tok.RefreshToken
+	if refreshToken == "" && refreshed {
+		refreshToken = cfg.RefreshToken // not rotated
+	}
+	if dryVariablesFrom(ctx) == nil {
+		if err := o.queries.SetOAuth2ConfigToken(ctx, repository.SetOAuth2ConfigTokenParams{
+			RefreshToken: refreshToken, ExpiresAt: expires, ID: cfg.ID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// request posts the form to the token endpoint
+func (o *OAuth2Tokens) request(ctx context.Context, tokenURL string, form url.Values, basic bool, clientID, clientSecret string) (*oauth2TokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Relay/1.0")
+	if basic && clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	client, err := CreateHTTPClient(ctx, o.queries, o.fileStorage, sql.NullInt64{})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOAuth2Response))
+	if err != nil {
+		return nil, fmt.Errorf("reading token response: %w", err)
+	}
+
+	var tok oauth2TokenResponse
+	jsonErr := json.Unmarshal(body, &tok)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || tok.Error != "" {
+		msg := tok.Error
+		if tok.ErrorDescription != "" {
+			msg += ": " + tok.ErrorDescription
+		}
+		if msg == "" {
+			msg = truncateUTF8(strings.TrimSpace(string(body)), 200)
+		}
+		return nil, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, msg)
+	}
+	if jsonErr != nil || tok.AccessToken == "" {
+		return nil, errors.New("token response has no access_token")
+	}
+	return &tok, nil
+}
+
+// tokenEnvironment picks where a token goes: the configured environment, else
+// the workspace's active one at fetch time
+func tokenEnvironment(ctx context.Context, queries *repository.Queries, envID sql.NullInt64, wsID int64) (int64, error) {
+	if envID.Valid {
+		return envID.Int64, nil
+	}
+	env, err := queries.GetActiveEnvironment(ctx, wsID)
+	if err != nil {
+		return 0, ErrNoTokenEnvironment
+	}
+	return env.ID, nil
+}
+
+// parseExpiresIn reads expires_in, which some servers send as a string
+func parseExpiresIn(raw json.RawMessage) int64 {
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return 0
+	}
+	if v, err := n.Int64(); err == nil {
+		return v
+	}
+	f, _ := n.Float64()
+	return int64(f)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+// oauth2Server issues numbered tokens for the password grant and rotates
+// refresh tokens; revoked refresh tokens are rejected
+type oauth2Server struct {
+	mu      sync.Mutex
+	issued  int
+	grants  []string
+	revoked bool
+}
+
+func (s *oauth2Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.ParseForm()
+	grant := r.PostForm.Get("grant_type")
+	s.grants = append(s.grants, grant)
+
+	switch {
+	case r.PostForm.Get("client_id") != "cli" || r.PostForm.Get("client_secret") != "s3cret":
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client"}`))
+		return
+	case grant == "password" && (r.PostForm.Get("username") != "ann" || r.PostForm.Get("password") != "pw"):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	case grant == "refresh_token" && (s.revoked || r.PostForm.Get("refresh_token") != fmt.Sprintf("rt-%d", s.issued)):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"refresh token revoked"}`))
+		return
+	}
+	s.issued++
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token":  fmt.Sprintf("at-%d", s.issued),
+		"token_type":    "Bearer",
+		"expires_in":    "3600",
+		"refresh_token": fmt.Sprintf("rt-%d", s.issued),
+	})
+}
+
+func TestOAuth2Tokens_FetchAndRefresh(t *testing.T) {
+	srv := &oauth2Server{}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	tokens := NewOAuth2Tokens(q, vr)
+	ctx := context.Background()
+
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name: "dev", Variables: sql.NullString{String: `{"user":"ann"}`, Valid: true}, WorkspaceID: 1,
+	})
+	q.ActivateEnvironment(ctx, env.ID)
+	cfg, err := q.CreateOAuth2Config(ctx, repository.CreateOAuth2ConfigParams{
+		WorkspaceID: 1, Name: "api", GrantType: "password", TokenUrl: ts.URL + "/token",
+		ClientID: "cli", ClientSecret: "s3cret", ClientAuth: "body",
+		Username: "{{user}}", Password: "pw", TokenVariable: "accessToken", AutoRefresh: 1,
+	})
+	if err != nil {
+		t.Fatalf("create config: %v", err)
+	}
+	envVar := func() string {
+		t.Helper()
+		e, _ := q.GetEnvironment(ctx, env.ID)
+		var vars map[string]string
+		json.Unmarshal([]byte(e.Variables.String), &vars)
+		return vars["accessToken"]
+	}
+
+	tok, err := tokens.Fetch(ctx, cfg)
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if tok.EnvironmentID != env.ID || tok.Variable != "accessToken" || tok.ExpiresAt == nil || tok.Refreshed {
+		t.Errorf("token = %+v", tok)
+	}
+	if v := envVar(); v != "at-1" {
+		t.Errorf("accessToken = %q, want at-1", v)
+	}
+	cfg, _ = q.GetOAuth2Config(ctx, cfg.ID)
+	if cfg.RefreshToken != "rt-1" || !cfg.ExpiresAt.Valid {
+		t.Errorf("stored refresh token %q, expires %v", cfg.RefreshToken, cfg.ExpiresAt)
+	}
+
+	// A token that is not about to expire is left alone
+	tokens.RefreshExpired(ctx)
+	if v := envVar(); v != "at-1" {
+		t.Errorf("unexpired token refreshed: %q", v)
+	}
+
+	expire := func(refreshToken string) {
+		q.SetOAuth2ConfigToken(ctx, repository.SetOAuth2ConfigTokenParams{
+			RefreshToken: refreshToken, ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}, ID: cfg.ID,
+		})
+	}
+
+	// An expired token is renewed with the refresh token
+	expire("rt-1")
+	tokens.RefreshExpired(ctx)
+	if v := envVar(); v != "at-2" {
+		t.Errorf("after refresh accessToken = %q, want at-2", v)
+	}
+	if last := srv.grants[len(srv.grants)-1]; last != "refresh_token" {
+		t.Errorf("refresh used grant %q", last)
+	}
+
+	// A revoked refresh token falls back to the password grant
+	srv.revoked = true
+	expire("rt-2")
+	tokens.RefreshExpired(ctx)
+	if v := envVar(); v != "at-3" {
+		t.Errorf("after fallback accessToken = %q, want at-3", v)
+	}
+	if got := srv.grants[len(srv.grants)-2:]; got[0] != "refresh_token" || got[1] != "password" {
+		t.Errorf("fallback grants = %v", got)
+	}
+
+	// Without auto-refresh an expired token stays
+	q.UpdateOAuth2Config(ctx, repository.UpdateOAuth2ConfigParams{
+		Name: cfg.Name, GrantType: cfg.GrantType, TokenUrl: cfg.TokenUrl, ClientID: cfg.ClientID,
+		ClientSecret: cfg.ClientSecret, ClientAuth: cfg.ClientAuth, Username: cfg.Username, Password: cfg.Password,
+		TokenVariable: cfg.TokenVariable, AutoRefresh: 0, ID: cfg.ID,
+	})
+	expire("")
+	tokens.RefreshExpired(ctx)
+	if v := envVar(); v != "at-3" {
+		t.Errorf("auto-refresh off: accessToken = %q", v)
+	}
+}
+
+func TestOAuth2Tokens_Errors(t *testing.T) {
+	srv := &oauth2Server{}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	tokens := NewOAuth2Tokens(q, NewVariableResolver(q))
+	ctx := context.Background()
+
+	cfg, _ := q.CreateOAuth2Config(ctx, repository.CreateOAuth2ConfigParams{
+		WorkspaceID: 1, Name: "api", GrantType: "client_credentials", TokenUrl: ts.URL,
+		ClientID: "cli", ClientSecret: "wrong", ClientAuth: "body", TokenVariable: "accessToken", AutoRefresh: 1,
+	})
+	if _, err := tokens.Fetch(ctx, cfg); err != ErrNoTokenEnvironment {
+		t.Errorf("no environment: err = %v", err)
+	}
+
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "dev", WorkspaceID: 1})
+	q.ActivateEnvironment(ctx, env.ID)
+	if _, err := tokens.Fetch(ctx, cfg); err == nil || err.Error() != "token endpoint returned 401: invalid_client" {
+		t.Errorf("rejected client: err = %v", err)
+	}
+}
+
+func TestFlowRunner_RefreshesOAuth2Token(t *testing.T) {
+	srv := &oauth2Server{}
+	tokenServer := httptest.NewServer(srv)
+	defer tokenServer.Close()
+	var authorization []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	ctx := context.Background()
+
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name: "dev", Variables: sql.NullString{String: `{"accessToken":"stale"}`, Valid: true}, WorkspaceID: 1,
+	})
+	q.ActivateEnvironment(ctx, env.ID)
+	cfg, _ := q.CreateOAuth2Config(ctx, repository.CreateOAuth2ConfigParams{
+		WorkspaceID: 1, Name: "api", GrantType: "client_credentials", TokenUrl: tokenServer.URL,
+		ClientID: "cli", ClientSecret: "s3cret", ClientAuth: "body",
+		EnvironmentID: sql.NullInt64{Int64: env.ID, Valid: true}, TokenVariable: "accessToken", AutoRefresh: 1,
+	})
+	q.SetOAuth2ConfigToken(ctx, repository.SetOAuth2ConfigTokenParams{
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}, ID: cfg.ID,
+	})
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "list", Method: "GET", Url: api.URL, Headers: sql.NullString{String: `{"Authorization":"Bearer {{accessToken}}"}`, Valid: true}},
+		{Name: "again", Method: "GET", Url: api.URL, Headers: sql.NullString{String: `{"Authorization":"Bearer {{accessToken}}"}`, Valid: true}},
+	})
+	result, err := fr.Run(ctx, flowID, nil)
+	if err != nil || !result.Success {
+		t.Fatalf("run: %v, %+v", err, result)
+	}
+	if len(authorization) != 2 || authorization[0] != "Bearer at-1" || authorization[1] != "Bearer at-1" {
+		t.Errorf("authorization = %v, want the refreshed token once fetched", authorization)
+	}
+	if len(srv.grants) != 1 {
+		t.Errorf("token requests = %v, want one", srv.grants)
+	}
+}
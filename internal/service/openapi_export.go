@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"relay/internal/repository"
+)
+
+// annotationPathPattern accepts the paths contract drift reports: $ for the
+// root, .field for object fields and [] for array elements ($.items[].id)
+var annotationPathPattern = regexp.MustCompile(`^\$(\.[^.\[\]]+|\[\])*$`)
+
+const maxAnnotationDescription = 2000
+
+// ValidateResponseAnnotation checks an annotation's JSON path and description
+func ValidateResponseAnnotation(path, description string) error {
+	if !annotationPathPattern.MatchString(path) {
+		return errors.New("path must look like $.field, $.items[].id or $")
+	}
+	if len(description) > maxAnnotationDescription {
+		return errors.New("description must be at most 2000 characters")
+	}
+	return nil
+}
+
+// ResponseExamplePaths returns the paths present in a JSON example, so
+// annotations that no longer match the response can be flagged
+func ResponseExamplePaths(example string) map[string]bool {
+	var v interface{}
+	if err := json.Unmarshal([]byte(example), &v); err != nil {
+		return nil
+	}
+	paths := make(map[string]bool)
+	for path := range inferJSONShape(v) {
+		paths[path] = true
+	}
+	return paths
+}
+
+// OpenAPIDocument is a minimal OpenAPI 3.0 document: paths with one response
+// schema each, inferred from the request's latest JSON response
+type OpenAPIDocument struct {
+	OpenAPI string                                  `json:"openapi"`
+	Info    OpenAPIInfo                             `json:"info"`
+	Paths   map[string]map[string]*OpenAPIOperation `json:"paths"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIOperation struct {
+	Summary    string                     `json:"summary,omitempty"`
+	Parameters []OpenAPIParameter         `json:"parameters,omitempty"`
+	Responses  map[string]OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string                 `json:"name"`
+	In       string                 `json:"in"`
+	Required bool                   `json:"required"`
+	Schema   map[string]interface{} `json:"schema"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema  map[string]interface{} `json:"schema"`
+	Example json.RawMessage        `json:"example,omitempty"`
+}
+
+// openAPIMethods are the methods OpenAPI has operations for
+var openAPIMethods = map[string]bool{
+	"GET": true, "PUT": true, "POST": true, "DELETE": true,
+	"OPTIONS": true, "HEAD": true, "PATCH": true, "TRACE": true,
+}
+
+var templateVarPattern = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// BuildCollectionOpenAPI documents the HTTP requests of a collection and its
+// sub-collections. Each response schema comes from the request's latest
+// successful JSON response, with its annotations as field descriptions and
+// deprecation flags. When two requests share a method and path the first wins.
+func BuildCollectionOpenAPI(ctx context.Context, queries *repository.Queries, coll repository.Collection) (*OpenAPIDocument, error) {
+	doc := &OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: coll.Name, Version: "1.0.0"},
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+	if err := doc.addCollection(ctx, queries, coll.ID); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (d *OpenAPIDocument) addCollection(ctx context.Context, queries *repository.Queries, collectionID int64) error {
+	parent := sql.NullInt64{Int64: collectionID, Valid: true}
+	requests, err := queries.ListRequestsByCollection(ctx, parent)
+	if err != nil {
+		return err
+	}
+	for _, req := range requests {
+		if req.ArchivedAt.Valid || !openAPIMethods[strings.ToUpper(req.Method)] {
+			continue
+		}
+		path, params := OpenAPIPath(req.Url)
+		method := strings.ToLower(req.Method)
+		if d.Paths[path] == nil {
+			d.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		if _, exists := d.Paths[path][method]; exists {
+			continue
+		}
+		annotations, err := queries.ListResponseAnnotations(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+		op := &OpenAPIOperation{Summary: req.Name, Responses: make(map[string]OpenAPIResponse)}
+		for _, p := range params {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name: p, In: "path", Required: true, Schema: map[string]interface{}{"type": "string"},
+			})
+		}
+		if example, ok := LatestJSONResponse(ctx, queries, req.ID); ok {
+			op.Responses[strconv.FormatInt(example.StatusCode.Int64, 10)] = exampleResponse(example.ResponseBody.String, annotations)
+		} else {
+			op.Responses["default"] = OpenAPIResponse{Description: "No recorded response"}
+		}
+		d.Paths[path][method] = op
+	}
+
+	children, err := queries.ListChildCollections(ctx, parent)
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := d.addCollection(ctx, queries, child.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exampleResponse(body string, annotations []repository.ResponseAnnotation) OpenAPIResponse {
+	var v interface{}
+	json.Unmarshal([]byte(body), &v)
+	byPath := make(map[string]repository.ResponseAnnotation, len(annotations))
+	for _, a := range annotations {
+		byPath[a.Path] = a
+	}
+	resp := OpenAPIResponse{
+		Description: "Example response",
+		Content: map[string]OpenAPIMediaType{
+			"application/json": {Schema: exampleSchema("$", v, byPath), Example: json.RawMessage(body)},
+		},
+	}
+	if a, ok := byPath["$"]; ok && a.Description != "" {
+		resp.Description = a.Description
+	}
+	return resp
+}
+
+// exampleSchema infers a JSON schema for v; arrays take the schema of their
+// first element
+func exampleSchema(path string, v interface{}, annotations map[string]repository.ResponseAnnotation) map[string]interface{} {
+	schema := make(map[string]interface{})
+	switch val := v.(type) {
+	case map[string]interface{}:
+		schema["type"] = "object"
+		props := make(map[string]interface{}, len(val))
+		for k, child := range val {
+			props[k] = exampleSchema(path+"."+k, child, annotations)
+		}
+		schema["properties"] = props
+	case []interface{}:
+		schema["type"] = "array"
+		items := map[string]interface{}{}
+		if len(val) > 0 {
+			items = exampleSchema(path+"[]", val[0], annotations)
+		}
+		schema["items"] = items
+	case string:
+		schema["type"] = "string"
+	case float64:
+		if val == math.Trunc(val) {
+			schema["type"] = "integer"
+		} else {
+			schema["type"] = "number"
+		}
+	case bool:
+		schema["type"] = "boolean"
+	case nil:
+		schema["nullable"] = true
+	}
+	if a, ok := annotations[path]; ok {
+		if a.Description != "" {
+			schema["description"] = a.Description
+		}
+		if a.Deprecated == 1 {
+			schema["deprecated"] = true
+		}
+	}
+	return schema
+}
+
+// OpenAPIPath turns a request URL into an OpenAPI path: the scheme and host
+// (or a leading {{baseUrl}}-style variable) and the query string are dropped,
+// and {{name}} variables become {name} path parameters
+func OpenAPIPath(rawURL string) (string, []string) {
+	path := rawURL
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if loc := templateVarPattern.FindStringIndex(path); loc != nil && loc[0] == 0 {
+		path = path[loc[1]:]
+	} else if _, rest, ok := strings.Cut(path, "://"); ok {
+		path = ""
+		if i := strings.Index(rest, "/"); i >= 0 {
+			path = rest[i:]
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	var params []string
+	seen := make(map[string]bool)
+	path = templateVarPattern.ReplaceAllStringFunc(path, func(m string) string {
+		name := templateVarPattern.FindStringSubmatch(m)[1]
+		if !seen[name] {
+			seen[name] = true
+			params = append(params, name)
+		}
+		return "{" + name + "}"
+	})
+	return path, params
+}
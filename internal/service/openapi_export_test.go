@@ -0,0 +1,39 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPIPath(t *testing.T) {
+	tests := []struct {
+		url    string
+		path   string
+		params []string
+	}{
+		{"https://api.example.com/users/{{userId}}?expand=1", "/users/{userId}", []string{"userId"}},
+		{"{{baseUrl}}/orders/{{ orderId }}/items", "/orders/{orderId}/items", []string{"orderId"}},
+		{"http://{{host}}:8080/health", "/health", nil},
+		{"https://api.example.com", "/", nil},
+		{"/a/{{id}}/b/{{id}}", "/a/{id}/b/{id}", []string{"id"}},
+	}
+	for _, tt := range tests {
+		path, params := OpenAPIPath(tt.url)
+		if path != tt.path || !reflect.DeepEqual(params, tt.params) {
+			t.Errorf("OpenAPIPath(%q) = %q %v, want %q %v", tt.url, path, params, tt.path, tt.params)
+		}
+	}
+}
+
+func TestValidateResponseAnnotation(t *testing.T) {
+	for _, path := range []string{"$", "$.id", "$.items[].id", "$[]", "$.a-b.c_d"} {
+		if err := ValidateResponseAnnotation(path, ""); err != nil {
+			t.Errorf("%q rejected: %v", path, err)
+		}
+	}
+	for _, path := range []string{"", "id", "$.", "$..id", "$.items[0]", "$.a[b]"} {
+		if err := ValidateResponseAnnotation(path, ""); err == nil {
+			t.Errorf("%q accepted", path)
+		}
+	}
+}
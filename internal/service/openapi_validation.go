@@ -0,0 +1,507 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+const (
+	maxSpecFailures    = 50
+	maxSchemaDepth     = 64 // nesting and $ref hops, so recursive schemas end
+	MaxOpenAPISpecSize = 5 << 20
+)
+
+// SpecValidation is the result of checking a response against the
+// workspace's OpenAPI specs. Failures are reported like failed assertions:
+// flow steps whose response does not match fail.
+type SpecValidation struct {
+	SpecID    int64         `json:"specId,omitempty"`
+	SpecName  string        `json:"specName,omitempty"`
+	Operation string        `json:"operation,omitempty"` // e.g. "GET /pets/{id}"
+	Passed    bool          `json:"passed"`
+	Skipped   string        `json:"skipped,omitempty"` // why nothing was checked
+	Failures  []SpecFailure `json:"failures,omitempty"`
+}
+
+// SpecFailure is one mismatch between the response and the spec
+type SpecFailure struct {
+	Path    string `json:"path,omitempty"` // in the JSON body, e.g. $.items[0].id
+	Message string `json:"message"`
+}
+
+// Summary describes the first failure, for flow errors
+func (v *SpecValidation) Summary() string {
+	if len(v.Failures) == 0 {
+		return fmt.Sprintf("response matches %s", v.Operation)
+	}
+	f := v.Failures[0]
+	msg := f.Message
+	if f.Path != "" {
+		msg = f.Path + ": " + msg
+	}
+	if len(v.Failures) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(v.Failures)-1)
+	}
+	return fmt.Sprintf("response does not match %s in %q: %s", v.Operation, v.SpecName, msg)
+}
+
+type specValidationKey struct{}
+
+// WithSpecValidation checks every response executed with ctx against the
+// workspace's OpenAPI specs (ExecuteResult.SpecValidation)
+func WithSpecValidation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, specValidationKey{}, true)
+}
+
+func specValidationEnabled(ctx context.Context) bool {
+	on, _ := ctx.Value(specValidationKey{}).(bool)
+	return on
+}
+
+// openAPISpec is a parsed OpenAPI 3 document
+type openAPISpec struct {
+	root  map[string]interface{}
+	bases []string // path prefixes of the servers
+	paths []specPath
+}
+
+type specPath struct {
+	template string
+	pattern  *regexp.Regexp
+	literal  int // characters outside {params}; more literal paths match first
+	item     map[string]interface{}
+}
+
+var specParamPattern = regexp.MustCompile(`\{[^}/]+\}`)
+
+// ValidateOpenAPISpec checks that raw is an OpenAPI 3 JSON document
+// responses can be validated against
+func ValidateOpenAPISpec(raw []byte) error {
+	_, err := parseOpenAPISpec(raw)
+	return err
+}
+
+// parseOpenAPISpec parses an OpenAPI 3 JSON document
+func parseOpenAPISpec(raw []byte) (*openAPISpec, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, errors.New("spec must be an OpenAPI JSON document")
+	}
+	if v, _ := root["openapi"].(string); !strings.HasPrefix(v, "3.") {
+		return nil, errors.New(`spec must be OpenAPI 3 ("openapi": "3.x")`)
+	}
+	paths, ok := root["paths"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("spec has no paths")
+	}
+
+	s := &openAPISpec{root: root}
+	if servers, ok := root["servers"].([]interface{}); ok {
+		for _, srv := range servers {
+			raw, _ := asObject(srv)["url"].(string)
+			if u, err := url.Parse(raw); err == nil {
+				if base := strings.TrimRight(u.Path, "/"); base != "" && !strings.Contains(base, "{") {
+					s.bases = append(s.bases, base)
+				}
+			}
+		}
+	}
+	for template, item := range paths {
+		if !strings.HasPrefix(template, "/") {
+			return nil, fmt.Errorf("path %q must start with /", template)
+		}
+		expr := "^" + regexp.QuoteMeta(template) + "$"
+		expr = specParamPattern.ReplaceAllString(strings.ReplaceAll(expr, `\{`, "{"), `[^/]+`)
+		expr = strings.ReplaceAll(expr, `\}`, "}")
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("path %q: %v", template, err)
+		}
+		literal := len(specParamPattern.ReplaceAllString(template, ""))
+		s.paths = append(s.paths, specPath{template: template, pattern: pattern, literal: literal, item: asObject(s.resolve(item))})
+	}
+	sort.Slice(s.paths, func(i, j int) bool {
+		if s.paths[i].literal != s.paths[j].literal {
+			return s.paths[i].literal > s.paths[j].literal
+		}
+		return s.paths[i].template < s.paths[j].template
+	})
+	return s, nil
+}
+
+// match finds the path template reqPath belongs to, with or without a
+// server's base path
+func (s *openAPISpec) match(reqPath string) (specPath, bool) {
+	candidates := []string{reqPath}
+	for _, base := range s.bases {
+		if rest, ok := strings.CutPrefix(reqPath, base); ok && (rest == "" || rest[0] == '/') {
+			candidates = append(candidates, "/"+strings.TrimPrefix(rest, "/"))
+		}
+	}
+	for _, p := range s.paths {
+		for _, c := range candidates {
+			if p.pattern.MatchString(c) {
+				return p, true
+			}
+		}
+	}
+	return specPath{}, false
+}
+
+// resolve follows local $refs (#/components/...)
+func (s *openAPISpec) resolve(v interface{}) interface{} {
+	for i := 0; i < maxSchemaDepth; i++ {
+		ref, ok := asObject(v)["$ref"].(string)
+		if !ok {
+			return v
+		}
+		target, ok := s.pointer(ref)
+		if !ok {
+			return nil
+		}
+		v = target
+	}
+	return nil
+}
+
+func (s *openAPISpec) pointer(ref string) (interface{}, bool) {
+	rest, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, false
+	}
+	var cur interface{} = s.root
+	for _, part := range strings.Split(rest, "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		cur, ok = asObject(cur)[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// ValidateAgainstSpecs checks a response to method on its resolved URL
+// against the operation it belongs to in the workspace's OpenAPI specs:
+// its status must be documented and its JSON body must match the schema
+// of its content type.
+func ValidateAgainstSpecs(ctx context.Context, queries *repository.Queries, method string, result *ExecuteResult) *SpecValidation {
+	rows, err := queries.ListOpenAPISpecs(ctx, middleware.GetWorkspaceID(ctx))
+	if err != nil || len(rows) == 0 {
+		return &SpecValidation{Passed: true, Skipped: "the workspace has no OpenAPI specs"}
+	}
+	u, err := url.Parse(result.ResolvedURL)
+	if err != nil {
+		return &SpecValidation{Passed: true, Skipped: "the request URL is invalid"}
+	}
+	reqPath := u.Path
+	if reqPath == "" {
+		reqPath = "/"
+	}
+
+	var spec *openAPISpec
+	var row repository.OpenapiSpec
+	var path specPath
+	for _, r := range rows {
+		s, err := parseOpenAPISpec([]byte(r.Spec))
+		if err != nil {
+			continue
+		}
+		if p, ok := s.match(reqPath); ok && (spec == nil || p.literal > path.literal) {
+			spec, row, path = s, r, p
+		}
+	}
+	if spec == nil {
+		return &SpecValidation{Passed: true, Skipped: fmt.Sprintf("no path in the workspace's OpenAPI specs matches %s", reqPath)}
+	}
+
+	v := &SpecValidation{SpecID: row.ID, SpecName: row.Name, Operation: strings.ToUpper(method) + " " + path.template}
+	v.Failures = spec.validateResponse(path, strings.ToLower(method), result)
+	v.Passed = len(v.Failures) == 0
+	return v
+}
+
+func (s *openAPISpec) validateResponse(path specPath, method string, result *ExecuteResult) []SpecFailure {
+	op := asObject(s.resolve(path.item[method]))
+	if op == nil {
+		return []SpecFailure{{Message: fmt.Sprintf("%s is not documented for %s", strings.ToUpper(method), path.template)}}
+	}
+	responses := asObject(op["responses"])
+	status := strconv.Itoa(result.StatusCode)
+	documented, ok := responses[status]
+	if !ok {
+		rng := status[:1] + "XX"
+		if documented, ok = responses[rng]; !ok {
+			if documented, ok = responses[strings.ToLower(rng)]; !ok {
+				documented, ok = responses["default"]
+			}
+		}
+	}
+	if !ok {
+		return []SpecFailure{{Message: fmt.Sprintf("status %d is not documented", result.StatusCode)}}
+	}
+
+	content := asObject(asObject(s.resolve(documented))["content"])
+	if len(content) == 0 {
+		return nil
+	}
+	contentType := ""
+	for k, v := range result.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			contentType = v
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	media, ok := content[mediaType]
+	if !ok {
+		if media, ok = content[strings.Split(mediaType, "/")[0]+"/*"]; !ok {
+			media, ok = content["*/*"]
+		}
+	}
+	if !ok {
+		documentedTypes := make([]string, 0, len(content))
+		for t := range content {
+			documentedTypes = append(documentedTypes, t)
+		}
+		sort.Strings(documentedTypes)
+		return []SpecFailure{{Message: fmt.Sprintf("content type %q is not documented (expected %s)", mediaType, strings.Join(documentedTypes, ", "))}}
+	}
+	schema, ok := asObject(media)["schema"]
+	if !ok || !(mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+
+	var body interface{}
+	if err := json.Unmarshal([]byte(result.Body), &body); err != nil {
+		return []SpecFailure{{Message: "body is not valid JSON"}}
+	}
+	sv := &schemaValidator{spec: s}
+	sv.validate(schema, body, "$", 0)
+	return sv.failures
+}
+
+// schemaValidator checks JSON values against OpenAPI 3 schemas: types,
+// nullable, enum, required/properties/additionalProperties, items, allOf,
+// anyOf, oneOf and the length/range/pattern keywords. Formats are not checked.
+type schemaValidator struct {
+	spec     *openAPISpec
+	failures []SpecFailure
+}
+
+func (sv *schemaValidator) fail(path, format string, args ...interface{}) {
+	if len(sv.failures) < maxSpecFailures {
+		sv.failures = append(sv.failures, SpecFailure{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// matches reports whether v matches schema, without recording failures
+func (sv *schemaValidator) matches(schema, v interface{}, path string, depth int) bool {
+	sub := &schemaValidator{spec: sv.spec}
+	sub.validate(schema, v, path, depth)
+	return len(sub.failures) == 0
+}
+
+func (sv *schemaValidator) validate(raw, v interface{}, path string, depth int) {
+	if depth > maxSchemaDepth {
+		return
+	}
+	schema := asObject(sv.spec.resolve(raw))
+	if schema == nil {
+		return
+	}
+
+	for _, sub := range asArray(schema["allOf"]) {
+		sv.validate(sub, v, path, depth+1)
+	}
+	if anyOf := asArray(schema["anyOf"]); len(anyOf) > 0 {
+		matched := false
+		for _, sub := range anyOf {
+			if sv.matches(sub, v, path, depth+1) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sv.fail(path, "does not match any of the anyOf schemas")
+		}
+	}
+	if oneOf := asArray(schema["oneOf"]); len(oneOf) > 0 {
+		n := 0
+		for _, sub := range oneOf {
+			if sv.matches(sub, v, path, depth+1) {
+				n++
+			}
+		}
+		if n != 1 {
+			sv.fail(path, "matches %d of the oneOf schemas, expected exactly 1", n)
+		}
+	}
+
+	types := schemaTypes(schema["type"])
+	if v == nil {
+		if nullable, _ := schema["nullable"].(bool); !nullable && len(types) > 0 && !containsString(types, "null") {
+			sv.fail(path, "must not be null")
+		}
+		return
+	}
+	if len(types) > 0 && !typeMatches(types, v) {
+		sv.fail(path, "expected %s, got %s", strings.Join(types, " or "), jsonTypeName(v))
+		return
+	}
+	if enum := asArray(schema["enum"]); len(enum) > 0 {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			sv.fail(path, "%s is not one of the enum values", compactJSON(v))
+		}
+	}
+
+	switch val := v.(type) {
+	case string:
+		n := float64(utf8.RuneCountInString(val))
+		if min, ok := schema["minLength"].(float64); ok && n < min {
+			sv.fail(path, "shorter than minLength %v", min)
+		}
+		if max, ok := schema["maxLength"].(float64); ok && n > max {
+			sv.fail(path, "longer than maxLength %v", max)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(val) {
+				sv.fail(path, "does not match pattern %s", pattern)
+			}
+		}
+	case float64:
+		exclusiveMin, _ := schema["exclusiveMinimum"].(bool)
+		exclusiveMax, _ := schema["exclusiveMaximum"].(bool)
+		if min, ok := schema["minimum"].(float64); ok && (val < min || exclusiveMin && val == min) {
+			sv.fail(path, "%v is below the minimum %v", val, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && (val > max || exclusiveMax && val == max) {
+			sv.fail(path, "%v is above the maximum %v", val, max)
+		}
+	case []interface{}:
+		n := float64(len(val))
+		if min, ok := schema["minItems"].(float64); ok && n < min {
+			sv.fail(path, "has %d items, fewer than minItems %v", len(val), min)
+		}
+		if max, ok := schema["maxItems"].(float64); ok && n > max {
+			sv.fail(path, "has %d items, more than maxItems %v", len(val), max)
+		}
+		if items, ok := schema["items"]; ok {
+			for i, item := range val {
+				sv.validate(items, item, fmt.Sprintf("%s[%d]", path, i), depth+1)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range asArray(schema["required"]) {
+			if key, ok := name.(string); ok {
+				if _, present := val[key]; !present {
+					sv.fail(path+"."+key, "missing required property")
+				}
+			}
+		}
+		props := asObject(schema["properties"])
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if prop, ok := props[k]; ok {
+				sv.validate(prop, val[k], path+"."+k, depth+1)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					sv.fail(path+"."+k, "property is not allowed")
+				}
+			case map[string]interface{}:
+				sv.validate(extra, val[k], path+"."+k, depth+1)
+			}
+		}
+	}
+}
+
+// schemaTypes reads "type": a name (3.0) or a list of names (3.1)
+func schemaTypes(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		var types []string
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func typeMatches(types []string, v interface{}) bool {
+	actual := jsonTypeName(v)
+	for _, t := range types {
+		switch {
+		case t == actual:
+			return true
+		case t == "number" && actual == "integer":
+			return true
+		}
+	}
+	return false
+}
+
+func jsonTypeName(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == float64(int64(val)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func compactJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func asObject(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
+
+func asArray(v interface{}) []interface{} {
+	a, _ := v.([]interface{})
+	return a
+}
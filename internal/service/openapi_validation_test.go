@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+const testPetSpec = `{
+  "openapi": "3.0.3",
+  "servers": [{"url": "https://api.example.com/v1"}],
+  "paths": {
+    "/pets": {
+      "get": {"responses": {"200": {"content": {"application/json": {"schema": {
+        "type": "array", "maxItems": 2, "items": {"$ref": "#/components/schemas/Pet"}
+      }}}}}}
+    },
+    "/pets/{id}": {
+      "get": {"responses": {
+        "200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+        "4XX": {"description": "error"}
+      }}
+    },
+    "/pets/mine": {
+      "get": {"responses": {"204": {"description": "none"}}}
+    }
+  },
+  "components": {"schemas": {"Pet": {
+    "type": "object",
+    "required": ["id", "name"],
+    "additionalProperties": false,
+    "properties": {
+      "id": {"type": "integer", "minimum": 1},
+      "name": {"type": "string", "minLength": 1},
+      "tag": {"type": "string", "nullable": true, "enum": ["cat", "dog"]}
+    }
+  }}}
+}`
+
+func TestValidateOpenAPISpec(t *testing.T) {
+	for _, raw := range []string{
+		`not json`,
+		`{"swagger": "2.0", "paths": {}}`,
+		`{"openapi": "3.0.0"}`,
+		`{"openapi": "3.0.0", "paths": {"pets": {}}}`,
+	} {
+		if err := ValidateOpenAPISpec([]byte(raw)); err == nil {
+			t.Errorf("%s: accepted", raw)
+		}
+	}
+	if err := ValidateOpenAPISpec([]byte(testPetSpec)); err != nil {
+		t.Errorf("valid spec: %v", err)
+	}
+}
+
+func TestOpenAPISpec_ValidateResponse(t *testing.T) {
+	spec, err := parseOpenAPISpec([]byte(testPetSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(method, path string, status int, body string) []SpecFailure {
+		t.Helper()
+		p, ok := spec.match(path)
+		if !ok {
+			t.Fatalf("%s did not match", path)
+		}
+		return spec.validateResponse(p, method, &ExecuteResult{
+			StatusCode: status,
+			Headers:    map[string]string{"Content-Type": "application/json; charset=utf-8"},
+			Body:       body,
+		})
+	}
+
+	// Literal paths win over templates, and the server base path is optional
+	if p, _ := spec.match("/v1/pets/mine"); p.template != "/pets/mine" {
+		t.Errorf("matched %s", p.template)
+	}
+	if _, ok := spec.match("/v1/pets/1/toys"); ok {
+		t.Error("matched a path with an extra segment")
+	}
+
+	if f := check("get", "/pets/1", 200, `{"id": 1, "name": "Rex", "tag": null}`); len(f) != 0 {
+		t.Errorf("valid pet: %+v", f)
+	}
+	if f := check("get", "/pets/1", 404, `not json`); len(f) != 0 {
+		t.Errorf("4XX without content: %+v", f)
+	}
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		body, want   string
+	}{
+		{"delete", "/pets/1", 204, ``, "DELETE is not documented"},
+		{"get", "/pets/1", 500, ``, "status 500 is not documented"},
+		{"get", "/pets/1", 200, `{"id": 1`, "not valid JSON"},
+		{"get", "/pets/1", 200, `{"id": 1.5, "name": "Rex"}`, "$.id: expected integer, got number"},
+		{"get", "/pets/1", 200, `{"id": 0, "name": "Rex"}`, "$.id: 0 is below the minimum 1"},
+		{"get", "/pets/1", 200, `{"id": 1}`, "$.name: missing required property"},
+		{"get", "/pets/1", 200, `{"id": 1, "name": "Rex", "age": 3}`, "$.age: property is not allowed"},
+		{"get", "/pets/1", 200, `{"id": 1, "name": "Rex", "tag": "fish"}`, `$.tag: "fish" is not one of the enum values`},
+		{"get", "/pets", 200, `[{"id": 1, "name": ""}]`, "$[0].name: shorter than minLength 1"},
+		{"get", "/pets", 200, `[{"id": 1, "name": "a"}, {"id": 2, "name": "b"}, {"id": 3, "name": "c"}]`, "more than maxItems 2"},
+	} {
+		failures := check(tc.method, tc.path, tc.status, tc.body)
+		got := ""
+		if len(failures) > 0 {
+			got = failures[0].Message
+			if failures[0].Path != "" {
+				got = failures[0].Path + ": " + got
+			}
+		}
+		if !strings.Contains(got, tc.want) {
+			t.Errorf("%s %s %d %s: failure = %q, want %q", tc.method, tc.path, tc.status, tc.body, got, tc.want)
+		}
+	}
+}
+
+func TestFlowRunner_ValidateSpec(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1", "name": "Rex"}`))
+	}))
+	defer srv.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+	ctx := context.Background()
+
+	flow, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "Pets", WorkspaceID: 1, SortOrder: 1})
+	q.CreateFlowStep(ctx, repository.CreateFlowStepParams{
+		FlowID:    flow.ID,
+		StepOrder: 1,
+		Name:      "Get pet",
+		Method:    "GET",
+		Url:       srv.URL + "/pets/1",
+		LoopCount: sql.NullInt64{Int64: 1, Valid: true},
+	})
+
+	// Without specs the step is not checked
+	result, err := fr.RunWithOptions(ctx, flow.ID, &RunOptions{ValidateSpec: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := result.Steps[0].ExecuteResult.SpecValidation; !result.Success || v == nil || v.Skipped == "" {
+		t.Errorf("no specs: success=%v validation=%+v", result.Success, v)
+	}
+
+	q.CreateOpenAPISpec(ctx, repository.CreateOpenAPISpecParams{WorkspaceID: 1, Name: "Pet Store", Spec: testPetSpec})
+	result, _ = fr.RunWithOptions(ctx, flow.ID, &RunOptions{ValidateSpec: true}, nil)
+	v := result.Steps[0].ExecuteResult.SpecValidation
+	if result.Success || v == nil || v.Passed || v.Operation != "GET /pets/{id}" {
+		t.Fatalf("mismatch: success=%v validation=%+v", result.Success, v)
+	}
+	if !strings.Contains(result.Error, `step "Get pet": response does not match GET /pets/{id} in "Pet Store": $.id: expected integer, got string`) {
+		t.Errorf("error = %q", result.Error)
+	}
+
+	// Validation is opt-in
+	if result, _ = fr.RunWithOptions(ctx, flow.ID, &RunOptions{}, nil); !result.Success || result.Steps[0].ExecuteResult.SpecValidation != nil {
+		t.Errorf("without validateSpec: success=%v", result.Success)
+	}
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"relay/internal/repository"
+)
+
+const defaultOTLPServiceName = "relay"
+
+// OTLPSettings points span export at an OpenTelemetry collector (OTLP/HTTP, JSON)
+type OTLPSettings struct {
+	// Endpoint is the collector base URL, e.g. http://otel-collector:4318;
+	// spans are posted to <endpoint>/v1/traces
+	Endpoint string `json:"endpoint"`
+	// Headers are sent with every export, e.g. a vendor API key
+	Headers map[string]string `json:"headers,omitempty"`
+	// ServiceName is the service.name resource attribute (default relay)
+	ServiceName string `json:"serviceName,omitempty"`
+}
+
+func (o OTLPSettings) Validate() error {
+	u, err := url.Parse(o.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("tracing.otlp.endpoint must be an http(s) URL")
+	}
+	for k := range o.Headers {
+		if !httpTokenPattern.MatchString(k) {
+			return fmt.Errorf("tracing.otlp.headers: %q is not a valid header name", k)
+		}
+	}
+	return nil
+}
+
+func (o OTLPSettings) tracesURL() string {
+	endpoint := strings.TrimSuffix(o.Endpoint, "/")
+	if strings.HasSuffix(endpoint, "/v1/traces") {
+		return endpoint
+	}
+	return endpoint + "/v1/traces"
+}
+
+// OTLP span kinds and status codes
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	spanStatusError  = 2
+)
+
+// exportSpan is a finished span waiting to be sent
+type exportSpan struct {
+	trace      requestTrace
+	name       string
+	kind       int
+	start, end time.Time
+	attributes map[string]any // string, int64 or bool values
+	err        string         // non-empty marks the span as failed
+}
+
+// Export queue limits: spans wait in a bounded queue and one worker posts
+// them in batches per collector, when a batch is full or on the interval
+const (
+	otlpQueueSize     = 4096
+	otlpBatchSize     = 256
+	otlpFlushInterval = 2 * time.Second
+)
+
+// OTLPExporter posts spans to a collector. Export never blocks the caller
+// and a failed export is only logged, so a collector outage cannot slow down
+// or fail an execution. When the queue is full new spans are dropped and
+// counted in the next flush's log line.
+type OTLPExporter struct {
+	client *http.Client
+
+	mu      sync.RWMutex // guards closing queue against Export
+	queue   chan otlpQueued
+	closed  bool
+	start   sync.Once
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+type otlpQueued struct {
+	settings OTLPSettings
+	span     exportSpan
+}
+
+// otlpBatch holds the pending spans of one collector configuration
+type otlpBatch struct {
+	settings OTLPSettings
+	spans    []exportSpan
+}
+
+func NewOTLPExporter() *OTLPExporter {
+	return &OTLPExporter{
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan otlpQueued, otlpQueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// Export queues spans for the batching worker, starting it on first use
+func (e *OTLPExporter) Export(settings OTLPSettings, spans ...exportSpan) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		e.dropped.Add(int64(len(spans)))
+		return
+	}
+	e.start.Do(func() { go e.run() })
+	for _, span := range spans {
+		select {
+		case e.queue <- otlpQueued{settings: settings, span: span}:
+		default:
+			e.dropped.Add(1)
+		}
+	}
+}
+
+// Shutdown stops accepting spans and waits until the queued ones are sent
+// or ctx ends
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	if !e.closed {
+		e.closed = true
+		e.start.Do(func() { close(e.done) }) // worker never started
+		close(e.queue)
+	}
+	e.mu.Unlock()
+	select {
+	case <-e.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	pending := make(map[string]*otlpBatch)
+	for {
+		select {
+		case item, ok := <-e.queue:
+			if !ok {
+				e.flush(pending)
+				return
+			}
+			key := item.settings.batchKey()
+			b := pending[key]
+			if b == nil {
+				b = &otlpBatch{settings: item.settings}
+				pending[key] = b
+			}
+			b.spans = append(b.spans, item.span)
+			if len(b.spans) >= otlpBatchSize {
+				e.post(b)
+				delete(pending, key)
+			}
+		case <-ticker.C:
+			e.flush(pending)
+		}
+	}
+}
+
+// flush sends and clears every pending batch
+func (e *OTLPExporter) flush(pending map[string]*otlpBatch) {
+	for key, b := range pending {
+		e.post(b)
+		delete(pending, key)
+	}
+	if n := e.dropped.Swap(0); n > 0 {
+		log.Printf("otlp: export queue full, dropped %d spans", n)
+	}
+}
+
+func (e *OTLPExporter) post(b *otlpBatch) {
+	if err := e.send(context.Background(), b.settings, b.spans); err != nil {
+		log.Printf("otlp: export of %d spans to %s failed: %v", len(b.spans), b.settings.Endpoint, err)
+	}
+}
+
+// batchKey identifies spans that can share one export request
+func (o OTLPSettings) batchKey() string {
+	key, _ := json.Marshal(o) // map keys are sorted
+	return string(key)
+}
+
+func (e *OTLPExporter) send(ctx context.Context, settings OTLPSettings, spans []exportSpan) error {
+	body, err := json.Marshal(otlpPayload(settings, spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, settings.tracesURL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range settings.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+// otlpPayload builds an ExportTraceServiceRequest in the OTLP JSON encoding
+// (hex IDs, 64-bit integers as strings)
+func otlpPayload(settings OTLPSettings, spans []exportSpan) map[string]any {
+	service := settings.ServiceName
+	if service == "" {
+		service = defaultOTLPServiceName
+	}
+	out := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		span := map[string]any{
+			"traceId":           s.trace.TraceID,
+			"spanId":            s.trace.SpanID,
+			"name":              s.name,
+			"kind":              s.kind,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.attributes),
+		}
+		if s.trace.ParentID != "" {
+			span["parentSpanId"] = s.trace.ParentID
+		}
+		if s.err != "" {
+			span["status"] = map[string]any{"code": spanStatusError, "message": s.err}
+		}
+		out = append(out, span)
+	}
+	return map[string]any{
+		"resourceSpans": []map[string]any{{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": service}),
+			},
+			"scopeSpans": []map[string]any{{
+				"scope": map[string]any{"name": "relay"},
+				"spans": out,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs map[string]any) []map[string]any {
+	out := make([]map[string]any, 0, len(attrs))
+	for k, v := range attrs {
+		var value map[string]any
+		switch v := v.(type) {
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, map[string]any{"key": k, "value": value})
+	}
+	return out
+}
+
+// requestSpan describes one HTTP execution as a client span. 4xx and 5xx
+// responses count as errors, as in the OpenTelemetry HTTP client conventions.
+func requestSpan(trace requestTrace, req repository.Request, result *ExecuteResult, start time.Time) exportSpan {
+	span := exportSpan{
+		trace: trace,
+		name:  req.Method,
+		kind:  spanKindClient,
+		start: start,
+		end:   start.Add(time.Duration(result.DurationMs) * time.Millisecond),
+		attributes: map[string]any{
+			"http.request.method": req.Method,
+			"url.full":            result.ResolvedURL,
+		},
+		err: result.Error,
+	}
+	if req.Name != "" {
+		span.name = req.Name
+		span.attributes["relay.request.name"] = req.Name
+	}
+	if req.ID != 0 {
+		span.attributes["relay.request.id"] = req.ID
+	}
+	if u, err := url.Parse(result.ResolvedURL); err == nil && u.Host != "" {
+		span.attributes["server.address"] = u.Hostname()
+	}
+	if result.QueuedMs > 0 {
+		span.attributes["relay.queued_ms"] = result.QueuedMs
+	}
+	if result.StatusCode > 0 {
+		span.attributes["http.response.status_code"] = int64(result.StatusCode)
+		if result.StatusCode >= 400 && span.err == "" {
+			span.err = http.StatusText(result.StatusCode)
+			span.attributes["error.type"] = strconv.Itoa(result.StatusCode)
+		}
+	}
+	return span
+}
+
+// flowRunSpan is the root span the run's step executions nest under
+func flowRunSpan(trace requestTrace, flow repository.Flow, result *FlowResult, start time.Time) exportSpan {
+	return exportSpan{
+		trace: trace,
+		name:  "flow " + flow.Name,
+		kind:  spanKindInternal,
+		start: start,
+		end:   time.Now(),
+		attributes: map[string]any{
+			"relay.flow.id":      flow.ID,
+			"relay.flow.name":    flow.Name,
+			"relay.flow.steps":   int64(len(result.Steps)),
+			"relay.flow.success": result.Success,
+		},
+		err: result.Error,
+	}
+}
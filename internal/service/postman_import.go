@@ -0,0 +1,503 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ImportedCollection is a collection tree converted from another tool,
+// ready to be created in a workspace
+type ImportedCollection struct {
+	Name      string
+	Variables map[string]string
+	Secrets   []string
+	Requests  []ImportedRequest
+	Children  []*ImportedCollection
+}
+
+// ImportedRequest holds the columns of a request to create
+type ImportedRequest struct {
+	Name       string
+	Method     string
+	URL        string
+	Headers    string
+	Body       string
+	BodyType   string
+	Auth       string
+	PreScript  string
+	PostScript string
+}
+
+// Count returns the number of collections and requests in the tree
+func (c *ImportedCollection) Count() (collections, requests int) {
+	collections, requests = 1, len(c.Requests)
+	for _, child := range c.Children {
+		cc, rc := child.Count()
+		collections += cc
+		requests += rc
+	}
+	return collections, requests
+}
+
+var ErrNotPostmanCollection = errors.New("not a Postman collection (expected info.schema of collection v2.1)")
+
+// Postman Collection v2.1 format (https://schema.postman.com/collection/json/v2.1.0/)
+
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Auth     *postmanAuth      `json:"auth"`
+	Event    []postmanEvent    `json:"event"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+// postmanItem is a folder (with Item) or a request
+type postmanItem struct {
+	Name     string            `json:"name"`
+	Item     []postmanItem     `json:"item"`
+	Request  *postmanRequest   `json:"request"`
+	Auth     *postmanAuth      `json:"auth"`
+	Event    []postmanEvent    `json:"event"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+type postmanRequest struct {
+	Method string        `json:"method"`
+	Header []postmanPair `json:"header"`
+	URL    postmanURL    `json:"url"`
+	Body   *postmanBody  `json:"body"`
+	Auth   *postmanAuth  `json:"auth"`
+	raw    string        // request given as a URL string
+}
+
+func (r *postmanRequest) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		r.raw = s
+		return nil
+	}
+	type plain postmanRequest
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+type postmanPair struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Disabled    bool   `json:"disabled"`
+	Type        string `json:"type"`
+	ContentType string `json:"contentType"`
+}
+
+// postmanURL is either a string or an object with the URL's parts
+type postmanURL struct {
+	Raw      string        `json:"raw"`
+	Protocol string        `json:"protocol"`
+	Host     postmanParts  `json:"host"`
+	Path     postmanParts  `json:"path"`
+	Query    []postmanPair `json:"query"`
+	Variable []postmanPair `json:"variable"`
+}
+
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		u.Raw = s
+		return nil
+	}
+	type plain postmanURL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+// postmanParts is a host or path, given as a string or as segments
+type postmanParts []string
+
+func (p *postmanParts) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		*p = []string{s}
+		return nil
+	}
+	var segments []json.RawMessage
+	if err := json.Unmarshal(data, &segments); err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		var str string
+		if json.Unmarshal(seg, &str) != nil {
+			// {type, value} path segments
+			var obj struct {
+				Value string `json:"value"`
+			}
+			json.Unmarshal(seg, &obj)
+			str = obj.Value
+		}
+		*p = append(*p, str)
+	}
+	return nil
+}
+
+type postmanBody struct {
+	Mode       string        `json:"mode"`
+	Raw        string        `json:"raw"`
+	URLEncoded []postmanPair `json:"urlencoded"`
+	FormData   []postmanPair `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+	Disabled bool `json:"disabled"`
+}
+
+// postmanAuth keeps each scheme's parameters as key/value pairs
+type postmanAuth struct {
+	Type   string
+	Params map[string]string
+}
+
+func (a *postmanAuth) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	json.Unmarshal(raw["type"], &a.Type)
+	a.Params = map[string]string{}
+	params := raw[a.Type]
+	// v2.1 lists parameters as [{key, value}], v2.0 as an object
+	var pairs []postmanPair
+	if json.Unmarshal(params, &pairs) == nil {
+		for _, p := range pairs {
+			a.Params[p.Key] = p.Value
+		}
+		return nil
+	}
+	var obj map[string]any
+	json.Unmarshal(params, &obj)
+	for k, v := range obj {
+		if s, ok := v.(string); ok {
+			a.Params[k] = s
+		} else if v != nil {
+			a.Params[k] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec postmanParts `json:"exec"`
+	} `json:"script"`
+	Disabled bool `json:"disabled"`
+}
+
+type postmanVariable struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+}
+
+// ParsePostmanCollection converts a Postman Collection v2.1 export into a
+// collection tree. Folders become child collections; parts Relay can't
+// represent are dropped with a warning.
+func ParsePostmanCollection(data []byte) (*ImportedCollection, []string, error) {
+	var pc postmanCollection
+	if err := json.Unmarshal(data, &pc); err != nil {
+		return nil, nil, fmt.Errorf("invalid Postman collection: %w", err)
+	}
+	if !strings.Contains(pc.Info.Schema, "schema.getpostman.com") && !strings.Contains(pc.Info.Schema, "schema.postman.com") {
+		return nil, nil, ErrNotPostmanCollection
+	}
+	if !strings.Contains(pc.Info.Schema, "v2.1") && !strings.Contains(pc.Info.Schema, "v2.0") {
+		return nil, nil, fmt.Errorf("unsupported Postman collection schema %q (expected v2.1)", pc.Info.Schema)
+	}
+
+	conv := &postmanConverter{}
+	name := strings.TrimSpace(pc.Info.Name)
+	if name == "" {
+		name = "Postman import"
+	}
+	root := conv.folder(postmanItem{
+		Name:     name,
+		Item:     pc.Item,
+		Auth:     pc.Auth,
+		Event:    pc.Event,
+		Variable: pc.Variable,
+	}, nil, name)
+	return root, conv.warnings, nil
+}
+
+type postmanConverter struct {
+	warnings []string
+}
+
+func (c *postmanConverter) warn(where, format string, args ...any) {
+	c.warnings = append(c.warnings, where+": "+fmt.Sprintf(format, args...))
+}
+
+// folder converts a folder; auth set on it applies to requests that inherit
+func (c *postmanConverter) folder(item postmanItem, inherited *postmanAuth, where string) *ImportedCollection {
+	coll := &ImportedCollection{Name: item.Name, Variables: map[string]string{}}
+	if strings.TrimSpace(coll.Name) == "" {
+		coll.Name = "Untitled folder"
+	}
+	for _, v := range item.Variable {
+		if v.Disabled || v.Key == "" {
+			continue
+		}
+		value := ""
+		if v.Value != nil {
+			value = fmt.Sprint(v.Value)
+		}
+		coll.Variables[v.Key] = value
+		if v.Type == "secret" {
+			coll.Secrets = append(coll.Secrets, v.Key)
+		}
+	}
+	for _, e := range item.Event {
+		if !e.Disabled && strings.TrimSpace(strings.Join(e.Script.Exec, "")) != "" {
+			c.warn(where, "%s scripts on folders are not supported and were skipped", e.Listen)
+		}
+	}
+	auth := inherited
+	if item.Auth != nil && item.Auth.Type != "inherit" {
+		auth = item.Auth
+	}
+
+	for _, child := range item.Item {
+		childWhere := where + " / " + child.Name
+		if child.Request == nil {
+			// An empty folder has no request and no items either
+			coll.Children = append(coll.Children, c.folder(child, auth, childWhere))
+			continue
+		}
+		coll.Requests = append(coll.Requests, c.request(child, auth, childWhere))
+	}
+	return coll
+}
+
+func (c *postmanConverter) request(item postmanItem, inherited *postmanAuth, where string) ImportedRequest {
+	pr := item.Request
+	req := ImportedRequest{Name: item.Name, Method: strings.ToUpper(strings.TrimSpace(pr.Method)), BodyType: "none"}
+	if req.Name == "" {
+		req.Name = "Untitled request"
+	}
+	if req.Method == "" {
+		req.Method = "GET"
+	}
+	if pr.raw != "" {
+		req.URL = pr.raw
+	} else {
+		req.URL = c.url(pr.URL, where)
+	}
+
+	headers := map[string]HeaderValue{}
+	for _, h := range pr.Header {
+		if h.Key != "" {
+			headers[h.Key] = HeaderValue{Value: h.Value, Enabled: !h.Disabled}
+		}
+	}
+	if pr.Body != nil && !pr.Body.Disabled {
+		c.body(&req, pr.Body, headers, where)
+	}
+
+	auth := inherited
+	if pr.Auth != nil && pr.Auth.Type != "inherit" {
+		auth = pr.Auth
+	}
+	if auth != nil {
+		req.Auth = c.auth(&req, auth, headers, where)
+	}
+
+	h, _ := json.Marshal(headers)
+	req.Headers = string(h)
+	for _, e := range item.Event {
+		if e.Disabled {
+			continue
+		}
+		script := strings.TrimSpace(strings.Join(e.Script.Exec, "\n"))
+		switch e.Listen {
+		case "prerequest":
+			req.PreScript = script
+		case "test":
+			req.PostScript = script
+		}
+	}
+	return req
+}
+
+var postmanPathVariable = regexp.MustCompile(`/:([A-Za-z_][A-Za-z0-9_.-]*)`)
+
+// url returns the raw URL, filling in :path variables that have a value
+func (c *postmanConverter) url(u postmanURL, where string) string {
+	raw := u.Raw
+	if raw == "" {
+		raw = strings.Join(u.Host, ".")
+		if u.Protocol != "" {
+			raw = u.Protocol + "://" + raw
+		}
+		if len(u.Path) > 0 {
+			raw += "/" + strings.Join(u.Path, "/")
+		}
+		var query []string
+		for _, q := range u.Query {
+			if !q.Disabled {
+				query = append(query, q.Key+"="+q.Value)
+			}
+		}
+		if len(query) > 0 {
+			raw += "?" + strings.Join(query, "&")
+		}
+	}
+	values := map[string]string{}
+	for _, v := range u.Variable {
+		values[v.Key] = v.Value
+	}
+	return postmanPathVariable.ReplaceAllStringFunc(raw, func(m string) string {
+		name := m[2:]
+		if v, ok := values[name]; ok && v != "" {
+			return "/" + v
+		}
+		c.warn(where, "path variable :%s has no value; use {{%s}} instead", name, name)
+		return m
+	})
+}
+
+func (c *postmanConverter) body(req *ImportedRequest, b *postmanBody, headers map[string]HeaderValue, where string) {
+	switch b.Mode {
+	case "raw":
+		if b.Raw == "" {
+			return
+		}
+		req.Body = b.Raw
+		switch b.Options.Raw.Language {
+		case "json":
+			req.BodyType = "json"
+		case "xml":
+			req.BodyType = "xml"
+		default:
+			req.BodyType = "text"
+			for k, h := range headers {
+				if strings.EqualFold(k, "Content-Type") && strings.Contains(h.Value, "json") {
+					req.BodyType = "json"
+				}
+			}
+		}
+	case "urlencoded":
+		var pairs []string
+		for _, p := range b.URLEncoded {
+			if !p.Disabled && p.Key != "" {
+				pairs = append(pairs, formEscape(p.Key)+"="+formEscape(p.Value))
+			}
+		}
+		req.Body = strings.Join(pairs, "&")
+		req.BodyType = "form-urlencoded"
+	case "formdata":
+		items := []formDataItem{}
+		for _, p := range b.FormData {
+			if p.Key == "" {
+				continue
+			}
+			item := formDataItem{Key: p.Key, Value: p.Value, Type: "text", Enabled: !p.Disabled, ContentType: p.ContentType}
+			if p.Type == "file" {
+				item.Type = "file"
+				item.Value = ""
+				c.warn(where, "file field %q must be re-attached", p.Key)
+			}
+			items = append(items, item)
+		}
+		data, _ := json.Marshal(items)
+		req.Body = string(data)
+		req.BodyType = "formdata"
+	case "graphql":
+		if b.GraphQL == nil {
+			return
+		}
+		payload := map[string]any{"query": b.GraphQL.Query}
+		if strings.TrimSpace(b.GraphQL.Variables) != "" {
+			var vars any
+			if err := json.Unmarshal([]byte(b.GraphQL.Variables), &vars); err == nil {
+				payload["variables"] = vars
+			} else {
+				c.warn(where, "GraphQL variables are not valid JSON and were skipped")
+			}
+		}
+		data, _ := json.Marshal(payload)
+		req.Body = string(data)
+		req.BodyType = "graphql"
+	case "file":
+		c.warn(where, "binary file bodies are not supported; the body was left empty")
+	}
+}
+
+var postmanVariableRef = regexp.MustCompile(`\{\{[^{}]*\}\}`)
+
+// formEscape URL-encodes a form value, leaving {{variables}} intact so they
+// are still substituted at run time
+func formEscape(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range postmanVariableRef.FindAllStringIndex(s, -1) {
+		b.WriteString(url.QueryEscape(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(url.QueryEscape(s[last:]))
+	return b.String()
+}
+
+// auth maps Postman auth onto headers, query parameters or the request's
+// auth block, returning the auth column
+func (c *postmanConverter) auth(req *ImportedRequest, a *postmanAuth, headers map[string]HeaderValue, where string) string {
+	p := a.Params
+	switch a.Type {
+	case "", "noauth":
+	case "bearer":
+		headers["Authorization"] = HeaderValue{Value: "Bearer " + p["token"], Enabled: true}
+	case "basic":
+		credentials := p["username"] + ":" + p["password"]
+		if postmanVariableRef.MatchString(credentials) {
+			c.warn(where, "basic auth credentials use variables, which can't be substituted once encoded; re-enter them")
+		}
+		headers["Authorization"] = HeaderValue{Value: "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials)), Enabled: true}
+	case "apikey":
+		key := p["key"]
+		if key == "" {
+			key = "X-API-Key"
+		}
+		if p["in"] == "query" {
+			sep := "?"
+			if strings.Contains(req.URL, "?") {
+				sep = "&"
+			}
+			req.URL += sep + formEscape(key) + "=" + formEscape(p["value"])
+		} else {
+			headers[key] = HeaderValue{Value: p["value"], Enabled: true}
+		}
+	case "ntlm":
+		data, _ := json.Marshal(RequestAuth{
+			Type:        "ntlm",
+			Domain:      p["domain"],
+			Username:    p["username"],
+			Password:    p["password"],
+			Workstation: p["workstation"],
+		})
+		return string(data)
+	default:
+		c.warn(where, "%s auth is not supported and was skipped", a.Type)
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const postmanSample = `{
+  "info": {"name": "Shop API", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+  "variable": [
+    {"key": "baseUrl", "value": "https://shop.test"},
+    {"key": "token", "value": "s3cret", "type": "secret"},
+    {"key": "old", "value": "x", "disabled": true}
+  ],
+  "item": [
+    {
+      "name": "Orders",
+      "item": [
+        {
+          "name": "Create order",
+          "event": [{"listen": "test", "script": {"exec": ["pm.test('ok', () => {", "  pm.response.to.have.status(201);", "});"]}}],
+          "request": {
+            "method": "post",
+            "header": [{"key": "X-Trace", "value": "1", "disabled": true}],
+            "url": {"raw": "{{baseUrl}}/orders/:orderId", "variable": [{"key": "orderId", "value": "42"}]},
+            "body": {"mode": "raw", "raw": "{\"sku\": \"A1\"}", "options": {"raw": {"language": "json"}}}
+          }
+        },
+        {
+          "name": "Login form",
+          "request": {
+            "method": "POST",
+            "auth": {"type": "noauth"},
+            "url": "{{baseUrl}}/login",
+            "body": {"mode": "urlencoded", "urlencoded": [{"key": "user", "value": "{{user}}"}, {"key": "pass", "value": "a b&c"}]}
+          }
+        }
+      ]
+    },
+    {
+      "name": "Search",
+      "request": {
+        "auth": {"type": "apikey", "apikey": [{"key": "key", "value": "api_key"}, {"key": "value", "value": "k1"}, {"key": "in", "value": "query"}]},
+        "url": {"protocol": "https", "host": ["shop", "test"], "path": ["search"], "query": [{"key": "q", "value": "shoes"}, {"key": "page", "value": "2", "disabled": true}]},
+        "body": {"mode": "graphql", "graphql": {"query": "{ items { id } }", "variables": "{\"n\": 1}"}}
+      }
+    },
+    {
+      "name": "Upload",
+      "request": {"method": "PUT", "url": "{{baseUrl}}/upload", "auth": {"type": "oauth2"},
+        "body": {"mode": "formdata", "formdata": [{"key": "name", "value": "doc"}, {"key": "file", "type": "file", "src": "/tmp/a.pdf"}]}}
+    }
+  ]
+}`
+
+func TestParsePostmanCollection(t *testing.T) {
+	root, warnings, err := ParsePostmanCollection([]byte(postmanSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Name != "Shop API" || len(root.Children) != 1 || len(root.Requests) != 2 {
+		t.Fatalf("tree = %+v", root)
+	}
+	if root.Variables["baseUrl"] != "https://shop.test" || len(root.Variables) != 2 || len(root.Secrets) != 1 || root.Secrets[0] != "token" {
+		t.Errorf("variables = %v, secrets = %v", root.Variables, root.Secrets)
+	}
+	if cols, reqs := root.Count(); cols != 2 || reqs != 4 {
+		t.Errorf("Count() = %d, %d", cols, reqs)
+	}
+
+	headers := func(r ImportedRequest) map[string]HeaderValue {
+		h := map[string]HeaderValue{}
+		json.Unmarshal([]byte(r.Headers), &h)
+		return h
+	}
+
+	create := root.Children[0].Requests[0]
+	if create.Method != "POST" || create.URL != "{{baseUrl}}/orders/42" || create.BodyType != "json" || create.Body != `{"sku": "A1"}` {
+		t.Errorf("create = %+v", create)
+	}
+	if h := headers(create); h["Authorization"].Value != "Bearer {{token}}" || h["X-Trace"].Enabled {
+		t.Errorf("inherited auth/headers = %v", h)
+	}
+	if !strings.Contains(create.PostScript, "pm.response.to.have.status(201);\n});") {
+		t.Errorf("test script = %q", create.PostScript)
+	}
+
+	login := root.Children[0].Requests[1]
+	if login.BodyType != "form-urlencoded" || login.Body != "user={{user}}&pass=a+b%26c" {
+		t.Errorf("login body = %s %q", login.BodyType, login.Body)
+	}
+	if _, ok := headers(login)["Authorization"]; ok {
+		t.Error("noauth request got the collection's auth")
+	}
+
+	search := root.Requests[0]
+	if search.Method != "GET" || search.URL != "https://shop.test/search?q=shoes&api_key=k1" {
+		t.Errorf("search url = %s %s", search.Method, search.URL)
+	}
+	if search.BodyType != "graphql" || search.Body != `{"query":"{ items { id } }","variables":{"n":1}}` {
+		t.Errorf("graphql body = %s", search.Body)
+	}
+
+	upload := root.Requests[1]
+	var items []formDataItem
+	json.Unmarshal([]byte(upload.Body), &items)
+	if upload.BodyType != "formdata" || len(items) != 2 || items[1].Type != "file" || !items[0].Enabled {
+		t.Errorf("formdata = %+v", items)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "Shop API / Upload: file field") || !strings.Contains(warnings[1], "oauth2 auth is not supported") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestParsePostmanCollection_Rejects(t *testing.T) {
+	for _, doc := range []string{
+		`{"flow": {}}`,
+		`{"info": {"name": "x", "schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`,
+	} {
+		if _, _, err := ParsePostmanCollection([]byte(doc)); err == nil {
+			t.Errorf("%s: expected an error", doc)
+		}
+	}
+}
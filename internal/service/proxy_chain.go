@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// ProxyChainSettings routes a workspace's requests through a chain: Relay's
+// in-process interception stage, then an upstream proxy, then the target.
+type ProxyChainSettings struct {
+	// Intercept passes requests through the interception stage, which
+	// captures each request exactly as it leaves Relay (after variables,
+	// cookies and signing hooks)
+	Intercept bool `json:"intercept"`
+	// UpstreamProxyID is the proxy the chain ends in for requests that inherit
+	// the workspace proxy; it takes the place of the active proxy. 0 keeps the
+	// active proxy. Requests with their own proxy setting are unaffected.
+	UpstreamProxyID int64 `json:"upstreamProxyId,omitempty"`
+}
+
+func (c ProxyChainSettings) Validate() error {
+	if c.UpstreamProxyID < 0 {
+		return errors.New("proxyChain.upstreamProxyId must not be negative")
+	}
+	return nil
+}
+
+// Hop kinds of a request's route
+const (
+	HopIntercept = "intercept"
+	HopProxy     = "proxy"
+	HopTarget    = "target"
+)
+
+// RouteHop is one stage a request passed through on its way to the target
+type RouteHop struct {
+	Kind    string `json:"kind"`
+	Address string `json:"address,omitempty"` // host:port of proxies and the target
+}
+
+// RequestTiming splits an execution's duration into phases. Connection
+// phases are to the first network hop: the proxy when there is one.
+// Reused connections report 0 for DNS, connect and TLS.
+type RequestTiming struct {
+	InterceptMs int64      `json:"interceptMs,omitempty"` // in the interception stage
+	DNSMs       int64      `json:"dnsMs"`
+	ConnectMs   int64      `json:"connectMs"`
+	TLSMs       int64      `json:"tlsMs"`
+	FirstByteMs int64      `json:"firstByteMs"` // request written → first response byte
+	Route       []RouteHop `json:"route"`
+}
+
+// InterceptedRequest is the request as captured by the interception stage
+type InterceptedRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+}
+
+// proxyChain looks up the workspace's chain settings
+func proxyChain(ctx context.Context, queries *repository.Queries) ProxyChainSettings {
+	raw, err := queries.GetWorkspaceSettings(ctx, middleware.GetWorkspaceID(ctx))
+	if err != nil {
+		return ProxyChainSettings{}
+	}
+	return ParseWorkspaceSettings(raw).ProxyChain
+}
+
+// upstreamProxy returns the URL of the chain's upstream proxy, if the
+// workspace configures one
+func upstreamProxy(ctx context.Context, queries *repository.Queries) (*url.URL, bool) {
+	id := proxyChain(ctx, queries).UpstreamProxyID
+	if id == 0 {
+		return nil, false
+	}
+	proxy, err := queries.GetProxy(ctx, id)
+	if err != nil || proxy.WorkspaceID != middleware.GetWorkspaceID(ctx) || proxy.Url == "" {
+		return nil, false
+	}
+	proxyURL, err := url.Parse(proxy.Url)
+	if err != nil {
+		return nil, false
+	}
+	return proxyURL, true
+}
+
+// interceptTransport is the interception stage: it captures each request
+// before handing it to the next hop
+type interceptTransport struct {
+	next     http.RoundTripper
+	captured *InterceptedRequest
+	elapsed  time.Duration
+}
+
+func (t *interceptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	c := &InterceptedRequest{Method: req.Method, URL: req.URL.String(), Headers: make(map[string]string, len(req.Header))}
+	for k := range req.Header {
+		c.Headers[k] = req.Header.Get(k)
+	}
+	t.captured = c
+	t.elapsed = time.Since(start)
+	return t.next.RoundTrip(req)
+}
+
+// timingRecorder collects connection phase timings through httptrace
+type timingRecorder struct {
+	mu                            sync.Mutex
+	dnsStart, connStart, tlsStart time.Time
+	wrote                         time.Time
+	dns, connect, tls, firstByte  time.Duration
+}
+
+func (r *timingRecorder) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { r.set(&r.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { r.since(&r.dns, &r.dnsStart) },
+		ConnectStart:         func(string, string) { r.set(&r.connStart) },
+		ConnectDone:          func(string, string, error) { r.since(&r.connect, &r.connStart) },
+		TLSHandshakeStart:    func() { r.set(&r.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { r.since(&r.tls, &r.tlsStart) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { r.set(&r.wrote) },
+		GotFirstResponseByte: func() { r.since(&r.firstByte, &r.wrote) },
+	}
+}
+
+func (r *timingRecorder) set(t *time.Time) {
+	r.mu.Lock()
+	*t = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *timingRecorder) since(d *time.Duration, start *time.Time) {
+	r.mu.Lock()
+	if !start.IsZero() {
+		*d = time.Since(*start)
+	}
+	r.mu.Unlock()
+}
+
+// timing assembles the phases and the route req took through transport
+func (r *timingRecorder) timing(req *http.Request, transport *http.Transport, intercept *interceptTransport) *RequestTiming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := &RequestTiming{
+		DNSMs:       r.dns.Milliseconds(),
+		ConnectMs:   r.connect.Milliseconds(),
+		TLSMs:       r.tls.Milliseconds(),
+		FirstByteMs: r.firstByte.Milliseconds(),
+	}
+	if intercept != nil {
+		t.InterceptMs = intercept.elapsed.Milliseconds()
+		t.Route = append(t.Route, RouteHop{Kind: HopIntercept})
+	}
+	if transport != nil && transport.Proxy != nil {
+		if proxyURL, err := transport.Proxy(req); err == nil && proxyURL != nil {
+			t.Route = append(t.Route, RouteHop{Kind: HopProxy, Address: proxyURL.Host})
+		}
+	}
+	t.Route = append(t.Route, RouteHop{Kind: HopTarget, Address: req.URL.Host})
+	return t
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestExecuteRequest_ProxyChain(t *testing.T) {
+	// A forward proxy that answers itself instead of dialing the target
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Header().Set("X-Via", "upstream")
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+
+	p, err := q.CreateProxy(ctx, repository.CreateProxyParams{Name: "upstream", Url: proxy.URL, WorkspaceID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := fmt.Sprintf(`{"proxyChain":{"intercept":true,"upstreamProxyId":%d}}`, p.ID)
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: settings, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := re.ExecuteAdhoc(ctx, "GET", "http://target.example/x", `{"X-Test":"1"}`, "", nil, nil)
+	if err != nil || result.Error != "" {
+		t.Fatalf("execute: %v, %s", err, result.Error)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://target.example/x" || result.Headers["X-Via"] != "upstream" {
+		t.Errorf("request did not go through the upstream proxy: %v, %v", proxied, result.Headers)
+	}
+	if result.Intercepted == nil || result.Intercepted.URL != "http://target.example/x" || result.Intercepted.Headers["X-Test"] != "1" {
+		t.Errorf("intercepted = %+v", result.Intercepted)
+	}
+	proxyURL, _ := url.Parse(proxy.URL)
+	want := []RouteHop{{Kind: HopIntercept}, {Kind: HopProxy, Address: proxyURL.Host}, {Kind: HopTarget, Address: "target.example"}}
+	if result.Timing == nil || fmt.Sprint(result.Timing.Route) != fmt.Sprint(want) {
+		t.Errorf("route = %+v, want %+v", result.Timing, want)
+	}
+
+	// Without the chain the request goes straight to the target
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{}`, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer target.Close()
+	result, err = re.ExecuteAdhoc(ctx, "GET", target.URL, "", "", nil, nil)
+	if err != nil || result.Error != "" {
+		t.Fatalf("execute: %v, %s", err, result.Error)
+	}
+	targetURL, _ := url.Parse(target.URL)
+	if result.Intercepted != nil || len(result.Timing.Route) != 1 || result.Timing.Route[0].Address != targetURL.Host {
+		t.Errorf("direct route = %+v, intercepted = %+v", result.Timing.Route, result.Intercepted)
+	}
+}
+
+func TestExecuteRequest_NetworkInfo(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("relay", "hunter2")
+	p, err := q.CreateProxy(ctx, repository.CreateProxyParams{Name: "audited", Url: proxyURL.String(), WorkspaceID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := re.ExecuteAdhoc(ctx, "GET", "http://target.example/x", "", "", nil, &p.ID)
+	if err != nil || result.Error != "" {
+		t.Fatalf("execute: %v, %s", err, result.Error)
+	}
+	want := NetworkInfo{ProxyURL: "http://relay:xxxxx@" + proxyURL.Host, Protocol: "HTTP/1.1"}
+	if result.Network == nil || *result.Network != want {
+		t.Errorf("network = %+v, want %+v", result.Network, want)
+	}
+	h, err := q.GetHistory(ctx, result.HistoryID)
+	if err != nil || strings.Contains(h.Network, "hunter2") || !strings.Contains(h.Network, `"proxyUrl":"http://relay:xxxxx@`) {
+		t.Errorf("history network = %q, %v", h.Network, err)
+	}
+
+	// An explicit direct connection records no proxy
+	direct := int64(0)
+	result, _ = re.ExecuteAdhoc(ctx, "GET", proxy.URL, "", "", nil, &direct)
+	if result.Network == nil || result.Network.ProxyURL != "" {
+		t.Errorf("direct network = %+v", result.Network)
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"relay/internal/middleware"
+)
+
+// RequestAuth is the auth block of a request, a collection or the workspace.
+// Credentials may use {{variables}}; they are resolved when the request runs.
+type RequestAuth struct {
+	// Type is "bearer", "basic", "apikey", "custom", "ntlm", "negotiate" or
+	// "session". "" or "inherit" uses the enclosing collection's block (then
+	// the workspace's); "none" sends no auth.
+	Type string `json:"type"`
+	// Session names the workspace auth session whose token the request gets
+	Session string `json:"session,omitempty"`
+	Token   string `json:"token,omitempty"` // bearer
+	// Key, Value and In are the API key's name, value and where it goes
+	// ("header", the default, or "query"); custom sends Value in Header
+	Key         string `json:"key,omitempty"`
+	Value       string `json:"value,omitempty"`
+	In          string `json:"in,omitempty"`
+	Header      string `json:"header,omitempty"`
+	Domain      string `json:"domain,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	Workstation string `json:"workstation,omitempty"`
+}
+
+// ParseRequestAuth reads an auth column. It returns nil when the block is
+// empty, inherits or is "none".
+func ParseRequestAuth(raw string) (*RequestAuth, error) {
+	if strings.TrimSpace(raw) == "" || raw == "{}" {
+		return nil, nil
+	}
+	var a RequestAuth
+	if err := json.Unmarshal([]byte(raw), &a); err != nil {
+		return nil, fmt.Errorf("invalid auth: %w", err)
+	}
+	if !a.enabled() {
+		return nil, nil
+	}
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (a RequestAuth) Validate() error {
+	switch a.Type {
+	case "bearer":
+		if a.Token == "" {
+			return errors.New("auth.token is required for bearer")
+		}
+	case "basic", "ntlm", "negotiate":
+		if a.Username == "" {
+			return fmt.Errorf("auth.username is required for %s", a.Type)
+		}
+	case "apikey":
+		if a.Key == "" {
+			return errors.New("auth.key is required for apikey")
+		}
+		if a.In != "" && a.In != "header" && a.In != "query" {
+			return fmt.Errorf("auth.in must be header or query, got %q", a.In)
+		}
+	case "custom":
+		if a.Header == "" {
+			return errors.New("auth.header is required for custom")
+		}
+	case "session":
+		if a.Session == "" {
+			return errors.New("auth.session is required for session")
+		}
+	default:
+		return fmt.Errorf("auth.type %q is not supported (bearer, basic, apikey, custom, ntlm, negotiate, session)", a.Type)
+	}
+	return nil
+}
+
+func (a *RequestAuth) enabled() bool {
+	return a != nil && a.Type != "" && a.Type != "none" && a.Type != "inherit"
+}
+
+// handshake reports whether the block authenticates through ntlmTransport
+func (a RequestAuth) handshake() bool {
+	return a.Type == "ntlm" || a.Type == "negotiate"
+}
+
+// authInherits reports whether an auth column defers to the enclosing scope
+func authInherits(raw string) bool {
+	if strings.TrimSpace(raw) == "" || raw == "{}" {
+		return true
+	}
+	var a struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal([]byte(raw), &a); err != nil {
+		return false // reported by ParseRequestAuth
+	}
+	return a.Type == "" || a.Type == "inherit"
+}
+
+// effectiveAuth returns the block a request runs with: its own, else the one
+// on its collection or nearest ancestor that sets one, else the workspace's.
+// "none" at any level stops the lookup.
+func (re *RequestExecutor) effectiveAuth(ctx context.Context, raw string, colID int64) (*RequestAuth, error) {
+	if !authInherits(raw) {
+		return ParseRequestAuth(raw)
+	}
+	for depth := 0; colID > 0 && depth < 64; depth++ {
+		c, err := re.queries.GetCollection(ctx, colID)
+		if err != nil {
+			break
+		}
+		if !authInherits(c.Auth.String) {
+			return ParseRequestAuth(c.Auth.String)
+		}
+		if !c.ParentID.Valid {
+			break
+		}
+		colID = c.ParentID.Int64
+	}
+	settings, err := re.queries.GetWorkspaceSettings(ctx, middleware.GetWorkspaceID(ctx))
+	if err != nil {
+		return nil, nil
+	}
+	if a := ParseWorkspaceSettings(settings).Auth; a.enabled() {
+		return a, nil
+	}
+	return nil, nil
+}
+
+// apply adds a bearer, basic, apikey or custom block's header to headers,
+// unless the request sets that header itself, or its query parameter to
+// rawURL. It returns the URL to send to.
+func (a RequestAuth) apply(headers map[string]string, rawURL string) string {
+	name, value := "Authorization", ""
+	switch a.Type {
+	case "bearer":
+		value = "Bearer " + a.Token
+	case "basic":
+		value = "Basic " + base64.StdEncoding.EncodeToString([]byte(a.Username+":"+a.Password))
+	case "apikey":
+		if a.In == "query" {
+			u, err := url.Parse(rawURL)
+			if err != nil || u.Query().Has(a.Key) {
+				return rawURL
+			}
+			if u.RawQuery != "" {
+				u.RawQuery += "&"
+			}
+			u.RawQuery += url.QueryEscape(a.Key) + "=" + url.QueryEscape(a.Value)
+			return u.String()
+		}
+		name, value = a.Key, a.Value
+	case "custom":
+		name, value = a.Header, a.Value
+	default:
+		return rawURL
+	}
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return rawURL
+		}
+	}
+	headers[name] = value
+	return rawURL
+}
+
+// scheme is the Authorization scheme the handshake runs under. Negotiate
+// carries a raw NTLM token, which servers offering Negotiate accept when
+// Kerberos isn't used.
+func (a RequestAuth) scheme() string {
+	if a.Type == "negotiate" {
+		return "Negotiate"
+	}
+	return "NTLM"
+}
+
+// ntlmTransport authenticates requests with the NTLM challenge-response
+// handshake. Both legs must share a keep-alive connection, so next has to
+// pool connections (a per-request http.Transport does).
+type ntlmTransport struct {
+	next http.RoundTripper
+	auth RequestAuth
+}
+
+func (t *ntlmTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The body goes out with both legs
+	body, err := replayableBody(req)
+	if err != nil {
+		return nil, err
+	}
+	scheme := t.auth.scheme()
+
+	negotiate := req.Clone(req.Context())
+	negotiate.Body, _ = body()
+	negotiate.Header.Set("Authorization", scheme+" "+base64.StdEncoding.EncodeToString(ntlmNegotiateMessage()))
+	resp, err := t.next.RoundTrip(negotiate)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	token, ok := authChallenge(resp.Header.Values("WWW-Authenticate"), scheme)
+	if !ok {
+		// Not an NTLM gateway, or the credentials were refused outright
+		return resp, nil
+	}
+	challenge, err := parseNTLMChallenge(token)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	// Drain so the connection is reused for the second leg
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	clientChallenge := make([]byte, 8)
+	rand.Read(clientChallenge)
+	msg := ntlmAuthenticateMessage(challenge, t.auth.Domain, t.auth.Username, t.auth.Password, t.auth.Workstation, clientChallenge)
+	authenticate := req.Clone(req.Context())
+	authenticate.Body, _ = body()
+	authenticate.Header.Set("Authorization", scheme+" "+base64.StdEncoding.EncodeToString(msg))
+	return t.next.RoundTrip(authenticate)
+}
+
+// authChallenge returns the decoded token of the scheme's challenge header
+func authChallenge(values []string, scheme string) ([]byte, bool) {
+	for _, v := range values {
+		name, token, _ := strings.Cut(strings.TrimSpace(v), " ")
+		if !strings.EqualFold(name, scheme) || token == "" {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(token))
+		if err == nil {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// replayableBody returns a function yielding a fresh copy of req's body
+func replayableBody(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, errors.New("reading request body: " + err.Error())
+	}
+	return func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestExecuteRequest_AuthInheritance(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+
+	q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"auth":{"type":"bearer","token":"ws-token"}}`, Valid: true}, ID: 1,
+	})
+	parent, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "API", WorkspaceID: 1})
+	child, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Users", ParentID: sql.NullInt64{Int64: parent.ID, Valid: true}, WorkspaceID: 1})
+	q.UpdateCollectionVariables(ctx, repository.UpdateCollectionVariablesParams{
+		Variables: sql.NullString{String: `{"apiKey":"k-123"}`, Valid: true}, ID: child.ID,
+	})
+
+	run := func(colID int64, auth, headers string) *http.Request {
+		t.Helper()
+		got = nil
+		req := repository.Request{
+			Method:       "GET",
+			Url:          ts.URL + "/items?page=1",
+			Headers:      sql.NullString{String: headers, Valid: headers != ""},
+			Auth:         sql.NullString{String: auth, Valid: true},
+			CollectionID: sql.NullInt64{Int64: colID, Valid: colID != 0},
+			WorkspaceID:  1,
+		}
+		result, err := re.ExecuteRequest(ctx, req, nil)
+		if err != nil || result.Error != "" || got == nil {
+			t.Fatalf("execute: %v, %+v", err, result)
+		}
+		return got
+	}
+
+	// No collection auth: the workspace's block applies
+	if h := run(child.ID, "", "").Header.Get("Authorization"); h != "Bearer ws-token" {
+		t.Errorf("workspace auth = %q", h)
+	}
+
+	// The nearest collection with a block wins; its credentials resolve in
+	// the request's scope
+	q.UpdateCollectionAuth(ctx, repository.UpdateCollectionAuthParams{
+		Auth: sql.NullString{String: `{"type":"apikey","key":"api_key","value":"{{apiKey}}","in":"query"}`, Valid: true}, ID: parent.ID,
+	})
+	r := run(child.ID, `{"type":"inherit"}`, "")
+	if r.URL.RawQuery != "page=1&api_key=k-123" || r.Header.Get("Authorization") != "" {
+		t.Errorf("collection auth: query %q, authorization %q", r.URL.RawQuery, r.Header.Get("Authorization"))
+	}
+
+	// The request's own block, and headers it sets itself, take precedence
+	r = run(child.ID, `{"type":"basic","username":"ann","password":"pw"}`, "")
+	if user, pass, ok := r.BasicAuth(); !ok || user != "ann" || pass != "pw" {
+		t.Errorf("basic auth = %q", r.Header.Get("Authorization"))
+	}
+	r = run(0, `{"type":"custom","header":"X-Signature","value":"sig"}`, `{"x-signature":"manual"}`)
+	if r.Header.Get("X-Signature") != "manual" {
+		t.Errorf("custom header over an explicit one = %q", r.Header.Get("X-Signature"))
+	}
+
+	// "none" stops inheritance at any level
+	q.UpdateCollectionAuth(ctx, repository.UpdateCollectionAuthParams{
+		Auth: sql.NullString{String: `{"type":"none"}`, Valid: true}, ID: child.ID,
+	})
+	r = run(child.ID, "", "")
+	if r.URL.RawQuery != "page=1" || r.Header.Get("Authorization") != "" {
+		t.Errorf("none on the collection: query %q, authorization %q", r.URL.RawQuery, r.Header.Get("Authorization"))
+	}
+	if h := run(0, `{"type":"none"}`, "").Header.Get("Authorization"); h != "" {
+		t.Errorf("none on the request = %q", h)
+	}
+}
+
+func TestRequestAuth_Validate(t *testing.T) {
+	for _, raw := range []string{
+		`{"type":"bearer"}`,
+		`{"type":"basic","password":"x"}`,
+		`{"type":"apikey","value":"x"}`,
+		`{"type":"apikey","key":"k","in":"cookie"}`,
+		`{"type":"custom","value":"x"}`,
+		`{"type":"digest"}`,
+	} {
+		if _, err := ParseRequestAuth(raw); err == nil {
+			t.Errorf("%s: no error", raw)
+		}
+	}
+	for _, raw := range []string{"", `{}`, `{"type":"inherit"}`, `{"type":"none"}`} {
+		if a, err := ParseRequestAuth(raw); a != nil || err != nil {
+			t.Errorf("%s = %+v, %v; want no block", raw, a, err)
+		}
+	}
+	if (WorkspaceSettings{Auth: &RequestAuth{Type: "inherit"}}).Validate() == nil {
+		t.Error("inherit accepted at the workspace level")
+	}
+}
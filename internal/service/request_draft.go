@@ -0,0 +1,154 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"relay/internal/repository"
+)
+
+// RequestDraft is an autosaved, uncommitted edit of a saved request. It is
+// a full snapshot of the editable fields so executing it doesn't depend on
+// which fields were patched.
+type RequestDraft struct {
+	Name              string `json:"name"`
+	Method            string `json:"method"`
+	URL               string `json:"url"`
+	Headers           string `json:"headers"`
+	Body              string `json:"body"`
+	BodyType          string `json:"bodyType"`
+	Cookies           string `json:"cookies"`
+	ProxyID           *int64 `json:"proxyId"` // nil = global proxy
+	PreScript         string `json:"preScript"`
+	PostScript        string `json:"postScript"`
+	ResponseTransform string `json:"responseTransform"`
+	Auth              string `json:"auth"`
+}
+
+// RequestDraftPatch holds the fields sent by one autosave; omitted fields
+// keep their draft value, so saves of different fields never overwrite each
+// other. ProxyID -1 resets to the global proxy, as in request updates.
+type RequestDraftPatch struct {
+	Name              *string `json:"name"`
+	Method            *string `json:"method"`
+	URL               *string `json:"url"`
+	Headers           *string `json:"headers"`
+	Body              *string `json:"body"`
+	BodyType          *string `json:"bodyType"`
+	Cookies           *string `json:"cookies"`
+	ProxyID           *int64  `json:"proxyId"`
+	PreScript         *string `json:"preScript"`
+	PostScript        *string `json:"postScript"`
+	ResponseTransform *string `json:"responseTransform"`
+	Auth              *string `json:"auth"`
+}
+
+// DraftFieldDiff is one field whose draft value differs from the saved request
+type DraftFieldDiff struct {
+	Field string      `json:"field"`
+	Saved interface{} `json:"saved"`
+	Draft interface{} `json:"draft"`
+}
+
+// DraftFromRequest snapshots the saved definition as the starting draft
+func DraftFromRequest(req repository.Request) RequestDraft {
+	d := RequestDraft{
+		Name:              req.Name,
+		Method:            req.Method,
+		URL:               req.Url,
+		Headers:           req.Headers.String,
+		Body:              req.Body.String,
+		BodyType:          req.BodyType.String,
+		Cookies:           req.Cookies.String,
+		PreScript:         req.PreScript.String,
+		PostScript:        req.PostScript.String,
+		ResponseTransform: req.ResponseTransform.String,
+		Auth:              req.Auth.String,
+	}
+	if req.ProxyID.Valid {
+		pid := req.ProxyID.Int64
+		d.ProxyID = &pid
+	}
+	return d
+}
+
+// ParseRequestDraft decodes stored draft data over the saved request, so
+// fields added later fall back to the saved value
+func ParseRequestDraft(raw string, req repository.Request) RequestDraft {
+	d := DraftFromRequest(req)
+	json.Unmarshal([]byte(raw), &d)
+	return d
+}
+
+// Apply merges an autosave patch into the draft
+func (d *RequestDraft) Apply(p RequestDraftPatch) {
+	set := func(dst *string, v *string) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	set(&d.Name, p.Name)
+	set(&d.Method, p.Method)
+	set(&d.URL, p.URL)
+	set(&d.Headers, p.Headers)
+	set(&d.Body, p.Body)
+	set(&d.BodyType, p.BodyType)
+	set(&d.Cookies, p.Cookies)
+	set(&d.PreScript, p.PreScript)
+	set(&d.PostScript, p.PostScript)
+	set(&d.ResponseTransform, p.ResponseTransform)
+	set(&d.Auth, p.Auth)
+	if p.ProxyID != nil {
+		if *p.ProxyID == -1 {
+			d.ProxyID = nil
+		} else {
+			pid := *p.ProxyID
+			d.ProxyID = &pid
+		}
+	}
+}
+
+// ApplyTo overwrites req's editable fields with the draft
+func (d RequestDraft) ApplyTo(req *repository.Request) {
+	req.Name = d.Name
+	req.Method = d.Method
+	req.Url = d.URL
+	req.Headers = sql.NullString{String: d.Headers, Valid: true}
+	req.Body = sql.NullString{String: d.Body, Valid: d.Body != ""}
+	req.BodyType = sql.NullString{String: d.BodyType, Valid: true}
+	req.Cookies = sql.NullString{String: d.Cookies, Valid: true}
+	req.ProxyID = sql.NullInt64{}
+	if d.ProxyID != nil {
+		req.ProxyID = sql.NullInt64{Int64: *d.ProxyID, Valid: true}
+	}
+	req.PreScript = sql.NullString{String: d.PreScript, Valid: d.PreScript != ""}
+	req.PostScript = sql.NullString{String: d.PostScript, Valid: d.PostScript != ""}
+	req.ResponseTransform = sql.NullString{String: d.ResponseTransform, Valid: d.ResponseTransform != ""}
+	req.Auth = sql.NullString{String: d.Auth, Valid: d.Auth != ""}
+}
+
+// DiffRequestDraft lists the fields the draft changes, in editor order
+func DiffRequestDraft(saved repository.Request, d RequestDraft) []DraftFieldDiff {
+	s := DraftFromRequest(saved)
+	diffs := []DraftFieldDiff{}
+	add := func(field string, a, b string) {
+		if a != b {
+			diffs = append(diffs, DraftFieldDiff{Field: field, Saved: a, Draft: b})
+		}
+	}
+	add("name", s.Name, d.Name)
+	add("method", s.Method, d.Method)
+	add("url", s.URL, d.URL)
+	add("headers", s.Headers, d.Headers)
+	add("body", s.Body, d.Body)
+	add("bodyType", s.BodyType, d.BodyType)
+	add("cookies", s.Cookies, d.Cookies)
+	if (s.ProxyID == nil) != (d.ProxyID == nil) || (s.ProxyID != nil && *s.ProxyID != *d.ProxyID) {
+		diffs = append(diffs, DraftFieldDiff{Field: "proxyId", Saved: s.ProxyID, Draft: d.ProxyID})
+	}
+	add("preScript", s.PreScript, d.PreScript)
+	add("postScript", s.PostScript, d.PostScript)
+	add("responseTransform", s.ResponseTransform, d.ResponseTransform)
+	add("auth", s.Auth, d.Auth)
+	return diffs
+}
@@ -3,26 +3,40 @@ package service
 import (
 	"bytes"
 	"context"
-	"crypto/tls"
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/textproto"
-	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"relay/internal/middleware"
 	"relay/internal/repository"
+
+	"github.com/google/uuid"
 )
 
 type RequestExecutor struct {
 	queries          *repository.Queries
 	variableResolver *VariableResolver
 	fileStorage      *FileStorage
+	hostLimiter      *HostLimiter
+	knownHostLimits  sync.Map // "workspaceID/host" -> last HostLimit read from settings
+	signingHooks     *SigningHooks
+	exporter         *OTLPExporter
+	authTokens       *authSessionTokens
+	inFlight         atomic.Int64
 }
 
 func NewRequestExecutor(queries *repository.Queries, vr *VariableResolver, fs *FileStorage) *RequestExecutor {
@@ -30,9 +44,23 @@ func NewRequestExecutor(queries *repository.Queries, vr *VariableResolver, fs *F
 		queries:          queries,
 		variableResolver: vr,
 		fileStorage:      fs,
+		hostLimiter:      NewHostLimiter(),
+		exporter:         NewOTLPExporter(),
+		authTokens:       newAuthSessionTokens(),
 	}
 }
 
+// Shutdown sends the trace spans still queued for export
+func (re *RequestExecutor) Shutdown(ctx context.Context) error {
+	return re.exporter.Shutdown(ctx)
+}
+
+// SetSigningHooks enables collection signing hooks; without it requests in a
+// collection with a hook configured fail instead of going out unsigned
+func (re *RequestExecutor) SetSigningHooks(hooks *SigningHooks) {
+	re.signingHooks = hooks
+}
+
 type ExecuteResult struct {
 	StatusCode        int                 `json:"statusCode"`
 	Headers           map[string]string   `json:"headers"`
@@ -41,10 +69,47 @@ type ExecuteResult struct {
 	BodyBase64        string              `json:"bodyBase64,omitempty"`
 	BodySize          int64               `json:"bodySize"`
 	IsBinary          bool                `json:"isBinary,omitempty"`
+	Charset           string              `json:"charset,omitempty"` // declared response charset; Body is always UTF-8
+	Preview           *BinaryPreview      `json:"preview,omitempty"` // binary responses only
 	DurationMs        int64               `json:"durationMs"`
+	QueuedMs          int64               `json:"queuedMs,omitempty"` // wait for the host limit
 	Error             string              `json:"error,omitempty"`
+	Unreachable       bool                `json:"unreachable,omitempty"` // network down (DNS, route, dial timeout)
 	ResolvedURL       string              `json:"resolvedUrl"`
 	ResolvedHeaders   map[string]string   `json:"resolvedHeaders"`
+	OriginalBody      string              `json:"originalBody,omitempty"`
+	TransformError    string              `json:"transformError,omitempty"`
+	TraceID           string              `json:"traceId,omitempty"`       // shared by all requests of a flow run
+	VariableTrace     []VariableSource    `json:"variableTrace,omitempty"` // with WithVariableTrace: where each variable came from
+	Timing            *RequestTiming      `json:"timing,omitempty"`
+	Intercepted       *InterceptedRequest `json:"intercepted,omitempty"` // captured by the proxy chain's interception stage
+	Rewrites          []string            `json:"rewrites,omitempty"`    // names of the workspace rewrite rules applied
+	HistoryID         int64               `json:"historyId,omitempty"`   // the execution's history entry
+	Parts             []ResponsePart      `json:"parts,omitempty"`       // multipart/mixed and multipart/form-data responses
+	PartsError        string              `json:"partsError,omitempty"`
+	AuthSession       string              `json:"authSession,omitempty"`     // workspace auth session whose token was injected
+	SafeModeBlocked   bool                `json:"safeModeBlocked,omitempty"` // not sent: safe mode blocked it (reason in Error)
+	GRPCStatus        string              `json:"grpcStatus,omitempty"`      // gRPC requests: status code name (OK, NotFound, ...)
+	Attempts          int                 `json:"attempts,omitempty"`        // requests with a retry policy: attempts made, including the first
+	AttemptGroupID    string              `json:"attemptGroupId,omitempty"`  // shared by the history entries of those attempts
+	AttemptHistory    []AttemptSummary    `json:"attemptHistory,omitempty"`  // every attempt, in order
+	Fault             string              `json:"fault,omitempty"`           // chaos fault injected by the run: latency, drop or error
+	SpecValidation    *SpecValidation     `json:"specValidation,omitempty"`  // with WithSpecValidation: checked against the workspace's OpenAPI specs
+	Redirects         []RedirectHop       `json:"redirects,omitempty"`       // redirect responses followed, in order
+	Network           *NetworkInfo        `json:"network,omitempty"`         // proxy, TLS mode and protocol actually used
+
+	sendFailed bool // no response: the request could not be sent or timed out
+}
+
+// RawBody returns the response bytes as received: BodyBase64 holds them for
+// binary and transcoded responses, Body otherwise
+func (r *ExecuteResult) RawBody() []byte {
+	if r.BodyBase64 != "" {
+		if b, err := base64.StdEncoding.DecodeString(r.BodyBase64); err == nil {
+			return b
+		}
+	}
+	return []byte(r.Body)
 }
 
 type FormDataFile struct {
@@ -60,6 +125,22 @@ type RequestOverrides struct {
 	BodyType      string
 	ProxyID       *int64
 	FormDataFiles map[int]FormDataFile
+	// ResponseTransform replaces the saved transform when non-nil ("" disables it)
+	ResponseTransform *string
+	// Draft replaces the saved definition before the inline overrides apply
+	Draft *RequestDraft
+}
+
+// Proxy returns the proxy setting that replaces saved, if any
+func (o *RequestOverrides) Proxy(saved sql.NullInt64) sql.NullInt64 {
+	if o == nil || o.ProxyID == nil {
+		return saved
+	}
+	if *o.ProxyID == -1 {
+		// -1 means reset to global (NULL)
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *o.ProxyID, Valid: true}
 }
 
 func (re *RequestExecutor) Execute(ctx context.Context, requestID int64, runtimeVars map[string]string, overrides *RequestOverrides) (*ExecuteResult, error) {
@@ -72,6 +153,9 @@ func (re *RequestExecutor) Execute(ctx context.Context, requestID int64, runtime
 
 	// Apply overrides if provided
 	if overrides != nil {
+		if overrides.Draft != nil {
+			overrides.Draft.ApplyTo(&req)
+		}
 		if overrides.Method != "" {
 			req.Method = overrides.Method
 		}
@@ -87,14 +171,9 @@ func (re *RequestExecutor) Execute(ctx context.Context, requestID int64, runtime
 		if overrides.BodyType != "" {
 			req.BodyType = sql.NullString{String: overrides.BodyType, Valid: true}
 		}
-		if overrides.ProxyID != nil {
-			v := *overrides.ProxyID
-			if v == -1 {
-				// -1 means reset to global (NULL)
-				req.ProxyID = sql.NullInt64{}
-			} else {
-				req.ProxyID = sql.NullInt64{Int64: v, Valid: true}
-			}
+		req.ProxyID = overrides.Proxy(req.ProxyID)
+		if overrides.ResponseTransform != nil {
+			req.ResponseTransform = sql.NullString{String: *overrides.ResponseTransform, Valid: true}
 		}
 		formFiles = overrides.FormDataFiles
 	}
@@ -228,7 +307,7 @@ func escapeQuotes(s string) string {
 
 // buildCookieHeader parses the cookies JSON (same format as headers: {"name": {"value": "val", "enabled": true}})
 // and builds a Cookie header string like "name1=val1; name2=val2".
-func (re *RequestExecutor) buildCookieHeader(ctx context.Context, cookiesJSON string, runtimeVars map[string]string, collectionID ...int64) string {
+func buildCookieHeader(ctx context.Context, vr *VariableResolver, cookiesJSON string, runtimeVars map[string]string, collectionID ...int64) string {
 	var parsed map[string]json.RawMessage
 	if err := json.Unmarshal([]byte(cookiesJSON), &parsed); err != nil {
 		return ""
@@ -246,14 +325,14 @@ func (re *RequestExecutor) buildCookieHeader(ctx context.Context, cookiesJSON st
 			if err2 := json.Unmarshal(raw, &strVal); err2 != nil {
 				continue
 			}
-			resolved, _ := re.variableResolver.Resolve(ctx, strVal, runtimeVars, collectionID...)
+			resolved, _ := vr.Resolve(ctx, strVal, runtimeVars, collectionID...)
 			pairs = append(pairs, name+"="+resolved)
 			continue
 		}
 		if !obj.Enabled {
 			continue
 		}
-		resolved, _ := re.variableResolver.Resolve(ctx, obj.Value, runtimeVars, collectionID...)
+		resolved, _ := vr.Resolve(ctx, obj.Value, runtimeVars, collectionID...)
 		pairs = append(pairs, name+"="+resolved)
 	}
 	return strings.Join(pairs, "; ")
@@ -291,8 +370,57 @@ func isTextContentType(ct string) bool {
 	return false
 }
 
+// executeRequestInternal sends the request and, with WithSpecValidation,
+// checks its response against the workspace's OpenAPI specs
 func (re *RequestExecutor) executeRequestInternal(ctx context.Context, req repository.Request, runtimeVars map[string]string, formFiles map[int]FormDataFile) (*ExecuteResult, error) {
+	result, err := re.executeWithRetries(ctx, req, runtimeVars, formFiles)
+	if err == nil && specValidationEnabled(ctx) && result.StatusCode != 0 && req.Method != GRPCMethod {
+		result.SpecValidation = ValidateAgainstSpecs(ctx, re.queries, req.Method, result)
+	}
+	return result, err
+}
+
+// executeWithRetries sends the request, then resends it per its retry
+// policy.
+// Every attempt is saved to history, linked by an attempt group when the
+// request has a retry policy; the result is the last attempt's.
+func (re *RequestExecutor) executeWithRetries(ctx context.Context, req repository.Request, runtimeVars map[string]string, formFiles map[int]FormDataFile) (*ExecuteResult, error) {
+	retryOn, _ := ParseRetryStatuses(req.RetryOnStatus)
+	var group string
+	if req.RetryCount > 0 {
+		group = uuid.NewString()
+	}
+	var attempts []AttemptSummary
+	for attempt := 1; ; attempt++ {
+		result, err := re.executeOnce(withAttempt(ctx, group, attempt), req, runtimeVars, formFiles)
+		if err != nil {
+			return result, err
+		}
+		if group != "" {
+			attempts = append(attempts, summarizeAttempt(attempt, result))
+			result.Attempts = attempt
+			result.AttemptGroupID = group
+			result.AttemptHistory = attempts
+		}
+		if int64(attempt) > req.RetryCount || !shouldRetry(result, retryOn) {
+			return result, nil
+		}
+		select {
+		case <-ctx.Done():
+			return result, nil
+		case <-time.After(retryDelay(req.RetryBackoffMs, attempt)):
+		}
+	}
+}
+
+// executeOnce makes a single attempt at sending the request
+func (re *RequestExecutor) executeOnce(ctx context.Context, req repository.Request, runtimeVars map[string]string, formFiles map[int]FormDataFile) (*ExecuteResult, error) {
 	result := &ExecuteResult{}
+	ctx, varTrace := startVariableTrace(ctx)
+	if varTrace != nil {
+		defer func() { result.VariableTrace = varTrace.list() }()
+	}
+	ctx, locked := watchLockedSecrets(ctx)
 
 	// Extract collectionID for variable resolution
 	var colID int64
@@ -320,12 +448,56 @@ func (re *RequestExecutor) executeRequestInternal(ctx context.Context, req repos
 	}
 	result.ResolvedHeaders = resolvedHeaders
 
+	// Resolve the credentials of the request's own or inherited auth block
+	auth, err := re.effectiveAuth(ctx, req.Auth.String, colID)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if auth != nil {
+		for _, f := range []*string{&auth.Token, &auth.Key, &auth.Value, &auth.Header, &auth.Domain, &auth.Username, &auth.Password, &auth.Workstation} {
+			*f, _ = re.variableResolver.Resolve(ctx, *f, runtimeVars, colID)
+		}
+		resolvedURL = auth.apply(resolvedHeaders, resolvedURL)
+		result.ResolvedURL = resolvedURL
+	}
+
+	// Log in through the workspace auth session the request depends on
+	session, err := re.authSession(ctx, auth, resolvedURL, resolvedHeaders)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if session != nil {
+		token, err := re.authSessionToken(ctx, *session, runtimeVars)
+		if err != nil {
+			result.Error = fmt.Sprintf("auth session %q: %v", session.Name, err)
+			return result, nil
+		}
+		resolvedHeaders[session.header()] = session.headerValue(token)
+		result.AuthSession = session.Name
+	}
+
+	if req.Method == GRPCMethod {
+		return re.executeGRPC(ctx, req, runtimeVars, colID, result)
+	}
+
 	// Create HTTP client with proxy if active
 	client, err := re.createHTTPClient(ctx, req.ProxyID)
 	if err != nil {
 		result.Error = err.Error()
 		return result, nil
 	}
+	client.Jar = newCookieJar(ctx, re.queries)
+	client.Timeout = requestTimeout(req, client.Timeout)
+	redirects := newRedirectRecorder(req)
+	// Every hop goes through safe mode, not only the first request
+	redirects.block = func(next *http.Request) string { return re.safeModeBlock(ctx, next) }
+	client.CheckRedirect = redirects.checkRedirect
+	lp := longPollFrom(ctx)
+	if lp != nil {
+		client.Timeout = lp.opts.timeout()
+	}
 
 	// Build request body
 	var bodyReader io.Reader
@@ -382,7 +554,7 @@ func (re *RequestExecutor) executeRequestInternal(ctx context.Context, req repos
 
 	// Merge cookies from cookies field into Cookie header
 	if req.Cookies.Valid && req.Cookies.String != "" && req.Cookies.String != "{}" {
-		cookiePairs := re.buildCookieHeader(ctx, req.Cookies.String, runtimeVars, colID)
+		cookiePairs := buildCookieHeader(ctx, re.variableResolver, req.Cookies.String, runtimeVars, colID)
 		if cookiePairs != "" {
 			existing := httpReq.Header.Get("Cookie")
 			if existing != "" {
@@ -393,24 +565,149 @@ func (re *RequestExecutor) executeRequestInternal(ctx context.Context, req repos
 		}
 	}
 
+	// Correlation headers go in before signing so the hook can cover them
+	tracing := re.tracingSettings(ctx)
+	trace, tracingHeaders := injectTracingHeaders(ctx, httpReq, tracing)
+	result.TraceID = trace.TraceID
+	for k, v := range tracingHeaders {
+		result.ResolvedHeaders[k] = v
+	}
+
+	// Workspace rewrite rules go in before signing so the signature covers the rewritten request
+	if rules := re.rewriteRules(ctx); len(rules) > 0 {
+		applied, err := applyRewriteRules(httpReq, rules, re.variableResolver.resolveFunc(ctx, runtimeVars, colID))
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		if len(applied) > 0 {
+			result.Rewrites = applied
+			result.ResolvedURL = httpReq.URL.String()
+			for k := range httpReq.Header {
+				result.ResolvedHeaders[k] = httpReq.Header.Get(k)
+			}
+		}
+	}
+
+	// Safe mode checks the final method and host, after any rewrite
+	if reason := re.safeModeBlock(ctx, httpReq); reason != "" {
+		result.Error = reason
+		result.SafeModeBlocked = true
+		return result, nil
+	}
+
+	// Let the collection's signing hook rewrite the outgoing request
+	if hook := re.signingHook(ctx, colID); hook != nil {
+		for k, v := range hook.Config {
+			hook.Config[k], _ = re.variableResolver.Resolve(ctx, v, runtimeVars, colID)
+		}
+		if err := re.signingHooks.Apply(ctx, *hook, httpReq); err != nil {
+			result.Error = "Signing hook failed: " + err.Error()
+			return result, nil
+		}
+		result.ResolvedURL = httpReq.URL.String()
+		result.ResolvedHeaders = make(map[string]string, len(httpReq.Header))
+		for k := range httpReq.Header {
+			result.ResolvedHeaders[k] = httpReq.Header.Get(k)
+		}
+		// The hook may have changed the method or host
+		if reason := re.safeModeBlock(ctx, httpReq); reason != "" {
+			result.Error = reason
+			result.SafeModeBlocked = true
+			return result, nil
+		}
+	}
+
+	// Never send a request that needed a secret the workspace key could not decrypt
+	if err := locked.check(); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	// Wait for the target host's concurrency/delay budget
+	release, ok := re.acquireHost(ctx, httpReq.URL.Host, result)
+	if !ok {
+		return result, nil
+	}
+	defer release()
+
 	// Execute request
 	start := time.Now()
+	if injectFault(ctx, result) {
+		// Injected latency counts towards the request's duration
+		result.DurationMs = time.Since(start).Milliseconds()
+		return result, nil
+	}
+	if tracing.OTLP != nil {
+		defer func() {
+			re.exporter.Export(*tracing.OTLP, requestSpan(trace, req, result, start))
+		}()
+	}
+	transport := httpTransport(client.Transport)
+	connection := client.Transport
+	var intercept *interceptTransport
+	if proxyChain(ctx, re.queries).Intercept {
+		intercept = &interceptTransport{next: client.Transport}
+		client.Transport = intercept
+	}
+	if auth != nil && auth.handshake() {
+		// Outermost, so the interception stage sees both legs of the handshake
+		client.Transport = &ntlmTransport{next: client.Transport, auth: *auth}
+	}
+	timing := &timingRecorder{}
+	httpReq = httpReq.WithContext(httptrace.WithClientTrace(httpReq.Context(), timing.trace()))
+	var watch *longPollWatch
+	if lp != nil {
+		watch = lp.watch(start)
+		defer watch.stop()
+	}
+	re.inFlight.Add(1)
 	resp, err := client.Do(httpReq)
+	re.inFlight.Add(-1)
+	re.recordExecution(ctx, req)
+	result.Redirects = redirects.hops
 	duration := time.Since(start)
 	result.DurationMs = duration.Milliseconds()
+	result.Timing = timing.timing(httpReq, transport, intercept)
+	result.Network = networkInfo(httpReq, connection, resp)
+	if intercept != nil {
+		result.Intercepted = intercept.captured
+	}
 
 	if err != nil {
 		result.Error = err.Error()
-		re.saveHistory(ctx, req, result, nil)
+		result.Unreachable = isNetworkUnreachable(err)
+		result.sendFailed = true
+		if redirects.blocked != "" {
+			// The first request went out; safe mode stopped a later hop,
+			// which retrying would not change
+			result.Error = redirects.blocked
+			result.sendFailed = false
+		}
+		result.HistoryID = re.saveHistory(ctx, req, result, nil)
 		return result, nil
 	}
 	defer resp.Body.Close()
+	if session != nil && resp.StatusCode == http.StatusUnauthorized {
+		// The token was revoked early; the next request logs in again
+		re.authTokens.forget(ctx, *session)
+	}
+
+	var body io.Reader = resp.Body
+	if watch != nil {
+		ct := resp.Header.Get("Content-Type")
+		watch.receiving(resp.StatusCode, ct == "" || isTextContentType(ct))
+		body = watch.body(resp.Body)
+	}
 
 	// Read response (limit to 50MB)
-	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 50*1024*1024))
+	respBody, err := io.ReadAll(io.LimitReader(body, 50*1024*1024))
 	if err != nil {
 		result.Error = err.Error()
-		return result, nil
+		if watch == nil {
+			return result, nil
+		}
+		// Keep what a long poll received before it timed out or broke off
 	}
 
 	result.StatusCode = resp.StatusCode
@@ -429,58 +726,190 @@ func (re *RequestExecutor) executeRequestInternal(ctx context.Context, req repos
 	// Detect binary vs text based on Content-Type
 	ct := resp.Header.Get("Content-Type")
 	if ct == "" || isTextContentType(ct) {
-		result.Body = string(respBody)
+		var converted bool
+		result.Body, result.Charset, converted = DecodeResponseText(ct, respBody)
+		if converted {
+			// Keep the original bytes downloadable
+			result.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+		}
 	} else {
 		result.IsBinary = true
 		result.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+		result.Preview = BuildBinaryPreview(respBody)
+	}
+	if isMultipartResponse(ct) {
+		result.Parts, err = ParseMultipartResponse(ct, respBody)
+		if err != nil {
+			result.PartsError = err.Error()
+		}
 	}
 
-	// Save to history
-	re.saveHistory(ctx, req, result, nil)
+	// Save to history (raw body, before any transform)
+	result.HistoryID = re.saveHistory(ctx, req, result, nil)
+
+	if req.ResponseTransform.Valid && req.ResponseTransform.String != "" && !result.IsBinary {
+		applyResponseTransform(req.ResponseTransform.String, result)
+	}
 
 	return result, nil
 }
 
-func (re *RequestExecutor) createHTTPClient(ctx context.Context, proxyID sql.NullInt64) (*http.Client, error) {
-	return CreateHTTPClient(ctx, re.queries, proxyID)
+// applyResponseTransform replaces result.Body with the transformed body,
+// keeping the raw body in OriginalBody. On failure the body is left untouched.
+func applyResponseTransform(transform string, result *ExecuteResult) {
+	transformed, err := TransformResponseBody(transform, result)
+	if err != nil {
+		result.TransformError = err.Error()
+		return
+	}
+	result.OriginalBody = result.Body
+	result.Body = transformed
 }
 
-// CreateHTTPClient creates an HTTP client with optional proxy configuration.
-// Shared by RequestExecutor and WebSocketRelay.
-func CreateHTTPClient(ctx context.Context, queries *repository.Queries, proxyID sql.NullInt64) (*http.Client, error) {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+// isNetworkUnreachable reports whether err means the target network could not
+// be reached at all, as opposed to the server refusing or failing the request
+func isNetworkUnreachable(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		// "no such host" from a working resolver is a bad URL, not an outage
+		return !dnsErr.IsNotFound || dnsErr.IsTimeout || dnsErr.IsTemporary
 	}
+	if errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETDOWN) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout()
+}
 
-	if !proxyID.Valid {
-		// NULL → inherit global active proxy
-		wsID := middleware.GetWorkspaceID(ctx)
-		proxy, err := queries.GetActiveProxy(ctx, wsID)
-		if err == nil && proxy.Url != "" {
-			proxyURL, err := url.Parse(proxy.Url)
-			if err == nil {
-				transport.Proxy = http.ProxyURL(proxyURL)
-			}
+// signingHook returns the hook configured on the collection or its nearest ancestor
+func (re *RequestExecutor) signingHook(ctx context.Context, colID int64) *SigningHookConfig {
+	for depth := 0; colID > 0 && depth < 64; depth++ {
+		c, err := re.queries.GetCollection(ctx, colID)
+		if err != nil {
+			return nil
 		}
-	} else if proxyID.Int64 > 0 {
-		// > 0 → use specific proxy
-		proxy, err := queries.GetProxy(ctx, proxyID.Int64)
-		if err == nil && proxy.Url != "" {
-			proxyURL, err := url.Parse(proxy.Url)
-			if err == nil {
-				transport.Proxy = http.ProxyURL(proxyURL)
-			}
+		if cfg := ParseSigningHookConfig(c.SigningHook); cfg != nil {
+			return cfg
+		}
+		if !c.ParentID.Valid {
+			return nil
 		}
+		colID = c.ParentID.Int64
+	}
+	return nil
+}
+
+// acquireHost waits for the host's concurrency/delay budget and records the
+// wait on result. When ok is false result.Error is set and nothing may be sent.
+func (re *RequestExecutor) acquireHost(ctx context.Context, host string, result *ExecuteResult) (release func(), ok bool) {
+	limit, err := re.hostLimit(ctx, host)
+	if err != nil {
+		result.Error = "Host limit unavailable: " + err.Error()
+		return nil, false
+	}
+	queuedAt := time.Now()
+	release, err = re.hostLimiter.Acquire(ctx, host, limit)
+	if err != nil {
+		result.Error = "Cancelled while waiting for host limit: " + err.Error()
+		return nil, false
+	}
+	result.QueuedMs = time.Since(queuedAt).Milliseconds()
+	return release, true
+}
+
+// hostLimit looks up the workspace's throttling for host. If the settings
+// can't be read it keeps the last limit seen for the host, and fails when
+// there is none, so a lookup error never lifts the limit.
+func (re *RequestExecutor) hostLimit(ctx context.Context, host string) (HostLimit, error) {
+	wsID := middleware.GetWorkspaceID(ctx)
+	key := fmt.Sprintf("%d/%s", wsID, host)
+	raw, err := re.queries.GetWorkspaceSettings(ctx, wsID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		last, ok := re.knownHostLimits.Load(key)
+		if !ok {
+			return HostLimit{}, err
+		}
+		log.Printf("host limit: workspace %d settings: %v (keeping last limit for %s)", wsID, err, host)
+		return last.(HostLimit), nil
+	}
+	limit := ParseWorkspaceSettings(raw).HostLimits.For(host)
+	re.knownHostLimits.Store(key, limit)
+	return limit, nil
+}
+
+// InFlight returns the number of HTTP requests currently waiting on a response
+func (re *RequestExecutor) InFlight() int64 {
+	return re.inFlight.Load()
+}
+
+// PruneHostLimits drops idle per-host limiter state
+func (re *RequestExecutor) PruneHostLimits() int {
+	return re.hostLimiter.PruneIdle()
+}
+
+// tracingSettings looks up the workspace's correlation header options
+func (re *RequestExecutor) tracingSettings(ctx context.Context) TracingSettings {
+	raw, err := re.queries.GetWorkspaceSettings(ctx, middleware.GetWorkspaceID(ctx))
+	if err != nil {
+		return TracingSettings{}
 	}
-	// proxyID.Int64 == 0 → no proxy (direct connection), transport.Proxy stays nil
+	return ParseWorkspaceSettings(raw).Tracing
+}
+
+func (re *RequestExecutor) rewriteRules(ctx context.Context) []RewriteRule {
+	raw, err := re.queries.GetWorkspaceSettings(ctx, middleware.GetWorkspaceID(ctx))
+	if err != nil {
+		return nil
+	}
+	return ParseWorkspaceSettings(raw).RewriteRules
+}
+
+func (re *RequestExecutor) authSessions(ctx context.Context) []AuthSession {
+	raw, err := re.queries.GetWorkspaceSettings(ctx, middleware.GetWorkspaceID(ctx))
+	if err != nil {
+		return nil
+	}
+	return ParseWorkspaceSettings(raw).AuthSessions
+}
+
+func (re *RequestExecutor) createHTTPClient(ctx context.Context, proxyID sql.NullInt64) (*http.Client, error) {
+	return CreateHTTPClient(ctx, re.queries, re.fileStorage, proxyID)
+}
 
-	return &http.Client{
-		Transport: transport,
-		Timeout:   60 * time.Second,
-	}, nil
+type skipHistoryKey struct{}
+
+// withoutHistory marks ctx so executions are not recorded in request history
+// (e.g. periodic monitor checks, which keep their own results)
+func withoutHistory(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipHistoryKey{}, true)
+}
+
+type executionGroupKey struct{}
+
+// executionGroup ties the history entries of one flow run together
+type executionGroup struct {
+	RunID  string
+	FlowID int64
+}
+
+// withExecutionGroup records every execution under ctx as part of the run
+func withExecutionGroup(ctx context.Context, runID string, flowID int64) context.Context {
+	return context.WithValue(ctx, executionGroupKey{}, executionGroup{RunID: runID, FlowID: flowID})
 }
 
-func (re *RequestExecutor) saveHistory(ctx context.Context, req repository.Request, result *ExecuteResult, flowID *int64) {
+// recordExecution bumps a saved request's usage statistics once it was sent
+func (re *RequestExecutor) recordExecution(ctx context.Context, req repository.Request) {
+	if req.ID != 0 {
+		re.queries.RecordRequestExecution(context.WithoutCancel(ctx), req.ID)
+	}
+}
+
+// saveHistory records the execution and returns its history ID (0 when not recorded)
+func (re *RequestExecutor) saveHistory(ctx context.Context, req repository.Request, result *ExecuteResult, flowID *int64) int64 {
+	if ctx.Value(skipHistoryKey{}) != nil {
+		return 0
+	}
+
 	reqHeaders, _ := json.Marshal(result.ResolvedHeaders)
 	respHeaders, _ := json.Marshal(result.Headers)
 
@@ -488,6 +917,10 @@ func (re *RequestExecutor) saveHistory(ctx context.Context, req repository.Reque
 	if flowID != nil {
 		fid = sql.NullInt64{Int64: *flowID, Valid: true}
 	}
+	group, _ := ctx.Value(executionGroupKey{}).(executionGroup)
+	if !fid.Valid && group.FlowID != 0 {
+		fid = sql.NullInt64{Int64: group.FlowID, Valid: true}
+	}
 
 	body := ""
 	if req.Body.Valid {
@@ -505,21 +938,43 @@ func (re *RequestExecutor) saveHistory(ctx context.Context, req repository.Reque
 		isBinaryInt = 1
 	}
 
+	var redirects []byte
+	if len(result.Redirects) > 0 {
+		redirects, _ = json.Marshal(result.Redirects)
+	}
+
+	var network []byte
+	if result.Network != nil {
+		network, _ = json.Marshal(result.Network)
+	}
+
+	attempt, _ := ctx.Value(attemptKey{}).(attemptRef)
+
 	wsID := middleware.GetWorkspaceID(ctx)
-	re.queries.CreateHistory(ctx, repository.CreateHistoryParams{
-		RequestID:       sql.NullInt64{Int64: req.ID, Valid: req.ID != 0},
-		FlowID:          fid,
-		Method:          req.Method,
-		Url:             result.ResolvedURL,
-		RequestHeaders:  sql.NullString{String: string(reqHeaders), Valid: true},
-		RequestBody:     sql.NullString{String: body, Valid: true},
-		StatusCode:      sql.NullInt64{Int64: int64(result.StatusCode), Valid: result.StatusCode > 0},
-		ResponseHeaders: sql.NullString{String: string(respHeaders), Valid: true},
-		ResponseBody:    sql.NullString{String: responseBody, Valid: true},
-		DurationMs:      sql.NullInt64{Int64: result.DurationMs, Valid: true},
-		Error:           sql.NullString{String: result.Error, Valid: result.Error != ""},
-		BodySize:        sql.NullInt64{Int64: result.BodySize, Valid: true},
-		IsBinary:        sql.NullInt64{Int64: isBinaryInt, Valid: true},
-		WorkspaceID:     wsID,
+	entry, err := re.queries.CreateHistory(ctx, repository.CreateHistoryParams{
+		RequestID:        sql.NullInt64{Int64: req.ID, Valid: req.ID != 0},
+		FlowID:           fid,
+		Method:           req.Method,
+		Url:              result.ResolvedURL,
+		RequestHeaders:   sql.NullString{String: string(reqHeaders), Valid: true},
+		RequestBody:      sql.NullString{String: body, Valid: true},
+		StatusCode:       sql.NullInt64{Int64: int64(result.StatusCode), Valid: result.StatusCode > 0},
+		ResponseHeaders:  sql.NullString{String: string(respHeaders), Valid: true},
+		ResponseBody:     sql.NullString{String: responseBody, Valid: true},
+		DurationMs:       sql.NullInt64{Int64: result.DurationMs, Valid: true},
+		Error:            sql.NullString{String: result.Error, Valid: result.Error != ""},
+		BodySize:         sql.NullInt64{Int64: result.BodySize, Valid: true},
+		IsBinary:         sql.NullInt64{Int64: isBinaryInt, Valid: true},
+		WorkspaceID:      wsID,
+		TraceID:          sql.NullString{String: result.TraceID, Valid: result.TraceID != ""},
+		ExecutionGroupID: sql.NullString{String: group.RunID, Valid: group.RunID != ""},
+		Redirects:        string(redirects),
+		Network:          string(network),
+		AttemptGroupID:   sql.NullString{String: attempt.GroupID, Valid: attempt.GroupID != ""},
+		Attempt:          int64(attempt.N),
 	})
+	if err != nil {
+		return 0
+	}
+	return entry.ID
 }
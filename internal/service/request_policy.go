@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"relay/internal/repository"
+)
+
+// Limits of a request's timeout and retry policy
+const (
+	maxRequestTimeoutMs = 10 * 60 * 1000 // 10 minutes
+	maxRetryCount       = 10
+	maxRetryBackoffMs   = 60 * 1000
+	maxRetryDelay       = 5 * time.Minute
+	defaultMaxRedirects = 10
+	maxRedirectsLimit   = 50
+)
+
+// RequestPolicy is the timeout, retry and redirect policy of a saved request
+// or flow step. Zero values keep the defaults: the client's 60s timeout, no
+// retries, up to 10 redirects followed.
+type RequestPolicy struct {
+	TimeoutMs      int64  `json:"timeoutMs"`
+	RetryCount     int64  `json:"retryCount"`
+	RetryBackoffMs int64  `json:"retryBackoffMs"`
+	RetryOnStatus  string `json:"retryOnStatus"` // comma-separated status codes, e.g. "502,503,504"
+	MaxRedirects   int64  `json:"maxRedirects"`
+}
+
+// Validate checks the policy's limits and status list
+func (p RequestPolicy) Validate() error {
+	switch {
+	case p.TimeoutMs < 0 || p.TimeoutMs > maxRequestTimeoutMs:
+		return fmt.Errorf("timeoutMs must be between 0 and %d", maxRequestTimeoutMs)
+	case p.RetryCount < 0 || p.RetryCount > maxRetryCount:
+		return fmt.Errorf("retryCount must be between 0 and %d", maxRetryCount)
+	case p.RetryBackoffMs < 0 || p.RetryBackoffMs > maxRetryBackoffMs:
+		return fmt.Errorf("retryBackoffMs must be between 0 and %d", maxRetryBackoffMs)
+	case p.MaxRedirects < 0 || p.MaxRedirects > maxRedirectsLimit:
+		return fmt.Errorf("maxRedirects must be between 0 and %d", maxRedirectsLimit)
+	}
+	_, err := ParseRetryStatuses(p.RetryOnStatus)
+	return err
+}
+
+// ParseRetryStatuses parses a comma-separated list of HTTP status codes
+func ParseRetryStatuses(s string) (map[int]bool, error) {
+	codes := map[int]bool{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		code, err := strconv.Atoi(part)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("retryOnStatus: %q is not an HTTP status code", part)
+		}
+		codes[code] = true
+	}
+	return codes, nil
+}
+
+// requestTimeout returns the request's own timeout, or def when it has none
+func requestTimeout(req repository.Request, def time.Duration) time.Duration {
+	if req.TimeoutMs > 0 {
+		return time.Duration(req.TimeoutMs) * time.Millisecond
+	}
+	return def
+}
+
+// retryDelay is the wait before retry n (1-based): the backoff doubles after
+// each attempt, up to maxRetryDelay
+func retryDelay(backoffMs int64, n int) time.Duration {
+	d := time.Duration(backoffMs) * time.Millisecond
+	for i := 1; i < n && d < maxRetryDelay; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryDelay)
+}
+
+// shouldRetry reports whether an attempt failed in a way the request's
+// policy retries: the request could not be sent or its response status is
+// listed in retry_on_status. Errors before sending (variables, safe mode,
+// signing) are not retried.
+func shouldRetry(result *ExecuteResult, retryOn map[int]bool) bool {
+	if result.sendFailed {
+		return true
+	}
+	return result.StatusCode != 0 && retryOn[result.StatusCode]
+}
+
+// AttemptSummary is one attempt of a request with a retry policy; its
+// history entry holds what was sent and received
+type AttemptSummary struct {
+	Attempt    int    `json:"attempt"`
+	HistoryID  int64  `json:"historyId,omitempty"` // 0 when the attempt failed before sending
+	StatusCode int    `json:"statusCode,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+func summarizeAttempt(n int, result *ExecuteResult) AttemptSummary {
+	return AttemptSummary{
+		Attempt:    n,
+		HistoryID:  result.HistoryID,
+		StatusCode: result.StatusCode,
+		DurationMs: result.DurationMs,
+		Error:      result.Error,
+	}
+}
+
+type attemptKey struct{}
+
+// attemptRef links an execution's history entry to the other attempts of
+// the same request
+type attemptRef struct {
+	GroupID string
+	N       int
+}
+
+// withAttempt records executions under ctx as attempt n of group. An empty
+// group clears any outer attempt, so requests sent on the attempt's behalf
+// (e.g. auth session logins) are not linked to it.
+func withAttempt(ctx context.Context, group string, n int) context.Context {
+	if group == "" {
+		n = 0
+	}
+	return context.WithValue(ctx, attemptKey{}, attemptRef{GroupID: group, N: n})
+}
+
+// RedirectHop is a redirect response the client received on the way to the
+// final response
+type RedirectHop struct {
+	URL        string            `json:"url"`
+	StatusCode int               `json:"statusCode"`
+	Headers    map[string]string `json:"headers"`
+	Location   string            `json:"location"` // the resolved URL it redirected to
+}
+
+// redirectRecorder is an http.Client CheckRedirect that applies the
+// request's redirect policy and records every hop, so 3xx chains can be
+// debugged from the result and history
+type redirectRecorder struct {
+	follow  bool
+	max     int
+	hops    []RedirectHop
+	block   func(next *http.Request) string // optional; a reason stops the chain before next is sent
+	blocked string                          // the reason block stopped the chain
+}
+
+func newRedirectRecorder(req repository.Request) *redirectRecorder {
+	max := defaultMaxRedirects
+	if req.MaxRedirects > 0 {
+		max = int(req.MaxRedirects)
+	}
+	return &redirectRecorder{follow: req.DisableRedirects == 0, max: max}
+}
+
+func (r *redirectRecorder) checkRedirect(next *http.Request, via []*http.Request) error {
+	if !r.follow {
+		// The redirect itself is the result
+		return http.ErrUseLastResponse
+	}
+	if resp := next.Response; resp != nil {
+		headers := make(map[string]string, len(resp.Header))
+		for k, v := range resp.Header {
+			if len(v) > 0 {
+				headers[k] = v[0]
+			}
+		}
+		r.hops = append(r.hops, RedirectHop{
+			URL:        resp.Request.URL.String(),
+			StatusCode: resp.StatusCode,
+			Headers:    headers,
+			Location:   next.URL.String(),
+		})
+	}
+	if len(via) > r.max {
+		return fmt.Errorf("stopped after %d redirects", r.max)
+	}
+	if r.block != nil {
+		if reason := r.block(next); reason != "" {
+			r.blocked = reason
+			return errors.New(reason)
+		}
+	}
+	return nil
+}
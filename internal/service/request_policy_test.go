@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestRequestPolicy_Validate(t *testing.T) {
+	valid := []RequestPolicy{
+		{},
+		{TimeoutMs: 5000, RetryCount: 3, RetryBackoffMs: 100, RetryOnStatus: "502, 503,504", MaxRedirects: 3},
+	}
+	for _, p := range valid {
+		if err := p.Validate(); err != nil {
+			t.Errorf("%+v rejected: %v", p, err)
+		}
+	}
+	invalid := []RequestPolicy{
+		{TimeoutMs: -1},
+		{TimeoutMs: maxRequestTimeoutMs + 1},
+		{RetryCount: maxRetryCount + 1},
+		{RetryBackoffMs: -5},
+		{RetryOnStatus: "503,abc"},
+		{RetryOnStatus: "99"},
+		{MaxRedirects: maxRedirectsLimit + 1},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%+v accepted", p)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for n, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond} {
+		if got := retryDelay(100, n); got != want {
+			t.Errorf("retryDelay(100, %d) = %v, want %v", n, got, want)
+		}
+	}
+	if got := retryDelay(60000, 10); got != maxRetryDelay {
+		t.Errorf("retryDelay is not capped: %v", got)
+	}
+}
+
+func TestRequestExecutor_RetryOnStatus(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	req := repository.Request{Method: "GET", Url: srv.URL, RetryCount: 3, RetryBackoffMs: 1, RetryOnStatus: "503"}
+
+	result, err := re.ExecuteRequest(context.Background(), req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StatusCode != 200 || result.Attempts != 3 || calls.Load() != 3 {
+		t.Errorf("status %d after %d attempts, %d calls", result.StatusCode, result.Attempts, calls.Load())
+	}
+	// Each attempt is its own history entry, linked by the attempt group
+	if len(result.AttemptHistory) != 3 || result.AttemptHistory[0].StatusCode != 503 || result.AttemptHistory[2].HistoryID != result.HistoryID {
+		t.Errorf("attempt history = %+v", result.AttemptHistory)
+	}
+	entries, _ := q.ListHistoryByAttemptGroup(context.Background(), repository.ListHistoryByAttemptGroupParams{
+		WorkspaceID:    1,
+		AttemptGroupID: sql.NullString{String: result.AttemptGroupID, Valid: true},
+	})
+	if len(entries) != 3 || entries[0].Attempt != 1 || entries[0].StatusCode.Int64 != 503 || entries[2].StatusCode.Int64 != 200 {
+		t.Errorf("history entries = %+v", entries)
+	}
+
+	// Statuses not listed are returned as they are
+	calls.Store(0)
+	req.RetryOnStatus = "502"
+	result, _ = re.ExecuteRequest(context.Background(), req, nil)
+	if result.StatusCode != 503 || result.Attempts != 1 || calls.Load() != 1 {
+		t.Errorf("unlisted status retried: status %d, %d calls", result.StatusCode, calls.Load())
+	}
+
+	// Retries run out
+	calls.Store(-10)
+	req.RetryOnStatus, req.RetryCount = "503", 2
+	result, _ = re.ExecuteRequest(context.Background(), req, nil)
+	if result.StatusCode != 503 || result.Attempts != 3 || calls.Load() != -7 {
+		t.Errorf("status %d after %d attempts", result.StatusCode, result.Attempts)
+	}
+}
+
+func TestRequestExecutor_TimeoutAndRetry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+
+	result, _ := re.ExecuteRequest(context.Background(), repository.Request{Method: "GET", Url: srv.URL, TimeoutMs: 100}, nil)
+	if result.Error == "" || result.DurationMs >= 2000 {
+		t.Fatalf("timeout not applied: %+v", result)
+	}
+
+	calls.Store(0)
+	result, _ = re.ExecuteRequest(context.Background(), repository.Request{Method: "GET", Url: srv.URL, TimeoutMs: 100, RetryCount: 1}, nil)
+	if result.Error != "" || result.StatusCode != 200 || result.Attempts != 2 {
+		t.Errorf("timed out attempt not retried: %+v", result)
+	}
+
+	// Errors before sending are not retried
+	result, _ = re.ExecuteRequest(context.Background(), repository.Request{Method: "GET", Url: "http://%zz", RetryCount: 3}, nil)
+	if result.Error == "" || result.Attempts > 1 {
+		t.Errorf("unsent request retried %d times", result.Attempts)
+	}
+}
+
+func TestRequestExecutor_Redirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Hop", "a")
+		http.Redirect(w, r, "/b", http.StatusFound)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("done"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+	req := repository.Request{Method: "GET", Url: srv.URL + "/a"}
+
+	result, _ := re.ExecuteRequest(ctx, req, nil)
+	if result.StatusCode != 200 || result.Body != "done" || len(result.Redirects) != 2 {
+		t.Fatalf("followed = %d %q, redirects %+v", result.StatusCode, result.Body, result.Redirects)
+	}
+	hop := result.Redirects[0]
+	if hop.URL != srv.URL+"/a" || hop.StatusCode != 302 || hop.Headers["X-Hop"] != "a" || hop.Location != srv.URL+"/b" {
+		t.Errorf("first hop = %+v", hop)
+	}
+	if hop := result.Redirects[1]; hop.StatusCode != 301 || hop.Location != srv.URL+"/c" {
+		t.Errorf("second hop = %+v", hop)
+	}
+	entry, err := q.GetHistory(ctx, result.HistoryID)
+	if err != nil || !strings.Contains(entry.Redirects, `"statusCode":301`) {
+		t.Errorf("history redirects = %q (%v)", entry.Redirects, err)
+	}
+
+	// Without following, the redirect is the result
+	manual := req
+	manual.DisableRedirects = 1
+	result, _ = re.ExecuteRequest(ctx, manual, nil)
+	if result.StatusCode != 302 || result.Headers["Location"] != "/b" || len(result.Redirects) != 0 {
+		t.Errorf("not followed = %d %+v", result.StatusCode, result.Redirects)
+	}
+
+	limited := req
+	limited.MaxRedirects = 1
+	result, _ = re.ExecuteRequest(ctx, limited, nil)
+	if !strings.Contains(result.Error, "stopped after 1 redirects") || len(result.Redirects) != 2 {
+		t.Errorf("limited = %q %+v", result.Error, result.Redirects)
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/dop251/goja"
+)
+
+// responseTransformTimeout bounds JS transform execution
+const responseTransformTimeout = 2 * time.Second
+
+// TransformResponseBody applies a response transform to a response body.
+// Expressions starting with "$" are evaluated as JSONPath against the parsed
+// body. Anything else is evaluated as JavaScript with `body` (parsed JSON, or
+// the raw string for non-JSON bodies) and `response` in scope; if the result is
+// a function it is called as fn(body, response).
+// String results are returned as-is, everything else is JSON-encoded.
+func TransformResponseBody(transform string, result *ExecuteResult) (string, error) {
+	transform = strings.TrimSpace(transform)
+	if transform == "" {
+		return result.Body, nil
+	}
+
+	var data interface{}
+	_ = json.Unmarshal([]byte(result.Body), &data) // non-JSON bodies leave data nil
+
+	var value interface{}
+	if strings.HasPrefix(transform, "$") {
+		if data == nil {
+			return "", fmt.Errorf("response body is not JSON")
+		}
+		v, err := jsonpath.Get(transform, data)
+		if err != nil {
+			return "", err
+		}
+		value = v
+	} else {
+		v, err := evalJSTransform(transform, data, result)
+		if err != nil {
+			return "", err
+		}
+		value = v
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case nil:
+		return "null", nil
+	default:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+}
+
+func evalJSTransform(transform string, data interface{}, result *ExecuteResult) (interface{}, error) {
+	vm := goja.New()
+	timer := time.AfterFunc(responseTransformTimeout, func() {
+		vm.Interrupt("transform timed out")
+	})
+	defer timer.Stop()
+
+	var body interface{} = result.Body
+	if data != nil {
+		body = data
+	}
+	response := map[string]interface{}{
+		"status":  result.StatusCode,
+		"headers": result.Headers,
+		"body":    body,
+	}
+	vm.Set("body", body)
+	vm.Set("response", response)
+
+	v, err := vm.RunString(transform)
+	if err != nil {
+		return nil, err
+	}
+	if fn, ok := goja.AssertFunction(v); ok {
+		v, err = fn(goja.Undefined(), vm.ToValue(body), vm.ToValue(response))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil, nil
+	}
+	return v.Export(), nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestTransformResponseBody(t *testing.T) {
+	result := &ExecuteResult{
+		StatusCode: 200,
+		Body:       `{"data":{"items":[{"id":1,"name":"a"},{"id":2,"name":"b"}]},"meta":{"page":1}}`,
+	}
+
+	tests := []struct {
+		name      string
+		transform string
+		want      string
+	}{
+		{"jsonpath", "$.data.items[*].id", `[1,2]`},
+		{"jsonpath string", "$.data.items[0].name", `a`},
+		{"js expression", "body.data.items.map(i => i.name)", `["a","b"]`},
+		{"js function", "(body, response) => ({status: response.status, count: body.data.items.length})", `{"count":2,"status":200}`},
+		{"empty", "", result.Body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TransformResponseBody(tt.transform, result)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := TransformResponseBody("$.x", &ExecuteResult{Body: "plain text"}); err == nil {
+		t.Error("expected error for jsonpath on non-JSON body")
+	}
+	if _, err := TransformResponseBody("body.missing.field", result); err == nil {
+		t.Error("expected error for JS TypeError")
+	}
+}
+
+func TestFlowRunner_ResponseTransform(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"envelope":{"user":{"id":"u-7"}},"noise":"x"}`))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{
+			Name:              "fetch",
+			Method:            "GET",
+			Url:               ts.URL,
+			ResponseTransform: sql.NullString{String: "$.envelope.user", Valid: true},
+			ExtractVars:       sql.NullString{String: `{"userId":"$.id"}`, Valid: true},
+		},
+	})
+
+	result, err := fr.Run(ctx, flowID, nil)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(result.Steps) != 1 {
+		t.Fatalf("steps: got %d, want 1", len(result.Steps))
+	}
+	step := result.Steps[0]
+	if step.ExecuteResult.Body != `{"id":"u-7"}` {
+		t.Errorf("body: got %s", step.ExecuteResult.Body)
+	}
+	if step.ExecuteResult.OriginalBody == "" {
+		t.Error("expected original body to be preserved")
+	}
+	if step.ExtractedVars["userId"] != "u-7" {
+		t.Errorf("userId: got %q, want u-7", step.ExtractedVars["userId"])
+	}
+}
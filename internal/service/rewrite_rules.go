@@ -0,0 +1,155 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const maxRewriteRules = 100
+
+// RewriteRule changes matching outgoing requests before they are sent, e.g.
+// to point URLs hardcoded to production at staging while running a flow.
+// Rules apply in order; every matching rule applies, each seeing the request
+// as rewritten by the rules before it. Action values may use {{variables}}.
+type RewriteRule struct {
+	Name     string       `json:"name"`
+	Disabled bool         `json:"disabled,omitempty"`
+	Match    RewriteMatch `json:"match"`
+
+	// ReplaceURLPrefix swaps the leading From of the URL for To
+	ReplaceURLPrefix *URLPrefixRewrite `json:"replaceUrlPrefix,omitempty"`
+	// MapHost replaces the URL's host (host or host:port)
+	MapHost string `json:"mapHost,omitempty"`
+	// QueryParams are set on the URL, replacing existing values
+	QueryParams map[string]string `json:"queryParams,omitempty"`
+	// SetHeaders are set on the request, replacing existing values
+	SetHeaders map[string]string `json:"setHeaders,omitempty"`
+}
+
+// RewriteMatch selects the requests a rule applies to; empty fields match
+// anything
+type RewriteMatch struct {
+	Method string `json:"method,omitempty"`
+	// Host is a hostname or host:port; "*.example.com" matches subdomains
+	Host       string `json:"host,omitempty"`
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	// Header requires the header to be present, and to equal HeaderValue
+	// when that is set
+	Header      string `json:"header,omitempty"`
+	HeaderValue string `json:"headerValue,omitempty"`
+}
+
+type URLPrefixRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (r RewriteRule) validate(i int) error {
+	name := fmt.Sprintf("rewriteRules[%d]", i)
+	if r.Name == "" {
+		return fmt.Errorf("%s: name is required", name)
+	}
+	if r.Match.Header != "" && !httpTokenPattern.MatchString(r.Match.Header) {
+		return fmt.Errorf("%s: match.header %q is not a valid header name", name, r.Match.Header)
+	}
+	if r.ReplaceURLPrefix != nil && r.ReplaceURLPrefix.From == "" {
+		return fmt.Errorf("%s: replaceUrlPrefix.from is required", name)
+	}
+	for k := range r.SetHeaders {
+		if !httpTokenPattern.MatchString(k) {
+			return fmt.Errorf("%s: setHeaders %q is not a valid header name", name, k)
+		}
+	}
+	if r.ReplaceURLPrefix == nil && r.MapHost == "" && len(r.QueryParams) == 0 && len(r.SetHeaders) == 0 {
+		return fmt.Errorf("%s: at least one action is required", name)
+	}
+	return nil
+}
+
+// ValidateRewriteRules checks a workspace's rules
+func ValidateRewriteRules(rules []RewriteRule) error {
+	if len(rules) > maxRewriteRules {
+		return fmt.Errorf("at most %d rewrite rules are allowed", maxRewriteRules)
+	}
+	for i, r := range rules {
+		if err := r.validate(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m RewriteMatch) matches(req *http.Request) bool {
+	if m.Method != "" && !strings.EqualFold(m.Method, req.Method) {
+		return false
+	}
+	if m.Host != "" && !matchHost(m.Host, req.URL.Host) {
+		return false
+	}
+	if m.PathPrefix != "" && !strings.HasPrefix(req.URL.Path, m.PathPrefix) {
+		return false
+	}
+	if m.Header != "" {
+		values, ok := req.Header[http.CanonicalHeaderKey(m.Header)]
+		if !ok || (m.HeaderValue != "" && (len(values) == 0 || values[0] != m.HeaderValue)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchHost compares pattern with host (as in URL.Host). A pattern without a
+// port matches any port.
+func matchHost(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	if pattern == host {
+		return true
+	}
+	hostname := host
+	if u := (&url.URL{Host: host}); u.Port() != "" {
+		hostname = u.Hostname()
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(hostname, "."+suffix)
+	}
+	return pattern == hostname
+}
+
+// applyRewriteRules rewrites req with every enabled matching rule and returns
+// the names of the rules that applied. resolve substitutes variables in
+// action values.
+func applyRewriteRules(req *http.Request, rules []RewriteRule, resolve func(string) string) ([]string, error) {
+	var applied []string
+	for _, r := range rules {
+		if r.Disabled || !r.Match.matches(req) {
+			continue
+		}
+		if p := r.ReplaceURLPrefix; p != nil {
+			if rest, ok := strings.CutPrefix(req.URL.String(), resolve(p.From)); ok {
+				u, err := url.Parse(resolve(p.To) + rest)
+				if err != nil || u.Host == "" {
+					return applied, fmt.Errorf("rewrite rule %q: invalid url %q", r.Name, resolve(p.To)+rest)
+				}
+				req.URL = u
+			}
+		}
+		if r.MapHost != "" {
+			req.URL.Host = resolve(r.MapHost)
+		}
+		if len(r.QueryParams) > 0 {
+			q := req.URL.Query()
+			for k, v := range r.QueryParams {
+				q.Set(k, resolve(v))
+			}
+			req.URL.RawQuery = q.Encode()
+		}
+		for k, v := range r.SetHeaders {
+			req.Header.Set(k, resolve(v))
+		}
+		req.Host = req.URL.Host
+		applied = append(applied, r.Name)
+	}
+	return applied, nil
+}
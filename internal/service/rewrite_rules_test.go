@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestApplyRewriteRules(t *testing.T) {
+	rules := []RewriteRule{
+		{
+			Name:             "prod to staging",
+			Match:            RewriteMatch{Host: "*.example.com", PathPrefix: "/v1"},
+			ReplaceURLPrefix: &URLPrefixRewrite{From: "https://api.example.com/v1", To: "http://{{staging}}/v2"},
+			SetHeaders:       map[string]string{"X-Env": "staging"},
+		},
+		{Name: "debug", Match: RewriteMatch{Method: "post", Header: "X-Debug", HeaderValue: "1"}, QueryParams: map[string]string{"trace": "on"}},
+		{Name: "disabled", Disabled: true, MapHost: "nowhere"},
+		{Name: "other host", Match: RewriteMatch{Host: "other.com:8080"}, MapHost: "localhost:9"},
+	}
+	resolve := func(s string) string { return strings.ReplaceAll(s, "{{staging}}", "staging.local:8080") }
+
+	req, _ := http.NewRequest("POST", "https://api.example.com/v1/users?page=2", nil)
+	req.Header.Set("X-Debug", "1")
+	applied, err := applyRewriteRules(req, rules, resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(applied, ",") != "prod to staging,debug" {
+		t.Errorf("applied = %v", applied)
+	}
+	if got := req.URL.String(); got != "http://staging.local:8080/v2/users?page=2&trace=on" {
+		t.Errorf("url = %s", got)
+	}
+	if req.Host != "staging.local:8080" || req.Header.Get("X-Env") != "staging" {
+		t.Errorf("host = %s, headers = %v", req.Host, req.Header)
+	}
+
+	req, _ = http.NewRequest("GET", "http://other.com:8080/x", nil)
+	if applied, _ := applyRewriteRules(req, rules, resolve); len(applied) != 1 || req.URL.Host != "localhost:9" {
+		t.Errorf("applied = %v, url = %s", applied, req.URL)
+	}
+
+	req, _ = http.NewRequest("GET", "http://example.com/v1", nil)
+	if applied, _ := applyRewriteRules(req, rules, resolve); len(applied) != 0 {
+		t.Errorf("bare domain matched *.example.com: %v", applied)
+	}
+}
+
+func TestValidateRewriteRules(t *testing.T) {
+	bad := [][]RewriteRule{
+		{{SetHeaders: map[string]string{"X": "1"}}},
+		{{Name: "no action"}},
+		{{Name: "bad header", SetHeaders: map[string]string{"X Y": "1"}}},
+		{{Name: "no prefix", ReplaceURLPrefix: &URLPrefixRewrite{To: "http://x"}}},
+	}
+	for i, rules := range bad {
+		if err := ValidateRewriteRules(rules); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+	if err := ValidateRewriteRules([]RewriteRule{{Name: "ok", MapHost: "localhost"}}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExecuteRequest_RewriteRules(t *testing.T) {
+	var gotPath, gotHeader string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.RequestURI()
+		gotHeader = r.Header.Get("X-Rewritten")
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+
+	settings := `{"rewriteRules":[{"name":"to local","match":{"host":"prod.example.com"},"replaceUrlPrefix":{"from":"https://prod.example.com","to":"{{target}}"},"setHeaders":{"X-Rewritten":"yes"}}]}`
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: settings, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := re.ExecuteAdhoc(ctx, "GET", "https://prod.example.com/api?q=1", "", "", map[string]string{"target": ts.URL}, nil)
+	if err != nil || result.Error != "" {
+		t.Fatalf("execute: %v, %s", err, result.Error)
+	}
+	if gotPath != "/api?q=1" || gotHeader != "yes" {
+		t.Errorf("target saw %s with X-Rewritten=%q", gotPath, gotHeader)
+	}
+	if len(result.Rewrites) != 1 || result.ResolvedURL != ts.URL+"/api?q=1" || result.ResolvedHeaders["X-Rewritten"] != "yes" {
+		t.Errorf("rewrites = %v, resolvedUrl = %s, headers = %v", result.Rewrites, result.ResolvedURL, result.ResolvedHeaders)
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type frozenClockKey struct{}
+
+// WithFrozenClock makes everything run with ctx see t as the current time:
+// built-in clock variables ({{$timestamp}}, {{$date}} ...), the DSL's
+// {{__timestamp__}} and Date in JavaScript scripts. The clock does not
+// advance, so signed requests and snapshots are reproducible.
+func WithFrozenClock(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, frozenClockKey{}, t)
+}
+
+// frozenClockFrom returns the run's frozen time, if it has one
+func frozenClockFrom(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(frozenClockKey{}).(time.Time)
+	return t, ok
+}
+
+// ParseFrozenTime accepts RFC3339 or unix milliseconds
+func ParseFrozenTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("frozen time %q is neither RFC3339 nor unix milliseconds", s)
+	}
+	return t, nil
+}
+
+// clock returns the time source for ctx: the frozen time or the resolver's clock
+func (vr *VariableResolver) clock(ctx context.Context) func() time.Time {
+	if t, ok := frozenClockFrom(ctx); ok {
+		return func() time.Time { return t }
+	}
+	return vr.now
+}
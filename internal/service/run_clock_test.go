@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestParseFrozenTime(t *testing.T) {
+	want := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, s := range []string{"2026-01-02T03:04:05Z", "2026-01-02T12:04:05+09:00", "1767323045000"} {
+		got, err := ParseFrozenTime(s)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("%q: got %v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseFrozenTime("yesterday"); err == nil {
+		t.Error("expected error for invalid time")
+	}
+}
+
+func TestFlowRunner_FrozenTime(t *testing.T) {
+	var seen []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.URL.Query().Get("t"))
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{
+			Name:      "builtin",
+			Method:    "GET",
+			Url:       ts.URL + "?t={{$timestampMs}}",
+			PreScript: sql.NullString{String: `pm.variables.set("js", String(Date.now()))`, Valid: true},
+		},
+		{Name: "script", Method: "GET", Url: ts.URL + "?t={{js}}", Condition: sql.NullString{String: "{{$date 'YYYY'}} == 2026", Valid: true}},
+	})
+
+	result, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{FrozenTime: "2026-01-02T03:04:05Z"}, nil)
+	if err != nil {
+		t.Fatalf("run flow: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	if len(seen) != 2 || seen[0] != "1767323045000" || seen[1] != "1767323045000" {
+		t.Errorf("timestamps = %v", seen)
+	}
+	if result.FrozenTime != "2026-01-02T03:04:05Z" {
+		t.Errorf("frozenTime = %q", result.FrozenTime)
+	}
+
+	if _, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{FrozenTime: "soon"}, nil); !errors.Is(err, ErrInvalidRunOptions) {
+		t.Errorf("err = %v, want ErrInvalidRunOptions", err)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type runRandomKey struct{}
+
+// runRandom is a run's seeded random source. The same seed gives the same
+// {{$randomInt}}, {{$guid}}, {{__uuid__}} and Math.random() values on every
+// re-run. A nil *runRandom falls back to true randomness.
+type runRandom struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// WithRandomSeed makes the random built-in values of everything run with ctx
+// reproducible from seed
+func WithRandomSeed(ctx context.Context, seed int64) context.Context {
+	rr := &runRandom{r: rand.New(rand.NewPCG(uint64(seed), 0))}
+	return context.WithValue(ctx, runRandomKey{}, rr)
+}
+
+// runRandomFrom returns the run's seeded source, or nil when it has none
+func runRandomFrom(ctx context.Context) *runRandom {
+	rr, _ := ctx.Value(runRandomKey{}).(*runRandom)
+	return rr
+}
+
+// IntN returns a number in [0, n)
+func (rr *runRandom) IntN(n int) int {
+	if rr == nil {
+		return rand.IntN(n)
+	}
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return rr.r.IntN(n)
+}
+
+// Float64 returns a number in [0, 1)
+func (rr *runRandom) Float64() float64 {
+	if rr == nil {
+		return rand.Float64()
+	}
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+	return rr.r.Float64()
+}
+
+// UUID returns a version 4 UUID
+func (rr *runRandom) UUID() string {
+	if rr == nil {
+		return uuid.NewString()
+	}
+	var id uuid.UUID
+	rr.mu.Lock()
+	for i := range id {
+		id[i] = byte(rr.r.UintN(256))
+	}
+	rr.mu.Unlock()
+	id[6] = id[6]&0x0f | 0x40 // version 4
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+	return id.String()
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+
+	"github.com/google/uuid"
+)
+
+func TestResolveWithVars_BuiltinRandomVars(t *testing.T) {
+	vr := NewVariableResolver(nil)
+
+	for i := 0; i < 50; i++ {
+		n, err := strconv.Atoi(vr.ResolveWithVars("{{$randomInt '3' '5'}}", nil))
+		if err != nil || n < 3 || n > 5 {
+			t.Fatalf("$randomInt 3 5 = %d, %v", n, err)
+		}
+	}
+	if n, err := strconv.Atoi(vr.ResolveWithVars("{{$randomInt}}", nil)); err != nil || n < 0 || n > 1000 {
+		t.Errorf("$randomInt = %d, %v", n, err)
+	}
+	if got := vr.ResolveWithVars("{{$randomInt '5' '3'}}", nil); got != "{{$randomInt '5' '3'}}" {
+		t.Errorf("inverted range resolved to %q", got)
+	}
+	for _, v := range []string{"{{$guid}}", "{{$randomUUID}}"} {
+		id, err := uuid.Parse(vr.ResolveWithVars(v, nil))
+		if err != nil || id.Version() != 4 {
+			t.Errorf("%s = %v, %v", v, id, err)
+		}
+	}
+}
+
+func TestRunRandom_Seeded(t *testing.T) {
+	a := runRandomFrom(WithRandomSeed(context.Background(), 42))
+	b := runRandomFrom(WithRandomSeed(context.Background(), 42))
+	for i := 0; i < 5; i++ {
+		if x, y := a.UUID(), b.UUID(); x != y {
+			t.Fatalf("draw %d: %s != %s", i, x, y)
+		}
+		if x, y := a.IntN(1000), b.IntN(1000); x != y {
+			t.Fatalf("draw %d: %d != %d", i, x, y)
+		}
+	}
+	if id, err := uuid.Parse(a.UUID()); err != nil || id.Version() != 4 || id.Variant() != uuid.RFC4122 {
+		t.Errorf("seeded UUID = %v, %v", id, err)
+	}
+	if runRandomFrom(context.Background()) != nil {
+		t.Error("unseeded context has a random source")
+	}
+}
+
+func TestFlowRunner_Seed(t *testing.T) {
+	var seen []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.URL.RawQuery)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{
+			Name:      "random",
+			Method:    "GET",
+			Url:       ts.URL + "?id={{$guid}}&n={{$randomInt}}&js={{js}}",
+			PreScript: sql.NullString{String: `pm.variables.set("js", String(Math.random()))`, Valid: true},
+		},
+	})
+
+	seed := int64(7)
+	for i := 0; i < 2; i++ {
+		result, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{Seed: &seed}, nil)
+		if err != nil || !result.Success {
+			t.Fatalf("run %d: %v, %+v", i, err, result)
+		}
+		if result.Seed == nil || *result.Seed != seed {
+			t.Errorf("result seed = %v", result.Seed)
+		}
+	}
+	if _, err := fr.Run(context.Background(), flowID, nil); err != nil {
+		t.Fatalf("unseeded run: %v", err)
+	}
+
+	if len(seen) != 3 {
+		t.Fatalf("requests = %v", seen)
+	}
+	if seen[0] != seen[1] {
+		t.Errorf("seeded runs differ:\n%s\n%s", seen[0], seen[1])
+	}
+	if seen[2] == seen[0] {
+		t.Errorf("unseeded run repeated the seeded values: %s", seen[2])
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// Timeline phase kinds
+const (
+	TimelinePhaseScript     = "script"
+	TimelinePhaseDelay      = "delay" // step delays, approval waits and pauses between wait polls
+	TimelinePhaseQueue      = "queue" // waiting for the host concurrency limit
+	TimelinePhaseHTTP       = "http"
+	TimelinePhaseExtraction = "extraction"
+)
+
+// RunTimeline lays a run's steps (or graph nodes) out on a time axis for
+// Gantt-style views. Offsets are milliseconds from StartedAt.
+type RunTimeline struct {
+	RunID     string          `json:"runId"`
+	FlowID    int64           `json:"flowId"`
+	FlowName  string          `json:"flowName"`
+	Success   bool            `json:"success"`
+	StartedAt time.Time       `json:"startedAt"`
+	EndedAt   time.Time       `json:"endedAt"`
+	TotalMs   int64           `json:"totalMs"`
+	Entries   []TimelineEntry `json:"entries"`
+}
+
+// TimelineEntry is one step execution (each loop iteration separately) or
+// one graph node
+type TimelineEntry struct {
+	StepID    int64           `json:"stepId,omitempty"`
+	NodeKey   string          `json:"nodeKey,omitempty"`
+	Name      string          `json:"name"`
+	Iteration int64           `json:"iteration,omitempty"`
+	Status    string          `json:"status"` // success, failed, skipped
+	StartedAt time.Time       `json:"startedAt"`
+	EndedAt   time.Time       `json:"endedAt"`
+	StartMs   int64           `json:"startMs"`
+	EndMs     int64           `json:"endMs"`
+	Phases    []TimelinePhase `json:"phases,omitempty"`
+}
+
+// TimelinePhase is a span of a step spent in one phase. Time between phases
+// went to variable resolution, conditions and history writes.
+type TimelinePhase struct {
+	Kind    string `json:"kind"`
+	StartMs int64  `json:"startMs"`
+	EndMs   int64  `json:"endMs"`
+}
+
+// stepTiming is when a step (or node) ran and its phases, kept off the JSON
+// result and turned into a timeline when the run ends
+type stepTiming struct {
+	start, end time.Time
+	phases     []timedPhase
+}
+
+type timedPhase struct {
+	kind       string
+	start, end time.Time
+}
+
+// buildRunTimeline converts the run's step timings to offsets from start
+func buildRunTimeline(result *FlowResult, start, end time.Time) *RunTimeline {
+	offset := func(t time.Time) int64 { return t.Sub(start).Milliseconds() }
+	tl := &RunTimeline{
+		RunID:     result.RunID,
+		FlowID:    result.FlowID,
+		FlowName:  result.FlowName,
+		Success:   result.Success,
+		StartedAt: start.UTC(),
+		EndedAt:   end.UTC(),
+		TotalMs:   end.Sub(start).Milliseconds(),
+		Entries:   []TimelineEntry{},
+	}
+	add := func(e TimelineEntry, timing *stepTiming) {
+		e.StartedAt, e.EndedAt = timing.start.UTC(), timing.end.UTC()
+		e.StartMs, e.EndMs = offset(timing.start), offset(timing.end)
+		for _, p := range timing.phases {
+			e.Phases = append(e.Phases, TimelinePhase{Kind: p.kind, StartMs: offset(p.start), EndMs: offset(p.end)})
+		}
+		tl.Entries = append(tl.Entries, e)
+	}
+	for _, s := range result.Steps {
+		if s.timing == nil {
+			continue // never started, e.g. not selected
+		}
+		add(TimelineEntry{StepID: s.StepID, Name: s.RequestName, Iteration: s.Iteration, Status: stepStatus(s)}, s.timing)
+	}
+	for _, n := range result.Nodes {
+		if n.timing == nil {
+			continue // skipped nodes never start
+		}
+		add(TimelineEntry{NodeKey: n.NodeKey, Name: n.Name, Status: n.Status}, n.timing)
+	}
+	return tl
+}
+
+// stepStatus reports a step result as success, failed or skipped
+func stepStatus(s StepResult) string {
+	switch {
+	case s.Skipped:
+		return NodeStatusSkipped
+	case s.ExecuteResult == nil, s.ExecuteResult.Error != "",
+		s.ExecuteResult.StatusCode < 200 || s.ExecuteResult.StatusCode >= 300,
+		s.PreScriptResult != nil && !s.PreScriptResult.Success,
+		s.PostScriptResult != nil && !s.PostScriptResult.Success,
+		s.Wait != nil && !s.Wait.Met:
+		return NodeStatusFailed
+	}
+	return NodeStatusSuccess
+}
+
+// saveTimeline stores the run's timeline for GET /api/flows/runs/{id}/timeline;
+// HistoryRetention drops (or archives) it after a week
+func (fr *FlowRunner) saveTimeline(ctx context.Context, result *FlowResult, start time.Time) {
+	data, err := json.Marshal(buildRunTimeline(result, start, time.Now()))
+	if err != nil {
+		return
+	}
+	if err := fr.queries.CreateRunTimeline(ctx, repository.CreateRunTimelineParams{
+		RunID:       result.RunID,
+		WorkspaceID: middleware.GetWorkspaceID(ctx),
+		FlowID:      result.FlowID,
+		Timeline:    string(data),
+	}); err != nil {
+		log.Printf("flow: failed to save run timeline: %v", err)
+	}
+}
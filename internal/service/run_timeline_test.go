@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestRunTimeline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(`{"token":"abc"}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "login", Method: "GET", Url: ts.URL, PreScript: sql.NullString{String: `pm.variables.set("a", "1");`, Valid: true}},
+		{Name: "skipped", Method: "GET", Url: ts.URL, Condition: sql.NullString{String: "{{missing}}", Valid: true}},
+		{Name: "wait", Method: "GET", Url: ts.URL, DelayMs: sql.NullInt64{Int64: 30, Valid: true}},
+	})
+
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := q.GetRunTimeline(context.Background(), result.RunID)
+	if err != nil {
+		t.Fatalf("timeline not saved: %v", err)
+	}
+	var tl RunTimeline
+	if err := json.Unmarshal([]byte(row.Timeline), &tl); err != nil {
+		t.Fatal(err)
+	}
+	if tl.RunID != result.RunID || tl.FlowID != flowID || !tl.Success || len(tl.Entries) != 3 {
+		t.Fatalf("timeline = %+v", tl)
+	}
+
+	kinds := func(e TimelineEntry) []string {
+		var k []string
+		for _, p := range e.Phases {
+			k = append(k, p.Kind)
+		}
+		return k
+	}
+	login, skipped, wait := tl.Entries[0], tl.Entries[1], tl.Entries[2]
+	if got := kinds(login); len(got) != 2 || got[0] != TimelinePhaseScript || got[1] != TimelinePhaseHTTP {
+		t.Errorf("login phases = %v", got)
+	}
+	if skipped.Status != NodeStatusSkipped || len(skipped.Phases) != 0 {
+		t.Errorf("skipped entry = %+v", skipped)
+	}
+	if got := kinds(wait); len(got) != 2 || got[0] != TimelinePhaseDelay || got[1] != TimelinePhaseHTTP {
+		t.Errorf("wait phases = %v", got)
+	}
+
+	// Entries and phases are ordered on the run's time axis
+	delay, http := wait.Phases[0], wait.Phases[1]
+	if delay.EndMs-delay.StartMs < 30 || http.StartMs < delay.EndMs || http.EndMs > wait.EndMs {
+		t.Errorf("wait phases = %+v (entry %d-%d)", wait.Phases, wait.StartMs, wait.EndMs)
+	}
+	if login.EndMs > wait.StartMs || wait.EndMs > tl.TotalMs {
+		t.Errorf("entries out of order: login ends %d, wait %d-%d, total %d", login.EndMs, wait.StartMs, wait.EndMs, tl.TotalMs)
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"relay/internal/middleware"
+)
+
+// Safe mode keeps a run from changing anything on its targets: only GET,
+// HEAD and OPTIONS requests go out, and requests to the workspace's blocked
+// hosts are not sent at all. The check runs again after a signing hook and
+// on every redirect hop. Blocked requests come back with SafeModeBlocked set
+// and no history entry; flow runs report their steps as skipped. A redirect
+// to a blocked host fails the request after its first hop instead.
+
+// SafeModeSettings is the workspace's safe mode policy
+type SafeModeSettings struct {
+	// Enabled puts every execution in the workspace in safe mode, whether or
+	// not the run asked for it
+	Enabled bool `json:"enabled"`
+	// BlockedHosts are never contacted in safe mode, whatever the method.
+	// Entries are a hostname or host:port; "*.example.com" matches subdomains.
+	BlockedHosts []string `json:"blockedHosts"`
+}
+
+func (s SafeModeSettings) Validate() error {
+	for i, h := range s.BlockedHosts {
+		if strings.TrimSpace(h) == "" || strings.Contains(h, "/") {
+			return fmt.Errorf("safeMode.blockedHosts[%d]: %q is not a host", i, h)
+		}
+	}
+	return nil
+}
+
+type safeModeKey struct{}
+
+// WithSafeMode runs everything executed with ctx in safe mode
+func WithSafeMode(ctx context.Context) context.Context {
+	return context.WithValue(ctx, safeModeKey{}, true)
+}
+
+func safeModeFrom(ctx context.Context) bool {
+	on, _ := ctx.Value(safeModeKey{}).(bool)
+	return on
+}
+
+// safeMethod reports whether method only reads
+func safeMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// safeModeBlock returns why safe mode stops req, or "" when it may be sent
+// (or safe mode is off)
+func (re *RequestExecutor) safeModeBlock(ctx context.Context, req *http.Request) string {
+	policy := SafeModeSettings{}
+	if raw, err := re.queries.GetWorkspaceSettings(ctx, middleware.GetWorkspaceID(ctx)); err == nil {
+		policy = ParseWorkspaceSettings(raw).SafeMode
+	}
+	if !safeModeFrom(ctx) && !policy.Enabled {
+		return ""
+	}
+	for _, h := range policy.BlockedHosts {
+		if matchHost(h, req.URL.Host) {
+			return fmt.Sprintf("Blocked by safe mode: host %s is blocked", req.URL.Host)
+		}
+	}
+	if !safeMethod(req.Method) {
+		return fmt.Sprintf("Blocked by safe mode: %s requests are not allowed", req.Method)
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestFlowRunner_SafeMode(t *testing.T) {
+	var seen []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Method)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "read", Method: "GET", Url: ts.URL},
+		{Name: "delete", Method: "DELETE", Url: ts.URL, PostScript: sql.NullString{String: `pm.test("x", () => pm.expect(1).to.equal(2))`, Valid: true}},
+		{Name: "check", Method: "HEAD", Url: ts.URL},
+	})
+
+	result, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{SafeMode: true}, nil)
+	if err != nil || !result.Success || !result.SafeMode {
+		t.Fatalf("run: %v, %+v", err, result)
+	}
+	if strings.Join(seen, ",") != "GET,HEAD" {
+		t.Errorf("sent = %v", seen)
+	}
+	blocked := result.Steps[1]
+	if !blocked.Skipped || !blocked.ExecuteResult.SafeModeBlocked || blocked.PostScriptResult != nil ||
+		blocked.SkipReason != "Blocked by safe mode: DELETE requests are not allowed" {
+		t.Errorf("blocked step = %+v", blocked)
+	}
+
+	seen = nil
+	if result, _ := fr.Run(context.Background(), flowID, nil); result.SafeMode || len(seen) != 2 || result.Success {
+		t.Errorf("without safe mode: sent %v, %+v", seen, result)
+	}
+}
+
+func TestRequestExecutor_SafeModePolicy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	host, _ := url.Parse(ts.URL)
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"safeMode":{"blockedHosts":["` + host.Hostname() + `"]}}`, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Blocked hosts only apply in safe mode
+	get := repository.Request{Method: "GET", Url: ts.URL}
+	if res, _ := re.ExecuteRequest(ctx, get, nil); res.SafeModeBlocked || res.StatusCode != 200 {
+		t.Errorf("outside safe mode: %+v", res)
+	}
+	if res, _ := re.ExecuteRequest(WithSafeMode(ctx), get, nil); !res.SafeModeBlocked || !strings.Contains(res.Error, "host") || res.HistoryID != 0 {
+		t.Errorf("blocked host: %+v", res)
+	}
+
+	// An enabled policy puts every execution in safe mode
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"safeMode":{"enabled":true}}`, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := re.ExecuteRequest(ctx, repository.Request{Method: "POST", Url: ts.URL}, nil); !res.SafeModeBlocked {
+		t.Errorf("POST under policy: %+v", res)
+	}
+	if res, _ := re.ExecuteRequest(ctx, repository.Request{Method: "OPTIONS", Url: ts.URL}, nil); res.SafeModeBlocked {
+		t.Errorf("OPTIONS under policy: %+v", res)
+	}
+}
+
+func TestRequestExecutor_SafeModeAfterSigningHook(t *testing.T) {
+	var seen []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Method)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	// Signs by switching the read to a write
+	writeHook(t, dir, "to-delete", `cat > /dev/null
+echo '{"method":"DELETE"}'
+`)
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	re.SetSigningHooks(NewSigningHooks(dir))
+	ctx := context.Background()
+
+	col, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Signed", WorkspaceID: 1})
+	q.UpdateCollectionSigningHook(ctx, repository.UpdateCollectionSigningHookParams{
+		SigningHook: sql.NullString{String: `{"name":"to-delete"}`, Valid: true},
+		ID:          col.ID,
+	})
+	req := repository.Request{Method: "GET", Url: ts.URL, CollectionID: sql.NullInt64{Int64: col.ID, Valid: true}, WorkspaceID: 1}
+
+	res, _ := re.ExecuteRequest(WithSafeMode(ctx), req, nil)
+	if !res.SafeModeBlocked || res.Error != "Blocked by safe mode: DELETE requests are not allowed" {
+		t.Errorf("signed request: %+v", res)
+	}
+	if len(seen) != 0 {
+		t.Errorf("sent %v in safe mode", seen)
+	}
+	if res, _ := re.ExecuteRequest(ctx, req, nil); res.SafeModeBlocked || strings.Join(seen, ",") != "DELETE" {
+		t.Errorf("outside safe mode: sent %v, %+v", seen, res)
+	}
+}
+
+func TestRequestExecutor_SafeModeRedirects(t *testing.T) {
+	blockedHits := 0
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		blockedHits++
+	}))
+	defer blocked.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, blocked.URL+"/admin", http.StatusFound)
+	}))
+	defer ts.Close()
+	host, _ := url.Parse(blocked.URL)
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+	if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"safeMode":{"blockedHosts":["` + host.Host + `"]}}`, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := repository.Request{Method: "GET", Url: ts.URL, WorkspaceID: 1}
+	res, _ := re.ExecuteRequest(WithSafeMode(ctx), get, nil)
+	if res.Error != "Blocked by safe mode: host "+host.Host+" is blocked" || len(res.Redirects) != 1 {
+		t.Errorf("redirect to a blocked host: %+v", res)
+	}
+	if blockedHits != 0 {
+		t.Errorf("blocked host was contacted %d times", blockedHits)
+	}
+
+	if res, _ := re.ExecuteRequest(ctx, get, nil); res.StatusCode != 200 || blockedHits != 1 {
+		t.Errorf("outside safe mode: %d hits, %+v", blockedHits, res)
+	}
+}
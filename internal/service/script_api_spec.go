@@ -0,0 +1,175 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/dop251/goja"
+)
+
+// ScriptAPIEntry describes one member of the script sandbox (pm.*, console.*, helpers)
+type ScriptAPIEntry struct {
+	Path      string `json:"path"`
+	Kind      string `json:"kind"` // function, object, property
+	Signature string `json:"signature,omitempty"`
+	Returns   string `json:"returns,omitempty"`
+	Doc       string `json:"doc,omitempty"`
+}
+
+// ScriptAPISpec is the machine-readable description of the JavaScript sandbox
+type ScriptAPISpec struct {
+	Entries []ScriptAPIEntry `json:"entries"`
+}
+
+type scriptAPIDoc struct {
+	Signature string
+	Returns   string
+	Doc       string
+}
+
+// scriptAPIDocs annotates members discovered from the sandbox. Members that
+// exist in the sandbox but are missing here are still listed, without docs.
+var scriptAPIDocs = map[string]scriptAPIDoc{
+	"pm":                            {Doc: "Postman-compatible scripting API"},
+	"pm.environment":                {Doc: "Active environment variables (persisted after the script)"},
+	"pm.environment.get":            {Signature: "get(name: string)", Returns: "string | undefined", Doc: "Read a variable (pending writes, then runtime, then environment)"},
+	"pm.environment.set":            {Signature: "set(name: string, value: any)", Returns: "void", Doc: "Set an environment variable; saved to the active environment"},
+	"pm.environment.has":            {Signature: "has(name: string)", Returns: "boolean", Doc: "Whether the variable is defined"},
+	"pm.variables":                  {Doc: "Runtime variables scoped to the current run"},
+	"pm.variables.get":              {Signature: "get(name: string)", Returns: "string | undefined", Doc: "Read a runtime variable, falling back to the environment"},
+	"pm.variables.set":              {Signature: "set(name: string, value: any)", Returns: "void", Doc: "Set a runtime variable (not persisted)"},
+	"pm.globals":                    {Doc: "Workspace-wide variables (persisted after the script)"},
+	"pm.globals.get":                {Signature: "get(name: string)", Returns: "string | undefined", Doc: "Read a workspace variable"},
+	"pm.globals.set":                {Signature: "set(name: string, value: any)", Returns: "void", Doc: "Set a workspace variable"},
+	"pm.globals.has":                {Signature: "has(name: string)", Returns: "boolean", Doc: "Whether the workspace variable is defined"},
+	"pm.globals.unset":              {Signature: "unset(name: string)", Returns: "void", Doc: "Remove a workspace variable"},
+	"pm.globals.clear":              {Signature: "clear()", Returns: "void", Doc: "Remove all workspace variables"},
+	"pm.collectionVariables":        {Doc: "Variables of the request's collection (persisted after the script)"},
+	"pm.collectionVariables.get":    {Signature: "get(name: string)", Returns: "string | undefined", Doc: "Read a collection variable"},
+	"pm.collectionVariables.set":    {Signature: "set(name: string, value: any)", Returns: "void", Doc: "Set a collection variable"},
+	"pm.collectionVariables.has":    {Signature: "has(name: string)", Returns: "boolean", Doc: "Whether the collection variable is defined"},
+	"pm.collectionVariables.unset":  {Signature: "unset(name: string)", Returns: "void", Doc: "Remove a collection variable"},
+	"pm.collectionVariables.clear":  {Signature: "clear()", Returns: "void", Doc: "Remove all collection variables"},
+	"pm.response":                   {Doc: "Response of the current request (post-scripts only)"},
+	"pm.response.json":              {Signature: "json()", Returns: "any", Doc: "Response body parsed as JSON; throws if the body is not JSON"},
+	"pm.response.text":              {Signature: "text()", Returns: "string", Doc: "Raw response body"},
+	"pm.response.parts":             {Signature: "parts()", Returns: "Array<{name, filename, contentType, size, bodyBase64, headers, text(), json()}>", Doc: "Parts of a multipart/mixed or multipart/form-data response ([] otherwise); binary parts carry bodyBase64"},
+	"pm.response.code":              {Returns: "number", Doc: "HTTP status code"},
+	"pm.response.status":            {Returns: "number", Doc: "HTTP status code (alias of code)"},
+	"pm.response.responseTime":      {Returns: "number", Doc: "Response time in milliseconds"},
+	"pm.response.headers":           {Doc: "Response headers"},
+	"pm.response.headers.get":       {Signature: "get(name: string)", Returns: "string | undefined", Doc: "Header value (case-insensitive)"},
+	"pm.response.to":                {Doc: "Response assertions"},
+	"pm.response.to.have":           {Doc: "Response assertions"},
+	"pm.response.to.have.status":    {Signature: "status(code: number)", Returns: "void", Doc: "Assert the status code"},
+	"pm.response.to.have.header":    {Signature: "header(name: string)", Returns: "void", Doc: "Assert a header is present"},
+	"pm.response.to.have.jsonBody":  {Signature: "jsonBody()", Returns: "void", Doc: "Assert the body is valid JSON"},
+	"pm.test":                       {Signature: "test(name: string, fn: () => void)", Returns: "void", Doc: "Run a named test; a thrown error marks it failed"},
+	"pm.expect":                     {Signature: "expect(actual: any)", Returns: "Assertion", Doc: "Start a chai-style assertion chain"},
+	"pm.expect().to":                {Doc: "Assertion chain"},
+	"pm.expect().to.equal":          {Signature: "equal(expected: any)", Returns: "void", Doc: "Deep equality"},
+	"pm.expect().to.eql":            {Signature: "eql(expected: any)", Returns: "void", Doc: "Deep equality (alias of equal)"},
+	"pm.expect().to.include":        {Signature: "include(value: any)", Returns: "void", Doc: "String or array contains value"},
+	"pm.expect().to.contain":        {Signature: "contain(value: any)", Returns: "void", Doc: "Alias of include"},
+	"pm.expect().to.be":             {Doc: "Assertion chain"},
+	"pm.expect().to.be.true":        {Signature: "true()", Returns: "void", Doc: "Value is true"},
+	"pm.expect().to.be.false":       {Signature: "false()", Returns: "void", Doc: "Value is false"},
+	"pm.expect().to.be.undefined":   {Signature: "undefined()", Returns: "void", Doc: "Value is undefined"},
+	"pm.expect().to.be.null":        {Signature: "null()", Returns: "void", Doc: "Value is null"},
+	"pm.expect().to.be.a":           {Signature: "a(type: string)", Returns: "void", Doc: "Value has the given type (string, number, boolean, object, array, null)"},
+	"pm.expect().to.be.an":          {Signature: "an(type: string)", Returns: "void", Doc: "Alias of a"},
+	"pm.expect().to.be.above":       {Signature: "above(n: number)", Returns: "void", Doc: "Value is greater than n"},
+	"pm.expect().to.be.greaterThan": {Signature: "greaterThan(n: number)", Returns: "void", Doc: "Alias of above"},
+	"pm.expect().to.be.below":       {Signature: "below(n: number)", Returns: "void", Doc: "Value is less than n"},
+	"pm.expect().to.be.lessThan":    {Signature: "lessThan(n: number)", Returns: "void", Doc: "Alias of below"},
+	"pm.expect().to.have":           {Doc: "Assertion chain"},
+	"pm.expect().to.have.property":  {Signature: "property(name: string)", Returns: "void", Doc: "Object has the property"},
+	"pm.expect().to.have.length":    {Signature: "length(n: number)", Returns: "void", Doc: "String or array has length n"},
+	"pm.info":                       {Doc: "Information about the current execution"},
+	"pm.info.iteration":             {Returns: "number", Doc: "Current loop iteration (1-based)"},
+	"pm.info.loopCount":             {Returns: "number", Doc: "Configured loop count of the step"},
+	"pm.info.requestName":           {Returns: "string", Doc: "Name of the current step"},
+	"pm.execution":                  {Doc: "Flow control"},
+	"pm.execution.skipRequest":      {Signature: "skipRequest()", Returns: "void", Doc: "Continue with the next step"},
+	"pm.execution.setNextRequest":   {Signature: "setNextRequest(stepName: string | null)", Returns: "void", Doc: "Jump to the named step; null stops the flow"},
+	"pm.visualizer":                 {Doc: "Custom response visualization, rendered by the client (Postman-compatible)"},
+	"pm.visualizer.set":             {Signature: "set(template: string, data?: any, options?: object)", Returns: "void", Doc: "Render the Handlebars template with data in the Visualize tab; template and data are capped at 1MB"},
+	"pm.visualizer.clear":           {Signature: "clear()", Returns: "void", Doc: "Remove the visualization set earlier in the script"},
+	"pm.request":                    {Doc: "Current request (read-only)"},
+	"pm.request.url":                {Returns: "string", Doc: "Resolved request URL"},
+	"pm.request.method":             {Returns: "string", Doc: "HTTP method"},
+	"pm.request.headers":            {Doc: "Request headers"},
+	"pm.request.headers.get":        {Signature: "get(name: string)", Returns: "string | undefined", Doc: "Header value (case-insensitive)"},
+	"pm.request.body":               {Doc: "Request body"},
+	"pm.request.body.toString":      {Signature: "toString()", Returns: "string", Doc: "Raw request body"},
+	"pm.sendRequest":                {Signature: "sendRequest(req: string | {url, method, headers, body}, callback: (err, res) => void)", Returns: "void", Doc: "Send an HTTP request (10 per script unless the workspace sets scriptRequests.maxPerScript); res has code, status, text(), json() and headers.get()"},
+	"console":                       {Doc: "Logging (output is discarded)"},
+	"console.log":                   {Signature: "log(...args: any[])", Returns: "void"},
+	"console.error":                 {Signature: "error(...args: any[])", Returns: "void"},
+	"console.warn":                  {Signature: "warn(...args: any[])", Returns: "void"},
+	"console.info":                  {Signature: "info(...args: any[])", Returns: "void"},
+	"require":                       {Signature: "require(name: 'lodash' | 'relay-utils' | 'ajv' | 'uuid')", Returns: "any", Doc: "Load a bundled library; it must be enabled in the workspace settings"},
+	"parseInt":                      {Signature: "parseInt(s: string, radix?: number)", Returns: "number"},
+	"parseFloat":                    {Signature: "parseFloat(s: string)", Returns: "number"},
+}
+
+// scriptAPIRoots are the sandbox globals included in the spec
+var scriptAPIRoots = []string{"pm", "console", "require", "parseInt", "parseFloat"}
+
+// ScriptAPI builds the spec by walking the objects the executor installs in a
+// fresh sandbox, so it always reflects what scripts can actually call.
+func (jse *JSScriptExecutor) ScriptAPI() ScriptAPISpec {
+	vm := goja.New()
+	jse.setupSandbox(vm)
+	jse.setupPmAPI(vm, &JSScriptContext{}, &JSScriptResult{UpdatedVars: make(map[string]string)})
+	jse.setupConsole(vm)
+	jse.setupRequire(vm, nil)
+
+	spec := ScriptAPISpec{Entries: []ScriptAPIEntry{}}
+	for _, root := range scriptAPIRoots {
+		walkScriptAPI(root, vm.Get(root), 0, &spec.Entries)
+	}
+
+	// The assertion chain only exists on values returned by pm.expect()
+	if expect, ok := goja.AssertFunction(vm.Get("pm").ToObject(vm).Get("expect")); ok {
+		if v, err := expect(goja.Undefined(), goja.Undefined()); err == nil {
+			obj := v.ToObject(vm)
+			for _, k := range sortedKeys(obj) {
+				walkScriptAPI("pm.expect()."+k, obj.Get(k), 1, &spec.Entries)
+			}
+		}
+	}
+	return spec
+}
+
+const maxScriptAPIDepth = 6
+
+func walkScriptAPI(path string, v goja.Value, depth int, out *[]ScriptAPIEntry) {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) || depth > maxScriptAPIDepth {
+		return
+	}
+	entry := ScriptAPIEntry{Path: path}
+	doc := scriptAPIDocs[path]
+	entry.Signature, entry.Returns, entry.Doc = doc.Signature, doc.Returns, doc.Doc
+
+	if _, ok := goja.AssertFunction(v); ok {
+		entry.Kind = "function"
+		*out = append(*out, entry)
+		return
+	}
+	if obj, ok := v.(*goja.Object); ok {
+		entry.Kind = "object"
+		*out = append(*out, entry)
+		for _, k := range sortedKeys(obj) {
+			walkScriptAPI(path+"."+k, obj.Get(k), depth+1, out)
+		}
+		return
+	}
+	entry.Kind = "property"
+	*out = append(*out, entry)
+}
+
+func sortedKeys(obj *goja.Object) []string {
+	keys := obj.Keys()
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,40 @@
+package service
+
+import "testing"
+
+func TestScriptAPI_MatchesSandbox(t *testing.T) {
+	spec := NewJSScriptExecutor(nil).ScriptAPI()
+
+	seen := make(map[string]ScriptAPIEntry)
+	for _, e := range spec.Entries {
+		if _, dup := seen[e.Path]; dup {
+			t.Errorf("duplicate entry %s", e.Path)
+		}
+		seen[e.Path] = e
+		if _, ok := scriptAPIDocs[e.Path]; !ok {
+			t.Errorf("%s is exposed in the sandbox but has no entry in scriptAPIDocs", e.Path)
+		}
+	}
+	for path := range scriptAPIDocs {
+		if _, ok := seen[path]; !ok {
+			t.Errorf("scriptAPIDocs documents %s which the sandbox does not expose", path)
+		}
+	}
+
+	checks := map[string]string{
+		"pm.environment.set":         "function",
+		"pm.response":                "object",
+		"pm.response.code":           "property",
+		"pm.expect().to.be.above":    "function",
+		"pm.response.to.have.status": "function",
+		"console.log":                "function",
+	}
+	for path, kind := range checks {
+		if got := seen[path].Kind; got != kind {
+			t.Errorf("%s kind = %q, want %q", path, got, kind)
+		}
+	}
+	if seen["pm.sendRequest"].Signature == "" {
+		t.Error("pm.sendRequest should have a signature")
+	}
+}
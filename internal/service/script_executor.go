@@ -1,6 +1,10 @@
 package service
 
 import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -9,7 +13,6 @@ import (
 	"time"
 
 	"github.com/PaesslerAG/jsonpath"
-	"github.com/google/uuid"
 )
 
 // gojaErrorLocationRe matches goja runtime error locations:
@@ -74,10 +77,16 @@ type ScriptResult struct {
 	ErrorDetails     []ErrorDetail     `json:"errorDetails,omitempty"`
 	AssertionsPassed int               `json:"assertionsPassed"`
 	AssertionsFailed int               `json:"assertionsFailed"`
+	Tests            []TestResult      `json:"tests,omitempty"` // pm.test or DSL assertions in order
 	UpdatedVars      map[string]string `json:"updatedVars,omitempty"`
 	FlowAction       FlowAction        `json:"flowAction"`
 	GotoStepName     string            `json:"gotoStepName,omitempty"`
 	GotoStepOrder    int               `json:"gotoStepOrder,omitempty"`
+	VariableChanges  []VariableChange  `json:"variableChanges,omitempty"`
+	// SendRequestCacheHits counts pm.sendRequest calls answered from the run's cache
+	SendRequestCacheHits int `json:"sendRequestCacheHits,omitempty"`
+	// Visualization is the template and data of pm.visualizer.set (JavaScript only)
+	Visualization *ScriptVisualization `json:"visualization,omitempty"`
 }
 
 // ScriptContext provides context for script execution
@@ -85,13 +94,19 @@ type ScriptContext struct {
 	RuntimeVars  map[string]string
 	StatusCode   int
 	ResponseBody string
-	Headers      map[string]string
-	DurationMs   int64
-	StepName     string
-	StepOrder    int
-	FlowName     string
-	Iteration    int64
-	LoopCount    int64
+	// ResponseBytes is the raw body for binary assertions (binary or
+	// transcoded responses); ResponseBody is used when nil
+	ResponseBytes []byte
+	Headers       map[string]string
+	DurationMs    int64
+	StepName      string
+	StepOrder     int
+	FlowName      string
+	Iteration     int64
+	LoopCount     int64
+	Extensions    *WorkspaceExtensions // wasm assertions and transforms; set by FlowRunner
+	Clock         func() time.Time     // time for {{__timestamp__}}; nil uses the real clock
+	random        *runRandom           // source for {{__uuid__}} in seeded runs
 }
 
 // Script represents the DSL script structure
@@ -103,25 +118,41 @@ type Script struct {
 
 // Assertion represents a single assertion
 type Assertion struct {
-	Type     string      `json:"type"`               // status, jsonpath, header, responseTime, bodyContains
+	Type     string      `json:"type"`               // status, jsonpath, header, responseTime, bodyContains, bodySha256, bodyMd5, bodySize, bodyPrefix, wasm
 	Path     string      `json:"path,omitempty"`     // for jsonpath
 	Name     string      `json:"name,omitempty"`     // for header
+	Module   string      `json:"module,omitempty"`   // for wasm: workspace extension name
 	Operator string      `json:"operator,omitempty"` // eq, ne, gt, gte, lt, lte, contains, in, exists, regex
 	Value    interface{} `json:"value,omitempty"`
 }
 
+// label names an assertion in test results, e.g. "jsonpath $.id eq 1"
+func (a Assertion) label() string {
+	parts := []string{a.Type}
+	for _, p := range []string{a.Path, a.Name, a.Module, a.Operator} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if a.Value != nil {
+		parts = append(parts, fmt.Sprint(a.Value))
+	}
+	return strings.Join(parts, " ")
+}
+
 // VariableOperation represents a variable manipulation
 type VariableOperation struct {
 	Name       string      `json:"name"`
 	Value      interface{} `json:"value,omitempty"`      // for set (literal)
 	From       string      `json:"from,omitempty"`       // JSONPath to extract from response
-	Operation  string      `json:"operation,omitempty"`  // set, increment, decrement, math, concat, conditional
+	Operation  string      `json:"operation,omitempty"`  // set, increment, decrement, math, concat, conditional, wasm
 	By         float64     `json:"by,omitempty"`         // for increment/decrement
 	Expression string      `json:"expression,omitempty"` // for math
 	Values     []string    `json:"values,omitempty"`     // for concat
 	Condition  string      `json:"condition,omitempty"`  // for conditional
 	IfTrue     interface{} `json:"ifTrue,omitempty"`
 	IfFalse    interface{} `json:"ifFalse,omitempty"`
+	Module     string      `json:"module,omitempty"` // for wasm: workspace extension name
 }
 
 // FlowControl represents flow control logic
@@ -221,18 +252,22 @@ func (se *ScriptExecutor) Execute(scriptJSON string, ctx *ScriptContext) *Script
 
 func (se *ScriptExecutor) executeAssertions(script *Script, ctx *ScriptContext, result *ScriptResult) {
 	for _, assertion := range script.Assertions {
+		test := TestResult{Name: assertion.label(), Passed: true}
 		passed, err := se.evaluateAssertion(assertion, ctx)
 		if err != nil {
 			result.Errors = append(result.Errors, err.Error())
 			result.AssertionsFailed++
 			result.Success = false
+			test.Passed, test.Error = false, err.Error()
 		} else if passed {
 			result.AssertionsPassed++
 		} else {
 			result.AssertionsFailed++
 			result.Success = false
 			result.Errors = append(result.Errors, fmt.Sprintf("Assertion failed: %s %s %v", assertion.Type, assertion.Operator, assertion.Value))
+			test.Passed, test.Error = false, "assertion failed"
 		}
+		result.Tests = append(result.Tests, test)
 	}
 }
 
@@ -291,11 +326,78 @@ func (se *ScriptExecutor) evaluateAssertion(assertion Assertion, ctx *ScriptCont
 		}
 		return strings.Contains(ctx.ResponseBody, valueStr), nil
 
+	case "bodySha256":
+		sum := sha256.Sum256(ctx.rawBody())
+		return se.compareValues(hex.EncodeToString(sum[:]), assertion.Operator, lowerHex(assertion.Value))
+
+	case "bodyMd5":
+		sum := md5.Sum(ctx.rawBody())
+		return se.compareValues(hex.EncodeToString(sum[:]), assertion.Operator, lowerHex(assertion.Value))
+
+	case "bodySize":
+		return se.compareValues(float64(len(ctx.rawBody())), assertion.Operator, assertion.Value)
+
+	case "bodyPrefix":
+		// Magic bytes as hex, e.g. "25504446" (%PDF) or "89 50 4E 47" (PNG)
+		valueStr, ok := assertion.Value.(string)
+		if !ok {
+			return false, fmt.Errorf("bodyPrefix value must be a hex string")
+		}
+		prefix, err := decodeHexBytes(valueStr)
+		if err != nil {
+			return false, fmt.Errorf("bodyPrefix value must be a hex string: %v", err)
+		}
+		return bytes.HasPrefix(ctx.rawBody(), prefix), nil
+
+	case "wasm":
+		out, err := ctx.Extensions.Assert(assertion.Module, WasmAssertInput{
+			Status:     ctx.StatusCode,
+			Headers:    ctx.Headers,
+			Body:       ctx.ResponseBody,
+			DurationMs: ctx.DurationMs,
+			Args:       assertion.Value,
+		})
+		if err != nil {
+			return false, err
+		}
+		if !out.Pass && out.Message != "" {
+			return false, fmt.Errorf("Assertion failed: wasm %s: %s", assertion.Module, out.Message)
+		}
+		return out.Pass, nil
+
 	default:
 		return false, fmt.Errorf("unknown assertion type: %s", assertion.Type)
 	}
 }
 
+// rawBody returns the response bytes that binary assertions check
+func (ctx *ScriptContext) rawBody() []byte {
+	if ctx.ResponseBytes != nil {
+		return ctx.ResponseBytes
+	}
+	return []byte(ctx.ResponseBody)
+}
+
+// lowerHex normalizes expected digests (a string or list of strings) for comparison
+func lowerHex(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return strings.ToLower(strings.TrimSpace(t))
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			out[i] = lowerHex(item)
+		}
+		return out
+	}
+	return v
+}
+
+// decodeHexBytes decodes hex that may be space separated
+func decodeHexBytes(s string) ([]byte, error) {
+	return hex.DecodeString(strings.Join(strings.Fields(s), ""))
+}
+
 func (se *ScriptExecutor) compareValues(actual interface{}, operator string, expected interface{}) (bool, error) {
 	switch operator {
 	case "eq", "":
@@ -437,11 +539,11 @@ func (se *ScriptExecutor) executeVariableOp(op VariableOperation, ctx *ScriptCon
 
 	case "math":
 		expr := se.resolveVariables(op.Expression, ctx)
-		result, err := se.evaluateMathExpression(expr)
+		result, err := se.evaluateMathExpression(expr, ctx)
 		if err != nil {
 			return "", err
 		}
-		return fmt.Sprintf("%v", result), nil
+		return strconv.FormatFloat(result, 'f', -1, 64), nil
 
 	case "concat":
 		var parts []string
@@ -457,6 +559,32 @@ func (se *ScriptExecutor) executeVariableOp(op VariableOperation, ctx *ScriptCon
 		}
 		return fmt.Sprintf("%v", op.IfFalse), nil
 
+	case "wasm":
+		// Transforms the response body, or the value at "from" when set
+		input := ctx.ResponseBody
+		if op.From != "" {
+			var data interface{}
+			if err := json.Unmarshal([]byte(ctx.ResponseBody), &data); err != nil {
+				return "", fmt.Errorf("failed to parse response JSON: %v", err)
+			}
+			value, err := jsonpath.Get(op.From, data)
+			if err != nil {
+				return "", fmt.Errorf("JSONPath error: %v", err)
+			}
+			if str, ok := value.(string); ok {
+				input = str
+			} else {
+				raw, _ := json.Marshal(value)
+				input = string(raw)
+			}
+		}
+		return ctx.Extensions.Transform(op.Module, WasmTransformInput{
+			Status:  ctx.StatusCode,
+			Headers: ctx.Headers,
+			Body:    input,
+			Args:    op.Value,
+		})
+
 	default:
 		return "", fmt.Errorf("unknown operation: %s", op.Operation)
 	}
@@ -488,9 +616,13 @@ func (se *ScriptExecutor) resolveVariables(s string, ctx *ScriptContext) string
 		case "__flowName__":
 			return ctx.FlowName
 		case "__timestamp__":
-			return strconv.FormatInt(time.Now().UnixMilli(), 10)
+			now := time.Now()
+			if ctx.Clock != nil {
+				now = ctx.Clock()
+			}
+			return strconv.FormatInt(now.UnixMilli(), 10)
 		case "__uuid__":
-			return uuid.New().String()
+			return ctx.random.UUID()
 		}
 
 		if val, ok := ctx.RuntimeVars[varName]; ok {
@@ -500,39 +632,9 @@ func (se *ScriptExecutor) resolveVariables(s string, ctx *ScriptContext) string
 	})
 }
 
-func (se *ScriptExecutor) evaluateMathExpression(expr string) (float64, error) {
-	// Simple math expression evaluator supporting +, -, *, /, (), numbers
-	// This is a basic implementation - can be enhanced with a proper expression parser
-	expr = strings.ReplaceAll(expr, " ", "")
-
-	// For now, handle simple binary operations
-	// Pattern: number op number
-	re := regexp.MustCompile(`^(-?\d+\.?\d*)([\+\-\*/])(-?\d+\.?\d*)$`)
-	matches := re.FindStringSubmatch(expr)
-	if matches == nil {
-		// Try parsing as a single number
-		return strconv.ParseFloat(expr, 64)
-	}
-
-	left, _ := strconv.ParseFloat(matches[1], 64)
-	op := matches[2]
-	right, _ := strconv.ParseFloat(matches[3], 64)
-
-	switch op {
-	case "+":
-		return left + right, nil
-	case "-":
-		return left - right, nil
-	case "*":
-		return left * right, nil
-	case "/":
-		if right == 0 {
-			return 0, fmt.Errorf("division by zero")
-		}
-		return left / right, nil
-	default:
-		return 0, fmt.Errorf("unsupported operator: %s", op)
-	}
+// evaluateMathExpression evaluates a math expression; bare identifiers refer to runtime variables
+func (se *ScriptExecutor) evaluateMathExpression(expr string, ctx *ScriptContext) (float64, error) {
+	return EvaluateMath(expr, ctx.RuntimeVars)
 }
 
 func (se *ScriptExecutor) executeFlowControl(script *Script, ctx *ScriptContext, result *ScriptResult) {
@@ -581,71 +683,9 @@ func (se *ScriptExecutor) executeFlowControl(script *Script, ctx *ScriptContext,
 	}
 }
 
-// evaluateConditionExpr evaluates a condition expression
-// Supports: ==, !=, >, >=, <, <=, &&, ||, !, contains
+// evaluateConditionExpr evaluates a condition expression (see condition_expr.go).
+// Malformed expressions evaluate to false.
 func (se *ScriptExecutor) evaluateConditionExpr(expr string, ctx *ScriptContext) bool {
-	expr = strings.TrimSpace(expr)
-	if expr == "" {
-		return true
-	}
-
-	// Handle logical OR (lowest precedence)
-	if strings.Contains(expr, "||") {
-		parts := strings.SplitN(expr, "||", 2)
-		return se.evaluateConditionExpr(parts[0], ctx) || se.evaluateConditionExpr(parts[1], ctx)
-	}
-
-	// Handle logical AND
-	if strings.Contains(expr, "&&") {
-		parts := strings.SplitN(expr, "&&", 2)
-		return se.evaluateConditionExpr(parts[0], ctx) && se.evaluateConditionExpr(parts[1], ctx)
-	}
-
-	// Handle NOT
-	if strings.HasPrefix(expr, "!") {
-		return !se.evaluateConditionExpr(strings.TrimPrefix(expr, "!"), ctx)
-	}
-
-	// Handle comparisons
-	operators := []string{"==", "!=", ">=", "<=", ">", "<", " contains "}
-	for _, op := range operators {
-		if strings.Contains(expr, op) {
-			parts := strings.SplitN(expr, op, 2)
-			left := strings.TrimSpace(parts[0])
-			right := strings.TrimSpace(parts[1])
-
-			// Remove quotes from string literals
-			left = strings.Trim(left, "\"'")
-			right = strings.Trim(right, "\"'")
-
-			switch op {
-			case "==":
-				return left == right
-			case "!=":
-				return left != right
-			case ">":
-				lf, _ := strconv.ParseFloat(left, 64)
-				rf, _ := strconv.ParseFloat(right, 64)
-				return lf > rf
-			case ">=":
-				lf, _ := strconv.ParseFloat(left, 64)
-				rf, _ := strconv.ParseFloat(right, 64)
-				return lf >= rf
-			case "<":
-				lf, _ := strconv.ParseFloat(left, 64)
-				rf, _ := strconv.ParseFloat(right, 64)
-				return lf < rf
-			case "<=":
-				lf, _ := strconv.ParseFloat(left, 64)
-				rf, _ := strconv.ParseFloat(right, 64)
-				return lf <= rf
-			case " contains ":
-				return strings.Contains(left, right)
-			}
-		}
-	}
-
-	// Truthy check - non-empty and not "0" or "false"
-	expr = strings.TrimSpace(expr)
-	return expr != "" && expr != "0" && expr != "false" && expr != "null"
+	ok, err := EvaluateCondition(expr)
+	return err == nil && ok
 }
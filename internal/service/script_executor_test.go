@@ -93,6 +93,42 @@ func TestScriptExecutor_Assertions(t *testing.T) {
 			wantFail:   0,
 			wantAction: FlowActionNext,
 		},
+		{
+			name: "binary body checks",
+			script: `{
+				"assertions": [
+					{"type": "bodySha256", "value": "2D0F60E62F80DDEA32DC2D6182644881AF95907EFF64D4404913C9B55DE700DA"},
+					{"type": "bodyMd5", "operator": "in", "value": ["00000000000000000000000000000000", "7d381ceb22d0f867f80acff78e4f511d"]},
+					{"type": "bodySize", "operator": "eq", "value": 11},
+					{"type": "bodyPrefix", "value": "25 50 44 46"}
+				]
+			}`,
+			ctx: &ScriptContext{
+				ResponseBytes: []byte("%PDF-1.4\n\x00\xff"),
+				RuntimeVars:   make(map[string]string),
+			},
+			wantPass:   4,
+			wantFail:   0,
+			wantAction: FlowActionNext,
+		},
+		{
+			name: "binary body mismatch",
+			script: `{
+				"assertions": [
+					{"type": "bodySha256", "value": "0000000000000000000000000000000000000000000000000000000000000000"},
+					{"type": "bodySize", "operator": "gt", "value": 100},
+					{"type": "bodyPrefix", "value": "89504e47"},
+					{"type": "bodyPrefix", "value": "not hex"}
+				]
+			}`,
+			ctx: &ScriptContext{
+				ResponseBody: "%PDF-1.4",
+				RuntimeVars:  make(map[string]string),
+			},
+			wantPass:   0,
+			wantFail:   4,
+			wantAction: FlowActionNext,
+		},
 	}
 
 	for _, tt := range tests {
@@ -111,6 +147,33 @@ func TestScriptExecutor_Assertions(t *testing.T) {
 	}
 }
 
+func TestScriptExecutor_AssertionTests(t *testing.T) {
+	se := NewScriptExecutor(nil)
+	result := se.Execute(`{
+		"assertions": [
+			{"type": "status", "operator": "eq", "value": 200},
+			{"type": "jsonpath", "path": "$.id", "operator": "eq", "value": 2}
+		]
+	}`, &ScriptContext{
+		StatusCode:   200,
+		ResponseBody: `{"id": 1}`,
+		RuntimeVars:  make(map[string]string),
+	})
+
+	want := []TestResult{
+		{Name: "status eq 200", Passed: true},
+		{Name: "jsonpath $.id eq 2", Passed: false, Error: "assertion failed"},
+	}
+	if len(result.Tests) != len(want) {
+		t.Fatalf("Tests = %+v", result.Tests)
+	}
+	for i, w := range want {
+		if result.Tests[i] != w {
+			t.Errorf("Tests[%d] = %+v, want %+v", i, result.Tests[i], w)
+		}
+	}
+}
+
 func TestScriptExecutor_Variables(t *testing.T) {
 	se := NewScriptExecutor(nil)
 
@@ -193,6 +256,18 @@ func TestScriptExecutor_Variables(t *testing.T) {
 			},
 			wantVars: map[string]string{"greeting": "Hello, World!"},
 		},
+		{
+			name: "math expression",
+			script: `{
+				"setVariables": [
+					{"name": "total", "operation": "math", "expression": "round({{price}} * qty * (1 - discount), 2)"}
+				]
+			}`,
+			ctx: &ScriptContext{
+				RuntimeVars: map[string]string{"price": "19.99", "qty": "3", "discount": "0.15"},
+			},
+			wantVars: map[string]string{"total": "50.97"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dop251/goja"
+	"github.com/google/uuid"
+)
+
+// Bundled pure-JS libraries. ajv is the upstream 6.12.6 build (see
+// jslib/LICENSE-ajv); relay-utils.js is Relay's own collection/object helpers,
+// also served as "lodash" so Postman scripts that require it keep working.
+//
+//go:embed jslib/relay-utils.js jslib/ajv.min.js
+var jsLibFS embed.FS
+
+var ErrUnknownScriptLibrary = errors.New("unknown script library")
+
+type scriptLibrary struct {
+	// file is the embedded CommonJS source; native builds the module in Go instead
+	file   string
+	native func(vm *goja.Runtime) goja.Value
+
+	once sync.Once
+	prog *goja.Program
+	err  error
+}
+
+var relayUtils = &scriptLibrary{file: "jslib/relay-utils.js"}
+
+var scriptLibraries = map[string]*scriptLibrary{
+	"relay-utils": relayUtils,
+	"lodash":      relayUtils, // lodash-compatible subset, not a lodash build
+	"ajv":         {file: "jslib/ajv.min.js"},
+	"uuid":        {native: uuidModule},
+}
+
+// ScriptLibraryNames returns the libraries a workspace can enable
+func ScriptLibraryNames() []string {
+	names := make([]string, 0, len(scriptLibraries))
+	for name := range scriptLibraries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateScriptLibraries rejects names that are not bundled
+func ValidateScriptLibraries(names []string) error {
+	for _, n := range names {
+		if _, ok := scriptLibraries[n]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownScriptLibrary, n)
+		}
+	}
+	return nil
+}
+
+// program compiles the library once; the result is shared by all runtimes
+func (lib *scriptLibrary) program() (*goja.Program, error) {
+	lib.once.Do(func() {
+		src, err := jsLibFS.ReadFile(lib.file)
+		if err != nil {
+			lib.err = err
+			return
+		}
+		// Libraries such as ajv generate code with new Function(); the sandbox
+		// removes the global, so hand it back inside the module scope only.
+		// The sandbox's parseInt/parseFloat differ from the spec (e.g. 0 instead
+		// of NaN), so modules get the native ones.
+		wrapped := "(function (module, exports) { var Function = (function () {}).constructor," +
+			" parseInt = Number.parseInt, parseFloat = Number.parseFloat;\n" + string(src) + "\n})"
+		lib.prog, lib.err = goja.Compile(lib.file, wrapped, false)
+	})
+	return lib.prog, lib.err
+}
+
+func (lib *scriptLibrary) load(vm *goja.Runtime) (goja.Value, error) {
+	if lib.native != nil {
+		return lib.native(vm), nil
+	}
+	prog, err := lib.program()
+	if err != nil {
+		return nil, err
+	}
+	fnVal, err := vm.RunProgram(prog)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := goja.AssertFunction(fnVal)
+	if !ok {
+		return nil, fmt.Errorf("%s: module wrapper is not a function", lib.file)
+	}
+	module := vm.NewObject()
+	exports := vm.NewObject()
+	module.Set("exports", exports)
+	if _, err := fn(goja.Undefined(), module, exports); err != nil {
+		return nil, err
+	}
+	return module.Get("exports"), nil
+}
+
+// setupRequire installs require(name) for the libraries enabled in the workspace
+func (jse *JSScriptExecutor) setupRequire(vm *goja.Runtime, enabled []string) {
+	loaded := make(map[string]goja.Value)
+	vm.Set("require", func(call goja.FunctionCall) goja.Value {
+		name := call.Argument(0).String()
+		if v, ok := loaded[name]; ok {
+			return v
+		}
+		lib, ok := scriptLibraries[name]
+		if !ok {
+			panic(vm.ToValue(fmt.Sprintf("Cannot find module '%s' (available: %v)", name, ScriptLibraryNames())))
+		}
+		if !containsString(enabled, name) {
+			panic(vm.ToValue(fmt.Sprintf("Module '%s' is not enabled for this workspace", name)))
+		}
+		v, err := lib.load(vm)
+		if err != nil {
+			panic(vm.ToValue(fmt.Sprintf("Failed to load module '%s': %v", name, err)))
+		}
+		loaded[name] = v
+		return v
+	})
+}
+
+// uuidModule mirrors the commonly used parts of the npm uuid package
+func uuidModule(vm *goja.Runtime) goja.Value {
+	m := vm.NewObject()
+	m.Set("v4", func(call goja.FunctionCall) goja.Value {
+		return vm.ToValue(uuid.NewString())
+	})
+	m.Set("v1", func(call goja.FunctionCall) goja.Value {
+		id, err := uuid.NewUUID()
+		if err != nil {
+			panic(vm.ToValue(err.Error()))
+		}
+		return vm.ToValue(id.String())
+	})
+	m.Set("validate", func(call goja.FunctionCall) goja.Value {
+		s := call.Argument(0).String()
+		_, err := uuid.Parse(s)
+		return vm.ToValue(err == nil && len(s) == 36)
+	})
+	m.Set("version", func(call goja.FunctionCall) goja.Value {
+		id, err := uuid.Parse(call.Argument(0).String())
+		if err != nil {
+			panic(vm.ToValue("Invalid UUID"))
+		}
+		return vm.ToValue(int(id.Version()))
+	})
+	m.Set("NIL", uuid.Nil.String())
+	return m
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func runWithLibraries(t *testing.T, libs []string, script string) *JSScriptResult {
+	t.Helper()
+	ctx := &JSScriptContext{
+		RuntimeVars:     make(map[string]string),
+		EnvVars:         make(map[string]string),
+		ScriptLibraries: libs,
+	}
+	return NewJSScriptExecutor(nil).Execute(script, ctx)
+}
+
+func TestRequire_RelayUtils(t *testing.T) {
+	script := `
+		var _ = require('relay-utils');
+		var users = [{id: 3, role: 'admin', tags: ['a']}, {id: 1, role: 'user'}, {id: 2, role: 'admin'}];
+		pm.variables.set('get', _.get({a: {b: [{c: 'deep'}]}}, 'a.b[0].c'));
+		pm.variables.set('admins', _.map(_.filter(users, {role: 'admin'}), 'id').join(','));
+		pm.variables.set('sorted', _.map(_.sortBy(users, 'id'), 'id').join(','));
+		pm.variables.set('grouped', JSON.stringify(_.mapValues(_.groupBy(users, 'role'), 'length')));
+		pm.variables.set('picked', JSON.stringify(_.pick({a: 1, b: 2, c: 3}, ['a', 'c'])));
+		pm.variables.set('omitted', JSON.stringify(_.omit({a: 1, b: {x: 1, y: 2}}, 'b.y')));
+		pm.variables.set('merged', JSON.stringify(_.merge({a: {b: 1}}, {a: {c: 2}})));
+		pm.variables.set('uniq', _.uniq([1, 2, 2, 3, 1]).join(','));
+		pm.variables.set('chunk', JSON.stringify(_.chunk([1, 2, 3, 4, 5], 2)));
+		pm.variables.set('equal', String(_.isEqual({a: [1, {b: 2}]}, {a: [1, {b: 2}]})));
+		pm.variables.set('camel', _.camelCase('Foo Bar-baz'));
+		pm.variables.set('sum', String(_.sumBy(users, 'id')));
+	`
+	result := runWithLibraries(t, []string{"relay-utils"}, script)
+	if !result.Success {
+		t.Fatalf("script failed: %v", result.Errors)
+	}
+
+	want := map[string]string{
+		"get":     "deep",
+		"admins":  "3,2",
+		"sorted":  "1,2,3",
+		"grouped": `{"admin":2,"user":1}`,
+		"picked":  `{"a":1,"c":3}`,
+		"omitted": `{"a":1,"b":{"x":1}}`,
+		"merged":  `{"a":{"b":1,"c":2}}`,
+		"uniq":    "1,2,3",
+		"chunk":   "[[1,2],[3,4],[5]]",
+		"equal":   "true",
+		"camel":   "fooBarBaz",
+		"sum":     "6",
+	}
+	for k, v := range want {
+		if got := result.UpdatedVars[k]; got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestRequire_LodashAlias(t *testing.T) {
+	script := `
+		var _ = require('lodash');
+		var body = {data: {items: [{id: 1, name: 'a b'}, {id: 2, name: 'c'}]}};
+		pm.variables.set('get', _.get(body, 'data.items[1].name'));
+		pm.variables.set('each', String(_.reduce(_.get(body, 'data.items'), function (n, i) { return n + i.id; }, 0)));
+		pm.variables.set('flatMap', _.flatMap([[1], [2, 3]], _.identity).join(','));
+		pm.variables.set('findKey', _.findKey({a: {ok: false}, b: {ok: true}}, 'ok'));
+		pm.variables.set('zip', JSON.stringify(_.zipObject(['a', 'b'], [1, 2])));
+		pm.variables.set('xor', _.xor([1, 2], [2, 3]).join(','));
+		pm.variables.set('takeRight', _.takeRight([1, 2, 3], 2).join(','));
+		pm.variables.set('round', _.round(4.006, 2) + '/' + _.floor(2.79, 1));
+		pm.variables.set('upper', _.upperCase('fooBar'));
+		pm.variables.set('truncate', _.truncate('hello world', {length: 8}));
+		pm.variables.set('same', String(require('lodash') === _));
+	`
+	result := runWithLibraries(t, []string{"lodash"}, script)
+	if !result.Success {
+		t.Fatalf("script failed: %v", result.Errors)
+	}
+
+	want := map[string]string{
+		"get":       "c",
+		"each":      "3",
+		"flatMap":   "1,2,3",
+		"findKey":   "b",
+		"zip":       `{"a":1,"b":2}`,
+		"xor":       "1,3",
+		"takeRight": "2,3",
+		"round":     "4.01/2.7",
+		"upper":     "FOO BAR",
+		"truncate":  "hello...",
+		"same":      "true",
+	}
+	for k, v := range want {
+		if got := result.UpdatedVars[k]; got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+
+	// Each name is enabled separately
+	result = runWithLibraries(t, []string{"relay-utils"}, `require('lodash');`)
+	if result.Success {
+		t.Error("lodash should need its own entry in scriptLibraries")
+	}
+}
+
+func TestRequire_Ajv(t *testing.T) {
+	script := `
+		var Ajv = require('ajv');
+		var ajv = new Ajv({allErrors: true});
+		var validate = ajv.compile({
+			type: 'object',
+			required: ['id', 'email'],
+			properties: {id: {type: 'integer'}, email: {type: 'string', format: 'email'}}
+		});
+		pm.test('valid body', function () {
+			pm.expect(validate({id: 1, email: 'dev@example.com'})).to.be.true();
+		});
+		pm.test('invalid body', function () {
+			pm.expect(validate({id: 'x'})).to.be.false();
+			pm.expect(validate.errors.length).to.equal(2);
+		});
+	`
+	result := runWithLibraries(t, []string{"ajv"}, script)
+	if !result.Success || result.AssertionsFailed != 0 {
+		t.Fatalf("script failed: %v (failed=%d)", result.Errors, result.AssertionsFailed)
+	}
+	if result.AssertionsPassed != 2 {
+		t.Errorf("passed = %d, want 2", result.AssertionsPassed)
+	}
+}
+
+func TestRequire_UUID(t *testing.T) {
+	script := `
+		var uuid = require('uuid');
+		var id = uuid.v4();
+		pm.variables.set('valid', String(uuid.validate(id)));
+		pm.variables.set('version', String(uuid.version(id)));
+		pm.variables.set('invalid', String(uuid.validate('nope')));
+		pm.variables.set('same', String(require('uuid') === uuid));
+	`
+	result := runWithLibraries(t, []string{"uuid"}, script)
+	if !result.Success {
+		t.Fatalf("script failed: %v", result.Errors)
+	}
+	want := map[string]string{"valid": "true", "version": "4", "invalid": "false", "same": "true"}
+	for k, v := range want {
+		if got := result.UpdatedVars[k]; got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestRequire_NotEnabled(t *testing.T) {
+	result := runWithLibraries(t, nil, `require('relay-utils');`)
+	if result.Success {
+		t.Fatal("expected failure when relay-utils is not enabled")
+	}
+	if !strings.Contains(strings.Join(result.Errors, " "), "not enabled") {
+		t.Errorf("errors = %v, want 'not enabled'", result.Errors)
+	}
+
+	result = runWithLibraries(t, []string{"relay-utils"}, `require('fs');`)
+	if result.Success || !strings.Contains(strings.Join(result.Errors, " "), "Cannot find module") {
+		t.Errorf("errors = %v, want 'Cannot find module'", result.Errors)
+	}
+}
+
+func TestRequire_SandboxFunctionStillDisabled(t *testing.T) {
+	result := runWithLibraries(t, []string{"ajv"}, `require('ajv'); if (typeof Function !== 'undefined') throw new Error('Function leaked');`)
+	if !result.Success {
+		t.Fatalf("script failed: %v", result.Errors)
+	}
+}
+
+func TestValidateScriptLibraries(t *testing.T) {
+	if err := ValidateScriptLibraries([]string{"lodash", "relay-utils", "ajv", "uuid"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateScriptLibraries([]string{"moment"}); !errors.Is(err, ErrUnknownScriptLibrary) {
+		t.Errorf("err = %v, want ErrUnknownScriptLibrary", err)
+	}
+}
+
+func TestFlowRunner_RequireUsesWorkspaceSettings(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"items":[{"id":2},{"id":1}]}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{{
+		Name:   "list",
+		Method: "GET",
+		Url:    ts.URL,
+		PostScript: sql.NullString{
+			String: `var _ = require('relay-utils'); pm.variables.set('ids', _.map(_.sortBy(pm.response.json().items, 'id'), 'id').join(','));`,
+			Valid:  true,
+		},
+	}})
+
+	result, err := fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success {
+		t.Fatal("expected failure while relay-utils is not enabled")
+	}
+
+	if _, err := q.UpdateWorkspaceSettings(context.Background(), repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"scriptLibraries":["relay-utils"]}`, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = fr.Run(context.Background(), flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got %s", result.Error)
+	}
+	if got := result.Steps[0].ExtractedVars["ids"]; got != "1,2" {
+		t.Errorf("ids = %q, want 1,2", got)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"relay/internal/repository"
+)
+
+const (
+	maxScriptRequestsPerScript = 100
+	maxScriptRequestsPerRun    = 10000
+)
+
+// ErrScriptRequestLimit fails a script whose pm.sendRequest call would
+// exceed the workspace's cap
+var ErrScriptRequestLimit = errors.New("script request limit reached")
+
+// ScriptRequestLimits caps the pm.sendRequest traffic of a workspace's
+// scripts; zero values use the defaults
+type ScriptRequestLimits struct {
+	// MaxPerScript is the number of calls one script execution may make
+	// (default MaxSendRequests)
+	MaxPerScript int `json:"maxPerScript,omitempty"`
+	// MaxPerRun is the number of requests all scripts of a flow run may send;
+	// cached responses don't count (0 = unlimited)
+	MaxPerRun int `json:"maxPerRun,omitempty"`
+}
+
+func (l ScriptRequestLimits) Validate() error {
+	if l.MaxPerScript < 0 || l.MaxPerScript > maxScriptRequestsPerScript {
+		return fmt.Errorf("scriptRequests.maxPerScript must be between 0 and %d", maxScriptRequestsPerScript)
+	}
+	if l.MaxPerRun < 0 || l.MaxPerRun > maxScriptRequestsPerRun {
+		return fmt.Errorf("scriptRequests.maxPerRun must be between 0 and %d", maxScriptRequestsPerRun)
+	}
+	return nil
+}
+
+// ScriptRequest is one pm.sendRequest call of a step's scripts
+type ScriptRequest struct {
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+	Cached     bool   `json:"cached,omitempty"` // answered from the run's sendRequest cache
+	Error      string `json:"error,omitempty"`
+}
+
+type scriptRequestsKey struct{}
+
+// ScriptRequests scopes the pm.sendRequest calls of one flow step or saved
+// request: they go out through its proxy, are listed in its result and their
+// history entries become children of its own entry
+type ScriptRequests struct {
+	proxyID sql.NullInt64
+
+	mu         sync.Mutex
+	calls      []ScriptRequest
+	historyIDs []int64
+}
+
+// WithScriptRequests makes pm.sendRequest calls run with ctx use proxyID, as
+// the calling step's request does (NULL inherits the workspace proxy)
+func WithScriptRequests(ctx context.Context, proxyID sql.NullInt64) (context.Context, *ScriptRequests) {
+	s := &ScriptRequests{proxyID: proxyID}
+	return context.WithValue(ctx, scriptRequestsKey{}, s), s
+}
+
+// scriptRequestsFrom returns the calls' scope, or nil outside one
+func scriptRequestsFrom(ctx context.Context) *ScriptRequests {
+	s, _ := ctx.Value(scriptRequestsKey{}).(*ScriptRequests)
+	return s
+}
+
+func (s *ScriptRequests) record(call ScriptRequest, historyID int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	if historyID != 0 {
+		s.historyIDs = append(s.historyIDs, historyID)
+	}
+	s.mu.Unlock()
+}
+
+// Calls returns the calls made so far
+func (s *ScriptRequests) Calls() []ScriptRequest {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ScriptRequest(nil), s.calls...)
+}
+
+// Attach links the history entries of the calls made so far to parent's
+// entry. Calls are recorded before their parent (pre-scripts run first), so
+// linking happens once the parent has been executed.
+func (s *ScriptRequests) Attach(ctx context.Context, queries *repository.Queries, parent *ExecuteResult) {
+	if s == nil || parent == nil || parent.HistoryID == 0 {
+		return
+	}
+	s.mu.Lock()
+	ids := s.historyIDs
+	s.historyIDs = nil
+	s.mu.Unlock()
+	for _, id := range ids {
+		queries.SetHistoryParent(ctx, repository.SetHistoryParentParams{
+			ParentHistoryID: sql.NullInt64{Int64: parent.HistoryID, Valid: true},
+			ID:              id,
+		})
+	}
+}
+
+type scriptRequestBudgetKey struct{}
+
+// scriptRequestBudget counts the requests a run's scripts have sent
+type scriptRequestBudget struct {
+	max  int64
+	used atomic.Int64
+}
+
+// withScriptRequestBudget caps the requests sent by scripts run with ctx at
+// max; max <= 0 leaves them uncapped
+func withScriptRequestBudget(ctx context.Context, max int) context.Context {
+	if max <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, scriptRequestBudgetKey{}, &scriptRequestBudget{max: int64(max)})
+}
+
+// takeScriptRequest reserves one request of the run's budget
+func takeScriptRequest(ctx context.Context) error {
+	b, ok := ctx.Value(scriptRequestBudgetKey{}).(*scriptRequestBudget)
+	if !ok {
+		return nil
+	}
+	if b.used.Add(1) > b.max {
+		return fmt.Errorf("%w: at most %d pm.sendRequest calls per run", ErrScriptRequestLimit, b.max)
+	}
+	return nil
+}
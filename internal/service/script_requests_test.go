@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestFlowRunner_SendRequestUsesStepProxy(t *testing.T) {
+	// A forward proxy that answers itself instead of dialing the target
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Write([]byte(`{"token":"abc"}`))
+	}))
+	defer proxy.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+	ctx := context.Background()
+
+	p, err := q.CreateProxy(ctx, repository.CreateProxyParams{Name: "step proxy", Url: proxy.URL, WorkspaceID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{
+			Name:       "login",
+			Method:     "GET",
+			Url:        "http://api.internal/me",
+			ProxyID:    sql.NullInt64{Int64: p.ID, Valid: true},
+			PreScript:  sql.NullString{String: `pm.sendRequest("http://auth.internal/token", function(err, res) {})`, Valid: true},
+			PostScript: sql.NullString{String: `pm.sendRequest("http://auth.internal/refresh", function(err, res) {})`, Valid: true},
+		},
+	})
+
+	result, err := fr.Run(ctx, flowID, nil)
+	if err != nil || !result.Success {
+		t.Fatalf("run: %v, %+v", err, result)
+	}
+	if len(proxied) != 3 || proxied[0] != "http://auth.internal/token" || proxied[2] != "http://auth.internal/refresh" {
+		t.Fatalf("requests through the step proxy = %v", proxied)
+	}
+
+	parent := result.Steps[0].ExecuteResult
+	if parent.HistoryID == 0 {
+		t.Fatal("step has no history entry")
+	}
+	children, err := q.ListHistoryChildren(ctx, sql.NullInt64{Int64: parent.HistoryID, Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 2 || children[0].Url != "http://auth.internal/token" || children[1].Url != "http://auth.internal/refresh" {
+		t.Errorf("children = %+v", children)
+	}
+	if children[0].ExecutionGroupID.String != result.RunID {
+		t.Errorf("child run = %q, want %q", children[0].ExecutionGroupID.String, result.RunID)
+	}
+}
+
+func TestFlowRunner_ScriptRequestsListedAndCapped(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+	ctx := context.Background()
+
+	script := `
+		pm.sendRequest("` + ts.URL + `/lookup", function(err, res) {});
+		pm.sendRequest("` + ts.URL + `/lookup", function(err, res) {});
+		pm.sendRequest({url: "` + ts.URL + `/missing", method: "DELETE"}, function(err, res) {});
+	`
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "step", Method: "GET", Url: ts.URL + "/main", PreScript: sql.NullString{String: script, Valid: true}},
+	})
+
+	result, err := fr.RunWithOptions(ctx, flowID, &RunOptions{CacheSendRequests: true}, nil)
+	if err != nil || !result.Success {
+		t.Fatalf("run: %v, %+v", err, result)
+	}
+	calls := result.Steps[0].ScriptRequests
+	if len(calls) != 3 {
+		t.Fatalf("scriptRequests = %+v", calls)
+	}
+	if calls[0].URL != ts.URL+"/lookup" || calls[0].StatusCode != 200 || calls[0].Cached || !calls[1].Cached {
+		t.Errorf("lookups = %+v, %+v", calls[0], calls[1])
+	}
+	if calls[2].Method != "DELETE" || calls[2].StatusCode != 404 {
+		t.Errorf("delete = %+v", calls[2])
+	}
+
+	setSettings := func(settings string) {
+		if _, err := q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+			Settings: sql.NullString{String: settings, Valid: true},
+			ID:       1,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Cached calls don't count against the run's budget
+	setSettings(`{"scriptRequests":{"maxPerRun":2}}`)
+	result, err = fr.RunWithOptions(ctx, flowID, &RunOptions{CacheSendRequests: true}, nil)
+	if err != nil || !result.Success {
+		t.Fatalf("run within budget: %v, %+v", err, result)
+	}
+	result, err = fr.Run(ctx, flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pre := result.Steps[0].PreScriptResult
+	if pre.Success || len(pre.Errors) == 0 || !strings.Contains(pre.Errors[0], "per run") {
+		t.Errorf("uncached run over budget: %+v", pre)
+	}
+	if n := len(result.Steps[0].ScriptRequests); n != 2 {
+		t.Errorf("calls over budget = %d, want 2", n)
+	}
+
+	setSettings(`{"scriptRequests":{"maxPerScript":1}}`)
+	result, err = fr.Run(ctx, flowID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pre := result.Steps[0].PreScriptResult; pre.Success || !strings.Contains(pre.Errors[0], "limit (1)") {
+		t.Errorf("per-script cap: %+v", pre)
+	}
+}
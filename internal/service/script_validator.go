@@ -0,0 +1,372 @@
+package service
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// Script languages reported by ValidateScript
+const (
+	ScriptLanguageDSL        = "dsl"
+	ScriptLanguageJavaScript = "javascript"
+)
+
+// ValidationIssue describes a problem found while validating a script.
+// Path points into the DSL document (e.g. "setVariables[1].expression");
+// Line/Column are set for syntax errors.
+type ValidationIssue struct {
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+// ValidateScript checks a pre/post script without running it. Scripts starting
+// with "{" are validated against the DSL schema; anything else is compiled as
+// JavaScript. An empty script is valid.
+func ValidateScript(script string) (string, []ValidationIssue) {
+	trimmed := strings.TrimSpace(script)
+	if strings.HasPrefix(trimmed, "{") {
+		return ScriptLanguageDSL, validateDSL(script)
+	}
+	if trimmed == "" {
+		return ScriptLanguageJavaScript, nil
+	}
+	return ScriptLanguageJavaScript, validateJS(script)
+}
+
+// validateJS compiles the script with goja. {{var}} placeholders are replaced
+// by a same-width numeric literal so reported columns still line up.
+func validateJS(script string) []ValidationIssue {
+	src := variablePattern.ReplaceAllStringFunc(script, func(m string) string {
+		return "0" + strings.Repeat(" ", len(m)-1)
+	})
+	if _, err := goja.Compile("script", src, true); err != nil {
+		msg, line, col := parseGojaErrorLocation(err.Error())
+		return []ValidationIssue{{Message: msg, Line: line, Column: col}}
+	}
+	return nil
+}
+
+var (
+	dslAssertionTypes = []string{"status", "jsonpath", "header", "responseTime", "bodyContains", "bodySha256", "bodyMd5", "bodySize", "bodyPrefix", "wasm"}
+	dslOperators      = []string{"eq", "ne", "gt", "gte", "lt", "lte", "contains", "in", "exists", "regex"}
+	dslVarOperations  = []string{"set", "increment", "decrement", "math", "concat", "conditional", "wasm"}
+	dslFlowTypes      = []string{"always", "conditional", "switch"}
+	dslFlowActions    = []string{string(FlowActionNext), string(FlowActionGoto), string(FlowActionStop), string(FlowActionRepeat)}
+)
+
+type dslValidator struct {
+	issues []ValidationIssue
+}
+
+func (v *dslValidator) addf(path, format string, args ...interface{}) {
+	v.issues = append(v.issues, ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func validateDSL(script string) []ValidationIssue {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(script), &doc); err != nil {
+		issue := ValidationIssue{Message: fmt.Sprintf("Invalid script JSON: %v", err)}
+		var syn *json.SyntaxError
+		if errors.As(err, &syn) {
+			issue.Line, issue.Column = offsetToLineCol(script, int(syn.Offset))
+		}
+		return []ValidationIssue{issue}
+	}
+
+	v := &dslValidator{}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		v.addf("", "script must be a JSON object")
+		return v.issues
+	}
+	v.checkKeys("", root, "assertions", "setVariables", "flow")
+
+	if raw, ok := root["assertions"]; ok {
+		v.eachObject("assertions", raw, v.checkAssertion)
+	}
+	if raw, ok := root["setVariables"]; ok {
+		v.eachObject("setVariables", raw, v.checkVariableOp)
+	}
+	if raw, ok := root["flow"]; ok {
+		if obj, ok := raw.(map[string]interface{}); ok {
+			v.checkFlow("flow", obj)
+		} else {
+			v.addf("flow", "must be an object")
+		}
+	}
+	return v.issues
+}
+
+func (v *dslValidator) eachObject(path string, raw interface{}, check func(string, map[string]interface{})) {
+	list, ok := raw.([]interface{})
+	if !ok {
+		v.addf(path, "must be an array")
+		return
+	}
+	for i, item := range list {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			v.addf(itemPath, "must be an object")
+			continue
+		}
+		check(itemPath, obj)
+	}
+}
+
+// checkKeys reports fields that the executor would silently ignore
+func (v *dslValidator) checkKeys(path string, obj map[string]interface{}, allowed ...string) {
+	var unknown []string
+	for k := range obj {
+		if !containsString(allowed, k) {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		v.addf(joinPath(path, k), "unknown field")
+	}
+}
+
+func (v *dslValidator) checkAssertion(path string, a map[string]interface{}) {
+	v.checkKeys(path, a, "type", "path", "name", "operator", "value", "module")
+
+	typ, ok := v.requireString(path, a, "type")
+	if ok && !containsString(dslAssertionTypes, typ) {
+		v.addf(joinPath(path, "type"), "unknown assertion type %q (expected one of %s)", typ, strings.Join(dslAssertionTypes, ", "))
+	}
+	switch typ {
+	case "jsonpath":
+		v.requireString(path, a, "path")
+	case "header":
+		v.requireString(path, a, "name")
+	case "bodyContains":
+		if _, ok := a["value"].(string); !ok {
+			v.addf(joinPath(path, "value"), "bodyContains value must be a string")
+		}
+	case "bodySha256", "bodyMd5":
+		digestLen := 64
+		if typ == "bodyMd5" {
+			digestLen = 32
+		}
+		// A list is checked by the 'in' operator rule below
+		if _, isList := a["value"].([]interface{}); !isList {
+			s, _ := a["value"].(string)
+			s = strings.TrimSpace(s)
+			if _, err := hex.DecodeString(s); err != nil || len(s) != digestLen {
+				v.addf(joinPath(path, "value"), "%s value must be a %d-character hex digest", typ, digestLen)
+			}
+		}
+	case "bodySize":
+		_, isNumber := a["value"].(float64)
+		_, isList := a["value"].([]interface{})
+		if !isNumber && !isList {
+			v.addf(joinPath(path, "value"), "bodySize value must be a number")
+		}
+	case "bodyPrefix":
+		s, ok := a["value"].(string)
+		if _, err := decodeHexBytes(s); !ok || s == "" || err != nil {
+			v.addf(joinPath(path, "value"), "bodyPrefix value must be hex bytes (e.g. \"25504446\")")
+		}
+	case "wasm":
+		v.requireString(path, a, "module")
+	}
+
+	op, hasOp := v.optionalString(path, a, "operator")
+	if hasOp && op != "" && !containsString(dslOperators, op) {
+		v.addf(joinPath(path, "operator"), "unknown operator %q (expected one of %s)", op, strings.Join(dslOperators, ", "))
+	}
+	if op == "in" {
+		if _, ok := a["value"].([]interface{}); !ok {
+			v.addf(joinPath(path, "value"), "'in' operator requires array value")
+		}
+	}
+}
+
+func (v *dslValidator) checkVariableOp(path string, op map[string]interface{}) {
+	v.checkKeys(path, op, "name", "value", "from", "operation", "by", "expression", "values", "condition", "ifTrue", "ifFalse", "module")
+	v.requireString(path, op, "name")
+
+	operation, _ := v.optionalString(path, op, "operation")
+	if operation != "" && !containsString(dslVarOperations, operation) {
+		v.addf(joinPath(path, "operation"), "unknown operation %q (expected one of %s)", operation, strings.Join(dslVarOperations, ", "))
+		return
+	}
+	v.optionalString(path, op, "from")
+	if by, ok := op["by"]; ok {
+		if _, ok := by.(float64); !ok {
+			v.addf(joinPath(path, "by"), "must be a number")
+		}
+	}
+
+	switch operation {
+	case "math":
+		if expr, ok := v.requireString(path, op, "expression"); ok {
+			if err := ValidateMath(expr); err != nil {
+				v.addf(joinPath(path, "expression"), "%v", err)
+			}
+		}
+	case "concat":
+		values, ok := op["values"].([]interface{})
+		if !ok {
+			v.addf(joinPath(path, "values"), "must be an array of strings")
+			break
+		}
+		for i, s := range values {
+			if _, ok := s.(string); !ok {
+				v.addf(fmt.Sprintf("%s[%d]", joinPath(path, "values"), i), "must be a string")
+			}
+		}
+	case "conditional":
+		if cond, ok := v.requireString(path, op, "condition"); ok {
+			v.checkCondition(joinPath(path, "condition"), cond)
+		}
+	case "wasm":
+		v.requireString(path, op, "module")
+	}
+}
+
+func (v *dslValidator) checkFlow(path string, flow map[string]interface{}) {
+	v.checkKeys(path, flow, "type", "action", "step", "stepOrder", "condition", "onTrue", "onFalse", "cases", "default")
+
+	typ, _ := v.optionalString(path, flow, "type")
+	if typ != "" && !containsString(dslFlowTypes, typ) {
+		v.addf(joinPath(path, "type"), "unknown flow type %q (expected one of %s)", typ, strings.Join(dslFlowTypes, ", "))
+		return
+	}
+
+	switch typ {
+	case "", "always":
+		v.checkFlowAction(path, flow)
+	case "conditional":
+		if cond, ok := v.requireString(path, flow, "condition"); ok {
+			v.checkCondition(joinPath(path, "condition"), cond)
+		}
+		for _, key := range []string{"onTrue", "onFalse"} {
+			if raw, ok := flow[key]; ok {
+				v.checkFlowAct(joinPath(path, key), raw)
+			}
+		}
+	case "switch":
+		v.eachObject(joinPath(path, "cases"), flow["cases"], func(casePath string, c map[string]interface{}) {
+			v.checkKeys(casePath, c, "condition", "action", "step", "stepOrder")
+			if cond, ok := v.requireString(casePath, c, "condition"); ok {
+				v.checkCondition(joinPath(casePath, "condition"), cond)
+			}
+			v.checkFlowAction(casePath, c)
+		})
+		if raw, ok := flow["default"]; ok {
+			v.checkFlowAct(joinPath(path, "default"), raw)
+		}
+	}
+}
+
+func (v *dslValidator) checkFlowAct(path string, raw interface{}) {
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		v.addf(path, "must be an object")
+		return
+	}
+	v.checkKeys(path, obj, "action", "step", "stepOrder")
+	v.checkFlowAction(path, obj)
+}
+
+// checkFlowAction validates action/step/stepOrder on a flow object
+func (v *dslValidator) checkFlowAction(path string, obj map[string]interface{}) {
+	action, ok := v.requireString(path, obj, "action")
+	if !ok {
+		return
+	}
+	if !containsString(dslFlowActions, action) {
+		v.addf(joinPath(path, "action"), "unknown action %q (expected one of %s)", action, strings.Join(dslFlowActions, ", "))
+		return
+	}
+	step, _ := v.optionalString(path, obj, "step")
+	order, hasOrder := obj["stepOrder"]
+	if hasOrder {
+		if _, isNum := order.(float64); !isNum {
+			v.addf(joinPath(path, "stepOrder"), "must be a number")
+		}
+	}
+	if FlowAction(action) == FlowActionGoto && step == "" && !hasOrder {
+		v.addf(path, "goto requires step or stepOrder")
+	}
+}
+
+func (v *dslValidator) checkCondition(path, cond string) {
+	if err := ValidateCondition(cond); err != nil {
+		v.addf(path, "%v", err)
+	}
+}
+
+func (v *dslValidator) requireString(path string, obj map[string]interface{}, key string) (string, bool) {
+	raw, ok := obj[key]
+	if !ok {
+		v.addf(joinPath(path, key), "is required")
+		return "", false
+	}
+	s, ok := raw.(string)
+	if !ok {
+		v.addf(joinPath(path, key), "must be a string")
+		return "", false
+	}
+	if strings.TrimSpace(s) == "" {
+		v.addf(joinPath(path, key), "must not be empty")
+		return "", false
+	}
+	return s, true
+}
+
+func (v *dslValidator) optionalString(path string, obj map[string]interface{}, key string) (string, bool) {
+	raw, ok := obj[key]
+	if !ok {
+		return "", false
+	}
+	s, ok := raw.(string)
+	if !ok {
+		v.addf(joinPath(path, key), "must be a string")
+		return "", false
+	}
+	return s, true
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// offsetToLineCol converts a byte offset into 1-based line and column
+func offsetToLineCol(s string, offset int) (int, int) {
+	if offset > len(s) {
+		offset = len(s)
+	}
+	line, col := 1, 1
+	for _, c := range s[:offset] {
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateScript_JavaScript(t *testing.T) {
+	lang, issues := ValidateScript(`pm.test("ok", function () { pm.expect({{status}}).to.equal(200); });`)
+	if lang != ScriptLanguageJavaScript {
+		t.Errorf("language = %q, want javascript", lang)
+	}
+	if len(issues) != 0 {
+		t.Errorf("unexpected issues: %+v", issues)
+	}
+
+	_, issues = ValidateScript("const a = 1;\nconst b = ;")
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %+v", issues)
+	}
+	if issues[0].Line != 2 {
+		t.Errorf("line = %d, want 2 (%s)", issues[0].Line, issues[0].Message)
+	}
+}
+
+func TestValidateScript_DSLSyntaxError(t *testing.T) {
+	lang, issues := ValidateScript("{\n  \"assertions\": [\n    {\"type\": \"status\",}\n  ]\n}")
+	if lang != ScriptLanguageDSL {
+		t.Errorf("language = %q, want dsl", lang)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("expected 1 issue, got %+v", issues)
+	}
+	if issues[0].Line != 3 {
+		t.Errorf("line = %d, want 3", issues[0].Line)
+	}
+}
+
+func TestValidateScript_DSLSchema(t *testing.T) {
+	valid := `{
+		"assertions": [
+			{"type": "status", "operator": "eq", "value": 200},
+			{"type": "jsonpath", "path": "$.id", "operator": "exists"},
+			{"type": "wasm", "module": "schema-check", "value": {"strict": true}},
+			{"type": "bodyMd5", "value": "7d381ceb22d0f867f80acff78e4f511d"},
+			{"type": "bodySize", "operator": "lte", "value": 1048576},
+			{"type": "bodyPrefix", "value": "89 50 4E 47"}
+		],
+		"setVariables": [
+			{"name": "token", "from": "$.token"},
+			{"name": "plain", "operation": "wasm", "module": "decrypt", "from": "$.payload"},
+			{"name": "total", "operation": "math", "expression": "round({{price}} * qty, 2)"},
+			{"name": "label", "operation": "conditional", "condition": "{{total}} > 100", "ifTrue": "big", "ifFalse": "small"}
+		],
+		"flow": {
+			"type": "switch",
+			"cases": [{"condition": "{{status}} in [200, 201]", "action": "next"}],
+			"default": {"action": "goto", "step": "Retry"}
+		}
+	}`
+	if _, issues := ValidateScript(valid); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+
+	invalid := `{
+		"assertions": [
+			{"type": "statuz"},
+			{"type": "header", "operator": "approx"},
+			{"type": "wasm"},
+			{"type": "bodySha256", "value": "abc123"},
+			{"type": "bodyPrefix", "value": "%PDF"}
+		],
+		"setVariables": [
+			{"operation": "math", "expression": "1 +"},
+			{"name": "x", "operation": "concat", "values": ["a", 1]}
+		],
+		"flow": {"type": "conditional", "condition": "a ==", "onTrue": {"action": "goto"}},
+		"extra": true
+	}`
+	_, issues := ValidateScript(invalid)
+
+	want := map[string]string{
+		"extra":                      "unknown field",
+		"assertions[0].type":         "unknown assertion type",
+		"assertions[1].name":         "is required",
+		"assertions[1].operator":     "unknown operator",
+		"assertions[2].module":       "is required",
+		"assertions[3].value":        "64-character hex digest",
+		"assertions[4].value":        "hex bytes",
+		"setVariables[0].name":       "is required",
+		"setVariables[0].expression": "math:",
+		"setVariables[1].values[1]":  "must be a string",
+		"flow.condition":             "condition:",
+		"flow.onTrue":                "goto requires step or stepOrder",
+	}
+	got := make(map[string]string)
+	for _, is := range issues {
+		got[is.Path] = is.Message
+	}
+	for path, substr := range want {
+		msg, ok := got[path]
+		if !ok {
+			t.Errorf("missing issue at %s (got %+v)", path, issues)
+			continue
+		}
+		if !strings.Contains(msg, substr) {
+			t.Errorf("issue at %s = %q, want it to contain %q", path, msg, substr)
+		}
+	}
+	if len(issues) != len(want) {
+		t.Errorf("got %d issues, want %d: %+v", len(issues), len(want), issues)
+	}
+}
+
+func TestValidateMath(t *testing.T) {
+	for _, expr := range []string{"{{a}} / {{b}}", "x / (y - 1)", "max(a, b, 3) ^ 2"} {
+		if err := ValidateMath(expr); err != nil {
+			t.Errorf("ValidateMath(%q) = %v, want nil", expr, err)
+		}
+	}
+	for _, expr := range []string{"", "1 +", "(a", "abs(1, 2)", "nope(1)"} {
+		if err := ValidateMath(expr); err == nil {
+			t.Errorf("ValidateMath(%q) expected error", expr)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+type sendRequestCacheKey struct{}
+
+// sendRequestCache memoizes pm.sendRequest responses for one run, keyed by
+// method, URL and body, so lookups repeated in loops or across steps reach
+// the target once. Failed requests are not cached.
+type sendRequestCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedSendResponse
+}
+
+type cachedSendResponse struct {
+	status  int
+	body    string
+	headers map[string]string
+}
+
+// withSendRequestCache makes pm.sendRequest calls run with ctx share a cache
+func withSendRequestCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sendRequestCacheKey{}, &sendRequestCache{entries: make(map[string]cachedSendResponse)})
+}
+
+// sendRequestCacheFrom returns the run's cache, or nil when caching is off
+func sendRequestCacheFrom(ctx context.Context) *sendRequestCache {
+	c, _ := ctx.Value(sendRequestCacheKey{}).(*sendRequestCache)
+	return c
+}
+
+func sendRequestCacheEntryKey(method, url, body string) string {
+	return method + "\x00" + url + "\x00" + body
+}
+
+func (c *sendRequestCache) get(method, url, body string) (cachedSendResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.entries[sendRequestCacheEntryKey(method, url, body)]
+	return r, ok
+}
+
+func (c *sendRequestCache) put(method, url, body string, r cachedSendResponse) {
+	c.mu.Lock()
+	c.entries[sendRequestCacheEntryKey(method, url, body)] = r
+	c.mu.Unlock()
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestFlowRunner_CacheSendRequests(t *testing.T) {
+	var lookups atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			lookups.Add(1)
+			w.Write([]byte(`{"token":"abc"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	script := `
+		pm.sendRequest("` + ts.URL + `/token", function(err, res) { pm.variables.set("token", res.json().token); });
+		pm.sendRequest("` + ts.URL + `/token", function(err, res) {});
+		pm.sendRequest({url: "` + ts.URL + `/token", method: "POST", body: "x"}, function(err, res) {});
+	`
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{Name: "first", Method: "GET", Url: ts.URL + "/a?t={{token}}", PreScript: sql.NullString{String: script, Valid: true}},
+		{Name: "second", Method: "GET", Url: ts.URL + "/b?t={{token}}", PreScript: sql.NullString{String: script, Valid: true}},
+	})
+
+	result, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{CacheSendRequests: true}, nil)
+	if err != nil || !result.Success {
+		t.Fatalf("run: %v, %+v", err, result)
+	}
+	// One GET and one POST reach the target; every other call is a hit
+	if n := lookups.Load(); n != 2 {
+		t.Errorf("token lookups = %d, want 2", n)
+	}
+	if hits := result.Steps[0].PreScriptResult.SendRequestCacheHits; hits != 1 {
+		t.Errorf("first step hits = %d, want 1", hits)
+	}
+	if hits := result.Steps[1].PreScriptResult.SendRequestCacheHits; hits != 3 {
+		t.Errorf("second step hits = %d, want 3", hits)
+	}
+
+	lookups.Store(0)
+	if _, err := fr.RunWithOptions(context.Background(), flowID, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := lookups.Load(); n != 6 {
+		t.Errorf("uncached token lookups = %d, want 6", n)
+	}
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrSigningHooksDisabled = errors.New("signing hooks are disabled (set SIGNING_HOOK_DIR)")
+	ErrSigningHookNotFound  = errors.New("signing hook not found")
+)
+
+const (
+	signingHookTimeout   = 10 * time.Second
+	maxSigningHookOutput = 10 * 1024 * 1024
+)
+
+var signingHookNameRe = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// SigningHookConfig is stored per collection (collections.signing_hook) and
+// applies to its requests and those of its sub-collections
+type SigningHookConfig struct {
+	Name   string            `json:"name"`   // executable in SIGNING_HOOK_DIR
+	Config map[string]string `json:"config"` // passed through to the hook, after variable resolution
+}
+
+// ParseSigningHookConfig decodes the signing_hook column; nil means no hook
+func ParseSigningHookConfig(raw sql.NullString) *SigningHookConfig {
+	if !raw.Valid || raw.String == "" {
+		return nil
+	}
+	var c SigningHookConfig
+	if err := json.Unmarshal([]byte(raw.String), &c); err != nil || c.Name == "" {
+		return nil
+	}
+	if c.Config == nil {
+		c.Config = map[string]string{}
+	}
+	return &c
+}
+
+// SigningHookInput is written to the hook's stdin as JSON
+type SigningHookInput struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`                 // UTF-8 body; empty when binary
+	Body64  string            `json:"bodyBase64,omitempty"` // set when the body is not valid UTF-8
+	Config  map[string]string `json:"config"`
+	Now     string            `json:"now,omitempty"` // RFC3339 frozen time of the run; sign with it when set
+}
+
+// SigningHookOutput is read from the hook's stdout; omitted fields are left unchanged
+type SigningHookOutput struct {
+	Method        *string           `json:"method"`
+	URL           *string           `json:"url"`
+	Headers       map[string]string `json:"headers"`       // set or replace
+	RemoveHeaders []string          `json:"removeHeaders"` // delete
+	Body          *string           `json:"body"`
+}
+
+// SigningHooks runs external executables that may rewrite an outgoing request
+// (e.g. proprietary signing schemes). Only executables placed in the
+// configured directory can be used, so the API cannot run arbitrary commands.
+type SigningHooks struct {
+	dir string
+}
+
+// NewSigningHooks serves hooks from dir; an empty dir disables them
+func NewSigningHooks(dir string) *SigningHooks {
+	return &SigningHooks{dir: dir}
+}
+
+func (h *SigningHooks) Enabled() bool {
+	return h != nil && h.dir != ""
+}
+
+// List returns the names of the available hook executables
+func (h *SigningHooks) List() ([]string, error) {
+	if !h.Enabled() {
+		return []string{}, nil
+	}
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		if _, err := h.path(e.Name()); err == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// path resolves name to an executable inside the hook directory
+func (h *SigningHooks) path(name string) (string, error) {
+	if !h.Enabled() {
+		return "", ErrSigningHooksDisabled
+	}
+	if !signingHookNameRe.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", ErrSigningHookNotFound, name)
+	}
+	p := filepath.Join(h.dir, name)
+	info, err := os.Stat(p)
+	if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0o111 == 0 {
+		return "", fmt.Errorf("%w: %q", ErrSigningHookNotFound, name)
+	}
+	return p, nil
+}
+
+// Validate checks that cfg names an available hook
+func (h *SigningHooks) Validate(cfg SigningHookConfig) error {
+	_, err := h.path(cfg.Name)
+	return err
+}
+
+// Apply runs the hook with req as JSON and applies the changes it returns.
+// req.Body is consumed and replaced.
+func (h *SigningHooks) Apply(ctx context.Context, cfg SigningHookConfig, req *http.Request) error {
+	p, err := h.path(cfg.Name)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if req.Body != nil {
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return err
+		}
+		req.Body.Close()
+	}
+	in := SigningHookInput{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Headers: make(map[string]string, len(req.Header)),
+		Config:  cfg.Config,
+	}
+	if frozen, ok := frozenClockFrom(ctx); ok {
+		in.Now = frozen.UTC().Format(time.RFC3339Nano)
+	}
+	for k := range req.Header {
+		in.Headers[k] = req.Header.Get(k)
+	}
+	if utf8.Valid(body) {
+		in.Body = string(body)
+	} else {
+		in.Body64 = base64.StdEncoding.EncodeToString(body)
+	}
+	input, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	hookCtx, cancel := context.WithTimeout(ctx, signingHookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(hookCtx, p)
+	cmd.Dir = h.dir
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &limitedBuffer{buf: &stdout, max: maxSigningHookOutput}
+	cmd.Stderr = &limitedBuffer{buf: &stderr, max: 4096}
+	if err := cmd.Run(); err != nil {
+		if hookCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%s timed out after %s", cfg.Name, signingHookTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %v: %s", cfg.Name, err, msg)
+		}
+		return fmt.Errorf("%s: %v", cfg.Name, err)
+	}
+
+	var out SigningHookOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return fmt.Errorf("%s: invalid output: %v", cfg.Name, err)
+	}
+	if out.Method != nil && *out.Method != "" {
+		req.Method = strings.ToUpper(*out.Method)
+	}
+	if out.URL != nil && *out.URL != "" {
+		u, err := url.Parse(*out.URL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("%s: invalid url %q", cfg.Name, *out.URL)
+		}
+		req.URL = u
+		req.Host = u.Host
+	}
+	for _, k := range out.RemoveHeaders {
+		req.Header.Del(k)
+	}
+	for k, v := range out.Headers {
+		req.Header.Set(k, v)
+	}
+	if out.Body != nil {
+		body = []byte(*out.Body)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return nil
+}
+
+// limitedBuffer keeps the first max bytes written and discards the rest
+type limitedBuffer struct {
+	buf *bytes.Buffer
+	max int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if room := l.max - l.buf.Len(); room > 0 {
+		if len(p) > room {
+			l.buf.Write(p[:room])
+		} else {
+			l.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+// writeHook installs a shell script hook in dir
+func writeHook(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSigningHooks_List(t *testing.T) {
+	dir := t.TempDir()
+	writeHook(t, dir, "hmac", "echo '{}'\n")
+	writeHook(t, dir, "aws-sig", "echo '{}'\n")
+	os.WriteFile(filepath.Join(dir, "README"), []byte("not executable"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".hidden"), []byte("#!/bin/sh\n"), 0o755)
+
+	names, err := NewSigningHooks(dir).List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "aws-sig,hmac" {
+		t.Errorf("hooks = %v", names)
+	}
+
+	hooks := NewSigningHooks(dir)
+	for _, name := range []string{"README", "../hmac", "missing", ".hidden"} {
+		if err := hooks.Validate(SigningHookConfig{Name: name}); !errors.Is(err, ErrSigningHookNotFound) {
+			t.Errorf("Validate(%q) = %v, want not found", name, err)
+		}
+	}
+	if err := NewSigningHooks("").Validate(SigningHookConfig{Name: "hmac"}); !errors.Is(err, ErrSigningHooksDisabled) {
+		t.Errorf("disabled hooks: %v", err)
+	}
+}
+
+func TestExecuteRequest_SigningHook(t *testing.T) {
+	var got http.Header
+	var gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		b := make([]byte, r.ContentLength)
+		r.Body.Read(b)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	// Records its input, then signs the request and rewrites the body
+	writeHook(t, dir, "sign", `cat > input.json
+echo '{"headers":{"X-Signature":"sig-123"},"removeHeaders":["X-Drop"],"body":"{\"signed\":true}"}'
+`)
+	writeHook(t, dir, "broken", "echo 'bad key' >&2\nexit 3\n")
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	re.SetSigningHooks(NewSigningHooks(dir))
+	ctx := context.Background()
+
+	parent, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Partner API", WorkspaceID: 1})
+	child, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Orders", ParentID: sql.NullInt64{Int64: parent.ID, Valid: true}, WorkspaceID: 1})
+	q.UpdateCollectionVariables(ctx, repository.UpdateCollectionVariablesParams{
+		Variables: sql.NullString{String: `{"apiSecret":"s3cr3t"}`, Valid: true},
+		ID:        child.ID,
+	})
+	q.UpdateCollectionSigningHook(ctx, repository.UpdateCollectionSigningHookParams{
+		SigningHook: sql.NullString{String: `{"name":"sign","config":{"secret":"{{apiSecret}}"}}`, Valid: true},
+		ID:          parent.ID,
+	})
+
+	// Requests in sub-collections use the nearest ancestor's hook
+	req, _ := q.CreateRequest(ctx, repository.CreateRequestParams{
+		CollectionID: sql.NullInt64{Int64: child.ID, Valid: true},
+		Name:         "Create order",
+		Method:       "POST",
+		Url:          ts.URL + "/orders",
+		Headers:      sql.NullString{String: `{"X-Drop":"1","X-Keep":"2"}`, Valid: true},
+		Body:         sql.NullString{String: `{"qty":1}`, Valid: true},
+		BodyType:     sql.NullString{String: "json", Valid: true},
+		WorkspaceID:  1,
+	})
+	result, err := re.Execute(ctx, req.ID, nil, nil)
+	if err != nil || result.Error != "" {
+		t.Fatalf("execute: %v %q", err, result.Error)
+	}
+	if got.Get("X-Signature") != "sig-123" || got.Get("X-Drop") != "" || got.Get("X-Keep") != "2" {
+		t.Errorf("server headers = %v", got)
+	}
+	if gotBody != `{"signed":true}` {
+		t.Errorf("server body = %q", gotBody)
+	}
+	if result.ResolvedHeaders["X-Signature"] != "sig-123" {
+		t.Errorf("resolved headers should show the signed request: %v", result.ResolvedHeaders)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var in SigningHookInput
+	json.Unmarshal(raw, &in)
+	if in.Method != "POST" || in.URL != ts.URL+"/orders" || in.Body != `{"qty":1}` || in.Headers["X-Drop"] != "1" {
+		t.Errorf("hook input = %+v", in)
+	}
+	if in.Config["secret"] != "s3cr3t" {
+		t.Errorf("config variables should be resolved: %v", in.Config)
+	}
+
+	// A failing hook stops the request instead of sending it unsigned
+	q.UpdateCollectionSigningHook(ctx, repository.UpdateCollectionSigningHookParams{
+		SigningHook: sql.NullString{String: `{"name":"broken"}`, Valid: true},
+		ID:          child.ID,
+	})
+	got = nil
+	result, _ = re.Execute(ctx, req.ID, nil, nil)
+	if !strings.Contains(result.Error, "bad key") || got != nil {
+		t.Errorf("broken hook: error %q, request sent: %v", result.Error, got != nil)
+	}
+
+	re.SetSigningHooks(nil)
+	result, _ = re.Execute(ctx, req.ID, nil, nil)
+	if !strings.Contains(result.Error, "disabled") || got != nil {
+		t.Errorf("hooks disabled: error %q, request sent: %v", result.Error, got != nil)
+	}
+}
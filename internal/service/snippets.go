@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// Snippets are named text fragments, usually pieces of JSON shared by many
+// request bodies, stored per workspace. {{snippet:NAME}} is replaced by the
+// snippet's content before variables are resolved, so {{variables}} inside a
+// snippet resolve like ones written in place. Snippets may include other
+// snippets; a reference that is unknown, circular or nested deeper than
+// maxSnippetDepth is left as written.
+
+const (
+	MaxSnippetSize = 256 * 1024
+
+	snippetVarPrefix = "snippet:"
+	maxSnippetDepth  = 8
+)
+
+var (
+	snippetNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+	// Unlike variablePattern, a reference may follow a "{" ({{{snippet:x}}, ...})
+	snippetPattern = regexp.MustCompile(`\{\{\s*snippet:([^{}]+)\}\}`)
+)
+
+// ValidSnippetName reports whether name can be used for a snippet
+func ValidSnippetName(name string) bool {
+	return snippetNameRe.MatchString(name)
+}
+
+// ValidateSnippet checks a snippet's content before it is stored
+func ValidateSnippet(content string) error {
+	if len(content) > MaxSnippetSize {
+		return fmt.Errorf("snippet exceeds %d bytes", MaxSnippetSize)
+	}
+	return nil
+}
+
+// snippetExpander expands snippet references for one workspace, loading
+// each snippet at most once
+type snippetExpander struct {
+	ctx     context.Context
+	queries *repository.Queries
+	wsID    int64
+	loaded  map[string]*string // nil = no such snippet
+}
+
+func (vr *VariableResolver) snippetExpander(ctx context.Context) *snippetExpander {
+	if vr.queries == nil {
+		return nil
+	}
+	return &snippetExpander{
+		ctx:     ctx,
+		queries: vr.queries,
+		wsID:    middleware.GetWorkspaceID(ctx),
+		loaded:  make(map[string]*string),
+	}
+}
+
+// expand replaces the snippet references in input with their content
+func (s *snippetExpander) expand(input string) string {
+	if s == nil || !strings.Contains(input, snippetVarPrefix) {
+		return input
+	}
+	return s.expandIn(input, nil)
+}
+
+// expandIn expands input, which is the content of the snippets in stack
+func (s *snippetExpander) expandIn(input string, stack []string) string {
+	return snippetPattern.ReplaceAllStringFunc(input, func(match string) string {
+		ref := strings.TrimSpace(snippetPattern.FindStringSubmatch(match)[1])
+		if len(stack) >= maxSnippetDepth || slices.Contains(stack, ref) {
+			return match
+		}
+		content, ok := s.load(ref)
+		if !ok {
+			return match
+		}
+		return s.expandIn(content, append(stack, ref))
+	})
+}
+
+func (s *snippetExpander) load(name string) (string, bool) {
+	if c, seen := s.loaded[name]; seen {
+		if c == nil {
+			return "", false
+		}
+		return *c, true
+	}
+	s.loaded[name] = nil
+	if !ValidSnippetName(name) {
+		return "", false
+	}
+	sn, err := s.queries.GetSnippet(s.ctx, repository.GetSnippetParams{WorkspaceID: s.wsID, Name: name})
+	if err != nil {
+		return "", false
+	}
+	s.loaded[name] = &sn.Content
+	return sn.Content, true
+}
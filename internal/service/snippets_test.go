@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestSnippets_Expand(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	vr := NewVariableResolver(q)
+	put := func(wsID int64, name, content string) {
+		t.Helper()
+		if _, err := q.UpsertSnippet(ctx, repository.UpsertSnippetParams{WorkspaceID: wsID, Name: name, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put(1, "address", `{"city": "{{city}}", "zip": "04524"}`)
+	put(1, "customer", `"name": "{{name}}", "address": {{snippet:address}}`)
+	put(1, "loop", `[{{ snippet:loop }}]`)
+
+	got := mustResolve(t, vr, ctx, `{{{snippet:customer}}, "id": {{seq:id}}}`, map[string]string{"name": "Kim", "city": "Seoul"}, 0)
+	if want := `{"name": "Kim", "address": {"city": "Seoul", "zip": "04524"}, "id": 1}`; got != want {
+		t.Errorf("expanded = %s\nwant %s", got, want)
+	}
+
+	// Unknown and circular references are left as written
+	if got := mustResolve(t, vr, ctx, "{{snippet:missing}} {{snippet:loop}}", nil, 0); got != "{{snippet:missing}} [{{ snippet:loop }}]" {
+		t.Errorf("unresolved = %q", got)
+	}
+
+	// Previews report the variables used inside snippets
+	text, sources := vr.Explain(ctx, "{{snippet:address}}", map[string]string{"city": "Busan"}, 0)
+	if !strings.Contains(text, `"Busan"`) || len(sources) != 1 || sources[0].Name != "city" {
+		t.Errorf("explain = %q, %+v", text, sources)
+	}
+
+	// Snippets are per workspace
+	ws, err := q.CreateWorkspace(ctx, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mustResolve(t, vr, middleware.WithWorkspaceID(ctx, ws.ID), "{{snippet:address}}", nil, 0); got != "{{snippet:address}}" {
+		t.Errorf("other workspace = %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"relay/internal/repository"
+)
+
+// Test summary item statuses
+const (
+	TestStatusPassing = "passing" // the latest run passed
+	TestStatusFailing = "failing" // the latest run failed
+	TestStatusNoRuns  = "noRuns"
+)
+
+const (
+	DefaultTestSummaryRuns = 10
+	MaxTestSummaryRuns     = 100
+)
+
+// TestSummary rolls up the latest runs of a workspace's flows, monitors and
+// environment rotations (scheduled flow runs) for a health dashboard
+type TestSummary struct {
+	WorkspaceID int64             `json:"workspaceId"`
+	Runs        int               `json:"runs"` // runs per item counted in Passed/Failed/Trend
+	Totals      TestSummaryTotals `json:"totals"`
+	Flows       []TestSummaryItem `json:"flows"`
+	Monitors    []TestSummaryItem `json:"monitors"`
+	Rotations   []TestSummaryItem `json:"rotations"`
+}
+
+// TestSummaryTotals counts items by status, and runs over all items
+type TestSummaryTotals struct {
+	Items      int `json:"items"`
+	Passing    int `json:"passing"`
+	Failing    int `json:"failing"`
+	NoRuns     int `json:"noRuns"`
+	PassedRuns int `json:"passedRuns"`
+	FailedRuns int `json:"failedRuns"`
+}
+
+// TestSummaryItem is one flow, monitor or rotation with its recent runs
+type TestSummaryItem struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Disabled  bool   `json:"disabled,omitempty"` // paused monitors and rotations
+	Status    string `json:"status"`
+	LastRunAt string `json:"lastRunAt,omitempty"`
+	Passed    int    `json:"passed"`
+	Failed    int    `json:"failed"`
+	Trend     []bool `json:"trend"` // pass/fail of the last runs, oldest first
+}
+
+// summaryRun is one recent run of an item; rows without a run (LEFT JOIN)
+// have an invalid success
+type summaryRun struct {
+	id       int64
+	name     string
+	disabled bool
+	success  sql.NullInt64
+	at       sql.NullTime
+}
+
+// BuildTestSummary collects the last runs (at most runs per item) of every
+// unarchived flow, monitor and environment rotation in the workspace. Flow
+// runs come from run timelines, which are kept for 7 days.
+func BuildTestSummary(ctx context.Context, queries *repository.Queries, wsID int64, runs int) (*TestSummary, error) {
+	n := int64(runs)
+	flowRows, err := queries.ListRecentFlowRuns(ctx, repository.ListRecentFlowRunsParams{Runs: n, WorkspaceID: wsID})
+	if err != nil {
+		return nil, err
+	}
+	monitorRows, err := queries.ListRecentMonitorChecks(ctx, repository.ListRecentMonitorChecksParams{Runs: n, WorkspaceID: wsID})
+	if err != nil {
+		return nil, err
+	}
+	rotationRows, err := queries.ListRecentEnvironmentRotationRuns(ctx, repository.ListRecentEnvironmentRotationRunsParams{Runs: n, WorkspaceID: wsID})
+	if err != nil {
+		return nil, err
+	}
+
+	flows := make([]summaryRun, len(flowRows))
+	for i, r := range flowRows {
+		flows[i] = summaryRun{id: r.FlowID, name: r.FlowName, success: r.Success, at: r.CreatedAt}
+	}
+	monitors := make([]summaryRun, len(monitorRows))
+	for i, r := range monitorRows {
+		monitors[i] = summaryRun{id: r.MonitorID, name: r.RequestName, disabled: r.Enabled == 0, success: r.Success, at: r.CheckedAt}
+	}
+	rotations := make([]summaryRun, len(rotationRows))
+	for i, r := range rotationRows {
+		name := fmt.Sprintf("%s → %s", r.FlowName, r.EnvironmentName)
+		rotations[i] = summaryRun{id: r.RotationID, name: name, disabled: r.Enabled == 0, success: r.Success, at: r.RanAt}
+	}
+
+	s := &TestSummary{WorkspaceID: wsID, Runs: runs}
+	s.Flows = s.rollup(flows)
+	s.Monitors = s.rollup(monitors)
+	s.Rotations = s.rollup(rotations)
+	return s, nil
+}
+
+// rollup groups rows (ordered by item, oldest run first) into items and adds
+// them to the totals
+func (s *TestSummary) rollup(rows []summaryRun) []TestSummaryItem {
+	items := []TestSummaryItem{}
+	for _, r := range rows {
+		if len(items) == 0 || items[len(items)-1].ID != r.id {
+			items = append(items, TestSummaryItem{ID: r.id, Name: r.name, Disabled: r.disabled, Status: TestStatusNoRuns, Trend: []bool{}})
+		}
+		if !r.success.Valid {
+			continue
+		}
+		item := &items[len(items)-1]
+		passed := r.success.Int64 == 1
+		item.Trend = append(item.Trend, passed)
+		if passed {
+			item.Passed++
+			item.Status = TestStatusPassing
+		} else {
+			item.Failed++
+			item.Status = TestStatusFailing
+		}
+		if r.at.Valid {
+			item.LastRunAt = r.at.Time.UTC().Format(time.RFC3339)
+		}
+	}
+
+	for _, item := range items {
+		s.Totals.Items++
+		switch item.Status {
+		case TestStatusPassing:
+			s.Totals.Passing++
+		case TestStatusFailing:
+			s.Totals.Failing++
+		default:
+			s.Totals.NoRuns++
+		}
+		s.Totals.PassedRuns += item.Passed
+		s.Totals.FailedRuns += item.Failed
+	}
+	return items
+}
@@ -0,0 +1,42 @@
+;; Test extension for wasm_extensions_test.go (pass_marker.wasm is this module
+;; assembled). Both exports look for the bytes "PASS" in their JSON input.
+(module
+  (memory (export "memory") 1)
+  (global $next (mut i32) (i32.const 1024))
+
+  (data (i32.const 0) "{\"pass\":true}")
+  (data (i32.const 32) "{\"pass\":false,\"message\":\"no PASS marker\"}")
+  (data (i32.const 96) "{\"body\":\"PASS seen\"}")
+  (data (i32.const 128) "{\"error\":\"no PASS marker\"}")
+
+  ;; Bump allocator; instances are discarded after one call
+  (func (export "alloc") (param $n i32) (result i32)
+    (local $p i32)
+    (local.set $p (global.get $next))
+    (global.set $next (i32.add (global.get $next) (local.get $n)))
+    (local.get $p))
+
+  (func $has_pass (param $ptr i32) (param $len i32) (result i32)
+    (local $i i32)
+    (if (i32.lt_u (local.get $len) (i32.const 4)) (then (return (i32.const 0))))
+    (local.set $len (i32.sub (i32.add (local.get $ptr) (local.get $len)) (i32.const 4)))
+    (local.set $i (local.get $ptr))
+    (block
+      (loop
+        (br_if 1 (i32.gt_u (local.get $i) (local.get $len)))
+        (if (i32.eq (i32.load (local.get $i)) (i32.const 0x53534150)) ;; "PASS"
+          (then (return (i32.const 1))))
+        (local.set $i (i32.add (local.get $i) (i32.const 1)))
+        (br 0)))
+    (i32.const 0))
+
+  ;; Results are packed as (ptr << 32) | len
+  (func (export "assert") (param i32 i32) (result i64)
+    (if (result i64) (call $has_pass (local.get 0) (local.get 1))
+      (then (i64.const 13))                          ;; 0 << 32 | 13
+      (else (i64.const 137438953513))))              ;; 32 << 32 | 41
+
+  (func (export "transform") (param i32 i32) (result i64)
+    (if (result i64) (call $has_pass (local.get 0) (local.get 1))
+      (then (i64.const 412316860436))                ;; 96 << 32 | 20
+      (else (i64.const 549755813914)))))             ;; 128 << 32 | 26
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// TLSSettings configures how a workspace's requests check server
+// certificates and which client certificates they present (mTLS).
+// Certificates, keys and CA bundles are PEM files uploaded through /files.
+type TLSSettings struct {
+	// Verify checks server certificates against the system roots and the CA
+	// bundle; off (the default) accepts any certificate
+	Verify bool `json:"verify"`
+	// CAFileID is a CA bundle trusted in addition to the system roots
+	CAFileID int64 `json:"caFileId,omitempty"`
+	// ClientCerts are presented to matching hosts; the first match wins
+	ClientCerts []ClientCertificate `json:"clientCerts"`
+}
+
+// ClientCertificate is a certificate and key presented to Host: a hostname,
+// host:port or *.domain
+type ClientCertificate struct {
+	Host       string `json:"host"`
+	CertFileID int64  `json:"certFileId"` // certificate, followed by its chain
+	KeyFileID  int64  `json:"keyFileId"`
+}
+
+func (s TLSSettings) Validate() error {
+	if s.CAFileID < 0 {
+		return errors.New("tls.caFileId must not be negative")
+	}
+	for _, c := range s.ClientCerts {
+		if c.Host == "" || strings.ContainsAny(c.Host, "/?#@ ") {
+			return fmt.Errorf("tls.clientCerts: invalid host %q (use hostname, host:port or *.domain)", c.Host)
+		}
+		if c.CertFileID <= 0 || c.KeyFileID <= 0 {
+			return fmt.Errorf("tls.clientCerts %s: certFileId and keyFileId are required", c.Host)
+		}
+	}
+	return nil
+}
+
+// FileIDs lists the uploaded files the settings refer to
+func (s TLSSettings) FileIDs() []int64 {
+	var ids []int64
+	if s.CAFileID > 0 {
+		ids = append(ids, s.CAFileID)
+	}
+	for _, c := range s.ClientCerts {
+		ids = append(ids, c.CertFileID, c.KeyFileID)
+	}
+	return ids
+}
+
+// clientCertFor returns the index of the client certificate for host (as in
+// URL.Host), or -1
+func (s TLSSettings) clientCertFor(host string) int {
+	for i, c := range s.ClientCerts {
+		if matchHost(c.Host, host) {
+			return i
+		}
+	}
+	return -1
+}
+
+// workspaceTLS looks up the workspace's TLS settings
+func workspaceTLS(ctx context.Context, queries *repository.Queries) TLSSettings {
+	raw, err := queries.GetWorkspaceSettings(ctx, middleware.GetWorkspaceID(ctx))
+	if err != nil {
+		return TLSSettings{}
+	}
+	return ParseWorkspaceSettings(raw).TLS
+}
+
+// loadWorkspaceFile reads an uploaded file of the ctx workspace, opening it
+// if it is sealed with the workspace key
+func loadWorkspaceFile(ctx context.Context, queries *repository.Queries, fs *FileStorage, id int64) ([]byte, error) {
+	if fs == nil {
+		return nil, errors.New("file storage is not available")
+	}
+	f, err := queries.GetUploadedFile(ctx, id)
+	if err != nil || f.WorkspaceID != middleware.GetWorkspaceID(ctx) {
+		return nil, fmt.Errorf("file %d not found", id)
+	}
+	data, err := fs.Load(f.StoredName)
+	if err != nil || !isSealedFile(data) {
+		return data, err
+	}
+	return fs.keys.openFile(ctx, f.WorkspaceID, data)
+}
+
+// applyTLSSettings configures transport with the workspace's TLS settings.
+// With client certificates the returned RoundTripper sends each request
+// through a copy of transport holding the certificate for its host.
+func applyTLSSettings(ctx context.Context, queries *repository.Queries, fs *FileStorage, transport *http.Transport) (http.RoundTripper, error) {
+	s := workspaceTLS(ctx, queries)
+	transport.TLSClientConfig.InsecureSkipVerify = !s.Verify
+	if s.CAFileID > 0 {
+		data, err := loadWorkspaceFile(ctx, queries, fs, s.CAFileID)
+		if err != nil {
+			return nil, fmt.Errorf("tls: CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, errors.New("tls: CA bundle has no PEM certificates")
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	if len(s.ClientCerts) == 0 {
+		return transport, nil
+	}
+	return &clientCertTransport{
+		base:     transport,
+		settings: s,
+		load: func(id int64) ([]byte, error) {
+			return loadWorkspaceFile(ctx, queries, fs, id)
+		},
+		hosts: map[int]*http.Transport{},
+	}, nil
+}
+
+// clientCertTransport picks the transport for each request by its host, so
+// redirects to other hosts never see another host's certificate. Certificates
+// are loaded on first use.
+type clientCertTransport struct {
+	base     *http.Transport
+	settings TLSSettings
+	load     func(id int64) ([]byte, error)
+	mu       sync.Mutex
+	hosts    map[int]*http.Transport // by client certificate index
+}
+
+func (t *clientCertTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	i := t.settings.clientCertFor(req.URL.Host)
+	if i < 0 {
+		return t.base.RoundTrip(req)
+	}
+	transport, err := t.transportFor(i)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return transport.RoundTrip(req)
+}
+
+func (t *clientCertTransport) transportFor(i int) (*http.Transport, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if transport, ok := t.hosts[i]; ok {
+		return transport, nil
+	}
+	c := t.settings.ClientCerts[i]
+	certPEM, err := t.load(c.CertFileID)
+	if err != nil {
+		return nil, fmt.Errorf("tls: client certificate for %s: %w", c.Host, err)
+	}
+	keyPEM, err := t.load(c.KeyFileID)
+	if err != nil {
+		return nil, fmt.Errorf("tls: client key for %s: %w", c.Host, err)
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("tls: client certificate for %s: %w", c.Host, err)
+	}
+	transport := t.base.Clone()
+	transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	t.hosts[i] = transport
+	return transport, nil
+}
+
+// httpTransport returns the *http.Transport under a client's RoundTripper
+func httpTransport(rt http.RoundTripper) *http.Transport {
+	switch t := rt.(type) {
+	case *http.Transport:
+		return t
+	case *clientCertTransport:
+		return t.base
+	}
+	return nil
+}
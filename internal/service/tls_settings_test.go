@@ -0,0 +1,206 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+// selfSignedPEM returns a PEM certificate and key for CN
+func selfSignedPEM(t *testing.T, cn string) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func uploadTestFile(t *testing.T, q *repository.Queries, fs *FileStorage, data []byte) int64 {
+	t.Helper()
+	name, size, err := fs.Store(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := q.CreateUploadedFile(context.Background(), repository.CreateUploadedFileParams{
+		WorkspaceID: 1, OriginalName: "file.pem", StoredName: name, ContentType: "application/x-pem-file", Size: size,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.ID
+}
+
+func TestRequestExecutor_TLSSettings(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+		}
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	srv.StartTLS()
+	defer srv.Close()
+
+	q := testutil.SetupTestDB(t)
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := NewRequestExecutor(q, NewVariableResolver(q), fs)
+	req := repository.Request{Method: "GET", Url: srv.URL}
+	setTLS := func(s TLSSettings) {
+		t.Helper()
+		data, _ := json.Marshal(WorkspaceSettings{TLS: s})
+		setNotificationSettings(t, q, string(data))
+	}
+
+	// Default: the self-signed server certificate is accepted
+	result, _ := re.ExecuteRequest(context.Background(), req, nil)
+	if result.StatusCode != 200 || result.Body != "" {
+		t.Fatalf("default = %+v", result)
+	}
+	if n := result.Network; n == nil || n.TLSVerify || n.TLSVersion == "" || n.ClientCert != "" {
+		t.Errorf("default network = %+v", n)
+	}
+
+	setTLS(TLSSettings{Verify: true})
+	if result, _ := re.ExecuteRequest(context.Background(), req, nil); !strings.Contains(result.Error, "certificate") {
+		t.Errorf("unverified certificate accepted: %+v", result)
+	}
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	caID := uploadTestFile(t, q, fs, caPEM)
+	certPEM, keyPEM := selfSignedPEM(t, "relay-client")
+	certID, keyID := uploadTestFile(t, q, fs, certPEM), uploadTestFile(t, q, fs, keyPEM)
+
+	setTLS(TLSSettings{Verify: true, CAFileID: caID, ClientCerts: []ClientCertificate{{Host: "example.com", CertFileID: certID, KeyFileID: keyID}}})
+	if result, _ := re.ExecuteRequest(context.Background(), req, nil); result.StatusCode != 200 || result.Body != "" {
+		t.Errorf("CA bundle = %+v", result)
+	}
+
+	// The certificate goes only to its host
+	setTLS(TLSSettings{Verify: true, CAFileID: caID, ClientCerts: []ClientCertificate{{Host: "127.0.0.1", CertFileID: certID, KeyFileID: keyID}}})
+	if result, _ := re.ExecuteRequest(context.Background(), req, nil); result.StatusCode != 200 || result.Body != "relay-client" {
+		t.Errorf("client certificate = %+v", result)
+	} else if n := result.Network; n == nil || !n.TLSVerify || n.ClientCert != "127.0.0.1" {
+		t.Errorf("client certificate network = %+v", n)
+	}
+
+	// A key that does not match its certificate fails the request
+	setTLS(TLSSettings{ClientCerts: []ClientCertificate{{Host: "127.0.0.1", CertFileID: certID, KeyFileID: caID}}})
+	if result, _ := re.ExecuteRequest(context.Background(), req, nil); !strings.Contains(result.Error, "client") {
+		t.Errorf("bad key pair = %+v", result)
+	}
+}
+
+func TestCleanupOrphanFiles_KeepsTLSFiles(t *testing.T) {
+	db, q := testutil.SetupTestDBWithConn(t)
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	caID := uploadTestFile(t, q, fs, []byte("ca"))
+	orphanID := uploadTestFile(t, q, fs, []byte("orphan"))
+	if _, err := q.UpdateWorkspaceSettings(context.Background(), repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: fmt.Sprintf(`{"tls":{"caFileId":%d}}`, caID), Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := CleanupOrphanFiles(context.Background(), db, q, fs, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Orphans) != 1 || result.Orphans[0].FileID != orphanID {
+		t.Errorf("orphans = %+v", result.Orphans)
+	}
+}
+
+func TestCreateWebSocketClient_HTTPSProxyTLS(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer target.Close()
+	var tunnels []string
+	proxy := httptest.NewTLSServer(connectProxy(&tunnels))
+	defer proxy.Close()
+
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	fs, err := NewFileStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := q.CreateProxy(ctx, repository.CreateProxyParams{Name: "corp", Url: proxy.URL, WorkspaceID: 1})
+	proxyID := sql.NullInt64{Int64: p.ID, Valid: true}
+	setTLS := func(s TLSSettings) {
+		t.Helper()
+		data, _ := json.Marshal(WorkspaceSettings{TLS: s})
+		setNotificationSettings(t, q, string(data))
+	}
+	get := func() error {
+		t.Helper()
+		client, err := CreateWebSocketClient(ctx, q, fs, proxyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Get(target.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	// The proxy's certificate is checked like a target's
+	if err := get(); err != nil {
+		t.Fatalf("default: %v", err)
+	}
+	setTLS(TLSSettings{Verify: true})
+	if err := get(); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("unverified proxy certificate accepted: %v", err)
+	}
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: proxy.Certificate().Raw})
+	setTLS(TLSSettings{Verify: true, CAFileID: uploadTestFile(t, q, fs, caPEM)})
+	if err := get(); err != nil {
+		t.Errorf("CA bundle: %v", err)
+	}
+	if len(tunnels) != 2 {
+		t.Errorf("tunnels = %v, want 2", tunnels)
+	}
+}
@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaesslerAG/jsonpath"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+)
+
+// Token refresh interval bounds (seconds)
+const (
+	MinTokenRefreshInterval = 30
+	MaxTokenRefreshInterval = 24 * 60 * 60
+)
+
+const (
+	tokenRefreshTick     = 10 * time.Second
+	tokenRefreshSkew     = time.Minute      // refresh this long before the token expires
+	tokenRefreshRetry    = 30 * time.Second // retry delay while an expiring token can't be refreshed
+	tokenRefreshTimeout  = 30 * time.Second // per login request
+	tokenRefreshLeaseTTL = 2 * time.Minute
+	maxTokenExtractVars  = 20
+)
+
+// Token freshness statuses
+const (
+	TokenStatusFresh   = "fresh"
+	TokenStatusExpired = "expired" // the token's expiresAt has passed
+	TokenStatusFailing = "failing" // the last refresh failed
+	TokenStatusPending = "pending" // never refreshed yet
+	TokenStatusPaused  = "paused"
+)
+
+// TokenRefresher keeps login tokens fresh in environments: each refresher
+// runs its login request on an interval, and before the token expires when
+// the response says when that is, and writes the extracted values into its
+// environment (the active one when unset). Logins are not written to request
+// history.
+type TokenRefresher struct {
+	queries  *repository.Queries
+	executor *RequestExecutor
+	instance *Instance // optional; refreshes run only while holding the lease
+	jobs     *JobQueue // optional; scheduled refreshes run as queued jobs
+}
+
+func NewTokenRefresher(queries *repository.Queries, executor *RequestExecutor) *TokenRefresher {
+	return &TokenRefresher{queries: queries, executor: executor}
+}
+
+// SetInstance makes each refresh run once when several instances share the
+// database
+func (t *TokenRefresher) SetInstance(inst *Instance) {
+	t.instance = inst
+}
+
+// SetJobQueue runs scheduled refreshes on the job queue: each tick queues a
+// job per due refresher instead of logging in itself
+func (t *TokenRefresher) SetJobQueue(jq *JobQueue) {
+	t.jobs = jq
+	jq.Register(JobTypeTokenRefresh, func(ctx context.Context, job repository.Job) (interface{}, error) {
+		tr, ok, err := loadScheduled(ctx, job, t.queries.GetTokenRefresher)
+		if !ok || tr.Enabled == 0 || time.Now().Before(NextTokenRefresh(tr)) {
+			return nil, err
+		}
+		tr, err = t.refreshScheduled(ctx, tr)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": TokenFreshness(tr, time.Now())}, nil
+	})
+}
+
+// Start refreshes due tokens in the background until ctx is cancelled
+func (t *TokenRefresher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(tokenRefreshTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !t.instance.Acquire(ctx, LeaseTokenRefreshers, tokenRefreshLeaseTTL) {
+					continue
+				}
+				if t.jobs != nil {
+					t.EnqueueDue(ctx)
+				} else {
+					t.RunDue(ctx)
+				}
+			}
+		}
+	}()
+}
+
+// RunDue refreshes every enabled refresher that is due and returns how many
+// were run
+func (t *TokenRefresher) RunDue(ctx context.Context) int {
+	refreshers, err := t.queries.ListEnabledTokenRefreshers(ctx)
+	if err != nil {
+		log.Printf("token refresher: failed to list refreshers: %v", err)
+		return 0
+	}
+	now := time.Now()
+	ran := 0
+	for _, tr := range refreshers {
+		if now.Before(NextTokenRefresh(tr)) {
+			continue
+		}
+		ran++
+		if _, err := t.refreshScheduled(ctx, tr); err != nil {
+			log.Printf("token refresher %d: %v", tr.ID, err)
+		}
+	}
+	if err := t.queries.PruneTokenRefreshRuns(ctx); err != nil {
+		log.Printf("token refresher: failed to prune runs: %v", err)
+	}
+	return ran
+}
+
+// EnqueueDue queues a refresh of every enabled refresher that is due and
+// returns how many were due
+func (t *TokenRefresher) EnqueueDue(ctx context.Context) int {
+	refreshers, err := t.queries.ListEnabledTokenRefreshers(ctx)
+	if err != nil {
+		log.Printf("token refresher: failed to list refreshers: %v", err)
+		return 0
+	}
+	now := time.Now()
+	due := 0
+	for _, tr := range refreshers {
+		if now.Before(NextTokenRefresh(tr)) {
+			continue
+		}
+		due++
+		t.jobs.enqueueScheduled(ctx, JobTypeTokenRefresh, tr.ID, tr.WorkspaceID)
+	}
+	if err := t.queries.PruneTokenRefreshRuns(ctx); err != nil {
+		log.Printf("token refresher: failed to prune runs: %v", err)
+	}
+	return due
+}
+
+// refreshScheduled refreshes on schedule, counting against the workspace's
+// scheduled runs; once they are used up for the day the refresh is skipped
+// and the refresher shows the quota as its error
+func (t *TokenRefresher) refreshScheduled(ctx context.Context, tr repository.TokenRefresher) (repository.TokenRefresher, error) {
+	var exceeded *QuotaExceededError
+	if err := CheckQuotas(ctx, t.queries, tr.WorkspaceID, QuotaScheduledRuns); errors.As(err, &exceeded) {
+		if err := t.queries.MarkTokenRefreshFailed(ctx, repository.MarkTokenRefreshFailedParams{LastError: exceeded.Error(), ID: tr.ID}); err != nil {
+			return tr, err
+		}
+		return t.queries.GetTokenRefresher(ctx, tr.ID)
+	}
+	if err := t.queries.RecordTokenRefreshRun(ctx, tr.ID); err != nil {
+		return tr, err
+	}
+	return t.Refresh(ctx, tr)
+}
+
+// Refresh runs the login request now and records the outcome; a failed login
+// is recorded on the refresher rather than returned
+func (t *TokenRefresher) Refresh(ctx context.Context, tr repository.TokenRefresher) (repository.TokenRefresher, error) {
+	loginCtx, cancel := context.WithTimeout(withoutHistory(middleware.WithWorkspaceID(ctx, tr.WorkspaceID)), tokenRefreshTimeout)
+	defer cancel()
+
+	if expires, err := t.login(loginCtx, tr); err != nil {
+		if err := t.queries.MarkTokenRefreshFailed(ctx, repository.MarkTokenRefreshFailedParams{LastError: err.Error(), ID: tr.ID}); err != nil {
+			return tr, err
+		}
+	} else if err := t.queries.MarkTokenRefreshed(ctx, repository.MarkTokenRefreshedParams{ExpiresAt: expires, ID: tr.ID}); err != nil {
+		return tr, err
+	}
+	return t.queries.GetTokenRefresher(ctx, tr.ID)
+}
+
+// login runs the login request and writes the extracted values, returning
+// the token's expiry when the response has one
+func (t *TokenRefresher) login(ctx context.Context, tr repository.TokenRefresher) (expires sql.NullTime, err error) {
+	envID, err := tokenEnvironment(ctx, t.queries, tr.EnvironmentID, tr.WorkspaceID)
+	if err != nil {
+		return expires, err
+	}
+	req, err := t.queries.GetRequest(ctx, tr.LoginRequestID)
+	if err != nil || req.WorkspaceID != tr.WorkspaceID {
+		return expires, fmt.Errorf("login request %d not found", tr.LoginRequestID)
+	}
+
+	result, err := t.executor.ExecuteRequest(ctx, req, nil)
+	if err != nil {
+		return expires, err
+	}
+	if result.Error != "" {
+		return expires, errors.New("login failed: " + result.Error)
+	}
+	if result.StatusCode < 200 || result.StatusCode >= 300 {
+		return expires, fmt.Errorf("login failed with status %d", result.StatusCode)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(result.Body), &data); err != nil {
+		return expires, errors.New("login response is not JSON")
+	}
+	vars := make(map[string]string)
+	for name, path := range ParseTokenExtractVars(tr.ExtractVars) {
+		value, err := jsonpath.Get(path, data)
+		if err != nil || value == nil {
+			return expires, fmt.Errorf("login response has no value at %s", path)
+		}
+		if s, ok := value.(string); ok {
+			vars[name] = s
+		} else {
+			b, _ := json.Marshal(value)
+			vars[name] = string(b)
+		}
+	}
+	if tr.ExpiresInPath != "" {
+		if v, err := jsonpath.Get(tr.ExpiresInPath, data); err == nil {
+			if secs := tokenLifetime(v); secs > 0 {
+				expires = sql.NullTime{Time: time.Now().Add(time.Duration(secs * float64(time.Second))).UTC(), Valid: true}
+			}
+		}
+	}
+
+	if err := writeEnvironmentVariables(ctx, t.queries, t.executor.variableResolver, envID, vars); err != nil {
+		return sql.NullTime{}, err
+	}
+	return expires, nil
+}
+
+// tokenLifetime reads a lifetime in seconds, which some servers send as a string
+func tokenLifetime(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+// NextTokenRefresh is when the refresher is next due: an interval after the
+// last attempt, or shortly before the token expires when that comes first.
+// The zero time means now.
+func NextTokenRefresh(tr repository.TokenRefresher) time.Time {
+	if !tr.LastAttemptAt.Valid {
+		return time.Time{}
+	}
+	next := tr.LastAttemptAt.Time.Add(time.Duration(tr.IntervalSeconds) * time.Second)
+	if tr.ExpiresAt.Valid {
+		early := tr.ExpiresAt.Time.Add(-tokenRefreshSkew)
+		if retry := tr.LastAttemptAt.Time.Add(tokenRefreshRetry); early.Before(retry) {
+			early = retry
+		}
+		if early.Before(next) {
+			next = early
+		}
+	}
+	return next
+}
+
+// TokenFreshness reports the refresher's token status at now
+func TokenFreshness(tr repository.TokenRefresher, now time.Time) string {
+	switch {
+	case tr.Enabled == 0:
+		return TokenStatusPaused
+	case !tr.LastRefreshedAt.Valid && tr.LastError == "":
+		return TokenStatusPending
+	case tr.ExpiresAt.Valid && !now.Before(tr.ExpiresAt.Time):
+		return TokenStatusExpired
+	case tr.LastError != "":
+		return TokenStatusFailing
+	}
+	return TokenStatusFresh
+}
+
+// ParseTokenExtractVars decodes the extract_vars column: variable -> JSONPath
+func ParseTokenExtractVars(raw string) map[string]string {
+	var vars map[string]string
+	if err := json.Unmarshal([]byte(raw), &vars); err != nil {
+		return nil
+	}
+	return vars
+}
+
+// ValidateTokenRefresher checks what a refresher extracts from the login
+// response
+func ValidateTokenRefresher(extractVars map[string]string, expiresInPath string) error {
+	if len(extractVars) == 0 {
+		return errors.New("extractVars must name at least one variable")
+	}
+	if len(extractVars) > maxTokenExtractVars {
+		return fmt.Errorf("at most %d extractVars are allowed", maxTokenExtractVars)
+	}
+	for name, path := range extractVars {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("extractVars: variable name is required")
+		}
+		if !strings.HasPrefix(path, "$") {
+			return fmt.Errorf("extractVars %q: path must be a JSONPath starting with $", name)
+		}
+	}
+	if expiresInPath != "" && !strings.HasPrefix(expiresInPath, "$") {
+		return errors.New("expiresInPath must be a JSONPath starting with $")
+	}
+	return nil
+}
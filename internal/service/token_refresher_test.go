@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestTokenRefresher_RunDue(t *testing.T) {
+	var logins atomic.Int32
+	var rejected atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rejected.Load() {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"token":"t-%d","user":{"id":7}},"expires_in":"120"}`, logins.Add(1))
+	}))
+	defer server.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	vr := NewVariableResolver(q)
+	refresher := NewTokenRefresher(q, NewRequestExecutor(q, vr, nil))
+
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "dev", WorkspaceID: 1})
+	q.ActivateEnvironment(ctx, env.ID)
+	login, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Login", Method: "POST", Url: server.URL + "/login", WorkspaceID: 1})
+	tr, err := q.CreateTokenRefresher(ctx, repository.CreateTokenRefresherParams{
+		WorkspaceID: 1, Name: "api", LoginRequestID: login.ID,
+		ExtractVars:   `{"accessToken":"$.data.token","user":"$.data.user"}`,
+		ExpiresInPath: "$.expires_in", IntervalSeconds: 900, Enabled: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	envVars := func() map[string]string {
+		t.Helper()
+		e, _ := q.GetEnvironment(ctx, env.ID)
+		var vars map[string]string
+		json.Unmarshal([]byte(e.Variables.String), &vars)
+		return vars
+	}
+	reload := func() repository.TokenRefresher {
+		t.Helper()
+		tr, err := q.GetTokenRefresher(ctx, tr.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tr
+	}
+
+	if status := TokenFreshness(tr, time.Now()); status != TokenStatusPending {
+		t.Errorf("before the first refresh: status = %s", status)
+	}
+	if n := refresher.RunDue(ctx); n != 1 {
+		t.Fatalf("first RunDue refreshed %d, want 1", n)
+	}
+	if vars := envVars(); vars["accessToken"] != "t-1" || vars["user"] != `{"id":7}` {
+		t.Errorf("environment = %v", vars)
+	}
+	tr = reload()
+	if status := TokenFreshness(tr, time.Now()); status != TokenStatusFresh {
+		t.Errorf("after refresh: status = %s", status)
+	}
+	// The token lives 120s, so the next refresh comes a minute before that
+	// rather than after the 900s interval
+	if next := NextTokenRefresh(tr); next.Before(time.Now().Add(30*time.Second)) || next.After(time.Now().Add(90*time.Second)) {
+		t.Errorf("next refresh at %v, want about a minute from now", next)
+	}
+	if n := refresher.RunDue(ctx); n != 0 {
+		t.Errorf("second RunDue refreshed %d, want 0", n)
+	}
+
+	// Once the token is about to expire the login runs again
+	db.Exec(`UPDATE token_refreshers SET last_attempt_at = datetime('now', '-61 seconds'),
+		expires_at = datetime('now', '+59 seconds') WHERE id = ?`, tr.ID)
+	if n := refresher.RunDue(ctx); n != 1 {
+		t.Fatalf("RunDue near expiry refreshed %d, want 1", n)
+	}
+	if vars := envVars(); vars["accessToken"] != "t-2" {
+		t.Errorf("accessToken = %q, want t-2", vars["accessToken"])
+	}
+
+	// A failed login keeps the token and reports the error
+	rejected.Store(true)
+	tr, err = refresher.Refresh(ctx, reload())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.LastError != "login failed with status 401" || TokenFreshness(tr, time.Now()) != TokenStatusFailing {
+		t.Errorf("failed refresh: %q, status %s", tr.LastError, TokenFreshness(tr, time.Now()))
+	}
+	if vars := envVars(); vars["accessToken"] != "t-2" {
+		t.Errorf("failed refresh changed accessToken to %q", vars["accessToken"])
+	}
+	if status := TokenFreshness(tr, tr.ExpiresAt.Time.Add(time.Second)); status != TokenStatusExpired {
+		t.Errorf("past expiry: status = %s", status)
+	}
+	// Retries come sooner than the interval while the token is expiring
+	if next := NextTokenRefresh(tr); next.After(time.Now().Add(time.Minute)) {
+		t.Errorf("retry at %v", next)
+	}
+
+	rejected.Store(false)
+	tr, _ = refresher.Refresh(ctx, tr)
+	if tr.LastError != "" || TokenFreshness(tr, time.Now()) != TokenStatusFresh {
+		t.Errorf("recovered: %q, status %s", tr.LastError, TokenFreshness(tr, time.Now()))
+	}
+
+	// Paused refreshers are skipped
+	q.UpdateTokenRefresher(ctx, repository.UpdateTokenRefresherParams{
+		Name: tr.Name, LoginRequestID: tr.LoginRequestID, ExtractVars: tr.ExtractVars,
+		IntervalSeconds: tr.IntervalSeconds, Enabled: 0, ID: tr.ID,
+	})
+	db.Exec("UPDATE token_refreshers SET last_attempt_at = NULL WHERE id = ?", tr.ID)
+	if n := refresher.RunDue(ctx); n != 0 {
+		t.Errorf("paused refresher ran")
+	}
+}
+
+func TestTokenRefresher_Failures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"session":"s"}`))
+	}))
+	defer server.Close()
+
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	vr := NewVariableResolver(q)
+	refresher := NewTokenRefresher(q, NewRequestExecutor(q, vr, nil))
+
+	login, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Login", Method: "POST", Url: server.URL, WorkspaceID: 1})
+	tr, _ := q.CreateTokenRefresher(ctx, repository.CreateTokenRefresherParams{
+		WorkspaceID: 1, Name: "api", LoginRequestID: login.ID,
+		ExtractVars: `{"accessToken":"$.token"}`, IntervalSeconds: 900, Enabled: 1,
+	})
+
+	tr, _ = refresher.Refresh(ctx, tr)
+	if tr.LastError != ErrNoTokenEnvironment.Error() {
+		t.Errorf("without an environment: %q", tr.LastError)
+	}
+
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "dev", WorkspaceID: 1})
+	tr.EnvironmentID = sql.NullInt64{Int64: env.ID, Valid: true}
+	tr, _ = refresher.Refresh(ctx, tr)
+	if tr.LastError != "login response has no value at $.token" {
+		t.Errorf("missing token: %q", tr.LastError)
+	}
+
+	if err := ValidateTokenRefresher(map[string]string{"accessToken": "token"}, ""); err == nil {
+		t.Error("path without $ accepted")
+	}
+	if err := ValidateTokenRefresher(nil, ""); err == nil {
+		t.Error("refresher without variables accepted")
+	}
+}
+
+func TestTokenRefresher_ScheduledQuota(t *testing.T) {
+	var logins atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"token":"t-%d"}`, logins.Add(1))
+	}))
+	defer server.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	vr := NewVariableResolver(q)
+	refresher := NewTokenRefresher(q, NewRequestExecutor(q, vr, nil))
+
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "dev", WorkspaceID: 1})
+	q.ActivateEnvironment(ctx, env.ID)
+	login, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Login", Method: "POST", Url: server.URL, WorkspaceID: 1})
+	tr, _ := q.CreateTokenRefresher(ctx, repository.CreateTokenRefresherParams{
+		WorkspaceID: 1, Name: "api", LoginRequestID: login.ID,
+		ExtractVars: `{"accessToken":"$.token"}`, IntervalSeconds: 900, Enabled: 1,
+	})
+	q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: `{"quotas":{"maxScheduledRunsPerDay":1}}`, Valid: true}, ID: 1,
+	})
+
+	// Scheduled refreshes count against the workspace quota; manual ones don't
+	if n := refresher.RunDue(ctx); n != 1 || logins.Load() != 1 {
+		t.Fatalf("first RunDue ran %d, logins %d", n, logins.Load())
+	}
+	db.Exec("UPDATE token_refreshers SET last_attempt_at = NULL WHERE id = ?", tr.ID)
+	refresher.RunDue(ctx)
+	if logins.Load() != 1 {
+		t.Errorf("refresh over quota logged in (%d logins)", logins.Load())
+	}
+	tr, _ = q.GetTokenRefresher(ctx, tr.ID)
+	if !strings.Contains(tr.LastError, "scheduled") {
+		t.Errorf("last error over quota = %q", tr.LastError)
+	}
+
+	tr, err := refresher.Refresh(ctx, tr)
+	if err != nil || tr.LastError != "" || logins.Load() != 2 {
+		t.Errorf("manual refresh = %q, %v (%d logins)", tr.LastError, err, logins.Load())
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+const defaultRequestIDHeader = "X-Request-Id"
+
+// TracingSettings controls the correlation headers added to outgoing requests
+type TracingSettings struct {
+	// Enabled adds a request ID header and a W3C traceparent to every request
+	Enabled bool `json:"enabled"`
+	// RequestIDHeader names the request ID header (default X-Request-Id)
+	RequestIDHeader string `json:"requestIdHeader,omitempty"`
+	// OTLP exports executions and flow runs as spans; nil disables export
+	OTLP *OTLPSettings `json:"otlp,omitempty"`
+}
+
+func (t TracingSettings) Validate() error {
+	if t.RequestIDHeader != "" && !httpTokenPattern.MatchString(t.RequestIDHeader) {
+		return fmt.Errorf("tracing.requestIdHeader %q is not a valid header name", t.RequestIDHeader)
+	}
+	if t.OTLP != nil {
+		return t.OTLP.Validate()
+	}
+	return nil
+}
+
+var (
+	httpTokenPattern   = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+	traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+)
+
+// requestTrace identifies the span of one execution
+type requestTrace struct {
+	TraceID  string
+	SpanID   string
+	ParentID string
+}
+
+type traceSpanKey struct{}
+
+// withTraceSpan makes every request executed under ctx share traceID, with
+// spanID as their parent span, so a flow run's requests can be correlated in
+// history, in backend logs and in the exported trace
+func withTraceSpan(ctx context.Context, traceID, spanID string) context.Context {
+	return context.WithValue(ctx, traceSpanKey{}, requestTrace{TraceID: traceID, SpanID: spanID})
+}
+
+// traceFrom returns the trace ID and parent span ID set by withTraceSpan
+func traceFrom(ctx context.Context) (string, string) {
+	t, _ := ctx.Value(traceSpanKey{}).(requestTrace)
+	return t.TraceID, t.SpanID
+}
+
+// NewTraceID returns a random W3C trace ID (32 lowercase hex digits)
+func NewTraceID() string {
+	return randomHex(16)
+}
+
+func newSpanID() string {
+	return randomHex(8)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// injectTracingHeaders adds the request ID and traceparent headers unless the
+// request already sets them, and returns the request's span along with the
+// headers it added. A traceparent set by the user wins, so its trace ID is the
+// one recorded. With only OTLP export on, the span is created but no headers
+// are sent.
+func injectTracingHeaders(ctx context.Context, req *http.Request, settings TracingSettings) (requestTrace, map[string]string) {
+	traceID, parentID := traceFrom(ctx)
+	if !settings.Enabled && settings.OTLP == nil {
+		return requestTrace{TraceID: traceID}, nil
+	}
+	if traceID == "" {
+		traceID = NewTraceID()
+	}
+	trace := requestTrace{TraceID: traceID, SpanID: newSpanID(), ParentID: parentID}
+	if !settings.Enabled {
+		return trace, nil
+	}
+	added := make(map[string]string, 2)
+
+	header := settings.RequestIDHeader
+	if header == "" {
+		header = defaultRequestIDHeader
+	}
+	if req.Header.Get(header) == "" {
+		added[header] = uuid.NewString()
+		req.Header.Set(header, added[header])
+	}
+
+	if m := traceparentPattern.FindStringSubmatch(req.Header.Get("traceparent")); m != nil {
+		trace.TraceID, trace.ParentID = m[1], m[2]
+		return trace, added
+	}
+	added["traceparent"] = fmt.Sprintf("00-%s-%s-01", trace.TraceID, trace.SpanID)
+	req.Header.Set("traceparent", added["traceparent"])
+	return trace, added
+}
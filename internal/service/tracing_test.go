@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+var traceparentRe = regexp.MustCompile(`^00-([0-9a-f]{32})-[0-9a-f]{16}-01$`)
+
+func enableTracing(t *testing.T, q *repository.Queries, settings string) {
+	t.Helper()
+	if _, err := q.UpdateWorkspaceSettings(context.Background(), repository.UpdateWorkspaceSettingsParams{
+		Settings: sql.NullString{String: settings, Valid: true},
+		ID:       1,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecuteRequest_TracingHeaders(t *testing.T) {
+	var mu sync.Mutex
+	var seen []http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Clone())
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	re := NewRequestExecutor(q, NewVariableResolver(q), nil)
+	ctx := context.Background()
+
+	// Off by default
+	result, _ := re.ExecuteAdhoc(ctx, "GET", ts.URL, "", "", nil, nil)
+	if seen[0].Get("traceparent") != "" || seen[0].Get("X-Request-Id") != "" || result.TraceID != "" {
+		t.Errorf("tracing headers sent while disabled: %v", seen[0])
+	}
+
+	enableTracing(t, q, `{"tracing":{"enabled":true,"requestIdHeader":"X-Correlation-Id"}}`)
+	result, _ = re.ExecuteAdhoc(ctx, "GET", ts.URL, "", "", nil, nil)
+	m := traceparentRe.FindStringSubmatch(seen[1].Get("traceparent"))
+	if m == nil || m[1] != result.TraceID {
+		t.Fatalf("traceparent = %q, traceId = %q", seen[1].Get("traceparent"), result.TraceID)
+	}
+	reqID := seen[1].Get("X-Correlation-Id")
+	if len(reqID) != 36 || result.ResolvedHeaders["X-Correlation-Id"] != reqID {
+		t.Errorf("request id = %q, resolved headers = %v", reqID, result.ResolvedHeaders)
+	}
+
+	// The headers and trace ID are echoed into history
+	history, _ := q.ListHistoryByTrace(ctx, repository.ListHistoryByTraceParams{
+		WorkspaceID: 1,
+		TraceID:     sql.NullString{String: result.TraceID, Valid: true},
+	})
+	if len(history) != 1 || !strings.Contains(history[0].RequestHeaders.String, reqID) {
+		t.Errorf("history for trace = %+v", history)
+	}
+
+	// Headers the request sets itself are kept
+	userParent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+	result, _ = re.ExecuteAdhoc(ctx, "GET", ts.URL, `{"traceparent":"`+userParent+`","X-Correlation-Id":"mine"}`, "", nil, nil)
+	if seen[2].Get("traceparent") != userParent || seen[2].Get("X-Correlation-Id") != "mine" {
+		t.Errorf("user headers replaced: %v", seen[2])
+	}
+	if result.TraceID != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("traceId = %q", result.TraceID)
+	}
+}
+
+func TestFlowRun_SharesTraceID(t *testing.T) {
+	var mu sync.Mutex
+	var parents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		parents = append(parents, r.Header.Get("traceparent"))
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	ctx := context.Background()
+	enableTracing(t, q, `{"tracing":{"enabled":true}}`)
+
+	flow, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "traced", WorkspaceID: 1})
+	for i, path := range []string{"/login", "/orders"} {
+		q.CreateFlowStep(ctx, repository.CreateFlowStepParams{
+			FlowID:    flow.ID,
+			StepOrder: int64(i + 1),
+			Name:      path,
+			Method:    "GET",
+			Url:       ts.URL + path,
+		})
+	}
+
+	run := func() *FlowResult {
+		result, err := fr.Run(ctx, flow.ID, nil)
+		if err != nil || !result.Success {
+			t.Fatalf("run: %v %+v", err, result)
+		}
+		return result
+	}
+	first, second := run(), run()
+	if first.TraceID == second.TraceID {
+		t.Errorf("runs share trace ID %q", first.TraceID)
+	}
+
+	for i, p := range parents {
+		m := traceparentRe.FindStringSubmatch(p)
+		want := first.TraceID
+		if i >= 2 {
+			want = second.TraceID
+		}
+		if m == nil || m[1] != want {
+			t.Errorf("request %d traceparent = %q, want trace %s", i, p, want)
+		}
+	}
+	if parents[0] == parents[1] {
+		t.Error("steps should get their own span IDs")
+	}
+
+	history, _ := q.ListHistoryByTrace(ctx, repository.ListHistoryByTraceParams{
+		WorkspaceID: 1,
+		TraceID:     sql.NullString{String: first.TraceID, Valid: true},
+	})
+	if len(history) != 2 || !strings.HasSuffix(history[0].Url, "/login") || !strings.HasSuffix(history[1].Url, "/orders") {
+		t.Errorf("history for trace = %+v", history)
+	}
+}
+
+func TestFlowRun_ExportsOTLPSpans(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer target.Close()
+
+	type otlpSpan struct {
+		TraceID      string `json:"traceId"`
+		SpanID       string `json:"spanId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+		Status       *struct {
+			Code int `json:"code"`
+		} `json:"status"`
+	}
+	spans := make(chan otlpSpan, 10)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("X-Api-Key") != "secret" {
+			t.Errorf("export to %s with key %q", r.URL.Path, r.Header.Get("X-Api-Key"))
+		}
+		var payload struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []otlpSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		for _, rs := range payload.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans <- s
+				}
+			}
+		}
+	}))
+	defer collector.Close()
+
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+	ctx := context.Background()
+	enableTracing(t, q, `{"tracing":{"otlp":{"endpoint":"`+collector.URL+`","headers":{"X-Api-Key":"secret"}}}}`)
+
+	flow, _ := q.CreateFlow(ctx, repository.CreateFlowParams{Name: "checkout", WorkspaceID: 1})
+	for i, path := range []string{"/cart", "/missing"} {
+		q.CreateFlowStep(ctx, repository.CreateFlowStepParams{
+			FlowID:    flow.ID,
+			StepOrder: int64(i + 1),
+			Name:      path,
+			Method:    "GET",
+			Url:       target.URL + path,
+		})
+	}
+	result, err := fr.Run(ctx, flow.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := re.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]otlpSpan)
+	for len(byName) < 3 {
+		select {
+		case s := <-spans:
+			byName[s.Name] = s
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for spans, got %v", byName)
+		}
+	}
+	run := byName["flow checkout"]
+	if run.TraceID != result.TraceID || run.ParentSpanID != "" {
+		t.Errorf("run span = %+v, trace %s", run, result.TraceID)
+	}
+	for _, name := range []string{"/cart", "/missing"} {
+		step := byName[name]
+		if step.TraceID != result.TraceID || step.ParentSpanID != run.SpanID {
+			t.Errorf("step span %s = %+v, want child of %s", name, step, run.SpanID)
+		}
+	}
+	if byName["/cart"].Status != nil || byName["/missing"].Status == nil || byName["/missing"].Status.Code != 2 {
+		t.Errorf("span status: cart %+v, missing %+v", byName["/cart"].Status, byName["/missing"].Status)
+	}
+}
+
+func TestOTLPExporter_BatchesAndFlushesOnShutdown(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []json.RawMessage `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		batches = append(batches, len(payload.ResourceSpans[0].ScopeSpans[0].Spans))
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	e := NewOTLPExporter()
+	settings := OTLPSettings{Endpoint: collector.URL}
+	now := time.Now()
+	for i := 0; i < otlpBatchSize+10; i++ {
+		e.Export(settings, exportSpan{trace: requestTrace{TraceID: NewTraceID(), SpanID: newSpanID()}, name: "GET", start: now, end: now})
+	}
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	e.Export(settings, exportSpan{trace: requestTrace{TraceID: NewTraceID(), SpanID: newSpanID()}, name: "late"})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || batches[0] != otlpBatchSize || batches[1] != 10 {
+		t.Errorf("batches = %v, want [%d 10]", batches, otlpBatchSize)
+	}
+}
+
+func TestTracingSettings_Validate(t *testing.T) {
+	for raw, ok := range map[string]bool{
+		`{"requestIdHeader":"X-Trace"}`:                          true,
+		`{"requestIdHeader":"bad header"}`:                       false,
+		`{"otlp":{"endpoint":"http://collector:4318"}}`:          true,
+		`{"otlp":{"endpoint":"collector:4318"}}`:                 false,
+		`{"otlp":{"endpoint":"https://x","headers":{"a b":""}}}`: false,
+	} {
+		var s TracingSettings
+		if err := json.Unmarshal([]byte(raw), &s); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Validate(); (err == nil) != ok {
+			t.Errorf("%s: err = %v", raw, err)
+		}
+	}
+}
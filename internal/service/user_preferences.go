@@ -0,0 +1,84 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// UserPreferences holds UI settings that follow a user across machines. There
+// are no accounts yet: a preference set is identified by an opaque client token.
+type UserPreferences struct {
+	Theme              string              `json:"theme"` // system | light | dark
+	DefaultWorkspaceID *int64              `json:"defaultWorkspaceId"`
+	Editor             EditorPreferences   `json:"editor"`
+	HiddenColumns      map[string][]string `json:"hiddenColumns"` // table → hidden column keys
+}
+
+type EditorPreferences struct {
+	FontSize    int  `json:"fontSize"`
+	TabSize     int  `json:"tabSize"`
+	WordWrap    bool `json:"wordWrap"`
+	LineNumbers bool `json:"lineNumbers"`
+}
+
+var userThemes = map[string]bool{"system": true, "light": true, "dark": true}
+
+// userTokenPattern accepts client-generated tokens such as UUIDs
+var userTokenPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{16,128}$`)
+
+func DefaultUserPreferences() UserPreferences {
+	return UserPreferences{
+		Theme:         "system",
+		Editor:        EditorPreferences{FontSize: 13, TabSize: 2, LineNumbers: true},
+		HiddenColumns: map[string][]string{},
+	}
+}
+
+// ParseUserPreferences decodes stored preferences over the defaults, so fields
+// added later get their default value
+func ParseUserPreferences(raw string) UserPreferences {
+	p := DefaultUserPreferences()
+	if raw != "" {
+		json.Unmarshal([]byte(raw), &p)
+	}
+	if p.HiddenColumns == nil {
+		p.HiddenColumns = map[string][]string{}
+	}
+	return p
+}
+
+func (p UserPreferences) Validate() error {
+	if !userThemes[p.Theme] {
+		return fmt.Errorf("invalid theme %q (expected system, light or dark)", p.Theme)
+	}
+	if p.Editor.FontSize < 8 || p.Editor.FontSize > 32 {
+		return fmt.Errorf("editor.fontSize must be between 8 and 32")
+	}
+	if p.Editor.TabSize < 1 || p.Editor.TabSize > 8 {
+		return fmt.Errorf("editor.tabSize must be between 1 and 8")
+	}
+	if len(p.HiddenColumns) > 50 {
+		return fmt.Errorf("too many hiddenColumns tables")
+	}
+	for table := range p.HiddenColumns {
+		if table == "" {
+			return fmt.Errorf("hiddenColumns table name is required")
+		}
+	}
+	return nil
+}
+
+// ValidUserToken reports whether token can identify a preference set
+func ValidUserToken(token string) bool {
+	return userTokenPattern.MatchString(token)
+}
+
+// HashUserToken is the storage key for a token, so the database never holds
+// tokens that could be replayed
+func HashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,69 @@
+package service
+
+import "sort"
+
+// Variable scopes reported in the change log
+const (
+	VarScopeRuntime     = "runtime"
+	VarScopeEnvironment = "environment"
+	VarScopeCollection  = "collection"
+	VarScopeGlobal      = "global"
+)
+
+// VariableChange records a single variable mutation during a run
+type VariableChange struct {
+	StepID    int64  `json:"stepId,omitempty"`
+	StepName  string `json:"stepName,omitempty"`
+	Iteration int64  `json:"iteration,omitempty"`
+	Source    string `json:"source,omitempty"` // preScript, postScript, extractVars
+	Scope     string `json:"scope"`
+	Name      string `json:"name"`
+	OldValue  string `json:"oldValue"`
+	NewValue  string `json:"newValue"`
+	Created   bool   `json:"created,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+	Dry       bool   `json:"dry,omitempty"` // kept in memory by a dry-variables run, not saved
+}
+
+func cloneVars(vars map[string]string) map[string]string {
+	clone := make(map[string]string, len(vars))
+	for k, v := range vars {
+		clone[k] = v
+	}
+	return clone
+}
+
+// diffVars compares two snapshots of the same variable map
+func diffVars(scope string, before, after map[string]string) []VariableChange {
+	var changes []VariableChange
+	for k, newVal := range after {
+		oldVal, existed := before[k]
+		if existed && oldVal == newVal {
+			continue
+		}
+		changes = append(changes, VariableChange{Scope: scope, Name: k, OldValue: oldVal, NewValue: newVal, Created: !existed})
+	}
+	for k, oldVal := range before {
+		if _, ok := after[k]; !ok {
+			changes = append(changes, VariableChange{Scope: scope, Name: k, OldValue: oldVal, Deleted: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// persistedChanges describes pending writes to a persisted scope (empty value = delete)
+func persistedChanges(scope string, existing, updates map[string]string) []VariableChange {
+	var changes []VariableChange
+	for k, newVal := range updates {
+		oldVal, existed := existing[k]
+		switch {
+		case newVal == "" && existed:
+			changes = append(changes, VariableChange{Scope: scope, Name: k, OldValue: oldVal, Deleted: true})
+		case newVal != "" && (!existed || oldVal != newVal):
+			changes = append(changes, VariableChange{Scope: scope, Name: k, OldValue: oldVal, NewValue: newVal, Created: !existed})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestDiffVars(t *testing.T) {
+	before := map[string]string{"a": "1", "b": "2", "c": "3"}
+	after := map[string]string{"a": "1", "b": "20", "d": "4"}
+
+	changes := diffVars(VarScopeRuntime, before, after)
+	if len(changes) != 3 {
+		t.Fatalf("changes: got %d, want 3 (%+v)", len(changes), changes)
+	}
+	if changes[0].Name != "b" || changes[0].OldValue != "2" || changes[0].NewValue != "20" {
+		t.Errorf("b change: got %+v", changes[0])
+	}
+	if changes[1].Name != "c" || !changes[1].Deleted {
+		t.Errorf("c change: got %+v", changes[1])
+	}
+	if changes[2].Name != "d" || !changes[2].Created {
+		t.Errorf("d change: got %+v", changes[2])
+	}
+}
+
+func TestFlowRunner_VariableChangeLog(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"42"}`))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	fr := NewFlowRunner(q, re, vr)
+
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "Env",
+		WorkspaceID: 1,
+		Variables:   sql.NullString{String: `{"token":"old"}`, Valid: true},
+	})
+	q.ActivateEnvironment(ctx, env.ID)
+
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{
+			Name:        "fetch",
+			Method:      "GET",
+			Url:         ts.URL,
+			ExtractVars: sql.NullString{String: `{"userId":"$.id"}`, Valid: true},
+			PostScript:  sql.NullString{String: `pm.environment.set("token", "new");`, Valid: true},
+		},
+	})
+
+	result, err := fr.Run(ctx, flowID, nil)
+	if err != nil {
+		t.Fatalf("run flow: %v", err)
+	}
+
+	var sawExtract, sawEnv bool
+	for _, c := range result.VariableChanges {
+		if c.Source == "extractVars" && c.Name == "userId" && c.NewValue == "42" && c.StepName == "fetch" {
+			sawExtract = true
+		}
+		if c.Source == "postScript" && c.Scope == VarScopeEnvironment && c.Name == "token" && c.OldValue == "old" && c.NewValue == "new" {
+			sawEnv = true
+		}
+	}
+	if !sawExtract {
+		t.Errorf("missing extractVars change in %+v", result.VariableChanges)
+	}
+	if !sawEnv {
+		t.Errorf("missing environment change in %+v", result.VariableChanges)
+	}
+}
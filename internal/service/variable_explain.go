@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"relay/internal/middleware"
+)
+
+// VarScopeBuiltin marks a value produced by a built-in $ variable
+const VarScopeBuiltin = "builtin"
+
+const maskedSecret = "******"
+
+// VariableSource tells where one {{variable}} got its value
+type VariableSource struct {
+	Name     string   `json:"name"`
+	Scope    string   `json:"scope,omitempty"`   // runtime, environment, collection, global or builtin; empty when unresolved
+	ScopeID  int64    `json:"scopeId,omitempty"` // environment, collection or workspace ID
+	Value    string   `json:"value,omitempty"`
+	Secret   bool     `json:"secret,omitempty"`   // value is masked
+	Shadowed []string `json:"shadowed,omitempty"` // lower-priority scopes that define it too, highest first
+}
+
+// Explain resolves input like Resolve and reports, in order of first use,
+// which scope each variable was taken from. Secret workspace and collection
+// variables are masked in both the text and the report. Sequences and pools
+// show their next value without consuming it.
+func (vr *VariableResolver) Explain(ctx context.Context, input string, runtimeVars map[string]string, collectionID int64) (string, []VariableSource) {
+	x := vr.newExplainer(ctx, runtimeVars, collectionID)
+	x.env.data = x.env.data.previewing()
+	return x.resolve(vr.snippetExpander(ctx).expand(input), true)
+}
+
+// explainer resolves strings for one request while tracking variable sources
+type explainer struct {
+	vr      *VariableResolver
+	layers  []varLayer
+	allVars map[string]string
+	secrets map[varScopeKey]map[string]bool
+	env     builtinEnv
+}
+
+func (vr *VariableResolver) newExplainer(ctx context.Context, runtimeVars map[string]string, collectionID int64) *explainer {
+	x := &explainer{
+		vr:      vr,
+		layers:  vr.variableLayers(ctx, runtimeVars, collectionID),
+		allVars: make(map[string]string),
+		secrets: vr.secretNames(ctx, collectionID),
+		env:     vr.builtinEnv(ctx),
+	}
+	for _, layer := range x.layers {
+		for k, v := range layer.vars {
+			x.allVars[k] = v
+		}
+	}
+	return x
+}
+
+// resolve substitutes input's variables and returns their sources. Secret
+// values are always masked in the sources; in the text only with maskText.
+func (x *explainer) resolve(input string, maskText bool) (string, []VariableSource) {
+	sources := []VariableSource{}
+	seen := make(map[string]bool)
+	text := variablePattern.ReplaceAllStringFunc(input, func(match string) string {
+		name := strings.TrimSpace(match[2 : len(match)-2])
+		val, ok := x.vr.lookup(name, x.allVars, x.env)
+		if !ok {
+			val = match
+		}
+		src := x.source(name, val, ok)
+		if src.Secret && maskText {
+			val = maskedSecret
+		}
+		if !seen[name] {
+			seen[name] = true
+			sources = append(sources, src)
+		}
+		return val
+	})
+	return text, sources
+}
+
+// source finds the highest-priority scope defining name
+func (x *explainer) source(name, val string, resolved bool) VariableSource {
+	src := VariableSource{Name: name}
+	if !resolved {
+		return src
+	}
+	src.Scope, src.Value = VarScopeBuiltin, val
+	for i := len(x.layers) - 1; i >= 0; i-- {
+		if _, defined := x.layers[i].vars[name]; !defined {
+			continue
+		}
+		if src.Scope == VarScopeBuiltin {
+			src.Scope, src.ScopeID = x.layers[i].scope, x.layers[i].id
+		} else {
+			src.Shadowed = append(src.Shadowed, x.layers[i].scope)
+		}
+	}
+	if x.secrets[varScopeKey{src.Scope, src.ScopeID}][name] {
+		src.Secret, src.Value = true, maskedSecret
+	}
+	return src
+}
+
+type (
+	variableTraceOnKey struct{}
+	variableTraceKey   struct{}
+)
+
+// variableTrace collects the sources of the variables one execution resolves
+type variableTrace struct {
+	mu      sync.Mutex
+	sources []VariableSource
+	seen    map[string]bool
+}
+
+// WithVariableTrace makes every request executed under ctx report where each
+// of its variables came from in ExecuteResult.VariableTrace
+func WithVariableTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, variableTraceOnKey{}, true)
+}
+
+// startVariableTrace gives an execution its own trace when tracing is on;
+// it returns nil otherwise
+func startVariableTrace(ctx context.Context) (context.Context, *variableTrace) {
+	if ctx.Value(variableTraceOnKey{}) == nil {
+		return ctx, nil
+	}
+	t := &variableTrace{seen: make(map[string]bool)}
+	return context.WithValue(ctx, variableTraceKey{}, t), t
+}
+
+func (t *variableTrace) add(sources []VariableSource) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, src := range sources {
+		if !t.seen[src.Name] {
+			t.seen[src.Name] = true
+			t.sources = append(t.sources, src)
+		}
+	}
+}
+
+func (t *variableTrace) list() []VariableSource {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]VariableSource{}, t.sources...)
+}
+
+// resolveFunc returns the substitution Resolve applies for a request in
+// collectionID, after expanding snippets; inside a traced execution it also
+// records variable sources, and it notes secrets that could not be decrypted
+// for the execution to fail on
+func (vr *VariableResolver) resolveFunc(ctx context.Context, runtimeVars map[string]string, collectionID ...int64) func(string) string {
+	locked := lockedSecretsFrom(ctx)
+	snippets := vr.snippetExpander(ctx)
+	if t, ok := ctx.Value(variableTraceKey{}).(*variableTrace); ok {
+		var colID int64
+		if len(collectionID) > 0 {
+			colID = collectionID[0]
+		}
+		x := vr.newExplainer(ctx, runtimeVars, colID)
+		return func(input string) string {
+			text, sources := x.resolve(snippets.expand(input), false)
+			t.add(sources)
+			locked.note(text)
+			return text
+		}
+	}
+	allVars := vr.buildAllVars(ctx, runtimeVars, collectionID...)
+	env := vr.builtinEnv(ctx)
+	return func(input string) string {
+		text := vr.resolveWithVars(snippets.expand(input), allVars, env)
+		locked.note(text)
+		return text
+	}
+}
+
+// secretNames returns the names of secret variables by scope
+func (vr *VariableResolver) secretNames(ctx context.Context, collectionID int64) map[varScopeKey]map[string]bool {
+	names := make(map[varScopeKey]map[string]bool)
+	parse := func(key varScopeKey, raw sql.NullString) {
+		var list []string
+		if raw.Valid && raw.String != "" {
+			json.Unmarshal([]byte(raw.String), &list)
+		}
+		names[key] = make(map[string]bool, len(list))
+		for _, n := range list {
+			names[key][n] = true
+		}
+	}
+	wsID := middleware.GetWorkspaceID(ctx)
+	if ws, err := vr.queries.GetWorkspace(ctx, wsID); err == nil {
+		parse(varScopeKey{VarScopeGlobal, wsID}, ws.SecretVariables)
+	}
+	if collectionID > 0 {
+		if c, err := vr.queries.GetCollection(ctx, collectionID); err == nil {
+			parse(varScopeKey{VarScopeCollection, collectionID}, c.SecretVariables)
+		}
+	}
+	return names
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestExplain_Scopes(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	if _, err := q.UpdateWorkspaceVariableSet(ctx, repository.UpdateWorkspaceVariableSetParams{
+		Variables:       sql.NullString{String: `{"host":"ws-host","token":"s3cret","region":"eu"}`, Valid: true},
+		SecretVariables: sql.NullString{String: `["token"]`, Valid: true},
+		ID:              1,
+	}); err != nil {
+		t.Fatalf("update workspace vars: %v", err)
+	}
+	col, err := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "col", WorkspaceID: 1})
+	if err != nil {
+		t.Fatalf("create collection: %v", err)
+	}
+	if _, err := q.UpdateCollectionVariables(ctx, repository.UpdateCollectionVariablesParams{
+		Variables: sql.NullString{String: `{"host":"col-host"}`, Valid: true},
+		ID:        col.ID,
+	}); err != nil {
+		t.Fatalf("update collection vars: %v", err)
+	}
+	env, err := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "dev",
+		Variables:   sql.NullString{String: `{"host":"env-host"}`, Valid: true},
+		WorkspaceID: 1,
+	})
+	if err != nil {
+		t.Fatalf("create environment: %v", err)
+	}
+	if _, err := q.ActivateEnvironment(ctx, env.ID); err != nil {
+		t.Fatalf("activate environment: %v", err)
+	}
+
+	vr := NewVariableResolver(q)
+	text, sources := vr.Explain(ctx, "{{host}}/{{region}}?t={{token}}&id={{ id }}&u={{missing}}&h={{host}}", map[string]string{"id": "7"}, col.ID)
+
+	if want := "env-host/eu?t=******&id=7&u={{missing}}&h=env-host"; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	want := []VariableSource{
+		{Name: "host", Scope: VarScopeEnvironment, ScopeID: env.ID, Value: "env-host", Shadowed: []string{VarScopeCollection, VarScopeGlobal}},
+		{Name: "region", Scope: VarScopeGlobal, ScopeID: 1, Value: "eu"},
+		{Name: "token", Scope: VarScopeGlobal, ScopeID: 1, Value: "******", Secret: true},
+		{Name: "id", Scope: VarScopeRuntime, Value: "7"},
+		{Name: "missing"},
+	}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("sources =\n%+v\nwant\n%+v", sources, want)
+	}
+
+	_, sources = vr.Explain(ctx, "{{$timestamp}}", nil, 0)
+	if len(sources) != 1 || sources[0].Scope != VarScopeBuiltin || sources[0].Value == "" {
+		t.Errorf("builtin sources = %+v", sources)
+	}
+}
+
+func TestExecuteRequest_VariableTrace(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	if _, err := q.UpdateWorkspaceVariableSet(ctx, repository.UpdateWorkspaceVariableSetParams{
+		Variables:       sql.NullString{String: `{"path":"ws","token":"s3cret"}`, Valid: true},
+		SecretVariables: sql.NullString{String: `["token"]`, Valid: true},
+		ID:              1,
+	}); err != nil {
+		t.Fatalf("update workspace vars: %v", err)
+	}
+	vr := NewVariableResolver(q)
+	re := NewRequestExecutor(q, vr, nil)
+	req := repository.Request{
+		Method:  "GET",
+		Url:     ts.URL + "/{{path}}",
+		Headers: sql.NullString{String: `{"Authorization":"Bearer {{token}}","X-Path":"{{path}}"}`, Valid: true},
+	}
+
+	result, err := re.ExecuteRequest(ctx, req, map[string]string{"path": "runtime"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.VariableTrace != nil {
+		t.Errorf("trace without WithVariableTrace: %+v", result.VariableTrace)
+	}
+
+	result, err = re.ExecuteRequest(WithVariableTrace(ctx), req, map[string]string{"path": "runtime"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if result.ResolvedHeaders["Authorization"] != "Bearer s3cret" {
+		t.Errorf("secret not sent: %q", result.ResolvedHeaders["Authorization"])
+	}
+	want := []VariableSource{
+		{Name: "path", Scope: VarScopeRuntime, Value: "runtime", Shadowed: []string{VarScopeGlobal}},
+		{Name: "token", Scope: VarScopeGlobal, ScopeID: 1, Value: "******", Secret: true},
+	}
+	if !reflect.DeepEqual(result.VariableTrace, want) {
+		t.Errorf("trace =\n%+v\nwant\n%+v", result.VariableTrace, want)
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// VariableMode selects how a flow run sees persisted variables (environment,
+// collection, workspace) that change while it is running.
+type VariableMode string
+
+const (
+	// VariableModeLive reads the latest stored values for every request and
+	// script, so writes made by other runs are visible immediately (default).
+	VariableModeLive VariableMode = "live"
+	// VariableModeSnapshot pins each scope at its first use for the rest of the
+	// run. The run still sees and persists its own writes, but not other runs'.
+	VariableModeSnapshot VariableMode = "snapshot"
+)
+
+func (m VariableMode) valid() bool {
+	return m == "" || m == VariableModeLive || m == VariableModeSnapshot
+}
+
+// variableWriteMu serialises the read-merge-write of persisted variable scopes,
+// so concurrent runs writing different keys don't overwrite each other.
+var variableWriteMu sync.Mutex
+
+// mergeVarUpdates applies script writes to vars in place (empty value = delete)
+func mergeVarUpdates(vars, updates map[string]string) {
+	for k, v := range updates {
+		if v == "" {
+			delete(vars, k)
+		} else {
+			vars[k] = v
+		}
+	}
+}
+
+type varScopeKey struct {
+	scope string
+	id    int64
+}
+
+// variableSnapshot holds the persisted scopes a snapshot-mode run has read
+type variableSnapshot struct {
+	mu        sync.Mutex
+	envLoaded bool
+	envID     int64
+	scopes    map[varScopeKey]map[string]string
+}
+
+type variableSnapshotKey struct{}
+
+func withVariableSnapshot(ctx context.Context) context.Context {
+	snap := &variableSnapshot{scopes: make(map[varScopeKey]map[string]string)}
+	return context.WithValue(ctx, variableSnapshotKey{}, snap)
+}
+
+// variableSnapshotFrom returns the run's snapshot, or nil in live mode
+func variableSnapshotFrom(ctx context.Context) *variableSnapshot {
+	snap, _ := ctx.Value(variableSnapshotKey{}).(*variableSnapshot)
+	return snap
+}
+
+// get returns a copy of the scope, loading it on first access
+func (s *variableSnapshot) get(key varScopeKey, load func() map[string]string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vars, ok := s.scopes[key]
+	if !ok {
+		vars = load()
+		s.scopes[key] = vars
+	}
+	return cloneVars(vars)
+}
+
+// environment pins the active environment (and its values) at first access,
+// so switching environments mid-run does not affect a snapshot run either.
+func (s *variableSnapshot) environment(load func() (int64, map[string]string)) (int64, map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.envLoaded {
+		id, vars := load()
+		s.envID, s.envLoaded = id, true
+		s.scopes[varScopeKey{VarScopeEnvironment, id}] = vars
+	}
+	return s.envID, cloneVars(s.scopes[varScopeKey{VarScopeEnvironment, s.envID}])
+}
+
+// apply records the run's own writes so later reads in the run observe them
+func (s *variableSnapshot) apply(key varScopeKey, updates map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if vars, ok := s.scopes[key]; ok {
+		mergeVarUpdates(vars, updates)
+	}
+}
+
+// dryVariableWrites keeps a dry-variables run's writes to persisted scopes in
+// memory. Later reads in the same run see them; the database is never touched.
+type dryVariableWrites struct {
+	mu     sync.Mutex
+	writes map[varScopeKey]map[string]string // empty value = deleted
+}
+
+type dryVariablesKey struct{}
+
+func withDryVariables(ctx context.Context) context.Context {
+	dry := &dryVariableWrites{writes: make(map[varScopeKey]map[string]string)}
+	return context.WithValue(ctx, dryVariablesKey{}, dry)
+}
+
+// dryVariablesFrom returns the run's pending writes, or nil when writes are persisted
+func dryVariablesFrom(ctx context.Context) *dryVariableWrites {
+	dry, _ := ctx.Value(dryVariablesKey{}).(*dryVariableWrites)
+	return dry
+}
+
+func (d *dryVariableWrites) record(key varScopeKey, updates map[string]string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w, ok := d.writes[key]
+	if !ok {
+		w = make(map[string]string)
+		d.writes[key] = w
+	}
+	for k, v := range updates {
+		w[k] = v
+	}
+}
+
+// overlay applies the run's writes to vars in place; a nil receiver is a no-op
+func (d *dryVariableWrites) overlay(key varScopeKey, vars map[string]string) map[string]string {
+	if d == nil {
+		return vars
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	mergeVarUpdates(vars, d.writes[key])
+	return vars
+}
+
+// VariableRestore selects when a flow run rolls back the writes it made to
+// persisted variables (environment, collection, workspace).
+type VariableRestore string
+
+const (
+	// VariableRestoreNever keeps the run's writes (default)
+	VariableRestoreNever VariableRestore = "never"
+	// VariableRestoreAlways rolls the writes back once the run ends
+	VariableRestoreAlways VariableRestore = "always"
+	// VariableRestoreOnFailure rolls the writes back only when the run fails or
+	// is cancelled, so a broken run doesn't leave half-updated tokens behind
+	VariableRestoreOnFailure VariableRestore = "onFailure"
+)
+
+func (r VariableRestore) valid() bool {
+	return r == "" || r == VariableRestoreNever || r == VariableRestoreAlways || r == VariableRestoreOnFailure
+}
+
+// variableJournal snapshots the stored value of each persisted variable just
+// before the run first writes it, so the run's writes can be rolled back key by
+// key without clobbering keys only other runs touched.
+type variableJournal struct {
+	mu        sync.Mutex
+	originals map[varScopeKey]map[string]*string // nil = did not exist
+}
+
+func newVariableJournal() *variableJournal {
+	return &variableJournal{originals: make(map[varScopeKey]map[string]*string)}
+}
+
+type variableJournalKey struct{}
+
+func withVariableJournal(ctx context.Context, j *variableJournal) context.Context {
+	return context.WithValue(ctx, variableJournalKey{}, j)
+}
+
+// variableJournalFrom returns the run's journal, or nil when writes are kept
+func variableJournalFrom(ctx context.Context) *variableJournal {
+	j, _ := ctx.Value(variableJournalKey{}).(*variableJournal)
+	return j
+}
+
+// record keeps the stored values of the keys about to be written; stored is the
+// scope as currently saved. Only a key's first write is recorded.
+func (j *variableJournal) record(key varScopeKey, stored, updates map[string]string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	originals, ok := j.originals[key]
+	if !ok {
+		originals = make(map[string]*string)
+		j.originals[key] = originals
+	}
+	for k := range updates {
+		if _, seen := originals[k]; seen {
+			continue
+		}
+		if v, existed := stored[k]; existed {
+			originals[k] = &v
+		} else {
+			originals[k] = nil
+		}
+	}
+}
+
+// scopes returns the journaled scopes in a stable order
+func (j *variableJournal) scopes() []varScopeKey {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	keys := make([]varScopeKey, 0, len(j.originals))
+	for key := range j.originals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].scope != keys[b].scope {
+			return keys[a].scope < keys[b].scope
+		}
+		return keys[a].id < keys[b].id
+	})
+	return keys
+}
+
+// rollback puts the journaled keys of a scope back to their original values in
+// place (absent originals are deleted)
+func (j *variableJournal) rollback(key varScopeKey, vars map[string]string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for k, v := range j.originals[key] {
+		if v == nil {
+			delete(vars, k)
+		} else {
+			vars[k] = *v
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+// runVariableModeFlow runs a two-step flow while another writer changes the
+// environment between the steps, and returns the query strings step 2 sent.
+func runVariableModeFlow(t *testing.T, mode VariableMode) (token, mine string) {
+	t.Helper()
+	ctx := context.Background()
+	q := testutil.SetupTestDB(t)
+
+	env, err := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "dev",
+		WorkspaceID: 1,
+		Variables:   sql.NullString{String: `{"token":"old"}`, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.ActivateEnvironment(ctx, env.ID)
+
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/first" {
+			// Another run persists a new token while this run is in flight
+			q.UpdateEnvironmentVariables(ctx, repository.UpdateEnvironmentVariablesParams{
+				ID:        env.ID,
+				Variables: sql.NullString{String: `{"token":"new"}`, Valid: true},
+			})
+		} else {
+			token, mine = r.URL.Query().Get("token"), r.URL.Query().Get("mine")
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{
+			Name: "first", Method: "GET", Url: ts.URL + "/first",
+			PostScript: sql.NullString{String: `pm.environment.set("mine", "m1");`, Valid: true},
+		},
+		{Name: "second", Method: "GET", Url: ts.URL + "/second?token={{token}}&mine={{mine}}"},
+	})
+
+	result, err := fr.RunWithOptions(ctx, flowID, &RunOptions{VariableMode: mode}, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("run failed: %s", result.Error)
+	}
+	return token, mine
+}
+
+func TestVariableMode_LiveSeesConcurrentWrites(t *testing.T) {
+	token, mine := runVariableModeFlow(t, VariableModeLive)
+	if token != "new" {
+		t.Errorf("live run should see the other run's token, got %q", token)
+	}
+	if mine != "m1" {
+		t.Errorf("live run should see its own write, got %q", mine)
+	}
+}
+
+func TestVariableMode_SnapshotIgnoresConcurrentWrites(t *testing.T) {
+	token, mine := runVariableModeFlow(t, VariableModeSnapshot)
+	if token != "old" {
+		t.Errorf("snapshot run should keep the token it started with, got %q", token)
+	}
+	if mine != "m1" {
+		t.Errorf("snapshot run should see its own write, got %q", mine)
+	}
+}
+
+func TestVariableMode_Invalid(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	flowID := createFlowWithSteps(t, q, nil)
+
+	_, err := fr.RunWithOptions(context.Background(), flowID, &RunOptions{VariableMode: "cached"}, nil)
+	if !errors.Is(err, ErrInvalidRunOptions) {
+		t.Errorf("expected ErrInvalidRunOptions, got %v", err)
+	}
+}
+
+func TestPersistVariables_MergesIntoLatest(t *testing.T) {
+	ctx := context.Background()
+	q := testutil.SetupTestDB(t)
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+
+	env, err := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "dev",
+		WorkspaceID: 1,
+		Variables:   sql.NullString{String: `{"a":"1","b":"2"}`, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent scripts each write their own key; none may be lost
+	var wg sync.WaitGroup
+	for _, k := range []string{"x", "y", "z"} {
+		wg.Add(1)
+		go func(k string) {
+			defer wg.Done()
+			fr.persistEnvironmentVariables(ctx, env.ID, map[string]string{k: k})
+		}(k)
+	}
+	wg.Wait()
+	fr.persistEnvironmentVariables(ctx, env.ID, map[string]string{"b": ""})
+
+	got, err := q.GetEnvironment(ctx, env.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vars map[string]string
+	json.Unmarshal([]byte(got.Variables.String), &vars)
+	want := map[string]string{"a": "1", "x": "x", "y": "y", "z": "z"}
+	if len(vars) != len(want) {
+		t.Fatalf("vars = %v, want %v", vars, want)
+	}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("%s = %q, want %q", k, vars[k], v)
+		}
+	}
+}
+
+func TestDryVariables_WritesStayInRun(t *testing.T) {
+	ctx := context.Background()
+	q := testutil.SetupTestDB(t)
+
+	env, err := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "dev",
+		WorkspaceID: 1,
+		Variables:   sql.NullString{String: `{"token":"real"}`, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.ActivateEnvironment(ctx, env.ID)
+
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/second" {
+			query = r.URL.RawQuery
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		{
+			Name: "first", Method: "GET", Url: ts.URL + "/first",
+			PostScript: sql.NullString{String: `pm.environment.set("token", "dry"); pm.globals.set("g", "1");`, Valid: true},
+		},
+		{
+			Name: "second", Method: "GET", Url: ts.URL + "/second?token={{token}}&g={{g}}",
+			PostScript: sql.NullString{String: `pm.variables.set("seen", pm.globals.get("g"));`, Valid: true},
+		},
+	})
+
+	result, err := fr.RunWithOptions(ctx, flowID, &RunOptions{DryVariables: true}, nil)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("run failed: %s", result.Error)
+	}
+	if query != "token=dry&g=1" {
+		t.Errorf("later steps should see dry writes, got query %q", query)
+	}
+	if got := result.Steps[1].ExtractedVars["seen"]; got != "1" {
+		t.Errorf("pm.globals.get should see the dry write, got %q", got)
+	}
+
+	stored, _ := q.GetEnvironment(ctx, env.ID)
+	if stored.Variables.String != `{"token":"real"}` {
+		t.Errorf("environment was persisted: %s", stored.Variables.String)
+	}
+	if ws, _ := q.GetWorkspaceVariables(ctx, 1); ws.Valid && ws.String != "" && ws.String != "{}" {
+		t.Errorf("workspace variables were persisted: %s", ws.String)
+	}
+
+	dry := 0
+	for _, c := range result.VariableChanges {
+		if c.Scope != VarScopeRuntime {
+			if !c.Dry {
+				t.Errorf("change %+v should be marked dry", c)
+			}
+			dry++
+		}
+	}
+	if dry != 2 {
+		t.Errorf("expected 2 dry changes, got %d: %+v", dry, result.VariableChanges)
+	}
+}
+
+func TestDryVariables_NoDatabaseWrites(t *testing.T) {
+	ctx := context.Background()
+	q := testutil.SetupTestDB(t)
+
+	env, err := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "dev",
+		WorkspaceID: 1,
+		Variables:   sql.NullString{String: `{"token":"real"}`, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.ActivateEnvironment(ctx, env.ID)
+	q.UpdateWorkspaceVariables(ctx, repository.UpdateWorkspaceVariablesParams{
+		ID:        1,
+		Variables: sql.NullString{String: `{"region":"us"}`, Valid: true},
+	})
+	stored := func() (string, string) {
+		e, _ := q.GetEnvironment(ctx, env.ID)
+		ws, _ := q.GetWorkspaceVariables(ctx, 1)
+		return e.Variables.String, ws.String
+	}
+	envBefore, wsBefore := stored()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	flowID := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{{
+		Name: "step", Method: "GET", Url: ts.URL,
+		PreScript:  sql.NullString{String: `pm.environment.set("pre", "x"); pm.globals.set("region", "eu");`, Valid: true},
+		PostScript: sql.NullString{String: `pm.environment.set("token", "dry"); pm.globals.set("g", "1");`, Valid: true},
+	}})
+
+	result, err := fr.RunWithOptions(ctx, flowID, &RunOptions{DryVariables: true}, nil)
+	if err != nil || !result.Success {
+		t.Fatalf("run: %v %s", err, result.Error)
+	}
+	if envAfter, wsAfter := stored(); envAfter != envBefore || wsAfter != wsBefore {
+		t.Errorf("dry run wrote to the DB: environment %s -> %s, workspace %s -> %s", envBefore, envAfter, wsBefore, wsAfter)
+	}
+
+	// The same flow without dryVariables does persist, so the check above is meaningful
+	if _, err := fr.Run(ctx, flowID, nil); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if envAfter, wsAfter := stored(); envAfter == envBefore || wsAfter == wsBefore {
+		t.Errorf("regular run should persist: environment %s, workspace %s", envAfter, wsAfter)
+	}
+}
+
+func TestRestoreVariables(t *testing.T) {
+	ctx := context.Background()
+	q := testutil.SetupTestDB(t)
+
+	env, err := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "dev",
+		WorkspaceID: 1,
+		Variables:   sql.NullString{String: `{"token":"real"}`, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.ActivateEnvironment(ctx, env.ID)
+	setEnv := func(vars string) {
+		q.UpdateEnvironmentVariables(ctx, repository.UpdateEnvironmentVariablesParams{
+			ID:        env.ID,
+			Variables: sql.NullString{String: vars, Valid: true},
+		})
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/other" {
+			// Another run persists its own key while this run is in flight
+			setEnv(`{"counter":"1","other":"o","token":"half"}`)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	write := repository.CreateFlowStepParams{
+		Name: "login", Method: "GET", Url: ts.URL,
+		PostScript: sql.NullString{String: `pm.environment.set("token", "half"); pm.environment.set("counter", "1"); pm.globals.set("g", "1");`, Valid: true},
+	}
+	passing := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{write})
+	failing := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{write, {Name: "no url", Method: "GET"}})
+
+	run := func(flowID int64, restore VariableRestore) *FlowResult {
+		t.Helper()
+		setEnv(`{"token":"real"}`)
+		q.UpdateWorkspaceVariables(ctx, repository.UpdateWorkspaceVariablesParams{ID: 1, Variables: sql.NullString{String: `{}`, Valid: true}})
+		result, err := fr.RunWithOptions(ctx, flowID, &RunOptions{RestoreVariables: restore}, nil)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return result
+	}
+	envVars := func() string {
+		stored, _ := q.GetEnvironment(ctx, env.ID)
+		return stored.Variables.String
+	}
+
+	// onFailure keeps a successful run's writes
+	result := run(passing, VariableRestoreOnFailure)
+	if got := envVars(); got != `{"counter":"1","token":"half"}` || len(result.RestoredVariables) != 0 {
+		t.Errorf("successful run: env %s, restored %+v", got, result.RestoredVariables)
+	}
+
+	// ...and rolls back a failed one, including globals
+	result = run(failing, VariableRestoreOnFailure)
+	if result.Success {
+		t.Fatal("flow should fail")
+	}
+	if got := envVars(); got != `{"token":"real"}` {
+		t.Errorf("failed run: env %s", got)
+	}
+	if g := vr.loadWorkspaceVars(ctx, 1); len(g) != 0 {
+		t.Errorf("failed run: globals %v", g)
+	}
+	if len(result.RestoredVariables) != 3 {
+		t.Errorf("restored = %+v", result.RestoredVariables)
+	}
+
+	// always rolls back even a successful run, but leaves the keys another
+	// run wrote meanwhile
+	concurrent := createFlowWithSteps(t, q, []repository.CreateFlowStepParams{
+		write,
+		{Name: "other run", Method: "GET", Url: ts.URL + "/other"},
+	})
+	result = run(concurrent, VariableRestoreAlways)
+	if !result.Success {
+		t.Fatalf("run failed: %s", result.Error)
+	}
+	if got := envVars(); got != `{"other":"o","token":"real"}` {
+		t.Errorf("always: env %s", got)
+	}
+
+	if _, err := fr.RunWithOptions(ctx, passing, &RunOptions{RestoreVariables: "sometimes"}, nil); !errors.Is(err, ErrInvalidRunOptions) {
+		t.Errorf("unknown restore mode: err = %v", err)
+	}
+}
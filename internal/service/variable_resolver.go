@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"regexp"
 	"strings"
+	"time"
 
 	"relay/internal/middleware"
 	"relay/internal/repository"
@@ -12,33 +14,61 @@ import (
 
 type VariableResolver struct {
 	queries *repository.Queries
+	now     func() time.Time // clock for built-in $ variables
+	keys    *KeyRing
 }
 
 func NewVariableResolver(queries *repository.Queries) *VariableResolver {
-	return &VariableResolver{queries: queries}
+	return &VariableResolver{queries: queries, now: time.Now}
+}
+
+// SetKeyRing sets the workspace keys sealed secret variables are opened with
+func (vr *VariableResolver) SetKeyRing(keys *KeyRing) {
+	vr.keys = keys
 }
 
 var variablePattern = regexp.MustCompile(`\{\{([^}]+)\}\}`)
 
 // Resolve replaces {{variable}} patterns with values from all variable layers.
-// Priority (highest first): runtimeVars → environment → collection → workspace
+// Priority (highest first): runtimeVars → collection environment → workspace
+// environment → collection → workspace. Environments include their parents.
+// {{snippet:name}} references are expanded first.
+// It fails with ErrEncryptionLocked when input needs a secret that cannot be
+// decrypted.
 func (vr *VariableResolver) Resolve(ctx context.Context, input string, runtimeVars map[string]string, collectionID ...int64) (string, error) {
-	allVars := vr.buildAllVars(ctx, runtimeVars, collectionID...)
-	return vr.ResolveWithVars(input, allVars), nil
+	resolved := vr.resolveFunc(ctx, runtimeVars, collectionID...)(input)
+	return resolved, lockedSecretError(resolved)
 }
 
 // ResolveWithVars replaces {{variable}} patterns with provided values
 func (vr *VariableResolver) ResolveWithVars(input string, vars map[string]string) string {
+	return vr.resolveWithVars(input, vars, builtinEnv{now: vr.now})
+}
+
+// resolveWithVars is ResolveWithVars with built-in variables read from env
+func (vr *VariableResolver) resolveWithVars(input string, vars map[string]string, env builtinEnv) string {
 	return variablePattern.ReplaceAllStringFunc(input, func(match string) string {
 		// Extract variable name from {{name}}
 		varName := strings.TrimSpace(match[2 : len(match)-2])
-		if val, ok := vars[varName]; ok {
+		if val, ok := vr.lookup(varName, vars, env); ok {
 			return val
 		}
 		return match // Keep original if not found
 	})
 }
 
+// lookup returns the value of one variable: a user variable, else a built-in
+// or data factory value
+func (vr *VariableResolver) lookup(name string, vars map[string]string, env builtinEnv) (string, bool) {
+	if val, ok := vars[name]; ok {
+		return val, true
+	}
+	if strings.HasPrefix(name, "$") {
+		return vr.resolveBuiltin(name, env)
+	}
+	return env.data.resolve(name)
+}
+
 // HeaderValue represents a header with enabled flag (new format)
 type HeaderValue struct {
 	Value   string `json:"value"`
@@ -49,17 +79,17 @@ type HeaderValue struct {
 // Supports both legacy format { "key": "value" } and new format { "key": { "value": "...", "enabled": true } }
 func (vr *VariableResolver) ResolveHeaders(ctx context.Context, headersJSON string, runtimeVars map[string]string, collectionID ...int64) (map[string]string, error) {
 	resolved := make(map[string]string)
-	allVars := vr.buildAllVars(ctx, runtimeVars, collectionID...)
+	resolve := vr.resolveFunc(ctx, runtimeVars, collectionID...)
 
 	// Try new format first: { "key": { "value": "...", "enabled": true } }
 	var headersNew map[string]HeaderValue
 	if err := json.Unmarshal([]byte(headersJSON), &headersNew); err == nil {
 		for key, hv := range headersNew {
 			if hv.Enabled {
-				resolved[vr.ResolveWithVars(key, allVars)] = vr.ResolveWithVars(hv.Value, allVars)
+				resolved[resolve(key)] = resolve(hv.Value)
 			}
 		}
-		return resolved, nil
+		return resolved, lockedHeaderError(resolved)
 	}
 
 	// Fall back to legacy format: { "key": "value" }
@@ -69,48 +99,174 @@ func (vr *VariableResolver) ResolveHeaders(ctx context.Context, headersJSON stri
 	}
 
 	for key, value := range headersLegacy {
-		resolved[vr.ResolveWithVars(key, allVars)] = vr.ResolveWithVars(value, allVars)
+		resolved[resolve(key)] = resolve(value)
 	}
 
-	return resolved, nil
+	return resolved, lockedHeaderError(resolved)
+}
+
+// lockedHeaderError reports a resolved header that needed a locked secret
+func lockedHeaderError(headers map[string]string) error {
+	for _, v := range headers {
+		if err := lockedSecretError(v); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // buildAllVars merges all variable layers with proper priority.
-// Priority (highest first): runtimeVars → environment → collection → workspace
+// Priority (highest first): runtimeVars → collection environment → workspace
+// environment → collection → workspace
 func (vr *VariableResolver) buildAllVars(ctx context.Context, runtimeVars map[string]string, collectionID ...int64) map[string]string {
+	var colID int64
+	if len(collectionID) > 0 {
+		colID = collectionID[0]
+	}
 	allVars := make(map[string]string)
+	for _, layer := range vr.variableLayers(ctx, runtimeVars, colID) {
+		for k, v := range layer.vars {
+			allVars[k] = v
+		}
+	}
+	return allVars
+}
+
+// varLayer is the variables of one scope
+type varLayer struct {
+	scope string
+	id    int64 // workspace, collection or environment ID; 0 for runtime
+	vars  map[string]string
+}
 
-	// Lowest priority: workspace (global) variables
-	wsVars := vr.getWorkspaceVars(ctx)
-	for k, v := range wsVars {
-		allVars[k] = v
+// variableLayers returns the scopes a request in collectionID sees, lowest
+// priority first
+func (vr *VariableResolver) variableLayers(ctx context.Context, runtimeVars map[string]string, collectionID int64) []varLayer {
+	layers := []varLayer{{VarScopeGlobal, middleware.GetWorkspaceID(ctx), vr.getWorkspaceVars(ctx)}}
+	if collectionID > 0 {
+		layers = append(layers, varLayer{VarScopeCollection, collectionID, vr.getCollectionVars(ctx, collectionID)})
+	}
+	envID, envVars := vr.getActiveEnvironment(ctx)
+	layers = append(layers, varLayer{VarScopeEnvironment, envID, envVars})
+	if colEnvID, colEnvVars, ok := vr.getCollectionEnvironment(ctx, collectionID); ok {
+		layers = append(layers, varLayer{VarScopeEnvironment, colEnvID, colEnvVars})
 	}
+	return append(layers, varLayer{VarScopeRuntime, 0, runtimeVars})
+}
 
-	// Collection variables
-	if len(collectionID) > 0 && collectionID[0] > 0 {
-		colVars := vr.getCollectionVars(ctx, collectionID[0])
-		for k, v := range colVars {
-			allVars[k] = v
-		}
+func (vr *VariableResolver) getWorkspaceVars(ctx context.Context) map[string]string {
+	wsID := middleware.GetWorkspaceID(ctx)
+	key := varScopeKey{VarScopeGlobal, wsID}
+	var vars map[string]string
+	if snap := variableSnapshotFrom(ctx); snap != nil {
+		vars = snap.get(key, func() map[string]string {
+			return vr.loadWorkspaceVars(ctx, wsID)
+		})
+	} else {
+		vars = vr.loadWorkspaceVars(ctx, wsID)
 	}
+	return dryVariablesFrom(ctx).overlay(key, vr.keys.openVars(ctx, wsID, vars))
+}
 
-	// Environment variables
-	envVars, _ := vr.getActiveEnvironmentVars(ctx)
-	for k, v := range envVars {
-		allVars[k] = v
+func (vr *VariableResolver) getCollectionVars(ctx context.Context, collectionID int64) map[string]string {
+	key := varScopeKey{VarScopeCollection, collectionID}
+	var vars map[string]string
+	if snap := variableSnapshotFrom(ctx); snap != nil {
+		vars = snap.get(key, func() map[string]string {
+			return vr.loadCollectionVars(ctx, collectionID)
+		})
+	} else {
+		vars = vr.loadCollectionVars(ctx, collectionID)
 	}
+	return dryVariablesFrom(ctx).overlay(key, vr.keys.openVars(ctx, middleware.GetWorkspaceID(ctx), vars))
+}
 
-	// Highest priority: runtime variables
-	for k, v := range runtimeVars {
-		allVars[k] = v
+// getActiveEnvironment returns the active environment ID (0 if none) and its variables
+func (vr *VariableResolver) getActiveEnvironment(ctx context.Context) (int64, map[string]string) {
+	var envID int64
+	var vars map[string]string
+	if snap := variableSnapshotFrom(ctx); snap != nil {
+		envID, vars = snap.environment(func() (int64, map[string]string) {
+			return vr.loadActiveEnvironment(ctx)
+		})
+	} else {
+		envID, vars = vr.loadActiveEnvironment(ctx)
 	}
+	return envID, dryVariablesFrom(ctx).overlay(varScopeKey{VarScopeEnvironment, envID}, vars)
+}
 
-	return allVars
+// getEnvironmentFor returns the environment a request in collectionID sees:
+// the active environment of the collection (or its nearest ancestor) layered
+// over the workspace's. The returned ID is where script writes go, so the
+// collection environment takes them when there is one.
+func (vr *VariableResolver) getEnvironmentFor(ctx context.Context, collectionID int64) (int64, map[string]string) {
+	envID, vars := vr.getActiveEnvironment(ctx)
+	colEnvID, colVars, ok := vr.getCollectionEnvironment(ctx, collectionID)
+	if !ok {
+		return envID, vars
+	}
+	for k, v := range colVars {
+		vars[k] = v
+	}
+	return colEnvID, vars
 }
 
-func (vr *VariableResolver) getWorkspaceVars(ctx context.Context) map[string]string {
+// getCollectionEnvironment returns the active environment of the collection
+// or its nearest ancestor, with its parents' variables merged in
+func (vr *VariableResolver) getCollectionEnvironment(ctx context.Context, collectionID int64) (int64, map[string]string, bool) {
+	if collectionID <= 0 {
+		return 0, nil, false
+	}
+	env, ok := vr.findCollectionEnvironment(ctx, collectionID)
+	if !ok {
+		return 0, nil, false
+	}
+
+	key := varScopeKey{VarScopeEnvironment, env.ID}
+	load := func() map[string]string {
+		return MergeEnvironmentVars(EnvironmentChain(ctx, vr.queries, env))
+	}
+	var colVars map[string]string
+	if snap := variableSnapshotFrom(ctx); snap != nil {
+		colVars = snap.get(key, load)
+	} else {
+		colVars = load()
+	}
+	return env.ID, dryVariablesFrom(ctx).overlay(key, colVars), true
+}
+
+// findCollectionEnvironment returns the active environment scoped to the
+// collection or its nearest ancestor
+func (vr *VariableResolver) findCollectionEnvironment(ctx context.Context, colID int64) (repository.Environment, bool) {
+	for depth := 0; colID > 0 && depth < 64; depth++ {
+		env, err := vr.queries.GetActiveCollectionEnvironment(ctx, sql.NullInt64{Int64: colID, Valid: true})
+		if err == nil {
+			return env, true
+		}
+		c, err := vr.queries.GetCollection(ctx, colID)
+		if err != nil || !c.ParentID.Valid {
+			break
+		}
+		colID = c.ParentID.Int64
+	}
+	return repository.Environment{}, false
+}
+
+// sealSecrets seals the plaintext secret values of a workspace or collection
+// scope before they are stored
+func (vr *VariableResolver) sealSecrets(ctx context.Context, key varScopeKey, vars map[string]string) error {
+	if vr.keys == nil {
+		return nil
+	}
+	var colID int64
+	if key.scope == VarScopeCollection {
+		colID = key.id
+	}
+	return vr.keys.SealVariables(ctx, middleware.GetWorkspaceID(ctx), vars, vr.secretNames(ctx, colID)[key])
+}
+
+func (vr *VariableResolver) loadWorkspaceVars(ctx context.Context, wsID int64) map[string]string {
 	vars := make(map[string]string)
-	wsID := middleware.GetWorkspaceID(ctx)
 	wsVars, err := vr.queries.GetWorkspaceVariables(ctx, wsID)
 	if err == nil && wsVars.Valid && wsVars.String != "" {
 		json.Unmarshal([]byte(wsVars.String), &vars)
@@ -118,7 +274,7 @@ func (vr *VariableResolver) getWorkspaceVars(ctx context.Context) map[string]str
 	return vars
 }
 
-func (vr *VariableResolver) getCollectionVars(ctx context.Context, collectionID int64) map[string]string {
+func (vr *VariableResolver) loadCollectionVars(ctx context.Context, collectionID int64) map[string]string {
 	vars := make(map[string]string)
 	colVars, err := vr.queries.GetCollectionVariables(ctx, collectionID)
 	if err == nil && colVars.Valid && colVars.String != "" {
@@ -127,20 +283,22 @@ func (vr *VariableResolver) getCollectionVars(ctx context.Context, collectionID
 	return vars
 }
 
-func (vr *VariableResolver) getActiveEnvironmentVars(ctx context.Context) (map[string]string, error) {
-	vars := make(map[string]string)
-
+func (vr *VariableResolver) loadActiveEnvironment(ctx context.Context) (int64, map[string]string) {
 	wsID := middleware.GetWorkspaceID(ctx)
 	env, err := vr.queries.GetActiveEnvironment(ctx, wsID)
 	if err != nil {
-		return vars, nil // No active environment is OK
+		return 0, make(map[string]string) // No active environment is OK
 	}
 
-	if env.Variables.Valid {
-		if err := json.Unmarshal([]byte(env.Variables.String), &vars); err != nil {
-			return vars, nil
-		}
-	}
+	// Inherited variables are merged in; script writes still go to env itself
+	return env.ID, MergeEnvironmentVars(EnvironmentChain(ctx, vr.queries, env))
+}
 
-	return vars, nil
+func (vr *VariableResolver) loadEnvironmentVars(ctx context.Context, envID int64) map[string]string {
+	vars := make(map[string]string)
+	env, err := vr.queries.GetEnvironment(ctx, envID)
+	if err == nil && env.Variables.Valid {
+		json.Unmarshal([]byte(env.Variables.String), &vars)
+	}
+	return vars
 }
@@ -344,3 +344,159 @@ func TestResolveHeaders_VarSubstitution(t *testing.T) {
 		t.Errorf("Authorization: got %q, want %q", got["Authorization"], want)
 	}
 }
+
+func TestResolve_EnvironmentInheritance(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	base, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "base",
+		Variables:   sql.NullString{String: `{"baseUrl":"https://api.example.com","region":"us","token":"base-token"}`, Valid: true},
+		WorkspaceID: 1,
+	})
+	staging, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "staging",
+		Variables:   sql.NullString{String: `{"baseUrl":"https://staging.example.com"}`, Valid: true},
+		WorkspaceID: 1,
+		ParentID:    sql.NullInt64{Int64: base.ID, Valid: true},
+	})
+	eu, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "staging-eu",
+		Variables:   sql.NullString{String: `{"region":"eu"}`, Valid: true},
+		WorkspaceID: 1,
+		ParentID:    sql.NullInt64{Int64: staging.ID, Valid: true},
+	})
+	q.ActivateEnvironment(ctx, eu.ID)
+
+	vr := NewVariableResolver(q)
+	got, _ := vr.Resolve(ctx, "{{baseUrl}}/{{region}}?t={{token}}", nil)
+	if want := "https://staging.example.com/eu?t=base-token"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// A cycle written behind the API's back must not hang resolution
+	q.UpdateEnvironment(ctx, repository.UpdateEnvironmentParams{
+		ID:        base.ID,
+		Name:      base.Name,
+		Variables: base.Variables,
+		ParentID:  sql.NullInt64{Int64: eu.ID, Valid: true},
+	})
+	got, _ = vr.Resolve(ctx, "{{baseUrl}}/{{region}}", nil)
+	if want := "https://staging.example.com/eu"; got != want {
+		t.Errorf("with cycle: got %q, want %q", got, want)
+	}
+}
+
+func TestCheckEnvironmentParent(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	a, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "a", WorkspaceID: 1})
+	b, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "b", WorkspaceID: 1, ParentID: sql.NullInt64{Int64: a.ID, Valid: true}})
+	ws2, _ := q.CreateWorkspace(ctx, "other")
+	other, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "other", WorkspaceID: ws2.ID})
+
+	cases := []struct {
+		name        string
+		env, parent int64
+		wantErr     bool
+	}{
+		{"new child", 0, b.ID, false},
+		{"self", a.ID, a.ID, true},
+		{"cycle", a.ID, b.ID, true},
+		{"other workspace", 0, other.ID, true},
+		{"missing", 0, 9999, true},
+	}
+	for _, tc := range cases {
+		err := CheckEnvironmentParent(ctx, q, 1, tc.env, tc.parent)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+	}
+}
+
+func TestResolve_CollectionEnvironmentPrecedence(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	parent, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Payments", WorkspaceID: 1})
+	child, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Refunds", ParentID: sql.NullInt64{Int64: parent.ID, Valid: true}, WorkspaceID: 1})
+	other, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Users", WorkspaceID: 1})
+	q.UpdateCollectionVariables(ctx, repository.UpdateCollectionVariablesParams{
+		ID:        child.ID,
+		Variables: sql.NullString{String: `{"host":"collection-var","path":"/refunds"}`, Valid: true},
+	})
+
+	wsEnv, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:        "staging",
+		Variables:   sql.NullString{String: `{"host":"staging-host","token":"ws-token"}`, Valid: true},
+		WorkspaceID: 1,
+	})
+	q.ActivateEnvironment(ctx, wsEnv.ID)
+	colEnv, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:         "payments-sandbox",
+		Variables:    sql.NullString{String: `{"host":"sandbox-host"}`, Valid: true},
+		WorkspaceID:  1,
+		CollectionID: sql.NullInt64{Int64: parent.ID, Valid: true},
+	})
+	q.ActivateEnvironment(ctx, colEnv.ID)
+
+	vr := NewVariableResolver(q)
+	input := "{{host}}{{path}}?t={{token}}"
+	cases := []struct {
+		name         string
+		collectionID int64
+		want         string
+	}{
+		// The parent's environment applies to the subcollection and beats
+		// the workspace environment and collection variables
+		{"subcollection", child.ID, "sandbox-host/refunds?t=ws-token"},
+		{"other collection", other.ID, "staging-host{{path}}?t=ws-token"},
+		{"no collection", 0, "staging-host{{path}}?t=ws-token"},
+	}
+	for _, tc := range cases {
+		got, _ := vr.Resolve(ctx, input, nil, tc.collectionID)
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	// The workspace environment stays active alongside the collection one
+	if env, err := q.GetActiveEnvironment(ctx, 1); err != nil || env.ID != wsEnv.ID {
+		t.Errorf("active workspace environment = %+v, %v", env, err)
+	}
+
+	q.DeactivateEnvironment(ctx, colEnv.ID)
+	if got, _ := vr.Resolve(ctx, "{{host}}", nil, child.ID); got != "staging-host" {
+		t.Errorf("after deactivate: got %q", got)
+	}
+}
+
+func TestScript_EnvironmentWritesGoToCollectionEnvironment(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+
+	col, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Payments", WorkspaceID: 1})
+	wsEnv, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{Name: "staging", Variables: sql.NullString{String: `{}`, Valid: true}, WorkspaceID: 1})
+	q.ActivateEnvironment(ctx, wsEnv.ID)
+	colEnv, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name:         "sandbox",
+		Variables:    sql.NullString{String: `{"token":"old"}`, Valid: true},
+		WorkspaceID:  1,
+		CollectionID: sql.NullInt64{Int64: col.ID, Valid: true},
+	})
+	q.ActivateEnvironment(ctx, colEnv.ID)
+
+	vr := NewVariableResolver(q)
+	fr := NewFlowRunner(q, NewRequestExecutor(q, vr, nil), vr)
+	fr.ExecuteScriptForRequest(ctx, `pm.environment.set("token", pm.environment.get("token") + "-new")`, map[string]string{}, col.ID)
+
+	got, _ := q.GetEnvironment(ctx, colEnv.ID)
+	if got.Variables.String != `{"token":"old-new"}` {
+		t.Errorf("collection environment = %s", got.Variables.String)
+	}
+	ws, _ := q.GetEnvironment(ctx, wsEnv.ID)
+	if ws.Variables.String != `{}` {
+		t.Errorf("workspace environment = %s", ws.Variables.String)
+	}
+}
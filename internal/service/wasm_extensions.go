@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Wasm extension exports callable from DSL scripts. Each takes (ptr, len) of a
+// JSON input written into memory obtained from alloc(len) and returns
+// (ptr << 32) | len of its JSON output.
+const (
+	WasmExportAssert    = "assert"    // {"pass": bool, "message": string}
+	WasmExportTransform = "transform" // {"body": string} or {"error": string}
+)
+
+const (
+	MaxWasmModuleSize   = 10 * 1024 * 1024
+	wasmMemoryPages     = 256 // 16 MiB
+	wasmCallTimeout     = 2 * time.Second
+	maxWasmOutput       = 10 * 1024 * 1024
+	maxCompiledModules  = 32
+	wasiModuleName      = "wasi_snapshot_preview1"
+	wasmAllocExportName = "alloc"
+)
+
+var wasmExtensionNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ValidWasmExtensionName reports whether name can be used to register an extension
+func ValidWasmExtensionName(name string) bool {
+	return wasmExtensionNameRe.MatchString(name)
+}
+
+// WasmAssertInput is passed to an extension's assert export
+type WasmAssertInput struct {
+	Status     int               `json:"status"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	DurationMs int64             `json:"durationMs"`
+	Args       interface{}       `json:"args"` // the assertion's "value"
+}
+
+type WasmAssertOutput struct {
+	Pass    bool   `json:"pass"`
+	Message string `json:"message"`
+}
+
+// WasmTransformInput is passed to an extension's transform export
+type WasmTransformInput struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	Args    interface{}       `json:"args"` // the variable operation's "value"
+}
+
+type WasmTransformOutput struct {
+	Body  string `json:"body"`
+	Error string `json:"error"`
+}
+
+func newWasmRuntime(ctx context.Context) wazero.Runtime {
+	cfg := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(wasmMemoryPages).
+		WithCloseOnContextDone(true)
+	r := wazero.NewRuntimeWithConfig(ctx, cfg)
+	// WASI without preopened dirs, env or network: lets toolchains that
+	// target WASI load while keeping modules sandboxed
+	wasi_snapshot_preview1.MustInstantiate(ctx, r)
+	return r
+}
+
+// InspectWasmModule compiles a module and checks it against the extension
+// ABI, returning the extension exports it implements.
+func InspectWasmModule(ctx context.Context, module []byte) ([]string, error) {
+	if len(module) > MaxWasmModuleSize {
+		return nil, fmt.Errorf("module exceeds %d bytes", MaxWasmModuleSize)
+	}
+	r := newWasmRuntime(ctx)
+	defer r.Close(ctx)
+	compiled, err := r.CompileModule(ctx, module)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wasm module: %v", err)
+	}
+	return wasmModuleExports(compiled)
+}
+
+func wasmModuleExports(compiled wazero.CompiledModule) ([]string, error) {
+	for _, fn := range compiled.ImportedFunctions() {
+		if mod, name, _ := fn.Import(); mod != wasiModuleName {
+			return nil, fmt.Errorf("module imports %s.%s; only %s is available", mod, name, wasiModuleName)
+		}
+	}
+	if len(compiled.ImportedMemories()) > 0 {
+		return nil, errors.New("module must define its own memory")
+	}
+	if _, ok := compiled.ExportedMemories()["memory"]; !ok {
+		return nil, errors.New(`module must export its memory as "memory"`)
+	}
+
+	fns := compiled.ExportedFunctions()
+	if !wasmSignature(fns[wasmAllocExportName], []api.ValueType{api.ValueTypeI32}, api.ValueTypeI32) {
+		return nil, errors.New("module must export alloc(i32) -> i32")
+	}
+	var exports []string
+	for _, name := range []string{WasmExportAssert, WasmExportTransform} {
+		fn, ok := fns[name]
+		if !ok {
+			continue
+		}
+		if !wasmSignature(fn, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, api.ValueTypeI64) {
+			return nil, fmt.Errorf("%s must have signature (i32, i32) -> i64", name)
+		}
+		exports = append(exports, name)
+	}
+	if len(exports) == 0 {
+		return nil, fmt.Errorf("module exports neither %q nor %q", WasmExportAssert, WasmExportTransform)
+	}
+	return exports, nil
+}
+
+func wasmSignature(fn api.FunctionDefinition, params []api.ValueType, result api.ValueType) bool {
+	if fn == nil {
+		return false
+	}
+	p, r := fn.ParamTypes(), fn.ResultTypes()
+	if len(p) != len(params) || len(r) != 1 || r[0] != result {
+		return false
+	}
+	for i := range p {
+		if p[i] != params[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// WasmModuleHash identifies a module's content
+func WasmModuleHash(module []byte) string {
+	sum := sha256.Sum256(module)
+	return hex.EncodeToString(sum[:])
+}
+
+// WasmExtensions runs workspace-registered wasm modules. Every call gets a
+// fresh instance with no host access beyond an empty WASI, a 16 MiB memory
+// cap and a 2 second deadline.
+type WasmExtensions struct {
+	queries *repository.Queries
+
+	once    sync.Once
+	runtime wazero.Runtime
+
+	mu       sync.Mutex
+	compiled map[string]wazero.CompiledModule // by module hash
+}
+
+func NewWasmExtensions(queries *repository.Queries) *WasmExtensions {
+	return &WasmExtensions{queries: queries, compiled: make(map[string]wazero.CompiledModule)}
+}
+
+func (w *WasmExtensions) compile(ctx context.Context, ext repository.WasmExtension) (wazero.CompiledModule, error) {
+	w.once.Do(func() { w.runtime = newWasmRuntime(context.Background()) })
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if c, ok := w.compiled[ext.Sha256]; ok {
+		return c, nil
+	}
+	if len(w.compiled) >= maxCompiledModules {
+		// Replaced modules leave stale entries behind; start over
+		for hash, c := range w.compiled {
+			c.Close(ctx)
+			delete(w.compiled, hash)
+		}
+	}
+	c, err := w.runtime.CompileModule(ctx, ext.Module)
+	if err != nil {
+		return nil, err
+	}
+	w.compiled[ext.Sha256] = c
+	return c, nil
+}
+
+// ClearCache closes every compiled module and returns how many there were;
+// modules are recompiled on their next call
+func (w *WasmExtensions) ClearCache(ctx context.Context) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := len(w.compiled)
+	for hash, c := range w.compiled {
+		c.Close(ctx)
+		delete(w.compiled, hash)
+	}
+	return n
+}
+
+// call runs export of the workspace's extension name with input as JSON and
+// decodes its JSON result into output
+func (w *WasmExtensions) call(ctx context.Context, wsID int64, name, export string, input, output interface{}) error {
+	ext, err := w.queries.GetWasmExtension(ctx, repository.GetWasmExtensionParams{WorkspaceID: wsID, Name: name})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("wasm extension %q not found", name)
+	}
+	if err != nil {
+		return err
+	}
+	if !containsString(strings.Split(ext.Exports, ","), export) {
+		return fmt.Errorf("wasm extension %q does not export %s", name, export)
+	}
+	compiled, err := w.compile(ctx, ext)
+	if err != nil {
+		return fmt.Errorf("wasm extension %q: %v", name, err)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, wasmCallTimeout)
+	defer cancel()
+	fail := func(err error) error {
+		if callCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("wasm extension %q timed out after %s", name, wasmCallTimeout)
+		}
+		return fmt.Errorf("wasm extension %q: %v", name, err)
+	}
+
+	// Anonymous instance so concurrent calls don't collide; _initialize is run
+	// for reactor-style modules and skipped when absent
+	mod, err := w.runtime.InstantiateModule(callCtx, compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return fail(err)
+	}
+	defer mod.Close(ctx)
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	res, err := mod.ExportedFunction(wasmAllocExportName).Call(callCtx, uint64(len(data)))
+	if err != nil {
+		return fail(err)
+	}
+	ptr := uint32(res[0])
+	if !mod.Memory().Write(ptr, data) {
+		return fail(errors.New("alloc returned memory out of range"))
+	}
+	res, err = mod.ExportedFunction(export).Call(callCtx, uint64(ptr), uint64(len(data)))
+	if err != nil {
+		return fail(err)
+	}
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+	if outLen > maxWasmOutput {
+		return fail(fmt.Errorf("output exceeds %d bytes", maxWasmOutput))
+	}
+	out, ok := mod.Memory().Read(outPtr, outLen)
+	if !ok {
+		return fail(errors.New("output out of memory range"))
+	}
+	if err := json.Unmarshal(out, output); err != nil {
+		return fail(fmt.Errorf("invalid output: %v", err))
+	}
+	return nil
+}
+
+// ForWorkspace binds the extensions of ctx's workspace for a script run
+func (w *WasmExtensions) ForWorkspace(ctx context.Context) *WorkspaceExtensions {
+	if w == nil {
+		return nil
+	}
+	return &WorkspaceExtensions{ext: w, ctx: ctx, wsID: middleware.GetWorkspaceID(ctx)}
+}
+
+// WorkspaceExtensions calls one workspace's wasm extensions from DSL scripts
+type WorkspaceExtensions struct {
+	ext  *WasmExtensions
+	ctx  context.Context
+	wsID int64
+}
+
+func (we *WorkspaceExtensions) Assert(name string, in WasmAssertInput) (WasmAssertOutput, error) {
+	var out WasmAssertOutput
+	if we == nil {
+		return out, errors.New("wasm extensions are not available")
+	}
+	err := we.ext.call(we.ctx, we.wsID, name, WasmExportAssert, in, &out)
+	return out, err
+}
+
+func (we *WorkspaceExtensions) Transform(name string, in WasmTransformInput) (string, error) {
+	if we == nil {
+		return "", errors.New("wasm extensions are not available")
+	}
+	var out WasmTransformOutput
+	if err := we.ext.call(we.ctx, we.wsID, name, WasmExportTransform, in, &out); err != nil {
+		return "", err
+	}
+	if out.Error != "" {
+		return "", fmt.Errorf("wasm extension %q: %s", name, out.Error)
+	}
+	return out.Body, nil
+}
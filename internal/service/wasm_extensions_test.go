@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"relay/internal/middleware"
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+// registerPassMarker stores testdata/pass_marker.wasm as a workspace extension
+func registerPassMarker(t *testing.T, q *repository.Queries, wsID int64, name string) {
+	t.Helper()
+	module, err := os.ReadFile("testdata/pass_marker.wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exports, err := InspectWasmModule(context.Background(), module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(exports, ",") != "assert,transform" {
+		t.Fatalf("exports = %v", exports)
+	}
+	if _, err := q.UpsertWasmExtension(context.Background(), repository.UpsertWasmExtensionParams{
+		WorkspaceID: wsID,
+		Name:        name,
+		Module:      module,
+		Sha256:      WasmModuleHash(module),
+		Size:        int64(len(module)),
+		Exports:     strings.Join(exports, ","),
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInspectWasmModule_Invalid(t *testing.T) {
+	ctx := context.Background()
+	cases := map[string][]byte{
+		"not wasm": []byte("hello"),
+		// Valid module with no exports
+		"no exports": {0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		// (import "env" "now" (func))
+		"host import": {
+			0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+			0x01, 0x04, 0x01, 0x60, 0x00, 0x00,
+			0x02, 0x0b, 0x01, 0x03, 'e', 'n', 'v', 0x03, 'n', 'o', 'w', 0x00, 0x00,
+		},
+	}
+	for name, module := range cases {
+		if _, err := InspectWasmModule(ctx, module); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestScriptExecutor_WasmExtensions(t *testing.T) {
+	q := testutil.SetupTestDB(t)
+	registerPassMarker(t, q, 1, "pass-marker")
+
+	ext := NewWasmExtensions(q)
+	se := NewScriptExecutor(nil)
+	run := func(ctx context.Context, body string) *ScriptResult {
+		return se.Execute(`{
+			"assertions": [{"type": "wasm", "module": "pass-marker", "value": {"field": "status"}}],
+			"setVariables": [
+				{"name": "whole", "operation": "wasm", "module": "pass-marker"},
+				{"name": "field", "operation": "wasm", "module": "pass-marker", "from": "$.status"}
+			]
+		}`, &ScriptContext{
+			RuntimeVars:  map[string]string{},
+			StatusCode:   200,
+			ResponseBody: body,
+			Extensions:   ext.ForWorkspace(ctx),
+		})
+	}
+
+	result := run(context.Background(), `{"status":"PASS"}`)
+	if !result.Success || result.AssertionsPassed != 1 {
+		t.Fatalf("expected pass: %+v", result)
+	}
+	if result.UpdatedVars["whole"] != "PASS seen" || result.UpdatedVars["field"] != "PASS seen" {
+		t.Errorf("transformed vars = %v", result.UpdatedVars)
+	}
+
+	result = run(context.Background(), `{"status":"FAIL"}`)
+	if result.Success || result.AssertionsFailed != 1 {
+		t.Fatalf("expected failure: %+v", result)
+	}
+	if len(result.Errors) != 3 || !strings.Contains(result.Errors[0], "no PASS marker") || !strings.Contains(result.Errors[1], "no PASS marker") {
+		t.Errorf("errors = %v", result.Errors)
+	}
+
+	// Extensions are scoped to the workspace running the script
+	result = run(middleware.WithWorkspaceID(context.Background(), 2), `{"status":"PASS"}`)
+	if result.Success || !strings.Contains(result.Errors[0], "not found") {
+		t.Errorf("other workspace: %+v", result)
+	}
+
+	// Without a runtime (e.g. the script executor used on its own)
+	result = se.Execute(`{"assertions": [{"type": "wasm", "module": "pass-marker"}]}`, &ScriptContext{RuntimeVars: map[string]string{}})
+	if result.Success || !strings.Contains(result.Errors[0], "not available") {
+		t.Errorf("no extensions: %+v", result)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// maxWSFrameBytes caps a single frame in either direction; the target
+	// connection is closed with 1009 (message too big) past it
+	maxWSFrameBytes = 1 << 20
+	// maxWSHexBytes is how much of a binary frame is sent as hex rows; the
+	// full frame is always in the base64 payload
+	maxWSHexBytes = 4 << 10
+	wsHexRowBytes = 16
+	// maxWSStoredMessages is how many frames of a session are kept in
+	// ws_messages; the history entry still lists them all
+	maxWSStoredMessages = 5000
+)
+
+// WSHexRow is one 16-byte line of a hexdump
+type WSHexRow struct {
+	Offset int    `json:"offset"`
+	Hex    string `json:"hex"`   // space-separated byte pairs
+	ASCII  string `json:"ascii"` // printable bytes, '.' for the rest
+}
+
+// wsPayload encodes a frame for an envelope: text as is, binary as base64
+func wsPayload(data []byte, binary bool) string {
+	if binary {
+		return base64.StdEncoding.EncodeToString(data)
+	}
+	return string(data)
+}
+
+// wsFrameData decodes the payload of a "send" envelope and checks its size
+func wsFrameData(msg wsEnvelope) ([]byte, error) {
+	data := []byte(msg.Payload)
+	if msg.Format == "binary" {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(msg.Payload); err != nil {
+			return nil, fmt.Errorf("binary payload must be base64: %w", err)
+		}
+	}
+	if len(data) > maxWSFrameBytes {
+		return nil, fmt.Errorf("frame is %d bytes, the limit is %d", len(data), maxWSFrameBytes)
+	}
+	return data, nil
+}
+
+// wsHexRows splits the first 4KB of data into hexdump rows and reports
+// whether the rest was left out
+func wsHexRows(data []byte) ([]WSHexRow, bool) {
+	truncated := len(data) > maxWSHexBytes
+	if truncated {
+		data = data[:maxWSHexBytes]
+	}
+	rows := make([]WSHexRow, 0, (len(data)+wsHexRowBytes-1)/wsHexRowBytes)
+	for off := 0; off < len(data); off += wsHexRowBytes {
+		line := data[off:min(off+wsHexRowBytes, len(data))]
+		hexed := make([]byte, 0, len(line)*3)
+		ascii := make([]byte, len(line))
+		for i, b := range line {
+			if i > 0 {
+				hexed = append(hexed, ' ')
+			}
+			hexed = hex.AppendEncode(hexed, []byte{b})
+			ascii[i] = '.'
+			if b >= 0x20 && b < 0x7f {
+				ascii[i] = b
+			}
+		}
+		rows = append(rows, WSHexRow{Offset: off, Hex: string(hexed), ASCII: string(ascii)})
+	}
+	return rows, truncated
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"relay/internal/middleware"
@@ -18,6 +20,7 @@ import (
 type WebSocketRelay struct {
 	queries          *repository.Queries
 	variableResolver *VariableResolver
+	fileStorage      *FileStorage
 }
 
 func NewWebSocketRelay(queries *repository.Queries, vr *VariableResolver) *WebSocketRelay {
@@ -27,21 +30,35 @@ func NewWebSocketRelay(queries *repository.Queries, vr *VariableResolver) *WebSo
 	}
 }
 
+// SetFileStorage lets connections use the workspace's CA bundle and client
+// certificates; without it workspaces referring to them fail to connect
+func (wr *WebSocketRelay) SetFileStorage(fs *FileStorage) {
+	wr.fileStorage = fs
+}
+
 // Envelope types for browser <-> Go communication
 type wsEnvelope struct {
-	Type           string   `json:"type"`
-	URL            string   `json:"url,omitempty"`
-	Headers        string   `json:"headers,omitempty"`
-	ProxyID        *int64   `json:"proxyId,omitempty"`
-	WSConnectionID *int64   `json:"wsConnectionId,omitempty"`
-	Subprotocols   []string `json:"subprotocols,omitempty"`
-	Subprotocol    string   `json:"subprotocol,omitempty"`
-	Payload        string   `json:"payload,omitempty"`
-	Format         string   `json:"format,omitempty"`
-	Message        string   `json:"message,omitempty"`
-	Code           int      `json:"code,omitempty"`
-	Reason         string   `json:"reason,omitempty"`
-	Timestamp      string   `json:"timestamp,omitempty"`
+	Type           string `json:"type"`
+	URL            string `json:"url,omitempty"`
+	Headers        string `json:"headers,omitempty"`
+	ProxyID        *int64 `json:"proxyId,omitempty"`
+	WSConnectionID *int64 `json:"wsConnectionId,omitempty"`
+	// Variables are runtime values resolved before the request's scopes
+	Variables    map[string]string `json:"variables,omitempty"`
+	Subprotocols []string          `json:"subprotocols,omitempty"`
+	Subprotocol  string            `json:"subprotocol,omitempty"`
+	// Payload is the frame; base64 when Format is "binary"
+	Payload string `json:"payload,omitempty"`
+	Format  string `json:"format,omitempty"`
+	// Size, Hex and HexTruncated describe received binary frames for the
+	// hex viewer; Hex covers the first 4KB
+	Size         int        `json:"size,omitempty"`
+	Hex          []WSHexRow `json:"hex,omitempty"`
+	HexTruncated bool       `json:"hexTruncated,omitempty"`
+	Message      string     `json:"message,omitempty"`
+	Code         int        `json:"code,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+	Timestamp    string     `json:"timestamp,omitempty"`
 }
 
 func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
@@ -54,6 +71,8 @@ func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer browserConn.Close(websocket.StatusNormalClosure, "")
+	// Room for a maximum frame as base64 inside its envelope
+	browserConn.SetReadLimit(2 * maxWSFrameBytes)
 
 	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
@@ -70,8 +89,32 @@ func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A saved WS request resolves through its collection and uses its proxy
+	// unless the message overrides it, like RequestExecutor. Without a URL in
+	// the message the saved URL, headers and cookies are used, so a saved
+	// request can be connected by ID alone.
+	var colID int64
+	var savedProxy sql.NullInt64
+	var savedCookies string
+	if connectMsg.WSConnectionID != nil {
+		saved, err := wr.queries.GetRequest(ctx, *connectMsg.WSConnectionID)
+		if err != nil || saved.WorkspaceID != middleware.GetWorkspaceID(ctx) {
+			sendError(ctx, browserConn, "WebSocket request not found")
+			return
+		}
+		colID = saved.CollectionID.Int64
+		savedProxy = saved.ProxyID
+		if connectMsg.URL == "" {
+			connectMsg.URL = saved.Url
+			savedCookies = saved.Cookies.String
+			if connectMsg.Headers == "" {
+				connectMsg.Headers = saved.Headers.String
+			}
+		}
+	}
+
 	// Resolve variables in URL
-	resolvedURL, err := wr.variableResolver.Resolve(ctx, connectMsg.URL, nil)
+	resolvedURL, err := wr.variableResolver.Resolve(ctx, connectMsg.URL, connectMsg.Variables, colID)
 	if err != nil {
 		sendError(ctx, browserConn, "Failed to resolve URL variables: "+err.Error())
 		return
@@ -82,30 +125,37 @@ func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
 	if headersJSON == "" {
 		headersJSON = "{}"
 	}
-	resolvedHeaders, err := wr.variableResolver.ResolveHeaders(ctx, headersJSON, nil)
+	resolvedHeaders, err := wr.variableResolver.ResolveHeaders(ctx, headersJSON, connectMsg.Variables, colID)
 	if err != nil {
 		sendError(ctx, browserConn, "Failed to resolve header variables: "+err.Error())
 		return
 	}
 
-	// Build HTTP headers for target connection
+	// Build HTTP headers for target connection. Saved requests keep their
+	// subprotocols in a Sec-WebSocket-Protocol header; the dialer sends its own.
 	httpHeaders := http.Header{}
+	subprotocols := connectMsg.Subprotocols
 	for k, v := range resolvedHeaders {
+		if strings.EqualFold(k, "Sec-WebSocket-Protocol") {
+			if len(subprotocols) == 0 {
+				subprotocols = wsSubprotocols(v)
+			}
+			continue
+		}
 		httpHeaders.Set(k, v)
 	}
-
-	// Configure dial options with proxy
-	var proxyID sql.NullInt64
-	if connectMsg.ProxyID != nil {
-		v := *connectMsg.ProxyID
-		if v == -1 {
-			proxyID = sql.NullInt64{}
-		} else {
-			proxyID = sql.NullInt64{Int64: v, Valid: true}
+	if savedCookies != "" {
+		if cookies := buildCookieHeader(ctx, wr.variableResolver, savedCookies, connectMsg.Variables, colID); cookies != "" {
+			if existing := httpHeaders.Get("Cookie"); existing != "" {
+				cookies = existing + "; " + cookies
+			}
+			httpHeaders.Set("Cookie", cookies)
 		}
 	}
 
-	httpClient, err := CreateHTTPClient(ctx, wr.queries, proxyID)
+	// Configure dial options with proxy
+	proxyID := (&RequestOverrides{ProxyID: connectMsg.ProxyID}).Proxy(savedProxy)
+	httpClient, err := CreateWebSocketClient(ctx, wr.queries, wr.fileStorage, proxyID)
 	if err != nil {
 		sendError(ctx, browserConn, "Failed to create HTTP client: "+err.Error())
 		return
@@ -114,7 +164,7 @@ func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
 	dialOpts := &websocket.DialOptions{
 		HTTPHeader:   httpHeaders,
 		HTTPClient:   httpClient,
-		Subprotocols: connectMsg.Subprotocols,
+		Subprotocols: subprotocols,
 	}
 
 	// Connect to target WebSocket server
@@ -124,6 +174,7 @@ func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer targetConn.Close(websocket.StatusNormalClosure, "")
+	targetConn.SetReadLimit(maxWSFrameBytes)
 
 	// Send "connected" to browser
 	wsjson.Write(ctx, browserConn, wsEnvelope{
@@ -134,7 +185,14 @@ func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
 	})
 
 	startTime := time.Now()
+	// Both directions append to the log
+	var logMu sync.Mutex
 	var messageLog []wsEnvelope
+	logMessage := func(msg wsEnvelope) {
+		logMu.Lock()
+		messageLog = append(messageLog, msg)
+		logMu.Unlock()
+	}
 
 	// Goroutine: target -> browser
 	go func() {
@@ -154,18 +212,20 @@ func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
-			format := "text"
-			if msgType == websocket.MessageBinary {
-				format = "binary"
-			}
-
+			binary := msgType == websocket.MessageBinary
 			msg := wsEnvelope{
 				Type:      "received",
-				Payload:   string(data),
-				Format:    format,
+				Payload:   wsPayload(data, binary),
+				Format:    "text",
 				Timestamp: time.Now().Format(time.RFC3339Nano),
 			}
-			messageLog = append(messageLog, msg)
+			if binary {
+				msg.Format, msg.Size = "binary", len(data)
+			}
+			logMessage(msg)
+			if binary {
+				msg.Hex, msg.HexTruncated = wsHexRows(data)
+			}
 			wsjson.Write(ctx, browserConn, msg)
 		}
 	}()
@@ -179,19 +239,28 @@ func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
 
 		switch msg.Type {
 		case "send":
+			data, err := wsFrameData(msg)
+			if err != nil {
+				sendError(ctx, browserConn, "Failed to send to target: "+err.Error())
+				continue
+			}
 			msgType := websocket.MessageText
 			if msg.Format == "binary" {
 				msgType = websocket.MessageBinary
 			}
-			if err := targetConn.Write(ctx, msgType, []byte(msg.Payload)); err != nil {
+			if err := targetConn.Write(ctx, msgType, data); err != nil {
 				sendError(ctx, browserConn, "Failed to send to target: "+err.Error())
 			} else {
-				messageLog = append(messageLog, wsEnvelope{
+				sent := wsEnvelope{
 					Type:      "sent",
 					Payload:   msg.Payload,
 					Format:    msg.Format,
 					Timestamp: time.Now().Format(time.RFC3339Nano),
-				})
+				}
+				if msgType == websocket.MessageBinary {
+					sent.Size = len(data)
+				}
+				logMessage(sent)
 			}
 		case "close":
 			targetConn.Close(websocket.StatusNormalClosure, "client requested close")
@@ -208,7 +277,21 @@ func (wr *WebSocketRelay) HandleRelay(w http.ResponseWriter, r *http.Request) {
 	// Save history
 	duration := time.Since(startTime).Milliseconds()
 	wsID := middleware.GetWorkspaceID(r.Context())
-	wr.saveWSHistory(context.Background(), connectMsg, resolvedURL, resolvedHeaders, messageLog, duration, wsID)
+	logMu.Lock()
+	messages := messageLog
+	logMu.Unlock()
+	wr.saveWSHistory(context.Background(), connectMsg, resolvedURL, resolvedHeaders, messages, duration, wsID)
+}
+
+// wsSubprotocols splits a Sec-WebSocket-Protocol header value
+func wsSubprotocols(header string) []string {
+	var protocols []string
+	for _, p := range strings.Split(header, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			protocols = append(protocols, p)
+		}
+	}
+	return protocols
 }
 
 func sendError(ctx context.Context, conn *websocket.Conn, message string) {
@@ -228,7 +311,7 @@ func (wr *WebSocketRelay) saveWSHistory(ctx context.Context, connectMsg wsEnvelo
 		wsConnID = sql.NullInt64{Int64: *connectMsg.WSConnectionID, Valid: true}
 	}
 
-	wr.queries.CreateHistory(ctx, repository.CreateHistoryParams{
+	hist, err := wr.queries.CreateHistory(ctx, repository.CreateHistoryParams{
 		RequestID:       wsConnID,
 		Method:          "WS",
 		Url:             resolvedURL,
@@ -243,4 +326,36 @@ func (wr *WebSocketRelay) saveWSHistory(ctx context.Context, connectMsg wsEnvelo
 		IsBinary:        sql.NullInt64{Int64: 0, Valid: true},
 		WorkspaceID:     workspaceID,
 	})
+	if err != nil {
+		log.Printf("WS relay: failed to save history: %v", err)
+		return
+	}
+	wr.saveWSMessages(ctx, hist.ID, messages)
+}
+
+// saveWSMessages keeps the session's frames, up to maxWSStoredMessages, so
+// they can be paged through after the connection is gone
+func (wr *WebSocketRelay) saveWSMessages(ctx context.Context, historyID int64, messages []wsEnvelope) {
+	if len(messages) > maxWSStoredMessages {
+		messages = messages[:maxWSStoredMessages]
+	}
+	for i, msg := range messages {
+		sentAt, _ := time.Parse(time.RFC3339Nano, msg.Timestamp)
+		size := int64(msg.Size)
+		if msg.Format != "binary" {
+			size = int64(len(msg.Payload))
+		}
+		if err := wr.queries.CreateWSMessage(ctx, repository.CreateWSMessageParams{
+			HistoryID: historyID,
+			Seq:       int64(i + 1),
+			Direction: msg.Type,
+			Format:    msg.Format,
+			Payload:   msg.Payload,
+			Size:      size,
+			SentAt:    sentAt.UTC(),
+		}); err != nil {
+			log.Printf("WS relay: failed to save messages of history %d: %v", historyID, err)
+			return
+		}
+	}
 }
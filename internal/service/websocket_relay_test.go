@@ -2,10 +2,15 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -417,3 +422,251 @@ func TestWSRelay_MultipleMessages(t *testing.T) {
 		}
 	}
 }
+
+// startConnectProxy creates an HTTP proxy that only supports CONNECT tunnels
+// and records the addresses it was asked for
+func startConnectProxy(t *testing.T, tunnels *[]string) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(connectProxy(tunnels))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// connectProxy handles CONNECT tunnels, recording their addresses in tunnels
+func connectProxy(tunnels *[]string) http.Handler {
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "forwarding not supported", http.StatusBadGateway)
+			return
+		}
+		mu.Lock()
+		*tunnels = append(*tunnels, r.Host)
+		mu.Unlock()
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		client, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		client.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			io.Copy(target, client)
+			target.Close()
+		}()
+		io.Copy(client, target)
+		client.Close()
+	})
+}
+
+func TestWSRelay_SavedRequestThroughProxy(t *testing.T) {
+	target := startTargetWS(t)
+	defer target.Close()
+	var tunnels []string
+	proxy := startConnectProxy(t, &tunnels)
+
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	p, _ := q.CreateProxy(ctx, repository.CreateProxyParams{Name: "corp", Url: proxy.URL, WorkspaceID: 1})
+	coll, _ := q.CreateCollection(ctx, repository.CreateCollectionParams{Name: "Realtime", WorkspaceID: 1})
+	q.UpdateCollectionVariables(ctx, repository.UpdateCollectionVariablesParams{
+		Variables: sql.NullString{String: `{"wsHost":"` + strings.TrimPrefix(target.URL, "http://") + `"}`, Valid: true},
+		ID:        coll.ID,
+	})
+	saved, _ := q.CreateRequest(ctx, repository.CreateRequestParams{
+		CollectionID: sql.NullInt64{Int64: coll.ID, Valid: true},
+		Name:         "Feed",
+		Method:       "WS",
+		Url:          "ws://{{wsHost}}/{{channel}}",
+		ProxyID:      sql.NullInt64{Int64: p.ID, Valid: true},
+		WorkspaceID:  1,
+	})
+
+	wr := NewWebSocketRelay(q, NewVariableResolver(q))
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/ws/relay", wr.HandleRelay)
+	relay := httptest.NewServer(r)
+	defer relay.Close()
+
+	conn, _, err := websocket.Dial(ctx, relayURL(relay), nil)
+	if err != nil {
+		t.Fatalf("dial relay: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	// Collection and runtime variables resolve; the saved proxy tunnels the plain ws:// target
+	wsjson.Write(ctx, conn, wsEnvelope{
+		Type:           "connect",
+		URL:            saved.Url,
+		WSConnectionID: &saved.ID,
+		Variables:      map[string]string{"channel": "prices"},
+	})
+	env := readEnvelope(t, ctx, conn)
+	if env.Type != "connected" || env.URL != targetWSURL(target)+"/prices" {
+		t.Fatalf("connect = %+v", env)
+	}
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "send", Payload: "via proxy"})
+	if env := readEnvelope(t, ctx, conn); env.Payload != "via proxy" {
+		t.Errorf("echo = %+v", env)
+	}
+	if len(tunnels) != 1 || tunnels[0] != strings.TrimPrefix(target.URL, "http://") {
+		t.Errorf("tunnels = %v", tunnels)
+	}
+
+	// Requests of other workspaces are not reachable
+	other, _, err := websocket.Dial(ctx, relayURL(relay), &websocket.DialOptions{HTTPHeader: http.Header{"X-Workspace-ID": {"2"}}})
+	if err != nil {
+		t.Fatalf("dial relay: %v", err)
+	}
+	defer other.Close(websocket.StatusNormalClosure, "")
+	wsjson.Write(ctx, other, wsEnvelope{Type: "connect", URL: saved.Url, WSConnectionID: &saved.ID})
+	if env := readEnvelope(t, ctx, other); env.Type != "error" {
+		t.Errorf("other workspace = %+v", env)
+	}
+}
+
+func TestWSRelay_BinaryFrames(t *testing.T) {
+	target := startTargetWS(t)
+	defer target.Close()
+	relay := startRelayServer(t)
+
+	ctx := context.Background()
+	conn, _, err := websocket.Dial(ctx, relayURL(relay), nil)
+	if err != nil {
+		t.Fatalf("dial relay: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(4 << 20)
+
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "connect", URL: targetWSURL(target)})
+	if env := readEnvelope(t, ctx, conn); env.Type != "connected" {
+		t.Fatalf("expected 'connected', got %q", env.Type)
+	}
+
+	// Bytes that are not valid UTF-8 survive the round trip as base64
+	frame := []byte{0x08, 0x96, 0x01, 0xff, 0x00, 'h', 'i'}
+	payload := base64.StdEncoding.EncodeToString(frame)
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "send", Payload: payload, Format: "binary"})
+	env := readEnvelope(t, ctx, conn)
+	if env.Type != "received" || env.Format != "binary" || env.Payload != payload || env.Size != len(frame) {
+		t.Fatalf("echo = %+v", env)
+	}
+	if len(env.Hex) != 1 || env.Hex[0].Hex != "08 96 01 ff 00 68 69" || env.Hex[0].ASCII != ".....hi" || env.HexTruncated {
+		t.Errorf("hex = %+v, truncated %v", env.Hex, env.HexTruncated)
+	}
+
+	// Large frames carry hex rows for the first 4KB only
+	big := make([]byte, maxWSHexBytes+100)
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "send", Payload: base64.StdEncoding.EncodeToString(big), Format: "binary"})
+	env = readEnvelope(t, ctx, conn)
+	if env.Size != len(big) || len(env.Hex) != maxWSHexBytes/16 || !env.HexTruncated {
+		t.Errorf("big frame: size %d, %d rows, truncated %v", env.Size, len(env.Hex), env.HexTruncated)
+	}
+
+	// Invalid base64 and oversized frames are rejected without closing
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "send", Payload: "not base64!", Format: "binary"})
+	if env := readEnvelope(t, ctx, conn); env.Type != "error" || !strings.Contains(env.Message, "base64") {
+		t.Errorf("invalid base64 = %+v", env)
+	}
+	tooBig := base64.StdEncoding.EncodeToString(make([]byte, maxWSFrameBytes+1))
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "send", Payload: tooBig, Format: "binary"})
+	if env := readEnvelope(t, ctx, conn); env.Type != "error" || !strings.Contains(env.Message, "limit") {
+		t.Errorf("oversized = %+v", env)
+	}
+
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "send", Payload: "still open"})
+	if env := readEnvelope(t, ctx, conn); env.Payload != "still open" || env.Format != "text" || env.Hex != nil {
+		t.Errorf("text after errors = %+v", env)
+	}
+}
+
+func TestWSRelay_SavedRequestByIDAndMessages(t *testing.T) {
+	var receivedHeaders http.Header
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedHeaders = r.Header.Clone()
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{Subprotocols: []string{"chat.v2"}})
+		if err != nil {
+			return
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		for {
+			msgType, data, err := conn.Read(r.Context())
+			if err != nil {
+				return
+			}
+			conn.Write(r.Context(), msgType, data)
+		}
+	}))
+	defer target.Close()
+
+	q := testutil.SetupTestDB(t)
+	ctx := context.Background()
+	env, _ := q.CreateEnvironment(ctx, repository.CreateEnvironmentParams{
+		Name: "dev", Variables: sql.NullString{String: `{"token":"t-1"}`, Valid: true}, WorkspaceID: 1,
+	})
+	q.ActivateEnvironment(ctx, env.ID)
+	saved, _ := q.CreateRequest(ctx, repository.CreateRequestParams{
+		Name:   "Chat",
+		Method: "WS",
+		Url:    targetWSURL(target) + "/chat",
+		Headers: sql.NullString{String: `{"X-Token":{"value":"{{token}}","enabled":true},` +
+			`"Sec-WebSocket-Protocol":{"value":"chat.v2, chat.v1","enabled":true}}`, Valid: true},
+		Cookies:     sql.NullString{String: `{"session":{"value":"abc","enabled":true},"old":{"value":"x","enabled":false}}`, Valid: true},
+		WorkspaceID: 1,
+	})
+
+	wr := NewWebSocketRelay(q, NewVariableResolver(q))
+	r := chi.NewRouter()
+	r.Use(middleware.WorkspaceID)
+	r.Get("/ws/relay", wr.HandleRelay)
+	relay := httptest.NewServer(r)
+	defer relay.Close()
+
+	conn, _, err := websocket.Dial(ctx, relayURL(relay), nil)
+	if err != nil {
+		t.Fatalf("dial relay: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	// The saved request's URL, headers, cookies and subprotocols are used
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "connect", WSConnectionID: &saved.ID})
+	connected := readEnvelope(t, ctx, conn)
+	if connected.Type != "connected" || connected.URL != targetWSURL(target)+"/chat" || connected.Subprotocol != "chat.v2" {
+		t.Fatalf("connect = %+v", connected)
+	}
+	if receivedHeaders.Get("X-Token") != "t-1" || receivedHeaders.Get("Cookie") != "session=abc" {
+		t.Errorf("headers = %v", receivedHeaders)
+	}
+
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "send", Payload: "hello"})
+	readEnvelope(t, ctx, conn)
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "send", Payload: base64.StdEncoding.EncodeToString([]byte{1, 2, 3}), Format: "binary"})
+	readEnvelope(t, ctx, conn)
+	wsjson.Write(ctx, conn, wsEnvelope{Type: "close"})
+	readEnvelope(t, ctx, conn)
+	conn.Close(websocket.StatusNormalClosure, "")
+
+	// Frames are kept per session once the relay finishes
+	var messages []repository.WsMessage
+	for deadline := time.Now().Add(2 * time.Second); len(messages) < 4 && time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		histories, _ := q.ListHistoryByRequest(ctx, repository.ListHistoryByRequestParams{RequestID: sql.NullInt64{Int64: saved.ID, Valid: true}, Limit: 1})
+		if len(histories) == 1 {
+			messages, _ = q.ListWSMessages(ctx, repository.ListWSMessagesParams{HistoryID: histories[0].ID, Limit: 10})
+		}
+	}
+	if len(messages) != 4 {
+		t.Fatalf("messages = %+v", messages)
+	}
+	sent, binary := messages[0], messages[2]
+	if sent.Seq != 1 || sent.Direction != "sent" || sent.Payload != "hello" || sent.Size != 5 || sent.SentAt.IsZero() {
+		t.Errorf("first message = %+v", sent)
+	}
+	if binary.Format != "binary" || binary.Size != 3 || binary.Payload != "AQID" {
+		t.Errorf("binary message = %+v", binary)
+	}
+}
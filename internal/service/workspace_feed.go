@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"relay/internal/repository"
+)
+
+// Feed item kinds
+const (
+	FeedKindChange  = "change"  // a collection, request, flow or environment was created or edited
+	FeedKindRun     = "run"     // a flow run finished
+	FeedKindMonitor = "monitor" // a monitor went up or down
+)
+
+const (
+	DefaultFeedLimit = 50
+	MaxFeedLimit     = 200
+)
+
+// FeedItem is one entry of a workspace's activity feed
+type FeedItem struct {
+	ID      string    `json:"id"`
+	Kind    string    `json:"kind"`
+	Title   string    `json:"title"`
+	Summary string    `json:"summary"`
+	Date    time.Time `json:"date"`
+	Tags    []string  `json:"tags"`
+}
+
+// WorkspaceFeed collects the latest changes to a workspace's entities, flow
+// runs and monitor state changes, newest first. Deletions are not reported.
+func WorkspaceFeed(ctx context.Context, queries *repository.Queries, wsID int64, limit int) ([]FeedItem, error) {
+	n := int64(limit)
+	changes, err := queries.ListWorkspaceChanges(ctx, repository.ListWorkspaceChangesParams{
+		WorkspaceID: wsID, WorkspaceID_2: wsID, WorkspaceID_3: wsID, WorkspaceID_4: wsID, Limit: n,
+	})
+	if err != nil {
+		return nil, err
+	}
+	runs, err := queries.ListWorkspaceRunTimelines(ctx, repository.ListWorkspaceRunTimelinesParams{WorkspaceID: wsID, Limit: n})
+	if err != nil {
+		return nil, err
+	}
+	transitions, err := queries.ListMonitorTransitions(ctx, repository.ListMonitorTransitionsParams{WorkspaceID: wsID, Limit: n})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]FeedItem, 0, len(changes)+len(runs)+len(transitions))
+	for _, c := range changes {
+		items = append(items, changeFeedItem(c))
+	}
+	for _, r := range runs {
+		items = append(items, runFeedItem(r))
+	}
+	for _, t := range transitions {
+		items = append(items, monitorFeedItem(t))
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Date.After(items[j].Date) })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func changeFeedItem(c repository.ListWorkspaceChangesRow) FeedItem {
+	verb := "updated"
+	if c.CreatedAt.Valid && c.UpdatedAt.Valid && c.CreatedAt.Time.Equal(c.UpdatedAt.Time) {
+		verb = "created"
+	}
+	date := c.UpdatedAt.Time.UTC()
+	return FeedItem{
+		// A new ID per edit so feed readers show each one
+		ID:      fmt.Sprintf("%s-%d-%d", c.Kind, c.ID, date.Unix()),
+		Kind:    FeedKindChange,
+		Title:   fmt.Sprintf("%s %s %s", capitalize(c.Kind), c.Name, verb),
+		Summary: fmt.Sprintf("%s #%d %q was %s", capitalize(c.Kind), c.ID, c.Name, verb),
+		Date:    date,
+		Tags:    []string{FeedKindChange, c.Kind},
+	}
+}
+
+func runFeedItem(r repository.RunTimeline) FeedItem {
+	var tl RunTimeline
+	json.Unmarshal([]byte(r.Timeline), &tl)
+
+	status, failed := "passed", 0
+	for _, e := range tl.Entries {
+		if e.Status == "failed" {
+			failed++
+		}
+	}
+	if !tl.Success {
+		status = "failed"
+	}
+	date := tl.EndedAt
+	if date.IsZero() {
+		date = r.CreatedAt.Time.UTC()
+	}
+	return FeedItem{
+		ID:      "run-" + r.RunID,
+		Kind:    FeedKindRun,
+		Title:   fmt.Sprintf("Flow %s %s", tl.FlowName, status),
+		Summary: fmt.Sprintf("Run %s of flow #%d %s in %d ms: %d steps, %d failed", r.RunID, r.FlowID, status, tl.TotalMs, len(tl.Entries), failed),
+		Date:    date,
+		Tags:    []string{FeedKindRun, status},
+	}
+}
+
+func monitorFeedItem(t repository.ListMonitorTransitionsRow) FeedItem {
+	state, detail := "up", fmt.Sprintf("status %d", t.StatusCode)
+	if t.Success == 0 {
+		state = "down"
+		if t.Error != "" {
+			detail = t.Error
+		}
+	}
+	return FeedItem{
+		ID:      fmt.Sprintf("monitor-check-%d", t.ID),
+		Kind:    FeedKindMonitor,
+		Title:   fmt.Sprintf("Monitor %s is %s", t.RequestName, state),
+		Summary: fmt.Sprintf("Monitor #%d (%s) is %s: %s", t.MonitorID, t.RequestName, state, detail),
+		Date:    t.CheckedAt.Time.UTC(),
+		Tags:    []string{FeedKindMonitor, state},
+	}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// JSONFeed is a JSON Feed 1.1 document (https://jsonfeed.org/version/1.1)
+type JSONFeed struct {
+	Version string         `json:"version"`
+	Title   string         `json:"title"`
+	FeedURL string         `json:"feed_url,omitempty"`
+	Items   []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string   `json:"id"`
+	Title         string   `json:"title"`
+	ContentText   string   `json:"content_text"`
+	DatePublished string   `json:"date_published"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// NewJSONFeed renders feed items as a JSON Feed
+func NewJSONFeed(title, feedURL string, items []FeedItem) JSONFeed {
+	feed := JSONFeed{Version: "https://jsonfeed.org/version/1.1", Title: title, FeedURL: feedURL, Items: []JSONFeedItem{}}
+	for _, it := range items {
+		feed.Items = append(feed.Items, JSONFeedItem{
+			ID:            it.ID,
+			Title:         it.Title,
+			ContentText:   it.Summary,
+			DatePublished: it.Date.Format(time.RFC3339),
+			Tags:          it.Tags,
+		})
+	}
+	return feed
+}
+
+// AtomFeed is an Atom (RFC 4287) feed document
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    *AtomLink   `xml:"link,omitempty"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// NewAtomFeed renders feed items as an Atom feed. IDs are URNs scoped to the
+// workspace; updated is the newest item's date (or now for an empty feed).
+func NewAtomFeed(wsID int64, title, feedURL string, items []FeedItem) AtomFeed {
+	updated := time.Now().UTC()
+	if len(items) > 0 {
+		updated = items[0].Date
+	}
+	feed := AtomFeed{
+		ID:      fmt.Sprintf("urn:relay:workspace:%d:feed", wsID),
+		Title:   title,
+		Updated: updated.Format(time.RFC3339),
+	}
+	if feedURL != "" {
+		feed.Link = &AtomLink{Rel: "self", Href: feedURL}
+	}
+	for _, it := range items {
+		entry := AtomEntry{
+			ID:      fmt.Sprintf("urn:relay:workspace:%d:%s", wsID, it.ID),
+			Title:   it.Title,
+			Updated: it.Date.Format(time.RFC3339),
+			Summary: it.Summary,
+		}
+		for _, tag := range it.Tags {
+			entry.Categories = append(entry.Categories, AtomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"relay/internal/repository"
+)
+
+// Quotas are soft limits for shared deployments: existing data is never
+// removed, but once a limit is reached new requests can't be created,
+// executions are refused until history is cleared, and scheduled monitor
+// checks, environment rotations and token refreshes pause until the next
+// UTC day.
+const (
+	QuotaRequests      = "requests"
+	QuotaHistoryRows   = "historyRows"
+	QuotaStorageBytes  = "storageBytes"
+	QuotaScheduledRuns = "scheduledRunsPerDay"
+)
+
+// WorkspaceQuotas are the workspace's limits; 0 means unlimited
+type WorkspaceQuotas struct {
+	MaxRequests            int64 `json:"maxRequests,omitempty"`
+	MaxHistoryRows         int64 `json:"maxHistoryRows,omitempty"`
+	MaxStorageBytes        int64 `json:"maxStorageBytes,omitempty"` // history bodies and uploaded files
+	MaxScheduledRunsPerDay int64 `json:"maxScheduledRunsPerDay,omitempty"`
+}
+
+func (q WorkspaceQuotas) Validate() error {
+	if q.MaxRequests < 0 || q.MaxHistoryRows < 0 || q.MaxStorageBytes < 0 || q.MaxScheduledRunsPerDay < 0 {
+		return errors.New("quotas must not be negative")
+	}
+	return nil
+}
+
+// QuotaUsage is the usage of one quota
+type QuotaUsage struct {
+	Used     int64 `json:"used"`
+	Limit    int64 `json:"limit"` // 0 = unlimited
+	Exceeded bool  `json:"exceeded"`
+}
+
+func newQuotaUsage(used, limit int64) QuotaUsage {
+	return QuotaUsage{Used: used, Limit: limit, Exceeded: limit > 0 && used >= limit}
+}
+
+// WorkspaceUsage reports a workspace's usage against its quotas
+type WorkspaceUsage struct {
+	WorkspaceID   int64      `json:"workspaceId"`
+	Requests      QuotaUsage `json:"requests"`
+	HistoryRows   QuotaUsage `json:"historyRows"`
+	StorageBytes  QuotaUsage `json:"storageBytes"`
+	ScheduledRuns QuotaUsage `json:"scheduledRunsPerDay"` // monitor checks, environment rotations and token refreshes since UTC midnight
+}
+
+// Quota returns the usage of the named quota
+func (u *WorkspaceUsage) Quota(name string) QuotaUsage {
+	switch name {
+	case QuotaRequests:
+		return u.Requests
+	case QuotaHistoryRows:
+		return u.HistoryRows
+	case QuotaStorageBytes:
+		return u.StorageBytes
+	case QuotaScheduledRuns:
+		return u.ScheduledRuns
+	}
+	return QuotaUsage{}
+}
+
+// QuotaExceededError is returned when a workspace has used up a quota
+type QuotaExceededError struct {
+	Quota string
+	QuotaUsage
+	Adding int64 // what the refused action would have added, for actions adding several at once
+}
+
+func (e *QuotaExceededError) Error() string {
+	switch e.Quota {
+	case QuotaRequests:
+		if e.Adding > 0 {
+			return fmt.Sprintf("Adding %d requests would exceed this workspace's limit of %d saved requests (%d used)", e.Adding, e.Limit, e.Used)
+		}
+		return fmt.Sprintf("This workspace has reached its limit of %d saved requests; delete unused requests to add more", e.Limit)
+	case QuotaHistoryRows:
+		return fmt.Sprintf("This workspace has reached its limit of %d history entries; clear history to keep executing requests", e.Limit)
+	case QuotaStorageBytes:
+		return fmt.Sprintf("This workspace has used its %d bytes of storage; clear history or uploaded files to keep executing requests", e.Limit)
+	case QuotaScheduledRuns:
+		return fmt.Sprintf("This workspace has reached its limit of %d scheduled runs today", e.Limit)
+	}
+	return fmt.Sprintf("quota %s exceeded", e.Quota)
+}
+
+// GetWorkspaceUsage measures the workspace's usage against its quotas
+func GetWorkspaceUsage(ctx context.Context, queries *repository.Queries, wsID int64) (*WorkspaceUsage, error) {
+	raw, err := queries.GetWorkspaceSettings(ctx, wsID)
+	if err != nil {
+		return nil, err
+	}
+	quotas := ParseWorkspaceSettings(raw).Quotas
+	row, err := queries.GetWorkspaceUsage(ctx, repository.GetWorkspaceUsageParams{
+		WorkspaceID:   wsID,
+		WorkspaceID_2: wsID,
+		WorkspaceID_3: wsID,
+		WorkspaceID_4: wsID,
+		WorkspaceID_5: wsID,
+		WorkspaceID_6: wsID,
+		WorkspaceID_7: wsID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &WorkspaceUsage{
+		WorkspaceID:   wsID,
+		Requests:      newQuotaUsage(row.Requests, quotas.MaxRequests),
+		HistoryRows:   newQuotaUsage(row.HistoryRows, quotas.MaxHistoryRows),
+		StorageBytes:  newQuotaUsage(row.StorageBytes, quotas.MaxStorageBytes),
+		ScheduledRuns: newQuotaUsage(row.ScheduledRunsToday, quotas.MaxScheduledRunsPerDay),
+	}, nil
+}
+
+// CheckQuotas returns a *QuotaExceededError for the first of the named quotas
+// the workspace has used up. Workspaces without quotas are not measured.
+func CheckQuotas(ctx context.Context, queries *repository.Queries, wsID int64, names ...string) error {
+	raw, err := queries.GetWorkspaceSettings(ctx, wsID)
+	if err != nil || ParseWorkspaceSettings(raw).Quotas == (WorkspaceQuotas{}) {
+		return nil
+	}
+	usage, err := GetWorkspaceUsage(ctx, queries, wsID)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if q := usage.Quota(name); q.Exceeded {
+			return &QuotaExceededError{Quota: name, QuotaUsage: q}
+		}
+	}
+	return nil
+}
+
+// CheckRequestQuota returns a *QuotaExceededError when adding more saved
+// requests at once would take the workspace past its request limit
+func CheckRequestQuota(ctx context.Context, queries *repository.Queries, wsID, adding int64) error {
+	raw, err := queries.GetWorkspaceSettings(ctx, wsID)
+	if err != nil || ParseWorkspaceSettings(raw).Quotas.MaxRequests == 0 {
+		return nil
+	}
+	usage, err := GetWorkspaceUsage(ctx, queries, wsID)
+	if err != nil {
+		return err
+	}
+	if q := usage.Requests; q.Used+adding > q.Limit {
+		return &QuotaExceededError{Quota: QuotaRequests, QuotaUsage: q, Adding: adding}
+	}
+	return nil
+}
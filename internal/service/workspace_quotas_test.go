@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"relay/internal/repository"
+	"relay/internal/testutil"
+)
+
+func TestWorkspaceQuotas_ScheduledRuns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	db, q := testutil.SetupTestDBWithConn(t)
+	ctx := context.Background()
+	runner := NewMonitorRunner(q, NewRequestExecutor(q, NewVariableResolver(q), nil), nil)
+
+	q.UpdateWorkspaceSettings(ctx, repository.UpdateWorkspaceSettingsParams{
+		ID:       1,
+		Settings: sql.NullString{String: `{"quotas":{"maxScheduledRunsPerDay":1,"maxRequests":5}}`, Valid: true},
+	})
+	req, _ := q.CreateRequest(ctx, repository.CreateRequestParams{Name: "Health", Method: "GET", Url: server.URL, WorkspaceID: 1})
+	mon, _ := q.CreateMonitor(ctx, repository.CreateMonitorParams{WorkspaceID: 1, RequestID: req.ID, IntervalSeconds: 60, Enabled: 1})
+
+	runner.RunDue(ctx)
+	db.Exec("UPDATE monitors SET last_checked_at = datetime('now', '-61 seconds') WHERE id = ?", mon.ID)
+	runner.RunDue(ctx)
+
+	checks, _ := q.ListMonitorChecks(ctx, repository.ListMonitorChecksParams{MonitorID: mon.ID, Limit: 10})
+	if len(checks) != 1 {
+		t.Errorf("checks = %d, want 1 (quota reached)", len(checks))
+	}
+	// Manual checks are not held back by the quota
+	if _, err := runner.Check(ctx, mon); err != nil {
+		t.Error(err)
+	}
+
+	usage, err := GetWorkspaceUsage(ctx, q, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Requests != (QuotaUsage{Used: 1, Limit: 5}) || !usage.ScheduledRuns.Exceeded || usage.HistoryRows.Limit != 0 {
+		t.Errorf("usage = %+v", usage)
+	}
+
+	var exceeded *QuotaExceededError
+	if err := CheckQuotas(ctx, q, 1, QuotaRequests, QuotaScheduledRuns); !errors.As(err, &exceeded) || exceeded.Quota != QuotaScheduledRuns {
+		t.Errorf("CheckQuotas = %v", err)
+	}
+	if err := CheckQuotas(ctx, q, 2, QuotaScheduledRuns); err != nil {
+		t.Errorf("workspace without quotas: %v", err)
+	}
+}
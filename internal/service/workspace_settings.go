@@ -0,0 +1,127 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+)
+
+// WorkspaceSettings holds per-workspace options stored as JSON in workspaces.settings
+type WorkspaceSettings struct {
+	// ScriptLibraries lists bundled JS libraries scripts may require()
+	ScriptLibraries []string `json:"scriptLibraries"`
+	// Notifications configures email alerts sent through the server's SMTP settings
+	Notifications NotificationSettings `json:"notifications"`
+	// HostLimits throttles outgoing requests per target host
+	HostLimits HostLimitSettings `json:"hostLimits"`
+	// Tracing adds request ID / traceparent headers to outgoing requests
+	Tracing TracingSettings `json:"tracing"`
+	// ProxyChain routes requests through the interception stage and an upstream proxy
+	ProxyChain ProxyChainSettings `json:"proxyChain"`
+	// RewriteRules change matching requests before they are sent
+	RewriteRules []RewriteRule `json:"rewriteRules"`
+	// ScriptRequests caps pm.sendRequest traffic per script and per flow run
+	ScriptRequests ScriptRequestLimits `json:"scriptRequests"`
+	// AuthSessions log in on demand and inject their tokens into dependent requests
+	AuthSessions []AuthSession `json:"authSessions"`
+	// SafeMode blocks requests that could change their target's state
+	SafeMode SafeModeSettings `json:"safeMode"`
+	// Quotas are soft limits on the workspace's size and scheduled runs
+	Quotas WorkspaceQuotas `json:"quotas"`
+	// HistorySearch controls full-text indexing of history response bodies
+	HistorySearch HistorySearchSettings `json:"historySearch"`
+	// TLS configures certificate verification and client certificates
+	TLS TLSSettings `json:"tls"`
+	// Auth is used by requests whose own and collections' auth blocks inherit
+	Auth *RequestAuth `json:"auth,omitempty"`
+}
+
+type NotificationSettings struct {
+	Emails          []string `json:"emails"`          // recipients
+	MonitorFailures bool     `json:"monitorFailures"` // monitor went down / recovered
+	WeeklyDigest    bool     `json:"weeklyDigest"`    // run summary every Monday
+}
+
+// ParseWorkspaceSettings decodes the settings column; malformed or empty
+// values yield the defaults.
+func ParseWorkspaceSettings(raw sql.NullString) WorkspaceSettings {
+	var s WorkspaceSettings
+	if raw.Valid && raw.String != "" {
+		json.Unmarshal([]byte(raw.String), &s)
+	}
+	if s.ScriptLibraries == nil {
+		s.ScriptLibraries = []string{}
+	}
+	if s.Notifications.Emails == nil {
+		s.Notifications.Emails = []string{}
+	}
+	if s.HostLimits.Hosts == nil {
+		s.HostLimits.Hosts = map[string]HostLimit{}
+	}
+	if s.RewriteRules == nil {
+		s.RewriteRules = []RewriteRule{}
+	}
+	if s.AuthSessions == nil {
+		s.AuthSessions = []AuthSession{}
+	}
+	if s.SafeMode.BlockedHosts == nil {
+		s.SafeMode.BlockedHosts = []string{}
+	}
+	if s.TLS.ClientCerts == nil {
+		s.TLS.ClientCerts = []ClientCertificate{}
+	}
+	return s
+}
+
+// Validate checks that every referenced option is known
+func (s WorkspaceSettings) Validate() error {
+	if err := ValidateScriptLibraries(s.ScriptLibraries); err != nil {
+		return err
+	}
+	if err := s.HostLimits.Validate(); err != nil {
+		return err
+	}
+	if err := s.Tracing.Validate(); err != nil {
+		return err
+	}
+	if err := s.ProxyChain.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateRewriteRules(s.RewriteRules); err != nil {
+		return err
+	}
+	if err := s.ScriptRequests.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateAuthSessions(s.AuthSessions); err != nil {
+		return err
+	}
+	if err := s.SafeMode.Validate(); err != nil {
+		return err
+	}
+	if err := s.Quotas.Validate(); err != nil {
+		return err
+	}
+	if err := s.HistorySearch.Validate(); err != nil {
+		return err
+	}
+	if err := s.TLS.Validate(); err != nil {
+		return err
+	}
+	if s.Auth != nil && s.Auth.Type == "inherit" {
+		return errors.New("auth.type inherit has nothing to inherit from at the workspace level")
+	}
+	if s.Auth.enabled() {
+		if err := s.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, addr := range s.Notifications.Emails {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return fmt.Errorf("invalid notification email %q", addr)
+		}
+	}
+	return nil
+}
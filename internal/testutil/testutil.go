@@ -15,7 +15,9 @@ CREATE TABLE IF NOT EXISTS workspaces (
     name TEXT NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    variables TEXT DEFAULT '{}'
+    variables TEXT DEFAULT '{}',
+    settings TEXT DEFAULT '{}',
+    secret_variables TEXT DEFAULT '[]'
 );
 
 INSERT OR IGNORE INTO workspaces (id, name) VALUES (1, 'Default');
@@ -28,7 +30,11 @@ CREATE TABLE IF NOT EXISTS collections (
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
     sort_order INTEGER NOT NULL DEFAULT 0,
-    variables TEXT DEFAULT '{}'
+    variables TEXT DEFAULT '{}',
+    secret_variables TEXT DEFAULT '[]',
+    signing_hook TEXT DEFAULT '',
+    run_flows TEXT DEFAULT '',
+    auth TEXT DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS requests (
@@ -47,7 +53,19 @@ CREATE TABLE IF NOT EXISTS requests (
     workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
     sort_order INTEGER NOT NULL DEFAULT 0,
     pre_script TEXT DEFAULT '',
-    post_script TEXT DEFAULT ''
+    post_script TEXT DEFAULT '',
+    response_transform TEXT DEFAULT '',
+    version INTEGER NOT NULL DEFAULT 1,
+    auth TEXT DEFAULT '',
+    execution_count INTEGER NOT NULL DEFAULT 0,
+    last_executed_at DATETIME,
+    archived_at DATETIME,
+    timeout_ms INTEGER NOT NULL DEFAULT 0,
+    retry_count INTEGER NOT NULL DEFAULT 0,
+    retry_backoff_ms INTEGER NOT NULL DEFAULT 0,
+    retry_on_status TEXT NOT NULL DEFAULT '',
+    disable_redirects INTEGER NOT NULL DEFAULT 0,
+    max_redirects INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS environments (
@@ -57,7 +75,10 @@ CREATE TABLE IF NOT EXISTS environments (
     is_active BOOLEAN DEFAULT FALSE,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES environments(id) ON DELETE SET NULL,
+    collection_id INTEGER REFERENCES collections(id) ON DELETE CASCADE,
+    comments TEXT NOT NULL DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS proxies (
@@ -77,7 +98,11 @@ CREATE TABLE IF NOT EXISTS flows (
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
-    sort_order INTEGER NOT NULL DEFAULT 0
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    outputs TEXT DEFAULT '',
+    execution_count INTEGER NOT NULL DEFAULT 0,
+    last_executed_at DATETIME,
+    archived_at DATETIME
 );
 
 CREATE TABLE IF NOT EXISTS flow_steps (
@@ -102,7 +127,47 @@ CREATE TABLE IF NOT EXISTS flow_steps (
     loop_count INTEGER DEFAULT 1,
     pre_script TEXT DEFAULT '',
     post_script TEXT DEFAULT '',
-    continue_on_error INTEGER DEFAULT 0
+    continue_on_error INTEGER DEFAULT 0,
+    response_transform TEXT DEFAULT '',
+    wait_until TEXT DEFAULT '',
+    approval TEXT DEFAULT '',
+    timeout_ms INTEGER NOT NULL DEFAULT 0,
+    retry_count INTEGER NOT NULL DEFAULT 0,
+    retry_backoff_ms INTEGER NOT NULL DEFAULT 0,
+    retry_on_status TEXT NOT NULL DEFAULT '',
+    disable_redirects INTEGER NOT NULL DEFAULT 0,
+    max_redirects INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS flow_nodes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    node_key TEXT NOT NULL,
+    type TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    config TEXT NOT NULL DEFAULT '{}',
+    position_x REAL NOT NULL DEFAULT 0,
+    position_y REAL NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (flow_id, node_key)
+);
+
+CREATE TABLE IF NOT EXISTS flow_edges (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    source_key TEXT NOT NULL,
+    target_key TEXT NOT NULL,
+    label TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS run_timelines (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id TEXT NOT NULL UNIQUE,
+    workspace_id INTEGER NOT NULL DEFAULT 1,
+    flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    timeline TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE TABLE IF NOT EXISTS request_history (
@@ -121,9 +186,51 @@ CREATE TABLE IF NOT EXISTS request_history (
     body_size INTEGER DEFAULT 0,
     is_binary INTEGER DEFAULT 0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    trace_id TEXT,
+    note TEXT DEFAULT '',
+    flagged INTEGER NOT NULL DEFAULT 0,
+    execution_group_id TEXT,
+    parent_history_id INTEGER REFERENCES request_history(id) ON DELETE SET NULL,
+    redirects TEXT NOT NULL DEFAULT '',
+    network TEXT NOT NULL DEFAULT '',
+    attempt_group_id TEXT,
+    attempt INTEGER NOT NULL DEFAULT 0
 );
 
+CREATE VIRTUAL TABLE IF NOT EXISTS history_search USING fts5(
+    body,
+    error,
+    workspace_id UNINDEXED
+);
+
+CREATE TABLE IF NOT EXISTS history_search_cursor (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    last_history_id INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS oauth2_configs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    grant_type TEXT NOT NULL,
+    token_url TEXT NOT NULL,
+    client_id TEXT NOT NULL DEFAULT '',
+    client_secret TEXT NOT NULL DEFAULT '',
+    client_auth TEXT NOT NULL DEFAULT 'basic',
+    username TEXT NOT NULL DEFAULT '',
+    password TEXT NOT NULL DEFAULT '',
+    scope TEXT NOT NULL DEFAULT '',
+    environment_id INTEGER REFERENCES environments(id) ON DELETE SET NULL,
+    token_variable TEXT NOT NULL DEFAULT 'accessToken',
+    auto_refresh INTEGER NOT NULL DEFAULT 1,
+    refresh_token TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_oauth2_configs_workspace ON oauth2_configs(workspace_id);
+
 CREATE TABLE IF NOT EXISTS uploaded_files (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
@@ -135,12 +242,308 @@ CREATE TABLE IF NOT EXISTS uploaded_files (
 );
 CREATE INDEX IF NOT EXISTS idx_uploaded_files_workspace ON uploaded_files(workspace_id);
 
+CREATE TABLE IF NOT EXISTS monitors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    request_id INTEGER NOT NULL UNIQUE REFERENCES requests(id) ON DELETE CASCADE,
+    interval_seconds INTEGER NOT NULL DEFAULT 60,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_checked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    max_queue_seconds INTEGER NOT NULL DEFAULT 900,
+    offline_since DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS monitor_checks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    monitor_id INTEGER NOT NULL REFERENCES monitors(id) ON DELETE CASCADE,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    success INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    checked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    offline_seconds INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_monitor_checks_monitor ON monitor_checks(monitor_id, checked_at);
+
+CREATE TABLE IF NOT EXISTS environment_rotations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    environment_id INTEGER NOT NULL REFERENCES environments(id) ON DELETE CASCADE,
+    flow_id INTEGER NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+    interval_seconds INTEGER NOT NULL DEFAULT 86400,
+    outputs TEXT NOT NULL DEFAULT '',
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_run_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_environment_rotations_workspace ON environment_rotations(workspace_id);
+
+CREATE TABLE IF NOT EXISTS environment_rotation_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    rotation_id INTEGER NOT NULL REFERENCES environment_rotations(id) ON DELETE CASCADE,
+    success INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    variables TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    ran_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_environment_rotation_runs_rotation ON environment_rotation_runs(rotation_id, ran_at);
+
+CREATE TABLE IF NOT EXISTS token_refreshers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    login_request_id INTEGER NOT NULL REFERENCES requests(id) ON DELETE CASCADE,
+    environment_id INTEGER REFERENCES environments(id) ON DELETE SET NULL,
+    extract_vars TEXT NOT NULL,
+    expires_in_path TEXT NOT NULL DEFAULT '',
+    interval_seconds INTEGER NOT NULL DEFAULT 900,
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_attempt_at DATETIME,
+    last_refreshed_at DATETIME,
+    expires_at DATETIME,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_token_refreshers_workspace ON token_refreshers(workspace_id);
+
+CREATE TABLE IF NOT EXISTS token_refresh_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    refresher_id INTEGER NOT NULL REFERENCES token_refreshers(id) ON DELETE CASCADE,
+    ran_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_token_refresh_runs_refresher ON token_refresh_runs(refresher_id, ran_at);
+
+CREATE TABLE IF NOT EXISTS ws_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    history_id INTEGER NOT NULL REFERENCES request_history(id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    direction TEXT NOT NULL,
+    format TEXT NOT NULL DEFAULT 'text',
+    payload TEXT NOT NULL DEFAULT '',
+    size INTEGER NOT NULL DEFAULT 0,
+    sent_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_ws_messages_history ON ws_messages(history_id, seq);
+
+CREATE TABLE IF NOT EXISTS response_annotations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    request_id INTEGER NOT NULL REFERENCES requests(id) ON DELETE CASCADE,
+    path TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    deprecated INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(request_id, path)
+);
+
+CREATE TABLE IF NOT EXISTS cookies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    domain TEXT NOT NULL,
+    path TEXT NOT NULL DEFAULT '/',
+    name TEXT NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME,
+    secure INTEGER NOT NULL DEFAULT 0,
+    http_only INTEGER NOT NULL DEFAULT 0,
+    host_only INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(workspace_id, domain, path, name)
+);
+
+CREATE TABLE IF NOT EXISTS openapi_specs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    spec TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workspace_encryption_keys (
+    workspace_id INTEGER PRIMARY KEY REFERENCES workspaces(id) ON DELETE CASCADE,
+    source TEXT NOT NULL,
+    kms_key_env TEXT NOT NULL DEFAULT '',
+    salt TEXT NOT NULL DEFAULT '',
+    iterations INTEGER NOT NULL DEFAULT 0,
+    key_check TEXT NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    rotated_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS sequences (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    value INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(workspace_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS value_pools (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    mode TEXT NOT NULL DEFAULT 'roundRobin',
+    items TEXT NOT NULL DEFAULT '[]',
+    cursor INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(workspace_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS snippets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(workspace_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS archives (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    run_id TEXT,
+    flow_id INTEGER,
+    storage TEXT NOT NULL,
+    object_key TEXT NOT NULL,
+    entries INTEGER NOT NULL DEFAULT 0,
+    size INTEGER NOT NULL DEFAULT 0,
+    oldest_at DATETIME,
+    newest_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS flow_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id TEXT NOT NULL UNIQUE,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    flow_id INTEGER NOT NULL,
+    flow_name TEXT NOT NULL DEFAULT '',
+    success INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    total_time_ms INTEGER NOT NULL DEFAULT 0,
+    step_count INTEGER NOT NULL DEFAULT 0,
+    result TEXT NOT NULL DEFAULT '{}',
+    started_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS drift_baselines (
+    request_id INTEGER PRIMARY KEY REFERENCES requests(id) ON DELETE CASCADE,
+    history_id INTEGER,
+    response_body TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS drift_monitors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    collection_id INTEGER NOT NULL UNIQUE REFERENCES collections(id) ON DELETE CASCADE,
+    interval_seconds INTEGER NOT NULL DEFAULT 3600,
+    webhook_url TEXT NOT NULL DEFAULT '',
+    enabled INTEGER NOT NULL DEFAULT 1,
+    last_run_at DATETIME,
+    last_report TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS wasm_extensions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER NOT NULL DEFAULT 1 REFERENCES workspaces(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    module BLOB NOT NULL,
+    sha256 TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    exports TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(workspace_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS user_preferences (
+    token_hash TEXT PRIMARY KEY,
+    preferences TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS request_drafts (
+    request_id INTEGER PRIMARY KEY REFERENCES requests(id) ON DELETE CASCADE,
+    data TEXT NOT NULL DEFAULT '{}',
+    base_version INTEGER NOT NULL,
+    revision INTEGER NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS editor_sessions (
+    token_hash TEXT NOT NULL,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    state TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (token_hash, workspace_id)
+);
+
+CREATE TABLE IF NOT EXISTS instances (
+    id TEXT PRIMARY KEY,
+    hostname TEXT NOT NULL DEFAULT '',
+    pid INTEGER NOT NULL DEFAULT 0,
+    started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    heartbeat_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS job_leases (
+    name TEXT PRIMARY KEY,
+    holder TEXT NOT NULL,
+    expires_at INTEGER NOT NULL,
+    acquired_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    payload TEXT NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 3,
+    run_at INTEGER NOT NULL,
+    locked_by TEXT,
+    locked_until INTEGER,
+    result TEXT,
+    last_error TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    finished_at DATETIME,
+    dedupe_key TEXT
+);
+
 CREATE INDEX IF NOT EXISTS idx_requests_collection ON requests(collection_id);
 CREATE INDEX IF NOT EXISTS idx_collections_parent ON collections(parent_id);
 CREATE INDEX IF NOT EXISTS idx_flow_steps_flow ON flow_steps(flow_id);
 CREATE INDEX IF NOT EXISTS idx_flow_steps_order ON flow_steps(flow_id, step_order);
 CREATE INDEX IF NOT EXISTS idx_history_request ON request_history(request_id);
 CREATE INDEX IF NOT EXISTS idx_history_created ON request_history(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_history_trace ON request_history(trace_id);
+CREATE INDEX IF NOT EXISTS idx_history_execution_group ON request_history(execution_group_id);
+CREATE INDEX IF NOT EXISTS idx_history_parent ON request_history(parent_history_id);
+CREATE INDEX IF NOT EXISTS idx_jobs_due ON jobs(status, run_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_active_key ON jobs(dedupe_key) WHERE dedupe_key IS NOT NULL AND status IN ('queued', 'running');
 `
 
 // SetupTestDB creates an in-memory SQLite database with all tables and returns a Queries instance.
@@ -159,6 +562,8 @@ func SetupTestDBWithConn(t *testing.T) (*sql.DB, *repository.Queries) {
 		t.Fatalf("failed to open in-memory db: %v", err)
 	}
 	t.Cleanup(func() { db.Close() })
+	// Every :memory: connection is a separate empty database, so keep one
+	db.SetMaxOpenConns(1)
 
 	if _, err := db.Exec(ddl); err != nil {
 		t.Fatalf("failed to run migrations: %v", err)
@@ -1,5 +1,6 @@
 import api from '../client';
-import type { Collection } from './types';
+import type { RequestAuth } from '../requests/types';
+import type { Collection, CollectionAuth, ImportCommitInput, ImportFormat, ImportPreview, PostmanImportResult } from './types';
 
 export const getCollections = () => api.get('collections').json<Collection[]>();
 
@@ -18,3 +19,22 @@ export const duplicateCollection = (id: number) =>
 
 export const reorderCollections = (orders: { id: number; sortOrder: number; parentId?: number | null }[]) =>
   api.put('collections/reorder', { json: { orders } });
+
+export const getCollectionAuth = (id: number) =>
+  api.get(`collections/${id}/auth`).json<CollectionAuth>();
+
+// type 'inherit' removes the collection's block
+export const updateCollectionAuth = (id: number, auth: RequestAuth) =>
+  api.put(`collections/${id}/auth`, { json: auth }).json<CollectionAuth>();
+
+// Postman Collection v2.1 export (parsed JSON)
+export const importPostmanCollection = (collection: unknown) =>
+  api.post('import/postman', { json: collection }).json<PostmanImportResult>();
+
+// Parses an export without creating anything
+export const previewImport = (data: unknown, format: ImportFormat = 'postman') =>
+  api.post('import/preview', { json: { format, data } }).json<ImportPreview>();
+
+// Creates only the selected items of a previewed export
+export const commitImport = (input: ImportCommitInput) =>
+  api.post('import/commit', { json: input }).json<PostmanImportResult>();
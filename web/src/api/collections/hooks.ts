@@ -1,6 +1,8 @@
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { queryKeys } from '../shared/queryKeys';
 import * as api from './client';
+import type { RequestAuth } from '../requests/types';
+import type { ImportFormat } from './types';
 
 export const useCollections = () =>
   useQuery({ queryKey: queryKeys.collections, queryFn: api.getCollections });
@@ -48,3 +50,39 @@ export const useReorderCollections = () => {
     onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.collections }),
   });
 };
+
+export const useCollectionAuth = (id: number) =>
+  useQuery({ queryKey: [...queryKeys.collections, id, 'auth'], queryFn: () => api.getCollectionAuth(id), enabled: !!id });
+
+export const useUpdateCollectionAuth = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, auth }: { id: number; auth: RequestAuth }) => api.updateCollectionAuth(id, auth),
+    onSuccess: (_, { id }) => queryClient.invalidateQueries({ queryKey: [...queryKeys.collections, id, 'auth'] }),
+  });
+};
+
+export const useImportPostmanCollection = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.importPostmanCollection,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.collections });
+      queryClient.invalidateQueries({ queryKey: queryKeys.requests });
+    },
+  });
+};
+
+export const usePreviewImport = () =>
+  useMutation({ mutationFn: ({ data, format }: { data: unknown; format?: ImportFormat }) => api.previewImport(data, format) });
+
+export const useCommitImport = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.commitImport,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.collections });
+      queryClient.invalidateQueries({ queryKey: queryKeys.requests });
+    },
+  });
+};
@@ -6,5 +6,18 @@ export {
   useDeleteCollection,
   useDuplicateCollection,
   useReorderCollections,
+  useImportPostmanCollection,
+  usePreviewImport,
+  useCommitImport,
+  useCollectionAuth,
+  useUpdateCollectionAuth,
 } from './hooks';
-export type { Collection } from './types';
+export type {
+  Collection,
+  CollectionAuth,
+  ImportCommitInput,
+  ImportFormat,
+  ImportPreview,
+  ImportPreviewNode,
+  PostmanImportResult,
+} from './types';
@@ -1,4 +1,4 @@
-import type { Request } from '../requests/types';
+import type { Request, RequestAuth } from '../requests/types';
 
 export interface Collection {
   id: number;
@@ -10,3 +10,42 @@ export interface Collection {
   createdAt: string;
   updatedAt: string;
 }
+
+export interface PostmanImportResult {
+  collection: Collection;
+  collections: number;
+  requests: number;
+  warnings?: string[];
+}
+
+export type ImportFormat = 'postman';
+
+// A parsed item; ids are positions in the tree ('0' root, '0.1' folder, '0.1:2' request)
+export interface ImportPreviewNode {
+  id: string;
+  type: 'collection' | 'request';
+  name: string;
+  method?: string;
+  url?: string;
+  variables?: number;
+  children?: ImportPreviewNode[];
+}
+
+export interface ImportPreview {
+  tree: ImportPreviewNode;
+  collections: number;
+  requests: number;
+  warnings?: string[];
+}
+
+export interface ImportCommitInput {
+  format?: ImportFormat;
+  data: unknown; // the same document that was previewed
+  items: string[]; // a selected collection brings everything below it
+  collectionId?: number; // add to this collection instead of creating a new root
+}
+
+// A collection's own auth block, inherited by its requests and subcollections
+export interface CollectionAuth extends RequestAuth {
+  collectionId: number;
+}
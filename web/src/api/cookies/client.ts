@@ -0,0 +1,17 @@
+import api from '../client';
+import type { Cookie, CookieFilter, CookieInput } from './types';
+
+export const getCookies = (filter: CookieFilter = {}) =>
+  api.get('cookies', { searchParams: { ...filter } }).json<Cookie[]>();
+
+export const createCookie = (data: CookieInput) =>
+  api.post('cookies', { json: data }).json<Cookie>();
+
+export const updateCookie = (id: number, data: CookieInput) =>
+  api.put(`cookies/${id}`, { json: data }).json<Cookie>();
+
+export const deleteCookie = (id: number) => api.delete(`cookies/${id}`);
+
+// Empties the jar, or only the domain's own cookies
+export const clearCookies = (domain?: string) =>
+  api.delete('cookies', { searchParams: domain ? { domain } : {} }).json<{ deleted: number }>();
@@ -0,0 +1,39 @@
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
+import { queryKeys } from '../shared/queryKeys';
+import * as api from './client';
+import type { CookieFilter, CookieInput } from './types';
+
+export const useCookies = (filter: CookieFilter = {}) =>
+  useQuery({ queryKey: [...queryKeys.cookies, filter], queryFn: () => api.getCookies(filter) });
+
+export const useCreateCookie = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.createCookie,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.cookies }),
+  });
+};
+
+export const useUpdateCookie = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, data }: { id: number; data: CookieInput }) => api.updateCookie(id, data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.cookies }),
+  });
+};
+
+export const useDeleteCookie = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.deleteCookie,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.cookies }),
+  });
+};
+
+export const useClearCookies = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.clearCookies,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.cookies }),
+  });
+};
@@ -0,0 +1,2 @@
+export { useCookies, useCreateCookie, useUpdateCookie, useDeleteCookie, useClearCookies } from './hooks';
+export type { Cookie, CookieFilter, CookieInput } from './types';
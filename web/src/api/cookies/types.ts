@@ -0,0 +1,29 @@
+export interface Cookie {
+  id: number;
+  domain: string;
+  path: string;
+  name: string;
+  value: string;
+  expiresAt?: string; // absent for session cookies
+  secure: boolean;
+  httpOnly: boolean;
+  hostOnly: boolean; // sent to domain only, not its subdomains
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface CookieInput {
+  domain: string;
+  path?: string; // default "/"
+  name: string;
+  value: string;
+  expiresAt?: string; // RFC3339
+  secure?: boolean;
+  httpOnly?: boolean;
+  hostOnly?: boolean;
+}
+
+export interface CookieFilter {
+  domain?: string; // the domain's own cookies
+  url?: string; // cookies a request to this URL would send
+}
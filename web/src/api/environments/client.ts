@@ -1,17 +1,79 @@
 import api from '../client';
-import type { Environment } from './types';
+import type { ListQuery } from '../shared/types';
+import type {
+  DotenvImportInput,
+  DotenvImportResult,
+  Environment,
+  EnvironmentRotation,
+  EnvironmentRotationInput,
+  RotationRun,
+  TokenRefresher,
+  TokenRefresherInput,
+  VariablePreview,
+  VariablePreviewInput,
+} from './types';
 
-export const getEnvironments = () => api.get('environments').json<Environment[]>();
+export const getEnvironments = (query?: ListQuery) =>
+  api.get('environments', { searchParams: query ? { ...query } : undefined }).json<Environment[]>();
+
+export const getCollectionEnvironments = (collectionId: number) =>
+  api.get('environments', { searchParams: { collectionId } }).json<Environment[]>();
 
 export const getEnvironment = (id: number) => api.get(`environments/${id}`).json<Environment>();
 
-export const createEnvironment = (data: { name: string; variables: string }) =>
+export const createEnvironment = (data: { name: string; variables: string; parentId?: number | null; collectionId?: number }) =>
   api.post('environments', { json: data }).json<Environment>();
 
-export const updateEnvironment = (id: number, data: { name: string; variables: string }) =>
+// parentId null (or omitted) removes the parent
+export const updateEnvironment = (id: number, data: { name: string; variables: string; parentId?: number | null }) =>
   api.put(`environments/${id}`, { json: data }).json<Environment>();
 
 export const deleteEnvironment = (id: number) => api.delete(`environments/${id}`);
 
 export const activateEnvironment = (id: number) =>
   api.post(`environments/${id}/activate`).json<Environment>();
+
+export const deactivateEnvironment = (id: number) =>
+  api.post(`environments/${id}/deactivate`).json<Environment>();
+
+export const importDotenv = (data: DotenvImportInput) =>
+  api.post('environments/import-dotenv', { json: data }).json<DotenvImportResult>();
+
+// The environment's own variables as .env file content
+export const exportDotenv = (id: number) => api.get(`environments/${id}/export-dotenv`).text();
+
+// Resolves {{variables}} in text and reports the scope of each value
+export const previewVariables = (data: VariablePreviewInput) =>
+  api.post('variables/preview', { json: data }).json<VariablePreview>();
+
+export const getEnvironmentRotations = () =>
+  api.get('environment-rotations').json<EnvironmentRotation[]>();
+
+export const createEnvironmentRotation = (data: EnvironmentRotationInput) =>
+  api.post('environment-rotations', { json: data }).json<EnvironmentRotation>();
+
+export const updateEnvironmentRotation = (id: number, data: EnvironmentRotationInput) =>
+  api.put(`environment-rotations/${id}`, { json: data }).json<EnvironmentRotation>();
+
+export const deleteEnvironmentRotation = (id: number) => api.delete(`environment-rotations/${id}`);
+
+export const getRotationRuns = (id: number) =>
+  api.get(`environment-rotations/${id}/runs`).json<RotationRun[]>();
+
+// Runs the rotation's flow now and writes its outputs
+export const runEnvironmentRotation = (id: number) =>
+  api.post(`environment-rotations/${id}/run`).json<RotationRun>();
+
+export const getTokenRefreshers = () => api.get('token-refreshers').json<TokenRefresher[]>();
+
+export const createTokenRefresher = (data: TokenRefresherInput) =>
+  api.post('token-refreshers', { json: data }).json<TokenRefresher>();
+
+export const updateTokenRefresher = (id: number, data: TokenRefresherInput) =>
+  api.put(`token-refreshers/${id}`, { json: data }).json<TokenRefresher>();
+
+export const deleteTokenRefresher = (id: number) => api.delete(`token-refreshers/${id}`);
+
+// Runs the login now; a failed login comes back as status 'failing'
+export const refreshToken = (id: number) =>
+  api.post(`token-refreshers/${id}/refresh`).json<TokenRefresher>();
@@ -1,9 +1,10 @@
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { queryKeys } from '../shared/queryKeys';
 import * as api from './client';
+import type { EnvironmentRotationInput, TokenRefresherInput } from './types';
 
 export const useEnvironments = () =>
-  useQuery({ queryKey: queryKeys.environments, queryFn: api.getEnvironments });
+  useQuery({ queryKey: queryKeys.environments, queryFn: () => api.getEnvironments() });
 
 export const useCreateEnvironment = () => {
   const queryClient = useQueryClient();
@@ -16,7 +17,7 @@ export const useCreateEnvironment = () => {
 export const useUpdateEnvironment = () => {
   const queryClient = useQueryClient();
   return useMutation({
-    mutationFn: ({ id, data }: { id: number; data: { name: string; variables: string } }) =>
+    mutationFn: ({ id, data }: { id: number; data: { name: string; variables: string; parentId?: number | null } }) =>
       api.updateEnvironment(id, data),
     onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.environments }),
   });
@@ -37,3 +38,96 @@ export const useActivateEnvironment = () => {
     onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.environments }),
   });
 };
+
+export const useImportDotenv = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.importDotenv,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.environments }),
+  });
+};
+
+export const usePreviewVariables = () => useMutation({ mutationFn: api.previewVariables });
+
+export const useEnvironmentRotations = () =>
+  useQuery({ queryKey: queryKeys.environmentRotations, queryFn: api.getEnvironmentRotations });
+
+export const useRotationRuns = (id: number) =>
+  useQuery({ queryKey: queryKeys.rotationRuns(id), queryFn: () => api.getRotationRuns(id) });
+
+export const useCreateEnvironmentRotation = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.createEnvironmentRotation,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.environmentRotations }),
+  });
+};
+
+export const useUpdateEnvironmentRotation = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, data }: { id: number; data: EnvironmentRotationInput }) =>
+      api.updateEnvironmentRotation(id, data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.environmentRotations }),
+  });
+};
+
+export const useDeleteEnvironmentRotation = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.deleteEnvironmentRotation,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.environmentRotations }),
+  });
+};
+
+// A run rewrites environment variables, so environments are refetched too
+export const useRunEnvironmentRotation = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.runEnvironmentRotation,
+    onSuccess: (_, id) => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.environmentRotations });
+      queryClient.invalidateQueries({ queryKey: queryKeys.rotationRuns(id) });
+      queryClient.invalidateQueries({ queryKey: queryKeys.environments });
+    },
+  });
+};
+
+export const useTokenRefreshers = () =>
+  useQuery({ queryKey: queryKeys.tokenRefreshers, queryFn: api.getTokenRefreshers });
+
+export const useCreateTokenRefresher = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.createTokenRefresher,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.tokenRefreshers }),
+  });
+};
+
+export const useUpdateTokenRefresher = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, data }: { id: number; data: TokenRefresherInput }) => api.updateTokenRefresher(id, data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.tokenRefreshers }),
+  });
+};
+
+export const useDeleteTokenRefresher = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.deleteTokenRefresher,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.tokenRefreshers }),
+  });
+};
+
+// A refresh rewrites environment variables, so environments are refetched too
+export const useRefreshToken = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.refreshToken,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.tokenRefreshers });
+      queryClient.invalidateQueries({ queryKey: queryKeys.environments });
+    },
+  });
+};
@@ -4,5 +4,32 @@ export {
   useUpdateEnvironment,
   useDeleteEnvironment,
   useActivateEnvironment,
+  useImportDotenv,
+  usePreviewVariables,
+  useEnvironmentRotations,
+  useRotationRuns,
+  useCreateEnvironmentRotation,
+  useUpdateEnvironmentRotation,
+  useDeleteEnvironmentRotation,
+  useRunEnvironmentRotation,
+  useTokenRefreshers,
+  useCreateTokenRefresher,
+  useUpdateTokenRefresher,
+  useDeleteTokenRefresher,
+  useRefreshToken,
 } from './hooks';
-export type { Environment } from './types';
+export type {
+  DotenvImportInput,
+  DotenvImportResult,
+  Environment,
+  EnvironmentRotation,
+  EnvironmentRotationInput,
+  RotationRun,
+  TokenFreshnessStatus,
+  TokenRefresher,
+  TokenRefresherInput,
+  VariablePreview,
+  VariablePreviewInput,
+  VariableScope,
+  VariableSource,
+} from './types';
@@ -2,7 +2,110 @@ export interface Environment {
   id: number;
   name: string;
   variables: string;
+  parentId: number | null;
+  collectionId: number | null;
   isActive: boolean;
   createdAt: string;
   updatedAt: string;
 }
+
+// name creates a new environment; environmentId merges into an existing one
+export interface DotenvImportInput {
+  content: string;
+  name?: string;
+  environmentId?: number;
+}
+
+export interface DotenvImportResult {
+  environment: Environment;
+  imported: number;
+  warnings?: string[];
+}
+
+export type VariableScope = 'runtime' | 'environment' | 'collection' | 'global' | 'builtin';
+
+export interface VariableSource {
+  name: string;
+  scope?: VariableScope; // omitted when unresolved
+  scopeId?: number; // environment, collection or workspace ID
+  value?: string;
+  secret?: boolean; // value is masked
+  shadowed?: VariableScope[]; // lower-priority scopes defining it too
+}
+
+export interface VariablePreviewInput {
+  text: string;
+  requestId?: number;
+  flowStepId?: number;
+}
+
+export interface VariablePreview {
+  resolved: string;
+  variables: VariableSource[];
+}
+
+export interface RotationRun {
+  id: number;
+  success: boolean;
+  error?: string;
+  variables?: string[]; // names written; values are not recorded
+  durationMs: number;
+  ranAt: string;
+}
+
+// A flow run on a schedule whose outputs are written into an environment
+export interface EnvironmentRotation {
+  id: number;
+  environmentId: number;
+  environmentName: string;
+  flowId: number;
+  flowName: string;
+  intervalSeconds: number;
+  outputs?: Record<string, string>; // environment variable -> flow output; unset writes every output
+  enabled: boolean;
+  lastRunAt?: string;
+  nextRunAt?: string;
+  lastRun?: RotationRun;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface EnvironmentRotationInput {
+  environmentId?: number;
+  flowId?: number;
+  intervalSeconds?: number;
+  outputs?: Record<string, string>;
+  enabled?: boolean;
+}
+
+export type TokenFreshnessStatus = 'fresh' | 'expired' | 'failing' | 'pending' | 'paused';
+
+export interface TokenRefresher {
+  id: number;
+  name: string;
+  loginRequestId: number;
+  loginRequestName: string;
+  environmentId: number | null; // null: the active environment at refresh time
+  extractVars: Record<string, string>; // environment variable -> JSONPath in the login response
+  expiresInPath?: string;
+  intervalSeconds: number;
+  enabled: boolean;
+  status: TokenFreshnessStatus;
+  lastRefreshedAt?: string;
+  lastAttemptAt?: string;
+  lastError?: string;
+  expiresAt?: string;
+  nextRefreshAt?: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface TokenRefresherInput {
+  name: string;
+  loginRequestId: number;
+  environmentId?: number | null;
+  extractVars: Record<string, string>;
+  expiresInPath?: string;
+  intervalSeconds?: number;
+  enabled?: boolean;
+}
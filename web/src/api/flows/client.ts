@@ -1,14 +1,16 @@
 import api from '../client';
-import type { Flow, FlowStep, FlowResult, StepStartEvent, StepResult, FlowCompleteEvent, RunFlowStreamCallbacks } from './types';
+import type { ArchiveFilter, ListQuery, UsageSort } from '../shared/types';
+import type { Flow, FlowStep, FlowGraph, FlowResult, FlowRun, FlowRunDetail, FlowRunWaiting, RunTimeline, StepStartEvent, StepResult, StepWaitEvent, PendingApproval, FlowCompleteEvent, RunFlowStreamCallbacks } from './types';
 
-export const getFlows = () => api.get('flows').json<Flow[]>();
+export const getFlows = (query?: ListQuery<UsageSort['sort'] | 'name' | 'createdAt' | 'updatedAt'> & ArchiveFilter) =>
+  api.get('flows', { searchParams: query ? { ...query } : undefined }).json<Flow[]>();
 
 export const getFlow = (id: number) => api.get(`flows/${id}`).json<Flow>();
 
-export const createFlow = (data: { name: string; description: string }) =>
+export const createFlow = (data: { name: string; description: string; outputs?: Record<string, string> }) =>
   api.post('flows', { json: data }).json<Flow>();
 
-export const updateFlow = (id: number, data: { name: string; description: string }) =>
+export const updateFlow = (id: number, data: { name: string; description: string; outputs?: Record<string, string> }) =>
   api.put(`flows/${id}`, { json: data }).json<Flow>();
 
 export const deleteFlow = (id: number) => api.delete(`flows/${id}`);
@@ -16,13 +18,34 @@ export const deleteFlow = (id: number) => api.delete(`flows/${id}`);
 export const duplicateFlow = (id: number) =>
   api.post(`flows/${id}/duplicate`).json<Flow>();
 
+export const archiveFlow = (id: number) =>
+  api.post(`flows/${id}/archive`).json<Flow>();
+
+export const unarchiveFlow = (id: number) =>
+  api.post(`flows/${id}/unarchive`).json<Flow>();
+
 export const reorderFlows = (orders: { id: number; sortOrder: number }[]) =>
   api.put('flows/reorder', { json: { orders } });
 
 export const runFlow = (id: number, stepIds?: number[]) =>
   api.post(`flows/${id}/run`, {
     json: stepIds && stepIds.length > 0 ? { stepIds } : {}
-  }).json<FlowResult>();
+  }).json<FlowResult | FlowRunWaiting>();
+
+export const getRunTimeline = (runId: string) =>
+  api.get(`flows/runs/${runId}/timeline`).json<RunTimeline>();
+
+export const getFlowRuns = (flowId: number, limit?: number) =>
+  api.get(`flows/${flowId}/runs`, { searchParams: limit ? { limit } : undefined }).json<FlowRun[]>();
+
+export const getFlowRun = (runId: string) =>
+  api.get(`flow-runs/${runId}`).json<FlowRunDetail>();
+
+export const getPendingApprovals = () =>
+  api.get('flows/runs/approvals').json<PendingApproval[]>();
+
+export const approveFlowRun = (runId: string, data: { approved?: boolean; comment?: string } = {}) =>
+  api.post(`flows/runs/${runId}/approve`, { json: data }).json<{ runId: string; stepId: number; stepName: string; decision: string }>();
 
 export const getFlowSteps = (flowId: number) =>
   api.get(`flows/${flowId}/steps`).json<FlowStep[]>();
@@ -36,6 +59,12 @@ export const updateFlowStep = (flowId: number, stepId: number, data: Partial<Flo
 export const deleteFlowStep = (flowId: number, stepId: number) =>
   api.delete(`flows/${flowId}/steps/${stepId}`);
 
+export const getFlowGraph = (flowId: number) =>
+  api.get(`flows/${flowId}/graph`).json<FlowGraph>();
+
+export const updateFlowGraph = (flowId: number, graph: FlowGraph) =>
+  api.put(`flows/${flowId}/graph`, { json: graph }).json<FlowGraph>();
+
 export const importCollection = (flowId: number, collectionId: number) =>
   api.post(`flows/${flowId}/import-collection`, { json: { collectionId } }).json<FlowStep[]>();
 
@@ -86,6 +115,12 @@ export const runFlowStream = async (
             case 'step:start':
               callbacks.onStepStart(JSON.parse(data) as StepStartEvent);
               break;
+            case 'step:wait':
+              callbacks.onStepWait?.(JSON.parse(data) as StepWaitEvent);
+              break;
+            case 'step:approval':
+              callbacks.onStepApproval?.(JSON.parse(data) as PendingApproval);
+              break;
             case 'step:complete':
               callbacks.onStepComplete(JSON.parse(data) as StepResult);
               break;
@@ -1,10 +1,10 @@
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { queryKeys } from '../shared/queryKeys';
-import type { FlowStep } from './types';
+import type { FlowGraph, FlowStep } from './types';
 import * as api from './client';
 
 export const useFlows = () =>
-  useQuery({ queryKey: queryKeys.flows, queryFn: api.getFlows });
+  useQuery({ queryKey: queryKeys.flows, queryFn: () => api.getFlows() });
 
 export const useFlow = (id: number) =>
   useQuery({ queryKey: queryKeys.flow(id), queryFn: () => api.getFlow(id), enabled: !!id });
@@ -12,6 +12,26 @@ export const useFlow = (id: number) =>
 export const useFlowSteps = (flowId: number) =>
   useQuery({ queryKey: queryKeys.flowSteps(flowId), queryFn: () => api.getFlowSteps(flowId), enabled: !!flowId });
 
+export const useFlowGraph = (flowId: number) =>
+  useQuery({ queryKey: queryKeys.flowGraph(flowId), queryFn: () => api.getFlowGraph(flowId), enabled: !!flowId });
+
+export const useUpdateFlowGraph = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ flowId, graph }: { flowId: number; graph: FlowGraph }) => api.updateFlowGraph(flowId, graph),
+    onSuccess: (graph, { flowId }) => queryClient.setQueryData(queryKeys.flowGraph(flowId), graph),
+  });
+};
+
+export const useRunTimeline = (runId: string) =>
+  useQuery({ queryKey: queryKeys.runTimeline(runId), queryFn: () => api.getRunTimeline(runId), enabled: !!runId });
+
+export const useFlowRuns = (flowId: number) =>
+  useQuery({ queryKey: queryKeys.flowRuns(flowId), queryFn: () => api.getFlowRuns(flowId), enabled: !!flowId });
+
+export const useFlowRun = (runId: string) =>
+  useQuery({ queryKey: queryKeys.flowRun(runId), queryFn: () => api.getFlowRun(runId), enabled: !!runId });
+
 export const useCreateFlow = () => {
   const queryClient = useQueryClient();
   return useMutation({
@@ -23,7 +43,7 @@ export const useCreateFlow = () => {
 export const useUpdateFlow = () => {
   const queryClient = useQueryClient();
   return useMutation({
-    mutationFn: ({ id, data }: { id: number; data: { name: string; description: string } }) =>
+    mutationFn: ({ id, data }: { id: number; data: { name: string; description: string; outputs?: Record<string, string> } }) =>
       api.updateFlow(id, data),
     onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.flows }),
   });
@@ -45,6 +65,18 @@ export const useDuplicateFlow = () => {
   });
 };
 
+export const useArchiveFlow = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, archived }: { id: number; archived: boolean }) =>
+      archived ? api.archiveFlow(id) : api.unarchiveFlow(id),
+    onSuccess: (_, { id }) => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.flows });
+      queryClient.invalidateQueries({ queryKey: queryKeys.flow(id) });
+    },
+  });
+};
+
 export const useReorderFlows = () => {
   const queryClient = useQueryClient();
   return useMutation({
@@ -58,7 +90,10 @@ export const useRunFlow = () => {
   return useMutation({
     mutationFn: ({ flowId, stepIds }: { flowId: number; stepIds?: number[] }) =>
       api.runFlow(flowId, stepIds),
-    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.history }),
+    onSuccess: (_, { flowId }) => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.history });
+      queryClient.invalidateQueries({ queryKey: queryKeys.flowRuns(flowId) });
+    },
   });
 };
 
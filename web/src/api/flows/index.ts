@@ -6,12 +6,15 @@ export {
   useUpdateFlow,
   useDeleteFlow,
   useDuplicateFlow,
+  useArchiveFlow,
   useReorderFlows,
   useRunFlow,
   useCreateFlowStep,
   useUpdateFlowStep,
   useDeleteFlowStep,
   useImportCollection,
+  useFlowRuns,
+  useFlowRun,
 } from './hooks';
-export { runFlowStream } from './client';
-export type { Flow, FlowStep, FlowResult, StepResult, StepStartEvent, FlowCompleteEvent, RunFlowStreamCallbacks } from './types';
+export { runFlowStream, getPendingApprovals, approveFlowRun } from './client';
+export type { Flow, FlowRun, FlowRunDetail, FlowRunSummary, FlowStep, FlowResult, StepResult, StepStartEvent, StepWaitEvent, PendingApproval, ApprovalGate, ApprovalResult, FlowCompleteEvent, RunFlowStreamCallbacks } from './types';
@@ -1,12 +1,25 @@
-import type { ExecuteResult } from '../shared/types';
+import type { ExecuteResult, TestResult } from '../shared/types';
 
 export interface Flow {
   id: number;
   name: string;
   description: string;
+  outputs: Record<string, string>; // output name -> variable name
   sortOrder: number;
   createdAt: string;
   updatedAt: string;
+  executionCount?: number;
+  lastExecutedAt?: string; // unset when never run
+  archived?: boolean;
+  archivedAt?: string;
+  lastRun?: FlowRunSummary; // on the list, while the latest run's timeline is kept
+}
+
+export interface FlowRunSummary {
+  runId: string;
+  success: boolean;
+  startedAt: string;
+  durationMs: number;
 }
 
 export interface FlowStep {
@@ -28,6 +41,14 @@ export interface FlowStep {
   preScript: string;
   postScript: string;
   continueOnError: boolean;
+  waitUntil?: string; // JSON WaitUntil, '' when the step does not poll
+  approval?: string; // JSON ApprovalGate, '' when the step needs no approval
+  timeoutMs?: number; // timeout and retry policy, as on saved requests
+  retryCount?: number;
+  retryBackoffMs?: number;
+  retryOnStatus?: string;
+  followRedirects?: boolean; // redirect policy, as on saved requests
+  maxRedirects?: number;
   createdAt: string;
   updatedAt: string;
 }
@@ -40,6 +61,139 @@ export interface FlowResult {
   success: boolean;
   error?: string;
   warnings?: string[];
+  runId: string; // groups the run's history entries
+  traceId: string;
+  frozenTime?: string; // RFC3339, when the run's clock was frozen
+  seed?: number; // random seed of a seeded run
+  profile?: RunProfile;
+  outputs?: Record<string, string>; // declared flow outputs at the end of the run
+  nodes?: NodeResult[]; // graph flows only, in completion order
+}
+
+export type FlowNodeType = 'request' | 'condition' | 'delay' | 'script' | 'parallel' | 'subflow';
+
+export interface FlowGraphNode {
+  key: string;
+  type: FlowNodeType;
+  name: string;
+  config?: Record<string, unknown>; // shape depends on type
+  position: { x: number; y: number };
+}
+
+export interface FlowGraphEdge {
+  source: string;
+  target: string;
+  label?: FlowEdgeLabel; // condition branches or node outcomes
+}
+
+// Outcomes a request node reports; script and sub-flow nodes report success/failure
+export type FlowNodeOutcome =
+  | 'success'
+  | 'failure'
+  | '2xx'
+  | '3xx'
+  | '4xx'
+  | '5xx'
+  | 'networkError'
+  | 'assertionFailed';
+
+export type FlowEdgeLabel = 'true' | 'false' | FlowNodeOutcome;
+
+export interface FlowGraph {
+  nodes: FlowGraphNode[];
+  edges: FlowGraphEdge[];
+}
+
+export interface NodeResult {
+  nodeKey: string;
+  type: FlowNodeType;
+  name?: string;
+  status: 'success' | 'failed' | 'skipped';
+  outcome?: FlowNodeOutcome;
+  branch?: FlowEdgeLabel; // label of the edge followed
+  error?: string;
+  skipReason?: string;
+  durationMs: number;
+  step?: StepResult;
+  script?: ScriptResult;
+  subFlow?: FlowResult;
+}
+
+export interface StepProfile {
+  totalMs: number;
+  scriptMs: number;
+  httpMs: number;
+  queueMs: number;
+  delayMs: number;
+  extractionMs: number;
+  otherMs: number;
+  allocBytes: number;
+}
+
+export type TimelinePhaseKind = 'script' | 'delay' | 'queue' | 'http' | 'extraction';
+
+// Offsets are milliseconds from the run start
+export interface TimelinePhase {
+  kind: TimelinePhaseKind;
+  startMs: number;
+  endMs: number;
+}
+
+export interface TimelineEntry {
+  stepId?: number;
+  nodeKey?: string; // graph flows
+  name: string;
+  iteration?: number;
+  status: 'success' | 'failed' | 'skipped';
+  startedAt: string;
+  endedAt: string;
+  startMs: number;
+  endMs: number;
+  phases?: TimelinePhase[];
+}
+
+export interface RunTimeline {
+  runId: string;
+  flowId: number;
+  flowName: string;
+  success: boolean;
+  startedAt: string;
+  endedAt: string;
+  totalMs: number;
+  entries: TimelineEntry[];
+}
+
+export type FlowRunStatus = 'waiting_approval' | 'running' | 'succeeded' | 'failed';
+
+export interface FlowRun {
+  id: number;
+  runId: string;
+  status: FlowRunStatus;
+  flowId: number;
+  flowName: string;
+  success: boolean;
+  error?: string;
+  totalTimeMs: number;
+  stepCount: number; // step executions, or graph nodes
+  startedAt: string;
+  createdAt: string; // when the run finished
+}
+
+export interface FlowRunDetail extends FlowRun {
+  result: FlowResult | null; // null while the run is still going on
+}
+
+// POST /flows/:id/run answers with this once the run waits at an approval gate
+export interface FlowRunWaiting {
+  runId: string;
+  status: 'waiting_approval';
+  approval: PendingApproval;
+}
+
+export interface RunProfile extends StepProfile {
+  steps: number;
+  slowestStepId?: number;
+  slowestMs?: number;
 }
 
 export interface ScriptResult {
@@ -47,6 +201,7 @@ export interface ScriptResult {
   errors?: string[];
   assertionsPassed: number;
   assertionsFailed: number;
+  tests?: TestResult[];
   updatedVars?: Record<string, string>;
   flowAction: 'next' | 'goto' | 'stop' | 'repeat';
   gotoStepName?: string;
@@ -66,6 +221,57 @@ export interface StepResult {
   preScriptResult?: ScriptResult;
   postScriptResult?: ScriptResult;
   warnings?: string[];
+  profile?: StepProfile;
+  wait?: WaitResult;
+  approval?: ApprovalResult;
+  scriptRequests?: ScriptRequest[]; // pm.sendRequest calls of the step's scripts
+}
+
+export interface ScriptRequest {
+  method: string;
+  url: string;
+  statusCode?: number;
+  durationMs: number;
+  cached?: boolean; // answered from the run's sendRequest cache
+  error?: string;
+}
+
+export interface WaitUntil {
+  condition: string;
+  intervalMs?: number;
+  timeoutMs?: number;
+}
+
+export interface ApprovalGate {
+  message?: string;
+  timeoutMs?: number; // default 1 hour
+  onTimeout?: 'fail' | 'skip' | 'approve';
+}
+
+export interface ApprovalResult {
+  decision: 'approved' | 'rejected' | 'timedOut';
+  comment?: string;
+  waitedMs: number;
+}
+
+// A run waiting at an approval gate (also the step:approval stream event)
+export interface PendingApproval {
+  runId: string;
+  workspaceId: number;
+  flowId: number;
+  flowName: string;
+  stepId: number;
+  stepName: string;
+  message?: string;
+  requestedAt: string;
+  expiresAt: string;
+  onTimeout: 'fail' | 'skip' | 'approve';
+}
+
+export interface WaitResult {
+  attempts: number;
+  met: boolean;
+  waitedMs: number;
 }
 
 // SSE streaming event types
@@ -76,6 +282,15 @@ export interface StepStartEvent {
   loopCount: number;
 }
 
+export interface StepWaitEvent {
+  stepId: number;
+  stepName: string;
+  attempt: number;
+  statusCode: number;
+  met: boolean;
+  elapsedMs: number;
+}
+
 export interface FlowCompleteEvent {
   success: boolean;
   totalTimeMs: number;
@@ -85,15 +300,19 @@ export interface FlowCompleteEvent {
 export interface RunFlowStreamCallbacks {
   onStepStart: (event: StepStartEvent) => void;
   onStepComplete: (result: StepResult) => void;
+  onStepWait?: (event: StepWaitEvent) => void;
+  onStepApproval?: (event: PendingApproval) => void;
   onFlowComplete: (event: FlowCompleteEvent) => void;
   onError: (error: string) => void;
 }
 
 // DSL Types for Script Editor
 export interface Assertion {
-  type: 'status' | 'jsonpath' | 'header' | 'responseTime' | 'bodyContains';
+  type: 'status' | 'jsonpath' | 'header' | 'responseTime' | 'bodyContains'
+    | 'bodySha256' | 'bodyMd5' | 'bodySize' | 'bodyPrefix' | 'wasm';
   path?: string;
   name?: string;
+  module?: string;
   operator: 'eq' | 'ne' | 'gt' | 'gte' | 'lt' | 'lte' | 'contains' | 'in' | 'exists' | 'regex';
   value?: string | number | boolean | string[];
 }
@@ -102,13 +321,14 @@ export interface VariableOperation {
   name: string;
   value?: string | number | boolean;
   from?: string;
-  operation?: 'set' | 'increment' | 'decrement' | 'math' | 'concat' | 'conditional';
+  operation?: 'set' | 'increment' | 'decrement' | 'math' | 'concat' | 'conditional' | 'wasm';
   by?: number;
   expression?: string;
   values?: string[];
   condition?: string;
   ifTrue?: string | number | boolean;
   ifFalse?: string | number | boolean;
+  module?: string;
 }
 
 export interface FlowControlAction {
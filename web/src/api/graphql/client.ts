@@ -0,0 +1,10 @@
+import api from '../client';
+import type { GraphQLResponse } from './types';
+
+// Errors come back in the response body (400 when the query couldn't run)
+export const queryGraphQL = <T>(query: string, variables?: Record<string, unknown>, operationName?: string) =>
+  api
+    .post('graphql', { json: { query, variables, operationName }, throwHttpErrors: false })
+    .json<GraphQLResponse<T>>();
+
+export const getGraphQLSchema = () => api.get('graphql/schema').text();
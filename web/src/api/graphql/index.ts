@@ -0,0 +1,2 @@
+export { queryGraphQL, getGraphQLSchema } from './client';
+export type { GraphQLError, GraphQLResponse } from './types';
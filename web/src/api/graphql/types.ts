@@ -0,0 +1,9 @@
+export interface GraphQLError {
+  message: string;
+  path?: (string | number)[];
+}
+
+export interface GraphQLResponse<T> {
+  data?: T;
+  errors?: GraphQLError[];
+}
@@ -0,0 +1,6 @@
+import api from '../client';
+import type { GRPCReflectInput, GRPCReflectResult } from './types';
+
+// Lists the server's services through gRPC server reflection
+export const reflectGRPC = (input: GRPCReflectInput) =>
+  api.post('grpc/reflect', { json: input }).json<GRPCReflectResult>();
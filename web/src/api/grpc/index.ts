@@ -0,0 +1,2 @@
+export { reflectGRPC } from './client';
+export type { GRPCMethodInfo, GRPCReflectInput, GRPCReflectResult, GRPCServiceInfo } from './types';
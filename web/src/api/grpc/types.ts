@@ -0,0 +1,25 @@
+export interface GRPCMethodInfo {
+  name: string;
+  path: string; // /package.Service/Method, appended to the grpc:// server URL
+  inputType: string;
+  outputType: string;
+  clientStreaming?: boolean; // streaming methods are listed but can't be called
+  serverStreaming?: boolean;
+  requestTemplate?: Record<string, unknown>; // input message with zero-valued fields
+}
+
+export interface GRPCServiceInfo {
+  name: string;
+  methods: GRPCMethodInfo[];
+}
+
+export interface GRPCReflectInput {
+  url: string; // grpc://host:port or grpcs://host:port
+  headers?: string; // JSON object, sent as metadata
+  collectionId?: number;
+  variables?: Record<string, string>;
+}
+
+export interface GRPCReflectResult {
+  services: GRPCServiceInfo[];
+}
@@ -1,8 +1,44 @@
 import api from '../client';
-import type { History } from './types';
+import type {
+  History,
+  HistoryGroup,
+  HistoryNoteInput,
+  HistorySearchResult,
+  RunArchive,
+  RunArchiveContent,
+  WSSessionMessagePage,
+} from './types';
 
 export const getHistory = () => api.get('history').json<History[]>();
 
+export const getHistoryByTrace = (traceId: string) =>
+  api.get('history', { searchParams: { traceId } }).json<History[]>();
+
+export const getHistoryByRun = () =>
+  api.get('history', { searchParams: { groupBy: 'run' } }).json<HistoryGroup[]>();
+
+export const getFlaggedHistory = () =>
+  api.get('history', { searchParams: { flagged: 'true' } }).json<History[]>();
+
+export const searchHistory = (q: string, limit?: number) =>
+  api.get('history/search', { searchParams: limit ? { q, limit } : { q } }).json<HistorySearchResult[]>();
+
 export const getHistoryItem = (id: number) => api.get(`history/${id}`).json<History>();
 
 export const deleteHistory = (id: number) => api.delete(`history/${id}`);
+
+export const setHistoryNote = (id: number, data: HistoryNoteInput) =>
+  api.post(`history/${id}/note`, { json: data }).json<History>();
+
+// Frames of a WebSocket session entry, oldest first
+export const getWSMessages = (id: number, after = 0, limit = 200) =>
+  api.get(`history/${id}/ws-messages`, { searchParams: { after, limit } }).json<WSSessionMessagePage>();
+
+export const getArchives = (kind?: 'run' | 'history') =>
+  api.get('archives', { searchParams: kind ? { kind } : undefined }).json<RunArchive[]>();
+
+// Reads an archive back from the archive store
+export const getArchive = (id: number) => api.get(`archives/${id}`).json<RunArchiveContent>();
+
+export const getArchivedRun = (runId: string) =>
+  api.get(`archives/runs/${encodeURIComponent(runId)}`).json<RunArchiveContent>();
@@ -1,10 +1,17 @@
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { queryKeys } from '../shared/queryKeys';
 import * as api from './client';
+import type { HistoryNoteInput } from './types';
 
 export const useHistory = () =>
   useQuery({ queryKey: queryKeys.history, queryFn: api.getHistory });
 
+export const useHistorySearch = (q: string) =>
+  useQuery({ queryKey: queryKeys.historySearch(q), queryFn: () => api.searchHistory(q), enabled: q.trim() !== '' });
+
+export const useWSMessages = (id: number, after = 0) =>
+  useQuery({ queryKey: queryKeys.wsMessages(id, after), queryFn: () => api.getWSMessages(id, after) });
+
 export const useDeleteHistory = () => {
   const queryClient = useQueryClient();
   return useMutation({
@@ -12,3 +19,11 @@ export const useDeleteHistory = () => {
     onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.history }),
   });
 };
+
+export const useSetHistoryNote = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, data }: { id: number; data: HistoryNoteInput }) => api.setHistoryNote(id, data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.history }),
+  });
+};
@@ -1,2 +1,11 @@
-export { useHistory, useDeleteHistory } from './hooks';
-export type { History } from './types';
+export { useHistory, useHistorySearch, useWSMessages, useDeleteHistory, useSetHistoryNote } from './hooks';
+export type {
+  History,
+  HistoryGroup,
+  HistoryNoteInput,
+  HistorySearchResult,
+  RunArchive,
+  RunArchiveContent,
+  WSSessionMessage,
+  WSSessionMessagePage,
+} from './types';
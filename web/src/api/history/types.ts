@@ -1,3 +1,5 @@
+import type { NetworkInfo, RedirectHop } from '../shared/types';
+
 export interface History {
   id: number;
   requestId?: number;
@@ -13,5 +15,70 @@ export interface History {
   error?: string;
   bodySize: number;
   isBinary?: boolean;
+  traceId?: string;
+  runId?: string;
+  note?: string;
+  flagged: boolean;
   createdAt: string;
+  redirects?: RedirectHop[]; // redirect responses followed before the final one
+  network?: NetworkInfo; // proxy, TLS mode and protocol the execution used
+  attemptGroupId?: string; // links the attempts of a request with a retry policy
+  attempt?: number; // this entry's number in the group, from 1
+  attempts?: History[]; // all entries of the attempt group (single entry only)
+  parentId?: number; // entry of the step/request whose script sent this one
+  children?: History[]; // its scripts' pm.sendRequest calls (single entry only)
+}
+
+export interface HistoryGroup {
+  runId?: string;
+  flowId?: number;
+  createdAt: string;
+  entries: History[];
+}
+
+// A history entry whose response body or error matched a search, with the
+// matching words wrapped in <mark> tags in the snippet
+export interface HistorySearchResult extends History {
+  snippet: string;
+}
+
+export interface HistoryNoteInput {
+  note: string;
+  flagged?: boolean;
+}
+
+// A frame of a saved WebSocket session
+export interface WSSessionMessage {
+  seq: number;
+  direction: 'sent' | 'received';
+  format: 'text' | 'binary';
+  payload: string; // base64 when format is 'binary'
+  size: number;
+  sentAt: string;
+}
+
+export interface WSSessionMessagePage {
+  messages: WSSessionMessage[];
+  nextAfter?: number; // seq to pass as `after` for the next page
+}
+
+// Runs and history moved to the archive store after their retention window
+export interface RunArchive {
+  id: number;
+  kind: 'run' | 'history';
+  runId?: string;
+  flowId?: number;
+  storage: 'dir' | 's3';
+  objectKey: string;
+  entries: number;
+  size: number; // compressed bytes
+  oldestAt?: string;
+  newestAt?: string;
+  createdAt: string;
+}
+
+export interface RunArchiveContent extends RunArchive {
+  timeline?: unknown; // the run's timeline as GET /flows/runs/:id/timeline returned it
+  result?: unknown; // the run's result as GET /flow-runs/:id returned it
+  history: History[];
 }
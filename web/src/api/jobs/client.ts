@@ -0,0 +1,11 @@
+import api from '../client';
+import type { Job, JobStatus } from './types';
+
+export const getJobs = (status?: JobStatus) =>
+  api.get('jobs', { searchParams: status ? { status } : {} }).json<Job[]>();
+
+export const getJob = (id: number) => api.get(`jobs/${id}`).json<Job>();
+
+export const retryJob = (id: number) => api.post(`jobs/${id}/retry`).json<Job>();
+
+export const cancelJob = (id: number) => api.post(`jobs/${id}/cancel`).json<Job>();
@@ -0,0 +1,22 @@
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
+import { queryKeys } from '../shared/queryKeys';
+import * as api from './client';
+
+export const useJobs = () =>
+  useQuery({ queryKey: queryKeys.jobs, queryFn: () => api.getJobs() });
+
+export const useRetryJob = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.retryJob,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.jobs }),
+  });
+};
+
+export const useCancelJob = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.cancelJob,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.jobs }),
+  });
+};
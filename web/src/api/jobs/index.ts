@@ -0,0 +1,2 @@
+export { useJobs, useRetryJob, useCancelJob } from './hooks';
+export type { Job, JobStatus } from './types';
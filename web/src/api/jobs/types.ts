@@ -0,0 +1,18 @@
+export type JobStatus = 'queued' | 'running' | 'succeeded' | 'failed' | 'cancelled';
+
+export interface Job {
+  id: number;
+  type: string;
+  status: JobStatus;
+  payload: unknown;
+  attempts: number;
+  maxAttempts: number;
+  runAt: string;
+  result?: unknown;
+  lastError?: string;
+  workspaceId: number | null;
+  createdAt: string;
+  updatedAt: string;
+  finishedAt?: string;
+  key?: string;
+}
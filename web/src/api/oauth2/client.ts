@@ -0,0 +1,17 @@
+import api from '../client';
+import type { OAuth2Config, OAuth2ConfigInput, OAuth2Token } from './types';
+
+export const getOAuth2Configs = () => api.get('oauth2/configs').json<OAuth2Config[]>();
+
+export const getOAuth2Config = (id: number) => api.get(`oauth2/configs/${id}`).json<OAuth2Config>();
+
+export const createOAuth2Config = (data: OAuth2ConfigInput) =>
+  api.post('oauth2/configs', { json: data }).json<OAuth2Config>();
+
+export const updateOAuth2Config = (id: number, data: OAuth2ConfigInput) =>
+  api.put(`oauth2/configs/${id}`, { json: data }).json<OAuth2Config>();
+
+export const deleteOAuth2Config = (id: number) => api.delete(`oauth2/configs/${id}`);
+
+export const fetchOAuth2Token = (id: number) =>
+  api.post(`oauth2/configs/${id}/fetch-token`).json<OAuth2Token>();
@@ -0,0 +1,44 @@
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
+import { queryKeys } from '../shared/queryKeys';
+import * as api from './client';
+import type { OAuth2ConfigInput } from './types';
+
+export const useOAuth2Configs = () =>
+  useQuery({ queryKey: queryKeys.oauth2Configs, queryFn: api.getOAuth2Configs });
+
+export const useCreateOAuth2Config = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.createOAuth2Config,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.oauth2Configs }),
+  });
+};
+
+export const useUpdateOAuth2Config = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, data }: { id: number; data: OAuth2ConfigInput }) =>
+      api.updateOAuth2Config(id, data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.oauth2Configs }),
+  });
+};
+
+export const useDeleteOAuth2Config = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.deleteOAuth2Config,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.oauth2Configs }),
+  });
+};
+
+// The token lands in an environment variable, so environments are refetched
+export const useFetchOAuth2Token = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.fetchOAuth2Token,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.oauth2Configs });
+      queryClient.invalidateQueries({ queryKey: queryKeys.environments });
+    },
+  });
+};
@@ -0,0 +1,8 @@
+export {
+  useOAuth2Configs,
+  useCreateOAuth2Config,
+  useUpdateOAuth2Config,
+  useDeleteOAuth2Config,
+  useFetchOAuth2Token,
+} from './hooks';
+export type { OAuth2Config, OAuth2ConfigInput, OAuth2GrantType, OAuth2Token } from './types';
@@ -0,0 +1,45 @@
+export type OAuth2GrantType = 'client_credentials' | 'password';
+
+export interface OAuth2Config {
+  id: number;
+  name: string;
+  grantType: OAuth2GrantType;
+  tokenUrl: string;
+  clientId: string;
+  hasClientSecret: boolean;
+  clientAuth: 'basic' | 'body';
+  username?: string;
+  hasPassword: boolean;
+  scope?: string;
+  environmentId: number | null; // null: the active environment when fetching
+  tokenVariable: string;
+  autoRefresh: boolean;
+  hasRefreshToken: boolean;
+  expiresAt?: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface OAuth2ConfigInput {
+  name: string;
+  grantType: OAuth2GrantType;
+  tokenUrl: string;
+  clientId?: string;
+  clientSecret?: string | null; // null keeps the stored secret
+  clientAuth?: 'basic' | 'body';
+  username?: string;
+  password?: string | null; // null keeps the stored password
+  scope?: string;
+  environmentId?: number | null;
+  tokenVariable?: string;
+  autoRefresh?: boolean;
+}
+
+export interface OAuth2Token {
+  environmentId: number;
+  variable: string;
+  tokenType?: string;
+  scope?: string;
+  expiresAt?: string;
+  refreshed?: boolean;
+}
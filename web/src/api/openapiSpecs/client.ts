@@ -0,0 +1,14 @@
+import api from '../client';
+import type { OpenAPISpec, OpenAPISpecInput } from './types';
+
+export const getOpenAPISpecs = () => api.get('openapi-specs').json<OpenAPISpec[]>();
+
+export const getOpenAPISpec = (id: number) => api.get(`openapi-specs/${id}`).json<OpenAPISpec>();
+
+export const createOpenAPISpec = (data: OpenAPISpecInput) =>
+  api.post('openapi-specs', { json: data }).json<OpenAPISpec>();
+
+export const updateOpenAPISpec = (id: number, data: OpenAPISpecInput) =>
+  api.put(`openapi-specs/${id}`, { json: data }).json<OpenAPISpec>();
+
+export const deleteOpenAPISpec = (id: number) => api.delete(`openapi-specs/${id}`);
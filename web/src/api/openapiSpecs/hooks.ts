@@ -0,0 +1,34 @@
+import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
+import { queryKeys } from '../shared/queryKeys';
+import * as api from './client';
+import type { OpenAPISpecInput } from './types';
+
+export const useOpenAPISpecs = () =>
+  useQuery({ queryKey: queryKeys.openAPISpecs, queryFn: api.getOpenAPISpecs });
+
+export const useOpenAPISpec = (id: number) =>
+  useQuery({ queryKey: queryKeys.openAPISpec(id), queryFn: () => api.getOpenAPISpec(id), enabled: !!id });
+
+export const useCreateOpenAPISpec = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.createOpenAPISpec,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.openAPISpecs }),
+  });
+};
+
+export const useUpdateOpenAPISpec = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, data }: { id: number; data: OpenAPISpecInput }) => api.updateOpenAPISpec(id, data),
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.openAPISpecs }),
+  });
+};
+
+export const useDeleteOpenAPISpec = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.deleteOpenAPISpec,
+    onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.openAPISpecs }),
+  });
+};
@@ -0,0 +1,8 @@
+export {
+  useOpenAPISpecs,
+  useOpenAPISpec,
+  useCreateOpenAPISpec,
+  useUpdateOpenAPISpec,
+  useDeleteOpenAPISpec,
+} from './hooks';
+export type { OpenAPISpec, OpenAPISpecInput } from './types';
@@ -0,0 +1,12 @@
+export interface OpenAPISpec {
+  id: number;
+  name: string;
+  spec?: Record<string, unknown>; // OpenAPI 3 document; omitted from lists
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface OpenAPISpecInput {
+  name: string;
+  spec: Record<string, unknown>;
+}
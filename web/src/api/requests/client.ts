@@ -1,37 +1,71 @@
 import api from '../client';
-import type { ExecuteResult, RequestExecuteResult } from '../shared/types';
-import type { Request } from './types';
+import type { ArchiveFilter, RequestExecuteResult, UsageSort } from '../shared/types';
+import type { DuplicateMode, LongPollCallbacks, LongPollOptions, LongPollProgress, MergeRequestsInput, MergeRequestsResult, Request, RequestDraft, RequestDraftDiff, RequestDraftFields, ResponseAnnotation, ResponseAnnotationInput } from './types';
 
-export const getRequests = () => api.get('requests').json<Request[]>();
+export const getRequests = (usage?: UsageSort & ArchiveFilter) =>
+  api.get('requests', { searchParams: usage ? { ...usage } : undefined }).json<Request[]>();
 
 export const getRequest = (id: number) => api.get(`requests/${id}`).json<Request>();
 
-export const createRequest = (data: Partial<Request>) =>
-  api.post('requests', { json: data }).json<Request>();
+export const createRequest = (data: Partial<Request>, duplicates?: DuplicateMode) =>
+  api.post('requests', { json: data, searchParams: duplicates ? { duplicates } : undefined }).json<Request>();
 
-export const updateRequest = (id: number, data: Partial<Request>) =>
-  api.put(`requests/${id}`, { json: data }).json<Request>();
+export const updateRequest = (id: number, data: Partial<Request>, duplicates?: DuplicateMode) =>
+  api.put(`requests/${id}`, { json: data, searchParams: duplicates ? { duplicates } : undefined }).json<Request>();
 
 export const deleteRequest = (id: number) => api.delete(`requests/${id}`);
 
 export const duplicateRequest = (id: number) =>
   api.post(`requests/${id}/duplicate`).json<Request>();
 
+export const archiveRequest = (id: number) =>
+  api.post(`requests/${id}/archive`).json<Request>();
+
+export const unarchiveRequest = (id: number) =>
+  api.post(`requests/${id}/unarchive`).json<Request>();
+
 export const reorderRequests = (orders: { id: number; sortOrder: number; collectionId?: number | null }[]) =>
   api.put('requests/reorder', { json: { orders } });
 
+export const mergeRequests = (data: MergeRequestsInput) =>
+  api.post('requests/merge', { json: data }).json<MergeRequestsResult>();
+
+export const getResponseAnnotations = (id: number) =>
+  api.get(`requests/${id}/annotations`).json<ResponseAnnotation[]>();
+
+// Creates or replaces the annotation at input.path
+export const putResponseAnnotation = (id: number, input: ResponseAnnotationInput) =>
+  api.put(`requests/${id}/annotations`, { json: input }).json<ResponseAnnotation>();
+
+export const deleteResponseAnnotation = (id: number, annotationId: number) =>
+  api.delete(`requests/${id}/annotations/${annotationId}`);
+
+export const getRequestDraft = (id: number) => api.get(`requests/${id}/draft`).json<RequestDraft>();
+
+// proxyId -1 resets the draft to the global proxy
+export const patchRequestDraft = (id: number, data: Partial<Omit<RequestDraftFields, 'proxyId'>> & { proxyId?: number }) =>
+  api.patch(`requests/${id}/draft`, { json: data }).json<RequestDraft>();
+
+export const getRequestDraftDiff = (id: number) =>
+  api.get(`requests/${id}/draft/diff`).json<RequestDraftDiff>();
+
+export const applyRequestDraft = (id: number, force = false) =>
+  api.post(`requests/${id}/draft/apply`, { searchParams: force ? { force: 'true' } : {} }).json<Request>();
+
+export const discardRequestDraft = (id: number) => api.delete(`requests/${id}/draft`);
+
 export const executeRequest = (
   id: number,
   variables?: Record<string, string>,
-  overrides?: { method: string; url: string; headers: string; body: string; bodyType: string; proxyId?: number },
+  overrides?: { method: string; url: string; headers: string; body: string; bodyType: string; proxyId?: number; useDraft?: boolean; traceVariables?: boolean },
   signal?: AbortSignal,
 ) =>
   api.post(`requests/${id}/execute`, { json: { variables, ...overrides }, signal }).json<RequestExecuteResult>();
 
 export const executeAdhoc = (
-  data: { method: string; url: string; headers: string; body: string; variables?: Record<string, string>; proxyId?: number },
+  data: { method: string; url: string; headers: string; body: string; variables?: Record<string, string>; proxyId?: number; preScript?: string },
   signal?: AbortSignal,
-) => api.post('execute', { json: data, signal }).json<ExecuteResult>();
+) => api.post('execute', { json: data, signal }).json<RequestExecuteResult>();
 
 export interface FormDataFileItem {
   key: string;
@@ -62,7 +96,7 @@ export const executeRequestWithFiles = (
 
 export const executeAdhocWithFiles = (
   items: FormDataFileItem[],
-  overrides: { method: string; url: string; headers: string; proxyId?: number },
+  overrides: { method: string; url: string; headers: string; proxyId?: number; preScript?: string },
   variables?: Record<string, string>,
   signal?: AbortSignal,
 ) => {
@@ -74,5 +108,78 @@ export const executeAdhocWithFiles = (
       formData.append(`file_${index}`, item.file);
     }
   });
-  return api.post('execute', { body: formData, signal }).json<ExecuteResult>();
+  return api.post('execute', { body: formData, signal }).json<RequestExecuteResult>();
+};
+
+// Runs a saved request in long-polling mode, streaming progress until the result arrives
+export const executeRequestLongPoll = (
+  id: number,
+  data: { variables?: Record<string, string>; useDraft?: boolean; longPoll?: LongPollOptions },
+  callbacks: LongPollCallbacks<RequestExecuteResult>,
+  signal?: AbortSignal,
+) => streamLongPoll(`/api/requests/${id}/execute/stream`, data, callbacks, signal);
+
+export const executeAdhocLongPoll = (
+  data: { method: string; url: string; headers: string; body: string; variables?: Record<string, string>; proxyId?: number; preScript?: string; longPoll?: LongPollOptions },
+  callbacks: LongPollCallbacks<RequestExecuteResult>,
+  signal?: AbortSignal,
+) => streamLongPoll('/api/execute/stream', data, callbacks, signal);
+
+const streamLongPoll = async <T>(path: string, data: unknown, callbacks: LongPollCallbacks<T>, signal?: AbortSignal) => {
+  const workspaceId = localStorage.getItem('workspaceId') || '1';
+  const response = await fetch(path, {
+    method: 'POST',
+    headers: {
+      'Content-Type': 'application/json',
+      'X-Workspace-ID': workspaceId,
+    },
+    body: JSON.stringify(data),
+    signal,
+  });
+
+  if (!response.ok || !response.body) {
+    const error = await response.json().catch(() => null);
+    callbacks.onError(error?.error || 'Failed to start long-polling execution');
+    return;
+  }
+
+  const reader = response.body.getReader();
+  const decoder = new TextDecoder();
+  let buffer = '';
+  let currentEvent = '';
+
+  while (true) {
+    const { done, value } = await reader.read();
+    if (done) break;
+
+    buffer += decoder.decode(value, { stream: true });
+    const lines = buffer.split('\n');
+    buffer = lines.pop() || '';
+
+    for (const line of lines) {
+      if (line.startsWith('event: ')) {
+        currentEvent = line.slice(7);
+      } else if (line.startsWith('data: ') && currentEvent) {
+        const payload = line.slice(6);
+        try {
+          switch (currentEvent) {
+            case 'progress':
+              callbacks.onProgress?.(JSON.parse(payload) as LongPollProgress);
+              break;
+            case 'complete':
+              callbacks.onComplete(JSON.parse(payload) as T);
+              break;
+            case 'error':
+              callbacks.onError((JSON.parse(payload) as { error: string }).error);
+              break;
+          }
+        } catch {
+          // ignore parse errors
+        }
+        currentEvent = '';
+      } else if (line === '') {
+        currentEvent = '';
+      }
+    }
+  }
 };
@@ -3,7 +3,7 @@ import { queryKeys } from '../shared/queryKeys';
 import * as api from './client';
 
 export const useRequests = () =>
-  useQuery({ queryKey: queryKeys.requests, queryFn: api.getRequests });
+  useQuery({ queryKey: queryKeys.requests, queryFn: () => api.getRequests() });
 
 export const useRequest = (id: number) =>
   useQuery({ queryKey: queryKeys.request(id), queryFn: () => api.getRequest(id), enabled: !!id });
@@ -54,6 +54,19 @@ export const useDuplicateRequest = () => {
   });
 };
 
+export const useArchiveRequest = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, archived }: { id: number; archived: boolean }) =>
+      archived ? api.archiveRequest(id) : api.unarchiveRequest(id),
+    onSuccess: (_, { id }) => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.requests });
+      queryClient.invalidateQueries({ queryKey: queryKeys.request(id) });
+      queryClient.invalidateQueries({ queryKey: queryKeys.collections });
+    },
+  });
+};
+
 export const useExecuteRequest = () => {
   const queryClient = useQueryClient();
   return useMutation({
@@ -112,6 +125,19 @@ export const useReorderRequests = () => {
   });
 };
 
+export const useMergeRequests = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.mergeRequests,
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: queryKeys.requests });
+      queryClient.invalidateQueries({ queryKey: queryKeys.collections });
+      queryClient.invalidateQueries({ queryKey: queryKeys.flows });
+      queryClient.invalidateQueries({ queryKey: queryKeys.history });
+    },
+  });
+};
+
 export const useExecuteAdhocWithFiles = () => {
   const queryClient = useQueryClient();
   return useMutation({
@@ -129,3 +155,24 @@ export const useExecuteAdhocWithFiles = () => {
     onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.history }),
   });
 };
+
+export const useResponseAnnotations = (id: number) =>
+  useQuery({ queryKey: queryKeys.responseAnnotations(id), queryFn: () => api.getResponseAnnotations(id), enabled: !!id });
+
+export const usePutResponseAnnotation = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, input }: { id: number; input: Parameters<typeof api.putResponseAnnotation>[1] }) =>
+      api.putResponseAnnotation(id, input),
+    onSuccess: (_, { id }) => queryClient.invalidateQueries({ queryKey: queryKeys.responseAnnotations(id) }),
+  });
+};
+
+export const useDeleteResponseAnnotation = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, annotationId }: { id: number; annotationId: number }) =>
+      api.deleteResponseAnnotation(id, annotationId),
+    onSuccess: (_, { id }) => queryClient.invalidateQueries({ queryKey: queryKeys.responseAnnotations(id) }),
+  });
+};
@@ -5,10 +5,16 @@ export {
   useUpdateRequest,
   useDeleteRequest,
   useDuplicateRequest,
+  useArchiveRequest,
   useReorderRequests,
+  useMergeRequests,
   useExecuteRequest,
   useExecuteAdhoc,
   useExecuteRequestWithFiles,
   useExecuteAdhocWithFiles,
+  useResponseAnnotations,
+  usePutResponseAnnotation,
+  useDeleteResponseAnnotation,
 } from './hooks';
-export type { Request } from './types';
+export { executeRequestLongPoll, executeAdhocLongPoll } from './client';
+export type { LongPollCallbacks, LongPollOptions, LongPollProgress, MergeRequestsInput, MergeRequestsResult, Request, RequestDraft, RequestDraftDiff, RequestDraftFields, ResponseAnnotation, ResponseAnnotationInput } from './types';
@@ -12,6 +12,132 @@ export interface Request {
   sortOrder: number;
   preScript?: string;
   postScript?: string;
+  auth?: string; // JSON-encoded RequestAuth
+  // Timeout and retry policy; 0 keeps the defaults (60s, no retries)
+  timeoutMs?: number;
+  retryCount?: number;
+  retryBackoffMs?: number; // doubles after each attempt
+  retryOnStatus?: string; // comma-separated status codes, e.g. '502,503,504'
+  followRedirects?: boolean; // default true; false returns the 3xx response itself
+  maxRedirects?: number; // 0 = default (10)
+  version?: number;
   createdAt?: string;
   updatedAt?: string;
+  executionCount?: number;
+  lastExecutedAt?: string; // unset when never executed
+  archived?: boolean;
+  archivedAt?: string;
+  duplicates?: DuplicateRequest[]; // same method + URL, with duplicates=warn
+  annotations?: ResponseAnnotation[]; // collection exports only
+}
+
+// Documents one JSON path of the request's response ($.field, $.items[].id)
+export interface ResponseAnnotation {
+  id: number;
+  path: string;
+  description: string;
+  deprecated: boolean;
+  inExample?: boolean; // false when the latest JSON response lacks the path; unset without one
+  updatedAt?: string;
+}
+
+export interface ResponseAnnotationInput {
+  path: string;
+  description: string;
+  deprecated?: boolean;
+}
+
+// 'warn' saves and lists duplicates; 'reject' answers 409 instead of saving
+export type DuplicateMode = 'warn' | 'reject';
+
+export interface DuplicateRequest {
+  id: number;
+  collectionId?: number;
+  name: string;
+  method: string;
+  url: string;
+}
+
+// Which side each field is taken from; unlisted fields keep the survivor's value
+export type MergeSide = 'survivor' | 'loser';
+
+export interface MergeRequestsInput {
+  survivorId: number;
+  loserId: number;
+  fields?: Partial<Record<keyof RequestDraftFields | 'collectionId' | 'proxyId', MergeSide>>;
+}
+
+export interface MergeRequestsResult {
+  request: Request;
+  rewiredSteps: number;
+  rewiredNodes: number;
+  movedHistory: number;
+  movedMonitor: boolean;
+}
+
+export interface RequestDraftFields {
+  name: string;
+  method: string;
+  url: string;
+  headers: string;
+  body: string;
+  bodyType: string;
+  cookies: string;
+  proxyId: number | null;
+  preScript: string;
+  postScript: string;
+  responseTransform: string;
+  auth: string;
+}
+
+// '' or 'inherit' uses the collection's block (then the workspace's); 'none' sends no auth
+export interface RequestAuth {
+  type: '' | 'inherit' | 'none' | 'bearer' | 'basic' | 'apikey' | 'custom' | 'ntlm' | 'negotiate' | 'session';
+  session?: string; // workspace auth session name
+  token?: string; // bearer
+  key?: string; // apikey name
+  value?: string; // apikey or custom header value
+  in?: 'header' | 'query'; // apikey placement, header by default
+  header?: string; // custom header name
+  domain?: string;
+  username?: string;
+  password?: string;
+  workstation?: string;
+}
+
+export interface RequestDraft {
+  requestId: number;
+  draft: RequestDraftFields;
+  baseVersion: number;
+  version: number;
+  revision: number;
+  stale: boolean;
+  updatedAt?: string;
+}
+
+export interface RequestDraftDiff {
+  requestId: number;
+  stale: boolean;
+  changes: { field: keyof RequestDraftFields; saved: unknown; draft: unknown }[];
+}
+
+// Long-polling execution: extended timeout with keep-alive progress events
+export interface LongPollOptions {
+  timeoutSeconds?: number; // default 300, at most 1800
+  heartbeatSeconds?: number; // default 5, at most 60
+}
+
+export interface LongPollProgress {
+  elapsedMs: number;
+  phase: 'waiting' | 'receiving';
+  statusCode?: number;
+  bytesReceived: number;
+  chunk?: string; // text body received since the previous event
+  truncated?: boolean; // chunks skipped text; the final result has the whole body
+}
+
+export interface LongPollCallbacks<T> {
+  onProgress?: (progress: LongPollProgress) => void;
+  onComplete: (result: T) => void;
+  onError: (error: string) => void;
 }
@@ -1,12 +1,29 @@
 export const queryKeys = {
   workspaces: ['workspaces'] as const,
+  testSummary: (id: number, runs?: number) => ['workspaces', id, 'testSummary', runs] as const,
+  workspaceEncryption: (id: number) => ['workspaces', id, 'encryption'] as const,
   collections: ['collections'] as const,
   requests: ['requests'] as const,
   request: (id: number) => ['requests', id] as const,
+  responseAnnotations: (id: number) => ['requests', id, 'annotations'] as const,
   environments: ['environments'] as const,
+  environmentRotations: ['environmentRotations'] as const,
+  rotationRuns: (id: number) => ['environmentRotations', id, 'runs'] as const,
+  tokenRefreshers: ['tokenRefreshers'] as const,
   proxies: ['proxies'] as const,
+  cookies: ['cookies'] as const,
+  openAPISpecs: ['openAPISpecs'] as const,
+  openAPISpec: (id: number) => ['openAPISpecs', id] as const,
   flows: ['flows'] as const,
   flow: (id: number) => ['flows', id] as const,
   flowSteps: (flowId: number) => ['flows', flowId, 'steps'] as const,
+  flowGraph: (flowId: number) => ['flows', flowId, 'graph'] as const,
+  runTimeline: (runId: string) => ['flows', 'runs', runId, 'timeline'] as const,
+  flowRuns: (flowId: number) => ['flows', flowId, 'runs'] as const,
+  flowRun: (runId: string) => ['flow-runs', runId] as const,
   history: ['history'] as const,
+  historySearch: (q: string) => ['history', 'search', q] as const,
+  wsMessages: (id: number, after: number) => ['history', id, 'wsMessages', after] as const,
+  jobs: ['jobs'] as const,
+  oauth2Configs: ['oauth2Configs'] as const,
 };
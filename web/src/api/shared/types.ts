@@ -1,3 +1,5 @@
+import type { VariableSource } from '../environments/types';
+
 export interface ExecuteResult {
   statusCode: number;
   headers: Record<string, string>;
@@ -6,10 +8,111 @@ export interface ExecuteResult {
   bodyBase64?: string;
   bodySize: number;
   isBinary?: boolean;
+  charset?: string;
+  preview?: BinaryPreview;
   durationMs: number;
   error?: string;
   resolvedUrl: string;
   resolvedHeaders: Record<string, string>;
+  traceId?: string;
+  variableTrace?: VariableSource[]; // with traceVariables: where each variable came from
+  timing?: RequestTiming;
+  intercepted?: InterceptedRequest; // captured by the proxy chain's interception stage
+  rewrites?: string[]; // names of the workspace rewrite rules applied
+  historyId?: number;
+  parts?: ResponsePart[]; // multipart/* responses split into their parts
+  partsError?: string;
+  authSession?: string; // workspace auth session whose token was injected
+  grpcStatus?: string; // GRPC requests: status code name (OK, NotFound, ...)
+  attempts?: number; // requests with a retry policy: attempts made, including the first
+  attemptGroupId?: string; // shared by the history entries of those attempts
+  attemptHistory?: AttemptSummary[]; // every attempt, in order
+  fault?: 'latency' | 'drop' | 'error'; // chaos fault injected into the request
+  specValidation?: SpecValidation; // with validateSpec: checked against the workspace's OpenAPI specs
+  redirects?: RedirectHop[]; // redirect responses followed, in order
+  network?: NetworkInfo; // proxy, TLS mode and protocol actually used
+}
+
+// A redirect response received on the way to the final response
+export interface RedirectHop {
+  url: string;
+  statusCode: number;
+  headers: Record<string, string>;
+  location: string; // resolved URL it redirected to
+}
+
+export interface AttemptSummary {
+  attempt: number;
+  historyId?: number; // absent when the attempt failed before sending
+  statusCode?: number;
+  durationMs: number;
+  error?: string;
+}
+
+export interface NetworkInfo {
+  proxyUrl?: string; // credentials redacted; absent for a direct connection
+  tlsVerify: boolean;
+  clientCert?: string; // host pattern of the client certificate presented
+  protocol?: string; // e.g. HTTP/1.1, HTTP/2.0
+  tlsVersion?: string; // absent for plain HTTP
+}
+
+// Result of checking a response against the workspace's OpenAPI specs
+export interface SpecValidation {
+  specId?: number;
+  specName?: string;
+  operation?: string; // e.g. "GET /pets/{id}"
+  passed: boolean;
+  skipped?: string; // why nothing was checked
+  failures?: { path?: string; message: string }[];
+}
+
+// Fault injection options of an execute or flow run
+export interface ChaosOptions {
+  latencyMs?: number;
+  dropPercent?: number;
+  errorPercent?: number;
+  errorStatus?: number; // default 500
+  seed?: number;
+}
+
+export interface ResponsePart {
+  headers: Record<string, string>;
+  contentType?: string;
+  name?: string;
+  filename?: string;
+  body?: string; // text parts
+  bodyBase64?: string; // binary parts
+  size: number;
+}
+
+export interface RouteHop {
+  kind: 'intercept' | 'proxy' | 'target';
+  address?: string;
+}
+
+export interface RequestTiming {
+  interceptMs?: number;
+  dnsMs: number;
+  connectMs: number;
+  tlsMs: number;
+  firstByteMs: number;
+  route: RouteHop[];
+}
+
+export interface InterceptedRequest {
+  method: string;
+  url: string;
+  headers: Record<string, string>;
+}
+
+export interface BinaryPreview {
+  contentType: string;
+  sha256: string;
+  format?: string;
+  width?: number;
+  height?: number;
+  pageCount?: number;
 }
 
 export interface ErrorDetail {
@@ -24,10 +127,47 @@ export interface ScriptResult {
   errorDetails?: ErrorDetail[];
   assertionsPassed: number;
   assertionsFailed: number;
+  tests?: TestResult[]; // pm.test calls and DSL assertions, in order
   updatedVars?: Record<string, string>;
+  sendRequestCacheHits?: number; // pm.sendRequest calls answered from the run's cache
+  visualization?: ScriptVisualization; // set by pm.visualizer.set (JavaScript scripts)
+}
+
+export interface TestResult {
+  name: string;
+  passed: boolean;
+  error?: string;
+}
+
+// Handlebars template and data captured by pm.visualizer.set
+export interface ScriptVisualization {
+  template: string;
+  data?: unknown;
+  options?: Record<string, unknown>; // Handlebars compile options
 }
 
 export interface RequestExecuteResult extends ExecuteResult {
   preScriptResult?: ScriptResult;
   postScriptResult?: ScriptResult;
 }
+
+// List ordering by usage; ascending puts unused entries first
+export interface UsageSort {
+  sort: 'executions' | 'lastExecuted';
+  order?: 'asc' | 'desc';
+}
+
+// Archived requests and flows are only listed when includeArchived is set
+export interface ArchiveFilter {
+  includeArchived?: boolean;
+}
+
+// Server-side filtering, sorting and paging of a list; the number of
+// matching items is in the X-Total-Count response header
+export interface ListQuery<S extends string = 'name' | 'createdAt' | 'updatedAt'> {
+  q?: string;
+  sort?: S;
+  order?: 'asc' | 'desc';
+  limit?: number;
+  offset?: number;
+}
@@ -1,5 +1,5 @@
 import api from '../client';
-import type { Workspace } from './types';
+import type { EncryptionKeySpec, EncryptionStatus, TestSummary, Workspace, WorkspaceUsage } from './types';
 
 export const getWorkspaces = () => api.get('workspaces').json<Workspace[]>();
 
@@ -10,3 +10,20 @@ export const updateWorkspace = (id: number, data: { name: string }) =>
   api.put(`workspaces/${id}`, { json: data }).json<Workspace>();
 
 export const deleteWorkspace = (id: number) => api.delete(`workspaces/${id}`);
+
+export const getWorkspaceUsage = (id: number) => api.get(`workspaces/${id}/usage`).json<WorkspaceUsage>();
+
+export const getTestSummary = (id: number, runs?: number) =>
+  api.get(`workspaces/${id}/test-summary`, { searchParams: runs ? { runs } : {} }).json<TestSummary>();
+
+export const getEncryption = (id: number) => api.get(`workspaces/${id}/encryption`).json<EncryptionStatus>();
+
+export const rotateEncryptionKey = (id: number, data: EncryptionKeySpec) =>
+  api.put(`workspaces/${id}/encryption`, { json: data }).json<EncryptionStatus>();
+
+export const removeEncryptionKey = (id: number) => api.delete(`workspaces/${id}/encryption`).json<EncryptionStatus>();
+
+export const unlockEncryption = (id: number, passphrase: string) =>
+  api.post(`workspaces/${id}/encryption/unlock`, { json: { passphrase } }).json<EncryptionStatus>();
+
+export const lockEncryption = (id: number) => api.post(`workspaces/${id}/encryption/lock`).json<EncryptionStatus>();
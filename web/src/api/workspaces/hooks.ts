@@ -1,6 +1,7 @@
 import { useQuery, useMutation, useQueryClient } from '@tanstack/react-query';
 import { queryKeys } from '../shared/queryKeys';
 import * as api from './client';
+import type { EncryptionKeySpec } from './types';
 
 export const useWorkspaces = () =>
   useQuery({ queryKey: queryKeys.workspaces, queryFn: api.getWorkspaces });
@@ -29,3 +30,41 @@ export const useDeleteWorkspace = () => {
     onSuccess: () => queryClient.invalidateQueries({ queryKey: queryKeys.workspaces }),
   });
 };
+
+export const useTestSummary = (id: number, runs?: number) =>
+  useQuery({ queryKey: queryKeys.testSummary(id, runs), queryFn: () => api.getTestSummary(id, runs), enabled: !!id });
+
+export const useEncryption = (id: number) =>
+  useQuery({ queryKey: queryKeys.workspaceEncryption(id), queryFn: () => api.getEncryption(id), enabled: !!id });
+
+export const useRotateEncryptionKey = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, data }: { id: number; data: EncryptionKeySpec }) => api.rotateEncryptionKey(id, data),
+    onSuccess: (_, { id }) => queryClient.invalidateQueries({ queryKey: queryKeys.workspaceEncryption(id) }),
+  });
+};
+
+export const useRemoveEncryptionKey = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.removeEncryptionKey,
+    onSuccess: (_, id) => queryClient.invalidateQueries({ queryKey: queryKeys.workspaceEncryption(id) }),
+  });
+};
+
+export const useUnlockEncryption = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: ({ id, passphrase }: { id: number; passphrase: string }) => api.unlockEncryption(id, passphrase),
+    onSuccess: (_, { id }) => queryClient.invalidateQueries({ queryKey: queryKeys.workspaceEncryption(id) }),
+  });
+};
+
+export const useLockEncryption = () => {
+  const queryClient = useQueryClient();
+  return useMutation({
+    mutationFn: api.lockEncryption,
+    onSuccess: (_, id) => queryClient.invalidateQueries({ queryKey: queryKeys.workspaceEncryption(id) }),
+  });
+};
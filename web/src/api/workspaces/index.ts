@@ -3,5 +3,21 @@ export {
   useCreateWorkspace,
   useUpdateWorkspace,
   useDeleteWorkspace,
+  useTestSummary,
+  useEncryption,
+  useRotateEncryptionKey,
+  useRemoveEncryptionKey,
+  useUnlockEncryption,
+  useLockEncryption,
 } from './hooks';
-export type { Workspace } from './types';
+export type {
+  EncryptionKeySource,
+  EncryptionKeySpec,
+  EncryptionStatus,
+  QuotaUsage,
+  TestStatus,
+  TestSummary,
+  TestSummaryItem,
+  Workspace,
+  WorkspaceUsage,
+} from './types';
@@ -4,3 +4,68 @@ export interface Workspace {
   createdAt: string;
   updatedAt: string;
 }
+
+export interface QuotaUsage {
+  used: number;
+  limit: number; // 0 = unlimited
+  exceeded: boolean;
+}
+
+// Usage against the quotas in the workspace settings
+export interface WorkspaceUsage {
+  workspaceId: number;
+  requests: QuotaUsage;
+  historyRows: QuotaUsage;
+  storageBytes: QuotaUsage;
+  scheduledRunsPerDay: QuotaUsage;
+}
+
+export type TestStatus = 'passing' | 'failing' | 'noRuns';
+
+// A flow, monitor or environment rotation with its recent runs
+export interface TestSummaryItem {
+  id: number;
+  name: string;
+  disabled?: boolean; // paused monitors and rotations
+  status: TestStatus; // from the latest run
+  lastRunAt?: string;
+  passed: number;
+  failed: number;
+  trend: boolean[]; // pass/fail of the last runs, oldest first
+}
+
+export interface TestSummary {
+  workspaceId: number;
+  runs: number; // runs per item counted in passed/failed/trend
+  totals: {
+    items: number;
+    passing: number;
+    failing: number;
+    noRuns: number;
+    passedRuns: number;
+    failedRuns: number;
+  };
+  flows: TestSummaryItem[];
+  monitors: TestSummaryItem[];
+  rotations: TestSummaryItem[];
+}
+
+export type EncryptionKeySource = 'passphrase' | 'kms';
+
+// Key that seals the workspace's secret variables and TLS certificates
+export interface EncryptionKeySpec {
+  source: EncryptionKeySource;
+  passphrase?: string;
+  kmsKeyEnv?: string; // environment variable holding a base64 32-byte key
+}
+
+export interface EncryptionStatus {
+  enabled: boolean;
+  source?: EncryptionKeySource;
+  kmsKeyEnv?: string;
+  locked: boolean; // executions needing a secret fail until unlocked
+  version?: number;
+  createdAt?: string;
+  rotatedAt?: string;
+  resealed?: { secrets: number; files: number }; // set by rotate and remove
+}
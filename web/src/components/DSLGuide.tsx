@@ -175,6 +175,41 @@ HTTP 응답을 검증합니다. **Post-Script에서만 사용 가능합니다.**
 }
 \`\`\`
 
+### 1.6 바이너리 본문 검증
+
+파일 다운로드처럼 문자열 검사가 맞지 않는 응답은 원본 바이트로 검증합니다 (바이너리 응답은 base64 디코딩 후, 문자셋이 변환된 응답은 변환 전 바이트 기준).
+
+\`\`\`json
+{
+  "assertions": [
+    { "type": "bodySha256", "value": "2d0f60e62f80ddea32dc2d6182644881af95907eff64d4404913c9b55de700da" },
+    { "type": "bodyMd5", "value": "7d381ceb22d0f867f80acff78e4f511d" },
+    { "type": "bodySize", "operator": "eq", "value": 11 },
+    { "type": "bodyPrefix", "value": "25 50 44 46" }
+  ]
+}
+\`\`\`
+
+| 타입 | value | 설명 |
+|------|-------|------|
+| \`bodySha256\` / \`bodyMd5\` | hex 다이제스트 (대소문자 무관) | \`in\` 연산자로 여러 값 허용 |
+| \`bodySize\` | 바이트 수 | 모든 숫자 연산자 사용 가능 |
+| \`bodyPrefix\` | hex 바이트 (공백 허용) | 매직 바이트 확인 (PNG \`89504E47\`, PDF \`25504446\`, ZIP \`504B0304\`) |
+
+### 1.7 Wasm 확장 검증
+
+워크스페이스에 등록한 wasm 확장(\`PUT /api/extensions/:name\`)의 \`assert\`로 검증합니다. \`value\`는 확장에 \`args\`로 그대로 전달됩니다.
+
+\`\`\`json
+{
+  "assertions": [
+    { "type": "wasm", "module": "schema-check", "value": { "schema": "order" } }
+  ]
+}
+\`\`\`
+
+확장은 \`{status, headers, body, durationMs, args}\`를 받아 \`{"pass": bool, "message": "..."}\`를 반환합니다. 실패 시 \`message\`가 오류 메시지로 표시됩니다.
+
 ### 연산자 목록
 
 | 연산자 | 설명 | 예시 |
@@ -275,6 +310,26 @@ HTTP 응답을 검증합니다. **Post-Script에서만 사용 가능합니다.**
 }
 \`\`\`
 
+### 2.6 Wasm 변환 (wasm)
+
+wasm 확장의 \`transform\`으로 응답 본문(\`from\` 지정 시 해당 JSONPath 값)을 변환해 변수에 저장합니다.
+
+\`\`\`json
+{
+  "setVariables": [
+    {
+      "name": "payload",
+      "operation": "wasm",
+      "module": "decrypt",
+      "from": "$.data",
+      "value": { "keyId": "k1" }
+    }
+  ]
+}
+\`\`\`
+
+확장은 \`{status, headers, body, args}\`를 받아 \`{"body": "..."}\` 또는 \`{"error": "..."}\`를 반환합니다.
+
 ---
 
 ## 3. Flow Control (흐름 제어)
@@ -28,6 +28,7 @@ export function EnvironmentEditor({ isOpen, onClose }: EnvironmentEditorProps) {
 
   const [selectedEnvId, setSelectedEnvId] = useState<number | null>(null);
   const [name, setName] = useState('');
+  const [parentId, setParentId] = useState<number | null>(null);
   const [variables, setVariables] = useState<VariableItem[]>([]);
   const [showNewEnvInput, setShowNewEnvInput] = useState(false);
   const [newEnvName, setNewEnvName] = useState('');
@@ -51,6 +52,7 @@ export function EnvironmentEditor({ isOpen, onClose }: EnvironmentEditorProps) {
   if (selectedEnv && selectedEnv.id !== syncedEnvId) {
     setSyncedEnvId(selectedEnv.id);
     setName(selectedEnv.name);
+    setParentId(selectedEnv.parentId ?? null);
     try {
       const parsed = JSON.parse(selectedEnv.variables || '{}');
       const items = Object.entries(parsed).map(([key, value]) => ({
@@ -102,6 +104,7 @@ export function EnvironmentEditor({ isOpen, onClose }: EnvironmentEditorProps) {
       data: {
         name,
         variables: JSON.stringify(varsObj),
+        parentId,
       },
     }, {
       onSuccess: (env) => {
@@ -189,6 +192,17 @@ export function EnvironmentEditor({ isOpen, onClose }: EnvironmentEditorProps) {
                     className="flex-1 px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-md text-xs focus:outline-none focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100"
                     placeholder="Environment name"
                   />
+                  <select
+                    value={parentId ?? ''}
+                    onChange={e => setParentId(e.target.value ? Number(e.target.value) : null)}
+                    className="px-2 py-2 border border-gray-300 dark:border-gray-600 rounded-md text-xs focus:outline-none focus:ring-2 focus:ring-blue-500 dark:bg-gray-700 dark:text-gray-100"
+                    title="Variables not set here are inherited from the parent"
+                  >
+                    <option value="">No parent</option>
+                    {environments.filter(e => e.id !== selectedEnv.id).map(e => (
+                      <option key={e.id} value={e.id}>Inherits {e.name}</option>
+                    ))}
+                  </select>
                   {!selectedEnv.isActive && (
                     <button
                       onClick={handleActivate}
@@ -67,7 +67,8 @@ function downloadResponse(response: ExecuteResult) {
   let blob: Blob;
   const ct = response.headers?.['Content-Type'] || response.headers?.['content-type'] || 'application/octet-stream';
 
-  if (response.isBinary && response.bodyBase64) {
+  // bodyBase64 holds the raw bytes for binary and transcoded (non-UTF-8) responses
+  if (response.bodyBase64) {
     const binary = atob(response.bodyBase64);
     const bytes = new Uint8Array(binary.length);
     for (let i = 0; i < binary.length; i++) {
@@ -127,6 +128,16 @@ function isImageContentType(ct: string): boolean {
   return ct.startsWith('image/') && !ct.includes('svg');
 }
 
+function previewDetails(response: ExecuteResult): string[] {
+  const p = response.preview;
+  if (!p) return [];
+  const details: string[] = [];
+  if (p.width && p.height) details.push(`${p.width} × ${p.height}`);
+  if (p.pageCount) details.push(`${p.pageCount} page${p.pageCount === 1 ? '' : 's'}`);
+  details.push(`SHA-256 ${p.sha256.slice(0, 12)}`);
+  return details;
+}
+
 export function ResponseViewer({ response, isLoading, onCancel, onImportCookies }: ResponseViewerProps) {
   const [activeTab, setActiveTab] = useState<Tab>('body');
 
@@ -192,7 +203,9 @@ export function ResponseViewer({ response, isLoading, onCancel, onImportCookies
   const isXml = contentType.includes('xml') && !contentType.includes('html');
   const isHtml = contentType.includes('html');
   const isCss = contentType.includes('css');
-  const isImage = isImageContentType(contentType);
+  // Sniffed type wins for binary bodies served as application/octet-stream
+  const previewType = response.preview?.contentType || contentType;
+  const isImage = isImageContentType(previewType);
   const bodySize = response.bodySize || (response.body ? response.body.length : 0);
 
   return (
@@ -220,6 +233,9 @@ export function ResponseViewer({ response, isLoading, onCancel, onImportCookies
         {isCss && (
           <span className="text-xs px-2 py-0.5 bg-gray-200 dark:bg-gray-600 text-gray-700 dark:text-gray-200 rounded">CSS</span>
         )}
+        {response.charset && response.charset !== 'utf-8' && (
+          <span className="text-xs px-2 py-0.5 bg-gray-200 dark:bg-gray-600 text-gray-700 dark:text-gray-200 rounded uppercase" title="Converted to UTF-8 for display">{response.charset}</span>
+        )}
         <span className="text-xs text-gray-400 dark:text-gray-500 truncate flex-1">
           {response.resolvedUrl}
         </span>
@@ -254,7 +270,7 @@ export function ResponseViewer({ response, isLoading, onCancel, onImportCookies
             return (
               <div className="flex flex-col items-center gap-4">
                 <img
-                  src={`data:${contentType};base64,${response.bodyBase64}`}
+                  src={`data:${previewType};base64,${response.bodyBase64}`}
                   alt="Response preview"
                   className="max-w-full max-h-[60vh] object-contain rounded border border-gray-200 dark:border-gray-700"
                 />
@@ -264,6 +280,7 @@ export function ResponseViewer({ response, isLoading, onCancel, onImportCookies
                 >
                   Download Image ({formatSize(bodySize)})
                 </button>
+                <p className="text-xs text-gray-500 dark:text-gray-400">{previewDetails(response).join(' · ')}</p>
               </div>
             );
           }
@@ -276,7 +293,9 @@ export function ResponseViewer({ response, isLoading, onCancel, onImportCookies
                   <path strokeLinecap="round" strokeLinejoin="round" strokeWidth={1} d="M7 21h10a2 2 0 002-2V9.414a1 1 0 00-.293-.707l-5.414-5.414A1 1 0 0012.586 3H7a2 2 0 00-2 2v14a2 2 0 002 2z" />
                 </svg>
                 <p className="text-base font-medium mb-1">Binary Response</p>
-                <p className="text-xs mb-4">{formatSize(bodySize)} &middot; {contentType || 'unknown type'}</p>
+                <p className="text-xs mb-4">
+                  {[formatSize(bodySize), previewType || 'unknown type', ...previewDetails(response)].join(' · ')}
+                </p>
                 <button
                   onClick={handleDownload}
                   className="px-4 py-2 text-xs bg-blue-50 dark:bg-blue-900/30 text-blue-600 dark:text-blue-400 border border-blue-200 dark:border-blue-700 rounded-md hover:bg-blue-100 dark:hover:bg-blue-900/50 transition-colors"
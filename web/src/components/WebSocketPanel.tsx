@@ -10,7 +10,8 @@ interface WebSocketPanelProps {
 
 export function WebSocketPanel({ messages, isConnected, onSend, onClear }: WebSocketPanelProps) {
   const [messageInput, setMessageInput] = useState('');
-  const [messageFormat, setMessageFormat] = useState<'text' | 'json'>('text');
+  const [messageFormat, setMessageFormat] = useState<'text' | 'json' | 'hex'>('text');
+  const [inputError, setInputError] = useState<string | null>(null);
   const messagesEndRef = useRef<HTMLDivElement>(null);
 
   useEffect(() => {
@@ -18,10 +19,19 @@ export function WebSocketPanel({ messages, isConnected, onSend, onClear }: WebSo
   }, [messages]);
 
   const handleSend = () => {
-    if (messageInput.trim()) {
+    if (!messageInput.trim()) return;
+    if (messageFormat === 'hex') {
+      const base64 = hexToBase64(messageInput);
+      if (base64 === null) {
+        setInputError('Hex input must be pairs of hex digits, e.g. "08 96 01"');
+        return;
+      }
+      onSend(base64, 'binary');
+    } else {
       onSend(messageInput, 'text');
-      setMessageInput('');
     }
+    setInputError(null);
+    setMessageInput('');
   };
 
   const handleKeyDown = (e: React.KeyboardEvent) => {
@@ -55,6 +65,7 @@ export function WebSocketPanel({ messages, isConnected, onSend, onClear }: WebSo
               rows={3}
               className="w-full px-3 py-2 border border-gray-300 dark:border-gray-600 rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500 font-mono text-xs dark:bg-gray-700 dark:text-gray-100 resize-none disabled:opacity-50 disabled:cursor-not-allowed"
             />
+            {inputError && <div className="mt-1 text-xs text-red-500">{inputError}</div>}
           </div>
           <div className="flex flex-col gap-1">
             <div className="flex gap-1">
@@ -70,6 +81,13 @@ export function WebSocketPanel({ messages, isConnected, onSend, onClear }: WebSo
               >
                 JSON
               </button>
+              <button
+                onClick={() => setMessageFormat('hex')}
+                title="Send a binary frame from hex bytes"
+                className={`px-2 py-1 text-xs rounded ${messageFormat === 'hex' ? 'bg-blue-100 text-blue-700 dark:bg-blue-900/40 dark:text-blue-400' : 'text-gray-500 dark:text-gray-400 hover:bg-gray-100 dark:hover:bg-gray-700'}`}
+              >
+                Hex
+              </button>
             </div>
             <button
               onClick={handleSend}
@@ -124,8 +142,18 @@ export function WebSocketPanel({ messages, isConnected, onSend, onClear }: WebSo
                   }`}>
                     {msg.type}
                   </span>
+                  {msg.format === 'binary' && (
+                    <span className="text-xs font-sans text-gray-400">binary, {msg.size ?? 0} bytes</span>
+                  )}
                 </div>
-                <pre className="whitespace-pre-wrap break-all text-xs text-gray-800 dark:text-gray-200">{msg.payload}</pre>
+                {msg.format === 'binary' && msg.hex ? (
+                  <pre className="text-xs text-gray-800 dark:text-gray-200 overflow-x-auto">
+                    {msg.hex.map(row => `${row.offset.toString(16).padStart(8, '0')}  ${row.hex.padEnd(47)}  ${row.ascii}`).join('\n')}
+                    {msg.hexTruncated && '\n…'}
+                  </pre>
+                ) : (
+                  <pre className="whitespace-pre-wrap break-all text-xs text-gray-800 dark:text-gray-200">{msg.payload}</pre>
+                )}
               </div>
             ))
           )}
@@ -136,6 +164,18 @@ export function WebSocketPanel({ messages, isConnected, onSend, onClear }: WebSo
   );
 }
 
+// hexToBase64 parses bytes written as hex pairs (spaces and 0x prefixes
+// allowed) into base64, or returns null when the input is not hex
+function hexToBase64(input: string): string | null {
+  const digits = input.replace(/0x/gi, '').replace(/[\s,]/g, '');
+  if (digits.length % 2 !== 0 || !/^[0-9a-f]*$/i.test(digits)) return null;
+  let binary = '';
+  for (let i = 0; i < digits.length; i += 2) {
+    binary += String.fromCharCode(parseInt(digits.slice(i, i + 2), 16));
+  }
+  return btoa(binary);
+}
+
 function formatTimestamp(ts: string): string {
   try {
     const date = new Date(ts);
@@ -375,6 +375,15 @@ export function RequestEditor({ request, onExecute, onUpdate, onExecutingChange,
                       }
                     }
                   });
+                  // Enabled cookies go in the Cookie header, as for HTTP requests
+                  const cookiePairs = form.cookieItems
+                    .filter(c => c.key.trim() && c.enabled)
+                    .map(c => `${c.key}=${c.value}`)
+                    .join('; ');
+                  if (cookiePairs) {
+                    const existing = headersObj['Cookie'] || '';
+                    headersObj['Cookie'] = existing ? `${existing}; ${cookiePairs}` : cookiePairs;
+                  }
                   ws.connect(form.url, JSON.stringify(headersObj), form.proxyId, request?.id, subprotocols);
                 }
               }}
@@ -3,7 +3,7 @@ import type { FormDataItem } from '../ui';
 // ─── Constants ───
 
 export const METHODS = ['GET', 'POST', 'PUT', 'DELETE', 'PATCH', 'HEAD', 'OPTIONS'];
-export const METHODS_WITH_WS = ['GET', 'POST', 'PUT', 'DELETE', 'PATCH', 'HEAD', 'OPTIONS', 'WS'];
+export const METHODS_WITH_WS = ['GET', 'POST', 'PUT', 'DELETE', 'PATCH', 'HEAD', 'OPTIONS', 'WS', 'GRPC'];
 export const BODY_TYPES = ['none', 'json', 'text', 'xml', 'form-urlencoded', 'formdata', 'graphql'];
 
 export const COMMON_HEADERS = [
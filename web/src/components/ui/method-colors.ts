@@ -7,6 +7,7 @@ export const METHOD_TEXT_COLORS: Record<string, string> = {
   HEAD: 'text-gray-600 dark:text-gray-400',
   OPTIONS: 'text-gray-600 dark:text-gray-400',
   WS: 'text-fuchsia-600 dark:text-fuchsia-400',
+  GRPC: 'text-teal-600 dark:text-teal-400',
 };
 
 export const METHOD_BG_COLORS: Record<string, string> = {
@@ -18,4 +19,5 @@ export const METHOD_BG_COLORS: Record<string, string> = {
   HEAD: 'bg-gray-500',
   OPTIONS: 'bg-gray-500',
   WS: 'bg-fuchsia-600',
+  GRPC: 'bg-teal-600',
 };
@@ -1,5 +1,5 @@
 import { useState, useRef, useEffect } from 'react';
-import type { WSMessage, WSConnectionStatus } from '../types';
+import type { WSMessage, WSConnectionStatus, WSHexRow } from '../types';
 
 interface RelayEnvelope {
   type: 'connected' | 'received' | 'error' | 'closed';
@@ -7,6 +7,9 @@ interface RelayEnvelope {
   subprotocol?: string;
   payload?: string;
   format?: string;
+  size?: number;
+  hex?: WSHexRow[];
+  hexTruncated?: boolean;
   message?: string;
   code?: number;
   reason?: string;
@@ -20,7 +23,7 @@ export function useWebSocket() {
   const [messages, setMessages] = useState<WSMessage[]>([]);
   const wsRef = useRef<WebSocket | null>(null);
 
-  const connect = (url: string, headers: string, proxyId?: number | null, wsConnectionId?: number, subprotocols?: string[]) => {
+  const connect = (url: string, headers: string, proxyId?: number | null, wsConnectionId?: number, subprotocols?: string[], variables?: Record<string, string>) => {
     if (wsRef.current) {
       wsRef.current.close();
     }
@@ -48,6 +51,9 @@ export function useWebSocket() {
       if (subprotocols?.length) {
         connectMsg.subprotocols = subprotocols;
       }
+      if (variables && Object.keys(variables).length > 0) {
+        connectMsg.variables = variables;
+      }
       ws.send(JSON.stringify(connectMsg));
     };
 
@@ -75,6 +81,9 @@ export function useWebSocket() {
             payload: envelope.payload || '',
             format: (envelope.format as 'text' | 'binary') || 'text',
             timestamp: ts,
+            size: envelope.size,
+            hex: envelope.hex,
+            hexTruncated: envelope.hexTruncated,
           }]);
           break;
         case 'error':
@@ -117,15 +126,18 @@ export function useWebSocket() {
     };
   };
 
+  // Binary payloads are base64; the relay decodes them into a binary frame
   const send = (payload: string, format: 'text' | 'binary' = 'text') => {
     if (wsRef.current?.readyState === WebSocket.OPEN) {
       wsRef.current.send(JSON.stringify({ type: 'send', payload, format }));
+      const binary = format === 'binary' ? toHexRows(payload) : undefined;
       setMessages(prev => [...prev, {
         id: String(++messageIdCounter),
         type: 'sent',
         payload,
         format,
         timestamp: new Date().toISOString(),
+        ...binary,
       }]);
     }
   };
@@ -153,3 +165,21 @@ export function useWebSocket() {
 
   return { status, messages, connect, send, disconnect, clearMessages };
 }
+
+const HEX_VIEW_BYTES = 4096;
+
+// toHexRows builds the same hex viewer rows the relay sends for received frames
+function toHexRows(base64: string): Pick<WSMessage, 'size' | 'hex' | 'hexTruncated'> {
+  const bytes = Uint8Array.from(atob(base64), c => c.charCodeAt(0));
+  const shown = bytes.subarray(0, HEX_VIEW_BYTES);
+  const hex: WSHexRow[] = [];
+  for (let offset = 0; offset < shown.length; offset += 16) {
+    const line = Array.from(shown.subarray(offset, offset + 16));
+    hex.push({
+      offset,
+      hex: line.map(b => b.toString(16).padStart(2, '0')).join(' '),
+      ascii: line.map(b => (b >= 0x20 && b < 0x7f ? String.fromCharCode(b) : '.')).join(''),
+    });
+  }
+  return { size: bytes.length, hex, hexTruncated: bytes.length > HEX_VIEW_BYTES };
+}
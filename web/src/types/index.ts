@@ -9,12 +9,21 @@ export type { ExecuteResult, ScriptResult, RequestExecuteResult } from '../api/s
 export type { History } from '../api/history';
 
 // WebSocket types (not part of any API domain)
+export interface WSHexRow {
+  offset: number;
+  hex: string;
+  ascii: string;
+}
+
 export interface WSMessage {
   id: string;
   type: 'sent' | 'received' | 'system';
-  payload: string;
+  payload: string; // base64 when format is 'binary'
   format: 'text' | 'binary';
   timestamp: string;
+  size?: number; // binary frame size in bytes
+  hex?: WSHexRow[]; // first 4KB of a binary frame
+  hexTruncated?: boolean;
 }
 
 export type WSConnectionStatus = 'disconnected' | 'connecting' | 'connected';